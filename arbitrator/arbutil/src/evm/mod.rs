@@ -76,6 +76,7 @@ pub const GASPRICE_GAS: Gas = GAS_QUICK_STEP;
 pub const ORIGIN_GAS: Gas = GAS_QUICK_STEP;
 
 pub const ARBOS_VERSION_STYLUS_CHARGING_FIXES: u64 = 32;
+pub const ARBOS_VERSION_STYLUS_CALL_BATCHING: u64 = 40;
 
 #[derive(Clone, Copy, Debug, Default)]
 #[repr(C)]
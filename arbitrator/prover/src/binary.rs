@@ -10,7 +10,9 @@ use crate::{
     value::{ArbValueType, FunctionType, IntegerValType, Value},
 };
 use arbutil::{
-    evm::ARBOS_VERSION_STYLUS_CHARGING_FIXES, math::SaturatingSum, Bytes32, Color, DebugColor,
+    evm::{ARBOS_VERSION_STYLUS_CALL_BATCHING, ARBOS_VERSION_STYLUS_CHARGING_FIXES},
+    math::SaturatingSum,
+    Bytes32, Color, DebugColor,
 };
 use eyre::{bail, ensure, eyre, Result, WrapErr};
 use fnv::{FnvHashMap as HashMap, FnvHashSet as HashSet};
@@ -685,6 +687,15 @@ impl<'a> WasmBinary<'a> {
             limit!(513, bin.imports.len(), "imports")
         }
 
+        // hostios added after activation began can't be imported by programs activated earlier
+        if arbos_version_for_gas < ARBOS_VERSION_STYLUS_CALL_BATCHING {
+            for import in &bin.imports {
+                if import.module == "vm_hooks" && import.name == "call_contracts" {
+                    bail!("hostio {} requires a newer ArbOS", import.name.red());
+                }
+            }
+        }
+
         let table_entries = bin.tables.iter().map(|x| x.initial).saturating_sum();
         limit!(4096, table_entries, "table entries");
 
@@ -200,6 +200,20 @@ pub(crate) fn static_call_contract<D: DataReader, E: EvmApi<D>>(
     )
 }
 
+pub(crate) fn call_contracts<D: DataReader, E: EvmApi<D>>(
+    mut env: WasmEnvMut<D, E>,
+    calls: GuestPtr,
+    calls_len: u32,
+    results: GuestPtr,
+    results_cap: u32,
+    results_len: GuestPtr,
+) -> Result<u32, Escape> {
+    hostio!(
+        env,
+        call_contracts(calls, calls_len, results, results_cap, results_len)
+    )
+}
+
 pub(crate) fn create1<D: DataReader, E: EvmApi<D>>(
     mut env: WasmEnvMut<D, E>,
     code: GuestPtr,
@@ -168,6 +168,7 @@ impl<D: DataReader, E: EvmApi<D>> NativeInstance<D, E> {
                 "call_contract" => func!(host::call_contract),
                 "delegate_call_contract" => func!(host::delegate_call_contract),
                 "static_call_contract" => func!(host::static_call_contract),
+                "call_contracts" => func!(host::call_contracts),
                 "create1" => func!(host::create1),
                 "create2" => func!(host::create2),
                 "read_return_data" => func!(host::read_return_data),
@@ -385,6 +386,7 @@ pub fn module(wasm: &[u8], compile: CompileConfig, target: Target) -> Result<Vec
             "call_contract" => stub!(u8 <- |_: u32, _: u32, _: u32, _: u32, _: u64, _: u32|),
             "delegate_call_contract" => stub!(u8 <- |_: u32, _: u32, _: u32, _: u64, _: u32|),
             "static_call_contract" => stub!(u8 <- |_: u32, _: u32, _: u32, _: u64, _: u32|),
+            "call_contracts" => stub!(u32 <- |_: u32, _: u32, _: u32, _: u32, _: u32|),
             "create1" => stub!(|_: u32, _: u32, _: u32, _: u32, _: u32|),
             "create2" => stub!(|_: u32, _: u32, _: u32, _: u32, _: u32, _: u32|),
             "read_return_data" => stub!(u32 <- |_: u32, _: u32, _: u32|),
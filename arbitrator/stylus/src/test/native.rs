@@ -470,6 +470,60 @@ fn test_calls() -> Result<()> {
     Ok(())
 }
 
+#[test]
+fn test_call_batching() -> Result<()> {
+    // in call-contracts.wat
+    //     the input is passed to call_contracts as a batch
+    //     the batch's results are returned as the output
+    //
+    // in storage.rs
+    //     an input starting with 0x00 will induce a storage read
+    //     all other inputs induce a storage write
+
+    let store_addr = random_bytes20();
+    let key = random_bytes32();
+    let value = random_bytes32();
+
+    fn call(args: &mut Vec<u8>, contract: Bytes20, data: Vec<u8>) {
+        args.extend([0, 0]); // a normal call that must succeed
+        args.extend(contract);
+        args.extend(Bytes32::default());
+        args.extend(u64::MAX.to_be_bytes());
+        args.extend(u32::to_be_bytes(data.len() as u32));
+        args.extend(data);
+    }
+
+    // write the value, then read it back
+    let mut args = vec![];
+    call(
+        &mut args,
+        store_addr,
+        [&[0x01][..], &key[..], &value[..]].concat(),
+    );
+    call(&mut args, store_addr, [&[0x00][..], &key[..]].concat());
+
+    let filename = "tests/call-contracts.wat";
+    let (compile, config, ink) = test_configs();
+
+    let (mut native, mut evm) = TestInstance::new_with_evm(filename, &compile, config)?;
+    evm.deploy(store_addr, config, "storage")?;
+
+    let output = run_native(&mut native, &args, ink)?;
+    assert_eq!(evm.get_bytes32(key, Gas(0)).0, value);
+
+    // each result is the status, gas used, return data length, and return data
+    let mut output = output.as_slice();
+    for expected in [&[][..], &value[..]] {
+        let (result, rest) = output.split_at(13);
+        assert_eq!(result[0], UserOutcomeKind::Success as u8);
+        let len = u32::from_be_bytes(result[9..13].try_into().unwrap()) as usize;
+        assert_eq!(&rest[..len], expected);
+        output = &rest[len..];
+    }
+    assert!(output.is_empty());
+    Ok(())
+}
+
 #[test]
 fn test_exit_early() -> Result<()> {
     // in exit-early.wat
@@ -0,0 +1,26 @@
+;; Copyright 2024, Offchain Labs, Inc.
+;; For license information, see https://github.com/nitro/blob/master/LICENSE
+
+(module
+    (import "vm_hooks" "read_args"      (func $read_args      (param i32)))
+    (import "vm_hooks" "write_result"   (func $write_result   (param i32 i32)))
+    (import "vm_hooks" "call_contracts" (func $call_contracts (param i32 i32 i32 i32 i32) (result i32)))
+    (memory (export "memory") 2 2)
+    (func $main (export "user_entrypoint") (param $args_len i32) (result i32)
+        (local $len i32)
+
+        ;; write the batch to 0x0
+        (call $read_args (i32.const 0))
+
+        ;; make the calls, writing up to 0x8000 bytes of results to 0x8000 and their length to 0x10000
+        (call $call_contracts (i32.const 0) (local.get $args_len) (i32.const 0x8000) (i32.const 0x8000) (i32.const 0x10000))
+        drop
+
+        ;; return the results that fit
+        (local.set $len (i32.load (i32.const 0x10000)))
+        (call $write_result
+            (i32.const 0x8000)
+            (select (i32.const 0x8000) (local.get $len) (i32.gt_u (local.get $len) (i32.const 0x8000))))
+        i32.const 0
+    )
+)
@@ -0,0 +1,20 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+#![no_main]
+
+use stylus_sdk::{alloy_primitives::Address, call::RawCall, console, prelude::*};
+
+// Built without the SDK's `reentrant` feature, so the entrypoint reverts if the program is reentered.
+#[entrypoint]
+fn user_main(input: Vec<u8>) -> Result<Vec<u8>, Vec<u8>> {
+    if input.len() < 20 {
+        return Ok(vec![]);
+    }
+
+    // forward the rest of the input to the address it starts with
+    let target = Address::try_from(&input[..20]).unwrap();
+    let data = &input[20..];
+    console!("Forwarding to {target}: {}", hex::encode(data));
+    RawCall::new().call(target, data)
+}
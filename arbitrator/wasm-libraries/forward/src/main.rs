@@ -6,7 +6,7 @@ use std::{fs::File, io::Write, path::PathBuf};
 use structopt::StructOpt;
 
 /// order matters!
-const HOSTIOS: [[&str; 3]; 42] = [
+const HOSTIOS: [[&str; 3]; 43] = [
     ["read_args", "i32", ""],
     ["write_result", "i32 i32", ""],
     ["exit_early", "i32", ""],
@@ -49,6 +49,7 @@ const HOSTIOS: [[&str; 3]; 42] = [
     ["tx_ink_price", "", "i32"],
     ["tx_origin", "i32", ""],
     ["pay_for_memory_grow", "i32", ""],
+    ["call_contracts", "i32 i32 i32 i32 i32", "i32"],
 ];
 
 #[derive(StructOpt)]
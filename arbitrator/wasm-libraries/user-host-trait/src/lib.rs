@@ -53,6 +53,17 @@ macro_rules! trace {
         trace!($name, $env, [$args], [$outs], $ret)
     };
 }
+
+/// Takes the next `len` bytes off the front of the input, failing if there aren't enough.
+fn take<'a>(input: &mut &'a [u8], len: usize) -> Result<&'a [u8]> {
+    if input.len() < len {
+        return Err(eyre!("malformed call batch"));
+    }
+    let (front, rest) = input.split_at(len);
+    *input = rest;
+    Ok(front)
+}
+
 type Address = Bytes20;
 type Wei = Bytes32;
 type U256 = Uint<256, 4>;
@@ -321,6 +332,97 @@ pub trait UserHost<DR: DataReader>: GasMeteredMachine {
         self.do_call(contract, data, data_len, None, gas, ret_len, call, "static")
     }
 
+    /// Makes several calls to other contracts in a single hostio, avoiding the overhead of a
+    /// hostio per call. `calls` is a packed list of calls, each encoded as
+    ///
+    /// | kind (1) | flags (1) | contract (20) | value (32) | gas (8) | calldata len (4) | calldata |
+    ///
+    /// where `kind` is `0` for [`CALL`], `1` for [`DELEGATE_CALL`], and `2` for [`STATIC_CALL`], and
+    /// setting bit `0` of `flags` lets the batch continue if the call fails. The `value` must be `0`
+    /// unless `kind` is `0`, and integers are big-endian. Calls are made in order with the same
+    /// semantics as the `call_contract`, `delegate_call_contract`, and `static_call_contract`
+    /// hostios, and the batch stops after the first failed call that doesn't allow failure.
+    ///
+    /// Returns the number of calls made. Their results are written to `results` as a packed list of
+    ///
+    /// | status (1) | gas used (8) | return data len (4) | return data |
+    ///
+    /// copying only the first `results_cap` bytes, with the full length stored in `results_len`.
+    /// As with the other calls, the last call's return data can also be read via `read_return_data`.
+    ///
+    /// [`CALL`]: https://www.evm.codes/#f1
+    /// [`DELEGATE_CALL`]: https://www.evm.codes/#F4
+    /// [`STATIC_CALL`]: https://www.evm.codes/#FA
+    fn call_contracts(
+        &mut self,
+        calls: GuestPtr,
+        calls_len: u32,
+        results: GuestPtr,
+        results_cap: u32,
+        results_len: GuestPtr,
+    ) -> Result<u32, Self::Err> {
+        self.buy_ink(HOSTIO_INK + 3 * PTR_INK)?;
+        self.pay_for_read(calls_len)?;
+        let batch = self.read_slice(calls, calls_len)?;
+
+        let mut input = batch.as_slice();
+        let mut outs = vec![];
+        let mut outs_total: u32 = 0;
+        let mut made: u32 = 0;
+        while !input.is_empty() {
+            let kind = take(&mut input, 1)?[0];
+            let flags = take(&mut input, 1)?[0];
+            let contract = Bytes20::try_from(take(&mut input, 20)?).unwrap();
+            let value = Bytes32::try_from(take(&mut input, 32)?).unwrap();
+            let gas = Gas(u64::from_be_bytes(take(&mut input, 8)?.try_into().unwrap()));
+            let data_len = u32::from_be_bytes(take(&mut input, 4)?.try_into().unwrap());
+            let data = take(&mut input, data_len as usize)?;
+            if kind > 2 {
+                Err(eyre!("unknown call kind {kind}"))?;
+            }
+            if kind != 0 && value != Bytes32::default() {
+                Err(eyre!("value sent with a delegate or static call"))?;
+            }
+
+            self.buy_ink(PTR_INK + EVM_API_INK)?;
+            self.pay_for_geth_bytes(data_len)?;
+            let gas_left = self.gas_left()?;
+            let gas_req = gas.min(gas_left);
+            let api = self.evm_api();
+            let (outs_len, gas_cost, status) = match kind {
+                0 => api.contract_call(contract, data, gas_left, gas_req, value),
+                1 => api.delegate_call(contract, data, gas_left, gas_req),
+                _ => api.static_call(contract, data, gas_left, gas_req),
+            };
+            self.buy_gas(gas_cost)?;
+            *self.evm_return_data_len() = outs_len;
+            made += 1;
+
+            outs.push(status as u8);
+            outs.extend(be!(gas_cost.0));
+            outs.extend(be!(outs_len));
+            outs_total = outs_total.saturating_add(13).saturating_add(outs_len);
+
+            // only copy the return data that fits in the results, which are paid for when written
+            let room = results_cap.saturating_sub(outs.len() as u32);
+            let copied = outs_len.min(room);
+            if copied > 0 {
+                self.buy_ink(EVM_API_INK)?;
+                let data = self.evm_api().get_return_data();
+                outs.extend(&data.slice()[..copied as usize]);
+            }
+            if status != UserOutcomeKind::Success && flags & 1 == 0 {
+                break;
+            }
+        }
+
+        let written = (outs.len() as u32).min(results_cap);
+        self.pay_for_write(written)?;
+        self.write_slice(results, &outs[..written as usize])?;
+        self.write_u32(results_len, outs_total)?;
+        trace!("call_contracts", self, [batch], [be!(made), outs], made)
+    }
+
     /// Performs one of the supported EVM calls.
     /// Note that `value` must only be [`Some`] for normal calls.
     fn do_call<F>(
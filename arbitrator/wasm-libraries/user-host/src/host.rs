@@ -121,6 +121,23 @@ pub unsafe extern "C" fn user_host__static_call_contract(
     ))
 }
 
+#[no_mangle]
+pub unsafe extern "C" fn user_host__call_contracts(
+    calls: GuestPtr,
+    calls_len: u32,
+    results: GuestPtr,
+    results_cap: u32,
+    results_len: GuestPtr,
+) -> u32 {
+    hostio!(call_contracts(
+        calls,
+        calls_len,
+        results,
+        results_cap,
+        results_len
+    ))
+}
+
 #[no_mangle]
 pub unsafe extern "C" fn user_host__create1(
     code: GuestPtr,
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/staker"
 	"github.com/offchainlabs/nitro/validator"
@@ -22,6 +23,55 @@ func (a *BlockValidatorAPI) LatestValidated(ctx context.Context) (*staker.Global
 	return a.val.ReadLastValidatedInfo()
 }
 
+// Status changes buffered per subscriber before it starts missing them
+const validationStatusSubscriptionBuffer = 256
+
+type ValidationStatusAPI struct {
+	val *staker.BlockValidator
+}
+
+// ValidationStatus pushes the block validator's progress, starting with where it stands:
+// validated messages, module root changes, failed validations and challenge transitions.
+func (a *ValidationStatusAPI) ValidationStatus(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	initial, err := a.val.Status()
+	if err != nil {
+		return nil, err
+	}
+	statuses, unsubscribe := a.val.StatusFeed().Subscribe(validationStatusSubscriptionBuffer)
+	sub := notifier.CreateSubscription()
+	go func() {
+		defer unsubscribe()
+		if err := notifier.Notify(sub.ID, initial); err != nil {
+			return
+		}
+		for {
+			select {
+			case status := <-statuses:
+				if err := notifier.Notify(sub.ID, status); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+type StakerAPI struct {
+	staker *staker.Staker
+}
+
+// ValidatorReadiness reports whether the rollup's validator allowlist lets this node's validator
+// act, and anything else that'd stop it from posting assertions.
+func (a *StakerAPI) ValidatorReadiness(ctx context.Context) (*staker.ValidatorReadiness, error) {
+	return a.staker.Readiness(ctx)
+}
+
 type BlockValidatorDebugAPI struct {
 	val *staker.StatelessBlockValidator
 }
@@ -61,3 +111,122 @@ func (a *BlockValidatorDebugAPI) ValidationInputsAt(ctx context.Context, msgNum
 ) (server_api.InputJSON, error) {
 	return a.val.ValidationInputsAt(ctx, arbutil.MessageIndex(msgNum), target)
 }
+
+// ExecutionWitness returns the witness of the block produced by a message: the headers, codes and trie nodes
+// needed to execute it statelessly, and the accounts and storage slots it accessed.
+func (a *BlockValidatorDebugAPI) ExecutionWitness(ctx context.Context, msgNum hexutil.Uint64) (*staker.ExecutionWitness, error) {
+	return a.val.ExecutionWitnessAt(ctx, arbutil.MessageIndex(msgNum))
+}
+
+type PauseAPI struct {
+	controls *PauseControls
+}
+
+// PauseActor stops one background actor (batch-poster, staker, validation or feed) until it's resumed,
+// including across restarts.
+func (a *PauseAPI) PauseActor(actor string) error {
+	return a.controls.SetPaused(actor, true)
+}
+
+func (a *PauseAPI) ResumeActor(actor string) error {
+	return a.controls.SetPaused(actor, false)
+}
+
+// PauseStatus returns whether each background actor is paused.
+func (a *PauseAPI) PauseStatus() map[string]bool {
+	return a.controls.Status()
+}
+
+type MaintenanceAPI struct {
+	runner *MaintenanceRunner
+	dbs    map[string]ethdb.Database
+}
+
+type MaintenanceStatus struct {
+	Running       bool                    `json:"running"`
+	Paused        bool                    `json:"paused"`
+	LastCompleted uint64                  `json:"lastCompleted"`
+	Tasks         []MaintenanceTaskStatus `json:"tasks"`
+}
+
+func (a *MaintenanceAPI) Trigger() error {
+	return a.runner.Trigger()
+}
+
+func (a *MaintenanceAPI) Pause() {
+	a.runner.Pause()
+}
+
+func (a *MaintenanceAPI) Resume() {
+	a.runner.Resume()
+}
+
+func (a *MaintenanceAPI) Status() MaintenanceStatus {
+	// #nosec G115
+	return MaintenanceStatus{
+		Running:       a.runner.running.Load(),
+		Paused:        a.runner.paused.Load(),
+		LastCompleted: uint64(a.runner.lastCompleted.Load()),
+		Tasks:         a.runner.TaskStatuses(),
+	}
+}
+
+// RunTask starts a maintenance task in the background, outside of the maintenance windows.
+func (a *MaintenanceAPI) RunTask(name string) error {
+	return a.runner.RunTask(name)
+}
+
+// DatabaseStats returns the internal LSM metrics (levels, compaction debt, stalls) of a database.
+func (a *MaintenanceAPI) DatabaseStats(name string) (string, error) {
+	db, ok := a.dbs[name]
+	if !ok {
+		return "", fmt.Errorf("unknown database %v", name)
+	}
+	// pebble ignores the property and returns all of its metrics
+	return db.Stat("leveldb.stats")
+}
+
+type L1FeeReportAPI struct {
+	reporter *L1FeeReporter
+}
+
+func parseReportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(l1FeeReportDateFormat, s)
+}
+
+// L1FeeReport reconciles batch posting costs against L1 fees collected for blocks from the start (inclusive)
+// to the end (exclusive) time, given as RFC 3339 times or UTC dates such as 2024-06-01.
+func (a *L1FeeReportAPI) L1FeeReport(ctx context.Context, from string, to string) (*L1FeeReport, error) {
+	fromTime, err := parseReportTime(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report start: %w", err)
+	}
+	toTime, err := parseReportTime(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report end: %w", err)
+	}
+	return a.reporter.Generate(ctx, fromTime, toTime)
+}
+
+type DepositWatcherAPI struct {
+	watcher *DepositWatcher
+}
+
+// DepositStatus reports the delayed messages a parent chain transaction created, the L2 transactions
+// they'll turn into, and how far along they are in being sequenced and posted.
+func (a *DepositWatcherAPI) DepositStatus(ctx context.Context, parentChainTxHash common.Hash) (*DepositStatus, error) {
+	return a.watcher.Status(ctx, parentChainTxHash)
+}
+
+type BatchInfoAPI struct {
+	reporter *BatchInfoReporter
+}
+
+// TransactionBatchInfo reports the batch a transaction was posted in, its message's position in the batch,
+// and the parent chain transaction which posted it. The batch fields are omitted until it's posted.
+func (a *BatchInfoAPI) TransactionBatchInfo(ctx context.Context, txHash common.Hash) (*TransactionBatchInfo, error) {
+	return a.reporter.TransactionBatchInfo(ctx, txHash)
+}
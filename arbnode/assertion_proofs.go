@@ -0,0 +1,345 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/dbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var (
+	assertionProofsRecordedCounter     = metrics.NewRegisteredCounter("arb/assertionproofs/recorded", nil)
+	assertionProofsSnapshotsCounter    = metrics.NewRegisteredCounter("arb/assertionproofs/snapshots", nil)
+	assertionProofsMissingStateCounter = metrics.NewRegisteredCounter("arb/assertionproofs/missingstate", nil)
+
+	ErrNoConfirmedAssertion = errors.New("no confirmed assertion recorded")
+)
+
+type AssertionProofsConfig struct {
+	Enable           bool   `koanf:"enable"`
+	SnapshotInterval uint64 `koanf:"snapshot-interval" reload:"hot"`
+}
+
+var DefaultAssertionProofsConfig = AssertionProofsConfig{
+	Enable:           false,
+	SnapshotInterval: 1,
+}
+
+func AssertionProofsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAssertionProofsConfig.Enable, "record confirmed assertions and serve account and storage proofs against their state roots (requires the staker, which may be a watchtower)")
+	f.Uint64(prefix+".snapshot-interval", DefaultAssertionProofsConfig.SnapshotInterval, "keep the state trie of every this many confirmed assertions on disk, so proofs against them survive pruning")
+}
+
+func (c *AssertionProofsConfig) Validate() error {
+	if c.Enable && c.SnapshotInterval == 0 {
+		return errors.New("assertion proofs snapshot-interval must be positive")
+	}
+	return nil
+}
+
+type AssertionProofsConfigFetcher func() *AssertionProofsConfig
+
+// ConfirmedAssertion is the L2 state a confirmed assertion committed to, as recorded when it was confirmed.
+type ConfirmedAssertion struct {
+	MessageCount arbutil.MessageIndex `json:"messageCount"`
+	BlockHash    common.Hash          `json:"blockHash"`
+	BlockNumber  uint64               `json:"blockNumber"`
+	StateRoot    common.Hash          `json:"stateRoot"`
+	SendRoot     common.Hash          `json:"sendRoot"`
+	Batch        uint64               `json:"batch"`
+	PosInBatch   uint64               `json:"posInBatch"`
+	Snapshotted  bool                 `json:"snapshotted"` // whether the state trie was kept on disk
+}
+
+// AssertionProofs records every confirmed assertion with the state root of its block, periodically
+// keeping that state's trie on disk, so that anyone can be given proofs of historical L2 state that L1
+// contracts can check against the assertion alone.
+type AssertionProofs struct {
+	stopwaiter.StopWaiter
+	config AssertionProofsConfigFetcher
+	bc     *core.BlockChain
+	db     ethdb.Database
+
+	pendingMutex  sync.Mutex
+	pending       *ConfirmedAssertion
+	pendingSignal chan struct{}
+
+	sinceSnapshot uint64
+}
+
+func NewAssertionProofs(config AssertionProofsConfigFetcher, bc *core.BlockChain, db ethdb.Database) (*AssertionProofs, error) {
+	if err := config().Validate(); err != nil {
+		return nil, err
+	}
+	if bc.TrieDB().Scheme() == rawdb.PathScheme {
+		return nil, errors.New("assertion proofs need the hash state scheme to keep historical states")
+	}
+	return &AssertionProofs{
+		config:        config,
+		bc:            bc,
+		db:            db,
+		pendingSignal: make(chan struct{}, 1),
+	}, nil
+}
+
+func (a *AssertionProofs) Start(ctxIn context.Context) {
+	a.StopWaiter.Start(ctxIn, a)
+	a.LaunchThread(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.pendingSignal:
+			}
+			a.recordPending()
+		}
+	})
+}
+
+// UpdateLatestConfirmed queues a newly confirmed assertion to be recorded.
+func (a *AssertionProofs) UpdateLatestConfirmed(count arbutil.MessageIndex, globalState validator.GoGlobalState) {
+	a.pendingMutex.Lock()
+	defer a.pendingMutex.Unlock()
+	if a.pending != nil && a.pending.MessageCount >= count {
+		return
+	}
+	a.pending = &ConfirmedAssertion{
+		MessageCount: count,
+		BlockHash:    globalState.BlockHash,
+		SendRoot:     globalState.SendRoot,
+		Batch:        globalState.Batch,
+		PosInBatch:   globalState.PosInBatch,
+	}
+	select {
+	case a.pendingSignal <- struct{}{}:
+	default:
+	}
+}
+
+func (a *AssertionProofs) recordPending() {
+	a.pendingMutex.Lock()
+	assertion := a.pending
+	a.pendingMutex.Unlock()
+	if assertion == nil {
+		return
+	}
+	latest, err := a.Latest()
+	if err != nil && !errors.Is(err, ErrNoConfirmedAssertion) {
+		log.Error("failed to read latest recorded assertion", "err", err)
+		return
+	}
+	if latest != nil && latest.MessageCount >= assertion.MessageCount {
+		return
+	}
+	if err := a.record(*assertion); err != nil {
+		// The block may not have been executed yet, so it's retried on the next confirmation
+		log.Warn("failed to record confirmed assertion", "count", assertion.MessageCount, "blockHash", assertion.BlockHash, "err", err)
+	}
+}
+
+// record looks up the assertion's block, keeps its state trie on disk if a snapshot is due, and indexes it.
+func (a *AssertionProofs) record(assertion ConfirmedAssertion) error {
+	header := a.bc.GetHeaderByHash(assertion.BlockHash)
+	if header == nil {
+		return fmt.Errorf("block %v not found", assertion.BlockHash)
+	}
+	assertion.BlockNumber = header.Number.Uint64()
+	assertion.StateRoot = header.Root
+
+	a.sinceSnapshot++
+	if a.sinceSnapshot >= a.config().SnapshotInterval {
+		if _, err := a.bc.StateAt(header.Root); err != nil {
+			assertionProofsMissingStateCounter.Inc(1)
+			log.Warn("state of confirmed assertion not available to snapshot", "count", assertion.MessageCount, "block", assertion.BlockNumber, "err", err)
+		} else if err := a.bc.TrieDB().Commit(header.Root, false); err != nil {
+			return fmt.Errorf("committing state of block %v: %w", assertion.BlockNumber, err)
+		} else {
+			assertion.Snapshotted = true
+			a.sinceSnapshot = 0
+			assertionProofsSnapshotsCounter.Inc(1)
+		}
+	}
+
+	data, err := rlp.EncodeToBytes(&assertion)
+	if err != nil {
+		return err
+	}
+	batch := a.db.NewBatch()
+	if err := batch.Put(dbKey(confirmedAssertionPrefix, uint64(assertion.MessageCount)), data); err != nil {
+		return err
+	}
+	if err := batch.Put(latestConfirmedAssertionKey, data); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	assertionProofsRecordedCounter.Inc(1)
+	log.Info("recorded confirmed assertion", "count", assertion.MessageCount, "block", assertion.BlockNumber, "stateRoot", assertion.StateRoot, "snapshotted", assertion.Snapshotted)
+	return nil
+}
+
+func (a *AssertionProofs) read(key []byte) (*ConfirmedAssertion, error) {
+	data, err := a.db.Get(key)
+	if dbutil.IsErrNotFound(err) {
+		return nil, ErrNoConfirmedAssertion
+	}
+	if err != nil {
+		return nil, err
+	}
+	var assertion ConfirmedAssertion
+	if err := rlp.DecodeBytes(data, &assertion); err != nil {
+		return nil, err
+	}
+	return &assertion, nil
+}
+
+// Get returns the confirmed assertion with the given message count.
+func (a *AssertionProofs) Get(count arbutil.MessageIndex) (*ConfirmedAssertion, error) {
+	assertion, err := a.read(dbKey(confirmedAssertionPrefix, uint64(count)))
+	if errors.Is(err, ErrNoConfirmedAssertion) {
+		return nil, fmt.Errorf("%w with message count %v", err, count)
+	}
+	return assertion, err
+}
+
+// Latest returns the last confirmed assertion recorded.
+func (a *AssertionProofs) Latest() (*ConfirmedAssertion, error) {
+	return a.read(latestConfirmedAssertionKey)
+}
+
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, common.CopyBytes(value))
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+type AssertionStorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value common.Hash     `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// AssertionProof proves an account and its storage against the state root of a confirmed assertion's block.
+// The header's hash is the assertion's block hash, which links the state root to the assertion on L1.
+type AssertionProof struct {
+	Assertion    *ConfirmedAssertion     `json:"assertion"`
+	Header       hexutil.Bytes           `json:"header"` // RLP encoded
+	Address      common.Address          `json:"address"`
+	AccountProof []hexutil.Bytes         `json:"accountProof"`
+	Balance      *hexutil.Big            `json:"balance"`
+	Nonce        hexutil.Uint64          `json:"nonce"`
+	CodeHash     common.Hash             `json:"codeHash"`
+	StorageHash  common.Hash             `json:"storageHash"`
+	StorageProof []AssertionStorageProof `json:"storageProof"`
+}
+
+// Prove builds the proofs of an account and storage slots in the state of a confirmed assertion.
+func (a *AssertionProofs) Prove(count arbutil.MessageIndex, address common.Address, storageKeys []common.Hash) (*AssertionProof, error) {
+	assertion, err := a.Get(count)
+	if err != nil {
+		return nil, err
+	}
+	header := a.bc.GetHeaderByHash(assertion.BlockHash)
+	if header == nil {
+		return nil, fmt.Errorf("block %v of assertion not found", assertion.BlockHash)
+	}
+	encodedHeader, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := a.bc.StateAt(assertion.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("state of assertion with message count %v not available: %w", count, err)
+	}
+	triedb := a.bc.StateCache().TrieDB()
+	accountTrie, err := trie.New(trie.StateTrieID(assertion.StateRoot), triedb)
+	if err != nil {
+		return nil, err
+	}
+	var accountProof proofList
+	if err := accountTrie.Prove(crypto.Keccak256(address.Bytes()), &accountProof); err != nil {
+		return nil, err
+	}
+
+	storageHash := statedb.GetStorageRoot(address)
+	if storageHash == (common.Hash{}) {
+		storageHash = types.EmptyRootHash
+	}
+	storageProofs := make([]AssertionStorageProof, 0, len(storageKeys))
+	if len(storageKeys) > 0 {
+		storageTrie, err := trie.New(trie.StorageTrieID(assertion.StateRoot, crypto.Keccak256Hash(address.Bytes()), storageHash), triedb)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range storageKeys {
+			var proof proofList
+			if err := storageTrie.Prove(crypto.Keccak256(key.Bytes()), &proof); err != nil {
+				return nil, err
+			}
+			storageProofs = append(storageProofs, AssertionStorageProof{
+				Key:   key,
+				Value: statedb.GetState(address, key),
+				Proof: proof,
+			})
+		}
+	}
+
+	return &AssertionProof{
+		Assertion:    assertion,
+		Header:       encodedHeader,
+		Address:      address,
+		AccountProof: accountProof,
+		Balance:      (*hexutil.Big)(statedb.GetBalance(address).ToBig()),
+		Nonce:        hexutil.Uint64(statedb.GetNonce(address)),
+		CodeHash:     statedb.GetCodeHash(address),
+		StorageHash:  storageHash,
+		StorageProof: storageProofs,
+	}, nil
+}
+
+type AssertionProofsAPI struct {
+	proofs *AssertionProofs
+}
+
+// ConfirmedAssertion returns the recorded confirmed assertion with the given message count.
+func (a *AssertionProofsAPI) ConfirmedAssertion(ctx context.Context, count arbutil.MessageIndex) (*ConfirmedAssertion, error) {
+	return a.proofs.Get(count)
+}
+
+// LatestConfirmedAssertion returns the last confirmed assertion recorded.
+func (a *AssertionProofsAPI) LatestConfirmedAssertion(ctx context.Context) (*ConfirmedAssertion, error) {
+	return a.proofs.Latest()
+}
+
+// AssertionProof returns proofs of an account and its storage slots against a confirmed assertion's state.
+func (a *AssertionProofsAPI) AssertionProof(ctx context.Context, count arbutil.MessageIndex, address common.Address, storageKeys []common.Hash) (*AssertionProof, error) {
+	return a.proofs.Prove(count, address, storageKeys)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestAssertionProofs(t *testing.T) {
+	ownerAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	_, _, arbDb, bc := NewTransactionStreamerForTest(t, ownerAddress)
+	if bc.TrieDB().Scheme() == rawdb.PathScheme {
+		t.Skip("assertion proofs need the hash state scheme")
+	}
+	config := DefaultAssertionProofsConfig
+	proofs, err := NewAssertionProofs(func() *AssertionProofsConfig { return &config }, bc, arbDb)
+	Require(t, err)
+
+	if _, err := proofs.Latest(); !errors.Is(err, ErrNoConfirmedAssertion) {
+		Fail(t, "expected no recorded assertion, got", err)
+	}
+	head := bc.CurrentBlock()
+	proofs.UpdateLatestConfirmed(1, validator.GoGlobalState{BlockHash: head.Hash(), Batch: 1})
+	proofs.recordPending()
+	assertion, err := proofs.Latest()
+	Require(t, err)
+	if assertion.MessageCount != 1 || assertion.StateRoot != head.Root || !assertion.Snapshotted {
+		Fail(t, "unexpected recorded assertion", assertion)
+	}
+	// An assertion confirmed again isn't recorded twice
+	config.SnapshotInterval = 2
+	proofs.UpdateLatestConfirmed(1, validator.GoGlobalState{BlockHash: head.Hash(), Batch: 1})
+	proofs.recordPending()
+	if proofs.sinceSnapshot != 0 {
+		Fail(t, "assertion recorded again")
+	}
+
+	slot := common.Hash{1}
+	proof, err := proofs.Prove(1, ownerAddress, []common.Hash{slot})
+	Require(t, err)
+	var header types.Header
+	Require(t, rlp.DecodeBytes(proof.Header, &header))
+	if header.Hash() != assertion.BlockHash {
+		Fail(t, "header doesn't match the assertion's block hash")
+	}
+
+	proofDb := memorydb.New()
+	for _, node := range proof.AccountProof {
+		Require(t, proofDb.Put(crypto.Keccak256(node), node))
+	}
+	encodedAccount, err := trie.VerifyProof(header.Root, crypto.Keccak256(ownerAddress.Bytes()), proofDb)
+	Require(t, err)
+	var account types.StateAccount
+	Require(t, rlp.DecodeBytes(encodedAccount, &account))
+	if account.Balance.ToBig().Cmp(proof.Balance.ToInt()) != 0 || account.Balance.Sign() == 0 {
+		Fail(t, "proven balance doesn't match", account.Balance, proof.Balance)
+	}
+	if account.Root != proof.StorageHash || !bytes.Equal(account.CodeHash, proof.CodeHash.Bytes()) {
+		Fail(t, "proven account doesn't match", account)
+	}
+
+	// The owner has no storage, so the slot is proven absent by the empty storage root
+	if len(proof.StorageProof) != 1 || proof.StorageProof[0].Value != (common.Hash{}) || len(proof.StorageProof[0].Proof) != 0 || proof.StorageHash != types.EmptyRootHash {
+		Fail(t, "unexpected storage proof", proof.StorageHash, proof.StorageProof)
+	}
+
+	if _, err := proofs.Prove(2, ownerAddress, nil); !errors.Is(err, ErrNoConfirmedAssertion) {
+		Fail(t, "proved against an unrecorded assertion", err)
+	}
+}
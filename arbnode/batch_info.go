@@ -0,0 +1,95 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TransactionBatchInfo locates a transaction's message in the sequencer batches posted to the parent chain,
+// complementing its receipt.
+type TransactionBatchInfo struct {
+	TransactionHash common.Hash `json:"transactionHash"`
+	BlockNumber     uint64      `json:"blockNumber"`
+	BlockHash       common.Hash `json:"blockHash"`
+	MessageIndex    uint64      `json:"messageIndex"`
+	// Set once the message has been posted in a batch
+	Batch *uint64 `json:"batch,omitempty"`
+	// The message's position among the batch's messages
+	PositionInBatch  *uint64 `json:"positionInBatch,omitempty"`
+	ParentChainBlock *uint64 `json:"parentChainBlock,omitempty"`
+	// Set if the node reads the parent chain
+	ParentChainTxHash *common.Hash `json:"parentChainTxHash,omitempty"`
+}
+
+// BatchInfoReporter joins L2 transactions with the batches the inbox tracker has seen them posted in.
+type BatchInfoReporter struct {
+	chainDb         ethdb.Database
+	tracker         *InboxTracker
+	reader          *InboxReader // nil if the node doesn't read the parent chain
+	genesisBlockNum uint64
+}
+
+func NewBatchInfoReporter(chainDb ethdb.Database, tracker *InboxTracker, reader *InboxReader, genesisBlockNum uint64) *BatchInfoReporter {
+	return &BatchInfoReporter{
+		chainDb:         chainDb,
+		tracker:         tracker,
+		reader:          reader,
+		genesisBlockNum: genesisBlockNum,
+	}
+}
+
+func (r *BatchInfoReporter) TransactionBatchInfo(ctx context.Context, txHash common.Hash) (*TransactionBatchInfo, error) {
+	tx, blockHash, blockNumber, _ := rawdb.ReadTransaction(r.chainDb, txHash)
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+	if blockNumber < r.genesisBlockNum {
+		return nil, errors.New("transaction is from before the chain's genesis")
+	}
+	pos := arbutil.BlockNumberToMessageCount(blockNumber, r.genesisBlockNum) - 1
+	info := &TransactionBatchInfo{
+		TransactionHash: txHash,
+		BlockNumber:     blockNumber,
+		BlockHash:       blockHash,
+		MessageIndex:    uint64(pos),
+	}
+	batch, found, err := r.tracker.FindInboxBatchContainingMessage(pos)
+	if err != nil || !found {
+		return info, err
+	}
+	metadata, err := r.tracker.GetBatchMetadata(batch)
+	if err != nil {
+		return nil, err
+	}
+	var firstMessage arbutil.MessageIndex
+	if batch > 0 {
+		prevMetadata, err := r.tracker.GetBatchMetadata(batch - 1)
+		if err != nil {
+			return nil, err
+		}
+		firstMessage = prevMetadata.MessageCount
+	}
+	position := uint64(pos - firstMessage)
+	info.Batch = &batch
+	info.PositionInBatch = &position
+	info.ParentChainBlock = &metadata.ParentChainBlock
+	if r.reader != nil {
+		parentChainTxHash, err := r.reader.GetBatchParentChainTxHash(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		info.ParentChainTxHash = &parentChainTxHash
+	}
+	return info, nil
+}
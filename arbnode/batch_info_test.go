@@ -0,0 +1,78 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+func TestTransactionBatchInfo(t *testing.T) {
+	tracker := &InboxTracker{
+		db:        rawdb.NewMemoryDatabase(),
+		batchMeta: containers.NewLruCache[uint64, BatchMetadata](100),
+	}
+	batches := []BatchMetadata{
+		{MessageCount: 1, ParentChainBlock: 5},
+		{MessageCount: 4, ParentChainBlock: 10},
+		{MessageCount: 6, ParentChainBlock: 12},
+	}
+	for i, metadata := range batches {
+		data, err := rlp.EncodeToBytes(metadata)
+		Require(t, err)
+		Require(t, tracker.db.Put(dbKey(sequencerBatchMetaPrefix, uint64(i)), data))
+	}
+	count, err := rlp.EncodeToBytes(uint64(len(batches)))
+	Require(t, err)
+	Require(t, tracker.db.Put(sequencerBatchCountKey, count))
+
+	chainDb := rawdb.NewMemoryDatabase()
+	addTx := func(blockNumber uint64) common.Hash {
+		tx := types.NewTx(&types.LegacyTx{Nonce: blockNumber, GasPrice: big.NewInt(1), Gas: 21000})
+		header := &types.Header{Number: new(big.Int).SetUint64(blockNumber), Difficulty: common.Big1}
+		block := types.NewBlock(header, types.Transactions{tx}, nil, nil, trie.NewStackTrie(nil))
+		rawdb.WriteBlock(chainDb, block)
+		rawdb.WriteCanonicalHash(chainDb, block.Hash(), blockNumber)
+		rawdb.WriteTxLookupEntriesByBlock(chainDb, block)
+		return tx.Hash()
+	}
+	genesisBlockNum := uint64(2)
+	reporter := NewBatchInfoReporter(chainDb, tracker, nil, genesisBlockNum)
+
+	// Message 2 is the second message of batch 1
+	// #nosec G115
+	posted := addTx(uint64(arbutil.MessageCountToBlockNumber(3, genesisBlockNum)))
+	info, err := reporter.TransactionBatchInfo(context.Background(), posted)
+	Require(t, err)
+	if info.MessageIndex != 2 || info.Batch == nil || *info.Batch != 1 || *info.PositionInBatch != 1 || *info.ParentChainBlock != 10 {
+		Fail(t, "unexpected batch info", info)
+	}
+	if info.ParentChainTxHash != nil {
+		Fail(t, "parent chain tx hash set without an inbox reader")
+	}
+
+	// Message 6 hasn't been posted yet
+	// #nosec G115
+	unposted := addTx(uint64(arbutil.MessageCountToBlockNumber(7, genesisBlockNum)))
+	info, err = reporter.TransactionBatchInfo(context.Background(), unposted)
+	Require(t, err)
+	if info.MessageIndex != 6 || info.Batch != nil || info.PositionInBatch != nil {
+		Fail(t, "unexpected batch info for unposted transaction", info)
+	}
+
+	if _, err := reporter.TransactionBatchInfo(context.Background(), common.Hash{1}); !errors.Is(err, ErrTransactionNotFound) {
+		Fail(t, "expected unknown transaction not to be found", err)
+	}
+}
@@ -73,6 +73,12 @@ var (
 
 	batchPosterFailureCounter = metrics.NewRegisteredCounter("arb/batchPoster/action/failure", nil)
 
+	batchPosterDryRunBatchCounter      = metrics.NewRegisteredCounter("arb/batchposter/dryrun/batches", nil)
+	batchPosterDryRunBytesCounter      = metrics.NewRegisteredCounter("arb/batchposter/dryrun/bytes", nil)
+	batchPosterDryRunBlobsCounter      = metrics.NewRegisteredCounter("arb/batchposter/dryrun/blobs", nil)
+	batchPosterDryRunCostGweiCounter   = metrics.NewRegisteredCounter("arb/batchposter/dryrun/cost_gwei", nil)
+	batchPosterDryRunLastCostGweiGauge = metrics.NewRegisteredGauge("arb/batchposter/dryrun/last_cost_gwei", nil)
+
 	usableBytesInBlob    = big.NewInt(int64(len(kzg4844.Blob{}) * 31 / 32))
 	blobTxBlobGasPerBlob = big.NewInt(params.BlobTxBlobGasPerBlob)
 )
@@ -80,6 +86,9 @@ var (
 const (
 	batchPosterSimpleRedisLockKey = "node.batch-poster.redis-lock.simple-lock-key"
 
+	// The size of a DAS certificate, which a batch stored by a DA provider posts in place of its data
+	dryRunDACertSize = 1 + 32 + 32 + 8 + 1 + 8 + 96
+
 	sequencerBatchPostMethodName          = "addSequencerL2BatchFromOrigin0"
 	sequencerBatchPostWithBlobsMethodName = "addSequencerL2BatchFromBlobs"
 )
@@ -109,6 +118,7 @@ type BatchPoster struct {
 	dataPoster         *dataposter.DataPoster
 	redisLock          *redislock.Simple
 	messagesPerBatch   *arbmath.MovingAverage[uint64]
+	blobFees           *BlobFeeTracker
 	non4844BatchCount  int // Count of consecutive non-4844 batches posted
 	// This is an atomic variable that should only be accessed atomically.
 	// An estimate of the number of batches we want to post but haven't yet.
@@ -120,7 +130,12 @@ type BatchPoster struct {
 	nextRevertCheckBlock int64       // the last parent block scanned for reverting batches
 	postedFirstBatch     bool        // indicates if batch poster has posted the first batch
 
+	dryRun         bool                 // fixed at startup, as a dry run batch poster may have no wallet to post with
+	dryRunPosition *batchPosterPosition // the end of the last simulated batch
+
 	accessList func(SequencerInboxAccs, AfterDelayedMessagesRead uint64) types.AccessList
+
+	pauses *PauseControls // may be nil
 }
 
 type l1BlockBound int
@@ -140,8 +155,9 @@ type BatchPosterDangerousConfig struct {
 }
 
 type BatchPosterConfig struct {
-	Enable                             bool `koanf:"enable"`
-	DisableDapFallbackStoreDataOnChain bool `koanf:"disable-dap-fallback-store-data-on-chain" reload:"hot"`
+	Enable                             bool   `koanf:"enable"`
+	DAProvider                         string `koanf:"da-provider"`
+	DisableDapFallbackStoreDataOnChain bool   `koanf:"disable-dap-fallback-store-data-on-chain" reload:"hot"`
 	// Max batch size.
 	MaxSize int `koanf:"max-size" reload:"hot"`
 	// Maximum 4844 blob enabled batch size.
@@ -163,6 +179,7 @@ type BatchPosterConfig struct {
 	ExtraBatchGas                  uint64                      `koanf:"extra-batch-gas" reload:"hot"`
 	Post4844Blobs                  bool                        `koanf:"post-4844-blobs" reload:"hot"`
 	IgnoreBlobPrice                bool                        `koanf:"ignore-blob-price" reload:"hot"`
+	BlobFee                        BlobFeeTrackerConfig        `koanf:"blob-fee" reload:"hot"`
 	ParentChainWallet              genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
 	L1BlockBound                   string                      `koanf:"l1-block-bound" reload:"hot"`
 	L1BlockBoundBypass             time.Duration               `koanf:"l1-block-bound-bypass" reload:"hot"`
@@ -172,6 +189,8 @@ type BatchPosterConfig struct {
 	ReorgResistanceMargin          time.Duration               `koanf:"reorg-resistance-margin" reload:"hot"`
 	CheckBatchCorrectness          bool                        `koanf:"check-batch-correctness"`
 	MaxEmptyBatchDelay             time.Duration               `koanf:"max-empty-batch-delay"`
+	DryRun                         bool                        `koanf:"dry-run"`
+	DelayedMessagesWindow          uint64                      `koanf:"delayed-messages-window" reload:"hot"`
 
 	gasRefunder  common.Address
 	l1BlockBound l1BlockBound
@@ -185,6 +204,15 @@ func (c *BatchPosterConfig) Validate() error {
 	if c.MaxSize <= 40 {
 		return errors.New("MaxBatchSize too small")
 	}
+	if c.DAProvider != DAProviderAnyTrust && c.DAProvider != DAProviderExternal {
+		return fmt.Errorf("invalid data availability provider \"%v\" (see --help for options)", c.DAProvider)
+	}
+	if err := c.BlobFee.Validate(); err != nil {
+		return err
+	}
+	if c.DelayedMessagesWindow > arbstate.MaxDelayedMessagesWindow {
+		return fmt.Errorf("delayed-messages-window %v exceeds the maximum of %v", c.DelayedMessagesWindow, arbstate.MaxDelayedMessagesWindow)
+	}
 	if c.L1BlockBound == "" {
 		c.l1BlockBound = l1BlockBoundDefault
 	} else if c.L1BlockBound == "safe" {
@@ -203,12 +231,18 @@ func (c *BatchPosterConfig) Validate() error {
 
 type BatchPosterConfigFetcher func() *BatchPosterConfig
 
+const (
+	DAProviderAnyTrust = "anytrust"
+	DAProviderExternal = "external"
+)
+
 func DangerousBatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".allow-posting-first-batch-when-sequencer-message-count-mismatch", DefaultBatchPosterConfig.Dangerous.AllowPostingFirstBatchWhenSequencerMessageCountMismatch, "allow posting the first batch even if sequence number doesn't match chain (useful after force-inclusion)")
 }
 
 func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultBatchPosterConfig.Enable, "enable posting batches to l1")
+	f.String(prefix+".da-provider", DefaultBatchPosterConfig.DAProvider, "data availability provider to store batches with: \""+DAProviderAnyTrust+"\" (used if node.data-availability is enabled) or \""+DAProviderExternal+"\" (node.external-da)")
 	f.Bool(prefix+".disable-dap-fallback-store-data-on-chain", DefaultBatchPosterConfig.DisableDapFallbackStoreDataOnChain, "If unable to batch to DA provider, disable fallback storing data on chain")
 	f.Int(prefix+".max-size", DefaultBatchPosterConfig.MaxSize, "maximum batch size")
 	f.Int(prefix+".max-4844-batch-size", DefaultBatchPosterConfig.Max4844BatchSize, "maximum 4844 blob enabled batch size")
@@ -222,6 +256,7 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Uint64(prefix+".extra-batch-gas", DefaultBatchPosterConfig.ExtraBatchGas, "use this much more gas than estimation says is necessary to post batches")
 	f.Bool(prefix+".post-4844-blobs", DefaultBatchPosterConfig.Post4844Blobs, "if the parent chain supports 4844 blobs and they're well priced, post EIP-4844 blobs")
 	f.Bool(prefix+".ignore-blob-price", DefaultBatchPosterConfig.IgnoreBlobPrice, "if the parent chain supports 4844 blobs and ignore-blob-price is true, post 4844 blobs even if it's not price efficient")
+	BlobFeeTrackerConfigAddOptions(prefix+".blob-fee", f)
 	f.String(prefix+".redis-url", DefaultBatchPosterConfig.RedisUrl, "if non-empty, the Redis URL to store queued transactions in")
 	f.String(prefix+".l1-block-bound", DefaultBatchPosterConfig.L1BlockBound, "only post messages to batches when they're within the max future block/timestamp as of this L1 block tag (\"safe\", \"finalized\", \"latest\", or \"ignore\" to ignore this check)")
 	f.Duration(prefix+".l1-block-bound-bypass", DefaultBatchPosterConfig.L1BlockBoundBypass, "post batches even if not within the layer 1 future bounds if we're within this margin of the max delay")
@@ -230,6 +265,8 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Duration(prefix+".reorg-resistance-margin", DefaultBatchPosterConfig.ReorgResistanceMargin, "do not post batch if its within this duration from layer 1 minimum bounds. Requires l1-block-bound option not be set to \"ignore\"")
 	f.Bool(prefix+".check-batch-correctness", DefaultBatchPosterConfig.CheckBatchCorrectness, "setting this to true will run the batch against an inbox multiplexer and verifies that it produces the correct set of messages")
 	f.Duration(prefix+".max-empty-batch-delay", DefaultBatchPosterConfig.MaxEmptyBatchDelay, "maximum empty batch posting delay, batch poster will only be able to post an empty batch if this time period building a batch has passed")
+	f.Bool(prefix+".dry-run", DefaultBatchPosterConfig.DryRun, "build batches as usual but only log their projected size and cost instead of posting them (doesn't need a wallet, so it can run on a replica)")
+	f.Uint64(prefix+".delayed-messages-window", DefaultBatchPosterConfig.DelayedMessagesWindow, "on chains created with ArbOS 40 or later, acknowledge up to this many consecutive delayed messages with a single batch segment (0 or 1 to acknowledge each separately)")
 	redislock.AddConfigOptions(prefix+".redis-lock", f)
 	dataposter.DataPosterConfigAddOptions(prefix+".data-poster", f, dataposter.DefaultDataPosterConfig)
 	genericconf.WalletConfigAddOptions(prefix+".parent-chain-wallet", f, DefaultBatchPosterConfig.ParentChainWallet.Pathname)
@@ -238,6 +275,7 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 
 var DefaultBatchPosterConfig = BatchPosterConfig{
 	Enable:                             false,
+	DAProvider:                         DAProviderAnyTrust,
 	DisableDapFallbackStoreDataOnChain: false,
 	// This default is overridden for L3 chains in applyChainParameters in cmd/nitro/nitro.go
 	MaxSize: 100000,
@@ -253,6 +291,7 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 	ExtraBatchGas:                  50_000,
 	Post4844Blobs:                  false,
 	IgnoreBlobPrice:                false,
+	BlobFee:                        DefaultBlobFeeTrackerConfig,
 	DataPoster:                     dataposter.DefaultDataPosterConfig,
 	ParentChainWallet:              DefaultBatchPosterL1WalletConfig,
 	L1BlockBound:                   "",
@@ -263,6 +302,8 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 	ReorgResistanceMargin:          10 * time.Minute,
 	CheckBatchCorrectness:          true,
 	MaxEmptyBatchDelay:             3 * 24 * time.Hour,
+	DryRun:                         false,
+	DelayedMessagesWindow:          256,
 }
 
 var DefaultBatchPosterL1WalletConfig = genericconf.WalletConfig{
@@ -275,6 +316,7 @@ var DefaultBatchPosterL1WalletConfig = genericconf.WalletConfig{
 
 var TestBatchPosterConfig = BatchPosterConfig{
 	Enable:                         true,
+	DAProvider:                     DAProviderAnyTrust,
 	MaxSize:                        100000,
 	Max4844BatchSize:               DefaultBatchPosterConfig.Max4844BatchSize,
 	PollInterval:                   time.Millisecond * 10,
@@ -287,6 +329,7 @@ var TestBatchPosterConfig = BatchPosterConfig{
 	ExtraBatchGas:                  10_000,
 	Post4844Blobs:                  false,
 	IgnoreBlobPrice:                false,
+	BlobFee:                        DefaultBlobFeeTrackerConfig,
 	DataPoster:                     dataposter.TestDataPosterConfig,
 	ParentChainWallet:              DefaultBatchPosterL1WalletConfig,
 	L1BlockBound:                   "",
@@ -294,6 +337,7 @@ var TestBatchPosterConfig = BatchPosterConfig{
 	UseAccessLists:                 true,
 	GasEstimateBaseFeeMultipleBips: arbmath.OneInUBips * 3 / 2,
 	CheckBatchCorrectness:          true,
+	DelayedMessagesWindow:          256,
 }
 
 type BatchPosterOpts struct {
@@ -309,6 +353,7 @@ type BatchPosterOpts struct {
 	DAPWriter     daprovider.Writer
 	ParentChainID *big.Int
 	DAPReaders    []daprovider.Reader
+	Pauses        *PauseControls
 }
 
 func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, error) {
@@ -333,7 +378,12 @@ func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, e
 		simpleRedisLockConfig.Key = batchPosterSimpleRedisLockKey
 		return &simpleRedisLockConfig
 	}
-	redisLock, err := redislock.NewSimple(redisClient, redisLockConfigFetcher, func() bool { return opts.SyncMonitor.Synced() })
+	lockClient := redisClient
+	if opts.Config().DryRun {
+		// A dry run never posts, so it mustn't take the lock real batch posters coordinate with
+		lockClient = nil
+	}
+	redisLock, err := redislock.NewSimple(lockClient, redisLockConfigFetcher, func() bool { return opts.SyncMonitor.Synced() })
 	if err != nil {
 		return nil, err
 	}
@@ -352,6 +402,9 @@ func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, e
 		dapWriter:          opts.DAPWriter,
 		redisLock:          redisLock,
 		dapReaders:         opts.DAPReaders,
+		blobFees:           NewBlobFeeTracker(func() *BlobFeeTrackerConfig { return &opts.Config().BlobFee }),
+		dryRun:             opts.Config().DryRun,
+		pauses:             opts.Pauses,
 	}
 	b.messagesPerBatch, err = arbmath.NewMovingAverage[uint64](20)
 	if err != nil {
@@ -596,6 +649,7 @@ func (b *BatchPoster) pollForL1PriceData(ctx context.Context) {
 			}
 			baseFeeGauge.Update(h.BaseFee.Int64())
 			l1GasPrice := h.BaseFee.Uint64()
+			b.blobFees.Observe(h)
 			if h.BlobGasUsed != nil {
 				if h.ExcessBlobGas != nil {
 					blobFeePerByte := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*h.ExcessBlobGas, *h.BlobGasUsed))
@@ -709,6 +763,8 @@ type batchSegments struct {
 	timestamp             uint64
 	blockNum              uint64
 	delayedMsg            uint64
+	delayedWindow         uint64 // the most delayed messages one segment may acknowledge, or 0 for one per segment
+	delayedRun            uint64 // how many delayed messages the last segment acknowledges, or 0 if it isn't one
 	sizeLimit             int
 	recompressionLevel    int
 	newUncompressedSize   int
@@ -729,7 +785,7 @@ type buildingBatch struct {
 	firstUsefulMsg     *arbostypes.MessageWithMetadata
 }
 
-func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64, use4844 bool) *batchSegments {
+func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64, use4844 bool, delayedWindow uint64) *batchSegments {
 	maxSize := config.MaxSize
 	if use4844 {
 		maxSize = config.Max4844BatchSize
@@ -767,6 +823,7 @@ func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog ui
 		recompressionLevel: recompressionLevel,
 		rawSegments:        make([][]byte, 0, 128),
 		delayedMsg:         firstDelayed,
+		delayedWindow:      delayedWindow,
 	}
 }
 
@@ -859,6 +916,7 @@ func (s *batchSegments) addSegment(segment []byte, isHeader bool) (bool, error)
 		return false, s.close()
 	}
 	s.rawSegments = append(s.rawSegments, segment)
+	s.delayedRun = 0
 	if isHeader {
 		s.trailingHeaders++
 	} else {
@@ -901,14 +959,45 @@ func (s *batchSegments) maybeAddDiffSegment(base *uint64, newVal uint64, segment
 }
 
 func (s *batchSegments) addDelayedMessage() (bool, error) {
+	if s.delayedRun > 0 && s.delayedRun < s.delayedWindow {
+		return s.extendDelayedWindow()
+	}
 	segment := []byte{arbstate.BatchSegmentKindDelayedMessages}
 	success, err := s.addSegment(segment, false)
 	if (err == nil) && success {
 		s.delayedMsg += 1
+		s.delayedRun = 1
 	}
 	return success, err
 }
 
+// extendDelayedWindow acknowledges one more delayed message by replacing the last segment with a larger window.
+// The replaced segment stays in the compressed estimate until the batch is recompressed on close, which only
+// overestimates the batch's size.
+func (s *batchSegments) extendDelayedWindow() (bool, error) {
+	if s.isDone {
+		return false, errBatchAlreadyClosed
+	}
+	segment, err := s.prepareIntSegment(s.delayedRun+1, arbstate.BatchSegmentKindDelayedMessagesWindow)
+	if err != nil {
+		return false, err
+	}
+	if err := s.addSegmentToCompressed(segment); err != nil {
+		return false, err
+	}
+	overflow, err := s.testForOverflow(false)
+	if err != nil {
+		return false, err
+	}
+	if overflow {
+		return false, s.close()
+	}
+	s.rawSegments[len(s.rawSegments)-1] = segment
+	s.delayedRun++
+	s.delayedMsg++
+	return true, nil
+}
+
 func (s *batchSegments) AddMessage(msg *arbostypes.MessageWithMetadata) (bool, error) {
 	if s.isDone {
 		return false, errBatchAlreadyClosed
@@ -1104,17 +1193,108 @@ const ethPosBlockTime = 12 * time.Second
 
 var errAttemptLockFailed = errors.New("failed to acquire lock; either another batch poster posted a batch or this node fell behind")
 
+// nextDryRunPosition returns where the next simulated batch starts: after the last simulated batch,
+// unless the batches actually posted have since gone past it.
+func (b *BatchPoster) nextDryRunPosition() (batchPosterPosition, error) {
+	batchCount, err := b.inbox.GetBatchCount()
+	if err != nil {
+		return batchPosterPosition{}, err
+	}
+	position := batchPosterPosition{NextSeqNum: batchCount}
+	if batchCount > 0 {
+		meta, err := b.inbox.GetBatchMetadata(batchCount - 1)
+		if err != nil {
+			return batchPosterPosition{}, err
+		}
+		position.MessageCount = meta.MessageCount
+		position.DelayedMessageCount = meta.DelayedMessageCount
+	}
+	if b.dryRunPosition != nil && b.dryRunPosition.MessageCount > position.MessageCount {
+		simulated := *b.dryRunPosition
+		simulated.NextSeqNum = max(simulated.NextSeqNum, batchCount)
+		return simulated, nil
+	}
+	return position, nil
+}
+
+// reportDryRunBatch logs the projected size and parent chain cost of a batch built in dry-run mode,
+// and moves the simulated position past it.
+func (b *BatchPoster) reportDryRunBatch(ctx context.Context, batchPosition batchPosterPosition, compressedSize int, data []byte, kzgBlobs []kzg4844.Blob) error {
+	latestHeader, err := b.l1Reader.LastHeader(ctx)
+	if err != nil {
+		return err
+	}
+	// The calldata's intrinsic gas, plus the inbox's execution which extra-batch-gas approximates
+	gas := params.TxGas + b.config().ExtraBatchGas
+	for _, c := range data {
+		if c == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	cost := arbmath.BigMulByUint(latestHeader.BaseFee, gas)
+	if len(kzgBlobs) > 0 && latestHeader.ExcessBlobGas != nil && latestHeader.BlobGasUsed != nil {
+		blobFee := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*latestHeader.ExcessBlobGas, *latestHeader.BlobGasUsed))
+		// #nosec G115
+		cost.Add(cost, arbmath.BigMulByUint(blobFee, uint64(len(kzgBlobs))*params.BlobTxBlobGasPerBlob))
+	}
+	da := "calldata"
+	if b.dapWriter != nil {
+		da = "das"
+	} else if b.building.use4844 {
+		da = "blobs"
+	}
+	log.Info(
+		"BatchPoster: dry run batch",
+		"sequenceNumber", batchPosition.NextSeqNum,
+		"from", batchPosition.MessageCount,
+		"to", b.building.msgCount,
+		"prevDelayed", batchPosition.DelayedMessageCount,
+		"currentDelayed", b.building.segments.delayedMsg,
+		"da", da,
+		"compressedSize", compressedSize,
+		"calldataSize", len(data),
+		"numBlobs", len(kzgBlobs),
+		"gas", gas,
+		"baseFee", latestHeader.BaseFee,
+		"projectedCost", cost,
+	)
+	costGwei := arbmath.BigDivByUint(cost, params.GWei).Int64()
+	batchPosterDryRunBatchCounter.Inc(1)
+	batchPosterDryRunBytesCounter.Inc(int64(compressedSize))
+	batchPosterDryRunBlobsCounter.Inc(int64(len(kzgBlobs)))
+	batchPosterDryRunCostGweiCounter.Inc(costGwei)
+	batchPosterDryRunLastCostGweiGauge.Update(costGwei)
+	b.dryRunPosition = &batchPosterPosition{
+		MessageCount:        b.building.msgCount,
+		DelayedMessageCount: b.building.segments.delayedMsg,
+		NextSeqNum:          batchPosition.NextSeqNum + 1,
+	}
+	return nil
+}
+
 func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error) {
 	if b.batchReverted.Load() {
 		return false, fmt.Errorf("batch was reverted, not posting any more batches")
 	}
-	nonce, batchPositionBytes, err := b.dataPoster.GetNextNonceAndMeta(ctx)
-	if err != nil {
-		return false, err
-	}
+	var nonce uint64
+	var batchPositionBytes []byte
 	var batchPosition batchPosterPosition
-	if err := rlp.DecodeBytes(batchPositionBytes, &batchPosition); err != nil {
-		return false, fmt.Errorf("decoding batch position: %w", err)
+	var err error
+	if b.dryRun {
+		batchPosition, err = b.nextDryRunPosition()
+		if err != nil {
+			return false, err
+		}
+	} else {
+		nonce, batchPositionBytes, err = b.dataPoster.GetNextNonceAndMeta(ctx)
+		if err != nil {
+			return false, err
+		}
+		if err := rlp.DecodeBytes(batchPositionBytes, &batchPosition); err != nil {
+			return false, fmt.Errorf("decoding batch position: %w", err)
+		}
 	}
 
 	dbBatchCount, err := b.inbox.GetBatchCount()
@@ -1160,8 +1340,14 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 			}
 		}
 
+		var delayedWindow uint64
+		// Nodes only read delayed message windows on chains created with ArbOS 40 or later
+		if config.DelayedMessagesWindow > 1 && arbstate.DelayedMessagesWindowsEnabled(b.streamer.ChainConfig()) {
+			delayedWindow = config.DelayedMessagesWindow
+		}
+
 		b.building = &buildingBatch{
-			segments:      newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.GetBacklogEstimate(), use4844),
+			segments:      newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.GetBacklogEstimate(), use4844, delayedWindow),
 			msgCount:      batchPosition.MessageCount,
 			startMsgCount: batchPosition.MessageCount,
 			use4844:       use4844,
@@ -1360,6 +1546,13 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		return false, nil
 	}
 
+	if b.building.use4844 && time.Since(firstUsefulMsgTime) < config.MaxDelay && b.blobFees.ShouldWait(time.Since(firstUsefulMsgTime)) {
+		// blobs are expensive compared to recently, so hold the batch back for up to blob-fee.max-wait
+		blobFeeWaitedCounter.Inc(1)
+		log.Debug("waiting for a cheaper blob slot", "forecast", b.blobFees.Forecast(), "waited", time.Since(firstUsefulMsgTime))
+		return false, nil
+	}
+
 	sequencerMsg, err := b.building.segments.CloseAndGetBytes()
 	if err != nil {
 		return false, err
@@ -1370,7 +1563,8 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		return false, nil
 	}
 
-	if b.dapWriter != nil {
+	compressedSize := len(sequencerMsg)
+	if b.dapWriter != nil && !b.dryRun {
 		if !b.redisLock.AttemptLock(ctx) {
 			return false, errAttemptLockFailed
 		}
@@ -1395,6 +1589,12 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		batchPosterDALastSuccessfulActionGauge.Update(time.Now().Unix())
 	}
 
+	postedMsg := sequencerMsg
+	if b.dapWriter != nil && b.dryRun {
+		// The batch isn't stored, so price the certificate the parent chain would get instead
+		postedMsg = bytes.Repeat([]byte{0xff}, dryRunDACertSize)
+	}
+
 	prevMessageCount := batchPosition.MessageCount
 	if b.config().Dangerous.AllowPostingFirstBatchWhenSequencerMessageCountMismatch && !b.postedFirstBatch {
 		// AllowPostingFirstBatchWhenSequencerMessageCountMismatch can be used when the
@@ -1425,7 +1625,7 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		prevMessageCount = 0
 	}
 
-	data, kzgBlobs, err := b.encodeAddBatch(new(big.Int).SetUint64(batchPosition.NextSeqNum), prevMessageCount, b.building.msgCount, sequencerMsg, b.building.segments.delayedMsg, b.building.use4844)
+	data, kzgBlobs, err := b.encodeAddBatch(new(big.Int).SetUint64(batchPosition.NextSeqNum), prevMessageCount, b.building.msgCount, postedMsg, b.building.segments.delayedMsg, b.building.use4844)
 	if err != nil {
 		return false, err
 	}
@@ -1433,16 +1633,20 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		return false, fmt.Errorf("produced %v blobs for batch but a block can only hold %v (compressed batch was %v bytes long)", len(kzgBlobs), params.MaxBlobGasPerBlock/params.BlobTxBlobGasPerBlob, len(sequencerMsg))
 	}
 	accessList := b.accessList(batchPosition.NextSeqNum, b.building.segments.delayedMsg)
-	// On restart, we may be trying to estimate gas for a batch whose successor has
-	// already made it into pending state, if not latest state.
-	// In that case, we might get a revert with `DelayedBackwards()`.
-	// To avoid that, we artificially increase the delayed messages to `lastPotentialMsg.DelayedMessagesRead`.
-	// In theory, this might reduce gas usage, but only by a factor that's already
-	// accounted for in `config.ExtraBatchGas`, as that same factor can appear if a user
-	// posts a new delayed message that we didn't see while gas estimating.
-	gasLimit, err := b.estimateGas(ctx, sequencerMsg, lastPotentialMsg.DelayedMessagesRead, data, kzgBlobs, nonce, accessList)
-	if err != nil {
-		return false, err
+	var gasLimit uint64
+	if !b.dryRun {
+		// On restart, we may be trying to estimate gas for a batch whose successor has
+		// already made it into pending state, if not latest state.
+		// In that case, we might get a revert with `DelayedBackwards()`.
+		// To avoid that, we artificially increase the delayed messages to `lastPotentialMsg.DelayedMessagesRead`.
+		// In theory, this might reduce gas usage, but only by a factor that's already
+		// accounted for in `config.ExtraBatchGas`, as that same factor can appear if a user
+		// posts a new delayed message that we didn't see while gas estimating.
+		// A dry run can't estimate gas, as its sender usually isn't allowed to post batches.
+		gasLimit, err = b.estimateGas(ctx, sequencerMsg, lastPotentialMsg.DelayedMessagesRead, data, kzgBlobs, nonce, accessList)
+		if err != nil {
+			return false, err
+		}
 	}
 	newMeta, err := rlp.EncodeToBytes(batchPosterPosition{
 		MessageCount:        b.building.msgCount,
@@ -1467,7 +1671,7 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		b.building.muxBackend.seqMsg = seqMsg
 		b.building.muxBackend.delayedInboxStart = batchPosition.DelayedMessageCount
 		b.building.muxBackend.SetPositionWithinMessage(0)
-		simMux := arbstate.NewInboxMultiplexer(b.building.muxBackend, batchPosition.DelayedMessageCount, dapReaders, daprovider.KeysetValidate)
+		simMux := arbstate.NewInboxMultiplexer(b.building.muxBackend, batchPosition.DelayedMessageCount, dapReaders, daprovider.KeysetValidate, b.streamer.ChainConfig())
 		log.Debug("Begin checking the correctness of batch against inbox multiplexer", "startMsgSeqNum", batchPosition.MessageCount, "endMsgSeqNum", b.building.msgCount-1)
 		for i := batchPosition.MessageCount; i < b.building.msgCount; i++ {
 			msg, err := simMux.Pop(ctx)
@@ -1486,31 +1690,38 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		log.Debug("Successfully checked that the batch produces correct messages when ran through inbox multiplexer", "sequenceNumber", batchPosition.NextSeqNum)
 	}
 
-	tx, err := b.dataPoster.PostTransaction(ctx,
-		firstUsefulMsgTime,
-		nonce,
-		newMeta,
-		b.seqInboxAddr,
-		data,
-		gasLimit,
-		new(big.Int),
-		kzgBlobs,
-		accessList,
-	)
-	if err != nil {
-		return false, err
+	var tx *types.Transaction
+	if b.dryRun {
+		if err := b.reportDryRunBatch(ctx, batchPosition, compressedSize, data, kzgBlobs); err != nil {
+			return false, err
+		}
+	} else {
+		tx, err = b.dataPoster.PostTransaction(ctx,
+			firstUsefulMsgTime,
+			nonce,
+			newMeta,
+			b.seqInboxAddr,
+			data,
+			gasLimit,
+			new(big.Int),
+			kzgBlobs,
+			accessList,
+		)
+		if err != nil {
+			return false, err
+		}
+		b.postedFirstBatch = true
+		log.Info(
+			"BatchPoster: batch sent",
+			"sequenceNumber", batchPosition.NextSeqNum,
+			"from", batchPosition.MessageCount,
+			"to", b.building.msgCount,
+			"prevDelayed", batchPosition.DelayedMessageCount,
+			"currentDelayed", b.building.segments.delayedMsg,
+			"totalSegments", len(b.building.segments.rawSegments),
+			"numBlobs", len(kzgBlobs),
+		)
 	}
-	b.postedFirstBatch = true
-	log.Info(
-		"BatchPoster: batch sent",
-		"sequenceNumber", batchPosition.NextSeqNum,
-		"from", batchPosition.MessageCount,
-		"to", b.building.msgCount,
-		"prevDelayed", batchPosition.DelayedMessageCount,
-		"currentDelayed", b.building.segments.delayedMsg,
-		"totalSegments", len(b.building.segments.rawSegments),
-		"numBlobs", len(kzgBlobs),
-	)
 
 	recentlyHitL1Bounds := time.Since(b.lastHitL1Bounds) < config.PollInterval*3
 	postedMessages := b.building.msgCount - batchPosition.MessageCount
@@ -1563,7 +1774,7 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 	b.building = nil
 
 	// If we aren't queueing up transactions, wait for the receipt before moving on to the next batch.
-	if config.DataPoster.UseNoOpStorage {
+	if config.DataPoster.UseNoOpStorage && tx != nil {
 		receipt, err := b.l1Reader.WaitForTxApproval(ctx, tx)
 		if err != nil {
 			return false, fmt.Errorf("error waiting for tx receipt: %w", err)
@@ -1579,10 +1790,16 @@ func (b *BatchPoster) GetBacklogEstimate() uint64 {
 }
 
 func (b *BatchPoster) Start(ctxIn context.Context) {
-	b.dataPoster.Start(ctxIn)
+	if b.dryRun {
+		log.Warn("batch poster is in dry-run mode, batches will be logged but not posted")
+	} else {
+		b.dataPoster.Start(ctxIn)
+	}
 	b.redisLock.Start(ctxIn)
 	b.StopWaiter.Start(ctxIn, b)
-	b.LaunchThread(b.pollForReverts)
+	if !b.dryRun {
+		b.LaunchThread(b.pollForReverts)
+	}
 	b.LaunchThread(b.pollForL1PriceData)
 	commonEphemeralErrorHandler := util.NewEphemeralErrorHandler(time.Minute, "", 0)
 	exceedMaxMempoolSizeEphemeralErrorHandler := util.NewEphemeralErrorHandler(5*time.Minute, dataposter.ErrExceedsMaxMempoolSize.Error(), time.Minute)
@@ -1614,6 +1831,12 @@ func (b *BatchPoster) Start(ctxIn context.Context) {
 				batchPosterWalletBalance.Update(arbmath.BalancePerEther(walletBalance))
 			}
 		}
+		if b.pauses.Paused(PauseBatchPoster) {
+			log.Debug("Not posting batches right now because the batch poster is paused")
+			b.building = nil
+			resetAllEphemeralErrs()
+			return b.config().PollInterval
+		}
 		couldLock, err := b.redisLock.CouldAcquireLock(ctx)
 		if err != nil {
 			log.Warn("Error checking if we could acquire redis lock", "err", err)
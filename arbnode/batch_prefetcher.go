@@ -0,0 +1,184 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	batchPrefetchHitCounter  = metrics.NewRegisteredCounter("arb/inbox/prefetch/hit", nil)
+	batchPrefetchMissCounter = metrics.NewRegisteredCounter("arb/inbox/prefetch/miss", nil)
+
+	errNoDAPayload = errors.New("batch has no data availability payload")
+)
+
+// How many batches per worker may be fetched ahead of the one the multiplexer is reading
+const batchPrefetchLookahead = 2
+
+// batchPrefetcher fetches the data of sequencer batches from the parent chain, and recovers
+// their payloads from any data availability provider, on several threads ahead of the inbox
+// multiplexer. The multiplexer still parses the batches and the tracker commits their messages
+// strictly in order, it just finds the slow parts already done.
+type batchPrefetcher struct {
+	// Both maps are only written when the prefetcher is created
+	serialized map[uint64]*containers.Promise[[]byte]
+	payloads   map[uint64]*containers.Promise[[]byte]
+	// Holds a slot for each batch started but not yet consumed, so fetches can't run
+	// arbitrarily far ahead of the multiplexer and pile up payloads in memory
+	window chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newBatchPrefetcher(ctx context.Context, client *ethclient.Client, batches []*SequencerInboxBatch, dapReaders []daprovider.Reader, workers int) *batchPrefetcher {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &batchPrefetcher{
+		serialized: make(map[uint64]*containers.Promise[[]byte], len(batches)),
+		payloads:   make(map[uint64]*containers.Promise[[]byte], len(batches)),
+		window:     make(chan struct{}, workers*batchPrefetchLookahead),
+		cancel:     cancel,
+	}
+	for _, batch := range batches {
+		serialized := containers.NewPromise[[]byte](nil)
+		payload := containers.NewPromise[[]byte](nil)
+		p.serialized[batch.SequenceNumber] = &serialized
+		p.payloads[batch.SequenceNumber] = &payload
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		// Batches are started in order so the ones the multiplexer needs first are ready first
+		sem := make(chan struct{}, workers)
+		for _, batch := range batches {
+			select {
+			case p.window <- struct{}{}:
+			case <-ctx.Done():
+				p.abort(batch.SequenceNumber, ctx.Err())
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				p.abort(batch.SequenceNumber, ctx.Err())
+				continue
+			}
+			p.wg.Add(1)
+			go func(batch *SequencerInboxBatch) {
+				defer p.wg.Done()
+				defer func() { <-sem }()
+				p.prefetch(ctx, client, batch, dapReaders)
+			}(batch)
+		}
+	}()
+	return p
+}
+
+func (p *batchPrefetcher) abort(seqNum uint64, err error) {
+	p.serialized[seqNum].ProduceError(err)
+	p.payloads[seqNum].ProduceError(err)
+}
+
+func (p *batchPrefetcher) prefetch(ctx context.Context, client *ethclient.Client, batch *SequencerInboxBatch, dapReaders []daprovider.Reader) {
+	data, err := batch.Serialize(ctx, client)
+	if err != nil {
+		p.abort(batch.SequenceNumber, err)
+		return
+	}
+	p.serialized[batch.SequenceNumber].Produce(data)
+	// Mirrors how parseSequencerMessage picks the reader
+	if len(data) <= 40 {
+		p.payloads[batch.SequenceNumber].ProduceError(errNoDAPayload)
+		return
+	}
+	for _, dapReader := range dapReaders {
+		if dapReader != nil && dapReader.IsValidHeaderByte(data[40]) {
+			payload, err := dapReader.RecoverPayloadFromBatch(ctx, batch.SequenceNumber, batch.BlockHash, data, nil, true)
+			if err != nil {
+				p.payloads[batch.SequenceNumber].ProduceError(err)
+			} else {
+				p.payloads[batch.SequenceNumber].Produce(payload)
+			}
+			return
+		}
+	}
+	p.payloads[batch.SequenceNumber].ProduceError(errNoDAPayload)
+}
+
+// awaitSerialized waits for the batch's data to be fetched, after which it's safe to call Serialize on it.
+func (p *batchPrefetcher) awaitSerialized(ctx context.Context, seqNum uint64) {
+	if promise, ok := p.serialized[seqNum]; ok {
+		_, _ = promise.Await(ctx)
+	}
+}
+
+// consumed tells the prefetcher the multiplexer is done with a batch, letting it start another.
+func (p *batchPrefetcher) consumed() {
+	// The slot may be missing if the batch was aborted before it started
+	select {
+	case <-p.window:
+	default:
+	}
+}
+
+func (p *batchPrefetcher) awaitPayload(ctx context.Context, seqNum uint64) ([]byte, bool) {
+	promise, ok := p.payloads[seqNum]
+	if !ok {
+		return nil, false
+	}
+	payload, err := promise.Await(ctx)
+	return payload, err == nil
+}
+
+// stop cancels any prefetching still in progress and waits for it to exit.
+func (p *batchPrefetcher) stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *batchPrefetcher) wrapReaders(dapReaders []daprovider.Reader) []daprovider.Reader {
+	wrapped := make([]daprovider.Reader, len(dapReaders))
+	for i, dapReader := range dapReaders {
+		if dapReader != nil {
+			wrapped[i] = &prefetchedReader{Reader: dapReader, prefetcher: p}
+		}
+	}
+	return wrapped
+}
+
+// prefetchedReader returns payloads recovered by the prefetcher, falling back
+// to the underlying reader if the prefetch failed or can't be used.
+type prefetchedReader struct {
+	daprovider.Reader
+	prefetcher *batchPrefetcher
+}
+
+func (r *prefetchedReader) RecoverPayloadFromBatch(
+	ctx context.Context,
+	batchNum uint64,
+	batchBlockHash common.Hash,
+	sequencerMsg []byte,
+	preimageRecorder daprovider.PreimageRecorder,
+	validateSeqMsg bool,
+) ([]byte, error) {
+	// The prefetch didn't record preimages and always validated
+	if preimageRecorder == nil && validateSeqMsg {
+		if payload, ok := r.prefetcher.awaitPayload(ctx, batchNum); ok {
+			batchPrefetchHitCounter.Inc(1)
+			return payload, nil
+		}
+	}
+	batchPrefetchMissCounter.Inc(1)
+	return r.Reader.RecoverPayloadFromBatch(ctx, batchNum, batchBlockHash, sequencerMsg, preimageRecorder, validateSeqMsg)
+}
@@ -0,0 +1,117 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+const testDAHeaderByte = 0x80
+
+type countingDAReader struct {
+	calls atomic.Int64
+}
+
+func (r *countingDAReader) IsValidHeaderByte(headerByte byte) bool {
+	return headerByte == testDAHeaderByte
+}
+
+func (r *countingDAReader) RecoverPayloadFromBatch(
+	_ context.Context,
+	batchNum uint64,
+	_ common.Hash,
+	_ []byte,
+	_ daprovider.PreimageRecorder,
+	_ bool,
+) ([]byte, error) {
+	r.calls.Add(1)
+	return []byte{byte(batchNum)}, nil
+}
+
+func testPrefetchBatches(count uint64) []*SequencerInboxBatch {
+	var batches []*SequencerInboxBatch
+	for i := uint64(0); i < count; i++ {
+		serialized := make([]byte, 41)
+		serialized[40] = testDAHeaderByte
+		batches = append(batches, &SequencerInboxBatch{
+			SequenceNumber: i,
+			serialized:     serialized,
+		})
+	}
+	return batches
+}
+
+func TestBatchPrefetcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	batches := testPrefetchBatches(10)
+	// The last batch has no DA payload, so there's nothing to prefetch for it
+	batches[9].serialized = make([]byte, 40)
+
+	reader := &countingDAReader{}
+	prefetcher := newBatchPrefetcher(ctx, nil, batches, []daprovider.Reader{reader}, 3)
+	defer prefetcher.stop()
+	readers := prefetcher.wrapReaders([]daprovider.Reader{reader})
+
+	for _, batch := range batches[:9] {
+		prefetcher.awaitSerialized(ctx, batch.SequenceNumber)
+		payload, err := readers[0].RecoverPayloadFromBatch(ctx, batch.SequenceNumber, common.Hash{}, batch.serialized, nil, true)
+		Require(t, err)
+		if !bytes.Equal(payload, []byte{byte(batch.SequenceNumber)}) {
+			Fail(t, "unexpected payload", payload, "for batch", batch.SequenceNumber)
+		}
+		prefetcher.consumed()
+	}
+	if calls := reader.calls.Load(); calls != 9 {
+		Fail(t, "expected each payload to be recovered once but got", calls, "calls")
+	}
+
+	// Recording preimages always goes to the underlying reader
+	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	_, err := readers[0].RecoverPayloadFromBatch(ctx, 0, common.Hash{}, batches[0].serialized, daprovider.RecordPreimagesTo(preimages), true)
+	Require(t, err)
+	if calls := reader.calls.Load(); calls != 10 {
+		Fail(t, "expected recording to bypass the prefetched payload")
+	}
+}
+
+func TestBatchPrefetcherLookahead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workers := 2
+	lookahead := int64(workers * batchPrefetchLookahead)
+	batches := testPrefetchBatches(20)
+	reader := &countingDAReader{}
+	prefetcher := newBatchPrefetcher(ctx, nil, batches, []daprovider.Reader{reader}, workers)
+	defer prefetcher.stop()
+
+	waitForCalls := func(expected int64) {
+		t.Helper()
+		for i := 0; i < 100 && reader.calls.Load() < expected; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Give the prefetcher a chance to run past the window if it's going to
+		time.Sleep(50 * time.Millisecond)
+		if calls := reader.calls.Load(); calls != expected {
+			Fail(t, "expected", expected, "batches to be prefetched but got", calls)
+		}
+	}
+
+	waitForCalls(lookahead)
+	for i := int64(1); i <= 3; i++ {
+		prefetcher.consumed()
+		waitForCalls(lookahead + i)
+	}
+}
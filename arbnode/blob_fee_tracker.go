@@ -0,0 +1,205 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+var (
+	blobFeeEwmaGauge     = metrics.NewRegisteredGauge("arb/batchposter/blobfee/ewma", nil)
+	blobFeeMedianGauge   = metrics.NewRegisteredGauge("arb/batchposter/blobfee/median", nil)
+	blobFeeWaitedCounter = metrics.NewRegisteredCounter("arb/batchposter/blobfee/waited", nil)
+)
+
+// BlobFeeTrackerConfig configures the model of recent blob prices, and how long the batch poster may hold
+// a blob batch back in the hope of a cheaper slot.
+type BlobFeeTrackerConfig struct {
+	Window         uint64        `koanf:"window"`
+	EwmaHalfLife   uint64        `koanf:"ewma-half-life" reload:"hot"`
+	MaxWait        time.Duration `koanf:"max-wait" reload:"hot"`
+	WaitPercentile uint64        `koanf:"wait-percentile" reload:"hot"`
+	MinSamples     uint64        `koanf:"min-samples" reload:"hot"`
+}
+
+var DefaultBlobFeeTrackerConfig = BlobFeeTrackerConfig{
+	Window:         300,
+	EwmaHalfLife:   25,
+	MaxWait:        0,
+	WaitPercentile: 25,
+	MinSamples:     30,
+}
+
+func BlobFeeTrackerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".window", DefaultBlobFeeTrackerConfig.Window, "number of recent parent chain blocks to model blob prices over")
+	f.Uint64(prefix+".ewma-half-life", DefaultBlobFeeTrackerConfig.EwmaHalfLife, "half-life in parent chain blocks of the exponentially weighted moving average of the blob price")
+	f.Duration(prefix+".max-wait", DefaultBlobFeeTrackerConfig.MaxWait, "how long to hold back a blob batch while the blob price is above wait-percentile of the window (0 = never wait)")
+	f.Uint64(prefix+".wait-percentile", DefaultBlobFeeTrackerConfig.WaitPercentile, "percentile of the window's blob prices a blob batch is held back for")
+	f.Uint64(prefix+".min-samples", DefaultBlobFeeTrackerConfig.MinSamples, "minimum number of observed blocks before blob batches are held back")
+}
+
+func (c *BlobFeeTrackerConfig) Validate() error {
+	if c.Window == 0 {
+		return errors.New("blob fee window must be positive")
+	}
+	if c.EwmaHalfLife == 0 {
+		return errors.New("blob fee ewma-half-life must be positive")
+	}
+	if c.WaitPercentile > 100 {
+		return errors.New("blob fee wait-percentile must be at most 100")
+	}
+	return nil
+}
+
+// BlobFeeForecast summarizes recent blob prices in wei per usable byte of blob space.
+type BlobFeeForecast struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	Samples     int    `json:"samples"`
+	Current     uint64 `json:"current"`
+	Ewma        uint64 `json:"ewma"`
+	P10         uint64 `json:"p10"`
+	P25         uint64 `json:"p25"`
+	P50         uint64 `json:"p50"`
+	P75         uint64 `json:"p75"`
+	P90         uint64 `json:"p90"`
+}
+
+type blobFeeSample struct {
+	blockNumber uint64
+	feePerByte  uint64
+}
+
+// BlobFeeTracker keeps a window of the blob price over recent parent chain blocks.
+type BlobFeeTracker struct {
+	config  func() *BlobFeeTrackerConfig
+	mutex   sync.Mutex
+	samples []blobFeeSample // ring buffer of the window
+	next    int
+	ewma    float64
+}
+
+func NewBlobFeeTracker(config func() *BlobFeeTrackerConfig) *BlobFeeTracker {
+	return &BlobFeeTracker{
+		config:  config,
+		samples: make([]blobFeeSample, 0, config().Window),
+	}
+}
+
+// blobFeePerByte is the blob price a transaction included in the block after the header would pay.
+func blobFeePerByte(header *types.Header) (uint64, bool) {
+	if header.ExcessBlobGas == nil || header.BlobGasUsed == nil {
+		return 0, false
+	}
+	fee := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*header.ExcessBlobGas, *header.BlobGasUsed))
+	fee.Mul(fee, blobTxBlobGasPerBlob)
+	fee.Div(fee, usableBytesInBlob)
+	return arbmath.BigToUintSaturating(fee), true
+}
+
+// Observe records the blob price following a parent chain header.
+func (t *BlobFeeTracker) Observe(header *types.Header) {
+	fee, ok := blobFeePerByte(header)
+	if !ok {
+		return
+	}
+	t.observe(header.Number.Uint64(), fee)
+}
+
+func (t *BlobFeeTracker) observe(blockNumber, feePerByte uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	sample := blobFeeSample{blockNumber, feePerByte}
+	if last, ok := t.latest(); ok && blockNumber <= last.blockNumber {
+		// A reorg or a repeated header replaces the latest sample rather than adding to the window
+		t.samples[(t.next+len(t.samples)-1)%len(t.samples)] = sample
+		return
+	}
+	if len(t.samples) == 0 {
+		t.ewma = float64(feePerByte)
+	} else {
+		alpha := 1 - math.Exp2(-1/float64(t.config().EwmaHalfLife))
+		t.ewma += alpha * (float64(feePerByte) - t.ewma)
+	}
+	if len(t.samples) < cap(t.samples) {
+		t.samples = append(t.samples, sample)
+	} else {
+		t.samples[t.next] = sample
+		t.next = (t.next + 1) % len(t.samples)
+	}
+	blobFeeEwmaGauge.Update(arbmath.SaturatingCast[int64](uint64(t.ewma)))
+	blobFeeMedianGauge.Update(arbmath.SaturatingCast[int64](t.percentile(50)))
+}
+
+func (t *BlobFeeTracker) latest() (blobFeeSample, bool) {
+	if len(t.samples) == 0 {
+		return blobFeeSample{}, false
+	}
+	return t.samples[(t.next+len(t.samples)-1)%len(t.samples)], true
+}
+
+func (t *BlobFeeTracker) percentile(p uint64) uint64 {
+	if len(t.samples) == 0 {
+		return 0
+	}
+	fees := make([]uint64, 0, len(t.samples))
+	for _, sample := range t.samples {
+		fees = append(fees, sample.feePerByte)
+	}
+	slices.Sort(fees)
+	return fees[p*uint64(len(fees)-1)/100]
+}
+
+func (t *BlobFeeTracker) Forecast() BlobFeeForecast {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	last, _ := t.latest()
+	return BlobFeeForecast{
+		BlockNumber: last.blockNumber,
+		Samples:     len(t.samples),
+		Current:     last.feePerByte,
+		Ewma:        uint64(t.ewma),
+		P10:         t.percentile(10),
+		P25:         t.percentile(25),
+		P50:         t.percentile(50),
+		P75:         t.percentile(75),
+		P90:         t.percentile(90),
+	}
+}
+
+// ShouldWait reports whether a blob batch first ready age ago should be held back for a cheaper slot.
+func (t *BlobFeeTracker) ShouldWait(age time.Duration) bool {
+	config := t.config()
+	if config.MaxWait <= 0 || age >= config.MaxWait {
+		return false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	last, ok := t.latest()
+	if !ok || uint64(len(t.samples)) < config.MinSamples {
+		return false
+	}
+	return last.feePerByte > t.percentile(config.WaitPercentile)
+}
+
+type BlobFeeAPI struct {
+	tracker *BlobFeeTracker
+}
+
+// BlobFeeForecast returns the batch poster's model of recent blob prices.
+func (a *BlobFeeAPI) BlobFeeForecast(ctx context.Context) (BlobFeeForecast, error) {
+	return a.tracker.Forecast(), nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlobFeeTrackerWindow(t *testing.T) {
+	config := DefaultBlobFeeTrackerConfig
+	config.Window = 10
+	tracker := NewBlobFeeTracker(func() *BlobFeeTrackerConfig { return &config })
+
+	for i := uint64(1); i <= 20; i++ {
+		tracker.observe(i, i*100)
+	}
+	forecast := tracker.Forecast()
+	if forecast.Samples != 10 || forecast.BlockNumber != 20 || forecast.Current != 2000 {
+		Fail(t, "unexpected window", forecast)
+	}
+	if forecast.P10 != 1100 || forecast.P50 != 1500 || forecast.P90 != 1900 {
+		Fail(t, "unexpected percentiles", forecast)
+	}
+	if forecast.Ewma <= 100 || forecast.Ewma >= forecast.P50 {
+		Fail(t, "ewma should lag behind a rising price", forecast)
+	}
+
+	// A repeated block replaces the latest sample
+	tracker.observe(20, 100)
+	forecast = tracker.Forecast()
+	if forecast.Samples != 10 || forecast.Current != 100 || forecast.P10 != 100 {
+		Fail(t, "repeated block wasn't replaced", forecast)
+	}
+}
+
+func TestBlobFeeTrackerShouldWait(t *testing.T) {
+	config := DefaultBlobFeeTrackerConfig
+	config.MinSamples = 5
+	tracker := NewBlobFeeTracker(func() *BlobFeeTrackerConfig { return &config })
+
+	for i := uint64(1); i <= 9; i++ {
+		tracker.observe(i, 100)
+	}
+	tracker.observe(10, 500)
+	if tracker.ShouldWait(time.Second) {
+		Fail(t, "waited without a max wait configured")
+	}
+	config.MaxWait = time.Minute
+	if !tracker.ShouldWait(time.Second) {
+		Fail(t, "didn't wait out a price spike")
+	}
+	if tracker.ShouldWait(time.Minute) {
+		Fail(t, "waited past the max wait")
+	}
+	tracker.observe(11, 100)
+	if tracker.ShouldWait(time.Second) {
+		Fail(t, "waited at a typical price")
+	}
+	config.MinSamples = 20
+	tracker.observe(12, 500)
+	if tracker.ShouldWait(time.Second) {
+		Fail(t, "waited without enough samples")
+	}
+}
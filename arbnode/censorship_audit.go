@@ -0,0 +1,492 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	censorshipAuditReportsCounter    = metrics.NewRegisteredCounter("arb/censorshipaudit/reports", nil)
+	censorshipAuditViolationsCounter = metrics.NewRegisteredCounter("arb/censorshipaudit/violations", nil)
+)
+
+type CensorshipAuditConfig struct {
+	Enable        bool          `koanf:"enable"`
+	Interval      time.Duration `koanf:"interval" reload:"hot"`
+	DelayedWindow time.Duration `koanf:"delayed-window" reload:"hot"`
+	TxWindow      time.Duration `koanf:"tx-window" reload:"hot"`
+	TxSampleRate  float64       `koanf:"tx-sample-rate" reload:"hot"`
+	MaxSamples    int           `koanf:"max-samples" reload:"hot"`
+	PublishFeed   bool          `koanf:"publish-feed" reload:"hot"`
+	KeepReports   int           `koanf:"keep-reports"`
+}
+
+type CensorshipAuditConfigFetcher func() *CensorshipAuditConfig
+
+var DefaultCensorshipAuditConfig = CensorshipAuditConfig{
+	Enable:        false,
+	Interval:      time.Hour,
+	DelayedWindow: time.Hour,
+	TxWindow:      5 * time.Second,
+	TxSampleRate:  0.01,
+	MaxSamples:    100,
+	PublishFeed:   true,
+	KeepReports:   24,
+}
+
+func CensorshipAuditConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCensorshipAuditConfig.Enable, "have the sequencer audit how promptly it sequences delayed messages and transactions, publishing a report signed with the batch poster's key through the arb_sequencerAudits RPC method and the feed")
+	f.Duration(prefix+".interval", DefaultCensorshipAuditConfig.Interval, "how often to publish a report, covering what was observed since the previous one")
+	f.Duration(prefix+".delayed-window", DefaultCensorshipAuditConfig.DelayedWindow, "delayed messages must be sequenced within this long of being posted to the parent chain")
+	f.Duration(prefix+".tx-window", DefaultCensorshipAuditConfig.TxWindow, "transactions must be included in a block within this long of being submitted")
+	f.Float64(prefix+".tx-sample-rate", DefaultCensorshipAuditConfig.TxSampleRate, "fraction of transactions submitted to this sequencer that are audited")
+	f.Int(prefix+".max-samples", DefaultCensorshipAuditConfig.MaxSamples, "maximum delayed messages and maximum transactions listed individually in each report, chosen at random from those audited")
+	f.Bool(prefix+".publish-feed", DefaultCensorshipAuditConfig.PublishFeed, "also send reports to feed clients")
+	f.Int(prefix+".keep-reports", DefaultCensorshipAuditConfig.KeepReports, "how many of the most recent reports are served over RPC")
+}
+
+func (c *CensorshipAuditConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval <= 0 || c.DelayedWindow <= 0 || c.TxWindow <= 0 {
+		return errors.New("censorship audit interval and windows must be positive")
+	}
+	if c.TxSampleRate < 0 || c.TxSampleRate > 1 {
+		return fmt.Errorf("censorship audit tx-sample-rate %v must be between 0 and 1", c.TxSampleRate)
+	}
+	if c.MaxSamples < 0 {
+		return errors.New("censorship audit max-samples can't be negative")
+	}
+	if c.KeepReports < 1 {
+		return errors.New("censorship audit keep-reports must be positive")
+	}
+	return nil
+}
+
+const CensorshipAuditFormatVersion = 1
+
+// Outcomes of an audited transaction
+const (
+	TxOutcomeIncluded = "included"
+	TxOutcomeRejected = "rejected"
+	TxOutcomeTimedOut = "timed-out"
+)
+
+type CensorshipAuditDelayedSample struct {
+	Index            uint64 `json:"index"`
+	ParentChainBlock uint64 `json:"parentChainBlock"`
+	PostedAt         uint64 `json:"postedAt"`    // unix seconds, from the parent chain
+	SequencedAt      uint64 `json:"sequencedAt"` // unix seconds
+	OnTime           bool   `json:"onTime"`
+}
+
+type CensorshipAuditTxSample struct {
+	Hash        common.Hash `json:"hash"`
+	SubmittedAt uint64      `json:"submittedAt"` // unix milliseconds
+	LatencyMs   uint64      `json:"latencyMs"`
+	Outcome     string      `json:"outcome"`
+	Error       string      `json:"error,omitempty"` // why the transaction was rejected
+}
+
+// CensorshipAuditReport is what the sequencer observed over a period. Every delayed message sequenced and
+// every audited transaction is counted, and a random subset is listed so users can check them on chain.
+type CensorshipAuditReport struct {
+	FormatVersion uint64               `json:"formatVersion"`
+	ChainID       uint64               `json:"chainId"`
+	Start         uint64               `json:"start"` // unix seconds
+	End           uint64               `json:"end"`
+	MsgCount      arbutil.MessageIndex `json:"msgCount"` // when the report was made
+	// The policy windows, in seconds and milliseconds
+	DelayedWindow uint64 `json:"delayedWindow"`
+	TxWindow      uint64 `json:"txWindow"`
+
+	DelayedSequenced     uint64                         `json:"delayedSequenced"`
+	DelayedLate          uint64                         `json:"delayedLate"`
+	DelayedPending       uint64                         `json:"delayedPending"`
+	DelayedPendingLate   uint64                         `json:"delayedPendingLate"` // waiting longer than the window
+	OldestPendingDelayed *uint64                        `json:"oldestPendingDelayed,omitempty"`
+	DelayedSamples       []CensorshipAuditDelayedSample `json:"delayedSamples"`
+
+	TxsAudited  uint64                    `json:"txsAudited"`
+	TxsIncluded uint64                    `json:"txsIncluded"`
+	TxsLate     uint64                    `json:"txsLate"` // included, but after the window
+	TxsRejected uint64                    `json:"txsRejected"`
+	TxsTimedOut uint64                    `json:"txsTimedOut"`
+	TxSamples   []CensorshipAuditTxSample `json:"txSamples"`
+
+	Violations []string `json:"violations"`
+	Compliant  bool     `json:"compliant"`
+}
+
+// OpenSequencerAudit checks a published report was signed by its claimed signer and decodes it.
+// Callers should also check the signer is the chain's sequencer.
+func OpenSequencerAudit(audit *m.SequencerAuditMessage) (*CensorshipAuditReport, error) {
+	pubKey, err := crypto.SigToPub(crypto.Keccak256(audit.Report), audit.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if signer := crypto.PubkeyToAddress(*pubKey); signer != audit.Signer {
+		return nil, fmt.Errorf("report is signed by %v, not the claimed %v", signer, audit.Signer)
+	}
+	var report CensorshipAuditReport
+	if err := json.Unmarshal(audit.Report, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// auditReservoir keeps a uniformly random subset of the samples added to it.
+type auditReservoir[T any] struct {
+	samples []T
+	seen    uint64
+}
+
+func (r *auditReservoir[T]) add(sample T, max int) {
+	r.seen++
+	if len(r.samples) < max {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	// #nosec G115
+	if i := rand.Int63n(int64(r.seen)); i < int64(max) {
+		r.samples[i] = sample
+	}
+}
+
+type censorshipAuditPeriod struct {
+	start       time.Time
+	delayed     auditReservoir[CensorshipAuditDelayedSample]
+	delayedLate uint64
+	txs         auditReservoir[CensorshipAuditTxSample]
+	txsIncluded uint64
+	txsLate     uint64
+	txsRejected uint64
+	txsTimedOut uint64
+}
+
+type censorshipAuditInbox interface {
+	GetDelayedCount() (uint64, error)
+	GetDelayedMessage(ctx context.Context, seqNum uint64) (*arbostypes.L1IncomingMessage, error)
+}
+
+type sequencerAuditPublisher interface {
+	PublishSequencerAudit(audit *m.SequencerAuditMessage)
+}
+
+// CensorshipAudit has the sequencer check its own record against its inclusion policy: delayed messages
+// sequenced within a window of being posted, and transactions submitted to it included within a window.
+// Reports are signed with the feed's signing key, so users get evidence of non-censorship straight from
+// the sequencer rather than needing to monitor it.
+type CensorshipAudit struct {
+	stopwaiter.StopWaiter
+	config      CensorshipAuditConfigFetcher
+	chainId     uint64
+	inbox       censorshipAuditInbox
+	delayedRead func() (uint64, error)
+	msgCount    func() (arbutil.MessageIndex, error)
+	chosen      func() bool
+	signer      signature.DataSignerFunc
+	signerAddr  common.Address
+	feed        sequencerAuditPublisher // nil without a feed
+
+	mutex   sync.Mutex
+	period  censorshipAuditPeriod
+	reports []*m.SequencerAuditMessage // oldest first
+}
+
+func NewCensorshipAudit(
+	config CensorshipAuditConfigFetcher,
+	chainId uint64,
+	inbox censorshipAuditInbox,
+	delayedRead func() (uint64, error),
+	msgCount func() (arbutil.MessageIndex, error),
+	chosen func() bool,
+	signer signature.DataSignerFunc,
+	signerAddr common.Address,
+	feed sequencerAuditPublisher,
+) *CensorshipAudit {
+	return &CensorshipAudit{
+		config:      config,
+		chainId:     chainId,
+		inbox:       inbox,
+		delayedRead: delayedRead,
+		msgCount:    msgCount,
+		chosen:      chosen,
+		signer:      signer,
+		signerAddr:  signerAddr,
+		feed:        feed,
+		period:      censorshipAuditPeriod{start: time.Now()},
+	}
+}
+
+// DelayedSequenced records a delayed message being sequenced. A nil CensorshipAudit records nothing.
+func (a *CensorshipAudit) DelayedSequenced(index uint64, msg *arbostypes.L1IncomingMessage) {
+	if a == nil {
+		return
+	}
+	config := a.config()
+	now := time.Now()
+	// #nosec G115
+	posted := time.Unix(int64(msg.Header.Timestamp), 0)
+	sample := CensorshipAuditDelayedSample{
+		Index:            index,
+		ParentChainBlock: msg.Header.BlockNumber,
+		PostedAt:         msg.Header.Timestamp,
+		// #nosec G115
+		SequencedAt: uint64(now.Unix()),
+		OnTime:      now.Sub(posted) <= config.DelayedWindow,
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.period.delayed.add(sample, config.MaxSamples)
+	if !sample.OnTime {
+		a.period.delayedLate++
+	}
+}
+
+// TxOutcome records what happened to a transaction submitted to this sequencer, if it's sampled.
+func (a *CensorshipAudit) TxOutcome(tx *types.Transaction, submitted time.Time, err error) {
+	config := a.config()
+	if rand.Float64() >= config.TxSampleRate {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		// The submitter gave up waiting, so there's nothing to judge
+		return
+	}
+	latency := time.Since(submitted)
+	sample := CensorshipAuditTxSample{
+		Hash: tx.Hash(),
+		// #nosec G115
+		SubmittedAt: uint64(submitted.UnixMilli()),
+		// #nosec G115
+		LatencyMs: uint64(latency.Milliseconds()),
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	switch {
+	case err == nil:
+		sample.Outcome = TxOutcomeIncluded
+		a.period.txsIncluded++
+		if latency > config.TxWindow {
+			a.period.txsLate++
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		sample.Outcome = TxOutcomeTimedOut
+		a.period.txsTimedOut++
+	default:
+		sample.Outcome = TxOutcomeRejected
+		sample.Error = err.Error()
+		a.period.txsRejected++
+	}
+	a.period.txs.add(sample, config.MaxSamples)
+}
+
+// pendingDelayed counts the delayed messages not yet sequenced, and how many of them are past the window.
+func (a *CensorshipAudit) pendingDelayed(ctx context.Context, report *CensorshipAuditReport, now time.Time, window time.Duration) error {
+	count, err := a.inbox.GetDelayedCount()
+	if err != nil {
+		return err
+	}
+	read, err := a.delayedRead()
+	if err != nil {
+		return err
+	}
+	if read >= count {
+		return nil
+	}
+	report.DelayedPending = count - read
+	// Delayed messages are in the order they were posted, so the late ones come first
+	for index := read; index < count; index++ {
+		msg, err := a.inbox.GetDelayedMessage(ctx, index)
+		if err != nil {
+			return err
+		}
+		// #nosec G115
+		if now.Sub(time.Unix(int64(msg.Header.Timestamp), 0)) <= window {
+			break
+		}
+		if report.OldestPendingDelayed == nil {
+			oldest := index
+			report.OldestPendingDelayed = &oldest
+		}
+		report.DelayedPendingLate++
+	}
+	return nil
+}
+
+// makeReport reports on the period since the previous report, and starts a new one.
+func (a *CensorshipAudit) makeReport(ctx context.Context) (*CensorshipAuditReport, error) {
+	config := a.config()
+	now := time.Now()
+	report := &CensorshipAuditReport{
+		FormatVersion: CensorshipAuditFormatVersion,
+		ChainID:       a.chainId,
+		// #nosec G115
+		End: uint64(now.Unix()),
+		// #nosec G115
+		DelayedWindow: uint64(config.DelayedWindow / time.Second),
+		// #nosec G115
+		TxWindow:   uint64(config.TxWindow / time.Millisecond),
+		Violations: []string{},
+	}
+	var err error
+	report.MsgCount, err = a.msgCount()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.pendingDelayed(ctx, report, now, config.DelayedWindow); err != nil {
+		return nil, fmt.Errorf("error checking pending delayed messages: %w", err)
+	}
+
+	a.mutex.Lock()
+	period := a.period
+	a.period = censorshipAuditPeriod{start: now}
+	a.mutex.Unlock()
+
+	// #nosec G115
+	report.Start = uint64(period.start.Unix())
+	report.DelayedSequenced = period.delayed.seen
+	report.DelayedLate = period.delayedLate
+	report.DelayedSamples = period.delayed.samples
+	if report.DelayedSamples == nil {
+		report.DelayedSamples = []CensorshipAuditDelayedSample{}
+	}
+	report.TxsAudited = period.txs.seen
+	report.TxsIncluded = period.txsIncluded
+	report.TxsLate = period.txsLate
+	report.TxsRejected = period.txsRejected
+	report.TxsTimedOut = period.txsTimedOut
+	report.TxSamples = period.txs.samples
+	if report.TxSamples == nil {
+		report.TxSamples = []CensorshipAuditTxSample{}
+	}
+
+	violation := func(format string, args ...interface{}) {
+		report.Violations = append(report.Violations, fmt.Sprintf(format, args...))
+	}
+	if report.DelayedLate > 0 {
+		violation("%d of %d delayed messages were sequenced more than %v after being posted", report.DelayedLate, report.DelayedSequenced, config.DelayedWindow)
+	}
+	if report.DelayedPendingLate > 0 {
+		violation("%d delayed messages starting at %d have waited more than %v to be sequenced", report.DelayedPendingLate, *report.OldestPendingDelayed, config.DelayedWindow)
+	}
+	if report.TxsLate > 0 {
+		violation("%d of %d audited transactions were included more than %v after being submitted", report.TxsLate, report.TxsAudited, config.TxWindow)
+	}
+	if report.TxsTimedOut > 0 {
+		violation("%d of %d audited transactions timed out waiting to be sequenced", report.TxsTimedOut, report.TxsAudited)
+	}
+	report.Compliant = len(report.Violations) == 0
+	return report, nil
+}
+
+func (a *CensorshipAudit) sign(report *CensorshipAuditReport) (*m.SequencerAuditMessage, error) {
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := a.signer(crypto.Keccak256(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return &m.SequencerAuditMessage{Report: encoded, Signer: a.signerAddr, Signature: sig}, nil
+}
+
+func (a *CensorshipAudit) publish(ctx context.Context) error {
+	report, err := a.makeReport(ctx)
+	if err != nil {
+		return err
+	}
+	signed, err := a.sign(report)
+	if err != nil {
+		return fmt.Errorf("error signing censorship audit report: %w", err)
+	}
+	config := a.config()
+	a.mutex.Lock()
+	a.reports = append(a.reports, signed)
+	if excess := len(a.reports) - config.KeepReports; excess > 0 {
+		a.reports = a.reports[excess:]
+	}
+	a.mutex.Unlock()
+	if config.PublishFeed && a.feed != nil {
+		a.feed.PublishSequencerAudit(signed)
+	}
+	censorshipAuditReportsCounter.Inc(1)
+	// #nosec G115
+	censorshipAuditViolationsCounter.Inc(int64(len(report.Violations)))
+	if report.Compliant {
+		log.Info("published censorship audit", "delayedSequenced", report.DelayedSequenced, "txsAudited", report.TxsAudited)
+	} else {
+		log.Warn("published censorship audit with violations", "violations", report.Violations)
+	}
+	return nil
+}
+
+func (a *CensorshipAudit) Start(ctxIn context.Context) {
+	a.StopWaiter.Start(ctxIn, a)
+	a.CallIteratively(func(ctx context.Context) time.Duration {
+		interval := a.config().Interval
+		a.mutex.Lock()
+		elapsed := time.Since(a.period.start)
+		a.mutex.Unlock()
+		if elapsed < interval {
+			return interval - elapsed
+		}
+		if !a.chosen() {
+			// Only the active sequencer answers for what was sequenced
+			a.mutex.Lock()
+			a.period = censorshipAuditPeriod{start: time.Now()}
+			a.mutex.Unlock()
+			return interval
+		}
+		if err := a.publish(ctx); err != nil {
+			log.Error("failed to publish censorship audit", "err", err)
+			return time.Minute
+		}
+		return interval
+	})
+}
+
+// Reports returns up to count of the most recent reports, newest first.
+func (a *CensorshipAudit) Reports(count uint64) []*m.SequencerAuditMessage {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	reports := []*m.SequencerAuditMessage{}
+	for i := len(a.reports) - 1; i >= 0 && uint64(len(reports)) < count; i-- {
+		reports = append(reports, a.reports[i])
+	}
+	return reports
+}
+
+type CensorshipAuditAPI struct {
+	audit *CensorshipAudit
+}
+
+// SequencerAudits returns up to count of the sequencer's most recent signed self-audit reports, newest first.
+func (a *CensorshipAuditAPI) SequencerAudits(count uint64) []*m.SequencerAuditMessage {
+	return a.audit.Reports(count)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+type mockAuditInbox []*arbostypes.L1IncomingMessage
+
+func (i mockAuditInbox) GetDelayedCount() (uint64, error) {
+	return uint64(len(i)), nil
+}
+
+func (i mockAuditInbox) GetDelayedMessage(ctx context.Context, seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+	return i[seqNum], nil
+}
+
+type mockAuditFeed []*m.SequencerAuditMessage
+
+func (f *mockAuditFeed) PublishSequencerAudit(audit *m.SequencerAuditMessage) {
+	*f = append(*f, audit)
+}
+
+func auditTestMessage(posted time.Time) *arbostypes.L1IncomingMessage {
+	return &arbostypes.L1IncomingMessage{
+		// #nosec G115
+		Header: &arbostypes.L1IncomingMessageHeader{Kind: arbostypes.L1MessageType_EthDeposit, BlockNumber: 100, Timestamp: uint64(posted.Unix())},
+	}
+}
+
+func TestCensorshipAudit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	signerAddr := crypto.PubkeyToAddress(key.PublicKey)
+	config := DefaultCensorshipAuditConfig
+	config.Enable = true
+	config.TxSampleRate = 1
+	config.TxWindow = time.Second
+	config.MaxSamples = 2
+	Require(t, config.Validate())
+
+	now := time.Now()
+	inbox := mockAuditInbox{
+		auditTestMessage(now.Add(-5 * time.Hour)),
+		auditTestMessage(now.Add(-4 * time.Hour)),
+		auditTestMessage(now.Add(-4 * time.Hour)),
+		auditTestMessage(now.Add(-3 * time.Hour)),
+		auditTestMessage(now),
+	}
+	delayedRead := uint64(3)
+	feed := &mockAuditFeed{}
+	audit := NewCensorshipAudit(
+		func() *CensorshipAuditConfig { return &config },
+		412346,
+		inbox,
+		func() (uint64, error) { return delayedRead, nil },
+		func() (arbutil.MessageIndex, error) { return 10, nil },
+		func() bool { return true },
+		signature.DataSignerFromPrivateKey(key),
+		signerAddr,
+		feed,
+	)
+
+	audit.DelayedSequenced(1, auditTestMessage(now.Add(-10*time.Minute)))
+	audit.DelayedSequenced(2, auditTestMessage(now.Add(-2*time.Hour)))
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &common.Address{}})
+	audit.TxOutcome(tx, time.Now(), nil)
+	audit.TxOutcome(tx, time.Now().Add(-2*time.Second), nil)
+	audit.TxOutcome(tx, time.Now(), errors.New("nonce too low"))
+	audit.TxOutcome(tx, time.Now(), context.DeadlineExceeded)
+	audit.TxOutcome(tx, time.Now(), context.Canceled)
+
+	Require(t, audit.publish(context.Background()))
+	if len(*feed) != 1 {
+		Fail(t, "report wasn't published to the feed")
+	}
+	reports := audit.Reports(10)
+	if len(reports) != 1 || reports[0] != (*feed)[0] {
+		Fail(t, "unexpected reports", reports)
+	}
+	report, err := OpenSequencerAudit(reports[0])
+	Require(t, err)
+	if report.ChainID != 412346 || report.MsgCount != 10 || report.Compliant || len(report.Violations) != 4 {
+		Fail(t, "unexpected report", report)
+	}
+	if report.DelayedSequenced != 2 || report.DelayedLate != 1 || len(report.DelayedSamples) != 2 {
+		Fail(t, "unexpected delayed messages sequenced", report)
+	}
+	if report.DelayedPending != 2 || report.DelayedPendingLate != 1 || report.OldestPendingDelayed == nil || *report.OldestPendingDelayed != 3 {
+		Fail(t, "unexpected pending delayed messages", report)
+	}
+	if report.TxsAudited != 4 || report.TxsIncluded != 2 || report.TxsLate != 1 || report.TxsRejected != 1 || report.TxsTimedOut != 1 || len(report.TxSamples) != 2 {
+		Fail(t, "unexpected transactions", report)
+	}
+
+	tampered := *reports[0]
+	tampered.Report = append([]byte{}, tampered.Report...)
+	tampered.Report[len(tampered.Report)-2] ^= 1
+	if _, err := OpenSequencerAudit(&tampered); err == nil {
+		Fail(t, "opened a tampered report")
+	}
+
+	// A new period starts with each report
+	delayedRead = uint64(len(inbox))
+	Require(t, audit.publish(context.Background()))
+	report, err = OpenSequencerAudit(audit.Reports(1)[0])
+	Require(t, err)
+	if !report.Compliant || report.DelayedSequenced != 0 || report.TxsAudited != 0 || report.DelayedPending != 0 {
+		Fail(t, "unexpected report", report)
+	}
+}
@@ -79,6 +79,7 @@ type DataPoster struct {
 	extraBacklog      func() uint64
 	parentChainID     *big.Int
 	parentChainID256  *uint256.Int
+	privateRelay      *rpc.Client
 
 	// These fields are protected by the mutex.
 	// TODO: factor out these fields into separate structure, since now one
@@ -187,6 +188,15 @@ func NewDataPoster(ctx context.Context, opts *DataPosterOpts) (*DataPoster, erro
 			},
 		}
 	}
+	if cfg.PrivateRelay.URL != "" {
+		if err := cfg.PrivateRelay.Validate(); err != nil {
+			return nil, err
+		}
+		dp.privateRelay, err = rpc.DialContext(ctx, cfg.PrivateRelay.URL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to private relay: %w", err)
+		}
+	}
 
 	return dp, nil
 }
@@ -914,7 +924,7 @@ func (p *DataPoster) sendTx(ctx context.Context, prevTx *storage.QueuedTransacti
 		}
 	}
 
-	if err := p.client.SendTransaction(ctx, newTx.FullTx); err != nil {
+	if err := p.submitTx(ctx, newTx.FullTx); err != nil {
 		if !rpcclient.IsAlreadyKnownError(err) && !strings.Contains(err.Error(), "nonce too low") {
 			log.Warn("DataPoster failed to send transaction", "err", err, "nonce", newTx.FullTx.Nonce(), "feeCap", newTx.FullTx.GasFeeCap(), "tipCap", newTx.FullTx.GasTipCap(), "blobFeeCap", newTx.FullTx.BlobGasFeeCap(), "gas", newTx.FullTx.Gas())
 			return err
@@ -1031,7 +1041,11 @@ func (p *DataPoster) replaceTx(ctx context.Context, prevTx *storage.QueuedTransa
 func (p *DataPoster) updateNonce(ctx context.Context) error {
 	var blockNumQuery *big.Int
 	if p.waitForL1Finality() {
-		blockNumQuery = big.NewInt(int64(rpc.FinalizedBlockNumber))
+		var err error
+		blockNumQuery, err = p.headerReader.FinalizedBlockTag(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the finalized L1 block: %w", err)
+		}
 	}
 	header, err := p.client.HeaderByNumber(ctx, blockNumQuery)
 	if err != nil {
@@ -1239,27 +1253,28 @@ type DataPosterConfig struct {
 	BlobTxReplacementTimes []time.Duration            `koanf:"blob-tx-replacement-times"`
 	// This is forcibly disabled if the parent chain is an Arbitrum chain,
 	// so you should probably use DataPoster's waitForL1Finality method instead of reading this field directly.
-	WaitForL1Finality      bool              `koanf:"wait-for-l1-finality" reload:"hot"`
-	MaxMempoolTransactions uint64            `koanf:"max-mempool-transactions" reload:"hot"`
-	MaxMempoolWeight       uint64            `koanf:"max-mempool-weight" reload:"hot"`
-	MaxQueuedTransactions  int               `koanf:"max-queued-transactions" reload:"hot"`
-	TargetPriceGwei        float64           `koanf:"target-price-gwei" reload:"hot"`
-	UrgencyGwei            float64           `koanf:"urgency-gwei" reload:"hot"`
-	MinTipCapGwei          float64           `koanf:"min-tip-cap-gwei" reload:"hot"`
-	MinBlobTxTipCapGwei    float64           `koanf:"min-blob-tx-tip-cap-gwei" reload:"hot"`
-	MaxTipCapGwei          float64           `koanf:"max-tip-cap-gwei" reload:"hot"`
-	MaxBlobTxTipCapGwei    float64           `koanf:"max-blob-tx-tip-cap-gwei" reload:"hot"`
-	MaxFeeBidMultipleBips  arbmath.UBips     `koanf:"max-fee-bid-multiple-bips" reload:"hot"`
-	NonceRbfSoftConfs      uint64            `koanf:"nonce-rbf-soft-confs" reload:"hot"`
-	AllocateMempoolBalance bool              `koanf:"allocate-mempool-balance" reload:"hot"`
-	UseDBStorage           bool              `koanf:"use-db-storage"`
-	UseNoOpStorage         bool              `koanf:"use-noop-storage"`
-	LegacyStorageEncoding  bool              `koanf:"legacy-storage-encoding" reload:"hot"`
-	Dangerous              DangerousConfig   `koanf:"dangerous"`
-	ExternalSigner         ExternalSignerCfg `koanf:"external-signer"`
-	MaxFeeCapFormula       string            `koanf:"max-fee-cap-formula" reload:"hot"`
-	ElapsedTimeBase        time.Duration     `koanf:"elapsed-time-base" reload:"hot"`
-	ElapsedTimeImportance  float64           `koanf:"elapsed-time-importance" reload:"hot"`
+	WaitForL1Finality      bool               `koanf:"wait-for-l1-finality" reload:"hot"`
+	MaxMempoolTransactions uint64             `koanf:"max-mempool-transactions" reload:"hot"`
+	MaxMempoolWeight       uint64             `koanf:"max-mempool-weight" reload:"hot"`
+	MaxQueuedTransactions  int                `koanf:"max-queued-transactions" reload:"hot"`
+	TargetPriceGwei        float64            `koanf:"target-price-gwei" reload:"hot"`
+	UrgencyGwei            float64            `koanf:"urgency-gwei" reload:"hot"`
+	MinTipCapGwei          float64            `koanf:"min-tip-cap-gwei" reload:"hot"`
+	MinBlobTxTipCapGwei    float64            `koanf:"min-blob-tx-tip-cap-gwei" reload:"hot"`
+	MaxTipCapGwei          float64            `koanf:"max-tip-cap-gwei" reload:"hot"`
+	MaxBlobTxTipCapGwei    float64            `koanf:"max-blob-tx-tip-cap-gwei" reload:"hot"`
+	MaxFeeBidMultipleBips  arbmath.UBips      `koanf:"max-fee-bid-multiple-bips" reload:"hot"`
+	NonceRbfSoftConfs      uint64             `koanf:"nonce-rbf-soft-confs" reload:"hot"`
+	AllocateMempoolBalance bool               `koanf:"allocate-mempool-balance" reload:"hot"`
+	UseDBStorage           bool               `koanf:"use-db-storage"`
+	UseNoOpStorage         bool               `koanf:"use-noop-storage"`
+	LegacyStorageEncoding  bool               `koanf:"legacy-storage-encoding" reload:"hot"`
+	Dangerous              DangerousConfig    `koanf:"dangerous"`
+	ExternalSigner         ExternalSignerCfg  `koanf:"external-signer"`
+	PrivateRelay           PrivateRelayConfig `koanf:"private-relay"`
+	MaxFeeCapFormula       string             `koanf:"max-fee-cap-formula" reload:"hot"`
+	ElapsedTimeBase        time.Duration      `koanf:"elapsed-time-base" reload:"hot"`
+	ElapsedTimeImportance  float64            `koanf:"elapsed-time-importance" reload:"hot"`
 	// When set, dataposter will not post new batches, but will keep running to
 	// get existing batches confirmed.
 	DisableNewTx bool `koanf:"disable-new-tx" reload:"hot"`
@@ -1324,6 +1339,7 @@ func DataPosterConfigAddOptions(prefix string, f *pflag.FlagSet, defaultDataPost
 	signature.SimpleHmacConfigAddOptions(prefix+".redis-signer", f)
 	addDangerousOptions(prefix+".dangerous", f)
 	addExternalSignerOptions(prefix+".external-signer", f)
+	addPrivateRelayOptions(prefix+".private-relay", f)
 	f.Bool(prefix+".disable-new-tx", defaultDataPosterConfig.DisableNewTx, "disable posting new transactions, data poster will still keep confirming existing batches")
 }
 
@@ -1361,6 +1377,7 @@ var DefaultDataPosterConfig = DataPosterConfig{
 	LegacyStorageEncoding:  false,
 	Dangerous:              DangerousConfig{ClearDBStorage: false},
 	ExternalSigner:         ExternalSignerCfg{Method: "eth_signTransaction", InsecureSkipVerify: false},
+	PrivateRelay:           DefaultPrivateRelayConfig,
 	MaxFeeCapFormula:       "((BacklogOfBatches * UrgencyGWei) ** 2) + ((ElapsedTime/ElapsedTimeBase) ** 2) * ElapsedTimeImportance + TargetPriceGWei",
 	ElapsedTimeBase:        10 * time.Minute,
 	ElapsedTimeImportance:  10,
@@ -1395,6 +1412,7 @@ var TestDataPosterConfig = DataPosterConfig{
 	UseNoOpStorage:         false,
 	LegacyStorageEncoding:  false,
 	ExternalSigner:         ExternalSignerCfg{Method: "eth_signTransaction", InsecureSkipVerify: true},
+	PrivateRelay:           DefaultPrivateRelayConfig,
 	MaxFeeCapFormula:       "((BacklogOfBatches * UrgencyGWei) ** 2) + ((ElapsedTime/ElapsedTimeBase) ** 2) * ElapsedTimeImportance + TargetPriceGWei",
 	ElapsedTimeBase:        10 * time.Minute,
 	ElapsedTimeImportance:  10,
@@ -0,0 +1,109 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/rpcclient"
+)
+
+var (
+	privateRelaySentCounter     = metrics.NewRegisteredCounter("arb/dataposter/privaterelay/sent", nil)
+	privateRelayFailedCounter   = metrics.NewRegisteredCounter("arb/dataposter/privaterelay/failed", nil)
+	privateRelayFallbackCounter = metrics.NewRegisteredCounter("arb/dataposter/privaterelay/fallback", nil)
+)
+
+const (
+	// Takes the raw transaction, as the public method does (e.g. Flashbots Protect)
+	PrivateRelayMethodSendRawTransaction = "eth_sendRawTransaction"
+	// Takes the raw transaction
+	PrivateRelayMethodSendPrivateRawTransaction = "eth_sendPrivateRawTransaction"
+	// Takes an object with the raw transaction as "tx" (e.g. the Flashbots relay)
+	PrivateRelayMethodSendPrivateTransaction = "eth_sendPrivateTransaction"
+)
+
+type PrivateRelayConfig struct {
+	// URL of the private relay, posting transactions go to the public mempool if empty.
+	URL              string        `koanf:"url"`
+	Method           string        `koanf:"method"`
+	Timeout          time.Duration `koanf:"timeout" reload:"hot"`
+	FallbackToPublic bool          `koanf:"fallback-to-public" reload:"hot"`
+	IncludeBlobTxs   bool          `koanf:"include-blob-txs" reload:"hot"`
+}
+
+var DefaultPrivateRelayConfig = PrivateRelayConfig{
+	Method:           PrivateRelayMethodSendRawTransaction,
+	Timeout:          10 * time.Second,
+	FallbackToPublic: true,
+}
+
+func addPrivateRelayOptions(prefix string, f *pflag.FlagSet) {
+	f.String(prefix+".url", DefaultPrivateRelayConfig.URL, "url of a private relay to submit posting transactions to instead of the public mempool")
+	f.String(prefix+".method", DefaultPrivateRelayConfig.Method, "private relay rpc method, one of "+PrivateRelayMethodSendRawTransaction+", "+PrivateRelayMethodSendPrivateRawTransaction+" or "+PrivateRelayMethodSendPrivateTransaction)
+	f.Duration(prefix+".timeout", DefaultPrivateRelayConfig.Timeout, "timeout for submitting a transaction to the private relay")
+	f.Bool(prefix+".fallback-to-public", DefaultPrivateRelayConfig.FallbackToPublic, "submit transactions to the public mempool when the private relay fails")
+	f.Bool(prefix+".include-blob-txs", DefaultPrivateRelayConfig.IncludeBlobTxs, "submit blob transactions to the private relay too, rather than only to the public mempool")
+}
+
+func (c *PrivateRelayConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	switch c.Method {
+	case PrivateRelayMethodSendRawTransaction, PrivateRelayMethodSendPrivateRawTransaction, PrivateRelayMethodSendPrivateTransaction:
+	default:
+		return fmt.Errorf("unknown private relay method %v", c.Method)
+	}
+	if c.Timeout <= 0 {
+		return errors.New("private relay timeout must be positive")
+	}
+	return nil
+}
+
+// sendPrivateTx submits tx to the private relay.
+func sendPrivateTx(ctx context.Context, relay *rpc.Client, config *PrivateRelayConfig, tx *types.Transaction) error {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	var param interface{} = hexutil.Bytes(data)
+	if config.Method == PrivateRelayMethodSendPrivateTransaction {
+		param = map[string]interface{}{"tx": hexutil.Bytes(data)}
+	}
+	return relay.CallContext(ctx, nil, config.Method, param)
+}
+
+// submitTx submits tx to the private relay if there's one, and to the parent chain's mempool otherwise or if
+// the relay fails and falling back is enabled.
+func (p *DataPoster) submitTx(ctx context.Context, tx *types.Transaction) error {
+	config := &p.config().PrivateRelay
+	if p.privateRelay == nil || (tx.Type() == types.BlobTxType && !config.IncludeBlobTxs) {
+		return p.client.SendTransaction(ctx, tx)
+	}
+	err := sendPrivateTx(ctx, p.privateRelay, config, tx)
+	if err == nil || rpcclient.IsAlreadyKnownError(err) {
+		privateRelaySentCounter.Inc(1)
+		return err
+	}
+	privateRelayFailedCounter.Inc(1)
+	if !config.FallbackToPublic {
+		return fmt.Errorf("submitting transaction to private relay: %w", err)
+	}
+	log.Warn("DataPoster failed to submit transaction to private relay, sending it to the public mempool", "err", err, "nonce", tx.Nonce(), "hash", tx.Hash())
+	privateRelayFallbackCounter.Inc(1)
+	return p.client.SendTransaction(ctx, tx)
+}
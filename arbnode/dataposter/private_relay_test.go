@@ -0,0 +1,109 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testRelayService struct {
+	fail bool
+	txs  []common.Hash
+}
+
+func (s *testRelayService) add(data hexutil.Bytes) (common.Hash, error) {
+	if s.fail {
+		return common.Hash{}, errors.New("relay unavailable")
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	s.txs = append(s.txs, tx.Hash())
+	return tx.Hash(), nil
+}
+
+func (s *testRelayService) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	return s.add(data)
+}
+
+type privateTransactionArgs struct {
+	Tx hexutil.Bytes `json:"tx"`
+}
+
+func (s *testRelayService) SendPrivateTransaction(args privateTransactionArgs) (common.Hash, error) {
+	return s.add(args.Tx)
+}
+
+func testRelayClient(t *testing.T, service *testRelayService) *rpc.Client {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return rpc.DialInProc(server)
+}
+
+func TestSubmitTxToPrivateRelay(t *testing.T) {
+	ctx := context.Background()
+	relay, public := &testRelayService{}, &testRelayService{}
+	config := TestDataPosterConfig
+	config.PrivateRelay.URL = "relay"
+	p := &DataPoster{
+		client:       ethclient.NewClient(testRelayClient(t, public)),
+		privateRelay: testRelayClient(t, relay),
+		config:       func() *DataPosterConfig { return &config },
+	}
+	if err := config.PrivateRelay.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.submitTx(ctx, dynamicFeeTx); err != nil {
+		t.Fatal(err)
+	}
+	config.PrivateRelay.Method = PrivateRelayMethodSendPrivateTransaction
+	if err := p.submitTx(ctx, dynamicFeeTx); err != nil {
+		t.Fatal(err)
+	}
+	if len(relay.txs) != 2 || relay.txs[0] != dynamicFeeTx.Hash() || len(public.txs) != 0 {
+		t.Fatalf("expected the transaction to only go to the relay twice, relay got %v, public mempool got %v", relay.txs, public.txs)
+	}
+
+	// Blob transactions go to the public mempool unless the relay takes them
+	if err := p.submitTx(ctx, blobTx); err != nil {
+		t.Fatal(err)
+	}
+	if len(relay.txs) != 2 || len(public.txs) != 1 {
+		t.Fatalf("expected the blob transaction to go to the public mempool, relay got %v, public mempool got %v", relay.txs, public.txs)
+	}
+
+	relay.fail = true
+	if err := p.submitTx(ctx, dynamicFeeTx); err != nil {
+		t.Fatal(err)
+	}
+	if len(public.txs) != 2 {
+		t.Fatalf("expected the transaction to fall back to the public mempool, public mempool got %v", public.txs)
+	}
+	config.PrivateRelay.FallbackToPublic = false
+	if err := p.submitTx(ctx, dynamicFeeTx); err == nil {
+		t.Fatal("relay failure wasn't an error without falling back")
+	}
+	if len(public.txs) != 2 {
+		t.Fatalf("transaction went to the public mempool without falling back, public mempool got %v", public.txs)
+	}
+
+	config.PrivateRelay.Method = "eth_sendBundle"
+	if err := config.PrivateRelay.Validate(); err == nil {
+		t.Fatal("unknown relay method passed validation")
+	}
+}
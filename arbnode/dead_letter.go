@@ -0,0 +1,225 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/dbutil"
+)
+
+var deadLetterParkedGauge = metrics.NewRegisteredGauge("arb/streamer/deadletter/parked", nil)
+
+type DeadLetterResolution string
+
+const (
+	DeadLetterParked DeadLetterResolution = ""
+	DeadLetterRetry  DeadLetterResolution = "retry"
+	DeadLetterSkip   DeadLetterResolution = "skip"
+)
+
+var ErrNoDeadLetter = errors.New("no dead letter for message")
+
+// DeadLetter is a message the execution engine repeatedly failed to digest, parked until an operator
+// decides whether to retry it or skip it.
+type DeadLetter struct {
+	Pos          arbutil.MessageIndex           `json:"pos"`
+	Message      arbostypes.MessageWithMetadata `json:"message"`
+	FeedHash     *common.Hash                   `json:"feedBlockHash" rlp:"nil"` // the block hash from the feed, if any
+	Error        string                         `json:"error"`
+	Attempts     uint64                         `json:"attempts"`
+	FirstFailure uint64                         `json:"firstFailure"` // unix seconds
+	LastFailure  uint64                         `json:"lastFailure"`  // unix seconds
+	Resolution   DeadLetterResolution           `json:"resolution"`
+	ResolvedAt   uint64                         `json:"resolvedAt"` // unix seconds
+}
+
+type digestFailures struct {
+	pos      arbutil.MessageIndex
+	attempts uint64
+	first    time.Time
+}
+
+func (s *TransactionStreamer) readDeadLetter(pos arbutil.MessageIndex) (*DeadLetter, error) {
+	data, err := s.db.Get(dbKey(deadLetterPrefix, uint64(pos)))
+	if dbutil.IsErrNotFound(err) {
+		return nil, fmt.Errorf("%w %v", ErrNoDeadLetter, pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var letter DeadLetter
+	if err := rlp.DecodeBytes(data, &letter); err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+func (s *TransactionStreamer) writeDeadLetter(letter *DeadLetter) error {
+	data, err := rlp.EncodeToBytes(letter)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(dbKey(deadLetterPrefix, uint64(letter.Pos)), data)
+}
+
+// loadDeadLetters restores skip decisions made before a restart. Messages parked without a decision are
+// retried, as the restart may have been an upgrade that fixes them.
+func (s *TransactionStreamer) loadDeadLetters() error {
+	iter := s.db.NewIterator(deadLetterPrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		var letter DeadLetter
+		if err := rlp.DecodeBytes(iter.Value(), &letter); err != nil {
+			return fmt.Errorf("decoding dead letter %x: %w", iter.Key(), err)
+		}
+		if letter.Resolution == DeadLetterSkip {
+			s.deadLetterSkips[letter.Pos] = letter.Message
+		}
+	}
+	return iter.Error()
+}
+
+// deadLetterParked reports whether pos is parked awaiting an operator's decision.
+func (s *TransactionStreamer) deadLetterParked(pos arbutil.MessageIndex) bool {
+	s.deadLetterMutex.Lock()
+	defer s.deadLetterMutex.Unlock()
+	return s.parkedPos != nil && *s.parkedPos == pos
+}
+
+// substituteSkipped replaces a message an operator chose to skip with an invalid message, which ArbOS
+// turns into an empty block, keeping the header so the chain's clock and delayed count move as expected.
+func (s *TransactionStreamer) substituteSkipped(pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) *arbostypes.MessageWithMetadata {
+	s.deadLetterMutex.Lock()
+	skipped, skip := s.deadLetterSkips[pos]
+	s.deadLetterMutex.Unlock()
+	// A reorg may have replaced the message the operator chose to skip
+	if !skip || skipped.DelayedMessagesRead != msg.DelayedMessagesRead || !skipped.Message.Equals(msg.Message) {
+		return msg
+	}
+	header := *msg.Message.Header
+	header.Kind = arbostypes.L1MessageType_Invalid
+	log.Warn("executing skipped dead letter as an empty block", "pos", pos)
+	return &arbostypes.MessageWithMetadata{
+		Message: &arbostypes.L1IncomingMessage{
+			Header: &header,
+			L2msg:  []byte{},
+		},
+		DelayedMessagesRead: msg.DelayedMessagesRead,
+	}
+}
+
+// digestFailed counts a failure to digest pos, parking the message once it has failed too many times.
+func (s *TransactionStreamer) digestFailed(pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadataAndBlockHash, digestErr error) {
+	maxAttempts := s.config().DeadLetterAttempts
+	if maxAttempts == 0 {
+		return
+	}
+	s.deadLetterMutex.Lock()
+	defer s.deadLetterMutex.Unlock()
+	if s.failures.pos != pos || s.failures.attempts == 0 {
+		s.failures = digestFailures{pos: pos, first: time.Now()}
+	}
+	s.failures.attempts++
+	if s.failures.attempts < maxAttempts {
+		return
+	}
+	letter, err := s.readDeadLetter(pos)
+	if errors.Is(err, ErrNoDeadLetter) {
+		letter = &DeadLetter{
+			Pos:          pos,
+			Message:      msg.MessageWithMeta,
+			FeedHash:     msg.BlockHash,
+			FirstFailure: uint64(s.failures.first.Unix()), // #nosec G115
+		}
+	} else if err != nil {
+		log.Error("failed to read dead letter", "pos", pos, "err", err)
+		return
+	}
+	letter.Error = digestErr.Error()
+	letter.Attempts += s.failures.attempts
+	letter.LastFailure = uint64(time.Now().Unix()) // #nosec G115
+	letter.Resolution = DeadLetterParked
+	letter.ResolvedAt = 0
+	if err := s.writeDeadLetter(letter); err != nil {
+		log.Error("failed to park message in the dead letter queue", "pos", pos, "err", err)
+		return
+	}
+	s.failures = digestFailures{}
+	s.parkedPos = &pos
+	deadLetterParkedGauge.Update(1)
+	log.Error("execution repeatedly failed to digest message; parked it until an operator retries or skips it", "pos", pos, "attempts", letter.Attempts, "err", digestErr)
+}
+
+// digestSucceeded clears the failure count after pos was digested.
+func (s *TransactionStreamer) digestSucceeded(pos arbutil.MessageIndex) {
+	s.deadLetterMutex.Lock()
+	defer s.deadLetterMutex.Unlock()
+	if s.failures.pos == pos {
+		s.failures = digestFailures{}
+	}
+}
+
+// ResolveDeadLetter records an operator's decision for a parked message and unparks it.
+func (s *TransactionStreamer) ResolveDeadLetter(pos arbutil.MessageIndex, resolution DeadLetterResolution) error {
+	if resolution != DeadLetterRetry && resolution != DeadLetterSkip {
+		return fmt.Errorf("unknown dead letter resolution \"%v\"", resolution)
+	}
+	s.deadLetterMutex.Lock()
+	defer s.deadLetterMutex.Unlock()
+	letter, err := s.readDeadLetter(pos)
+	if err != nil {
+		return err
+	}
+	letter.Resolution = resolution
+	letter.ResolvedAt = uint64(time.Now().Unix()) // #nosec G115
+	if err := s.writeDeadLetter(letter); err != nil {
+		return err
+	}
+	if resolution == DeadLetterSkip {
+		s.deadLetterSkips[pos] = letter.Message
+	} else {
+		delete(s.deadLetterSkips, pos)
+	}
+	if s.parkedPos != nil && *s.parkedPos == pos {
+		s.parkedPos = nil
+		deadLetterParkedGauge.Update(0)
+	}
+	log.Warn("dead letter resolved", "pos", pos, "resolution", resolution)
+	select {
+	case s.newMessageNotifier <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+type DeadLetterAPI struct {
+	streamer *TransactionStreamer
+}
+
+// DeadLetter returns the dead letter queue entry for a message.
+func (a *DeadLetterAPI) DeadLetter(ctx context.Context, pos arbutil.MessageIndex) (*DeadLetter, error) {
+	return a.streamer.readDeadLetter(pos)
+}
+
+// RetryDeadLetter unparks a message so that execution tries it again.
+func (a *DeadLetterAPI) RetryDeadLetter(ctx context.Context, pos arbutil.MessageIndex) error {
+	return a.streamer.ResolveDeadLetter(pos, DeadLetterRetry)
+}
+
+// SkipDeadLetter executes a parked message as an empty block instead. This diverges from every node that
+// executes the message normally, so it's only for chains where the operator controls all nodes.
+func (a *DeadLetterAPI) SkipDeadLetter(ctx context.Context, pos arbutil.MessageIndex) error {
+	return a.streamer.ResolveDeadLetter(pos, DeadLetterSkip)
+}
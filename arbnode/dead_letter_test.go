@@ -0,0 +1,90 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func newDeadLetterTestStreamer(t *testing.T, db ethdb.Database, attempts uint64) *TransactionStreamer {
+	config := TestTransactionStreamerConfig
+	config.DeadLetterAttempts = attempts
+	streamer := &TransactionStreamer{
+		db:                 db,
+		config:             func() *TransactionStreamerConfig { return &config },
+		newMessageNotifier: make(chan struct{}, 1),
+		deadLetterSkips:    make(map[arbutil.MessageIndex]arbostypes.MessageWithMetadata),
+	}
+	Require(t, streamer.loadDeadLetters())
+	return streamer
+}
+
+func TestDeadLetterQueue(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	streamer := newDeadLetterTestStreamer(t, db, 3)
+	pos := arbutil.MessageIndex(7)
+	msg := &arbostypes.MessageWithMetadataAndBlockHash{
+		MessageWithMeta: arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{
+					Kind:      arbostypes.L1MessageType_L2Message,
+					Poster:    common.HexToAddress("0x1234"),
+					Timestamp: 1000,
+				},
+				L2msg: []byte{1, 2, 3},
+			},
+			DelayedMessagesRead: 4,
+		},
+	}
+	digestErr := errors.New("unsupported message")
+
+	streamer.digestFailed(pos, msg, digestErr)
+	streamer.digestFailed(pos, msg, digestErr)
+	if streamer.deadLetterParked(pos) {
+		Fail(t, "parked before reaching the attempt limit")
+	}
+	streamer.digestFailed(pos, msg, digestErr)
+	if !streamer.deadLetterParked(pos) {
+		Fail(t, "not parked after reaching the attempt limit")
+	}
+	letter, err := streamer.readDeadLetter(pos)
+	Require(t, err)
+	if letter.Attempts != 3 || letter.Error != digestErr.Error() || letter.Resolution != DeadLetterParked || !letter.Message.Message.Equals(msg.MessageWithMeta.Message) {
+		Fail(t, "unexpected dead letter", letter)
+	}
+
+	// Retrying unparks the message without changing it
+	Require(t, streamer.ResolveDeadLetter(pos, DeadLetterRetry))
+	if streamer.deadLetterParked(pos) {
+		Fail(t, "still parked after retry")
+	}
+	if streamer.substituteSkipped(pos, &msg.MessageWithMeta) != &msg.MessageWithMeta {
+		Fail(t, "retried message was substituted")
+	}
+
+	// Skipping persists across restarts and replaces only the skipped message
+	Require(t, streamer.ResolveDeadLetter(pos, DeadLetterSkip))
+	streamer = newDeadLetterTestStreamer(t, db, 3)
+	substituted := streamer.substituteSkipped(pos, &msg.MessageWithMeta)
+	if substituted.Message.Header.Kind != arbostypes.L1MessageType_Invalid || len(substituted.Message.L2msg) != 0 || substituted.DelayedMessagesRead != 4 || substituted.Message.Header.Timestamp != 1000 {
+		Fail(t, "skipped message wasn't substituted", substituted)
+	}
+	reorged := msg.MessageWithMeta
+	reorged.Message = &arbostypes.L1IncomingMessage{Header: msg.MessageWithMeta.Message.Header, L2msg: []byte{4}}
+	if streamer.substituteSkipped(pos, &reorged) != &reorged {
+		Fail(t, "a different message at the skipped position was substituted")
+	}
+
+	if !errors.Is(streamer.ResolveDeadLetter(pos+1, DeadLetterSkip), ErrNoDeadLetter) {
+		Fail(t, "resolved a message that was never parked")
+	}
+}
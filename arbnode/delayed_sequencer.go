@@ -9,36 +9,60 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
+var (
+	delayedInclusionLatencyHistogram = metrics.NewRegisteredHistogram("arb/delayedsequencer/inclusion/latency", nil, metrics.NewBoundedHistogramSample())
+	delayedPendingGauge              = metrics.NewRegisteredGauge("arb/delayedsequencer/pending/count", nil)
+	delayedPendingMaxAgeGauge        = metrics.NewRegisteredGauge("arb/delayedsequencer/pending/maxage", nil)
+	delayedForceInclusionDelayGauge  = metrics.NewRegisteredGauge("arb/delayedsequencer/forceinclusion/delay", nil)
+)
+
+// How often the sequencer inbox's force inclusion delay is refreshed
+const forceInclusionDelayRefresh = 10 * time.Minute
+
 type DelayedSequencer struct {
 	stopwaiter.StopWaiter
 	l1Reader                 *headerreader.HeaderReader
 	bridge                   *DelayedBridge
 	inbox                    *InboxTracker
 	reader                   *InboxReader
+	seqInbox                 *bridgegen.SequencerInbox
 	exec                     execution.ExecutionSequencer
 	coordinator              *SeqCoordinator
 	waitingForFinalizedBlock uint64
 	mutex                    sync.Mutex
 	config                   DelayedSequencerConfigFetcher
+	audit                    *CensorshipAudit // nil unless enabled
+
+	// Only in run thread
+	forceInclusionDelay        uint64
+	forceInclusionDelayUpdated time.Time
+	lastAgeWarning             time.Time
 }
 
 type DelayedSequencerConfig struct {
-	Enable              bool  `koanf:"enable" reload:"hot"`
-	FinalizeDistance    int64 `koanf:"finalize-distance" reload:"hot"`
-	RequireFullFinality bool  `koanf:"require-full-finality" reload:"hot"`
-	UseMergeFinality    bool  `koanf:"use-merge-finality" reload:"hot"`
+	Enable                        bool          `koanf:"enable" reload:"hot"`
+	FinalizeDistance              int64         `koanf:"finalize-distance" reload:"hot"`
+	RequireFullFinality           bool          `koanf:"require-full-finality" reload:"hot"`
+	UseMergeFinality              bool          `koanf:"use-merge-finality" reload:"hot"`
+	ForceInclusionWarningFraction float64       `koanf:"force-inclusion-warning-fraction" reload:"hot"`
+	AgeWarningInterval            time.Duration `koanf:"age-warning-interval" reload:"hot"`
 }
 
 type DelayedSequencerConfigFetcher func() *DelayedSequencerConfig
@@ -48,20 +72,26 @@ func DelayedSequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int64(prefix+".finalize-distance", DefaultDelayedSequencerConfig.FinalizeDistance, "how many blocks in the past L1 block is considered final (ignored when using Merge finality)")
 	f.Bool(prefix+".require-full-finality", DefaultDelayedSequencerConfig.RequireFullFinality, "whether to wait for full finality before sequencing delayed messages")
 	f.Bool(prefix+".use-merge-finality", DefaultDelayedSequencerConfig.UseMergeFinality, "whether to use The Merge's notion of finality before sequencing delayed messages")
+	f.Float64(prefix+".force-inclusion-warning-fraction", DefaultDelayedSequencerConfig.ForceInclusionWarningFraction, "warn when the oldest delayed message waiting to be sequenced is older than this fraction of the sequencer inbox's force inclusion delay (0 to disable)")
+	f.Duration(prefix+".age-warning-interval", DefaultDelayedSequencerConfig.AgeWarningInterval, "minimum time between warnings about delayed messages approaching force inclusion")
 }
 
 var DefaultDelayedSequencerConfig = DelayedSequencerConfig{
-	Enable:              false,
-	FinalizeDistance:    20,
-	RequireFullFinality: false,
-	UseMergeFinality:    true,
+	Enable:                        false,
+	FinalizeDistance:              20,
+	RequireFullFinality:           false,
+	UseMergeFinality:              true,
+	ForceInclusionWarningFraction: 0.25,
+	AgeWarningInterval:            time.Minute,
 }
 
 var TestDelayedSequencerConfig = DelayedSequencerConfig{
-	Enable:              true,
-	FinalizeDistance:    20,
-	RequireFullFinality: false,
-	UseMergeFinality:    false,
+	Enable:                        true,
+	FinalizeDistance:              20,
+	RequireFullFinality:           false,
+	UseMergeFinality:              false,
+	ForceInclusionWarningFraction: 0.25,
+	AgeWarningInterval:            time.Minute,
 }
 
 func NewDelayedSequencer(l1Reader *headerreader.HeaderReader, reader *InboxReader, exec execution.ExecutionSequencer, coordinator *SeqCoordinator, config DelayedSequencerConfigFetcher) (*DelayedSequencer, error) {
@@ -70,6 +100,7 @@ func NewDelayedSequencer(l1Reader *headerreader.HeaderReader, reader *InboxReade
 		bridge:      reader.DelayedBridge(),
 		inbox:       reader.Tracker(),
 		reader:      reader,
+		seqInbox:    reader.sequencerInbox.con,
 		coordinator: coordinator,
 		exec:        exec,
 		config:      config,
@@ -80,6 +111,14 @@ func NewDelayedSequencer(l1Reader *headerreader.HeaderReader, reader *InboxReade
 	return d, nil
 }
 
+// SetCensorshipAudit must be called before the delayed sequencer starts.
+func (d *DelayedSequencer) SetCensorshipAudit(audit *CensorshipAudit) {
+	if d.Started() {
+		panic("trying to set censorship audit after start")
+	}
+	d.audit = audit
+}
+
 func (d *DelayedSequencer) getDelayedMessagesRead() (uint64, error) {
 	return d.exec.NextDelayedMessageNumber()
 }
@@ -192,9 +231,19 @@ func (d *DelayedSequencer) sequenceWithoutLockout(ctx context.Context, lastBlock
 		for i, msg := range messages {
 			// #nosec G115
 			err = d.exec.SequenceDelayedMessage(msg, startPos+uint64(i))
+			if errors.Is(err, execution.ErrSequencerFrozen) {
+				// The remaining messages will be sequenced once block production resumes
+				log.Debug("DelayedSequencer: block production is frozen", "startpos", startPos)
+				d.waitingForFinalizedBlock = 0
+				return nil
+			}
 			if err != nil {
 				return err
 			}
+			// #nosec G115
+			delayedInclusionLatencyHistogram.Update(int64(messageAge(msg) / time.Second))
+			// #nosec G115
+			d.audit.DelayedSequenced(startPos+uint64(i), msg)
 		}
 		log.Info("DelayedSequencer: Sequenced", "msgnum", len(messages), "startpos", startPos)
 	}
@@ -202,6 +251,73 @@ func (d *DelayedSequencer) sequenceWithoutLockout(ctx context.Context, lastBlock
 	return nil
 }
 
+func messageAge(msg *arbostypes.L1IncomingMessage) time.Duration {
+	// #nosec G115
+	return time.Since(time.Unix(int64(msg.Header.Timestamp), 0))
+}
+
+// checkPendingAge tracks how long the oldest delayed message has waited to be sequenced, and warns
+// once that nears the point where anyone can force include it, as deposits quietly slowing down
+// would otherwise go unnoticed.
+func (d *DelayedSequencer) checkPendingAge(ctx context.Context) error {
+	dbDelayedCount, err := d.inbox.GetDelayedCount()
+	if err != nil {
+		return err
+	}
+	read, err := d.getDelayedMessagesRead()
+	if err != nil {
+		return err
+	}
+	pending := arbmath.SaturatingUSub(dbDelayedCount, read)
+	// #nosec G115
+	delayedPendingGauge.Update(int64(pending))
+	if pending == 0 {
+		delayedPendingMaxAgeGauge.Update(0)
+		return nil
+	}
+	oldest, err := d.inbox.GetDelayedMessage(ctx, read)
+	if err != nil {
+		return err
+	}
+	age := messageAge(oldest)
+	delayedPendingMaxAgeGauge.Update(int64(age / time.Second))
+
+	config := d.config()
+	if config.ForceInclusionWarningFraction <= 0 {
+		return nil
+	}
+	if time.Since(d.forceInclusionDelayUpdated) > forceInclusionDelayRefresh {
+		_, _, delaySeconds, _, err := d.seqInbox.MaxTimeVariation(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return fmt.Errorf("error getting sequencer inbox max time variation: %w", err)
+		}
+		d.forceInclusionDelay = arbmath.BigToUintSaturating(delaySeconds)
+		d.forceInclusionDelayUpdated = time.Now()
+		// #nosec G115
+		delayedForceInclusionDelayGauge.Update(int64(d.forceInclusionDelay))
+	}
+	forceInclusionDelay := time.Duration(d.forceInclusionDelay) * time.Second
+	if age < time.Duration(float64(forceInclusionDelay)*config.ForceInclusionWarningFraction) || time.Since(d.lastAgeWarning) < config.AgeWarningInterval {
+		return nil
+	}
+	d.lastAgeWarning = time.Now()
+	logLevel := log.Warn
+	if age >= forceInclusionDelay {
+		logLevel = log.Error
+	}
+	logLevel(
+		"delayed message is approaching force inclusion",
+		"delayedMessage", read,
+		"pending", pending,
+		"age", age.Round(time.Second),
+		"forceInclusionIn", (forceInclusionDelay - age).Round(time.Second),
+		"parentChainBlock", oldest.Header.BlockNumber,
+		"kind", oldest.Header.Kind,
+		"sender", oldest.Header.Poster,
+	)
+	return nil
+}
+
 // Dangerous: bypasses lockout check!
 func (d *DelayedSequencer) ForceSequenceDelayed(ctx context.Context) error {
 	lastBlockHeader, err := d.l1Reader.LastHeader(ctx)
@@ -225,6 +341,11 @@ func (d *DelayedSequencer) run(ctx context.Context) {
 			if err := d.trySequence(ctx, nextHeader); err != nil {
 				log.Error("Delayed sequencer error", "err", err)
 			}
+			if d.config().Enable {
+				if err := d.checkPendingAge(ctx); err != nil {
+					log.Warn("Delayed sequencer failed to check pending delayed messages", "err", err)
+				}
+			}
 		case <-ctx.Done():
 			log.Info("delayed sequencer: context done", "err", ctx.Err())
 			return
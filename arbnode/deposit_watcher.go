@@ -0,0 +1,282 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+// The stages a delayed message goes through before it's part of the chain's history
+const (
+	// The node hasn't read the message from the parent chain yet
+	DepositStateUnread = "unread"
+	// The message is in the delayed inbox, waiting for the sequencer to include it
+	DepositStateDelayed = "delayed"
+	// The message has been included in an L2 block
+	DepositStateSequenced = "sequenced"
+	// The message's L2 block has been posted to the parent chain in a batch
+	DepositStatePosted = "posted"
+)
+
+// Used to estimate when a message will be final enough to sequence if the parent chain's block time can't be measured
+const defaultParentChainBlockTime = 12 * time.Second
+
+type DepositInfo struct {
+	DelayedMessageIndex uint64         `json:"delayedMessageIndex"`
+	Kind                uint8          `json:"kind"`
+	Sender              common.Address `json:"sender"`
+	ParentChainBlock    uint64         `json:"parentChainBlock"`
+	Timestamp           uint64         `json:"timestamp"`
+	// The hashes of the L2 transactions the message will create, in order
+	L2TxHashes []common.Hash `json:"l2TxHashes"`
+	State      string        `json:"state"`
+	// Set once the message is sequenced
+	MessageIndex *uint64      `json:"messageIndex,omitempty"`
+	L2Block      *uint64      `json:"l2Block,omitempty"`
+	L2BlockHash  *common.Hash `json:"l2BlockHash,omitempty"`
+	Batch        *uint64      `json:"batch,omitempty"`
+	// Set while the message is waiting to be sequenced
+	EstimatedSequencingTime *uint64 `json:"estimatedSequencingTime,omitempty"`
+	// When anyone may force the message into the chain if the sequencer doesn't include it
+	ForceInclusionTime *uint64 `json:"forceInclusionTime,omitempty"`
+}
+
+type DepositStatus struct {
+	ParentChainTxHash common.Hash    `json:"parentChainTxHash"`
+	ParentChainBlock  uint64         `json:"parentChainBlock"`
+	Deposits          []*DepositInfo `json:"deposits"`
+}
+
+// DepositWatcher reports on the progress of the delayed messages created by a parent chain transaction.
+type DepositWatcher struct {
+	l1Reader         *headerreader.HeaderReader
+	bridge           *DelayedBridge
+	seqInbox         *bridgegen.SequencerInbox
+	tracker          *InboxTracker
+	streamer         *TransactionStreamer
+	chainId          *big.Int
+	genesisBlockNum  uint64
+	delayedSeqConfig DelayedSequencerConfigFetcher
+}
+
+func NewDepositWatcher(
+	l1Reader *headerreader.HeaderReader,
+	bridge *DelayedBridge,
+	seqInboxAddr common.Address,
+	tracker *InboxTracker,
+	streamer *TransactionStreamer,
+	chainId *big.Int,
+	genesisBlockNum uint64,
+	delayedSeqConfig DelayedSequencerConfigFetcher,
+) (*DepositWatcher, error) {
+	seqInbox, err := bridgegen.NewSequencerInbox(seqInboxAddr, l1Reader.Client())
+	if err != nil {
+		return nil, err
+	}
+	return &DepositWatcher{
+		l1Reader:         l1Reader,
+		bridge:           bridge,
+		seqInbox:         seqInbox,
+		tracker:          tracker,
+		streamer:         streamer,
+		chainId:          chainId,
+		genesisBlockNum:  genesisBlockNum,
+		delayedSeqConfig: delayedSeqConfig,
+	}, nil
+}
+
+func (w *DepositWatcher) Status(ctx context.Context, txHash common.Hash) (*DepositStatus, error) {
+	receipt, err := w.l1Reader.Client().TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent chain receipt: %w", err)
+	}
+	var logs []types.Log
+	for _, l := range receipt.Logs {
+		if l.Address == w.bridge.address && len(l.Topics) > 0 && l.Topics[0] == messageDeliveredID {
+			logs = append(logs, *l)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, errors.New("transaction didn't create any delayed messages")
+	}
+	messages, err := w.bridge.logsToDeliveredMessages(ctx, logs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delayedCount, err := w.tracker.GetDelayedCount()
+	if err != nil {
+		return nil, err
+	}
+	delayedRead, err := w.delayedMessagesRead()
+	if err != nil {
+		return nil, err
+	}
+	status := &DepositStatus{
+		ParentChainTxHash: txHash,
+		ParentChainBlock:  receipt.BlockNumber.Uint64(),
+	}
+	var estimate *uint64
+	for _, msg := range messages {
+		index := msg.Message.Header.RequestId.Big().Uint64()
+		info := &DepositInfo{
+			DelayedMessageIndex: index,
+			Kind:                msg.Message.Header.Kind,
+			Sender:              msg.Message.Header.Poster,
+			ParentChainBlock:    msg.ParentChainBlockNumber,
+			Timestamp:           msg.Message.Header.Timestamp,
+			L2TxHashes:          []common.Hash{},
+		}
+		txes, err := arbos.ParseL2Transactions(msg.Message, w.chainId)
+		if err != nil {
+			log.Debug("failed to parse delayed message transactions", "index", index, "err", err)
+		}
+		for _, tx := range txes {
+			info.L2TxHashes = append(info.L2TxHashes, tx.Hash())
+		}
+		switch {
+		case index >= delayedCount:
+			info.State = DepositStateUnread
+		case index >= delayedRead:
+			info.State = DepositStateDelayed
+		default:
+			info.State = DepositStateSequenced
+			if err := w.fillSequencedInfo(info); err != nil {
+				return nil, err
+			}
+		}
+		if info.State == DepositStateUnread || info.State == DepositStateDelayed {
+			if estimate == nil {
+				estimate, err = w.estimateSequencingTime(ctx, msg.ParentChainBlockNumber)
+				if err != nil {
+					log.Warn("failed to estimate delayed message sequencing time", "err", err)
+				}
+			}
+			info.EstimatedSequencingTime = estimate
+			info.ForceInclusionTime = w.forceInclusionTime(ctx, msg.Message.Header.Timestamp)
+		}
+		status.Deposits = append(status.Deposits, info)
+	}
+	return status, nil
+}
+
+// delayedMessagesRead returns how many delayed messages have been sequenced.
+func (w *DepositWatcher) delayedMessagesRead() (uint64, error) {
+	count, err := w.streamer.GetMessageCount()
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	msg, err := w.streamer.GetMessage(count - 1)
+	if err != nil {
+		return 0, err
+	}
+	return msg.DelayedMessagesRead, nil
+}
+
+// fillSequencedInfo finds the L2 block and batch a sequenced delayed message was included in.
+func (w *DepositWatcher) fillSequencedInfo(info *DepositInfo) error {
+	count, err := w.streamer.GetMessageCount()
+	if err != nil {
+		return err
+	}
+	// Find the first message which had read past the delayed message
+	low, high := arbutil.MessageIndex(0), count
+	for low < high {
+		mid := low + (high-low)/2
+		msg, err := w.streamer.GetMessage(mid)
+		if err != nil {
+			return err
+		}
+		if msg.DelayedMessagesRead > info.DelayedMessageIndex {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	pos := low
+	if pos >= count {
+		return fmt.Errorf("sequenced delayed message %v not found", info.DelayedMessageIndex)
+	}
+	messageIndex := uint64(pos)
+	info.MessageIndex = &messageIndex
+	// #nosec G115
+	l2Block := uint64(arbutil.MessageCountToBlockNumber(pos+1, w.genesisBlockNum))
+	info.L2Block = &l2Block
+	if result, err := w.streamer.ResultAtCount(pos + 1); err == nil {
+		info.L2BlockHash = &result.BlockHash
+	}
+	batch, found, err := w.tracker.FindInboxBatchContainingMessage(pos)
+	if err != nil {
+		return err
+	}
+	if found {
+		info.State = DepositStatePosted
+		info.Batch = &batch
+	}
+	return nil
+}
+
+// estimateSequencingTime estimates when the delayed sequencer will consider a parent chain block final enough to sequence its messages.
+func (w *DepositWatcher) estimateSequencingTime(ctx context.Context, parentChainBlock uint64) (*uint64, error) {
+	config := w.delayedSeqConfig()
+	latest, err := w.l1Reader.LastHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var final *types.Header
+	if config.UseMergeFinality && headerreader.HeaderIndicatesFinalitySupport(latest) {
+		if config.RequireFullFinality {
+			final, err = w.l1Reader.LatestFinalizedBlockHeader(ctx)
+		} else {
+			final, err = w.l1Reader.LatestSafeBlockHeader(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		number := arbmath.SaturatingUSub(latest.Number.Uint64(), arbmath.SaturatingUCast[uint64](config.FinalizeDistance))
+		final, err = w.l1Reader.Client().HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+	}
+	// #nosec G115
+	now := uint64(time.Now().Unix())
+	finalNumber := final.Number.Uint64()
+	if parentChainBlock <= finalNumber {
+		return &now, nil
+	}
+	// The final block advances at about the parent chain's block rate
+	blockTime := uint64(defaultParentChainBlockTime / time.Second)
+	if latest.Number.Uint64() > finalNumber && latest.Time > final.Time {
+		blockTime = arbmath.DivCeil(latest.Time-final.Time, latest.Number.Uint64()-finalNumber)
+	}
+	estimate := now + (parentChainBlock-finalNumber)*blockTime
+	return &estimate, nil
+}
+
+func (w *DepositWatcher) forceInclusionTime(ctx context.Context, timestamp uint64) *uint64 {
+	_, _, delaySeconds, _, err := w.seqInbox.MaxTimeVariation(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		log.Warn("failed to get sequencer inbox max time variation", "err", err)
+		return nil
+	}
+	deadline := arbmath.SaturatingUAdd(timestamp, arbmath.BigToUintSaturating(delaySeconds))
+	return &deadline
+}
@@ -0,0 +1,318 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+// A feed audit export holds the sequencer feed messages for a range of messages together with the batches
+// posted for them, so a third party can check the sequencer posted exactly what it promised on the feed.
+const FeedAuditFormatVersion = 1
+
+type FeedAuditBatch struct {
+	SequenceNumber      uint64               `json:"sequenceNumber"`
+	ParentChainBlock    uint64               `json:"parentChainBlock"`
+	BlockHash           common.Hash          `json:"blockHash"`
+	FirstMessage        arbutil.MessageIndex `json:"firstMessage"`
+	MessageCount        arbutil.MessageIndex `json:"messageCount"`
+	DelayedMessagesRead uint64               `json:"delayedMessagesRead"` // before the batch
+	Data                hexutil.Bytes        `json:"data"`
+}
+
+type FeedAuditDelayedMessage struct {
+	Index   uint64                        `json:"index"`
+	Message *arbostypes.L1IncomingMessage `json:"message"`
+}
+
+type FeedAuditExport struct {
+	FormatVersion   uint64                    `json:"formatVersion"`
+	ChainID         uint64                    `json:"chainId"`
+	FirstMessage    arbutil.MessageIndex      `json:"firstMessage"`
+	EndMessage      arbutil.MessageIndex      `json:"endMessage"`
+	FeedMessages    []*m.BroadcastFeedMessage `json:"feedMessages"`
+	Batches         []FeedAuditBatch          `json:"batches"`
+	DelayedMessages []FeedAuditDelayedMessage `json:"delayedMessages"`
+}
+
+// SignedFeedAuditExport is an export signed by whoever produced it, over the keccak256 of Export.
+type SignedFeedAuditExport struct {
+	Export    json.RawMessage `json:"export"`
+	Signer    common.Address  `json:"signer"`
+	Signature hexutil.Bytes   `json:"signature"`
+}
+
+// FeedAuditBatchReader returns a posted batch, serialized with its header, and the hash of the parent
+// chain block it was posted in.
+type FeedAuditBatchReader func(ctx context.Context, seqNum uint64, parentChainBlock uint64) ([]byte, common.Hash, error)
+
+// BuildFeedAuditExport collects the batches posted for messages [first, end) and the delayed messages they
+// read from the tracker's database, along with the feed messages recorded for the range.
+func BuildFeedAuditExport(
+	ctx context.Context,
+	tracker *InboxTracker,
+	readBatch FeedAuditBatchReader,
+	chainId uint64,
+	feed []*m.BroadcastFeedMessage,
+	first arbutil.MessageIndex,
+	end arbutil.MessageIndex,
+) (*FeedAuditExport, error) {
+	if end <= first {
+		return nil, fmt.Errorf("empty message range [%v, %v)", first, end)
+	}
+	firstBatch, found, err := tracker.FindInboxBatchContainingMessage(first)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("message %v isn't in a posted batch yet", first)
+	}
+	lastBatch, found, err := tracker.FindInboxBatchContainingMessage(end - 1)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("message %v isn't in a posted batch yet", end-1)
+	}
+	export := &FeedAuditExport{
+		FormatVersion: FeedAuditFormatVersion,
+		ChainID:       chainId,
+		FirstMessage:  first,
+		EndMessage:    end,
+	}
+	for _, msg := range feed {
+		if msg.SequenceNumber >= first && msg.SequenceNumber < end {
+			export.FeedMessages = append(export.FeedMessages, msg)
+		}
+	}
+	var prev BatchMetadata
+	if firstBatch > 0 {
+		prev, err = tracker.GetBatchMetadata(firstBatch - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for seqNum := firstBatch; seqNum <= lastBatch; seqNum++ {
+		meta, err := tracker.GetBatchMetadata(seqNum)
+		if err != nil {
+			return nil, err
+		}
+		data, blockHash, err := readBatch(ctx, seqNum, meta.ParentChainBlock)
+		if err != nil {
+			return nil, fmt.Errorf("reading batch %v: %w", seqNum, err)
+		}
+		export.Batches = append(export.Batches, FeedAuditBatch{
+			SequenceNumber:      seqNum,
+			ParentChainBlock:    meta.ParentChainBlock,
+			BlockHash:           blockHash,
+			FirstMessage:        prev.MessageCount,
+			MessageCount:        meta.MessageCount - prev.MessageCount,
+			DelayedMessagesRead: prev.DelayedMessageCount,
+			Data:                data,
+		})
+		for i := prev.DelayedMessageCount; i < meta.DelayedMessageCount; i++ {
+			msg, err := readFeedAuditDelayedMessage(tracker.db, i)
+			if err != nil {
+				return nil, fmt.Errorf("reading delayed message %v: %w", i, err)
+			}
+			export.DelayedMessages = append(export.DelayedMessages, FeedAuditDelayedMessage{Index: i, Message: msg})
+		}
+		prev = meta
+	}
+	return export, nil
+}
+
+// readFeedAuditDelayedMessage reads a delayed message as it was posted. Unlike the inbox tracker, it doesn't
+// fill in batch posting reports' gas costs, which need the inbox reader and aren't compared by the audit.
+func readFeedAuditDelayedMessage(db ethdb.KeyValueReader, seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+	data, err := getOptional(db, dbKey(rlpDelayedMessagePrefix, seqNum))
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		if len(data) < 32 {
+			return nil, errors.New("delayed message new entry missing accumulator")
+		}
+		var msg *arbostypes.L1IncomingMessage
+		err = rlp.DecodeBytes(data[32:], &msg)
+		return msg, err
+	}
+	data, err = db.Get(dbKey(legacyDelayedMessagePrefix, seqNum))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 32 {
+		return nil, errors.New("delayed message legacy entry missing accumulator")
+	}
+	return arbostypes.ParseIncomingL1Message(bytes.NewReader(data[32:]), nil)
+}
+
+// SignFeedAuditExport signs the JSON encoding of the export.
+func SignFeedAuditExport(export *FeedAuditExport, signer signature.DataSignerFunc, signerAddr common.Address) (*SignedFeedAuditExport, error) {
+	encoded, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer(crypto.Keccak256(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return &SignedFeedAuditExport{Export: encoded, Signer: signerAddr, Signature: sig}, nil
+}
+
+// Open checks the export's signature and decodes it.
+func (s *SignedFeedAuditExport) Open() (*FeedAuditExport, error) {
+	pubKey, err := crypto.SigToPub(crypto.Keccak256(s.Export), s.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export signature: %w", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubKey); signer != s.Signer {
+		return nil, fmt.Errorf("export is signed by %v, not the claimed %v", signer, s.Signer)
+	}
+	var export FeedAuditExport
+	if err := json.Unmarshal(s.Export, &export); err != nil {
+		return nil, err
+	}
+	if export.FormatVersion != FeedAuditFormatVersion {
+		return nil, fmt.Errorf("unsupported feed audit format version %v", export.FormatVersion)
+	}
+	return &export, nil
+}
+
+type FeedAuditReport struct {
+	MessagesChecked uint64   `json:"messagesChecked"`
+	Issues          []string `json:"issues"`
+}
+
+// VerifyFeedAudit derives the messages of every batch in the export the way a node does, and reports each
+// feed message in the range that doesn't match what was posted, or that isn't signed by one of the sequencers.
+// Feed signatures aren't checked if sequencers is empty. chainConfig is the config of the export's chain, or nil
+// if it isn't known, in which case batches using format extensions that depend on it are reported as malformed.
+func VerifyFeedAudit(ctx context.Context, export *FeedAuditExport, sequencers []common.Address, dapReaders []daprovider.Reader, chainConfig *params.ChainConfig) (*FeedAuditReport, error) {
+	report := &FeedAuditReport{}
+	addIssue := func(format string, args ...interface{}) {
+		report.Issues = append(report.Issues, fmt.Sprintf(format, args...))
+	}
+
+	feed := make(map[arbutil.MessageIndex]*m.BroadcastFeedMessage, len(export.FeedMessages))
+	for _, msg := range export.FeedMessages {
+		if msg.Message.Message == nil || msg.Message.Message.Header == nil {
+			addIssue("feed message %v is empty", msg.SequenceNumber)
+			continue
+		}
+		if _, exists := feed[msg.SequenceNumber]; exists {
+			addIssue("feed message %v appears more than once", msg.SequenceNumber)
+		}
+		feed[msg.SequenceNumber] = msg
+		if len(sequencers) == 0 {
+			continue
+		}
+		hash, err := msg.Hash(export.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		if len(msg.Signature) == 0 {
+			addIssue("feed message %v isn't signed", msg.SequenceNumber)
+			continue
+		}
+		pubKey, err := crypto.SigToPub(hash.Bytes(), msg.Signature)
+		if err != nil {
+			addIssue("feed message %v has an invalid signature: %v", msg.SequenceNumber, err)
+			continue
+		}
+		signer := crypto.PubkeyToAddress(*pubKey)
+		authorized := false
+		for _, sequencer := range sequencers {
+			authorized = authorized || signer == sequencer
+		}
+		if !authorized {
+			addIssue("feed message %v is signed by %v, not a sequencer", msg.SequenceNumber, signer)
+		}
+	}
+
+	delayed := make(map[uint64]*arbostypes.L1IncomingMessage, len(export.DelayedMessages))
+	for _, msg := range export.DelayedMessages {
+		delayed[msg.Index] = msg.Message
+	}
+	readDelayed := func(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+		msg, ok := delayed[seqNum]
+		if !ok {
+			return nil, fmt.Errorf("delayed message %v isn't in the export", seqNum)
+		}
+		return msg, nil
+	}
+
+	checked := make(map[arbutil.MessageIndex]bool)
+	for i, batch := range export.Batches {
+		if i > 0 {
+			prev := export.Batches[i-1]
+			if batch.SequenceNumber != prev.SequenceNumber+1 || batch.FirstMessage != prev.FirstMessage+prev.MessageCount {
+				addIssue("batch %v doesn't follow batch %v", batch.SequenceNumber, prev.SequenceNumber)
+			}
+		}
+		check, err := arbstate.CheckBatch(ctx, batch.SequenceNumber, batch.BlockHash, batch.Data, batch.DelayedMessagesRead, dapReaders, readDelayed, chainConfig)
+		if err != nil {
+			addIssue("batch %v can't be read: %v", batch.SequenceNumber, err)
+			continue
+		}
+		for _, issue := range check.Issues {
+			addIssue("batch %v: %v", batch.SequenceNumber, issue)
+		}
+		// #nosec G115
+		if arbutil.MessageIndex(len(check.Messages)) != batch.MessageCount {
+			addIssue("batch %v has %v messages but the export claims %v", batch.SequenceNumber, len(check.Messages), batch.MessageCount)
+		}
+		for j, posted := range check.Messages {
+			// #nosec G115
+			pos := batch.FirstMessage + arbutil.MessageIndex(j)
+			if pos < export.FirstMessage || pos >= export.EndMessage {
+				continue
+			}
+			checked[pos] = true
+			report.MessagesChecked++
+			feedMsg, ok := feed[pos]
+			if !ok {
+				addIssue("message %v was posted in batch %v but isn't in the feed", pos, batch.SequenceNumber)
+				continue
+			}
+			if !feedMsg.Message.Message.Equals(posted.Message) || feedMsg.Message.DelayedMessagesRead != posted.DelayedMessagesRead {
+				addIssue("feed message %v doesn't match what was posted in batch %v", pos, batch.SequenceNumber)
+			}
+		}
+	}
+	for pos := export.FirstMessage; pos < export.EndMessage; pos++ {
+		if !checked[pos] {
+			addIssue("message %v isn't in any batch of the export", pos)
+		}
+	}
+	return report, nil
+}
+
+var ErrFeedAuditFailed = errors.New("feed doesn't match the posted batches")
+
+// Err returns ErrFeedAuditFailed if the report has any issue.
+func (r *FeedAuditReport) Err() error {
+	if len(r.Issues) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %v issues", ErrFeedAuditFailed, len(r.Issues))
+}
@@ -0,0 +1,180 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+const feedAuditTestChainId = 412346
+
+func buildFeedAuditTestBatch(t *testing.T, afterDelayed uint64, segments ...[]byte) []byte {
+	t.Helper()
+	var batch []byte
+	for _, field := range []uint64{100, 200, 10, 20, afterDelayed} {
+		batch = binary.BigEndian.AppendUint64(batch, field)
+	}
+	var encoded bytes.Buffer
+	for _, segment := range segments {
+		Require(t, rlp.Encode(&encoded, segment))
+	}
+	compressed, err := arbcompress.CompressWell(encoded.Bytes())
+	Require(t, err)
+	batch = append(batch, daprovider.BrotliMessageHeaderByte)
+	return append(batch, compressed...)
+}
+
+func signFeedAuditTestMessage(t *testing.T, msg *m.BroadcastFeedMessage, key *ecdsa.PrivateKey) {
+	t.Helper()
+	hash, err := msg.Hash(feedAuditTestChainId)
+	Require(t, err)
+	msg.Signature, err = crypto.Sign(hash.Bytes(), key)
+	Require(t, err)
+}
+
+func requireFeedAuditIssue(t *testing.T, report *FeedAuditReport, substring string) {
+	t.Helper()
+	for _, issue := range report.Issues {
+		if strings.Contains(issue, substring) {
+			return
+		}
+	}
+	Fail(t, "missing issue containing", substring, "in", report.Issues)
+}
+
+func TestFeedAudit(t *testing.T) {
+	ctx := context.Background()
+	l2Message := func(data ...byte) []byte {
+		return append([]byte{arbstate.BatchSegmentKindL2Message}, data...)
+	}
+	batches := [][]byte{
+		buildFeedAuditTestBatch(t, 1, l2Message(1, 2, 3), []byte{arbstate.BatchSegmentKindDelayedMessages}),
+		buildFeedAuditTestBatch(t, 1, l2Message(4, 5)),
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	delayed, err := rlp.EncodeToBytes(&arbostypes.TestIncomingMessageWithRequestId)
+	Require(t, err)
+	Require(t, db.Put(dbKey(rlpDelayedMessagePrefix, 0), append(common.Hash{1}.Bytes(), delayed...)))
+	putRLP(t, db, delayedMessageCountKey, uint64(1))
+	for i, count := range []arbutil.MessageIndex{2, 3} {
+		putRLP(t, db, dbKey(sequencerBatchMetaPrefix, uint64(i)), BatchMetadata{
+			MessageCount:        count,
+			DelayedMessageCount: 1,
+			ParentChainBlock:    100 + uint64(i),
+		})
+	}
+	putRLP(t, db, sequencerBatchCountKey, uint64(len(batches)))
+	tracker, err := NewInboxTracker(db, nil, nil, SnapSyncConfig{})
+	Require(t, err)
+	readBatch := func(ctx context.Context, seqNum uint64, parentChainBlock uint64) ([]byte, common.Hash, error) {
+		if parentChainBlock != 100+seqNum {
+			return nil, common.Hash{}, errors.New("wrong parent chain block")
+		}
+		return batches[seqNum], common.Hash{byte(seqNum)}, nil
+	}
+
+	// An honest sequencer's feed has exactly the messages a node derives from its batches
+	sequencerKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencer := crypto.PubkeyToAddress(sequencerKey.PublicKey)
+	var feed []*m.BroadcastFeedMessage
+	delayedRead := uint64(0)
+	for i, batch := range batches {
+		check, err := arbstate.CheckBatch(ctx, uint64(i), common.Hash{byte(i)}, batch, delayedRead, nil, func(uint64) (*arbostypes.L1IncomingMessage, error) {
+			msg := arbostypes.TestIncomingMessageWithRequestId
+			return &msg, nil
+		})
+		Require(t, err)
+		for _, msg := range check.Messages {
+			feedMsg := &m.BroadcastFeedMessage{SequenceNumber: arbutil.MessageIndex(len(feed)), Message: *msg}
+			signFeedAuditTestMessage(t, feedMsg, sequencerKey)
+			feed = append(feed, feedMsg)
+		}
+		delayedRead = check.AfterDelayedMessages
+	}
+
+	exporterKey, err := crypto.GenerateKey()
+	Require(t, err)
+	exportAndVerify := func(feed []*m.BroadcastFeedMessage, first, end arbutil.MessageIndex) *FeedAuditReport {
+		t.Helper()
+		export, err := BuildFeedAuditExport(ctx, tracker, readBatch, feedAuditTestChainId, feed, first, end)
+		Require(t, err)
+		signed, err := SignFeedAuditExport(export, signature.DataSignerFromPrivateKey(exporterKey), crypto.PubkeyToAddress(exporterKey.PublicKey))
+		Require(t, err)
+		encoded, err := json.Marshal(signed)
+		Require(t, err)
+		var decoded SignedFeedAuditExport
+		Require(t, json.Unmarshal(encoded, &decoded))
+		opened, err := decoded.Open()
+		Require(t, err)
+		report, err := VerifyFeedAudit(ctx, opened, []common.Address{sequencer}, nil, nil)
+		Require(t, err)
+		return report
+	}
+
+	report := exportAndVerify(feed, 0, 3)
+	Require(t, report.Err())
+	if report.MessagesChecked != 3 {
+		Fail(t, "checked", report.MessagesChecked, "messages instead of 3")
+	}
+	report = exportAndVerify(feed, 2, 3)
+	Require(t, report.Err())
+	if report.MessagesChecked != 1 {
+		Fail(t, "checked", report.MessagesChecked, "messages instead of 1")
+	}
+
+	// The feed promised a different message than was posted
+	tampered := *feed[2]
+	tampered.Message.Message = &arbostypes.L1IncomingMessage{Header: feed[2].Message.Message.Header, L2msg: []byte{6}}
+	signFeedAuditTestMessage(t, &tampered, sequencerKey)
+	report = exportAndVerify([]*m.BroadcastFeedMessage{feed[0], feed[1], &tampered}, 0, 3)
+	if !errors.Is(report.Err(), ErrFeedAuditFailed) {
+		Fail(t, "tampered feed passed the audit")
+	}
+	requireFeedAuditIssue(t, report, "feed message 2 doesn't match what was posted in batch 1")
+
+	// Messages missing from the feed or signed by someone else
+	otherKey, err := crypto.GenerateKey()
+	Require(t, err)
+	forged := *feed[0]
+	signFeedAuditTestMessage(t, &forged, otherKey)
+	report = exportAndVerify([]*m.BroadcastFeedMessage{&forged, feed[2]}, 0, 3)
+	requireFeedAuditIssue(t, report, "feed message 0 is signed by")
+	requireFeedAuditIssue(t, report, "message 1 was posted in batch 0 but isn't in the feed")
+
+	// Changing the export breaks the exporter's signature
+	export, err := BuildFeedAuditExport(ctx, tracker, readBatch, feedAuditTestChainId, feed, 0, 3)
+	Require(t, err)
+	signed, err := SignFeedAuditExport(export, signature.DataSignerFromPrivateKey(exporterKey), crypto.PubkeyToAddress(exporterKey.PublicKey))
+	Require(t, err)
+	signed.Export = bytes.Replace(signed.Export, []byte(`"endMessage":3`), []byte(`"endMessage":2`), 1)
+	if _, err := signed.Open(); err == nil {
+		Fail(t, "opened an export that was changed after signing")
+	}
+
+	if _, err := BuildFeedAuditExport(ctx, tracker, readBatch, feedAuditTestChainId, feed, 0, 4); err == nil {
+		Fail(t, "exported messages that aren't posted yet")
+	}
+}
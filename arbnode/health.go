@@ -0,0 +1,223 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type HealthConfig struct {
+	Addr              string        `koanf:"addr"`
+	RequireSynced     bool          `koanf:"require-synced" reload:"hot"`
+	MaxFeedSilence    time.Duration `koanf:"max-feed-silence" reload:"hot"`
+	MaxInboxLag       uint64        `koanf:"max-inbox-lag" reload:"hot"`
+	MaxBatchBacklog   uint64        `koanf:"max-batch-backlog" reload:"hot"`
+	MaxValidatorLag   uint64        `koanf:"max-validator-lag" reload:"hot"`
+	DASTimeout        time.Duration `koanf:"das-timeout" reload:"hot"`
+	ReadHeaderTimeout time.Duration `koanf:"read-header-timeout"`
+}
+
+var DefaultHealthConfig = HealthConfig{
+	Addr:              "",
+	RequireSynced:     true,
+	MaxFeedSilence:    0,
+	MaxInboxLag:       10,
+	MaxBatchBacklog:   20,
+	MaxValidatorLag:   10000,
+	DASTimeout:        5 * time.Second,
+	ReadHeaderTimeout: 5 * time.Second,
+}
+
+func HealthConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".addr", DefaultHealthConfig.Addr, "if non-empty, launch an HTTP service binding to this address serving /healthz and /readyz")
+	f.Bool(prefix+".require-synced", DefaultHealthConfig.RequireSynced, "only report ready once the node is synced")
+	f.Duration(prefix+".max-feed-silence", DefaultHealthConfig.MaxFeedSilence, "not ready if the sequencer feed has been silent for longer than this (0 to disable)")
+	f.Uint64(prefix+".max-inbox-lag", DefaultHealthConfig.MaxInboxLag, "not ready if the inbox reader has seen more than this many batches it hasn't read (0 to disable)")
+	f.Uint64(prefix+".max-batch-backlog", DefaultHealthConfig.MaxBatchBacklog, "not ready if the batch poster's backlog is more than this many batches (0 to disable)")
+	f.Uint64(prefix+".max-validator-lag", DefaultHealthConfig.MaxValidatorLag, "not ready if the block validator is more than this many messages behind (0 to disable)")
+	f.Duration(prefix+".das-timeout", DefaultHealthConfig.DASTimeout, "not ready if the data availability service doesn't answer within this long (0 to disable)")
+	f.Duration(prefix+".read-header-timeout", DefaultHealthConfig.ReadHeaderTimeout, "the health server's read header timeout")
+}
+
+// ModuleHealth is one module's entry in a health report.
+type ModuleHealth struct {
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail"`
+}
+
+type HealthReport struct {
+	Live    bool                    `json:"live"`
+	Ready   bool                    `json:"ready"`
+	Modules map[string]ModuleHealth `json:"modules"`
+}
+
+// HealthServer serves Kubernetes style probes. /healthz answers as long as the node is running, so that a
+// slow module doesn't get the node restarted, while /readyz only answers 200 once every module is within
+// its configured thresholds. Both return the per-module report.
+type HealthServer struct {
+	stopwaiter.StopWaiter
+	config   func() *HealthConfig
+	node     *Node
+	daReader das.DataAvailabilityServiceReader
+}
+
+func NewHealthServer(config func() *HealthConfig, node *Node, daReader das.DataAvailabilityServiceReader) *HealthServer {
+	return &HealthServer{
+		config:   config,
+		node:     node,
+		daReader: daReader,
+	}
+}
+
+func (h *HealthServer) checkSynced() error {
+	if h.node.SyncMonitor.Synced() {
+		return nil
+	}
+	return errors.New("not synced")
+}
+
+func (h *HealthServer) checkFeed(maxSilence time.Duration) error {
+	last := h.node.BroadcastClients.LastMessageTime()
+	if last.IsZero() {
+		return errors.New("no feed messages received")
+	}
+	if silence := time.Since(last); silence > maxSilence {
+		return fmt.Errorf("no feed messages for %v", silence.Truncate(time.Second))
+	}
+	return nil
+}
+
+func (h *HealthServer) checkInbox(maxLag uint64) error {
+	seen := h.node.InboxReader.GetLastSeenBatchCount()
+	read := h.node.InboxReader.GetLastReadBatchCount()
+	if seen > read && seen-read > maxLag {
+		return fmt.Errorf("%v batches seen but not read", seen-read)
+	}
+	return nil
+}
+
+func (h *HealthServer) checkBatchPoster(maxBacklog uint64) error {
+	if backlog := h.node.BatchPoster.GetBacklogEstimate(); backlog > maxBacklog {
+		return fmt.Errorf("backlog of %v batches", backlog)
+	}
+	return nil
+}
+
+func (h *HealthServer) checkValidator(maxLag uint64) error {
+	processed, err := h.node.TxStreamer.GetProcessedMessageCount()
+	if err != nil {
+		return err
+	}
+	validated := h.node.BlockValidator.GetValidated()
+	if processed > validated && uint64(processed-validated) > maxLag {
+		return fmt.Errorf("%v messages behind", processed-validated)
+	}
+	return nil
+}
+
+func (h *HealthServer) checkDAS(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err := h.daReader.ExpirationPolicy(ctx)
+	return err
+}
+
+// Report checks every module the node runs that has a threshold configured.
+func (h *HealthServer) Report(ctx context.Context) *HealthReport {
+	config := h.config()
+	report := &HealthReport{
+		Live:    true,
+		Ready:   true,
+		Modules: make(map[string]ModuleHealth),
+	}
+	record := func(module string, err error) {
+		if err != nil {
+			report.Ready = false
+			report.Modules[module] = ModuleHealth{Healthy: false, Detail: err.Error()}
+		} else {
+			report.Modules[module] = ModuleHealth{Healthy: true, Detail: "ok"}
+		}
+	}
+	if config.RequireSynced {
+		record("sync", h.checkSynced())
+	}
+	if config.MaxFeedSilence != 0 && h.node.BroadcastClients != nil {
+		record("feed", h.checkFeed(config.MaxFeedSilence))
+	}
+	if config.MaxInboxLag != 0 && h.node.InboxReader != nil {
+		record("inbox", h.checkInbox(config.MaxInboxLag))
+	}
+	if config.MaxBatchBacklog != 0 && h.node.BatchPoster != nil {
+		record("batch-poster", h.checkBatchPoster(config.MaxBatchBacklog))
+	}
+	if config.MaxValidatorLag != 0 && h.node.BlockValidator != nil {
+		record("validator", h.checkValidator(config.MaxValidatorLag))
+	}
+	if config.DASTimeout != 0 && h.daReader != nil {
+		record("das", h.checkDAS(ctx, config.DASTimeout))
+	}
+	return report
+}
+
+func (h *HealthServer) serveReport(w http.ResponseWriter, r *http.Request, readiness bool) {
+	report := h.Report(r.Context())
+	status := http.StatusOK
+	if readiness && !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Warn("error writing health report", "err", err)
+	}
+}
+
+func (h *HealthServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h.serveReport(w, r, false)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		h.serveReport(w, r, true)
+	})
+	return mux
+}
+
+func (h *HealthServer) launchServer(ctx context.Context) {
+	server := &http.Server{
+		Addr:              h.config().Addr,
+		Handler:           h.Handler(),
+		ReadHeaderTimeout: h.config().ReadHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		err := server.Shutdown(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("error shutting down health server", "err", err)
+		}
+	}()
+
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Warn("error serving health server", "err", err)
+	}
+}
+
+func (h *HealthServer) Start(ctxIn context.Context) {
+	h.StopWaiter.Start(ctxIn, h)
+	h.LaunchThread(h.launchServer)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+)
+
+type healthTestDASReader struct {
+	err error
+}
+
+func (r *healthTestDASReader) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return nil, r.err
+}
+
+func (r *healthTestDASReader) ExpirationPolicy(ctx context.Context) (daprovider.ExpirationPolicy, error) {
+	return daprovider.KeepForever, r.err
+}
+
+func (r *healthTestDASReader) String() string {
+	return "healthTestDASReader"
+}
+
+func getHealthReport(t *testing.T, handler http.Handler, path string) (int, *HealthReport) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var report HealthReport
+	Require(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	return recorder.Code, &report
+}
+
+func TestHealthServer(t *testing.T) {
+	config := DefaultHealthConfig
+	daReader := &healthTestDASReader{err: errors.New("unreachable")}
+	node := &Node{SyncMonitor: NewSyncMonitor(func() *SyncMonitorConfig { return &TestSyncMonitorConfig })}
+	handler := NewHealthServer(func() *HealthConfig { return &config }, node, daReader).Handler()
+
+	// An unsynced node with an unreachable DAS is live but not ready
+	code, report := getHealthReport(t, handler, "/healthz")
+	if code != http.StatusOK || !report.Live || report.Ready {
+		Fail(t, "unexpected liveness", code, report)
+	}
+	code, report = getHealthReport(t, handler, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Ready {
+		Fail(t, "unexpected readiness", code, report)
+	}
+	if report.Modules["sync"].Healthy || report.Modules["das"].Healthy {
+		Fail(t, "unexpected module health", report.Modules)
+	}
+	if _, ok := report.Modules["inbox"]; ok {
+		Fail(t, "reported a module the node doesn't run", report.Modules)
+	}
+
+	// Modules with checks disabled don't hold up readiness
+	config.RequireSynced = false
+	daReader.err = nil
+	code, report = getHealthReport(t, handler, "/readyz")
+	if code != http.StatusOK || !report.Ready || !report.Modules["das"].Healthy {
+		Fail(t, "unexpected readiness", code, report)
+	}
+	if _, ok := report.Modules["sync"]; ok {
+		Fail(t, "reported a disabled check", report.Modules)
+	}
+}
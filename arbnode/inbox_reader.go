@@ -10,29 +10,33 @@ import (
 	"math"
 	"math/big"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
 type InboxReaderConfig struct {
-	DelayBlocks         uint64        `koanf:"delay-blocks" reload:"hot"`
-	CheckDelay          time.Duration `koanf:"check-delay" reload:"hot"`
-	HardReorg           bool          `koanf:"hard-reorg" reload:"hot"`
-	MinBlocksToRead     uint64        `koanf:"min-blocks-to-read" reload:"hot"`
-	DefaultBlocksToRead uint64        `koanf:"default-blocks-to-read" reload:"hot"`
-	TargetMessagesRead  uint64        `koanf:"target-messages-read" reload:"hot"`
-	MaxBlocksToRead     uint64        `koanf:"max-blocks-to-read" reload:"hot"`
-	ReadMode            string        `koanf:"read-mode" reload:"hot"`
+	DelayBlocks          uint64        `koanf:"delay-blocks" reload:"hot"`
+	CheckDelay           time.Duration `koanf:"check-delay" reload:"hot"`
+	HardReorg            bool          `koanf:"hard-reorg" reload:"hot"`
+	MinBlocksToRead      uint64        `koanf:"min-blocks-to-read" reload:"hot"`
+	DefaultBlocksToRead  uint64        `koanf:"default-blocks-to-read" reload:"hot"`
+	TargetMessagesRead   uint64        `koanf:"target-messages-read" reload:"hot"`
+	MaxBlocksToRead      uint64        `koanf:"max-blocks-to-read" reload:"hot"`
+	ReadMode             string        `koanf:"read-mode" reload:"hot"`
+	BatchPrefetchWorkers int           `koanf:"batch-prefetch-workers" reload:"hot"`
 }
 
 type InboxReaderConfigFetcher func() *InboxReaderConfig
@@ -57,28 +61,31 @@ func InboxReaderConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".target-messages-read", DefaultInboxReaderConfig.TargetMessagesRead, "if adjust-blocks-to-read is enabled, the target number of messages to read at once")
 	f.Uint64(prefix+".max-blocks-to-read", DefaultInboxReaderConfig.MaxBlocksToRead, "if adjust-blocks-to-read is enabled, the maximum number of blocks to read at once")
 	f.String(prefix+".read-mode", DefaultInboxReaderConfig.ReadMode, "mode to only read latest or safe or finalized L1 blocks. Enabling safe or finalized disables feed input and output. Defaults to latest. Takes string input, valid strings- latest, safe, finalized")
+	f.Int(prefix+".batch-prefetch-workers", DefaultInboxReaderConfig.BatchPrefetchWorkers, "number of sequencer batches to fetch and recover data availability payloads for in parallel when reading several at once (messages are still added in order and at most twice this many batches are fetched ahead of them, 1 or less disables prefetching)")
 }
 
 var DefaultInboxReaderConfig = InboxReaderConfig{
-	DelayBlocks:         0,
-	CheckDelay:          time.Minute,
-	HardReorg:           false,
-	MinBlocksToRead:     1,
-	DefaultBlocksToRead: 100,
-	TargetMessagesRead:  500,
-	MaxBlocksToRead:     2000,
-	ReadMode:            "latest",
+	DelayBlocks:          0,
+	CheckDelay:           time.Minute,
+	HardReorg:            false,
+	MinBlocksToRead:      1,
+	DefaultBlocksToRead:  100,
+	TargetMessagesRead:   500,
+	MaxBlocksToRead:      2000,
+	ReadMode:             "latest",
+	BatchPrefetchWorkers: 4,
 }
 
 var TestInboxReaderConfig = InboxReaderConfig{
-	DelayBlocks:         0,
-	CheckDelay:          time.Millisecond * 10,
-	HardReorg:           false,
-	MinBlocksToRead:     1,
-	DefaultBlocksToRead: 100,
-	TargetMessagesRead:  500,
-	MaxBlocksToRead:     2000,
-	ReadMode:            "latest",
+	DelayBlocks:          0,
+	CheckDelay:           time.Millisecond * 10,
+	HardReorg:            false,
+	MinBlocksToRead:      1,
+	DefaultBlocksToRead:  100,
+	TargetMessagesRead:   500,
+	MaxBlocksToRead:      2000,
+	ReadMode:             "latest",
+	BatchPrefetchWorkers: 4,
 }
 
 type InboxReader struct {
@@ -97,6 +104,9 @@ type InboxReader struct {
 	client         *ethclient.Client
 	l1Reader       *headerreader.HeaderReader
 
+	cancelRunMutex sync.Mutex
+	cancelRun      context.CancelFunc // cancels the current pass reading the parent chain
+
 	// Atomic
 	lastSeenBatchCount atomic.Uint64
 	lastReadBatchCount atomic.Uint64
@@ -107,6 +117,7 @@ func NewInboxReader(tracker *InboxTracker, client *ethclient.Client, l1Reader *h
 	if err != nil {
 		return nil, err
 	}
+	tracker.batchPrefetchWorkers = func() int { return config().BatchPrefetchWorkers }
 	return &InboxReader{
 		tracker:           tracker,
 		delayedBridge:     delayedBridge,
@@ -123,6 +134,11 @@ func (r *InboxReader) Start(ctxIn context.Context) error {
 	r.StopWaiter.Start(ctxIn, r)
 	hadError := false
 	r.CallIteratively(func(ctx context.Context) time.Duration {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		r.cancelRunMutex.Lock()
+		r.cancelRun = cancel
+		r.cancelRunMutex.Unlock()
 		err := r.run(ctx, hadError)
 		if err != nil && !errors.Is(err, context.Canceled) && !strings.Contains(err.Error(), "header not found") {
 			log.Warn("error reading inbox", "err", err)
@@ -672,6 +688,16 @@ func (r *InboxReader) getNextBlockToRead(ctx context.Context) (*big.Int, error)
 	return msgBlock, nil
 }
 
+// Restart abandons what the reader is in the middle of, starting it over from what the inbox tracker has
+// stored, as if the node had crashed and been restarted.
+func (r *InboxReader) Restart() {
+	r.cancelRunMutex.Lock()
+	defer r.cancelRunMutex.Unlock()
+	if r.cancelRun != nil {
+		r.cancelRun()
+	}
+}
+
 func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint64) ([]byte, common.Hash, error) {
 	metadata, err := r.tracker.GetBatchMetadata(seqNum)
 	if err != nil {
@@ -693,6 +719,63 @@ func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint6
 	return nil, common.Hash{}, fmt.Errorf("sequencer batch %v not found in L1 block %v (found batches %v)", seqNum, metadata.ParentChainBlock, seenBatches)
 }
 
+// GetBatchParentChainTxHash finds the parent chain transaction which posted a sequencer batch.
+func (r *InboxReader) GetBatchParentChainTxHash(ctx context.Context, seqNum uint64) (common.Hash, error) {
+	metadata, err := r.tracker.GetBatchMetadata(seqNum)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	blockNum := arbmath.UintToBig(metadata.ParentChainBlock)
+	seqBatches, err := r.sequencerInbox.LookupBatchesInRange(ctx, blockNum, blockNum)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for _, batch := range seqBatches {
+		if batch.SequenceNumber == seqNum {
+			return batch.rawLog.TxHash, nil
+		}
+	}
+	return common.Hash{}, fmt.Errorf("sequencer batch %v not found in L1 block %v", seqNum, metadata.ParentChainBlock)
+}
+
+// GetBatchDataProof proves which data a sequencer batch posted, against the accumulator the SequencerInbox stored for it.
+func (r *InboxReader) GetBatchDataProof(ctx context.Context, seqNum uint64) (*execution.BatchDataProof, error) {
+	metadata, err := r.tracker.GetBatchMetadata(seqNum)
+	if err != nil {
+		return nil, err
+	}
+	blockNum := arbmath.UintToBig(metadata.ParentChainBlock)
+	seqBatches, err := r.sequencerInbox.LookupBatchesInRange(ctx, blockNum, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range seqBatches {
+		if batch.SequenceNumber != seqNum {
+			continue
+		}
+		data, err := batch.Serialize(ctx, r.client)
+		if err != nil {
+			return nil, err
+		}
+		proof := &execution.BatchDataProof{
+			SequenceNumber:   seqNum,
+			DataHash:         crypto.Keccak256Hash(data),
+			BeforeAcc:        batch.BeforeInboxAcc,
+			DelayedAcc:       batch.AfterDelayedAcc,
+			AfterAcc:         batch.AfterInboxAcc,
+			ParentChainBlock: metadata.ParentChainBlock,
+		}
+		if crypto.Keccak256Hash(proof.BeforeAcc[:], proof.DataHash[:], proof.DelayedAcc[:]) != proof.AfterAcc {
+			return nil, fmt.Errorf("sequencer batch %v data doesn't match its accumulator", seqNum)
+		}
+		if proof.AfterAcc != metadata.Accumulator {
+			return nil, fmt.Errorf("sequencer batch %v accumulator %v differs from tracked %v", seqNum, proof.AfterAcc, metadata.Accumulator)
+		}
+		return proof, nil
+	}
+	return nil, fmt.Errorf("sequencer batch %v not found in L1 block %v", seqNum, metadata.ParentChainBlock)
+}
+
 func (r *InboxReader) GetLastReadBatchCount() uint64 {
 	return r.lastReadBatchCount.Load()
 }
@@ -41,17 +41,22 @@ type InboxTracker struct {
 	validator      *staker.BlockValidator
 	dapReaders     []daprovider.Reader
 	snapSyncConfig SnapSyncConfig
+	// set by the inbox reader, nil disables prefetching batches
+	batchPrefetchWorkers func() int
 
 	batchMetaMutex sync.Mutex
-	batchMeta      *containers.LruCache[uint64, BatchMetadata]
+	batchMeta      *containers.Cache[uint64, BatchMetadata]
 }
 
 func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, dapReaders []daprovider.Reader, snapSyncConfig SnapSyncConfig) (*InboxTracker, error) {
 	tracker := &InboxTracker{
-		db:             db,
-		txStreamer:     txStreamer,
-		dapReaders:     dapReaders,
-		batchMeta:      containers.NewLruCache[uint64, BatchMetadata](1000),
+		db:         db,
+		txStreamer: txStreamer,
+		dapReaders: dapReaders,
+		batchMeta: containers.NewCache[uint64, BatchMetadata](containers.CacheOpts[BatchMetadata]{
+			Capacity:      1000,
+			MetricsPrefix: "arb/inbox/batchmeta/cache",
+		}),
 		snapSyncConfig: snapSyncConfig,
 	}
 	return tracker, nil
@@ -599,15 +604,20 @@ type multiplexerBackend struct {
 	batches               []*SequencerInboxBatch
 	positionWithinMessage uint64
 
-	ctx    context.Context
-	client *ethclient.Client
-	inbox  *InboxTracker
+	ctx        context.Context
+	client     *ethclient.Client
+	inbox      *InboxTracker
+	prefetcher *batchPrefetcher
 }
 
 func (b *multiplexerBackend) PeekSequencerInbox() ([]byte, common.Hash, error) {
 	if len(b.batches) == 0 {
 		return nil, common.Hash{}, errors.New("read past end of specified sequencer batches")
 	}
+	if b.prefetcher != nil {
+		// Serialize isn't thread safe, so let the prefetch finish with it first
+		b.prefetcher.awaitSerialized(b.ctx, b.batches[0].SequenceNumber)
+	}
 	bytes, err := b.batches[0].Serialize(b.ctx, b.client)
 	return bytes, b.batches[0].BlockHash, err
 }
@@ -620,6 +630,9 @@ func (b *multiplexerBackend) AdvanceSequencerInbox() {
 	b.batchSeqNum++
 	if len(b.batches) > 0 {
 		b.batches = b.batches[1:]
+		if b.prefetcher != nil {
+			b.prefetcher.consumed()
+		}
 	}
 }
 
@@ -726,16 +739,27 @@ func (t *InboxTracker) AddSequencerBatches(ctx context.Context, client *ethclien
 		pos++
 	}
 
+	dapReaders := t.dapReaders
+	var prefetcher *batchPrefetcher
+	if t.batchPrefetchWorkers != nil {
+		if workers := t.batchPrefetchWorkers(); workers > 1 && len(batches) > 1 {
+			prefetcher = newBatchPrefetcher(ctx, client, batches, t.dapReaders, workers)
+			defer prefetcher.stop()
+			dapReaders = prefetcher.wrapReaders(t.dapReaders)
+		}
+	}
+
 	var messages []arbostypes.MessageWithMetadata
 	backend := &multiplexerBackend{
 		batchSeqNum: batches[0].SequenceNumber,
 		batches:     batches,
 
-		inbox:  t,
-		ctx:    ctx,
-		client: client,
+		inbox:      t,
+		ctx:        ctx,
+		client:     client,
+		prefetcher: prefetcher,
 	}
-	multiplexer := arbstate.NewInboxMultiplexer(backend, prevbatchmeta.DelayedMessageCount, t.dapReaders, daprovider.KeysetValidate)
+	multiplexer := arbstate.NewInboxMultiplexer(backend, prevbatchmeta.DelayedMessageCount, dapReaders, daprovider.KeysetValidate, t.txStreamer.ChainConfig())
 	batchMessageCounts := make(map[uint64]arbutil.MessageIndex)
 	currentpos := prevbatchmeta.MessageCount + 1
 	for {
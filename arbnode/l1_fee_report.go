@@ -0,0 +1,280 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+const l1FeeReportDateFormat = "2006-01-02"
+
+// L1FeeReportBatch is the parent chain cost of posting a batch, as reported to ArbOS.
+type L1FeeReportBatch struct {
+	BatchNumber      uint64         `json:"batchNumber"`
+	Poster           common.Address `json:"poster"`
+	ParentChainBlock uint64         `json:"parentChainBlock"`
+	// The L2 block ArbOS was told about the batch in, which is when the poster is reimbursed
+	ReportedInBlock uint64   `json:"reportedInBlock"`
+	ReportedAt      uint64   `json:"reportedAt"`
+	DataGas         uint64   `json:"dataGas"`
+	PerBatchGas     int64    `json:"perBatchGas"`
+	L1BaseFeeWei    *big.Int `json:"l1BaseFeeWei"`
+	CostWei         *big.Int `json:"costWei"`
+}
+
+// L1FeeReportDay compares the L1 fees ArbOS collected from transactions with the batch posting costs reported to it over a UTC day.
+type L1FeeReportDay struct {
+	Date             string   `json:"date"`
+	FromBlock        uint64   `json:"fromBlock"`
+	ToBlock          uint64   `json:"toBlock"`
+	Transactions     uint64   `json:"transactions"`
+	L1GasCharged     uint64   `json:"l1GasCharged"`
+	FeesCollectedWei *big.Int `json:"feesCollectedWei"`
+	Batches          uint64   `json:"batches"`
+	PostingCostWei   *big.Int `json:"postingCostWei"`
+	SurplusWei       *big.Int `json:"surplusWei"`
+}
+
+type L1FeeReport struct {
+	From                  time.Time           `json:"from"`
+	To                    time.Time           `json:"to"`
+	FromBlock             uint64              `json:"fromBlock"`
+	ToBlock               uint64              `json:"toBlock"`
+	TotalFeesCollectedWei *big.Int            `json:"totalFeesCollectedWei"`
+	TotalPostingCostWei   *big.Int            `json:"totalPostingCostWei"`
+	TotalSurplusWei       *big.Int            `json:"totalSurplusWei"`
+	Days                  []*L1FeeReportDay   `json:"days"`
+	Batches               []*L1FeeReportBatch `json:"batches"`
+}
+
+// L1FeeReporter reconciles what batch posters spent posting to the parent chain against
+// the L1 fees ArbOS charged transactions, using the L2 blocks and the inbox tracker.
+type L1FeeReporter struct {
+	bc      *core.BlockChain
+	tracker *InboxTracker
+}
+
+func NewL1FeeReporter(bc *core.BlockChain, tracker *InboxTracker) *L1FeeReporter {
+	return &L1FeeReporter{bc: bc, tracker: tracker}
+}
+
+// firstBlockAtOrAfter returns the number of the first block with a timestamp at or after t, or head+1 if there's none.
+func (r *L1FeeReporter) firstBlockAtOrAfter(t time.Time, head uint64) uint64 {
+	// #nosec G115
+	target := uint64(t.Unix())
+	return uint64(sort.Search(int(head+1), func(i int) bool {
+		// #nosec G115
+		header := r.bc.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Time >= target
+	}))
+}
+
+// Generate builds the report for the blocks with timestamps in [from, to).
+func (r *L1FeeReporter) Generate(ctx context.Context, from, to time.Time) (*L1FeeReport, error) {
+	if !from.Before(to) {
+		return nil, errors.New("report start must be before its end")
+	}
+	head := r.bc.CurrentBlock()
+	if head == nil {
+		return nil, errors.New("no head block")
+	}
+	fromBlock := r.firstBlockAtOrAfter(from, head.Number.Uint64())
+	toBlock := r.firstBlockAtOrAfter(to, head.Number.Uint64())
+	report := &L1FeeReport{
+		From:                  from.UTC(),
+		To:                    to.UTC(),
+		FromBlock:             fromBlock,
+		TotalFeesCollectedWei: new(big.Int),
+		TotalPostingCostWei:   new(big.Int),
+	}
+	if toBlock <= fromBlock {
+		report.TotalSurplusWei = new(big.Int)
+		return report, nil
+	}
+	report.ToBlock = toBlock - 1
+
+	var day *L1FeeReportDay
+	for number := fromBlock; number < toBlock; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := r.bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("missing block %v", number)
+		}
+		// #nosec G115
+		date := time.Unix(int64(block.Time()), 0).UTC().Format(l1FeeReportDateFormat)
+		if day == nil || day.Date != date {
+			day = &L1FeeReportDay{
+				Date:             date,
+				FromBlock:        number,
+				FeesCollectedWei: new(big.Int),
+				PostingCostWei:   new(big.Int),
+			}
+			report.Days = append(report.Days, day)
+		}
+		day.ToBlock = number
+
+		receipts := r.bc.GetReceiptsByHash(block.Hash())
+		for _, receipt := range receipts {
+			if receipt.GasUsedForL1 == 0 {
+				continue
+			}
+			day.Transactions++
+			day.L1GasCharged += receipt.GasUsedForL1
+			day.FeesCollectedWei.Add(day.FeesCollectedWei, arbmath.BigMulByUint(block.BaseFee(), receipt.GasUsedForL1))
+		}
+
+		for _, tx := range block.Transactions() {
+			batch, err := r.batchPostingReport(block, tx)
+			if err != nil {
+				return nil, err
+			}
+			if batch == nil {
+				continue
+			}
+			day.Batches++
+			day.PostingCostWei.Add(day.PostingCostWei, batch.CostWei)
+			report.Batches = append(report.Batches, batch)
+		}
+	}
+	for _, day := range report.Days {
+		day.SurplusWei = new(big.Int).Sub(day.FeesCollectedWei, day.PostingCostWei)
+		report.TotalFeesCollectedWei.Add(report.TotalFeesCollectedWei, day.FeesCollectedWei)
+		report.TotalPostingCostWei.Add(report.TotalPostingCostWei, day.PostingCostWei)
+	}
+	report.TotalSurplusWei = new(big.Int).Sub(report.TotalFeesCollectedWei, report.TotalPostingCostWei)
+	return report, nil
+}
+
+// batchPostingReport returns the batch reported by tx, or nil if tx isn't a batch posting report.
+func (r *L1FeeReporter) batchPostingReport(block *types.Block, tx *types.Transaction) (*L1FeeReportBatch, error) {
+	data := tx.Data()
+	if tx.Type() != types.ArbitrumInternalTxType || len(data) < 4 || !bytes.Equal(data[:4], arbos.InternalTxBatchPostingReportMethodID[:]) {
+		return nil, nil
+	}
+	inputs, err := util.UnpackInternalTxDataBatchPostingReport(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack batch posting report in block %v: %w", block.NumberU64(), err)
+	}
+	batch := &L1FeeReportBatch{
+		BatchNumber:     util.SafeMapGet[uint64](inputs, "batchNumber"),
+		Poster:          util.SafeMapGet[common.Address](inputs, "batchPosterAddress"),
+		ReportedInBlock: block.NumberU64(),
+		ReportedAt:      block.Time(),
+		DataGas:         util.SafeMapGet[uint64](inputs, "batchDataGas"),
+		L1BaseFeeWei:    util.SafeMapGet[*big.Int](inputs, "l1BaseFeeWei"),
+		PerBatchGas:     r.perBatchGas(block),
+	}
+	if batch.L1BaseFeeWei == nil {
+		batch.L1BaseFeeWei = new(big.Int)
+	}
+	// Matches how ArbOS computes the poster's reimbursement
+	gasSpent := arbmath.SaturatingAdd(batch.PerBatchGas, arbmath.SaturatingCast[int64](batch.DataGas))
+	batch.CostWei = arbmath.BigMulByUint(batch.L1BaseFeeWei, arbmath.SaturatingUCast[uint64](gasSpent))
+	if r.tracker != nil {
+		parentChainBlock, err := r.tracker.GetBatchParentChainBlock(batch.BatchNumber)
+		if err != nil {
+			log.Debug("batch from posting report not in inbox tracker", "batch", batch.BatchNumber, "err", err)
+		} else {
+			batch.ParentChainBlock = parentChainBlock
+		}
+	}
+	return batch, nil
+}
+
+// perBatchGas reads the per batch gas cost ArbOS used for reports in block from the pricing state
+// before it, falling back to the current pricing state if that state isn't available.
+func (r *L1FeeReporter) perBatchGas(block *types.Block) int64 {
+	parent := r.bc.GetHeaderByHash(block.ParentHash())
+	statedb, err := r.bc.State()
+	if parent != nil {
+		if parentState, parentErr := r.bc.StateAt(parent.Root); parentErr == nil {
+			statedb, err = parentState, nil
+		}
+	}
+	if err != nil {
+		log.Warn("no state to read per batch gas cost from", "err", err)
+		return 0
+	}
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		log.Warn("failed to open arbos state", "err", err)
+		return 0
+	}
+	perBatchGas, err := state.L1PricingState().PerBatchGasCost()
+	if err != nil {
+		log.Warn("failed to read per batch gas cost", "err", err)
+		return 0
+	}
+	return perBatchGas
+}
+
+func formatWei(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	return wei.String()
+}
+
+// WriteDaysCSV writes one row per day, followed by a total row.
+func (r *L1FeeReport) WriteDaysCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"date", "from_block", "to_block", "transactions", "l1_gas_charged", "fees_collected_wei", "batches", "posting_cost_wei", "surplus_wei"}}
+	for _, day := range r.Days {
+		rows = append(rows, []string{
+			day.Date,
+			strconv.FormatUint(day.FromBlock, 10),
+			strconv.FormatUint(day.ToBlock, 10),
+			strconv.FormatUint(day.Transactions, 10),
+			strconv.FormatUint(day.L1GasCharged, 10),
+			formatWei(day.FeesCollectedWei),
+			strconv.FormatUint(day.Batches, 10),
+			formatWei(day.PostingCostWei),
+			formatWei(day.SurplusWei),
+		})
+	}
+	rows = append(rows, []string{"total", "", "", "", "", formatWei(r.TotalFeesCollectedWei), strconv.Itoa(len(r.Batches)), formatWei(r.TotalPostingCostWei), formatWei(r.TotalSurplusWei)})
+	return writer.WriteAll(rows)
+}
+
+// WriteBatchesCSV writes one row per batch posting report.
+func (r *L1FeeReport) WriteBatchesCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"batch", "poster", "parent_chain_block", "reported_in_block", "reported_at", "data_gas", "per_batch_gas", "l1_base_fee_wei", "cost_wei"}}
+	for _, batch := range r.Batches {
+		rows = append(rows, []string{
+			strconv.FormatUint(batch.BatchNumber, 10),
+			batch.Poster.Hex(),
+			strconv.FormatUint(batch.ParentChainBlock, 10),
+			strconv.FormatUint(batch.ReportedInBlock, 10),
+			// #nosec G115
+			time.Unix(int64(batch.ReportedAt), 0).UTC().Format(time.RFC3339),
+			strconv.FormatUint(batch.DataGas, 10),
+			strconv.FormatInt(batch.PerBatchGas, 10),
+			formatWei(batch.L1BaseFeeWei),
+			formatWei(batch.CostWei),
+		})
+	}
+	return writer.WriteAll(rows)
+}
@@ -5,87 +5,239 @@ package arbnode
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbnode/redislock"
 	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 )
 
-// Regularly runs db compaction if configured
+// Names of the maintenance tasks
+const (
+	MaintenanceTaskCompaction       = "compaction"
+	MaintenanceTaskSnapshot         = "snapshot"
+	MaintenanceTaskMessagePruning   = "message-pruning"
+	MaintenanceTaskWasmCacheCleanup = "wasm-cache-cleanup"
+)
+
+// Regularly runs db compaction if configured, and the other maintenance tasks in low-traffic windows
 type MaintenanceRunner struct {
 	stopwaiter.StopWaiter
 
 	exec            execution.FullExecutionClient
 	config          MaintenanceConfigFetcher
 	seqCoordinator  *SeqCoordinator
+	sequencer       bool
 	dbs             []ethdb.Database
 	lastMaintenance time.Time
 
+	tasksMutex sync.Mutex
+	tasks      map[string]*maintenanceTask
+
+	paused        atomic.Bool
+	running       atomic.Bool
+	lastCompleted atomic.Int64 // unix seconds, 0 if maintenance hasn't run
+
 	// lock is used to ensures that at any given time, only single node is on
 	// maintenance mode.
 	lock *redislock.Simple
 }
 
+type MaintenanceTaskConfig struct {
+	Enable   bool          `koanf:"enable"`
+	Interval time.Duration `koanf:"interval"`
+}
+
+type MaintenanceTasksConfig struct {
+	Compaction       MaintenanceTaskConfig `koanf:"compaction"`
+	Snapshot         MaintenanceTaskConfig `koanf:"snapshot"`
+	MessagePruning   MaintenanceTaskConfig `koanf:"message-pruning"`
+	WasmCacheCleanup MaintenanceTaskConfig `koanf:"wasm-cache-cleanup"`
+}
+
+func (c *MaintenanceTasksConfig) byName() map[string]*MaintenanceTaskConfig {
+	return map[string]*MaintenanceTaskConfig{
+		MaintenanceTaskCompaction:       &c.Compaction,
+		MaintenanceTaskSnapshot:         &c.Snapshot,
+		MaintenanceTaskMessagePruning:   &c.MessagePruning,
+		MaintenanceTaskWasmCacheCleanup: &c.WasmCacheCleanup,
+	}
+}
+
 type MaintenanceConfig struct {
-	TimeOfDay string              `koanf:"time-of-day" reload:"hot"`
-	Lock      redislock.SimpleCfg `koanf:"lock" reload:"hot"`
+	TimeOfDay         string                 `koanf:"time-of-day" reload:"hot"`
+	Lock              redislock.SimpleCfg    `koanf:"lock" reload:"hot"`
+	Windows           []string               `koanf:"windows" reload:"hot"`
+	WhenNotSequencing bool                   `koanf:"when-not-sequencing" reload:"hot"`
+	Tasks             MaintenanceTasksConfig `koanf:"tasks" reload:"hot"`
 
 	// Generated: the minutes since start of UTC day to compact at
 	minutesAfterMidnight int
 	enabled              bool
+	// Generated: the low-traffic windows, as minutes since start of UTC day
+	windows []maintenanceWindow
 }
 
-// Returns true if successful
-func (c *MaintenanceConfig) parseDbCompactionTime() bool {
-	if c.TimeOfDay == "" {
-		return true
+type maintenanceWindow struct {
+	start, end int
+}
+
+// contains returns whether a time of day, in minutes since the start of the UTC day, is in the window,
+// which may wrap around midnight.
+func (w maintenanceWindow) contains(minutes int) bool {
+	if w.start <= w.end {
+		return minutes >= w.start && minutes < w.end
 	}
-	parts := strings.Split(c.TimeOfDay, ":")
+	return minutes >= w.start || minutes < w.end
+}
+
+// parseTimeOfDay parses a 24-hour HH:MM time into minutes since the start of the day.
+func parseTimeOfDay(timeOfDay string) (int, bool) {
+	parts := strings.Split(timeOfDay, ":")
 	if len(parts) != 2 {
-		return false
+		return 0, false
 	}
 	hours, err := strconv.Atoi(parts[0])
-	if err != nil || hours >= 24 {
-		return false
+	if err != nil || hours < 0 || hours >= 24 {
+		return 0, false
 	}
 	minutes, err := strconv.Atoi(parts[1])
-	if err != nil || minutes >= 60 {
+	if err != nil || minutes < 0 || minutes >= 60 {
+		return 0, false
+	}
+	return hours*60 + minutes, true
+}
+
+// Returns true if successful
+func (c *MaintenanceConfig) parseDbCompactionTime() bool {
+	if c.TimeOfDay == "" {
+		return true
+	}
+	minutes, ok := parseTimeOfDay(c.TimeOfDay)
+	if !ok {
 		return false
 	}
 	c.enabled = true
-	c.minutesAfterMidnight = hours*60 + minutes
+	c.minutesAfterMidnight = minutes
 	return true
 }
 
+func (c *MaintenanceConfig) parseWindows() error {
+	c.windows = nil
+	for _, window := range c.Windows {
+		bounds := strings.Split(window, "-")
+		if len(bounds) != 2 {
+			return fmt.Errorf("expected maintenance window in 24-hour HH:MM-HH:MM format but got \"%v\"", window)
+		}
+		start, startOk := parseTimeOfDay(bounds[0])
+		end, endOk := parseTimeOfDay(bounds[1])
+		if !startOk || !endOk {
+			return fmt.Errorf("expected maintenance window in 24-hour HH:MM-HH:MM format but got \"%v\"", window)
+		}
+		if start == end {
+			return fmt.Errorf("maintenance window \"%v\" is empty", window)
+		}
+		c.windows = append(c.windows, maintenanceWindow{start: start, end: end})
+	}
+	return nil
+}
+
 func (c *MaintenanceConfig) Validate() error {
 	if !c.parseDbCompactionTime() {
 		return fmt.Errorf("expected sequencer coordinator db compaction time to be in 24-hour HH:MM format but got \"%v\"", c.TimeOfDay)
 	}
+	if err := c.parseWindows(); err != nil {
+		return err
+	}
+	for name, task := range c.Tasks.byName() {
+		if !task.Enable {
+			continue
+		}
+		if task.Interval <= 0 {
+			return fmt.Errorf("maintenance task %v interval must be positive", name)
+		}
+		if len(c.windows) == 0 && !c.WhenNotSequencing {
+			return fmt.Errorf("maintenance task %v is enabled, but there are no maintenance windows and when-not-sequencing isn't set", name)
+		}
+	}
 	return nil
 }
 
+func MaintenanceTaskConfigAddOptions(prefix string, f *flag.FlagSet, defaultConfig MaintenanceTaskConfig, description string) {
+	f.Bool(prefix+".enable", defaultConfig.Enable, "schedule "+description+" in maintenance windows")
+	f.Duration(prefix+".interval", defaultConfig.Interval, "minimum time between scheduled runs of "+description)
+}
+
 func MaintenanceConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".time-of-day", DefaultMaintenanceConfig.TimeOfDay, "UTC 24-hour time of day to run maintenance (currently only db compaction) at (e.g. 15:00)")
 	redislock.AddConfigOptions(prefix+".lock", f)
+	f.StringSlice(prefix+".windows", DefaultMaintenanceConfig.Windows, "UTC 24-hour low-traffic windows scheduled maintenance tasks may start in (e.g. 02:00-04:00,22:30-23:00)")
+	f.Bool(prefix+".when-not-sequencing", DefaultMaintenanceConfig.WhenNotSequencing, "also run scheduled maintenance tasks at any time this node isn't producing blocks")
+	MaintenanceTaskConfigAddOptions(prefix+".tasks.compaction", f, DefaultMaintenanceConfig.Tasks.Compaction, "database compaction (handing off the chosen sequencer role first)")
+	MaintenanceTaskConfigAddOptions(prefix+".tasks.snapshot", f, DefaultMaintenanceConfig.Tasks.Snapshot, "producing a snapshot (requires the snapshot producer)")
+	MaintenanceTaskConfigAddOptions(prefix+".tasks.message-pruning", f, DefaultMaintenanceConfig.Tasks.MessagePruning, "pruning confirmed messages (requires the message pruner)")
+	MaintenanceTaskConfigAddOptions(prefix+".tasks.wasm-cache-cleanup", f, DefaultMaintenanceConfig.Tasks.WasmCacheCleanup, "clearing the in-memory stylus program cache (requires a local execution node)")
 }
 
 var DefaultMaintenanceConfig = MaintenanceConfig{
-	TimeOfDay: "",
-	Lock:      redislock.DefaultCfg,
+	TimeOfDay:         "",
+	Lock:              redislock.DefaultCfg,
+	Windows:           []string{},
+	WhenNotSequencing: false,
+	Tasks: MaintenanceTasksConfig{
+		Compaction:       MaintenanceTaskConfig{Interval: 24 * time.Hour},
+		Snapshot:         MaintenanceTaskConfig{Interval: 24 * time.Hour},
+		MessagePruning:   MaintenanceTaskConfig{Interval: time.Hour},
+		WasmCacheCleanup: MaintenanceTaskConfig{Interval: 6 * time.Hour},
+	},
 
 	minutesAfterMidnight: 0,
 }
 
+var (
+	errMaintenancePaused = errors.New("maintenance is paused")
+	errMaintenanceBusy   = errors.New("maintenance already running")
+)
+
+// maintenanceTask is a task the runner schedules in maintenance windows or runs on demand.
+type maintenanceTask struct {
+	name string
+	run  func(ctx context.Context) error
+
+	running      atomic.Bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastErr      error
+
+	runsCounter     metrics.Counter
+	failuresCounter metrics.Counter
+	durationGauge   metrics.Gauge
+}
+
+type MaintenanceTaskStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+	// Unix seconds, 0 if the task hasn't run
+	LastRun      uint64 `json:"lastRun"`
+	LastDuration string `json:"lastDuration,omitempty"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
 type MaintenanceConfigFetcher func() *MaintenanceConfig
 
-func NewMaintenanceRunner(config MaintenanceConfigFetcher, seqCoordinator *SeqCoordinator, dbs []ethdb.Database, exec execution.FullExecutionClient) (*MaintenanceRunner, error) {
+func NewMaintenanceRunner(config MaintenanceConfigFetcher, seqCoordinator *SeqCoordinator, sequencer bool, dbs []ethdb.Database, exec execution.FullExecutionClient) (*MaintenanceRunner, error) {
 	cfg := config()
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validating config: %w", err)
@@ -94,9 +246,17 @@ func NewMaintenanceRunner(config MaintenanceConfigFetcher, seqCoordinator *SeqCo
 		exec:            exec,
 		config:          config,
 		seqCoordinator:  seqCoordinator,
+		sequencer:       sequencer,
 		dbs:             dbs,
 		lastMaintenance: time.Now().UTC(),
+		tasks:           make(map[string]*maintenanceTask),
 	}
+	res.AddTask(MaintenanceTaskCompaction, func(ctx context.Context) error {
+		if !res.attemptMaintenance(ctx) {
+			return errors.New("couldn't hand off the chosen sequencer role")
+		}
+		return nil
+	})
 
 	if seqCoordinator != nil {
 		c := func() *redislock.SimpleCfg { return &cfg.Lock }
@@ -134,41 +294,221 @@ func wentPastTimeOfDay(before time.Time, after time.Time, timeOfDay int) bool {
 	return prevMinutes < dbCompactionMinutes && newMinutes >= dbCompactionMinutes
 }
 
+// AddTask registers a task the runner can schedule and run on demand. The compaction task is always registered.
+func (mr *MaintenanceRunner) AddTask(name string, run func(ctx context.Context) error) {
+	mr.tasksMutex.Lock()
+	defer mr.tasksMutex.Unlock()
+	prefix := "arb/maintenance/" + name
+	mr.tasks[name] = &maintenanceTask{
+		name:            name,
+		run:             run,
+		runsCounter:     metrics.GetOrRegisterCounter(prefix+"/runs", nil),
+		failuresCounter: metrics.GetOrRegisterCounter(prefix+"/failures", nil),
+		durationGauge:   metrics.GetOrRegisterGauge(prefix+"/duration", nil),
+	}
+}
+
+// producingBlocks returns whether this node is currently the one producing blocks.
+func (mr *MaintenanceRunner) producingBlocks() bool {
+	if mr.seqCoordinator != nil {
+		return mr.seqCoordinator.CurrentlyChosen()
+	}
+	return mr.sequencer
+}
+
+// inMaintenanceWindow returns whether scheduled tasks may start now.
+func (mr *MaintenanceRunner) inMaintenanceWindow(config *MaintenanceConfig, now time.Time) bool {
+	minutes := now.Hour()*60 + now.Minute()
+	for _, window := range config.windows {
+		if window.contains(minutes) {
+			return true
+		}
+	}
+	return config.WhenNotSequencing && !mr.producingBlocks()
+}
+
+// runDueTasks runs the enabled tasks that haven't run for their interval, one at a time.
+func (mr *MaintenanceRunner) runDueTasks(ctx context.Context, config *MaintenanceConfig, now time.Time) {
+	if mr.paused.Load() || !mr.inMaintenanceWindow(config, now) {
+		return
+	}
+	taskConfigs := config.Tasks.byName()
+	for _, task := range mr.sortedTasks() {
+		taskConfig := taskConfigs[task.name]
+		if !taskConfig.Enable {
+			continue
+		}
+		mr.tasksMutex.Lock()
+		due := now.Sub(task.lastRun) >= taskConfig.Interval
+		mr.tasksMutex.Unlock()
+		if !due || ctx.Err() != nil {
+			continue
+		}
+		if err := mr.runTask(ctx, task, now); err != nil && !errors.Is(err, errMaintenanceBusy) {
+			log.Warn("Scheduled maintenance task failed", "task", task.name, "err", err)
+		}
+	}
+}
+
+func (mr *MaintenanceRunner) sortedTasks() []*maintenanceTask {
+	mr.tasksMutex.Lock()
+	defer mr.tasksMutex.Unlock()
+	tasks := make([]*maintenanceTask, 0, len(mr.tasks))
+	for _, task := range mr.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].name < tasks[j].name })
+	return tasks
+}
+
+func (mr *MaintenanceRunner) runTask(ctx context.Context, task *maintenanceTask, now time.Time) error {
+	if !task.running.CompareAndSwap(false, true) {
+		return errMaintenanceBusy
+	}
+	defer task.running.Store(false)
+	log.Info("Running maintenance task", "task", task.name)
+	start := time.Now()
+	err := task.run(ctx)
+	elapsed := time.Since(start)
+	task.runsCounter.Inc(1)
+	task.durationGauge.Update(elapsed.Milliseconds())
+	if err != nil {
+		task.failuresCounter.Inc(1)
+	}
+	mr.tasksMutex.Lock()
+	task.lastRun = now
+	task.lastDuration = elapsed
+	task.lastErr = err
+	mr.tasksMutex.Unlock()
+	log.Info("Finished maintenance task", "task", task.name, "elapsed", elapsed, "err", err)
+	return err
+}
+
+// RunTask starts a task in the background, regardless of the maintenance windows and the task's interval.
+func (mr *MaintenanceRunner) RunTask(name string) error {
+	mr.tasksMutex.Lock()
+	task, ok := mr.tasks[name]
+	mr.tasksMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown or unavailable maintenance task %v", name)
+	}
+	if mr.paused.Load() {
+		return errMaintenancePaused
+	}
+	if task.running.Load() {
+		return fmt.Errorf("maintenance task %v already running", name)
+	}
+	return mr.LaunchThreadSafe(func(ctx context.Context) {
+		if err := mr.runTask(ctx, task, time.Now().UTC()); err != nil {
+			log.Warn("Maintenance task failed", "task", name, "err", err)
+		}
+	})
+}
+
+// TaskStatuses reports each registered task's most recent run.
+func (mr *MaintenanceRunner) TaskStatuses() []MaintenanceTaskStatus {
+	taskConfigs := mr.config().Tasks.byName()
+	tasks := mr.sortedTasks()
+	mr.tasksMutex.Lock()
+	defer mr.tasksMutex.Unlock()
+	statuses := make([]MaintenanceTaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		status := MaintenanceTaskStatus{
+			Name:    task.name,
+			Enabled: taskConfigs[task.name].Enable,
+			Running: task.running.Load(),
+		}
+		if !task.lastRun.IsZero() {
+			status.LastRun = uint64(task.lastRun.Unix()) // #nosec G115
+			status.LastDuration = task.lastDuration.String()
+		}
+		if task.lastErr != nil {
+			status.LastError = task.lastErr.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
 func (mr *MaintenanceRunner) maybeRunMaintenance(ctx context.Context) time.Duration {
 	config := mr.config()
+	now := time.Now().UTC()
+	mr.runDueTasks(ctx, config, now)
 	if !config.enabled {
 		return time.Minute
 	}
 
-	now := time.Now().UTC()
-
 	if !wentPastTimeOfDay(mr.lastMaintenance, now, config.minutesAfterMidnight) {
 		return time.Minute
 	}
 
-	if mr.seqCoordinator == nil {
+	if mr.paused.Load() {
+		log.Info("Skipping scheduled maintenance, maintenance is paused")
 		mr.lastMaintenance = now
-		mr.runMaintenance()
 		return time.Minute
 	}
 
+	if mr.attemptMaintenance(ctx) {
+		mr.lastMaintenance = now
+	}
+	return time.Minute
+}
+
+// attemptMaintenance runs maintenance, first handing off the chosen sequencer role if there's a
+// coordinator. Returns false if maintenance couldn't run and should be retried.
+func (mr *MaintenanceRunner) attemptMaintenance(ctx context.Context) bool {
+	if mr.seqCoordinator == nil {
+		mr.runMaintenance()
+		return true
+	}
+
 	if !mr.lock.AttemptLock(ctx) {
-		return time.Minute
+		return false
 	}
 	defer mr.lock.Release(ctx)
 
-	log.Info("Attempting avoiding lockout and handing off", "targetTime", config.TimeOfDay)
+	log.Info("Attempting avoiding lockout and handing off", "targetTime", mr.config().TimeOfDay)
 	// Avoid lockout for the sequencer and try to handoff.
+	ran := false
 	if mr.seqCoordinator.AvoidLockout(ctx) && mr.seqCoordinator.TryToHandoffChosenOne(ctx) {
-		mr.lastMaintenance = now
 		mr.runMaintenance()
+		ran = true
 	}
 	defer mr.seqCoordinator.SeekLockout(ctx) // needs called even if c.Zombify returns false
 
-	return time.Minute
+	return ran
+}
+
+// Trigger starts maintenance in the background without waiting for the configured time of day.
+func (mr *MaintenanceRunner) Trigger() error {
+	if mr.paused.Load() {
+		return errMaintenancePaused
+	}
+	if mr.running.Load() {
+		return errMaintenanceBusy
+	}
+	return mr.LaunchThreadSafe(func(ctx context.Context) {
+		if !mr.attemptMaintenance(ctx) {
+			log.Warn("Triggered maintenance didn't run, couldn't hand off the chosen sequencer role")
+		}
+	})
+}
+
+// Pause stops scheduled and triggered maintenance from starting; maintenance already running isn't interrupted.
+func (mr *MaintenanceRunner) Pause() {
+	mr.paused.Store(true)
+}
+
+func (mr *MaintenanceRunner) Resume() {
+	mr.paused.Store(false)
 }
 
 func (mr *MaintenanceRunner) runMaintenance() {
+	if !mr.running.CompareAndSwap(false, true) {
+		log.Warn("Maintenance already running, skipping")
+		return
+	}
+	defer mr.running.Store(false)
 	log.Info("Compacting databases (this may take a while...)")
 	results := make(chan error, len(mr.dbs))
 	expected := 0
@@ -189,5 +529,6 @@ func (mr *MaintenanceRunner) runMaintenance() {
 			log.Warn("maintenance error", "err", err)
 		}
 	}
+	mr.lastCompleted.Store(time.Now().Unix())
 	log.Info("Done compacting databases")
 }
@@ -4,6 +4,8 @@
 package arbnode
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -39,3 +41,113 @@ func TestWentPastTimeOfDay(t *testing.T) {
 		}
 	}
 }
+
+func TestMaintenanceWindows(t *testing.T) {
+	config := MaintenanceConfig{Windows: []string{"02:00-04:00", "23:30-00:30"}}
+	Require(t, config.Validate())
+	for _, tc := range []struct {
+		hour, minute int
+		want         bool
+	}{
+		{hour: 1, minute: 59},
+		{hour: 2, minute: 0, want: true},
+		{hour: 3, minute: 59, want: true},
+		{hour: 4, minute: 0},
+		{hour: 23, minute: 29},
+		{hour: 23, minute: 45, want: true},
+		{hour: 0, minute: 15, want: true},
+		{hour: 0, minute: 30},
+	} {
+		minutes := tc.hour*60 + tc.minute
+		got := false
+		for _, window := range config.windows {
+			got = got || window.contains(minutes)
+		}
+		if got != tc.want {
+			t.Errorf("%02d:%02d in windows = %v want %v", tc.hour, tc.minute, got, tc.want)
+		}
+	}
+
+	for _, invalid := range []string{"02:00", "02:00-24:00", "03:00-03:00", "2-4"} {
+		config := MaintenanceConfig{Windows: []string{invalid}}
+		if config.Validate() == nil {
+			Fail(t, "invalid window", invalid, "was accepted")
+		}
+	}
+	config = DefaultMaintenanceConfig
+	config.Tasks.Snapshot.Enable = true
+	if config.Validate() == nil {
+		Fail(t, "task enabled without windows was accepted")
+	}
+}
+
+func TestMaintenanceTaskScheduling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := DefaultMaintenanceConfig
+	config.Windows = []string{"02:00-04:00"}
+	config.Tasks.MessagePruning.Enable = true
+	Require(t, config.Validate())
+	runner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &config }, nil, true, nil, nil)
+	Require(t, err)
+	runs := 0
+	var taskErr error
+	runner.AddTask(MaintenanceTaskMessagePruning, func(context.Context) error {
+		runs++
+		return taskErr
+	})
+	// Registered but disabled, so never scheduled
+	runner.AddTask(MaintenanceTaskSnapshot, func(context.Context) error {
+		Fail(t, "disabled task was scheduled")
+		return nil
+	})
+
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	runner.runDueTasks(ctx, &config, day.Add(time.Hour))
+	if runs != 0 {
+		Fail(t, "task ran outside of the windows")
+	}
+	runner.runDueTasks(ctx, &config, day.Add(2*time.Hour))
+	runner.runDueTasks(ctx, &config, day.Add(2*time.Hour+30*time.Minute))
+	if runs != 1 {
+		Fail(t, "expected the task to run once within its interval but it ran", runs, "times")
+	}
+	taskErr = errors.New("failed")
+	runner.runDueTasks(ctx, &config, day.Add(3*time.Hour))
+	if runs != 2 {
+		Fail(t, "task didn't run again after its interval")
+	}
+	taskErr = nil
+
+	// A sequencer without a coordinator is always producing blocks
+	config.Windows = nil
+	config.WhenNotSequencing = true
+	Require(t, config.Validate())
+	runner.runDueTasks(ctx, &config, day.Add(6*time.Hour))
+	if runs != 2 {
+		Fail(t, "task ran while producing blocks")
+	}
+	runner.sequencer = false
+	runner.runDueTasks(ctx, &config, day.Add(6*time.Hour))
+	if runs != 3 {
+		Fail(t, "task didn't run while not producing blocks")
+	}
+
+	runner.Pause()
+	runner.runDueTasks(ctx, &config, day.Add(8*time.Hour))
+	if runs != 3 {
+		Fail(t, "task ran while paused")
+	}
+
+	for _, status := range runner.TaskStatuses() {
+		if status.Name != MaintenanceTaskMessagePruning {
+			continue
+		}
+		if !status.Enabled || status.LastRun == 0 || status.LastError != "" {
+			Fail(t, "unexpected status", status)
+		}
+		return
+	}
+	Fail(t, "no status for the message pruning task")
+}
@@ -0,0 +1,403 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+// A message archive is a directory holding the consensus database's message sequence independently of the
+// database engine, so a node can be bootstrapped from it instead of replaying every batch from the parent chain.
+//
+// The directory has a manifest.json, a MessageArchiveManifest, and chunk files named in it. A chunk file is a
+// sequence of RLP encoded messageArchiveRecords, and the manifest has the SHA-256 of each. A record's value and
+// extra are what the database stores for that kind of entry, all of which are RLP encoded:
+//
+//	kind 0, message: index is the message number, value an arbostypes.MessageWithMetadata and extra the block
+//	  hash the feed reported for it, if any
+//	kind 1, delayed message: index is the delayed message number, value the inbox accumulator followed by the
+//	  RLP encoded L1IncomingMessage and extra the parent chain block it was read from, if recorded
+//	kind 2, legacy delayed message: as kind 1, but the message is serialized as it was on the parent chain
+//	  (written by nodes before database schema version 1)
+//	kind 3, batch: index is the batch sequence number, value its BatchMetadata, with the batch boundaries
+//	kind 4, delayed sequenced: index is a delayed message count, value the first batch reading up to it
+//
+// Records are in kind order, and message, delayed message and batch records in index order with no gaps.
+const (
+	MessageArchiveFormatVersion = 1
+	messageArchiveManifestFile  = "manifest.json"
+)
+
+const (
+	archiveKindMessage uint8 = iota
+	archiveKindDelayedMessage
+	archiveKindLegacyDelayedMessage
+	archiveKindBatch
+	archiveKindDelayedSequenced
+)
+
+type messageArchiveRecord struct {
+	Kind  uint8
+	Index uint64
+	Value []byte
+	Extra []byte
+}
+
+type MessageArchiveChunk struct {
+	File    string `json:"file"`
+	Records uint64 `json:"records"`
+	SHA256  string `json:"sha256"`
+}
+
+type MessageArchiveManifest struct {
+	FormatVersion       uint64                `json:"formatVersion"`
+	MessageCount        arbutil.MessageIndex  `json:"messageCount"`
+	DelayedMessageCount uint64                `json:"delayedMessageCount"`
+	BatchCount          uint64                `json:"batchCount"`
+	Chunks              []MessageArchiveChunk `json:"chunks"`
+}
+
+func readUint64Key(db ethdb.KeyValueReader, key []byte) (uint64, error) {
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return 0, err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	err = rlp.DecodeBytes(data, &value)
+	return value, err
+}
+
+func getOptional(db ethdb.KeyValueReader, key []byte) ([]byte, error) {
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	return db.Get(key)
+}
+
+type messageArchiveWriter struct {
+	dir       string
+	chunkSize uint64
+	manifest  *MessageArchiveManifest
+
+	file    *os.File
+	buf     *bufio.Writer
+	sum     func() []byte
+	records uint64
+}
+
+func (w *messageArchiveWriter) write(record *messageArchiveRecord) error {
+	if w.file == nil {
+		name := fmt.Sprintf("chunk-%06d.rlp", len(w.manifest.Chunks))
+		file, err := os.Create(filepath.Join(w.dir, name))
+		if err != nil {
+			return err
+		}
+		hasher := sha256.New()
+		w.file = file
+		w.buf = bufio.NewWriter(io.MultiWriter(file, hasher))
+		w.sum = func() []byte { return hasher.Sum(nil) }
+		w.records = 0
+		w.manifest.Chunks = append(w.manifest.Chunks, MessageArchiveChunk{File: name})
+	}
+	if err := rlp.Encode(w.buf, record); err != nil {
+		return err
+	}
+	w.records++
+	if w.records >= w.chunkSize {
+		return w.closeChunk()
+	}
+	return nil
+}
+
+func (w *messageArchiveWriter) closeChunk() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	chunk := &w.manifest.Chunks[len(w.manifest.Chunks)-1]
+	chunk.Records = w.records
+	chunk.SHA256 = hex.EncodeToString(w.sum())
+	w.file = nil
+	return nil
+}
+
+// ExportMessageArchive writes the consensus database's messages, delayed messages and batch metadata
+// to a message archive in dir, with up to chunkSize records per chunk file.
+func ExportMessageArchive(ctx context.Context, db ethdb.Database, dir string, chunkSize uint64) (*MessageArchiveManifest, error) {
+	if chunkSize == 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	messageCount, err := readUint64Key(db, messageCountKey)
+	if err != nil {
+		return nil, err
+	}
+	delayedCount, err := readUint64Key(db, delayedMessageCountKey)
+	if err != nil {
+		return nil, err
+	}
+	batchCount, err := readUint64Key(db, sequencerBatchCountKey)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &MessageArchiveManifest{
+		FormatVersion:       MessageArchiveFormatVersion,
+		MessageCount:        arbutil.MessageIndex(messageCount),
+		DelayedMessageCount: delayedCount,
+		BatchCount:          batchCount,
+	}
+	w := &messageArchiveWriter{dir: dir, chunkSize: chunkSize, manifest: manifest}
+	defer func() {
+		if w.file != nil {
+			w.file.Close()
+		}
+	}()
+
+	for i := uint64(0); i < messageCount; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		value, err := db.Get(dbKey(messagePrefix, i))
+		if err != nil {
+			return nil, fmt.Errorf("reading message %d: %w", i, err)
+		}
+		blockHash, err := getOptional(db, dbKey(blockHashInputFeedPrefix, i))
+		if err != nil {
+			return nil, err
+		}
+		if err := w.write(&messageArchiveRecord{archiveKindMessage, i, value, blockHash}); err != nil {
+			return nil, err
+		}
+	}
+	for i := uint64(0); i < delayedCount; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		kind := archiveKindDelayedMessage
+		value, err := getOptional(db, dbKey(rlpDelayedMessagePrefix, i))
+		if err == nil && value == nil {
+			kind = archiveKindLegacyDelayedMessage
+			value, err = db.Get(dbKey(legacyDelayedMessagePrefix, i))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading delayed message %d: %w", i, err)
+		}
+		parentChainBlock, err := getOptional(db, dbKey(parentChainBlockNumberPrefix, i))
+		if err != nil {
+			return nil, err
+		}
+		if err := w.write(&messageArchiveRecord{kind, i, value, parentChainBlock}); err != nil {
+			return nil, err
+		}
+	}
+	for i := uint64(0); i < batchCount; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		value, err := db.Get(dbKey(sequencerBatchMetaPrefix, i))
+		if err != nil {
+			return nil, fmt.Errorf("reading batch %d: %w", i, err)
+		}
+		if err := w.write(&messageArchiveRecord{archiveKindBatch, i, value, nil}); err != nil {
+			return nil, err
+		}
+	}
+	iter := db.NewIterator(delayedSequencedPrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(delayedSequencedPrefix)+8 {
+			continue
+		}
+		index := binary.BigEndian.Uint64(bytes.TrimPrefix(key, delayedSequencedPrefix))
+		value := append([]byte{}, iter.Value()...)
+		if err := w.write(&messageArchiveRecord{archiveKindDelayedSequenced, index, value, nil}); err != nil {
+			return nil, err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if err := w.closeChunk(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Written last, so an interrupted export has no manifest
+	if err := os.WriteFile(filepath.Join(dir, messageArchiveManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ReadMessageArchiveManifest reads the manifest of the message archive in dir.
+func ReadMessageArchiveManifest(dir string) (*MessageArchiveManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, messageArchiveManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest MessageArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid message archive manifest: %w", err)
+	}
+	if manifest.FormatVersion != MessageArchiveFormatVersion {
+		return nil, fmt.Errorf("unsupported message archive format version %d", manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// ImportMessageArchive writes the message archive in dir to a consensus database that has no messages yet.
+// The counts are written last, so the database still looks empty to a node if the import is interrupted.
+func ImportMessageArchive(ctx context.Context, db ethdb.Database, dir string) (*MessageArchiveManifest, error) {
+	manifest, err := ReadMessageArchiveManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range [][]byte{messageCountKey, delayedMessageCountKey, sequencerBatchCountKey} {
+		count, err := readUint64Key(db, key)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("database already has %s %d, messages can only be imported into an empty database", key, count)
+		}
+	}
+
+	// The next index expected for each kind of record that has no gaps, legacy delayed messages counting as delayed
+	next := make(map[uint8]uint64)
+	batch := db.NewBatch()
+	for _, chunk := range manifest.Chunks {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if filepath.Base(chunk.File) != chunk.File {
+			return nil, fmt.Errorf("chunk file %q isn't in the archive's directory", chunk.File)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, chunk.File))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != chunk.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for chunk %v", chunk.File)
+		}
+		stream := rlp.NewStream(bytes.NewReader(data), uint64(len(data)))
+		records := uint64(0)
+		for {
+			var record messageArchiveRecord
+			if err := stream.Decode(&record); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("decoding chunk %v: %w", chunk.File, err)
+			}
+			records++
+			var key, extraKey []byte
+			switch record.Kind {
+			case archiveKindMessage:
+				key, extraKey = dbKey(messagePrefix, record.Index), dbKey(blockHashInputFeedPrefix, record.Index)
+			case archiveKindDelayedMessage:
+				key, extraKey = dbKey(rlpDelayedMessagePrefix, record.Index), dbKey(parentChainBlockNumberPrefix, record.Index)
+			case archiveKindLegacyDelayedMessage:
+				key, extraKey = dbKey(legacyDelayedMessagePrefix, record.Index), dbKey(parentChainBlockNumberPrefix, record.Index)
+			case archiveKindBatch:
+				key = dbKey(sequencerBatchMetaPrefix, record.Index)
+			case archiveKindDelayedSequenced:
+				key = dbKey(delayedSequencedPrefix, record.Index)
+			default:
+				return nil, fmt.Errorf("unknown record kind %d in chunk %v", record.Kind, chunk.File)
+			}
+			sequence := record.Kind
+			if sequence == archiveKindLegacyDelayedMessage {
+				sequence = archiveKindDelayedMessage
+			}
+			if sequence != archiveKindDelayedSequenced {
+				if record.Index != next[sequence] {
+					return nil, fmt.Errorf("record kind %d has index %d but expected %d", record.Kind, record.Index, next[sequence])
+				}
+				next[sequence]++
+			}
+			if err := batch.Put(key, record.Value); err != nil {
+				return nil, err
+			}
+			if len(record.Extra) > 0 && extraKey != nil {
+				if err := batch.Put(extraKey, record.Extra); err != nil {
+					return nil, err
+				}
+			}
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return nil, err
+				}
+				batch.Reset()
+			}
+		}
+		if records != chunk.Records {
+			return nil, fmt.Errorf("chunk %v has %d records but the manifest lists %d", chunk.File, records, chunk.Records)
+		}
+	}
+	if next[archiveKindMessage] != uint64(manifest.MessageCount) {
+		return nil, fmt.Errorf("archive has %d messages but the manifest lists %d", next[archiveKindMessage], manifest.MessageCount)
+	}
+	if next[archiveKindDelayedMessage] != manifest.DelayedMessageCount {
+		return nil, fmt.Errorf("archive has %d delayed messages but the manifest lists %d", next[archiveKindDelayedMessage], manifest.DelayedMessageCount)
+	}
+	if next[archiveKindBatch] != manifest.BatchCount {
+		return nil, fmt.Errorf("archive has %d batches but the manifest lists %d", next[archiveKindBatch], manifest.BatchCount)
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	batch.Reset()
+
+	if err := setMessageCount(batch, manifest.MessageCount); err != nil {
+		return nil, err
+	}
+	for key, count := range map[string]uint64{string(delayedMessageCountKey): manifest.DelayedMessageCount, string(sequencerBatchCountKey): manifest.BatchCount} {
+		value, err := rlp.EncodeToBytes(count)
+		if err != nil {
+			return nil, err
+		}
+		if err := batch.Put([]byte(key), value); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("imported message archive", "messages", manifest.MessageCount, "delayedMessages", manifest.DelayedMessageCount, "batches", manifest.BatchCount)
+	return manifest, nil
+}
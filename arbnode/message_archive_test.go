@@ -0,0 +1,127 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func putRLP(t *testing.T, db ethdb.KeyValueWriter, key []byte, value interface{}) {
+	t.Helper()
+	data, err := rlp.EncodeToBytes(value)
+	Require(t, err)
+	Require(t, db.Put(key, data))
+}
+
+func newMessageArchiveTestDB(t *testing.T) ethdb.Database {
+	db := rawdb.NewMemoryDatabase()
+	for i := uint64(0); i < 5; i++ {
+		putRLP(t, db, dbKey(messagePrefix, i), arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{
+					Kind:        arbostypes.L1MessageType_L2Message,
+					Poster:      common.HexToAddress("0x1234"),
+					BlockNumber: i,
+					L1BaseFee:   common.Big1,
+				},
+				L2msg: []byte{byte(i)},
+			},
+			DelayedMessagesRead: 1,
+		})
+		putRLP(t, db, dbKey(blockHashInputFeedPrefix, i), blockHashDBValue{BlockHash: &common.Hash{byte(i)}})
+	}
+	Require(t, setMessageCount(db, 5))
+	// One delayed message in each format
+	Require(t, db.Put(dbKey(legacyDelayedMessagePrefix, 0), append(common.Hash{1}.Bytes(), 0xaa)))
+	Require(t, db.Put(dbKey(rlpDelayedMessagePrefix, 1), append(common.Hash{2}.Bytes(), 0xbb)))
+	putRLP(t, db, dbKey(parentChainBlockNumberPrefix, 1), uint64(100))
+	putRLP(t, db, delayedMessageCountKey, uint64(2))
+	for i := uint64(0); i < 2; i++ {
+		putRLP(t, db, dbKey(sequencerBatchMetaPrefix, i), BatchMetadata{
+			Accumulator:         common.Hash{byte(i + 10)},
+			MessageCount:        arbutil.MessageIndex(2 + i*3),
+			DelayedMessageCount: i + 1,
+			ParentChainBlock:    100 + i,
+		})
+		putRLP(t, db, dbKey(delayedSequencedPrefix, i+1), i)
+	}
+	putRLP(t, db, sequencerBatchCountKey, uint64(2))
+	return db
+}
+
+func TestMessageArchiveRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newMessageArchiveTestDB(t)
+	dir := t.TempDir()
+	manifest, err := ExportMessageArchive(ctx, source, dir, 4)
+	Require(t, err)
+	if manifest.MessageCount != 5 || manifest.DelayedMessageCount != 2 || manifest.BatchCount != 2 {
+		Fail(t, "wrong counts in manifest", manifest.MessageCount, manifest.DelayedMessageCount, manifest.BatchCount)
+	}
+	// 5 messages, 2 delayed messages, 2 batches and 2 delayed sequenced entries
+	if len(manifest.Chunks) != 3 {
+		Fail(t, "expected 3 chunks but got", len(manifest.Chunks))
+	}
+
+	dest := rawdb.NewMemoryDatabase()
+	_, err = ImportMessageArchive(ctx, dest, dir)
+	Require(t, err)
+	iter := source.NewIterator(nil, nil)
+	defer iter.Release()
+	keys := 0
+	for iter.Next() {
+		keys++
+		value, err := dest.Get(iter.Key())
+		Require(t, err, "missing key", string(iter.Key()))
+		if !bytes.Equal(value, iter.Value()) {
+			Fail(t, "wrong value imported for key", iter.Key())
+		}
+	}
+	Require(t, iter.Error())
+	if keys == 0 {
+		Fail(t, "source database is empty")
+	}
+
+	// The database now has messages
+	_, err = ImportMessageArchive(ctx, dest, dir)
+	if err == nil || !strings.Contains(err.Error(), "empty database") {
+		Fail(t, "expected importing into a database with messages to fail, got", err)
+	}
+}
+
+func TestMessageArchiveChecksum(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	manifest, err := ExportMessageArchive(ctx, newMessageArchiveTestDB(t), dir, 100)
+	Require(t, err)
+	path := filepath.Join(dir, manifest.Chunks[0].File)
+	data, err := os.ReadFile(path)
+	Require(t, err)
+	data[len(data)-1] ^= 0xff
+	Require(t, os.WriteFile(path, data, 0600))
+
+	dest := rawdb.NewMemoryDatabase()
+	_, err = ImportMessageArchive(ctx, dest, dir)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		Fail(t, "expected a checksum mismatch, got", err)
+	}
+	count, err := readUint64Key(dest, messageCountKey)
+	Require(t, err)
+	if count != 0 {
+		Fail(t, "failed import left a message count of", count)
+	}
+}
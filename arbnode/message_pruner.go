@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -28,6 +29,9 @@ type MessagePruner struct {
 	config                           MessagePrunerConfigFetcher
 	pruningLock                      sync.Mutex
 	lastPruneDone                    time.Time
+	confirmedMutex                   sync.Mutex
+	confirmedCount                   arbutil.MessageIndex
+	confirmedGlobalState             *validator.GoGlobalState
 	cachedPrunedMessages             uint64
 	cachedPrunedBlockHashesInputFeed uint64
 	cachedPrunedMessageResult        uint64
@@ -68,6 +72,11 @@ func (m *MessagePruner) Start(ctxIn context.Context) {
 }
 
 func (m *MessagePruner) UpdateLatestConfirmed(count arbutil.MessageIndex, globalState validator.GoGlobalState) {
+	m.confirmedMutex.Lock()
+	m.confirmedCount = count
+	m.confirmedGlobalState = &globalState
+	m.confirmedMutex.Unlock()
+
 	locked := m.pruningLock.TryLock()
 	if !locked {
 		return
@@ -90,6 +99,19 @@ func (m *MessagePruner) UpdateLatestConfirmed(count arbutil.MessageIndex, global
 	}
 }
 
+// PruneNow prunes up to the latest confirmed state, without waiting for the prune interval.
+func (m *MessagePruner) PruneNow(ctx context.Context) error {
+	m.confirmedMutex.Lock()
+	count, globalState := m.confirmedCount, m.confirmedGlobalState
+	m.confirmedMutex.Unlock()
+	if globalState == nil {
+		return errors.New("no confirmed state to prune up to yet")
+	}
+	m.pruningLock.Lock()
+	defer m.pruningLock.Unlock()
+	return m.prune(ctx, count, *globalState)
+}
+
 func (m *MessagePruner) prune(ctx context.Context, count arbutil.MessageIndex, globalState validator.GoGlobalState) error {
 	trimBatchCount := globalState.Batch
 	minBatchesLeft := m.config().MinBatchesLeft
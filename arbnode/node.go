@@ -27,15 +27,20 @@ import (
 	"github.com/offchainlabs/nitro/arbnode/dataposter"
 	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
 	"github.com/offchainlabs/nitro/arbnode/resourcemanager"
+	"github.com/offchainlabs/nitro/arbnode/snapshot"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbstate/daprovider/external"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcastclient"
 	"github.com/offchainlabs/nitro/broadcastclients"
 	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/broadcastgossip"
 	"github.com/offchainlabs/nitro/cmd/chaininfo"
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/execution/execrpc"
 	"github.com/offchainlabs/nitro/execution/gethexec"
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
@@ -86,14 +91,23 @@ type Config struct {
 	MessagePruner       MessagePrunerConfig         `koanf:"message-pruner" reload:"hot"`
 	BlockValidator      staker.BlockValidatorConfig `koanf:"block-validator" reload:"hot"`
 	Feed                broadcastclient.FeedConfig  `koanf:"feed" reload:"hot"`
+	FeedGossip          broadcastgossip.Config      `koanf:"feed-gossip" reload:"hot"`
 	Staker              staker.L1ValidatorConfig    `koanf:"staker" reload:"hot"`
 	SeqCoordinator      SeqCoordinatorConfig        `koanf:"seq-coordinator"`
 	DataAvailability    das.DataAvailabilityConfig  `koanf:"data-availability"`
+	ExternalDA          external.Config             `koanf:"external-da"`
 	SyncMonitor         SyncMonitorConfig           `koanf:"sync-monitor"`
 	Dangerous           DangerousConfig             `koanf:"dangerous"`
 	TransactionStreamer TransactionStreamerConfig   `koanf:"transaction-streamer" reload:"hot"`
 	Maintenance         MaintenanceConfig           `koanf:"maintenance" reload:"hot"`
 	ResourceMgmt        resourcemanager.Config      `koanf:"resource-mgmt" reload:"hot"`
+	Health              HealthConfig                `koanf:"health" reload:"hot"`
+	SnapshotProducer    snapshot.ProducerConfig     `koanf:"snapshot-producer"`
+	SnapshotPeerServer  snapshot.PeerServerConfig   `koanf:"snapshot-peer-server" reload:"hot"`
+	AssertionProofs     AssertionProofsConfig       `koanf:"assertion-proofs"`
+	RollupCompatibility RollupCompatibilityConfig   `koanf:"rollup-compatibility"`
+	CensorshipAudit     CensorshipAuditConfig       `koanf:"censorship-audit" reload:"hot"`
+	IPC                 execrpc.IPCConfig           `koanf:"ipc"`
 	// SnapSyncConfig is only used for testing purposes, these should not be configured in production.
 	SnapSyncTest SnapSyncConfig
 }
@@ -111,6 +125,7 @@ func (c *Config) Validate() error {
 		}
 		c.Feed.Output.Enable = false
 		c.Feed.Input.URL = []string{}
+		c.FeedGossip.Enable = false
 	}
 	if err := c.BlockValidator.Validate(); err != nil {
 		return err
@@ -118,18 +133,54 @@ func (c *Config) Validate() error {
 	if err := c.Maintenance.Validate(); err != nil {
 		return err
 	}
+	if err := c.SeqCoordinator.Validate(); err != nil {
+		return err
+	}
+	if err := c.ResourceMgmt.Validate(); err != nil {
+		return err
+	}
 	if err := c.InboxReader.Validate(); err != nil {
 		return err
 	}
+	if err := c.FeedGossip.Validate(); err != nil {
+		return err
+	}
 	if err := c.BatchPoster.Validate(); err != nil {
 		return err
 	}
+	if err := c.ExternalDA.Validate(); err != nil {
+		return err
+	}
+	if c.BatchPoster.Enable && c.BatchPoster.DAProvider == DAProviderExternal && !c.ExternalDA.Enable {
+		return errors.New("batch poster set to store batches with an external data availability provider, but external-da is not enabled")
+	}
 	if err := c.Feed.Validate(); err != nil {
 		return err
 	}
 	if err := c.Staker.Validate(); err != nil {
 		return err
 	}
+	if err := c.SnapshotProducer.Validate(); err != nil {
+		return err
+	}
+	if c.SnapshotProducer.Enable && !c.Staker.Enable {
+		return errors.New("the snapshot producer needs the staker enabled to learn confirmed assertions")
+	}
+	if err := c.SnapshotPeerServer.Validate(); err != nil {
+		return err
+	}
+	if err := c.AssertionProofs.Validate(); err != nil {
+		return err
+	}
+	if c.AssertionProofs.Enable && !c.Staker.Enable {
+		return errors.New("assertion proofs need the staker enabled to learn confirmed assertions")
+	}
+	if err := c.CensorshipAudit.Validate(); err != nil {
+		return err
+	}
+	if c.CensorshipAudit.Enable && !c.Sequencer {
+		return errors.New("the censorship audit can only run on a sequencer")
+	}
 	return nil
 }
 
@@ -152,13 +203,24 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 	MessagePrunerConfigAddOptions(prefix+".message-pruner", f)
 	staker.BlockValidatorConfigAddOptions(prefix+".block-validator", f)
 	broadcastclient.FeedConfigAddOptions(prefix+".feed", f, feedInputEnable, feedOutputEnable)
+	if feedInputEnable {
+		broadcastgossip.ConfigAddOptions(prefix+".feed-gossip", f)
+	}
 	staker.L1ValidatorConfigAddOptions(prefix+".staker", f)
 	SeqCoordinatorConfigAddOptions(prefix+".seq-coordinator", f)
 	das.DataAvailabilityConfigAddNodeOptions(prefix+".data-availability", f)
+	external.ConfigAddOptions(prefix+".external-da", f)
 	SyncMonitorConfigAddOptions(prefix+".sync-monitor", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	TransactionStreamerConfigAddOptions(prefix+".transaction-streamer", f)
 	MaintenanceConfigAddOptions(prefix+".maintenance", f)
+	HealthConfigAddOptions(prefix+".health", f)
+	snapshot.ProducerConfigAddOptions(prefix+".snapshot-producer", f)
+	snapshot.PeerServerConfigAddOptions(prefix+".snapshot-peer-server", f)
+	AssertionProofsConfigAddOptions(prefix+".assertion-proofs", f)
+	RollupCompatibilityConfigAddOptions(prefix+".rollup-compatibility", f)
+	CensorshipAuditConfigAddOptions(prefix+".censorship-audit", f)
+	execrpc.IPCConfigAddOptions(prefix+".ipc", f, "consensus")
 }
 
 var ConfigDefault = Config{
@@ -170,14 +232,23 @@ var ConfigDefault = Config{
 	MessagePruner:       DefaultMessagePrunerConfig,
 	BlockValidator:      staker.DefaultBlockValidatorConfig,
 	Feed:                broadcastclient.FeedConfigDefault,
+	FeedGossip:          broadcastgossip.DefaultConfig,
 	Staker:              staker.DefaultL1ValidatorConfig,
 	SeqCoordinator:      DefaultSeqCoordinatorConfig,
 	DataAvailability:    das.DefaultDataAvailabilityConfig,
+	ExternalDA:          external.DefaultConfig,
 	SyncMonitor:         DefaultSyncMonitorConfig,
 	Dangerous:           DefaultDangerousConfig,
 	TransactionStreamer: DefaultTransactionStreamerConfig,
 	ResourceMgmt:        resourcemanager.DefaultConfig,
 	Maintenance:         DefaultMaintenanceConfig,
+	Health:              DefaultHealthConfig,
+	SnapshotProducer:    snapshot.DefaultProducerConfig,
+	SnapshotPeerServer:  snapshot.DefaultPeerServerConfig,
+	AssertionProofs:     DefaultAssertionProofsConfig,
+	RollupCompatibility: DefaultRollupCompatibilityConfig,
+	CensorshipAudit:     DefaultCensorshipAuditConfig,
+	IPC:                 execrpc.DefaultIPCConfig,
 	SnapSyncTest:        DefaultSnapSyncConfig,
 }
 
@@ -205,6 +276,7 @@ func ConfigDefaultL1NonSequencerTest() *Config {
 	config.Staker = staker.TestL1ValidatorConfig
 	config.Staker.Enable = false
 	config.BlockValidator.ValidationServerConfigs = []rpcclient.ClientConfig{{URL: ""}}
+	config.RollupCompatibility = TestRollupCompatibilityConfig
 
 	return &config
 }
@@ -269,10 +341,19 @@ type Node struct {
 	Staker                  *staker.Staker
 	BroadcastServer         *broadcaster.Broadcaster
 	BroadcastClients        *broadcastclients.BroadcastClients
+	FeedGossip              *broadcastgossip.Gossip
 	SeqCoordinator          *SeqCoordinator
 	MaintenanceRunner       *MaintenanceRunner
 	DASLifecycleManager     *das.LifecycleManager
+	ExternalDAClient        *external.Client
 	SyncMonitor             *SyncMonitor
+	HealthServer            *HealthServer
+	SnapshotProducer        *snapshot.Producer
+	AssertionProofs         *AssertionProofs
+	RollupCompatibility     *RollupCompatibilityChecker
+	PauseControls           *PauseControls
+	CensorshipAudit         *CensorshipAudit   // nil unless enabled
+	IPCServer               *execrpc.IPCServer // nil unless enabled
 	configFetcher           ConfigFetcher
 	ctx                     context.Context
 }
@@ -457,6 +538,11 @@ func createNodeImpl(
 	if err != nil {
 		return nil, err
 	}
+	pauseControls, err := NewPauseControls(arbDb)
+	if err != nil {
+		return nil, err
+	}
+	txStreamer.SetPauseControls(pauseControls)
 	var coordinator *SeqCoordinator
 	var bpVerifier *contracts.AddressVerifier
 	if deployInfo != nil && l1client != nil {
@@ -478,29 +564,60 @@ func createNodeImpl(
 		return nil, errors.New("sequencer must be enabled with coordinator, unless dangerous.no-sequencer-coordinator set")
 	}
 	dbs := []ethdb.Database{arbDb}
-	maintenanceRunner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &configFetcher.Get().Maintenance }, coordinator, dbs, exec)
+	maintenanceRunner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &configFetcher.Get().Maintenance }, coordinator, config.Sequencer, dbs, exec)
 	if err != nil {
 		return nil, err
 	}
+	if execNode, ok := exec.(*gethexec.ExecutionNode); ok {
+		maintenanceRunner.AddTask(MaintenanceTaskWasmCacheCleanup, func(context.Context) error {
+			execNode.ExecEngine.ClearWasmLruCache()
+			return nil
+		})
+	}
 
 	var broadcastClients *broadcastclients.BroadcastClients
-	if config.Feed.Input.Enable() {
-		currentMessageCount, err := txStreamer.GetMessageCount()
+	var feedGossip *broadcastgossip.Gossip
+	if config.Feed.Input.Enable() || config.FeedGossip.Enable {
+		// Feed signers published by the chain owner are read from our own copy of the chain's state
+		arbOwnerPublic, err := precompilesgen.NewArbOwnerPublicCaller(types.ArbOwnerPublicAddress, ethclient.NewClient(stack.Attach()))
 		if err != nil {
 			return nil, err
 		}
-
-		broadcastClients, err = broadcastclients.NewBroadcastClients(
-			func() *broadcastclient.Config { return &configFetcher.Get().Feed.Input },
-			l2ChainId,
-			currentMessageCount,
-			txStreamer,
-			nil,
-			fatalErrChan,
-			bpVerifier,
-		)
-		if err != nil {
-			return nil, err
+		feedSigners := contracts.NewFeedSignerVerifier(arbOwnerPublic)
+		var feedStreamer broadcastclient.TransactionStreamerInterface = txStreamer
+		if config.FeedGossip.Enable {
+			// Gossip peers must sign with the same keys the feed input accepts
+			feedGossip, err = broadcastgossip.New(
+				func() *broadcastgossip.Config { return &configFetcher.Get().FeedGossip },
+				&config.Feed.Input.Verify,
+				l2ChainId,
+				txStreamer,
+				bpVerifier,
+				feedSigners,
+			)
+			if err != nil {
+				return nil, err
+			}
+			feedStreamer = feedGossip
+		}
+		if config.Feed.Input.Enable() {
+			currentMessageCount, err := txStreamer.GetMessageCount()
+			if err != nil {
+				return nil, err
+			}
+			broadcastClients, err = broadcastclients.NewBroadcastClients(
+				func() *broadcastclient.Config { return &configFetcher.Get().Feed.Input },
+				l2ChainId,
+				currentMessageCount,
+				feedStreamer,
+				nil,
+				fatalErrChan,
+				bpVerifier,
+				feedSigners,
+			)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -523,10 +640,13 @@ func createNodeImpl(
 			Staker:                  nil,
 			BroadcastServer:         broadcastServer,
 			BroadcastClients:        broadcastClients,
+			FeedGossip:              feedGossip,
 			SeqCoordinator:          coordinator,
 			MaintenanceRunner:       maintenanceRunner,
 			DASLifecycleManager:     nil,
+			ExternalDAClient:        nil,
 			SyncMonitor:             syncMonitor,
+			PauseControls:           pauseControls,
 			configFetcher:           configFetcher,
 			ctx:                     ctx,
 		}, nil
@@ -578,10 +698,20 @@ func createNodeImpl(
 	if txStreamer != nil && txStreamer.chainConfig.ArbitrumChainParams.DataAvailabilityCommittee && daReader == nil {
 		return nil, errors.New("data availability service required but unconfigured")
 	}
+	var externalDAClient *external.Client
+	if config.ExternalDA.Enable {
+		externalDAClient, err = external.NewClient(ctx, func() *external.Config { return &configFetcher.Get().ExternalDA })
+		if err != nil {
+			return nil, err
+		}
+	}
 	var dapReaders []daprovider.Reader
 	if daReader != nil {
 		dapReaders = append(dapReaders, daprovider.NewReaderForDAS(daReader, dasKeysetFetcher))
 	}
+	if externalDAClient != nil {
+		dapReaders = append(dapReaders, externalDAClient)
+	}
 	if blobReader != nil {
 		dapReaders = append(dapReaders, daprovider.NewReaderForBlobReader(blobReader))
 	}
@@ -630,10 +760,13 @@ func createNodeImpl(
 		if err != nil {
 			return nil, err
 		}
+		blockValidator.SetPausedCheck(pauseControls.PausedFunc(PauseValidation))
 	}
 
 	var stakerObj *staker.Staker
 	var messagePruner *MessagePruner
+	var snapshotProducer *snapshot.Producer
+	var assertionProofs *AssertionProofs
 	var stakerAddr common.Address
 
 	if config.Staker.Enable {
@@ -685,33 +818,78 @@ func createNodeImpl(
 			messagePruner = NewMessagePruner(txStreamer, inboxTracker, func() *MessagePrunerConfig { return &configFetcher.Get().MessagePruner })
 			confirmedNotifiers = append(confirmedNotifiers, messagePruner)
 		}
+		if config.SnapshotProducer.Enable {
+			execNode, ok := exec.(*gethexec.ExecutionNode)
+			if !ok {
+				return nil, errors.New("the snapshot producer needs a local execution node")
+			}
+			snapshotProducer, err = snapshot.NewProducer(func() *snapshot.ProducerConfig { return &configFetcher.Get().SnapshotProducer }, l2ChainId, execNode.ChainDB, arbDb)
+			if err != nil {
+				return nil, err
+			}
+			confirmedNotifiers = append(confirmedNotifiers, snapshotProducer)
+		}
+		if config.AssertionProofs.Enable {
+			execNode, ok := exec.(*gethexec.ExecutionNode)
+			if !ok {
+				return nil, errors.New("assertion proofs need a local execution node")
+			}
+			assertionProofs, err = NewAssertionProofs(func() *AssertionProofsConfig { return &configFetcher.Get().AssertionProofs }, execNode.ArbInterface.BlockChain(), arbDb)
+			if err != nil {
+				return nil, err
+			}
+			confirmedNotifiers = append(confirmedNotifiers, assertionProofs)
+		}
 
 		stakerObj, err = staker.NewStaker(l1Reader, wallet, bind.CallOpts{}, func() *staker.L1ValidatorConfig { return &configFetcher.Get().Staker }, blockValidator, statelessBlockValidator, nil, confirmedNotifiers, deployInfo.ValidatorUtils, fatalErrChan)
 		if err != nil {
 			return nil, err
 		}
+		stakerObj.SetPausedCheck(pauseControls.PausedFunc(PauseStaker))
 		if err := wallet.Initialize(ctx); err != nil {
 			return nil, err
 		}
 		if dp != nil {
 			stakerAddr = dp.Sender()
 		}
-		whitelisted, err := stakerObj.IsWhitelisted(ctx)
+		readiness, err := stakerObj.Readiness(ctx)
 		if err != nil {
 			return nil, err
 		}
-		log.Info("running as validator", "txSender", stakerAddr, "actingAsWallet", wallet.Address(), "whitelisted", whitelisted, "strategy", config.Staker.Strategy)
+		log.Info("running as validator", "txSender", stakerAddr, "actingAsWallet", wallet.Address(), "allowlistDisabled", readiness.Allowlist.Disabled, "whitelisted", readiness.Allowlist.Allowed, "strategy", config.Staker.Strategy)
+		if !readiness.Ready && !strings.EqualFold(config.Staker.Strategy, "watchtower") {
+			log.Warn("validator isn't ready to post assertions", "problems", readiness.Problems)
+		}
 	}
 
 	var batchPoster *BatchPoster
 	var delayedSequencer *DelayedSequencer
 	if config.BatchPoster.Enable {
 		if txOptsBatchPoster == nil && config.BatchPoster.DataPoster.ExternalSigner.URL == "" {
-			return nil, errors.New("batchposter, but no TxOpts")
+			if !config.BatchPoster.DryRun {
+				return nil, errors.New("batchposter, but no TxOpts")
+			}
+			// A dry run never signs, so it can run on a replica without the batch poster's key
+			txOptsBatchPoster = &bind.TransactOpts{
+				Signer: func(common.Address, *types.Transaction) (*types.Transaction, error) {
+					return nil, errors.New("batch poster is in dry-run mode")
+				},
+			}
 		}
 		var dapWriter daprovider.Writer
-		if daWriter != nil {
-			dapWriter = daprovider.NewWriterForDAS(daWriter)
+		switch config.BatchPoster.DAProvider {
+		case DAProviderExternal:
+			if externalDAClient == nil {
+				return nil, errors.New("batch poster set to store batches with an external data availability provider, but external-da is not enabled")
+			}
+			if !arbstate.ExternalDAEnabled(l2Config) {
+				return nil, errors.New("batch poster set to store batches with an external data availability provider, but the chain wasn't created with an ArbOS version that reads them")
+			}
+			dapWriter = externalDAClient
+		default:
+			if daWriter != nil {
+				dapWriter = daprovider.NewWriterForDAS(daWriter)
+			}
 		}
 		batchPoster, err = NewBatchPoster(ctx, &BatchPosterOpts{
 			DataPosterDB:  rawdb.NewTable(arbDb, storage.BatchPosterPrefix),
@@ -726,6 +904,7 @@ func createNodeImpl(
 			DAPWriter:     dapWriter,
 			ParentChainID: parentChainID,
 			DAPReaders:    dapReaders,
+			Pauses:        pauseControls,
 		})
 		if err != nil {
 			return nil, err
@@ -743,7 +922,40 @@ func createNodeImpl(
 		return nil, err
 	}
 
-	return &Node{
+	var censorshipAudit *CensorshipAudit
+	if config.CensorshipAudit.Enable {
+		if dataSigner == nil || txOptsBatchPoster == nil {
+			return nil, errors.New("the censorship audit signs its reports with the batch poster's wallet, which isn't configured")
+		}
+		var feed sequencerAuditPublisher
+		if broadcastServer != nil {
+			feed = broadcastServer
+		}
+		censorshipAudit = NewCensorshipAudit(
+			func() *CensorshipAuditConfig { return &configFetcher.Get().CensorshipAudit },
+			l2ChainId,
+			inboxTracker,
+			exec.NextDelayedMessageNumber,
+			txStreamer.GetMessageCount,
+			func() bool { return coordinator == nil || coordinator.CurrentlyChosen() },
+			dataSigner,
+			txOptsBatchPoster.From,
+			feed,
+		)
+		delayedSequencer.SetCensorshipAudit(censorshipAudit)
+		if execNode, ok := exec.(*gethexec.ExecutionNode); ok && execNode.Sequencer != nil {
+			execNode.Sequencer.SetTxOutcomeHook(censorshipAudit.TxOutcome)
+		}
+	}
+
+	if messagePruner != nil {
+		maintenanceRunner.AddTask(MaintenanceTaskMessagePruning, messagePruner.PruneNow)
+	}
+	if snapshotProducer != nil {
+		maintenanceRunner.AddTask(MaintenanceTaskSnapshot, snapshotProducer.ProduceLatest)
+	}
+
+	node := &Node{
 		ArbDB:                   arbDb,
 		Stack:                   stack,
 		Execution:               exec,
@@ -761,13 +973,29 @@ func createNodeImpl(
 		Staker:                  stakerObj,
 		BroadcastServer:         broadcastServer,
 		BroadcastClients:        broadcastClients,
+		FeedGossip:              feedGossip,
 		SeqCoordinator:          coordinator,
 		MaintenanceRunner:       maintenanceRunner,
 		DASLifecycleManager:     dasLifecycleManager,
+		ExternalDAClient:        externalDAClient,
 		SyncMonitor:             syncMonitor,
+		SnapshotProducer:        snapshotProducer,
+		AssertionProofs:         assertionProofs,
+		PauseControls:           pauseControls,
+		CensorshipAudit:         censorshipAudit,
 		configFetcher:           configFetcher,
 		ctx:                     ctx,
-	}, nil
+	}
+	getValidator := func() *staker.StatelessBlockValidator { return node.StatelessBlockValidator }
+	if execNode, ok := exec.(*gethexec.ExecutionNode); ok {
+		node.RollupCompatibility = NewRollupCompatibilityChecker(l1client, deployInfo, execNode.ArbInterface.BlockChain(), getValidator)
+	} else {
+		node.RollupCompatibility = NewRollupCompatibilityChecker(l1client, deployInfo, nil, getValidator)
+	}
+	if config.Health.Addr != "" {
+		node.HealthServer = NewHealthServer(func() *HealthConfig { return &configFetcher.Get().Health }, node, daReader)
+	}
+	return node, nil
 }
 
 func (n *Node) OnConfigReload(_ *Config, _ *Config) error {
@@ -803,6 +1031,36 @@ func CreateNode(
 			Service:   &BlockValidatorAPI{val: currentNode.BlockValidator},
 			Public:    false,
 		})
+		apis = append(apis, rpc.API{
+			Namespace: "validator",
+			Version:   "1.0",
+			Service:   &ValidationStatusAPI{val: currentNode.BlockValidator},
+			Public:    false,
+		})
+	}
+	if currentNode.Staker != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &StakerAPI{staker: currentNode.Staker},
+			Public:    false,
+		})
+	}
+	if currentNode.AssertionProofs != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &AssertionProofsAPI{proofs: currentNode.AssertionProofs},
+			Public:    false,
+		})
+	}
+	if currentNode.RollupCompatibility != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &RollupCompatibilityAPI{checker: currentNode.RollupCompatibility},
+			Public:    false,
+		})
 	}
 	if currentNode.StatelessBlockValidator != nil {
 		apis = append(apis, rpc.API{
@@ -815,7 +1073,110 @@ func CreateNode(
 		})
 	}
 
+	if currentNode.MaintenanceRunner != nil {
+		dbs := map[string]ethdb.Database{"arbitrumdata": arbDb}
+		if execNode, ok := exec.(*gethexec.ExecutionNode); ok {
+			dbs["l2chaindata"] = execNode.ChainDB
+		}
+		apis = append(apis, rpc.API{
+			Namespace: "maintenance",
+			Version:   "1.0",
+			Service:   &MaintenanceAPI{runner: currentNode.MaintenanceRunner, dbs: dbs},
+			Public:    false,
+		})
+	}
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   &DeadLetterAPI{streamer: currentNode.TxStreamer},
+		Public:    false,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   &PauseAPI{controls: currentNode.PauseControls},
+		Public:    false,
+	})
+	if execNode, ok := exec.(*gethexec.ExecutionNode); ok && configFetcher.Get().SnapshotPeerServer.Enable {
+		apis = append(apis, rpc.API{
+			Namespace: snapshot.PeerNamespace,
+			Version:   "1.0",
+			Service:   snapshot.NewPeerAPI(func() *snapshot.PeerServerConfig { return &configFetcher.Get().SnapshotPeerServer }, currentNode.SnapshotProducer, execNode.ChainDB),
+			Public:    false,
+		})
+	}
+	if currentNode.CensorshipAudit != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &CensorshipAuditAPI{audit: currentNode.CensorshipAudit},
+			Public:    false,
+		})
+	}
+	if currentNode.SeqCoordinator != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &SeqCoordinatorAPI{coordinator: currentNode.SeqCoordinator},
+			Public:    false,
+		})
+	}
+	if currentNode.BatchPoster != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &BlobFeeAPI{tracker: currentNode.BatchPoster.blobFees},
+			Public:    false,
+		})
+	}
+	if execNode, ok := exec.(*gethexec.ExecutionNode); ok && currentNode.InboxTracker != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &L1FeeReportAPI{reporter: NewL1FeeReporter(execNode.ArbInterface.BlockChain(), currentNode.InboxTracker)},
+			Public:    false,
+		})
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &BatchInfoAPI{reporter: NewBatchInfoReporter(execNode.ChainDB, currentNode.InboxTracker, currentNode.InboxReader, l2Config.ArbitrumChainParams.GenesisBlockNum)},
+			Public:    false,
+		})
+	}
+	if currentNode.L1Reader != nil && currentNode.InboxReader != nil {
+		depositWatcher, err := NewDepositWatcher(
+			currentNode.L1Reader,
+			currentNode.InboxReader.DelayedBridge(),
+			deployInfo.SequencerInbox,
+			currentNode.InboxTracker,
+			currentNode.TxStreamer,
+			l2Config.ChainID,
+			l2Config.ArbitrumChainParams.GenesisBlockNum,
+			func() *DelayedSequencerConfig { return &configFetcher.Get().DelayedSequencer },
+		)
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &DepositWatcherAPI{watcher: depositWatcher},
+			Public:    false,
+		})
+	}
+	apis = append(apis, rpc.API{
+		Namespace: execrpc.ConsensusNamespace,
+		Version:   "1.0",
+		Service:   execrpc.NewConsensusServerAPI(currentNode),
+		Public:    false,
+	})
 	stack.RegisterAPIs(apis)
+	if ipcPath := configFetcher.Get().IPC.Path; ipcPath != "" {
+		currentNode.IPCServer, err = execrpc.NewIPCServer(ipcPath, execrpc.ConsensusNamespace, execrpc.NewConsensusServerAPI(currentNode))
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return currentNode, nil
 }
@@ -897,12 +1258,18 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.DelayedSequencer != nil {
 		n.DelayedSequencer.Start(ctx)
 	}
+	if n.CensorshipAudit != nil {
+		n.CensorshipAudit.Start(ctx)
+	}
 	if n.BatchPoster != nil {
 		n.BatchPoster.Start(ctx)
 	}
 	if n.MessagePruner != nil {
 		n.MessagePruner.Start(ctx)
 	}
+	if n.AssertionProofs != nil {
+		n.AssertionProofs.Start(ctx)
+	}
 	if n.Staker != nil {
 		err = n.Staker.Initialize(ctx)
 		if err != nil {
@@ -920,6 +1287,13 @@ func (n *Node) Start(ctx context.Context) error {
 			n.BlockValidator = nil
 		}
 	}
+	// After the validation servers start so their module roots are known
+	if n.RollupCompatibility != nil && n.configFetcher.Get().RollupCompatibility.Enable {
+		err = n.RollupCompatibility.startupCheck(ctx, &n.configFetcher.Get().RollupCompatibility)
+		if err != nil {
+			return err
+		}
+	}
 	if n.BlockValidator != nil {
 		err = n.BlockValidator.Initialize(ctx)
 		if err != nil {
@@ -936,6 +1310,11 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.L1Reader != nil {
 		n.L1Reader.Start(ctx)
 	}
+	if n.FeedGossip != nil {
+		if err := n.FeedGossip.Start(ctx); err != nil {
+			return fmt.Errorf("error starting feed gossip: %w", err)
+		}
+	}
 	if n.BroadcastClients != nil {
 		go func() {
 			if n.InboxReader != nil {
@@ -952,10 +1331,33 @@ func (n *Node) Start(ctx context.Context) error {
 		n.configFetcher.Start(ctx)
 	}
 	n.SyncMonitor.Start(ctx)
+	if n.SnapshotProducer != nil {
+		err = n.SnapshotProducer.Start(ctx)
+		if err != nil {
+			return fmt.Errorf("error starting snapshot producer: %w", err)
+		}
+	}
+	if n.HealthServer != nil {
+		n.HealthServer.Start(ctx)
+	}
+	if n.IPCServer != nil {
+		if err := n.IPCServer.Start(ctx); err != nil {
+			return fmt.Errorf("error starting consensus ipc server: %w", err)
+		}
+	}
 	return nil
 }
 
 func (n *Node) StopAndWait() {
+	if n.IPCServer != nil {
+		n.IPCServer.StopAndWait()
+	}
+	if n.HealthServer != nil && n.HealthServer.Started() {
+		n.HealthServer.StopAndWait()
+	}
+	if n.SnapshotProducer != nil && n.SnapshotProducer.Started() {
+		n.SnapshotProducer.StopAndWait()
+	}
 	if n.MaintenanceRunner != nil && n.MaintenanceRunner.Started() {
 		n.MaintenanceRunner.StopAndWait()
 	}
@@ -971,18 +1373,27 @@ func (n *Node) StopAndWait() {
 	if n.DelayedSequencer != nil && n.DelayedSequencer.Started() {
 		n.DelayedSequencer.StopAndWait()
 	}
+	if n.CensorshipAudit != nil && n.CensorshipAudit.Started() {
+		n.CensorshipAudit.StopAndWait()
+	}
 	if n.BatchPoster != nil && n.BatchPoster.Started() {
 		n.BatchPoster.StopAndWait()
 	}
 	if n.MessagePruner != nil && n.MessagePruner.Started() {
 		n.MessagePruner.StopAndWait()
 	}
+	if n.AssertionProofs != nil && n.AssertionProofs.Started() {
+		n.AssertionProofs.StopAndWait()
+	}
 	if n.BroadcastServer != nil && n.BroadcastServer.Started() {
 		n.BroadcastServer.StopAndWait()
 	}
 	if n.BroadcastClients != nil {
 		n.BroadcastClients.StopAndWait()
 	}
+	if n.FeedGossip != nil && n.FeedGossip.Started() {
+		n.FeedGossip.StopAndWait()
+	}
 	if n.BlockValidator != nil && n.BlockValidator.Started() {
 		n.BlockValidator.StopAndWait()
 	}
@@ -1009,6 +1420,9 @@ func (n *Node) StopAndWait() {
 	if n.DASLifecycleManager != nil {
 		n.DASLifecycleManager.StopAndWaitUntil(2 * time.Second)
 	}
+	if n.ExternalDAClient != nil {
+		n.ExternalDAClient.Close()
+	}
 	if n.Execution != nil {
 		n.Execution.StopAndWait()
 	}
@@ -1025,6 +1439,13 @@ func (n *Node) GetBatchParentChainBlock(seqNum uint64) (uint64, error) {
 	return n.InboxTracker.GetBatchParentChainBlock(seqNum)
 }
 
+func (n *Node) GetBatchDataProof(ctx context.Context, seqNum uint64) (*execution.BatchDataProof, error) {
+	if n.InboxReader == nil {
+		return nil, errors.New("batch data proofs need the parent chain inbox reader")
+	}
+	return n.InboxReader.GetBatchDataProof(ctx, seqNum)
+}
+
 func (n *Node) FullSyncProgressMap() map[string]interface{} {
 	return n.SyncMonitor.FullSyncProgressMap()
 }
@@ -1060,3 +1481,15 @@ func (n *Node) ValidatedMessageCount() (arbutil.MessageIndex, error) {
 	}
 	return n.BlockValidator.GetValidated(), nil
 }
+
+// BatchPostedMessageCount returns the number of messages in batches which have been read from the parent chain.
+func (n *Node) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	if n.InboxTracker == nil {
+		return 0, errors.New("inbox tracker not set up")
+	}
+	batchCount, err := n.InboxTracker.GetBatchCount()
+	if err != nil || batchCount == 0 {
+		return 0, err
+	}
+	return n.InboxTracker.GetBatchMessageCount(batchCount - 1)
+}
@@ -0,0 +1,151 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Background actors that can be paused on their own, leaving the rest of the node running
+const (
+	PauseBatchPoster = "batch-poster"
+	PauseStaker      = "staker"
+	PauseValidation  = "validation"
+	PauseFeed        = "feed"
+)
+
+var (
+	pausableActors = []string{PauseBatchPoster, PauseStaker, PauseValidation, PauseFeed}
+
+	pausedGauges = map[string]metrics.Gauge{
+		PauseBatchPoster: metrics.NewRegisteredGauge("arb/pause/batch-poster", nil),
+		PauseStaker:      metrics.NewRegisteredGauge("arb/pause/staker", nil),
+		PauseValidation:  metrics.NewRegisteredGauge("arb/pause/validation", nil),
+		PauseFeed:        metrics.NewRegisteredGauge("arb/pause/feed", nil),
+	}
+)
+
+// PauseControls records which background actors an operator paused, in the database so they stay paused
+// across restarts. Paused actors keep their state and pick up where they left off once resumed.
+type PauseControls struct {
+	db     ethdb.KeyValueStore
+	mutex  sync.RWMutex
+	paused map[string]bool
+}
+
+func NewPauseControls(db ethdb.KeyValueStore) (*PauseControls, error) {
+	p := &PauseControls{
+		db:     db,
+		paused: make(map[string]bool),
+	}
+	has, err := db.Has(pausedActorsKey)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		data, err := db.Get(pausedActorsKey)
+		if err != nil {
+			return nil, err
+		}
+		var actors []string
+		if err := rlp.DecodeBytes(data, &actors); err != nil {
+			return nil, err
+		}
+		for _, actor := range actors {
+			if _, ok := pausedGauges[actor]; !ok {
+				log.Warn("ignoring unknown paused actor in database", "actor", actor)
+				continue
+			}
+			p.paused[actor] = true
+			pausedGauges[actor].Update(1)
+		}
+	}
+	if len(p.paused) > 0 {
+		log.Warn("background actors paused by an operator, resume them with arb_resumeActor", "paused", p.pausedActors())
+	}
+	return p, nil
+}
+
+// Paused returns whether the actor is paused. A nil PauseControls pauses nothing.
+func (p *PauseControls) Paused(actor string) bool {
+	if p == nil {
+		return false
+	}
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.paused[actor]
+}
+
+// PausedFunc returns a check of whether the actor is paused, for components outside this package.
+func (p *PauseControls) PausedFunc(actor string) func() bool {
+	return func() bool { return p.Paused(actor) }
+}
+
+// The mutex must be held
+func (p *PauseControls) pausedActors() []string {
+	actors := make([]string, 0, len(p.paused))
+	for actor := range p.paused {
+		actors = append(actors, actor)
+	}
+	sort.Strings(actors)
+	return actors
+}
+
+// SetPaused pauses or resumes an actor, persisting the change before it takes effect.
+func (p *PauseControls) SetPaused(actor string, paused bool) error {
+	gauge, ok := pausedGauges[actor]
+	if !ok {
+		return fmt.Errorf("unknown actor \"%v\", expected one of %v", actor, pausableActors)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.paused[actor] == paused {
+		return nil
+	}
+	next := make(map[string]bool, len(p.paused)+1)
+	for other := range p.paused {
+		next[other] = true
+	}
+	if paused {
+		next[actor] = true
+	} else {
+		delete(next, actor)
+	}
+	previous := p.paused
+	p.paused = next
+	data, err := rlp.EncodeToBytes(p.pausedActors())
+	if err == nil {
+		err = p.db.Put(pausedActorsKey, data)
+	}
+	if err != nil {
+		p.paused = previous
+		return err
+	}
+	if paused {
+		gauge.Update(1)
+		log.Warn("paused background actor", "actor", actor)
+	} else {
+		gauge.Update(0)
+		log.Info("resumed background actor", "actor", actor)
+	}
+	return nil
+}
+
+// Status returns whether each actor is paused.
+func (p *PauseControls) Status() map[string]bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	status := make(map[string]bool, len(pausableActors))
+	for _, actor := range pausableActors {
+		status[actor] = p.paused[actor]
+	}
+	return status
+}
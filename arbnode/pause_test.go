@@ -0,0 +1,40 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestPauseControlsPersist(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	controls, err := NewPauseControls(db)
+	Require(t, err)
+	Require(t, controls.SetPaused(PauseBatchPoster, true))
+	Require(t, controls.SetPaused(PauseFeed, true))
+	Require(t, controls.SetPaused(PauseFeed, false))
+	if err := controls.SetPaused("sequencer", true); err == nil {
+		Fail(t, "paused an unknown actor")
+	}
+	stakerPaused := controls.PausedFunc(PauseStaker)
+	Require(t, controls.SetPaused(PauseStaker, true))
+	if !stakerPaused() {
+		Fail(t, "paused check doesn't follow the controls")
+	}
+
+	// A restarted node keeps the actors paused
+	reopened, err := NewPauseControls(db)
+	Require(t, err)
+	status := reopened.Status()
+	if !status[PauseBatchPoster] || !status[PauseStaker] || status[PauseFeed] || status[PauseValidation] || len(status) != 4 {
+		Fail(t, "unexpected status after restart", status)
+	}
+
+	var none *PauseControls
+	if none.Paused(PauseBatchPoster) {
+		Fail(t, "nil controls paused an actor")
+	}
+}
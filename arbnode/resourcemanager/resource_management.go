@@ -11,13 +11,14 @@ import (
 	"os"
 	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/membudget"
 )
 
 var (
@@ -57,48 +58,34 @@ func Init(conf *Config) error {
 	return nil
 }
 
+// ParseMemLimit parses a memory limit, see membudget.ParseMemLimit.
 func ParseMemLimit(limitStr string) (int, error) {
-	var (
-		limit int = 1
-		s     string
-	)
-	if _, err := fmt.Sscanf(limitStr, "%d%s", &limit, &s); err != nil {
-		return 0, err
-	}
-
-	switch strings.ToUpper(s) {
-	case "K", "KB":
-		limit <<= 10
-	case "M", "MB":
-		limit <<= 20
-	case "G", "GB":
-		limit <<= 30
-	case "T", "TB":
-		limit <<= 40
-	case "B":
-	default:
-		return 0, fmt.Errorf("unsupported memory limit suffix string %s", s)
-	}
-
-	return limit, nil
+	return membudget.ParseMemLimit(limitStr)
 }
 
 // Config contains the configuration for resourcemanager functionality.
 // Currently only a memory limit is supported, other limits may be added
 // in the future.
 type Config struct {
-	MemFreeLimit string `koanf:"mem-free-limit" reload:"hot"`
+	MemFreeLimit string           `koanf:"mem-free-limit" reload:"hot"`
+	MemBudget    membudget.Config `koanf:"mem-budget" reload:"hot"`
 }
 
 // DefaultConfig has the defaul resourcemanager configuration,
 // all limits are disabled.
 var DefaultConfig = Config{
 	MemFreeLimit: "",
+	MemBudget:    membudget.DefaultConfig,
+}
+
+func (c *Config) Validate() error {
+	return c.MemBudget.Validate()
 }
 
 // ConfigAddOptions adds the configuration options for resourcemanager.
 func ConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.String(prefix+".mem-free-limit", DefaultConfig.MemFreeLimit, "RPC calls are throttled if free system memory excluding the page cache is below this amount, expressed in bytes or multiples of bytes with suffix B, K, M, G. The limit should be set such that sufficient free memory is left for the page cache in order for the system to be performant")
+	membudget.ConfigAddOptions(prefix+".mem-budget", f)
 }
 
 // httpServer implements http.Handler and wraps calls to inner with a resource
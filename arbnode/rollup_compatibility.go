@@ -0,0 +1,280 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/staker"
+)
+
+type RollupCompatibilityConfig struct {
+	Enable             bool `koanf:"enable"`
+	FailOnIncompatible bool `koanf:"fail-on-incompatible"`
+}
+
+var DefaultRollupCompatibilityConfig = RollupCompatibilityConfig{
+	Enable:             true,
+	FailOnIncompatible: true,
+}
+
+// Tests run validators against mock validation servers, which don't support the deployed module root
+var TestRollupCompatibilityConfig = RollupCompatibilityConfig{
+	Enable:             true,
+	FailOnIncompatible: false,
+}
+
+func RollupCompatibilityConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRollupCompatibilityConfig.Enable, "check on startup that the node supports the parent chain's rollup contracts, their wasm module root and the chain's ArbOS version")
+	f.Bool(prefix+".fail-on-incompatible", DefaultRollupCompatibilityConfig.FailOnIncompatible, "refuse to start if the startup check finds the node incompatible, rather than only logging the problems")
+}
+
+// RollupCompatibility is what the node found comparing itself to the rollup's contracts and chain.
+type RollupCompatibility struct {
+	Compatible               bool          `json:"compatible"`
+	WasmModuleRoot           common.Hash   `json:"wasmModuleRoot"`
+	SupportedWasmModuleRoots []common.Hash `json:"supportedWasmModuleRoots,omitempty"` // only known when validating
+	ArbOSVersion             uint64        `json:"arbosVersion"`
+	ScheduledArbOSVersion    uint64        `json:"scheduledArbosVersion,omitempty"`
+	ScheduledArbOSTimestamp  uint64        `json:"scheduledArbosTimestamp,omitempty"`
+	MaxArbOSVersionSupported uint64        `json:"maxArbosVersionSupported"`
+	Problems                 []string      `json:"problems"`
+}
+
+func (r *RollupCompatibility) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// RollupCompatibilityChecker compares the contracts at the chain info's rollup addresses, and the chain's
+// ArbOS version, with what this node supports, so contract and ArbOS upgrades the node predates are caught
+// up front instead of as failures reading the inbox or validating.
+type RollupCompatibilityChecker struct {
+	l1Client   *ethclient.Client
+	deployInfo *chaininfo.RollupAddresses
+	bc         *core.BlockChain                       // nil without a local execution node
+	validator  func() *staker.StatelessBlockValidator // nil result when not validating
+}
+
+func NewRollupCompatibilityChecker(l1Client *ethclient.Client, deployInfo *chaininfo.RollupAddresses, bc *core.BlockChain, validator func() *staker.StatelessBlockValidator) *RollupCompatibilityChecker {
+	return &RollupCompatibilityChecker{
+		l1Client:   l1Client,
+		deployInfo: deployInfo,
+		bc:         bc,
+		validator:  validator,
+	}
+}
+
+// Check returns an error only if the parent chain can't be reached; anything else wrong is a problem in the result.
+func (c *RollupCompatibilityChecker) Check(ctx context.Context) (*RollupCompatibility, error) {
+	if _, err := c.l1Client.BlockNumber(ctx); err != nil {
+		return nil, fmt.Errorf("error reaching the parent chain: %w", err)
+	}
+	result := &RollupCompatibility{Problems: []string{}}
+	if c.checkContracts(ctx, result) {
+		c.checkWasmModuleRoot(result)
+	}
+	if err := c.checkArbOSVersion(result); err != nil {
+		return nil, err
+	}
+	result.Compatible = len(result.Problems) == 0
+	return result, nil
+}
+
+// checkContracts checks the rollup's contracts exist, point at each other and have the methods the node calls.
+// It returns whether the rollup contract can be read from.
+func (c *RollupCompatibilityChecker) checkContracts(ctx context.Context, result *RollupCompatibility) bool {
+	callOpts := &bind.CallOpts{Context: ctx}
+	contracts := []struct {
+		name    string
+		address common.Address
+	}{
+		{"rollup", c.deployInfo.Rollup},
+		{"bridge", c.deployInfo.Bridge},
+		{"sequencer-inbox", c.deployInfo.SequencerInbox},
+		{"inbox", c.deployInfo.Inbox},
+	}
+	missing := make(map[string]bool)
+	for _, contract := range contracts {
+		if contract.address == (common.Address{}) && contract.name == "inbox" {
+			continue
+		}
+		code, err := c.l1Client.CodeAt(ctx, contract.address, nil)
+		if err == nil && len(code) == 0 {
+			err = fmt.Errorf("no contract at %v", contract.address)
+		}
+		if err != nil {
+			missing[contract.name] = true
+			result.problem("chain info's %v address is wrong or on a different parent chain: %v", contract.name, err)
+		}
+	}
+
+	// Each method is one the node relies on, so a revert means the contracts were upgraded to something it doesn't know
+	linked := func(contract, method string, expected common.Address, actual common.Address, err error) {
+		if err != nil {
+			result.problem("%v doesn't implement %v(), which this node needs: %v", contract, method, err)
+		} else if actual != expected {
+			result.problem("%v's %v() is %v but chain info has %v; update the chain info to the rollup's current contracts", contract, method, actual, expected)
+		}
+	}
+	works := func(contract, method string, err error) {
+		if err != nil {
+			result.problem("%v doesn't implement %v(), which this node needs: %v", contract, method, err)
+		}
+	}
+	rollupReadable := false
+	if !missing["rollup"] {
+		rollup, err := rollupgen.NewRollupUserLogic(c.deployInfo.Rollup, c.l1Client)
+		if err != nil {
+			result.problem("error binding the rollup contract: %v", err)
+		} else {
+			bridge, err := rollup.Bridge(callOpts)
+			linked("rollup", "bridge", c.deployInfo.Bridge, bridge, err)
+			seqInbox, err := rollup.SequencerInbox(callOpts)
+			linked("rollup", "sequencerInbox", c.deployInfo.SequencerInbox, seqInbox, err)
+			result.WasmModuleRoot, err = rollup.WasmModuleRoot(callOpts)
+			works("rollup", "wasmModuleRoot", err)
+			rollupReadable = err == nil
+		}
+	}
+	if !missing["bridge"] {
+		bridge, err := bridgegen.NewIBridge(c.deployInfo.Bridge, c.l1Client)
+		if err != nil {
+			result.problem("error binding the bridge contract: %v", err)
+		} else {
+			seqInbox, err := bridge.SequencerInbox(callOpts)
+			linked("bridge", "sequencerInbox", c.deployInfo.SequencerInbox, seqInbox, err)
+			_, err = bridge.DelayedMessageCount(callOpts)
+			works("bridge", "delayedMessageCount", err)
+			_, err = bridge.SequencerMessageCount(callOpts)
+			works("bridge", "sequencerMessageCount", err)
+		}
+	}
+	if !missing["sequencer-inbox"] {
+		seqInbox, err := bridgegen.NewSequencerInbox(c.deployInfo.SequencerInbox, c.l1Client)
+		if err != nil {
+			result.problem("error binding the sequencer inbox contract: %v", err)
+		} else {
+			bridge, err := seqInbox.Bridge(callOpts)
+			linked("sequencer-inbox", "bridge", c.deployInfo.Bridge, bridge, err)
+			_, err = seqInbox.BatchCount(callOpts)
+			works("sequencer-inbox", "batchCount", err)
+			_, _, _, _, err = seqInbox.MaxTimeVariation(callOpts)
+			works("sequencer-inbox", "maxTimeVariation", err)
+		}
+	}
+	if c.deployInfo.Inbox != (common.Address{}) && !missing["inbox"] {
+		inbox, err := bridgegen.NewInbox(c.deployInfo.Inbox, c.l1Client)
+		if err != nil {
+			result.problem("error binding the inbox contract: %v", err)
+		} else {
+			bridge, err := inbox.Bridge(callOpts)
+			linked("inbox", "bridge", c.deployInfo.Bridge, bridge, err)
+		}
+	}
+	return rollupReadable
+}
+
+func (c *RollupCompatibilityChecker) checkWasmModuleRoot(result *RollupCompatibility) {
+	if result.WasmModuleRoot == (common.Hash{}) {
+		result.problem("the rollup's wasm module root is zero")
+		return
+	}
+	var validator *staker.StatelessBlockValidator
+	if c.validator != nil {
+		validator = c.validator()
+	}
+	if validator == nil {
+		// Only validators execute the module root's machine
+		return
+	}
+	supported, err := validator.WasmModuleRoots()
+	if err != nil {
+		result.problem("error getting the validation servers' wasm module roots: %v", err)
+		return
+	}
+	result.SupportedWasmModuleRoots = supported
+	for _, root := range supported {
+		if root == result.WasmModuleRoot {
+			return
+		}
+	}
+	result.problem("the rollup's wasm module root %v isn't supported by this node's validation servers; upgrade them or add its machine", result.WasmModuleRoot)
+}
+
+func (c *RollupCompatibilityChecker) checkArbOSVersion(result *RollupCompatibility) error {
+	if c.bc == nil {
+		return nil
+	}
+	result.MaxArbOSVersionSupported = arbosState.MaxArbosVersionSupported
+	if c.bc.Config().DebugMode() {
+		result.MaxArbOSVersionSupported = arbosState.MaxDebugArbosVersionSupported
+	}
+	statedb, err := c.bc.State()
+	if err != nil {
+		return fmt.Errorf("error getting the chain's state: %w", err)
+	}
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return fmt.Errorf("error opening ArbOS state: %w", err)
+	}
+	result.ArbOSVersion = state.ArbOSVersion()
+	if result.ArbOSVersion > result.MaxArbOSVersionSupported {
+		result.problem("the chain is on ArbOS %d but this node supports up to ArbOS %d; upgrade the node", result.ArbOSVersion, result.MaxArbOSVersionSupported)
+	}
+	version, timestamp, err := state.GetScheduledUpgrade()
+	if err != nil {
+		return fmt.Errorf("error reading the scheduled ArbOS upgrade: %w", err)
+	}
+	if version > result.ArbOSVersion {
+		result.ScheduledArbOSVersion = version
+		result.ScheduledArbOSTimestamp = timestamp
+		if version > result.MaxArbOSVersionSupported {
+			result.problem("an upgrade to ArbOS %d is scheduled at timestamp %d but this node supports up to ArbOS %d; upgrade the node before then", version, timestamp, result.MaxArbOSVersionSupported)
+		}
+	}
+	return nil
+}
+
+// startupCheck logs what Check finds, failing if configured to and the node is incompatible.
+func (c *RollupCompatibilityChecker) startupCheck(ctx context.Context, config *RollupCompatibilityConfig) error {
+	result, err := c.Check(ctx)
+	if err != nil {
+		log.Warn("couldn't check compatibility with the rollup", "err", err)
+		return nil
+	}
+	if result.Compatible {
+		log.Info("node is compatible with the rollup", "wasmModuleRoot", result.WasmModuleRoot, "arbosVersion", result.ArbOSVersion)
+		return nil
+	}
+	for _, problem := range result.Problems {
+		log.Error("node is incompatible with the rollup", "problem", problem)
+	}
+	if config.FailOnIncompatible {
+		return fmt.Errorf("node is incompatible with the rollup (see node.rollup-compatibility.fail-on-incompatible): %v", strings.Join(result.Problems, "; "))
+	}
+	return nil
+}
+
+type RollupCompatibilityAPI struct {
+	checker *RollupCompatibilityChecker
+}
+
+// RollupCompatibility checks the node against the rollup's contracts and chain as it does on startup.
+func (a *RollupCompatibilityAPI) RollupCompatibility(ctx context.Context) (*RollupCompatibility, error) {
+	return a.checker.Check(ctx)
+}
@@ -12,11 +12,18 @@ var (
 	parentChainBlockNumberPrefix []byte = []byte("p") // maps a delayed sequence number to a parent chain block number
 	sequencerBatchMetaPrefix     []byte = []byte("s") // maps a batch sequence number to BatchMetadata
 	delayedSequencedPrefix       []byte = []byte("a") // maps a delayed message count to the first sequencer batch sequence number with this delayed count
+	seqCoordinatorHandoffPrefix  []byte = []byte("h") // maps a handoff audit log index to a HandoffEvent
+	deadLetterPrefix             []byte = []byte("l") // maps a message sequence number to a DeadLetter the execution engine failed to digest
+	confirmedAssertionPrefix     []byte = []byte("c") // maps a confirmed assertion's message count to a ConfirmedAssertion
 
 	messageCountKey        []byte = []byte("_messageCount")        // contains the current message count
 	delayedMessageCountKey []byte = []byte("_delayedMessageCount") // contains the current delayed message count
 	sequencerBatchCountKey []byte = []byte("_sequencerBatchCount") // contains the current sequencer message count
 	dbSchemaVersion        []byte = []byte("_schemaVersion")       // contains a uint64 representing the database schema version
+
+	seqCoordinatorHandoffCountKey []byte = []byte("_seqCoordinatorHandoffCount") // contains the number of handoff audit log entries ever recorded
+	latestConfirmedAssertionKey   []byte = []byte("_latestConfirmedAssertion")   // contains the last ConfirmedAssertion recorded
+	pausedActorsKey               []byte = []byte("_pausedActors")               // contains the names of the background actors an operator paused
 )
 
 const currentDbSchemaVersion uint64 = 1
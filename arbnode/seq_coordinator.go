@@ -20,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 
+	"github.com/offchainlabs/nitro/arbnode/seqlockout"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/execution"
@@ -41,6 +42,8 @@ type SeqCoordinator struct {
 	redisCoordinator      redisutil.RedisCoordinator
 	prevRedisCoordinator  *redisutil.RedisCoordinator
 	prevRedisMessageCount arbutil.MessageIndex
+	lockout               seqlockout.Lockout // nil if the lockout is taken in redis
+	lockoutFence          uint64             // fencing token of the external lockout we last acquired, guarded by wantsLockoutMutex
 
 	sync             *SyncMonitor
 	streamer         *TransactionStreamer
@@ -58,6 +61,8 @@ type SeqCoordinator struct {
 	avoidLockout      int        // If > 0, prevents acquiring the lockout but not extending the lockout if no alternative sequencer wants the lockout. Protected by chosenUpdateMutex.
 
 	redisErrors int // error counter, from workthread
+
+	handoffLog *handoffAuditLog // nil if disabled
 }
 
 type SeqCoordinatorConfig struct {
@@ -77,9 +82,24 @@ type SeqCoordinatorConfig struct {
 	MsgPerPoll          arbutil.MessageIndex       `koanf:"msg-per-poll"`
 	MyUrl               string                     `koanf:"my-url"`
 	DeleteFinalizedMsgs bool                       `koanf:"delete-finalized-msgs"`
+	HandoffLogEntries   uint64                     `koanf:"handoff-log-entries"`
+	Lockout             seqlockout.Config          `koanf:"lockout"`
 	Signer              signature.SignVerifyConfig `koanf:"signer"`
 }
 
+func (c *SeqCoordinatorConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if err := c.Lockout.Validate(); err != nil {
+		return err
+	}
+	if c.Lockout.Backend != seqlockout.BackendRedis && c.NewRedisUrl != "" {
+		return errors.New("seq-coordinator new-redis-url can only be used with the lockout in redis")
+	}
+	return nil
+}
+
 func (c *SeqCoordinatorConfig) Url() string {
 	if c.MyUrl == "" {
 		return redisutil.INVALID_URL
@@ -103,6 +123,8 @@ func SeqCoordinatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".msg-per-poll", uint64(DefaultSeqCoordinatorConfig.MsgPerPoll), "will only be marked as wanting the lockout if not too far behind")
 	f.String(prefix+".my-url", DefaultSeqCoordinatorConfig.MyUrl, "url for this sequencer if it is the chosen")
 	f.Bool(prefix+".delete-finalized-msgs", DefaultSeqCoordinatorConfig.DeleteFinalizedMsgs, "enable deleting of finalized messages from redis")
+	f.Uint64(prefix+".handoff-log-entries", DefaultSeqCoordinatorConfig.HandoffLogEntries, "number of lockout acquisitions, releases and chosen sequencer changes to keep in the database for arb_sequencerHandoffLog (0 = disabled)")
+	seqlockout.ConfigAddOptions(prefix+".lockout", f)
 	signature.SignVerifyConfigAddOptions(prefix+".signer", f)
 }
 
@@ -122,6 +144,8 @@ var DefaultSeqCoordinatorConfig = SeqCoordinatorConfig{
 	MsgPerPoll:            2000,
 	MyUrl:                 redisutil.INVALID_URL,
 	DeleteFinalizedMsgs:   true,
+	HandoffLogEntries:     10000,
+	Lockout:               seqlockout.DefaultConfig,
 	Signer:                signature.DefaultSignVerifyConfig,
 }
 
@@ -140,6 +164,8 @@ var TestSeqCoordinatorConfig = SeqCoordinatorConfig{
 	MsgPerPoll:          20,
 	MyUrl:               redisutil.INVALID_URL,
 	DeleteFinalizedMsgs: true,
+	HandoffLogEntries:   1000,
+	Lockout:             seqlockout.DefaultConfig,
 	Signer:              signature.DefaultSignVerifyConfig,
 }
 
@@ -159,14 +185,22 @@ func NewSeqCoordinator(
 	if err != nil {
 		return nil, err
 	}
+	lockout, err := seqlockout.New(&config.Lockout)
+	if err != nil {
+		return nil, err
+	}
 	coordinator := &SeqCoordinator{
 		redisCoordinator: *redisCoordinator,
+		lockout:          lockout,
 		sync:             sync,
 		streamer:         streamer,
 		sequencer:        sequencer,
 		config:           config,
 		signer:           signer,
 	}
+	if config.HandoffLogEntries > 0 {
+		coordinator.handoffLog = newHandoffAuditLog(streamer.db, config.HandoffLogEntries)
+	}
 	streamer.SetSeqCoordinator(coordinator)
 	return coordinator, nil
 }
@@ -295,6 +329,9 @@ func (c *SeqCoordinator) acquireLockoutAndWriteMessage(ctx context.Context, msgC
 	c.wantsLockoutMutex.Lock()
 	defer c.wantsLockoutMutex.Unlock()
 	setWantsLockout := c.avoidLockout <= 0
+	if c.lockout != nil {
+		return c.acquireExternalLockoutAndWriteMessage(ctx, msgCountExpected, msgCountMsg, msgCountToWrite, messageData, messageSigData, setWantsLockout)
+	}
 	lockoutUntil := time.Now().Add(c.config.LockoutDuration)
 	err = c.RedisCoordinator().Client.Watch(ctx, func(tx *redis.Tx) error {
 		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
@@ -364,6 +401,108 @@ func (c *SeqCoordinator) acquireLockoutAndWriteMessage(ctx context.Context, msgC
 	return nil
 }
 
+// Acquires or refreshes the chosen one lockout in the external lockout service, and then optionally writes
+// a message into redis. Writes are fenced with the lockout's fencing token, so a sequencer whose lockout
+// expired can't keep writing once the next holder has. Each time the lockout is acquired, the chosen sequencer
+// is also published in redis for forwarders and the coordinator manager. Requires the caller hold the wantsLockoutMutex.
+func (c *SeqCoordinator) acquireExternalLockoutAndWriteMessage(ctx context.Context, msgCountExpected arbutil.MessageIndex, msgCountMsg []byte, msgCountToWrite arbutil.MessageIndex, messageData, messageSigData *string, setWantsLockout bool) error {
+	wasChosen := c.CurrentlyChosen()
+	// The lockout is ours until it expires, so it's only refreshed once it's about to
+	marginOfError := arbmath.MaxInt(c.config.LockoutDuration/5, c.config.UpdateInterval*5)
+	refresh := !time.Now().Add(marginOfError).Before(atomicTimeRead(&c.lockoutUntil))
+	lockoutUntil := time.Now().Add(c.config.LockoutDuration)
+	if refresh {
+		fence, err := c.lockout.Acquire(ctx, c.config.Url(), lockoutUntil)
+		if err != nil {
+			if errors.Is(err, seqlockout.ErrHeld) {
+				return fmt.Errorf("%w: failed to catch lock: %w", execution.ErrRetrySequencer, err)
+			}
+			return fmt.Errorf("failed to acquire lockout: %w", err)
+		}
+		c.lockoutFence = fence
+		if setWantsLockout {
+			if err := c.lockout.WantsLockout(ctx, c.config.Url(), lockoutUntil); err != nil {
+				log.Warn("failed to update wants lockout", "err", err)
+			} else {
+				c.reportedWantsLockout = true
+			}
+		}
+	}
+	var fencedOut bool
+	err := c.RedisCoordinator().Client.Watch(ctx, func(tx *redis.Tx) error {
+		fence, err := tx.Get(ctx, redisutil.LOCKOUT_FENCE_KEY).Uint64()
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		if fence > c.lockoutFence {
+			fencedOut = true
+			return fmt.Errorf("%w: lockout was taken over, redis fence %d is past ours %d", execution.ErrRetrySequencer, fence, c.lockoutFence)
+		}
+		remoteMsgCount, err := c.getRemoteMsgCountImpl(ctx, tx)
+		if err != nil {
+			return err
+		}
+		// this was called from update(), while msgCount was changed by a call from SequencingMessage
+		msgCountMoved := remoteMsgCount > msgCountExpected && messageData == nil && wasChosen
+		if remoteMsgCount > msgCountExpected && !msgCountMoved {
+			log.Info("coordinator failed to become main", "expected", msgCountExpected, "found", remoteMsgCount, "message is nil?", messageData == nil)
+			return fmt.Errorf("%w: failed to catch lock. expected msg %d found %d", execution.ErrRetrySequencer, msgCountExpected, remoteMsgCount)
+		}
+		if msgCountMoved && !refresh {
+			return nil
+		}
+		pipe := tx.TxPipeline()
+		if fence < c.lockoutFence {
+			pipe.Set(ctx, redisutil.LOCKOUT_FENCE_KEY, c.lockoutFence, 0)
+		}
+		if refresh {
+			// Publish the refreshed lockout even if there's nothing else to write
+			pipe.Set(ctx, redisutil.CHOSENSEQ_KEY, c.config.Url(), 0)
+			pipe.PExpireAt(ctx, redisutil.CHOSENSEQ_KEY, lockoutUntil)
+		}
+		if !msgCountMoved {
+			pipe.Set(ctx, redisutil.MSG_COUNT_KEY, msgCountMsg, c.config.SeqNumDuration)
+		}
+		if messageData != nil {
+			pipe.Set(ctx, redisutil.MessageKeyFor(msgCountToWrite-1), *messageData, c.config.SeqNumDuration)
+			if messageSigData != nil {
+				pipe.Set(ctx, redisutil.MessageSigKeyFor(msgCountToWrite-1), *messageSigData, c.config.SeqNumDuration)
+			}
+		}
+		err = execTestPipe(pipe, ctx)
+		if errors.Is(err, redis.TxFailedErr) {
+			return fmt.Errorf("%w: message count or lockout fence changed while writing", execution.ErrRetrySequencer)
+		}
+		if err != nil {
+			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
+		}
+		return nil
+	}, redisutil.LOCKOUT_FENCE_KEY, redisutil.MSG_COUNT_KEY)
+	if fencedOut {
+		// Another sequencer holds the lockout now, so stop acting as the chosen one
+		atomicTimeWrite(&c.lockoutUntil, time.Time{})
+		isActiveSequencer.Update(0)
+		return err
+	}
+	if err != nil {
+		if refresh && !wasChosen {
+			// Let another sequencer take the lockout while we catch up
+			if releaseErr := c.lockout.Release(ctx, c.config.Url()); releaseErr != nil {
+				log.Warn("failed to release lockout after failing to become main", "err", releaseErr)
+			}
+		}
+		return err
+	}
+	isActiveSequencer.Update(1)
+	if refresh {
+		atomicTimeWrite(&c.lockoutUntil, lockoutUntil.Add(-c.config.LockoutSpare))
+	}
+	return nil
+}
+
 func (c *SeqCoordinator) getRemoteFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
 	resStr, err := c.RedisCoordinator().Client.Get(ctx, redisutil.FINALIZED_MSG_COUNT_KEY).Result()
 	if err != nil {
@@ -398,8 +537,15 @@ func (c *SeqCoordinator) wantsLockoutUpdateWithMutex(ctx context.Context, client
 	if c.avoidLockout > 0 {
 		return nil
 	}
-	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
 	wantsLockoutUntil := time.Now().Add(c.config.LockoutDuration)
+	if c.lockout != nil {
+		if err := c.lockout.WantsLockout(ctx, c.config.Url(), wantsLockoutUntil); err != nil {
+			return fmt.Errorf("failed to update wants lockout: %w", err)
+		}
+		c.reportedWantsLockout = true
+		return nil
+	}
+	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
 	pipe := client.TxPipeline()
 	initialDuration := c.config.LockoutDuration
 	if initialDuration < 2*time.Second {
@@ -418,6 +564,12 @@ func (c *SeqCoordinator) wantsLockoutUpdateWithMutex(ctx context.Context, client
 func (c *SeqCoordinator) chosenOneRelease(ctx context.Context) error {
 	atomicTimeWrite(&c.lockoutUntil, time.Time{})
 	isActiveSequencer.Update(0)
+	if c.lockout != nil {
+		if err := c.lockout.Release(ctx, c.config.Url()); err != nil {
+			return err
+		}
+		// Then withdraw the chosen sequencer we published in redis
+	}
 	releaseErr := c.RedisCoordinator().Client.Watch(ctx, func(tx *redis.Tx) error {
 		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
 		if errors.Is(err, redis.Nil) {
@@ -457,6 +609,13 @@ func (c *SeqCoordinator) wantsLockoutRelease(ctx context.Context) error {
 	if !c.reportedWantsLockout {
 		return nil
 	}
+	if c.lockout != nil {
+		if err := c.lockout.ReleaseWantsLockout(ctx, c.config.Url()); err != nil {
+			return err
+		}
+		c.reportedWantsLockout = false
+		return nil
+	}
 	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
 	releaseErr := c.RedisCoordinator().Client.Del(ctx, myWantsLockoutKey).Err()
 	if releaseErr != nil {
@@ -504,6 +663,7 @@ func (c *SeqCoordinator) updateWithLockout(ctx context.Context, nextChosen strin
 		}
 		c.prevChosenSequencer = setPrevChosenTo
 		log.Info("released chosen-coordinator lock", "myUrl", c.config.Url(), "nextChosen", nextChosen)
+		c.recordHandoff(ctx, HandoffLockoutReleased, nextChosen)
 		return c.noRedisError()
 	}
 	// Was, and still is, the active sequencer
@@ -593,8 +753,16 @@ func (c *SeqCoordinator) deleteFinalizedMsgsFromRedis(ctx context.Context, final
 	return nil
 }
 
+// recommendSequencerWantingLockout returns the top priority sequencer wanting the lockout
+func (c *SeqCoordinator) recommendSequencerWantingLockout(ctx context.Context) (string, error) {
+	if c.lockout != nil {
+		return c.lockout.RecommendSequencerWantingLockout(ctx)
+	}
+	return c.RedisCoordinator().RecommendSequencerWantingLockout(ctx)
+}
+
 func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
-	chosenSeq, err := c.RedisCoordinator().RecommendSequencerWantingLockout(ctx)
+	chosenSeq, err := c.recommendSequencerWantingLockout(ctx)
 	if err != nil {
 		log.Warn("coordinator failed finding sequencer wanting lockout", "err", err)
 		return c.retryAfterRedisError()
@@ -610,6 +778,7 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 		if err == nil {
 			c.prevChosenSequencer = chosenSeq
 			log.Info("chosen sequencer changing", "recommended", chosenSeq)
+			c.recordHandoff(ctx, HandoffChosenChanged, chosenSeq)
 		} else {
 			// The error was already logged in ForwardTo, just clean up state.
 			// Next run this will attempt to reconnect.
@@ -768,6 +937,7 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 				return c.retryAfterRedisError()
 			}
 			log.Info("caught chosen-coordinator lock", "myUrl", c.config.Url())
+			c.recordHandoff(ctx, HandoffLockoutAcquired, c.config.Url())
 			if c.delayedSequencer != nil {
 				err = c.delayedSequencer.ForceSequenceDelayed(ctx)
 				if err != nil {
@@ -951,6 +1121,9 @@ func (c *SeqCoordinator) StopAndWait() {
 		log.Info("releasing chosen one", "myUrl", c.config.Url(), "attempt", i)
 		err := c.chosenOneRelease(parentCtx)
 		if err == nil {
+			if c.prevChosenSequencer == c.config.Url() {
+				c.recordHandoff(parentCtx, HandoffLockoutReleased, "")
+			}
 			c.noRedisError()
 			break
 		} else {
@@ -958,6 +1131,11 @@ func (c *SeqCoordinator) StopAndWait() {
 			time.Sleep(c.retryAfterRedisError())
 		}
 	}
+	if c.lockout != nil {
+		if err := c.lockout.Close(parentCtx); err != nil {
+			log.Warn("failed to close lockout", "err", err)
+		}
+	}
 	_ = c.RedisCoordinator().Client.Close()
 }
 
@@ -1000,7 +1178,7 @@ func (c *SeqCoordinator) TryToHandoffChosenOne(ctx context.Context) bool {
 			return !c.CurrentlyChosen()
 		})
 		if success {
-			wantsLockout, err := c.RedisCoordinator().RecommendSequencerWantingLockout(ctx)
+			wantsLockout, err := c.recommendSequencerWantingLockout(ctx)
 			if err == nil {
 				log.Info("released chosen one status; a new sequencer hopefully wants to acquire it", "delay", c.config.SafeShutdownDelay, "wantsLockout", wantsLockout)
 			} else {
@@ -0,0 +1,172 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/dbutil"
+)
+
+type HandoffEventKind string
+
+const (
+	HandoffLockoutAcquired HandoffEventKind = "lockout-acquired"
+	HandoffLockoutReleased HandoffEventKind = "lockout-released"
+	HandoffChosenChanged   HandoffEventKind = "chosen-changed"
+)
+
+// HandoffEvent is an entry in the sequencer coordinator's handoff audit log, with the message counts as
+// this node saw them at the moment of the handoff.
+type HandoffEvent struct {
+	Index     uint64           `json:"index"`
+	Timestamp uint64           `json:"timestamp"` // unix milliseconds
+	Kind      HandoffEventKind `json:"kind"`
+	MyUrl     string           `json:"myUrl"`
+	Chosen    string           `json:"chosen"` // the sequencer chosen after the event, if known
+	// The local message count, how many of those the execution layer has processed, and the count in redis
+	MsgCount          arbutil.MessageIndex `json:"msgCount"`
+	ProcessedMsgCount arbutil.MessageIndex `json:"processedMsgCount"`
+	RemoteMsgCount    arbutil.MessageIndex `json:"remoteMsgCount"`
+	RemoteErr         string               `json:"remoteErr,omitempty"`
+}
+
+// handoffAuditLog keeps the most recent handoff events in the node's database.
+type handoffAuditLog struct {
+	db         ethdb.Database
+	maxEntries uint64
+	mutex      sync.Mutex
+}
+
+func newHandoffAuditLog(db ethdb.Database, maxEntries uint64) *handoffAuditLog {
+	return &handoffAuditLog{
+		db:         db,
+		maxEntries: maxEntries,
+	}
+}
+
+func (l *handoffAuditLog) count() (uint64, error) {
+	data, err := l.db.Get(seqCoordinatorHandoffCountKey)
+	if dbutil.IsErrNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var count uint64
+	err = rlp.DecodeBytes(data, &count)
+	return count, err
+}
+
+func (l *handoffAuditLog) record(event HandoffEvent) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	count, err := l.count()
+	if err != nil {
+		return err
+	}
+	event.Index = count
+	value, err := rlp.EncodeToBytes(event)
+	if err != nil {
+		return err
+	}
+	countValue, err := rlp.EncodeToBytes(count + 1)
+	if err != nil {
+		return err
+	}
+	batch := l.db.NewBatch()
+	if err := batch.Put(dbKey(seqCoordinatorHandoffPrefix, count), value); err != nil {
+		return err
+	}
+	if count >= l.maxEntries {
+		if err := batch.Delete(dbKey(seqCoordinatorHandoffPrefix, count-l.maxEntries)); err != nil {
+			return err
+		}
+	}
+	if err := batch.Put(seqCoordinatorHandoffCountKey, countValue); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// events returns up to max events starting at index from, skipping any that were pruned.
+func (l *handoffAuditLog) events(from, max uint64) ([]HandoffEvent, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	count, err := l.count()
+	if err != nil {
+		return nil, err
+	}
+	if count > l.maxEntries && from < count-l.maxEntries {
+		from = count - l.maxEntries
+	}
+	events := []HandoffEvent{}
+	for i := from; i < count && uint64(len(events)) < max; i++ {
+		data, err := l.db.Get(dbKey(seqCoordinatorHandoffPrefix, i))
+		if dbutil.IsErrNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var event HandoffEvent
+		if err := rlp.DecodeBytes(data, &event); err != nil {
+			return nil, fmt.Errorf("decoding handoff event %v: %w", i, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// recordHandoff adds an event to the audit log, if enabled. Failures are logged rather than returned so
+// that auditing never gets in the way of a handoff.
+func (c *SeqCoordinator) recordHandoff(ctx context.Context, kind HandoffEventKind, chosen string) {
+	if c.handoffLog == nil {
+		return
+	}
+	event := HandoffEvent{
+		// #nosec G115
+		Timestamp: uint64(time.Now().UnixMilli()),
+		Kind:      kind,
+		MyUrl:     c.config.Url(),
+		Chosen:    chosen,
+	}
+	var err error
+	event.MsgCount, err = c.streamer.GetMessageCount()
+	if err != nil {
+		log.Warn("handoff audit failed to read message count", "err", err)
+	}
+	event.ProcessedMsgCount, err = c.streamer.GetProcessedMessageCount()
+	if err != nil {
+		log.Warn("handoff audit failed to read processed message count", "err", err)
+	}
+	event.RemoteMsgCount, err = c.getRemoteMsgCountImpl(ctx, c.RedisCoordinator().Client)
+	if err != nil {
+		event.RemoteErr = err.Error()
+	}
+	if err := c.handoffLog.record(event); err != nil {
+		log.Error("failed to record sequencer handoff", "kind", kind, "err", err)
+	}
+}
+
+type SeqCoordinatorAPI struct {
+	coordinator *SeqCoordinator
+}
+
+// SequencerHandoffLog returns up to maxEvents handoff audit log entries starting at index from.
+func (a *SeqCoordinatorAPI) SequencerHandoffLog(ctx context.Context, from uint64, maxEvents uint64) ([]HandoffEvent, error) {
+	if a.coordinator.handoffLog == nil {
+		return nil, errors.New("the sequencer handoff audit log is disabled")
+	}
+	return a.coordinator.handoffLog.events(from, maxEvents)
+}
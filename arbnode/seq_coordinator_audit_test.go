@@ -0,0 +1,59 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func TestHandoffAuditLog(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	auditLog := newHandoffAuditLog(db, 3)
+
+	events, err := auditLog.events(0, 10)
+	Require(t, err)
+	if len(events) != 0 {
+		Fail(t, "new audit log had events", events)
+	}
+
+	kinds := []HandoffEventKind{HandoffChosenChanged, HandoffLockoutAcquired, HandoffLockoutReleased, HandoffChosenChanged, HandoffLockoutAcquired}
+	for i, kind := range kinds {
+		Require(t, auditLog.record(HandoffEvent{
+			Kind:     kind,
+			MyUrl:    "seq0",
+			Chosen:   "seq1",
+			MsgCount: arbutil.MessageIndex(100 + i),
+		}))
+	}
+
+	// Only the last three entries are kept
+	events, err = auditLog.events(0, 10)
+	Require(t, err)
+	if len(events) != 3 {
+		Fail(t, "expected 3 events, got", events)
+	}
+	for i, event := range events {
+		index := uint64(i + 2)
+		if event.Index != index || event.Kind != kinds[index] || event.MsgCount != arbutil.MessageIndex(100+index) || event.Chosen != "seq1" {
+			Fail(t, "unexpected event", index, event)
+		}
+	}
+
+	events, err = auditLog.events(3, 1)
+	Require(t, err)
+	if len(events) != 1 || events[0].Index != 3 {
+		Fail(t, "unexpected page", events)
+	}
+
+	// The log survives reopening
+	events, err = newHandoffAuditLog(db, 3).events(4, 10)
+	Require(t, err)
+	if len(events) != 1 || events[0].Kind != HandoffLockoutAcquired {
+		Fail(t, "unexpected events after reopening", events)
+	}
+}
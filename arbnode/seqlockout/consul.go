@@ -0,0 +1,251 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package seqlockout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Consul doesn't allow session TTLs shorter than this
+const consulMinSessionTTL = 10 * time.Second
+
+// consulLockout takes the lockout in consul's KV store, holding keys with a session that deletes them if
+// it isn't renewed in time. The session's TTL is set when it's created, from the first expiry asked for.
+type consulLockout struct {
+	client  *http.Client
+	baseUrl string
+	token   string
+	keys    keys
+
+	mutex   sync.Mutex // held while creating or renewing the session
+	session string
+}
+
+func newConsulLockout(config *Config, keys keys) *consulLockout {
+	return &consulLockout{
+		client:  &http.Client{Timeout: config.Timeout},
+		baseUrl: strings.TrimSuffix(config.URL, "/"),
+		token:   config.Token,
+		keys:    keys,
+	}
+}
+
+type consulKeyValue struct {
+	Value       []byte `json:"Value"`
+	Session     string `json:"Session"`
+	ModifyIndex uint64 `json:"ModifyIndex"`
+}
+
+// call makes a request to the consul API, returning its status. Statuses other than OK and not found are errors.
+func (l *consulLockout) call(ctx context.Context, method, path string, query url.Values, body []byte, response interface{}) (int, error) {
+	requestUrl := l.baseUrl + (&url.URL{Path: path}).EscapedPath()
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, method, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if l.token != "" {
+		httpRequest.Header.Set("X-Consul-Token", l.token)
+	}
+	httpResponse, err := l.client.Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return 0, err
+	}
+	switch httpResponse.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return httpResponse.StatusCode, nil
+	default:
+		return httpResponse.StatusCode, fmt.Errorf("consul %v %v returned %v: %v", method, path, httpResponse.Status, string(data))
+	}
+	if response == nil {
+		return httpResponse.StatusCode, nil
+	}
+	return httpResponse.StatusCode, json.Unmarshal(data, response)
+}
+
+// get returns a key, or nil if it isn't set.
+func (l *consulLockout) get(ctx context.Context, key string) (*consulKeyValue, error) {
+	var entries []consulKeyValue
+	status, err := l.call(ctx, http.MethodGet, "/v1/kv/"+key, nil, nil, &entries)
+	if err != nil || status == http.StatusNotFound || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// acquire sets a key held by our session, and returns whether it wasn't held by another session.
+func (l *consulLockout) acquire(ctx context.Context, key, value string, until time.Time) (bool, error) {
+	session, err := l.renewSession(ctx, until)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	_, err = l.call(ctx, http.MethodPut, "/v1/kv/"+key, url.Values{"acquire": {session}}, []byte(value), &acquired)
+	return acquired, err
+}
+
+// renewSession renews our session, or creates one if it was invalidated, and returns its ID.
+func (l *consulLockout) renewSession(ctx context.Context, until time.Time) (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.session != "" {
+		status, err := l.call(ctx, http.MethodPut, "/v1/session/renew/"+l.session, nil, nil, nil)
+		if err != nil {
+			return "", err
+		}
+		if status == http.StatusOK {
+			return l.session, nil
+		}
+		log.Warn("consul session for the sequencer coordinator was invalidated", "session", l.session)
+		l.session = ""
+	}
+	ttl := max(consulMinSessionTTL, time.Duration(ttlSeconds(until))*time.Second)
+	request, err := json.Marshal(map[string]string{
+		"Name":      "nitro-sequencer-coordinator",
+		"TTL":       ttl.String(),
+		"Behavior":  "delete",
+		"LockDelay": "0s",
+	})
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		ID string `json:"ID"`
+	}
+	status, err := l.call(ctx, http.MethodPut, "/v1/session/create", nil, request, &response)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK || response.ID == "" {
+		return "", fmt.Errorf("consul didn't create a session, status %v", status)
+	}
+	l.session = response.ID
+	return l.session, nil
+}
+
+func (l *consulLockout) currentSession() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.session
+}
+
+func (l *consulLockout) Chosen(ctx context.Context) (string, error) {
+	entry, err := l.get(ctx, l.keys.chosen)
+	if err != nil || entry == nil || entry.Session == "" {
+		return "", err
+	}
+	return string(entry.Value), nil
+}
+
+// Acquire's fencing token is the key's modify index after taking it, as consul's indexes only grow.
+func (l *consulLockout) Acquire(ctx context.Context, sequencerUrl string, until time.Time) (uint64, error) {
+	acquired, err := l.acquire(ctx, l.keys.chosen, sequencerUrl, until)
+	if err != nil {
+		return 0, err
+	}
+	if !acquired {
+		return 0, ErrHeld
+	}
+	entry, err := l.get(ctx, l.keys.chosen)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil || entry.Session != l.currentSession() {
+		// Our session was invalidated right after acquiring
+		return 0, ErrHeld
+	}
+	return entry.ModifyIndex, nil
+}
+
+func (l *consulLockout) Release(ctx context.Context, sequencerUrl string) error {
+	session := l.currentSession()
+	if session == "" {
+		return nil
+	}
+	entry, err := l.get(ctx, l.keys.chosen)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.Session != session || string(entry.Value) != sequencerUrl {
+		return nil
+	}
+	var released bool
+	_, err = l.call(ctx, http.MethodPut, "/v1/kv/"+l.keys.chosen, url.Values{"release": {session}}, []byte(sequencerUrl), &released)
+	if err == nil && !released {
+		err = fmt.Errorf("consul didn't release %v", l.keys.chosen)
+	}
+	return err
+}
+
+func (l *consulLockout) WantsLockout(ctx context.Context, sequencerUrl string, until time.Time) error {
+	key := l.keys.wantsLockout(sequencerUrl)
+	acquired, err := l.acquire(ctx, key, "OK", until)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		// Likely the session of a previous run of this sequencer, which will expire
+		return fmt.Errorf("%v is held by another consul session", key)
+	}
+	return nil
+}
+
+func (l *consulLockout) ReleaseWantsLockout(ctx context.Context, sequencerUrl string) error {
+	_, err := l.call(ctx, http.MethodDelete, "/v1/kv/"+l.keys.wantsLockout(sequencerUrl), nil, nil, nil)
+	return err
+}
+
+func (l *consulLockout) RecommendSequencerWantingLockout(ctx context.Context) (string, error) {
+	priorities, err := l.get(ctx, l.keys.priorities)
+	if err != nil {
+		return "", err
+	}
+	if priorities == nil {
+		return "", fmt.Errorf("sequencer priorities unset at %v", l.keys.priorities)
+	}
+	for _, sequencerUrl := range splitPriorities(string(priorities.Value)) {
+		entry, err := l.get(ctx, l.keys.wantsLockout(sequencerUrl))
+		if err != nil {
+			return "", err
+		}
+		if entry != nil && entry.Session != "" {
+			return sequencerUrl, nil
+		}
+	}
+	log.Error("no sequencer appears to want the lockout on consul", "priorities", string(priorities.Value))
+	return "", nil
+}
+
+// Close destroys the session, which deletes the keys it still holds.
+func (l *consulLockout) Close(ctx context.Context) error {
+	l.mutex.Lock()
+	session := l.session
+	l.session = ""
+	l.mutex.Unlock()
+	var err error
+	if session != "" {
+		_, err = l.call(ctx, http.MethodPut, "/v1/session/destroy/"+session, nil, nil, nil)
+	}
+	l.client.CloseIdleConnections()
+	return err
+}
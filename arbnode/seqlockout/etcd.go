@@ -0,0 +1,276 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package seqlockout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// etcdLockout takes the lockout in etcd through its gRPC gateway, attaching keys to leases so they're
+// deleted when they expire. Each refresh attaches the key to a new lease, and revokes the previous one.
+type etcdLockout struct {
+	client  *http.Client
+	baseUrl string
+	keys    keys
+
+	mutex sync.Mutex
+	// The lease each of our keys is attached to, and when it expires
+	leases map[string]etcdLease
+}
+
+type etcdLease struct {
+	id      int64
+	expires time.Time
+}
+
+func newEtcdLockout(config *Config, keys keys) *etcdLockout {
+	return &etcdLockout{
+		client:  &http.Client{Timeout: config.Timeout},
+		baseUrl: strings.TrimSuffix(config.URL, "/"),
+		keys:    keys,
+		leases:  make(map[string]etcdLease),
+	}
+}
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type etcdCompare struct {
+	Key     []byte `json:"key"`
+	Target  string `json:"target"`
+	Result  string `json:"result"`
+	Version string `json:"version,omitempty"`
+	Value   []byte `json:"value,omitempty"`
+}
+
+type etcdPut struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Lease string `json:"lease,omitempty"`
+}
+
+type etcdRequestOp struct {
+	RequestPut         *etcdPut      `json:"request_put,omitempty"`
+	RequestDeleteRange *etcdKeyValue `json:"request_delete_range,omitempty"`
+}
+
+func (l *etcdLockout) call(ctx context.Context, path string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, l.baseUrl+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpResponse, err := l.client.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return err
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd %v returned %v: %v", path, httpResponse.Status, string(data))
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
+
+// get returns a key's value, or nil if it isn't set.
+func (l *etcdLockout) get(ctx context.Context, key string) ([]byte, error) {
+	var response struct {
+		Kvs []etcdKeyValue `json:"kvs"`
+	}
+	if err := l.call(ctx, "/v3/kv/range", etcdKeyValue{Key: []byte(key)}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Kvs) == 0 {
+		return nil, nil
+	}
+	return response.Kvs[0].Value, nil
+}
+
+// txn runs the operations if every comparison holds, and returns whether they did, and the store's revision after it.
+func (l *etcdLockout) txn(ctx context.Context, compare []etcdCompare, success []etcdRequestOp) (bool, uint64, error) {
+	request := struct {
+		Compare []etcdCompare   `json:"compare"`
+		Success []etcdRequestOp `json:"success"`
+	}{compare, success}
+	var response struct {
+		Header struct {
+			Revision string `json:"revision"`
+		} `json:"header"`
+		Succeeded bool `json:"succeeded"`
+	}
+	if err := l.call(ctx, "/v3/kv/txn", request, &response); err != nil {
+		return false, 0, err
+	}
+	revision, err := strconv.ParseUint(response.Header.Revision, 10, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("etcd returned invalid revision %q: %w", response.Header.Revision, err)
+	}
+	return response.Succeeded, revision, nil
+}
+
+func (l *etcdLockout) grantLease(ctx context.Context, until time.Time) (etcdLease, error) {
+	ttl := ttlSeconds(until)
+	var response struct {
+		ID string `json:"ID"`
+	}
+	if err := l.call(ctx, "/v3/lease/grant", map[string]string{"TTL": strconv.FormatInt(ttl, 10)}, &response); err != nil {
+		return etcdLease{}, err
+	}
+	id, err := strconv.ParseInt(response.ID, 10, 64)
+	if err != nil {
+		return etcdLease{}, fmt.Errorf("etcd granted lease with invalid id %q: %w", response.ID, err)
+	}
+	return etcdLease{id: id, expires: time.Now().Add(time.Duration(ttl) * time.Second)}, nil
+}
+
+func (l *etcdLockout) revokeLease(ctx context.Context, lease etcdLease) {
+	err := l.call(ctx, "/v3/lease/revoke", map[string]string{"ID": strconv.FormatInt(lease.id, 10)}, nil)
+	if err != nil {
+		log.Debug("failed to revoke etcd lease", "lease", lease.id, "err", err)
+	}
+}
+
+// put sets a key, attached to a new lease lasting until the given time, if the comparisons hold.
+// It returns the revision of the put, which is the key's new modification revision.
+func (l *etcdLockout) put(ctx context.Context, key, value string, until time.Time, compare []etcdCompare) (bool, uint64, error) {
+	lease, err := l.grantLease(ctx, until)
+	if err != nil {
+		return false, 0, err
+	}
+	op := etcdRequestOp{RequestPut: &etcdPut{Key: []byte(key), Value: []byte(value), Lease: strconv.FormatInt(lease.id, 10)}}
+	succeeded, revision, err := l.txn(ctx, compare, []etcdRequestOp{op})
+	// Revoke whichever lease the key isn't attached to
+	unused, revoke := lease, true
+	if err == nil && succeeded {
+		l.mutex.Lock()
+		unused, revoke = l.leases[key]
+		l.leases[key] = lease
+		l.mutex.Unlock()
+	}
+	if revoke {
+		l.revokeLease(ctx, unused)
+	}
+	return succeeded, revision, err
+}
+
+func (l *etcdLockout) forget(ctx context.Context, key string) {
+	l.mutex.Lock()
+	lease, ok := l.leases[key]
+	delete(l.leases, key)
+	l.mutex.Unlock()
+	if ok {
+		l.revokeLease(ctx, lease)
+	}
+}
+
+func (l *etcdLockout) Chosen(ctx context.Context) (string, error) {
+	value, err := l.get(ctx, l.keys.chosen)
+	return string(value), err
+}
+
+// Acquire's fencing token is the revision it put the key at, as revisions only grow.
+func (l *etcdLockout) Acquire(ctx context.Context, sequencerUrl string, until time.Time) (uint64, error) {
+	key := []byte(l.keys.chosen)
+	succeeded, revision, err := l.put(ctx, l.keys.chosen, sequencerUrl, until, []etcdCompare{{Key: key, Target: "VALUE", Result: "EQUAL", Value: []byte(sequencerUrl)}})
+	if err != nil || succeeded {
+		return revision, err
+	}
+	// Take it if nobody holds it
+	succeeded, revision, err = l.put(ctx, l.keys.chosen, sequencerUrl, until, []etcdCompare{{Key: key, Target: "VERSION", Result: "EQUAL", Version: "0"}})
+	if err != nil || succeeded {
+		return revision, err
+	}
+	return 0, ErrHeld
+}
+
+func (l *etcdLockout) Release(ctx context.Context, sequencerUrl string) error {
+	key := []byte(l.keys.chosen)
+	_, _, err := l.txn(ctx,
+		[]etcdCompare{{Key: key, Target: "VALUE", Result: "EQUAL", Value: []byte(sequencerUrl)}},
+		[]etcdRequestOp{{RequestDeleteRange: &etcdKeyValue{Key: key}}},
+	)
+	if err != nil {
+		return err
+	}
+	l.forget(ctx, l.keys.chosen)
+	return nil
+}
+
+func (l *etcdLockout) WantsLockout(ctx context.Context, sequencerUrl string, until time.Time) error {
+	key := l.keys.wantsLockout(sequencerUrl)
+	// The coordinator refreshes it every update, so only renew the lease once it's half over
+	l.mutex.Lock()
+	lease, ok := l.leases[key]
+	l.mutex.Unlock()
+	if ok && time.Until(lease.expires) > time.Until(until)/2 {
+		return nil
+	}
+	_, _, err := l.put(ctx, key, "OK", until, nil)
+	return err
+}
+
+func (l *etcdLockout) ReleaseWantsLockout(ctx context.Context, sequencerUrl string) error {
+	key := l.keys.wantsLockout(sequencerUrl)
+	if err := l.call(ctx, "/v3/kv/deleterange", etcdKeyValue{Key: []byte(key)}, nil); err != nil {
+		return err
+	}
+	l.forget(ctx, key)
+	return nil
+}
+
+func (l *etcdLockout) RecommendSequencerWantingLockout(ctx context.Context) (string, error) {
+	priorities, err := l.get(ctx, l.keys.priorities)
+	if err != nil {
+		return "", err
+	}
+	if priorities == nil {
+		return "", fmt.Errorf("sequencer priorities unset at %v", l.keys.priorities)
+	}
+	for _, sequencerUrl := range splitPriorities(string(priorities)) {
+		wants, err := l.get(ctx, l.keys.wantsLockout(sequencerUrl))
+		if err != nil {
+			return "", err
+		}
+		if wants != nil {
+			return sequencerUrl, nil
+		}
+	}
+	log.Error("no sequencer appears to want the lockout on etcd", "priorities", string(priorities))
+	return "", nil
+}
+
+func (l *etcdLockout) Close(ctx context.Context) error {
+	l.mutex.Lock()
+	leases := l.leases
+	l.leases = make(map[string]etcdLease)
+	l.mutex.Unlock()
+	for _, lease := range leases {
+		l.revokeLease(ctx, lease)
+	}
+	l.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package seqlockout implements the sequencer coordinator's chosen one lockout in lock services other than redis.
+package seqlockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	BackendRedis  = "redis"
+	BackendEtcd   = "etcd"
+	BackendConsul = "consul"
+)
+
+// ErrHeld is returned when acquiring the lockout another sequencer holds.
+var ErrHeld = errors.New("lockout held by another sequencer")
+
+// Lockout is where sequencers take the chosen one lockout, and advertise they want it. Entries expire at the
+// given times unless refreshed, so a sequencer that stops responding loses them.
+type Lockout interface {
+	// Chosen returns the URL of the sequencer holding the lockout, or "" if none does.
+	Chosen(ctx context.Context) (string, error)
+	// Acquire takes or extends the lockout for url until at least the given time, failing with ErrHeld if
+	// another sequencer holds it. It returns a fencing token, which grows every time the lockout is taken
+	// or extended, so writes made under the lockout can be rejected once a later holder has written.
+	Acquire(ctx context.Context, url string, until time.Time) (uint64, error)
+	// Release gives the lockout up if url holds it.
+	Release(ctx context.Context, url string) error
+	// WantsLockout marks url as wanting the lockout until the given time.
+	WantsLockout(ctx context.Context, url string, until time.Time) error
+	ReleaseWantsLockout(ctx context.Context, url string) error
+	// RecommendSequencerWantingLockout returns the top priority sequencer wanting the lockout, or "" if none does.
+	RecommendSequencerWantingLockout(ctx context.Context) (string, error)
+	Close(ctx context.Context) error
+}
+
+type Config struct {
+	Backend   string        `koanf:"backend"`
+	URL       string        `koanf:"url"`
+	KeyPrefix string        `koanf:"key-prefix"`
+	Token     string        `koanf:"token"`
+	Timeout   time.Duration `koanf:"timeout"`
+}
+
+var DefaultConfig = Config{
+	Backend:   BackendRedis,
+	URL:       "",
+	KeyPrefix: "nitro/coordinator",
+	Token:     "",
+	Timeout:   5 * time.Second,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".backend", DefaultConfig.Backend, "where to take the chosen one lockout: redis, etcd or consul. With etcd or consul, redis only relays messages, which only the lockout holder writes, and publishes the chosen sequencer for forwarders")
+	f.String(prefix+".url", DefaultConfig.URL, "the URL of the etcd gRPC gateway or the consul HTTP API")
+	f.String(prefix+".key-prefix", DefaultConfig.KeyPrefix, "the prefix of the lockout's keys. The priorities are read from <prefix>/priorities, a comma separated list of sequencer URLs")
+	f.String(prefix+".token", DefaultConfig.Token, "consul ACL token")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout of requests to the lock service")
+}
+
+func (c *Config) Validate() error {
+	switch c.Backend {
+	case BackendRedis:
+		return nil
+	case BackendEtcd, BackendConsul:
+	default:
+		return fmt.Errorf("invalid lockout backend %q", c.Backend)
+	}
+	if c.URL == "" {
+		return fmt.Errorf("%v lockout backend requires a url", c.Backend)
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return fmt.Errorf("invalid %v lockout url: %w", c.Backend, err)
+	}
+	if c.KeyPrefix == "" {
+		return errors.New("lockout key-prefix cannot be empty")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("lockout timeout must be positive")
+	}
+	return nil
+}
+
+// New returns the configured lockout, or nil if the lockout is taken in redis.
+func New(config *Config) (Lockout, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	keys := newKeys(config.KeyPrefix)
+	switch config.Backend {
+	case BackendEtcd:
+		return newEtcdLockout(config, keys), nil
+	case BackendConsul:
+		return newConsulLockout(config, keys), nil
+	}
+	return nil, nil
+}
+
+type keys struct {
+	chosen           string
+	priorities       string
+	wantsLockoutBase string
+}
+
+func newKeys(prefix string) keys {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return keys{
+		chosen:           prefix + "/chosen",
+		priorities:       prefix + "/priorities",
+		wantsLockoutBase: prefix + "/liveliness/",
+	}
+}
+
+// Sequencer URLs are escaped, since they contain slashes
+func (k keys) wantsLockout(sequencerUrl string) string {
+	return k.wantsLockoutBase + url.PathEscape(sequencerUrl)
+}
+
+func splitPriorities(priorities string) []string {
+	var urls []string
+	for _, sequencerUrl := range strings.Split(priorities, ",") {
+		if sequencerUrl = strings.TrimSpace(sequencerUrl); sequencerUrl != "" {
+			urls = append(urls, sequencerUrl)
+		}
+	}
+	return urls
+}
+
+// ttlSeconds is how many whole seconds it is until the given time, rounded up, and at least a second.
+func ttlSeconds(until time.Time) int64 {
+	ttl := time.Until(until)
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	return max(seconds, 1)
+}
@@ -0,0 +1,348 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package seqlockout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd implements the parts of etcd's gRPC gateway the lockout uses.
+type fakeEtcd struct {
+	mutex     sync.Mutex
+	values    map[string][]byte
+	keyLeases map[string]int64
+	leases    map[int64]bool
+	nextLease int64
+	revision  int64
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{values: make(map[string][]byte), keyLeases: make(map[string]int64), leases: make(map[int64]bool)}
+}
+
+func (f *fakeEtcd) set(key string, value []byte, lease int64) {
+	f.values[key] = value
+	f.keyLeases[key] = lease
+}
+
+func (f *fakeEtcd) revoke(lease int64) {
+	delete(f.leases, lease)
+	for key, keyLease := range f.keyLeases {
+		if keyLease == lease {
+			delete(f.values, key)
+			delete(f.keyLeases, key)
+		}
+	}
+}
+
+func (f *fakeEtcd) expireAll() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for lease := range f.leases {
+		f.revoke(lease)
+	}
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	var response interface{} = struct{}{}
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		f.nextLease++
+		f.leases[f.nextLease] = true
+		response = map[string]string{"ID": strconv.FormatInt(f.nextLease, 10)}
+	case "/v3/lease/revoke":
+		var request map[string]string
+		_ = json.Unmarshal(body, &request)
+		lease, _ := strconv.ParseInt(request["ID"], 10, 64)
+		f.revoke(lease)
+	case "/v3/kv/range":
+		var request etcdKeyValue
+		_ = json.Unmarshal(body, &request)
+		kvs := []etcdKeyValue{}
+		if value, ok := f.values[string(request.Key)]; ok {
+			kvs = append(kvs, etcdKeyValue{Key: request.Key, Value: value})
+		}
+		response = map[string]interface{}{"kvs": kvs}
+	case "/v3/kv/deleterange":
+		var request etcdKeyValue
+		_ = json.Unmarshal(body, &request)
+		delete(f.values, string(request.Key))
+		delete(f.keyLeases, string(request.Key))
+	case "/v3/kv/txn":
+		var request struct {
+			Compare []etcdCompare   `json:"compare"`
+			Success []etcdRequestOp `json:"success"`
+		}
+		_ = json.Unmarshal(body, &request)
+		succeeded := true
+		for _, compare := range request.Compare {
+			value, exists := f.values[string(compare.Key)]
+			switch compare.Target {
+			case "VALUE":
+				succeeded = succeeded && exists && string(value) == string(compare.Value)
+			case "VERSION":
+				succeeded = succeeded && !exists && compare.Version == "0"
+			default:
+				http.Error(w, "unsupported compare", http.StatusBadRequest)
+				return
+			}
+		}
+		if succeeded {
+			f.revision++
+			for _, op := range request.Success {
+				if op.RequestPut != nil {
+					lease, _ := strconv.ParseInt(op.RequestPut.Lease, 10, 64)
+					if lease != 0 && !f.leases[lease] {
+						http.Error(w, "requested lease not found", http.StatusBadRequest)
+						return
+					}
+					f.set(string(op.RequestPut.Key), op.RequestPut.Value, lease)
+				}
+				if op.RequestDeleteRange != nil {
+					delete(f.values, string(op.RequestDeleteRange.Key))
+					delete(f.keyLeases, string(op.RequestDeleteRange.Key))
+				}
+			}
+		}
+		response = map[string]interface{}{
+			"header":    map[string]string{"revision": strconv.FormatInt(f.revision, 10)},
+			"succeeded": succeeded,
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// fakeConsul implements the parts of consul's KV store and sessions the lockout uses.
+type fakeConsul struct {
+	mutex       sync.Mutex
+	kv          map[string]*consulKeyValue
+	sessions    map[string]bool
+	nextSession int
+	index       uint64
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{kv: make(map[string]*consulKeyValue), sessions: make(map[string]bool)}
+}
+
+func (f *fakeConsul) invalidate(session string) {
+	delete(f.sessions, session)
+	for key, entry := range f.kv {
+		if entry.Session == session {
+			delete(f.kv, key)
+		}
+	}
+}
+
+func (f *fakeConsul) expireAll() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for session := range f.sessions {
+		f.invalidate(session)
+	}
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	var response interface{} = true
+	switch path := r.URL.Path; {
+	case path == "/v1/session/create":
+		f.nextSession++
+		session := fmt.Sprint("session-", f.nextSession)
+		f.sessions[session] = true
+		response = map[string]string{"ID": session}
+	case strings.HasPrefix(path, "/v1/session/renew/"):
+		if !f.sessions[strings.TrimPrefix(path, "/v1/session/renew/")] {
+			http.NotFound(w, r)
+			return
+		}
+	case strings.HasPrefix(path, "/v1/session/destroy/"):
+		f.invalidate(strings.TrimPrefix(path, "/v1/session/destroy/"))
+	case strings.HasPrefix(path, "/v1/kv/"):
+		key := strings.TrimPrefix(path, "/v1/kv/")
+		entry := f.kv[key]
+		switch r.Method {
+		case http.MethodGet:
+			if entry == nil {
+				http.NotFound(w, r)
+				return
+			}
+			response = []*consulKeyValue{entry}
+		case http.MethodDelete:
+			delete(f.kv, key)
+		case http.MethodPut:
+			if session := r.URL.Query().Get("acquire"); session != "" {
+				if !f.sessions[session] {
+					http.Error(w, "invalid session", http.StatusInternalServerError)
+					return
+				}
+				if entry != nil && entry.Session != "" && entry.Session != session {
+					response = false
+					break
+				}
+				f.index++
+				f.kv[key] = &consulKeyValue{Value: body, Session: session, ModifyIndex: f.index}
+			} else if session := r.URL.Query().Get("release"); session != "" {
+				if entry == nil || entry.Session != session {
+					response = false
+					break
+				}
+				f.index++
+				f.kv[key] = &consulKeyValue{Value: body, ModifyIndex: f.index}
+			} else {
+				f.index++
+				f.kv[key] = &consulKeyValue{Value: body, ModifyIndex: f.index}
+			}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestLockout(t *testing.T) {
+	etcd := newFakeEtcd()
+	consul := newFakeConsul()
+	for _, backend := range []struct {
+		name          string
+		server        http.Handler
+		setPriorities func(key, priorities string)
+		expireAll     func()
+	}{
+		{BackendEtcd, etcd, func(key, priorities string) { etcd.set(key, []byte(priorities), 0) }, etcd.expireAll},
+		{BackendConsul, consul, func(key, priorities string) { consul.kv[key] = &consulKeyValue{Value: []byte(priorities)} }, consul.expireAll},
+	} {
+		t.Run(backend.name, func(t *testing.T) {
+			testLockout(t, backend.name, backend.server, backend.setPriorities, backend.expireAll)
+		})
+	}
+}
+
+func testLockout(t *testing.T, backend string, handler http.Handler, setPriorities func(key, priorities string), expireAll func()) {
+	ctx := context.Background()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config := DefaultConfig
+	config.Backend = backend
+	config.URL = server.URL
+	newLockout := func() Lockout {
+		lockout, err := New(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lockout
+	}
+	a, b := newLockout(), newLockout()
+	const urlA, urlB = "http://a:8547", "http://b:8547"
+	until := time.Now().Add(time.Minute)
+
+	expectRecommended := func(expected string) {
+		t.Helper()
+		recommended, err := a.RecommendSequencerWantingLockout(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recommended != expected {
+			t.Fatalf("recommended %q, expected %q", recommended, expected)
+		}
+	}
+	expectChosen := func(expected string) {
+		t.Helper()
+		chosen, err := b.Chosen(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chosen != expected {
+			t.Fatalf("chosen %q, expected %q", chosen, expected)
+		}
+	}
+
+	if _, err := a.RecommendSequencerWantingLockout(ctx); err == nil {
+		t.Fatal("recommended a sequencer without priorities")
+	}
+	setPriorities(newKeys(config.KeyPrefix).priorities, urlA+","+urlB)
+	expectRecommended("")
+	if err := b.WantsLockout(ctx, urlB, until); err != nil {
+		t.Fatal(err)
+	}
+	expectRecommended(urlB)
+	if err := a.WantsLockout(ctx, urlA, until); err != nil {
+		t.Fatal(err)
+	}
+	expectRecommended(urlA)
+
+	expectChosen("")
+	fenceA, err := a.Acquire(ctx, urlA, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectChosen(urlA)
+	if _, err := b.Acquire(ctx, urlB, until); !errors.Is(err, ErrHeld) {
+		t.Fatal("acquired a held lockout", err)
+	}
+	refreshedFenceA, err := a.Acquire(ctx, urlA, until.Add(time.Second))
+	if err != nil {
+		t.Fatal("failed to refresh the lockout", err)
+	}
+	if refreshedFenceA <= fenceA {
+		t.Fatal("refreshing the lockout didn't advance its fencing token", fenceA, refreshedFenceA)
+	}
+	if err := b.Release(ctx, urlB); err != nil {
+		t.Fatal(err)
+	}
+	expectChosen(urlA)
+	if err := a.Release(ctx, urlA); err != nil {
+		t.Fatal(err)
+	}
+	expectChosen("")
+	fenceB, err := b.Acquire(ctx, urlB, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fenceB <= refreshedFenceA {
+		t.Fatal("the next holder's fencing token isn't above the last one's", refreshedFenceA, fenceB)
+	}
+	expectChosen(urlB)
+
+	if err := a.ReleaseWantsLockout(ctx, urlA); err != nil {
+		t.Fatal(err)
+	}
+	expectRecommended(urlB)
+
+	// Expired leases or sessions drop the keys they held
+	expireAll()
+	expectChosen("")
+	expectRecommended("")
+	if _, err := a.Acquire(ctx, urlA, until); err != nil {
+		t.Fatal("failed to acquire the lockout after it expired", err)
+	}
+	expectChosen(urlA)
+
+	if err := a.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
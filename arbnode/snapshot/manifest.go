@@ -0,0 +1,137 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package snapshot produces database snapshots that other nodes can bootstrap from with --init.url or
+// --init.latest, and verifies the signed manifests that describe them.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+const ManifestVersion = 1
+
+// ManifestSuffix is appended to the archive's url to find its signed manifest. The unsigned
+// ".manifest.txt" listing parts and their checksums is written alongside for older nodes.
+const ManifestSuffix = ".manifest.json"
+
+var ErrManifestSigner = errors.New("snapshot manifest isn't signed by the expected signer")
+
+// Assertion is the confirmed assertion a snapshot was anchored to. The snapshot contains its block,
+// so a node bootstrapping from it can check the state it starts from against the rollup contract.
+type Assertion struct {
+	MessageCount uint64      `json:"messageCount"`
+	BlockHash    common.Hash `json:"blockHash"`
+	SendRoot     common.Hash `json:"sendRoot"`
+	Batch        uint64      `json:"batch"`
+	PosInBatch   uint64      `json:"posInBatch"`
+	StateHash    common.Hash `json:"stateHash"` // the hash of the assertion's global state
+}
+
+func NewAssertion(messageCount uint64, globalState validator.GoGlobalState) Assertion {
+	return Assertion{
+		MessageCount: messageCount,
+		BlockHash:    globalState.BlockHash,
+		SendRoot:     globalState.SendRoot,
+		Batch:        globalState.Batch,
+		PosInBatch:   globalState.PosInBatch,
+		StateHash:    globalState.Hash(),
+	}
+}
+
+type ManifestPart struct {
+	Name   string        `json:"name"`
+	Sha256 hexutil.Bytes `json:"sha256"`
+	Size   uint64        `json:"size"`
+}
+
+type Manifest struct {
+	Version         uint64         `json:"version"`
+	ChainID         uint64         `json:"chainId"`
+	Kind            string         `json:"kind"`
+	CreatedAt       uint64         `json:"createdAt"` // unix seconds
+	HeadBlockNumber uint64         `json:"headBlockNumber"`
+	HeadBlockHash   common.Hash    `json:"headBlockHash"`
+	Assertion       Assertion      `json:"assertion"`
+	ArchiveSha256   hexutil.Bytes  `json:"archiveSha256"`
+	ArchiveSize     uint64         `json:"archiveSize"`
+	Parts           []ManifestPart `json:"parts"`
+	Signature       hexutil.Bytes  `json:"signature,omitempty"`
+}
+
+// SigningHash is the hash the producer signs: that of the manifest's json without the signature.
+func (m *Manifest) SigningHash() (common.Hash, error) {
+	unsigned := *m
+	unsigned.Signature = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+func (m *Manifest) Sign(signer signature.DataSignerFunc) error {
+	hash, err := m.SigningHash()
+	if err != nil {
+		return err
+	}
+	sig, err := signer(hash.Bytes())
+	if err != nil {
+		return err
+	}
+	m.Signature = sig
+	return nil
+}
+
+func (m *Manifest) Signer() (common.Address, error) {
+	if len(m.Signature) == 0 {
+		return common.Address{}, errors.New("snapshot manifest isn't signed")
+	}
+	hash, err := m.SigningHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubKey, err := crypto.SigToPub(hash.Bytes(), m.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// ParseManifest decodes a manifest and checks that it was signed by expectedSigner.
+func ParseManifest(data []byte, expectedSigner common.Address) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding snapshot manifest: %w", err)
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported snapshot manifest version %v", manifest.Version)
+	}
+	signer, err := manifest.Signer()
+	if err != nil {
+		return nil, err
+	}
+	if signer != expectedSigner {
+		return nil, fmt.Errorf("%w: signed by %v, expected %v", ErrManifestSigner, signer, expectedSigner)
+	}
+	if len(manifest.Parts) == 0 {
+		return nil, errors.New("snapshot manifest lists no parts")
+	}
+	for _, part := range manifest.Parts {
+		// Parts are fetched relative to the archive, so they mustn't point anywhere else
+		if part.Name == "" || strings.ContainsAny(part.Name, "/\\") || part.Name == ".." {
+			return nil, fmt.Errorf("invalid part name \"%v\" in snapshot manifest", part.Name)
+		}
+	}
+	return &manifest, nil
+}
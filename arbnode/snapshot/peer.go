@@ -0,0 +1,271 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// PeerNamespace is the RPC namespace a node serves its snapshots and state to trusted peers in. It should only
+// be exposed over authenticated RPC, e.g. with --auth.api.
+const PeerNamespace = "snapshot"
+
+type PeerServerConfig struct {
+	Enable        bool   `koanf:"enable"`
+	ChunkSize     uint64 `koanf:"chunk-size" reload:"hot"`
+	MaxStateNodes uint64 `koanf:"max-state-nodes" reload:"hot"`
+}
+
+var DefaultPeerServerConfig = PeerServerConfig{
+	Enable:        false,
+	ChunkSize:     4 << 20,
+	MaxStateNodes: 1024,
+}
+
+func PeerServerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultPeerServerConfig.Enable, "serve this node's snapshots and state trie to peers bootstrapping with --init.peer, in the \""+PeerNamespace+"\" RPC namespace, which should only be exposed over authenticated RPC")
+	f.Uint64(prefix+".chunk-size", DefaultPeerServerConfig.ChunkSize, "maximum bytes of a snapshot archive part to return per request")
+	f.Uint64(prefix+".max-state-nodes", DefaultPeerServerConfig.MaxStateNodes, "maximum state trie nodes or contract codes to return per request")
+}
+
+func (c *PeerServerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.ChunkSize == 0 {
+		return errors.New("snapshot peer server chunk size must be positive")
+	}
+	if c.MaxStateNodes == 0 {
+		return errors.New("snapshot peer server max state nodes must be positive")
+	}
+	return nil
+}
+
+// PeerSnapshot is the latest snapshot a peer produced.
+type PeerSnapshot struct {
+	Archive  string          `json:"archive"` // the archive's path relative to the peer's snapshot directory
+	Manifest json.RawMessage `json:"manifest"`
+}
+
+// PeerAPI serves the snapshots a node produced, and the nodes of its state trie, to trusted peers, so a new
+// validator can bootstrap from an existing one instead of a public mirror. Everything it serves is checked by
+// the peer: snapshot parts against the signed manifest, and state nodes against their hashes.
+type PeerAPI struct {
+	config   func() *PeerServerConfig
+	producer *Producer // nil if the node doesn't produce snapshots
+	chainDB  ethdb.KeyValueReader
+}
+
+func NewPeerAPI(config func() *PeerServerConfig, producer *Producer, chainDB ethdb.KeyValueReader) *PeerAPI {
+	return &PeerAPI{config, producer, chainDB}
+}
+
+// LatestSnapshot returns the signed manifest of the latest snapshot of a kind.
+func (a *PeerAPI) LatestSnapshot(ctx context.Context, kind string) (*PeerSnapshot, error) {
+	if a.producer == nil {
+		return nil, errors.New("this node doesn't produce snapshots")
+	}
+	if produced := a.producer.config().Kind; kind != produced {
+		return nil, fmt.Errorf("this node produces %v snapshots, not %v", produced, kind)
+	}
+	archive, err := a.producer.latestArchive()
+	if err != nil {
+		return nil, err
+	}
+	if archive == "" {
+		return nil, errors.New("no snapshot produced yet")
+	}
+	manifest, err := os.ReadFile(filepath.Join(a.producer.config().Dir, filepath.FromSlash(archive)+ManifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+	return &PeerSnapshot{Archive: archive, Manifest: manifest}, nil
+}
+
+// ReadPart returns up to the configured chunk size of a part of an archive, starting at offset.
+func (a *PeerAPI) ReadPart(ctx context.Context, archive string, part string, offset hexutil.Uint64) (hexutil.Bytes, error) {
+	if a.producer == nil {
+		return nil, errors.New("this node doesn't produce snapshots")
+	}
+	clean := path.Clean(archive)
+	if clean != archive || path.IsAbs(archive) || strings.HasPrefix(archive, ".") || strings.Contains(archive, "/.") || strings.Contains(archive, "\\") {
+		return nil, fmt.Errorf("invalid archive \"%v\"", archive)
+	}
+	if strings.ContainsAny(part, "/\\") || !strings.HasPrefix(part, path.Base(archive)+".part") {
+		return nil, fmt.Errorf("invalid part \"%v\" of archive \"%v\"", part, archive)
+	}
+	file, err := os.Open(filepath.Join(a.producer.config().Dir, filepath.FromSlash(path.Dir(archive)), part))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(int64(offset), io.SeekStart); err != nil { // #nosec G115
+		return nil, err
+	}
+	chunk := make([]byte, a.config().ChunkSize)
+	n, err := io.ReadFull(file, chunk)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return chunk[:n], nil
+}
+
+// StateNodes returns the state trie nodes or contract codes with the given hashes, with empty entries for those
+// the node doesn't have.
+func (a *PeerAPI) StateNodes(ctx context.Context, hashes []common.Hash) ([]hexutil.Bytes, error) {
+	if limit := a.config().MaxStateNodes; uint64(len(hashes)) > limit {
+		return nil, fmt.Errorf("requested %v state nodes, at most %v are served per request", len(hashes), limit)
+	}
+	if rawdb.ReadStateScheme(a.chainDB) == rawdb.PathScheme {
+		return nil, errors.New("state nodes can only be served from databases using the hash state scheme")
+	}
+	nodes := make([]hexutil.Bytes, len(hashes))
+	for i, hash := range hashes {
+		data := rawdb.ReadLegacyTrieNode(a.chainDB, hash)
+		if len(data) == 0 {
+			data = rawdb.ReadCode(a.chainDB, hash)
+		}
+		nodes[i] = data
+	}
+	return nodes, nil
+}
+
+// PeerCaller is the RPC client a node fetches from its peer with.
+type PeerCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// FetchSnapshot downloads the latest snapshot of a kind from a peer into dir, checking that its manifest is
+// signed by signer and that every part matches the manifest. Returns the archive's path and its manifest.
+func FetchSnapshot(ctx context.Context, peer PeerCaller, kind string, signer common.Address, dir string) (string, *Manifest, error) {
+	var latest PeerSnapshot
+	if err := peer.CallContext(ctx, &latest, PeerNamespace+"_latestSnapshot", kind); err != nil {
+		return "", nil, fmt.Errorf("error getting the peer's latest snapshot: %w", err)
+	}
+	manifest, err := ParseManifest(latest.Manifest, signer)
+	if err != nil {
+		return "", nil, err
+	}
+	if manifest.Kind != kind {
+		return "", nil, fmt.Errorf("peer returned a %v snapshot, expected %v", manifest.Kind, kind)
+	}
+	log.Info("Verified peer snapshot manifest signature", "signer", signer, "headBlock", manifest.HeadBlockNumber, "assertionBlockHash", manifest.Assertion.BlockHash, "assertionStateHash", manifest.Assertion.StateHash)
+
+	archiveName := path.Base(latest.Archive)
+	if archiveName == "." || archiveName == ".." || archiveName == "/" {
+		return "", nil, fmt.Errorf("invalid archive \"%v\" from peer", latest.Archive)
+	}
+	archivePath := filepath.Join(dir, archiveName)
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+	fail := func(err error) (string, *Manifest, error) {
+		if removeErr := os.Remove(archivePath); removeErr != nil {
+			log.Warn("Failed to remove incomplete snapshot", "file", archivePath, "err", removeErr)
+		}
+		return "", nil, err
+	}
+	archiveHash := sha256.New()
+	for _, part := range manifest.Parts {
+		log.Info("Downloading database part from peer", "part", part.Name, "size", part.Size)
+		partHash := sha256.New()
+		writer := io.MultiWriter(file, partHash, archiveHash)
+		for offset := uint64(0); offset < part.Size; {
+			var chunk hexutil.Bytes
+			if err := peer.CallContext(ctx, &chunk, PeerNamespace+"_readPart", latest.Archive, part.Name, hexutil.Uint64(offset)); err != nil {
+				return fail(fmt.Errorf("error reading part \"%v\" from peer: %w", part.Name, err))
+			}
+			if len(chunk) == 0 || offset+uint64(len(chunk)) > part.Size {
+				return fail(fmt.Errorf("peer returned %v bytes of part \"%v\" at offset %v, which is %v bytes", len(chunk), part.Name, offset, part.Size))
+			}
+			if _, err := writer.Write(chunk); err != nil {
+				return fail(err)
+			}
+			offset += uint64(len(chunk))
+		}
+		if !bytes.Equal(partHash.Sum(nil), part.Sha256) {
+			return fail(fmt.Errorf("checksum mismatch for part \"%v\" from peer", part.Name))
+		}
+	}
+	if !bytes.Equal(archiveHash.Sum(nil), manifest.ArchiveSha256) {
+		return fail(errors.New("checksum mismatch for the snapshot archive from peer"))
+	}
+	return archivePath, manifest, nil
+}
+
+// FetchState copies the state trie under root, with its storage tries and contract code, from a peer into db,
+// checking every node against its hash. Nodes db already has aren't fetched again, so an interrupted fetch
+// resumes where it stopped. This seeds a database with the state a validator starts recording blocks from.
+func FetchState(ctx context.Context, peer PeerCaller, db ethdb.Database, root common.Hash, batchSize int) error {
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		return errors.New("state can only be fetched from peers into databases using the hash state scheme")
+	}
+	sched := state.NewStateSync(root, db, nil, rawdb.HashScheme)
+	fetched := 0
+	for sched.Pending() > 0 {
+		paths, nodeHashes, codeHashes := sched.Missing(batchSize)
+		hashes := append(append([]common.Hash{}, nodeHashes...), codeHashes...)
+		if len(hashes) == 0 {
+			return errors.New("state sync has pending nodes but none are missing")
+		}
+		var results []hexutil.Bytes
+		if err := peer.CallContext(ctx, &results, PeerNamespace+"_stateNodes", hashes); err != nil {
+			return fmt.Errorf("error fetching state nodes from peer: %w", err)
+		}
+		if len(results) != len(hashes) {
+			return fmt.Errorf("peer returned %v state nodes, requested %v", len(results), len(hashes))
+		}
+		for i, data := range results {
+			if len(data) == 0 {
+				return fmt.Errorf("peer doesn't have state node %v", hashes[i])
+			}
+			if crypto.Keccak256Hash(data) != hashes[i] {
+				return fmt.Errorf("peer returned state node not matching hash %v", hashes[i])
+			}
+			var err error
+			if i < len(nodeHashes) {
+				err = sched.ProcessNode(trie.NodeSyncResult{Path: paths[i], Data: data})
+			} else {
+				err = sched.ProcessCode(trie.CodeSyncResult{Hash: hashes[i], Data: data})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		batch := db.NewBatch()
+		if err := sched.Commit(batch); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		fetched += len(hashes)
+		log.Debug("Fetched state nodes from peer", "root", root, "fetched", fetched, "pending", sched.Pending())
+	}
+	log.Info("Fetched state from peer", "root", root, "nodes", fetched)
+	return nil
+}
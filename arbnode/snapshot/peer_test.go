@@ -0,0 +1,167 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/holiman/uint256"
+
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func newTestPeer(t *testing.T, api *PeerAPI) *rpc.Client {
+	server := rpc.NewServer()
+	Require(t, server.RegisterName(PeerNamespace, api))
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return client
+}
+
+func TestFetchSnapshotFromPeer(t *testing.T) {
+	srcDir := t.TempDir()
+	path := filepath.Join(srcDir, "l2chaindata", "000001.sst")
+	Require(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	Require(t, os.WriteFile(path, bytes.Repeat([]byte{1}, 2000), 0o600))
+
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	config := DefaultProducerConfig
+	config.Dir = t.TempDir()
+	config.ChainName = "TestChain"
+	producer := &Producer{
+		config:  func() *ProducerConfig { return &config },
+		chainId: 42,
+		signer:  signature.DataSignerFromPrivateKey(key),
+	}
+	serverConfig := DefaultPeerServerConfig
+	serverConfig.Enable = true
+	serverConfig.ChunkSize = 100
+	peer := newTestPeer(t, NewPeerAPI(func() *PeerServerConfig { return &serverConfig }, producer, rawdb.NewMemoryDatabase()))
+	ctx := context.Background()
+
+	if _, _, err := FetchSnapshot(ctx, peer, config.Kind, signer, t.TempDir()); err == nil {
+		Fail(t, "fetched a snapshot before one was produced")
+	}
+
+	snapshotDir := filepath.Join(producer.chainDir(), "2024-01-01-000000-10")
+	Require(t, os.MkdirAll(snapshotDir, 0o755))
+	parts, err := writeArchive(ctx, srcDir, snapshotDir, archiveName(config.Kind), 1024)
+	Require(t, err)
+	published := &Manifest{Version: ManifestVersion, ChainID: 42, Kind: config.Kind}
+	Require(t, producer.publish(snapshotDir, parts, published))
+
+	archivePath, manifest, err := FetchSnapshot(ctx, peer, config.Kind, signer, t.TempDir())
+	Require(t, err)
+	if !bytes.Equal(manifest.ArchiveSha256, published.ArchiveSha256) {
+		Fail(t, "fetched the wrong manifest", manifest)
+	}
+	var original []byte
+	for _, part := range published.Parts {
+		data, err := os.ReadFile(filepath.Join(snapshotDir, part.Name))
+		Require(t, err)
+		original = append(original, data...)
+	}
+	fetched, err := os.ReadFile(archivePath)
+	Require(t, err)
+	if !bytes.Equal(fetched, original) {
+		Fail(t, "fetched archive doesn't match the published parts")
+	}
+
+	if _, _, err := FetchSnapshot(ctx, peer, config.Kind, common.HexToAddress("0x1234"), t.TempDir()); !errors.Is(err, ErrManifestSigner) {
+		Fail(t, "accepted a snapshot from the wrong signer", err)
+	}
+	if _, _, err := FetchSnapshot(ctx, peer, "archive", signer, t.TempDir()); err == nil {
+		Fail(t, "fetched a snapshot of a kind the peer doesn't produce")
+	}
+
+	// A part corrupted on the peer is rejected, and the incomplete archive removed
+	corrupted := filepath.Join(snapshotDir, published.Parts[0].Name)
+	Require(t, os.WriteFile(corrupted, bytes.Repeat([]byte{2}, int(published.Parts[0].Size)), 0o600))
+	fetchDir := t.TempDir()
+	if _, _, err := FetchSnapshot(ctx, peer, config.Kind, signer, fetchDir); err == nil {
+		Fail(t, "accepted a corrupted part")
+	}
+	if entries, err := os.ReadDir(fetchDir); err != nil || len(entries) != 0 {
+		Fail(t, "incomplete archive wasn't removed", entries, err)
+	}
+
+	var chunk hexutil.Bytes
+	for _, archive := range []string{"../outside/nitro-pruned.tar", "/etc/nitro-pruned.tar", "testchain/../../nitro-pruned.tar"} {
+		if err := peer.CallContext(ctx, &chunk, PeerNamespace+"_readPart", archive, "nitro-pruned.tar.part0000", hexutil.Uint64(0)); err == nil {
+			Fail(t, "read a part outside the snapshot directory", archive)
+		}
+	}
+	archive := "testchain/2024-01-01-000000-10/nitro-pruned.tar"
+	if err := peer.CallContext(ctx, &chunk, PeerNamespace+"_readPart", archive, "nitro-pruned.tar.manifest.json", hexutil.Uint64(0)); err == nil {
+		Fail(t, "read a file that isn't an archive part")
+	}
+}
+
+func TestFetchStateFromPeer(t *testing.T) {
+	srcDB := rawdb.NewMemoryDatabase()
+	stateDB := state.NewDatabaseWithConfig(srcDB, &triedb.Config{HashDB: hashdb.Defaults})
+	statedb, err := state.New(common.Hash{}, stateDB, nil)
+	Require(t, err)
+	for i := int64(1); i <= 50; i++ {
+		account := common.BigToAddress(big.NewInt(i))
+		statedb.SetBalance(account, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+		statedb.SetCode(account, []byte{byte(i), 0x60, 0x00})
+		statedb.SetState(account, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i*i)))
+	}
+	root, err := statedb.Commit(0, true)
+	Require(t, err)
+	Require(t, stateDB.TrieDB().Commit(root, false))
+
+	serverConfig := DefaultPeerServerConfig
+	serverConfig.Enable = true
+	serverConfig.MaxStateNodes = 16
+	peer := newTestPeer(t, NewPeerAPI(func() *PeerServerConfig { return &serverConfig }, nil, srcDB))
+	ctx := context.Background()
+
+	var nodes []hexutil.Bytes
+	if err := peer.CallContext(ctx, &nodes, PeerNamespace+"_stateNodes", make([]common.Hash, 17)); err == nil {
+		Fail(t, "served more state nodes than the limit")
+	}
+
+	dstDB := rawdb.NewMemoryDatabase()
+	Require(t, FetchState(ctx, peer, dstDB, root, 16))
+	fetched, err := state.New(root, state.NewDatabaseWithConfig(dstDB, &triedb.Config{HashDB: hashdb.Defaults}), nil)
+	Require(t, err)
+	for i := int64(1); i <= 50; i++ {
+		account := common.BigToAddress(big.NewInt(i))
+		if fetched.GetBalance(account).Uint64() != uint64(i) {
+			Fail(t, "unexpected balance", account)
+		}
+		if !bytes.Equal(fetched.GetCode(account), []byte{byte(i), 0x60, 0x00}) {
+			Fail(t, "unexpected code", account)
+		}
+		if fetched.GetState(account, common.BigToHash(big.NewInt(i))) != common.BigToHash(big.NewInt(i*i)) {
+			Fail(t, "unexpected storage", account)
+		}
+	}
+
+	if err := FetchState(ctx, peer, rawdb.NewMemoryDatabase(), common.HexToHash("0x1234"), 16); err == nil {
+		Fail(t, "fetched state the peer doesn't have")
+	}
+}
@@ -0,0 +1,686 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package snapshot
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var (
+	snapshotProducedCounter  = metrics.NewRegisteredCounter("arb/snapshot/produced", nil)
+	snapshotFailedCounter    = metrics.NewRegisteredCounter("arb/snapshot/failed", nil)
+	snapshotHeadBlockGauge   = metrics.NewRegisteredGauge("arb/snapshot/headblock", nil)
+	snapshotArchiveSizeGauge = metrics.NewRegisteredGauge("arb/snapshot/archivesize", nil)
+)
+
+// The chain freezer's tables, copied in this order.
+var ancientTables = []string{
+	rawdb.ChainFreezerHashTable,
+	rawdb.ChainFreezerHeaderTable,
+	rawdb.ChainFreezerBodiesTable,
+	rawdb.ChainFreezerReceiptTable,
+	rawdb.ChainFreezerDifficultyTable,
+}
+
+type ProducerConfig struct {
+	Enable         bool          `koanf:"enable"`
+	Dir            string        `koanf:"dir"`
+	ChainName      string        `koanf:"chain-name"`
+	Kind           string        `koanf:"kind"`
+	Interval       time.Duration `koanf:"interval"`
+	RetryInterval  time.Duration `koanf:"retry-interval"`
+	PartSize       uint64        `koanf:"part-size"`
+	Keep           uint64        `koanf:"keep"`
+	SigningKey     string        `koanf:"signing-key"`
+	ServeAddr      string        `koanf:"serve-addr"`
+	IdealBatchSize int           `koanf:"ideal-batch-size"`
+}
+
+var DefaultProducerConfig = ProducerConfig{
+	Enable:         false,
+	Dir:            "",
+	ChainName:      "",
+	Kind:           "pruned",
+	Interval:       24 * time.Hour,
+	RetryInterval:  10 * time.Minute,
+	PartSize:       4 << 30,
+	Keep:           2,
+	SigningKey:     "",
+	ServeAddr:      "",
+	IdealBatchSize: 100 * 1024 * 1024,
+}
+
+func ProducerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultProducerConfig.Enable, "periodically produce signed database snapshots anchored to the latest confirmed assertion (requires the staker, which may be a watchtower)")
+	f.String(prefix+".dir", DefaultProducerConfig.Dir, "directory to write snapshots into, laid out so it can be used as another node's --init.latest-base")
+	f.String(prefix+".chain-name", DefaultProducerConfig.ChainName, "chain name to file snapshots under, which nodes bootstrapping with --init.latest look up by their --chain.name (defaults to the chain id)")
+	f.String(prefix+".kind", DefaultProducerConfig.Kind, "kind of snapshot this node's database makes, as requested with --init.latest (\"pruned\" or \"archive\")")
+	f.Duration(prefix+".interval", DefaultProducerConfig.Interval, "how often to produce a snapshot")
+	f.Duration(prefix+".retry-interval", DefaultProducerConfig.RetryInterval, "how long to wait before retrying a failed snapshot")
+	f.Uint64(prefix+".part-size", DefaultProducerConfig.PartSize, "size in bytes of the parts the snapshot archive is split into")
+	f.Uint64(prefix+".keep", DefaultProducerConfig.Keep, "number of snapshots to keep")
+	f.String(prefix+".signing-key", DefaultProducerConfig.SigningKey, "private key, or path to a file containing it, to sign snapshot manifests with")
+	f.String(prefix+".serve-addr", DefaultProducerConfig.ServeAddr, "if non-empty, serve the snapshot directory over HTTP on this address")
+	f.Int(prefix+".ideal-batch-size", DefaultProducerConfig.IdealBatchSize, "batch size in bytes when copying the database")
+}
+
+func (c *ProducerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Dir == "" {
+		return errors.New("snapshot producer enabled without a directory")
+	}
+	if c.SigningKey == "" {
+		return errors.New("snapshot producer enabled without a signing key")
+	}
+	if c.Kind != "pruned" && c.Kind != "archive" {
+		return fmt.Errorf("invalid snapshot kind \"%v\", expected \"pruned\" or \"archive\"", c.Kind)
+	}
+	if c.PartSize == 0 {
+		return errors.New("snapshot part size must be positive")
+	}
+	if c.Keep == 0 {
+		return errors.New("the snapshot producer must keep at least one snapshot")
+	}
+	return nil
+}
+
+type ProducerConfigFetcher func() *ProducerConfig
+
+// Producer copies the node's databases into a consistent snapshot, packs them into an archive split in
+// parts, and publishes them with a manifest signed by the producer.
+type Producer struct {
+	stopwaiter.StopWaiter
+	config  ProducerConfigFetcher
+	chainId uint64
+	chainDB ethdb.Database
+	arbDB   ethdb.Database
+	signer  signature.DataSignerFunc
+
+	confirmedMutex sync.Mutex
+	confirmed      *Assertion
+
+	// Serializes producing snapshots, which also happens on demand through ProduceLatest
+	produceMutex sync.Mutex
+	lastProduced time.Time
+}
+
+func NewProducer(config ProducerConfigFetcher, chainId uint64, chainDB ethdb.Database, arbDB ethdb.Database) (*Producer, error) {
+	if err := config().Validate(); err != nil {
+		return nil, err
+	}
+	keyHash, err := signature.LoadSigningKey(config().SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading snapshot signing key: %w", err)
+	}
+	privateKey, err := crypto.ToECDSA(keyHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot signing key: %w", err)
+	}
+	log.Info("snapshot producer signing manifests", "signer", crypto.PubkeyToAddress(privateKey.PublicKey))
+	return &Producer{
+		config:  config,
+		chainId: chainId,
+		chainDB: chainDB,
+		arbDB:   arbDB,
+		signer:  signature.DataSignerFromPrivateKey(privateKey),
+	}, nil
+}
+
+// UpdateLatestConfirmed records the assertion the next snapshot is anchored to.
+func (p *Producer) UpdateLatestConfirmed(count arbutil.MessageIndex, globalState validator.GoGlobalState) {
+	assertion := NewAssertion(uint64(count), globalState)
+	p.confirmedMutex.Lock()
+	defer p.confirmedMutex.Unlock()
+	p.confirmed = &assertion
+}
+
+func (p *Producer) latestConfirmed() *Assertion {
+	p.confirmedMutex.Lock()
+	defer p.confirmedMutex.Unlock()
+	return p.confirmed
+}
+
+func (p *Producer) chainDir() string {
+	chainName := p.config().ChainName
+	if chainName == "" {
+		chainName = strconv.FormatUint(p.chainId, 10)
+	}
+	return filepath.Join(p.config().Dir, strings.ToLower(chainName))
+}
+
+func (p *Producer) latestFile() string {
+	return filepath.Join(p.chainDir(), "latest-"+p.config().Kind+".txt")
+}
+
+func archiveName(kind string) string {
+	return "nitro-" + kind + ".tar"
+}
+
+// latestArchive returns the path, relative to the snapshot directory, of the archive published last, or "" if none was.
+func (p *Producer) latestArchive() (string, error) {
+	latest, err := os.ReadFile(p.latestFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(latest)), nil
+}
+
+// readLatestManifest returns the manifest of the snapshot published last, if any.
+func (p *Producer) readLatestManifest() (*Manifest, error) {
+	latest, err := p.latestArchive()
+	if err != nil || latest == "" {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(p.config().Dir, filepath.FromSlash(latest)+ManifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// copyKeyValues copies every entry of src into dst. The iterator reads from a consistent view of src,
+// so writes made while copying don't tear the copy.
+func copyKeyValues(ctx context.Context, src ethdb.Iteratee, dst ethdb.KeyValueStore, idealBatchSize int) error {
+	iter := src.NewIterator(nil, nil)
+	defer iter.Release()
+	batch := dst.NewBatch()
+	for iter.Next() {
+		if err := batch.Put(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= idealBatchSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// copyAncients copies the chain freezer. It must run after the key-value store was copied: blocks frozen
+// since then were deleted from the key-value store, so they must be in the copied freezer instead.
+func copyAncients(ctx context.Context, src ethdb.AncientReader, dst ethdb.AncientWriter, idealBatchSize int) error {
+	tail, err := src.Tail()
+	if err != nil {
+		return err
+	}
+	if tail != 0 {
+		return fmt.Errorf("can't snapshot a freezer whose history was pruned up to %v", tail)
+	}
+	frozen, err := src.Ancients()
+	if err != nil {
+		return err
+	}
+	for start := uint64(0); start < frozen; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		items := make(map[string][][]byte, len(ancientTables))
+		count := frozen - start
+		for _, table := range ancientTables {
+			data, err := src.AncientRange(table, start, count, uint64(idealBatchSize)) // #nosec G115
+			if err != nil {
+				return fmt.Errorf("error reading ancient %v from %v: %w", table, start, err)
+			}
+			if len(data) == 0 {
+				return fmt.Errorf("ancient %v missing item %v", table, start)
+			}
+			count = min(count, uint64(len(data)))
+			items[table] = data
+		}
+		_, err := dst.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i := uint64(0); i < count; i++ {
+				for _, table := range ancientTables {
+					if err := op.AppendRaw(table, start+i, items[table][i]); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		start += count
+	}
+	return nil
+}
+
+func openStagingDB(dir string, ancients bool, namespace string) (ethdb.Database, error) {
+	options := rawdb.OpenOptions{
+		Type:               rawdb.DBPebble,
+		Directory:          dir,
+		Namespace:          namespace,
+		Cache:              16,
+		Handles:            16,
+		PebbleExtraOptions: conf.PersistentConfigDefault.Pebble.ExtraOptions(namespace),
+	}
+	if ancients {
+		options.AncientsDirectory = filepath.Join(dir, "ancient")
+	}
+	return rawdb.Open(options)
+}
+
+// copyDatabases copies the node's databases into a layout nitro opens on startup. The chain database is
+// copied first, so that the copy of the consensus database has every message the chain has blocks for.
+func (p *Producer) copyDatabases(ctx context.Context, stagingDir string, assertion *Assertion) (*Manifest, error) {
+	idealBatchSize := p.config().IdealBatchSize
+	chainDB, err := openStagingDB(filepath.Join(stagingDir, "l2chaindata"), true, "l2chaindata/")
+	if err != nil {
+		return nil, err
+	}
+	defer chainDB.Close()
+	if err := copyKeyValues(ctx, p.chainDB, chainDB, idealBatchSize); err != nil {
+		return nil, fmt.Errorf("error copying chain database: %w", err)
+	}
+	if err := copyAncients(ctx, p.chainDB, chainDB, idealBatchSize); err != nil {
+		return nil, fmt.Errorf("error copying chain freezer: %w", err)
+	}
+	arbDB, err := openStagingDB(filepath.Join(stagingDir, "arbitrumdata"), false, "arbitrumdata/")
+	if err != nil {
+		return nil, err
+	}
+	defer arbDB.Close()
+	if err := copyKeyValues(ctx, p.arbDB, arbDB, idealBatchSize); err != nil {
+		return nil, fmt.Errorf("error copying consensus database: %w", err)
+	}
+
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		ChainID:   p.chainId,
+		Kind:      p.config().Kind,
+		CreatedAt: uint64(time.Now().Unix()), // #nosec G115
+		Assertion: *assertion,
+	}
+	if err := verifyChainCopy(chainDB, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// verifyChainCopy checks that the copied chain reaches the anchored assertion's block, and fills in the
+// manifest's head.
+func verifyChainCopy(chainDB ethdb.Database, manifest *Manifest) error {
+	head := rawdb.ReadHeadBlockHash(chainDB)
+	headNumber := rawdb.ReadHeaderNumber(chainDB, head)
+	if headNumber == nil {
+		return errors.New("copied chain has no head block")
+	}
+	assertionNumber := rawdb.ReadHeaderNumber(chainDB, manifest.Assertion.BlockHash)
+	if assertionNumber == nil || *assertionNumber > *headNumber || rawdb.ReadCanonicalHash(chainDB, *assertionNumber) != manifest.Assertion.BlockHash {
+		return fmt.Errorf("copied chain doesn't contain the confirmed assertion's block %v", manifest.Assertion.BlockHash)
+	}
+	manifest.HeadBlockNumber = *headNumber
+	manifest.HeadBlockHash = head
+	return nil
+}
+
+// partWriter splits what's written to it into parts of at most partSize bytes, hashing each part and
+// the whole.
+type partWriter struct {
+	dir         string
+	name        string
+	partSize    uint64
+	parts       []ManifestPart
+	file        *os.File
+	partHash    hash.Hash
+	written     uint64
+	archiveHash hash.Hash
+	size        uint64
+}
+
+func newPartWriter(dir string, name string, partSize uint64) *partWriter {
+	return &partWriter{
+		dir:         dir,
+		name:        name,
+		partSize:    partSize,
+		archiveHash: sha256.New(),
+	}
+}
+
+func (w *partWriter) Write(data []byte) (int, error) {
+	total := 0
+	for len(data) > 0 {
+		if w.file == nil {
+			file, err := os.Create(filepath.Join(w.dir, fmt.Sprintf("%v.part%04d", w.name, len(w.parts))))
+			if err != nil {
+				return total, err
+			}
+			w.file = file
+			w.partHash = sha256.New()
+			w.written = 0
+		}
+		chunk := data[:min(uint64(len(data)), w.partSize-w.written)]
+		n, err := w.file.Write(chunk)
+		w.partHash.Write(chunk[:n])
+		w.archiveHash.Write(chunk[:n])
+		w.written += uint64(n) // #nosec G115
+		w.size += uint64(n)    // #nosec G115
+		total += n
+		if err != nil {
+			return total, err
+		}
+		data = data[n:]
+		if w.written == w.partSize {
+			if err := w.closePart(); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (w *partWriter) closePart() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.parts = append(w.parts, ManifestPart{
+		Name:   filepath.Base(w.file.Name()),
+		Sha256: w.partHash.Sum(nil),
+		Size:   w.written,
+	})
+	w.file = nil
+	return nil
+}
+
+func (w *partWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.closePart()
+}
+
+// writeArchive tars srcDir into parts in destDir.
+func writeArchive(ctx context.Context, srcDir string, destDir string, name string, partSize uint64) (*partWriter, error) {
+	parts := newPartWriter(destDir, name, partSize)
+	tarWriter := tar.NewWriter(parts)
+	err := filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		// The staging databases' lock files aren't part of the snapshot
+		if entry.Name() == "LOCK" || entry.Name() == "FLOCK" {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if closeErr := parts.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil { // #nosec G306
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// publish writes the manifests for an archive and points the latest file at it.
+func (p *Producer) publish(snapshotDir string, parts *partWriter, manifest *Manifest) error {
+	manifest.ArchiveSha256 = parts.archiveHash.Sum(nil)
+	manifest.ArchiveSize = parts.size
+	manifest.Parts = parts.parts
+	if err := manifest.Sign(p.signer); err != nil {
+		return fmt.Errorf("error signing snapshot manifest: %w", err)
+	}
+	archivePath := filepath.Join(snapshotDir, archiveName(manifest.Kind))
+	var partList strings.Builder
+	for _, part := range manifest.Parts {
+		fmt.Fprintf(&partList, "%v %v\n", hex.EncodeToString(part.Sha256), part.Name)
+	}
+	if err := writeFileAtomic(archivePath+".manifest.txt", []byte(partList.String())); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(archivePath+ManifestSuffix, data); err != nil {
+		return err
+	}
+	latest, err := filepath.Rel(p.config().Dir, archivePath)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.latestFile(), []byte(filepath.ToSlash(latest)+"\n"))
+}
+
+// prune removes all but the newest snapshots. Snapshot directories are named by creation time, so
+// sorting them by name sorts them by age.
+func (p *Producer) prune() error {
+	entries, err := os.ReadDir(p.chainDir())
+	if err != nil {
+		return err
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			snapshots = append(snapshots, entry.Name())
+		}
+	}
+	sort.Strings(snapshots)
+	keep := p.config().Keep
+	for uint64(len(snapshots)) > keep {
+		log.Info("removing old snapshot", "snapshot", snapshots[0])
+		if err := os.RemoveAll(filepath.Join(p.chainDir(), snapshots[0])); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// Produce makes and publishes one snapshot anchored to assertion.
+func (p *Producer) Produce(ctx context.Context, assertion *Assertion) (*Manifest, error) {
+	start := time.Now()
+	config := p.config()
+	stagingDir := filepath.Join(config.Dir, ".staging")
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+	log.Info("producing snapshot", "assertionBlock", assertion.BlockHash, "assertionMessageCount", assertion.MessageCount)
+	manifest, err := p.copyDatabases(ctx, stagingDir, assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	name := time.Unix(int64(manifest.CreatedAt), 0).UTC().Format("2006-01-02-150405") + "-" + strconv.FormatUint(manifest.HeadBlockNumber, 10) // #nosec G115
+	snapshotDir := filepath.Join(p.chainDir(), name)
+	if err := os.MkdirAll(snapshotDir, 0o755); err != nil { // #nosec G301
+		return nil, err
+	}
+	parts, err := writeArchive(ctx, stagingDir, snapshotDir, archiveName(manifest.Kind), config.PartSize)
+	if err == nil {
+		err = p.publish(snapshotDir, parts, manifest)
+	}
+	if err != nil {
+		if removeErr := os.RemoveAll(snapshotDir); removeErr != nil {
+			log.Warn("failed to remove incomplete snapshot", "dir", snapshotDir, "err", removeErr)
+		}
+		return nil, err
+	}
+	if err := p.prune(); err != nil {
+		log.Warn("failed to remove old snapshots", "err", err)
+	}
+	snapshotProducedCounter.Inc(1)
+	snapshotHeadBlockGauge.Update(int64(manifest.HeadBlockNumber)) // #nosec G115
+	snapshotArchiveSizeGauge.Update(int64(manifest.ArchiveSize))   // #nosec G115
+	log.Info("produced snapshot", "dir", snapshotDir, "headBlock", manifest.HeadBlockNumber, "size", manifest.ArchiveSize, "parts", len(manifest.Parts), "elapsed", time.Since(start))
+	return manifest, nil
+}
+
+// ProduceLatest makes and publishes a snapshot anchored to the latest confirmed assertion, regardless of the interval.
+func (p *Producer) ProduceLatest(ctx context.Context) error {
+	assertion := p.latestConfirmed()
+	if assertion == nil {
+		return errors.New("no confirmed assertion to anchor a snapshot to yet")
+	}
+	p.produceMutex.Lock()
+	defer p.produceMutex.Unlock()
+	if _, err := p.Produce(ctx, assertion); err != nil {
+		snapshotFailedCounter.Inc(1)
+		return err
+	}
+	p.lastProduced = time.Now()
+	return nil
+}
+
+func (p *Producer) produceIfDue(ctx context.Context) time.Duration {
+	config := p.config()
+	p.produceMutex.Lock()
+	defer p.produceMutex.Unlock()
+	if due := time.Until(p.lastProduced.Add(config.Interval)); due > 0 {
+		return due
+	}
+	assertion := p.latestConfirmed()
+	if assertion == nil {
+		log.Debug("snapshot producer waiting for a confirmed assertion")
+		return time.Minute
+	}
+	if _, err := p.Produce(ctx, assertion); err != nil {
+		if ctx.Err() == nil {
+			snapshotFailedCounter.Inc(1)
+			log.Error("failed to produce snapshot", "err", err)
+		}
+		return config.RetryInterval
+	}
+	p.lastProduced = time.Now()
+	return config.Interval
+}
+
+// Handler serves the snapshot directory, hiding the staging area.
+func (p *Producer) Handler() http.Handler {
+	files := http.FileServer(http.Dir(p.config().Dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+func (p *Producer) launchServer(ctx context.Context) {
+	server := &http.Server{
+		Addr:              p.config().ServeAddr,
+		Handler:           p.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		err := server.Shutdown(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("error shutting down snapshot server", "err", err)
+		}
+	}()
+
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Warn("error serving snapshots", "err", err)
+	}
+}
+
+func (p *Producer) Start(ctxIn context.Context) error {
+	if err := os.MkdirAll(p.chainDir(), 0o755); err != nil { // #nosec G301
+		return err
+	}
+	latest, err := p.readLatestManifest()
+	if err != nil {
+		log.Warn("failed to read the latest snapshot's manifest", "err", err)
+	} else if latest != nil {
+		p.lastProduced = time.Unix(int64(latest.CreatedAt), 0) // #nosec G115
+	}
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(p.produceIfDue)
+	if p.config().ServeAddr != "" {
+		p.LaunchThread(p.launchServer)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestManifestSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	globalState := validator.GoGlobalState{BlockHash: common.HexToHash("0x01"), Batch: 3}
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		ChainID:   42,
+		Kind:      "pruned",
+		Assertion: NewAssertion(10, globalState),
+		Parts:     []ManifestPart{{Name: "nitro-pruned.tar.part0000", Sha256: make([]byte, 32), Size: 1}},
+	}
+	Require(t, manifest.Sign(signature.DataSignerFromPrivateKey(key)))
+	data, err := json.Marshal(manifest)
+	Require(t, err)
+
+	parsed, err := ParseManifest(data, crypto.PubkeyToAddress(key.PublicKey))
+	Require(t, err)
+	if parsed.Assertion.StateHash != globalState.Hash() {
+		Fail(t, "unexpected assertion", parsed.Assertion)
+	}
+	if _, err := ParseManifest(data, common.HexToAddress("0x1234")); !errors.Is(err, ErrManifestSigner) {
+		Fail(t, "accepted a manifest from the wrong signer", err)
+	}
+	manifest.HeadBlockNumber++
+	tampered, err := json.Marshal(manifest)
+	Require(t, err)
+	if _, err := ParseManifest(tampered, crypto.PubkeyToAddress(key.PublicKey)); err == nil {
+		Fail(t, "accepted a tampered manifest")
+	}
+}
+
+func TestCopyKeyValues(t *testing.T) {
+	src := rawdb.NewMemoryDatabase()
+	for i := byte(0); i < 100; i++ {
+		Require(t, src.Put([]byte{i}, bytes.Repeat([]byte{i}, 100)))
+	}
+	dst := rawdb.NewMemoryDatabase()
+	Require(t, copyKeyValues(context.Background(), src, dst, 1000))
+	for i := byte(0); i < 100; i++ {
+		value, err := dst.Get([]byte{i})
+		Require(t, err)
+		if !bytes.Equal(value, bytes.Repeat([]byte{i}, 100)) {
+			Fail(t, "unexpected value for key", i)
+		}
+	}
+}
+
+func TestWriteAndPublishArchive(t *testing.T) {
+	srcDir := t.TempDir()
+	files := map[string][]byte{
+		"l2chaindata/000001.sst":  bytes.Repeat([]byte{1}, 700),
+		"arbitrumdata/000002.sst": bytes.Repeat([]byte{2}, 300),
+		"arbitrumdata/LOCK":       {},
+	}
+	for name, data := range files {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		Require(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		Require(t, os.WriteFile(path, data, 0o600))
+	}
+
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	config := DefaultProducerConfig
+	config.Dir = t.TempDir()
+	config.ChainName = "TestChain"
+	config.Keep = 1
+	producer := &Producer{
+		config:  func() *ProducerConfig { return &config },
+		chainId: 42,
+		signer:  signature.DataSignerFromPrivateKey(key),
+	}
+
+	var manifests []*Manifest
+	for _, name := range []string{"2024-01-01-000000-10", "2024-01-02-000000-20"} {
+		snapshotDir := filepath.Join(producer.chainDir(), name)
+		Require(t, os.MkdirAll(snapshotDir, 0o755))
+		parts, err := writeArchive(context.Background(), srcDir, snapshotDir, archiveName(config.Kind), 256)
+		Require(t, err)
+		manifest := &Manifest{Version: ManifestVersion, ChainID: 42, Kind: config.Kind}
+		Require(t, producer.publish(snapshotDir, parts, manifest))
+		Require(t, producer.prune())
+		manifests = append(manifests, manifest)
+	}
+	manifest := manifests[1]
+
+	// Only the newest snapshot is kept, and latest points to it
+	if _, err := os.Stat(filepath.Join(config.Dir, "testchain", "2024-01-01-000000-10")); !os.IsNotExist(err) {
+		Fail(t, "old snapshot wasn't pruned", err)
+	}
+	latest, err := producer.readLatestManifest()
+	Require(t, err)
+	if latest == nil || !bytes.Equal(latest.ArchiveSha256, manifest.ArchiveSha256) {
+		Fail(t, "latest doesn't point at the newest snapshot", latest)
+	}
+	latestPath, err := os.ReadFile(filepath.Join(config.Dir, "testchain", "latest-pruned.txt"))
+	Require(t, err)
+	if string(latestPath) != "testchain/2024-01-02-000000-20/nitro-pruned.tar\n" {
+		Fail(t, "unexpected latest file", string(latestPath))
+	}
+
+	// The parts join into a tar of the databases, without lock files
+	if len(manifest.Parts) < 2 {
+		Fail(t, "archive wasn't split", manifest.Parts)
+	}
+	var joined bytes.Buffer
+	for _, part := range manifest.Parts {
+		data, err := os.ReadFile(filepath.Join(config.Dir, "testchain", "2024-01-02-000000-20", part.Name))
+		Require(t, err)
+		if part.Size != uint64(len(data)) || part.Size > 256 {
+			Fail(t, "unexpected part size", part)
+		}
+		partHash := sha256.Sum256(data)
+		if !bytes.Equal(partHash[:], part.Sha256) {
+			Fail(t, "part checksum mismatch", part.Name)
+		}
+		joined.Write(data)
+	}
+	archiveHash := sha256.Sum256(joined.Bytes())
+	if !bytes.Equal(archiveHash[:], manifest.ArchiveSha256) || uint64(joined.Len()) != manifest.ArchiveSize {
+		Fail(t, "archive checksum mismatch")
+	}
+	reader := tar.NewReader(&joined)
+	found := map[string]bool{}
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		Require(t, err)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		Require(t, err)
+		if !bytes.Equal(data, files[header.Name]) {
+			Fail(t, "unexpected archived file", header.Name)
+		}
+		found[header.Name] = true
+	}
+	if len(found) != 2 || found["arbitrumdata/LOCK"] {
+		Fail(t, "unexpected archived files", found)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
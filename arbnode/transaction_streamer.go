@@ -64,16 +64,23 @@ type TransactionStreamer struct {
 	broadcasterQueuedMessagesPos         atomic.Uint64
 	broadcasterQueuedMessagesActiveReorg bool
 
+	deadLetterMutex sync.Mutex
+	failures        digestFailures
+	parkedPos       *arbutil.MessageIndex
+	deadLetterSkips map[arbutil.MessageIndex]arbostypes.MessageWithMetadata
+
 	coordinator     *SeqCoordinator
 	broadcastServer *broadcaster.Broadcaster
 	inboxReader     *InboxReader
 	delayedBridge   *DelayedBridge
+	pauses          *PauseControls
 }
 
 type TransactionStreamerConfig struct {
 	MaxBroadcasterQueueSize int           `koanf:"max-broadcaster-queue-size"`
 	MaxReorgResequenceDepth int64         `koanf:"max-reorg-resequence-depth" reload:"hot"`
 	ExecuteMessageLoopDelay time.Duration `koanf:"execute-message-loop-delay" reload:"hot"`
+	DeadLetterAttempts      uint64        `koanf:"dead-letter-attempts" reload:"hot"`
 }
 
 type TransactionStreamerConfigFetcher func() *TransactionStreamerConfig
@@ -82,18 +89,21 @@ var DefaultTransactionStreamerConfig = TransactionStreamerConfig{
 	MaxBroadcasterQueueSize: 50_000,
 	MaxReorgResequenceDepth: 1024,
 	ExecuteMessageLoopDelay: time.Millisecond * 100,
+	DeadLetterAttempts:      100,
 }
 
 var TestTransactionStreamerConfig = TransactionStreamerConfig{
 	MaxBroadcasterQueueSize: 10_000,
 	MaxReorgResequenceDepth: 128 * 1024,
 	ExecuteMessageLoopDelay: time.Millisecond,
+	DeadLetterAttempts:      100,
 }
 
 func TransactionStreamerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-broadcaster-queue-size", DefaultTransactionStreamerConfig.MaxBroadcasterQueueSize, "maximum cache of pending broadcaster messages")
 	f.Int64(prefix+".max-reorg-resequence-depth", DefaultTransactionStreamerConfig.MaxReorgResequenceDepth, "maximum number of messages to attempt to resequence on reorg (0 = never resequence, -1 = always resequence)")
 	f.Duration(prefix+".execute-message-loop-delay", DefaultTransactionStreamerConfig.ExecuteMessageLoopDelay, "delay when polling calls to execute messages")
+	f.Uint64(prefix+".dead-letter-attempts", DefaultTransactionStreamerConfig.DeadLetterAttempts, "after this many consecutive failures to execute a message, park it in the dead letter queue until an operator retries or skips it (0 = retry forever)")
 }
 
 func NewTransactionStreamer(
@@ -114,11 +124,16 @@ func NewTransactionStreamer(
 		fatalErrChan:       fatalErrChan,
 		config:             config,
 		snapSyncConfig:     snapSyncConfig,
+		deadLetterSkips:    make(map[arbutil.MessageIndex]arbostypes.MessageWithMetadata),
 	}
 	err := streamer.cleanupInconsistentState()
 	if err != nil {
 		return nil, err
 	}
+	err = streamer.loadDeadLetters()
+	if err != nil {
+		return nil, err
+	}
 	return streamer, nil
 }
 
@@ -173,6 +188,13 @@ func (s *TransactionStreamer) SetInboxReaders(inboxReader *InboxReader, delayedB
 	s.delayedBridge = delayedBridge
 }
 
+func (s *TransactionStreamer) SetPauseControls(pauses *PauseControls) {
+	if s.Started() {
+		panic("trying to set pause controls after start")
+	}
+	s.pauses = pauses
+}
+
 func (s *TransactionStreamer) ChainConfig() *params.ChainConfig {
 	return s.chainConfig
 }
@@ -1036,6 +1058,10 @@ func (s *TransactionStreamer) broadcastMessages(
 	if s.broadcastServer == nil {
 		return
 	}
+	// Feed readers get the messages sequenced while the feed is paused from the parent chain
+	if s.pauses.Paused(PauseFeed) {
+		return
+	}
 	if err := s.broadcastServer.BroadcastMessages(msgs, pos); err != nil {
 		log.Error("failed broadcasting messages", "pos", pos, "err", err)
 	}
@@ -1164,6 +1190,9 @@ func (s *TransactionStreamer) ExecuteNextMsg(ctx context.Context) bool {
 	if pos >= msgCount {
 		return false
 	}
+	if s.deadLetterParked(pos) {
+		return false
+	}
 	msgAndBlockHash, err := s.getMessageWithMetadataAndBlockHash(pos)
 	if err != nil {
 		log.Error("feedOneMsg failed to readMessage", "err", err, "pos", pos)
@@ -1178,15 +1207,17 @@ func (s *TransactionStreamer) ExecuteNextMsg(ctx context.Context) bool {
 		}
 		msgForPrefetch = msg
 	}
-	msgResult, err := s.exec.DigestMessage(pos, &msgAndBlockHash.MessageWithMeta, msgForPrefetch)
+	msgResult, err := s.exec.DigestMessage(pos, s.substituteSkipped(pos, &msgAndBlockHash.MessageWithMeta), msgForPrefetch)
 	if err != nil {
 		logger := log.Warn
 		if prevMessageCount < msgCount {
 			logger = log.Debug
 		}
 		logger("feedOneMsg failed to send message to execEngine", "err", err, "pos", pos)
+		s.digestFailed(pos, msgAndBlockHash, err)
 		return false
 	}
+	s.digestSucceeded(pos)
 
 	s.checkResult(msgResult, msgAndBlockHash.BlockHash)
 
@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -25,12 +26,18 @@ import (
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/blockhash"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/chainmetadata"
+	"github.com/offchainlabs/nitro/arbos/disabledmethods"
+	"github.com/offchainlabs/nitro/arbos/expresslane"
+	"github.com/offchainlabs/nitro/arbos/feereports"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/merkleAccumulator"
+	"github.com/offchainlabs/nitro/arbos/paymasters"
 	"github.com/offchainlabs/nitro/arbos/programs"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/tokenregistry"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/testhelpers/env"
 )
@@ -58,12 +65,30 @@ type ArbosState struct {
 	genesisBlockNum        storage.StorageBackedUint64
 	infraFeeAccount        storage.StorageBackedAddress
 	brotliCompressionLevel storage.StorageBackedUint64 // brotli compression level used for pricing
+	sequencerFrozenSince   storage.StorageBackedUint64 // when the chain owner froze the sequencer, or 0 if it isn't frozen
+	maxTxSize              storage.StorageBackedUint64 // the largest encoded transaction allowed, or 0 for the node's default
+	maxCalldataSize        storage.StorageBackedUint64 // the largest transaction calldata allowed, or 0 for no limit
+	timeWarp               storage.StorageBackedUint64 // seconds added to block timestamps by ArbDebug
+	l1BlockNumberWarp      storage.StorageBackedUint64 // blocks added to the recorded L1 block number by ArbDebug
+	maxLogsPerTx           storage.StorageBackedUint64 // the most logs a transaction may emit, or 0 for no limit
+	maxReturnDataSize      storage.StorageBackedUint64 // the largest data a transaction may return, or 0 for no limit
+	feeHolidayEnd          storage.StorageBackedUint64 // when the chain owner's fee holiday ends, or 0 if there isn't one
+	tokenRegistry          *tokenregistry.TokenRegistry
+	feedSigners            *addressSet.AddressSet
+	chainMetadata          *chainmetadata.ChainMetadata
+	paymasters             *paymasters.Paymasters
+	disabledMethods        *disabledmethods.DisabledMethods
+	feeReports             *feereports.FeeReports
+	expressLane            *expresslane.ExpressLane
 	backingStorage         *storage.Storage
 	Burner                 burn.Burner
 }
 
+// ArbosVersion_40 is still in development, so only debug chains may upgrade to it
+const ArbosVersion_40 uint64 = 40
+
 const MaxArbosVersionSupported uint64 = params.ArbosVersion_StylusChargingFixes
-const MaxDebugArbosVersionSupported uint64 = params.ArbosVersion_StylusChargingFixes
+const MaxDebugArbosVersionSupported uint64 = ArbosVersion_40
 
 var ErrUninitializedArbOS = errors.New("ArbOS uninitialized")
 var ErrAlreadyInitialized = errors.New("ArbOS is already initialized")
@@ -95,6 +120,21 @@ func OpenArbosState(stateDB vm.StateDB, burner burn.Burner) (*ArbosState, error)
 		backingStorage.OpenStorageBackedUint64(uint64(genesisBlockNumOffset)),
 		backingStorage.OpenStorageBackedAddress(uint64(infraFeeAccountOffset)),
 		backingStorage.OpenStorageBackedUint64(uint64(brotliCompressionLevelOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(sequencerFrozenSinceOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(maxTxSizeOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(maxCalldataSizeOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(timeWarpOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(l1BlockNumberWarpOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(maxLogsPerTxOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(maxReturnDataSizeOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(feeHolidayEndOffset)),
+		tokenregistry.Open(backingStorage.OpenSubStorage(tokenRegistrySubspace)),
+		addressSet.OpenAddressSet(backingStorage.OpenCachedSubStorage(feedSignersSubspace)),
+		chainmetadata.Open(backingStorage.OpenSubStorage(chainMetadataSubspace)),
+		paymasters.Open(backingStorage.OpenSubStorage(paymastersSubspace)),
+		disabledmethods.Open(backingStorage.OpenSubStorage(disabledMethodsSubspace)),
+		feereports.Open(backingStorage.OpenSubStorage(feeReportsSubspace)),
+		expresslane.Open(backingStorage.OpenSubStorage(expressLaneSubspace)),
 		backingStorage,
 		burner,
 	}, nil
@@ -136,6 +176,19 @@ func NewArbosMemoryBackedArbOSState() (*ArbosState, *state.StateDB) {
 	return newState, statedb
 }
 
+// IsPrecompileMethodDisabled checks whether the chain owner disabled a precompile method without opening the
+// rest of the ArbOS state. Every precompile call checks, so how many methods are disabled is read for free,
+// and the burner is only charged for looking the method up when some are.
+func IsPrecompileMethodDisabled(stateDB vm.StateDB, burner burn.Burner, precompile common.Address, selector [4]byte) (bool, error) {
+	freeStorage := storage.NewGeth(stateDB, burn.NewSystemBurner(nil, false))
+	count, err := disabledmethods.Open(freeStorage.OpenSubStorage(disabledMethodsSubspace)).Size()
+	if count == 0 || err != nil {
+		return false, err
+	}
+	backingStorage := storage.NewGeth(stateDB, burner)
+	return disabledmethods.Open(backingStorage.OpenSubStorage(disabledMethodsSubspace)).IsDisabled(disabledmethods.Method{Precompile: precompile, Selector: selector})
+}
+
 // ArbOSVersion returns the ArbOS version
 func ArbOSVersion(stateDB vm.StateDB) uint64 {
 	backingStorage := storage.NewGeth(stateDB, burn.NewSystemBurner(nil, false))
@@ -157,20 +210,37 @@ const (
 	genesisBlockNumOffset
 	infraFeeAccountOffset
 	brotliCompressionLevelOffset
+	sequencerFrozenSinceOffset
+	maxTxSizeOffset
+	maxCalldataSizeOffset
+	timeWarpOffset
+	l1BlockNumberWarpOffset
+	maxLogsPerTxOffset
+	maxReturnDataSizeOffset
+	feeHolidayEndOffset
 )
 
 type SubspaceID []byte
 
 var (
-	l1PricingSubspace    SubspaceID = []byte{0}
-	l2PricingSubspace    SubspaceID = []byte{1}
-	retryablesSubspace   SubspaceID = []byte{2}
-	addressTableSubspace SubspaceID = []byte{3}
-	chainOwnerSubspace   SubspaceID = []byte{4}
-	sendMerkleSubspace   SubspaceID = []byte{5}
-	blockhashesSubspace  SubspaceID = []byte{6}
-	chainConfigSubspace  SubspaceID = []byte{7}
-	programsSubspace     SubspaceID = []byte{8}
+	l1PricingSubspace        SubspaceID = []byte{0}
+	l2PricingSubspace        SubspaceID = []byte{1}
+	retryablesSubspace       SubspaceID = []byte{2}
+	addressTableSubspace     SubspaceID = []byte{3}
+	chainOwnerSubspace       SubspaceID = []byte{4}
+	sendMerkleSubspace       SubspaceID = []byte{5}
+	blockhashesSubspace      SubspaceID = []byte{6}
+	chainConfigSubspace      SubspaceID = []byte{7}
+	programsSubspace         SubspaceID = []byte{8}
+	tokenRegistrySubspace    SubspaceID = []byte{9}
+	feedSignersSubspace      SubspaceID = []byte{10}
+	chainMetadataSubspace    SubspaceID = []byte{11}
+	cancelledSendsSubspace   SubspaceID = []byte{12}
+	paymastersSubspace       SubspaceID = []byte{13}
+	disabledMethodsSubspace  SubspaceID = []byte{14}
+	feeReportsSubspace       SubspaceID = []byte{15}
+	expressLaneSubspace      SubspaceID = []byte{16}
+	parameterSunsetsSubspace SubspaceID = []byte{17}
 )
 
 var PrecompileMinArbOSVersions = make(map[common.Address]uint64)
@@ -334,6 +404,15 @@ func (state *ArbosState) UpgradeArbosVersion(
 		case 32:
 			// no change state needed
 
+		case 33, 34, 35, 36, 37, 38, 39:
+			// these versions are left to Orbit chains for custom upgrades.
+
+		case ArbosVersion_40:
+			// no change state needed for the minimum base fee schedule, chain metadata, cancelled sends, paymasters, disabled methods or parameter sunsets, as they start out empty,
+			// nor for the transaction size limits, which start out unset
+			ensure(tokenregistry.Initialize(state.backingStorage.OpenSubStorage(tokenRegistrySubspace)))
+			ensure(addressSet.Initialize(state.backingStorage.OpenCachedSubStorage(feedSignersSubspace)))
+
 		default:
 			return fmt.Errorf(
 				"the chain is upgrading to unsupported ArbOS version %v, %w",
@@ -414,6 +493,171 @@ func (state *ArbosState) SetBrotliCompressionLevel(val uint64) error {
 	return errors.New("invalid brotli compression level")
 }
 
+// SequencerFrozenSince returns when the chain owner froze the sequencer, or 0 if it isn't frozen.
+// The freeze is only advisory: sequencers stop including transactions from anyone but the chain owners,
+// but blocks remain valid either way, and delayed messages are still sequenced.
+func (state *ArbosState) SequencerFrozenSince() (uint64, error) {
+	return state.sequencerFrozenSince.Get()
+}
+
+func (state *ArbosState) SetSequencerFrozenSince(timestamp uint64) error {
+	return state.sequencerFrozenSince.Set(timestamp)
+}
+
+// Bounds on the transaction size limits the chain owner may set. Transactions, and so blocks of them, must
+// fit in a batch: batch posters default to 100KB batches to stay under the 128KB parent chain txpool limit,
+// which leaves room for the batch header past the sequencer's default 95KB limit. Limits too small could
+// lock the owner out of changing them back.
+const (
+	MinTxSizeLimit       = 16 * 1024
+	MaxTxSizeLimit       = 95000
+	MinCalldataSizeLimit = 4 * 1024
+)
+
+var (
+	ErrTxTooLarge       = errors.New("transaction exceeds the chain's size limit")
+	ErrCalldataTooLarge = errors.New("transaction calldata exceeds the chain's size limit")
+)
+
+// Lower bounds on the transaction result limits the chain owner may set, so ordinary contracts keep working.
+const (
+	MinLogsPerTxLimit      = 256
+	MinReturnDataSizeLimit = 4 * 1024
+)
+
+var (
+	ErrTooManyLogs        = errors.New("transaction emitted more logs than the chain allows")
+	ErrReturnDataTooLarge = errors.New("transaction return data exceeds the chain's size limit")
+)
+
+// MaxTxSize returns the largest encoded transaction the chain owner allows, or 0 if they haven't set a limit,
+// in which case sequencers use their configured limit.
+func (state *ArbosState) MaxTxSize() (uint64, error) {
+	return state.maxTxSize.Get()
+}
+
+// SetMaxTxSize sets the transaction size limit, or unsets it if the limit is 0.
+func (state *ArbosState) SetMaxTxSize(limit uint64) error {
+	if limit != 0 && (limit < MinTxSizeLimit || limit > MaxTxSizeLimit) {
+		return fmt.Errorf("max tx size %v out of bounds [%v, %v]", limit, MinTxSizeLimit, MaxTxSizeLimit)
+	}
+	return state.maxTxSize.Set(limit)
+}
+
+// MaxCalldataSize returns the largest transaction calldata the chain owner allows, or 0 if it's unlimited.
+func (state *ArbosState) MaxCalldataSize() (uint64, error) {
+	return state.maxCalldataSize.Get()
+}
+
+// SetMaxCalldataSize sets the calldata size limit, or unsets it if the limit is 0.
+func (state *ArbosState) SetMaxCalldataSize(limit uint64) error {
+	if limit != 0 && (limit < MinCalldataSizeLimit || limit > MaxTxSizeLimit) {
+		return fmt.Errorf("max calldata size %v out of bounds [%v, %v]", limit, MinCalldataSizeLimit, MaxTxSizeLimit)
+	}
+	return state.maxCalldataSize.Set(limit)
+}
+
+// TimeWarp returns how many seconds ahead of their L1 timestamps blocks are produced.
+// It's only ever nonzero on chains with debug precompiles.
+func (state *ArbosState) TimeWarp() (uint64, error) {
+	return state.timeWarp.Get()
+}
+
+// L1BlockNumberWarp returns how many blocks ahead of the real L1 block number the recorded one is.
+// It's only ever nonzero on chains with debug precompiles.
+func (state *ArbosState) L1BlockNumberWarp() (uint64, error) {
+	return state.l1BlockNumberWarp.Get()
+}
+
+// WarpTime moves block timestamps forward, starting with the next block.
+// Time can't be moved back, as block timestamps must never decrease.
+func (state *ArbosState) WarpTime(seconds uint64) error {
+	warp, err := state.timeWarp.Get()
+	if err != nil {
+		return err
+	}
+	if warp+seconds < warp {
+		return errors.New("time warp overflows")
+	}
+	return state.timeWarp.Set(warp + seconds)
+}
+
+// WarpL1BlockNumber moves the recorded L1 block number forward, starting with the next block.
+func (state *ArbosState) WarpL1BlockNumber(blocks uint64) error {
+	warp, err := state.l1BlockNumberWarp.Get()
+	if err != nil {
+		return err
+	}
+	if warp+blocks < warp {
+		return errors.New("L1 block number warp overflows")
+	}
+	return state.l1BlockNumberWarp.Set(warp + blocks)
+}
+
+// CheckTxSize checks a transaction against the size limits the chain owner set.
+func (state *ArbosState) CheckTxSize(tx *types.Transaction) error {
+	maxTxSize, err := state.MaxTxSize()
+	if err != nil {
+		return err
+	}
+	if maxTxSize != 0 && tx.Size() > maxTxSize {
+		return fmt.Errorf("%w: size %v, max %v", ErrTxTooLarge, tx.Size(), maxTxSize)
+	}
+	maxCalldataSize, err := state.MaxCalldataSize()
+	if err != nil {
+		return err
+	}
+	if maxCalldataSize != 0 && uint64(len(tx.Data())) > maxCalldataSize {
+		return fmt.Errorf("%w: size %v, max %v", ErrCalldataTooLarge, len(tx.Data()), maxCalldataSize)
+	}
+	return nil
+}
+
+// MaxLogsPerTx returns the most logs a transaction may emit, or 0 if it's unlimited.
+func (state *ArbosState) MaxLogsPerTx() (uint64, error) {
+	return state.maxLogsPerTx.Get()
+}
+
+// SetMaxLogsPerTx sets the limit on logs per transaction, or unsets it if the limit is 0.
+func (state *ArbosState) SetMaxLogsPerTx(limit uint64) error {
+	if limit != 0 && limit < MinLogsPerTxLimit {
+		return fmt.Errorf("max logs per tx %v below the minimum of %v", limit, MinLogsPerTxLimit)
+	}
+	return state.maxLogsPerTx.Set(limit)
+}
+
+// MaxReturnDataSize returns the largest data a transaction may return, or 0 if it's unlimited.
+func (state *ArbosState) MaxReturnDataSize() (uint64, error) {
+	return state.maxReturnDataSize.Get()
+}
+
+// SetMaxReturnDataSize sets the return data size limit, or unsets it if the limit is 0.
+func (state *ArbosState) SetMaxReturnDataSize(limit uint64) error {
+	if limit != 0 && limit < MinReturnDataSizeLimit {
+		return fmt.Errorf("max return data size %v below the minimum of %v", limit, MinReturnDataSizeLimit)
+	}
+	return state.maxReturnDataSize.Set(limit)
+}
+
+// CheckTxResult checks what a transaction did against the result limits the chain owner set.
+func (state *ArbosState) CheckTxResult(logs int, returnDataSize int) error {
+	maxLogs, err := state.MaxLogsPerTx()
+	if err != nil {
+		return err
+	}
+	if maxLogs != 0 && uint64(logs) > maxLogs {
+		return fmt.Errorf("%w: emitted %v, max %v", ErrTooManyLogs, logs, maxLogs)
+	}
+	maxReturnDataSize, err := state.MaxReturnDataSize()
+	if err != nil {
+		return err
+	}
+	if maxReturnDataSize != 0 && uint64(returnDataSize) > maxReturnDataSize {
+		return fmt.Errorf("%w: size %v, max %v", ErrReturnDataTooLarge, returnDataSize, maxReturnDataSize)
+	}
+	return nil
+}
+
 func (state *ArbosState) RetryableState() *retryables.RetryableState {
 	return state.retryableState
 }
@@ -445,6 +689,82 @@ func (state *ArbosState) Programs() *programs.Programs {
 	return state.programs
 }
 
+func (state *ArbosState) TokenRegistry() *tokenregistry.TokenRegistry {
+	return state.tokenRegistry
+}
+
+// FeedSigners is the set of keys the chain owner authorizes to sign the sequencer feed
+func (state *ArbosState) FeedSigners() *addressSet.AddressSet {
+	return state.feedSigners
+}
+
+// ChainMetadata is the chain owner's description of the chain for wallets and explorers
+func (state *ArbosState) ChainMetadata() *chainmetadata.ChainMetadata {
+	return state.chainMetadata
+}
+
+// CancelledSends maps the hashes of outbox messages their senders cancelled to a nonzero value
+func (state *ArbosState) CancelledSends() *storage.Storage {
+	return state.backingStorage.OpenSubStorage(cancelledSendsSubspace)
+}
+
+// DisabledMethods is the set of precompile methods the chain owner disabled
+func (state *ArbosState) DisabledMethods() *disabledmethods.DisabledMethods {
+	return state.disabledMethods
+}
+
+// FeeReports accumulates the fees paid to the fee accounts for reporting in events
+func (state *ArbosState) FeeReports() *feereports.FeeReports {
+	return state.feeReports
+}
+
+// ExpressLane records the sequencer's express lane rounds and who controls them
+func (state *ArbosState) ExpressLane() *expresslane.ExpressLane {
+	return state.expressLane
+}
+
+// Bits of the bitmap EnabledFeatures returns
+const (
+	FeatureStylus uint64 = 1 << iota
+	FeatureTimeboost
+	FeatureBlobPosting
+	FeatureCustomFeeToken
+)
+
+// ArbOS accepts batches posted as EIP-4844 blobs from version 20 on
+const blobBatchesArbosVersion = 20
+
+// EnabledFeatures returns a bitmap of the protocol features enabled on the chain
+func (state *ArbosState) EnabledFeatures() (uint64, error) {
+	var features uint64
+	if state.arbosVersion >= params.ArbosVersion_Stylus {
+		features |= FeatureStylus
+	}
+	roundDuration, _, err := state.expressLane.RoundTiming()
+	if err != nil {
+		return 0, err
+	}
+	if roundDuration != 0 {
+		features |= FeatureTimeboost
+	}
+	if state.arbosVersion >= blobBatchesArbosVersion {
+		features |= FeatureBlobPosting
+	}
+	customFeeToken, err := state.l1PricingState.HasParentTokenExchangeRate()
+	if err != nil {
+		return 0, err
+	}
+	if customFeeToken {
+		features |= FeatureCustomFeeToken
+	}
+	return features, nil
+}
+
+// Paymasters maps target contracts to the paymasters the chain owner registered to pay for transactions to them
+func (state *ArbosState) Paymasters() *paymasters.Paymasters {
+	return state.paymasters
+}
+
 func (state *ArbosState) Blockhashes() *blockhash.Blockhashes {
 	return state.blockhashes
 }
@@ -0,0 +1,72 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MaxFeeHolidayDuration bounds how long, in seconds, a fee holiday may last.
+const MaxFeeHolidayDuration uint64 = 7 * 24 * 60 * 60
+
+var ErrFeeHolidayInProgress = errors.New("fee holiday already in progress")
+
+// FeeHolidayEnd returns when the chain owner's fee holiday ends, or 0 if there isn't one.
+// During a fee holiday the L2 base fee is zero, so transactions pay neither L2 gas nor L1 data fees.
+func (state *ArbosState) FeeHolidayEnd() (uint64, error) {
+	return state.feeHolidayEnd.Get()
+}
+
+// StartFeeHoliday drops the base fee to zero until duration seconds after currentTime, returning when it ends.
+func (state *ArbosState) StartFeeHoliday(currentTime uint64, duration uint64) (uint64, error) {
+	if duration == 0 || duration > MaxFeeHolidayDuration {
+		return 0, fmt.Errorf("fee holiday of %v seconds isn't between 1 and %v", duration, MaxFeeHolidayDuration)
+	}
+	end, err := state.FeeHolidayEnd()
+	if err != nil {
+		return 0, err
+	}
+	if end != 0 {
+		return 0, ErrFeeHolidayInProgress
+	}
+	end = currentTime + duration
+	if err := state.feeHolidayEnd.Set(end); err != nil {
+		return 0, err
+	}
+	return end, state.l2PricingState.SetBaseFeeWei(common.Big0)
+}
+
+// EndFeeHoliday ends a fee holiday early, returning whether there was one.
+// The base fee restarts at the minimum, and the pricing model takes over from the next block.
+func (state *ArbosState) EndFeeHoliday() (bool, error) {
+	end, err := state.FeeHolidayEnd()
+	if err != nil || end == 0 {
+		return false, err
+	}
+	if err := state.feeHolidayEnd.Clear(); err != nil {
+		return false, err
+	}
+	minBaseFee, err := state.l2PricingState.MinBaseFeeWei()
+	if err != nil {
+		return false, err
+	}
+	return true, state.l2PricingState.SetBaseFeeWei(minBaseFee)
+}
+
+// ApplyFeeHoliday runs after the pricing model at the start of each block. It keeps the base fee at zero
+// during a fee holiday, or ends the holiday once it's due, leaving the pricing model's base fee in place.
+// It returns whether the holiday ended.
+func (state *ArbosState) ApplyFeeHoliday(currentTime uint64) (bool, error) {
+	end, err := state.FeeHolidayEnd()
+	if err != nil || end == 0 {
+		return false, err
+	}
+	if currentTime < end {
+		return false, state.l2PricingState.SetBaseFeeWei(common.Big0)
+	}
+	return true, state.feeHolidayEnd.Clear()
+}
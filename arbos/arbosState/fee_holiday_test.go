@@ -0,0 +1,71 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFeeHoliday(t *testing.T) {
+	state, statedb := NewArbosMemoryBackedArbOSState()
+	l2p := state.L2PricingState()
+	baseFee := func() uint64 {
+		t.Helper()
+		fee, err := l2p.BaseFeeWei()
+		Require(t, err)
+		return fee.Uint64()
+	}
+	minBaseFee, err := l2p.MinBaseFeeWei()
+	Require(t, err)
+
+	if _, err := state.StartFeeHoliday(100, MaxFeeHolidayDuration+1); err == nil {
+		Fail(t, "started a fee holiday longer than the maximum")
+	}
+	if _, err := state.StartFeeHoliday(100, 0); err == nil {
+		Fail(t, "started an empty fee holiday")
+	}
+	end, err := state.StartFeeHoliday(100, 50)
+	Require(t, err)
+	if end != 150 || baseFee() != 0 {
+		Fail(t, "fee holiday didn't start", end, baseFee())
+	}
+	if _, err := state.StartFeeHoliday(120, 50); !errors.Is(err, ErrFeeHolidayInProgress) {
+		Fail(t, "extended a fee holiday in progress", err)
+	}
+
+	// The pricing model raises the base fee each block, but the holiday keeps it at zero until the end
+	l2p.UpdatePricingModel(nil, 1, false)
+	ended, err := state.ApplyFeeHoliday(149)
+	Require(t, err)
+	if ended || baseFee() != 0 {
+		Fail(t, "fee holiday ended early", baseFee())
+	}
+	l2p.UpdatePricingModel(nil, 1, false)
+	ended, err = state.ApplyFeeHoliday(150)
+	Require(t, err)
+	if !ended || baseFee() != minBaseFee.Uint64() {
+		Fail(t, "fee holiday didn't end", baseFee())
+	}
+	end, err = state.FeeHolidayEnd()
+	Require(t, err)
+	if end != 0 {
+		Fail(t, "ended fee holiday wasn't cleared", end)
+	}
+
+	// The owner may end a holiday early
+	_, err = state.StartFeeHoliday(200, MaxFeeHolidayDuration)
+	Require(t, err)
+	found, err := state.EndFeeHoliday()
+	Require(t, err)
+	if !found || baseFee() != minBaseFee.Uint64() {
+		Fail(t, "fee holiday wasn't ended", baseFee())
+	}
+	found, err = state.EndFeeHoliday()
+	Require(t, err)
+	if found {
+		Fail(t, "ended a fee holiday twice")
+	}
+	Require(t, CheckStorageLayout(statedb))
+}
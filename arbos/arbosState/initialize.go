@@ -142,7 +142,7 @@ func InitializeArbosInDatabase(db ethdb.Database, cacheConfig *core.CacheConfig,
 	if err != nil {
 		return common.Hash{}, err
 	}
-	err = initializeRetryables(statedb, arbosState.RetryableState(), retryableReader, timestamp)
+	err = initializeRetryables(statedb, arbosState.RetryableState(), retryableReader, timestamp, arbosState.ArbOSVersion() >= ArbosVersion_40)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -193,7 +193,7 @@ func InitializeArbosInDatabase(db ethdb.Database, cacheConfig *core.CacheConfig,
 	return commit()
 }
 
-func initializeRetryables(statedb *state.StateDB, rs *retryables.RetryableState, initData statetransfer.RetryableDataReader, currentTimestamp uint64) error {
+func initializeRetryables(statedb *state.StateDB, rs *retryables.RetryableState, initData statetransfer.RetryableDataReader, currentTimestamp uint64, beneficiaryIndexed bool) error {
 	var retryablesList []*statetransfer.InitializationDataForRetryable
 	for initData.More() {
 		r, err := initData.GetNext()
@@ -221,7 +221,7 @@ func initializeRetryables(statedb *state.StateDB, rs *retryables.RetryableState,
 			to = &addr
 		}
 		statedb.AddBalance(retryables.RetryableEscrowAddress(r.Id), uint256.MustFromBig(r.Callvalue), tracing.BalanceChangeUnspecified)
-		_, err := rs.CreateRetryable(r.Id, r.Timeout, r.From, to, r.Callvalue, r.Beneficiary, r.Calldata)
+		_, err := rs.CreateRetryable(r.Id, r.Timeout, r.From, to, r.Callvalue, r.Beneficiary, r.Calldata, beneficiaryIndexed)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,165 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+type LayoutKind string
+
+const (
+	LayoutOffset   LayoutKind = "offset"
+	LayoutSubspace LayoutKind = "subspace"
+)
+
+// layoutItem declares a top-level offset or subspace of ArbOS's storage.
+// New subsystems must be added here, or CheckStorageLayout will reject the slots they write.
+type layoutItem struct {
+	name     string
+	kind     LayoutKind
+	offset   Offset
+	subspace SubspaceID
+	since    uint64 // the ArbOS version that started using it
+}
+
+var layoutItems = []layoutItem{
+	{name: "version", kind: LayoutOffset, offset: versionOffset, since: 1},
+	{name: "upgradeVersion", kind: LayoutOffset, offset: upgradeVersionOffset, since: 1},
+	{name: "upgradeTimestamp", kind: LayoutOffset, offset: upgradeTimestampOffset, since: 1},
+	{name: "networkFeeAccount", kind: LayoutOffset, offset: networkFeeAccountOffset, since: 1},
+	{name: "chainId", kind: LayoutOffset, offset: chainIdOffset, since: 1},
+	{name: "genesisBlockNum", kind: LayoutOffset, offset: genesisBlockNumOffset, since: 1},
+	{name: "infraFeeAccount", kind: LayoutOffset, offset: infraFeeAccountOffset, since: 1},
+	{name: "brotliCompressionLevel", kind: LayoutOffset, offset: brotliCompressionLevelOffset, since: 1},
+	{name: "sequencerFrozenSince", kind: LayoutOffset, offset: sequencerFrozenSinceOffset, since: ArbosVersion_40},
+	{name: "maxTxSize", kind: LayoutOffset, offset: maxTxSizeOffset, since: ArbosVersion_40},
+	{name: "maxCalldataSize", kind: LayoutOffset, offset: maxCalldataSizeOffset, since: ArbosVersion_40},
+	{name: "timeWarp", kind: LayoutOffset, offset: timeWarpOffset, since: ArbosVersion_40},
+	{name: "l1BlockNumberWarp", kind: LayoutOffset, offset: l1BlockNumberWarpOffset, since: ArbosVersion_40},
+	{name: "maxLogsPerTx", kind: LayoutOffset, offset: maxLogsPerTxOffset, since: ArbosVersion_40},
+	{name: "maxReturnDataSize", kind: LayoutOffset, offset: maxReturnDataSizeOffset, since: ArbosVersion_40},
+	{name: "feeHolidayEnd", kind: LayoutOffset, offset: feeHolidayEndOffset, since: ArbosVersion_40},
+	{name: "l1Pricing", kind: LayoutSubspace, subspace: l1PricingSubspace, since: 1},
+	{name: "l2Pricing", kind: LayoutSubspace, subspace: l2PricingSubspace, since: 1},
+	{name: "retryables", kind: LayoutSubspace, subspace: retryablesSubspace, since: 1},
+	{name: "addressTable", kind: LayoutSubspace, subspace: addressTableSubspace, since: 1},
+	{name: "chainOwners", kind: LayoutSubspace, subspace: chainOwnerSubspace, since: 1},
+	{name: "sendMerkle", kind: LayoutSubspace, subspace: sendMerkleSubspace, since: 1},
+	{name: "blockhashes", kind: LayoutSubspace, subspace: blockhashesSubspace, since: 1},
+	{name: "chainConfig", kind: LayoutSubspace, subspace: chainConfigSubspace, since: 1},
+	{name: "programs", kind: LayoutSubspace, subspace: programsSubspace, since: params.ArbosVersion_Stylus},
+	{name: "tokenRegistry", kind: LayoutSubspace, subspace: tokenRegistrySubspace, since: ArbosVersion_40},
+	{name: "feedSigners", kind: LayoutSubspace, subspace: feedSignersSubspace, since: ArbosVersion_40},
+	{name: "chainMetadata", kind: LayoutSubspace, subspace: chainMetadataSubspace, since: ArbosVersion_40},
+	{name: "cancelledSends", kind: LayoutSubspace, subspace: cancelledSendsSubspace, since: ArbosVersion_40},
+	{name: "paymasters", kind: LayoutSubspace, subspace: paymastersSubspace, since: ArbosVersion_40},
+	{name: "disabledMethods", kind: LayoutSubspace, subspace: disabledMethodsSubspace, since: ArbosVersion_40},
+	{name: "feeReports", kind: LayoutSubspace, subspace: feeReportsSubspace, since: ArbosVersion_40},
+	{name: "expressLane", kind: LayoutSubspace, subspace: expressLaneSubspace, since: ArbosVersion_40},
+	{name: "parameterSunsets", kind: LayoutSubspace, subspace: parameterSunsetsSubspace, since: ArbosVersion_40},
+}
+
+// LayoutEntry describes where a top-level offset or subspace lives in the ArbOS account's storage.
+type LayoutEntry struct {
+	Name string     `json:"name"`
+	Kind LayoutKind `json:"kind"`
+	// The offset or subspace ID within the root storage space
+	Key hexutil.Bytes `json:"key"`
+	// For offsets the slot holding the value, and for subspaces the slot holding offset 0 within it
+	Slot  common.Hash `json:"slot"`
+	Since uint64      `json:"since"`
+}
+
+var ErrStorageLayout = errors.New("ArbOS storage layout violation")
+
+// StorageLayout returns the top-level storage layout used by the given ArbOS version.
+func StorageLayout(arbosVersion uint64) []LayoutEntry {
+	root := storage.NewMemoryBacked(burn.NewSystemBurner(nil, true))
+	var entries []LayoutEntry
+	for _, item := range layoutItems {
+		if item.since > arbosVersion {
+			continue
+		}
+		entry := LayoutEntry{Name: item.name, Kind: item.kind, Since: item.since}
+		switch item.kind {
+		case LayoutOffset:
+			entry.Key = util.UintToHash(uint64(item.offset)).Bytes()
+			entry.Slot = root.GetStorageSlot(util.UintToHash(uint64(item.offset)))
+		case LayoutSubspace:
+			entry.Key = common.CopyBytes(item.subspace)
+			entry.Slot = root.OpenSubStorage(item.subspace).GetStorageSlot(common.Hash{})
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// validateLayout checks the declared layout for names, offsets, subspaces or slots used twice.
+func validateLayout() error {
+	names := make(map[string]bool)
+	keys := make(map[string]string)
+	slots := make(map[common.Hash]string)
+	for _, entry := range StorageLayout(^uint64(0)) {
+		if names[entry.Name] {
+			return fmt.Errorf("%w: %v is declared twice", ErrStorageLayout, entry.Name)
+		}
+		names[entry.Name] = true
+		key := string(entry.Kind) + ":" + entry.Key.String()
+		if other, ok := keys[key]; ok {
+			return fmt.Errorf("%w: %v and %v share %v %v", ErrStorageLayout, other, entry.Name, entry.Kind, entry.Key)
+		}
+		keys[key] = entry.Name
+		if other, ok := slots[entry.Slot]; ok {
+			return fmt.Errorf("%w: %v and %v share slot %v", ErrStorageLayout, other, entry.Name, entry.Slot)
+		}
+		slots[entry.Slot] = entry.Name
+	}
+	return nil
+}
+
+// CheckStorageLayout checks a state's ArbOS storage against the declared layout. It fails if the layout
+// declares something twice, if an undeclared top-level offset is in use, or if an offset a later ArbOS
+// version introduces is already in use.
+func CheckStorageLayout(stateDB vm.StateDB) error {
+	if err := validateLayout(); err != nil {
+		return err
+	}
+	root := storage.NewGeth(stateDB, burn.NewSystemBurner(nil, true))
+	arbosVersion := root.GetFree(util.UintToHash(uint64(versionOffset))).Big().Uint64()
+	if arbosVersion == 0 {
+		return ErrUninitializedArbOS
+	}
+
+	declared := make(map[uint64]layoutItem)
+	for _, item := range layoutItems {
+		if item.kind == LayoutOffset {
+			declared[uint64(item.offset)] = item
+		}
+	}
+	// Offsets are mapped in pages of 256 slots, so the first page holds every top-level offset in use
+	for offset := uint64(0); offset < 256; offset++ {
+		if root.GetFree(util.UintToHash(offset)) == (common.Hash{}) {
+			continue
+		}
+		item, ok := declared[offset]
+		if !ok {
+			return fmt.Errorf("%w: undeclared offset %v is in use", ErrStorageLayout, offset)
+		}
+		if item.since > arbosVersion {
+			return fmt.Errorf("%w: %v is in use before ArbOS version %v", ErrStorageLayout, item.name, item.since)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+func TestStorageLayoutIsValid(t *testing.T) {
+	Require(t, validateLayout())
+
+	if len(StorageLayout(1)) >= len(StorageLayout(ArbosVersion_40)) {
+		Fail(t, "ArbOS version 40 should use more storage than version 1")
+	}
+	for _, entry := range StorageLayout(ArbosVersion_40) {
+		if entry.Name == "tokenRegistry" {
+			return
+		}
+	}
+	Fail(t, "token registry missing from the version 40 layout")
+}
+
+func TestCheckStorageLayout(t *testing.T) {
+	state, statedb := NewArbosMemoryBackedArbOSState()
+	Require(t, CheckStorageLayout(statedb))
+
+	if state.ArbOSVersion() < ArbosVersion_40 {
+		Require(t, state.SetSequencerFrozenSince(1))
+		if err := CheckStorageLayout(statedb); !errors.Is(err, ErrStorageLayout) {
+			Fail(t, "storage introduced by a later ArbOS version wasn't detected", err)
+		}
+		Require(t, state.SetSequencerFrozenSince(0))
+	}
+
+	// A subsystem writing to an offset nobody declared
+	root := storage.NewGeth(statedb, state.Burner)
+	Require(t, root.Set(util.UintToHash(200), common.Hash{1}))
+	if err := CheckStorageLayout(statedb); !errors.Is(err, ErrStorageLayout) {
+		Fail(t, "undeclared offset wasn't detected", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+// Parameter identifies a chain parameter the owner may change with a sunset,
+// after which ArbOS reverts it to the value it had before.
+type Parameter uint64
+
+const (
+	ParameterMaxTxGasLimit Parameter = iota + 1
+	ParameterSpeedLimit
+	ParameterL2GasPricingInertia
+	ParameterL2GasBacklogTolerance
+	ParameterMaxTxSize
+	ParameterMaxCalldataSize
+	ParameterMaxLogsPerTx
+	ParameterMaxReturnDataSize
+	numParameters = iota
+)
+
+var ErrUnknownParameter = errors.New("unknown parameter")
+
+// ParameterSunset is a pending revert of a parameter to its value before the owner changed it
+type ParameterSunset struct {
+	Parameter     Parameter
+	RevertAt      uint64
+	PreviousValue uint64
+}
+
+// The number of pending sunsets is stored at offset 0 of the subspace, so blocks without any only read one slot.
+// Each parameter's sunset is stored in a subspace keyed by the parameter: the revert timestamp, or 0 if none is
+// pending, then the previous value.
+const (
+	parameterSunsetCountOffset uint64 = 0
+	sunsetRevertAtOffset       uint64 = 0
+	sunsetPreviousValueOffset  uint64 = 1
+)
+
+func (state *ArbosState) parameterSunsets() *storage.Storage {
+	return state.backingStorage.OpenSubStorage(parameterSunsetsSubspace)
+}
+
+func (state *ArbosState) parameterSunset(parameter Parameter) *storage.Storage {
+	return state.parameterSunsets().OpenSubStorage([]byte{byte(parameter)})
+}
+
+func (state *ArbosState) parameterAccessors(parameter Parameter) (func() (uint64, error), func(uint64) error, error) {
+	l2p := state.l2PricingState
+	switch parameter {
+	case ParameterMaxTxGasLimit:
+		return l2p.PerBlockGasLimit, l2p.SetMaxPerBlockGasLimit, nil
+	case ParameterSpeedLimit:
+		return l2p.SpeedLimitPerSecond, l2p.SetSpeedLimitPerSecond, nil
+	case ParameterL2GasPricingInertia:
+		return l2p.PricingInertia, l2p.SetPricingInertia, nil
+	case ParameterL2GasBacklogTolerance:
+		return l2p.BacklogTolerance, l2p.SetBacklogTolerance, nil
+	case ParameterMaxTxSize:
+		return state.MaxTxSize, state.SetMaxTxSize, nil
+	case ParameterMaxCalldataSize:
+		return state.MaxCalldataSize, state.SetMaxCalldataSize, nil
+	case ParameterMaxLogsPerTx:
+		return state.MaxLogsPerTx, state.SetMaxLogsPerTx, nil
+	case ParameterMaxReturnDataSize:
+		return state.MaxReturnDataSize, state.SetMaxReturnDataSize, nil
+	}
+	return nil, nil, fmt.Errorf("%w %v", ErrUnknownParameter, parameter)
+}
+
+// ParameterSunset returns the pending sunset of a parameter, or nil if there isn't one.
+func (state *ArbosState) ParameterSunset(parameter Parameter) (*ParameterSunset, error) {
+	if _, _, err := state.parameterAccessors(parameter); err != nil {
+		return nil, err
+	}
+	sto := state.parameterSunset(parameter)
+	revertAt, err := sto.GetUint64ByUint64(sunsetRevertAtOffset)
+	if err != nil || revertAt == 0 {
+		return nil, err
+	}
+	previousValue, err := sto.GetUint64ByUint64(sunsetPreviousValueOffset)
+	if err != nil {
+		return nil, err
+	}
+	return &ParameterSunset{parameter, revertAt, previousValue}, nil
+}
+
+// ParameterSunsets returns every pending sunset, ordered by parameter.
+func (state *ArbosState) ParameterSunsets() ([]ParameterSunset, error) {
+	count, err := state.parameterSunsets().GetUint64ByUint64(parameterSunsetCountOffset)
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	sunsets := make([]ParameterSunset, 0, count)
+	for parameter := Parameter(1); parameter < numParameters; parameter++ {
+		sunset, err := state.ParameterSunset(parameter)
+		if err != nil {
+			return nil, err
+		}
+		if sunset != nil {
+			sunsets = append(sunsets, *sunset)
+		}
+	}
+	return sunsets, nil
+}
+
+// SetParameterWithSunset sets a parameter, and has ArbOS revert it in the first block at or after revertAt.
+// If the parameter already has a sunset pending, it keeps reverting to the value from before that one.
+func (state *ArbosState) SetParameterWithSunset(parameter Parameter, value uint64, revertAt uint64) error {
+	get, set, err := state.parameterAccessors(parameter)
+	if err != nil {
+		return err
+	}
+	if revertAt == 0 {
+		return errors.New("sunset timestamp must be nonzero")
+	}
+	pending, err := state.ParameterSunset(parameter)
+	if err != nil {
+		return err
+	}
+	sto := state.parameterSunset(parameter)
+	if pending == nil {
+		previousValue, err := get()
+		if err != nil {
+			return err
+		}
+		if err := sto.SetUint64ByUint64(sunsetPreviousValueOffset, previousValue); err != nil {
+			return err
+		}
+		if _, err := state.parameterSunsets().OpenStorageBackedUint64(parameterSunsetCountOffset).Increment(); err != nil {
+			return err
+		}
+	}
+	if err := sto.SetUint64ByUint64(sunsetRevertAtOffset, revertAt); err != nil {
+		return err
+	}
+	return set(value)
+}
+
+// CancelParameterSunset keeps a parameter at its current value, returning whether a sunset was pending.
+func (state *ArbosState) CancelParameterSunset(parameter Parameter) (bool, error) {
+	pending, err := state.ParameterSunset(parameter)
+	if err != nil || pending == nil {
+		return false, err
+	}
+	return true, state.clearParameterSunset(parameter)
+}
+
+func (state *ArbosState) clearParameterSunset(parameter Parameter) error {
+	sto := state.parameterSunset(parameter)
+	if err := sto.ClearByUint64(sunsetRevertAtOffset); err != nil {
+		return err
+	}
+	if err := sto.ClearByUint64(sunsetPreviousValueOffset); err != nil {
+		return err
+	}
+	_, err := state.parameterSunsets().OpenStorageBackedUint64(parameterSunsetCountOffset).Decrement()
+	return err
+}
+
+// ApplyParameterSunsets reverts the parameters whose sunsets are due by currentTime.
+// A previous value the parameter's bounds no longer allow is dropped rather than halting the chain.
+func (state *ArbosState) ApplyParameterSunsets(currentTime uint64) error {
+	sunsets, err := state.ParameterSunsets()
+	if err != nil {
+		return err
+	}
+	for _, sunset := range sunsets {
+		if sunset.RevertAt > currentTime {
+			continue
+		}
+		_, set, err := state.parameterAccessors(sunset.Parameter)
+		if err != nil {
+			return err
+		}
+		if err := set(sunset.PreviousValue); err != nil {
+			log.Warn("failed to revert parameter at its sunset", "parameter", sunset.Parameter, "value", sunset.PreviousValue, "err", err)
+		}
+		if err := state.clearParameterSunset(sunset.Parameter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParameterSunsets(t *testing.T) {
+	state, statedb := NewArbosMemoryBackedArbOSState()
+	l2p := state.L2PricingState()
+	originalGasLimit, err := l2p.PerBlockGasLimit()
+	Require(t, err)
+
+	if err := state.SetParameterWithSunset(numParameters, 1, 100); !errors.Is(err, ErrUnknownParameter) {
+		Fail(t, "set an unknown parameter", err)
+	}
+	Require(t, state.SetParameterWithSunset(ParameterMaxTxGasLimit, originalGasLimit*2, 100))
+	// Changing it again keeps the original value to revert to
+	Require(t, state.SetParameterWithSunset(ParameterMaxTxGasLimit, originalGasLimit*3, 200))
+	Require(t, state.SetParameterWithSunset(ParameterMaxLogsPerTx, 1000, 100))
+	Require(t, state.SetParameterWithSunset(ParameterSpeedLimit, 1, 100))
+	found, err := state.CancelParameterSunset(ParameterSpeedLimit)
+	Require(t, err)
+	if !found {
+		Fail(t, "pending sunset wasn't cancelled")
+	}
+
+	sunsets, err := state.ParameterSunsets()
+	Require(t, err)
+	expected := []ParameterSunset{{ParameterMaxTxGasLimit, 200, originalGasLimit}, {ParameterMaxLogsPerTx, 100, 0}}
+	if len(sunsets) != len(expected) || sunsets[0] != expected[0] || sunsets[1] != expected[1] {
+		Fail(t, "unexpected sunsets", sunsets)
+	}
+
+	Require(t, state.ApplyParameterSunsets(150))
+	maxLogs, err := state.MaxLogsPerTx()
+	Require(t, err)
+	gasLimit, err := l2p.PerBlockGasLimit()
+	Require(t, err)
+	if maxLogs != 0 || gasLimit != originalGasLimit*3 {
+		Fail(t, "only the due sunset should have reverted", maxLogs, gasLimit)
+	}
+	Require(t, state.ApplyParameterSunsets(200))
+	gasLimit, err = l2p.PerBlockGasLimit()
+	Require(t, err)
+	if gasLimit != originalGasLimit {
+		Fail(t, "gas limit wasn't reverted", gasLimit)
+	}
+	speedLimit, err := l2p.SpeedLimitPerSecond()
+	Require(t, err)
+	if speedLimit != 1 {
+		Fail(t, "cancelled sunset was reverted", speedLimit)
+	}
+	sunsets, err = state.ParameterSunsets()
+	Require(t, err)
+	if len(sunsets) != 0 {
+		Fail(t, "applied sunsets weren't cleared", sunsets)
+	}
+	Require(t, CheckStorageLayout(statedb))
+}
@@ -0,0 +1,133 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbostypes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/offchainlabs/nitro/util/deterministic"
+)
+
+type MessageEncoding uint8
+
+const (
+	// MessageEncodingRLP is the original encoding, used in the database and for message hashes.
+	MessageEncodingRLP MessageEncoding = iota
+	// MessageEncodingDeterministicV1 is the canonical encoding from util/deterministic,
+	// prefixed with its version byte.
+	MessageEncodingDeterministicV1
+)
+
+// RLP encodings of a MessageWithMetadata are lists, which always start with a byte
+// of at least rlpListPrefix, so version bytes must stay below it to be unambiguous.
+const rlpListPrefix = 0xc0
+
+func EncodeMessageWithMetadata(msg *MessageWithMetadata, encoding MessageEncoding) ([]byte, error) {
+	switch encoding {
+	case MessageEncodingRLP:
+		return rlp.EncodeToBytes(msg)
+	case MessageEncodingDeterministicV1:
+		w := deterministic.NewWriter(128)
+		w.Uint8(uint8(MessageEncodingDeterministicV1))
+		if err := msg.WriteDeterministic(w); err != nil {
+			return nil, err
+		}
+		return w.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown message encoding %v", encoding)
+	}
+}
+
+// DecodeMessageWithMetadata decodes a message in any supported encoding, detected from its first byte.
+func DecodeMessageWithMetadata(data []byte) (*MessageWithMetadata, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty message encoding")
+	}
+	if data[0] >= rlpListPrefix {
+		var msg MessageWithMetadata
+		if err := rlp.DecodeBytes(data, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+	}
+	switch MessageEncoding(data[0]) {
+	case MessageEncodingDeterministicV1:
+		r := deterministic.NewReader(data[1:])
+		msg := ReadDeterministicMessageWithMetadata(r)
+		if err := r.Finish(); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("unknown message encoding version %v", data[0])
+	}
+}
+
+// WriteDeterministic writes the message without a version byte, for embedding in other encodings.
+func (m *MessageWithMetadata) WriteDeterministic(w *deterministic.Writer) error {
+	if m.Message == nil || m.Message.Header == nil {
+		return errors.New("cannot encode message without header")
+	}
+	header := m.Message.Header
+	w.Uint8(header.Kind)
+	w.Fixed(header.Poster[:])
+	w.Uint64(header.BlockNumber)
+	w.Uint64(header.Timestamp)
+	w.Bool(header.RequestId != nil)
+	if header.RequestId != nil {
+		w.Fixed(header.RequestId[:])
+	}
+	w.Bool(header.L1BaseFee != nil)
+	if header.L1BaseFee != nil {
+		if err := w.BigInt(header.L1BaseFee); err != nil {
+			return err
+		}
+	}
+	if err := w.VarBytes(m.Message.L2msg); err != nil {
+		return err
+	}
+	w.Bool(m.Message.BatchGasCost != nil)
+	if m.Message.BatchGasCost != nil {
+		w.Uint64(*m.Message.BatchGasCost)
+	}
+	w.Uint64(m.DelayedMessagesRead)
+	return nil
+}
+
+// ReadDeterministicMessageWithMetadata reads a message written by WriteDeterministic.
+// Errors are reported through the reader.
+func ReadDeterministicMessageWithMetadata(r *deterministic.Reader) *MessageWithMetadata {
+	header := &L1IncomingMessageHeader{}
+	header.Kind = r.Uint8()
+	r.Fixed(header.Poster[:])
+	header.BlockNumber = r.Uint64()
+	header.Timestamp = r.Uint64()
+	if r.Bool() {
+		var requestId common.Hash
+		r.Fixed(requestId[:])
+		header.RequestId = &requestId
+	}
+	if r.Bool() {
+		header.L1BaseFee = r.BigInt()
+	}
+	msg := &L1IncomingMessage{
+		Header: header,
+		L2msg:  r.VarBytes(),
+	}
+	if r.Bool() {
+		batchGasCost := r.Uint64()
+		msg.BatchGasCost = &batchGasCost
+	}
+	delayedMessagesRead := r.Uint64()
+	if r.Err() != nil {
+		return nil
+	}
+	return &MessageWithMetadata{
+		Message:             msg,
+		DelayedMessagesRead: delayedMessagesRead,
+	}
+}
@@ -16,12 +16,14 @@ import (
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/arbmath"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/arbitrum_types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
@@ -37,6 +39,9 @@ var L2ToL1TransactionEventID common.Hash
 var L2ToL1TxEventID common.Hash
 var EmitReedeemScheduledEvent func(*vm.EVM, uint64, uint64, [32]byte, [32]byte, common.Address, *big.Int, *big.Int) error
 var EmitTicketCreatedEvent func(*vm.EVM, [32]byte) error
+var EmitFeesCollectedEvent func(*vm.EVM, uint64, uint64, common.Address, *big.Int, common.Address, *big.Int, *big.Int, *big.Int) error
+var EmitFeeHolidayStartedEvent func(*vm.EVM, uint64, uint64) error
+var EmitFeeHolidayEndedEvent func(*vm.EVM, uint64) error
 
 // A helper struct that implements String() by marshalling to JSON.
 // This is useful for logging because it's lazy, so if the log level is too high to print the transaction,
@@ -183,10 +188,20 @@ func ProduceBlockAdvanced(
 
 	poster := l1Header.Poster
 
+	// On debug chains, ArbDebug may have moved the chain ahead of its parent
+	timeWarp, err := state.TimeWarp()
+	if err != nil {
+		return nil, nil, err
+	}
+	l1BlockNumberWarp, err := state.L1BlockNumberWarp()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	l1Info := &L1Info{
 		poster:        poster,
-		l1BlockNumber: l1Header.BlockNumber,
-		l1Timestamp:   l1Header.Timestamp,
+		l1BlockNumber: arbmath.SaturatingUAdd(l1Header.BlockNumber, l1BlockNumberWarp),
+		l1Timestamp:   arbmath.SaturatingUAdd(l1Header.Timestamp, timeWarp),
 	}
 
 	header := createNewHeader(lastBlockHeader, l1Info, state, chainConfig)
@@ -263,6 +278,12 @@ func ProduceBlockAdvanced(
 				return nil, nil, err
 			}
 
+			if isUserTx && state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+				if err = state.CheckTxSize(tx); err != nil {
+					return nil, nil, err
+				}
+			}
+
 			if err = hooks.PreTxFilter(chainConfig, header, statedb, state, tx, options, sender, l1Info); err != nil {
 				return nil, nil, err
 			}
@@ -309,28 +330,50 @@ func ProduceBlockAdvanced(
 			snap := statedb.Snapshot()
 			statedb.SetTxContext(tx.Hash(), len(receipts)) // the number of successful state transitions
 
-			gasPool := gethGas
-			receipt, result, err := core.ApplyTransactionWithResultFilter(
-				chainConfig,
-				chainContext,
-				&header.Coinbase,
-				&gasPool,
-				statedb,
-				header,
-				tx,
-				&header.GasUsed,
-				vm.Config{},
-				runMode,
-				func(result *core.ExecutionResult) error {
-					return hooks.PostTxFilter(header, state, tx, sender, dataGas, result)
-				},
-			)
+			applyTx := func(filter func(*core.ExecutionResult) error) (*types.Receipt, *core.ExecutionResult, error) {
+				gasPool := gethGas
+				return core.ApplyTransactionWithResultFilter(
+					chainConfig,
+					chainContext,
+					&header.Coinbase,
+					&gasPool,
+					statedb,
+					header,
+					tx,
+					&header.GasUsed,
+					vm.Config{},
+					runMode,
+					filter,
+				)
+			}
+			receipt, result, err := applyTx(func(result *core.ExecutionResult) error {
+				return hooks.PostTxFilter(header, state, tx, sender, dataGas, result)
+			})
 			if err != nil {
 				// Ignore this transaction if it's invalid under the state transition function
 				statedb.RevertToSnapshot(snap)
 				return nil, nil, err
 			}
 
+			// Signed transactions may not emit too many logs or return too much data.
+			// Those that do are applied again as failing, so they're included and still pay for their gas.
+			if isUserTx && tx.Type() < types.ArbitrumDepositTxType && state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+				if limitErr := state.CheckTxResult(len(receipt.Logs), len(result.ReturnData)); limitErr != nil {
+					statedb.RevertToSnapshot(snap)
+					header.GasUsed = preTxHeaderGasUsed
+					ApplyNextTxAsFailing(statedb, result.UsedGas)
+					receipt, result, err = applyTx(func(result *core.ExecutionResult) error {
+						result.Err = limitErr
+						result.ReturnData = txLimitRevert(limitErr)
+						return nil
+					})
+					if err != nil {
+						statedb.RevertToSnapshot(snap)
+						return nil, nil, err
+					}
+				}
+			}
+
 			// Additional post-transaction validity check
 			if err = extraPostTxFilter(chainConfig, header, statedb, state, tx, options, sender, l1Info, result); err != nil {
 				statedb.RevertToSnapshot(snap)
@@ -489,6 +532,16 @@ func ProduceBlockAdvanced(
 	return block, receipts, nil
 }
 
+// txLimitRevert is what a tx that broke a result limit reverts with, an Error(string) like Solidity's require
+func txLimitRevert(limitErr error) []byte {
+	stringType, _ := abi.NewType("string", "", nil)
+	message, err := abi.Arguments{{Type: stringType}}.Pack(limitErr.Error())
+	if err != nil {
+		panic(err)
+	}
+	return append(crypto.Keccak256([]byte("Error(string)"))[:4], message...)
+}
+
 // Also sets header.Root
 func FinalizeBlock(header *types.Header, txs types.Transactions, statedb *state.StateDB, chainConfig *params.ChainConfig) {
 	if header != nil {
@@ -0,0 +1,107 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package chainmetadata stores the chain owner's description of the chain, so that wallets and explorers
+// can configure themselves by reading the chain rather than a centralized registry.
+package chainmetadata
+
+import (
+	"fmt"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+// MaxFieldLength bounds each metadata string, so a logo URI should point to the image rather than embed it.
+const MaxFieldLength = 1024
+
+type ChainMetadata struct {
+	name                storage.StorageBackedBytes
+	logoURI             storage.StorageBackedBytes
+	nativeTokenName     storage.StorageBackedBytes
+	nativeTokenSymbol   storage.StorageBackedBytes
+	nativeTokenDecimals storage.StorageBackedUint64
+}
+
+var (
+	nameKey              = []byte{0}
+	logoURIKey           = []byte{1}
+	nativeTokenNameKey   = []byte{2}
+	nativeTokenSymbolKey = []byte{3}
+)
+
+const (
+	nativeTokenDecimalsOffset uint64 = iota
+)
+
+// NativeToken describes the token the chain's gas is paid in.
+type NativeToken struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+func Open(sto *storage.Storage) *ChainMetadata {
+	return &ChainMetadata{
+		name:                sto.OpenStorageBackedBytes(nameKey),
+		logoURI:             sto.OpenStorageBackedBytes(logoURIKey),
+		nativeTokenName:     sto.OpenStorageBackedBytes(nativeTokenNameKey),
+		nativeTokenSymbol:   sto.OpenStorageBackedBytes(nativeTokenSymbolKey),
+		nativeTokenDecimals: sto.OpenStorageBackedUint64(nativeTokenDecimalsOffset),
+	}
+}
+
+func get(sbb *storage.StorageBackedBytes) (string, error) {
+	value, err := sbb.Get()
+	return string(value), err
+}
+
+func set(sbb *storage.StorageBackedBytes, field, value string) error {
+	if len(value) > MaxFieldLength {
+		return fmt.Errorf("chain %v is %v bytes long, but the limit is %v", field, len(value), MaxFieldLength)
+	}
+	return sbb.Set([]byte(value))
+}
+
+func (m *ChainMetadata) Name() (string, error) {
+	return get(&m.name)
+}
+
+func (m *ChainMetadata) SetName(name string) error {
+	return set(&m.name, "name", name)
+}
+
+func (m *ChainMetadata) LogoURI() (string, error) {
+	return get(&m.logoURI)
+}
+
+func (m *ChainMetadata) SetLogoURI(uri string) error {
+	return set(&m.logoURI, "logo URI", uri)
+}
+
+// NativeToken returns the native token's description, which is empty until the chain owner sets it.
+func (m *ChainMetadata) NativeToken() (NativeToken, error) {
+	name, err := get(&m.nativeTokenName)
+	if err != nil {
+		return NativeToken{}, err
+	}
+	symbol, err := get(&m.nativeTokenSymbol)
+	if err != nil {
+		return NativeToken{}, err
+	}
+	decimals, err := m.nativeTokenDecimals.Get()
+	if err != nil {
+		return NativeToken{}, err
+	}
+	// #nosec G115
+	return NativeToken{Name: name, Symbol: symbol, Decimals: uint8(decimals)}, nil
+}
+
+func (m *ChainMetadata) SetNativeToken(token NativeToken) error {
+	if err := set(&m.nativeTokenName, "native token name", token.Name); err != nil {
+		return err
+	}
+	if err := set(&m.nativeTokenSymbol, "native token symbol", token.Symbol); err != nil {
+		return err
+	}
+	return m.nativeTokenDecimals.Set(uint64(token.Decimals))
+}
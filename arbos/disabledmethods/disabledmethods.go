@@ -0,0 +1,130 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package disabledmethods stores the precompile methods the chain owner disabled,
+// so they revert before ArbOS versions removing them are adopted.
+package disabledmethods
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+// Method identifies a precompile method by the precompile's address and the method's selector.
+type Method struct {
+	Precompile common.Address
+	Selector   [4]byte
+}
+
+// key packs the address and selector left aligned, so keys are never zero for real precompiles.
+func (m Method) key() common.Hash {
+	var key common.Hash
+	copy(key[:], m.Precompile.Bytes())
+	copy(key[common.AddressLength:], m.Selector[:])
+	return key
+}
+
+func methodFromKey(key common.Hash) Method {
+	var method Method
+	copy(method.Precompile[:], key[:common.AddressLength])
+	copy(method.Selector[:], key[common.AddressLength:])
+	return method
+}
+
+// DisabledMethods is a set of methods.
+// size is stored at position 0, members are stored sequentially from 1 onward,
+// and each member's position is stored by key.
+type DisabledMethods struct {
+	backingStorage *storage.Storage
+	size           storage.StorageBackedUint64
+	byKey          *storage.Storage
+}
+
+func Open(sto *storage.Storage) *DisabledMethods {
+	return &DisabledMethods{
+		backingStorage: sto,
+		size:           sto.OpenStorageBackedUint64(0),
+		byKey:          sto.OpenSubStorage([]byte{0}),
+	}
+}
+
+func (d *DisabledMethods) IsDisabled(method Method) (bool, error) {
+	position, err := d.byKey.GetUint64(method.key())
+	return position != 0, err
+}
+
+// Disable adds a method to the set, doing nothing if it's already there.
+func (d *DisabledMethods) Disable(method Method) error {
+	disabled, err := d.IsDisabled(method)
+	if disabled || err != nil {
+		return err
+	}
+	size, err := d.size.Increment()
+	if err != nil {
+		return err
+	}
+	if err := d.backingStorage.SetByUint64(size, method.key()); err != nil {
+		return err
+	}
+	return d.byKey.SetUint64(method.key(), size)
+}
+
+// Enable removes a method from the set, doing nothing if it isn't there.
+// The last member is moved into the removed member's position.
+func (d *DisabledMethods) Enable(method Method) error {
+	key := method.key()
+	position, err := d.byKey.GetUint64(key)
+	if position == 0 || err != nil {
+		return err
+	}
+	if err := d.byKey.Clear(key); err != nil {
+		return err
+	}
+	size, err := d.size.Get()
+	if err != nil {
+		return err
+	}
+	if position != size {
+		lastKey, err := d.backingStorage.GetByUint64(size)
+		if err != nil {
+			return err
+		}
+		if err := d.backingStorage.SetByUint64(position, lastKey); err != nil {
+			return err
+		}
+		if err := d.byKey.SetUint64(lastKey, position); err != nil {
+			return err
+		}
+	}
+	if err := d.backingStorage.ClearByUint64(size); err != nil {
+		return err
+	}
+	_, err = d.size.Decrement()
+	return err
+}
+
+func (d *DisabledMethods) Size() (uint64, error) {
+	return d.size.Get()
+}
+
+// All returns up to maxNumToReturn disabled methods.
+func (d *DisabledMethods) All(maxNumToReturn uint64) ([]Method, error) {
+	size, err := d.size.Get()
+	if err != nil {
+		return nil, err
+	}
+	if size > maxNumToReturn {
+		size = maxNumToReturn
+	}
+	methods := make([]Method, size)
+	for i := range methods {
+		// #nosec G115
+		key, err := d.backingStorage.GetByUint64(uint64(i + 1))
+		if err != nil {
+			return nil, err
+		}
+		methods[i] = methodFromKey(key)
+	}
+	return methods, nil
+}
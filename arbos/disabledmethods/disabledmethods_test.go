@@ -0,0 +1,72 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package disabledmethods
+
+import (
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestDisabledMethods(t *testing.T) {
+	disabled := Open(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+	methods := []Method{
+		{testhelpers.RandomAddress(), [4]byte{1, 2, 3, 4}},
+		{testhelpers.RandomAddress(), [4]byte{5, 6, 7, 8}},
+		{testhelpers.RandomAddress(), [4]byte{9, 10, 11, 12}},
+	}
+	for _, method := range methods {
+		Require(t, disabled.Disable(method))
+	}
+	// Disabling twice does nothing
+	Require(t, disabled.Disable(methods[0]))
+	expectMembers(t, disabled, methods)
+
+	// Removing a member moves the last one into its position
+	Require(t, disabled.Enable(methods[0]))
+	expectMembers(t, disabled, []Method{methods[2], methods[1]})
+	isDisabled, err := disabled.IsDisabled(methods[0])
+	Require(t, err)
+	if isDisabled {
+		Fail(t, "method still disabled after being enabled")
+	}
+	// Enabling a method which isn't disabled does nothing
+	Require(t, disabled.Enable(methods[0]))
+	Require(t, disabled.Enable(methods[1]))
+	Require(t, disabled.Enable(methods[2]))
+	expectMembers(t, disabled, []Method{})
+}
+
+func expectMembers(t *testing.T, disabled *DisabledMethods, expected []Method) {
+	t.Helper()
+	size, err := disabled.Size()
+	Require(t, err)
+	if size != uint64(len(expected)) {
+		Fail(t, "expected", len(expected), "disabled methods but got", size)
+	}
+	all, err := disabled.All(size + 1)
+	Require(t, err)
+	for i, method := range expected {
+		if all[i] != method {
+			Fail(t, "unexpected method at", i, all[i], "expected", method)
+		}
+		isDisabled, err := disabled.IsDisabled(method)
+		Require(t, err)
+		if !isDisabled {
+			Fail(t, "method not disabled", method)
+		}
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,120 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package expresslane records who controls the sequencer's express lane in each round, so the priority
+// the sequencer gives to the controller's transactions can be audited against the chain's own state.
+// An auctioneer chosen by the chain owner sells the rounds and records each winner before its round starts.
+package expresslane
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+const (
+	roundDurationOffset uint64 = iota
+	roundOffsetOffset
+	auctioneerOffset
+	// controllers are kept for this round and the next, in slots chosen by the round's parity
+	evenRoundOffset
+	evenControllerOffset
+	oddRoundOffset
+	oddControllerOffset
+)
+
+var ErrDisabled = errors.New("the express lane is disabled")
+
+type ExpressLane struct {
+	roundDuration storage.StorageBackedUint64 // seconds per round, or 0 if disabled
+	roundOffset   storage.StorageBackedUint64 // timestamp round 0 starts at
+	auctioneer    storage.StorageBackedAddress
+	rounds        [2]storage.StorageBackedUint64
+	controllers   [2]storage.StorageBackedAddress
+}
+
+func Open(sto *storage.Storage) *ExpressLane {
+	return &ExpressLane{
+		roundDuration: sto.OpenStorageBackedUint64(roundDurationOffset),
+		roundOffset:   sto.OpenStorageBackedUint64(roundOffsetOffset),
+		auctioneer:    sto.OpenStorageBackedAddress(auctioneerOffset),
+		rounds: [2]storage.StorageBackedUint64{
+			sto.OpenStorageBackedUint64(evenRoundOffset),
+			sto.OpenStorageBackedUint64(oddRoundOffset),
+		},
+		controllers: [2]storage.StorageBackedAddress{
+			sto.OpenStorageBackedAddress(evenControllerOffset),
+			sto.OpenStorageBackedAddress(oddControllerOffset),
+		},
+	}
+}
+
+func (e *ExpressLane) RoundTiming() (uint64, uint64, error) {
+	duration, err := e.roundDuration.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err := e.roundOffset.Get()
+	return duration, offset, err
+}
+
+// SetRoundTiming sets the length of each round in seconds, and when round 0 starts.
+// A duration of 0 disables the express lane.
+func (e *ExpressLane) SetRoundTiming(duration uint64, offset uint64) error {
+	if err := e.roundDuration.Set(duration); err != nil {
+		return err
+	}
+	return e.roundOffset.Set(offset)
+}
+
+// Round returns the round at a timestamp, failing if the express lane is disabled or round 0 hasn't started.
+func (e *ExpressLane) Round(timestamp uint64) (uint64, error) {
+	duration, offset, err := e.RoundTiming()
+	if err != nil {
+		return 0, err
+	}
+	if duration == 0 {
+		return 0, ErrDisabled
+	}
+	if timestamp < offset {
+		return 0, errors.New("the first express lane round hasn't started")
+	}
+	return (timestamp - offset) / duration, nil
+}
+
+func (e *ExpressLane) Auctioneer() (common.Address, error) {
+	return e.auctioneer.Get()
+}
+
+func (e *ExpressLane) SetAuctioneer(auctioneer common.Address) error {
+	return e.auctioneer.Set(auctioneer)
+}
+
+// Controller returns the account controlling the express lane in a round, or the zero address if none was recorded.
+// Only the current and next rounds are remembered.
+func (e *ExpressLane) Controller(round uint64) (common.Address, error) {
+	slot := round % 2
+	recorded, err := e.rounds[slot].Get()
+	if err != nil || recorded != round {
+		return common.Address{}, err
+	}
+	return e.controllers[slot].Get()
+}
+
+// SetController records the controller of a round, which must be the one at the timestamp or the next.
+func (e *ExpressLane) SetController(round uint64, controller common.Address, timestamp uint64) error {
+	current, err := e.Round(timestamp)
+	if err != nil {
+		return err
+	}
+	if round != current && round != current+1 {
+		return errors.New("express lane controllers can only be set for the current or next round")
+	}
+	slot := round % 2
+	if err := e.rounds[slot].Set(round); err != nil {
+		return err
+	}
+	return e.controllers[slot].Set(controller)
+}
@@ -0,0 +1,156 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package feereports accumulates the fees ArbOS pays to the fee accounts, so they can be reported
+// in events covering a range of blocks at an interval the chain owner chooses.
+package feereports
+
+import (
+	"math/big"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+const (
+	intervalOffset uint64 = iota
+	fromBlockOffset
+	networkFeesOffset
+	infraFeesOffset
+	posterFeesOffset
+)
+
+// Fees breaks fees down by the account they're paid to. Refunds make them negative.
+type Fees struct {
+	Network *big.Int // paid to the network fee account
+	Infra   *big.Int // paid to the infrastructure fee account
+	Poster  *big.Int // paid to the L1 pricer's funds pool for batch posting
+}
+
+func NewFees() Fees {
+	return Fees{Network: new(big.Int), Infra: new(big.Int), Poster: new(big.Int)}
+}
+
+func (f Fees) Total() *big.Int {
+	return arbmath.BigAdd(arbmath.BigAdd(f.Network, f.Infra), f.Poster)
+}
+
+func (f Fees) IsZero() bool {
+	return f.Network.Sign() == 0 && f.Infra.Sign() == 0 && f.Poster.Sign() == 0
+}
+
+// Report is the fees paid in blocks FromBlock through ToBlock.
+type Report struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Fees
+}
+
+type FeeReports struct {
+	interval    storage.StorageBackedUint64 // blocks per report, or 0 if disabled
+	fromBlock   storage.StorageBackedUint64 // first block of the current report
+	networkFees storage.StorageBackedBigInt
+	infraFees   storage.StorageBackedBigInt
+	posterFees  storage.StorageBackedBigInt
+}
+
+func Open(sto *storage.Storage) *FeeReports {
+	return &FeeReports{
+		interval:    sto.OpenStorageBackedUint64(intervalOffset),
+		fromBlock:   sto.OpenStorageBackedUint64(fromBlockOffset),
+		networkFees: sto.OpenStorageBackedBigInt(networkFeesOffset),
+		infraFees:   sto.OpenStorageBackedBigInt(infraFeesOffset),
+		posterFees:  sto.OpenStorageBackedBigInt(posterFeesOffset),
+	}
+}
+
+func (r *FeeReports) Interval() (uint64, error) {
+	return r.interval.Get()
+}
+
+// SetInterval sets the number of blocks each report covers, or disables reports if it's 0.
+// Enabling reports starts the first one at the given block, and disabling them drops the fees accumulated so far.
+func (r *FeeReports) SetInterval(blocks uint64, blockNumber uint64) error {
+	old, err := r.interval.Get()
+	if err != nil {
+		return err
+	}
+	if old == 0 || blocks == 0 {
+		if err := r.reset(blockNumber); err != nil {
+			return err
+		}
+	}
+	return r.interval.Set(blocks)
+}
+
+// Record adds fees to the current report, doing nothing if reports are disabled.
+func (r *FeeReports) Record(fees Fees) error {
+	interval, err := r.interval.Get()
+	if interval == 0 || err != nil || fees.IsZero() {
+		return err
+	}
+	add := func(sbbi *storage.StorageBackedBigInt, amount *big.Int, name string) error {
+		if amount.Sign() == 0 {
+			return nil
+		}
+		current, err := sbbi.Get()
+		if err != nil {
+			return err
+		}
+		return sbbi.SetSaturatingWithWarning(arbmath.BigAdd(current, amount), name)
+	}
+	if err := add(&r.networkFees, fees.Network, "feeReportNetworkFees"); err != nil {
+		return err
+	}
+	if err := add(&r.infraFees, fees.Infra, "feeReportInfraFees"); err != nil {
+		return err
+	}
+	return add(&r.posterFees, fees.Poster, "feeReportPosterFees")
+}
+
+// Due returns the report ending the block before the given one and starts the next report,
+// if reports are enabled and the interval has passed.
+func (r *FeeReports) Due(blockNumber uint64) (*Report, error) {
+	interval, err := r.interval.Get()
+	if interval == 0 || err != nil {
+		return nil, err
+	}
+	fromBlock, err := r.fromBlock.Get()
+	if err != nil {
+		return nil, err
+	}
+	if blockNumber < arbmath.SaturatingUAdd(fromBlock, interval) {
+		return nil, nil
+	}
+	network, err := r.networkFees.Get()
+	if err != nil {
+		return nil, err
+	}
+	infra, err := r.infraFees.Get()
+	if err != nil {
+		return nil, err
+	}
+	poster, err := r.posterFees.Get()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		FromBlock: fromBlock,
+		ToBlock:   blockNumber - 1,
+		Fees:      Fees{Network: network, Infra: infra, Poster: poster},
+	}
+	return report, r.reset(blockNumber)
+}
+
+func (r *FeeReports) reset(blockNumber uint64) error {
+	if err := r.fromBlock.Set(blockNumber); err != nil {
+		return err
+	}
+	if err := r.networkFees.SetByUint(0); err != nil {
+		return err
+	}
+	if err := r.infraFees.SetByUint(0); err != nil {
+		return err
+	}
+	return r.posterFees.SetByUint(0)
+}
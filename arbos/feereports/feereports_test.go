@@ -0,0 +1,77 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package feereports
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func fees(network, infra, poster int64) Fees {
+	return Fees{Network: big.NewInt(network), Infra: big.NewInt(infra), Poster: big.NewInt(poster)}
+}
+
+func TestFeeReports(t *testing.T) {
+	reports := Open(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+
+	// Nothing is accumulated while reports are disabled
+	Require(t, reports.Record(fees(1, 2, 3)))
+	report, err := reports.Due(1000)
+	Require(t, err)
+	if report != nil {
+		Fail(t, "report due while disabled", report)
+	}
+
+	Require(t, reports.SetInterval(10, 100))
+	Require(t, reports.Record(fees(10, 20, 30)))
+	Require(t, reports.Record(fees(-4, 0, 5)))
+	report, err = reports.Due(109)
+	Require(t, err)
+	if report != nil {
+		Fail(t, "report due before the interval passed", report)
+	}
+	report, err = reports.Due(110)
+	Require(t, err)
+	if report == nil {
+		Fail(t, "report not due after the interval passed")
+	}
+	if report.FromBlock != 100 || report.ToBlock != 109 {
+		Fail(t, "unexpected report range", report.FromBlock, report.ToBlock)
+	}
+	if report.Network.Int64() != 6 || report.Infra.Int64() != 20 || report.Poster.Int64() != 35 || report.Total().Int64() != 61 {
+		Fail(t, "unexpected report fees", report.Network, report.Infra, report.Poster)
+	}
+
+	// The next report starts empty, and changing the interval keeps it going
+	Require(t, reports.SetInterval(5, 112))
+	report, err = reports.Due(115)
+	Require(t, err)
+	if report == nil || report.FromBlock != 110 || report.ToBlock != 114 || !report.IsZero() {
+		Fail(t, "unexpected second report", report)
+	}
+
+	// Disabling reports drops what was accumulated
+	Require(t, reports.Record(fees(1, 1, 1)))
+	Require(t, reports.SetInterval(0, 116))
+	Require(t, reports.SetInterval(1, 200))
+	report, err = reports.Due(201)
+	Require(t, err)
+	if report == nil || report.FromBlock != 200 || !report.IsZero() {
+		Fail(t, "unexpected report after re-enabling", report)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
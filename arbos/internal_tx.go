@@ -43,6 +43,46 @@ func InternalTxStartBlock(
 	}
 }
 
+// emitFeeReportIfDue reports the fees paid to the fee accounts since the last report,
+// if the chain owner enabled fee reports and the interval has passed.
+func emitFeeReportIfDue(state *arbosState.ArbosState, evm *vm.EVM) {
+	report, err := state.FeeReports().Due(evm.Context.BlockNumber.Uint64())
+	state.Restrict(err)
+	if report == nil {
+		return
+	}
+	networkFeeAccount, err := state.NetworkFeeAccount()
+	state.Restrict(err)
+	infraFeeAccount, err := state.InfraFeeAccount()
+	state.Restrict(err)
+	err = EmitFeesCollectedEvent(
+		evm,
+		report.FromBlock,
+		report.ToBlock,
+		networkFeeAccount,
+		report.Network,
+		infraFeeAccount,
+		report.Infra,
+		report.Poster,
+		report.Total(),
+	)
+	if err != nil {
+		log.Error("failed to emit FeesCollected event", "err", err)
+	}
+}
+
+// applyFeeHoliday keeps the base fee at zero during the chain owner's fee holiday, announcing when it ends.
+func applyFeeHoliday(state *arbosState.ArbosState, evm *vm.EVM) {
+	ended, err := state.ApplyFeeHoliday(evm.Context.Time)
+	state.Restrict(err)
+	if !ended {
+		return
+	}
+	if err := EmitFeeHolidayEndedEvent(evm, evm.Context.Time); err != nil {
+		log.Error("failed to emit FeeHolidayEnded event", "err", err)
+	}
+}
+
 func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.ArbosState, evm *vm.EVM) error {
 	if len(tx.Data) < 4 {
 		return fmt.Errorf("internal tx data is too short (only %v bytes, at least 4 required)", len(tx.Data))
@@ -83,10 +123,19 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		currentTime := evm.Context.Time
 
 		// Try to reap 2 retryables
-		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
-		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
-
+		beneficiaryIndexed := state.ArbOSVersion() >= arbosState.ArbosVersion_40
+		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, beneficiaryIndexed)
+		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, beneficiaryIndexed)
+
+		if state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+			state.Restrict(state.L2PricingState().ApplyMinBaseFeeSchedule(currentTime))
+			state.Restrict(state.ApplyParameterSunsets(currentTime))
+			emitFeeReportIfDue(state, evm)
+		}
 		state.L2PricingState().UpdatePricingModel(l2BaseFee, timePassed, false)
+		if state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+			applyFeeHoliday(state, evm)
+		}
 
 		return state.UpgradeArbosVersionIfNecessary(currentTime, evm.StateDB, evm.ChainConfig())
 	case InternalTxBatchPostingReportMethodID:
@@ -100,6 +149,13 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		l1BaseFeeWei := util.SafeMapGet[*big.Int](inputs, "l1BaseFeeWei")
 
 		l1p := state.L1PricingState()
+		if state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+			// The poster paid in the parent chain's gas token, which needn't be the fee token
+			l1BaseFeeWei, err = l1p.ConvertFromParentToken(l1BaseFeeWei)
+			if err != nil {
+				log.Warn("L1Pricing ConvertFromParentToken failed", "err", err)
+			}
+		}
 		perBatchGas, err := l1p.PerBatchGasCost()
 		if err != nil {
 			log.Warn("L1Pricing PerBatchGas failed", "err", err)
@@ -35,6 +35,20 @@ type BatchPosterState struct {
 	fundsDue     storage.StorageBackedBigInt
 	payTo        storage.StorageBackedAddress
 	postersTable *BatchPostersTable
+
+	// Cumulative since the stats were last reset, only kept from ArbOS 40
+	batchesPosted  storage.StorageBackedUint64
+	unitsPosted    storage.StorageBackedUint64
+	costAttributed storage.StorageBackedBigUint
+	feesReimbursed storage.StorageBackedBigUint
+}
+
+// BatchPosterStats is what a batch poster has posted and been paid for since its stats were last reset.
+type BatchPosterStats struct {
+	BatchesPosted  uint64
+	UnitsPosted    uint64   // L1 data units allocated to the poster's batches
+	CostAttributed *big.Int // what the poster was owed for its batches, after the amortized cost cap
+	FeesReimbursed *big.Int // what has been paid out to its fee collector
 }
 
 func InitializeBatchPostersTable(storage *storage.Storage) error {
@@ -70,9 +84,13 @@ func (bpt *BatchPostersTable) OpenPoster(poster common.Address, createIfNotExist
 func (bpt *BatchPostersTable) internalOpen(poster common.Address) *BatchPosterState {
 	bpStorage := bpt.posterInfo.OpenSubStorage(poster.Bytes())
 	return &BatchPosterState{
-		fundsDue:     bpStorage.OpenStorageBackedBigInt(0),
-		payTo:        bpStorage.OpenStorageBackedAddress(1),
-		postersTable: bpt,
+		fundsDue:       bpStorage.OpenStorageBackedBigInt(0),
+		payTo:          bpStorage.OpenStorageBackedAddress(1),
+		postersTable:   bpt,
+		batchesPosted:  bpStorage.OpenStorageBackedUint64(2),
+		unitsPosted:    bpStorage.OpenStorageBackedUint64(3),
+		costAttributed: bpStorage.OpenStorageBackedBigUint(4),
+		feesReimbursed: bpStorage.OpenStorageBackedBigUint(5),
 	}
 }
 
@@ -140,6 +158,75 @@ func (bps *BatchPosterState) SetPayTo(addr common.Address) error {
 	return bps.payTo.Set(addr)
 }
 
+func (bps *BatchPosterState) Stats() (*BatchPosterStats, error) {
+	batches, err := bps.batchesPosted.Get()
+	if err != nil {
+		return nil, err
+	}
+	units, err := bps.unitsPosted.Get()
+	if err != nil {
+		return nil, err
+	}
+	cost, err := bps.costAttributed.Get()
+	if err != nil {
+		return nil, err
+	}
+	reimbursed, err := bps.feesReimbursed.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &BatchPosterStats{
+		BatchesPosted:  batches,
+		UnitsPosted:    units,
+		CostAttributed: cost,
+		FeesReimbursed: reimbursed,
+	}, nil
+}
+
+func (bps *BatchPosterState) recordBatch(units uint64, cost *big.Int) error {
+	batches, err := bps.batchesPosted.Get()
+	if err != nil {
+		return err
+	}
+	if err := bps.batchesPosted.Set(arbmath.SaturatingUAdd(batches, 1)); err != nil {
+		return err
+	}
+	prevUnits, err := bps.unitsPosted.Get()
+	if err != nil {
+		return err
+	}
+	if err := bps.unitsPosted.Set(arbmath.SaturatingUAdd(prevUnits, units)); err != nil {
+		return err
+	}
+	prevCost, err := bps.costAttributed.Get()
+	if err != nil {
+		return err
+	}
+	return bps.costAttributed.SetSaturatingWithWarning(arbmath.BigAdd(prevCost, cost), "batch poster cost attributed")
+}
+
+func (bps *BatchPosterState) recordReimbursement(amount *big.Int) error {
+	prev, err := bps.feesReimbursed.Get()
+	if err != nil {
+		return err
+	}
+	return bps.feesReimbursed.SetSaturatingWithWarning(arbmath.BigAdd(prev, amount), "batch poster fees reimbursed")
+}
+
+// ResetStats zeroes the poster's stats, leaving what it's still owed alone.
+func (bps *BatchPosterState) ResetStats() error {
+	if err := bps.batchesPosted.Clear(); err != nil {
+		return err
+	}
+	if err := bps.unitsPosted.Clear(); err != nil {
+		return err
+	}
+	if err := bps.costAttributed.SetChecked(common.Big0); err != nil {
+		return err
+	}
+	return bps.feesReimbursed.SetChecked(common.Big0)
+}
+
 type FundsDueItem struct {
 	dueTo   common.Address
 	balance *big.Int
@@ -45,6 +45,10 @@ type L1PricingState struct {
 	perBatchGasCost      storage.StorageBackedInt64   // introduced in ArbOS version 3
 	amortizedCostCapBips storage.StorageBackedUint64  // in basis points; introduced in ArbOS version 3
 	l1FeesAvailable      storage.StorageBackedBigUint
+	// fee token wei per parent chain gas token wei, scaled by ParentTokenExchangeRateOne, or 0 for 1:1;
+	// introduced in ArbOS version 40
+	parentTokenExchangeRate storage.StorageBackedBigUint
+	parentTokenRateUpdater  storage.StorageBackedAddress // may push parentTokenExchangeRate; introduced in ArbOS version 40
 }
 
 var (
@@ -69,6 +73,8 @@ const (
 	perBatchGasCostOffset
 	amortizedCostCapBipsOffset
 	l1FeesAvailableOffset
+	parentTokenExchangeRateOffset
+	parentTokenRateUpdaterOffset
 )
 
 const (
@@ -78,6 +84,10 @@ const (
 	InitialPerBatchGasCostV12 = 210_000 // overridden as part of the upgrade
 )
 
+// ParentTokenExchangeRateOne is the exchange rate between a chain's fee token and its parent chain's gas token
+// when they're worth the same.
+var ParentTokenExchangeRateOne = big.NewInt(1e18)
+
 // one minute at 100000 bytes / sec
 var InitialEquilibrationUnitsV0 = arbmath.UintToBig(60 * params.TxDataNonZeroGasEIP2028 * 100000)
 var InitialEquilibrationUnitsV6 = arbmath.UintToBig(params.TxDataNonZeroGasEIP2028 * 10000000)
@@ -131,6 +141,8 @@ func OpenL1PricingState(sto *storage.Storage) *L1PricingState {
 		sto.OpenStorageBackedInt64(perBatchGasCostOffset),
 		sto.OpenStorageBackedUint64(amortizedCostCapBipsOffset),
 		sto.OpenStorageBackedBigUint(l1FeesAvailableOffset),
+		sto.OpenStorageBackedBigUint(parentTokenExchangeRateOffset),
+		sto.OpenStorageBackedAddress(parentTokenRateUpdaterOffset),
 	}
 }
 
@@ -275,6 +287,50 @@ func (ps *L1PricingState) AddToL1FeesAvailable(delta *big.Int) (*big.Int, error)
 	return new, nil
 }
 
+// ParentTokenExchangeRate is how many wei of the fee token a wei of the parent chain's gas token is worth,
+// scaled by ParentTokenExchangeRateOne.
+func (ps *L1PricingState) ParentTokenExchangeRate() (*big.Int, error) {
+	rate, err := ps.parentTokenExchangeRate.Get()
+	if err != nil || rate.Sign() != 0 {
+		return rate, err
+	}
+	return new(big.Int).Set(ParentTokenExchangeRateOne), nil
+}
+
+// HasParentTokenExchangeRate returns whether an exchange rate was set, meaning the chain pays fees in a token
+// other than its parent chain's gas token.
+func (ps *L1PricingState) HasParentTokenExchangeRate() (bool, error) {
+	rate, err := ps.parentTokenExchangeRate.Get()
+	if err != nil {
+		return false, err
+	}
+	return rate.Sign() != 0, nil
+}
+
+func (ps *L1PricingState) SetParentTokenExchangeRate(rate *big.Int) error {
+	if rate.Sign() <= 0 {
+		return errors.New("parent token exchange rate must be positive")
+	}
+	return ps.parentTokenExchangeRate.SetChecked(rate)
+}
+
+func (ps *L1PricingState) ParentTokenRateUpdater() (common.Address, error) {
+	return ps.parentTokenRateUpdater.Get()
+}
+
+func (ps *L1PricingState) SetParentTokenRateUpdater(updater common.Address) error {
+	return ps.parentTokenRateUpdater.Set(updater)
+}
+
+// ConvertFromParentToken converts an amount of the parent chain's gas token into the fee token.
+func (ps *L1PricingState) ConvertFromParentToken(wei *big.Int) (*big.Int, error) {
+	rate, err := ps.parentTokenExchangeRate.Get()
+	if err != nil || rate.Sign() == 0 {
+		return wei, err
+	}
+	return am.BigDivByUint(am.BigMul(wei, rate), ParentTokenExchangeRateOne.Uint64()), nil
+}
+
 func (ps *L1PricingState) TransferFromL1FeesAvailable(
 	recipient common.Address,
 	amount *big.Int,
@@ -386,6 +442,13 @@ func (ps *L1PricingState) UpdateForBatchPosterSpending(
 	if err != nil {
 		return err
 	}
+	// ArbOS 40 started attributing costs to each batch poster
+	recordStats := arbosVersion >= 40
+	if recordStats {
+		if err := posterState.recordBatch(unitsAllocated, weiSpent); err != nil {
+			return err
+		}
+	}
 	perUnitReward, err := ps.PerUnitReward()
 	if err != nil {
 		return err
@@ -440,6 +503,11 @@ func (ps *L1PricingState) UpdateForBatchPosterSpending(
 		if err != nil {
 			return err
 		}
+		if recordStats {
+			if err := posterState.recordReimbursement(balanceToTransfer); err != nil {
+				return err
+			}
+		}
 	}
 
 	// update time
@@ -32,3 +32,27 @@ func TestL1PriceUpdate(t *testing.T) {
 		Fail(t)
 	}
 }
+
+func TestConvertFromParentToken(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, InitializeL1PricingState(sto, common.Address{}, big.NewInt(params.GWei)))
+	ps := OpenL1PricingState(sto)
+
+	wei := big.NewInt(3 * params.GWei)
+	converted, err := ps.ConvertFromParentToken(wei)
+	Require(t, err)
+	if converted.Cmp(wei) != 0 {
+		Fail(t, "an unset exchange rate should be 1:1", converted)
+	}
+
+	// the fee token is worth a quarter of the parent chain's gas token
+	Require(t, ps.SetParentTokenExchangeRate(big.NewInt(4e18)))
+	converted, err = ps.ConvertFromParentToken(wei)
+	Require(t, err)
+	if converted.Cmp(big.NewInt(12*params.GWei)) != 0 {
+		Fail(t, "unexpected conversion", converted)
+	}
+	if ps.SetParentTokenExchangeRate(big.NewInt(0)) == nil {
+		Fail(t, "zero exchange rate accepted")
+	}
+}
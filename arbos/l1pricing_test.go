@@ -327,3 +327,54 @@ func newMockEVMForTesting() *vm.EVM {
 	evm.ProcessingHook = &TxProcessor{}
 	return evm
 }
+
+func TestBatchPosterStats(t *testing.T) {
+	evm := newMockEVMForTesting()
+	arbosSt, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(nil, false))
+	Require(t, err)
+
+	l1p := arbosSt.L1PricingState()
+	Require(t, l1p.SetPerUnitReward(0))
+	Require(t, l1p.SetAmortizedCostCapBips(0))
+	posterAddr := common.Address{3, 4, 5}
+	payTo := common.Address{6, 7}
+	poster, err := l1p.BatchPosterTable().AddPoster(posterAddr, payTo)
+	Require(t, err)
+
+	// Only enough fees collected to reimburse part of the batch
+	available := big.NewInt(600)
+	l1PoolAddress := l1pricing.L1PricerFundsPoolAddress
+	util.MintBalance(&l1PoolAddress, available, evm, util.TracingBeforeEVM, "test")
+	Require(t, l1p.SetL1FeesAvailable(available))
+	Require(t, l1p.SetUnitsSinceUpdate(1000))
+	Require(t, l1p.UpdateForBatchPosterSpending(
+		evm.StateDB, evm, arbosState.ArbosVersion_40, 1, 1, posterAddr, big.NewInt(1000), common.Big1, util.TracingDuringEVM,
+	))
+
+	checkStats := func(batches, units, cost, reimbursed, due int64) {
+		t.Helper()
+		stats, err := poster.Stats()
+		Require(t, err)
+		fundsDue, err := poster.FundsDue()
+		Require(t, err)
+		// #nosec G115
+		if stats.BatchesPosted != uint64(batches) || stats.UnitsPosted != uint64(units) ||
+			stats.CostAttributed.Int64() != cost || stats.FeesReimbursed.Int64() != reimbursed || fundsDue.Int64() != due {
+			Fail(t, "unexpected batch poster stats", stats, fundsDue)
+		}
+	}
+	checkStats(1, 1000, 1000, 600, 400)
+	if balance := evm.StateDB.GetBalance(payTo); balance.Uint64() != 600 {
+		Fail(t, "fee collector paid", balance, "instead of what the stats say it was reimbursed")
+	}
+
+	// Batches reported before ArbOS 40 aren't counted
+	Require(t, l1p.SetUnitsSinceUpdate(1000))
+	Require(t, l1p.UpdateForBatchPosterSpending(
+		evm.StateDB, evm, arbosState.ArbosVersion_40-1, 2, 2, posterAddr, big.NewInt(1000), common.Big1, util.TracingDuringEVM,
+	))
+	checkStats(1, 1000, 1000, 600, 1400)
+
+	Require(t, poster.ResetStats())
+	checkStats(0, 0, 0, 0, 1400)
+}
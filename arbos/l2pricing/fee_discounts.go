@@ -0,0 +1,34 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package l2pricing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// FeeDiscount returns the discount on L2 gas fees the chain owner gave an account, in basis points.
+func (ps *L2PricingState) FeeDiscount(account common.Address) (arbmath.UBips, error) {
+	discount, err := ps.feeDiscounts.GetUint64(util.AddressToHash(account))
+	return arbmath.UBips(discount), err
+}
+
+// SetFeeDiscount sets an account's discount on L2 gas fees, in basis points, or removes it if it's 0.
+func (ps *L2PricingState) SetFeeDiscount(account common.Address, discount arbmath.UBips) error {
+	if discount > arbmath.OneInUBips {
+		return fmt.Errorf("fee discount of %v basis points is more than %v", discount, arbmath.OneInUBips)
+	}
+	return ps.feeDiscounts.SetUint64(util.AddressToHash(account), uint64(discount))
+}
+
+// ApplyFeeDiscount splits a fee into the part still charged and the part discounted.
+func ApplyFeeDiscount(fee *big.Int, discount arbmath.UBips) (*big.Int, *big.Int) {
+	discounted := arbmath.BigMulByUBips(fee, discount)
+	return arbmath.BigSub(fee, discounted), discounted
+}
@@ -4,9 +4,11 @@
 package l2pricing
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 type L2PricingState struct {
@@ -18,6 +20,9 @@ type L2PricingState struct {
 	gasBacklog          storage.StorageBackedUint64
 	pricingInertia      storage.StorageBackedUint64
 	backlogTolerance    storage.StorageBackedUint64
+	gasTargetBips       storage.StorageBackedUint64 // the rate the backlog drains at, relative to the speed limit
+	minBaseFeeSchedule  *storage.Storage
+	feeDiscounts        *storage.Storage // the chain owner's discounts on L2 gas fees, in basis points by account
 }
 
 const (
@@ -28,8 +33,12 @@ const (
 	gasBacklogOffset
 	pricingInertiaOffset
 	backlogToleranceOffset
+	gasTargetBipsOffset
 )
 
+var minBaseFeeScheduleKey = []byte{0}
+var feeDiscountsKey = []byte{1}
+
 const GethBlockGasLimit = 1 << 50
 
 func InitializeL2PricingState(sto *storage.Storage) error {
@@ -52,6 +61,9 @@ func OpenL2PricingState(sto *storage.Storage) *L2PricingState {
 		sto.OpenStorageBackedUint64(gasBacklogOffset),
 		sto.OpenStorageBackedUint64(pricingInertiaOffset),
 		sto.OpenStorageBackedUint64(backlogToleranceOffset),
+		sto.OpenStorageBackedUint64(gasTargetBipsOffset),
+		sto.OpenCachedSubStorage(minBaseFeeScheduleKey),
+		sto.OpenCachedSubStorage(feeDiscountsKey),
 	}
 }
 
@@ -114,6 +126,20 @@ func (ps *L2PricingState) SetBacklogTolerance(val uint64) error {
 	return ps.backlogTolerance.Set(val)
 }
 
+// GasTargetBips returns the gas target as a fraction of the speed limit, or 0 if it's unset and the
+// target is the speed limit.
+func (ps *L2PricingState) GasTargetBips() (arbmath.UBips, error) {
+	bips, err := ps.gasTargetBips.Get()
+	return arbmath.UBips(bips), err
+}
+
+func (ps *L2PricingState) SetGasTargetBips(bips arbmath.UBips) error {
+	if bips > arbmath.OneInUBips {
+		return fmt.Errorf("gas target of %v basis points is more than the speed limit", bips)
+	}
+	return ps.gasTargetBips.Set(uint64(bips))
+}
+
 func (ps *L2PricingState) Restrict(err error) {
 	ps.storage.Burner().Restrict(err)
 }
@@ -4,9 +4,12 @@
 package l2pricing
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/offchainlabs/nitro/arbos/burn"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -90,6 +93,135 @@ func TestPricingModelExp(t *testing.T) {
 	}
 }
 
+func TestMinBaseFeeSchedule(t *testing.T) {
+	pricing := PricingForTest(t)
+	initialMin := getMinPrice(t, pricing)
+
+	Require(t, pricing.ScheduleMinBaseFee(300, arbmath.UintToBig(3)))
+	Require(t, pricing.ScheduleMinBaseFee(100, arbmath.UintToBig(1)))
+	Require(t, pricing.ScheduleMinBaseFee(200, arbmath.UintToBig(2)))
+	Require(t, pricing.ScheduleMinBaseFee(200, arbmath.UintToBig(5)))
+	checkSchedule := func(expected ...uint64) {
+		t.Helper()
+		schedule, err := pricing.MinBaseFeeSchedule()
+		Require(t, err)
+		if len(schedule)*2 != len(expected) {
+			Fail(t, "unexpected schedule", schedule)
+		}
+		for i, entry := range schedule {
+			if entry.Timestamp != expected[2*i] || entry.MinBaseFeeWei.Uint64() != expected[2*i+1] {
+				Fail(t, "unexpected schedule", schedule)
+			}
+		}
+	}
+	checkSchedule(100, 1, 200, 5, 300, 3)
+
+	found, err := pricing.CancelScheduledMinBaseFee(300)
+	Require(t, err)
+	if !found {
+		Fail(t, "scheduled change not found")
+	}
+	found, err = pricing.CancelScheduledMinBaseFee(300)
+	Require(t, err)
+	if found {
+		Fail(t, "cancelled change found")
+	}
+	checkSchedule(100, 1, 200, 5)
+
+	for timestamp, expected := range map[uint64]uint64{99: initialMin, 100: 1, 199: 1, 200: 5, 1000: 5} {
+		minBaseFee, err := pricing.MinBaseFeeWeiAt(timestamp)
+		Require(t, err)
+		if minBaseFee.Uint64() != expected {
+			Fail(t, "unexpected minimum base fee at", timestamp, minBaseFee)
+		}
+	}
+
+	// nothing is due yet
+	Require(t, pricing.ApplyMinBaseFeeSchedule(99))
+	if getMinPrice(t, pricing) != initialMin {
+		Fail(t, "minimum base fee changed early")
+	}
+
+	// only the latest due change is applied when several are due
+	Require(t, pricing.ApplyMinBaseFeeSchedule(250))
+	if getMinPrice(t, pricing) != 5 {
+		Fail(t, "unexpected minimum base fee", getMinPrice(t, pricing))
+	}
+	checkSchedule()
+
+	for i := uint64(0); i < MaxMinBaseFeeScheduleSize; i++ {
+		Require(t, pricing.ScheduleMinBaseFee(1000+i, arbmath.UintToBig(i)))
+	}
+	if err := pricing.ScheduleMinBaseFee(2000, common.Big1); !errors.Is(err, ErrMinBaseFeeScheduleFull) {
+		Fail(t, "expected full schedule but got", err)
+	}
+	// replacing an existing change is still allowed
+	Require(t, pricing.ScheduleMinBaseFee(1000, common.Big1))
+}
+
+func TestFeeDiscounts(t *testing.T) {
+	pricing := PricingForTest(t)
+	account := testhelpers.RandomAddress()
+
+	discount, err := pricing.FeeDiscount(account)
+	Require(t, err)
+	if discount != 0 {
+		Fail(t, "discount before being set", discount)
+	}
+	Require(t, pricing.SetFeeDiscount(account, 2500))
+	discount, err = pricing.FeeDiscount(account)
+	Require(t, err)
+	if discount != 2500 {
+		Fail(t, "unexpected discount", discount)
+	}
+	if err := pricing.SetFeeDiscount(account, arbmath.OneInUBips+1); err == nil {
+		Fail(t, "discount over 100% allowed")
+	}
+
+	charged, discounted := ApplyFeeDiscount(arbmath.UintToBig(1000), discount)
+	if charged.Uint64() != 750 || discounted.Uint64() != 250 {
+		Fail(t, "unexpected discounted fee", charged, discounted)
+	}
+	charged, discounted = ApplyFeeDiscount(arbmath.UintToBig(1000), 0)
+	if charged.Uint64() != 1000 || discounted.Sign() != 0 {
+		Fail(t, "fee changed without a discount", charged, discounted)
+	}
+}
+
+func TestGasTarget(t *testing.T) {
+	pricing := PricingForTest(t)
+	limit := getSpeedLimit(t, pricing)
+	target, err := pricing.GasTargetPerSecond()
+	Require(t, err)
+	if target != limit {
+		Fail(t, "unset gas target isn't the speed limit", target, limit)
+	}
+	if err := pricing.SetGasTargetBips(arbmath.OneInUBips + 1); err == nil {
+		Fail(t, "gas target over the speed limit allowed")
+	}
+	Require(t, pricing.SetGasTargetBips(5000))
+	target, err = pricing.GasTargetPerSecond()
+	Require(t, err)
+	if target != limit/2 {
+		Fail(t, "unexpected gas target", target, limit)
+	}
+
+	// running at the speed limit now builds up a backlog, which is priced once past the tolerance
+	minPrice := getPrice(t, pricing)
+	// #nosec G115
+	fakeBlockUpdate(t, pricing, int64(limit), 1)
+	if backlog, _ := pricing.GasBacklog(); backlog != limit/2 {
+		Fail(t, "unexpected backlog", backlog)
+	}
+	for seconds := 0; seconds < 2*InitialBacklogTolerance+1; seconds++ {
+		// #nosec G115
+		fakeBlockUpdate(t, pricing, int64(limit), 1)
+	}
+	if getPrice(t, pricing) <= minPrice {
+		Fail(t, "price should have risen")
+	}
+}
+
 func getPrice(t *testing.T, pricing *L2PricingState) uint64 {
 	value, err := pricing.BaseFeeWei()
 	Require(t, err)
@@ -0,0 +1,168 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package l2pricing
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MaxMinBaseFeeScheduleSize bounds the cost of updating the schedule at the start of a block
+const MaxMinBaseFeeScheduleSize = 16
+
+var ErrMinBaseFeeScheduleFull = errors.New("minimum base fee schedule is full")
+
+// ScheduledMinBaseFee is a minimum base fee that takes effect in the first block with a timestamp at or after Timestamp
+type ScheduledMinBaseFee struct {
+	Timestamp     uint64
+	MinBaseFeeWei *big.Int
+}
+
+// The schedule's length is stored at offset 0, followed by its entries in increasing timestamp order,
+// each taking two slots: the timestamp, then the minimum base fee.
+const minBaseFeeScheduleLengthOffset uint64 = 0
+
+func minBaseFeeScheduleEntryOffset(index uint64) uint64 {
+	return 1 + 2*index
+}
+
+func (ps *L2PricingState) minBaseFeeScheduleLength() (uint64, error) {
+	return ps.minBaseFeeSchedule.GetUint64ByUint64(minBaseFeeScheduleLengthOffset)
+}
+
+// MinBaseFeeSchedule returns the pending minimum base fee changes, earliest first
+func (ps *L2PricingState) MinBaseFeeSchedule() ([]ScheduledMinBaseFee, error) {
+	length, err := ps.minBaseFeeScheduleLength()
+	if err != nil {
+		return nil, err
+	}
+	schedule := make([]ScheduledMinBaseFee, 0, length)
+	for i := uint64(0); i < length; i++ {
+		offset := minBaseFeeScheduleEntryOffset(i)
+		timestamp, err := ps.minBaseFeeSchedule.GetUint64ByUint64(offset)
+		if err != nil {
+			return nil, err
+		}
+		minBaseFee, err := ps.minBaseFeeSchedule.GetByUint64(offset + 1)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, ScheduledMinBaseFee{timestamp, minBaseFee.Big()})
+	}
+	return schedule, nil
+}
+
+func (ps *L2PricingState) setMinBaseFeeSchedule(schedule []ScheduledMinBaseFee) error {
+	oldLength, err := ps.minBaseFeeScheduleLength()
+	if err != nil {
+		return err
+	}
+	for i, entry := range schedule {
+		// #nosec G115
+		offset := minBaseFeeScheduleEntryOffset(uint64(i))
+		if err := ps.minBaseFeeSchedule.SetUint64ByUint64(offset, entry.Timestamp); err != nil {
+			return err
+		}
+		if err := ps.minBaseFeeSchedule.SetByUint64(offset+1, common.BigToHash(entry.MinBaseFeeWei)); err != nil {
+			return err
+		}
+	}
+	// #nosec G115
+	newLength := uint64(len(schedule))
+	for i := newLength; i < oldLength; i++ {
+		offset := minBaseFeeScheduleEntryOffset(i)
+		if err := ps.minBaseFeeSchedule.ClearByUint64(offset); err != nil {
+			return err
+		}
+		if err := ps.minBaseFeeSchedule.ClearByUint64(offset + 1); err != nil {
+			return err
+		}
+	}
+	return ps.minBaseFeeSchedule.SetUint64ByUint64(minBaseFeeScheduleLengthOffset, newLength)
+}
+
+// ScheduleMinBaseFee schedules the minimum base fee to change at timestamp, replacing any change already scheduled then
+func (ps *L2PricingState) ScheduleMinBaseFee(timestamp uint64, minBaseFee *big.Int) error {
+	if minBaseFee.Sign() < 0 || minBaseFee.BitLen() > 256 {
+		return errors.New("minimum base fee out of range")
+	}
+	schedule, err := ps.MinBaseFeeSchedule()
+	if err != nil {
+		return err
+	}
+	index := 0
+	for index < len(schedule) && schedule[index].Timestamp < timestamp {
+		index++
+	}
+	entry := ScheduledMinBaseFee{timestamp, new(big.Int).Set(minBaseFee)}
+	if index < len(schedule) && schedule[index].Timestamp == timestamp {
+		schedule[index] = entry
+	} else {
+		if len(schedule) >= MaxMinBaseFeeScheduleSize {
+			return ErrMinBaseFeeScheduleFull
+		}
+		schedule = append(schedule[:index], append([]ScheduledMinBaseFee{entry}, schedule[index:]...)...)
+	}
+	return ps.setMinBaseFeeSchedule(schedule)
+}
+
+// CancelScheduledMinBaseFee removes the change scheduled at timestamp, returning whether there was one
+func (ps *L2PricingState) CancelScheduledMinBaseFee(timestamp uint64) (bool, error) {
+	schedule, err := ps.MinBaseFeeSchedule()
+	if err != nil {
+		return false, err
+	}
+	for i, entry := range schedule {
+		if entry.Timestamp == timestamp {
+			return true, ps.setMinBaseFeeSchedule(append(schedule[:i], schedule[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// MinBaseFeeWeiAt returns the minimum base fee that will be in effect at timestamp, given the pending schedule
+func (ps *L2PricingState) MinBaseFeeWeiAt(timestamp uint64) (*big.Int, error) {
+	minBaseFee, err := ps.MinBaseFeeWei()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := ps.MinBaseFeeSchedule()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range schedule {
+		if entry.Timestamp > timestamp {
+			break
+		}
+		minBaseFee = entry.MinBaseFeeWei
+	}
+	return minBaseFee, nil
+}
+
+// ApplyMinBaseFeeSchedule sets the minimum base fee to the latest scheduled value that's due by currentTime,
+// and removes all due entries from the schedule.
+func (ps *L2PricingState) ApplyMinBaseFeeSchedule(currentTime uint64) error {
+	length, err := ps.minBaseFeeScheduleLength()
+	if err != nil || length == 0 {
+		return err
+	}
+	firstTimestamp, err := ps.minBaseFeeSchedule.GetUint64ByUint64(minBaseFeeScheduleEntryOffset(0))
+	if err != nil || firstTimestamp > currentTime {
+		return err
+	}
+	schedule, err := ps.MinBaseFeeSchedule()
+	if err != nil {
+		return err
+	}
+	due := 0
+	for due < len(schedule) && schedule[due].Timestamp <= currentTime {
+		due++
+	}
+	if err := ps.SetMinBaseFeeWei(schedule[due-1].MinBaseFeeWei); err != nil {
+		return err
+	}
+	return ps.setMinBaseFeeSchedule(schedule[due:])
+}
@@ -21,6 +21,11 @@ const InitialRateEstimateInertia = 60
 const InitialPricingInertia = 102
 const InitialBacklogTolerance = 10
 
+// SuggestedGasTargetBips, set along with doubling the speed limit, keeps the rate the backlog drains at
+// while letting bursts of up to twice it through with a gentler rise in the base fee.
+// The pricing simulator's tests check this against the initial parameters.
+const SuggestedGasTargetBips arbmath.UBips = 5000
+
 var InitialGasPoolTargetBips = arbmath.PercentToBips(80)
 var InitialGasPoolWeightBips = arbmath.PercentToBips(60)
 
@@ -38,10 +43,27 @@ func (ps *L2PricingState) AddToGasPool(gas int64) error {
 	return ps.SetGasBacklog(backlog)
 }
 
-// UpdatePricingModel updates the pricing model with info from the last block
+// GasTargetPerSecond is the rate the gas backlog drains at. Load above it raises the base fee once
+// the backlog passes the tolerance, however short of the speed limit it is.
+func (ps *L2PricingState) GasTargetPerSecond() (uint64, error) {
+	speedLimit, err := ps.SpeedLimitPerSecond()
+	if err != nil {
+		return 0, err
+	}
+	bips, err := ps.GasTargetBips()
+	if err != nil || bips == 0 {
+		return speedLimit, err
+	}
+	return arbmath.SaturatingUMul(speedLimit, uint64(bips)) / uint64(arbmath.OneInUBips), nil
+}
+
+// UpdatePricingModel updates the pricing model with info from the last block.
+// The backlog drains at the gas target, while the tolerance and inertia are measured in seconds at the
+// speed limit, so a target below the speed limit absorbs bursts and prices sustained load.
 func (ps *L2PricingState) UpdatePricingModel(l2BaseFee *big.Int, timePassed uint64, debug bool) {
 	speedLimit, _ := ps.SpeedLimitPerSecond()
-	_ = ps.AddToGasPool(arbmath.SaturatingCast[int64](arbmath.SaturatingUMul(timePassed, speedLimit)))
+	target, _ := ps.GasTargetPerSecond()
+	_ = ps.AddToGasPool(arbmath.SaturatingCast[int64](arbmath.SaturatingUMul(timePassed, target)))
 	inertia, _ := ps.PricingInertia()
 	tolerance, _ := ps.BacklogTolerance()
 	backlog, _ := ps.GasBacklog()
@@ -0,0 +1,59 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package paymasters stores the contracts the chain owner registered to pay for transactions
+// calling its target contracts, and the rules ArbOS applies before letting them pay.
+package paymasters
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+// ValidationGas is the most gas a paymaster's validation call may use.
+const ValidationGas uint64 = 50000
+
+// ValidateSelector is the selector of validatePaymasterTx(address from, address to, bytes4 selector, uint256 maxCost),
+// which a paymaster must implement to return true for the transactions it pays for.
+var ValidateSelector = crypto.Keccak256([]byte("validatePaymasterTx(address,address,bytes4,uint256)"))[:4]
+
+type Paymasters struct {
+	byTarget *storage.Storage
+}
+
+func Open(sto *storage.Storage) *Paymasters {
+	return &Paymasters{byTarget: sto}
+}
+
+// Set registers the paymaster for transactions to a target, or removes it if the paymaster is the zero address.
+func (p *Paymasters) Set(target, paymaster common.Address) error {
+	return p.byTarget.Set(util.AddressToHash(target), util.AddressToHash(paymaster))
+}
+
+// Get returns the paymaster registered for a target, or the zero address if there isn't one.
+func (p *Paymasters) Get(target common.Address) (common.Address, error) {
+	value, err := p.byTarget.Get(util.AddressToHash(target))
+	return common.BytesToAddress(value.Bytes()), err
+}
+
+// ValidationInput is the calldata of a paymaster's validation call.
+func ValidationInput(from, to common.Address, data []byte, maxCost *big.Int) []byte {
+	input := make([]byte, 0, 4+4*32)
+	input = append(input, ValidateSelector...)
+	input = append(input, common.LeftPadBytes(from.Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(to.Bytes(), 32)...)
+	var selector [32]byte
+	copy(selector[:4], data)
+	input = append(input, selector[:]...)
+	return append(input, common.BigToHash(maxCost).Bytes()...)
+}
+
+// Approved checks a validation call's result is an ABI encoded true.
+func Approved(result []byte) bool {
+	return len(result) == 32 && common.BytesToHash(result) == common.BigToHash(common.Big1)
+}
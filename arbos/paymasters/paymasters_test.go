@@ -0,0 +1,80 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package paymasters
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestPaymasters(t *testing.T) {
+	paymasters := Open(storage.NewMemoryBacked(burn.NewSystemBurner(nil, false)))
+	target := testhelpers.RandomAddress()
+	paymaster := testhelpers.RandomAddress()
+
+	got, err := paymasters.Get(target)
+	Require(t, err)
+	if got != (common.Address{}) {
+		Fail(t, "paymaster registered before being set", got)
+	}
+	Require(t, paymasters.Set(target, paymaster))
+	got, err = paymasters.Get(target)
+	Require(t, err)
+	if got != paymaster {
+		Fail(t, "unexpected paymaster", got)
+	}
+	Require(t, paymasters.Set(target, common.Address{}))
+	got, err = paymasters.Get(target)
+	Require(t, err)
+	if got != (common.Address{}) {
+		Fail(t, "paymaster not removed", got)
+	}
+}
+
+func TestValidationInput(t *testing.T) {
+	from := testhelpers.RandomAddress()
+	to := testhelpers.RandomAddress()
+	input := ValidationInput(from, to, []byte{1, 2, 3, 4, 5, 6}, big.NewInt(1000))
+	if len(input) != 4+4*32 || !bytes.Equal(input[:4], ValidateSelector) {
+		Fail(t, "unexpected input", input)
+	}
+	if common.BytesToAddress(input[4:36]) != from || common.BytesToAddress(input[36:68]) != to {
+		Fail(t, "unexpected addresses", input)
+	}
+	if !bytes.Equal(input[68:100], common.RightPadBytes([]byte{1, 2, 3, 4}, 32)) {
+		Fail(t, "unexpected selector", input[68:100])
+	}
+	if new(big.Int).SetBytes(input[100:]).Uint64() != 1000 {
+		Fail(t, "unexpected max cost", input[100:])
+	}
+	// Calldata shorter than a selector is zero padded
+	input = ValidationInput(from, to, []byte{1}, big.NewInt(0))
+	if !bytes.Equal(input[68:100], common.RightPadBytes([]byte{1}, 32)) {
+		Fail(t, "unexpected short selector", input[68:100])
+	}
+
+	if !Approved(common.BigToHash(common.Big1).Bytes()) {
+		Fail(t, "true not approved")
+	}
+	if Approved(common.BigToHash(common.Big2).Bytes()) || Approved(nil) || Approved(append(common.BigToHash(common.Big1).Bytes(), 0)) {
+		Fail(t, "approved a result other than true")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,54 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package pricingsim
+
+import (
+	"errors"
+	"math/big"
+)
+
+// SyntheticLoad describes a steady load with an optional burst, simulated as one block per second.
+type SyntheticLoad struct {
+	Seconds          uint64
+	StartTimestamp   uint64
+	GasPerSecond     uint64
+	L1UnitsPerSecond uint64
+	// Extra gas per second during the burst
+	BurstGasPerSecond uint64
+	// Seconds after the start the burst begins, and how long it lasts
+	BurstStart    uint64
+	BurstDuration uint64
+	// Seconds between batch posting reports, or 0 for none. Each batch pays for the units charged since the last one.
+	BatchInterval uint64
+	L1BaseFee     *big.Int
+}
+
+func (l *SyntheticLoad) Blocks() ([]Block, error) {
+	if l.BatchInterval > 0 && l.L1BaseFee == nil {
+		return nil, errors.New("synthetic load with batches needs an L1 base fee")
+	}
+	blocks := make([]Block, 0, l.Seconds)
+	var unbatchedUnits uint64
+	for second := uint64(0); second < l.Seconds; second++ {
+		block := Block{
+			Timestamp:  l.StartTimestamp + second,
+			ComputeGas: l.GasPerSecond,
+			L1Units:    l.L1UnitsPerSecond,
+		}
+		if second >= l.BurstStart && second < l.BurstStart+l.BurstDuration {
+			block.ComputeGas += l.BurstGasPerSecond
+		}
+		if l.BatchInterval > 0 && second > 0 && second%l.BatchInterval == 0 {
+			block.Batch = &BatchReport{
+				Timestamp: block.Timestamp,
+				DataGas:   unbatchedUnits,
+				L1BaseFee: l.L1BaseFee,
+			}
+			unbatchedUnits = 0
+		}
+		unbatchedUnits += block.L1Units
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
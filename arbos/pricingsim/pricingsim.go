@@ -0,0 +1,279 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package pricingsim replays L2 gas usage and L1 batch costs through ArbOS's L2 and L1 pricing models,
+// so the effect of parameter changes can be evaluated before applying them on-chain.
+//
+// The simulator runs the real pricing code against an in-memory ArbOS state, calling it the way
+// block production does: the L2 pricing model is updated at the start of each block, each block's
+// compute gas is then taken from the gas pool and its L1 calldata units are charged, and batch
+// posting reports update the L1 pricing model.
+package pricingsim
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// Params are the pricing parameters to simulate. Nil and zero values keep ArbOS's initial value.
+type Params struct {
+	SpeedLimitPerSecond uint64   `json:"speedLimitPerSecond,omitempty"`
+	PricingInertia      uint64   `json:"pricingInertia,omitempty"`
+	BacklogTolerance    uint64   `json:"backlogTolerance,omitempty"`
+	GasTargetBips       uint64   `json:"gasTargetBips,omitempty"`
+	MinBaseFeeWei       *big.Int `json:"minBaseFeeWei,omitempty"`
+	InitialGasBacklog   uint64   `json:"initialGasBacklog,omitempty"`
+
+	L1PricingInertia       uint64   `json:"l1PricingInertia,omitempty"`
+	L1PerUnitReward        uint64   `json:"l1PerUnitReward,omitempty"`
+	L1EquilibrationUnits   *big.Int `json:"l1EquilibrationUnits,omitempty"`
+	L1PerBatchGasCost      *int64   `json:"l1PerBatchGasCost,omitempty"`
+	L1AmortizedCostCapBips *uint64  `json:"l1AmortizedCostCapBips,omitempty"`
+	L1InitialPricePerUnit  *big.Int `json:"l1InitialPricePerUnit,omitempty"`
+}
+
+// BatchReport is a batch posting report, which ArbOS receives when a batch is posted to the parent chain.
+type BatchReport struct {
+	// When the batch was posted
+	Timestamp uint64   `json:"timestamp"`
+	DataGas   uint64   `json:"dataGas"`
+	L1BaseFee *big.Int `json:"l1BaseFee"`
+}
+
+// Block is the usage to simulate in one L2 block.
+type Block struct {
+	Timestamp uint64 `json:"timestamp"`
+	// Gas used by the block's transactions, excluding the gas paying for L1 calldata
+	ComputeGas uint64 `json:"computeGas"`
+	// Calldata units the block's transactions are charged for
+	L1Units uint64 `json:"l1Units"`
+	// A batch posting report included in the block
+	Batch *BatchReport `json:"batch,omitempty"`
+}
+
+// Step is the pricing state after simulating a block.
+type Step struct {
+	Timestamp uint64 `json:"timestamp"`
+	// The base fee the block's transactions paid
+	BaseFee            *big.Int `json:"baseFee"`
+	GasBacklog         uint64   `json:"gasBacklog"`
+	L1PricePerUnit     *big.Int `json:"l1PricePerUnit"`
+	L1Surplus          *big.Int `json:"l1Surplus"`
+	L1UnitsSinceUpdate uint64   `json:"l1UnitsSinceUpdate"`
+}
+
+type Simulator struct {
+	statedb       *state.StateDB
+	evm           *vm.EVM
+	state         *arbosState.ArbosState
+	lastTimestamp uint64
+	started       bool
+}
+
+func New(simParams *Params) (*Simulator, error) {
+	db := state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &triedb.Config{HashDB: hashdb.Defaults})
+	statedb, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig := params.ArbitrumDevTestChainConfig()
+	initMessage := &arbostypes.ParsedInitMessage{
+		ChainId:          chainConfig.ChainID,
+		InitialL1BaseFee: arbostypes.DefaultInitialL1BaseFee,
+		ChainConfig:      chainConfig,
+	}
+	arbState, err := arbosState.InitializeArbosState(statedb, burn.NewSystemBurner(nil, false), chainConfig, initMessage)
+	if err != nil {
+		return nil, err
+	}
+	blockContext := vm.BlockContext{
+		BlockNumber: new(big.Int),
+		GasLimit:    ^uint64(0),
+	}
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+	evm.ProcessingHook = &arbos.TxProcessor{}
+	s := &Simulator{
+		statedb: statedb,
+		evm:     evm,
+		state:   arbState,
+	}
+	if err := s.apply(simParams); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Simulator) apply(p *Params) error {
+	l2 := s.state.L2PricingState()
+	l1 := s.state.L1PricingState()
+	var errs []error
+	if p.SpeedLimitPerSecond != 0 {
+		errs = append(errs, l2.SetSpeedLimitPerSecond(p.SpeedLimitPerSecond))
+	}
+	if p.PricingInertia != 0 {
+		errs = append(errs, l2.SetPricingInertia(p.PricingInertia))
+	}
+	if p.BacklogTolerance != 0 {
+		errs = append(errs, l2.SetBacklogTolerance(p.BacklogTolerance))
+	}
+	if p.GasTargetBips != 0 {
+		errs = append(errs, l2.SetGasTargetBips(arbmath.UBips(p.GasTargetBips)))
+	}
+	if p.MinBaseFeeWei != nil {
+		errs = append(errs, l2.SetMinBaseFeeWei(p.MinBaseFeeWei), l2.SetBaseFeeWei(p.MinBaseFeeWei))
+	}
+	errs = append(errs, l2.SetGasBacklog(p.InitialGasBacklog))
+	if p.L1PricingInertia != 0 {
+		errs = append(errs, l1.SetInertia(p.L1PricingInertia))
+	}
+	if p.L1PerUnitReward != 0 {
+		errs = append(errs, l1.SetPerUnitReward(p.L1PerUnitReward))
+	}
+	if p.L1EquilibrationUnits != nil {
+		errs = append(errs, l1.SetEquilibrationUnits(p.L1EquilibrationUnits))
+	}
+	if p.L1PerBatchGasCost != nil {
+		errs = append(errs, l1.SetPerBatchGasCost(*p.L1PerBatchGasCost))
+	}
+	if p.L1AmortizedCostCapBips != nil {
+		errs = append(errs, l1.SetAmortizedCostCapBips(*p.L1AmortizedCostCapBips))
+	}
+	if p.L1InitialPricePerUnit != nil {
+		errs = append(errs, l1.SetPricePerUnit(p.L1InitialPricePerUnit))
+	}
+	return errors.Join(errs...)
+}
+
+// Step simulates a block, returning the pricing state afterwards.
+func (s *Simulator) Step(block *Block) (*Step, error) {
+	if s.started && block.Timestamp < s.lastTimestamp {
+		return nil, errors.New("blocks must be in timestamp order")
+	}
+	var timePassed uint64
+	if s.started {
+		timePassed = block.Timestamp - s.lastTimestamp
+	}
+	s.started = true
+	s.lastTimestamp = block.Timestamp
+	s.evm.Context.Time = block.Timestamp
+
+	l2 := s.state.L2PricingState()
+
+	// The block header's base fee is read before the StartBlock internal transaction updates the model
+	baseFee, err := l2.BaseFeeWei()
+	if err != nil {
+		return nil, err
+	}
+	if s.state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+		if err := l2.ApplyMinBaseFeeSchedule(block.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+	l2.UpdatePricingModel(baseFee, timePassed, false)
+	if block.Batch != nil {
+		if err := s.reportBatch(block.Batch); err != nil {
+			return nil, err
+		}
+	}
+
+	// The block's transactions, charged at the header's base fee
+	if err := l2.AddToGasPool(-arbmath.SaturatingCast[int64](block.ComputeGas)); err != nil {
+		return nil, err
+	}
+	if block.L1Units > 0 {
+		if err := s.chargeL1Units(block.L1Units, baseFee); err != nil {
+			return nil, err
+		}
+	}
+	return s.snapshot(block.Timestamp, baseFee)
+}
+
+// chargeL1Units charges for calldata like the transaction processor does: the poster cost is converted to
+// L2 gas at the base fee, and the fee paid for that gas goes to the L1 pricer's funds pool.
+func (s *Simulator) chargeL1Units(units uint64, baseFee *big.Int) error {
+	l1 := s.state.L1PricingState()
+	if err := l1.AddToUnitsSinceUpdate(units); err != nil {
+		return err
+	}
+	pricePerUnit, err := l1.PricePerUnit()
+	if err != nil {
+		return err
+	}
+	posterCost := arbmath.BigMulByUint(pricePerUnit, units)
+	if baseFee.Sign() == 0 {
+		return nil
+	}
+	posterGas := arbmath.BigToUintSaturating(arbmath.BigDiv(posterCost, baseFee))
+	posterFee := arbmath.BigMulByUint(baseFee, posterGas)
+	s.statedb.AddBalance(l1pricing.L1PricerFundsPoolAddress, uint256.MustFromBig(posterFee), tracing.BalanceChangeUnspecified)
+	_, err = l1.AddToL1FeesAvailable(posterFee)
+	return err
+}
+
+// reportBatch mirrors the BatchPostingReport internal transaction.
+func (s *Simulator) reportBatch(batch *BatchReport) error {
+	l1 := s.state.L1PricingState()
+	perBatchGas, err := l1.PerBatchGasCost()
+	if err != nil {
+		return err
+	}
+	gasSpent := arbmath.SaturatingAdd(perBatchGas, arbmath.SaturatingCast[int64](batch.DataGas))
+	weiSpent := arbmath.BigMulByUint(batch.L1BaseFee, arbmath.SaturatingUCast[uint64](gasSpent))
+	return l1.UpdateForBatchPosterSpending(
+		s.statedb,
+		s.evm,
+		s.state.ArbOSVersion(),
+		batch.Timestamp,
+		s.evm.Context.Time,
+		l1pricing.BatchPosterAddress,
+		weiSpent,
+		batch.L1BaseFee,
+		util.TracingDuringEVM,
+	)
+}
+
+func (s *Simulator) snapshot(timestamp uint64, baseFee *big.Int) (*Step, error) {
+	l1 := s.state.L1PricingState()
+	step := &Step{Timestamp: timestamp, BaseFee: baseFee}
+	var errs [4]error
+	step.GasBacklog, errs[0] = s.state.L2PricingState().GasBacklog()
+	step.L1PricePerUnit, errs[1] = l1.PricePerUnit()
+	step.L1Surplus, errs[2] = l1.GetL1PricingSurplus()
+	step.L1UnitsSinceUpdate, errs[3] = l1.UnitsSinceUpdate()
+	if err := errors.Join(errs[:]...); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// Run simulates every block in order.
+func (s *Simulator) Run(blocks []Block) ([]Step, error) {
+	steps := make([]Step, 0, len(blocks))
+	for i := range blocks {
+		step, err := s.Step(&blocks[i])
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, *step)
+	}
+	return steps, nil
+}
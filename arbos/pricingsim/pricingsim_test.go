@@ -0,0 +1,128 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package pricingsim
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func simulate(t *testing.T, simParams *Params, load *SyntheticLoad) []Step {
+	t.Helper()
+	sim, err := New(simParams)
+	testhelpers.RequireImpl(t, err)
+	blocks, err := load.Blocks()
+	testhelpers.RequireImpl(t, err)
+	steps, err := sim.Run(blocks)
+	testhelpers.RequireImpl(t, err)
+	return steps
+}
+
+func peakBaseFee(steps []Step) *big.Int {
+	peak := new(big.Int)
+	for _, step := range steps {
+		if step.BaseFee.Cmp(peak) > 0 {
+			peak = step.BaseFee
+		}
+	}
+	return peak
+}
+
+func TestSteadyLoadKeepsMinimumBaseFee(t *testing.T) {
+	steps := simulate(t, &Params{}, &SyntheticLoad{Seconds: 600, GasPerSecond: l2pricing.InitialSpeedLimitPerSecondV6})
+	for _, step := range steps {
+		if step.BaseFee.Cmp(big.NewInt(l2pricing.InitialMinimumBaseFeeWei)) != 0 {
+			testhelpers.FailImpl(t, "base fee left the minimum at the speed limit", step)
+		}
+	}
+}
+
+func TestBurstRaisesBaseFee(t *testing.T) {
+	load := &SyntheticLoad{
+		Seconds:           600,
+		GasPerSecond:      l2pricing.InitialSpeedLimitPerSecondV6 / 2,
+		BurstGasPerSecond: 4 * l2pricing.InitialSpeedLimitPerSecondV6,
+		BurstStart:        60,
+		BurstDuration:     60,
+	}
+	steps := simulate(t, &Params{}, load)
+	peak := peakBaseFee(steps)
+	if peak.Cmp(big.NewInt(l2pricing.InitialMinimumBaseFeeWei)) <= 0 {
+		testhelpers.FailImpl(t, "burst didn't raise the base fee", peak)
+	}
+	if last := steps[len(steps)-1]; last.GasBacklog != 0 || last.BaseFee.Cmp(big.NewInt(l2pricing.InitialMinimumBaseFeeWei)) != 0 {
+		testhelpers.FailImpl(t, "base fee didn't recover after the burst", last)
+	}
+
+	// Doubling the speed limit halves the backlog the burst builds up, so the peak is lower
+	faster := simulate(t, &Params{SpeedLimitPerSecond: 2 * l2pricing.InitialSpeedLimitPerSecondV6}, load)
+	if peakBaseFee(faster).Cmp(peak) >= 0 {
+		testhelpers.FailImpl(t, "faster speed limit didn't lower the peak base fee", peakBaseFee(faster), peak)
+	}
+}
+
+func TestGasTargetSmoothsBursts(t *testing.T) {
+	// Doubling the speed limit with the suggested target keeps the backlog draining at the same rate
+	targeted := &Params{
+		SpeedLimitPerSecond: 2 * l2pricing.InitialSpeedLimitPerSecondV6,
+		GasTargetBips:       uint64(l2pricing.SuggestedGasTargetBips),
+	}
+	burst := &SyntheticLoad{
+		Seconds:           600,
+		GasPerSecond:      l2pricing.InitialSpeedLimitPerSecondV6 / 2,
+		BurstGasPerSecond: 4 * l2pricing.InitialSpeedLimitPerSecondV6,
+		BurstStart:        60,
+		BurstDuration:     60,
+	}
+	peak := peakBaseFee(simulate(t, &Params{}, burst))
+	smoothed := simulate(t, targeted, burst)
+	if peakBaseFee(smoothed).Cmp(peak) >= 0 {
+		testhelpers.FailImpl(t, "gas target didn't soften the burst", peakBaseFee(smoothed), peak)
+	}
+	if last := smoothed[len(smoothed)-1]; last.GasBacklog != 0 {
+		testhelpers.FailImpl(t, "backlog didn't drain after the burst", last)
+	}
+
+	// Load sustained above the target still raises the base fee without bound
+	sustained := &SyntheticLoad{Seconds: 3600, GasPerSecond: 3 * l2pricing.InitialSpeedLimitPerSecondV6 / 2}
+	steps := simulate(t, targeted, sustained)
+	halfway := steps[len(steps)/2].BaseFee
+	if halfway.Cmp(big.NewInt(l2pricing.InitialMinimumBaseFeeWei)) <= 0 || steps[len(steps)-1].BaseFee.Cmp(halfway) <= 0 {
+		testhelpers.FailImpl(t, "sustained load over the target didn't keep raising the base fee", halfway, steps[len(steps)-1].BaseFee)
+	}
+}
+
+func TestBatchReportsUpdateL1Price(t *testing.T) {
+	load := &SyntheticLoad{
+		Seconds:          300,
+		GasPerSecond:     1_000_000,
+		L1UnitsPerSecond: 10_000,
+		BatchInterval:    60,
+		L1BaseFee:        big.NewInt(100 * params.GWei),
+	}
+	steps := simulate(t, &Params{}, load)
+	initial := steps[0].L1PricePerUnit
+	final := steps[len(steps)-1].L1PricePerUnit
+	// Batches cost far more than the initial price collects, so the price must rise
+	if final.Cmp(initial) <= 0 {
+		testhelpers.FailImpl(t, "L1 price per unit didn't rise with expensive batches", initial, final)
+	}
+
+	var csvOut, svgOut bytes.Buffer
+	testhelpers.RequireImpl(t, WriteCSV(&csvOut, steps))
+	testhelpers.RequireImpl(t, WriteSVG(&svgOut, steps))
+	if lines := strings.Count(csvOut.String(), "\n"); lines != len(steps)+1 {
+		testhelpers.FailImpl(t, "expected", len(steps)+1, "CSV lines but got", lines)
+	}
+	if !strings.HasPrefix(svgOut.String(), "<svg") || strings.Count(svgOut.String(), "<polyline") != 3 {
+		testhelpers.FailImpl(t, "unexpected SVG output")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package pricingsim
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func WriteCSV(w io.Writer, steps []Step) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "baseFee", "gasBacklog", "l1PricePerUnit", "l1Surplus", "l1UnitsSinceUpdate"}); err != nil {
+		return err
+	}
+	for _, step := range steps {
+		record := []string{
+			strconv.FormatUint(step.Timestamp, 10),
+			step.BaseFee.String(),
+			strconv.FormatUint(step.GasBacklog, 10),
+			step.L1PricePerUnit.String(),
+			step.L1Surplus.String(),
+			strconv.FormatUint(step.L1UnitsSinceUpdate, 10),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+const (
+	plotWidth       = 900
+	plotPanelHeight = 220
+	plotMargin      = 60
+)
+
+type plotSeries struct {
+	title  string
+	values []float64
+}
+
+// WriteSVG plots the L2 base fee, gas backlog and L1 price per unit over time, one panel each.
+func WriteSVG(w io.Writer, steps []Step) error {
+	gwei := func(value *big.Int) float64 {
+		f, _ := new(big.Float).Quo(new(big.Float).SetInt(value), big.NewFloat(1e9)).Float64()
+		return f
+	}
+	series := []plotSeries{
+		{title: "L2 base fee (gwei)"},
+		{title: "gas backlog"},
+		{title: "L1 price per unit (gwei)"},
+	}
+	for _, step := range steps {
+		series[0].values = append(series[0].values, gwei(step.BaseFee))
+		series[1].values = append(series[1].values, float64(step.GasBacklog))
+		series[2].values = append(series[2].values, gwei(step.L1PricePerUnit))
+	}
+	var start, end uint64
+	if len(steps) > 0 {
+		start, end = steps[0].Timestamp, steps[len(steps)-1].Timestamp
+	}
+
+	height := len(series) * (plotPanelHeight + plotMargin)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", plotWidth+2*plotMargin, height+plotMargin)
+	for i, s := range series {
+		top := plotMargin + i*(plotPanelHeight+plotMargin)
+		low, high := valueRange(s.values)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-weight="bold">%s</text>`+"\n", plotMargin, top-10, s.title)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#999"/>`+"\n", plotMargin, top, plotWidth, plotPanelHeight)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%.4g</text>`+"\n", plotMargin-5, top+10, high)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%.4g</text>`+"\n", plotMargin-5, top+plotPanelHeight, low)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">t=%d</text>`+"\n", plotMargin, top+plotPanelHeight+15, start)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">t=%d</text>`+"\n", plotMargin+plotWidth, top+plotPanelHeight+15, end)
+		b.WriteString(`<polyline fill="none" stroke="#1f77b4" stroke-width="1.5" points="`)
+		for j, value := range s.values {
+			x := float64(plotMargin)
+			if end > start {
+				x += float64(plotWidth) * float64(steps[j].Timestamp-start) / float64(end-start)
+			}
+			y := float64(top + plotPanelHeight)
+			if high > low {
+				y -= float64(plotPanelHeight) * (value - low) / (high - low)
+			}
+			fmt.Fprintf(&b, "%.1f,%.1f ", x, y)
+		}
+		b.WriteString("\"/>\n")
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func valueRange(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = min(low, value)
+		high = max(high, value)
+	}
+	return low, high
+}
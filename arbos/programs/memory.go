@@ -12,12 +12,14 @@ import (
 type MemoryModel struct {
 	freePages uint16 // number of pages the tx gets for free
 	pageGas   uint16 // base gas to charge per wasm page
+	pageRamp  uint64 // scales the exponential cost, which InitialPageRamp leaves as is
 }
 
-func NewMemoryModel(freePages uint16, pageGas uint16) *MemoryModel {
+func NewMemoryModel(freePages uint16, pageGas uint16, pageRamp uint64) *MemoryModel {
 	return &MemoryModel{
 		freePages: freePages,
 		pageGas:   pageGas,
+		pageRamp:  pageRamp,
 	}
 }
 
@@ -42,7 +44,11 @@ func (model *MemoryModel) GasCost(new, open, ever uint16) uint64 {
 
 func (model *MemoryModel) exp(pages uint16) uint64 {
 	if int(pages) < len(memoryExponents) {
-		return uint64(memoryExponents[pages])
+		exp := uint64(memoryExponents[pages])
+		if model.pageRamp == InitialPageRamp {
+			return exp
+		}
+		return arbmath.SaturatingUMul(exp, model.pageRamp) / InitialPageRamp
 	}
 	return math.MaxUint64
 }
@@ -12,7 +12,7 @@ import (
 )
 
 func TestTables(t *testing.T) {
-	model := NewMemoryModel(2, 1000)
+	model := NewMemoryModel(2, 1000, InitialPageRamp)
 	base := math.Exp(math.Log(31_874_000) / 128)
 	for p := uint16(0); p < 129; p++ {
 		value := uint64(math.Pow(base, float64(p)))
@@ -28,7 +28,7 @@ func TestTables(t *testing.T) {
 }
 
 func TestModel(t *testing.T) {
-	model := NewMemoryModel(2, 1000)
+	model := NewMemoryModel(2, 1000, InitialPageRamp)
 
 	for jump := uint16(1); jump <= 128; jump++ {
 		total := uint64(0)
@@ -68,12 +68,24 @@ func TestModel(t *testing.T) {
 	AssertEq(t, math.MaxUint64, model.GasCost(math.MaxUint16, 0, 0))
 
 	// check free pages
-	model = NewMemoryModel(128, 1000)
+	model = NewMemoryModel(128, 1000, InitialPageRamp)
 	AssertEq(t, 0, model.GasCost(128, 0, 0))
 	AssertEq(t, 0, model.GasCost(128, 0, 128))
 	AssertEq(t, math.MaxUint64, model.GasCost(129, 0, 0))
 }
 
+func TestModelPageRamp(t *testing.T) {
+	model := NewMemoryModel(2, 1000, InitialPageRamp)
+	steeper := NewMemoryModel(2, 1000, 2*InitialPageRamp)
+	flatter := NewMemoryModel(2, 1000, InitialPageRamp/2)
+	for p := uint16(0); p < 129; p++ {
+		AssertEq(t, steeper.exp(p), 2*model.exp(p))
+		AssertEq(t, flatter.exp(p), model.exp(p)/2)
+	}
+	AssertEq(t, math.MaxUint64, steeper.GasCost(129, 0, 0))
+	AssertEq(t, steeper.GasCost(128, 0, 0)-model.GasCost(128, 0, 0), model.exp(128)-model.exp(0))
+}
+
 func Fail(t *testing.T, printables ...interface{}) {
 	t.Helper()
 	testhelpers.FailImpl(t, printables...)
@@ -33,6 +33,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/burn"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/gethhook/hooks"
 )
 
 type u8 = C.uint8_t
@@ -187,7 +188,7 @@ func getLocalAsm(statedb vm.StateDB, moduleHash common.Hash, addressForLogging c
 	}
 
 	// addressForLogging may be empty or may not correspond to the code, so we need to be careful to use the code passed in separately
-	wasm, err := getWasmFromContractCode(code)
+	wasm, err := GetWasmFromContractCode(code)
 	if err != nil {
 		log.Error("Failed to reactivate program: getWasm", "address", addressForLogging, "expected moduleHash", moduleHash, "err", err)
 		return nil, fmt.Errorf("failed to reactivate program address: %v err: %w", addressForLogging, err)
@@ -308,7 +309,7 @@ func cacheProgram(db vm.StateDB, module common.Hash, program Program, addressFor
 			panic("unable to recreate wasm")
 		}
 		tag := db.Database().WasmCacheTag()
-		state.CacheWasmRust(asm, module, program.version, tag, debug)
+		hooks.CacheWasm(asm, module, program.version, tag, debug)
 		db.RecordCacheWasm(state.CacheWasm{ModuleHash: module, Version: program.version, Tag: tag, Debug: debug})
 	}
 }
@@ -318,7 +319,7 @@ func cacheProgram(db vm.StateDB, module common.Hash, program Program, addressFor
 func evictProgram(db vm.StateDB, module common.Hash, version uint16, debug bool, runMode core.MessageRunMode, forever bool) {
 	if runMode == core.MessageCommitMode {
 		tag := db.Database().WasmCacheTag()
-		state.EvictWasmRust(module, version, tag, debug)
+		hooks.EvictWasm(module, version, tag, debug)
 		if !forever {
 			db.RecordEvictWasm(state.EvictWasm{ModuleHash: module, Version: version, Tag: tag, Debug: debug})
 		}
@@ -326,12 +327,14 @@ func evictProgram(db vm.StateDB, module common.Hash, version uint16, debug bool,
 }
 
 func init() {
-	state.CacheWasmRust = func(asm []byte, moduleHash common.Hash, version uint16, tag uint32, debug bool) {
-		C.stylus_cache_module(goSlice(asm), hashToBytes32(moduleHash), u16(version), u32(tag), cbool(debug))
-	}
-	state.EvictWasmRust = func(moduleHash common.Hash, version uint16, tag uint32, debug bool) {
-		C.stylus_evict_module(hashToBytes32(moduleHash), u16(version), u32(tag), cbool(debug))
-	}
+	hooks.SetWasmCacheHooks(hooks.WasmCacheHooks{
+		Cache: func(asm []byte, moduleHash common.Hash, version uint16, tag uint32, debug bool) {
+			C.stylus_cache_module(goSlice(asm), hashToBytes32(moduleHash), u16(version), u32(tag), cbool(debug))
+		},
+		Evict: func(moduleHash common.Hash, version uint16, tag uint32, debug bool) {
+			C.stylus_evict_module(hashToBytes32(moduleHash), u16(version), u32(tag), cbool(debug))
+		},
+	})
 }
 
 func SetWasmLruCacheCapacity(capacityBytes uint64) {
@@ -389,7 +392,7 @@ func GetWasmCacheMetrics() *WasmCacheMetrics {
 	}
 }
 
-// Used for testing
+// Used for testing and by the maintenance wasm cache cleanup
 func ClearWasmLruCache() {
 	C.stylus_clear_lru_cache()
 }
@@ -19,7 +19,7 @@ const MaxWasmSize = 128 * 1024      // max decompressed wasm size (programs are
 const initialStackDepth = 4 * 65536 // 4 page stack.
 const InitialFreePages = 2          // 2 pages come free (per tx).
 const InitialPageGas = 1000         // linear cost per allocation.
-const initialPageRamp = 620674314   // targets 8MB costing 32 million gas, minus the linear term.
+const InitialPageRamp = 620674314   // targets 8MB costing 32 million gas, minus the linear term.
 const initialPageLimit = 128        // reject wasms with memories larger than 8MB.
 const initialInkPrice = 10000       // 1 evm gas buys 10k ink.
 const initialMinInitGas = 72        // charge 72 * 128 = 9216 gas.
@@ -88,7 +88,6 @@ func (p Programs) Params() (*StylusParams, error) {
 		MaxStackDepth:    am.BytesToUint32(take(4)),
 		FreePages:        am.BytesToUint16(take(2)),
 		PageGas:          am.BytesToUint16(take(2)),
-		PageRamp:         initialPageRamp,
 		PageLimit:        am.BytesToUint16(take(2)),
 		MinInitGas:       am.BytesToUint8(take(1)),
 		MinCachedInitGas: am.BytesToUint8(take(1)),
@@ -97,9 +96,27 @@ func (p Programs) Params() (*StylusParams, error) {
 		ExpiryDays:       am.BytesToUint16(take(2)),
 		KeepaliveDays:    am.BytesToUint16(take(2)),
 		BlockCacheSize:   am.BytesToUint16(take(2)),
+		PageRamp:         pageRampFromStorage(am.BytesToUint32(take(4))),
 	}, nil
 }
 
+// The page ramp is stored as a uint32 after the other params, with zero meaning the initial ramp. This keeps
+// the params word unchanged on chains that never set it.
+func pageRampFromStorage(stored uint32) uint64 {
+	if stored == 0 {
+		return InitialPageRamp
+	}
+	return uint64(stored)
+}
+
+func pageRampToStorage(ramp uint64) uint32 {
+	if ramp == InitialPageRamp {
+		return 0
+	}
+	// #nosec G115
+	return uint32(ramp)
+}
+
 // Writes the params to permanent storage.
 func (p *StylusParams) Save() error {
 	if p.backingStorage == nil {
@@ -122,6 +139,7 @@ func (p *StylusParams) Save() error {
 		am.Uint16ToBytes(p.ExpiryDays),
 		am.Uint16ToBytes(p.KeepaliveDays),
 		am.Uint16ToBytes(p.BlockCacheSize),
+		am.Uint32ToBytes(pageRampToStorage(p.PageRamp)),
 	)
 
 	slot := uint64(0)
@@ -160,7 +178,7 @@ func initStylusParams(sto *storage.Storage) {
 		MaxStackDepth:    initialStackDepth,
 		FreePages:        InitialFreePages,
 		PageGas:          InitialPageGas,
-		PageRamp:         initialPageRamp,
+		PageRamp:         InitialPageRamp,
 		PageLimit:        initialPageLimit,
 		MinInitGas:       initialMinInitGas,
 		MinCachedInitGas: initialMinCachedGas,
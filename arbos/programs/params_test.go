@@ -0,0 +1,47 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package programs
+
+import (
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestParamsPageRamp(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Initialize(sto)
+	programs := Open(sto)
+	word := func() []byte {
+		t.Helper()
+		value, err := sto.OpenSubStorage(paramsKey).GetByUint64(0)
+		Require(t, err)
+		return value.Bytes()
+	}
+	initialWord := word()
+
+	params, err := programs.Params()
+	Require(t, err)
+	AssertEq(t, params.PageRamp, InitialPageRamp)
+	params.PageRamp = 2 * InitialPageRamp
+	Require(t, params.Save())
+
+	params, err = programs.Params()
+	Require(t, err)
+	AssertEq(t, params.PageRamp, 2*InitialPageRamp)
+	AssertEq(t, params.PageLimit, initialPageLimit)
+	AssertEq(t, params.BlockCacheSize, initialRecentCacheSize)
+
+	// The initial ramp is stored as zero, so chains that never change it keep the same params word
+	params.PageRamp = InitialPageRamp
+	Require(t, params.Save())
+	AssertEq(t, string(word()), string(initialWord))
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
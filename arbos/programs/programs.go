@@ -199,7 +199,7 @@ func (p Programs) CallProgram(
 
 	// pay for memory init
 	open, ever := statedb.GetStylusPages()
-	model := NewMemoryModel(params.FreePages, params.PageGas)
+	model := NewMemoryModel(params.FreePages, params.PageGas, params.PageRamp)
 	callCost := model.GasCost(program.footprint, open, ever)
 
 	// pay for program init
@@ -273,10 +273,11 @@ func evmMemoryCost(size uint64) uint64 {
 
 func getWasm(statedb vm.StateDB, program common.Address) ([]byte, error) {
 	prefixedWasm := statedb.GetCode(program)
-	return getWasmFromContractCode(prefixedWasm)
+	return GetWasmFromContractCode(prefixedWasm)
 }
 
-func getWasmFromContractCode(prefixedWasm []byte) ([]byte, error) {
+// GetWasmFromContractCode decompresses the wasm deployed as a Stylus program's code
+func GetWasmFromContractCode(prefixedWasm []byte) ([]byte, error) {
 	if prefixedWasm == nil {
 		return nil, ProgramNotWasmError()
 	}
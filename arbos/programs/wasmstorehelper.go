@@ -13,6 +13,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/log"
+
+	am "github.com/offchainlabs/nitro/util/arbmath"
 )
 
 // SaveActiveProgramToWasmStore is used to save active stylus programs to wasm store during rebuilding
@@ -50,9 +52,9 @@ func (p Programs) SaveActiveProgramToWasmStore(statedb *state.StateDB, codeHash
 		return nil
 	}
 
-	wasm, err := getWasmFromContractCode(code)
+	wasm, err := GetWasmFromContractCode(code)
 	if err != nil {
-		log.Error("Failed to reactivate program while rebuilding wasm store: getWasmFromContractCode", "expected moduleHash", moduleHash, "err", err)
+		log.Error("Failed to reactivate program while rebuilding wasm store: GetWasmFromContractCode", "expected moduleHash", moduleHash, "err", err)
 		return fmt.Errorf("failed to reactivate program while rebuilding wasm store: %w", err)
 	}
 
@@ -82,3 +84,24 @@ func (p Programs) SaveActiveProgramToWasmStore(statedb *state.StateDB, codeHash
 
 	return nil
 }
+
+// ActivatedModule is used to garbage collect the wasm store. It returns the module hash of an activated program and
+// how many seconds ago it stopped being callable, which is zero while it's active. Programs activated under an older
+// Stylus version are treated as unusable since their activation. ok is false if the codehash was never activated.
+func (p Programs) ActivatedModule(codeHash common.Hash, time uint64, params *StylusParams) (moduleHash common.Hash, inactiveFor uint64, ok bool, err error) {
+	program, err := p.getProgram(codeHash, time)
+	if err != nil || program.version == 0 {
+		return common.Hash{}, 0, false, err
+	}
+	moduleHash, err = p.moduleHashes.Get(codeHash)
+	if err != nil {
+		return common.Hash{}, 0, false, err
+	}
+	expiry := am.DaysToSeconds(params.ExpiryDays)
+	if program.version != params.Version {
+		inactiveFor = program.ageSeconds
+	} else if program.ageSeconds > expiry {
+		inactiveFor = program.ageSeconds - expiry
+	}
+	return moduleHash, inactiveFor, true, nil
+}
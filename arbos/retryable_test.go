@@ -52,7 +52,7 @@ func TestRetryableLifecycle(t *testing.T) {
 	proveReapingDoesNothing := func() {
 		stateCheck(t, statedb, false, "reaping had an effect", func() {
 			evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-			Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+			Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion() >= arbosState.ArbosVersion_40))
 		})
 	}
 	checkQueueSize := func(expected int, message string) {
@@ -77,7 +77,7 @@ func TestRetryableLifecycle(t *testing.T) {
 		calldata := testhelpers.RandomizeSlice(make([]byte, rand.Intn(1<<12)))
 
 		timeout := timeoutAtCreation
-		_, err := retryableState.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata)
+		_, err := retryableState.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata, state.ArbOSVersion() >= arbosState.ArbosVersion_40)
 		Require(t, err)
 		ids = append(ids, id)
 	}
@@ -109,7 +109,7 @@ func TestRetryableLifecycle(t *testing.T) {
 		// check that our reap pricing is reflective of the true cost
 		gasBefore := burner.Burned()
 		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion() >= arbosState.ArbosVersion_40))
 		gasBurnedToReap := burner.Burned() - gasBefore
 		if gasBurnedToReap != retryables.RetryableReapPrice {
 			Fail(t, "reaping has been mispriced", gasBurnedToReap, retryables.RetryableReapPrice)
@@ -131,7 +131,7 @@ func TestRetryableLifecycle(t *testing.T) {
 
 		gasBefore := burner.Burned()
 		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion() >= arbosState.ArbosVersion_40))
 		gasBurnedToReapAndDelete := burner.Burned() - gasBefore
 		if gasBurnedToReapAndDelete <= retryables.RetryableReapPrice {
 			Fail(t, "deletion was cheap", gasBurnedToReapAndDelete, retryables.RetryableReapPrice)
@@ -174,10 +174,10 @@ func TestRetryableCleanup(t *testing.T) {
 	timestamp := 2 * timeout
 
 	stateCheck(t, statedb, false, "state has changed", func() {
-		_, err := retryableState.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata)
+		_, err := retryableState.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata, state.ArbOSVersion() >= arbosState.ArbosVersion_40)
 		Require(t, err)
-		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(timestamp, evm, util.TracingDuringEVM))
+		evm := vm.NewEVM(vm.BlockContext{ArbOSVersion: state.ArbOSVersion()}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+		Require(t, retryableState.TryToReapOneRetryable(timestamp, evm, util.TracingDuringEVM, state.ArbOSVersion() >= arbosState.ArbosVersion_40))
 		cleared, err := retryableState.TimeoutQueue.Shift()
 		Require(t, err)
 		if !cleared {
@@ -201,7 +201,7 @@ func TestRetryableCreate(t *testing.T) {
 		calldata[i] = byte(i + 3)
 	}
 	rstate := state.RetryableState()
-	retryable, err := rstate.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata)
+	retryable, err := rstate.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata, state.ArbOSVersion() >= arbosState.ArbosVersion_40)
 	Require(t, err)
 
 	reread, err := rstate.OpenRetryable(id, lastTimestamp)
@@ -217,6 +217,80 @@ func TestRetryableCreate(t *testing.T) {
 	}
 }
 
+func TestRetryablesByBeneficiary(t *testing.T) {
+	state, statedb := arbosState.NewArbosMemoryBackedArbOSState()
+	retryableState := state.RetryableState()
+	evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+
+	beneficiary := testhelpers.RandomAddress()
+	other := testhelpers.RandomAddress()
+	timeout := uint64(1000)
+	var ids []common.Hash
+	for i := 0; i < 3; i++ {
+		id := common.BigToHash(big.NewInt(int64(i + 1)))
+		ids = append(ids, id)
+		_, err := retryableState.CreateRetryable(id, timeout, other, &other, big.NewInt(int64(i)), beneficiary, nil, true)
+		Require(t, err)
+	}
+	_, err := retryableState.CreateRetryable(common.Hash{9}, timeout, other, &other, common.Big0, other, nil, true)
+	Require(t, err)
+	// Retryables from before the index aren't listed
+	_, err = retryableState.CreateRetryable(common.Hash{10}, timeout, other, &other, common.Big0, beneficiary, nil, false)
+	Require(t, err)
+
+	listed := func(offset uint64, limit uint64) map[common.Hash]*big.Int {
+		retryables, total, err := retryableState.TicketsByBeneficiary(beneficiary, 0, offset, limit)
+		Require(t, err)
+		if total != uint64(len(ids)) {
+			Fail(t, "expected", len(ids), "index entries but got", total)
+		}
+		callvalues := make(map[common.Hash]*big.Int)
+		for _, retryable := range retryables {
+			callvalue, err := retryable.Callvalue()
+			Require(t, err)
+			callvalues[retryable.Id()] = callvalue
+		}
+		return callvalues
+	}
+	callvalues := listed(0, 10)
+	if len(callvalues) != len(ids) {
+		Fail(t, "expected", len(ids), "retryables but listed", len(callvalues))
+	}
+	for i, id := range ids {
+		if callvalues[id] == nil || callvalues[id].Int64() != int64(i) {
+			Fail(t, "retryable", id, "listed with the wrong callvalue", callvalues[id])
+		}
+	}
+
+	// Pages don't overlap and run out past the end
+	firstPage, secondPage := listed(0, 2), listed(2, 2)
+	if len(firstPage) != 2 || len(secondPage) != 1 || secondPage[ids[2]] == nil {
+		Fail(t, "wrong pages listed", firstPage, secondPage)
+	}
+	if len(listed(3, 2)) != 0 || len(listed(1, 0)) != 0 {
+		Fail(t, "listed retryables outside the page")
+	}
+
+	// Deleting the first moves the last into its place
+	ids = ids[1:]
+	deleted, err := retryableState.DeleteRetryable(common.BigToHash(common.Big1), evm, util.TracingDuringEVM, true)
+	Require(t, err)
+	if !deleted {
+		Fail(t, "failed to delete retryable")
+	}
+	callvalues = listed(0, 10)
+	if len(callvalues) != 2 || callvalues[ids[0]] == nil || callvalues[ids[1]] == nil {
+		Fail(t, "wrong retryables listed after deleting one", callvalues)
+	}
+
+	// Expired retryables aren't live
+	retryables, _, err := retryableState.TicketsByBeneficiary(beneficiary, timeout+1, 0, 10)
+	Require(t, err)
+	if len(retryables) != 0 {
+		Fail(t, "listed expired retryables", len(retryables))
+	}
+}
+
 func stateCheck(t *testing.T, statedb *state.StateDB, change bool, message string, scope func()) {
 	stateBefore := statedb.IntermediateRoot(true)
 	dumpBefore := string(statedb.Dump(&state.DumpConfig{}))
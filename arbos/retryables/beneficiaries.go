@@ -0,0 +1,110 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package retryables
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+// Retryables created from ArbOS 40 are indexed by beneficiary, so they can be listed without an off-chain indexer.
+// Callers say whether the index is maintained with a beneficiaryIndexed argument.
+var beneficiaryIndexKey = []byte{2}
+
+// beneficiaryTickets is a beneficiary's set of tickets, laid out like an addressSet:
+// the size at position 0, the tickets from 1 onward, and each ticket's position in a substorage
+type beneficiaryTickets struct {
+	backingStorage *storage.Storage
+	size           storage.StorageBackedUint64
+	byTicket       *storage.Storage
+}
+
+func (rs *RetryableState) openBeneficiaryTickets(beneficiary common.Address) *beneficiaryTickets {
+	sto := rs.retryables.OpenSubStorage(beneficiaryIndexKey).OpenSubStorage(beneficiary.Bytes())
+	return &beneficiaryTickets{
+		backingStorage: sto,
+		size:           sto.OpenStorageBackedUint64(0),
+		byTicket:       sto.OpenSubStorage([]byte{0}),
+	}
+}
+
+func (bt *beneficiaryTickets) add(id common.Hash) error {
+	size, err := bt.size.Get()
+	if err != nil {
+		return err
+	}
+	if err := bt.byTicket.Set(id, util.UintToHash(1+size)); err != nil {
+		return err
+	}
+	if err := bt.backingStorage.SetByUint64(1+size, id); err != nil {
+		return err
+	}
+	_, err = bt.size.Increment()
+	return err
+}
+
+func (bt *beneficiaryTickets) remove(id common.Hash) error {
+	slot, err := bt.byTicket.GetUint64(id)
+	if slot == 0 || err != nil {
+		return err
+	}
+	if err := bt.byTicket.Clear(id); err != nil {
+		return err
+	}
+	size, err := bt.size.Get()
+	if err != nil {
+		return err
+	}
+	if slot < size {
+		last, err := bt.backingStorage.GetByUint64(size)
+		if err != nil {
+			return err
+		}
+		if err := bt.backingStorage.SetByUint64(slot, last); err != nil {
+			return err
+		}
+		if err := bt.byTicket.Set(last, util.UintToHash(slot)); err != nil {
+			return err
+		}
+	}
+	if err := bt.backingStorage.ClearByUint64(size); err != nil {
+		return err
+	}
+	_, err = bt.size.Decrement()
+	return err
+}
+
+// TicketsByBeneficiary lists the live retryables of a beneficiary that were created from ArbOS 40, reading up to
+// limit entries of their index starting at offset. Expired tickets take up entries until they're reaped, so a page
+// may hold fewer than limit retryables. It also returns the number of entries, to page through them all.
+func (rs *RetryableState) TicketsByBeneficiary(beneficiary common.Address, currentTimestamp uint64, offset uint64, limit uint64) ([]*Retryable, uint64, error) {
+	tickets := rs.openBeneficiaryTickets(beneficiary)
+	size, err := tickets.size.Get()
+	if err != nil {
+		return nil, 0, err
+	}
+	end := size
+	if offset >= size {
+		end = offset
+	} else if limit < size-offset {
+		end = offset + limit
+	}
+	var live []*Retryable
+	for i := offset + 1; i <= end; i++ {
+		id, err := tickets.backingStorage.GetByUint64(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		retryable, err := rs.OpenRetryable(id, currentTimestamp)
+		if err != nil {
+			return nil, 0, err
+		}
+		if retryable != nil {
+			live = append(live, retryable)
+		}
+	}
+	return live, size, nil
+}
@@ -72,6 +72,7 @@ func (rs *RetryableState) CreateRetryable(
 	callvalue *big.Int,
 	beneficiary common.Address,
 	calldata []byte,
+	beneficiaryIndexed bool,
 ) (*Retryable, error) {
 	sto := rs.retryables.OpenSubStorage(id.Bytes())
 	ret := &Retryable{
@@ -95,6 +96,12 @@ func (rs *RetryableState) CreateRetryable(
 	_ = ret.timeout.Set(timeout)
 	_ = ret.timeoutWindowsLeft.Set(0)
 
+	if beneficiaryIndexed {
+		if err := rs.openBeneficiaryTickets(beneficiary).add(id); err != nil {
+			return nil, err
+		}
+	}
+
 	// insert the new retryable into the queue so it can be reaped later
 	return ret, rs.TimeoutQueue.Put(id)
 }
@@ -133,7 +140,7 @@ func (rs *RetryableState) RetryableSizeBytes(id common.Hash, currentTime uint64)
 	return 6*32 + calldata, err
 }
 
-func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario util.TracingScenario) (bool, error) {
+func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario util.TracingScenario, beneficiaryIndexed bool) (bool, error) {
 	retStorage := rs.retryables.OpenSubStorage(id.Bytes())
 	timeout, err := retStorage.GetByUint64(timeoutOffset)
 	if timeout == (common.Hash{}) || err != nil {
@@ -144,6 +151,11 @@ func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario
 	beneficiary, _ := retStorage.GetByUint64(beneficiaryOffset)
 	escrowAddress := RetryableEscrowAddress(id)
 	beneficiaryAddress := common.BytesToAddress(beneficiary[:])
+	if beneficiaryIndexed {
+		if err := rs.openBeneficiaryTickets(beneficiaryAddress).remove(id); err != nil {
+			return false, err
+		}
+	}
 	amount := evm.StateDB.GetBalance(escrowAddress)
 	err = util.TransferBalance(&escrowAddress, &beneficiaryAddress, amount.ToBig(), evm, scenario, "escrow")
 	if err != nil {
@@ -162,6 +174,10 @@ func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario
 	return true, err
 }
 
+func (retryable *Retryable) Id() common.Hash {
+	return retryable.id
+}
+
 func (retryable *Retryable) NumTries() (uint64, error) {
 	return retryable.numTries.Get()
 }
@@ -287,7 +303,7 @@ func (retryable *Retryable) Equals(other *Retryable) (bool, error) { // for test
 	return true, err
 }
 
-func (rs *RetryableState) TryToReapOneRetryable(currentTimestamp uint64, evm *vm.EVM, scenario util.TracingScenario) error {
+func (rs *RetryableState) TryToReapOneRetryable(currentTimestamp uint64, evm *vm.EVM, scenario util.TracingScenario, beneficiaryIndexed bool) error {
 	id, err := rs.TimeoutQueue.Peek()
 	if err != nil || id == nil {
 		return err
@@ -318,7 +334,7 @@ func (rs *RetryableState) TryToReapOneRetryable(currentTimestamp uint64, evm *vm
 
 	if windowsLeft == 0 {
 		// the retryable has expired, time to reap
-		_, err = rs.DeleteRetryable(*id, evm, scenario)
+		_, err = rs.DeleteRetryable(*id, evm, scenario, beneficiaryIndexed)
 		return err
 	}
 
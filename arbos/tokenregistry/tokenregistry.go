@@ -0,0 +1,111 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package tokenregistry stores the chain owner's canonical mappings between parent chain
+// tokens and their child chain counterparts.
+package tokenregistry
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/addressSet"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+var (
+	ErrZeroAddress       = errors.New("token address must not be zero")
+	ErrAlreadyRegistered = errors.New("token is already registered")
+	ErrNotRegistered     = errors.New("token is not registered")
+)
+
+// TokenRegistry maps each registered parent chain token to exactly one child chain token, and back.
+type TokenRegistry struct {
+	parentTokens  *addressSet.AddressSet
+	childByParent *storage.Storage
+	parentByChild *storage.Storage
+}
+
+var (
+	parentTokensKey  = []byte{0}
+	childByParentKey = []byte{1}
+	parentByChildKey = []byte{2}
+)
+
+func Initialize(sto *storage.Storage) error {
+	return addressSet.Initialize(sto.OpenCachedSubStorage(parentTokensKey))
+}
+
+func Open(sto *storage.Storage) *TokenRegistry {
+	return &TokenRegistry{
+		parentTokens:  addressSet.OpenAddressSet(sto.OpenCachedSubStorage(parentTokensKey)),
+		childByParent: sto.OpenSubStorage(childByParentKey),
+		parentByChild: sto.OpenSubStorage(parentByChildKey),
+	}
+}
+
+// Register maps a parent chain token to a child chain token. Neither may already be registered.
+func (r *TokenRegistry) Register(parentToken, childToken common.Address) error {
+	if parentToken == (common.Address{}) || childToken == (common.Address{}) {
+		return ErrZeroAddress
+	}
+	existingChild, err := r.ChildToken(parentToken)
+	if err != nil {
+		return err
+	}
+	existingParent, err := r.ParentToken(childToken)
+	if err != nil {
+		return err
+	}
+	if existingChild != (common.Address{}) || existingParent != (common.Address{}) {
+		return ErrAlreadyRegistered
+	}
+	if err := r.parentTokens.Add(parentToken); err != nil {
+		return err
+	}
+	if err := r.childByParent.Set(util.AddressToHash(parentToken), util.AddressToHash(childToken)); err != nil {
+		return err
+	}
+	return r.parentByChild.Set(util.AddressToHash(childToken), util.AddressToHash(parentToken))
+}
+
+// Unregister removes a parent chain token's mapping, returning the child chain token it was mapped to.
+func (r *TokenRegistry) Unregister(parentToken common.Address, arbosVersion uint64) (common.Address, error) {
+	childToken, err := r.ChildToken(parentToken)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if childToken == (common.Address{}) {
+		return common.Address{}, ErrNotRegistered
+	}
+	if err := r.parentTokens.Remove(parentToken, arbosVersion); err != nil {
+		return common.Address{}, err
+	}
+	if err := r.childByParent.Clear(util.AddressToHash(parentToken)); err != nil {
+		return common.Address{}, err
+	}
+	return childToken, r.parentByChild.Clear(util.AddressToHash(childToken))
+}
+
+// ChildToken returns the child chain token mapped to a parent chain token, or the zero address if there isn't one.
+func (r *TokenRegistry) ChildToken(parentToken common.Address) (common.Address, error) {
+	value, err := r.childByParent.Get(util.AddressToHash(parentToken))
+	return common.BytesToAddress(value.Bytes()), err
+}
+
+// ParentToken returns the parent chain token mapped to a child chain token, or the zero address if there isn't one.
+func (r *TokenRegistry) ParentToken(childToken common.Address) (common.Address, error) {
+	value, err := r.parentByChild.Get(util.AddressToHash(childToken))
+	return common.BytesToAddress(value.Bytes()), err
+}
+
+func (r *TokenRegistry) Size() (uint64, error) {
+	return r.parentTokens.Size()
+}
+
+// AllParentTokens returns up to maxNumToReturn registered parent chain tokens.
+func (r *TokenRegistry) AllParentTokens(maxNumToReturn uint64) ([]common.Address, error) {
+	return r.parentTokens.AllMembers(maxNumToReturn)
+}
@@ -0,0 +1,84 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package tokenregistry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestTokenRegistry(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, Initialize(sto))
+	registry := Open(sto)
+	version := uint64(40)
+
+	parent1 := testhelpers.RandomAddress()
+	parent2 := testhelpers.RandomAddress()
+	child1 := testhelpers.RandomAddress()
+	child2 := testhelpers.RandomAddress()
+
+	Require(t, registry.Register(parent1, child1))
+	Require(t, registry.Register(parent2, child2))
+	if err := registry.Register(parent1, child2); !errors.Is(err, ErrAlreadyRegistered) {
+		Fail(t, "registered a parent token twice", err)
+	}
+	if err := registry.Register(testhelpers.RandomAddress(), child1); !errors.Is(err, ErrAlreadyRegistered) {
+		Fail(t, "registered a child token twice", err)
+	}
+	if err := registry.Register(common.Address{}, testhelpers.RandomAddress()); !errors.Is(err, ErrZeroAddress) {
+		Fail(t, "registered the zero address", err)
+	}
+
+	child, err := registry.ChildToken(parent1)
+	Require(t, err)
+	parent, err := registry.ParentToken(child2)
+	Require(t, err)
+	if child != child1 || parent != parent2 {
+		Fail(t, "wrong mappings", child, parent)
+	}
+	all, err := registry.AllParentTokens(16)
+	Require(t, err)
+	if len(all) != 2 {
+		Fail(t, "expected 2 registered tokens but got", all)
+	}
+
+	removed, err := registry.Unregister(parent1, version)
+	Require(t, err)
+	if removed != child1 {
+		Fail(t, "unregistered the wrong child token", removed)
+	}
+	if _, err := registry.Unregister(parent1, version); !errors.Is(err, ErrNotRegistered) {
+		Fail(t, "unregistered a token twice", err)
+	}
+	parent, err = registry.ParentToken(child1)
+	Require(t, err)
+	if parent != (common.Address{}) {
+		Fail(t, "child token still mapped after unregistering", parent)
+	}
+	size, err := registry.Size()
+	Require(t, err)
+	if size != 1 {
+		Fail(t, "expected 1 registered token but got", size)
+	}
+
+	// Both tokens can be registered again once freed
+	Require(t, registry.Register(parent1, child1))
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -9,7 +9,10 @@ import (
 	"math/big"
 
 	"github.com/holiman/uint256"
+	"github.com/offchainlabs/nitro/arbos/feereports"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/paymasters"
 
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -47,6 +50,10 @@ type TxProcessor struct {
 	evm              *vm.EVM
 	CurrentRetryable *common.Hash
 	CurrentRefundTo  *common.Address
+	paymaster        *common.Address // set in StartTxHook if a paymaster prefunded the sender's gas
+	paymasterPrefund *big.Int
+	validationGas    uint64 // gas a paymaster's validation call used, charged to the tx in GasChargingHook
+	failingGasUsed   uint64 // set in StartTxHook if the block processor is applying the tx as failing
 
 	// Caches for the latest L1 block number and hash,
 	// for the NUMBER and BLOCKHASH opcodes.
@@ -54,6 +61,17 @@ type TxProcessor struct {
 	cachedL1BlockHashes map[uint64]common.Hash
 }
 
+// failingTxSlot is the slot of ArbOS's transient storage through which the block processor applies the next
+// tx as failing, holding the gas the tx used when it ran normally. A tx only breaks some limits once it has
+// run, so it's applied again as failing to include it and charge it for that gas without running it twice.
+var failingTxSlot = common.BytesToHash([]byte("failing tx"))
+
+// ApplyNextTxAsFailing makes the next tx applied to statedb fail like it ran out of gas, charging it gasUsed.
+// The flag is cleared when the tx starts, and geth clears transient storage anyway, so it only reaches that tx.
+func ApplyNextTxAsFailing(statedb vm.StateDB, gasUsed uint64) {
+	statedb.SetTransientState(types.ArbosAddress, failingTxSlot, common.BigToHash(new(big.Int).SetUint64(gasUsed)))
+}
+
 func NewTxProcessor(evm *vm.EVM, msg *core.Message) *TxProcessor {
 	tracingInfo := util.NewTracingInfo(evm, msg.From, arbosAddress, util.TracingBeforeEVM)
 	arbosState := arbosState.OpenSystemArbosStateOrPanic(evm.StateDB, tracingInfo, false)
@@ -141,6 +159,11 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		return false, 0, nil, nil
 	}
 
+	if failing := p.evm.StateDB.GetTransientState(types.ArbosAddress, failingTxSlot); failing != (common.Hash{}) {
+		p.failingGasUsed = failing.Big().Uint64()
+		p.evm.StateDB.SetTransientState(types.ArbosAddress, failingTxSlot, common.Hash{})
+	}
+
 	var tracingInfo *util.TracingInfo
 	tipe := underlyingTx.Type()
 	p.TopTxType = &tipe
@@ -203,6 +226,8 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		networkFeeAccount, _ := p.state.NetworkFeeAccount()
 		from := tx.From
 		scenario := util.TracingDuringEVM
+		collected := feereports.NewFees()
+		defer p.recordFees(collected)
 
 		// mint funds with the deposit, then charge fees later
 		availableRefund := new(big.Int).Set(tx.DepositValue)
@@ -240,6 +265,7 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			glog.Error("failed to transfer submissionFee", "err", err)
 			return true, 0, err, nil
 		}
+		collected.Network.Add(collected.Network, submissionFee)
 		withheldSubmissionFee := takeFunds(availableRefund, submissionFee)
 
 		// refund excess submission fee
@@ -256,6 +282,8 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			// First, we give the submission fee back to the transaction sender:
 			if err := transfer(&networkFeeAccount, &tx.From, submissionFee); err != nil {
 				glog.Error("failed to refund submissionFee", "err", err)
+			} else {
+				collected.Network.Sub(collected.Network, submissionFee)
 			}
 			// Then, as limited by availableRefund, we attempt to move the refund to the fee refund address.
 			// If the deposit value was lower than the submission fee, only some (or none) of the submission fee may be moved.
@@ -279,6 +307,7 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			tx.RetryValue,
 			tx.Beneficiary,
 			tx.RetryData,
+			p.state.ArbOSVersion() >= arbosState.ArbosVersion_40,
 		)
 		p.state.Restrict(err)
 
@@ -322,6 +351,7 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 					glog.Error("failed to transfer gas cost to infrastructure fee account", "err", err)
 					return true, 0, nil, ticketId.Bytes()
 				}
+				collected.Infra.Add(collected.Infra, infraCost)
 			}
 		}
 		if arbmath.BigGreaterThan(networkCost, common.Big0) {
@@ -330,6 +360,7 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 				glog.Error("failed to transfer gas cost to network fee account", "err", err)
 				return true, 0, nil, ticketId.Bytes()
 			}
+			collected.Network.Add(collected.Network, networkCost)
 		}
 
 		withheldGasFunds := takeFunds(availableRefund, gascost) // gascost is conceptually charged before the gas price refund
@@ -401,10 +432,56 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		refundTo := tx.RefundTo
 		p.CurrentRetryable = &ticketId
 		p.CurrentRefundTo = &refundTo
+	case *types.LegacyTx, *types.AccessListTx, *types.DynamicFeeTx:
+		if p.state.ArbOSVersion() >= arbosState.ArbosVersion_40 {
+			p.sponsorGas()
+		}
 	}
 	return false, 0, nil, nil
 }
 
+// sponsorGas lets the paymaster registered for the tx's target prefund the sender's gas, if its validation
+// call approves. The sender still pays any callvalue, and EndTxHook returns the gas it didn't use to the paymaster.
+// Whether or not it approves, the validation call's gas is charged to the tx like the rest of its execution.
+func (p *TxProcessor) sponsorGas() {
+	evm := p.evm
+	from := p.msg.From
+	if p.msg.To == nil || evm.StateDB.GetCodeSize(from) != 0 {
+		// Senders with code could move the prefund elsewhere before it's returned
+		return
+	}
+	paymaster, err := p.state.Paymasters().Get(*p.msg.To)
+	if err != nil || paymaster == (common.Address{}) || evm.StateDB.GetCodeSize(paymaster) == 0 {
+		return
+	}
+	prefund := arbmath.BigMulByUint(p.msg.GasFeeCap, p.msg.GasLimit)
+	if prefund.Sign() == 0 {
+		return
+	}
+
+	// Tracers expect the tx's own call to be the first, so the validation call is hidden from them
+	tracer := evm.Config.Tracer
+	evm.Config.Tracer = nil
+	input := paymasters.ValidationInput(from, *p.msg.To, p.msg.Data, prefund)
+	result, gasLeft, err := evm.StaticCall(vm.AccountRef(arbosAddress), paymaster, input, paymasters.ValidationGas)
+	evm.Config.Tracer = tracer
+	p.validationGas = paymasters.ValidationGas - gasLeft
+	if err != nil || !paymasters.Approved(result) {
+		return
+	}
+
+	if arbmath.BigLessThan(evm.StateDB.GetBalance(paymaster).ToBig(), prefund) {
+		return
+	}
+	scenario := util.TracingBeforeEVM
+	if err := util.TransferBalance(&paymaster, &from, prefund, evm, scenario, "paymasterPrefund"); err != nil {
+		log.Error("failed to prefund gas from paymaster", "paymaster", paymaster, "err", err)
+		return
+	}
+	p.paymaster = &paymaster
+	p.paymasterPrefund = prefund
+}
+
 func GetPosterGas(state *arbosState.ArbosState, baseFee *big.Int, runMode core.MessageRunMode, posterCost *big.Int) uint64 {
 	if runMode == core.MessageGasEstimationMode {
 		// Suggest the amount of gas needed for a given amount of ETH is higher in case of congestion.
@@ -466,6 +543,8 @@ func (p *TxProcessor) GasChargingHook(gasRemaining *uint64) (common.Address, err
 		p.PosterFee = arbmath.BigMulByUint(basefee, p.posterGas) // round down
 		gasNeededToStartEVM = p.posterGas
 	}
+	// A paymaster's validation call ran before the EVM, so it's paid for out of the tx's gas and block's gas pool
+	gasNeededToStartEVM += p.validationGas
 
 	if *gasRemaining < gasNeededToStartEVM {
 		// the user couldn't pay for call data, so give up
@@ -482,6 +561,13 @@ func (p *TxProcessor) GasChargingHook(gasRemaining *uint64) (common.Address, err
 			*gasRemaining = gasAvailable
 		}
 	}
+	if p.failingGasUsed != 0 {
+		// Leave the tx no gas to run with so it fails right away, and burn the gas it used when it ran
+		gasUsedSoFar := p.msg.GasLimit - *gasRemaining - p.computeHoldGas
+		burn := arbmath.MinInt(arbmath.SaturatingUSub(p.failingGasUsed, gasUsedSoFar), *gasRemaining)
+		p.computeHoldGas += *gasRemaining - burn
+		*gasRemaining = 0
+	}
 	return tipReceipient, nil
 }
 
@@ -511,6 +597,17 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 	gasUsed := p.msg.GasLimit - gasLeft
 
+	if p.paymaster != nil {
+		// Return the prefund the sender didn't spend on gas to the paymaster
+		unspent := arbmath.BigSub(p.paymasterPrefund, arbmath.BigMulByUint(p.msg.GasPrice, gasUsed))
+		unspent = arbmath.BigMin(unspent, p.evm.StateDB.GetBalance(p.msg.From).ToBig())
+		if unspent.Sign() > 0 {
+			if err := util.TransferBalance(&p.msg.From, p.paymaster, unspent, p.evm, scenario, "paymasterRefund"); err != nil {
+				log.Error("failed to return unspent prefund to paymaster", "paymaster", *p.paymaster, "err", err)
+			}
+		}
+	}
+
 	if underlyingTx != nil && underlyingTx.Type() == types.ArbitrumRetryTxType {
 		inner, _ := underlyingTx.GetInner().(*types.ArbitrumRetryTx)
 		effectiveBaseFee := inner.GasFeeCap
@@ -533,7 +630,8 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 		}
 
 		maxRefund := new(big.Int).Set(inner.MaxRefund)
-		refund := func(refundFrom common.Address, amount *big.Int) {
+		refunded := feereports.NewFees()
+		refund := func(refundFrom common.Address, amount *big.Int, component *big.Int) {
 			const errLog = "fee address doesn't have enough funds to give user refund"
 
 			logMissingRefund := func(err error) {
@@ -558,18 +656,23 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 				// However, in theory, they could've been transferred out during the redeem attempt.
 				// If the network fee address doesn't have the necessary balance, log an error and don't give a refund.
 				logMissingRefund(err)
+			} else {
+				component.Add(component, toRefundAddr)
 			}
 			// Any extra refund can't be given to the fee refund address if it didn't come from the L1 deposit.
 			// Instead, give the refund to the retryable from address.
-			err = util.TransferBalance(&refundFrom, &inner.From, arbmath.BigSub(amount, toRefundAddr), p.evm, scenario, "refund")
+			extra := arbmath.BigSub(amount, toRefundAddr)
+			err = util.TransferBalance(&refundFrom, &inner.From, extra, p.evm, scenario, "refund")
 			if err != nil {
 				logMissingRefund(err)
+			} else {
+				component.Add(component, extra)
 			}
 		}
 
 		if success {
 			// If successful, refund the submission fee.
-			refund(networkFeeAccount, inner.SubmissionFeeRefund)
+			refund(networkFeeAccount, inner.SubmissionFeeRefund, refunded.Network)
 		} else {
 			// The submission fee is still taken from the L1 deposit earlier, even if it's not refunded.
 			takeFunds(maxRefund, inner.SubmissionFeeRefund)
@@ -588,16 +691,21 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 				infraFee := arbmath.BigMin(minBaseFee, effectiveBaseFee)
 				infraRefund := arbmath.BigMulByUint(infraFee, gasLeft)
 				infraRefund = takeFunds(networkRefund, infraRefund)
-				refund(infraFeeAccount, infraRefund)
+				refund(infraFeeAccount, infraRefund, refunded.Infra)
 			}
 		}
-		refund(networkFeeAccount, networkRefund)
+		refund(networkFeeAccount, networkRefund, refunded.Network)
+		p.recordFees(feereports.Fees{
+			Network: refunded.Network.Neg(refunded.Network),
+			Infra:   refunded.Infra.Neg(refunded.Infra),
+			Poster:  common.Big0,
+		})
 
 		if success {
 			// we don't want to charge for this
 			tracingInfo := util.NewTracingInfo(p.evm, arbosAddress, p.msg.From, scenario)
 			state := arbosState.OpenSystemArbosStateOrPanic(p.evm.StateDB, tracingInfo, false)
-			_, _ = state.RetryableState().DeleteRetryable(inner.TicketId, p.evm, scenario)
+			_, _ = state.RetryableState().DeleteRetryable(inner.TicketId, p.evm, scenario, state.ArbOSVersion() >= arbosState.ArbosVersion_40)
 		} else {
 			// return the Callvalue to escrow
 			escrow := retryables.RetryableEscrowAddress(inner.TicketId)
@@ -631,6 +739,9 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 
 	purpose := "feeCollection"
+	collected := feereports.NewFees()
+	discount := p.feeDiscount()
+	discounted := new(big.Int)
 	if p.state.ArbOSVersion() > 4 {
 		infraFeeAccount, err := p.state.InfraFeeAccount()
 		p.state.Restrict(err)
@@ -640,18 +751,35 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 			infraFee := arbmath.BigMin(minBaseFee, basefee)
 			computeGas := arbmath.SaturatingUSub(gasUsed, p.posterGas)
 			infraComputeCost := arbmath.BigMulByUint(infraFee, computeGas)
-			util.MintBalance(&infraFeeAccount, infraComputeCost, p.evm, scenario, purpose)
 			computeCost = arbmath.BigSub(computeCost, infraComputeCost)
+			infraComputeCost, infraDiscount := l2pricing.ApplyFeeDiscount(infraComputeCost, discount)
+			discounted.Add(discounted, infraDiscount)
+			util.MintBalance(&infraFeeAccount, infraComputeCost, p.evm, scenario, purpose)
+			collected.Infra = infraComputeCost
 		}
 	}
 	if arbmath.BigGreaterThan(computeCost, common.Big0) {
+		var networkDiscount *big.Int
+		computeCost, networkDiscount = l2pricing.ApplyFeeDiscount(computeCost, discount)
+		discounted.Add(discounted, networkDiscount)
 		util.MintBalance(&networkFeeAccount, computeCost, p.evm, scenario, purpose)
+		collected.Network = computeCost
+	}
+	if discounted.Sign() > 0 {
+		// Return the discounted part of the L2 fees to whoever paid for the gas
+		payer := p.msg.From
+		if p.paymaster != nil {
+			payer = *p.paymaster
+		}
+		util.MintBalance(&payer, discounted, p.evm, scenario, "feeDiscount")
 	}
 	posterFeeDestination := l1pricing.L1PricerFundsPoolAddress
 	if p.state.ArbOSVersion() < 2 {
 		posterFeeDestination = p.evm.Context.Coinbase
 	}
 	util.MintBalance(&posterFeeDestination, p.PosterFee, p.evm, scenario, purpose)
+	collected.Poster = p.PosterFee
+	p.recordFees(collected)
 	if p.state.ArbOSVersion() >= 10 {
 		if _, err := p.state.L1PricingState().AddToL1FeesAvailable(p.PosterFee); err != nil {
 			log.Error("failed to update L1FeesAvailable: ", "err", err)
@@ -677,6 +805,32 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 }
 
+// feeDiscount returns the larger of the chain owner's L2 fee discounts for the tx's sender and target.
+func (p *TxProcessor) feeDiscount() arbmath.UBips {
+	if p.state.ArbOSVersion() < arbosState.ArbosVersion_40 {
+		return 0
+	}
+	pricing := p.state.L2PricingState()
+	discount, err := pricing.FeeDiscount(p.msg.From)
+	p.state.Restrict(err)
+	if p.msg.To != nil {
+		targetDiscount, err := pricing.FeeDiscount(*p.msg.To)
+		p.state.Restrict(err)
+		discount = max(discount, targetDiscount)
+	}
+	return discount
+}
+
+// recordFees adds fees paid to the fee accounts to the chain owner's fee reports, if they're enabled.
+func (p *TxProcessor) recordFees(fees feereports.Fees) {
+	if p.state.ArbOSVersion() < arbosState.ArbosVersion_40 {
+		return
+	}
+	if err := p.state.FeeReports().Record(fees); err != nil {
+		log.Error("failed to record fees for fee reports", "err", err)
+	}
+}
+
 func (p *TxProcessor) ScheduledTxes() types.Transactions {
 	scheduled := types.Transactions{}
 	time := p.evm.Context.Time
@@ -490,7 +490,7 @@ func (info *TracingInfo) CaptureEVMTraceForHostio(name string, args, outs []byte
 		capture(vm.ORIGIN, nil)
 		capture(vm.POP, nil, address)
 
-	case "call_contract", "delegate_call_contract", "static_call_contract":
+	case "call_contract", "delegate_call_contract", "static_call_contract", "call_contracts":
 		// The API receives the CaptureHostIO after the EVM call is done but we want to
 		// capture the opcde before it. So, we capture the state in CaptureStylusCall.
 
@@ -0,0 +1,138 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbstate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+)
+
+// BatchCheck is the result of strictly checking a single sequencer batch.
+type BatchCheck struct {
+	BatchNum             uint64 `json:"batchNum"`
+	MinTimestamp         uint64 `json:"minTimestamp"`
+	MaxTimestamp         uint64 `json:"maxTimestamp"`
+	MinL1Block           uint64 `json:"minL1Block"`
+	MaxL1Block           uint64 `json:"maxL1Block"`
+	DelayedMessagesRead  uint64 `json:"delayedMessagesRead"` // before the batch
+	AfterDelayedMessages uint64 `json:"afterDelayedMessages"`
+	// Messages are exactly what a node derives from the batch, including the invalid messages it
+	// substitutes for ones it can't parse.
+	Messages []*arbostypes.MessageWithMetadata `json:"messages"`
+	Issues   []string                          `json:"issues"`
+}
+
+// Strict reports whether the batch has no issues.
+func (c *BatchCheck) Strict() bool {
+	return len(c.Issues) == 0
+}
+
+// singleBatchBackend feeds one batch to the inbox multiplexer. Delayed messages are produced by
+// readDelayed, as a batch only commits to their count.
+type singleBatchBackend struct {
+	batchNum       uint64
+	batchBlockHash common.Hash
+	data           []byte
+	advanced       bool
+	positionWithin uint64
+	readDelayed    func(seqNum uint64) (*arbostypes.L1IncomingMessage, error)
+}
+
+func (b *singleBatchBackend) PeekSequencerInbox() ([]byte, common.Hash, error) {
+	if b.advanced {
+		return nil, common.Hash{}, errors.New("read past the checked batch")
+	}
+	return b.data, b.batchBlockHash, nil
+}
+
+func (b *singleBatchBackend) GetSequencerInboxPosition() uint64 {
+	return b.batchNum
+}
+
+func (b *singleBatchBackend) AdvanceSequencerInbox() {
+	b.advanced = true
+}
+
+func (b *singleBatchBackend) GetPositionWithinMessage() uint64 {
+	return b.positionWithin
+}
+
+func (b *singleBatchBackend) SetPositionWithinMessage(pos uint64) {
+	b.positionWithin = pos
+}
+
+func (b *singleBatchBackend) ReadDelayedInbox(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+	return b.readDelayed(seqNum)
+}
+
+// CheckBatch decodes a serialized sequencer batch the way a node does, and reports every problem a node
+// tolerates silently, such as segments it drops or bounds it clamps. delayedMessagesRead is the number
+// of delayed messages read before the batch, readDelayed returns a delayed message by its number, and
+// chainConfig is the config of the chain the batch was posted for, as in NewInboxMultiplexer.
+func CheckBatch(
+	ctx context.Context,
+	batchNum uint64,
+	batchBlockHash common.Hash,
+	data []byte,
+	delayedMessagesRead uint64,
+	dapReaders []daprovider.Reader,
+	readDelayed func(seqNum uint64) (*arbostypes.L1IncomingMessage, error),
+	chainConfig *params.ChainConfig,
+) (*BatchCheck, error) {
+	if len(data) < 40 {
+		return nil, errors.New("sequencer message missing L1 header")
+	}
+	check := &BatchCheck{
+		BatchNum:             batchNum,
+		MinTimestamp:         binary.BigEndian.Uint64(data[:8]),
+		MaxTimestamp:         binary.BigEndian.Uint64(data[8:16]),
+		MinL1Block:           binary.BigEndian.Uint64(data[16:24]),
+		MaxL1Block:           binary.BigEndian.Uint64(data[24:32]),
+		DelayedMessagesRead:  delayedMessagesRead,
+		AfterDelayedMessages: binary.BigEndian.Uint64(data[32:40]),
+	}
+	issues := batchIssues{}
+	if check.MinTimestamp > check.MaxTimestamp {
+		issues.add("min timestamp %v after max timestamp %v", check.MinTimestamp, check.MaxTimestamp)
+	}
+	if check.MinL1Block > check.MaxL1Block {
+		issues.add("min parent chain block %v after max block %v", check.MinL1Block, check.MaxL1Block)
+	}
+	if check.AfterDelayedMessages < delayedMessagesRead {
+		issues.add("batch reads %v delayed messages in total, fewer than the %v already read", check.AfterDelayedMessages, delayedMessagesRead)
+	}
+
+	backend := &singleBatchBackend{
+		batchNum:       batchNum,
+		batchBlockHash: batchBlockHash,
+		data:           data,
+		readDelayed:    readDelayed,
+	}
+	multiplexer := &inboxMultiplexer{
+		backend:                backend,
+		delayedMessagesRead:    delayedMessagesRead,
+		dapReaders:             dapReaders,
+		keysetValidationMode:   daprovider.KeysetValidate,
+		delayedMessagesWindows: DelayedMessagesWindowsEnabled(chainConfig),
+		externalDA:             ExternalDAEnabled(chainConfig),
+		issues:                 &issues,
+	}
+	for !backend.advanced {
+		msg, err := multiplexer.Pop(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error reading message %v of batch %v: %w", len(check.Messages), batchNum, err)
+		}
+		check.Messages = append(check.Messages, msg)
+	}
+	check.Issues = issues
+	return check, nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbstate
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+)
+
+func buildCheckTestBatch(t *testing.T, afterDelayed uint64, segments ...[]byte) []byte {
+	t.Helper()
+	var batch []byte
+	for _, field := range []uint64{100, 200, 10, 20, afterDelayed} {
+		batch = binary.BigEndian.AppendUint64(batch, field)
+	}
+	var encoded bytes.Buffer
+	for _, segment := range segments {
+		if err := rlp.Encode(&encoded, segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compressed, err := arbcompress.CompressWell(encoded.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch = append(batch, daprovider.BrotliMessageHeaderByte)
+	return append(batch, compressed...)
+}
+
+func readCheckTestDelayed(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+	msg := arbostypes.TestIncomingMessageWithRequestId
+	return &msg, nil
+}
+
+func requireIssue(t *testing.T, check *BatchCheck, substring string) {
+	t.Helper()
+	for _, issue := range check.Issues {
+		if strings.Contains(issue, substring) {
+			return
+		}
+	}
+	t.Fatalf("missing issue containing %q in %v", substring, check.Issues)
+}
+
+func TestCheckBatch(t *testing.T) {
+	ctx := context.Background()
+	l2Message := append([]byte{BatchSegmentKindL2Message}, 1, 2, 3)
+	delayed := []byte{BatchSegmentKindDelayedMessages}
+
+	batch := buildCheckTestBatch(t, 1, l2Message, delayed)
+	check, err := CheckBatch(ctx, 5, common.Hash{}, batch, 0, nil, readCheckTestDelayed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Strict() || len(check.Messages) != 2 || check.MaxTimestamp != 200 || check.AfterDelayedMessages != 1 {
+		t.Fatal("unexpected check of a well formed batch", check)
+	}
+	if !bytes.Equal(check.Messages[0].Message.L2msg, []byte{1, 2, 3}) || check.Messages[1].DelayedMessagesRead != 1 {
+		t.Fatal("unexpected messages", check.Messages)
+	}
+
+	advanceTimestamp, err := rlp.EncodeToBytes(uint64(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch = buildCheckTestBatch(t, 2, append([]byte{BatchSegmentKindAdvanceTimestamp}, advanceTimestamp...), l2Message, []byte{9}, delayed)
+	check, err = CheckBatch(ctx, 5, common.Hash{}, batch, 0, nil, readCheckTestDelayed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Strict() {
+		t.Fatal("malformed batch passed the strict check")
+	}
+	requireIssue(t, check, "outside the batch's bounds")
+	requireIssue(t, check, "unknown kind 9")
+	requireIssue(t, check, "only read 1")
+	if check.Messages[0].Message.Header.Timestamp != 200 || check.Messages[len(check.Messages)-1].DelayedMessagesRead != 2 {
+		t.Fatal("messages don't match what a node derives", check.Messages)
+	}
+
+	check, err = CheckBatch(ctx, 5, common.Hash{}, buildCheckTestBatch(t, 0)[:41], 3, nil, readCheckTestDelayed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireIssue(t, check, "fewer than the 3 already read")
+}
+
+func TestDelayedMessagesWindow(t *testing.T) {
+	ctx := context.Background()
+	l2Message := append([]byte{BatchSegmentKindL2Message}, 1, 2, 3)
+	delayed := []byte{BatchSegmentKindDelayedMessages}
+	windowCount, err := rlp.EncodeToBytes(uint64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	window := append([]byte{BatchSegmentKindDelayedMessagesWindow}, windowCount...)
+	readDelayed := func(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+		msg := arbostypes.TestIncomingMessageWithRequestId
+		msg.L2msg = binary.BigEndian.AppendUint64(nil, seqNum)
+		return &msg, nil
+	}
+	chainConfig := params.ArbitrumDevTestChainConfig()
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = arbosState.ArbosVersion_40
+
+	separate := buildCheckTestBatch(t, 5, l2Message, delayed, delayed, delayed, l2Message, delayed, delayed)
+	windowed := buildCheckTestBatch(t, 5, l2Message, window, l2Message, delayed, delayed)
+	expected, err := CheckBatch(ctx, 5, common.Hash{}, separate, 0, nil, readDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, err := CheckBatch(ctx, 5, common.Hash{}, windowed, 0, nil, readDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Strict() || len(check.Messages) != len(expected.Messages) {
+		t.Fatal("unexpected check of a batch with a delayed messages window", check.Issues, len(check.Messages))
+	}
+	for i, msg := range check.Messages {
+		if !msg.Message.Equals(expected.Messages[i].Message) || msg.DelayedMessagesRead != expected.Messages[i].DelayedMessagesRead {
+			t.Fatal("window issued different messages than separate delayed segments", i, msg, expected.Messages[i])
+		}
+	}
+
+	// The replay binary reads each message with a fresh multiplexer, which must issue the same messages
+	backend := &singleBatchBackend{batchNum: 5, data: windowed, readDelayed: readDelayed}
+	var delayedMessagesRead uint64
+	for i := 0; !backend.advanced; i++ {
+		multiplexer := NewInboxMultiplexer(backend, delayedMessagesRead, nil, daprovider.KeysetValidate, chainConfig)
+		msg, err := multiplexer.Pop(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(expected.Messages) || !msg.Message.Equals(expected.Messages[i].Message) {
+			t.Fatal("replay issued a different message", i, msg)
+		}
+		delayedMessagesRead = msg.DelayedMessagesRead
+	}
+
+	emptyWindow, err := rlp.EncodeToBytes(uint64(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, err = CheckBatch(ctx, 5, common.Hash{}, buildCheckTestBatch(t, 0, append([]byte{BatchSegmentKindDelayedMessagesWindow}, emptyWindow...)), 0, nil, readDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireIssue(t, check, "invalid delayed messages window")
+
+	// Chains created before ArbOS 40 read a window as a single invalid message, as they always have
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = arbosState.ArbosVersion_40 - 1
+	check, err = CheckBatch(ctx, 5, common.Hash{}, buildCheckTestBatch(t, 3, l2Message, window), 0, nil, readDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireIssue(t, check, "unknown kind 5")
+	if len(check.Messages) != 5 || check.Messages[1].Message != arbostypes.InvalidL1Message {
+		t.Fatal("window read on a chain without windows", len(check.Messages), check.Messages)
+	}
+}
+
+type checkTestExternalDAReader struct {
+	data []byte
+}
+
+func (r *checkTestExternalDAReader) IsValidHeaderByte(headerByte byte) bool {
+	return daprovider.IsExternalDAMessageHeaderByte(headerByte)
+}
+
+func (r *checkTestExternalDAReader) RecoverPayloadFromBatch(ctx context.Context, batchNum uint64, batchBlockHash common.Hash, sequencerMsg []byte, preimageRecorder daprovider.PreimageRecorder, validateSeqMsg bool) ([]byte, error) {
+	return r.data, nil
+}
+
+func TestExternalDAGate(t *testing.T) {
+	ctx := context.Background()
+	stored := buildCheckTestBatch(t, 0, append([]byte{BatchSegmentKindL2Message}, 1, 2, 3))
+	batch := append(append([]byte{}, stored[:40]...), daprovider.ExternalDAMessageHeaderByte)
+	batch = append(batch, crypto.Keccak256(stored[40:])...)
+	reader := &checkTestExternalDAReader{data: stored[40:]}
+	chainConfig := params.ArbitrumDevTestChainConfig()
+
+	// Chains created before ArbOS 40 ignore the header, even on nodes with a provider
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = arbosState.ArbosVersion_40 - 1
+	check, err := CheckBatch(ctx, 5, common.Hash{}, batch, 0, []daprovider.Reader{reader}, readCheckTestDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireIssue(t, check, "unknown sequencer message format 0x01")
+
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = arbosState.ArbosVersion_40
+	check, err = CheckBatch(ctx, 5, common.Hash{}, batch, 0, []daprovider.Reader{reader}, readCheckTestDelayed, chainConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Strict() || len(check.Messages) != 1 || !bytes.Equal(check.Messages[0].Message.L2msg, []byte{1, 2, 3}) {
+		t.Fatal("unexpected check of an external data availability batch", check.Issues, check.Messages)
+	}
+
+	// Nodes without a provider can't read the batch, rather than reading it differently
+	_, err = CheckBatch(ctx, 5, common.Hash{}, batch, 0, nil, readCheckTestDelayed, chainConfig)
+	if !errors.Is(err, daprovider.ErrNoExternalDAReader) {
+		t.Fatal("expected a missing provider to be an error, got", err)
+	}
+}
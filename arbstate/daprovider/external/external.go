@@ -0,0 +1,155 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package external lets the batch poster store batches with, and nodes read them back from, a third party
+// data availability provider such as Celestia or EigenDA, through a small JSON-RPC API the provider implements:
+//
+//	daprovider_store(message Bytes, timeout Uint64) -> Bytes
+//	    Stores the batch data until timeout (UTC time in unix epoch seconds) and returns the payload to
+//	    post to the sequencer inbox in its place: daprovider.ExternalDAMessageHeaderByte, the keccak256
+//	    hash of the batch data, then any certificate the provider needs to find the data again.
+//	daprovider_recoverPayload(batchNum Uint64, batchBlockHash Hash, sequencerMsg Bytes) -> Bytes
+//	    Returns the batch data for a sequencer message, whose payload starts after its 40 byte header.
+//
+// Recovered data is checked against its hash and recorded as a keccak256 preimage for validation, which the
+// replay binary reads it back from. Nodes only read external data availability batches on chains where
+// arbstate.ExternalDAEnabled, whether or not they have a provider configured, so that a node's config can't
+// change the messages it derives.
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/rpcclient"
+)
+
+var (
+	storeFailureCounter   = metrics.NewRegisteredCounter("arb/daprovider/external/store/failure", nil)
+	recoverFailureCounter = metrics.NewRegisteredCounter("arb/daprovider/external/recover/failure", nil)
+)
+
+type Config struct {
+	Enable bool                   `koanf:"enable"`
+	RPC    rpcclient.ClientConfig `koanf:"rpc" reload:"hot"`
+}
+
+type ConfigFetcher func() *Config
+
+var DefaultConfig = Config{
+	Enable: false,
+	RPC: rpcclient.ClientConfig{
+		Retries:                   3,
+		RetryErrors:               "websocket: close.*|dial tcp .*|.*i/o timeout|.*connection reset by peer|.*connection refused",
+		ArgLogLimit:               2048,
+		WebsocketMessageSizeLimit: 256 * 1024 * 1024,
+	},
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "read batches stored with an external data availability provider, on chains created with ArbOS 40 or later, and allow the batch poster to store them there")
+	rpcclient.RPCClientAddOptions(prefix+".rpc", f, &DefaultConfig.RPC)
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.RPC.URL == "" {
+		return errors.New("external data availability provider enabled without an rpc url")
+	}
+	return c.RPC.Validate()
+}
+
+// Client is both a daprovider.Writer and a daprovider.Reader for an external data availability provider.
+type Client struct {
+	rpc *rpcclient.RpcClient
+}
+
+var _ daprovider.Writer = (*Client)(nil)
+var _ daprovider.Reader = (*Client)(nil)
+
+func NewClient(ctx context.Context, config ConfigFetcher) (*Client, error) {
+	rpc := rpcclient.NewRpcClient(func() *rpcclient.ClientConfig { return &config().RPC }, nil)
+	if err := rpc.Start(ctx); err != nil {
+		return nil, fmt.Errorf("error connecting to external data availability provider: %w", err)
+	}
+	return &Client{rpc: rpc}, nil
+}
+
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+func (c *Client) Store(ctx context.Context, message []byte, timeout uint64, disableFallbackStoreDataOnChain bool) ([]byte, error) {
+	var payload hexutil.Bytes
+	err := c.rpc.CallContext(ctx, &payload, "daprovider_store", hexutil.Bytes(message), hexutil.Uint64(timeout))
+	if err == nil {
+		err = checkPayload(payload, message)
+	}
+	if err != nil {
+		storeFailureCounter.Inc(1)
+		if disableFallbackStoreDataOnChain {
+			return nil, fmt.Errorf("unable to store batch with external data availability provider and fallback storing data on chain is disabled: %w", err)
+		}
+		log.Warn("Falling back to storing data on chain", "err", err)
+		return message, nil
+	}
+	return payload, nil
+}
+
+func (c *Client) IsValidHeaderByte(headerByte byte) bool {
+	return daprovider.IsExternalDAMessageHeaderByte(headerByte)
+}
+
+func (c *Client) RecoverPayloadFromBatch(
+	ctx context.Context,
+	batchNum uint64,
+	batchBlockHash common.Hash,
+	sequencerMsg []byte,
+	preimageRecorder daprovider.PreimageRecorder,
+	validateSeqMsg bool,
+) ([]byte, error) {
+	hash, ok := daprovider.ExternalDAPayloadHash(sequencerMsg)
+	if !ok {
+		log.Warn("External data availability batch payload too short", "batch", batchNum, "len", len(sequencerMsg))
+		return nil, nil
+	}
+	var data hexutil.Bytes
+	err := c.rpc.CallContext(ctx, &data, "daprovider_recoverPayload", hexutil.Uint64(batchNum), batchBlockHash, hexutil.Bytes(sequencerMsg))
+	if err != nil {
+		recoverFailureCounter.Inc(1)
+		return nil, fmt.Errorf("error recovering batch %v from external data availability provider: %w", batchNum, err)
+	}
+	if crypto.Keccak256Hash(data) != hash {
+		recoverFailureCounter.Inc(1)
+		return nil, fmt.Errorf("%w: batch %v from external data availability provider", daprovider.ErrHashMismatch, batchNum)
+	}
+	if preimageRecorder != nil {
+		preimageRecorder(hash, data, arbutil.Keccak256PreimageType)
+	}
+	return data, nil
+}
+
+// checkPayload makes sure a payload returned by the provider commits to the batch data.
+func checkPayload(payload []byte, message []byte) error {
+	if len(payload) < 1+len(common.Hash{}) || !daprovider.IsExternalDAMessageHeaderByte(payload[0]) {
+		return errors.New("external data availability provider returned a malformed payload")
+	}
+	if !bytes.Equal(payload[1:1+len(common.Hash{})], crypto.Keccak256(message)) {
+		return fmt.Errorf("%w: payload from external data availability provider", daprovider.ErrHashMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type testProvider struct {
+	stored  map[common.Hash][]byte
+	corrupt bool
+}
+
+func (p *testProvider) Store(message hexutil.Bytes, timeout hexutil.Uint64) (hexutil.Bytes, error) {
+	hash := crypto.Keccak256Hash(message)
+	p.stored[hash] = message
+	payload := append([]byte{daprovider.ExternalDAMessageHeaderByte}, hash.Bytes()...)
+	return append(payload, []byte("certificate")...), nil
+}
+
+func (p *testProvider) RecoverPayload(batchNum hexutil.Uint64, batchBlockHash common.Hash, sequencerMsg hexutil.Bytes) (hexutil.Bytes, error) {
+	data, ok := p.stored[common.BytesToHash(sequencerMsg[41:73])]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	if p.corrupt {
+		return append([]byte{}, data[1:]...), nil
+	}
+	return data, nil
+}
+
+func TestExternalProviderRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := &testProvider{stored: make(map[common.Hash][]byte)}
+	server := rpc.NewServer()
+	Require(t, server.RegisterName("daprovider", provider))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	config := DefaultConfig
+	config.Enable = true
+	config.RPC.URL = httpServer.URL
+	Require(t, config.Validate())
+	client, err := NewClient(ctx, func() *Config { return &config })
+	Require(t, err)
+	defer client.Close()
+
+	message := []byte("batch data")
+	payload, err := client.Store(ctx, message, 0, true)
+	Require(t, err)
+	if !client.IsValidHeaderByte(payload[0]) {
+		Fail(t, "stored payload has the wrong header byte", payload[0])
+	}
+
+	sequencerMsg := append(make([]byte, 40), payload...)
+	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	data, err := client.RecoverPayloadFromBatch(ctx, 1, common.Hash{}, sequencerMsg, daprovider.RecordPreimagesTo(preimages), true)
+	Require(t, err)
+	if !bytes.Equal(data, message) {
+		Fail(t, "recovered the wrong batch data", data)
+	}
+	if !bytes.Equal(preimages[arbutil.Keccak256PreimageType][crypto.Keccak256Hash(message)], message) {
+		Fail(t, "batch data wasn't recorded as a preimage")
+	}
+
+	provider.corrupt = true
+	_, err = client.RecoverPayloadFromBatch(ctx, 1, common.Hash{}, sequencerMsg, nil, true)
+	if !errors.Is(err, daprovider.ErrHashMismatch) {
+		Fail(t, "expected a hash mismatch for corrupted batch data but got", err)
+	}
+}
+
+func TestExternalProviderStoreFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A provider which doesn't implement the API can't store anything
+	server := rpc.NewServer()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	config := DefaultConfig
+	config.Enable = true
+	config.RPC.URL = httpServer.URL
+	config.RPC.Retries = 0
+	client, err := NewClient(ctx, func() *Config { return &config })
+	Require(t, err)
+	defer client.Close()
+
+	message := []byte("batch data")
+	payload, err := client.Store(ctx, message, 0, false)
+	Require(t, err)
+	if !bytes.Equal(payload, message) {
+		Fail(t, "expected the batch data to be posted on chain but got", payload)
+	}
+	if _, err := client.Store(ctx, message, 0, true); err == nil {
+		Fail(t, "expected storing to fail with the fallback disabled")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
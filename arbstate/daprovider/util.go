@@ -88,6 +88,10 @@ const BlobHashesHeaderFlag byte = L1AuthenticatedMessageHeaderFlag | 0x10 // 0x5
 // BrotliMessageHeaderByte indicates that the message is brotli-compressed.
 const BrotliMessageHeaderByte byte = 0
 
+// ExternalDAMessageHeaderByte indicates that the batch data is stored with an external data availability provider,
+// and the message is the keccak256 hash of the data followed by the provider's certificate.
+const ExternalDAMessageHeaderByte byte = 0x01
+
 // KnownHeaderBits is all header bits with known meaning to this nitro version
 const KnownHeaderBits byte = DASMessageHeaderFlag | TreeDASMessageHeaderFlag | L1AuthenticatedMessageHeaderFlag | ZeroheavyMessageHeaderFlag | BlobHashesHeaderFlag | BrotliMessageHeaderByte
 
@@ -120,6 +124,20 @@ func IsBrotliMessageHeaderByte(b uint8) bool {
 	return b == BrotliMessageHeaderByte
 }
 
+func IsExternalDAMessageHeaderByte(header byte) bool {
+	return header == ExternalDAMessageHeaderByte
+}
+
+// ExternalDAPayloadHash returns the hash of the batch data an external data availability sequencer message
+// commits to, or false if the message is too short to hold one, in which case the batch is empty.
+func ExternalDAPayloadHash(sequencerMsg []byte) (common.Hash, bool) {
+	// sequencer message header, then header byte and data hash
+	if len(sequencerMsg) < 40+1+len(common.Hash{}) {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(sequencerMsg[41 : 41+len(common.Hash{})]), true
+}
+
 // IsKnownHeaderByte returns true if the supplied header byte has only known bits
 func IsKnownHeaderByte(b uint8) bool {
 	return b&^KnownHeaderBits == 0
@@ -130,6 +148,7 @@ var (
 	ErrHashMismatch          = errors.New("result does not match expected hash")
 	ErrBatchToDasFailed      = errors.New("unable to batch to DAS")
 	ErrNoBlobReader          = errors.New("blob batch payload was encountered but no BlobReader was configured")
+	ErrNoExternalDAReader    = errors.New("external data availability batch payload was encountered but no external data availability provider was configured")
 	ErrInvalidBlobDataFormat = errors.New("blob batch data is not a list of hashes as expected")
 	ErrSeqMsgValidation      = errors.New("error validating recovered payload from batch")
 )
@@ -14,6 +14,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/offchainlabs/nitro/arbcompress"
@@ -49,7 +50,17 @@ const MaxDecompressedLen int = 1024 * 1024 * 16 // 16 MiB
 const maxZeroheavyDecompressedLen = 101*MaxDecompressedLen/100 + 64
 const MaxSegmentsPerSequencerMessage = 100 * 1024
 
-func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash common.Hash, data []byte, dapReaders []daprovider.Reader, keysetValidationMode daprovider.KeysetValidationMode) (*sequencerMessage, error) {
+// batchIssues collects the problems parsing tolerates and only logs, so that a strict check can report them.
+// A nil *batchIssues discards them.
+type batchIssues []string
+
+func (i *batchIssues) add(format string, args ...interface{}) {
+	if i != nil {
+		*i = append(*i, fmt.Sprintf(format, args...))
+	}
+}
+
+func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash common.Hash, data []byte, dapReaders []daprovider.Reader, keysetValidationMode daprovider.KeysetValidationMode, externalDA bool, issues *batchIssues) (*sequencerMessage, error) {
 	if len(data) < 40 {
 		return nil, errors.New("sequencer message missing L1 header")
 	}
@@ -75,7 +86,8 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash
 	// It's important that multiple DAS strategies can't both be invoked in the same batch,
 	// as these headers are validated by the sequencer inbox and not other DASs.
 	// We try to extract payload from the first occuring valid DA reader in the dapReaders list
-	if len(payload) > 0 {
+	// On chains without external DA its header byte is an unknown format, whatever readers this node has.
+	if len(payload) > 0 && (externalDA || !daprovider.IsExternalDAMessageHeaderByte(payload[0])) {
 		foundDA := false
 		var err error
 		for _, dapReader := range dapReaders {
@@ -90,6 +102,7 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash
 							logLevel = log.Crit
 						}
 						logLevel(err.Error())
+						issues.add("%v", err)
 					} else {
 						return nil, err
 					}
@@ -105,8 +118,11 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash
 		if !foundDA {
 			if daprovider.IsDASMessageHeaderByte(payload[0]) {
 				log.Error("No DAS Reader configured, but sequencer message found with DAS header")
+				issues.add("batch has a DAS header but no DAS reader is configured")
 			} else if daprovider.IsBlobHashesHeaderByte(payload[0]) {
 				return nil, daprovider.ErrNoBlobReader
+			} else if daprovider.IsExternalDAMessageHeaderByte(payload[0]) {
+				return nil, daprovider.ErrNoExternalDAReader
 			}
 		}
 	}
@@ -119,6 +135,7 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash
 		pl, err := io.ReadAll(io.LimitReader(zeroheavy.NewZeroheavyDecoder(bytes.NewReader(payload[1:])), int64(maxZeroheavyDecompressedLen)))
 		if err != nil {
 			log.Warn("error reading from zeroheavy decoder", err.Error())
+			issues.add("error reading from zeroheavy decoder: %v", err)
 			return parsedMsg, nil
 		}
 		payload = pl
@@ -136,24 +153,29 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, batchBlockHash
 				if err != nil {
 					if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
 						log.Warn("error parsing sequencer message segment", "err", err.Error())
+						issues.add("error parsing segment %v: %v", len(parsedMsg.segments), err)
 					}
 					break
 				}
 				if len(parsedMsg.segments) >= MaxSegmentsPerSequencerMessage {
 					log.Warn("too many segments in sequence batch")
+					issues.add("more than %v segments; the rest are ignored", MaxSegmentsPerSequencerMessage)
 					break
 				}
 				parsedMsg.segments = append(parsedMsg.segments, segment)
 			}
 		} else {
 			log.Warn("sequencer msg decompression failed", "err", err)
+			issues.add("brotli decompression failed: %v", err)
 		}
 	} else {
 		length := len(payload)
 		if length == 0 {
 			log.Warn("empty sequencer message")
+			issues.add("empty sequencer message")
 		} else {
 			log.Warn("unknown sequencer message format", "length", length, "firstByte", payload[0])
+			issues.add("unknown sequencer message format 0x%02x", payload[0])
 		}
 
 	}
@@ -172,14 +194,21 @@ type inboxMultiplexer struct {
 	cachedSegmentBlockNumber  uint64
 	cachedSubMessageNumber    uint64
 	keysetValidationMode      daprovider.KeysetValidationMode
+	delayedMessagesWindows    bool
+	externalDA                bool
+	issues                    *batchIssues
 }
 
-func NewInboxMultiplexer(backend InboxBackend, delayedMessagesRead uint64, dapReaders []daprovider.Reader, keysetValidationMode daprovider.KeysetValidationMode) arbostypes.InboxMultiplexer {
+// NewInboxMultiplexer reads messages from the backend's batches. chainConfig decides which batch format
+// extensions are understood, and may be nil when it isn't known yet, which allows none of them.
+func NewInboxMultiplexer(backend InboxBackend, delayedMessagesRead uint64, dapReaders []daprovider.Reader, keysetValidationMode daprovider.KeysetValidationMode, chainConfig *params.ChainConfig) arbostypes.InboxMultiplexer {
 	return &inboxMultiplexer{
-		backend:              backend,
-		delayedMessagesRead:  delayedMessagesRead,
-		dapReaders:           dapReaders,
-		keysetValidationMode: keysetValidationMode,
+		backend:                backend,
+		delayedMessagesRead:    delayedMessagesRead,
+		dapReaders:             dapReaders,
+		keysetValidationMode:   keysetValidationMode,
+		delayedMessagesWindows: DelayedMessagesWindowsEnabled(chainConfig),
+		externalDA:             ExternalDAEnabled(chainConfig),
 	}
 }
 
@@ -189,6 +218,56 @@ const BatchSegmentKindDelayedMessages uint8 = 2
 const BatchSegmentKindAdvanceTimestamp uint8 = 3
 const BatchSegmentKindAdvanceL1BlockNumber uint8 = 4
 
+// BatchSegmentKindDelayedMessagesWindow acknowledges the next delayed messages in one segment, issuing each as
+// its own message exactly as that many BatchSegmentKindDelayedMessages segments would. The messages are still
+// acknowledged in order: the sequencer inbox only commits to how many delayed messages a batch has read, so a
+// window can't skip one and acknowledge it later. It's only understood on chains where
+// DelayedMessagesWindowsEnabled, and is an invalid segment elsewhere, as it was before windows existed.
+const BatchSegmentKindDelayedMessagesWindow uint8 = 5
+
+// MaxDelayedMessagesWindow bounds how many delayed messages a window acknowledges,
+// so that a short segment can't expand into an unbounded number of messages.
+const MaxDelayedMessagesWindow uint64 = 1 << 16
+
+// DelayedMessagesWindowsEnabled reports whether a chain's batches may use delayed messages windows.
+// Messages are derived from a batch before ArbOS executes any of them, so this can't depend on the ArbOS
+// version the chain is running. Instead it depends on the ArbOS version the chain was created with, which is
+// part of the chain config every node and the replay binary share, so old batches are always read as before.
+func DelayedMessagesWindowsEnabled(chainConfig *params.ChainConfig) bool {
+	return chainConfig != nil && chainConfig.ArbitrumChainParams.InitialArbOSVersion >= arbosState.ArbosVersion_40
+}
+
+// ExternalDAEnabled reports whether a chain's batches may be stored with an external data availability provider.
+// Like DelayedMessagesWindowsEnabled, it depends on the chain config rather than on whether a node has a provider
+// configured, so every node and the replay binary derive the same messages from such a batch.
+func ExternalDAEnabled(chainConfig *params.ChainConfig) bool {
+	return chainConfig != nil && chainConfig.ArbitrumChainParams.InitialArbOSVersion >= arbosState.ArbosVersion_40
+}
+
+func parseDelayedMessagesWindow(data []byte) (uint64, error) {
+	count, err := rlp.NewStream(bytes.NewReader(data), 16).Uint64()
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 || count > MaxDelayedMessagesWindow {
+		return 0, fmt.Errorf("window of %v delayed messages out of bounds", count)
+	}
+	return count, nil
+}
+
+// segmentMessageCount is how many messages a segment issues: the size of a delayed messages window, or one.
+// A malformed window, or any window on a chain without them, issues a single invalid message.
+func (r *inboxMultiplexer) segmentMessageCount(segment []byte) uint64 {
+	if !r.delayedMessagesWindows || len(segment) == 0 || segment[0] != BatchSegmentKindDelayedMessagesWindow {
+		return 1
+	}
+	count, err := parseDelayedMessagesWindow(segment[1:])
+	if err != nil {
+		return 1
+	}
+	return count
+}
+
 // Pop returns the message from the top of the sequencer inbox and removes it from the queue.
 // Note: this does *not* return parse errors, those are transformed into invalid messages
 func (r *inboxMultiplexer) Pop(ctx context.Context) (*arbostypes.MessageWithMetadata, error) {
@@ -200,7 +279,7 @@ func (r *inboxMultiplexer) Pop(ctx context.Context) (*arbostypes.MessageWithMeta
 		}
 		r.cachedSequencerMessageNum = r.backend.GetSequencerInboxPosition()
 		var err error
-		r.cachedSequencerMessage, err = parseSequencerMessage(ctx, r.cachedSequencerMessageNum, batchBlockHash, bytes, r.dapReaders, r.keysetValidationMode)
+		r.cachedSequencerMessage, err = parseSequencerMessage(ctx, r.cachedSequencerMessageNum, batchBlockHash, bytes, r.dapReaders, r.keysetValidationMode, r.externalDA, r.issues)
 		if err != nil {
 			return nil, err
 		}
@@ -246,6 +325,13 @@ func (r *inboxMultiplexer) IsCachedSegementLast() bool {
 	if r.delayedMessagesRead < seqMsg.afterDelayedMessages {
 		return false
 	}
+	// a delayed messages window may have messages left to issue
+	if r.cachedSegmentNum < uint64(len(seqMsg.segments)) {
+		windowEnd := r.cachedSubMessageNumber + r.segmentMessageCount(seqMsg.segments[r.cachedSegmentNum])
+		if r.backend.GetPositionWithinMessage()+1 < windowEnd {
+			return false
+		}
+	}
 	for segmentNum := r.cachedSegmentNum + 1; segmentNum < uint64(len(seqMsg.segments)); segmentNum++ {
 		segment := seqMsg.segments[segmentNum]
 		if len(segment) == 0 {
@@ -255,7 +341,7 @@ func (r *inboxMultiplexer) IsCachedSegementLast() bool {
 		if kind == BatchSegmentKindL2Message || kind == BatchSegmentKindL2MessageBrotli {
 			return false
 		}
-		if kind == BatchSegmentKindDelayedMessages {
+		if kind == BatchSegmentKindDelayedMessages || (r.delayedMessagesWindows && kind == BatchSegmentKindDelayedMessagesWindow) {
 			return false
 		}
 	}
@@ -287,6 +373,7 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 			advancing, err := rlp.NewStream(rd, 16).Uint64()
 			if err != nil {
 				log.Warn("error parsing sequencer advancing segment", "err", err)
+				r.issues.add("error parsing advancing segment %v: %v", segmentNum, err)
 				segmentNum++
 				continue
 			}
@@ -296,9 +383,9 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 				blockNumber += advancing
 			}
 			segmentNum++
-		} else if submessageNumber < targetSubMessage {
+		} else if count := r.segmentMessageCount(segment); submessageNumber+count <= targetSubMessage {
 			segmentNum++
-			submessageNumber++
+			submessageNumber += count
 		} else {
 			break
 		}
@@ -307,11 +394,17 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 	r.cachedSegmentTimestamp = timestamp
 	r.cachedSegmentBlockNumber = blockNumber
 	r.cachedSubMessageNumber = submessageNumber
+	if timestamp < seqMsg.minTimestamp || timestamp > seqMsg.maxTimestamp {
+		r.issues.add("segment %v timestamp %v outside the batch's bounds [%v, %v]", segmentNum, timestamp, seqMsg.minTimestamp, seqMsg.maxTimestamp)
+	}
 	if timestamp < seqMsg.minTimestamp {
 		timestamp = seqMsg.minTimestamp
 	} else if timestamp > seqMsg.maxTimestamp {
 		timestamp = seqMsg.maxTimestamp
 	}
+	if blockNumber < seqMsg.minL1Block || blockNumber > seqMsg.maxL1Block {
+		r.issues.add("segment %v parent chain block %v outside the batch's bounds [%v, %v]", segmentNum, blockNumber, seqMsg.minL1Block, seqMsg.maxL1Block)
+	}
 	if blockNumber < seqMsg.minL1Block {
 		blockNumber = seqMsg.minL1Block
 	} else if blockNumber > seqMsg.maxL1Block {
@@ -320,12 +413,14 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 	if segmentNum >= uint64(len(seqMsg.segments)) {
 		// after end of batch there might be "virtual" delayedMsgSegments
 		log.Warn("reading virtual delayed message segment", "delayedMessagesRead", r.delayedMessagesRead, "afterDelayedMessages", seqMsg.afterDelayedMessages)
+		r.issues.add("batch claims %v delayed messages read but its segments only read %v", seqMsg.afterDelayedMessages, r.delayedMessagesRead)
 		segment = []byte{BatchSegmentKindDelayedMessages}
 	} else {
 		segment = seqMsg.segments[segmentNum]
 	}
 	if len(segment) == 0 {
 		log.Error("empty sequencer message segment", "sequence", r.cachedSegmentNum, "segmentNum", segmentNum)
+		r.issues.add("segment %v is empty", segmentNum)
 		return nil, nil
 	}
 	kind := segment[0]
@@ -337,6 +432,7 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 			decompressed, err := arbcompress.Decompress(segment, arbostypes.MaxL2MessageSize)
 			if err != nil {
 				log.Info("dropping compressed message", "err", err, "delayedMsg", r.delayedMessagesRead)
+				r.issues.add("segment %v brotli decompression failed: %v", segmentNum, err)
 				return nil, nil
 			}
 			segment = decompressed
@@ -356,7 +452,14 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 			},
 			DelayedMessagesRead: r.delayedMessagesRead,
 		}
-	} else if kind == BatchSegmentKindDelayedMessages {
+	} else if kind == BatchSegmentKindDelayedMessages || (r.delayedMessagesWindows && kind == BatchSegmentKindDelayedMessagesWindow) {
+		if kind == BatchSegmentKindDelayedMessagesWindow {
+			if _, err := parseDelayedMessagesWindow(segment); err != nil {
+				log.Warn("error parsing delayed messages window", "segmentNum", segmentNum, "err", err)
+				r.issues.add("segment %v has an invalid delayed messages window: %v", segmentNum, err)
+				return nil, nil
+			}
+		}
 		if r.delayedMessagesRead >= seqMsg.afterDelayedMessages {
 			if segmentNum < uint64(len(seqMsg.segments)) {
 				log.Warn(
@@ -364,6 +467,7 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 					"delayedMessagesRead", r.delayedMessagesRead,
 					"batchAfterDelayedMessages", seqMsg.afterDelayedMessages,
 				)
+				r.issues.add("segment %v reads past the batch's %v delayed messages", segmentNum, seqMsg.afterDelayedMessages)
 			}
 			msg = &arbostypes.MessageWithMetadata{
 				Message:             arbostypes.InvalidL1Message,
@@ -382,6 +486,7 @@ func (r *inboxMultiplexer) getNextMsg() (*arbostypes.MessageWithMetadata, error)
 		}
 	} else {
 		log.Error("bad sequencer message segment kind", "sequence", r.cachedSegmentNum, "segmentNum", segmentNum, "kind", kind)
+		r.issues.add("segment %v has unknown kind %v", segmentNum, kind)
 		return nil, nil
 	}
 	return msg, nil
@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbutil"
 	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/util/contracts"
 	"github.com/offchainlabs/nitro/util/signature"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
@@ -71,6 +72,8 @@ type Config struct {
 	SecondaryURL            []string                 `koanf:"secondary-url"`
 	Verify                  signature.VerifierConfig `koanf:"verify"`
 	EnableCompression       bool                     `koanf:"enable-compression" reload:"hot"`
+	EnableProtocolV2        bool                     `koanf:"enable-protocol-v2" reload:"hot"`
+	AuthTokenFile           string                   `koanf:"auth-token-file"`
 }
 
 func (c *Config) Enable() bool {
@@ -89,6 +92,8 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.StringSlice(prefix+".secondary-url", DefaultConfig.SecondaryURL, "list of secondary URLs of sequencer feed source. Would be started in the order they appear in the list when primary feeds fails")
 	signature.FeedVerifierConfigAddOptions(prefix+".verify", f)
 	f.Bool(prefix+".enable-compression", DefaultConfig.EnableCompression, "enable per message deflate compression support")
+	f.Bool(prefix+".enable-protocol-v2", DefaultConfig.EnableProtocolV2, "ask for batched, brotli compressed frames, falling back to the original protocol if the feed doesn't support them")
+	f.String(prefix+".auth-token-file", DefaultConfig.AuthTokenFile, "path to a file holding the bearer token to present to feeds requiring one (see feed-server-security.auth-token-file)")
 }
 
 var DefaultConfig = Config{
@@ -101,6 +106,8 @@ var DefaultConfig = Config{
 	SecondaryURL:            []string{},
 	Timeout:                 20 * time.Second,
 	EnableCompression:       true,
+	EnableProtocolV2:        true,
+	AuthTokenFile:           "",
 }
 
 var DefaultTestConfig = Config{
@@ -113,6 +120,8 @@ var DefaultTestConfig = Config{
 	SecondaryURL:            []string{},
 	Timeout:                 200 * time.Millisecond,
 	EnableCompression:       true,
+	EnableProtocolV2:        true,
+	AuthTokenFile:           "",
 }
 
 type TransactionStreamerInterface interface {
@@ -126,12 +135,15 @@ type BroadcastClient struct {
 	websocketUrl string
 	nextSeqNum   arbutil.MessageIndex
 	sigVerifier  *signature.Verifier
+	authToken    string
 
 	chainId uint64
 
 	// Protects conn and shuttingDown
 	connMutex sync.Mutex
 	conn      net.Conn
+	// The feed protocol version negotiated for conn, only accessed by the thread connecting and reading
+	protocolVersion int
 
 	retryCount atomic.Int64
 
@@ -157,12 +169,17 @@ func NewBroadcastClient(
 	confirmedSequencerNumberListener chan arbutil.MessageIndex,
 	fatalErrChan chan error,
 	addrVerifier contracts.AddressVerifierInterface,
+	feedSigners contracts.FeedSignerVerifierInterface,
 	adjustCount func(int32),
 ) (*BroadcastClient, error) {
-	sigVerifier, err := signature.NewVerifier(&config().Verify, addrVerifier)
+	sigVerifier, err := signature.NewVerifier(&config().Verify, addrVerifier, feedSigners)
 	if err != nil {
 		return nil, err
 	}
+	authToken, err := genericconf.ReadAuthTokenFile(config().AuthTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading feed auth token: %w", err)
+	}
 	return &BroadcastClient{
 		config:                          config,
 		websocketUrl:                    websocketUrl,
@@ -172,6 +189,7 @@ func NewBroadcastClient(
 		confirmedSequenceNumberListener: confirmedSequencerNumberListener,
 		fatalErrChan:                    fatalErrChan,
 		sigVerifier:                     sigVerifier,
+		authToken:                       authToken,
 		adjustCount:                     adjustCount,
 	}, err
 }
@@ -218,18 +236,26 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 		return nil, nil
 	}
 
-	header := ws.HandshakeHeaderHTTP(http.Header{
+	config := bc.config()
+	httpHeader := http.Header{
 		wsbroadcastserver.HTTPHeaderFeedClientVersion:       []string{strconv.Itoa(wsbroadcastserver.FeedClientVersion)},
 		wsbroadcastserver.HTTPHeaderRequestedSequenceNumber: []string{strconv.FormatUint(uint64(nextSeqNum), 10)},
-	})
+	}
+	if config.EnableProtocolV2 {
+		httpHeader[wsbroadcastserver.HTTPHeaderFeedMaxProtocolVersion] = []string{strconv.Itoa(wsbroadcastserver.FeedProtocolV2)}
+	}
+	if bc.authToken != "" {
+		httpHeader["Authorization"] = []string{"Bearer " + bc.authToken}
+	}
+	header := ws.HandshakeHeaderHTTP(httpHeader)
 
 	log.Info("connecting to arbitrum inbox message broadcaster", "url", bc.websocketUrl)
 	var foundChainId bool
 	var foundFeedServerVersion bool
 	var chainId uint64
 	var feedServerVersion uint64
+	protocolVersion := wsbroadcastserver.FeedProtocolV1
 
-	config := bc.config()
 	var extensions []httphead.Option
 	deflateExt := wsflate.DefaultParameters.Option()
 	if config.EnableCompression {
@@ -272,6 +298,15 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 					)
 					return ErrIncorrectChainId
 				}
+			} else if headerName == wsbroadcastserver.HTTPHeaderFeedProtocolVersion {
+				version, err := strconv.ParseUint(headerValue, 0, 64)
+				if err != nil {
+					return err
+				}
+				if version != wsbroadcastserver.FeedProtocolV2 || !config.EnableProtocolV2 {
+					return fmt.Errorf("feed picked unsupported protocol version %v", version)
+				}
+				protocolVersion = wsbroadcastserver.FeedProtocolV2
 			}
 			return nil
 		},
@@ -339,7 +374,8 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 	bc.connMutex.Lock()
 	bc.conn = conn
 	bc.connMutex.Unlock()
-	log.Info("Feed connected", "feedServerVersion", feedServerVersion, "chainId", chainId, "requestedSeqNum", nextSeqNum)
+	bc.protocolVersion = protocolVersion
+	log.Info("Feed connected", "feedServerVersion", feedServerVersion, "protocolVersion", protocolVersion, "chainId", chainId, "requestedSeqNum", nextSeqNum)
 
 	return earlyFrameData, nil
 }
@@ -397,10 +433,19 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 
 			if msg != nil {
 				res := m.BroadcastMessage{}
-				err = json.Unmarshal(msg, &res)
-				if err != nil {
-					log.Error("error unmarshalling message", "msg", msg, "err", err)
-					continue
+				if bc.protocolVersion >= wsbroadcastserver.FeedProtocolV2 && op == ws.OpBinary {
+					batch, err := m.DecodeBatch(msg)
+					if err != nil {
+						log.Error("error decoding feed batch", "length", len(msg), "err", err)
+						continue
+					}
+					res = *batch
+				} else {
+					err = json.Unmarshal(msg, &res)
+					if err != nil {
+						log.Error("error unmarshalling message", "msg", msg, "err", err)
+						continue
+					}
 				}
 
 				if !connected {
@@ -413,6 +458,8 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 					log.Debug("received batch item", "count", len(res.Messages), "first seq", res.Messages[0].SequenceNumber)
 				} else if res.ConfirmedSequenceNumberMessage != nil {
 					log.Debug("confirmed sequence number", "seq", res.ConfirmedSequenceNumberMessage.SequenceNumber)
+				} else if res.SequencerAuditMessage != nil {
+					log.Debug("received sequencer audit report", "signer", res.SequencerAuditMessage.Signer)
 				} else {
 					log.Debug("received broadcast with no messages populated", "length", len(msg))
 				}
@@ -114,6 +114,102 @@ func testReceiveMessages(t *testing.T, clientCompression bool, serverCompression
 
 }
 
+func TestReceiveMessagesWithProtocolV2(t *testing.T) {
+	t.Parallel()
+	testReceiveMessagesWithProtocols(t, true, true)
+}
+
+func TestReceiveMessagesWithServerOnlyProtocolV2(t *testing.T) {
+	t.Parallel()
+	testReceiveMessagesWithProtocols(t, true, false)
+}
+
+func TestReceiveMessagesWithClientOnlyProtocolV2(t *testing.T) {
+	t.Parallel()
+	testReceiveMessagesWithProtocols(t, false, true)
+}
+
+func testReceiveMessagesWithProtocols(t *testing.T, serverV2 bool, clientV2 bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broadcasterConfig := wsbroadcastserver.DefaultTestBroadcasterConfig
+	broadcasterConfig.ProtocolV2.Enable = serverV2
+	broadcasterConfig.ProtocolV2.MaxBatchSize = 16
+
+	messageCount := 100
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	feedErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &broadcasterConfig }, chainId, feedErrChan, dataSigner)
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	config := DefaultTestConfig
+	config.EnableProtocolV2 = clientV2
+	ts := NewDummyTransactionStreamer(chainId, &sequencerAddr)
+	broadcastClient, err := newTestBroadcastClient(config, b.ListenerAddr(), chainId, 0, ts, nil, feedErrChan, &sequencerAddr)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer func() {
+		// drain messages so the client isn't stuck sending them while stopping
+		clientDone := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-ts.messageReceiver:
+				case <-clientDone:
+					return
+				}
+			}
+		}()
+		broadcastClient.StopAndWait()
+		close(clientDone)
+	}()
+
+	// Send some messages before the client connects to be sent from the backlog
+	for i := 0; i < messageCount; i++ {
+		// #nosec G115
+		Require(t, b.BroadcastSingle(arbostypes.TestMessageWithMetadataAndRequestId, arbutil.MessageIndex(i), nil))
+		if i == messageCount/2 {
+			for b.ClientCount() == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	for i := 0; i < messageCount; i++ {
+		timer := time.NewTimer(10 * time.Second)
+		select {
+		case msg := <-ts.messageReceiver:
+			// #nosec G115
+			if msg.SequenceNumber != arbutil.MessageIndex(i) {
+				t.Fatalf("expected message %v but got %v", i, msg.SequenceNumber)
+			}
+		case err := <-feedErrChan:
+			t.Fatal(err)
+		case <-timer.C:
+			t.Fatalf("timed out waiting for message %v", i)
+		}
+		timer.Stop()
+	}
+
+	expectedVersion := wsbroadcastserver.FeedProtocolV1
+	if serverV2 && clientV2 {
+		expectedVersion = wsbroadcastserver.FeedProtocolV2
+	}
+	if broadcastClient.protocolVersion != expectedVersion {
+		t.Fatalf("expected protocol version %v to be negotiated but got %v", expectedVersion, broadcastClient.protocolVersion)
+	}
+}
+
 func TestInvalidSignature(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -210,7 +306,7 @@ func newTestBroadcastClient(config Config, listenerAddress net.Addr, chainId uin
 	} else {
 		config.Verify.AcceptSequencer = false
 	}
-	return NewBroadcastClient(func() *Config { return &config }, fmt.Sprintf("ws://127.0.0.1:%d/", port), chainId, currentMessageCount, txStreamer, confirmedSequenceNumberListener, feedErrChan, av, func(_ int32) {})
+	return NewBroadcastClient(func() *Config { return &config }, fmt.Sprintf("ws://127.0.0.1:%d/", port), chainId, currentMessageCount, txStreamer, confirmedSequenceNumberListener, feedErrChan, av, nil, func(_ int32) {})
 }
 
 func startMakeBroadcastClient(ctx context.Context, t *testing.T, clientConfig Config, addr net.Addr, index int, expectedCount int, chainId uint64, wg *sync.WaitGroup, sequencerAddr *common.Address) {
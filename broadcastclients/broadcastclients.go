@@ -49,7 +49,8 @@ type BroadcastClients struct {
 	secondaryRouter *Router
 
 	// Use atomic access
-	connected atomic.Int32
+	connected       atomic.Int32
+	lastMessageTime atomic.Int64 // unix nanoseconds
 }
 
 func NewBroadcastClients(
@@ -60,6 +61,7 @@ func NewBroadcastClients(
 	confirmedSequenceNumberListener chan arbutil.MessageIndex,
 	fatalErrChan chan error,
 	addrVerifier contracts.AddressVerifierInterface,
+	feedSigners contracts.FeedSignerVerifierInterface,
 ) (*BroadcastClients, error) {
 	config := configFetcher()
 	if len(config.URL) == 0 && len(config.SecondaryURL) == 0 {
@@ -90,6 +92,7 @@ func NewBroadcastClients(
 			router.confirmedSequenceNumberChan,
 			fatalErrChan,
 			addrVerifier,
+			feedSigners,
 			func(delta int32) { clients.adjustCount(delta) },
 		)
 	}
@@ -160,6 +163,7 @@ func (bcs *BroadcastClients) Start(ctx context.Context) {
 				return nil
 			}
 			recentFeedItemsNew[msg.SequenceNumber] = time.Now()
+			bcs.lastMessageTime.Store(time.Now().UnixNano())
 			if err := router.forwardTxStreamer.AddBroadcastMessages([]*m.BroadcastFeedMessage{&msg}); err != nil {
 				return err
 			}
@@ -238,6 +242,15 @@ func (bcs *BroadcastClients) Start(ctx context.Context) {
 	})
 }
 
+// LastMessageTime returns when a new message last arrived from any feed, or the zero time if none has.
+func (bcs *BroadcastClients) LastMessageTime() time.Time {
+	last := bcs.lastMessageTime.Load()
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, last)
+}
+
 func (bcs *BroadcastClients) startSecondaryFeed(ctx context.Context) {
 	pos := len(bcs.secondaryClients)
 	if pos < len(bcs.secondaryURL) {
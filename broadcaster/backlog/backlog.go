@@ -21,6 +21,7 @@ var (
 	confirmedSequenceNumberGauge = metrics.NewRegisteredGauge("arb/sequencenumber/confirmed", nil)
 	backlogSizeInBytesGauge      = metrics.NewRegisteredGauge("arb/feed/backlog/bytes", nil)
 	backlogSizeGauge             = metrics.NewRegisteredGauge("arb/feed/backlog/messages", nil)
+	backlogTrimmedCounter        = metrics.NewRegisteredCounter("arb/feed/backlog/trimmed", nil)
 )
 
 // Backlog defines the interface for backlog.
@@ -30,6 +31,9 @@ type Backlog interface {
 	Get(uint64, uint64) (*m.BroadcastMessage, error)
 	Count() uint64
 	Lookup(uint64) (BacklogSegment, error)
+	MemoryUsage() uint64
+	MemoryFloor() uint64
+	SetMemoryLimit(uint64)
 }
 
 // backlog stores backlogSegments and provides the ability to read/write
@@ -40,6 +44,7 @@ type backlog struct {
 	lookupByIndex atomic.Pointer[containers.SyncMap[uint64, *backlogSegment]]
 	config        ConfigFetcher
 	messageCount  atomic.Uint64
+	memoryLimit   atomic.Uint64
 }
 
 // NewBacklog creates a backlog.
@@ -149,11 +154,59 @@ func (b *backlog) Append(bm *m.BroadcastMessage) error {
 		backlogSizeInBytesGauge.Inc(int64(msg.Size()))
 	}
 
+	b.trimToMemoryLimit()
+
 	// #nosec G115
 	backlogSizeGauge.Update(int64(b.Count()))
 	return nil
 }
 
+// MemoryUsage returns the total size of the messages in the backlog.
+func (b *backlog) MemoryUsage() uint64 {
+	size, err := b.backlogSizeInBytes()
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// MemoryFloor returns the size of the messages which haven't been confirmed yet.
+// Confirmed messages are deleted as confirmations arrive, so that's every message
+// in the backlog, and the memory budget never asks for less than this.
+func (b *backlog) MemoryFloor() uint64 {
+	return b.MemoryUsage()
+}
+
+// SetMemoryLimit bounds the size of the backlog, 0 means unlimited. The oldest
+// segments are dropped on the next Append if over the limit. The memory budget
+// allocates at least MemoryFloor plus a margin, so that only happens if the
+// backlog outgrows the margin between rebalances, in which case clients
+// connecting far behind may need to catch up from another source.
+func (b *backlog) SetMemoryLimit(bytes uint64) {
+	b.memoryLimit.Store(bytes)
+}
+
+// trimToMemoryLimit removes whole segments from the head until the backlog fits
+// within its memory limit, always keeping the tail segment.
+func (b *backlog) trimToMemoryLimit() {
+	limit := b.memoryLimit.Load()
+	if limit == 0 {
+		return
+	}
+	for {
+		head := b.head.Load()
+		if head == nil || head == b.tail.Load() {
+			return
+		}
+		size, err := b.backlogSizeInBytes()
+		if err != nil || size <= limit {
+			return
+		}
+		b.delete(head.End())
+		backlogTrimmedCounter.Inc(1)
+	}
+}
+
 // Get reads messages from the given start to end MessageIndex.
 func (b *backlog) Get(start, end uint64) (*m.BroadcastMessage, error) {
 	head := b.head.Load()
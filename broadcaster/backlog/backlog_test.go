@@ -152,6 +152,25 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestMemoryLimit(t *testing.T) {
+	indexes := []arbutil.MessageIndex{40, 41, 42, 43, 44, 45, 46, 47, 48, 49}
+	b, err := createDummyBacklog(indexes)
+	if err != nil {
+		t.Fatalf("error creating dummy backlog: %s", err)
+	}
+	if b.MemoryUsage() == 0 {
+		t.Fatal("expected non-zero memory usage")
+	}
+	// Only takes effect on the next append, and always keeps the tail segment
+	b.SetMemoryLimit(1)
+	validateBacklog(t, b, 10, 40, 49, indexes)
+	bm := &m.BroadcastMessage{Messages: m.CreateDummyBroadcastMessages([]arbutil.MessageIndex{50})}
+	if err := b.Append(bm); err != nil {
+		t.Fatalf("error appending to backlog: %s", err)
+	}
+	validateBacklog(t, b, 2, 49, 50, []arbutil.MessageIndex{49, 50})
+}
+
 func TestDeleteInvalidBacklog(t *testing.T) {
 	// Create a backlog with an invalid sequence
 	s := &backlogSegment{
@@ -18,24 +18,37 @@ import (
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcaster/backlog"
 	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
+	"github.com/offchainlabs/nitro/util/membudget"
 	"github.com/offchainlabs/nitro/util/signature"
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
 
+// feedBacklogMemoryOpts has the memory budget allocate the feed backlog a margin on top
+// of its unconfirmed messages, so new messages aren't trimmed between rebalances.
+var feedBacklogMemoryOpts = membudget.ConsumerOpts{
+	Weight:   1,
+	MinBytes: 64 * 1024 * 1024,
+}
+
 type Broadcaster struct {
 	server     *wsbroadcastserver.WSBroadcastServer
 	backlog    backlog.Backlog
 	chainId    uint64
 	dataSigner signature.DataSignerFunc
+
+	unregisterBudget func()
 }
 
 func NewBroadcaster(config wsbroadcastserver.BroadcasterConfigFetcher, chainId uint64, feedErrChan chan error, dataSigner signature.DataSignerFunc) *Broadcaster {
 	bklg := backlog.NewBacklog(func() *backlog.Config { return &config().Backlog })
 	return &Broadcaster{
-		server:     wsbroadcastserver.NewWSBroadcastServer(config, bklg, chainId, feedErrChan),
-		backlog:    bklg,
-		chainId:    chainId,
-		dataSigner: dataSigner,
+		server:           wsbroadcastserver.NewWSBroadcastServer(config, bklg, chainId, feedErrChan),
+		backlog:          bklg,
+		chainId:          chainId,
+		dataSigner:       dataSigner,
+		unregisterBudget: membudget.Register("feed-backlog", bklg, feedBacklogMemoryOpts),
 	}
 }
 
@@ -123,6 +136,9 @@ func (b *Broadcaster) BroadcastFeedMessages(messages []*m.BroadcastFeedMessage)
 		Version:  1,
 		Messages: messages,
 	}
+	if len(messages) > 0 {
+		flightrecorder.Record(flightrecorder.KindFeedBroadcast, "first", messages[0].SequenceNumber, "count", len(messages))
+	}
 
 	b.server.Broadcast(bm)
 }
@@ -137,6 +153,14 @@ func (b *Broadcaster) Confirm(seq arbutil.MessageIndex) {
 	})
 }
 
+// PublishSequencerAudit sends a signed sequencer self-audit report to feed clients.
+func (b *Broadcaster) PublishSequencerAudit(audit *m.SequencerAuditMessage) {
+	b.server.Broadcast(&m.BroadcastMessage{
+		Version:               1,
+		SequencerAuditMessage: audit,
+	})
+}
+
 func (b *Broadcaster) ClientCount() int32 {
 	return b.server.ClientCount()
 }
@@ -150,6 +174,10 @@ func (b *Broadcaster) GetCachedMessageCount() int {
 	return int(b.backlog.Count())
 }
 
+func (b *Broadcaster) SetAuth(auth *genericconf.HTTPServerAuth) {
+	b.server.SetAuth(auth)
+}
+
 func (b *Broadcaster) Initialize() error {
 	return b.server.Initialize()
 }
@@ -163,6 +191,7 @@ func (b *Broadcaster) StartWithHeader(ctx context.Context, header ws.HandshakeHe
 }
 
 func (b *Broadcaster) StopAndWait() {
+	b.unregisterBudget()
 	b.server.StopAndWait()
 }
 
@@ -0,0 +1,70 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package message
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+)
+
+// BatchEncodingBrotliJSON is a brotli compressed JSON BroadcastMessage, prefixed by its uncompressed length.
+const BatchEncodingBrotliJSON byte = 1
+
+// MaxBatchDecodedSize bounds how much memory decoding a batch frame may use.
+const MaxBatchDecodedSize = 256 * 1024 * 1024
+
+var ErrBatchTooLarge = errors.New("feed batch too large")
+
+// EncodeBatch encodes a BroadcastMessage, which may hold many feed messages, as a protocol v2 batch frame.
+func EncodeBatch(bm *BroadcastMessage, compressionLevel uint64) ([]byte, error) {
+	encoded, err := json.Marshal(bm)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) > MaxBatchDecodedSize {
+		return nil, fmt.Errorf("%w: %v bytes", ErrBatchTooLarge, len(encoded))
+	}
+	compressed, err := arbcompress.CompressLevel(encoded, compressionLevel)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 1, 1+binary.MaxVarintLen64+len(compressed))
+	data[0] = BatchEncodingBrotliJSON
+	data = binary.AppendUvarint(data, uint64(len(encoded)))
+	return append(data, compressed...), nil
+}
+
+// DecodeBatch decodes a protocol v2 batch frame.
+func DecodeBatch(data []byte) (*BroadcastMessage, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty feed batch")
+	}
+	if data[0] != BatchEncodingBrotliJSON {
+		return nil, fmt.Errorf("unknown feed batch encoding %v", data[0])
+	}
+	size, n := binary.Uvarint(data[1:])
+	if n <= 0 {
+		return nil, errors.New("malformed feed batch length")
+	}
+	if size > MaxBatchDecodedSize {
+		return nil, fmt.Errorf("%w: %v bytes", ErrBatchTooLarge, size)
+	}
+	// #nosec G115
+	encoded, err := arbcompress.Decompress(data[1+n:], int(size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress feed batch: %w", err)
+	}
+	if uint64(len(encoded)) != size {
+		return nil, fmt.Errorf("feed batch decompressed to %v bytes but declared %v", len(encoded), size)
+	}
+	bm := &BroadcastMessage{}
+	if err := json.Unmarshal(encoded, bm); err != nil {
+		return nil, err
+	}
+	return bm, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/deterministic"
+)
+
+// EncodeDeterministic encodes the feed message with a leading version byte, using
+// the same versioning as arbostypes.EncodeMessageWithMetadata.
+func (m *BroadcastFeedMessage) EncodeDeterministic() ([]byte, error) {
+	w := deterministic.NewWriter(256)
+	w.Uint8(uint8(arbostypes.MessageEncodingDeterministicV1))
+	w.Uint64(uint64(m.SequenceNumber))
+	if err := m.Message.WriteDeterministic(w); err != nil {
+		return nil, err
+	}
+	w.Bool(m.BlockHash != nil)
+	if m.BlockHash != nil {
+		w.Fixed(m.BlockHash[:])
+	}
+	if err := w.VarBytes(m.Signature); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// DecodeBroadcastFeedMessage decodes either the JSON encoding or a versioned deterministic encoding.
+func DecodeBroadcastFeedMessage(data []byte) (*BroadcastFeedMessage, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty feed message encoding")
+	}
+	if data[0] == '{' {
+		var msg BroadcastFeedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+	}
+	switch arbostypes.MessageEncoding(data[0]) {
+	case arbostypes.MessageEncodingDeterministicV1:
+		r := deterministic.NewReader(data[1:])
+		msg := &BroadcastFeedMessage{}
+		msg.SequenceNumber = arbutil.MessageIndex(r.Uint64())
+		inner := arbostypes.ReadDeterministicMessageWithMetadata(r)
+		if inner != nil {
+			msg.Message = *inner
+		}
+		if r.Bool() {
+			var blockHash common.Hash
+			r.Fixed(blockHash[:])
+			msg.BlockHash = &blockHash
+		}
+		msg.Signature = r.VarBytes()
+		if err := r.Finish(); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("unknown feed message encoding version %v", data[0])
+	}
+}
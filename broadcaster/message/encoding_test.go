@@ -0,0 +1,127 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+func testFeedMessage() *BroadcastFeedMessage {
+	requestId := common.Hash{0: 0x12}
+	batchGasCost := uint64(100)
+	return &BroadcastFeedMessage{
+		SequenceNumber: 12345,
+		Message: arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{
+					Kind:        arbostypes.L1MessageType_BatchPostingReport,
+					Poster:      common.Address{0: 0x34},
+					BlockNumber: 10,
+					Timestamp:   20,
+					RequestId:   &requestId,
+					L1BaseFee:   big.NewInt(1_000_000_000),
+				},
+				L2msg:        []byte{0xde, 0xad, 0xbe, 0xef},
+				BatchGasCost: &batchGasCost,
+			},
+			DelayedMessagesRead: 3333,
+		},
+		BlockHash: &common.Hash{0: 0xff},
+		Signature: []byte{1, 2, 3},
+	}
+}
+
+func TestDeterministicFeedMessageEncoding(t *testing.T) {
+	msg := testFeedMessage()
+	encoded, err := msg.EncodeDeterministic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeBroadcastFeedMessage(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(msg, decoded) {
+		t.Fatalf("decoded message %+v doesn't match original %+v", decoded, msg)
+	}
+	reencoded, err := decoded.EncodeDeterministic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, reencoded) {
+		t.Fatal("re-encoding decoded message gave different bytes")
+	}
+	if _, err := DecodeBroadcastFeedMessage(append(encoded, 0)); err == nil {
+		t.Error("expected error decoding message with trailing bytes")
+	}
+	if _, err := DecodeBroadcastFeedMessage(encoded[:len(encoded)-1]); err == nil {
+		t.Error("expected error decoding truncated message")
+	}
+
+	jsonEncoded, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = DecodeBroadcastFeedMessage(jsonEncoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(msg, decoded) {
+		t.Fatalf("decoded json message %+v doesn't match original %+v", decoded, msg)
+	}
+}
+
+func TestMessageWithMetadataEncodings(t *testing.T) {
+	msg := &testFeedMessage().Message
+	for _, encoding := range []arbostypes.MessageEncoding{arbostypes.MessageEncodingRLP, arbostypes.MessageEncodingDeterministicV1} {
+		encoded, err := arbostypes.EncodeMessageWithMetadata(msg, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := arbostypes.DecodeMessageWithMetadata(encoded)
+		if err != nil {
+			t.Fatalf("decoding encoding %v: %v", encoding, err)
+		}
+		if !reflect.DeepEqual(msg, decoded) {
+			t.Fatalf("encoding %v: decoded message %+v doesn't match original %+v", encoding, decoded, msg)
+		}
+	}
+}
+
+func TestBatchEncoding(t *testing.T) {
+	first := testFeedMessage()
+	second := testFeedMessage()
+	second.SequenceNumber++
+	bm := &BroadcastMessage{
+		Version:                        V1,
+		Messages:                       []*BroadcastFeedMessage{first, second},
+		ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{SequenceNumber: 12000},
+	}
+	encoded, err := EncodeBatch(bm, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeBatch(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(bm, decoded) {
+		t.Fatalf("decoded batch %+v doesn't match original %+v", decoded, bm)
+	}
+
+	if _, err := DecodeBatch(encoded[:len(encoded)/2]); err == nil {
+		t.Fatal("expected truncated batch to be rejected")
+	}
+	encoded[0] = 0xff
+	if _, err := DecodeBatch(encoded); err == nil {
+		t.Fatal("expected unknown batch encoding to be rejected")
+	}
+}
@@ -1,7 +1,10 @@
 package message
 
 import (
+	"encoding/json"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
 )
@@ -29,6 +32,7 @@ type BroadcastMessage struct {
 	// TODO better name than messages since there are different types of messages
 	Messages                       []*BroadcastFeedMessage         `json:"messages,omitempty"`
 	ConfirmedSequenceNumberMessage *ConfirmedSequenceNumberMessage `json:"confirmedSequenceNumberMessage,omitempty"`
+	SequencerAuditMessage          *SequencerAuditMessage          `json:"sequencerAuditMessage,omitempty"`
 }
 
 type BroadcastFeedMessage struct {
@@ -56,3 +60,11 @@ func (m *BroadcastFeedMessage) Hash(chainId uint64) (common.Hash, error) {
 type ConfirmedSequenceNumberMessage struct {
 	SequenceNumber arbutil.MessageIndex `json:"sequenceNumber"`
 }
+
+// SequencerAuditMessage is a report the sequencer published on how promptly it sequenced delayed messages
+// and transactions, signed over the keccak256 of Report.
+type SequencerAuditMessage struct {
+	Report    json.RawMessage `json:"report"`
+	Signer    common.Address  `json:"signer"`
+	Signature hexutil.Bytes   `json:"signature"`
+}
@@ -0,0 +1,329 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package broadcastgossip relays signed sequencer feed messages between nodes,
+// so replicas keep receiving the feed while the central relays are unreachable.
+package broadcastgossip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/contracts"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	receivedCounter  = metrics.NewRegisteredCounter("arb/feed/gossip/received", nil)
+	duplicateCounter = metrics.NewRegisteredCounter("arb/feed/gossip/duplicate", nil)
+	invalidCounter   = metrics.NewRegisteredCounter("arb/feed/gossip/invalid", nil)
+	sentCounter      = metrics.NewRegisteredCounter("arb/feed/gossip/sent", nil)
+	droppedCounter   = metrics.NewRegisteredCounter("arb/feed/gossip/dropped", nil)
+)
+
+const gossipPath = "/gossip"
+
+type Config struct {
+	Enable        bool          `koanf:"enable"`
+	Addr          string        `koanf:"addr"`
+	Port          string        `koanf:"port"`
+	Peers         []string      `koanf:"peers"`
+	SeenCacheSize int           `koanf:"seen-cache-size"`
+	QueueSize     int           `koanf:"queue-size"`
+	Timeout       time.Duration `koanf:"timeout" reload:"hot"`
+	MaxBodySize   int64         `koanf:"max-body-size" reload:"hot"`
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.SeenCacheSize <= 0 {
+		return errors.New("feed gossip seen-cache-size must be positive")
+	}
+	if c.QueueSize <= 0 {
+		return errors.New("feed gossip queue-size must be positive")
+	}
+	return nil
+}
+
+var DefaultConfig = Config{
+	Enable:        false,
+	Addr:          "",
+	Port:          "9643",
+	Peers:         []string{},
+	SeenCacheSize: 10_000,
+	QueueSize:     1024,
+	Timeout:       5 * time.Second,
+	MaxBodySize:   32 * 1024 * 1024,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "relay signed feed messages to and from gossip peers")
+	f.String(prefix+".addr", DefaultConfig.Addr, "address to listen on for gossiped feed messages")
+	f.String(prefix+".port", DefaultConfig.Port, "port to listen on for gossiped feed messages")
+	f.StringSlice(prefix+".peers", DefaultConfig.Peers, "base URLs of the gossip peers to relay feed messages to")
+	f.Int(prefix+".seen-cache-size", DefaultConfig.SeenCacheSize, "number of recent message hashes remembered to suppress duplicates")
+	f.Int(prefix+".queue-size", DefaultConfig.QueueSize, "number of broadcasts queued per peer before new ones are dropped")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout for relaying a broadcast to a peer")
+	f.Int64(prefix+".max-body-size", DefaultConfig.MaxBodySize, "maximum size of a gossiped broadcast")
+}
+
+type ConfigFetcher func() *Config
+
+// TransactionStreamerInterface matches broadcastclient.TransactionStreamerInterface,
+// so a Gossip can sit between the feed clients and the transaction streamer.
+type TransactionStreamerInterface interface {
+	AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error
+}
+
+// Gossip delivers feed messages to the transaction streamer and relays them to its peers.
+// Messages received from peers must carry a valid signature, unlike messages from the feed clients
+// which have already been checked against the feed input's verifier config.
+type Gossip struct {
+	stopwaiter.StopWaiter
+	config      ConfigFetcher
+	chainId     uint64
+	txStreamer  TransactionStreamerInterface
+	sigVerifier *signature.Verifier
+	seen        *containers.LruCache[common.Hash, struct{}]
+	peerQueues  []chan *m.BroadcastMessage
+	client      *http.Client
+	server      *http.Server
+	listener    net.Listener
+}
+
+func New(
+	config ConfigFetcher,
+	verify *signature.VerifierConfig,
+	chainId uint64,
+	txStreamer TransactionStreamerInterface,
+	addrVerifier contracts.AddressVerifierInterface,
+	feedSigners contracts.FeedSignerVerifierInterface,
+) (*Gossip, error) {
+	// Anyone can reach the gossip port, so unsigned messages are never accepted
+	verifyConfig := *verify
+	verifyConfig.Dangerous.AcceptMissing = false
+	sigVerifier, err := signature.NewVerifier(&verifyConfig, addrVerifier, feedSigners)
+	if err != nil {
+		return nil, err
+	}
+	peerQueues := make([]chan *m.BroadcastMessage, len(config().Peers))
+	for i := range peerQueues {
+		peerQueues[i] = make(chan *m.BroadcastMessage, config().QueueSize)
+	}
+	return &Gossip{
+		config:      config,
+		chainId:     chainId,
+		txStreamer:  txStreamer,
+		sigVerifier: sigVerifier,
+		seen:        containers.NewLruCache[common.Hash, struct{}](config().SeenCacheSize),
+		peerQueues:  peerQueues,
+		client:      &http.Client{},
+	}, nil
+}
+
+func (g *Gossip) Start(ctxIn context.Context) error {
+	g.StopWaiter.Start(ctxIn, g)
+	listener, err := net.Listen("tcp", net.JoinHostPort(g.config().Addr, g.config().Port))
+	if err != nil {
+		return fmt.Errorf("error listening for feed gossip: %w", err)
+	}
+	g.listener = listener
+	mux := http.NewServeMux()
+	mux.HandleFunc(gossipPath, g.handleGossip)
+	g.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: g.config().Timeout,
+	}
+	g.LaunchThread(func(ctx context.Context) {
+		if err := g.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("feed gossip server failed", "err", err)
+		}
+	})
+	g.LaunchThread(func(ctx context.Context) {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), g.config().Timeout)
+		defer cancel()
+		if err := g.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn("error shutting down feed gossip server", "err", err)
+		}
+	})
+	for i, peer := range g.config().Peers {
+		queue := g.peerQueues[i]
+		url := peer + gossipPath
+		g.LaunchThread(func(ctx context.Context) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-queue:
+					if err := g.send(ctx, url, msg); err != nil {
+						log.Debug("error relaying feed messages to gossip peer", "peer", peer, "err", err)
+						continue
+					}
+					sentCounter.Inc(int64(len(msg.Messages)))
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// ListenAddr returns the address the gossip server is listening on, or nil before it's started.
+func (g *Gossip) ListenAddr() net.Addr {
+	if g.listener == nil {
+		return nil
+	}
+	return g.listener.Addr()
+}
+
+// AddBroadcastMessages takes messages from the feed clients, which have already verified them.
+func (g *Gossip) AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error {
+	fresh := g.filterSeen(feedMessages)
+	g.relay(fresh)
+	return g.txStreamer.AddBroadcastMessages(feedMessages)
+}
+
+func (g *Gossip) handleGossip(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var msg m.BroadcastMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, g.config().MaxBodySize)).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	receivedCounter.Inc(int64(len(msg.Messages)))
+	var verified []*m.BroadcastFeedMessage
+	for _, feedMessage := range msg.Messages {
+		if feedMessage == nil || feedMessage.Message.Message == nil || feedMessage.Message.Message.Header == nil {
+			invalidCounter.Inc(1)
+			continue
+		}
+		hash, err := feedMessage.Hash(g.chainId)
+		if err != nil {
+			invalidCounter.Inc(1)
+			continue
+		}
+		// Skip verifying messages we've already got
+		if g.seen.Contains(hash) {
+			duplicateCounter.Inc(1)
+			continue
+		}
+		if err := g.sigVerifier.VerifyHash(r.Context(), feedMessage.Signature, hash); err != nil {
+			invalidCounter.Inc(1)
+			log.Warn("dropping invalid gossiped feed message", "sequenceNumber", feedMessage.SequenceNumber, "remote", r.RemoteAddr, "err", err)
+			continue
+		}
+		verified = append(verified, feedMessage)
+	}
+	fresh := g.filterSeen(verified)
+	g.relay(fresh)
+	for _, run := range contiguousRuns(fresh) {
+		if err := g.txStreamer.AddBroadcastMessages(run); err != nil {
+			log.Warn("error adding gossiped feed messages", "sequenceNumber", run[0].SequenceNumber, "err", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// filterSeen returns the messages not seen before, remembering them.
+func (g *Gossip) filterSeen(feedMessages []*m.BroadcastFeedMessage) []*m.BroadcastFeedMessage {
+	var fresh []*m.BroadcastFeedMessage
+	for _, feedMessage := range feedMessages {
+		hash, err := feedMessage.Hash(g.chainId)
+		if err != nil {
+			continue
+		}
+		if g.seen.Contains(hash) {
+			duplicateCounter.Inc(1)
+			continue
+		}
+		g.seen.Add(hash, struct{}{})
+		fresh = append(fresh, feedMessage)
+	}
+	return fresh
+}
+
+func (g *Gossip) relay(feedMessages []*m.BroadcastFeedMessage) {
+	if !g.Started() || g.Stopped() {
+		return
+	}
+	// Peers would reject unsigned messages, which the feed input may accept
+	var signed []*m.BroadcastFeedMessage
+	for _, feedMessage := range feedMessages {
+		if len(feedMessage.Signature) > 0 {
+			signed = append(signed, feedMessage)
+		}
+	}
+	if len(signed) == 0 {
+		return
+	}
+	msg := &m.BroadcastMessage{
+		Version:  m.V1,
+		Messages: signed,
+	}
+	for _, queue := range g.peerQueues {
+		select {
+		case queue <- msg:
+		default:
+			droppedCounter.Inc(int64(len(signed)))
+		}
+	}
+}
+
+func (g *Gossip) send(ctx context.Context, url string, msg *m.BroadcastMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, g.config().Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// contiguousRuns splits messages into runs of consecutive sequence numbers,
+// as the transaction streamer expects.
+func contiguousRuns(feedMessages []*m.BroadcastFeedMessage) [][]*m.BroadcastFeedMessage {
+	var runs [][]*m.BroadcastFeedMessage
+	start := 0
+	for i := 1; i <= len(feedMessages); i++ {
+		if i == len(feedMessages) || feedMessages[i].SequenceNumber != feedMessages[i-1].SequenceNumber+1 {
+			runs = append(runs, feedMessages[start:i])
+			start = i
+		}
+	}
+	return runs
+}
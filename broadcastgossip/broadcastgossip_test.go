@@ -0,0 +1,151 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcastgossip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type mockTxStreamer struct {
+	mutex    sync.Mutex
+	received []arbutil.MessageIndex
+}
+
+func (s *mockTxStreamer) AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, feedMessage := range feedMessages {
+		s.received = append(s.received, feedMessage.SequenceNumber)
+	}
+	return nil
+}
+
+func (s *mockTxStreamer) Received() []arbutil.MessageIndex {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]arbutil.MessageIndex{}, s.received...)
+}
+
+func TestGossipRelaysSignedMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+	badKey, err := crypto.GenerateKey()
+	Require(t, err)
+	verify := signature.TestingFeedVerifierConfig
+	verify.AllowedAddresses = []string{crypto.PubkeyToAddress(privateKey.PublicKey).Hex()}
+	// The feed input's choice to accept missing signatures doesn't apply to gossip
+	verify.Dangerous.AcceptMissing = true
+
+	newMessage := func(seqNum arbutil.MessageIndex, signer signature.DataSignerFunc) *m.BroadcastFeedMessage {
+		feedMessage := &m.BroadcastFeedMessage{
+			SequenceNumber: seqNum,
+			Message:        arbostypes.TestMessageWithMetadataAndRequestId,
+		}
+		if signer != nil {
+			hash, err := feedMessage.Hash(chainId)
+			Require(t, err)
+			feedMessage.Signature, err = signer(hash.Bytes())
+			Require(t, err)
+		}
+		return feedMessage
+	}
+
+	receiverConfig := DefaultConfig
+	receiverConfig.Enable = true
+	receiverConfig.Port = "0"
+	receiverStreamer := &mockTxStreamer{}
+	receiver, err := New(func() *Config { return &receiverConfig }, &verify, chainId, receiverStreamer, nil, nil)
+	Require(t, err)
+	Require(t, receiver.Start(ctx))
+	defer receiver.StopAndWait()
+	receiverURL := "http://" + receiver.ListenAddr().String()
+
+	senderConfig := receiverConfig
+	senderConfig.Peers = []string{receiverURL}
+	senderStreamer := &mockTxStreamer{}
+	sender, err := New(func() *Config { return &senderConfig }, &verify, chainId, senderStreamer, nil, nil)
+	Require(t, err)
+	Require(t, sender.Start(ctx))
+	defer sender.StopAndWait()
+
+	// Feed clients hand the sender a signed and an unsigned message; only the signed one is relayed
+	Require(t, sender.AddBroadcastMessages([]*m.BroadcastFeedMessage{newMessage(0, dataSigner), newMessage(1, nil)}))
+	waitForReceived(t, receiverStreamer, 1)
+	if received := senderStreamer.Received(); len(received) != 2 {
+		Fail(t, "sender didn't pass feed messages to its transaction streamer", received)
+	}
+
+	// Messages signed by unknown keys are dropped, and duplicates are delivered once
+	post := func(feedMessages ...*m.BroadcastFeedMessage) {
+		body, err := json.Marshal(&m.BroadcastMessage{Version: m.V1, Messages: feedMessages})
+		Require(t, err)
+		resp, err := http.Post(receiverURL+gossipPath, "application/json", bytes.NewReader(body))
+		Require(t, err)
+		Require(t, resp.Body.Close())
+	}
+	post(newMessage(2, signature.DataSignerFromPrivateKey(badKey)), newMessage(3, nil))
+	post(newMessage(0, dataSigner), newMessage(4, dataSigner))
+	received := waitForReceived(t, receiverStreamer, 2)
+	if received[0] != 0 || received[1] != 4 {
+		Fail(t, "unexpected messages received", received)
+	}
+}
+
+func waitForReceived(t *testing.T, streamer *mockTxStreamer, count int) []arbutil.MessageIndex {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if received := streamer.Received(); len(received) >= count {
+			if len(received) > count {
+				Fail(t, "received too many messages", received)
+			}
+			return received
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	Fail(t, "timed out waiting for", count, "messages, got", streamer.Received())
+	return nil
+}
+
+func TestContiguousRuns(t *testing.T) {
+	var feedMessages []*m.BroadcastFeedMessage
+	for _, seqNum := range []arbutil.MessageIndex{3, 4, 6, 8, 9} {
+		feedMessages = append(feedMessages, &m.BroadcastFeedMessage{SequenceNumber: seqNum})
+	}
+	runs := contiguousRuns(feedMessages)
+	if len(runs) != 3 || len(runs[0]) != 2 || len(runs[1]) != 1 || len(runs[2]) != 2 {
+		Fail(t, "unexpected runs", runs)
+	}
+	if len(contiguousRuns(nil)) != 0 {
+		Fail(t, "expected no runs without messages")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
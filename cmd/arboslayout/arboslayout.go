@@ -0,0 +1,65 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// arboslayout writes the top-level layout of ArbOS's storage as JSON, either for one ArbOS version or for
+// every supported version, so layout changes can be reviewed and diffed.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type versionLayout struct {
+	ArbosVersion uint64                   `json:"arbosVersion"`
+	Entries      []arbosState.LayoutEntry `json:"entries"`
+}
+
+func run(args []string) error {
+	f := flag.NewFlagSet("arboslayout", flag.ContinueOnError)
+	arbosVersion := f.Uint64("arbos-version", 0, "ArbOS version to write the layout of (0 = every supported version)")
+	debug := f.Bool("debug", false, "include ArbOS versions only debug chains support")
+	output := f.String("output", "", "file to write the layout to (defaults to stdout)")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	var layouts []versionLayout
+	if *arbosVersion != 0 {
+		layouts = append(layouts, versionLayout{*arbosVersion, arbosState.StorageLayout(*arbosVersion)})
+	} else {
+		maxVersion := arbosState.MaxArbosVersionSupported
+		if *debug {
+			maxVersion = arbosState.MaxDebugArbosVersionSupported
+		}
+		for version := uint64(1); version <= maxVersion; version++ {
+			layouts = append(layouts, versionLayout{version, arbosState.StorageLayout(version)})
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(layouts)
+}
@@ -0,0 +1,406 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// batchcheck strictly decodes a single sequencer batch, read from the parent chain or a file, and prints
+// the L2 transactions it contains along with every framing or delayed message problem a node would
+// silently tolerate. It exits with status 2 if the batch has any problem.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+type BatchCheckConfig struct {
+	ChainID             uint64        `koanf:"chain-id"`
+	Batch               uint64        `koanf:"batch"`
+	ParentChainURL      string        `koanf:"parent-chain-url"`
+	ParentChainBlock    uint64        `koanf:"parent-chain-block"`
+	SequencerInbox      string        `koanf:"sequencer-inbox"`
+	BeaconURL           string        `koanf:"beacon-url"`
+	DASURLs             []string      `koanf:"das-url"`
+	File                string        `koanf:"file"`
+	DelayedMessagesRead uint64        `koanf:"delayed-messages-read"`
+	ChainInfoFiles      []string      `koanf:"chain-info-files"`
+	SearchWindow        uint64        `koanf:"search-window"`
+	MaxSearchWindows    uint64        `koanf:"max-search-windows"`
+	JSON                bool          `koanf:"json"`
+	Timeout             time.Duration `koanf:"timeout"`
+}
+
+var DefaultBatchCheckConfig = BatchCheckConfig{
+	SearchWindow:     10000,
+	MaxSearchWindows: 100,
+	Timeout:          10 * time.Minute,
+}
+
+func parseBatchCheckConfig(args []string) (*BatchCheckConfig, error) {
+	f := flag.NewFlagSet("batchcheck", flag.ContinueOnError)
+	f.Uint64("chain-id", DefaultBatchCheckConfig.ChainID, "chain id of the chain the batch was posted for (required)")
+	f.Uint64("batch", DefaultBatchCheckConfig.Batch, "sequence number of the batch")
+	f.String("parent-chain-url", DefaultBatchCheckConfig.ParentChainURL, "parent chain RPC URL to read the batch from")
+	f.Uint64("parent-chain-block", DefaultBatchCheckConfig.ParentChainBlock, "parent chain block the batch was posted in")
+	f.String("sequencer-inbox", DefaultBatchCheckConfig.SequencerInbox, "address of the chain's sequencer inbox on the parent chain")
+	f.String("beacon-url", DefaultBatchCheckConfig.BeaconURL, "beacon chain URL to read blob batches from")
+	f.StringSlice("das-url", DefaultBatchCheckConfig.DASURLs, "REST URLs of data availability servers to read batches posted as DAS certificates from")
+	f.String("file", DefaultBatchCheckConfig.File, "instead of reading the batch from the parent chain, read it from this file, hex encoded with its 40 byte header")
+	f.Uint64("delayed-messages-read", DefaultBatchCheckConfig.DelayedMessagesRead, "delayed messages read before the batch when reading it from a file")
+	f.StringSlice("chain-info-files", DefaultBatchCheckConfig.ChainInfoFiles, "files with the chain's info, if it isn't a known chain, to read the batch formats it allows from")
+	f.Uint64("search-window", DefaultBatchCheckConfig.SearchWindow, "parent chain blocks to search at a time for the previous batch")
+	f.Uint64("max-search-windows", DefaultBatchCheckConfig.MaxSearchWindows, "maximum number of windows to search for the previous batch")
+	f.Bool("json", DefaultBatchCheckConfig.JSON, "print the result as JSON")
+	f.Duration("timeout", DefaultBatchCheckConfig.Timeout, "timeout for checking the batch")
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config BatchCheckConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.ChainID == 0 {
+		return nil, errors.New("--chain-id is required")
+	}
+	if (config.File == "") == (config.ParentChainURL == "") {
+		return nil, errors.New("exactly one of --file and --parent-chain-url is required")
+	}
+	if config.ParentChainURL != "" && (!common.IsHexAddress(config.SequencerInbox) || config.ParentChainBlock == 0) {
+		return nil, errors.New("reading from the parent chain needs --sequencer-inbox and --parent-chain-block")
+	}
+	if config.SearchWindow == 0 {
+		return nil, errors.New("--search-window must be positive")
+	}
+	return &config, nil
+}
+
+// dasReaders reads from the first data availability server that has the data.
+type dasReaders []daprovider.DASReader
+
+func (r dasReaders) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	var errs []error
+	for _, reader := range r {
+		data, err := reader.GetByHash(ctx, hash)
+		if err == nil {
+			return data, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no data availability server has %v: %w", hash, errors.Join(errs...))
+}
+
+func (r dasReaders) ExpirationPolicy(ctx context.Context) (daprovider.ExpirationPolicy, error) {
+	return r[0].ExpirationPolicy(ctx)
+}
+
+// parentChainBatch is a batch as posted to the parent chain, with what the chain says about its
+// delayed messages.
+type parentChainBatch struct {
+	data                []byte
+	blockHash           common.Hash
+	delayedMessagesRead uint64
+	issues              []string
+}
+
+// findBatch looks for a batch posted at or before toBlock, searching back one window at a time.
+func findBatch(ctx context.Context, inbox *arbnode.SequencerInbox, seqNum uint64, toBlock uint64, config *BatchCheckConfig) (*arbnode.SequencerInboxBatch, error) {
+	for window := uint64(0); window < config.MaxSearchWindows; window++ {
+		var from uint64
+		if toBlock >= config.SearchWindow {
+			from = toBlock - config.SearchWindow + 1
+		}
+		batches, err := inbox.LookupBatchesInRange(ctx, new(big.Int).SetUint64(from), new(big.Int).SetUint64(toBlock))
+		if err != nil {
+			return nil, err
+		}
+		for _, batch := range batches {
+			if batch.SequenceNumber == seqNum {
+				return batch, nil
+			}
+		}
+		if from == 0 || (len(batches) > 0 && batches[0].SequenceNumber < seqNum) {
+			break
+		}
+		toBlock = from - 1
+	}
+	return nil, fmt.Errorf("batch %v not found", seqNum)
+}
+
+// readParentChainBatch reads a batch and checks its delayed message accumulator against the bridge's.
+func readParentChainBatch(ctx context.Context, config *BatchCheckConfig, client *ethclient.Client, inbox *arbnode.SequencerInbox, bridge *arbnode.DelayedBridge) (*parentChainBatch, error) {
+	batch, err := findBatch(ctx, inbox, config.Batch, config.ParentChainBlock, &BatchCheckConfig{SearchWindow: 1, MaxSearchWindows: 1})
+	if err != nil {
+		return nil, fmt.Errorf("%w in parent chain block %v", err, config.ParentChainBlock)
+	}
+	data, err := batch.Serialize(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	result := &parentChainBatch{data: data, blockHash: batch.BlockHash}
+	if config.Batch > 0 {
+		prev, err := findBatch(ctx, inbox, config.Batch-1, batch.ParentChainBlockNumber, config)
+		if err != nil {
+			return nil, fmt.Errorf("looking for the previous batch: %w", err)
+		}
+		result.delayedMessagesRead = prev.AfterDelayedCount
+	}
+	if batch.AfterDelayedCount > 0 {
+		acc, err := bridge.GetAccumulator(ctx, batch.AfterDelayedCount-1, nil, batch.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if acc != batch.AfterDelayedAcc {
+			result.issues = append(result.issues, fmt.Sprintf("batch's delayed accumulator %v doesn't match the bridge's %v for delayed message %v", batch.AfterDelayedAcc, acc, batch.AfterDelayedCount-1))
+		}
+	}
+	return result, nil
+}
+
+type checkedTx struct {
+	Hash  common.Hash     `json:"hash"`
+	Type  uint8           `json:"type"`
+	From  *common.Address `json:"from,omitempty"`
+	To    *common.Address `json:"to,omitempty"`
+	Nonce uint64          `json:"nonce"`
+	Value *hexutil.Big    `json:"value"`
+	Gas   uint64          `json:"gas"`
+}
+
+type checkedMessage struct {
+	Index               int         `json:"index"`
+	Kind                uint8       `json:"kind"`
+	Timestamp           uint64      `json:"timestamp"`
+	ParentChainBlock    uint64      `json:"parentChainBlock"`
+	DelayedMessage      *uint64     `json:"delayedMessage,omitempty"` // set for delayed messages
+	Transactions        []checkedTx `json:"transactions,omitempty"`
+	DelayedMessagesRead uint64      `json:"delayedMessagesRead"`
+}
+
+type checkResult struct {
+	*arbstate.BatchCheck
+	Messages []checkedMessage `json:"messages"`
+}
+
+// decodeMessages lists the transactions of each message, adding any message that doesn't parse to issues.
+func decodeMessages(check *arbstate.BatchCheck, chainId *big.Int) ([]checkedMessage, []string) {
+	signer := types.LatestSignerForChainID(chainId)
+	var messages []checkedMessage
+	var issues []string
+	prevDelayed := check.DelayedMessagesRead
+	for i, msg := range check.Messages {
+		header := msg.Message.Header
+		checked := checkedMessage{
+			Index:               i,
+			Kind:                header.Kind,
+			Timestamp:           header.Timestamp,
+			ParentChainBlock:    header.BlockNumber,
+			DelayedMessagesRead: msg.DelayedMessagesRead,
+		}
+		if msg.DelayedMessagesRead == prevDelayed+1 {
+			checked.DelayedMessage = &prevDelayed
+		} else if header.Kind == arbostypes.L1MessageType_L2Message {
+			txs, err := arbos.ParseL2Transactions(msg.Message, chainId)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("message %v doesn't parse: %v", i, err))
+			}
+			for _, tx := range txs {
+				checkedTx := checkedTx{
+					Hash:  tx.Hash(),
+					Type:  tx.Type(),
+					To:    tx.To(),
+					Nonce: tx.Nonce(),
+					Value: (*hexutil.Big)(tx.Value()),
+					Gas:   tx.Gas(),
+				}
+				if from, err := types.Sender(signer, tx); err == nil {
+					checkedTx.From = &from
+				} else {
+					issues = append(issues, fmt.Sprintf("message %v transaction %v has an invalid signature: %v", i, tx.Hash(), err))
+				}
+				checked.Transactions = append(checked.Transactions, checkedTx)
+			}
+		} else if header.Kind == arbostypes.L1MessageType_Invalid {
+			issues = append(issues, fmt.Sprintf("message %v is invalid and will be an empty block", i))
+		}
+		prevDelayed = msg.DelayedMessagesRead
+		messages = append(messages, checked)
+	}
+	return messages, issues
+}
+
+func printText(w io.Writer, result *checkResult) {
+	check := result.BatchCheck
+	fmt.Fprintf(w, "batch %v: timestamps [%v, %v], parent chain blocks [%v, %v], delayed messages read %v -> %v\n",
+		check.BatchNum, check.MinTimestamp, check.MaxTimestamp, check.MinL1Block, check.MaxL1Block, check.DelayedMessagesRead, check.AfterDelayedMessages)
+	for _, msg := range result.Messages {
+		if msg.DelayedMessage != nil {
+			fmt.Fprintf(w, "message %v: delayed message %v\n", msg.Index, *msg.DelayedMessage)
+			continue
+		}
+		fmt.Fprintf(w, "message %v: kind %v, timestamp %v, parent chain block %v, %v transactions\n", msg.Index, msg.Kind, msg.Timestamp, msg.ParentChainBlock, len(msg.Transactions))
+		for _, tx := range msg.Transactions {
+			from, to := "unknown", "contract creation"
+			if tx.From != nil {
+				from = tx.From.Hex()
+			}
+			if tx.To != nil {
+				to = tx.To.Hex()
+			}
+			fmt.Fprintf(w, "  %v type %v from %v to %v nonce %v value %v gas %v\n", tx.Hash, tx.Type, from, to, tx.Nonce, tx.Value.ToInt(), tx.Gas)
+		}
+	}
+	if len(check.Issues) == 0 {
+		fmt.Fprintln(w, "no issues")
+		return
+	}
+	fmt.Fprintf(w, "%v issues:\n", len(check.Issues))
+	for _, issue := range check.Issues {
+		fmt.Fprintf(w, "  %v\n", issue)
+	}
+}
+
+func run(ctx context.Context, config *BatchCheckConfig, w io.Writer) (bool, error) {
+	var batch *parentChainBatch
+	var dapReaders []daprovider.Reader
+	if config.File != "" {
+		contents, err := os.ReadFile(config.File)
+		if err != nil {
+			return false, err
+		}
+		data, err := hexutil.Decode(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return false, fmt.Errorf("batch file isn't hex encoded: %w", err)
+		}
+		batch = &parentChainBatch{data: data, delayedMessagesRead: config.DelayedMessagesRead}
+	} else {
+		client, err := ethclient.DialContext(ctx, config.ParentChainURL)
+		if err != nil {
+			return false, err
+		}
+		defer client.Close()
+		inboxAddr := common.HexToAddress(config.SequencerInbox)
+		inbox, err := arbnode.NewSequencerInbox(client, inboxAddr, 0)
+		if err != nil {
+			return false, err
+		}
+		inboxContract, err := bridgegen.NewSequencerInbox(inboxAddr, client)
+		if err != nil {
+			return false, err
+		}
+		bridgeAddr, err := inboxContract.Bridge(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return false, err
+		}
+		bridge, err := arbnode.NewDelayedBridge(client, bridgeAddr, 0)
+		if err != nil {
+			return false, err
+		}
+		batch, err = readParentChainBatch(ctx, config, client, inbox, bridge)
+		if err != nil {
+			return false, err
+		}
+		if len(config.DASURLs) > 0 {
+			var readers dasReaders
+			for _, url := range config.DASURLs {
+				reader, err := das.NewRestfulDasClientFromURL(url)
+				if err != nil {
+					return false, err
+				}
+				readers = append(readers, reader)
+			}
+			keysetFetcher, err := das.NewKeysetFetcher(client, inboxAddr)
+			if err != nil {
+				return false, err
+			}
+			dapReaders = append(dapReaders, daprovider.NewReaderForDAS(readers, keysetFetcher))
+		}
+		if config.BeaconURL != "" {
+			blobClient, err := headerreader.NewBlobClient(headerreader.BlobClientConfig{BeaconUrl: config.BeaconURL}, client)
+			if err != nil {
+				return false, err
+			}
+			if err := blobClient.Initialize(ctx); err != nil {
+				return false, err
+			}
+			dapReaders = append(dapReaders, daprovider.NewReaderForBlobReader(blobClient))
+		}
+	}
+
+	// Delayed messages aren't part of the batch, only their count is
+	readDelayed := func(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+		return &arbostypes.L1IncomingMessage{
+			Header: &arbostypes.L1IncomingMessageHeader{
+				Kind:      arbostypes.L1MessageType_EndOfBlock,
+				RequestId: &common.Hash{},
+				L1BaseFee: common.Big0,
+			},
+		}, nil
+	}
+	chainConfig, err := chaininfo.GetChainConfig(new(big.Int).SetUint64(config.ChainID), "", 0, config.ChainInfoFiles, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, batches using chain specific formats will be reported as malformed\n", err)
+		chainConfig = nil
+	}
+	check, err := arbstate.CheckBatch(ctx, config.Batch, batch.blockHash, batch.data, batch.delayedMessagesRead, dapReaders, readDelayed, chainConfig)
+	if err != nil {
+		return false, err
+	}
+	check.Issues = append(batch.issues, check.Issues...)
+	messages, issues := decodeMessages(check, new(big.Int).SetUint64(config.ChainID))
+	check.Issues = append(check.Issues, issues...)
+	result := &checkResult{BatchCheck: check, Messages: messages}
+
+	if config.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return false, err
+		}
+	} else {
+		printText(w, result)
+	}
+	return check.Strict(), nil
+}
+
+func main() {
+	config, err := parseBatchCheckConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	strict, err := run(ctx, config, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if !strict {
+		os.Exit(2)
+	}
+}
@@ -31,6 +31,12 @@ type ChainInfo struct {
 	HasGenesisState           bool                `json:"has-genesis-state"`
 	ChainConfig               *params.ChainConfig `json:"chain-config"`
 	RollupAddresses           *RollupAddresses    `json:"rollup"`
+	// Recommended node options for the chain, keyed by option name, which user supplied options take precedence over
+	NodeConfigPreset map[string]interface{} `json:"node-config-preset"`
+	// Node options which must have these values to follow the chain
+	RequiredNodeConfig map[string]interface{} `json:"required-node-config"`
+	// Contracts in the genesis state, so a new chain starts with them at fixed addresses
+	Predeploys []Predeploy `json:"predeploys"`
 }
 
 func GetChainConfig(chainId *big.Int, chainName string, genesisBlockNum uint64, l2ChainInfoFiles []string, l2ChainInfoJson string) (*params.ChainConfig, error) {
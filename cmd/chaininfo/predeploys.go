@@ -0,0 +1,72 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package chaininfo
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Addresses up to this one are reserved for Ethereum's and ArbOS's precompiles
+const maxReservedAddress = 0xffff
+
+// arbosStateAddress is the fictional account holding ArbOS's storage
+var arbosStateAddress = common.HexToAddress("0xA4B05FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+
+// Predeploy is an account in a new chain's genesis state.
+type Predeploy struct {
+	Address common.Address              `json:"address"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+}
+
+func GetPredeploys(chainId uint64, chainName string, l2ChainInfoFiles []string, l2ChainInfoJson string) ([]Predeploy, error) {
+	chainInfo, err := ProcessChainInfo(chainId, chainName, l2ChainInfoFiles, l2ChainInfoJson)
+	if err != nil {
+		return nil, err
+	}
+	return chainInfo.Predeploys, nil
+}
+
+// ValidatePredeploys checks predeploys can be put in the genesis state of a chain with the given config.
+func ValidatePredeploys(predeploys []Predeploy, chainConfig *params.ChainConfig) error {
+	maxCodeSize := uint64(params.MaxCodeSize)
+	if chainConfig.ArbitrumChainParams.MaxCodeSize != 0 {
+		maxCodeSize = chainConfig.ArbitrumChainParams.MaxCodeSize
+	}
+	seen := make(map[common.Address]struct{}, len(predeploys))
+	for _, predeploy := range predeploys {
+		addr := predeploy.Address
+		if addr.Big().IsUint64() && addr.Big().Uint64() <= maxReservedAddress {
+			return fmt.Errorf("predeploy address %v is reserved for precompiles", addr)
+		}
+		if addr == types.ArbosAddress || addr == arbosStateAddress {
+			return fmt.Errorf("predeploy address %v is reserved for ArbOS", addr)
+		}
+		if _, exists := seen[addr]; exists {
+			return fmt.Errorf("duplicate predeploy address %v", addr)
+		}
+		seen[addr] = struct{}{}
+		if uint64(len(predeploy.Code)) > maxCodeSize {
+			return fmt.Errorf("predeploy %v has %v bytes of code, more than the limit of %v", addr, len(predeploy.Code), maxCodeSize)
+		}
+		if len(predeploy.Code) == 0 && len(predeploy.Storage) > 0 {
+			return fmt.Errorf("predeploy %v has storage but no code", addr)
+		}
+		if len(predeploy.Code) > 0 && predeploy.Code[0] == 0xef {
+			// EIP-3541 rejects new code starting with 0xEF, which also marks Stylus programs needing activation
+			return fmt.Errorf("predeploy %v has code starting with 0xEF", addr)
+		}
+		if predeploy.Balance != nil && predeploy.Balance.ToInt().Sign() < 0 {
+			return fmt.Errorf("predeploy %v has a negative balance", addr)
+		}
+	}
+	return nil
+}
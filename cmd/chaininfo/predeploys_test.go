@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package chaininfo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPredeploys(t *testing.T) {
+	infoJson := `[{
+		"chain-name": "predeploys-test",
+		"chain-config": {"chainId": 412346, "arbitrum": {"EnableArbOS": true}},
+		"predeploys": [{
+			"address": "0xca11bde05977b3631167028862be2a173976ca11",
+			"code": "0x6080",
+			"storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"},
+			"balance": "0x10",
+			"nonce": 1
+		}]
+	}]`
+	predeploys, err := GetPredeploys(412346, "", nil, infoJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(predeploys) != 1 {
+		t.Fatal("expected one predeploy but got", predeploys)
+	}
+	predeploy := predeploys[0]
+	if predeploy.Address != common.HexToAddress("0xca11bde05977b3631167028862be2a173976ca11") || len(predeploy.Code) != 2 || predeploy.Nonce != 1 || predeploy.Balance.ToInt().Uint64() != 16 {
+		t.Error("unexpected predeploy", predeploy)
+	}
+	if predeploy.Storage[common.BigToHash(common.Big1)] != common.BigToHash(common.Big2) {
+		t.Error("unexpected predeploy storage", predeploy.Storage)
+	}
+
+	chainConfig := &params.ChainConfig{ArbitrumChainParams: params.ArbitrumChainParams{MaxCodeSize: 4}}
+	if err := ValidatePredeploys(predeploys, chainConfig); err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string]Predeploy{
+		"reserved for precompiles": {Address: common.HexToAddress("0x64")},
+		"reserved for ArbOS":       {Address: types.ArbosAddress},
+		"more than the limit":      {Address: predeploy.Address, Code: []byte{1, 2, 3, 4, 5}},
+		"storage but no code":      {Address: predeploy.Address, Storage: predeploy.Storage},
+		"starting with 0xEF":       {Address: predeploy.Address, Code: []byte{0xef, 0}},
+	}
+	for expected, predeploy := range invalid {
+		err := ValidatePredeploys([]Predeploy{predeploy}, chainConfig)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q but got %v", expected, err)
+		}
+	}
+	err = ValidatePredeploys([]Predeploy{predeploy, predeploy}, chainConfig)
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Error("expected duplicate predeploys to be rejected but got", err)
+	}
+}
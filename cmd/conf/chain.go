@@ -56,6 +56,7 @@ type L2Config struct {
 	Name      string                   `koanf:"name"`
 	InfoFiles []string                 `koanf:"info-files"`
 	InfoJson  string                   `koanf:"info-json"`
+	Preset    bool                     `koanf:"preset"`
 	DevWallet genericconf.WalletConfig `koanf:"dev-wallet"`
 }
 
@@ -64,6 +65,7 @@ var L2ConfigDefault = L2Config{
 	Name:      "",
 	InfoFiles: []string{}, // Default file used is chaininfo/arbitrum_chain_info.json, stored in DefaultChainInfo in chain_info.go
 	InfoJson:  "",
+	Preset:    true,
 	DevWallet: genericconf.WalletConfigDefault,
 }
 
@@ -72,6 +74,7 @@ func L2ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".name", L2ConfigDefault.Name, "L2 chain name (determines Arbitrum network)")
 	f.StringSlice(prefix+".info-files", L2ConfigDefault.InfoFiles, "L2 chain info json files")
 	f.String(prefix+".info-json", L2ConfigDefault.InfoJson, "L2 chain info in json string format")
+	f.Bool(prefix+".preset", L2ConfigDefault.Preset, "apply the recommended node configuration from the chain info, if it has one")
 
 	// Dev wallet does not exist unless specified
 	genericconf.WalletConfigAddOptions(prefix+".dev-wallet", f, "")
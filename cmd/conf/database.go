@@ -4,11 +4,13 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"time"
 
@@ -17,23 +19,25 @@ import (
 )
 
 type PersistentConfig struct {
-	GlobalConfig string       `koanf:"global-config"`
-	Chain        string       `koanf:"chain"`
-	LogDir       string       `koanf:"log-dir"`
-	Handles      int          `koanf:"handles"`
-	Ancient      string       `koanf:"ancient"`
-	DBEngine     string       `koanf:"db-engine"`
-	Pebble       PebbleConfig `koanf:"pebble"`
+	GlobalConfig    string       `koanf:"global-config"`
+	Chain           string       `koanf:"chain"`
+	LogDir          string       `koanf:"log-dir"`
+	Handles         int          `koanf:"handles"`
+	Ancient         string       `koanf:"ancient"`
+	AncientReadOnly bool         `koanf:"ancient-read-only"`
+	DBEngine        string       `koanf:"db-engine"`
+	Pebble          PebbleConfig `koanf:"pebble"`
 }
 
 var PersistentConfigDefault = PersistentConfig{
-	GlobalConfig: ".arbitrum",
-	Chain:        "",
-	LogDir:       "",
-	Handles:      512,
-	Ancient:      "",
-	DBEngine:     "", // auto-detect database type based on the db dir contents
-	Pebble:       PebbleConfigDefault,
+	GlobalConfig:    ".arbitrum",
+	Chain:           "",
+	LogDir:          "",
+	Handles:         512,
+	Ancient:         "",
+	AncientReadOnly: false,
+	DBEngine:        "", // auto-detect database type based on the db dir contents
+	Pebble:          PebbleConfigDefault,
 }
 
 func PersistentConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -42,6 +46,7 @@ func PersistentConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".log-dir", PersistentConfigDefault.LogDir, "directory to store log file")
 	f.Int(prefix+".handles", PersistentConfigDefault.Handles, "number of file descriptor handles to use for the database")
 	f.String(prefix+".ancient", PersistentConfigDefault.Ancient, "directory of ancient where the chain freezer can be opened")
+	f.Bool(prefix+".ancient-read-only", PersistentConfigDefault.AncientReadOnly, "open the ancient directory read-only so it can be shared by several replicas (e.g. on a network filesystem); blocks are not frozen and new ones stay in the local database")
 	f.String(prefix+".db-engine", PersistentConfigDefault.DBEngine, "backing database implementation to use. If set to empty string the database type will be autodetected and if no pre-existing database is found it will default to creating new pebble database ('leveldb', 'pebble' or '' = auto-detect)")
 	PebbleConfigAddOptions(prefix+".pebble", f, &PersistentConfigDefault.Pebble)
 }
@@ -97,6 +102,9 @@ func DatabaseInDirectory(path string) bool {
 }
 
 func (c *PersistentConfig) Validate() error {
+	if c.AncientReadOnly && c.Ancient == "" {
+		return errors.New("--persistent.ancient-read-only requires --persistent.ancient to be set to the shared ancient directory")
+	}
 	if c.DBEngine != "leveldb" && c.DBEngine != "pebble" && c.DBEngine != "" {
 		return fmt.Errorf(`invalid .db-engine choice: %q, allowed "leveldb", "pebble" or ""`, c.DBEngine)
 	}
@@ -112,16 +120,19 @@ func (c *PersistentConfig) Validate() error {
 
 type PebbleConfig struct {
 	MaxConcurrentCompactions int                      `koanf:"max-concurrent-compactions"`
+	Profile                  string                   `koanf:"profile"`
 	Experimental             PebbleExperimentalConfig `koanf:"experimental"`
 }
 
 var PebbleConfigDefault = PebbleConfig{
 	MaxConcurrentCompactions: runtime.NumCPU(),
+	Profile:                  "",
 	Experimental:             PebbleExperimentalConfigDefault,
 }
 
 func PebbleConfigAddOptions(prefix string, f *flag.FlagSet, defaultConfig *PebbleConfig) {
 	f.Int(prefix+".max-concurrent-compactions", defaultConfig.MaxConcurrentCompactions, "maximum number of concurrent compactions")
+	f.String(prefix+".profile", defaultConfig.Profile, "tuning profile providing defaults for the experimental options ('throughput', 'latency', 'low-memory' or '' = no profile); experimental options changed from their defaults take precedence")
 	PebbleExperimentalConfigAddOptions(prefix+".experimental", f, &defaultConfig.Experimental)
 }
 
@@ -129,6 +140,9 @@ func (c *PebbleConfig) Validate() error {
 	if c.MaxConcurrentCompactions < 1 {
 		return fmt.Errorf("invalid .max-concurrent-compactions value: %d, has to be greater then 0", c.MaxConcurrentCompactions)
 	}
+	if _, ok := pebbleProfiles[c.Profile]; !ok && c.Profile != "" {
+		return fmt.Errorf("invalid .profile value: %q, allowed \"throughput\", \"latency\", \"low-memory\" or \"\"", c.Profile)
+	}
 	if err := c.Experimental.Validate(); err != nil {
 		return err
 	}
@@ -222,53 +236,107 @@ func (c *PebbleExperimentalConfig) Validate() error {
 	return nil
 }
 
+// pebbleProfiles override PebbleExperimentalConfigDefault for a given workload.
+var pebbleProfiles = map[string]func(*PebbleExperimentalConfig){
+	// Larger files and levels and parallel sstable writes, at the cost of longer individual compactions.
+	"throughput": func(c *PebbleExperimentalConfig) {
+		c.L0CompactionThreshold = 8
+		c.L0StopWritesThreshold = 48
+		c.LBaseMaxBytes = 256 << 20 // 256 MB
+		c.TargetFileSize = 8 << 20  // 8 MB
+		c.TargetFileSizeEqualLevels = false
+		c.MaxWriterConcurrency = runtime.NumCPU()
+	},
+	// Compact L0 early and with more concurrency, keep write stalls far away and spread out syncs,
+	// to avoid multi-second pauses on the block production path.
+	"latency": func(c *PebbleExperimentalConfig) {
+		c.L0CompactionThreshold = 2
+		c.L0StopWritesThreshold = 36
+		c.MemTableStopWritesThreshold = 4
+		c.L0CompactionConcurrency = 2
+		c.CompactionDebtConcurrency = 256 << 20 // 256 MB
+		c.BytesPerSync = 1 << 20                // 1 MB
+		c.WALBytesPerSync = 1 << 20             // 1 MB
+	},
+	// Small tables and no parallel writers to keep memory usage down.
+	"low-memory": func(c *PebbleExperimentalConfig) {
+		c.MemTableStopWritesThreshold = 2
+		c.L0CompactionConcurrency = 20
+		c.BlockSize = 4 << 10      // 4 KB
+		c.IndexBlockSize = 4 << 10 // 4 KB
+		c.TargetFileSize = 2 << 20 // 2 MB
+		c.MaxWriterConcurrency = 0
+	},
+}
+
+// effectiveExperimental applies the profile to every experimental option left at its default.
+func (c *PebbleConfig) effectiveExperimental() PebbleExperimentalConfig {
+	apply, ok := pebbleProfiles[c.Profile]
+	if !ok {
+		return c.Experimental
+	}
+	profile := PebbleExperimentalConfigDefault
+	apply(&profile)
+	result := c.Experimental
+	current := reflect.ValueOf(&result).Elem()
+	defaults := reflect.ValueOf(PebbleExperimentalConfigDefault)
+	profileValues := reflect.ValueOf(profile)
+	for i := 0; i < current.NumField(); i++ {
+		if current.Field(i).Equal(defaults.Field(i)) {
+			current.Field(i).Set(profileValues.Field(i))
+		}
+	}
+	return result
+}
+
 func (c *PebbleConfig) ExtraOptions(namespace string) *pebble.ExtraOptions {
+	experimental := c.effectiveExperimental()
 	var maxConcurrentCompactions func() int
 	if c.MaxConcurrentCompactions > 0 {
 		maxConcurrentCompactions = func() int { return c.MaxConcurrentCompactions }
 	}
 	var walMinSyncInterval func() time.Duration
-	if c.Experimental.WALMinSyncInterval > 0 {
+	if experimental.WALMinSyncInterval > 0 {
 		walMinSyncInterval = func() time.Duration {
-			return time.Microsecond * time.Duration(c.Experimental.WALMinSyncInterval)
+			return time.Microsecond * time.Duration(experimental.WALMinSyncInterval)
 		}
 	}
 	var levels []pebble.ExtraLevelOptions
 	for i := 0; i < 7; i++ {
-		targetFileSize := c.Experimental.TargetFileSize
-		if !c.Experimental.TargetFileSizeEqualLevels {
+		targetFileSize := experimental.TargetFileSize
+		if !experimental.TargetFileSizeEqualLevels {
 			targetFileSize = targetFileSize << i
 		}
 		levels = append(levels, pebble.ExtraLevelOptions{
-			BlockSize:      c.Experimental.BlockSize,
-			IndexBlockSize: c.Experimental.IndexBlockSize,
+			BlockSize:      experimental.BlockSize,
+			IndexBlockSize: experimental.IndexBlockSize,
 			TargetFileSize: targetFileSize,
 		})
 	}
-	walDir := c.Experimental.WALDir
+	walDir := experimental.WALDir
 	if walDir != "" {
 		walDir = path.Join(walDir, namespace)
 	}
 	return &pebble.ExtraOptions{
-		BytesPerSync:                c.Experimental.BytesPerSync,
-		L0CompactionFileThreshold:   c.Experimental.L0CompactionFileThreshold,
-		L0CompactionThreshold:       c.Experimental.L0CompactionThreshold,
-		L0StopWritesThreshold:       c.Experimental.L0StopWritesThreshold,
-		LBaseMaxBytes:               c.Experimental.LBaseMaxBytes,
-		MemTableStopWritesThreshold: c.Experimental.MemTableStopWritesThreshold,
+		BytesPerSync:                experimental.BytesPerSync,
+		L0CompactionFileThreshold:   experimental.L0CompactionFileThreshold,
+		L0CompactionThreshold:       experimental.L0CompactionThreshold,
+		L0StopWritesThreshold:       experimental.L0StopWritesThreshold,
+		LBaseMaxBytes:               experimental.LBaseMaxBytes,
+		MemTableStopWritesThreshold: experimental.MemTableStopWritesThreshold,
 		MaxConcurrentCompactions:    maxConcurrentCompactions,
-		DisableAutomaticCompactions: c.Experimental.DisableAutomaticCompactions,
-		WALBytesPerSync:             c.Experimental.WALBytesPerSync,
+		DisableAutomaticCompactions: experimental.DisableAutomaticCompactions,
+		WALBytesPerSync:             experimental.WALBytesPerSync,
 		WALDir:                      walDir,
 		WALMinSyncInterval:          walMinSyncInterval,
-		TargetByteDeletionRate:      c.Experimental.TargetByteDeletionRate,
+		TargetByteDeletionRate:      experimental.TargetByteDeletionRate,
 		Experimental: pebble.ExtraOptionsExperimental{
-			L0CompactionConcurrency:   c.Experimental.L0CompactionConcurrency,
-			CompactionDebtConcurrency: c.Experimental.CompactionDebtConcurrency,
-			ReadCompactionRate:        c.Experimental.ReadCompactionRate,
-			ReadSamplingMultiplier:    c.Experimental.ReadSamplingMultiplier,
-			MaxWriterConcurrency:      c.Experimental.MaxWriterConcurrency,
-			ForceWriterParallelism:    c.Experimental.ForceWriterParallelism,
+			L0CompactionConcurrency:   experimental.L0CompactionConcurrency,
+			CompactionDebtConcurrency: experimental.CompactionDebtConcurrency,
+			ReadCompactionRate:        experimental.ReadCompactionRate,
+			ReadSamplingMultiplier:    experimental.ReadSamplingMultiplier,
+			MaxWriterConcurrency:      experimental.MaxWriterConcurrency,
+			ForceWriterParallelism:    experimental.ForceWriterParallelism,
 		},
 		Levels: levels,
 	}
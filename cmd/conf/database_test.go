@@ -0,0 +1,35 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package conf
+
+import (
+	"testing"
+)
+
+func TestPebbleProfiles(t *testing.T) {
+	config := PebbleConfigDefault
+	config.Profile = "latency"
+	config.Experimental.L0StopWritesThreshold = 100
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	options := config.ExtraOptions("test")
+	if options.L0CompactionThreshold != 2 {
+		t.Errorf("expected profile to set l0-compaction-threshold to 2, got %v", options.L0CompactionThreshold)
+	}
+	if options.L0StopWritesThreshold != 100 {
+		t.Errorf("expected explicitly set l0-stop-writes-threshold to take precedence over profile, got %v", options.L0StopWritesThreshold)
+	}
+	if options.L0CompactionFileThreshold != PebbleExperimentalConfigDefault.L0CompactionFileThreshold {
+		t.Errorf("expected option not in profile to keep its default, got %v", options.L0CompactionFileThreshold)
+	}
+	if config.Experimental.L0CompactionThreshold != PebbleExperimentalConfigDefault.L0CompactionThreshold {
+		t.Error("applying profile modified the config")
+	}
+
+	config.Profile = "fast"
+	if err := config.Validate(); err == nil {
+		t.Error("expected unknown profile to be invalid")
+	}
+}
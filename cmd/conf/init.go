@@ -1,12 +1,15 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/offchainlabs/nitro/util/rpcclient"
 	"github.com/spf13/pflag"
 )
 
@@ -16,6 +19,7 @@ type InitConfig struct {
 	Latest                   string        `koanf:"latest"`
 	LatestBase               string        `koanf:"latest-base"`
 	ValidateChecksum         bool          `koanf:"validate-checksum"`
+	SnapshotSigner           string        `koanf:"snapshot-signer"`
 	DownloadPath             string        `koanf:"download-path"`
 	DownloadPoll             time.Duration `koanf:"download-poll"`
 	DevInit                  bool          `koanf:"dev-init"`
@@ -31,11 +35,16 @@ type InitConfig struct {
 	PruneBloomSize           uint64        `koanf:"prune-bloom-size"`
 	PruneThreads             int           `koanf:"prune-threads"`
 	PruneTrieCleanCache      int           `koanf:"prune-trie-clean-cache"`
+	PruneRetention           uint64        `koanf:"prune-retention"`
 	RecreateMissingStateFrom uint64        `koanf:"recreate-missing-state-from"`
 	RebuildLocalWasm         string        `koanf:"rebuild-local-wasm"`
 	ReorgToBatch             int64         `koanf:"reorg-to-batch"`
 	ReorgToMessageBatch      int64         `koanf:"reorg-to-message-batch"`
 	ReorgToBlockBatch        int64         `koanf:"reorg-to-block-batch"`
+
+	// A trusted node serving snapshots and state, as enabled with --node.snapshot-peer-server
+	Peer           rpcclient.ClientConfig `koanf:"peer"`
+	PeerStateBlock int64                  `koanf:"peer-state-block"`
 }
 
 var InitConfigDefault = InitConfig{
@@ -44,6 +53,9 @@ var InitConfigDefault = InitConfig{
 	Latest:                   "",
 	LatestBase:               "https://snapshot.arbitrum.foundation/",
 	ValidateChecksum:         true,
+	SnapshotSigner:           "",
+	Peer:                     InitPeerConfigDefault,
+	PeerStateBlock:           -1,
 	DownloadPath:             "/tmp/",
 	DownloadPoll:             time.Minute,
 	DevInit:                  false,
@@ -59,6 +71,7 @@ var InitConfigDefault = InitConfig{
 	PruneBloomSize:           2048,
 	PruneThreads:             runtime.NumCPU(),
 	PruneTrieCleanCache:      600,
+	PruneRetention:           0,
 	RecreateMissingStateFrom: 0, // 0 = disabled
 	RebuildLocalWasm:         "auto",
 	ReorgToBatch:             -1,
@@ -66,12 +79,24 @@ var InitConfigDefault = InitConfig{
 	ReorgToBlockBatch:        -1,
 }
 
+var InitPeerConfigDefault = rpcclient.ClientConfig{
+	URL:                       "",
+	Retries:                   3,
+	Timeout:                   time.Minute,
+	ConnectionWait:            time.Minute,
+	ArgLogLimit:               2048,
+	WebsocketMessageSizeLimit: 256 * 1024 * 1024,
+}
+
 func InitConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".force", InitConfigDefault.Force, "if true: in case database exists init code will be reexecuted and genesis block compared to database")
 	f.String(prefix+".url", InitConfigDefault.Url, "url to download initialization data - will poll if download fails")
 	f.String(prefix+".latest", InitConfigDefault.Latest, "if set, searches for the latest snapshot of the given kind "+acceptedSnapshotKindsStr)
 	f.String(prefix+".latest-base", InitConfigDefault.LatestBase, "base url used when searching for the latest")
 	f.Bool(prefix+".validate-checksum", InitConfigDefault.ValidateChecksum, "if true: validate the checksum after downloading the snapshot")
+	f.String(prefix+".snapshot-signer", InitConfigDefault.SnapshotSigner, "if set, only accept a snapshot whose manifest is signed by this address, as produced by --node.snapshot-producer")
+	rpcclient.RPCClientAddOptions(prefix+".peer", f, &InitConfigDefault.Peer)
+	f.Int64(prefix+".peer-state-block", InitConfigDefault.PeerStateBlock, "if the database lacks the state of this block, such as the block a validator starts validating from, fetch it from the peer (-1 = disabled)")
 	f.String(prefix+".download-path", InitConfigDefault.DownloadPath, "path to save temp downloaded file")
 	f.Duration(prefix+".download-poll", InitConfigDefault.DownloadPoll, "how long to wait between polling attempts")
 	f.Bool(prefix+".dev-init", InitConfigDefault.DevInit, "init with dev data (1 account with balance) instead of file import")
@@ -83,10 +108,11 @@ func InitConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".then-quit", InitConfigDefault.ThenQuit, "quit after init is done")
 	f.String(prefix+".import-file", InitConfigDefault.ImportFile, "path for json data to import")
 	f.Uint(prefix+".accounts-per-sync", InitConfigDefault.AccountsPerSync, "during init - sync database every X accounts. Lower value for low-memory systems. 0 disables.")
-	f.String(prefix+".prune", InitConfigDefault.Prune, "pruning for a given use: \"full\" for full nodes serving RPC requests, or \"validator\" for validators")
+	f.String(prefix+".prune", InitConfigDefault.Prune, "pruning for a given use: \"full\" for full nodes serving RPC requests, \"validator\" for validators, or \"retention\" to keep the states of the last prune-retention blocks")
 	f.Uint64(prefix+".prune-bloom-size", InitConfigDefault.PruneBloomSize, "the amount of memory in megabytes to use for the pruning bloom filter (higher values prune better)")
 	f.Int(prefix+".prune-threads", InitConfigDefault.PruneThreads, "the number of threads to use when pruning")
 	f.Int(prefix+".prune-trie-clean-cache", InitConfigDefault.PruneTrieCleanCache, "amount of memory in megabytes to cache unchanged state trie nodes with when traversing state database during pruning")
+	f.Uint64(prefix+".prune-retention", InitConfigDefault.PruneRetention, "number of recent blocks whose states \"retention\" pruning keeps, one every 2000 blocks; a node that last ran as an archive and is restarted without archive is pruned this way automatically (0 = disabled)")
 	f.Uint64(prefix+".recreate-missing-state-from", InitConfigDefault.RecreateMissingStateFrom, "block number to start recreating missing states from (0 = disabled)")
 	f.Int64(prefix+".reorg-to-batch", InitConfigDefault.ReorgToBatch, "rolls back the blockchain to a specified batch number")
 	f.Int64(prefix+".reorg-to-message-batch", InitConfigDefault.ReorgToMessageBatch, "rolls back the blockchain to the first batch at or before a given message index")
@@ -105,9 +131,26 @@ func (c *InitConfig) Validate() error {
 	if c.Latest != "" && !isAcceptedSnapshotKind(c.Latest) {
 		return fmt.Errorf("invalid value for latest option: \"%s\" %s", c.Latest, acceptedSnapshotKindsStr)
 	}
+	if c.SnapshotSigner != "" && !common.IsHexAddress(c.SnapshotSigner) {
+		return fmt.Errorf("invalid snapshot signer address: \"%s\"", c.SnapshotSigner)
+	}
+	if c.Peer.URL != "" {
+		if c.Url != "" {
+			return errors.New("init url and peer can't both be set")
+		}
+		if c.Latest != "" && c.SnapshotSigner == "" {
+			return errors.New("downloading a snapshot from a peer requires the snapshot signer")
+		}
+	}
+	if c.PeerStateBlock >= 0 && c.Peer.URL == "" {
+		return errors.New("fetching state from a peer requires the peer url")
+	}
 	if c.Prune != "" && c.PruneThreads <= 0 {
 		return fmt.Errorf("invalid number of pruning threads: %d, has to be greater then 0", c.PruneThreads)
 	}
+	if c.Prune == "retention" && c.PruneRetention == 0 {
+		return fmt.Errorf("retention pruning requires prune-retention to be set")
+	}
 	if c.PruneTrieCleanCache < 0 {
 		return fmt.Errorf("invalid trie clean cache size: %d, has to be greater or equal 0", c.PruneTrieCleanCache)
 	}
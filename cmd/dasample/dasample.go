@@ -0,0 +1,196 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// dasample checks the availability of a posted batch's data by requesting randomly chosen chunks of it from
+// data availability servers and beacon nodes, checking each against the batch's commitment. It exits with
+// status 2 if any sample failed, or with --interval keeps sampling and printing a report each round.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+type DASampleConfig struct {
+	DataHash             string        `koanf:"data-hash"`
+	Certificate          string        `koanf:"certificate"`
+	DASURLs              []string      `koanf:"das-url"`
+	ParentChainURL       string        `koanf:"parent-chain-url"`
+	BeaconURLs           []string      `koanf:"beacon-url"`
+	ParentChainBlockHash string        `koanf:"parent-chain-block-hash"`
+	BlobHashes           []string      `koanf:"blob-hash"`
+	Samples              int           `koanf:"samples"`
+	Interval             time.Duration `koanf:"interval"`
+	JSON                 bool          `koanf:"json"`
+	Timeout              time.Duration `koanf:"timeout"`
+}
+
+var DefaultDASampleConfig = DASampleConfig{
+	Samples: 8,
+	Timeout: time.Minute,
+}
+
+func parseDASampleConfig(args []string) (*DASampleConfig, error) {
+	f := flag.NewFlagSet("dasample", flag.ContinueOnError)
+	f.String("data-hash", DefaultDASampleConfig.DataHash, "dastree root of the batch data to sample from data availability servers")
+	f.String("certificate", DefaultDASampleConfig.Certificate, "instead of --data-hash, the hex encoded data availability certificate posted for the batch")
+	f.StringSlice("das-url", DefaultDASampleConfig.DASURLs, "REST URLs of data availability servers to sample")
+	f.String("parent-chain-url", DefaultDASampleConfig.ParentChainURL, "parent chain RPC URL, needed to sample blobs")
+	f.StringSlice("beacon-url", DefaultDASampleConfig.BeaconURLs, "beacon chain URLs to sample blobs from")
+	f.String("parent-chain-block-hash", DefaultDASampleConfig.ParentChainBlockHash, "hash of the parent chain block the blob batch was posted in")
+	f.StringSlice("blob-hash", DefaultDASampleConfig.BlobHashes, "versioned hashes of the batch's blobs")
+	f.Int("samples", DefaultDASampleConfig.Samples, "chunks to request from each endpoint")
+	f.Duration("interval", DefaultDASampleConfig.Interval, "if nonzero, keep sampling at this interval")
+	f.Bool("json", DefaultDASampleConfig.JSON, "print reports as JSON")
+	f.Duration("timeout", DefaultDASampleConfig.Timeout, "timeout for each round of sampling")
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config DASampleConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.DataHash != "" && config.Certificate != "" {
+		return nil, errors.New("only one of --data-hash and --certificate may be given")
+	}
+	if (config.DataHash != "" || config.Certificate != "") != (len(config.DASURLs) > 0) {
+		return nil, errors.New("sampling data availability servers needs --das-url and one of --data-hash or --certificate")
+	}
+	if len(config.BeaconURLs) > 0 && (config.ParentChainURL == "" || config.ParentChainBlockHash == "" || len(config.BlobHashes) == 0) {
+		return nil, errors.New("sampling blobs needs --parent-chain-url, --parent-chain-block-hash and --blob-hash")
+	}
+	if len(config.DASURLs) == 0 && len(config.BeaconURLs) == 0 {
+		return nil, errors.New("nothing to sample, give --das-url or --beacon-url")
+	}
+	if config.Samples <= 0 {
+		return nil, errors.New("--samples must be positive")
+	}
+	return &config, nil
+}
+
+// dataHash returns the dastree root the batch's data is stored under.
+func dataHash(config *DASampleConfig) (common.Hash, error) {
+	if config.DataHash != "" {
+		return common.HexToHash(config.DataHash), nil
+	}
+	certBytes, err := hexutil.Decode(config.Certificate)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to decode certificate: %w", err)
+	}
+	cert, err := daprovider.DeserializeDASCertFrom(bytes.NewReader(certBytes))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// Version 0 certificates commit to a flat hash, stored as a single leaf tree
+	if cert.Version == 0 {
+		return dastree.FlatHashToTreeHash(cert.DataHash), nil
+	}
+	return cert.DataHash, nil
+}
+
+func newSamplers(ctx context.Context, config *DASampleConfig) ([]das.ChunkSampler, error) {
+	var samplers []das.ChunkSampler
+	if len(config.DASURLs) > 0 {
+		root, err := dataHash(config)
+		if err != nil {
+			return nil, err
+		}
+		for _, url := range config.DASURLs {
+			client, err := das.NewRestfulDasClientFromURL(url)
+			if err != nil {
+				return nil, err
+			}
+			samplers = append(samplers, das.NewRestChunkSampler(client, root))
+		}
+	}
+	if len(config.BeaconURLs) > 0 {
+		client, err := ethclient.DialContext(ctx, config.ParentChainURL)
+		if err != nil {
+			return nil, err
+		}
+		var blobHashes []common.Hash
+		for _, hash := range config.BlobHashes {
+			blobHashes = append(blobHashes, common.HexToHash(hash))
+		}
+		for _, url := range config.BeaconURLs {
+			blobClient, err := headerreader.NewBlobClient(headerreader.BlobClientConfig{BeaconUrl: url}, client)
+			if err != nil {
+				return nil, err
+			}
+			if err := blobClient.Initialize(ctx); err != nil {
+				return nil, fmt.Errorf("failed to initialize blob client for %v: %w", url, err)
+			}
+			samplers = append(samplers, das.NewBlobChunkSampler(url, blobClient, common.HexToHash(config.ParentChainBlockHash), blobHashes))
+		}
+	}
+	return samplers, nil
+}
+
+func printReport(w io.Writer, config *DASampleConfig, report *das.SamplingReport) error {
+	if config.JSON {
+		return json.NewEncoder(w).Encode(report)
+	}
+	fmt.Fprintf(w, "%v: %v of %v samples failed\n", time.Now().Format(time.RFC3339), report.Failures, len(report.Samples))
+	for _, sample := range report.Samples {
+		if sample.Error != "" {
+			fmt.Fprintf(w, "  %v: %v\n", sample.Endpoint, sample.Error)
+		}
+	}
+	return nil
+}
+
+func run(config *DASampleConfig, w io.Writer) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	samplers, err := newSamplers(ctx, config)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		report := das.SampleChunks(ctx, samplers, config.Samples)
+		cancel()
+		if err := printReport(w, config, report); err != nil {
+			return false, err
+		}
+		if config.Interval == 0 {
+			return report.Failures == 0, nil
+		}
+		time.Sleep(config.Interval)
+	}
+}
+
+func main() {
+	config, err := parseDASampleConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	available, err := run(config, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if !available {
+		os.Exit(2)
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
@@ -42,6 +41,9 @@ type DAServerConfig struct {
 	RESTPort           uint64                              `koanf:"rest-port"`
 	RESTServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"rest-server-timeouts"`
 
+	ServerSecurity  genericconf.HTTPServerSecurityConfig `koanf:"server-security"`
+	MetricsSecurity genericconf.HTTPServerSecurityConfig `koanf:"metrics-server-security"`
+
 	DataAvailability das.DataAvailabilityConfig `koanf:"data-availability"`
 
 	Conf     genericconf.ConfConfig `koanf:"conf"`
@@ -64,6 +66,8 @@ var DefaultDAServerConfig = DAServerConfig{
 	RESTAddr:           "localhost",
 	RESTPort:           9877,
 	RESTServerTimeouts: genericconf.HTTPServerTimeoutConfigDefault,
+	ServerSecurity:     genericconf.HTTPServerSecurityConfigDefault,
+	MetricsSecurity:    genericconf.HTTPServerSecurityConfigDefault,
 	DataAvailability:   das.DefaultDataAvailabilityConfig,
 	Conf:               genericconf.ConfConfigDefault,
 	LogLevel:           "INFO",
@@ -98,8 +102,11 @@ func parseDAServer(args []string) (*DAServerConfig, error) {
 	f.Uint64("rest-port", DefaultDAServerConfig.RESTPort, "REST server listening port")
 	genericconf.HTTPServerTimeoutConfigAddOptions("rest-server-timeouts", f)
 
+	genericconf.HTTPServerSecurityConfigAddOptions("server-security", f)
+
 	f.Bool("metrics", DefaultDAServerConfig.Metrics, "enable metrics")
 	genericconf.MetricsServerAddOptions("metrics-server", f)
+	genericconf.HTTPServerSecurityConfigAddOptions("metrics-server-security", f)
 
 	f.Bool("pprof", DefaultDAServerConfig.PProf, "enable pprof")
 	genericconf.PProfAddOptions("pprof-cfg", f)
@@ -119,6 +126,18 @@ func parseDAServer(args []string) (*DAServerConfig, error) {
 	if err := confighelpers.EndCommonParse(k, &serverConfig); err != nil {
 		return nil, err
 	}
+	if err := serverConfig.ServerSecurity.Validate(); err != nil {
+		return nil, err
+	}
+	if err := serverConfig.MetricsSecurity.Validate(); err != nil {
+		return nil, err
+	}
+	if err := genericconf.ValidateDistinctAuthTokens(map[string]*genericconf.HTTPServerSecurityConfig{
+		"server-security":         &serverConfig.ServerSecurity,
+		"metrics-server-security": &serverConfig.MetricsSecurity,
+	}); err != nil {
+		return nil, err
+	}
 	if serverConfig.Conf.Dump {
 		err = confighelpers.DumpConfig(k, map[string]interface{}{
 			"data-availability.key.priv-key": "",
@@ -166,7 +185,9 @@ func startMetrics(cfg *DAServerConfig) error {
 	}
 	if cfg.Metrics {
 		go metrics.CollectProcessMetrics(cfg.MetricsServer.UpdateInterval)
-		exp.Setup(fmt.Sprintf("%v:%v", cfg.MetricsServer.Addr, cfg.MetricsServer.Port))
+		if err := genericconf.StartMetricsServer(mAddr, genericconf.HTTPServerTimeoutConfigDefault, &cfg.MetricsSecurity); err != nil {
+			return err
+		}
 	}
 	if cfg.PProf {
 		genericconf.StartPprof(pAddr)
@@ -253,7 +274,7 @@ func startup() error {
 	if serverConfig.EnableRPC {
 		log.Info("Starting HTTP-RPC server", "addr", serverConfig.RPCAddr, "port", serverConfig.RPCPort, "revision", vcsRevision, "vcs.time", vcsTime)
 
-		rpcServer, err = das.StartDASRPCServer(ctx, serverConfig.RPCAddr, serverConfig.RPCPort, serverConfig.RPCServerTimeouts, serverConfig.RPCServerBodyLimit, daReader, daWriter, daHealthChecker, signatureVerifier)
+		rpcServer, err = das.StartDASRPCServer(ctx, serverConfig.RPCAddr, serverConfig.RPCPort, serverConfig.RPCServerTimeouts, &serverConfig.ServerSecurity, serverConfig.RPCServerBodyLimit, daReader, daWriter, daHealthChecker, signatureVerifier)
 		if err != nil {
 			return err
 		}
@@ -263,7 +284,7 @@ func startup() error {
 	if serverConfig.EnableREST {
 		log.Info("Starting REST server", "addr", serverConfig.RESTAddr, "port", serverConfig.RESTPort, "revision", vcsRevision, "vcs.time", vcsTime)
 
-		restServer, err = das.NewRestfulDasServer(serverConfig.RESTAddr, serverConfig.RESTPort, serverConfig.RESTServerTimeouts, daReader, daHealthChecker)
+		restServer, err = das.NewRestfulDasServer(serverConfig.RESTAddr, serverConfig.RESTPort, serverConfig.RESTServerTimeouts, &serverConfig.ServerSecurity, daReader, daHealthChecker)
 		if err != nil {
 			return err
 		}
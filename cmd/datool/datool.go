@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util"
@@ -29,13 +30,14 @@ import (
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/blobs"
 	"github.com/offchainlabs/nitro/util/signature"
 )
 
 func main() {
 	args := os.Args
 	if len(args) < 2 {
-		panic("Usage: datool [client|keygen|generatehash|dumpkeyset] ...")
+		panic("Usage: datool [client|keygen|generatehash|dumpkeyset|blobs] ...")
 	}
 
 	var err error
@@ -48,8 +50,10 @@ func main() {
 		err = generateHash(args[2])
 	case "dumpkeyset":
 		err = dumpKeyset(args[2:])
+	case "blobs":
+		err = startBlobs(args[2:])
 	default:
-		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash'", args[1]))
+		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash', 'dumpkeyset', 'blobs'", args[1]))
 	}
 	if err != nil {
 		panic(err)
@@ -92,6 +96,7 @@ type ClientStoreConfig struct {
 	SigningWallet         string        `koanf:"signing-wallet"`
 	SigningWalletPassword string        `koanf:"signing-wallet-password"`
 	MaxStoreChunkBodySize int           `koanf:"max-store-chunk-body-size"`
+	AuthTokenFile         string        `koanf:"auth-token-file"`
 }
 
 func parseClientStoreConfig(args []string) (*ClientStoreConfig, error) {
@@ -104,6 +109,7 @@ func parseClientStoreConfig(args []string) (*ClientStoreConfig, error) {
 	f.String("signing-wallet-password", genericconf.PASSWORD_NOT_SET, "password to unlock the wallet, if not specified the user is prompted for the password")
 	f.Duration("das-retention-period", 24*time.Hour, "The period which DASes are requested to retain the stored batches.")
 	f.Int("max-store-chunk-body-size", 512*1024, "The maximum HTTP POST body size for a chunked store request")
+	f.String("auth-token-file", "", "path to a file holding the bearer token to present to the DAS server, if it requires one")
 
 	k, err := confighelpers.BeginCommonParse(f, args)
 	if err != nil {
@@ -152,7 +158,11 @@ func startClientStore(args []string) error {
 		}
 	}
 
-	client, err := das.NewDASRPCClient(config.URL, signer, config.MaxStoreChunkBodySize)
+	authToken, err := genericconf.ReadAuthTokenFile(config.AuthTokenFile)
+	if err != nil {
+		return err
+	}
+	client, err := das.NewDASRPCClient(config.URL, signer, config.MaxStoreChunkBodySize, authToken)
 	if err != nil {
 		return err
 	}
@@ -189,14 +199,16 @@ func startClientStore(args []string) error {
 // datool client rest getbyhash
 
 type RESTClientGetByHashConfig struct {
-	URL      string `koanf:"url"`
-	DataHash string `koanf:"data-hash"`
+	URL           string `koanf:"url"`
+	DataHash      string `koanf:"data-hash"`
+	AuthTokenFile string `koanf:"auth-token-file"`
 }
 
 func parseRESTClientGetByHashConfig(args []string) (*RESTClientGetByHashConfig, error) {
 	f := flag.NewFlagSet("datool client retrieve", flag.ContinueOnError)
 	f.String("url", "http://localhost:9877", "URL of DAS server to connect to.")
 	f.String("data-hash", "", "hash of the message to retrieve, if starts with '0x' it's treated as hex encoded, otherwise base64 encoded")
+	f.String("auth-token-file", "", "path to a file holding the bearer token to present to the DAS server, if it requires one")
 
 	k, err := confighelpers.BeginCommonParse(f, args)
 	if err != nil {
@@ -220,6 +232,11 @@ func startRESTClientGetByHash(args []string) error {
 	if err != nil {
 		return err
 	}
+	authToken, err := genericconf.ReadAuthTokenFile(config.AuthTokenFile)
+	if err != nil {
+		return err
+	}
+	client.SetAuthToken(authToken)
 
 	var decodedHash []byte
 	if strings.HasPrefix(config.DataHash, "0x") {
@@ -376,3 +393,123 @@ func dumpKeyset(args []string) error {
 
 	return err
 }
+
+// datool blobs ...
+
+func startBlobs(args []string) error {
+	if len(args) == 0 {
+		return errors.New("datool blobs requires an argument, valid arguments are 'encode' and 'decode'")
+	}
+	switch strings.ToLower(args[0]) {
+	case "encode":
+		return startBlobsEncode(args[1:])
+	case "decode":
+		return startBlobsDecode(args[1:])
+	}
+	return fmt.Errorf("datool blobs '%s' not supported, valid arguments are 'encode' and 'decode'", args[0])
+}
+
+type BlobsConfig struct {
+	Input  string `koanf:"input"`
+	Output string `koanf:"output"`
+}
+
+func parseBlobsConfig(name string, args []string) (*BlobsConfig, error) {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	f.String("input", "", "file to read from")
+	f.String("output", "", "file to write to")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config BlobsConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Input == "" || config.Output == "" {
+		return nil, errors.New("--input and --output must be set")
+	}
+	return &config, nil
+}
+
+// startBlobsEncode encodes the raw payload in the input file into blobs, writing them back to back to the output file
+// and printing the versioned hash of each.
+func startBlobsEncode(args []string) error {
+	config, err := parseBlobsConfig("datool blobs encode", args)
+	if err != nil {
+		return err
+	}
+	input, err := os.Open(config.Input)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	info, err := input.Stat()
+	if err != nil {
+		return err
+	}
+	output, err := os.Create(config.Output)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	count := 0
+	// #nosec G115
+	err = blobs.EncodeBlobsFromReader(input, uint64(info.Size()), func(blob *kzg4844.Blob) error {
+		commitment, err := kzg4844.BlobToCommitment(blob)
+		if err != nil {
+			return err
+		}
+		if _, err := output.Write(blob[:]); err != nil {
+			return err
+		}
+		fmt.Printf("Blob %d versioned hash: %s\n", count, blobs.CommitmentToVersionedHash(commitment))
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return output.Sync()
+}
+
+// startBlobsDecode decodes blobs written back to back in the input file, writing the payload to the output file.
+func startBlobsDecode(args []string) error {
+	config, err := parseBlobsConfig("datool blobs decode", args)
+	if err != nil {
+		return err
+	}
+	input, err := os.Open(config.Input)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	output, err := os.Create(config.Output)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	decoder := blobs.NewBlobDecoder(output)
+	var blob kzg4844.Blob
+	for {
+		_, err := io.ReadFull(input, blob[:])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading blob: %w", err)
+		}
+		if err := decoder.Write(&blob); err != nil {
+			return err
+		}
+	}
+	if err := decoder.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Decoded %d bytes\n", decoder.Written())
+	return output.Sync()
+}
@@ -0,0 +1,524 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// feedaudit lets a third party hold a sequencer accountable for its feed. It records the feed, exports the
+// recorded messages for a block range together with the batches posted for them as a signed file, and
+// verifies such an export, exiting with status 2 if the feed didn't match what was posted.
+//
+//	feedaudit record --feed-url <feed> --chain-id <id> --out feed.jsonl
+//	feedaudit export --db <chain dir>/nitro/arbitrumdata --feed feed.jsonl --from-block <n> --to-block <n> ...
+//	feedaudit verify --export export.json --sequencer <address>
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: feedaudit [record|export|verify] ...")
+		os.Exit(1)
+	}
+	var err error
+	issues := false
+	switch strings.ToLower(os.Args[1]) {
+	case "record":
+		err = startRecord(os.Args[2:])
+	case "export":
+		err = startExport(os.Args[2:])
+	case "verify":
+		issues, err = startVerify(os.Args[2:], os.Stdout)
+	default:
+		err = fmt.Errorf("unknown mode '%s', valid modes are 'record', 'export' and 'verify'", os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if issues {
+		os.Exit(2)
+	}
+}
+
+func parseConfig(mode string, addOptions func(*flag.FlagSet), args []string, config interface{}) error {
+	f := flag.NewFlagSet(mode, flag.ContinueOnError)
+	addOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return err
+	}
+	return confighelpers.EndCommonParse(k, config)
+}
+
+// feedaudit record
+
+type RecordConfig struct {
+	FeedURL     string `koanf:"feed-url"`
+	ChainID     uint64 `koanf:"chain-id"`
+	FromMessage uint64 `koanf:"from-message"`
+	ToMessage   uint64 `koanf:"to-message"`
+	Out         string `koanf:"out"`
+}
+
+func recordConfigAddOptions(f *flag.FlagSet) {
+	f.String("feed-url", "", "URL of the sequencer feed to record (required)")
+	f.Uint64("chain-id", 0, "chain id of the feed (required)")
+	f.Uint64("from-message", 0, "first message to record, if the feed still has it")
+	f.Uint64("to-message", 0, "stop once every message before this one is recorded (0 to record until interrupted)")
+	f.String("out", "", "file to append the recorded messages to, one JSON feed message per line (required)")
+}
+
+// feedRecorder appends every new feed message, with its signature, to the record.
+type feedRecorder struct {
+	mutex   sync.Mutex
+	out     *bufio.Writer
+	next    arbutil.MessageIndex
+	to      arbutil.MessageIndex
+	done    chan struct{}
+	stopped bool
+}
+
+func (r *feedRecorder) AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, msg := range feedMessages {
+		// The feed resends messages when reconnecting
+		if msg.SequenceNumber < r.next {
+			continue
+		}
+		if msg.SequenceNumber > r.next {
+			fmt.Fprintf(os.Stderr, "warning: feed skipped messages %v to %v\n", r.next, msg.SequenceNumber-1)
+		}
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := r.out.Write(append(encoded, '\n')); err != nil {
+			return err
+		}
+		r.next = msg.SequenceNumber + 1
+	}
+	if err := r.out.Flush(); err != nil {
+		return err
+	}
+	if r.to > 0 && r.next >= r.to && !r.stopped {
+		r.stopped = true
+		close(r.done)
+	}
+	return nil
+}
+
+func startRecord(args []string) error {
+	var config RecordConfig
+	if err := parseConfig("record", recordConfigAddOptions, args, &config); err != nil {
+		return err
+	}
+	if config.FeedURL == "" || config.ChainID == 0 || config.Out == "" {
+		return errors.New("--feed-url, --chain-id and --out are required")
+	}
+	file, err := os.OpenFile(config.Out, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	recorder := &feedRecorder{
+		out:  bufio.NewWriter(file),
+		next: arbutil.MessageIndex(config.FromMessage),
+		to:   arbutil.MessageIndex(config.ToMessage),
+		done: make(chan struct{}),
+	}
+
+	// Record every message as it was sent, signed or not; verify checks the signatures
+	clientConfig := broadcastclient.DefaultConfig
+	clientConfig.URL = []string{config.FeedURL}
+	clientConfig.Verify.Dangerous.AcceptMissing = true
+	fatalErrChan := make(chan error, 1)
+	client, err := broadcastclient.NewBroadcastClient(
+		func() *broadcastclient.Config { return &clientConfig },
+		config.FeedURL,
+		config.ChainID,
+		recorder.next,
+		recorder,
+		nil,
+		fatalErrChan,
+		nil,
+		nil,
+		func(int32) {},
+	)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.Start(ctx)
+	defer client.StopAndWait()
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-recorder.done:
+	case <-sigint:
+	case err = <-fatalErrChan:
+	}
+	return err
+}
+
+// feedaudit export
+
+type ExportConfig struct {
+	DB             string `koanf:"db"`
+	DBEngine       string `koanf:"db-engine"`
+	Feed           string `koanf:"feed"`
+	ChainID        uint64 `koanf:"chain-id"`
+	GenesisBlock   uint64 `koanf:"genesis-block"`
+	FromBlock      uint64 `koanf:"from-block"`
+	ToBlock        uint64 `koanf:"to-block"`
+	ParentChainURL string `koanf:"parent-chain-url"`
+	SequencerInbox string `koanf:"sequencer-inbox"`
+	SigningKey     string `koanf:"signing-key"`
+	Out            string `koanf:"out"`
+}
+
+func exportConfigAddOptions(f *flag.FlagSet) {
+	f.String("db", "", "directory of the arbitrumdata database of a stopped node, used to find the batches (required)")
+	f.String("db-engine", "pebble", "engine of the node's database (pebble or leveldb)")
+	f.String("feed", "", "feed messages recorded by feedaudit record (required)")
+	f.Uint64("chain-id", 0, "chain id of the feed (required)")
+	f.Uint64("genesis-block", 0, "the chain's genesis block number")
+	f.Uint64("from-block", 0, "first block to export")
+	f.Uint64("to-block", 0, "last block to export (required)")
+	f.String("parent-chain-url", "", "parent chain RPC URL to read the posted batches from (required)")
+	f.String("sequencer-inbox", "", "address of the chain's sequencer inbox on the parent chain (required)")
+	f.String("signing-key", "", "key signing the export, hex encoded with a 0x prefix or the path of a key file (required)")
+	f.String("out", "", "file to write the export to (required)")
+}
+
+func (c *ExportConfig) Validate() error {
+	if c.DB == "" || c.Feed == "" || c.Out == "" || c.ChainID == 0 {
+		return errors.New("--db, --feed, --chain-id and --out are required")
+	}
+	if c.DBEngine != rawdb.DBPebble && c.DBEngine != rawdb.DBLeveldb {
+		return fmt.Errorf("invalid --db-engine \"%v\"", c.DBEngine)
+	}
+	if c.FromBlock < c.GenesisBlock || c.ToBlock < c.FromBlock {
+		return fmt.Errorf("invalid block range [%v, %v] for genesis block %v", c.FromBlock, c.ToBlock, c.GenesisBlock)
+	}
+	if c.ParentChainURL == "" || !common.IsHexAddress(c.SequencerInbox) {
+		return errors.New("--parent-chain-url and --sequencer-inbox are required")
+	}
+	if c.SigningKey == "" {
+		return errors.New("--signing-key is required")
+	}
+	return nil
+}
+
+func loadSigningKey(key string) (*ecdsa.PrivateKey, error) {
+	if strings.HasPrefix(key, "0x") {
+		return crypto.HexToECDSA(key[2:])
+	}
+	return crypto.LoadECDSA(key)
+}
+
+func readFeedRecord(path string) ([]*m.BroadcastFeedMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var messages []*m.BroadcastFeedMessage
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		var msg m.BroadcastFeedMessage
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading feed message %v of %v: %w", len(messages), path, err)
+		}
+		messages = append(messages, &msg)
+	}
+}
+
+func startExport(args []string) error {
+	var config ExportConfig
+	if err := parseConfig("export", exportConfigAddOptions, args, &config); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	key, err := loadSigningKey(config.SigningKey)
+	if err != nil {
+		return err
+	}
+	feed, err := readFeedRecord(config.Feed)
+	if err != nil {
+		return err
+	}
+	// Don't create an empty database to export from
+	if _, err := os.Stat(config.DB); err != nil {
+		return err
+	}
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Type:               config.DBEngine,
+		Directory:          config.DB,
+		Namespace:          "arbitrumdata/",
+		Cache:              16,
+		Handles:            16,
+		ReadOnly:           true,
+		PebbleExtraOptions: conf.PersistentConfigDefault.Pebble.ExtraOptions("arbitrumdata"),
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tracker, err := arbnode.NewInboxTracker(db, nil, nil, arbnode.SnapSyncConfig{})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := ethclient.DialContext(ctx, config.ParentChainURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	inbox, err := arbnode.NewSequencerInbox(client, common.HexToAddress(config.SequencerInbox), 0)
+	if err != nil {
+		return err
+	}
+	readBatch := func(ctx context.Context, seqNum uint64, parentChainBlock uint64) ([]byte, common.Hash, error) {
+		block := new(big.Int).SetUint64(parentChainBlock)
+		batches, err := inbox.LookupBatchesInRange(ctx, block, block)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		for _, batch := range batches {
+			if batch.SequenceNumber == seqNum {
+				data, err := batch.Serialize(ctx, client)
+				return data, batch.BlockHash, err
+			}
+		}
+		return nil, common.Hash{}, fmt.Errorf("batch not found in parent chain block %v", parentChainBlock)
+	}
+
+	first := arbutil.BlockNumberToMessageCount(config.FromBlock, config.GenesisBlock) - 1
+	end := arbutil.BlockNumberToMessageCount(config.ToBlock, config.GenesisBlock)
+	export, err := arbnode.BuildFeedAuditExport(ctx, tracker, readBatch, config.ChainID, feed, first, end)
+	if err != nil {
+		return err
+	}
+	// #nosec G115
+	if recorded := len(export.FeedMessages); recorded < int(end-first) {
+		fmt.Fprintf(os.Stderr, "warning: only %v of the %v messages in the range were recorded from the feed\n", recorded, end-first)
+	}
+	signed, err := arbnode.SignFeedAuditExport(export, signature.DataSignerFromPrivateKey(key), crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.Out, encoded, 0600)
+}
+
+// feedaudit verify
+
+type VerifyConfig struct {
+	Export         string        `koanf:"export"`
+	Exporter       string        `koanf:"exporter"`
+	Sequencers     []string      `koanf:"sequencer"`
+	ParentChainURL string        `koanf:"parent-chain-url"`
+	SequencerInbox string        `koanf:"sequencer-inbox"`
+	BeaconURL      string        `koanf:"beacon-url"`
+	DASURLs        []string      `koanf:"das-url"`
+	ChainInfoFiles []string      `koanf:"chain-info-files"`
+	JSON           bool          `koanf:"json"`
+	Timeout        time.Duration `koanf:"timeout"`
+}
+
+func verifyConfigAddOptions(f *flag.FlagSet) {
+	f.String("export", "", "export written by feedaudit export (required)")
+	f.String("exporter", "", "if set, require the export to be signed by this address")
+	f.StringSlice("sequencer", nil, "addresses allowed to sign the feed; feed signatures aren't checked if none are given")
+	f.String("parent-chain-url", "", "parent chain RPC URL, needed to read batches posted as DAS certificates or blobs")
+	f.String("sequencer-inbox", "", "address of the chain's sequencer inbox on the parent chain, needed for DAS batches")
+	f.String("beacon-url", "", "beacon chain URL to read blob batches from")
+	f.StringSlice("das-url", nil, "REST URLs of data availability servers to read batches posted as DAS certificates from")
+	f.StringSlice("chain-info-files", nil, "files with the chain's info, if it isn't a known chain, to read the batch formats it allows from")
+	f.Bool("json", false, "print the report as JSON")
+	f.Duration("timeout", 10*time.Minute, "timeout for verifying the export")
+}
+
+// dasReaders reads from the first data availability server that has the data.
+type dasReaders []daprovider.DASReader
+
+func (r dasReaders) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	var errs []error
+	for _, reader := range r {
+		data, err := reader.GetByHash(ctx, hash)
+		if err == nil {
+			return data, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no data availability server has %v: %w", hash, errors.Join(errs...))
+}
+
+func (r dasReaders) ExpirationPolicy(ctx context.Context) (daprovider.ExpirationPolicy, error) {
+	return r[0].ExpirationPolicy(ctx)
+}
+
+func openDAPReaders(ctx context.Context, config *VerifyConfig) ([]daprovider.Reader, func(), error) {
+	if config.ParentChainURL == "" {
+		if len(config.DASURLs) > 0 || config.BeaconURL != "" {
+			return nil, nil, errors.New("reading DAS or blob batches needs --parent-chain-url")
+		}
+		return nil, func() {}, nil
+	}
+	client, err := ethclient.DialContext(ctx, config.ParentChainURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dapReaders []daprovider.Reader
+	if len(config.DASURLs) > 0 {
+		if !common.IsHexAddress(config.SequencerInbox) {
+			client.Close()
+			return nil, nil, errors.New("reading DAS batches needs --sequencer-inbox")
+		}
+		var readers dasReaders
+		for _, url := range config.DASURLs {
+			reader, err := das.NewRestfulDasClientFromURL(url)
+			if err != nil {
+				client.Close()
+				return nil, nil, err
+			}
+			readers = append(readers, reader)
+		}
+		keysetFetcher, err := das.NewKeysetFetcher(client, common.HexToAddress(config.SequencerInbox))
+		if err != nil {
+			client.Close()
+			return nil, nil, err
+		}
+		dapReaders = append(dapReaders, daprovider.NewReaderForDAS(readers, keysetFetcher))
+	}
+	if config.BeaconURL != "" {
+		blobClient, err := headerreader.NewBlobClient(headerreader.BlobClientConfig{BeaconUrl: config.BeaconURL}, client)
+		if err == nil {
+			err = blobClient.Initialize(ctx)
+		}
+		if err != nil {
+			client.Close()
+			return nil, nil, err
+		}
+		dapReaders = append(dapReaders, daprovider.NewReaderForBlobReader(blobClient))
+	}
+	return dapReaders, client.Close, nil
+}
+
+func startVerify(args []string, w io.Writer) (bool, error) {
+	var config VerifyConfig
+	if err := parseConfig("verify", verifyConfigAddOptions, args, &config); err != nil {
+		return false, err
+	}
+	if config.Export == "" {
+		return false, errors.New("--export is required")
+	}
+	if config.Exporter != "" && !common.IsHexAddress(config.Exporter) {
+		return false, fmt.Errorf("invalid --exporter %v", config.Exporter)
+	}
+	var sequencers []common.Address
+	for _, sequencer := range config.Sequencers {
+		if !common.IsHexAddress(sequencer) {
+			return false, fmt.Errorf("invalid --sequencer %v", sequencer)
+		}
+		sequencers = append(sequencers, common.HexToAddress(sequencer))
+	}
+
+	contents, err := os.ReadFile(config.Export)
+	if err != nil {
+		return false, err
+	}
+	var signed arbnode.SignedFeedAuditExport
+	if err := json.Unmarshal(contents, &signed); err != nil {
+		return false, err
+	}
+	export, err := signed.Open()
+	if err != nil {
+		return false, err
+	}
+	if config.Exporter != "" && signed.Signer != common.HexToAddress(config.Exporter) {
+		return false, fmt.Errorf("export is signed by %v, not %v", signed.Signer, config.Exporter)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	dapReaders, closeClient, err := openDAPReaders(ctx, &config)
+	if err != nil {
+		return false, err
+	}
+	defer closeClient()
+	chainConfig, err := chaininfo.GetChainConfig(new(big.Int).SetUint64(export.ChainID), "", 0, config.ChainInfoFiles, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, batches using chain specific formats will be reported as malformed\n", err)
+		chainConfig = nil
+	}
+	report, err := arbnode.VerifyFeedAudit(ctx, export, sequencers, dapReaders, chainConfig)
+	if err != nil {
+		return false, err
+	}
+
+	if config.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return false, err
+		}
+	} else {
+		fmt.Fprintf(w, "export of messages [%v, %v) on chain %v signed by %v\n", export.FirstMessage, export.EndMessage, export.ChainID, signed.Signer)
+		fmt.Fprintf(w, "checked %v messages against %v batches\n", report.MessagesChecked, len(export.Batches))
+		if len(report.Issues) == 0 {
+			fmt.Fprintln(w, "no issues")
+		} else {
+			fmt.Fprintf(w, "%v issues:\n", len(report.Issues))
+			for _, issue := range report.Issues {
+				fmt.Fprintf(w, "  %v\n", issue)
+			}
+		}
+	}
+	return len(report.Issues) > 0, nil
+}
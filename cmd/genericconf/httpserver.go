@@ -0,0 +1,243 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package genericconf
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+type TLSServerConfig struct {
+	CertFile     string `koanf:"cert-file"`
+	KeyFile      string `koanf:"key-file"`
+	ClientCAFile string `koanf:"client-ca-file"`
+}
+
+var TLSServerConfigDefault = TLSServerConfig{
+	CertFile:     "",
+	KeyFile:      "",
+	ClientCAFile: "",
+}
+
+func TLSServerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".cert-file", TLSServerConfigDefault.CertFile, "path to the PEM encoded TLS certificate; TLS is disabled if unset")
+	f.String(prefix+".key-file", TLSServerConfigDefault.KeyFile, "path to the PEM encoded TLS private key")
+	f.String(prefix+".client-ca-file", TLSServerConfigDefault.ClientCAFile, "path to PEM encoded CA certificates; if set clients must present a certificate signed by one of them (mTLS)")
+}
+
+func (c *TLSServerConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+func (c *TLSServerConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("tls cert-file and key-file must be set together")
+	}
+	if c.ClientCAFile != "" && !c.Enabled() {
+		return errors.New("tls client-ca-file requires cert-file and key-file to be set")
+	}
+	return nil
+}
+
+// TLSConfig loads the configured certificates, returning nil if TLS is disabled.
+func (c *TLSServerConfig) TLSConfig() (*tls.Config, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading tls key pair: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading tls client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in tls client ca file %v", c.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// HTTPServerSecurityConfig holds the settings shared by all of the embedded HTTP servers,
+// so they can be configured consistently from a single config block.
+type HTTPServerSecurityConfig struct {
+	TLS             TLSServerConfig `koanf:"tls"`
+	CORSDomain      []string        `koanf:"corsdomain"`
+	AuthTokenFile   string          `koanf:"auth-token-file"`
+	AuthExemptPaths []string        `koanf:"auth-exempt-paths"`
+}
+
+var HTTPServerSecurityConfigDefault = HTTPServerSecurityConfig{
+	TLS:             TLSServerConfigDefault,
+	CORSDomain:      []string{},
+	AuthTokenFile:   "",
+	AuthExemptPaths: []string{"/health", "/livenessprobe"},
+}
+
+func HTTPServerSecurityConfigAddOptions(prefix string, f *flag.FlagSet) {
+	TLSServerConfigAddOptions(prefix+".tls", f)
+	f.StringSlice(prefix+".corsdomain", HTTPServerSecurityConfigDefault.CORSDomain, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
+	f.String(prefix+".auth-token-file", HTTPServerSecurityConfigDefault.AuthTokenFile, "path to a file holding a bearer token that requests must present in the Authorization header; auth is disabled if unset")
+	f.StringSlice(prefix+".auth-exempt-paths", HTTPServerSecurityConfigDefault.AuthExemptPaths, "request path prefixes that don't require the auth token (e.g. health checks)")
+}
+
+func (c *HTTPServerSecurityConfig) Validate() error {
+	return c.TLS.Validate()
+}
+
+// HTTPServerAuth is the token auth of a HTTPServerSecurityConfig, loaded once when a server starts.
+// A nil HTTPServerAuth authorizes every request.
+type HTTPServerAuth struct {
+	token       string
+	exemptPaths []string
+}
+
+// ReadAuthTokenFile reads a bearer token from a file, for servers checking it and clients presenting it.
+// It returns an empty token if the path is unset.
+func ReadAuthTokenFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading auth token file: %w", err)
+	}
+	trimmed := strings.TrimSpace(string(token))
+	if trimmed == "" {
+		return "", fmt.Errorf("auth token file %v is empty", path)
+	}
+	return trimmed, nil
+}
+
+// LoadAuth reads the auth token file, returning nil if auth is disabled.
+func (c *HTTPServerSecurityConfig) LoadAuth() (*HTTPServerAuth, error) {
+	token, err := ReadAuthTokenFile(c.AuthTokenFile)
+	if err != nil || token == "" {
+		return nil, err
+	}
+	return &HTTPServerAuth{
+		token:       token,
+		exemptPaths: c.AuthExemptPaths,
+	}, nil
+}
+
+// ValidateDistinctAuthTokens checks that servers with auth enabled each use their own token file,
+// so leaking the token of one server, like a public feed, doesn't expose the others.
+func ValidateDistinctAuthTokens(configs map[string]*HTTPServerSecurityConfig) error {
+	users := make(map[string]string)
+	for name, config := range configs {
+		if config.AuthTokenFile == "" {
+			continue
+		}
+		if other, ok := users[config.AuthTokenFile]; ok {
+			return fmt.Errorf("%v and %v must use different auth token files", other, name)
+		}
+		users[config.AuthTokenFile] = name
+	}
+	return nil
+}
+
+// Authorized returns whether a request with the given path and Authorization header value may proceed.
+func (a *HTTPServerAuth) Authorized(path string, authorization string) bool {
+	if a == nil {
+		return true
+	}
+	for _, exempt := range a.exemptPaths {
+		if strings.HasPrefix(path, exempt) {
+			return true
+		}
+	}
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+func (c *HTTPServerSecurityConfig) corsAllowed(origin string) bool {
+	for _, domain := range c.CORSDomain {
+		if domain == "*" || strings.EqualFold(domain, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// WrapHandler applies CORS and token auth to a handler, loading the auth token.
+func (c *HTTPServerSecurityConfig) WrapHandler(handler http.Handler) (http.Handler, error) {
+	auth, err := c.LoadAuth()
+	if err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && c.corsAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Add("Vary", "Origin")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		if !auth.Authorized(r.URL.Path, r.Header.Get("Authorization")) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}), nil
+}
+
+// NewHTTPServer creates an http.Server applying the timeouts and, if security is non-nil, its TLS, CORS, and auth settings.
+// The server should be started with ServeHTTPServer so TLS is used when configured.
+func NewHTTPServer(handler http.Handler, timeouts HTTPServerTimeoutConfig, security *HTTPServerSecurityConfig) (*http.Server, error) {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       timeouts.ReadTimeout,
+		ReadHeaderTimeout: timeouts.ReadHeaderTimeout,
+		WriteTimeout:      timeouts.WriteTimeout,
+		IdleTimeout:       timeouts.IdleTimeout,
+	}
+	if security == nil {
+		return srv, nil
+	}
+	tlsConfig, err := security.TLS.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = tlsConfig
+	srv.Handler, err = security.WrapHandler(handler)
+	if err != nil {
+		return nil, err
+	}
+	return srv, nil
+}
+
+// ServeHTTPServer serves on the listener, using TLS if the server was created with a TLS config.
+func ServeHTTPServer(srv *http.Server, listener net.Listener) error {
+	if srv.TLSConfig != nil {
+		// The certificates are already loaded into the TLS config
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
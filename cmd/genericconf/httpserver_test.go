@@ -0,0 +1,109 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package genericconf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTTPServerSecurityAuth(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := HTTPServerSecurityConfigDefault
+	config.AuthTokenFile = tokenFile
+	config.CORSDomain = []string{"https://example.com"}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := config.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path          string
+		authorization string
+		expected      int
+	}{
+		{"/get-by-hash/0x00", "", http.StatusUnauthorized},
+		{"/get-by-hash/0x00", "Bearer wrong", http.StatusUnauthorized},
+		{"/get-by-hash/0x00", "secret", http.StatusUnauthorized},
+		{"/get-by-hash/0x00", "Bearer secret", http.StatusOK},
+		{"/health", "", http.StatusOK},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		req.Header.Set("Origin", "https://example.com")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.expected {
+			t.Errorf("request to %v with authorization %q got status %v, expected %v", c.path, c.authorization, rec.Code, c.expected)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+			t.Errorf("request to %v missing CORS header", c.path)
+		}
+	}
+
+	auth, err := config.LoadAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Authorized("/", "") || !auth.Authorized("/", "Bearer secret") || !auth.Authorized("/livenessprobe", "") {
+		t.Error("loaded auth doesn't match the wrapped handler")
+	}
+	var disabled *HTTPServerAuth
+	if !disabled.Authorized("/", "") {
+		t.Error("disabled auth rejected a request")
+	}
+}
+
+func TestHTTPServerSecurityValidate(t *testing.T) {
+	config := HTTPServerSecurityConfigDefault
+	config.TLS.CertFile = "cert.pem"
+	if err := config.Validate(); err == nil {
+		t.Error("expected error with cert-file but no key-file")
+	}
+	config = HTTPServerSecurityConfigDefault
+	config.TLS.ClientCAFile = "ca.pem"
+	if err := config.Validate(); err == nil {
+		t.Error("expected error with client-ca-file but TLS disabled")
+	}
+	// The token file is only read when a server starts
+	config = HTTPServerSecurityConfigDefault
+	config.AuthTokenFile = filepath.Join(t.TempDir(), "missing")
+	if err := config.Validate(); err != nil {
+		t.Error("validate read the auth token file:", err)
+	}
+	if _, err := config.LoadAuth(); err == nil {
+		t.Error("expected error loading a missing auth token file")
+	}
+}
+
+func TestValidateDistinctAuthTokens(t *testing.T) {
+	feed := HTTPServerSecurityConfigDefault
+	metrics := HTTPServerSecurityConfigDefault
+	if err := ValidateDistinctAuthTokens(map[string]*HTTPServerSecurityConfig{"feed": &feed, "metrics": &metrics}); err != nil {
+		t.Error("servers without auth rejected:", err)
+	}
+	feed.AuthTokenFile = "feed-token"
+	metrics.AuthTokenFile = "metrics-token"
+	if err := ValidateDistinctAuthTokens(map[string]*HTTPServerSecurityConfig{"feed": &feed, "metrics": &metrics}); err != nil {
+		t.Error("servers with their own tokens rejected:", err)
+	}
+	metrics.AuthTokenFile = "feed-token"
+	if err := ValidateDistinctAuthTokens(map[string]*HTTPServerSecurityConfig{"feed": &feed, "metrics": &metrics}); err == nil {
+		t.Error("expected error with a shared auth token file")
+	}
+}
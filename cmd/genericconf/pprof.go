@@ -1,7 +1,9 @@
 package genericconf
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 
 	// Blank import pprof registers its HTTP handlers.
@@ -10,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/metrics/exp"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
 )
 
 func StartPprof(address string) {
@@ -22,3 +25,25 @@ func StartPprof(address string) {
 		}
 	}()
 }
+
+// StartMetricsServer serves the same endpoints as exp.Setup, but with the given timeouts and security settings applied.
+func StartMetricsServer(address string, timeouts HTTPServerTimeoutConfig, security *HTTPServerSecurityConfig) error {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/metrics", exp.ExpHandler(metrics.DefaultRegistry))
+	mux.Handle("/debug/metrics/prometheus", prometheus.Handler(metrics.DefaultRegistry))
+	srv, err := NewHTTPServer(mux, timeouts, security)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	log.Info("Starting metrics server", "addr", fmt.Sprintf("%s/debug/metrics", address), "tls", srv.TLSConfig != nil)
+	go func() {
+		if err := ServeHTTPServer(srv, listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Failure in running metrics server", "err", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// l1feereport fetches an L1 fee reimbursement report from a node's arb_l1FeeReport RPC method
+// and writes it as JSON, or as CSV with one row per day or per batch.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	f := flag.NewFlagSet("l1feereport", flag.ContinueOnError)
+	url := f.String("url", "http://localhost:8547", "node RPC URL, which must expose the arb namespace")
+	from := f.String("from", "", "start of the report, as an RFC 3339 time or a UTC date such as 2024-06-01 (inclusive)")
+	to := f.String("to", "", "end of the report, as an RFC 3339 time or a UTC date (exclusive)")
+	format := f.String("format", "csv", "output format: csv, batches-csv or json")
+	output := f.String("output", "", "file to write the report to (defaults to stdout)")
+	timeout := f.Duration("timeout", time.Hour, "timeout for generating the report")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("--from and --to are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	client, err := rpc.DialContext(ctx, *url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	var report arbnode.L1FeeReport
+	if err := client.CallContext(ctx, &report, "arb_l1FeeReport", *from, *to); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "csv":
+		return report.WriteDaysCSV(w)
+	case "batches-csv":
+		return report.WriteBatchesCSV(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(&report)
+	default:
+		return fmt.Errorf("unknown format %v", *format)
+	}
+}
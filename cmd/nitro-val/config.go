@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"reflect"
@@ -18,21 +19,23 @@ import (
 )
 
 type ValidationNodeConfig struct {
-	Conf          genericconf.ConfConfig          `koanf:"conf" reload:"hot"`
-	Validation    valnode.Config                  `koanf:"validation" reload:"hot"`
-	LogLevel      string                          `koanf:"log-level" reload:"hot"`
-	LogType       string                          `koanf:"log-type" reload:"hot"`
-	FileLogging   genericconf.FileLoggingConfig   `koanf:"file-logging" reload:"hot"`
-	Persistent    conf.PersistentConfig           `koanf:"persistent"`
-	HTTP          genericconf.HTTPConfig          `koanf:"http"`
-	WS            genericconf.WSConfig            `koanf:"ws"`
-	IPC           genericconf.IPCConfig           `koanf:"ipc"`
-	Auth          genericconf.AuthRPCConfig       `koanf:"auth"`
-	Metrics       bool                            `koanf:"metrics"`
-	MetricsServer genericconf.MetricsServerConfig `koanf:"metrics-server"`
-	PProf         bool                            `koanf:"pprof"`
-	PprofCfg      genericconf.PProf               `koanf:"pprof-cfg"`
-	Workdir       string                          `koanf:"workdir" reload:"hot"`
+	Conf            genericconf.ConfConfig               `koanf:"conf" reload:"hot"`
+	Validation      valnode.Config                       `koanf:"validation" reload:"hot"`
+	LogLevel        string                               `koanf:"log-level" reload:"hot"`
+	LogType         string                               `koanf:"log-type" reload:"hot"`
+	FileLogging     genericconf.FileLoggingConfig        `koanf:"file-logging" reload:"hot"`
+	Persistent      conf.PersistentConfig                `koanf:"persistent"`
+	HTTP            genericconf.HTTPConfig               `koanf:"http"`
+	WS              genericconf.WSConfig                 `koanf:"ws"`
+	IPC             genericconf.IPCConfig                `koanf:"ipc"`
+	Auth            genericconf.AuthRPCConfig            `koanf:"auth"`
+	Metrics         bool                                 `koanf:"metrics"`
+	MetricsServer   genericconf.MetricsServerConfig      `koanf:"metrics-server"`
+	ServerSecurity  genericconf.HTTPServerSecurityConfig `koanf:"server-security"`
+	MetricsSecurity genericconf.HTTPServerSecurityConfig `koanf:"metrics-server-security"`
+	PProf           bool                                 `koanf:"pprof"`
+	PprofCfg        genericconf.PProf                    `koanf:"pprof-cfg"`
+	Workdir         string                               `koanf:"workdir" reload:"hot"`
 }
 
 var HTTPConfigDefault = genericconf.HTTPConfig{
@@ -59,19 +62,21 @@ var IPCConfigDefault = genericconf.IPCConfig{
 }
 
 var ValidationNodeConfigDefault = ValidationNodeConfig{
-	Conf:          genericconf.ConfConfigDefault,
-	LogLevel:      "INFO",
-	LogType:       "plaintext",
-	Persistent:    conf.PersistentConfigDefault,
-	HTTP:          HTTPConfigDefault,
-	WS:            WSConfigDefault,
-	IPC:           IPCConfigDefault,
-	Auth:          genericconf.AuthRPCConfigDefault,
-	Metrics:       false,
-	MetricsServer: genericconf.MetricsServerConfigDefault,
-	PProf:         false,
-	PprofCfg:      genericconf.PProfDefault,
-	Workdir:       "",
+	Conf:            genericconf.ConfConfigDefault,
+	LogLevel:        "INFO",
+	LogType:         "plaintext",
+	Persistent:      conf.PersistentConfigDefault,
+	HTTP:            HTTPConfigDefault,
+	WS:              WSConfigDefault,
+	IPC:             IPCConfigDefault,
+	Auth:            genericconf.AuthRPCConfigDefault,
+	Metrics:         false,
+	MetricsServer:   genericconf.MetricsServerConfigDefault,
+	ServerSecurity:  genericconf.HTTPServerSecurityConfigDefault,
+	MetricsSecurity: genericconf.HTTPServerSecurityConfigDefault,
+	PProf:           false,
+	PprofCfg:        genericconf.PProfDefault,
+	Workdir:         "",
 }
 
 func ValidationNodeConfigAddOptions(f *flag.FlagSet) {
@@ -87,6 +92,8 @@ func ValidationNodeConfigAddOptions(f *flag.FlagSet) {
 	genericconf.AuthRPCConfigAddOptions("auth", f)
 	f.Bool("metrics", ValidationNodeConfigDefault.Metrics, "enable metrics")
 	genericconf.MetricsServerAddOptions("metrics-server", f)
+	genericconf.HTTPServerSecurityConfigAddOptions("server-security", f)
+	genericconf.HTTPServerSecurityConfigAddOptions("metrics-server-security", f)
 	f.Bool("pprof", ValidationNodeConfigDefault.PProf, "enable pprof")
 	genericconf.PProfAddOptions("pprof-cfg", f)
 	f.String("workdir", ValidationNodeConfigDefault.Workdir, "path used for purpose of resolving relative paths (ia. jwt secret file, log files), if empty then current working directory will be used.")
@@ -144,8 +151,26 @@ func (c *ValidationNodeConfig) GetReloadInterval() time.Duration {
 }
 
 func (c *ValidationNodeConfig) Validate() error {
-	// TODO
-	return nil
+	if err := c.Validation.Divergence.Validate(); err != nil {
+		return err
+	}
+	if err := c.ServerSecurity.Validate(); err != nil {
+		return err
+	}
+	if err := c.MetricsSecurity.Validate(); err != nil {
+		return err
+	}
+	if err := genericconf.ValidateDistinctAuthTokens(map[string]*genericconf.HTTPServerSecurityConfig{
+		"server-security":         &c.ServerSecurity,
+		"metrics-server-security": &c.MetricsSecurity,
+	}); err != nil {
+		return err
+	}
+	if c.ServerSecurity.AuthTokenFile != "" && c.Validation.ApiAuth {
+		// Both would be sent in the Authorization header
+		return errors.New("server-security.auth-token-file can't be used with validation.api-auth, which authenticates with the jwt secret")
+	}
+	return c.Validation.Arbitrator.Validate()
 }
 
 var DefaultValidationNodeStackConfig = node.Config{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	_ "net/http/pprof" // #nosec G108
 	"os"
 	"os/signal"
@@ -16,7 +17,6 @@ import (
 	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 	"github.com/ethereum/go-ethereum/node"
 
 	"github.com/offchainlabs/nitro/cmd/genericconf"
@@ -47,7 +47,9 @@ func startMetrics(cfg *ValidationNodeConfig) error {
 	}
 	if cfg.Metrics {
 		go metrics.CollectProcessMetrics(cfg.MetricsServer.UpdateInterval)
-		exp.Setup(fmt.Sprintf("%v:%v", cfg.MetricsServer.Addr, cfg.MetricsServer.Port))
+		if err := genericconf.StartMetricsServer(mAddr, genericconf.HTTPServerTimeoutConfigDefault, &cfg.MetricsSecurity); err != nil {
+			return err
+		}
 	}
 	if cfg.PProf {
 		genericconf.StartPprof(pAddr)
@@ -118,6 +120,16 @@ func mainImpl() int {
 
 	valnode.EnsureValidationExposedViaAuthRPC(&stackConf)
 
+	// The stack serves its own listeners, so only CORS and token auth can be applied to them
+	wrapHTTPHandler := node.WrapHTTPHandler
+	node.WrapHTTPHandler = func(srv http.Handler) (http.Handler, error) {
+		srv, err := nodeConfig.ServerSecurity.WrapHandler(srv)
+		if err != nil {
+			return nil, err
+		}
+		return wrapHTTPHandler(srv)
+	}
+
 	stack, err := node.New(&stackConf)
 	if err != nil {
 		flag.Usage()
@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	"github.com/offchainlabs/nitro/das"
@@ -84,6 +86,95 @@ func TestAggregatorConfig(t *testing.T) {
 	Require(t, err)
 }
 
+func chainInfoJsonWithPreset(t *testing.T, preset map[string]interface{}, required map[string]interface{}) string {
+	var chainsInfo []chaininfo.ChainInfo
+	Require(t, json.Unmarshal(chaininfo.DefaultChainInfo, &chainsInfo))
+	for _, chainInfo := range chainsInfo {
+		if chainInfo.ChainName == "arb-dev-test" {
+			chainInfo.NodeConfigPreset = preset
+			chainInfo.RequiredNodeConfig = required
+			infoJson, err := json.Marshal([]chaininfo.ChainInfo{chainInfo})
+			Require(t, err)
+			return string(infoJson)
+		}
+	}
+	Fail(t, "arb-dev-test chain info not found")
+	return ""
+}
+
+func TestChainConfigPreset(t *testing.T) {
+	baseArgs := strings.Split("--persistent.chain /tmp/data --init.dev-init --node.parent-chain-reader.enable=false --parent-chain.id 5 --execution.forwarding-target null", " ")
+	infoJson := chainInfoJsonWithPreset(t, map[string]interface{}{"execution.caching.trie-clean-cache": 1234}, nil)
+
+	config, _, err := ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson))
+	Require(t, err)
+	if config.Execution.Caching.TrieCleanCache != 1234 {
+		Fail(t, "preset wasn't applied, trie clean cache is", config.Execution.Caching.TrieCleanCache)
+	}
+
+	config, _, err = ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson, "--execution.caching.trie-clean-cache", "99"))
+	Require(t, err)
+	if config.Execution.Caching.TrieCleanCache != 99 {
+		Fail(t, "command line option didn't override the preset, trie clean cache is", config.Execution.Caching.TrieCleanCache)
+	}
+
+	config, _, err = ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson, "--chain.preset=false"))
+	Require(t, err)
+	if config.Execution.Caching.TrieCleanCache == 1234 {
+		Fail(t, "preset was applied despite being disabled")
+	}
+
+	infoJson = chainInfoJsonWithPreset(t, map[string]interface{}{"execution.caching.no-such-option": 1}, nil)
+	_, _, err = ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson))
+	if err == nil || !strings.Contains(err.Error(), "unknown option") {
+		Fail(t, "preset with unknown option wasn't rejected, err", err)
+	}
+}
+
+func TestRequiredChainConfig(t *testing.T) {
+	baseArgs := strings.Split("--persistent.chain /tmp/data --init.dev-init --node.parent-chain-reader.enable=false --parent-chain.id 5 --execution.forwarding-target null", " ")
+	infoJson := chainInfoJsonWithPreset(t, nil, map[string]interface{}{"execution.caching.archive": true})
+
+	config, _, err := ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson))
+	Require(t, err)
+	if !config.Execution.Caching.Archive {
+		Fail(t, "required option wasn't applied")
+	}
+
+	_, _, err = ParseNode(context.Background(), append(baseArgs, "--chain.info-json", infoJson, "--execution.caching.archive=false"))
+	if err == nil || !strings.Contains(err.Error(), "requires execution.caching.archive to be true") {
+		Fail(t, "overriding a required option wasn't rejected, err", err)
+	}
+}
+
+func TestAnyTrustChainDataAvailability(t *testing.T) {
+	var chainsInfo []chaininfo.ChainInfo
+	Require(t, json.Unmarshal(chaininfo.DefaultChainInfo, &chainsInfo))
+	var infoJson string
+	for _, chainInfo := range chainsInfo {
+		if chainInfo.ChainName == "arb-dev-test" {
+			chainInfo.ChainConfig.ArbitrumChainParams.DataAvailabilityCommittee = true
+			infoBytes, err := json.Marshal([]chaininfo.ChainInfo{chainInfo})
+			Require(t, err)
+			infoJson = string(infoBytes)
+		}
+	}
+	baseArgs := strings.Split("--persistent.chain /tmp/data --init.dev-init --parent-chain.id 5 --execution.forwarding-target null", " ")
+	baseArgs = append(baseArgs, "--chain.info-json", infoJson)
+
+	// Without the parent chain reader there are no batches to read
+	config, _, err := ParseNode(context.Background(), append(baseArgs, "--node.dangerous.no-l1-listener"))
+	Require(t, err)
+	if config.Node.DataAvailability.Enable {
+		Fail(t, "data availability was enabled without the parent chain reader")
+	}
+
+	_, _, err = ParseNode(context.Background(), append(baseArgs, "--node.data-availability.enable=false"))
+	if err == nil || !strings.Contains(err.Error(), "AnyTrust") {
+		Fail(t, "reading an AnyTrust chain without data availability wasn't rejected, err", err)
+	}
+}
+
 func TestReloads(t *testing.T) {
 	var check func(node reflect.Value, cold bool, path string)
 	check = func(node reflect.Value, cold bool, path string) {
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/snapshot"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/cmd/chaininfo"
@@ -46,11 +47,15 @@ import (
 	"github.com/offchainlabs/nitro/statetransfer"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/dbutil"
+	"github.com/offchainlabs/nitro/util/rpcclient"
 )
 
 var notFoundError = errors.New("file not found")
 
 func downloadInit(ctx context.Context, initConfig *conf.InitConfig) (string, error) {
+	if initConfig.Peer.URL != "" && initConfig.Latest != "" {
+		return downloadPeerSnapshot(ctx, initConfig)
+	}
 	if initConfig.Url == "" {
 		return "", nil
 	}
@@ -58,6 +63,9 @@ func downloadInit(ctx context.Context, initConfig *conf.InitConfig) (string, err
 		return initConfig.Url[5:], nil
 	}
 	log.Info("Downloading initial database", "url", initConfig.Url)
+	if initConfig.SnapshotSigner != "" {
+		return downloadSignedSnapshot(ctx, initConfig)
+	}
 	if !initConfig.ValidateChecksum {
 		file, err := downloadFile(ctx, initConfig, initConfig.Url, nil)
 		if err != nil && errors.Is(err, notFoundError) {
@@ -213,6 +221,87 @@ func downloadInitInParts(ctx context.Context, initConfig *conf.InitConfig) (stri
 		partNames = append(partNames, fields[1])
 	}
 
+	if !initConfig.ValidateChecksum {
+		checksums = nil
+	}
+	return downloadParts(ctx, initConfig, archiveUrl, partNames, checksums)
+}
+
+// downloadSignedSnapshot downloads a snapshot whose manifest is signed by the configured snapshot
+// signer, checking every part against the manifest's checksums.
+func downloadSignedSnapshot(ctx context.Context, initConfig *conf.InitConfig) (string, error) {
+	fileInfo, err := os.Stat(initConfig.DownloadPath)
+	if err != nil || !fileInfo.IsDir() {
+		return "", fmt.Errorf("download path must be a directory: %v", initConfig.DownloadPath)
+	}
+	archiveUrl, err := url.Parse(initConfig.Url)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse init url \"%s\": %w", initConfig.Url, err)
+	}
+	manifestData, err := httpGet(ctx, archiveUrl.String()+snapshot.ManifestSuffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed snapshot manifest: %w", err)
+	}
+	manifest, err := snapshot.ParseManifest(manifestData, common.HexToAddress(initConfig.SnapshotSigner))
+	if err != nil {
+		return "", err
+	}
+	log.Info("Verified snapshot manifest signature", "signer", initConfig.SnapshotSigner, "headBlock", manifest.HeadBlockNumber, "assertionBlockHash", manifest.Assertion.BlockHash, "assertionStateHash", manifest.Assertion.StateHash)
+	partNames := make([]string, 0, len(manifest.Parts))
+	checksums := make([][]byte, 0, len(manifest.Parts))
+	for _, part := range manifest.Parts {
+		partNames = append(partNames, part.Name)
+		checksums = append(checksums, part.Sha256)
+	}
+	return downloadParts(ctx, initConfig, archiveUrl, partNames, checksums)
+}
+
+func startInitPeer(ctx context.Context, initConfig *conf.InitConfig) (*rpcclient.RpcClient, error) {
+	peer := rpcclient.NewRpcClient(func() *rpcclient.ClientConfig { return &initConfig.Peer }, nil)
+	if err := peer.Start(ctx); err != nil {
+		return nil, fmt.Errorf("error connecting to init peer: %w", err)
+	}
+	return peer, nil
+}
+
+// downloadPeerSnapshot downloads the latest snapshot of the requested kind from the init peer, checking it
+// against its manifest signed by the configured snapshot signer.
+func downloadPeerSnapshot(ctx context.Context, initConfig *conf.InitConfig) (string, error) {
+	peer, err := startInitPeer(ctx, initConfig)
+	if err != nil {
+		return "", err
+	}
+	defer peer.Close()
+	log.Info("Downloading initial database from peer", "peer", initConfig.Peer.URL, "kind", initConfig.Latest)
+	archive, _, err := snapshot.FetchSnapshot(ctx, peer, initConfig.Latest, common.HexToAddress(initConfig.SnapshotSigner), initConfig.DownloadPath)
+	return archive, err
+}
+
+// fetchPeerStateIfMissing fetches the state of the configured block from the init peer, if the database lacks it.
+func fetchPeerStateIfMissing(ctx context.Context, chainDb ethdb.Database, initConfig *conf.InitConfig) error {
+	if initConfig.PeerStateBlock < 0 {
+		return nil
+	}
+	number := uint64(initConfig.PeerStateBlock)
+	header := rawdb.ReadHeader(chainDb, rawdb.ReadCanonicalHash(chainDb, number), number)
+	if header == nil {
+		return fmt.Errorf("database has no block %v to fetch the state of", number)
+	}
+	if rawdb.HasLegacyTrieNode(chainDb, header.Root) {
+		return nil
+	}
+	peer, err := startInitPeer(ctx, initConfig)
+	if err != nil {
+		return err
+	}
+	defer peer.Close()
+	log.Info("Fetching missing state from peer", "peer", initConfig.Peer.URL, "block", number, "root", header.Root)
+	return snapshot.FetchState(ctx, peer, chainDb, header.Root, 512)
+}
+
+// downloadParts downloads the parts of an archive and joins them, validating each part against its
+// checksum if checksums isn't nil.
+func downloadParts(ctx context.Context, initConfig *conf.InitConfig, archiveUrl *url.URL, partNames []string, checksums [][]byte) (string, error) {
 	partFiles := []string{}
 	defer func() {
 		// remove all temporary files.
@@ -229,7 +318,7 @@ func downloadInitInParts(ctx context.Context, initConfig *conf.InitConfig) (stri
 		log.Info("Downloading database part", "part", partName)
 		partUrl := archiveUrl.JoinPath("..", partName).String()
 		var checksum []byte
-		if initConfig.ValidateChecksum {
+		if checksums != nil {
 			checksum = checksums[i]
 		}
 		partFile, err := downloadFile(ctx, initConfig, partUrl, checksum)
@@ -270,7 +359,7 @@ func joinArchive(parts []string, archivePath string) (string, error) {
 
 // setLatestSnapshotUrl sets the Url in initConfig to the latest one available on the mirror.
 func setLatestSnapshotUrl(ctx context.Context, initConfig *conf.InitConfig, chain string) error {
-	if initConfig.Latest == "" {
+	if initConfig.Latest == "" || initConfig.Peer.URL != "" {
 		return nil
 	}
 	if initConfig.Url != "" {
@@ -495,6 +584,24 @@ func validateOrUpgradeWasmStoreSchemaVersion(db ethdb.Database) error {
 	return nil
 }
 
+// archiveConversionPruneConfig returns the init config to prune with, switching to retention pruning
+// when a node that last ran as an archive is restarted without archive and prune-retention is set.
+func archiveConversionPruneConfig(chainDb ethdb.Database, config *NodeConfig) (*conf.InitConfig, error) {
+	initConfig := config.Init
+	if initConfig.Prune != "" || initConfig.PruneRetention == 0 || config.Execution.Caching.Archive {
+		return &initConfig, nil
+	}
+	mode, err := gethexec.ReadStateMode(chainDb)
+	if err != nil {
+		return nil, err
+	}
+	if mode == gethexec.StateModeArchive {
+		log.Info("Node was an archive, pruning down to the retention window", "blocks", initConfig.PruneRetention)
+		initConfig.Prune = "retention"
+	}
+	return &initConfig, nil
+}
+
 func rebuildLocalWasm(ctx context.Context, config *gethexec.Config, l2BlockChain *core.BlockChain, chainDb, wasmDb ethdb.Database, rebuildMode string) (ethdb.Database, *core.BlockChain, error) {
 	var err error
 	latestBlock := l2BlockChain.CurrentBlock()
@@ -540,15 +647,58 @@ func rebuildLocalWasm(ctx context.Context, config *gethexec.Config, l2BlockChain
 	return chainDb, l2BlockChain, nil
 }
 
+// openL2ChainData opens the l2chaindata database with its freezer. If the ancient directory is
+// configured read-only, e.g. because it's shared between replicas, the key-value store is opened
+// separately so only the freezer is read-only, and new blocks stay in the local database.
+func openL2ChainData(stack *node.Node, persistentConfig *conf.PersistentConfig, cache int, handles int, readonly bool) (ethdb.Database, error) {
+	if !persistentConfig.AncientReadOnly {
+		return stack.OpenDatabaseWithFreezerWithExtraOptions("l2chaindata", cache, handles, persistentConfig.Ancient, "l2chaindata/", readonly, persistentConfig.Pebble.ExtraOptions("l2chaindata"))
+	}
+	kvStore, err := stack.OpenDatabaseWithExtraOptions("l2chaindata", cache, handles, "l2chaindata/", readonly, persistentConfig.Pebble.ExtraOptions("l2chaindata"))
+	if err != nil {
+		return nil, err
+	}
+	ancientDir := stack.ResolveAncient("l2chaindata", persistentConfig.Ancient)
+	chainData, err := rawdb.NewDatabaseWithFreezer(kvStore, ancientDir, "l2chaindata/", true)
+	if err != nil {
+		kvStore.Close()
+		return nil, fmt.Errorf("error opening read-only ancient directory %v: %w", ancientDir, err)
+	}
+	log.Info("Opened l2chaindata with read-only ancient directory", "ancient", ancientDir)
+	return chainData, nil
+}
+
+func predeployAccounts(predeploys []chaininfo.Predeploy) []statetransfer.AccountInitializationInfo {
+	accounts := make([]statetransfer.AccountInitializationInfo, 0, len(predeploys))
+	for _, predeploy := range predeploys {
+		account := statetransfer.AccountInitializationInfo{
+			Addr:       predeploy.Address,
+			Nonce:      predeploy.Nonce,
+			EthBalance: big.NewInt(0),
+		}
+		if predeploy.Balance != nil {
+			account.EthBalance = predeploy.Balance.ToInt()
+		}
+		if len(predeploy.Code) > 0 {
+			account.ContractInfo = &statetransfer.AccountInitContractInfo{
+				Code:            predeploy.Code,
+				ContractStorage: predeploy.Storage,
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
 func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeConfig, chainId *big.Int, cacheConfig *core.CacheConfig, targetConfig *gethexec.StylusTargetConfig, persistentConfig *conf.PersistentConfig, l1Client *ethclient.Client, rollupAddrs chaininfo.RollupAddresses) (ethdb.Database, *core.BlockChain, error) {
 	if !config.Init.Force {
-		if readOnlyDb, err := stack.OpenDatabaseWithFreezerWithExtraOptions("l2chaindata", 0, 0, config.Persistent.Ancient, "l2chaindata/", true, persistentConfig.Pebble.ExtraOptions("l2chaindata")); err == nil {
+		if readOnlyDb, err := openL2ChainData(stack, persistentConfig, 0, 0, true); err == nil {
 			if chainConfig := gethexec.TryReadStoredChainConfig(readOnlyDb); chainConfig != nil {
 				readOnlyDb.Close()
 				if !arbmath.BigEquals(chainConfig.ChainID, chainId) {
 					return nil, nil, fmt.Errorf("database has chain ID %v but config has chain ID %v (are you sure this database is for the right chain?)", chainConfig.ChainID, chainId)
 				}
-				chainData, err := stack.OpenDatabaseWithFreezerWithExtraOptions("l2chaindata", config.Execution.Caching.DatabaseCache, config.Persistent.Handles, config.Persistent.Ancient, "l2chaindata/", false, persistentConfig.Pebble.ExtraOptions("l2chaindata"))
+				chainData, err := openL2ChainData(stack, persistentConfig, config.Execution.Caching.DatabaseCache, config.Persistent.Handles, false)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -570,10 +720,22 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 				if err != nil {
 					return nil, nil, err
 				}
-				err = pruning.PruneChainDb(ctx, chainDb, stack, &config.Init, cacheConfig, persistentConfig, l1Client, rollupAddrs, config.Node.ValidatorRequired())
+				initConfig, err := archiveConversionPruneConfig(chainDb, config)
+				if err != nil {
+					return chainDb, nil, err
+				}
+				err = pruning.PruneChainDb(ctx, chainDb, stack, initConfig, cacheConfig, persistentConfig, l1Client, rollupAddrs, config.Node.ValidatorRequired())
 				if err != nil {
 					return chainDb, nil, fmt.Errorf("error pruning: %w", err)
 				}
+				if initConfig.Prune != "" {
+					if err := chainDb.Put(gethexec.StateModeKey, []byte(gethexec.StateModePruned)); err != nil {
+						return chainDb, nil, err
+					}
+				}
+				if err := fetchPeerStateIfMissing(ctx, chainDb, &config.Init); err != nil {
+					return chainDb, nil, fmt.Errorf("error fetching state from peer: %w", err)
+				}
 				l2BlockChain, err := gethexec.GetBlockChain(chainDb, cacheConfig, chainConfig, config.Execution.TxLookupLimit)
 				if err != nil {
 					return chainDb, nil, err
@@ -629,7 +791,7 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 
 	var initDataReader statetransfer.InitDataReader = nil
 
-	chainData, err := stack.OpenDatabaseWithFreezerWithExtraOptions("l2chaindata", config.Execution.Caching.DatabaseCache, config.Persistent.Handles, config.Persistent.Ancient, "l2chaindata/", false, persistentConfig.Pebble.ExtraOptions("l2chaindata"))
+	chainData, err := openL2ChainData(stack, persistentConfig, config.Execution.Caching.DatabaseCache, config.Persistent.Handles, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -695,6 +857,9 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 		if chainConfig == nil {
 			return chainDb, nil, errors.New("no --init.* mode supplied and chain data not in expected directory")
 		}
+		if err := fetchPeerStateIfMissing(ctx, chainDb, &config.Init); err != nil {
+			return chainDb, nil, fmt.Errorf("error fetching state from peer: %w", err)
+		}
 		l2BlockChain, err = gethexec.GetBlockChain(chainDb, cacheConfig, chainConfig, config.Execution.TxLookupLimit)
 		if err != nil {
 			return chainDb, nil, err
@@ -720,6 +885,17 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 		if config.Init.DevInit && config.Init.DevMaxCodeSize != 0 {
 			chainConfig.ArbitrumChainParams.MaxCodeSize = config.Init.DevMaxCodeSize
 		}
+		predeploys, err := chaininfo.GetPredeploys(config.Chain.ID, config.Chain.Name, config.Chain.InfoFiles, config.Chain.InfoJson)
+		if err != nil {
+			return chainDb, nil, err
+		}
+		if len(predeploys) > 0 {
+			if err := chaininfo.ValidatePredeploys(predeploys, chainConfig); err != nil {
+				return chainDb, nil, err
+			}
+			log.Info("Adding predeployed contracts to genesis state", "count", len(predeploys))
+			initDataReader = statetransfer.WithExtraAccounts(initDataReader, predeployAccounts(predeploys))
+		}
 		testUpdateTxIndex(chainDb, chainConfig, &txIndexWg)
 		ancients, err := chainDb.Ancients()
 		if err != nil {
@@ -569,6 +569,47 @@ func TestOpenInitializeChainDbEmptyInit(t *testing.T) {
 	Require(t, err)
 }
 
+func TestOpenL2ChainDataWithReadOnlyAncient(t *testing.T) {
+	t.Parallel()
+
+	persistentConfig := NodeConfigDefault.Persistent
+	persistentConfig.Ancient = filepath.Join(t.TempDir(), "ancient")
+
+	// The first node creates the shared ancient directory
+	writerStackConfig := testhelpers.CreateStackConfigForTest(t.TempDir())
+	writerStack, err := node.New(writerStackConfig)
+	Require(t, err)
+	writerDb, err := openL2ChainData(writerStack, &persistentConfig, 16, 16, false)
+	Require(t, err)
+	Require(t, writerDb.Close())
+	Require(t, writerStack.Close())
+
+	persistentConfig.AncientReadOnly = true
+	Require(t, persistentConfig.Validate())
+	replicaStackConfig := testhelpers.CreateStackConfigForTest(t.TempDir())
+	replicaStack, err := node.New(replicaStackConfig)
+	Require(t, err)
+	defer replicaStack.Close()
+	replicaDb, err := openL2ChainData(replicaStack, &persistentConfig, 16, 16, false)
+	Require(t, err)
+	defer replicaDb.Close()
+
+	// The local database is still writable
+	Require(t, replicaDb.Put([]byte("key"), []byte("value")))
+	if _, err := replicaDb.Ancients(); err != nil {
+		t.Fatal("Failed to read ancients:", err)
+	}
+	_, err = replicaDb.ModifyAncients(func(ethdb.AncientWriteOp) error { return nil })
+	if err == nil {
+		t.Fatal("Expected writing to read-only ancients to fail")
+	}
+
+	persistentConfig.Ancient = ""
+	if err := persistentConfig.Validate(); err == nil {
+		t.Fatal("Expected read-only ancient without ancient directory to be invalid")
+	}
+}
+
 func TestExtractSnapshot(t *testing.T) {
 	testCases := []struct {
 		name         string
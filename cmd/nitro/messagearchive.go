@@ -0,0 +1,144 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+// exportMessagesCommand dumps a stopped node's message sequence to a message archive, and importMessagesCommand
+// rebuilds a new node's from one: nitro export-messages --db <chain dir>/nitro/arbitrumdata --dir archive
+const (
+	exportMessagesCommand = "export-messages"
+	importMessagesCommand = "import-messages"
+)
+
+type MessageArchiveConfig struct {
+	Conf      genericconf.ConfConfig `koanf:"conf"`
+	DB        string                 `koanf:"db"`
+	DBEngine  string                 `koanf:"db-engine"`
+	Dir       string                 `koanf:"dir"`
+	ChunkSize uint64                 `koanf:"chunk-size"`
+	LogLevel  string                 `koanf:"log-level"`
+	LogType   string                 `koanf:"log-type"`
+}
+
+var MessageArchiveConfigDefault = MessageArchiveConfig{
+	Conf:      genericconf.ConfConfigDefault,
+	DBEngine:  "pebble",
+	ChunkSize: 100_000,
+	LogLevel:  "INFO",
+	LogType:   "plaintext",
+}
+
+func MessageArchiveConfigAddOptions(f *flag.FlagSet) {
+	genericconf.ConfConfigAddOptions("conf", f)
+	f.String("db", MessageArchiveConfigDefault.DB, "directory of the node's arbitrumdata database")
+	f.String("db-engine", MessageArchiveConfigDefault.DBEngine, "engine of the node's database (pebble or leveldb)")
+	f.String("dir", MessageArchiveConfigDefault.Dir, "directory of the message archive")
+	f.Uint64("chunk-size", MessageArchiveConfigDefault.ChunkSize, "records per chunk file when exporting")
+	f.String("log-level", MessageArchiveConfigDefault.LogLevel, "log level, valid values are CRIT, ERROR, WARN, INFO, DEBUG, TRACE")
+	f.String("log-type", MessageArchiveConfigDefault.LogType, "log type (plaintext or json)")
+}
+
+func (c *MessageArchiveConfig) Validate() error {
+	if c.DB == "" {
+		return errors.New("--db must be set")
+	}
+	if c.Dir == "" {
+		return errors.New("--dir must be set")
+	}
+	if c.DBEngine != rawdb.DBPebble && c.DBEngine != rawdb.DBLeveldb {
+		return fmt.Errorf("invalid --db-engine \"%v\"", c.DBEngine)
+	}
+	if c.ChunkSize == 0 {
+		return errors.New("--chunk-size must be positive")
+	}
+	return nil
+}
+
+func ParseMessageArchive(command string, args []string) (*MessageArchiveConfig, error) {
+	f := flag.NewFlagSet(command, flag.ContinueOnError)
+	MessageArchiveConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config MessageArchiveConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Conf.Dump {
+		if err := confighelpers.DumpConfig(k, map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func openMessageArchiveDB(config *MessageArchiveConfig, readOnly bool) (ethdb.Database, error) {
+	if readOnly {
+		// Don't create an empty database to export from
+		if _, err := os.Stat(config.DB); err != nil {
+			return nil, err
+		}
+	}
+	return rawdb.Open(rawdb.OpenOptions{
+		Type:               config.DBEngine,
+		Directory:          config.DB,
+		Namespace:          "arbitrumdata/",
+		Cache:              16,
+		Handles:            16,
+		ReadOnly:           readOnly,
+		PebbleExtraOptions: conf.PersistentConfigDefault.Pebble.ExtraOptions("arbitrumdata"),
+	})
+}
+
+// messageArchiveMain runs export-messages or import-messages against a database no node has open.
+func messageArchiveMain(ctx context.Context, command string, args []string) int {
+	config, err := ParseMessageArchive(command, args)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if err := genericconf.InitLog(config.LogType, config.LogLevel, &genericconf.FileLoggingConfig{Enable: false}, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+		return 1
+	}
+	exporting := command == exportMessagesCommand
+	db, err := openMessageArchiveDB(config, exporting)
+	if err != nil {
+		log.Error("failed to open the database", "db", config.DB, "err", err)
+		return 1
+	}
+	defer db.Close()
+	var manifest *arbnode.MessageArchiveManifest
+	if exporting {
+		manifest, err = arbnode.ExportMessageArchive(ctx, db, config.Dir, config.ChunkSize)
+	} else {
+		manifest, err = arbnode.ImportMessageArchive(ctx, db, config.Dir)
+	}
+	if err != nil {
+		log.Error(command+" failed", "err", err)
+		return 1
+	}
+	log.Info(command+" done", "messages", manifest.MessageCount, "delayedMessages", manifest.DelayedMessageCount, "batches", manifest.BatchCount, "chunks", len(manifest.Chunks))
+	return 0
+}
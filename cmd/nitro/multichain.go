@@ -0,0 +1,296 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/resourcemanager"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/execution/gethexec"
+	"github.com/offchainlabs/nitro/util/iostat"
+	"github.com/offchainlabs/nitro/util/profiling"
+)
+
+// multiChainCommand runs several chains in one process: nitro multi-chain --chains a.json,b.json
+const multiChainCommand = "multi-chain"
+
+// MultiChainConfig configures a process running several chains. Each chain has its own complete node
+// config file, with its own databases, feed, ports and parent chain. Logging, metrics, profiling and
+// RPC memory throttling are shared by the process and configured here instead.
+type MultiChainConfig struct {
+	Conf                genericconf.ConfConfig               `koanf:"conf"`
+	Chains              []string                             `koanf:"chains"`
+	LogLevel            string                               `koanf:"log-level"`
+	LogType             string                               `koanf:"log-type"`
+	FileLogging         genericconf.FileLoggingConfig        `koanf:"file-logging"`
+	Metrics             bool                                 `koanf:"metrics"`
+	MetricsServer       genericconf.MetricsServerConfig      `koanf:"metrics-server"`
+	ChainMetricInterval time.Duration                        `koanf:"chain-metric-interval"`
+	MetricsSecurity     genericconf.HTTPServerSecurityConfig `koanf:"metrics-server-security"`
+	PProf               bool                                 `koanf:"pprof"`
+	PprofCfg            genericconf.PProf                    `koanf:"pprof-cfg"`
+	Profiling           profiling.Config                     `koanf:"continuous-profiling"`
+	MemFreeLimit        string                               `koanf:"mem-free-limit"`
+}
+
+var MultiChainConfigDefault = MultiChainConfig{
+	Conf:                genericconf.ConfConfigDefault,
+	LogLevel:            "INFO",
+	LogType:             "plaintext",
+	FileLogging:         genericconf.DefaultFileLoggingConfig,
+	MetricsServer:       genericconf.MetricsServerConfigDefault,
+	ChainMetricInterval: 5 * time.Second,
+	MetricsSecurity:     genericconf.HTTPServerSecurityConfigDefault,
+	PprofCfg:            genericconf.PProfDefault,
+	Profiling:           profiling.DefaultConfig,
+}
+
+func MultiChainConfigAddOptions(f *flag.FlagSet) {
+	genericconf.ConfConfigAddOptions("conf", f)
+	f.StringSlice("chains", MultiChainConfigDefault.Chains, "node config files of the chains to run, one per chain")
+	f.String("log-level", MultiChainConfigDefault.LogLevel, "log level, valid values are CRIT, ERROR, WARN, INFO, DEBUG, TRACE")
+	f.String("log-type", MultiChainConfigDefault.LogType, "log type (plaintext or json)")
+	genericconf.FileLoggingConfigAddOptions("file-logging", f)
+	f.Bool("metrics", MultiChainConfigDefault.Metrics, "enable metrics")
+	genericconf.MetricsServerAddOptions("metrics-server", f)
+	f.Duration("chain-metric-interval", MultiChainConfigDefault.ChainMetricInterval, "how often to update the per-chain metrics")
+	genericconf.HTTPServerSecurityConfigAddOptions("metrics-server-security", f)
+	f.Bool("pprof", MultiChainConfigDefault.PProf, "enable pprof")
+	genericconf.PProfAddOptions("pprof-cfg", f)
+	profiling.ConfigAddOptions("continuous-profiling", f)
+	f.String("mem-free-limit", MultiChainConfigDefault.MemFreeLimit, "RPC calls of every chain are throttled if free system memory excluding the page cache is below this amount, expressed in bytes or multiples of bytes with suffix B, K, M, G. The underlying system must support cgroups v1 or v2.")
+}
+
+func (c *MultiChainConfig) Validate() error {
+	if len(c.Chains) == 0 {
+		return errors.New("--chains must list at least one chain config file")
+	}
+	if c.ChainMetricInterval <= 0 {
+		return errors.New("--chain-metric-interval must be positive")
+	}
+	if c.MemFreeLimit != "" {
+		if _, err := resourcemanager.ParseMemLimit(c.MemFreeLimit); err != nil {
+			return err
+		}
+	}
+	if err := c.MetricsSecurity.Validate(); err != nil {
+		return err
+	}
+	return c.Profiling.Validate()
+}
+
+func ParseMultiChain(args []string) (*MultiChainConfig, error) {
+	f := flag.NewFlagSet(multiChainCommand, flag.ContinueOnError)
+	MultiChainConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config MultiChainConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Conf.Dump {
+		if err := confighelpers.DumpConfig(k, map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// multiChainMember is one chain of a multi-chain process.
+type multiChainMember struct {
+	process    *multiChainProcess
+	configFile string
+
+	mutex    sync.Mutex
+	name     string
+	node     *arbnode.Node
+	execNode *gethexec.ExecutionNode
+}
+
+type multiChainProcess struct {
+	mutex sync.Mutex
+	names map[string]bool
+}
+
+// checkSupported rejects chain configs relying on process-wide hooks, which each chain would overwrite,
+// and registers the chain's name for its metrics.
+func (m *multiChainMember) checkSupported(config *NodeConfig) error {
+	if config.Execution.CallCache.Enable || config.Execution.RPCGateway.Enable {
+		return errors.New("the call cache and RPC gateway aren't supported in a multi-chain process")
+	}
+	if config.Node.ResourceMgmt.MemFreeLimit != "" || config.Node.ResourceMgmt.MemBudget.Enabled() {
+		return errors.New("resource management is configured for the whole multi-chain process, not per chain")
+	}
+	name := config.Chain.Name
+	if name == "" {
+		name = fmt.Sprint(config.Chain.ID)
+	}
+	m.process.mutex.Lock()
+	defer m.process.mutex.Unlock()
+	if m.process.names[name] {
+		return fmt.Errorf("chain %v is configured twice", name)
+	}
+	m.process.names[name] = true
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.name = name
+	return nil
+}
+
+func (m *multiChainMember) started(node *arbnode.Node, execNode *gethexec.ExecutionNode) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.node = node
+	m.execNode = execNode
+}
+
+// updateMetrics reports the chain's progress under arb/chains/<name>/, as the metrics of the node's
+// components are registered once for the whole process.
+func (m *multiChainMember) updateMetrics() {
+	m.mutex.Lock()
+	name, node, execNode := m.name, m.node, m.execNode
+	m.mutex.Unlock()
+	if node == nil {
+		return
+	}
+	prefix := "arb/chains/" + name + "/"
+	metrics.GetOrRegisterGauge(prefix+"running", nil).Update(1)
+	if messageCount, err := node.TxStreamer.GetMessageCount(); err == nil {
+		// #nosec G115
+		metrics.GetOrRegisterGauge(prefix+"messagecount", nil).Update(int64(messageCount))
+	}
+	if head := execNode.Backend.ArbInterface().BlockChain().CurrentBlock(); head != nil {
+		metrics.GetOrRegisterGauge(prefix+"blocknumber", nil).Update(head.Number.Int64())
+	}
+}
+
+func (m *multiChainMember) stopped() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.name != "" {
+		metrics.GetOrRegisterGauge("arb/chains/"+m.name+"/running", nil).Update(0)
+	}
+	m.node = nil
+	m.execNode = nil
+}
+
+// multiChainMain runs every configured chain until the process is interrupted or a chain stops,
+// which stops the others too. Returns the exit code.
+func multiChainMain(ctx context.Context, args []string) int {
+	config, err := ParseMultiChain(args)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	workdir, err := os.Getwd()
+	if err != nil {
+		log.Warn("Failed to get workdir", "err", err)
+	}
+	pathResolver := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(workdir, path)
+	}
+	if err := genericconf.InitLog(config.LogType, config.LogLevel, &config.FileLogging, pathResolver); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+		return 1
+	}
+	vcsRevision, _, vcsTime := confighelpers.GetVersion()
+	log.Info("Running Arbitrum nitro multi-chain process", "revision", vcsRevision, "vcs.time", vcsTime, "chains", len(config.Chains))
+
+	// Installed once, so it wraps the RPC servers of every chain
+	if err := resourcemanager.Init(&resourcemanager.Config{MemFreeLimit: config.MemFreeLimit}); err != nil {
+		log.Error("Failed to start resource management module", "err", err)
+		return 1
+	}
+	metricsConfig := &NodeConfig{
+		Metrics:         config.Metrics,
+		MetricsServer:   config.MetricsServer,
+		MetricsSecurity: config.MetricsSecurity,
+		PProf:           config.PProf,
+		PprofCfg:        config.PprofCfg,
+	}
+	if err := startMetrics(metricsConfig); err != nil {
+		log.Error("Error starting metrics", "error", err)
+		return 1
+	}
+	if config.Metrics {
+		go iostat.RegisterAndPopulateMetrics(ctx, 1, 5)
+	}
+	if config.Profiling.Enable {
+		profiler := profiling.NewContinuousProfiler(&config.Profiling)
+		profiler.Start(ctx)
+		defer profiler.StopAndWait()
+	}
+
+	chainsCtx, stopChains := context.WithCancel(ctx)
+	defer stopChains()
+	process := &multiChainProcess{names: make(map[string]bool)}
+	members := make([]*multiChainMember, 0, len(config.Chains))
+	exitCodes := make(chan int, len(config.Chains))
+	var wg sync.WaitGroup
+	for _, configFile := range config.Chains {
+		member := &multiChainMember{process: process, configFile: configFile}
+		members = append(members, member)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer member.stopped()
+			exitCode := runNode(chainsCtx, []string{"--conf.file", member.configFile}, member)
+			log.Info("chain stopped", "config", member.configFile, "exitCode", exitCode)
+			exitCodes <- exitCode
+		}()
+	}
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(config.ChainMetricInterval)
+	defer ticker.Stop()
+	exitCode := 0
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			if config.Metrics {
+				for _, member := range members {
+					member.updateMetrics()
+				}
+			}
+		case <-sigint:
+			log.Info("shutting down because of sigint")
+			break loop
+		case exitCode = <-exitCodes:
+			log.Warn("a chain stopped, shutting down the others")
+			break loop
+		}
+	}
+	stopChains()
+	wg.Wait()
+	close(exitCodes)
+	for code := range exitCodes {
+		exitCode = max(exitCode, code)
+	}
+	return exitCode
+}
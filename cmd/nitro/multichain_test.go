@@ -0,0 +1,51 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"testing"
+)
+
+func TestParseMultiChain(t *testing.T) {
+	if _, err := ParseMultiChain([]string{}); err == nil {
+		Fail(t, "expected an error without any chains")
+	}
+	config, err := ParseMultiChain([]string{"--chains", "l3a.json,l3b.json", "--log-level", "DEBUG"})
+	Require(t, err)
+	if len(config.Chains) != 2 || config.Chains[0] != "l3a.json" || config.Chains[1] != "l3b.json" {
+		Fail(t, "unexpected chains", config.Chains)
+	}
+	if config.LogLevel != "DEBUG" || config.ChainMetricInterval != MultiChainConfigDefault.ChainMetricInterval {
+		Fail(t, "unexpected config", config)
+	}
+}
+
+func TestMultiChainMemberCheckSupported(t *testing.T) {
+	process := &multiChainProcess{names: make(map[string]bool)}
+	first := &multiChainMember{process: process}
+	second := &multiChainMember{process: process}
+
+	config := NodeConfigDefault
+	config.Chain.ID = 333333
+	Require(t, first.checkSupported(&config))
+	if first.name != "333333" {
+		Fail(t, "unexpected chain name", first.name)
+	}
+	if err := second.checkSupported(&config); err == nil {
+		Fail(t, "expected an error running the same chain twice")
+	}
+
+	config.Chain.Name = "other"
+	config.Execution.CallCache.Enable = true
+	if err := second.checkSupported(&config); err == nil {
+		Fail(t, "expected an error enabling the call cache")
+	}
+	config.Execution.CallCache.Enable = false
+	config.Node.ResourceMgmt.MemFreeLimit = "1G"
+	if err := second.checkSupported(&config); err == nil {
+		Fail(t, "expected an error configuring resource management per chain")
+	}
+	config.Node.ResourceMgmt.MemFreeLimit = ""
+	Require(t, second.checkSupported(&config))
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -39,9 +40,9 @@ import (
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/offchainlabs/nitro/arbnode"
 	"github.com/offchainlabs/nitro/arbnode/resourcemanager"
@@ -56,6 +57,9 @@ import (
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/execution/gethexec"
 	_ "github.com/offchainlabs/nitro/execution/nodeInterface"
+	"github.com/offchainlabs/nitro/gethhook/hooks"
+	outboxexecutor "github.com/offchainlabs/nitro/outbox_executor"
+	rpcdiff "github.com/offchainlabs/nitro/rpc_diff"
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
@@ -63,10 +67,14 @@ import (
 	"github.com/offchainlabs/nitro/staker/validatorwallet"
 	"github.com/offchainlabs/nitro/util/colors"
 	"github.com/offchainlabs/nitro/util/dbutil"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/iostat"
+	"github.com/offchainlabs/nitro/util/membudget"
+	"github.com/offchainlabs/nitro/util/profiling"
 	"github.com/offchainlabs/nitro/util/rpcclient"
 	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/telemetry"
 	"github.com/offchainlabs/nitro/validator/server_common"
 	"github.com/offchainlabs/nitro/validator/valnode"
 )
@@ -156,7 +164,9 @@ func startMetrics(cfg *NodeConfig) error {
 	}
 	if cfg.Metrics {
 		go metrics.CollectProcessMetrics(cfg.MetricsServer.UpdateInterval)
-		exp.Setup(fmt.Sprintf("%v:%v", cfg.MetricsServer.Addr, cfg.MetricsServer.Port))
+		if err := genericconf.StartMetricsServer(mAddr, genericconf.HTTPServerTimeoutConfigDefault, &cfg.MetricsSecurity); err != nil {
+			return err
+		}
 	}
 	if cfg.PProf {
 		genericconf.StartPprof(pAddr)
@@ -166,10 +176,29 @@ func startMetrics(cfg *NodeConfig) error {
 
 // Returns the exit code
 func mainImpl() int {
+	defer flightrecorder.DumpOnPanic()
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 
-	args := os.Args[1:]
+	if len(os.Args) > 1 && os.Args[1] == multiChainCommand {
+		return multiChainMain(ctx, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == replayDiffCommand {
+		return replayDiffMain(ctx, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == upgradeOrchestrateCommand {
+		return upgradeOrchestrateMain(ctx, os.Args[2:])
+	}
+	if len(os.Args) > 1 && (os.Args[1] == exportMessagesCommand || os.Args[1] == importMessagesCommand) {
+		return messageArchiveMain(ctx, os.Args[1], os.Args[2:])
+	}
+	return runNode(ctx, os.Args[1:], nil)
+}
+
+// runNode runs a node until it's interrupted or fails, returning the exit code. If the node is one of the
+// chains of a multi-chain process, the process owns logging, metrics, profiling and resource management,
+// and stops the node by canceling ctx.
+func runNode(ctx context.Context, args []string, chain *multiChainMember) int {
 	nodeConfig, l2DevWallet, err := ParseNode(ctx, args)
 	if err != nil {
 		confighelpers.PrintErrorAndExit(err, printSampleUsage)
@@ -215,14 +244,25 @@ func mainImpl() int {
 		}
 		stackConf.JWTSecret = filename
 	}
-	err = genericconf.InitLog(nodeConfig.LogType, nodeConfig.LogLevel, &nodeConfig.FileLogging, pathResolver(nodeConfig.Persistent.LogDir))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+	if chain == nil {
+		err = genericconf.InitLog(nodeConfig.LogType, nodeConfig.LogLevel, &nodeConfig.FileLogging, pathResolver(nodeConfig.Persistent.LogDir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+			return 1
+		}
+		log.Info("Running Arbitrum nitro node", "revision", vcsRevision, "vcs.time", vcsTime)
+	} else {
+		if err := chain.checkSupported(nodeConfig); err != nil {
+			log.Error("chain can't run in a multi-chain process", "chain", chain.name, "err", err)
+			return 1
+		}
+		log.Info("Running chain in multi-chain process", "chain", chain.name, "chainId", nodeConfig.Chain.ID)
+	}
+	if err := hooks.CheckInstalled(); err != nil {
+		log.Error("go-ethereum isn't fully hooked", "err", err)
 		return 1
 	}
 
-	log.Info("Running Arbitrum nitro node", "revision", vcsRevision, "vcs.time", vcsTime)
-
 	if nodeConfig.Node.Dangerous.NoL1Listener {
 		nodeConfig.Node.ParentChainReader.Enable = false
 		nodeConfig.Node.BatchPoster.Enable = false
@@ -231,6 +271,15 @@ func mainImpl() int {
 		nodeConfig.Node.ParentChainReader.Enable = true
 	}
 
+	if nodeConfig.Telemetry.Preview {
+		// The stack's instance dir, where the node keeps its telemetry id
+		if err := printTelemetryPreview(stackConf.ResolvePath(""), vcsRevision, nodeConfig); err != nil {
+			log.Error("failed to preview telemetry report", "err", err)
+			return 1
+		}
+		return 0
+	}
+
 	if nodeConfig.Execution.Sequencer.Enable && nodeConfig.Node.ParentChainReader.Enable && nodeConfig.Node.InboxReader.HardReorg {
 		flag.Usage()
 		log.Crit("hard reorgs cannot safely be enabled with sequencer mode enabled")
@@ -247,9 +296,9 @@ func mainImpl() int {
 	var l1TransactionOptsValidator *bind.TransactOpts
 	var l1TransactionOptsBatchPoster *bind.TransactOpts
 	// If sequencer and signing is enabled or batchposter is enabled without
-	// external signing sequencer will need a key.
+	// external signing sequencer will need a key. A dry run batch poster never signs.
 	sequencerNeedsKey := (nodeConfig.Node.Sequencer && !nodeConfig.Node.Feed.Output.DisableSigning) ||
-		(nodeConfig.Node.BatchPoster.Enable && (nodeConfig.Node.BatchPoster.DataPoster.ExternalSigner.URL == "" || nodeConfig.Node.DataAvailability.Enable))
+		(nodeConfig.Node.BatchPoster.Enable && !nodeConfig.Node.BatchPoster.DryRun && (nodeConfig.Node.BatchPoster.DataPoster.ExternalSigner.URL == "" || nodeConfig.Node.DataAvailability.Enable))
 	validatorNeedsKey := nodeConfig.Node.Staker.OnlyCreateWalletContract ||
 		(nodeConfig.Node.Staker.Enable && !strings.EqualFold(nodeConfig.Node.Staker.Strategy, "watchtower") && nodeConfig.Node.Staker.DataPoster.ExternalSigner.URL == "")
 
@@ -285,6 +334,19 @@ func mainImpl() int {
 		}
 	}
 
+	var l1TransactionOptsOutboxExecutor *bind.TransactOpts
+	nodeConfig.OutboxExecutor.ParentChainWallet.ResolveDirectoryNames(nodeConfig.Persistent.Chain)
+	if (nodeConfig.OutboxExecutor.Enable && nodeConfig.OutboxExecutor.Execute) || nodeConfig.OutboxExecutor.ParentChainWallet.OnlyCreateKey {
+		l1TransactionOptsOutboxExecutor, _, err = util.OpenWallet("l1-outbox-executor", &nodeConfig.OutboxExecutor.ParentChainWallet, new(big.Int).SetUint64(nodeConfig.ParentChain.ID))
+		if err != nil {
+			flag.Usage()
+			log.Crit("error opening outbox executor parent chain wallet", "path", nodeConfig.OutboxExecutor.ParentChainWallet.Pathname, "account", nodeConfig.OutboxExecutor.ParentChainWallet.Account, "err", err)
+		}
+		if nodeConfig.OutboxExecutor.ParentChainWallet.OnlyCreateKey {
+			return 0
+		}
+	}
+
 	if nodeConfig.Node.Staker.Enable {
 		if !nodeConfig.Node.ParentChainReader.Enable {
 			flag.Usage()
@@ -391,9 +453,11 @@ func mainImpl() int {
 		return 0
 	}
 
-	if err := resourcemanager.Init(&nodeConfig.Node.ResourceMgmt); err != nil {
-		flag.Usage()
-		log.Crit("Failed to start resource management module", "err", err)
+	if chain == nil {
+		if err := resourcemanager.Init(&nodeConfig.Node.ResourceMgmt); err != nil {
+			flag.Usage()
+			log.Crit("Failed to start resource management module", "err", err)
+		}
 	}
 
 	var sameProcessValidationNodeEnabled bool
@@ -417,13 +481,21 @@ func mainImpl() int {
 		}
 	}
 
-	if err := startMetrics(nodeConfig); err != nil {
-		log.Error("Error starting metrics", "error", err)
-		return 1
-	}
+	if chain == nil {
+		if err := startMetrics(nodeConfig); err != nil {
+			log.Error("Error starting metrics", "error", err)
+			return 1
+		}
 
-	if nodeConfig.Metrics {
-		go iostat.RegisterAndPopulateMetrics(ctx, 1, 5)
+		if nodeConfig.Metrics {
+			go iostat.RegisterAndPopulateMetrics(ctx, 1, 5)
+		}
+
+		if nodeConfig.Profiling.Enable {
+			profiler := profiling.NewContinuousProfiler(&nodeConfig.Profiling)
+			profiler.Start(ctx)
+			defer profiler.StopAndWait()
+		}
 	}
 
 	var deferFuncs []func()
@@ -536,6 +608,30 @@ func mainImpl() int {
 		log.Error("failed to create execution node", "err", err)
 		return 1
 	}
+	if execNode.CallCache != nil || execNode.RPCGateway != nil || execNode.FlightRecorder != nil || execNode.RPCSLOTracker != nil || execNode.LoadShedder != nil {
+		// Must be set before the stack is started
+		wrapHTTPHandler := node.WrapHTTPHandler
+		node.WrapHTTPHandler = func(srv http.Handler) (http.Handler, error) {
+			if execNode.FlightRecorder != nil {
+				srv = execNode.FlightRecorder.WrapHandler(srv)
+			}
+			if execNode.CallCache != nil {
+				srv = execNode.CallCache.WrapHandler(srv)
+			}
+			if execNode.RPCSLOTracker != nil {
+				srv = execNode.RPCSLOTracker.WrapHandler(srv)
+			}
+			// The gateway goes outside the cache so cached calls still count against quotas
+			if execNode.RPCGateway != nil {
+				srv = execNode.RPCGateway.WrapHandler(srv)
+			}
+			// Shed load before anything else spends time on the request
+			if execNode.LoadShedder != nil {
+				srv = execNode.LoadShedder.WrapHandler(srv)
+			}
+			return wrapHTTPHandler(srv)
+		}
+	}
 
 	currentNode, err := arbnode.CreateNode(
 		ctx,
@@ -557,6 +653,14 @@ func mainImpl() int {
 		log.Error("failed to create node", "err", err)
 		return 1
 	}
+	if currentNode.BroadcastServer != nil {
+		auth, err := nodeConfig.FeedSecurity.LoadAuth()
+		if err != nil {
+			log.Error("failed to load the feed's auth token", "err", err)
+			return 1
+		}
+		currentNode.BroadcastServer.SetAuth(auth)
+	}
 
 	// Validate sequencer's MaxTxDataSize and batchPoster's MaxSize params.
 	// SequencerInbox's maxDataSize is defaulted to 117964 which is 90% of Geth's 128KB tx size limit, leaving ~13KB for proving.
@@ -594,8 +698,10 @@ func mainImpl() int {
 	}
 
 	liveNodeConfig.SetOnReloadHook(func(oldCfg *NodeConfig, newCfg *NodeConfig) error {
-		if err := genericconf.InitLog(newCfg.LogType, newCfg.LogLevel, &newCfg.FileLogging, pathResolver(nodeConfig.Persistent.LogDir)); err != nil {
-			return fmt.Errorf("failed to re-init logging: %w", err)
+		if chain == nil {
+			if err := genericconf.InitLog(newCfg.LogType, newCfg.LogLevel, &newCfg.FileLogging, pathResolver(nodeConfig.Persistent.LogDir)); err != nil {
+				return fmt.Errorf("failed to re-init logging: %w", err)
+			}
 		}
 		return currentNode.OnConfigReload(&oldCfg.Node, &newCfg.Node)
 	})
@@ -623,6 +729,60 @@ func mainImpl() int {
 		}
 	}
 
+	var outboxExecutor *outboxexecutor.OutboxExecutor
+	if nodeConfig.OutboxExecutor.Enable {
+		if !nodeConfig.Node.ParentChainReader.Enable {
+			log.Error("outbox executor requires the parent chain reader to be enabled")
+			return 1
+		}
+		outboxExecutor, err = outboxexecutor.New(&nodeConfig.OutboxExecutor, l1Client, ethclient.NewClient(stack.Attach()), rollupAddrs.Rollup, l1TransactionOptsOutboxExecutor)
+		if err != nil {
+			log.Error("failed to create outbox executor", "err", err)
+			return 1
+		}
+		// Must be registered before the stack is started
+		stack.RegisterAPIs([]rpc.API{{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   outboxexecutor.NewAPI(outboxExecutor),
+			Public:    false,
+		}})
+	}
+
+	var twoHopRelay *outboxexecutor.TwoHopRelay
+	if nodeConfig.TwoHopWithdrawals.Enable {
+		if !nodeConfig.Node.ParentChainReader.Enable {
+			log.Error("two-hop withdrawal relay requires the parent chain reader to be enabled")
+			return 1
+		}
+		grandparentClient, err := ethclient.DialContext(ctx, nodeConfig.TwoHopWithdrawals.GrandparentChainURL)
+		if err != nil {
+			log.Error("failed to connect to the grandparent chain", "url", nodeConfig.TwoHopWithdrawals.GrandparentChainURL, "err", err)
+			return 1
+		}
+		twoHopRelay, err = outboxexecutor.NewTwoHopRelay(&nodeConfig.TwoHopWithdrawals, ethclient.NewClient(stack.Attach()), l1Client, grandparentClient, rollupAddrs.Rollup)
+		if err != nil {
+			log.Error("failed to create two-hop withdrawal relay", "err", err)
+			return 1
+		}
+		// Must be registered before the stack is started
+		stack.RegisterAPIs([]rpc.API{{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   outboxexecutor.NewTwoHopAPI(twoHopRelay),
+			Public:    false,
+		}})
+	}
+
+	var rpcDiffProxy *rpcdiff.Proxy
+	if nodeConfig.RPCDiff.Enable {
+		rpcDiffProxy, err = rpcdiff.New(ctx, &nodeConfig.RPCDiff, stack.Attach())
+		if err != nil {
+			log.Error("failed to create differential RPC proxy", "err", err)
+			return 1
+		}
+	}
+
 	if valNode != nil {
 		err = valNode.Start(ctx)
 		if err != nil {
@@ -639,13 +799,68 @@ func mainImpl() int {
 		// remove previous deferFuncs, StopAndWait closes database and blockchain.
 		deferFuncs = []func(){func() { currentNode.StopAndWait() }}
 	}
+	if err == nil && outboxExecutor != nil {
+		err = outboxExecutor.Start(ctx)
+		if err != nil {
+			fatalErrChan <- fmt.Errorf("error starting outbox executor: %w", err)
+		} else {
+			deferFuncs = append(deferFuncs, func() { outboxExecutor.StopAndWait() })
+		}
+	}
+	if err == nil && twoHopRelay != nil {
+		err = twoHopRelay.Start(ctx)
+		if err != nil {
+			fatalErrChan <- fmt.Errorf("error starting two-hop withdrawal relay: %w", err)
+		} else {
+			deferFuncs = append(deferFuncs, func() { twoHopRelay.StopAndWait() })
+		}
+	}
+	if err == nil && rpcDiffProxy != nil {
+		err = rpcDiffProxy.Start(ctx)
+		if err != nil {
+			fatalErrChan <- fmt.Errorf("error starting differential RPC proxy: %w", err)
+		} else {
+			deferFuncs = append(deferFuncs, func() { rpcDiffProxy.StopAndWait() })
+		}
+	}
+	if err == nil && nodeConfig.Telemetry.Enable {
+		nodeId, err := telemetry.LoadNodeId(stack.InstanceDir())
+		if err != nil {
+			log.Error("failed to load telemetry node id", "err", err)
+			return 1
+		}
+		report := telemetryReport(nodeId, vcsRevision, chainInfo.ChainConfig.ChainID.Uint64(), liveNodeConfig.Get, currentNode.Synced)
+		reporter := telemetry.NewReporter(func() *telemetry.Config { return &liveNodeConfig.Get().Telemetry }, report)
+		reporter.Start(ctx)
+		deferFuncs = append(deferFuncs, func() { reporter.StopAndWait() })
+	}
 	if blocksReExecutor != nil && !nodeConfig.Init.ThenQuit {
 		blocksReExecutor.Start(ctx, nil)
 		deferFuncs = append(deferFuncs, func() { blocksReExecutor.StopAndWait() })
 	}
-
-	sigint := make(chan os.Signal, 1)
-	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	if chain != nil {
+		chain.started(currentNode, execNode)
+	}
+	if chain == nil && nodeConfig.Node.ResourceMgmt.MemBudget.Enabled() {
+		// geth sizes the trie caches at startup, so they're just taken out of the budget
+		caching := nodeConfig.Execution.Caching
+		trieCacheMB := caching.TrieCleanCache + caching.TrieDirtyCache + caching.SnapshotCache
+		// #nosec G115
+		membudget.Register("trie-cache", membudget.FixedConsumer(uint64(trieCacheMB)*1024*1024), membudget.ConsumerOpts{Fixed: true})
+		memBudget := membudget.New(func() *membudget.Config { return &liveNodeConfig.Get().Node.ResourceMgmt.MemBudget })
+		memBudget.Start(ctx)
+		deferFuncs = append(deferFuncs, func() { memBudget.StopAndWait() })
+	}
+
+	var sigint <-chan os.Signal
+	var stopped <-chan struct{}
+	if chain == nil {
+		sigintChan := make(chan os.Signal, 1)
+		signal.Notify(sigintChan, os.Interrupt, syscall.SIGTERM)
+		sigint = sigintChan
+	} else {
+		stopped = ctx.Done()
+	}
 
 	if err == nil && nodeConfig.Init.IsReorgRequested() {
 		err = initReorg(nodeConfig.Init, chainInfo.ChainConfig, currentNode.InboxTracker)
@@ -666,6 +881,12 @@ func mainImpl() int {
 		default:
 			log.Info("shutting down because of sigint")
 		}
+	case <-stopped:
+		select {
+		case err = <-fatalErrChan:
+		default:
+			log.Info("shutting down chain", "chain", chain.name)
+		}
 	}
 
 	if err != nil {
@@ -678,53 +899,67 @@ func mainImpl() int {
 }
 
 type NodeConfig struct {
-	Conf             genericconf.ConfConfig          `koanf:"conf" reload:"hot"`
-	Node             arbnode.Config                  `koanf:"node" reload:"hot"`
-	Execution        gethexec.Config                 `koanf:"execution" reload:"hot"`
-	Validation       valnode.Config                  `koanf:"validation" reload:"hot"`
-	ParentChain      conf.ParentChainConfig          `koanf:"parent-chain" reload:"hot"`
-	Chain            conf.L2Config                   `koanf:"chain"`
-	LogLevel         string                          `koanf:"log-level" reload:"hot"`
-	LogType          string                          `koanf:"log-type" reload:"hot"`
-	FileLogging      genericconf.FileLoggingConfig   `koanf:"file-logging" reload:"hot"`
-	Persistent       conf.PersistentConfig           `koanf:"persistent"`
-	HTTP             genericconf.HTTPConfig          `koanf:"http"`
-	WS               genericconf.WSConfig            `koanf:"ws"`
-	IPC              genericconf.IPCConfig           `koanf:"ipc"`
-	Auth             genericconf.AuthRPCConfig       `koanf:"auth"`
-	GraphQL          genericconf.GraphQLConfig       `koanf:"graphql"`
-	Metrics          bool                            `koanf:"metrics"`
-	MetricsServer    genericconf.MetricsServerConfig `koanf:"metrics-server"`
-	PProf            bool                            `koanf:"pprof"`
-	PprofCfg         genericconf.PProf               `koanf:"pprof-cfg"`
-	Init             conf.InitConfig                 `koanf:"init"`
-	Rpc              genericconf.RpcConfig           `koanf:"rpc"`
-	BlocksReExecutor blocksreexecutor.Config         `koanf:"blocks-reexecutor"`
+	Conf              genericconf.ConfConfig               `koanf:"conf" reload:"hot"`
+	Node              arbnode.Config                       `koanf:"node" reload:"hot"`
+	Execution         gethexec.Config                      `koanf:"execution" reload:"hot"`
+	Validation        valnode.Config                       `koanf:"validation" reload:"hot"`
+	ParentChain       conf.ParentChainConfig               `koanf:"parent-chain" reload:"hot"`
+	Chain             conf.L2Config                        `koanf:"chain"`
+	LogLevel          string                               `koanf:"log-level" reload:"hot"`
+	LogType           string                               `koanf:"log-type" reload:"hot"`
+	FileLogging       genericconf.FileLoggingConfig        `koanf:"file-logging" reload:"hot"`
+	Persistent        conf.PersistentConfig                `koanf:"persistent"`
+	HTTP              genericconf.HTTPConfig               `koanf:"http"`
+	WS                genericconf.WSConfig                 `koanf:"ws"`
+	IPC               genericconf.IPCConfig                `koanf:"ipc"`
+	Auth              genericconf.AuthRPCConfig            `koanf:"auth"`
+	GraphQL           genericconf.GraphQLConfig            `koanf:"graphql"`
+	Metrics           bool                                 `koanf:"metrics"`
+	MetricsServer     genericconf.MetricsServerConfig      `koanf:"metrics-server"`
+	MetricsSecurity   genericconf.HTTPServerSecurityConfig `koanf:"metrics-server-security"`
+	FeedSecurity      genericconf.HTTPServerSecurityConfig `koanf:"feed-server-security"`
+	PProf             bool                                 `koanf:"pprof"`
+	PprofCfg          genericconf.PProf                    `koanf:"pprof-cfg"`
+	Profiling         profiling.Config                     `koanf:"continuous-profiling"`
+	Init              conf.InitConfig                      `koanf:"init"`
+	Rpc               genericconf.RpcConfig                `koanf:"rpc"`
+	BlocksReExecutor  blocksreexecutor.Config              `koanf:"blocks-reexecutor"`
+	OutboxExecutor    outboxexecutor.Config                `koanf:"outbox-executor"`
+	TwoHopWithdrawals outboxexecutor.TwoHopConfig          `koanf:"two-hop-withdrawals"`
+	RPCDiff           rpcdiff.Config                       `koanf:"rpc-diff"`
+	Telemetry         telemetry.Config                     `koanf:"telemetry" reload:"hot"`
 }
 
 var NodeConfigDefault = NodeConfig{
-	Conf:             genericconf.ConfConfigDefault,
-	Node:             arbnode.ConfigDefault,
-	Execution:        gethexec.ConfigDefault,
-	Validation:       valnode.DefaultValidationConfig,
-	ParentChain:      conf.L1ConfigDefault,
-	Chain:            conf.L2ConfigDefault,
-	LogLevel:         "INFO",
-	LogType:          "plaintext",
-	FileLogging:      genericconf.DefaultFileLoggingConfig,
-	Persistent:       conf.PersistentConfigDefault,
-	HTTP:             genericconf.HTTPConfigDefault,
-	WS:               genericconf.WSConfigDefault,
-	IPC:              genericconf.IPCConfigDefault,
-	Auth:             genericconf.AuthRPCConfigDefault,
-	GraphQL:          genericconf.GraphQLConfigDefault,
-	Metrics:          false,
-	MetricsServer:    genericconf.MetricsServerConfigDefault,
-	Init:             conf.InitConfigDefault,
-	Rpc:              genericconf.DefaultRpcConfig,
-	PProf:            false,
-	PprofCfg:         genericconf.PProfDefault,
-	BlocksReExecutor: blocksreexecutor.DefaultConfig,
+	Conf:              genericconf.ConfConfigDefault,
+	Node:              arbnode.ConfigDefault,
+	Execution:         gethexec.ConfigDefault,
+	Validation:        valnode.DefaultValidationConfig,
+	ParentChain:       conf.L1ConfigDefault,
+	Chain:             conf.L2ConfigDefault,
+	LogLevel:          "INFO",
+	LogType:           "plaintext",
+	FileLogging:       genericconf.DefaultFileLoggingConfig,
+	Persistent:        conf.PersistentConfigDefault,
+	HTTP:              genericconf.HTTPConfigDefault,
+	WS:                genericconf.WSConfigDefault,
+	IPC:               genericconf.IPCConfigDefault,
+	Auth:              genericconf.AuthRPCConfigDefault,
+	GraphQL:           genericconf.GraphQLConfigDefault,
+	Metrics:           false,
+	MetricsServer:     genericconf.MetricsServerConfigDefault,
+	MetricsSecurity:   genericconf.HTTPServerSecurityConfigDefault,
+	FeedSecurity:      genericconf.HTTPServerSecurityConfigDefault,
+	Init:              conf.InitConfigDefault,
+	Rpc:               genericconf.DefaultRpcConfig,
+	PProf:             false,
+	PprofCfg:          genericconf.PProfDefault,
+	Profiling:         profiling.DefaultConfig,
+	BlocksReExecutor:  blocksreexecutor.DefaultConfig,
+	OutboxExecutor:    outboxexecutor.DefaultConfig,
+	TwoHopWithdrawals: outboxexecutor.DefaultTwoHopConfig,
+	RPCDiff:           rpcdiff.DefaultConfig,
+	Telemetry:         telemetry.DefaultConfig,
 }
 
 func NodeConfigAddOptions(f *flag.FlagSet) {
@@ -745,12 +980,19 @@ func NodeConfigAddOptions(f *flag.FlagSet) {
 	genericconf.GraphQLConfigAddOptions("graphql", f)
 	f.Bool("metrics", NodeConfigDefault.Metrics, "enable metrics")
 	genericconf.MetricsServerAddOptions("metrics-server", f)
+	genericconf.HTTPServerSecurityConfigAddOptions("metrics-server-security", f)
+	genericconf.HTTPServerSecurityConfigAddOptions("feed-server-security", f)
 	f.Bool("pprof", NodeConfigDefault.PProf, "enable pprof")
 	genericconf.PProfAddOptions("pprof-cfg", f)
+	profiling.ConfigAddOptions("continuous-profiling", f)
 
 	conf.InitConfigAddOptions("init", f)
 	genericconf.RpcConfigAddOptions("rpc", f)
 	blocksreexecutor.ConfigAddOptions("blocks-reexecutor", f)
+	outboxexecutor.ConfigAddOptions("outbox-executor", f)
+	outboxexecutor.TwoHopConfigAddOptions("two-hop-withdrawals", f)
+	rpcdiff.ConfigAddOptions("rpc-diff", f)
+	telemetry.ConfigAddOptions("telemetry", f)
 }
 
 func (c *NodeConfig) ResolveDirectoryNames() error {
@@ -820,6 +1062,36 @@ func (c *NodeConfig) Validate() error {
 	if err := c.BlocksReExecutor.Validate(); err != nil {
 		return err
 	}
+	if err := c.OutboxExecutor.Validate(); err != nil {
+		return err
+	}
+	if err := c.TwoHopWithdrawals.Validate(); err != nil {
+		return err
+	}
+	if err := c.RPCDiff.Validate(); err != nil {
+		return err
+	}
+	if err := c.Telemetry.Validate(); err != nil {
+		return err
+	}
+	if err := c.MetricsSecurity.Validate(); err != nil {
+		return err
+	}
+	if err := c.FeedSecurity.Validate(); err != nil {
+		return err
+	}
+	if err := genericconf.ValidateDistinctAuthTokens(map[string]*genericconf.HTTPServerSecurityConfig{
+		"metrics-server-security": &c.MetricsSecurity,
+		"feed-server-security":    &c.FeedSecurity,
+	}); err != nil {
+		return err
+	}
+	if err := c.Profiling.Validate(); err != nil {
+		return err
+	}
+	if err := c.Validation.Divergence.Validate(); err != nil {
+		return err
+	}
 	if c.Node.ValidatorRequired() && (c.Execution.Caching.StateScheme == rawdb.PathScheme) {
 		return errors.New("path cannot be used as execution.caching.state-scheme when validator is required")
 	}
@@ -845,7 +1117,7 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 	l2ChainInfoFiles := k.Strings("chain.info-files")
 	l2ChainInfoJson := k.String("chain.info-json")
 	// #nosec G115
-	err = applyChainParameters(k, uint64(l2ChainId), l2ChainName, l2ChainInfoFiles, l2ChainInfoJson)
+	chainInfo, err := applyChainParameters(k, f, uint64(l2ChainId), l2ChainName, l2ChainInfoFiles, l2ChainInfoJson)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -855,6 +1127,11 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 		return nil, nil, err
 	}
 
+	// Catch options which can't work with the chain now rather than when they're first used
+	if err := validateChainCompatibility(k, chainInfo); err != nil {
+		return nil, nil, err
+	}
+
 	if err = das.FixKeysetCLIParsing("node.data-availability.rpc-aggregator.backends", k); err != nil {
 		return nil, nil, err
 	}
@@ -908,10 +1185,10 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 	return &nodeConfig, &l2DevWallet, nil
 }
 
-func applyChainParameters(k *koanf.Koanf, chainId uint64, chainName string, l2ChainInfoFiles []string, l2ChainInfoJson string) error {
+func applyChainParameters(k *koanf.Koanf, f *flag.FlagSet, chainId uint64, chainName string, l2ChainInfoFiles []string, l2ChainInfoJson string) (*chaininfo.ChainInfo, error) {
 	chainInfo, err := chaininfo.ProcessChainInfo(chainId, chainName, l2ChainInfoFiles, l2ChainInfoJson)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var parentChainIsArbitrum bool
 	if chainInfo.ParentChainIsArbitrum != nil {
@@ -942,12 +1219,14 @@ func applyChainParameters(k *koanf.Koanf, chainId uint64, chainName string, l2Ch
 	if chainInfo.SecondaryFeedUrl != "" {
 		chainDefaults["node.feed.input.secondary-url"] = strings.Split(chainInfo.SecondaryFeedUrl, ",")
 	}
+	// Without the parent chain reader there are no batches to read, so no need for data availability
+	readsBatches := readsParentChainBatches(k)
 	if chainInfo.DasIndexUrl != "" {
-		chainDefaults["node.data-availability.enable"] = true
+		chainDefaults["node.data-availability.enable"] = readsBatches
 		chainDefaults["node.data-availability.rest-aggregator.enable"] = true
 		chainDefaults["node.data-availability.rest-aggregator.online-url-list"] = chainInfo.DasIndexUrl
 	} else if chainInfo.ChainConfig.ArbitrumChainParams.DataAvailabilityCommittee {
-		chainDefaults["node.data-availability.enable"] = true
+		chainDefaults["node.data-availability.enable"] = readsBatches
 	}
 	if !chainInfo.HasGenesisState {
 		chainDefaults["init.empty"] = true
@@ -956,7 +1235,7 @@ func applyChainParameters(k *koanf.Koanf, chainId uint64, chainName string, l2Ch
 		l2MaxTxSize := gethexec.DefaultSequencerConfig.MaxTxDataSize
 		bufferSpace := 5000
 		if l2MaxTxSize < bufferSpace*2 {
-			return fmt.Errorf("not enough room in parent chain max tx size %v for bufferSpace %v * 2", l2MaxTxSize, bufferSpace)
+			return nil, fmt.Errorf("not enough room in parent chain max tx size %v for bufferSpace %v * 2", l2MaxTxSize, bufferSpace)
 		}
 		safeBatchSize := l2MaxTxSize - bufferSpace
 		chainDefaults["node.batch-poster.max-size"] = safeBatchSize
@@ -968,13 +1247,49 @@ func applyChainParameters(k *koanf.Koanf, chainId uint64, chainName string, l2Ch
 	if chainInfo.DasIndexUrl != "" {
 		chainDefaults["node.batch-poster.max-size"] = 1_000_000
 	}
+	if k.Bool("chain.preset") {
+		for option, value := range chainInfo.NodeConfigPreset {
+			if f.Lookup(option) == nil {
+				return nil, fmt.Errorf("chain %v config preset has unknown option %v", chainInfo.ChainName, option)
+			}
+			chainDefaults[option] = value
+		}
+	}
+	for option, value := range chainInfo.RequiredNodeConfig {
+		if f.Lookup(option) == nil {
+			return nil, fmt.Errorf("chain %v requires unknown option %v", chainInfo.ChainName, option)
+		}
+		chainDefaults[option] = value
+	}
 	err = k.Load(confmap.Provider(chainDefaults, "."), nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return chainInfo, nil
+}
+
+// validateChainCompatibility checks the final configuration against the chain's required options,
+// and rejects option combinations which would otherwise only fail once the node hits a batch needing them.
+func validateChainCompatibility(k *koanf.Koanf, chainInfo *chaininfo.ChainInfo) error {
+	for option, required := range chainInfo.RequiredNodeConfig {
+		// Values may come from json or flags, so compare their formatting rather than their types
+		if actual := k.Get(option); fmt.Sprint(actual) != fmt.Sprint(required) {
+			return fmt.Errorf("chain %v requires %v to be %v but it's set to %v", chainInfo.ChainName, option, required, actual)
+		}
+	}
+	if chainInfo.ChainConfig != nil && chainInfo.ChainConfig.ArbitrumChainParams.DataAvailabilityCommittee {
+		if readsParentChainBatches(k) && !k.Bool("node.data-availability.enable") {
+			return fmt.Errorf("chain %v is an AnyTrust chain, so reading its batches requires --node.data-availability.enable", chainInfo.ChainName)
+		}
 	}
 	return nil
 }
 
+// readsParentChainBatches is whether the parent chain reader will be enabled, which runNode decides from the no-l1-listener option
+func readsParentChainBatches(k *koanf.Koanf) bool {
+	return !k.Bool("node.dangerous.no-l1-listener")
+}
+
 func initReorg(initConfig conf.InitConfig, chainConfig *params.ChainConfig, inboxTracker *arbnode.InboxTracker) error {
 	var batchCount uint64
 	if initConfig.ReorgToBatch >= 0 {
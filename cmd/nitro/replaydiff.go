@@ -0,0 +1,384 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/server_api"
+)
+
+// replayDiffCommand replays a block range two ways and diffs the results, to check that a release
+// stays consensus compatible: nitro replay-diff --from 100 --to 200 --a.url ... --b.url ...
+const replayDiffCommand = "replay-diff"
+
+// ReplayDiffSideConfig is one way of replaying blocks. Without a module root, url is the RPC of a node
+// that executed the blocks with the binary under test. With one, url is a validation server that replays
+// each block in that machine, from validation inputs recorded by the source node.
+type ReplayDiffSideConfig struct {
+	Url        string `koanf:"url"`
+	ModuleRoot string `koanf:"module-root"`
+}
+
+func ReplayDiffSideConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", "", "RPC url of a node that executed the blocks, or of a validation server if module-root is set")
+	f.String(prefix+".module-root", "", "wasm module root of the machine the validation server replays blocks with")
+}
+
+func (c *ReplayDiffSideConfig) Validate(name string) error {
+	if c.Url == "" {
+		return fmt.Errorf("--%v.url must be set", name)
+	}
+	if c.ModuleRoot == "" {
+		return nil
+	}
+	if root, err := hexutil.Decode(c.ModuleRoot); err != nil || len(root) != common.HashLength {
+		return fmt.Errorf("--%v.module-root \"%v\" isn't a 0x-prefixed hash", name, c.ModuleRoot)
+	}
+	return nil
+}
+
+type ReplayDiffConfig struct {
+	Conf            genericconf.ConfConfig `koanf:"conf"`
+	From            uint64                 `koanf:"from"`
+	To              uint64                 `koanf:"to"`
+	GenesisBlockNum uint64                 `koanf:"genesis-block-num"`
+	Source          string                 `koanf:"source"`
+	A               ReplayDiffSideConfig   `koanf:"a"`
+	B               ReplayDiffSideConfig   `koanf:"b"`
+	Output          string                 `koanf:"output"`
+	MaxDiffs        uint64                 `koanf:"max-diffs"`
+	LogLevel        string                 `koanf:"log-level"`
+	LogType         string                 `koanf:"log-type"`
+}
+
+var ReplayDiffConfigDefault = ReplayDiffConfig{
+	Conf:     genericconf.ConfConfigDefault,
+	MaxDiffs: 1000,
+	LogLevel: "INFO",
+	LogType:  "plaintext",
+}
+
+func ReplayDiffConfigAddOptions(f *flag.FlagSet) {
+	genericconf.ConfConfigAddOptions("conf", f)
+	f.Uint64("from", ReplayDiffConfigDefault.From, "first block to replay")
+	f.Uint64("to", ReplayDiffConfigDefault.To, "last block to replay")
+	f.Uint64("genesis-block-num", ReplayDiffConfigDefault.GenesisBlockNum, "the chain's genesis block number, used to find the message of each block replayed in a machine")
+	f.String("source", ReplayDiffConfigDefault.Source, "RPC url of a node with the arbdebug API, which records the validation inputs of blocks replayed in a machine")
+	ReplayDiffSideConfigAddOptions("a", f)
+	ReplayDiffSideConfigAddOptions("b", f)
+	f.String("output", ReplayDiffConfigDefault.Output, "file to write the JSON report to (defaults to stdout)")
+	f.Uint64("max-diffs", ReplayDiffConfigDefault.MaxDiffs, "stop replaying after finding this many differences (0 = no limit)")
+	f.String("log-level", ReplayDiffConfigDefault.LogLevel, "log level, valid values are CRIT, ERROR, WARN, INFO, DEBUG, TRACE")
+	f.String("log-type", ReplayDiffConfigDefault.LogType, "log type (plaintext or json)")
+}
+
+func (c *ReplayDiffConfig) Validate() error {
+	if c.To < c.From {
+		return fmt.Errorf("--to %v is before --from %v", c.To, c.From)
+	}
+	if err := c.A.Validate("a"); err != nil {
+		return err
+	}
+	if err := c.B.Validate("b"); err != nil {
+		return err
+	}
+	if c.A.ModuleRoot == "" && c.B.ModuleRoot == "" {
+		return nil
+	}
+	if c.Source == "" {
+		return errors.New("--source must be set to replay blocks in a machine")
+	}
+	if c.From <= c.GenesisBlockNum {
+		return fmt.Errorf("--from must be after the genesis block %v to replay blocks in a machine", c.GenesisBlockNum)
+	}
+	return nil
+}
+
+func ParseReplayDiff(args []string) (*ReplayDiffConfig, error) {
+	f := flag.NewFlagSet(replayDiffCommand, flag.ContinueOnError)
+	ReplayDiffConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config ReplayDiffConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Conf.Dump {
+		if err := confighelpers.DumpConfig(k, map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// replayResult is what one side produced for a block. A machine only reports the global state it ended
+// in, so its header and receipts are nil.
+type replayResult struct {
+	BlockHash common.Hash
+	SendRoot  common.Hash
+	Header    *types.Header
+	Receipts  types.Receipts
+}
+
+type replaySide interface {
+	replay(ctx context.Context, block uint64) (*replayResult, error)
+}
+
+type nodeReplaySide struct {
+	client *ethclient.Client
+}
+
+func (s *nodeReplaySide) replay(ctx context.Context, block uint64) (*replayResult, error) {
+	header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G115
+	receipts, err := s.client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(block)))
+	if err != nil {
+		return nil, err
+	}
+	return &replayResult{
+		BlockHash: header.Hash(),
+		SendRoot:  types.DeserializeHeaderExtraInformation(header).SendRoot,
+		Header:    header,
+		Receipts:  receipts,
+	}, nil
+}
+
+type machineReplaySide struct {
+	source          *rpc.Client
+	server          *rpc.Client
+	moduleRoot      common.Hash
+	target          ethdb.WasmTarget
+	genesisBlockNum uint64
+}
+
+func newMachineReplaySide(ctx context.Context, source *rpc.Client, config *ReplayDiffSideConfig, genesisBlockNum uint64) (*machineReplaySide, error) {
+	server, err := rpc.DialContext(ctx, config.Url)
+	if err != nil {
+		return nil, err
+	}
+	var targets []ethdb.WasmTarget
+	if err := server.CallContext(ctx, &targets, server_api.Namespace+"_stylusArchs"); err != nil {
+		server.Close()
+		return nil, fmt.Errorf("reading the stylus targets of validation server %v: %w", config.Url, err)
+	}
+	if len(targets) == 0 {
+		server.Close()
+		return nil, fmt.Errorf("validation server %v reported no stylus targets", config.Url)
+	}
+	return &machineReplaySide{
+		source:          source,
+		server:          server,
+		moduleRoot:      common.HexToHash(config.ModuleRoot),
+		target:          targets[0],
+		genesisBlockNum: genesisBlockNum,
+	}, nil
+}
+
+func (s *machineReplaySide) replay(ctx context.Context, block uint64) (*replayResult, error) {
+	msgNum := hexutil.Uint64(block - s.genesisBlockNum)
+	var input server_api.InputJSON
+	if err := s.source.CallContext(ctx, &input, "arbdebug_validationInputsAt", msgNum, s.target); err != nil {
+		return nil, fmt.Errorf("recording validation inputs: %w", err)
+	}
+	var end validator.GoGlobalState
+	if err := s.server.CallContext(ctx, &end, server_api.Namespace+"_validate", &input, s.moduleRoot); err != nil {
+		return nil, fmt.Errorf("replaying in machine %v: %w", s.moduleRoot, err)
+	}
+	return &replayResult{BlockHash: end.BlockHash, SendRoot: end.SendRoot}, nil
+}
+
+// ReplayDiff is a value that differs between the two sides' replays of a block.
+type ReplayDiff struct {
+	Block uint64 `json:"block"`
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+type ReplayDiffReport struct {
+	From            uint64       `json:"from"`
+	To              uint64       `json:"to"`
+	BlocksReplayed  uint64       `json:"blocksReplayed"`
+	BlocksDiffering uint64       `json:"blocksDiffering"`
+	Truncated       bool         `json:"truncated"` // replaying stopped early at max-diffs
+	Diffs           []ReplayDiff `json:"diffs"`
+}
+
+func logsHash(logs []*types.Log) (common.Hash, error) {
+	data, err := rlp.EncodeToBytes(logs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// diffReplayResults compares everything both sides report for a block.
+func diffReplayResults(block uint64, a, b *replayResult) ([]ReplayDiff, error) {
+	var diffs []ReplayDiff
+	check := func(field string, aValue, bValue interface{}) {
+		aString, bString := fmt.Sprint(aValue), fmt.Sprint(bValue)
+		if aString != bString {
+			diffs = append(diffs, ReplayDiff{Block: block, Field: field, A: aString, B: bString})
+		}
+	}
+	check("blockHash", a.BlockHash, b.BlockHash)
+	check("sendRoot", a.SendRoot, b.SendRoot)
+	if a.Header == nil || b.Header == nil {
+		return diffs, nil
+	}
+	check("stateRoot", a.Header.Root, b.Header.Root)
+	check("receiptsRoot", a.Header.ReceiptHash, b.Header.ReceiptHash)
+	check("gasUsed", a.Header.GasUsed, b.Header.GasUsed)
+	check("receipts", len(a.Receipts), len(b.Receipts))
+	for i := 0; i < len(a.Receipts) && i < len(b.Receipts); i++ {
+		aReceipt, bReceipt := a.Receipts[i], b.Receipts[i]
+		prefix := fmt.Sprintf("receipts[%v].", i)
+		check(prefix+"txHash", aReceipt.TxHash, bReceipt.TxHash)
+		check(prefix+"status", aReceipt.Status, bReceipt.Status)
+		check(prefix+"gasUsed", aReceipt.GasUsed, bReceipt.GasUsed)
+		check(prefix+"gasUsedForL1", aReceipt.GasUsedForL1, bReceipt.GasUsedForL1)
+		check(prefix+"cumulativeGasUsed", aReceipt.CumulativeGasUsed, bReceipt.CumulativeGasUsed)
+		check(prefix+"contractAddress", aReceipt.ContractAddress, bReceipt.ContractAddress)
+		aLogs, err := logsHash(aReceipt.Logs)
+		if err != nil {
+			return nil, err
+		}
+		bLogs, err := logsHash(bReceipt.Logs)
+		if err != nil {
+			return nil, err
+		}
+		check(prefix+"logsHash", aLogs, bLogs)
+	}
+	return diffs, nil
+}
+
+func openReplaySide(ctx context.Context, config *ReplayDiffConfig, side *ReplayDiffSideConfig, source *rpc.Client) (replaySide, error) {
+	if side.ModuleRoot != "" {
+		return newMachineReplaySide(ctx, source, side, config.GenesisBlockNum)
+	}
+	client, err := ethclient.DialContext(ctx, side.Url)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeReplaySide{client: client}, nil
+}
+
+func runReplayDiff(ctx context.Context, config *ReplayDiffConfig, a, b replaySide) (*ReplayDiffReport, error) {
+	report := &ReplayDiffReport{From: config.From, To: config.To, Diffs: []ReplayDiff{}}
+	for block := config.From; block <= config.To; block++ {
+		aResult, err := a.replay(ctx, block)
+		if err != nil {
+			return nil, fmt.Errorf("side a failed to replay block %v: %w", block, err)
+		}
+		bResult, err := b.replay(ctx, block)
+		if err != nil {
+			return nil, fmt.Errorf("side b failed to replay block %v: %w", block, err)
+		}
+		diffs, err := diffReplayResults(block, aResult, bResult)
+		if err != nil {
+			return nil, err
+		}
+		report.BlocksReplayed++
+		if len(diffs) > 0 {
+			report.BlocksDiffering++
+			report.Diffs = append(report.Diffs, diffs...)
+			log.Warn("replays differ", "block", block, "diffs", len(diffs))
+		}
+		if config.MaxDiffs != 0 && uint64(len(report.Diffs)) >= config.MaxDiffs && block < config.To {
+			report.Truncated = true
+			break
+		}
+		if block%1000 == 0 {
+			log.Info("replaying", "block", block, "to", config.To, "blocksDiffering", report.BlocksDiffering)
+		}
+	}
+	return report, nil
+}
+
+// replayDiffMain returns 0 if the replays match, 2 if they differ, and 1 if they couldn't be compared.
+func replayDiffMain(ctx context.Context, args []string) int {
+	config, err := ParseReplayDiff(args)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if err := genericconf.InitLog(config.LogType, config.LogLevel, &genericconf.FileLoggingConfig{Enable: false}, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+		return 1
+	}
+	var source *rpc.Client
+	if config.Source != "" {
+		source, err = rpc.DialContext(ctx, config.Source)
+		if err != nil {
+			log.Error("failed to connect to the source node", "err", err)
+			return 1
+		}
+		defer source.Close()
+	}
+	a, err := openReplaySide(ctx, config, &config.A, source)
+	if err != nil {
+		log.Error("failed to connect to side a", "err", err)
+		return 1
+	}
+	b, err := openReplaySide(ctx, config, &config.B, source)
+	if err != nil {
+		log.Error("failed to connect to side b", "err", err)
+		return 1
+	}
+	report, err := runReplayDiff(ctx, config, a, b)
+	if err != nil {
+		log.Error("replay diff failed", "err", err)
+		return 1
+	}
+	var output io.Writer = os.Stdout
+	if config.Output != "" {
+		file, err := os.Create(config.Output)
+		if err != nil {
+			log.Error("failed to create the report file", "err", err)
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Error("failed to write the report", "err", err)
+		return 1
+	}
+	log.Info("replay diff done", "blocksReplayed", report.BlocksReplayed, "blocksDiffering", report.BlocksDiffering, "truncated", report.Truncated)
+	if len(report.Diffs) > 0 {
+		return 2
+	}
+	return 0
+}
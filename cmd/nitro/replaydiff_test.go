@@ -0,0 +1,103 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseReplayDiff(t *testing.T) {
+	if _, err := ParseReplayDiff([]string{"--from", "10", "--to", "20", "--a.url", "http://a"}); err == nil {
+		Fail(t, "expected an error without side b")
+	}
+	if _, err := ParseReplayDiff([]string{"--from", "20", "--to", "10", "--a.url", "http://a", "--b.url", "http://b"}); err == nil {
+		Fail(t, "expected an error with to before from")
+	}
+	root := common.HexToHash("0x1234").Hex()
+	if _, err := ParseReplayDiff([]string{"--from", "10", "--to", "20", "--a.url", "http://a", "--b.url", "http://b", "--b.module-root", root}); err == nil {
+		Fail(t, "expected an error replaying in a machine without a source")
+	}
+	if _, err := ParseReplayDiff([]string{"--from", "10", "--to", "20", "--a.url", "http://a", "--b.url", "http://b", "--b.module-root", "0x1234", "--source", "http://s"}); err == nil {
+		Fail(t, "expected an error with a short module root")
+	}
+	config, err := ParseReplayDiff([]string{"--from", "10", "--to", "20", "--a.url", "http://a", "--b.url", "http://b", "--b.module-root", root, "--source", "http://s"})
+	Require(t, err)
+	if config.From != 10 || config.To != 20 || config.B.ModuleRoot != root || config.MaxDiffs != ReplayDiffConfigDefault.MaxDiffs {
+		Fail(t, "unexpected config", config)
+	}
+}
+
+type mockReplaySide map[uint64]*replayResult
+
+func (m mockReplaySide) replay(ctx context.Context, block uint64) (*replayResult, error) {
+	return m[block], nil
+}
+
+func replayDiffTestResult(stateRoot common.Hash, gasUsed ...uint64) *replayResult {
+	header := &types.Header{Number: big.NewInt(1), Root: stateRoot, Difficulty: big.NewInt(1)}
+	result := &replayResult{Header: header}
+	for i, gas := range gasUsed {
+		header.GasUsed += gas
+		result.Receipts = append(result.Receipts, &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			TxHash:            common.BigToHash(big.NewInt(int64(i))),
+			GasUsed:           gas,
+			CumulativeGasUsed: header.GasUsed,
+		})
+	}
+	result.BlockHash = header.Hash()
+	return result
+}
+
+func TestRunReplayDiff(t *testing.T) {
+	stateRoot := common.HexToHash("0x01")
+	a := mockReplaySide{
+		1: replayDiffTestResult(stateRoot, 21000, 50000),
+		2: replayDiffTestResult(stateRoot, 21000),
+		3: replayDiffTestResult(stateRoot, 21000),
+	}
+	b := mockReplaySide{
+		1: replayDiffTestResult(stateRoot, 21000, 50000),
+		2: replayDiffTestResult(common.HexToHash("0x02"), 21000),
+		3: {BlockHash: a[3].BlockHash},
+	}
+	config := ReplayDiffConfigDefault
+	config.From = 1
+	config.To = 3
+	report, err := runReplayDiff(context.Background(), &config, a, b)
+	Require(t, err)
+	if report.BlocksReplayed != 3 || report.BlocksDiffering != 1 || report.Truncated {
+		Fail(t, "unexpected report", report)
+	}
+	fields := map[string]bool{}
+	for _, diff := range report.Diffs {
+		if diff.Block != 2 {
+			Fail(t, "unexpected diff", diff)
+		}
+		fields[diff.Field] = true
+	}
+	if len(fields) != 2 || !fields["blockHash"] || !fields["stateRoot"] {
+		Fail(t, "unexpected diffs", report.Diffs)
+	}
+
+	b[1] = replayDiffTestResult(stateRoot, 21000, 60000)
+	config.MaxDiffs = 1
+	report, err = runReplayDiff(context.Background(), &config, a, b)
+	Require(t, err)
+	if report.BlocksReplayed != 1 || !report.Truncated {
+		Fail(t, "didn't stop at max-diffs", report)
+	}
+	fields = map[string]bool{}
+	for _, diff := range report.Diffs {
+		fields[diff.Field] = true
+	}
+	if !fields["gasUsed"] || !fields["receipts[1].gasUsed"] || !fields["receipts[1].cumulativeGasUsed"] || fields["receipts[0].gasUsed"] {
+		Fail(t, "unexpected diffs", report.Diffs)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+
+	"github.com/offchainlabs/nitro/util/telemetry"
+)
+
+// telemetryFeatures names the node's enabled roles and notable features, leaving out anything identifying it.
+func telemetryFeatures(config *NodeConfig) []string {
+	return telemetry.Features(map[string]bool{
+		"sequencer":        config.Node.Sequencer,
+		"batch-poster":     config.Node.BatchPoster.Enable,
+		"staker":           config.Node.Staker.Enable,
+		"block-validator":  config.Node.BlockValidator.Enable,
+		"feed-output":      config.Node.Feed.Output.Enable,
+		"anytrust":         config.Node.DataAvailability.Enable,
+		"external-da":      config.Node.ExternalDA.Enable,
+		"seq-coordinator":  config.Node.SeqCoordinator.Enable,
+		"archive":          config.Execution.Caching.Archive,
+		"path-scheme":      config.Execution.Caching.StateScheme == rawdb.PathScheme,
+		"express-lane":     config.Execution.Sequencer.ExpressLane.Enable,
+		"fair-ordering":    config.Execution.Sequencer.FairOrdering.Enable,
+		"stylus-verifier":  config.Execution.StylusVerifier.Enable,
+		"wasm-store-gc":    config.Execution.WasmStoreGC.Enable,
+		"censorship-audit": config.Node.CensorshipAudit.Enable,
+		"rpc-diff":         config.RPCDiff.Enable,
+	})
+}
+
+func telemetryReport(nodeId string, version string, chainId uint64, config func() *NodeConfig, synced func() bool) func() *telemetry.Report {
+	return func() *telemetry.Report {
+		return &telemetry.Report{
+			NodeId:   nodeId,
+			Version:  version,
+			ChainId:  chainId,
+			Synced:   synced(),
+			Features: telemetryFeatures(config()),
+			Time:     time.Now().UTC(),
+		}
+	}
+}
+
+// printTelemetryPreview prints the report built from the config alone, before anything is started,
+// so previewing doesn't need the node to come up. The node isn't running yet, so it reports as not synced.
+func printTelemetryPreview(nodeIdDir string, version string, config *NodeConfig) error {
+	nodeId, err := telemetry.LoadNodeId(nodeIdDir)
+	if err != nil {
+		return fmt.Errorf("failed to load telemetry node id: %w", err)
+	}
+	report := telemetryReport(nodeId, version, config.Chain.ID, func() *NodeConfig { return config }, func() bool { return false })
+	preview, err := json.MarshalIndent(report(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry report: %w", err)
+	}
+	fmt.Println(string(preview))
+	return nil
+}
@@ -0,0 +1,643 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/staker"
+	"github.com/offchainlabs/nitro/validator/server_api"
+)
+
+// upgradeOrchestrateCommand walks a chain through an ArbOS upgrade: it checks the validator fleet is ready,
+// schedules the upgrade, waits for the block it activates in and checks the chain and fleet afterwards:
+// nitro upgrade-orchestrate --l2-url ... --arbos-version 32 --validators ... --wallet.private-key ...
+const upgradeOrchestrateCommand = "upgrade-orchestrate"
+
+type UpgradeOrchestrateConfig struct {
+	Conf              genericconf.ConfConfig   `koanf:"conf"`
+	L2Url             string                   `koanf:"l2-url"`
+	Wallet            genericconf.WalletConfig `koanf:"wallet"`
+	ArbOSVersion      uint64                   `koanf:"arbos-version"`
+	UpgradeTimestamp  uint64                   `koanf:"upgrade-timestamp"`
+	UpgradeDelay      time.Duration            `koanf:"upgrade-delay"`
+	SkipSchedule      bool                     `koanf:"skip-schedule"`
+	WasmModuleRoot    string                   `koanf:"wasm-module-root"`
+	Validators        []string                 `koanf:"validators"`
+	ValidationServers []string                 `koanf:"validation-servers"`
+	PostUpgradeBlocks uint64                   `koanf:"post-upgrade-blocks"`
+	PollInterval      time.Duration            `koanf:"poll-interval"`
+	Timeout           time.Duration            `koanf:"timeout"`
+	Output            string                   `koanf:"output"`
+	LogLevel          string                   `koanf:"log-level"`
+	LogType           string                   `koanf:"log-type"`
+}
+
+var UpgradeOrchestrateConfigDefault = UpgradeOrchestrateConfig{
+	Conf:              genericconf.ConfConfigDefault,
+	Wallet:            genericconf.WalletConfigDefault,
+	UpgradeDelay:      time.Hour,
+	Validators:        []string{},
+	ValidationServers: []string{},
+	PostUpgradeBlocks: 10,
+	PollInterval:      5 * time.Second,
+	Timeout:           30 * time.Minute,
+	LogLevel:          "INFO",
+	LogType:           "plaintext",
+}
+
+func UpgradeOrchestrateConfigAddOptions(f *flag.FlagSet) {
+	genericconf.ConfConfigAddOptions("conf", f)
+	f.String("l2-url", UpgradeOrchestrateConfigDefault.L2Url, "RPC url of a node of the chain being upgraded")
+	genericconf.WalletConfigAddOptions("wallet", f, "")
+	f.Uint64("arbos-version", UpgradeOrchestrateConfigDefault.ArbOSVersion, "ArbOS version to upgrade the chain to")
+	f.Uint64("upgrade-timestamp", UpgradeOrchestrateConfigDefault.UpgradeTimestamp, "unix timestamp to schedule the upgrade at (defaults to upgrade-delay after the latest block)")
+	f.Duration("upgrade-delay", UpgradeOrchestrateConfigDefault.UpgradeDelay, "how long after the latest block to schedule the upgrade, if upgrade-timestamp isn't set")
+	f.Bool("skip-schedule", UpgradeOrchestrateConfigDefault.SkipSchedule, "don't schedule the upgrade, only check and monitor one the chain owner already scheduled")
+	f.String("wasm-module-root", UpgradeOrchestrateConfigDefault.WasmModuleRoot, "wasm module root of the machine for the new ArbOS version, which the validator fleet must support")
+	f.StringSlice("validators", UpgradeOrchestrateConfigDefault.Validators, "RPC urls of validator nodes to check, which must expose the arb namespace")
+	f.StringSlice("validation-servers", UpgradeOrchestrateConfigDefault.ValidationServers, "RPC urls of validation servers to check for the wasm module root")
+	f.Uint64("post-upgrade-blocks", UpgradeOrchestrateConfigDefault.PostUpgradeBlocks, "how many blocks after the upgrade block to check are on the new ArbOS version")
+	f.Duration("poll-interval", UpgradeOrchestrateConfigDefault.PollInterval, "how often to poll the chain and validators while waiting")
+	f.Duration("timeout", UpgradeOrchestrateConfigDefault.Timeout, "how long to wait for the upgrade after its timestamp, and then for the chain and validators to move past it")
+	f.String("output", UpgradeOrchestrateConfigDefault.Output, "file to write the JSON report to (defaults to stdout)")
+	f.String("log-level", UpgradeOrchestrateConfigDefault.LogLevel, "log level, valid values are CRIT, ERROR, WARN, INFO, DEBUG, TRACE")
+	f.String("log-type", UpgradeOrchestrateConfigDefault.LogType, "log type (plaintext or json)")
+}
+
+func (c *UpgradeOrchestrateConfig) Validate() error {
+	if c.L2Url == "" {
+		return errors.New("--l2-url must be set")
+	}
+	if c.ArbOSVersion == 0 {
+		return errors.New("--arbos-version must be set")
+	}
+	if c.WasmModuleRoot != "" {
+		if root, err := hexutil.Decode(c.WasmModuleRoot); err != nil || len(root) != common.HashLength {
+			return fmt.Errorf("--wasm-module-root \"%v\" isn't a 0x-prefixed hash", c.WasmModuleRoot)
+		}
+	}
+	if !c.SkipSchedule && c.Wallet.PrivateKey == "" && c.Wallet.Pathname == "" {
+		return errors.New("--wallet.private-key or --wallet.pathname must be set to schedule the upgrade as the chain owner, or use --skip-schedule")
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("--poll-interval must be positive")
+	}
+	return nil
+}
+
+func ParseUpgradeOrchestrate(args []string) (*UpgradeOrchestrateConfig, error) {
+	f := flag.NewFlagSet(upgradeOrchestrateCommand, flag.ContinueOnError)
+	UpgradeOrchestrateConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config UpgradeOrchestrateConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Conf.Dump {
+		if err := confighelpers.DumpConfig(k, map[string]interface{}{
+			"wallet.password":    "",
+			"wallet.private-key": "",
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+type upgradeChain interface {
+	// headerByNumber returns the latest header for a nil number
+	headerByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	headerByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	scheduledUpgrade(ctx context.Context) (uint64, uint64, error)
+	scheduleUpgrade(ctx context.Context, version uint64, timestamp uint64) (common.Hash, error)
+}
+
+type upgradeValidator interface {
+	url() string
+	compatibility(ctx context.Context) (*arbnode.RollupCompatibility, error)
+	latestValidated(ctx context.Context) (*staker.GlobalStateValidatedInfo, error)
+}
+
+type upgradeValidationServer interface {
+	url() string
+	wasmModuleRoots(ctx context.Context) ([]common.Hash, error)
+}
+
+type rpcUpgradeChain struct {
+	client      *ethclient.Client
+	owner       *precompilesgen.ArbOwner
+	ownerPublic *precompilesgen.ArbOwnerPublic
+	auth        *bind.TransactOpts // nil with skip-schedule
+}
+
+func newRPCUpgradeChain(client *ethclient.Client, auth *bind.TransactOpts) (*rpcUpgradeChain, error) {
+	owner, err := precompilesgen.NewArbOwner(types.ArbOwnerAddress, client)
+	if err != nil {
+		return nil, err
+	}
+	ownerPublic, err := precompilesgen.NewArbOwnerPublic(types.ArbOwnerPublicAddress, client)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcUpgradeChain{client: client, owner: owner, ownerPublic: ownerPublic, auth: auth}, nil
+}
+
+func (c *rpcUpgradeChain) headerByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.client.HeaderByNumber(ctx, number)
+}
+
+func (c *rpcUpgradeChain) headerByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return c.client.HeaderByHash(ctx, hash)
+}
+
+func (c *rpcUpgradeChain) scheduledUpgrade(ctx context.Context) (uint64, uint64, error) {
+	scheduled, err := c.ownerPublic.GetScheduledUpgrade(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, 0, err
+	}
+	return scheduled.ArbosVersion, scheduled.ScheduledForTimestamp, nil
+}
+
+func (c *rpcUpgradeChain) scheduleUpgrade(ctx context.Context, version uint64, timestamp uint64) (common.Hash, error) {
+	if c.auth == nil {
+		return common.Hash{}, errors.New("no chain owner wallet to schedule the upgrade with")
+	}
+	auth := *c.auth
+	auth.Context = ctx
+	tx, err := c.owner.ScheduleArbOSUpgrade(&auth, version, timestamp)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	receipt, err := bind.WaitMined(ctx, c.client, tx)
+	if err != nil {
+		return tx.Hash(), err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return tx.Hash(), fmt.Errorf("scheduling transaction %v failed, is the wallet a chain owner?", tx.Hash())
+	}
+	return tx.Hash(), nil
+}
+
+type rpcUpgradeValidator struct {
+	address string
+	client  *rpc.Client
+}
+
+func (v *rpcUpgradeValidator) url() string {
+	return v.address
+}
+
+func (v *rpcUpgradeValidator) compatibility(ctx context.Context) (*arbnode.RollupCompatibility, error) {
+	var result arbnode.RollupCompatibility
+	err := v.client.CallContext(ctx, &result, "arb_rollupCompatibility")
+	return &result, err
+}
+
+func (v *rpcUpgradeValidator) latestValidated(ctx context.Context) (*staker.GlobalStateValidatedInfo, error) {
+	var result *staker.GlobalStateValidatedInfo
+	err := v.client.CallContext(ctx, &result, "arb_latestValidated")
+	return result, err
+}
+
+type rpcUpgradeValidationServer struct {
+	address string
+	client  *rpc.Client
+}
+
+func (s *rpcUpgradeValidationServer) url() string {
+	return s.address
+}
+
+func (s *rpcUpgradeValidationServer) wasmModuleRoots(ctx context.Context) ([]common.Hash, error) {
+	var roots []common.Hash
+	err := s.client.CallContext(ctx, &roots, server_api.Namespace+"_wasmModuleRoots")
+	return roots, err
+}
+
+// UpgradeCheck is one thing checked before or after the upgrade.
+type UpgradeCheck struct {
+	Name     string   `json:"name"`
+	Ok       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+func (c *UpgradeCheck) problem(format string, args ...interface{}) {
+	c.Problems = append(c.Problems, fmt.Sprintf(format, args...))
+}
+
+func (c *UpgradeCheck) done() UpgradeCheck {
+	c.Ok = len(c.Problems) == 0
+	return *c
+}
+
+func allOk(checks []UpgradeCheck) bool {
+	for _, check := range checks {
+		if !check.Ok {
+			return false
+		}
+	}
+	return true
+}
+
+type UpgradeOrchestrateReport struct {
+	ArbOSVersion      uint64         `json:"arbosVersion"`
+	StartArbOSVersion uint64         `json:"startArbosVersion"`
+	UpgradeTimestamp  uint64         `json:"upgradeTimestamp,omitempty"`
+	ScheduleTx        *common.Hash   `json:"scheduleTx,omitempty"` // unset if the upgrade was already scheduled
+	Readiness         []UpgradeCheck `json:"readiness"`
+	UpgradeBlock      *uint64        `json:"upgradeBlock,omitempty"`
+	UpgradeBlockHash  *common.Hash   `json:"upgradeBlockHash,omitempty"`
+	Invariants        []UpgradeCheck `json:"invariants"`
+	Success           bool           `json:"success"`
+	StoppedAt         string         `json:"stoppedAt,omitempty"` // the step that failed, if any
+}
+
+func headerArbOSVersion(header *types.Header) uint64 {
+	return types.DeserializeHeaderExtraInformation(header).ArbOSFormatVersion
+}
+
+type upgradeOrchestrator struct {
+	config            *UpgradeOrchestrateConfig
+	chain             upgradeChain
+	validators        []upgradeValidator
+	validationServers []upgradeValidationServer
+}
+
+var errUpgradeTimeout = errors.New("timed out")
+
+// poll calls check every poll interval until it's done or the deadline passes. On timeout, it returns
+// check's last error if there was one.
+func (o *upgradeOrchestrator) poll(ctx context.Context, deadline time.Time, check func() (bool, error)) error {
+	for {
+		done, err := check()
+		if done {
+			return err
+		}
+		if err != nil {
+			log.Warn("upgrade check failed, retrying", "err", err)
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("%w: %w", errUpgradeTimeout, err)
+			}
+			return errUpgradeTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(o.config.PollInterval):
+		}
+	}
+}
+
+// checkReadiness checks every validator can run the new ArbOS version and, if a module root is configured,
+// that the validators and validation servers have its machine.
+func (o *upgradeOrchestrator) checkReadiness(ctx context.Context) []UpgradeCheck {
+	var moduleRoot common.Hash
+	if o.config.WasmModuleRoot != "" {
+		moduleRoot = common.HexToHash(o.config.WasmModuleRoot)
+	}
+	checks := []UpgradeCheck{}
+	for _, validator := range o.validators {
+		check := UpgradeCheck{Name: "validator " + validator.url(), Problems: []string{}}
+		compatibility, err := validator.compatibility(ctx)
+		if err != nil {
+			check.problem("error checking compatibility with the rollup: %v", err)
+			checks = append(checks, check.done())
+			continue
+		}
+		check.Problems = append(check.Problems, compatibility.Problems...)
+		if compatibility.MaxArbOSVersionSupported < o.config.ArbOSVersion {
+			check.problem("supports up to ArbOS %d", compatibility.MaxArbOSVersionSupported)
+		}
+		if moduleRoot != (common.Hash{}) && !containsHash(compatibility.SupportedWasmModuleRoots, moduleRoot) {
+			check.problem("its validation servers don't support wasm module root %v", moduleRoot)
+		}
+		checks = append(checks, check.done())
+	}
+	for _, server := range o.validationServers {
+		check := UpgradeCheck{Name: "validation server " + server.url(), Problems: []string{}}
+		roots, err := server.wasmModuleRoots(ctx)
+		if err != nil {
+			check.problem("error getting wasm module roots: %v", err)
+		} else if len(roots) == 0 {
+			check.problem("reported no wasm module roots")
+		} else if moduleRoot != (common.Hash{}) && !containsHash(roots, moduleRoot) {
+			check.problem("doesn't support wasm module root %v", moduleRoot)
+		}
+		checks = append(checks, check.done())
+	}
+	return checks
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// schedule schedules the upgrade unless it already is, returning its timestamp.
+func (o *upgradeOrchestrator) schedule(ctx context.Context, head *types.Header, report *UpgradeOrchestrateReport) (uint64, error) {
+	version, timestamp, err := o.chain.scheduledUpgrade(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error reading the scheduled upgrade: %w", err)
+	}
+	if version == o.config.ArbOSVersion {
+		log.Info("upgrade already scheduled", "arbosVersion", version, "timestamp", timestamp)
+		return timestamp, nil
+	}
+	if o.config.SkipSchedule {
+		return 0, fmt.Errorf("no upgrade to ArbOS %d is scheduled (scheduled version %d)", o.config.ArbOSVersion, version)
+	}
+	if version != 0 {
+		log.Warn("replacing scheduled upgrade", "arbosVersion", version, "timestamp", timestamp)
+	}
+	timestamp = o.config.UpgradeTimestamp
+	if timestamp == 0 {
+		timestamp = head.Time + uint64(o.config.UpgradeDelay/time.Second)
+	}
+	if timestamp <= head.Time {
+		return 0, fmt.Errorf("upgrade timestamp %d isn't after the latest block's %d", timestamp, head.Time)
+	}
+	txHash, err := o.chain.scheduleUpgrade(ctx, o.config.ArbOSVersion, timestamp)
+	if txHash != (common.Hash{}) {
+		report.ScheduleTx = &txHash
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error scheduling the upgrade: %w", err)
+	}
+	log.Info("scheduled upgrade", "arbosVersion", o.config.ArbOSVersion, "timestamp", timestamp, "tx", txHash)
+	return timestamp, nil
+}
+
+// waitForUpgrade returns the first block on the new ArbOS version, given a block before it.
+func (o *upgradeOrchestrator) waitForUpgrade(ctx context.Context, before uint64, timestamp uint64) (*types.Header, error) {
+	// #nosec G115
+	deadline := time.Unix(int64(timestamp), 0).Add(o.config.Timeout)
+	var upgrade *types.Header
+	err := o.poll(ctx, deadline, func() (bool, error) {
+		head, err := o.chain.headerByNumber(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		if headerArbOSVersion(head) < o.config.ArbOSVersion {
+			before = head.Number.Uint64()
+			return false, nil
+		}
+		for number := before + 1; number <= head.Number.Uint64(); number++ {
+			header, err := o.chain.headerByNumber(ctx, new(big.Int).SetUint64(number))
+			if err != nil {
+				return false, err
+			}
+			if headerArbOSVersion(header) >= o.config.ArbOSVersion {
+				upgrade = header
+				return true, nil
+			}
+			before = number
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for ArbOS %d to activate: %w", o.config.ArbOSVersion, err)
+	}
+	return upgrade, nil
+}
+
+// checkInvariants checks the upgrade activated when scheduled, the chain keeps producing blocks on the new
+// version, and the validators validate past the upgrade without finding problems.
+func (o *upgradeOrchestrator) checkInvariants(ctx context.Context, upgrade *types.Header, timestamp uint64) []UpgradeCheck {
+	upgradeNumber := upgrade.Number.Uint64()
+	deadline := time.Now().Add(o.config.Timeout)
+	checks := []UpgradeCheck{}
+
+	activation := UpgradeCheck{Name: "activation", Problems: []string{}}
+	if upgrade.Time < timestamp {
+		activation.problem("upgrade block %d has timestamp %d, before the scheduled %d", upgradeNumber, upgrade.Time, timestamp)
+	}
+	if version := headerArbOSVersion(upgrade); version != o.config.ArbOSVersion {
+		activation.problem("upgrade block %d is on ArbOS %d", upgradeNumber, version)
+	}
+	checks = append(checks, activation.done())
+
+	production := UpgradeCheck{Name: "block production", Problems: []string{}}
+	last := upgradeNumber + o.config.PostUpgradeBlocks
+	err := o.poll(ctx, deadline, func() (bool, error) {
+		head, err := o.chain.headerByNumber(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		return head.Number.Uint64() >= last, nil
+	})
+	if err != nil {
+		production.problem("waiting for block %d: %v", last, err)
+	} else {
+		for number := upgradeNumber + 1; number <= last; number++ {
+			header, err := o.chain.headerByNumber(ctx, new(big.Int).SetUint64(number))
+			if err != nil {
+				production.problem("error reading block %d: %v", number, err)
+				break
+			}
+			if version := headerArbOSVersion(header); version != o.config.ArbOSVersion {
+				production.problem("block %d is on ArbOS %d", number, version)
+			}
+		}
+	}
+	checks = append(checks, production.done())
+
+	for _, validator := range o.validators {
+		check := UpgradeCheck{Name: "validator " + validator.url(), Problems: []string{}}
+		err := o.poll(ctx, deadline, func() (bool, error) {
+			validated, err := validator.latestValidated(ctx)
+			if err != nil || validated == nil {
+				return false, err
+			}
+			header, err := o.chain.headerByHash(ctx, validated.GlobalState.BlockHash)
+			if err != nil {
+				return false, err
+			}
+			return header.Number.Uint64() >= upgradeNumber, nil
+		})
+		if err != nil {
+			check.problem("waiting to validate the upgrade block %d: %v", upgradeNumber, err)
+		}
+		compatibility, err := validator.compatibility(ctx)
+		if err != nil {
+			check.problem("error checking compatibility with the rollup: %v", err)
+		} else {
+			check.Problems = append(check.Problems, compatibility.Problems...)
+			if compatibility.ArbOSVersion != 0 && compatibility.ArbOSVersion != o.config.ArbOSVersion {
+				check.problem("its chain is on ArbOS %d", compatibility.ArbOSVersion)
+			}
+		}
+		checks = append(checks, check.done())
+	}
+	return checks
+}
+
+// run returns what it found so far along with any error, so the report covers what it got done.
+func (o *upgradeOrchestrator) run(ctx context.Context) (*UpgradeOrchestrateReport, error) {
+	report := &UpgradeOrchestrateReport{
+		ArbOSVersion: o.config.ArbOSVersion,
+		Readiness:    []UpgradeCheck{},
+		Invariants:   []UpgradeCheck{},
+	}
+	head, err := o.chain.headerByNumber(ctx, nil)
+	if err != nil {
+		report.StoppedAt = "start"
+		return report, fmt.Errorf("error reading the latest block: %w", err)
+	}
+	report.StartArbOSVersion = headerArbOSVersion(head)
+	if report.StartArbOSVersion >= o.config.ArbOSVersion {
+		report.StoppedAt = "start"
+		return report, fmt.Errorf("the chain is already on ArbOS %d", report.StartArbOSVersion)
+	}
+
+	report.Readiness = o.checkReadiness(ctx)
+	if !allOk(report.Readiness) {
+		report.StoppedAt = "readiness"
+		log.Error("validator fleet isn't ready for the upgrade, not scheduling it")
+		return report, nil
+	}
+
+	timestamp, err := o.schedule(ctx, head, report)
+	if err != nil {
+		report.StoppedAt = "schedule"
+		return report, err
+	}
+	report.UpgradeTimestamp = timestamp
+
+	// #nosec G115
+	log.Info("waiting for the upgrade", "arbosVersion", o.config.ArbOSVersion, "at", time.Unix(int64(timestamp), 0))
+	upgrade, err := o.waitForUpgrade(ctx, head.Number.Uint64(), timestamp)
+	if err != nil {
+		report.StoppedAt = "upgrade"
+		return report, err
+	}
+	upgradeNumber, upgradeHash := upgrade.Number.Uint64(), upgrade.Hash()
+	report.UpgradeBlock = &upgradeNumber
+	report.UpgradeBlockHash = &upgradeHash
+	log.Info("upgrade activated", "arbosVersion", o.config.ArbOSVersion, "block", upgradeNumber, "hash", upgradeHash)
+
+	report.Invariants = o.checkInvariants(ctx, upgrade, timestamp)
+	report.Success = allOk(report.Invariants)
+	if !report.Success {
+		report.StoppedAt = "invariants"
+	}
+	return report, nil
+}
+
+func newUpgradeOrchestrator(ctx context.Context, config *UpgradeOrchestrateConfig) (*upgradeOrchestrator, error) {
+	client, err := ethclient.DialContext(ctx, config.L2Url)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the chain: %w", err)
+	}
+	var auth *bind.TransactOpts
+	if !config.SkipSchedule {
+		chainId, err := client.ChainID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting the chain id: %w", err)
+		}
+		auth, _, err = util.OpenWallet("owner", &config.Wallet, chainId)
+		if err != nil {
+			return nil, fmt.Errorf("error opening the chain owner wallet: %w", err)
+		}
+	}
+	chain, err := newRPCUpgradeChain(client, auth)
+	if err != nil {
+		return nil, err
+	}
+	orchestrator := &upgradeOrchestrator{config: config, chain: chain}
+	for _, url := range config.Validators {
+		rpcClient, err := rpc.DialContext(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to validator %v: %w", url, err)
+		}
+		orchestrator.validators = append(orchestrator.validators, &rpcUpgradeValidator{address: url, client: rpcClient})
+	}
+	for _, url := range config.ValidationServers {
+		rpcClient, err := rpc.DialContext(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to validation server %v: %w", url, err)
+		}
+		orchestrator.validationServers = append(orchestrator.validationServers, &rpcUpgradeValidationServer{address: url, client: rpcClient})
+	}
+	return orchestrator, nil
+}
+
+// upgradeOrchestrateMain returns 0 if the upgrade went through cleanly, 2 if the fleet wasn't ready or a
+// check after the upgrade failed, and 1 if the upgrade couldn't be scheduled or monitored.
+func upgradeOrchestrateMain(ctx context.Context, args []string) int {
+	config, err := ParseUpgradeOrchestrate(args)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
+	}
+	if err := genericconf.InitLog(config.LogType, config.LogLevel, &genericconf.FileLoggingConfig{Enable: false}, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+		return 1
+	}
+	orchestrator, err := newUpgradeOrchestrator(ctx, config)
+	if err != nil {
+		log.Error("failed to set up the upgrade", "err", err)
+		return 1
+	}
+	report, runErr := orchestrator.run(ctx)
+	var output io.Writer = os.Stdout
+	if config.Output != "" {
+		file, err := os.Create(config.Output)
+		if err != nil {
+			log.Error("failed to create the report file", "err", err)
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Error("failed to write the report", "err", err)
+		return 1
+	}
+	if runErr != nil {
+		log.Error("upgrade orchestration failed", "stoppedAt", report.StoppedAt, "err", runErr)
+		return 1
+	}
+	if !report.Success {
+		log.Error("upgrade orchestration found problems", "stoppedAt", report.StoppedAt)
+		return 2
+	}
+	log.Info("upgrade done", "arbosVersion", report.ArbOSVersion, "upgradeBlock", *report.UpgradeBlock)
+	return 0
+}
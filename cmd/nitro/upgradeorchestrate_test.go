@@ -0,0 +1,190 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/staker"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestParseUpgradeOrchestrate(t *testing.T) {
+	if _, err := ParseUpgradeOrchestrate([]string{"--arbos-version", "32", "--skip-schedule"}); err == nil {
+		Fail(t, "expected an error without the chain's url")
+	}
+	if _, err := ParseUpgradeOrchestrate([]string{"--l2-url", "http://l2", "--arbos-version", "32"}); err == nil {
+		Fail(t, "expected an error scheduling without a wallet")
+	}
+	if _, err := ParseUpgradeOrchestrate([]string{"--l2-url", "http://l2", "--arbos-version", "32", "--skip-schedule", "--wasm-module-root", "0x1234"}); err == nil {
+		Fail(t, "expected an error with a short module root")
+	}
+	config, err := ParseUpgradeOrchestrate([]string{"--l2-url", "http://l2", "--arbos-version", "32", "--skip-schedule", "--validators", "http://v1,http://v2"})
+	Require(t, err)
+	if config.ArbOSVersion != 32 || len(config.Validators) != 2 || config.PostUpgradeBlocks != UpgradeOrchestrateConfigDefault.PostUpgradeBlocks {
+		Fail(t, "unexpected config", config)
+	}
+}
+
+// mockUpgradeChain produces a block every time its latest header is read, on the scheduled ArbOS version
+// once the block's timestamp reaches the upgrade's.
+type mockUpgradeChain struct {
+	start            uint64
+	version          uint64
+	scheduledVersion uint64
+	scheduledTime    uint64
+	scheduleCalls    int
+	headers          []*types.Header
+	byHash           map[common.Hash]*types.Header
+}
+
+func newMockUpgradeChain(version uint64) *mockUpgradeChain {
+	chain := &mockUpgradeChain{
+		// #nosec G115
+		start:   uint64(time.Now().Unix()),
+		version: version,
+		byHash:  make(map[common.Hash]*types.Header),
+	}
+	chain.produce()
+	return chain
+}
+
+func (c *mockUpgradeChain) produce() *types.Header {
+	number := uint64(len(c.headers))
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Time: c.start + number, Difficulty: common.Big1}
+	if c.scheduledVersion != 0 && header.Time >= c.scheduledTime {
+		c.version = c.scheduledVersion
+		c.scheduledVersion, c.scheduledTime = 0, 0
+	}
+	types.HeaderInfo{ArbOSFormatVersion: c.version}.UpdateHeaderWithInfo(header)
+	c.headers = append(c.headers, header)
+	c.byHash[header.Hash()] = header
+	return header
+}
+
+func (c *mockUpgradeChain) headerByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return c.produce(), nil
+	}
+	if number.Uint64() >= uint64(len(c.headers)) {
+		return nil, errors.New("block not found")
+	}
+	return c.headers[number.Uint64()], nil
+}
+
+func (c *mockUpgradeChain) headerByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	header, ok := c.byHash[hash]
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+	return header, nil
+}
+
+func (c *mockUpgradeChain) scheduledUpgrade(ctx context.Context) (uint64, uint64, error) {
+	return c.scheduledVersion, c.scheduledTime, nil
+}
+
+func (c *mockUpgradeChain) scheduleUpgrade(ctx context.Context, version uint64, timestamp uint64) (common.Hash, error) {
+	c.scheduleCalls++
+	c.scheduledVersion, c.scheduledTime = version, timestamp
+	return common.HexToHash("0xabcd"), nil
+}
+
+type mockUpgradeValidator struct {
+	chain  *mockUpgradeChain
+	result arbnode.RollupCompatibility
+}
+
+func (v *mockUpgradeValidator) url() string {
+	return "http://validator"
+}
+
+func (v *mockUpgradeValidator) compatibility(ctx context.Context) (*arbnode.RollupCompatibility, error) {
+	result := v.result
+	result.ArbOSVersion = v.chain.version
+	return &result, nil
+}
+
+func (v *mockUpgradeValidator) latestValidated(ctx context.Context) (*staker.GlobalStateValidatedInfo, error) {
+	head := v.chain.headers[len(v.chain.headers)-1]
+	return &staker.GlobalStateValidatedInfo{GlobalState: validator.GoGlobalState{BlockHash: head.Hash()}}, nil
+}
+
+type mockUpgradeValidationServer []common.Hash
+
+func (s mockUpgradeValidationServer) url() string {
+	return "http://validation-server"
+}
+
+func (s mockUpgradeValidationServer) wasmModuleRoots(ctx context.Context) ([]common.Hash, error) {
+	return s, nil
+}
+
+func TestUpgradeOrchestrator(t *testing.T) {
+	ctx := context.Background()
+	moduleRoot := common.HexToHash("0x1234")
+	config := UpgradeOrchestrateConfigDefault
+	config.ArbOSVersion = 32
+	config.WasmModuleRoot = moduleRoot.Hex()
+	config.UpgradeDelay = 3 * time.Second
+	config.PostUpgradeBlocks = 5
+	config.PollInterval = time.Millisecond
+	config.Timeout = 10 * time.Second
+
+	// A validator that doesn't support the new version stops the upgrade before it's scheduled
+	chain := newMockUpgradeChain(31)
+	outdated := &mockUpgradeValidator{chain: chain, result: arbnode.RollupCompatibility{MaxArbOSVersionSupported: 31, SupportedWasmModuleRoots: []common.Hash{moduleRoot}}}
+	orchestrator := &upgradeOrchestrator{
+		config:            &config,
+		chain:             chain,
+		validators:        []upgradeValidator{outdated},
+		validationServers: []upgradeValidationServer{mockUpgradeValidationServer{}},
+	}
+	report, err := orchestrator.run(ctx)
+	Require(t, err)
+	if report.Success || report.StoppedAt != "readiness" || chain.scheduleCalls != 0 {
+		Fail(t, "upgrade went ahead with the fleet not ready", report)
+	}
+	if len(report.Readiness) != 2 || report.Readiness[0].Ok || report.Readiness[1].Ok {
+		Fail(t, "unexpected readiness", report.Readiness)
+	}
+
+	ready := &mockUpgradeValidator{chain: chain, result: arbnode.RollupCompatibility{Compatible: true, MaxArbOSVersionSupported: 32, SupportedWasmModuleRoots: []common.Hash{moduleRoot}}}
+	orchestrator.validators = []upgradeValidator{ready}
+	orchestrator.validationServers = []upgradeValidationServer{mockUpgradeValidationServer{moduleRoot}}
+	report, err = orchestrator.run(ctx)
+	Require(t, err)
+	if !report.Success || chain.scheduleCalls != 1 || report.ScheduleTx == nil || report.UpgradeBlock == nil {
+		Fail(t, "upgrade didn't succeed", report)
+	}
+	upgrade := chain.headers[*report.UpgradeBlock]
+	if headerArbOSVersion(upgrade) != 32 || headerArbOSVersion(chain.headers[*report.UpgradeBlock-1]) != 31 || upgrade.Time < report.UpgradeTimestamp {
+		Fail(t, "reported the wrong upgrade block", *report.UpgradeBlock)
+	}
+	if len(report.Invariants) != 3 || !allOk(report.Invariants) {
+		Fail(t, "unexpected invariants", report.Invariants)
+	}
+
+	// Once upgraded, there's nothing left to do
+	if _, err := orchestrator.run(ctx); err == nil {
+		Fail(t, "expected an error upgrading to the current version")
+	}
+
+	// Without scheduling, the upgrade must already be scheduled
+	config.SkipSchedule = true
+	config.ArbOSVersion = 40
+	ready.result.MaxArbOSVersionSupported = 40
+	report, err = orchestrator.run(ctx)
+	if err == nil || report.StoppedAt != "schedule" || chain.scheduleCalls != 1 {
+		Fail(t, "expected to stop without a scheduled upgrade", report, err)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// pricingsim simulates ArbOS's L2 and L1 pricing models under a load, so the effect of parameter changes
+// such as the speed limit or pricing inertia can be evaluated before applying them on-chain.
+//
+// The load is either replayed from a chain through its arbdebug_pricingModel RPC method, read from a JSON
+// file of blocks, or generated from the synthetic load flags. The fee trajectory is written as CSV or JSON,
+// and can also be plotted to an SVG file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/pricingsim"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// pricingModelHistory is the part of arbdebug_pricingModel's result the replay needs.
+type pricingModelHistory struct {
+	Step      uint64   `json:"step"`
+	Timestamp []uint64 `json:"timestamp"`
+	GasUsed   []uint64 `json:"gasUsed"`
+}
+
+func run(args []string) error {
+	f := flag.NewFlagSet("pricingsim", flag.ContinueOnError)
+	paramsFile := f.String("params", "", "JSON file of pricing parameters to simulate, which the parameter flags override")
+	speedLimit := f.Uint64("speed-limit", 0, "L2 speed limit in gas per second (0 = ArbOS's initial value)")
+	inertia := f.Uint64("pricing-inertia", 0, "L2 pricing inertia (0 = ArbOS's initial value)")
+	gasTarget := f.Uint64("gas-target-bips", 0, "rate the L2 backlog drains at, in basis points of the speed limit (0 = the speed limit)")
+	tolerance := f.Uint64("backlog-tolerance", 0, "L2 backlog tolerance in seconds of gas at the speed limit (0 = ArbOS's initial value)")
+	minBaseFee := f.Uint64("min-base-fee", 0, "L2 minimum base fee in wei (0 = ArbOS's initial value)")
+
+	url := f.String("url", "", "replay the gas used by a chain's blocks, fetched with the arbdebug_pricingModel RPC method from this node")
+	start := f.Int64("start", 0, "first block to replay")
+	end := f.Int64("end", -1, "last block to replay (-1 = latest)")
+	blocksFile := f.String("blocks", "", "replay the blocks in this JSON file instead")
+
+	seconds := f.Uint64("seconds", 3600, "synthetic load: seconds to simulate")
+	gasPerSecond := f.Uint64("gas-per-second", 5_000_000, "synthetic load: compute gas used per second")
+	burstGas := f.Uint64("burst-gas-per-second", 0, "synthetic load: extra gas used per second during the burst")
+	burstStart := f.Uint64("burst-start", 600, "synthetic load: seconds after the start the burst begins")
+	burstDuration := f.Uint64("burst-duration", 300, "synthetic load: how many seconds the burst lasts")
+	l1UnitsPerSecond := f.Uint64("l1-units-per-second", 0, "L1 calldata units charged per second, also added to each replayed block")
+	batchInterval := f.Uint64("batch-interval", 0, "seconds between simulated batch posting reports (0 = none)")
+	l1BaseFeeGwei := f.Uint64("l1-base-fee", 30, "L1 base fee in gwei that simulated batches are posted at")
+
+	format := f.String("format", "csv", "output format: csv or json")
+	output := f.String("output", "", "file to write the fee trajectory to (defaults to stdout)")
+	plot := f.String("plot", "", "SVG file to plot the fee trajectory to")
+	timeout := f.Duration("timeout", 10*time.Minute, "timeout for fetching blocks to replay")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	simParams := &pricingsim.Params{}
+	if *paramsFile != "" {
+		data, err := os.ReadFile(*paramsFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, simParams); err != nil {
+			return fmt.Errorf("failed to parse %v: %w", *paramsFile, err)
+		}
+	}
+	if *speedLimit != 0 {
+		simParams.SpeedLimitPerSecond = *speedLimit
+	}
+	if *inertia != 0 {
+		simParams.PricingInertia = *inertia
+	}
+	if *gasTarget != 0 {
+		simParams.GasTargetBips = *gasTarget
+	}
+	if *tolerance != 0 {
+		simParams.BacklogTolerance = *tolerance
+	}
+	if *minBaseFee != 0 {
+		simParams.MinBaseFeeWei = new(big.Int).SetUint64(*minBaseFee)
+	}
+	l1BaseFee := new(big.Int).SetUint64(*l1BaseFeeGwei * params.GWei)
+
+	var blocks []pricingsim.Block
+	var err error
+	switch {
+	case *url != "" && *blocksFile != "":
+		return errors.New("only one of --url and --blocks may be set")
+	case *url != "":
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		blocks, err = fetchBlocks(ctx, *url, rpc.BlockNumber(*start), rpc.BlockNumber(*end))
+	case *blocksFile != "":
+		var data []byte
+		data, err = os.ReadFile(*blocksFile)
+		if err == nil {
+			err = json.Unmarshal(data, &blocks)
+		}
+	default:
+		load := &pricingsim.SyntheticLoad{
+			Seconds:           *seconds,
+			GasPerSecond:      *gasPerSecond,
+			L1UnitsPerSecond:  *l1UnitsPerSecond,
+			BurstGasPerSecond: *burstGas,
+			BurstStart:        *burstStart,
+			BurstDuration:     *burstDuration,
+			BatchInterval:     *batchInterval,
+			L1BaseFee:         l1BaseFee,
+		}
+		blocks, err = load.Blocks()
+	}
+	if err != nil {
+		return err
+	}
+	if *url != "" || *blocksFile != "" {
+		addL1Load(blocks, *l1UnitsPerSecond, *batchInterval, l1BaseFee)
+	}
+
+	sim, err := pricingsim.New(simParams)
+	if err != nil {
+		return err
+	}
+	steps, err := sim.Run(blocks)
+	if err != nil {
+		return err
+	}
+
+	if *plot != "" {
+		file, err := os.Create(*plot)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := pricingsim.WriteSVG(file, steps); err != nil {
+			return err
+		}
+	}
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "csv":
+		return pricingsim.WriteCSV(w, steps)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(steps)
+	default:
+		return fmt.Errorf("unknown format %v", *format)
+	}
+}
+
+// fetchBlocks replays a chain's history. Block gas includes the gas paying for L1 calldata, so the
+// backlog is somewhat overestimated. If the node samples every step'th block, each sample stands in
+// for the blocks around it.
+func fetchBlocks(ctx context.Context, url string, start, end rpc.BlockNumber) ([]pricingsim.Block, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	var history pricingModelHistory
+	if err := client.CallContext(ctx, &history, "arbdebug_pricingModel", start, end); err != nil {
+		return nil, err
+	}
+	if len(history.Timestamp) != len(history.GasUsed) {
+		return nil, errors.New("pricing model history has mismatched timestamps and gas used")
+	}
+	if history.Step > 1 {
+		fmt.Fprintf(os.Stderr, "warning: the node sampled every %v blocks, so the replay is approximate\n", history.Step)
+	}
+	blocks := make([]pricingsim.Block, 0, len(history.Timestamp))
+	for i, timestamp := range history.Timestamp {
+		gasUsed := history.GasUsed[i]
+		if history.Step > 1 {
+			gasUsed *= history.Step
+		}
+		blocks = append(blocks, pricingsim.Block{Timestamp: timestamp, ComputeGas: gasUsed})
+	}
+	return blocks, nil
+}
+
+// addL1Load adds L1 calldata units and batch posting reports to replayed blocks.
+func addL1Load(blocks []pricingsim.Block, unitsPerSecond, batchInterval uint64, l1BaseFee *big.Int) {
+	if len(blocks) == 0 {
+		return
+	}
+	lastTimestamp := blocks[0].Timestamp
+	lastBatch := blocks[0].Timestamp
+	var unbatchedUnits uint64
+	for i := range blocks {
+		block := &blocks[i]
+		if unitsPerSecond > 0 && block.Timestamp > lastTimestamp {
+			block.L1Units += unitsPerSecond * (block.Timestamp - lastTimestamp)
+		}
+		lastTimestamp = block.Timestamp
+		if batchInterval > 0 && block.Batch == nil && block.Timestamp >= lastBatch+batchInterval {
+			block.Batch = &pricingsim.BatchReport{
+				Timestamp: block.Timestamp,
+				DataGas:   unbatchedUnits,
+				L1BaseFee: l1BaseFee,
+			}
+			lastBatch = block.Timestamp
+			unbatchedUnits = 0
+		}
+		unbatchedUnits += block.L1Units
+	}
+}
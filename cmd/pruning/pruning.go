@@ -168,6 +168,31 @@ func findImportantRoots(ctx context.Context, chainDb ethdb.Database, stack *node
 		if validatorRequired {
 			return nil, errors.New("refusing to prune to full-node level when validator is enabled (you should prune in validator mode)")
 		}
+	} else if initConfig.Prune == "retention" {
+		if validatorRequired {
+			return nil, errors.New("refusing to prune to a retention window when validator is enabled (you should prune in validator mode)")
+		}
+		headHeader := rawdb.ReadHeadHeader(chainDb)
+		if headHeader == nil {
+			return nil, errors.New("missing L2 head block header")
+		}
+		head := headHeader.Number.Uint64()
+		from := genesisNum
+		if head > genesisNum+initConfig.PruneRetention {
+			from = head - initConfig.PruneRetention
+		}
+		// Keep a state every minRootDistance blocks of the window, the rest can be recreated from them
+		for num := from; num < head; num += minRootDistance {
+			header := rawdb.ReadHeader(chainDb, rawdb.ReadCanonicalHash(chainDb, num), num)
+			if header == nil {
+				log.Warn("missing block in retention window", "blockNum", num)
+				continue
+			}
+			err = roots.addHeader(header, false)
+			if err != nil {
+				return nil, err
+			}
+		}
 	} else if hashListRegex.MatchString(initConfig.Prune) {
 		parts := strings.Split(initConfig.Prune, ",")
 		roots := []common.Hash{genesisHeader.Root}
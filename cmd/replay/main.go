@@ -126,6 +126,30 @@ func (dasReader *PreimageDASReader) ExpirationPolicy(ctx context.Context) (dapro
 	return daprovider.DiscardImmediately, nil
 }
 
+// PreimageExternalDAReader reads batches stored with an external data availability provider from the
+// keccak256 preimages of their data, which the node recorded when it recovered them from the provider.
+type PreimageExternalDAReader struct {
+}
+
+func (r *PreimageExternalDAReader) IsValidHeaderByte(headerByte byte) bool {
+	return daprovider.IsExternalDAMessageHeaderByte(headerByte)
+}
+
+func (r *PreimageExternalDAReader) RecoverPayloadFromBatch(
+	ctx context.Context,
+	batchNum uint64,
+	batchBlockHash common.Hash,
+	sequencerMsg []byte,
+	preimageRecorder daprovider.PreimageRecorder,
+	validateSeqMsg bool,
+) ([]byte, error) {
+	hash, ok := daprovider.ExternalDAPayloadHash(sequencerMsg)
+	if !ok {
+		return nil, nil
+	}
+	return wavmio.ResolveTypedPreimage(arbutil.Keccak256PreimageType, hash)
+}
+
 type BlobPreimageReader struct {
 }
 
@@ -211,14 +235,16 @@ func main() {
 		}
 		return wavmio.ReadInboxMessage(batchNum), nil
 	}
-	readMessage := func(dasEnabled bool) *arbostypes.MessageWithMetadata {
+	// chainConfig is nil while reading the message that initializes ArbOS, which is never in a batch that
+	// needs to know the chain's config to be read.
+	readMessage := func(chainConfig *params.ChainConfig) *arbostypes.MessageWithMetadata {
 		var delayedMessagesRead uint64
 		if lastBlockHeader != nil {
 			delayedMessagesRead = lastBlockHeader.Nonce.Uint64()
 		}
 		var dasReader daprovider.DASReader
 		var dasKeysetFetcher daprovider.DASKeysetFetcher
-		if dasEnabled {
+		if chainConfig != nil && chainConfig.ArbitrumChainParams.DataAvailabilityCommittee {
 			// DAS batch and keysets are all together in the same preimage binary.
 			dasReader = &PreimageDASReader{}
 			dasKeysetFetcher = &PreimageDASReader{}
@@ -232,8 +258,11 @@ func main() {
 		if dasReader != nil {
 			dapReaders = append(dapReaders, daprovider.NewReaderForDAS(dasReader, dasKeysetFetcher))
 		}
+		if arbstate.ExternalDAEnabled(chainConfig) {
+			dapReaders = append(dapReaders, &PreimageExternalDAReader{})
+		}
 		dapReaders = append(dapReaders, daprovider.NewReaderForBlobReader(&BlobPreimageReader{}))
-		inboxMultiplexer := arbstate.NewInboxMultiplexer(backend, delayedMessagesRead, dapReaders, keysetValidationMode)
+		inboxMultiplexer := arbstate.NewInboxMultiplexer(backend, delayedMessagesRead, dapReaders, keysetValidationMode, chainConfig)
 		ctx := context.Background()
 		message, err := inboxMultiplexer.Pop(ctx)
 		if err != nil {
@@ -289,7 +318,7 @@ func main() {
 			}
 		}
 
-		message := readMessage(chainConfig.ArbitrumChainParams.DataAvailabilityCommittee)
+		message := readMessage(chainConfig)
 
 		chainContext := WavmChainContext{}
 		newBlock, _, err = arbos.ProduceBlock(message.Message, message.DelayedMessagesRead, lastBlockHeader, statedb, chainContext, chainConfig, false, core.MessageReplayMode)
@@ -299,7 +328,7 @@ func main() {
 	} else {
 		// Initialize ArbOS with this init message and create the genesis block.
 
-		message := readMessage(false)
+		message := readMessage(nil)
 
 		initMessage, err := message.Message.ParseInitMessage()
 		if err != nil {
@@ -6,45 +6,73 @@ package das
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
 	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/membudget"
 	"github.com/offchainlabs/nitro/util/pretty"
 	flag "github.com/spf13/pflag"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/log"
 )
 
 type CacheConfig struct {
-	Enable   bool `koanf:"enable"`
-	Capacity int  `koanf:"capacity"`
+	Enable   bool          `koanf:"enable"`
+	Capacity int           `koanf:"capacity"`
+	MaxBytes uint64        `koanf:"max-bytes"`
+	TTL      time.Duration `koanf:"ttl"`
 }
 
 var DefaultCacheConfig = CacheConfig{
 	Capacity: 20_000,
+	MaxBytes: 0,
+	TTL:      0,
 }
 
 var TestCacheConfig = CacheConfig{
 	Capacity: 1_000,
+	MaxBytes: 0,
+	TTL:      0,
 }
 
 func CacheConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultCacheConfig.Enable, "Enable local in-memory caching of sequencer batch data")
 	f.Int(prefix+".capacity", DefaultCacheConfig.Capacity, "Maximum number of entries (up to 64KB each) to store in the cache.")
+	f.Uint64(prefix+".max-bytes", DefaultCacheConfig.MaxBytes, "Maximum total size in bytes of the cached entries (0 = limited only by capacity)")
+	f.Duration(prefix+".ttl", DefaultCacheConfig.TTL, "How long an entry is kept in the cache before being re-fetched (0 = forever)")
 }
 
 type CacheStorageService struct {
 	baseStorageService StorageService
-	cache              *lru.Cache[common.Hash, []byte]
+	cache              *containers.Cache[common.Hash, []byte]
+	unregisterBudget   func()
 }
 
 func NewCacheStorageService(cacheConfig CacheConfig, baseStorageService StorageService) *CacheStorageService {
-	return &CacheStorageService{
+	c := &CacheStorageService{
 		baseStorageService: baseStorageService,
-		cache:              lru.NewCache[common.Hash, []byte](cacheConfig.Capacity),
+		cache: containers.NewCache[common.Hash, []byte](containers.CacheOpts[[]byte]{
+			Capacity:      cacheConfig.Capacity,
+			MaxBytes:      cacheConfig.MaxBytes,
+			TTL:           cacheConfig.TTL,
+			SizeOf:        func(value []byte) uint64 { return uint64(len(value)) },
+			MetricsPrefix: "arb/das/cache",
+		}),
 	}
+	// If a memory budget is configured it overrides max-bytes
+	c.unregisterBudget = membudget.Register("das-cache", c, membudget.DefaultConsumerOpts)
+	return c
+}
+
+func (c *CacheStorageService) MemoryUsage() uint64 {
+	return c.cache.Bytes()
+}
+
+func (c *CacheStorageService) SetMemoryLimit(bytes uint64) {
+	c.cache.SetMaxBytes(bytes)
 }
 
 func (c *CacheStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
@@ -79,6 +107,7 @@ func (c *CacheStorageService) Sync(ctx context.Context) error {
 }
 
 func (c *CacheStorageService) Close(ctx context.Context) error {
+	c.unregisterBudget()
 	return c.baseStorageService.Close(ctx)
 }
 
@@ -87,7 +116,7 @@ func (c *CacheStorageService) ExpirationPolicy(ctx context.Context) (daprovider.
 }
 
 func (c *CacheStorageService) String() string {
-	return fmt.Sprintf("CacheStorageService(size:%+v)", len(c.cache.Keys()))
+	return fmt.Sprintf("CacheStorageService(size:%+v)", c.cache.Len())
 }
 
 func (c *CacheStorageService) HealthCheck(ctx context.Context) error {
@@ -45,6 +45,7 @@ type DataAvailabilityConfig struct {
 	LocalFileStorage   LocalFileStorageConfig          `koanf:"local-file-storage"`
 	S3Storage          S3StorageServiceConfig          `koanf:"s3-storage"`
 	GoogleCloudStorage GoogleCloudStorageServiceConfig `koanf:"google-cloud-storage"`
+	RedundantStorage   ErasureCodedStorageConfig       `koanf:"redundant-storage"`
 
 	MigrateLocalDBToFileStorage bool `koanf:"migrate-local-db-to-file-storage"`
 
@@ -69,6 +70,7 @@ var DefaultDataAvailabilityConfig = DataAvailabilityConfig{
 	RPCAggregator:                 DefaultAggregatorConfig,
 	ParentChainConnectionAttempts: 15,
 	PanicOnError:                  false,
+	RedundantStorage:              DefaultErasureCodedStorageConfig,
 }
 
 func OptionalAddressFromString(s string) (*common.Address, error) {
@@ -116,6 +118,7 @@ func dataAvailabilityConfigAddOptions(prefix string, f *flag.FlagSet, r role) {
 		LocalFileStorageConfigAddOptions(prefix+".local-file-storage", f)
 		S3ConfigAddOptions(prefix+".s3-storage", f)
 		GoogleCloudConfigAddOptions(prefix+".google-cloud-storage", f)
+		ErasureCodedStorageConfigAddOptions(prefix+".redundant-storage", f)
 		f.Bool(prefix+".migrate-local-db-to-file-storage", DefaultDataAvailabilityConfig.MigrateLocalDBToFileStorage, "daserver will migrate all data on startup from local-db-storage to local-file-storage, then mark local-db-storage as unusable")
 
 		// Key config for storage
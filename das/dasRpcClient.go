@@ -46,8 +46,13 @@ func nilSigner(_ []byte) ([]byte, error) {
 
 const sendChunkJSONBoilerplate = "{\"jsonrpc\":\"2.0\",\"id\":4294967295,\"method\":\"das_sendChunked\",\"params\":[\"\"]}"
 
-func NewDASRPCClient(target string, signer signature.DataSignerFunc, maxStoreChunkBodySize int) (*DASRPCClient, error) {
-	clnt, err := rpc.Dial(target)
+// NewDASRPCClient dials a DAS RPC server, presenting authToken as a bearer token if it's set.
+func NewDASRPCClient(target string, signer signature.DataSignerFunc, maxStoreChunkBodySize int, authToken string) (*DASRPCClient, error) {
+	var options []rpc.ClientOption
+	if authToken != "" {
+		options = append(options, rpc.WithHeader("Authorization", "Bearer "+authToken))
+	}
+	clnt, err := rpc.DialOptions(context.Background(), target, options...)
 	if err != nil {
 		return nil, err
 	}
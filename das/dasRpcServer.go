@@ -23,6 +23,7 @@ import (
 	"github.com/offchainlabs/nitro/blsSignatures"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 )
 
 var (
@@ -46,15 +47,19 @@ type DASRPCServer struct {
 	batches *batchBuilder
 }
 
-func StartDASRPCServer(ctx context.Context, addr string, portNum uint64, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, rpcServerBodyLimit int, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker, signatureVerifier *SignatureVerifier) (*http.Server, error) {
+func StartDASRPCServer(ctx context.Context, addr string, portNum uint64, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, rpcServerSecurity *genericconf.HTTPServerSecurityConfig, rpcServerBodyLimit int, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker, signatureVerifier *SignatureVerifier) (*http.Server, error) {
 	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", addr, portNum))
 	if err != nil {
 		return nil, err
 	}
-	return StartDASRPCServerOnListener(ctx, listener, rpcServerTimeouts, rpcServerBodyLimit, daReader, daWriter, daHealthChecker, signatureVerifier)
+	return startDASRPCServerOnListener(ctx, listener, rpcServerTimeouts, rpcServerSecurity, rpcServerBodyLimit, daReader, daWriter, daHealthChecker, signatureVerifier)
 }
 
 func StartDASRPCServerOnListener(ctx context.Context, listener net.Listener, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, rpcServerBodyLimit int, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker, signatureVerifier *SignatureVerifier) (*http.Server, error) {
+	return startDASRPCServerOnListener(ctx, listener, rpcServerTimeouts, nil, rpcServerBodyLimit, daReader, daWriter, daHealthChecker, signatureVerifier)
+}
+
+func startDASRPCServerOnListener(ctx context.Context, listener net.Listener, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, rpcServerSecurity *genericconf.HTTPServerSecurityConfig, rpcServerBodyLimit int, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker, signatureVerifier *SignatureVerifier) (*http.Server, error) {
 	if daWriter == nil {
 		return nil, errors.New("No writer backend was configured for DAS RPC server. Has the BLS signing key been set up (--data-availability.key.key-dir or --data-availability.key.priv-key options)?")
 	}
@@ -77,16 +82,13 @@ func StartDASRPCServerOnListener(ctx context.Context, listener net.Listener, rpc
 		return nil, err
 	}
 
-	srv := &http.Server{
-		Handler:           rpcServer,
-		ReadTimeout:       rpcServerTimeouts.ReadTimeout,
-		ReadHeaderTimeout: rpcServerTimeouts.ReadHeaderTimeout,
-		WriteTimeout:      rpcServerTimeouts.WriteTimeout,
-		IdleTimeout:       rpcServerTimeouts.IdleTimeout,
+	srv, err := genericconf.NewHTTPServer(rpcServer, rpcServerTimeouts, rpcServerSecurity)
+	if err != nil {
+		return nil, err
 	}
 
 	go func() {
-		err := srv.Serve(listener)
+		err := genericconf.ServeHTTPServer(srv, listener)
 		if err != nil {
 			return
 		}
@@ -128,7 +130,7 @@ func (s *DASRPCServer) Store(ctx context.Context, message hexutil.Bytes, timeout
 
 	cert, err := s.daWriter.Store(ctx, message, uint64(timeout))
 	if err != nil {
-		return nil, err
+		return nil, rpcerrors.Wrap(rpcerrors.CodeDAUnavailable, err)
 	}
 	rpcStoreStoredBytesGauge.Inc(int64(len(message)))
 	success = true
@@ -338,7 +340,7 @@ func (s *DASRPCServer) CommitChunkedStore(ctx context.Context, batchId hexutil.U
 		rpcStoreDurationHistogram.Update(time.Since(startTime).Nanoseconds())
 	}()
 	if err != nil {
-		return nil, err
+		return nil, rpcerrors.Wrap(rpcerrors.CodeDAUnavailable, err)
 	}
 	rpcStoreStoredBytesGauge.Inc(int64(len(message)))
 	success = true
@@ -353,7 +355,7 @@ func (s *DASRPCServer) CommitChunkedStore(ctx context.Context, batchId hexutil.U
 }
 
 func (serv *DASRPCServer) HealthCheck(ctx context.Context) error {
-	return serv.daHealthChecker.HealthCheck(ctx)
+	return rpcerrors.Wrap(rpcerrors.CodeDAUnavailable, serv.daHealthChecker.HealthCheck(ctx))
 }
 
 func (serv *DASRPCServer) ExpirationPolicy(ctx context.Context) (string, error) {
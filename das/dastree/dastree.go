@@ -229,3 +229,119 @@ func Content(root bytes32, oracle func(bytes32) ([]byte, error)) ([]byte, error)
 	}
 	return preimage, nil
 }
+
+// Bin reads the bin that sample selects, modulo the number of bins, from the tree under root, checking each
+// preimage the oracle returns against its hash and the tree's size data. This lets a bin be checked against the
+// root without the rest of the tree's bins. Degenerate single-leaf trees have one bin, which may exceed BinSize.
+// Returns the bin and its index.
+func Bin(root bytes32, sample uint64, oracle func(bytes32) ([]byte, error)) ([]byte, uint64, error) {
+	fetch := func(hash bytes32) ([]byte, error) {
+		data, err := oracle(hash)
+		if err != nil {
+			return nil, err
+		}
+		if crypto.Keccak256Hash(data) != hash {
+			return nil, fmt.Errorf("preimage doesn't match hash %v", hash)
+		}
+		return data, nil
+	}
+	isLeaf := func(data []byte) bool { return len(data) == 33 && data[0] == LeafByte }
+	isNode := func(data []byte) bool { return len(data) == 69 && data[0] == NodeByte }
+
+	data, err := fetch(arbmath.FlipBit(root, 0))
+	if err != nil {
+		return nil, 0, err
+	}
+	if isLeaf(data) {
+		bin, err := fetch(common.BytesToHash(data[1:]))
+		return bin, 0, err
+	}
+	if !isNode(data) {
+		return nil, 0, fmt.Errorf("unexpected root preimage: %v", data)
+	}
+	size := binary.BigEndian.Uint32(data[65:])
+	bins := (uint64(size) + BinSize - 1) / BinSize
+	if bins == 0 {
+		return nil, 0, fmt.Errorf("invalid size data for root %v", root)
+	}
+	index := sample % bins
+	offset := index * BinSize
+	for {
+		// #nosec G115
+		halfPower := uint32(arbmath.NextOrCurrentPowerOf2(uint64(size)) / 2)
+		child, childSize := common.BytesToHash(data[1:33]), halfPower
+		if offset >= uint64(halfPower) {
+			child, childSize = common.BytesToHash(data[33:65]), size-halfPower
+			offset -= uint64(halfPower)
+		}
+		data, err = fetch(child)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch {
+		case isLeaf(data):
+			bin, err := fetch(common.BytesToHash(data[1:]))
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(bin) != int(childSize) || childSize > BinSize {
+				return nil, 0, fmt.Errorf("bin %v is incorrectly sized: %v vs %v", index, len(bin), childSize)
+			}
+			return bin, index, nil
+		case isNode(data):
+			if count := binary.BigEndian.Uint32(data[65:]); count != childSize {
+				return nil, 0, fmt.Errorf("invalid size data: %v vs %v for %v", count, childSize, data)
+			}
+			size = childSize
+		default:
+			return nil, 0, fmt.Errorf("failed to resolve preimage %v %v", child, data)
+		}
+	}
+}
+
+// BinProof returns the bin that sample selects from a preimage committed to by root, along with the preimages
+// of the nodes leading to it, which VerifyBinProof checks against the root.
+func BinProof(root bytes32, preimage []byte, sample uint64) ([]byte, [][]byte, error) {
+	preimages := make(map[bytes32][]byte)
+	record := func(hash bytes32, value []byte, _ arbutil.PreimageType) {
+		preimages[hash] = value
+	}
+	if RecordHash(record, preimage) != root {
+		// The preimage may be committed to by a degenerate single-leaf tree
+		flat := crypto.Keccak256Hash(preimage)
+		if FlatHashToTreeHash(flat) != root {
+			return nil, nil, fmt.Errorf("preimage isn't under root %v", root)
+		}
+		leaf := FlatHashToTreeLeaf(flat)
+		preimages = map[bytes32][]byte{flat: preimage, crypto.Keccak256Hash(leaf): leaf}
+	}
+	var proof [][]byte
+	bin, _, err := Bin(root, sample, func(hash bytes32) ([]byte, error) {
+		data, ok := preimages[hash]
+		if !ok {
+			return nil, fmt.Errorf("no preimage for %v", hash)
+		}
+		proof = append(proof, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return bin, proof[:len(proof)-1], nil
+}
+
+// VerifyBinProof checks that a bin is the one sample selects from the tree under root, returning its index.
+func VerifyBinProof(root bytes32, sample uint64, bin []byte, proof [][]byte) (uint64, error) {
+	preimages := map[bytes32][]byte{crypto.Keccak256Hash(bin): bin}
+	for _, data := range proof {
+		preimages[crypto.Keccak256Hash(data)] = data
+	}
+	_, index, err := Bin(root, sample, func(hash bytes32) ([]byte, error) {
+		data, ok := preimages[hash]
+		if !ok {
+			return nil, fmt.Errorf("proof is missing the preimage of %v", hash)
+		}
+		return data, nil
+	})
+	return index, err
+}
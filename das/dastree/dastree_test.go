@@ -62,6 +62,57 @@ func TestDASTree(t *testing.T) {
 	}
 }
 
+func TestBinProof(t *testing.T) {
+	tests := [][]byte{{}, {0x32}, testhelpers.RandomSlice(BinSize), testhelpers.RandomSlice(2*BinSize + 7), testhelpers.RandomSlice(5 * BinSize)}
+	for i := 0; i < 16; i++ {
+		tests = append(tests, testhelpers.RandomSlice(uint64(rand.Intn(12*BinSize))))
+	}
+	for _, test := range tests {
+		root := Hash(test)
+		bins := uint64(len(test)+BinSize-1) / BinSize
+		if bins == 0 {
+			bins = 1
+		}
+		for sample := uint64(0); sample < 2*bins; sample++ {
+			bin, proof, err := BinProof(root, test, sample)
+			Require(t, err)
+			index, err := VerifyBinProof(root, sample, bin, proof)
+			Require(t, err)
+			if index != sample%bins {
+				Fail(t, "wrong bin index", index, sample, bins)
+			}
+			start := index * BinSize
+			if !bytes.Equal(bin, test[start:start+uint64(len(bin))]) {
+				Fail(t, "wrong bin", index)
+			}
+			if bins > 1 {
+				if _, err := VerifyBinProof(root, sample+1, bin, proof); err == nil {
+					Fail(t, "verified a bin for another sample")
+				}
+			}
+			if len(bin) > 0 {
+				tampered := bytes.Clone(bin)
+				tampered[0] ^= 1
+				if _, err := VerifyBinProof(root, sample, tampered, proof); err == nil {
+					Fail(t, "verified a tampered bin")
+				}
+			}
+		}
+	}
+
+	// Degenerate single-leaf trees have one bin of any size
+	large := make([]byte, 3*BinSize)
+	root := FlatHashToTreeHash(crypto.Keccak256Hash(large))
+	bin, proof, err := BinProof(root, large, 7)
+	Require(t, err)
+	if _, err := VerifyBinProof(root, 7, bin, proof); err != nil || !bytes.Equal(bin, large) {
+		Fail(t, "failed to prove a degenerate tree's bin", err)
+	}
+	if _, _, err := BinProof(Hash([]byte{1}), large, 0); err == nil {
+		Fail(t, "proved a preimage under another root")
+	}
+}
+
 func Require(t *testing.T, err error, printables ...interface{}) {
 	t.Helper()
 	testhelpers.RequireImpl(t, err, printables...)
@@ -0,0 +1,241 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package erasure is a systematic Reed-Solomon code over GF(2^8). Data is split into data shards and
+// extended with parity shards, so that any data shards' worth of the shards recover it.
+package erasure
+
+import (
+	"errors"
+	"fmt"
+)
+
+const MaxShards = 256
+
+// The field is GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1, with generator 2.
+var expTable [510]byte
+var logTable [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(x)
+		expTable[i+255] = byte(x)
+		logTable[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func inv(a byte) byte {
+	return expTable[255-int(logTable[a])]
+}
+
+func pow(a byte, n int) byte {
+	result := byte(1)
+	for i := 0; i < n; i++ {
+		result = mul(result, a)
+	}
+	return result
+}
+
+type matrix [][]byte
+
+func newMatrix(rows, cols int) matrix {
+	m := make(matrix, rows)
+	for i := range m {
+		m[i] = make([]byte, cols)
+	}
+	return m
+}
+
+func (m matrix) mul(other matrix) matrix {
+	result := newMatrix(len(m), len(other[0]))
+	for r := range m {
+		for c := range other[0] {
+			var sum byte
+			for i := range other {
+				sum ^= mul(m[r][i], other[i][c])
+			}
+			result[r][c] = sum
+		}
+	}
+	return result
+}
+
+// invert returns the inverse of a square matrix by Gauss-Jordan elimination.
+func (m matrix) invert() (matrix, error) {
+	size := len(m)
+	work := newMatrix(size, 2*size)
+	for r := range m {
+		copy(work[r], m[r])
+		work[r][size+r] = 1
+	}
+	for c := 0; c < size; c++ {
+		pivot := c
+		for pivot < size && work[pivot][c] == 0 {
+			pivot++
+		}
+		if pivot == size {
+			return nil, errors.New("singular matrix")
+		}
+		work[c], work[pivot] = work[pivot], work[c]
+		scale := inv(work[c][c])
+		for i := range work[c] {
+			work[c][i] = mul(work[c][i], scale)
+		}
+		for r := 0; r < size; r++ {
+			if r == c || work[r][c] == 0 {
+				continue
+			}
+			factor := work[r][c]
+			for i := range work[r] {
+				work[r][i] ^= mul(factor, work[c][i])
+			}
+		}
+	}
+	result := newMatrix(size, size)
+	for r := range result {
+		copy(result[r], work[r][size:])
+	}
+	return result, nil
+}
+
+// Coder splits data into shards and joins it back together.
+type Coder struct {
+	dataShards   int
+	parityShards int
+	// Row i gives shard i as a combination of the data shards, the first rows being the identity
+	encoding matrix
+}
+
+func New(dataShards, parityShards int) (*Coder, error) {
+	if dataShards < 1 || parityShards < 0 {
+		return nil, errors.New("need at least one data shard and no negative parity shards")
+	}
+	total := dataShards + parityShards
+	if total > MaxShards {
+		return nil, fmt.Errorf("at most %d shards are supported but got %d", MaxShards, total)
+	}
+	// Any rows of a Vandermonde matrix with distinct elements are independent,
+	// and stay so when it's multiplied to make its top square the identity.
+	vandermonde := newMatrix(total, dataShards)
+	for r := range vandermonde {
+		for c := range vandermonde[r] {
+			vandermonde[r][c] = pow(byte(r), c)
+		}
+	}
+	top, err := vandermonde[:dataShards].invert()
+	if err != nil {
+		return nil, err
+	}
+	return &Coder{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		encoding:     vandermonde.mul(top),
+	}, nil
+}
+
+func (c *Coder) DataShards() int {
+	return c.dataShards
+}
+
+func (c *Coder) TotalShards() int {
+	return c.dataShards + c.parityShards
+}
+
+// ShardSize is the size of each shard of data of the given size.
+func (c *Coder) ShardSize(size int) int {
+	shardSize := (size + c.dataShards - 1) / c.dataShards
+	if shardSize == 0 {
+		return 1
+	}
+	return shardSize
+}
+
+// Split returns the data shards, the data padded with zeros, followed by the parity shards.
+func (c *Coder) Split(data []byte) [][]byte {
+	shardSize := c.ShardSize(len(data))
+	padded := make([]byte, shardSize*c.TotalShards())
+	copy(padded, data)
+	shards := make([][]byte, c.TotalShards())
+	for i := range shards {
+		shards[i] = padded[i*shardSize : (i+1)*shardSize]
+	}
+	for p := c.dataShards; p < c.TotalShards(); p++ {
+		row := c.encoding[p]
+		for d := 0; d < c.dataShards; d++ {
+			factor := row[d]
+			if factor == 0 {
+				continue
+			}
+			for i, b := range shards[d] {
+				shards[p][i] ^= mul(factor, b)
+			}
+		}
+	}
+	return shards
+}
+
+// Join recovers data of the given size from its shards, where missing shards are nil.
+func (c *Coder) Join(shards [][]byte, size int) ([]byte, error) {
+	if len(shards) != c.TotalShards() {
+		return nil, fmt.Errorf("expected %d shards but got %d", c.TotalShards(), len(shards))
+	}
+	shardSize := c.ShardSize(size)
+	var present []int
+	for i, shard := range shards {
+		if shard == nil {
+			continue
+		}
+		if len(shard) != shardSize {
+			return nil, fmt.Errorf("shard %d has size %d but expected %d", i, len(shard), shardSize)
+		}
+		present = append(present, i)
+		if len(present) == c.dataShards {
+			break
+		}
+	}
+	if len(present) < c.dataShards {
+		return nil, fmt.Errorf("need %d shards to recover the data but only have %d", c.dataShards, len(present))
+	}
+
+	data := make([]byte, shardSize*c.dataShards)
+	systematic := present[c.dataShards-1] == c.dataShards-1
+	if systematic {
+		for d := 0; d < c.dataShards; d++ {
+			copy(data[d*shardSize:], shards[d])
+		}
+		return data[:size], nil
+	}
+	sub := make(matrix, c.dataShards)
+	for i, index := range present {
+		sub[i] = c.encoding[index]
+	}
+	decoding, err := sub.invert()
+	if err != nil {
+		return nil, err
+	}
+	for d := 0; d < c.dataShards; d++ {
+		out := data[d*shardSize : (d+1)*shardSize]
+		for i, index := range present {
+			factor := decoding[d][i]
+			if factor == 0 {
+				continue
+			}
+			for j, b := range shards[index] {
+				out[j] ^= mul(factor, b)
+			}
+		}
+	}
+	return data[:size], nil
+}
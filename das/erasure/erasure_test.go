@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package erasure
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestSplitJoin(t *testing.T) {
+	coder, err := New(4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, 5, 100, 4096, 10_001} {
+		data := make([]byte, size)
+		rand.Read(data)
+		shards := coder.Split(data)
+		if len(shards) != 7 {
+			t.Fatal("expected 7 shards but got", len(shards))
+		}
+		if !bytes.Equal(bytes.Join(shards[:4], nil)[:size], data) {
+			t.Fatal("data shards aren't the data")
+		}
+
+		// Every way of losing 3 shards
+		for a := 0; a < 7; a++ {
+			for b := a + 1; b < 7; b++ {
+				for c := b + 1; c < 7; c++ {
+					damaged := append([][]byte{}, shards...)
+					damaged[a], damaged[b], damaged[c] = nil, nil, nil
+					joined, err := coder.Join(damaged, size)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(joined, data) {
+						t.Fatal("wrong data recovered without shards", a, b, c)
+					}
+				}
+			}
+		}
+
+		damaged := append([][]byte{}, shards...)
+		damaged[0], damaged[2], damaged[4], damaged[6] = nil, nil, nil, nil
+		if _, err := coder.Join(damaged, size); err == nil {
+			t.Fatal("recovered data with too few shards")
+		}
+	}
+}
+
+func TestNewLimits(t *testing.T) {
+	if _, err := New(0, 2); err == nil {
+		t.Fatal("created a coder without data shards")
+	}
+	if _, err := New(200, 57); err == nil {
+		t.Fatal("created a coder with too many shards")
+	}
+	if _, err := New(200, 56); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/das/erasure"
+	"github.com/offchainlabs/nitro/util/pretty"
+)
+
+var (
+	erasureShardWriteFailureCounter = metrics.NewRegisteredCounter("arb/das/erasure/shard/write/failures", nil)
+	erasureShardReadFailureCounter  = metrics.NewRegisteredCounter("arb/das/erasure/shard/read/failures", nil)
+	erasureReconstructedCounter     = metrics.NewRegisteredCounter("arb/das/erasure/reconstructed", nil)
+)
+
+type ErasureCodedStorageConfig struct {
+	Enable       bool                   `koanf:"enable"`
+	DataShards   int                    `koanf:"data-shards"`
+	ParityShards int                    `koanf:"parity-shards"`
+	WriteQuorum  int                    `koanf:"write-quorum"`
+	LocalDirs    []string               `koanf:"local-dirs"`
+	S3Buckets    []string               `koanf:"s3-buckets"`
+	S3           S3StorageServiceConfig `koanf:"s3"`
+}
+
+var DefaultErasureCodedStorageConfig = ErasureCodedStorageConfig{
+	Enable:       false,
+	DataShards:   2,
+	ParityShards: 1,
+	WriteQuorum:  0,
+	LocalDirs:    []string{},
+	S3Buckets:    []string{},
+}
+
+func ErasureCodedStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultErasureCodedStorageConfig.Enable, "enable storage/retrieval of sequencer batch data split with Reed-Solomon coding across several backends, one shard per backend")
+	f.Int(prefix+".data-shards", DefaultErasureCodedStorageConfig.DataShards, "number of shards the data is split into, any this many of the shards recover it")
+	f.Int(prefix+".parity-shards", DefaultErasureCodedStorageConfig.ParityShards, "number of parity shards, which is how many backends can be unavailable")
+	f.Int(prefix+".write-quorum", DefaultErasureCodedStorageConfig.WriteQuorum, "number of shards that must be stored for a store to succeed (0 = all of them)")
+	f.StringSlice(prefix+".local-dirs", DefaultErasureCodedStorageConfig.LocalDirs, "local data directories, each one a backend")
+	f.StringSlice(prefix+".s3-buckets", DefaultErasureCodedStorageConfig.S3Buckets, "S3 buckets, each one a backend, after the local directories; shards are assigned to backends in order, so backends must not be reordered")
+	f.String(prefix+".s3.access-key", DefaultErasureCodedStorageConfig.S3.AccessKey, "S3 access key for the buckets")
+	f.String(prefix+".s3.object-prefix", DefaultErasureCodedStorageConfig.S3.ObjectPrefix, "prefix to add to S3 objects")
+	f.String(prefix+".s3.region", DefaultErasureCodedStorageConfig.S3.Region, "S3 region of the buckets")
+	f.String(prefix+".s3.secret-key", DefaultErasureCodedStorageConfig.S3.SecretKey, "S3 secret key for the buckets")
+	f.Bool(prefix+".s3.discard-after-timeout", DefaultErasureCodedStorageConfig.S3.DiscardAfterTimeout, "discard data after its expiry timeout")
+}
+
+func (c *ErasureCodedStorageConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.DataShards < 1 || c.ParityShards < 0 {
+		return errors.New("redundant-storage needs at least one data shard and no negative parity shards")
+	}
+	backends := len(c.LocalDirs) + len(c.S3Buckets)
+	if backends != c.DataShards+c.ParityShards {
+		return fmt.Errorf("redundant-storage has %d backends but needs one for each of its %d shards", backends, c.DataShards+c.ParityShards)
+	}
+	if c.WriteQuorum != 0 && (c.WriteQuorum < c.DataShards || c.WriteQuorum > backends) {
+		return fmt.Errorf("redundant-storage write-quorum must be between data-shards %d and the number of backends %d", c.DataShards, backends)
+	}
+	return nil
+}
+
+// keyedStorageService is a StorageService that can store data under a key other than its hash.
+type keyedStorageService interface {
+	StorageService
+	putWithKey(ctx context.Context, key common.Hash, data []byte, expiry uint64) error
+}
+
+// Each shard is stored under the data's key, after a header identifying it:
+// version, data shards, total shards, shard index (uint16 each) and the data's size (uint64).
+const (
+	erasureShardVersion    = 1
+	erasureShardHeaderSize = 16
+)
+
+// ErasureCodedStorageService splits data with Reed-Solomon coding across its backends, one shard each, so
+// that it can be read while up to the number of parity shards of the backends are unavailable. This costs
+// (data + parity) / data times the data's size, rather than a full copy per backend like RedundantStorageService.
+type ErasureCodedStorageService struct {
+	coder       *erasure.Coder
+	backends    []keyedStorageService
+	writeQuorum int
+}
+
+func NewErasureCodedStorageService(dataShards, parityShards, writeQuorum int, backends []keyedStorageService) (*ErasureCodedStorageService, error) {
+	coder, err := erasure.New(dataShards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+	if len(backends) != coder.TotalShards() {
+		return nil, fmt.Errorf("%d backends for %d shards", len(backends), coder.TotalShards())
+	}
+	if writeQuorum == 0 {
+		writeQuorum = len(backends)
+	}
+	return &ErasureCodedStorageService{
+		coder:       coder,
+		backends:    backends,
+		writeQuorum: writeQuorum,
+	}, nil
+}
+
+func (e *ErasureCodedStorageService) encodeShard(index int, size int, shard []byte) []byte {
+	encoded := make([]byte, erasureShardHeaderSize+len(shard))
+	binary.BigEndian.PutUint16(encoded[0:], erasureShardVersion)
+	// #nosec G115
+	binary.BigEndian.PutUint16(encoded[2:], uint16(e.coder.DataShards()))
+	// #nosec G115
+	binary.BigEndian.PutUint16(encoded[4:], uint16(e.coder.TotalShards()))
+	// #nosec G115
+	binary.BigEndian.PutUint16(encoded[6:], uint16(index))
+	// #nosec G115
+	binary.BigEndian.PutUint64(encoded[8:], uint64(size))
+	copy(encoded[erasureShardHeaderSize:], shard)
+	return encoded
+}
+
+// decodeShard checks a stored shard is the expected one of this service's shards, returning it and the data's size.
+func (e *ErasureCodedStorageService) decodeShard(index int, encoded []byte) ([]byte, int, error) {
+	if len(encoded) < erasureShardHeaderSize {
+		return nil, 0, errors.New("shard is too short")
+	}
+	version := binary.BigEndian.Uint16(encoded[0:])
+	dataShards := int(binary.BigEndian.Uint16(encoded[2:]))
+	totalShards := int(binary.BigEndian.Uint16(encoded[4:]))
+	storedIndex := int(binary.BigEndian.Uint16(encoded[6:]))
+	size := binary.BigEndian.Uint64(encoded[8:])
+	if version != erasureShardVersion {
+		return nil, 0, fmt.Errorf("unknown shard version %d", version)
+	}
+	if dataShards != e.coder.DataShards() || totalShards != e.coder.TotalShards() || storedIndex != index {
+		return nil, 0, fmt.Errorf("shard %d of %d/%d stored where shard %d of %d/%d is expected", storedIndex, dataShards, totalShards, index, e.coder.DataShards(), e.coder.TotalShards())
+	}
+	shard := encoded[erasureShardHeaderSize:]
+	// #nosec G115
+	if size > uint64(len(shard)*e.coder.DataShards()) {
+		return nil, 0, errors.New("shard is too short for the data's size")
+	}
+	// #nosec G115
+	return shard, int(size), nil
+}
+
+type erasureShardResponse struct {
+	index int
+	shard []byte
+	size  int
+	err   error
+}
+
+func (e *ErasureCodedStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.ErasureCodedStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", e)
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	responses := make(chan erasureShardResponse, len(e.backends))
+	for i, backend := range e.backends {
+		go func(i int, backend StorageService) {
+			encoded, err := backend.GetByHash(subCtx, key)
+			if err != nil {
+				responses <- erasureShardResponse{index: i, err: err}
+				return
+			}
+			shard, size, err := e.decodeShard(i, encoded)
+			responses <- erasureShardResponse{i, shard, size, err}
+		}(i, backend)
+	}
+
+	shards := make([][]byte, len(e.backends))
+	found := 0
+	size := -1
+	var anyError error
+	for received := 0; received < len(e.backends); received++ {
+		var response erasureShardResponse
+		select {
+		case response = <-responses:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if response.err == nil && size >= 0 && response.size != size {
+			response.err = fmt.Errorf("shard %d is of data of size %d but others are of size %d", response.index, response.size, size)
+		}
+		if response.err != nil {
+			if !errors.Is(response.err, ErrNotFound) {
+				erasureShardReadFailureCounter.Inc(1)
+				log.Warn("failed to read erasure coded shard", "key", pretty.PrettyHash(key), "shard", response.index, "backend", e.backends[response.index], "err", response.err)
+			}
+			anyError = response.err
+			continue
+		}
+		size = response.size
+		shards[response.index] = response.shard
+		found++
+		if found < e.coder.DataShards() {
+			continue
+		}
+		data, err := e.coder.Join(shards, size)
+		if err != nil {
+			return nil, err
+		}
+		if !dastree.ValidHash(key, data) {
+			// A corrupt shard, which the remaining shards can't tell apart from the others
+			return nil, fmt.Errorf("data recovered from shards doesn't match its hash %v", key)
+		}
+		for _, shard := range shards[:e.coder.DataShards()] {
+			if shard == nil {
+				erasureReconstructedCounter.Inc(1)
+				break
+			}
+		}
+		return data, nil
+	}
+	if found == 0 && errors.Is(anyError, ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("only found %d of the %d shards needed, last error: %w", found, e.coder.DataShards(), anyError)
+}
+
+func (e *ErasureCodedStorageService) Put(ctx context.Context, data []byte, expiry uint64) error {
+	logPut("das.ErasureCodedStorageService.Store", data, expiry, e)
+	key := dastree.Hash(data)
+	shards := e.coder.Split(data)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	stored := 0
+	var anyError error
+	for i, backend := range e.backends {
+		wg.Add(1)
+		go func(i int, backend keyedStorageService) {
+			defer wg.Done()
+			err := backend.putWithKey(ctx, key, e.encodeShard(i, len(data), shards[i]), expiry)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				erasureShardWriteFailureCounter.Inc(1)
+				log.Warn("failed to store erasure coded shard", "key", pretty.PrettyHash(key), "shard", i, "backend", backend, "err", err)
+				anyError = err
+				return
+			}
+			stored++
+		}(i, backend)
+	}
+	wg.Wait()
+	if stored < e.writeQuorum {
+		return fmt.Errorf("stored %d shards but %d are required, last error: %w", stored, e.writeQuorum, anyError)
+	}
+	return nil
+}
+
+func (e *ErasureCodedStorageService) forEachBackend(f func(StorageService) error) error {
+	var wg sync.WaitGroup
+	var errorMutex sync.Mutex
+	var anyError error
+	for _, backend := range e.backends {
+		wg.Add(1)
+		go func(s StorageService) {
+			defer wg.Done()
+			if err := f(s); err != nil {
+				errorMutex.Lock()
+				anyError = err
+				errorMutex.Unlock()
+			}
+		}(backend)
+	}
+	wg.Wait()
+	return anyError
+}
+
+func (e *ErasureCodedStorageService) Sync(ctx context.Context) error {
+	return e.forEachBackend(func(s StorageService) error { return s.Sync(ctx) })
+}
+
+func (e *ErasureCodedStorageService) Close(ctx context.Context) error {
+	return e.forEachBackend(func(s StorageService) error { return s.Close(ctx) })
+}
+
+// ExpirationPolicy is the shortest of the backends', as data is lost once the parity can't make up for them.
+func (e *ErasureCodedStorageService) ExpirationPolicy(ctx context.Context) (daprovider.ExpirationPolicy, error) {
+	res := daprovider.KeepForever
+	for _, backend := range e.backends {
+		expirationPolicy, err := backend.ExpirationPolicy(ctx)
+		if err != nil {
+			return -1, err
+		}
+		switch expirationPolicy {
+		case daprovider.KeepForever:
+		case daprovider.DiscardAfterArchiveTimeout:
+			if res == daprovider.KeepForever {
+				res = daprovider.DiscardAfterArchiveTimeout
+			}
+		case daprovider.DiscardAfterDataTimeout:
+			res = daprovider.DiscardAfterDataTimeout
+		default:
+			return -1, errors.New("unknown expiration policy")
+		}
+	}
+	return res, nil
+}
+
+func (e *ErasureCodedStorageService) String() string {
+	backends := make([]string, len(e.backends))
+	for i, backend := range e.backends {
+		backends[i] = backend.String()
+	}
+	return fmt.Sprintf("ErasureCodedStorageService(%d+%d,%s)", e.coder.DataShards(), e.coder.TotalShards()-e.coder.DataShards(), strings.Join(backends, ","))
+}
+
+// HealthCheck fails if too few backends are healthy to meet the write quorum.
+func (e *ErasureCodedStorageService) HealthCheck(ctx context.Context) error {
+	var mutex sync.Mutex
+	healthy := 0
+	var anyError error
+	_ = e.forEachBackend(func(s StorageService) error {
+		err := s.HealthCheck(ctx)
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			anyError = err
+		} else {
+			healthy++
+		}
+		return nil
+	})
+	if healthy < e.writeQuorum {
+		return fmt.Errorf("%d backends are healthy but %d are required, last error: %w", healthy, e.writeQuorum, anyError)
+	}
+	return nil
+}
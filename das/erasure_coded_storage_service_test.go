@@ -0,0 +1,110 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func newErasureCodedTestService(t *testing.T, dataShards, parityShards, writeQuorum int) (*ErasureCodedStorageService, []*MemoryBackedStorageService) {
+	ctx := context.Background()
+	var backends []keyedStorageService
+	var memories []*MemoryBackedStorageService
+	for i := 0; i < dataShards+parityShards; i++ {
+		memory := NewMemoryBackedStorageService(ctx).(*MemoryBackedStorageService)
+		backends = append(backends, memory)
+		memories = append(memories, memory)
+	}
+	service, err := NewErasureCodedStorageService(dataShards, parityShards, writeQuorum, backends)
+	Require(t, err)
+	return service, memories
+}
+
+func TestErasureCodedStorageService(t *testing.T) {
+	ctx := context.Background()
+	// #nosec G115
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	service, backends := newErasureCodedTestService(t, 3, 2, 0)
+
+	val := []byte("The value split across the backends, which each get a shard of it")
+	key := dastree.Hash(val)
+	_, err := service.GetByHash(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	Require(t, service.Put(ctx, val, timeout))
+	got, err := service.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(got, val) {
+		t.Fatal(got, val)
+	}
+	for _, backend := range backends {
+		shard, err := backend.GetByHash(ctx, key)
+		Require(t, err)
+		if len(shard) >= len(val) {
+			Fail(t, "backend stores", len(shard), "bytes of", len(val))
+		}
+	}
+
+	// Losing as many backends as there are parity shards
+	delete(backends[0].contents, key)
+	Require(t, backends[3].Close(ctx))
+	got, err = service.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(got, val) {
+		t.Fatal(got, val)
+	}
+
+	delete(backends[2].contents, key)
+	if _, err := service.GetByHash(ctx, key); err == nil {
+		Fail(t, "recovered data with too few shards")
+	}
+}
+
+func TestErasureCodedStorageServiceWriteQuorum(t *testing.T) {
+	ctx := context.Background()
+	// #nosec G115
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	service, backends := newErasureCodedTestService(t, 2, 2, 3)
+	Require(t, backends[1].Close(ctx))
+
+	val := []byte("stored despite a closed backend")
+	Require(t, service.Put(ctx, val, timeout))
+	got, err := service.GetByHash(ctx, dastree.Hash(val))
+	Require(t, err)
+	if !bytes.Equal(got, val) {
+		t.Fatal(got, val)
+	}
+
+	Require(t, backends[2].Close(ctx))
+	if err := service.Put(ctx, []byte("short of the write quorum"), timeout); err == nil {
+		Fail(t, "stored data on fewer backends than the write quorum")
+	}
+	if err := service.HealthCheck(ctx); err == nil {
+		Fail(t, "healthy with fewer backends than the write quorum")
+	}
+}
+
+func TestErasureCodedStorageServiceCorruptShard(t *testing.T) {
+	ctx := context.Background()
+	// #nosec G115
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	service, backends := newErasureCodedTestService(t, 2, 1, 0)
+
+	val := []byte("a value with a corrupt shard")
+	key := dastree.Hash(val)
+	Require(t, service.Put(ctx, val, timeout))
+	backends[0].contents[key][erasureShardHeaderSize] ^= 0xff
+	backends[1].contents[key] = backends[1].contents[key][:4]
+	if _, err := service.GetByHash(ctx, key); err == nil {
+		Fail(t, "returned data recovered from a corrupt shard")
+	}
+}
@@ -74,6 +74,15 @@ func CreatePersistentStorageService(
 		storageServices = append(storageServices, s)
 	}
 
+	if config.RedundantStorage.Enable {
+		s, err := createErasureCodedStorageService(ctx, &config.RedundantStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		storageServices = append(storageServices, s)
+	}
+
 	if len(storageServices) > 1 {
 		s, err := NewRedundantStorageService(ctx, storageServices)
 		if err != nil {
@@ -92,6 +101,38 @@ func CreatePersistentStorageService(
 	return nil, &lifecycleManager, nil
 }
 
+// createErasureCodedStorageService creates the backends of an ErasureCodedStorageService, which closes them.
+func createErasureCodedStorageService(ctx context.Context, config *ErasureCodedStorageConfig) (*ErasureCodedStorageService, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	var backends []keyedStorageService
+	for _, dir := range config.LocalDirs {
+		fsConfig := DefaultLocalFileStorageConfig
+		fsConfig.Enable = true
+		fsConfig.DataDir = dir
+		fs, err := NewLocalFileStorageService(fsConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := fs.start(ctx); err != nil {
+			return nil, err
+		}
+		backends = append(backends, fs)
+	}
+	for _, bucket := range config.S3Buckets {
+		s3Config := config.S3
+		s3Config.Enable = true
+		s3Config.Bucket = bucket
+		s, err := NewS3StorageService(s3Config)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, s.(*S3StorageService))
+	}
+	return NewErasureCodedStorageService(config.DataShards, config.ParityShards, config.WriteQuorum, backends)
+}
+
 func WrapStorageWithCache(
 	ctx context.Context,
 	config *DataAvailabilityConfig,
@@ -169,8 +210,9 @@ func CreateDAComponentsForDaserver(
 	// Check config requirements
 	if !config.LocalDBStorage.Enable &&
 		!config.LocalFileStorage.Enable &&
-		!config.S3Storage.Enable {
-		return nil, nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage) must be enabled.")
+		!config.S3Storage.Enable &&
+		!config.RedundantStorage.Enable {
+		return nil, nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage|redundant-storage) must be enabled.")
 	}
 	// Done checking config requirements
 
@@ -134,6 +134,10 @@ func (s *LocalFileStorageService) GetByHash(ctx context.Context, key common.Hash
 
 func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, expiry uint64) error {
 	logPut("das.LocalFileStorageService.Store", data, expiry, s)
+	return s.putWithKey(ctx, dastree.Hash(data), data, expiry)
+}
+
+func (s *LocalFileStorageService) putWithKey(ctx context.Context, key common.Hash, data []byte, expiry uint64) error {
 	if expiry > math.MaxInt64 {
 		return fmt.Errorf("request expiry time (%v) exceeds max int64", expiry)
 	}
@@ -144,7 +148,6 @@ func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, expiry u
 		return fmt.Errorf("requested expiry time (%v) exceeds current time plus maximum allowed retention period(%v)", expiryTime, currentTimePlusRetention)
 	}
 
-	key := dastree.Hash(data)
 	var batchPath string
 	if !s.enableLegacyLayout {
 		s.layout.writeMutex.Lock()
@@ -44,12 +44,16 @@ func (m *MemoryBackedStorageService) GetByHash(ctx context.Context, key common.H
 
 func (m *MemoryBackedStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
 	logPut("das.MemoryBackedStorageService.Store", data, expirationTime, m)
+	return m.putWithKey(ctx, dastree.Hash(data), data, expirationTime)
+}
+
+func (m *MemoryBackedStorageService) putWithKey(ctx context.Context, key common.Hash, data []byte, expirationTime uint64) error {
 	m.rwmutex.Lock()
 	defer m.rwmutex.Unlock()
 	if m.closed {
 		return ErrClosed
 	}
-	m.contents[dastree.Hash(data)] = append([]byte{}, data...)
+	m.contents[key] = append([]byte{}, data...)
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,7 +21,8 @@ import (
 
 // RestfulDasClient implements daprovider.DASReader
 type RestfulDasClient struct {
-	url string
+	url       string
+	authToken string
 }
 
 func NewRestfulDasClient(protocol string, host string, port int) *RestfulDasClient {
@@ -39,8 +41,24 @@ func NewRestfulDasClientFromURL(url string) (*RestfulDasClient, error) {
 	}, nil
 }
 
+// SetAuthToken sets the bearer token presented to servers requiring one, or stops presenting one if it's empty.
+func (c *RestfulDasClient) SetAuthToken(token string) {
+	c.authToken = token
+}
+
+func (c *RestfulDasClient) get(ctx context.Context, url string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.authToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+	return http.DefaultClient.Do(request)
+}
+
 func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
-	res, err := http.Get(c.url + getByHashRequestPath + EncodeStorageServiceKey(hash))
+	res, err := c.get(ctx, c.url+getByHashRequestPath+EncodeStorageServiceKey(hash))
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +89,48 @@ func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]b
 	return decodedBytes, nil
 }
 
+// ErrChunksUnsupported is returned by GetChunk for servers that predate chunk requests
+var ErrChunksUnsupported = errors.New("server doesn't support chunk requests")
+
+// GetChunk fetches the bin that sample selects from the dastree under root, checking it against the root.
+// Returns the bin and its index.
+func (c *RestfulDasClient) GetChunk(ctx context.Context, root common.Hash, sample uint64) ([]byte, uint64, error) {
+	res, err := c.get(ctx, fmt.Sprintf("%s%s%s/%d", c.url, getChunkRequestPath, EncodeStorageServiceKey(root), sample))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusBadRequest {
+		return nil, 0, ErrChunksUnsupported
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	var response RestfulDasServerResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, 0, err
+	}
+	bin, err := base64.StdEncoding.DecodeString(response.Data)
+	if err != nil {
+		return nil, 0, err
+	}
+	proof := make([][]byte, len(response.Proof))
+	for i, node := range response.Proof {
+		proof[i], err = base64.StdEncoding.DecodeString(node)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	index, err := dastree.VerifyBinProof(root, sample, bin, proof)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", daprovider.ErrHashMismatch, err)
+	}
+	return bin, index, nil
+}
+
 func (c *RestfulDasClient) HealthCheck(ctx context.Context) error {
-	res, err := http.Get(c.url + healthRequestPath)
+	res, err := c.get(ctx, c.url+healthRequestPath)
 	if err != nil {
 		return err
 	}
@@ -83,7 +141,7 @@ func (c *RestfulDasClient) HealthCheck(ctx context.Context) error {
 }
 
 func (c *RestfulDasClient) ExpirationPolicy(ctx context.Context) (daprovider.ExpirationPolicy, error) {
-	res, err := http.Get(c.url + expirationPolicyRequestPath)
+	res, err := c.get(ctx, c.url+expirationPolicyRequestPath)
 	if err != nil {
 		return -1, err
 	}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
 )
 
@@ -28,6 +30,8 @@ var (
 	restGetByHashFailureGauge       = metrics.NewRegisteredGauge("arb/das/rest/getbyhash/failure", nil)
 	restGetByHashReturnedBytesGauge = metrics.NewRegisteredGauge("arb/das/rest/getbyhash/bytes", nil)
 	restGetByHashDurationHistogram  = metrics.NewRegisteredHistogram("arb/das/rest/getbyhash/duration", nil, metrics.NewBoundedHistogramSample())
+	restGetChunkRequestGauge        = metrics.NewRegisteredGauge("arb/das/rest/getchunk/requests", nil)
+	restGetChunkFailureGauge        = metrics.NewRegisteredGauge("arb/das/rest/getchunk/failure", nil)
 )
 
 type RestfulDasServer struct {
@@ -38,15 +42,19 @@ type RestfulDasServer struct {
 	httpServerError      error
 }
 
-func NewRestfulDasServer(address string, port uint64, restServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader daprovider.DASReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
+func NewRestfulDasServer(address string, port uint64, restServerTimeouts genericconf.HTTPServerTimeoutConfig, restServerSecurity *genericconf.HTTPServerSecurityConfig, daReader daprovider.DASReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
 	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, port))
 	if err != nil {
 		return nil, err
 	}
-	return NewRestfulDasServerOnListener(listener, restServerTimeouts, daReader, daHealthChecker)
+	return newRestfulDasServerOnListener(listener, restServerTimeouts, restServerSecurity, daReader, daHealthChecker)
 }
 
 func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader daprovider.DASReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
+	return newRestfulDasServerOnListener(listener, restServerTimeouts, nil, daReader, daHealthChecker)
+}
+
+func newRestfulDasServerOnListener(listener net.Listener, restServerTimeouts genericconf.HTTPServerTimeoutConfig, restServerSecurity *genericconf.HTTPServerSecurityConfig, daReader daprovider.DASReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
 
 	ret := &RestfulDasServer{
 		daReader:             daReader,
@@ -54,16 +62,14 @@ func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts gen
 		httpServerExitedChan: make(chan interface{}),
 	}
 
-	ret.server = &http.Server{
-		Handler:           ret,
-		ReadTimeout:       restServerTimeouts.ReadTimeout,
-		ReadHeaderTimeout: restServerTimeouts.ReadHeaderTimeout,
-		WriteTimeout:      restServerTimeouts.WriteTimeout,
-		IdleTimeout:       restServerTimeouts.IdleTimeout,
+	var err error
+	ret.server, err = genericconf.NewHTTPServer(ret, restServerTimeouts, restServerSecurity)
+	if err != nil {
+		return nil, err
 	}
 
 	go func() {
-		err := ret.server.Serve(listener)
+		err := genericconf.ServeHTTPServer(ret.server, listener)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			ret.httpServerError = err
 		}
@@ -76,6 +82,8 @@ func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts gen
 type RestfulDasServerResponse struct {
 	Data             string `json:"data,omitempty"`
 	ExpirationPolicy string `json:"expirationPolicy,omitempty"`
+	// For chunk requests, the base64 encoded preimages of the dastree nodes above the chunk
+	Proof []string `json:"proof,omitempty"`
 }
 
 var cacheControlKey = http.CanonicalHeaderKey("cache-control")
@@ -86,6 +94,9 @@ const healthRequestPath = "/health"
 const expirationPolicyRequestPath = "/expiration-policy/"
 const getByHashRequestPath = "/get-by-hash/"
 
+// Chunk requests are of the form /get-chunk/<hash>/<sample>, and return the bin the sample selects from the data's dastree
+const getChunkRequestPath = "/get-chunk/"
+
 func (rds *RestfulDasServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header()[cacheControlKey] = []string{cacheControlValueDefault}
 	requestPath := path.Clean(r.URL.Path)
@@ -97,6 +108,8 @@ func (rds *RestfulDasServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		rds.ExpirationPolicyHandler(w, r, requestPath)
 	case strings.HasPrefix(requestPath, getByHashRequestPath):
 		rds.GetByHashHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, getChunkRequestPath):
+		rds.GetChunkHandler(w, r, requestPath)
 	default:
 		log.Warn("Unknown requestPath", "requestPath", requestPath)
 		w.WriteHeader(http.StatusBadRequest)
@@ -187,6 +200,63 @@ func (rds *RestfulDasServer) GetByHashHandler(w http.ResponseWriter, r *http.Req
 	success = true
 }
 
+// GetChunkHandler serves a single bin of stored data, with the proof the client needs to check it against the
+// data's hash, so data availability can be sampled without downloading whole batches.
+func (rds *RestfulDasServer) GetChunkHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	restGetChunkRequestGauge.Inc(1)
+	success := false
+	defer func() {
+		if !success {
+			restGetChunkFailureGauge.Inc(1)
+		}
+	}()
+
+	hashString, sampleString, found := strings.Cut(strings.TrimPrefix(requestPath, getChunkRequestPath), "/")
+	if !found {
+		log.Warn("Chunk request is missing the sample", "path", requestPath)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	hashBytes, err := DecodeStorageServiceKey(hashString)
+	if err != nil || len(hashBytes) < 32 {
+		log.Warn("Failed to decode hex-encoded hash", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sample, err := strconv.ParseUint(sampleString, 10, 64)
+	if err != nil {
+		log.Warn("Failed to parse sample", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	root := common.BytesToHash(hashBytes[:32])
+
+	data, err := rds.daReader.GetByHash(r.Context(), root)
+	if err != nil {
+		log.Warn("Unable to find data", "path", requestPath, "err", err, "remoteAddr", r.RemoteAddr)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	bin, proof, err := dastree.BinProof(root, data, sample)
+	if err != nil {
+		// Data stored under old-style flat hashes has no tree to sample
+		log.Warn("Unable to prove chunk", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response := RestfulDasServerResponse{Data: base64.StdEncoding.EncodeToString(bin)}
+	for _, node := range proof {
+		response.Proof = append(response.Proof, base64.StdEncoding.EncodeToString(node))
+	}
+	w.Header()[cacheControlKey] = []string{cacheControlValueForSuccessfulGetByHash}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		return
+	}
+	success = true
+}
+
 func (rds *RestfulDasServer) GetServerExitedChan() <-chan interface{} { // channel will close when server terminates
 	return rds.httpServerExitedChan
 }
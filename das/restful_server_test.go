@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -69,3 +71,41 @@ func TestRestfulClientServer(t *testing.T) {
 	err = server.Shutdown()
 	Require(t, err)
 }
+
+func TestRestfulClientServerAuth(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := []byte("Testing a restful server requiring a token.")
+	// #nosec G115
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	Require(t, os.WriteFile(tokenFile, []byte("secret\n"), 0600))
+	security := genericconf.HTTPServerSecurityConfigDefault
+	security.AuthTokenFile = tokenFile
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	server, err := newRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, &security, storage, storage)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	client, err := NewRestfulDasClientFromURL("http://" + listener.Addr().String())
+	Require(t, err)
+	if _, err := client.GetByHash(ctx, dastree.Hash(data)); err == nil || !strings.Contains(err.Error(), "401") {
+		Fail(t, "expected a 401 error without the token", err)
+	}
+	token, err := genericconf.ReadAuthTokenFile(tokenFile)
+	Require(t, err)
+	client.SetAuthToken(token)
+	returnedData, err := client.GetByHash(ctx, dastree.Hash(data))
+	Require(t, err)
+	if !bytes.Equal(data, returnedData) {
+		Fail(t, fmt.Sprintf("Returned data '%s' does not match expected '%s'", returnedData, data))
+	}
+}
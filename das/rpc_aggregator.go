@@ -16,6 +16,7 @@ import (
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
 	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/util/metricsutil"
 	"github.com/offchainlabs/nitro/util/signature"
@@ -25,8 +26,9 @@ import (
 )
 
 type BackendConfig struct {
-	URL    string `koanf:"url" json:"url"`
-	Pubkey string `koanf:"pubkey" json:"pubkey"`
+	URL           string `koanf:"url" json:"url"`
+	Pubkey        string `koanf:"pubkey" json:"pubkey"`
+	AuthTokenFile string `koanf:"auth-token-file" json:"auth-token-file"`
 }
 
 type BackendConfigList []BackendConfig
@@ -109,7 +111,11 @@ func ParseServices(config AggregatorConfig, signer signature.DataSignerFunc) ([]
 		}
 		metricName := metricsutil.CanonicalizeMetricName(url.Hostname())
 
-		service, err := NewDASRPCClient(b.URL, signer, config.MaxStoreChunkBodySize)
+		authToken, err := genericconf.ReadAuthTokenFile(b.AuthTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		service, err := NewDASRPCClient(b.URL, signer, config.MaxStoreChunkBodySize, authToken)
 		if err != nil {
 			return nil, err
 		}
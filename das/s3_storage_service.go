@@ -107,9 +107,13 @@ func (s3s *S3StorageService) GetByHash(ctx context.Context, key common.Hash) ([]
 
 func (s3s *S3StorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
 	logPut("das.S3StorageService.Store", value, timeout, s3s)
+	return s3s.putWithKey(ctx, dastree.Hash(value), value, timeout)
+}
+
+func (s3s *S3StorageService) putWithKey(ctx context.Context, key common.Hash, value []byte, timeout uint64) error {
 	putObjectInput := s3.PutObjectInput{
 		Bucket: aws.String(s3s.bucket),
-		Key:    aws.String(s3s.objectPrefix + EncodeStorageServiceKey(dastree.Hash(value))),
+		Key:    aws.String(s3s.objectPrefix + EncodeStorageServiceKey(key)),
 		Body:   bytes.NewReader(value)}
 	if s3s.discardAfterTimeout && timeout <= math.MaxInt64 {
 		// #nosec G115
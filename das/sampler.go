@@ -0,0 +1,130 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+var (
+	sampleSuccessCounter = metrics.NewRegisteredCounter("arb/das/sample/success", nil)
+	sampleFailureCounter = metrics.NewRegisteredCounter("arb/das/sample/failure", nil)
+)
+
+// ChunkSampler fetches single chunks of a batch's data from one endpoint and checks them against the batch's
+// commitment, so the data's availability can be monitored without downloading all of it.
+type ChunkSampler interface {
+	// SampleChunk fetches and checks the chunk that sample selects, modulo the number of chunks, returning its index
+	SampleChunk(ctx context.Context, sample uint64) (uint64, error)
+	fmt.Stringer
+}
+
+type restChunkSampler struct {
+	client *RestfulDasClient
+	root   common.Hash
+}
+
+// NewRestChunkSampler samples the bins of the dastree under root from a REST data availability server.
+// Servers without chunk requests are sampled by downloading the whole batch.
+func NewRestChunkSampler(client *RestfulDasClient, root common.Hash) ChunkSampler {
+	return &restChunkSampler{client, root}
+}
+
+func (s *restChunkSampler) SampleChunk(ctx context.Context, sample uint64) (uint64, error) {
+	_, index, err := s.client.GetChunk(ctx, s.root, sample)
+	if !errors.Is(err, ErrChunksUnsupported) {
+		return index, err
+	}
+	data, err := s.client.GetByHash(ctx, s.root)
+	if err != nil {
+		return 0, err
+	}
+	bin, proof, err := dastree.BinProof(s.root, data, sample)
+	if err != nil {
+		return 0, err
+	}
+	return dastree.VerifyBinProof(s.root, sample, bin, proof)
+}
+
+func (s *restChunkSampler) String() string {
+	return s.client.url
+}
+
+type blobChunkSampler struct {
+	name            string
+	reader          daprovider.BlobReader
+	blockHash       common.Hash
+	versionedHashes []common.Hash
+}
+
+// NewBlobChunkSampler samples the blobs of a batch posted in the parent chain block with the given hash.
+// The reader checks each blob's KZG proof against its versioned hash.
+func NewBlobChunkSampler(name string, reader daprovider.BlobReader, blockHash common.Hash, versionedHashes []common.Hash) ChunkSampler {
+	return &blobChunkSampler{name, reader, blockHash, versionedHashes}
+}
+
+func (s *blobChunkSampler) SampleChunk(ctx context.Context, sample uint64) (uint64, error) {
+	if len(s.versionedHashes) == 0 {
+		return 0, errors.New("batch has no blobs")
+	}
+	index := sample % uint64(len(s.versionedHashes))
+	_, err := s.reader.GetBlobs(ctx, s.blockHash, s.versionedHashes[index:index+1])
+	return index, err
+}
+
+func (s *blobChunkSampler) String() string {
+	return s.name
+}
+
+type ChunkSample struct {
+	Endpoint string `json:"endpoint"`
+	Index    uint64 `json:"index"`
+	Error    string `json:"error,omitempty"`
+}
+
+type SamplingReport struct {
+	Samples  []ChunkSample `json:"samples"`
+	Failures int           `json:"failures"`
+}
+
+// SampleChunks requests samplesPerEndpoint randomly chosen chunks from each sampler, all concurrently.
+func SampleChunks(ctx context.Context, samplers []ChunkSampler, samplesPerEndpoint int) *SamplingReport {
+	samples := make([]ChunkSample, len(samplers)*samplesPerEndpoint)
+	var wg sync.WaitGroup
+	for i, sampler := range samplers {
+		for j := 0; j < samplesPerEndpoint; j++ {
+			wg.Add(1)
+			go func(sample *ChunkSample, sampler ChunkSampler) {
+				defer wg.Done()
+				sample.Endpoint = sampler.String()
+				index, err := sampler.SampleChunk(ctx, rand.Uint64())
+				sample.Index = index
+				if err != nil {
+					sample.Error = err.Error()
+				}
+			}(&samples[i*samplesPerEndpoint+j], sampler)
+		}
+	}
+	wg.Wait()
+	report := &SamplingReport{Samples: samples}
+	for _, sample := range samples {
+		if sample.Error != "" {
+			report.Failures++
+			sampleFailureCounter.Inc(1)
+		} else {
+			sampleSuccessCounter.Inc(1)
+		}
+	}
+	return report
+}
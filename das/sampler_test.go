@@ -0,0 +1,57 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestSampleChunks(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := testhelpers.RandomSlice(5*dastree.BinSize + 100)
+	// #nosec G115
+	err := storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix()))
+	Require(t, err)
+
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+	client := NewRestfulDasClient("http", LocalServerAddressForTest, port)
+
+	root := dastree.Hash(data)
+	for sample := uint64(0); sample < 12; sample++ {
+		bin, index, err := client.GetChunk(ctx, root, sample)
+		Require(t, err)
+		if index != sample%6 {
+			Fail(t, "unexpected chunk index", index, sample)
+		}
+		start := index * dastree.BinSize
+		if string(bin) != string(data[start:start+uint64(len(bin))]) {
+			Fail(t, "unexpected chunk", index)
+		}
+	}
+
+	missing := dastree.Hash([]byte("absent data"))
+	report := SampleChunks(ctx, []ChunkSampler{NewRestChunkSampler(client, root), NewRestChunkSampler(client, missing)}, 4)
+	if len(report.Samples) != 8 || report.Failures != 4 {
+		Fail(t, "unexpected sampling report", report)
+	}
+	for _, sample := range report.Samples[:4] {
+		if sample.Error != "" || sample.Index >= 6 {
+			Fail(t, "sampling stored data failed", sample)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
@@ -35,6 +36,7 @@ type RestfulClientAggregatorConfig struct {
 	MaxPerEndpointStats          int                                `koanf:"max-per-endpoint-stats"`
 	SimpleExploreExploitStrategy SimpleExploreExploitStrategyConfig `koanf:"simple-explore-exploit-strategy"`
 	SyncToStorage                SyncToStorageConfig                `koanf:"sync-to-storage"`
+	AuthTokenFile                string                             `koanf:"auth-token-file"`
 }
 
 var DefaultRestfulClientAggregatorConfig = RestfulClientAggregatorConfig{
@@ -47,6 +49,7 @@ var DefaultRestfulClientAggregatorConfig = RestfulClientAggregatorConfig{
 	MaxPerEndpointStats:          20,
 	SimpleExploreExploitStrategy: DefaultSimpleExploreExploitStrategyConfig,
 	SyncToStorage:                DefaultSyncToStorageConfig,
+	AuthTokenFile:                "",
 }
 
 type SimpleExploreExploitStrategyConfig struct {
@@ -70,6 +73,7 @@ func RestfulClientAggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-per-endpoint-stats", DefaultRestfulClientAggregatorConfig.MaxPerEndpointStats, "number of stats entries (latency and success rate) to keep for each REST endpoint; controls whether strategy is faster or slower to respond to changing conditions")
 	SimpleExploreExploitStrategyConfigAddOptions(prefix+".simple-explore-exploit-strategy", f)
 	SyncToStorageConfigAddOptions(prefix+".sync-to-storage", f)
+	f.String(prefix+".auth-token-file", DefaultRestfulClientAggregatorConfig.AuthTokenFile, "path to a file holding the bearer token to present to REST endpoints requiring one (see the daserver's server-security.auth-token-file)")
 }
 
 func SimpleExploreExploitStrategyConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -78,9 +82,14 @@ func SimpleExploreExploitStrategyConfigAddOptions(prefix string, f *flag.FlagSet
 }
 
 func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggregatorConfig) (*SimpleDASReaderAggregator, error) {
+	authToken, err := genericconf.ReadAuthTokenFile(config.AuthTokenFile)
+	if err != nil {
+		return nil, err
+	}
 	a := SimpleDASReaderAggregator{
-		config: config,
-		stats:  make(map[daprovider.DASReader]readerStats),
+		config:    config,
+		authToken: authToken,
+		stats:     make(map[daprovider.DASReader]readerStats),
 	}
 
 	combinedUrls := make(map[string]bool)
@@ -112,6 +121,7 @@ func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggreg
 		if err != nil {
 			return nil, err
 		}
+		reader.SetAuthToken(a.authToken)
 		a.readers = append(a.readers, reader)
 		a.stats[reader] = make([]readerStat, 0, config.MaxPerEndpointStats)
 	}
@@ -166,7 +176,8 @@ type readerStatMessage struct {
 type SimpleDASReaderAggregator struct {
 	stopwaiter.StopWaiter
 
-	config *RestfulClientAggregatorConfig
+	config    *RestfulClientAggregatorConfig
+	authToken string
 
 	readersMutex sync.RWMutex
 	// readers and stats are only to be updated by the stats goroutine
@@ -284,6 +295,7 @@ func (a *SimpleDASReaderAggregator) Start(ctx context.Context) {
 			if err != nil {
 				return
 			}
+			reader.SetAuthToken(a.authToken)
 			combinedReaders[reader] = true
 		}
 		a.readers = make([]daprovider.DASReader, 0, len(combinedUrls))
@@ -0,0 +1,176 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package execrpc exposes the execution and consensus interfaces over RPC, so the
+// consensus node and the execution engine can run in separate processes.
+// The namespaces aren't public and should only be served on the authenticated RPC endpoint,
+// or on a unix socket with IPCServer when both processes run on the same host.
+package execrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+const (
+	ExecutionNamespace = "execution"
+	ConsensusNamespace = "consensus"
+	// APIVersion must be bumped on any incompatible change to either API.
+	APIVersion uint64 = 1
+)
+
+var ErrAPIVersionMismatch = errors.New("execution/consensus rpc api version mismatch")
+
+type ExecutionServerAPI struct {
+	exec execution.FullExecutionClient
+}
+
+func NewExecutionServerAPI(exec execution.FullExecutionClient) *ExecutionServerAPI {
+	return &ExecutionServerAPI{exec: exec}
+}
+
+func (a *ExecutionServerAPI) ApiVersion() uint64 {
+	return APIVersion
+}
+
+func (a *ExecutionServerAPI) DigestMessage(num arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata, msgForPrefetch *arbostypes.MessageWithMetadata) (*execution.MessageResult, error) {
+	return a.exec.DigestMessage(num, msg, msgForPrefetch)
+}
+
+func (a *ExecutionServerAPI) Reorg(count arbutil.MessageIndex, newMessages []arbostypes.MessageWithMetadataAndBlockHash, oldMessages []*arbostypes.MessageWithMetadata) ([]*execution.MessageResult, error) {
+	return a.exec.Reorg(count, newMessages, oldMessages)
+}
+
+func (a *ExecutionServerAPI) HeadMessageNumber() (arbutil.MessageIndex, error) {
+	return a.exec.HeadMessageNumber()
+}
+
+func (a *ExecutionServerAPI) ResultAtPos(pos arbutil.MessageIndex) (*execution.MessageResult, error) {
+	return a.exec.ResultAtPos(pos)
+}
+
+func (a *ExecutionServerAPI) RecordBlockCreation(ctx context.Context, pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) (*execution.RecordResult, error) {
+	return a.exec.RecordBlockCreation(ctx, pos, msg)
+}
+
+func (a *ExecutionServerAPI) MarkValid(pos arbutil.MessageIndex, resultHash common.Hash) {
+	a.exec.MarkValid(pos, resultHash)
+}
+
+func (a *ExecutionServerAPI) PrepareForRecord(ctx context.Context, start, end arbutil.MessageIndex) error {
+	return a.exec.PrepareForRecord(ctx, start, end)
+}
+
+func (a *ExecutionServerAPI) Pause() {
+	a.exec.Pause()
+}
+
+func (a *ExecutionServerAPI) Activate() {
+	a.exec.Activate()
+}
+
+func (a *ExecutionServerAPI) ForwardTo(url string) error {
+	return a.exec.ForwardTo(url)
+}
+
+func (a *ExecutionServerAPI) SequenceDelayedMessage(message *arbostypes.L1IncomingMessage, delayedSeqNum uint64) error {
+	return a.exec.SequenceDelayedMessage(message, delayedSeqNum)
+}
+
+func (a *ExecutionServerAPI) NextDelayedMessageNumber() (uint64, error) {
+	return a.exec.NextDelayedMessageNumber()
+}
+
+func (a *ExecutionServerAPI) MarkFeedStart(to arbutil.MessageIndex) {
+	a.exec.MarkFeedStart(to)
+}
+
+func (a *ExecutionServerAPI) Synced() bool {
+	return a.exec.Synced()
+}
+
+func (a *ExecutionServerAPI) FullSyncProgressMap() map[string]interface{} {
+	return a.exec.FullSyncProgressMap()
+}
+
+func (a *ExecutionServerAPI) Maintenance() error {
+	return a.exec.Maintenance()
+}
+
+func (a *ExecutionServerAPI) ArbOSVersionForMessageNumber(messageNum arbutil.MessageIndex) (uint64, error) {
+	return a.exec.ArbOSVersionForMessageNumber(messageNum)
+}
+
+type ConsensusServerAPI struct {
+	consensus execution.FullConsensusClient
+}
+
+func NewConsensusServerAPI(consensus execution.FullConsensusClient) *ConsensusServerAPI {
+	return &ConsensusServerAPI{consensus: consensus}
+}
+
+func (a *ConsensusServerAPI) ApiVersion() uint64 {
+	return APIVersion
+}
+
+func (a *ConsensusServerAPI) FindInboxBatchContainingMessage(message arbutil.MessageIndex) (*BatchContainingMessageResult, error) {
+	batch, found, err := a.consensus.FindInboxBatchContainingMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchContainingMessageResult{Batch: batch, Found: found}, nil
+}
+
+func (a *ConsensusServerAPI) GetBatchParentChainBlock(seqNum uint64) (uint64, error) {
+	return a.consensus.GetBatchParentChainBlock(seqNum)
+}
+
+func (a *ConsensusServerAPI) GetBatchDataProof(ctx context.Context, seqNum uint64) (*execution.BatchDataProof, error) {
+	return a.consensus.GetBatchDataProof(ctx, seqNum)
+}
+
+func (a *ConsensusServerAPI) Synced() bool {
+	return a.consensus.Synced()
+}
+
+func (a *ConsensusServerAPI) FullSyncProgressMap() map[string]interface{} {
+	return a.consensus.FullSyncProgressMap()
+}
+
+func (a *ConsensusServerAPI) SyncTargetMessageCount() arbutil.MessageIndex {
+	return a.consensus.SyncTargetMessageCount()
+}
+
+func (a *ConsensusServerAPI) GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	return a.consensus.GetSafeMsgCount(ctx)
+}
+
+func (a *ConsensusServerAPI) GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	return a.consensus.GetFinalizedMsgCount(ctx)
+}
+
+func (a *ConsensusServerAPI) ValidatedMessageCount() (arbutil.MessageIndex, error) {
+	return a.consensus.ValidatedMessageCount()
+}
+
+func (a *ConsensusServerAPI) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	return a.consensus.BatchPostedMessageCount()
+}
+
+func (a *ConsensusServerAPI) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error {
+	return a.consensus.WriteMessageFromSequencer(pos, msgWithMeta, msgResult)
+}
+
+func (a *ConsensusServerAPI) ExpectChosenSequencer() error {
+	return a.consensus.ExpectChosenSequencer()
+}
+
+type BatchContainingMessageResult struct {
+	Batch uint64 `json:"batch"`
+	Found bool   `json:"found"`
+}
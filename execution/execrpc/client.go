@@ -0,0 +1,279 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execrpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/rpcclient"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type rpcClient struct {
+	stopwaiter.StopWaiter
+	client    *rpcclient.RpcClient
+	namespace string
+}
+
+func (c *rpcClient) start(ctx context.Context, self any) error {
+	if err := c.client.Start(ctx); err != nil {
+		return err
+	}
+	var version uint64
+	if err := c.client.CallContext(ctx, &version, c.namespace+"_apiVersion"); err != nil {
+		c.client.Close()
+		return fmt.Errorf("error reading %v api version: %w", c.namespace, err)
+	}
+	if version != APIVersion {
+		c.client.Close()
+		return fmt.Errorf("%w: %v server has version %v, expected %v", ErrAPIVersionMismatch, c.namespace, version, APIVersion)
+	}
+	c.StopWaiter.Start(ctx, self)
+	return nil
+}
+
+func (c *rpcClient) StopAndWait() {
+	c.StopWaiter.StopAndWait()
+	c.client.Close()
+}
+
+func (c *rpcClient) call(result interface{}, method string, args ...interface{}) error {
+	ctx, err := c.GetContextSafe()
+	if err != nil {
+		return err
+	}
+	return c.callContext(ctx, result, method, args...)
+}
+
+func (c *rpcClient) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.client.CallContext(ctx, result, c.namespace+"_"+method, args...)
+}
+
+// callNoError is for interface methods that can't return an error.
+func (c *rpcClient) callNoError(result interface{}, method string, args ...interface{}) {
+	if err := c.call(result, method, args...); err != nil {
+		log.Error("error calling remote "+c.namespace+" node", "method", method, "err", err)
+	}
+}
+
+// ExecutionRPCClient is a FullExecutionClient talking to an execution node in another process.
+type ExecutionRPCClient struct {
+	rpcClient
+}
+
+var _ execution.FullExecutionClient = (*ExecutionRPCClient)(nil)
+
+func NewExecutionRPCClient(config rpcclient.ClientConfigFetcher, stack *node.Node) *ExecutionRPCClient {
+	return &ExecutionRPCClient{
+		rpcClient: rpcClient{
+			client:    rpcclient.NewRpcClient(config, stack),
+			namespace: ExecutionNamespace,
+		},
+	}
+}
+
+func (c *ExecutionRPCClient) Start(ctx context.Context) error {
+	return c.start(ctx, c)
+}
+
+func (c *ExecutionRPCClient) DigestMessage(num arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata, msgForPrefetch *arbostypes.MessageWithMetadata) (*execution.MessageResult, error) {
+	var res execution.MessageResult
+	if err := c.call(&res, "digestMessage", num, msg, msgForPrefetch); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *ExecutionRPCClient) Reorg(count arbutil.MessageIndex, newMessages []arbostypes.MessageWithMetadataAndBlockHash, oldMessages []*arbostypes.MessageWithMetadata) ([]*execution.MessageResult, error) {
+	var res []*execution.MessageResult
+	err := c.call(&res, "reorg", count, newMessages, oldMessages)
+	return res, err
+}
+
+func (c *ExecutionRPCClient) HeadMessageNumber() (arbutil.MessageIndex, error) {
+	var res arbutil.MessageIndex
+	err := c.call(&res, "headMessageNumber")
+	return res, err
+}
+
+func (c *ExecutionRPCClient) HeadMessageNumberSync(t *testing.T) (arbutil.MessageIndex, error) {
+	return c.HeadMessageNumber()
+}
+
+func (c *ExecutionRPCClient) ResultAtPos(pos arbutil.MessageIndex) (*execution.MessageResult, error) {
+	var res execution.MessageResult
+	if err := c.call(&res, "resultAtPos", pos); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *ExecutionRPCClient) RecordBlockCreation(ctx context.Context, pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) (*execution.RecordResult, error) {
+	var res execution.RecordResult
+	if err := c.callContext(ctx, &res, "recordBlockCreation", pos, msg); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *ExecutionRPCClient) MarkValid(pos arbutil.MessageIndex, resultHash common.Hash) {
+	c.callNoError(nil, "markValid", pos, resultHash)
+}
+
+func (c *ExecutionRPCClient) PrepareForRecord(ctx context.Context, start, end arbutil.MessageIndex) error {
+	return c.callContext(ctx, nil, "prepareForRecord", start, end)
+}
+
+func (c *ExecutionRPCClient) Pause() {
+	c.callNoError(nil, "pause")
+}
+
+func (c *ExecutionRPCClient) Activate() {
+	c.callNoError(nil, "activate")
+}
+
+func (c *ExecutionRPCClient) ForwardTo(url string) error {
+	return c.call(nil, "forwardTo", url)
+}
+
+func (c *ExecutionRPCClient) SequenceDelayedMessage(message *arbostypes.L1IncomingMessage, delayedSeqNum uint64) error {
+	return c.call(nil, "sequenceDelayedMessage", message, delayedSeqNum)
+}
+
+func (c *ExecutionRPCClient) NextDelayedMessageNumber() (uint64, error) {
+	var res uint64
+	err := c.call(&res, "nextDelayedMessageNumber")
+	return res, err
+}
+
+func (c *ExecutionRPCClient) MarkFeedStart(to arbutil.MessageIndex) {
+	c.callNoError(nil, "markFeedStart", to)
+}
+
+func (c *ExecutionRPCClient) Synced() bool {
+	var res bool
+	c.callNoError(&res, "synced")
+	return res
+}
+
+func (c *ExecutionRPCClient) FullSyncProgressMap() map[string]interface{} {
+	var res map[string]interface{}
+	if err := c.call(&res, "fullSyncProgressMap"); err != nil {
+		return map[string]interface{}{"executionRpcError": err.Error()}
+	}
+	return res
+}
+
+func (c *ExecutionRPCClient) Maintenance() error {
+	return c.call(nil, "maintenance")
+}
+
+func (c *ExecutionRPCClient) ArbOSVersionForMessageNumber(messageNum arbutil.MessageIndex) (uint64, error) {
+	var res uint64
+	err := c.call(&res, "arbOSVersionForMessageNumber", messageNum)
+	return res, err
+}
+
+// ConsensusRPCClient is a FullConsensusClient talking to a consensus node in another process.
+type ConsensusRPCClient struct {
+	rpcClient
+}
+
+var _ execution.FullConsensusClient = (*ConsensusRPCClient)(nil)
+
+func NewConsensusRPCClient(config rpcclient.ClientConfigFetcher, stack *node.Node) *ConsensusRPCClient {
+	return &ConsensusRPCClient{
+		rpcClient: rpcClient{
+			client:    rpcclient.NewRpcClient(config, stack),
+			namespace: ConsensusNamespace,
+		},
+	}
+}
+
+func (c *ConsensusRPCClient) Start(ctx context.Context) error {
+	return c.start(ctx, c)
+}
+
+func (c *ConsensusRPCClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error) {
+	var res BatchContainingMessageResult
+	if err := c.call(&res, "findInboxBatchContainingMessage", message); err != nil {
+		return 0, false, err
+	}
+	return res.Batch, res.Found, nil
+}
+
+func (c *ConsensusRPCClient) GetBatchParentChainBlock(seqNum uint64) (uint64, error) {
+	var res uint64
+	err := c.call(&res, "getBatchParentChainBlock", seqNum)
+	return res, err
+}
+
+func (c *ConsensusRPCClient) GetBatchDataProof(ctx context.Context, seqNum uint64) (*execution.BatchDataProof, error) {
+	var res execution.BatchDataProof
+	if err := c.callContext(ctx, &res, "getBatchDataProof", seqNum); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *ConsensusRPCClient) Synced() bool {
+	var res bool
+	c.callNoError(&res, "synced")
+	return res
+}
+
+func (c *ConsensusRPCClient) FullSyncProgressMap() map[string]interface{} {
+	var res map[string]interface{}
+	if err := c.call(&res, "fullSyncProgressMap"); err != nil {
+		return map[string]interface{}{"consensusRpcError": err.Error()}
+	}
+	return res
+}
+
+func (c *ConsensusRPCClient) SyncTargetMessageCount() arbutil.MessageIndex {
+	var res arbutil.MessageIndex
+	c.callNoError(&res, "syncTargetMessageCount")
+	return res
+}
+
+func (c *ConsensusRPCClient) GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	var res arbutil.MessageIndex
+	err := c.callContext(ctx, &res, "getSafeMsgCount")
+	return res, err
+}
+
+func (c *ConsensusRPCClient) GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	var res arbutil.MessageIndex
+	err := c.callContext(ctx, &res, "getFinalizedMsgCount")
+	return res, err
+}
+
+func (c *ConsensusRPCClient) ValidatedMessageCount() (arbutil.MessageIndex, error) {
+	var res arbutil.MessageIndex
+	err := c.call(&res, "validatedMessageCount")
+	return res, err
+}
+
+func (c *ConsensusRPCClient) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	var res arbutil.MessageIndex
+	err := c.call(&res, "batchPostedMessageCount")
+	return res, err
+}
+
+func (c *ConsensusRPCClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error {
+	return c.call(nil, "writeMessageFromSequencer", pos, msgWithMeta, msgResult)
+}
+
+func (c *ConsensusRPCClient) ExpectChosenSequencer() error {
+	return c.call(nil, "expectChosenSequencer")
+}
@@ -0,0 +1,137 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execrpc
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/rpcclient"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type mockConsensus struct {
+	written []arbutil.MessageIndex
+}
+
+func (m *mockConsensus) FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error) {
+	return uint64(message) / 10, true, nil
+}
+func (m *mockConsensus) GetBatchParentChainBlock(seqNum uint64) (uint64, error) {
+	return seqNum + 100, nil
+}
+func (m *mockConsensus) GetBatchDataProof(ctx context.Context, seqNum uint64) (*execution.BatchDataProof, error) {
+	return &execution.BatchDataProof{SequenceNumber: seqNum, DataHash: common.Hash{1}, ParentChainBlock: seqNum + 100}, nil
+}
+func (m *mockConsensus) Synced() bool { return true }
+func (m *mockConsensus) FullSyncProgressMap() map[string]interface{} {
+	return map[string]interface{}{"synced": true}
+}
+func (m *mockConsensus) SyncTargetMessageCount() arbutil.MessageIndex { return 42 }
+func (m *mockConsensus) GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	return 40, nil
+}
+func (m *mockConsensus) GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
+	return 0, errors.New("no finality data")
+}
+func (m *mockConsensus) ValidatedMessageCount() (arbutil.MessageIndex, error) { return 30, nil }
+func (m *mockConsensus) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	return 20, nil
+}
+func (m *mockConsensus) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error {
+	m.written = append(m.written, pos)
+	return nil
+}
+func (m *mockConsensus) ExpectChosenSequencer() error { return nil }
+
+func TestConsensusRPCClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stackConf := node.DefaultConfig
+	stackConf.HTTPPort = 0
+	stackConf.DataDir = ""
+	stackConf.WSHost = "127.0.0.1"
+	stackConf.WSPort = 0
+	stackConf.WSModules = []string{ConsensusNamespace}
+	stackConf.P2P.NoDiscovery = true
+	stackConf.P2P.ListenAddr = ""
+	stack, err := node.New(&stackConf)
+	testhelpers.RequireImpl(t, err)
+	consensus := &mockConsensus{}
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: ConsensusNamespace,
+		Version:   "1.0",
+		Service:   NewConsensusServerAPI(consensus),
+		Public:    true,
+	}})
+	testhelpers.RequireImpl(t, stack.Start())
+	defer stack.Close()
+
+	config := rpcclient.TestClientConfig
+	client := NewConsensusRPCClient(func() *rpcclient.ClientConfig { return &config }, stack)
+	testhelpers.RequireImpl(t, client.Start(ctx))
+	defer client.StopAndWait()
+
+	batch, found, err := client.FindInboxBatchContainingMessage(25)
+	testhelpers.RequireImpl(t, err)
+	if batch != 2 || !found {
+		testhelpers.FailImpl(t, "unexpected batch", batch, found)
+	}
+	proof, err := client.GetBatchDataProof(ctx, 3)
+	testhelpers.RequireImpl(t, err)
+	if proof.SequenceNumber != 3 || proof.DataHash != (common.Hash{1}) || proof.ParentChainBlock != 103 {
+		testhelpers.FailImpl(t, "unexpected batch data proof", proof)
+	}
+	if count := client.SyncTargetMessageCount(); count != 42 {
+		testhelpers.FailImpl(t, "unexpected sync target", count)
+	}
+	if !client.Synced() {
+		testhelpers.FailImpl(t, "expected synced")
+	}
+	if _, err := client.GetFinalizedMsgCount(ctx); err == nil {
+		testhelpers.FailImpl(t, "expected error to be passed through")
+	}
+	err = client.WriteMessageFromSequencer(7, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	testhelpers.RequireImpl(t, err)
+	if len(consensus.written) != 1 || consensus.written[0] != 7 {
+		testhelpers.FailImpl(t, "message not written", consensus.written)
+	}
+}
+
+func TestConsensusRPCClientOverIPC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consensus := &mockConsensus{}
+	path := filepath.Join(t.TempDir(), "consensus.ipc")
+	server, err := NewIPCServer(path, ConsensusNamespace, NewConsensusServerAPI(consensus))
+	testhelpers.RequireImpl(t, err)
+	testhelpers.RequireImpl(t, server.Start(ctx))
+	defer server.StopAndWait()
+
+	config := rpcclient.TestClientConfig
+	config.URL = path
+	client := NewConsensusRPCClient(func() *rpcclient.ClientConfig { return &config }, nil)
+	testhelpers.RequireImpl(t, client.Start(ctx))
+	defer client.StopAndWait()
+
+	if count, err := client.ValidatedMessageCount(); err != nil || count != 30 {
+		testhelpers.FailImpl(t, "unexpected validated message count", count, err)
+	}
+	err = client.WriteMessageFromSequencer(9, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	testhelpers.RequireImpl(t, err)
+	if len(consensus.written) != 1 || consensus.written[0] != 9 {
+		testhelpers.FailImpl(t, "message not written", consensus.written)
+	}
+}
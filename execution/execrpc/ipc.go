@@ -0,0 +1,84 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+// IPCConfig is a unix socket serving one of the APIs, for a node in another process on the same host.
+// Clients connect by using the socket's path as their url.
+type IPCConfig struct {
+	Path string `koanf:"path"`
+}
+
+var DefaultIPCConfig = IPCConfig{
+	Path: "",
+}
+
+func IPCConfigAddOptions(prefix string, f *flag.FlagSet, api string) {
+	f.String(prefix+".path", DefaultIPCConfig.Path, "unix socket to serve the "+api+" api on, avoiding the overhead of the authenticated rpc endpoint when consensus and execution run on the same host (empty to disable)")
+}
+
+// IPCServer serves a single namespace over a unix socket only the node's user can connect to.
+type IPCServer struct {
+	stopwaiter.StopWaiter
+	path   string
+	server *rpc.Server
+}
+
+func NewIPCServer(path string, namespace string, service interface{}) (*IPCServer, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName(namespace, service); err != nil {
+		return nil, err
+	}
+	return &IPCServer{
+		path:   path,
+		server: server,
+	}, nil
+}
+
+func (s *IPCServer) Start(ctx context.Context) error {
+	// A socket left behind by an unclean shutdown would make listening fail
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing stale ipc socket %v: %w", s.path, err)
+	}
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("error listening on ipc socket %v: %w", s.path, err)
+	}
+	if err := os.Chmod(s.path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	s.StopWaiter.Start(ctx, s)
+	s.LaunchThread(func(ctx context.Context) {
+		<-ctx.Done()
+		listener.Close()
+		s.server.Stop()
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Warn("error removing ipc socket", "path", s.path, "err", err)
+		}
+	})
+	s.LaunchThread(func(ctx context.Context) {
+		err := s.server.ServeListener(listener)
+		if ctx.Err() == nil {
+			log.Error("ipc server stopped", "path", s.path, "err", err)
+		}
+	})
+	log.Info("serving api over ipc", "path", s.path)
+	return nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var addressActivityPrefix = []byte("arbitrum-address-activity-")
+
+// How an address appeared in a block, as a bitmask
+const (
+	addressActivitySender uint8 = 1 << iota
+	addressActivityRecipient
+	addressActivityLogEmitter
+	addressActivityLogTopic
+)
+
+var addressActivityRoleNames = []string{"sender", "recipient", "logEmitter", "logTopic"}
+
+// The block number is inverted so an address's activity is iterated newest first
+func addressActivityKey(address common.Address, block uint64) []byte {
+	key := append(append([]byte{}, addressActivityPrefix...), address.Bytes()...)
+	return binary.BigEndian.AppendUint64(key, ^block)
+}
+
+type AddressActivityIndexConfig struct {
+	Enable     bool `koanf:"enable"`
+	MaxResults int  `koanf:"max-results" reload:"hot"`
+}
+
+var DefaultAddressActivityIndexConfig = AddressActivityIndexConfig{
+	Enable:     false,
+	MaxResults: 1000,
+}
+
+func AddressActivityIndexConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAddressActivityIndexConfig.Enable, "index the blocks each address appeared in as a sender, recipient, log emitter or log topic as blocks are written, served by the arb_addressActivity RPC method")
+	f.Int(prefix+".max-results", DefaultAddressActivityIndexConfig.MaxResults, "maximum number of blocks arb_addressActivity returns in a page")
+}
+
+func (c *AddressActivityIndexConfig) Validate() error {
+	if c.MaxResults < 1 {
+		return errors.New("address activity index max-results must be positive")
+	}
+	return nil
+}
+
+// AddressActivityIndex records the blocks every address appeared in, so an account's history can be
+// listed without scanning the chain. Entries of blocks that were reorged out are left in place, and
+// skipped when read if their block hash is no longer canonical.
+type AddressActivityIndex struct {
+	db ethdb.KeyValueStore
+}
+
+func NewAddressActivityIndex(db ethdb.KeyValueStore) *AddressActivityIndex {
+	return &AddressActivityIndex{db: db}
+}
+
+// topicAddress returns the address a topic holds, if it looks like a left padded address.
+func topicAddress(topic common.Hash) (common.Address, bool) {
+	for _, b := range topic[:common.HashLength-common.AddressLength] {
+		if b != 0 {
+			return common.Address{}, false
+		}
+	}
+	address := common.BytesToAddress(topic[common.HashLength-common.AddressLength:])
+	return address, address != (common.Address{})
+}
+
+// indexBlock records the addresses that appeared in a block.
+func (i *AddressActivityIndex) indexBlock(block *types.Block, receipts types.Receipts, signer types.Signer) error {
+	roles := make(map[common.Address]uint8)
+	for txIndex, tx := range block.Transactions() {
+		if sender, err := types.Sender(signer, tx); err == nil {
+			roles[sender] |= addressActivitySender
+		}
+		if to := tx.To(); to != nil {
+			roles[*to] |= addressActivityRecipient
+		}
+		if txIndex >= len(receipts) {
+			continue
+		}
+		receipt := receipts[txIndex]
+		if receipt.ContractAddress != (common.Address{}) {
+			roles[receipt.ContractAddress] |= addressActivityRecipient
+		}
+		for _, txLog := range receipt.Logs {
+			roles[txLog.Address] |= addressActivityLogEmitter
+			for _, topic := range txLog.Topics {
+				if address, ok := topicAddress(topic); ok {
+					roles[address] |= addressActivityLogTopic
+				}
+			}
+		}
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	batch := i.db.NewBatch()
+	hash := block.Hash()
+	for address, role := range roles {
+		value := append(hash.Bytes(), role)
+		if err := batch.Put(addressActivityKey(address, block.NumberU64()), value); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+type AddressActivity struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Roles       []string       `json:"roles"`
+}
+
+// list returns up to limit entries of an address's activity at or below a block, newest first, and
+// whether there may be more.
+func (i *AddressActivityIndex) list(address common.Address, startBlock uint64, limit int) ([]AddressActivity, bool, error) {
+	prefix := append(append([]byte{}, addressActivityPrefix...), address.Bytes()...)
+	start := addressActivityKey(address, startBlock)[len(prefix):]
+	it := i.db.NewIterator(prefix, start)
+	defer it.Release()
+	var activity []AddressActivity
+	for it.Next() {
+		if len(activity) >= limit {
+			return activity, true, nil
+		}
+		key, value := it.Key(), it.Value()
+		if len(key) != len(prefix)+8 || len(value) != common.HashLength+1 {
+			return nil, false, errors.New("malformed address activity entry")
+		}
+		entry := AddressActivity{
+			BlockNumber: hexutil.Uint64(^binary.BigEndian.Uint64(key[len(prefix):])),
+			BlockHash:   common.BytesToHash(value[:common.HashLength]),
+		}
+		role := value[common.HashLength]
+		for bit, name := range addressActivityRoleNames {
+			if role&(1<<bit) != 0 {
+				entry.Roles = append(entry.Roles, name)
+			}
+		}
+		activity = append(activity, entry)
+	}
+	return activity, false, it.Error()
+}
+
+type AddressActivityPage struct {
+	Activity []AddressActivity `json:"activity"`
+	// The start block of the next page, null once all of the address's activity was returned
+	NextBlock *hexutil.Uint64 `json:"nextBlock"`
+}
+
+type AddressActivityAPI struct {
+	bc     *core.BlockChain
+	index  *AddressActivityIndex
+	config func() *AddressActivityIndexConfig
+}
+
+func NewAddressActivityAPI(bc *core.BlockChain, index *AddressActivityIndex, config func() *AddressActivityIndexConfig) *AddressActivityAPI {
+	return &AddressActivityAPI{bc, index, config}
+}
+
+// AddressActivity lists the blocks an address appeared in at or below startBlock, newest first, with how
+// it appeared in each. Blocks written before the index was enabled aren't listed.
+func (api *AddressActivityAPI) AddressActivity(_ context.Context, address common.Address, startBlock *rpc.BlockNumber, limit *int) (*AddressActivityPage, error) {
+	head := api.bc.CurrentBlock().Number.Uint64()
+	start := head
+	if startBlock != nil && *startBlock >= 0 && uint64(*startBlock) < head {
+		start = uint64(*startBlock)
+	}
+	maxResults := api.config().MaxResults
+	if limit != nil && *limit > 0 && *limit < maxResults {
+		maxResults = *limit
+	}
+	page := &AddressActivityPage{Activity: []AddressActivity{}}
+	for {
+		activity, more, err := api.index.list(address, start, maxResults-len(page.Activity))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range activity {
+			if api.bc.GetCanonicalHash(uint64(entry.BlockNumber)) == entry.BlockHash {
+				page.Activity = append(page.Activity, entry)
+			}
+		}
+		if !more {
+			return page, nil
+		}
+		next := activity[len(activity)-1].BlockNumber - 1
+		if len(page.Activity) >= maxResults {
+			page.NextBlock = &next
+			return page, nil
+		}
+		// Entries of reorged out blocks were skipped, so fill the rest of the page
+		start = uint64(next)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestAddressActivityIndex(t *testing.T) {
+	index := NewAddressActivityIndex(rawdb.NewMemoryDatabase())
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.HexToAddress("0x1234")
+	token := common.HexToAddress("0x5678")
+	holder := common.HexToAddress("0x9abc")
+
+	writeBlock := func(number int64, txs types.Transactions, receipts types.Receipts) {
+		t.Helper()
+		block := types.NewBlock(&types.Header{Number: big.NewInt(number)}, txs, nil, nil, trie.NewStackTrie(nil))
+		if err := index.indexBlock(block, receipts, signer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for number := int64(1); number <= 3; number++ {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: uint64(number), To: &recipient})
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful}
+		if number == 2 {
+			receipt.Logs = []*types.Log{{
+				Address: token,
+				Topics:  []common.Hash{crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), common.BytesToHash(sender.Bytes()), common.BytesToHash(holder.Bytes())},
+			}}
+		}
+		writeBlock(number, types.Transactions{tx}, types.Receipts{receipt})
+	}
+
+	activity, more, err := index.list(sender, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if more || len(activity) != 3 {
+		t.Fatal("unexpected sender activity", activity, more)
+	}
+	for i, entry := range activity {
+		if uint64(entry.BlockNumber) != uint64(3-i) {
+			t.Fatal("activity isn't newest first", activity)
+		}
+	}
+	if !reflect.DeepEqual(activity[1].Roles, []string{"sender", "logTopic"}) {
+		t.Fatal("unexpected roles", activity[1].Roles)
+	}
+
+	activity, more, err = index.list(recipient, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !more || len(activity) != 2 || activity[1].BlockNumber != 2 {
+		t.Fatal("limit wasn't applied", activity, more)
+	}
+	activity, more, err = index.list(recipient, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if more || len(activity) != 1 || activity[0].BlockNumber != 1 {
+		t.Fatal("start block wasn't applied", activity, more)
+	}
+
+	activity, _, err = index.list(holder, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || activity[0].BlockNumber != 2 || !reflect.DeepEqual(activity[0].Roles, []string{"logTopic"}) {
+		t.Fatal("unexpected holder activity", activity)
+	}
+	activity, _, err = index.list(token, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || !reflect.DeepEqual(activity[0].Roles, []string{"logEmitter"}) {
+		t.Fatal("unexpected token activity", activity)
+	}
+}
@@ -0,0 +1,637 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	analyticsCheckpointKey = []byte("arbitrum-analytics-export-checkpoint")
+
+	analyticsExportedBlocksCounter = metrics.NewRegisteredCounter("arb/analytics/exported/blocks", nil)
+	analyticsExportFailuresCounter = metrics.NewRegisteredCounter("arb/analytics/failures", nil)
+	analyticsReorgsCounter         = metrics.NewRegisteredCounter("arb/analytics/reorgs", nil)
+	analyticsLastBlockGauge        = metrics.NewRegisteredGauge("arb/analytics/lastblock", nil)
+)
+
+const (
+	AnalyticsSinkFile = "file"
+	AnalyticsSinkHTTP = "http"
+)
+
+type AnalyticsExportConfig struct {
+	Enable        bool          `koanf:"enable"`
+	Sink          string        `koanf:"sink"`
+	Dir           string        `koanf:"dir"`
+	URL           string        `koanf:"url"`
+	HTTPTimeout   time.Duration `koanf:"http-timeout"`
+	FromBlock     uint64        `koanf:"from-block"`
+	ChunkBlocks   uint64        `koanf:"chunk-blocks"`
+	WaitForBatch  bool          `koanf:"wait-for-batch"`
+	ReorgRewind   uint64        `koanf:"reorg-rewind"`
+	PollInterval  time.Duration `koanf:"poll-interval"`
+	RetryInterval time.Duration `koanf:"retry-interval"`
+}
+
+var DefaultAnalyticsExportConfig = AnalyticsExportConfig{
+	Enable:        false,
+	Sink:          AnalyticsSinkFile,
+	Dir:           "",
+	URL:           "",
+	HTTPTimeout:   time.Minute,
+	FromBlock:     0,
+	ChunkBlocks:   1000,
+	WaitForBatch:  true,
+	ReorgRewind:   64,
+	PollInterval:  time.Second,
+	RetryInterval: 10 * time.Second,
+}
+
+func AnalyticsExportConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAnalyticsExportConfig.Enable, "export blocks, transactions, receipts, logs, retryable activity and batch metadata to files or a warehouse loader, checkpointing progress so every block is delivered at least once")
+	f.String(prefix+".sink", DefaultAnalyticsExportConfig.Sink, "where to export to: \"file\" writes gzipped newline delimited JSON files per table, which warehouses such as BigQuery load directly, and \"http\" POSTs each chunk as JSON")
+	f.String(prefix+".dir", DefaultAnalyticsExportConfig.Dir, "directory the file sink writes to (defaults to analytics in the node's data directory)")
+	f.String(prefix+".url", DefaultAnalyticsExportConfig.URL, "URL the http sink POSTs chunks to")
+	f.Duration(prefix+".http-timeout", DefaultAnalyticsExportConfig.HTTPTimeout, "timeout for posting a chunk to the http sink")
+	f.Uint64(prefix+".from-block", DefaultAnalyticsExportConfig.FromBlock, "block to start exporting from when there's no checkpoint yet")
+	f.Uint64(prefix+".chunk-blocks", DefaultAnalyticsExportConfig.ChunkBlocks, "maximum number of blocks in an exported chunk")
+	f.Bool(prefix+".wait-for-batch", DefaultAnalyticsExportConfig.WaitForBatch, "only export blocks once they're posted in a batch, so they're unlikely to be reorged and their batch is known")
+	f.Uint64(prefix+".reorg-rewind", DefaultAnalyticsExportConfig.ReorgRewind, "how many blocks before the checkpoint to export again if the checkpoint block was reorged out")
+	f.Duration(prefix+".poll-interval", DefaultAnalyticsExportConfig.PollInterval, "how often to check for new blocks to export")
+	f.Duration(prefix+".retry-interval", DefaultAnalyticsExportConfig.RetryInterval, "how long to wait before retrying a chunk that failed to export")
+}
+
+func (c *AnalyticsExportConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	switch c.Sink {
+	case AnalyticsSinkFile:
+	case AnalyticsSinkHTTP:
+		parsed, err := url.Parse(c.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("analytics export url \"%v\" must be an http or https url", c.URL)
+		}
+		if c.HTTPTimeout <= 0 {
+			return errors.New("analytics export http-timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown analytics export sink \"%v\"", c.Sink)
+	}
+	if c.ChunkBlocks == 0 {
+		return errors.New("analytics export chunk-blocks must be positive")
+	}
+	if c.PollInterval <= 0 || c.RetryInterval <= 0 {
+		return errors.New("analytics export poll-interval and retry-interval must be positive")
+	}
+	return nil
+}
+
+// Exported rows. Blocks that were reorged out may have been exported already, so consumers should keep the
+// most recently exported row for each block number, and drop rows of other tables whose block hash differs.
+
+type AnalyticsBlock struct {
+	Number                uint64         `json:"number"`
+	Hash                  common.Hash    `json:"hash"`
+	ParentHash            common.Hash    `json:"parentHash"`
+	Timestamp             uint64         `json:"timestamp"`
+	GasUsed               uint64         `json:"gasUsed"`
+	GasLimit              uint64         `json:"gasLimit"`
+	BaseFee               *hexutil.Big   `json:"baseFee"`
+	Miner                 common.Address `json:"miner"`
+	TxCount               int            `json:"txCount"`
+	L1BlockNumber         uint64         `json:"l1BlockNumber"`
+	SendRoot              common.Hash    `json:"sendRoot"`
+	SendCount             uint64         `json:"sendCount"`
+	ArbOSVersion          uint64         `json:"arbosVersion"`
+	Batch                 *uint64        `json:"batch"`
+	BatchParentChainBlock *uint64        `json:"batchParentChainBlock"`
+}
+
+type AnalyticsTransaction struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	Index       int             `json:"index"`
+	Hash        common.Hash     `json:"hash"`
+	Type        uint8           `json:"type"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Nonce       uint64          `json:"nonce"`
+	Value       *hexutil.Big    `json:"value"`
+	Gas         uint64          `json:"gas"`
+	GasPrice    *hexutil.Big    `json:"gasPrice"`
+	GasFeeCap   *hexutil.Big    `json:"gasFeeCap"`
+	GasTipCap   *hexutil.Big    `json:"gasTipCap"`
+	Input       hexutil.Bytes   `json:"input"`
+}
+
+type AnalyticsReceipt struct {
+	BlockNumber       uint64          `json:"blockNumber"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	TxIndex           int             `json:"txIndex"`
+	TxHash            common.Hash     `json:"txHash"`
+	Status            uint64          `json:"status"`
+	GasUsed           uint64          `json:"gasUsed"`
+	GasUsedForL1      uint64          `json:"gasUsedForL1"`
+	CumulativeGasUsed uint64          `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	LogCount          int             `json:"logCount"`
+}
+
+type AnalyticsLog struct {
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxIndex     int            `json:"txIndex"`
+	TxHash      common.Hash    `json:"txHash"`
+	LogIndex    uint           `json:"logIndex"`
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+}
+
+const (
+	AnalyticsRetryableCreated  = "created"
+	AnalyticsRetryableRedeemed = "redeem_attempt"
+	AnalyticsRetryableCanceled = "canceled"
+)
+
+type AnalyticsRetryable struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	TxHash      common.Hash     `json:"txHash"`
+	TicketId    common.Hash     `json:"ticketId"`
+	Event       string          `json:"event"`
+	RequestId   *common.Hash    `json:"requestId,omitempty"`
+	From        *common.Address `json:"from,omitempty"`
+	Beneficiary *common.Address `json:"beneficiary,omitempty"`
+	RetryTo     *common.Address `json:"retryTo,omitempty"`
+	Callvalue   *hexutil.Big    `json:"callvalue,omitempty"`
+	Succeeded   bool            `json:"succeeded"`
+}
+
+type AnalyticsBatch struct {
+	SequenceNumber   uint64 `json:"sequenceNumber"`
+	ParentChainBlock uint64 `json:"parentChainBlock"`
+	// The first block of the batch that was exported, which is the batch's first block unless the export
+	// started partway through it
+	FirstBlock uint64 `json:"firstBlock"`
+}
+
+// AnalyticsChunk holds the rows of the consecutive blocks [FirstBlock, LastBlock].
+type AnalyticsChunk struct {
+	FirstBlock   uint64                 `json:"firstBlock"`
+	LastBlock    uint64                 `json:"lastBlock"`
+	Blocks       []AnalyticsBlock       `json:"blocks"`
+	Transactions []AnalyticsTransaction `json:"transactions"`
+	Receipts     []AnalyticsReceipt     `json:"receipts"`
+	Logs         []AnalyticsLog         `json:"logs"`
+	Retryables   []AnalyticsRetryable   `json:"retryables"`
+	Batches      []AnalyticsBatch       `json:"batches"`
+}
+
+// AnalyticsSink stores exported chunks. A chunk may be written again after a crash or reorg, so writing the
+// same block range again should replace what was written for it.
+type AnalyticsSink interface {
+	WriteChunk(ctx context.Context, chunk *AnalyticsChunk) error
+}
+
+// analyticsFileSink writes each table of a chunk to <dir>/<table>/<first block>-<last block>.json.gz.
+type analyticsFileSink struct {
+	dir string
+}
+
+func encodeAnalyticsRows[T any](rows []T) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for i := range rows {
+		if err := encoder.Encode(&rows[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *analyticsFileSink) writeTable(table string, chunk *AnalyticsChunk, data []byte) error {
+	dir := filepath.Join(s.dir, table)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so a crash never leaves a partial file under the final name
+	name := filepath.Join(dir, fmt.Sprintf("%012d-%012d.json.gz", chunk.FirstBlock, chunk.LastBlock))
+	tmp := name + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (s *analyticsFileSink) WriteChunk(_ context.Context, chunk *AnalyticsChunk) error {
+	tables := []struct {
+		name   string
+		encode func() ([]byte, error)
+	}{
+		{"blocks", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Blocks) }},
+		{"transactions", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Transactions) }},
+		{"receipts", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Receipts) }},
+		{"logs", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Logs) }},
+		{"retryables", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Retryables) }},
+		{"batches", func() ([]byte, error) { return encodeAnalyticsRows(chunk.Batches) }},
+	}
+	for _, table := range tables {
+		data, err := table.encode()
+		if err == nil {
+			err = s.writeTable(table.name, chunk, data)
+		}
+		if err != nil {
+			return fmt.Errorf("writing analytics table %v: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// analyticsHTTPSink POSTs each chunk as JSON. The Idempotency-Key header identifies the block range, so the
+// receiver can recognize a chunk delivered again.
+type analyticsHTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *analyticsHTTPSink) WriteChunk(ctx context.Context, chunk *AnalyticsChunk) error {
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("%v-%v-%v", chunk.FirstBlock, chunk.LastBlock, chunk.Blocks[len(chunk.Blocks)-1].Hash))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("analytics sink returned status %v", resp.Status)
+	}
+	return nil
+}
+
+func NewAnalyticsSink(config *AnalyticsExportConfig, defaultDir string) (AnalyticsSink, error) {
+	switch config.Sink {
+	case AnalyticsSinkFile:
+		dir := config.Dir
+		if dir == "" {
+			dir = defaultDir
+		}
+		return &analyticsFileSink{dir: dir}, nil
+	case AnalyticsSinkHTTP:
+		return &analyticsHTTPSink{url: config.URL, client: &http.Client{Timeout: config.HTTPTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown analytics export sink \"%v\"", config.Sink)
+	}
+}
+
+// analyticsCheckpoint is the last block that was exported.
+type analyticsCheckpoint struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// AnalyticsExporter follows the chain and exports each block's rows to a sink. Progress is only checkpointed
+// once the sink has stored a chunk, so blocks are delivered at least once across crashes.
+type AnalyticsExporter struct {
+	stopwaiter.StopWaiter
+	config  *AnalyticsExportConfig
+	db      ethdb.KeyValueStore
+	bc      *core.BlockChain
+	batches func() BatchPostingInfo
+	sink    AnalyticsSink
+
+	lastBatch *uint64
+}
+
+// BatchPostingInfo is what the exporter needs to know about posted batches.
+type BatchPostingInfo interface {
+	FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error)
+	GetBatchParentChainBlock(seqNum uint64) (uint64, error)
+	BatchPostedMessageCount() (arbutil.MessageIndex, error)
+}
+
+// NewAnalyticsExporter creates an exporter. batches returns the consensus client, which is set after the
+// execution node is created, or nil if there's none yet.
+func NewAnalyticsExporter(config *AnalyticsExportConfig, db ethdb.KeyValueStore, bc *core.BlockChain, batches func() BatchPostingInfo, sink AnalyticsSink) *AnalyticsExporter {
+	return &AnalyticsExporter{
+		config:  config,
+		db:      db,
+		bc:      bc,
+		batches: batches,
+		sink:    sink,
+	}
+}
+
+func (e *AnalyticsExporter) Start(ctx context.Context) {
+	e.StopWaiter.Start(ctx, e)
+	e.CallIteratively(func(ctx context.Context) time.Duration {
+		exported, err := e.exportNext(ctx)
+		if err != nil {
+			analyticsExportFailuresCounter.Inc(1)
+			log.Warn("failed to export analytics chunk", "err", err)
+			return e.config.RetryInterval
+		}
+		if exported {
+			return 0
+		}
+		return e.config.PollInterval
+	})
+}
+
+func (e *AnalyticsExporter) readCheckpoint() (*analyticsCheckpoint, error) {
+	has, err := e.db.Has(analyticsCheckpointKey)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := e.db.Get(analyticsCheckpointKey)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint analyticsCheckpoint
+	if err := rlp.DecodeBytes(data, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (e *AnalyticsExporter) writeCheckpoint(checkpoint analyticsCheckpoint) error {
+	data, err := rlp.EncodeToBytes(checkpoint)
+	if err != nil {
+		return err
+	}
+	return e.db.Put(analyticsCheckpointKey, data)
+}
+
+// nextBlock returns the first block to export, rewinding if the checkpoint was reorged out.
+func (e *AnalyticsExporter) nextBlock() (uint64, error) {
+	checkpoint, err := e.readCheckpoint()
+	if err != nil || checkpoint == nil {
+		return e.config.FromBlock, err
+	}
+	if e.bc.GetCanonicalHash(checkpoint.Number) == checkpoint.Hash {
+		return checkpoint.Number + 1, nil
+	}
+	analyticsReorgsCounter.Inc(1)
+	next := e.config.FromBlock
+	if checkpoint.Number > e.config.ReorgRewind && checkpoint.Number-e.config.ReorgRewind > next {
+		next = checkpoint.Number - e.config.ReorgRewind
+	}
+	log.Warn("analytics export checkpoint was reorged out, exporting again", "checkpoint", checkpoint.Number, "from", next)
+	e.lastBatch = nil
+	return next, nil
+}
+
+// exportableHead returns the last block that can be exported.
+func (e *AnalyticsExporter) exportableHead() (uint64, bool, error) {
+	head := e.bc.CurrentBlock().Number.Uint64()
+	if !e.config.WaitForBatch {
+		return head, true, nil
+	}
+	batches := e.batches()
+	if batches == nil {
+		return 0, false, nil
+	}
+	posted, err := batches.BatchPostedMessageCount()
+	if err != nil || posted == 0 {
+		return 0, false, err
+	}
+	genesis := e.bc.Config().ArbitrumChainParams.GenesisBlockNum
+	// #nosec G115
+	postedHead := uint64(arbutil.MessageCountToBlockNumber(posted, genesis))
+	return min(head, postedHead), true, nil
+}
+
+// exportNext exports the next chunk, if there are new blocks.
+func (e *AnalyticsExporter) exportNext(ctx context.Context) (bool, error) {
+	next, err := e.nextBlock()
+	if err != nil {
+		return false, err
+	}
+	head, ok, err := e.exportableHead()
+	if err != nil || !ok || head < next {
+		return false, err
+	}
+	last := min(head, next+e.config.ChunkBlocks-1)
+	chunk, lastBatch, err := e.buildChunk(next, last)
+	if err != nil {
+		return false, err
+	}
+	if err := e.sink.WriteChunk(ctx, chunk); err != nil {
+		return false, err
+	}
+	lastBlock := chunk.Blocks[len(chunk.Blocks)-1]
+	if err := e.writeCheckpoint(analyticsCheckpoint{Number: lastBlock.Number, Hash: lastBlock.Hash}); err != nil {
+		return false, err
+	}
+	e.lastBatch = lastBatch
+	analyticsExportedBlocksCounter.Inc(int64(len(chunk.Blocks)))
+	// #nosec G115
+	analyticsLastBlockGauge.Update(int64(lastBlock.Number))
+	return true, nil
+}
+
+// buildChunk returns the rows of blocks [first, last] and the last batch they were posted in.
+func (e *AnalyticsExporter) buildChunk(first, last uint64) (*AnalyticsChunk, *uint64, error) {
+	chunk := &AnalyticsChunk{FirstBlock: first, LastBlock: last}
+	lastBatch := e.lastBatch
+	genesis := e.bc.Config().ArbitrumChainParams.GenesisBlockNum
+	batches := e.batches()
+	for number := first; number <= last; number++ {
+		block := e.bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, nil, fmt.Errorf("block %v not found", number)
+		}
+		receipts := e.bc.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(block.Transactions()) {
+			return nil, nil, fmt.Errorf("block %v has %v transactions but %v receipts", number, len(block.Transactions()), len(receipts))
+		}
+		signer := types.MakeSigner(e.bc.Config(), block.Number(), block.Time())
+		blockRow := addAnalyticsBlockRows(chunk, block, receipts, signer)
+		if batches == nil || number < genesis {
+			continue
+		}
+		msgIdx := arbutil.BlockNumberToMessageCount(number, genesis) - 1
+		batch, found, err := batches.FindInboxBatchContainingMessage(msgIdx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+		parentChainBlock, err := batches.GetBatchParentChainBlock(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		blockRow.Batch = &batch
+		blockRow.BatchParentChainBlock = &parentChainBlock
+		if lastBatch == nil || *lastBatch != batch {
+			chunk.Batches = append(chunk.Batches, AnalyticsBatch{
+				SequenceNumber:   batch,
+				ParentChainBlock: parentChainBlock,
+				FirstBlock:       number,
+			})
+			lastBatch = &batch
+		}
+	}
+	return chunk, lastBatch, nil
+}
+
+// addAnalyticsBlockRows appends the rows of a block to the chunk and returns its block row.
+func addAnalyticsBlockRows(chunk *AnalyticsChunk, block *types.Block, receipts types.Receipts, signer types.Signer) *AnalyticsBlock {
+	header := block.Header()
+	info := types.DeserializeHeaderExtraInformation(header)
+	chunk.Blocks = append(chunk.Blocks, AnalyticsBlock{
+		Number:        block.NumberU64(),
+		Hash:          block.Hash(),
+		ParentHash:    block.ParentHash(),
+		Timestamp:     block.Time(),
+		GasUsed:       block.GasUsed(),
+		GasLimit:      block.GasLimit(),
+		BaseFee:       (*hexutil.Big)(block.BaseFee()),
+		Miner:         block.Coinbase(),
+		TxCount:       len(block.Transactions()),
+		L1BlockNumber: info.L1BlockNumber,
+		SendRoot:      info.SendRoot,
+		SendCount:     info.SendCount,
+		ArbOSVersion:  info.ArbOSFormatVersion,
+	})
+	blockRow := &chunk.Blocks[len(chunk.Blocks)-1]
+	number, hash := block.NumberU64(), block.Hash()
+	for i, tx := range block.Transactions() {
+		receipt := receipts[i]
+		from, _ := types.Sender(signer, tx)
+		chunk.Transactions = append(chunk.Transactions, AnalyticsTransaction{
+			BlockNumber: number,
+			BlockHash:   hash,
+			Index:       i,
+			Hash:        tx.Hash(),
+			Type:        tx.Type(),
+			From:        from,
+			To:          tx.To(),
+			Nonce:       tx.Nonce(),
+			Value:       (*hexutil.Big)(tx.Value()),
+			Gas:         tx.Gas(),
+			GasPrice:    (*hexutil.Big)(tx.GasPrice()),
+			GasFeeCap:   (*hexutil.Big)(tx.GasFeeCap()),
+			GasTipCap:   (*hexutil.Big)(tx.GasTipCap()),
+			Input:       tx.Data(),
+		})
+		receiptRow := AnalyticsReceipt{
+			BlockNumber:       number,
+			BlockHash:         hash,
+			TxIndex:           i,
+			TxHash:            tx.Hash(),
+			Status:            receipt.Status,
+			GasUsed:           receipt.GasUsed,
+			GasUsedForL1:      receipt.GasUsedForL1,
+			CumulativeGasUsed: receipt.CumulativeGasUsed,
+			EffectiveGasPrice: (*hexutil.Big)(receipt.EffectiveGasPrice),
+			LogCount:          len(receipt.Logs),
+		}
+		if receipt.ContractAddress != (common.Address{}) {
+			contract := receipt.ContractAddress
+			receiptRow.ContractAddress = &contract
+		}
+		chunk.Receipts = append(chunk.Receipts, receiptRow)
+		for _, txLog := range receipt.Logs {
+			chunk.Logs = append(chunk.Logs, AnalyticsLog{
+				BlockNumber: number,
+				BlockHash:   hash,
+				TxIndex:     i,
+				TxHash:      tx.Hash(),
+				LogIndex:    txLog.Index,
+				Address:     txLog.Address,
+				Topics:      txLog.Topics,
+				Data:        txLog.Data,
+			})
+			if txLog.Address == types.ArbRetryableTxAddress && len(txLog.Topics) >= 2 && txLog.Topics[0] == retryableCanceledEventID {
+				chunk.Retryables = append(chunk.Retryables, AnalyticsRetryable{
+					BlockNumber: number,
+					BlockHash:   hash,
+					TxHash:      tx.Hash(),
+					TicketId:    txLog.Topics[1],
+					Event:       AnalyticsRetryableCanceled,
+					Succeeded:   true,
+				})
+			}
+		}
+		switch inner := tx.GetInner().(type) {
+		case *types.ArbitrumSubmitRetryableTx:
+			chunk.Retryables = append(chunk.Retryables, AnalyticsRetryable{
+				BlockNumber: number,
+				BlockHash:   hash,
+				TxHash:      tx.Hash(),
+				TicketId:    tx.Hash(),
+				Event:       AnalyticsRetryableCreated,
+				RequestId:   &inner.RequestId,
+				From:        &inner.From,
+				Beneficiary: &inner.Beneficiary,
+				RetryTo:     inner.RetryTo,
+				Callvalue:   (*hexutil.Big)(inner.RetryValue),
+				Succeeded:   receipt.Status == types.ReceiptStatusSuccessful,
+			})
+		case *types.ArbitrumRetryTx:
+			chunk.Retryables = append(chunk.Retryables, AnalyticsRetryable{
+				BlockNumber: number,
+				BlockHash:   hash,
+				TxHash:      tx.Hash(),
+				TicketId:    inner.TicketId,
+				Event:       AnalyticsRetryableRedeemed,
+				Succeeded:   receipt.Status == types.ReceiptStatusSuccessful,
+			})
+		}
+	}
+	return blockRow
+}
@@ -0,0 +1,173 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func analyticsTestChunk(t *testing.T) *AnalyticsChunk {
+	t.Helper()
+	submit := types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:     big.NewInt(1),
+		RequestId:   common.HexToHash("0x01"),
+		From:        common.HexToAddress("0xaa"),
+		Beneficiary: common.HexToAddress("0xbb"),
+		RetryValue:  big.NewInt(5),
+	})
+	retry := types.NewTx(&types.ArbitrumRetryTx{ChainId: big.NewInt(1), TicketId: submit.Hash()})
+	header := &types.Header{Number: big.NewInt(7), Time: 100, BaseFee: big.NewInt(1)}
+	block := types.NewBlock(header, types.Transactions{submit, retry}, nil, nil, trie.NewStackTrie(nil))
+	canceled := common.HexToHash("0x02")
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, GasUsed: 10, GasUsedForL1: 3},
+		{Status: types.ReceiptStatusFailed, Logs: []*types.Log{{
+			Address: types.ArbRetryableTxAddress,
+			Topics:  []common.Hash{retryableCanceledEventID, canceled},
+		}}},
+	}
+	chunk := &AnalyticsChunk{FirstBlock: 7, LastBlock: 7}
+	blockRow := addAnalyticsBlockRows(chunk, block, receipts, types.LatestSignerForChainID(big.NewInt(1)))
+	batch := uint64(3)
+	blockRow.Batch = &batch
+	chunk.Batches = append(chunk.Batches, AnalyticsBatch{SequenceNumber: batch, ParentChainBlock: 50, FirstBlock: 7})
+	return chunk
+}
+
+func TestAnalyticsRows(t *testing.T) {
+	chunk := analyticsTestChunk(t)
+	if len(chunk.Blocks) != 1 || chunk.Blocks[0].TxCount != 2 || *chunk.Blocks[0].Batch != 3 {
+		t.Fatal("unexpected block rows", chunk.Blocks)
+	}
+	if len(chunk.Transactions) != 2 || len(chunk.Receipts) != 2 || len(chunk.Logs) != 1 {
+		t.Fatal("unexpected row counts", len(chunk.Transactions), len(chunk.Receipts), len(chunk.Logs))
+	}
+	if chunk.Receipts[0].GasUsedForL1 != 3 || chunk.Receipts[1].LogCount != 1 {
+		t.Fatal("unexpected receipt rows", chunk.Receipts)
+	}
+	ticketId := chunk.Transactions[0].Hash
+	var events []string
+	for _, row := range chunk.Retryables {
+		events = append(events, row.Event)
+		switch row.Event {
+		case AnalyticsRetryableCreated:
+			if row.TicketId != ticketId || row.RequestId == nil || *row.RequestId != common.HexToHash("0x01") || !row.Succeeded {
+				t.Fatal("unexpected creation row", row)
+			}
+		case AnalyticsRetryableRedeemed:
+			if row.TicketId != ticketId || row.Succeeded {
+				t.Fatal("unexpected redemption row", row)
+			}
+		case AnalyticsRetryableCanceled:
+			if row.TicketId != common.HexToHash("0x02") {
+				t.Fatal("unexpected cancellation row", row)
+			}
+		}
+	}
+	if len(events) != 3 {
+		t.Fatal("unexpected retryable rows", events)
+	}
+}
+
+func TestAnalyticsFileSink(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultAnalyticsExportConfig
+	config.Enable = true
+	config.Dir = dir
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewAnalyticsSink(&config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := analyticsTestChunk(t)
+	// Writing a chunk again replaces it
+	for i := 0; i < 2; i++ {
+		if err := sink.WriteChunk(context.Background(), chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := os.Open(filepath.Join(dir, "retryables", "000000000007-000000000007.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(gz)
+	var rows []AnalyticsRetryable
+	for scanner.Scan() {
+		var row AnalyticsRetryable
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(chunk.Retryables) || rows[0].TicketId != chunk.Retryables[0].TicketId {
+		t.Fatal("unexpected rows read back", rows)
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "*.tmp"))
+	if err != nil || len(matches) != 0 {
+		t.Fatal("temporary files left behind", matches, err)
+	}
+}
+
+func TestAnalyticsHTTPSink(t *testing.T) {
+	chunk := analyticsTestChunk(t)
+	fail := true
+	var received AnalyticsChunk
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		key = r.Header.Get("Idempotency-Key")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultAnalyticsExportConfig
+	config.Enable = true
+	config.Sink = AnalyticsSinkHTTP
+	config.URL = server.URL
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewAnalyticsSink(&config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.WriteChunk(context.Background(), chunk); err == nil {
+		t.Fatal("chunk rejected by the sink wasn't an error")
+	}
+	fail = false
+	if err := sink.WriteChunk(context.Background(), chunk); err != nil {
+		t.Fatal(err)
+	}
+	if received.FirstBlock != 7 || len(received.Receipts) != 2 || key == "" {
+		t.Fatal("unexpected chunk received", received.FirstBlock, len(received.Receipts), key)
+	}
+}
@@ -37,12 +37,19 @@ func (a *ArbAPI) CheckPublisherHealth(ctx context.Context) error {
 
 type ArbDebugAPI struct {
 	blockchain        *core.BlockChain
+	recreator         *StateRecreator
+	converter         *StateConverter
 	blockRangeBound   uint64
 	timeoutQueueBound uint64
 }
 
-func NewArbDebugAPI(blockchain *core.BlockChain, blockRangeBound uint64, timeoutQueueBound uint64) *ArbDebugAPI {
-	return &ArbDebugAPI{blockchain, blockRangeBound, timeoutQueueBound}
+func NewArbDebugAPI(blockchain *core.BlockChain, recreator *StateRecreator, converter *StateConverter, blockRangeBound uint64, timeoutQueueBound uint64) *ArbDebugAPI {
+	return &ArbDebugAPI{blockchain, recreator, converter, blockRangeBound, timeoutQueueBound}
+}
+
+// StateConversionProgress reports the progress of converting the database to an archive.
+func (api *ArbDebugAPI) StateConversionProgress(ctx context.Context) StateConversionProgress {
+	return api.converter.Progress()
 }
 
 type PricingModelHistory struct {
@@ -119,7 +126,7 @@ func (api *ArbDebugAPI) PricingModel(ctx context.Context, start, end rpc.BlockNu
 	}
 
 	for i := uint64(0); i < blocks; i++ {
-		state, header, err := stateAndHeader(api.blockchain, first+i*step)
+		state, header, err := api.stateAndHeader(ctx, first+i*step)
 		if err != nil {
 			return history, err
 		}
@@ -201,7 +208,7 @@ func (api *ArbDebugAPI) TimeoutQueueHistory(ctx context.Context, start, end rpc.
 	}
 
 	for i := uint64(0); i < blocks; i++ {
-		state, _, err := stateAndHeader(api.blockchain, first+i*step)
+		state, _, err := api.stateAndHeader(ctx, first+i*step)
 		if err != nil {
 			return history, err
 		}
@@ -232,7 +239,7 @@ func (api *ArbDebugAPI) TimeoutQueue(ctx context.Context, blockNum rpc.BlockNumb
 	}
 
 	// #nosec G115
-	state, _, err := stateAndHeader(api.blockchain, uint64(blockNum))
+	state, _, err := api.stateAndHeader(ctx, uint64(blockNum))
 	if err != nil {
 		return queue, err
 	}
@@ -268,12 +275,15 @@ func (api *ArbDebugAPI) TimeoutQueue(ctx context.Context, blockNum rpc.BlockNumb
 	return queue, err
 }
 
-func stateAndHeader(blockchain *core.BlockChain, block uint64) (*arbosState.ArbosState, *types.Header, error) {
-	header := blockchain.GetHeaderByNumber(block)
-	if !blockchain.Config().IsArbitrumNitro(header.Number) {
+func (api *ArbDebugAPI) stateAndHeader(ctx context.Context, block uint64) (*arbosState.ArbosState, *types.Header, error) {
+	header := api.blockchain.GetHeaderByNumber(block)
+	if header == nil {
+		return nil, nil, fmt.Errorf("block %v not found", block)
+	}
+	if !api.blockchain.Config().IsArbitrumNitro(header.Number) {
 		return nil, nil, types.ErrUseFallback
 	}
-	statedb, err := blockchain.StateAt(header.Root)
+	statedb, err := api.recreator.StateAt(ctx, header)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,235 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+
+	"github.com/offchainlabs/nitro/arbos"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("arbitrumTxTracer", newArbitrumTxTracer, false)
+}
+
+// Kinds of transactions in ArbitrumTxTrace
+const (
+	ArbitrumTxStartBlock         = "startBlock"
+	ArbitrumTxBatchPostingReport = "batchPostingReport"
+	ArbitrumTxRetryRedeem        = "retryRedeem"
+	ArbitrumTxSubmitRetryable    = "submitRetryable"
+	ArbitrumTxDeposit            = "deposit"
+	ArbitrumTxUnsigned           = "unsigned"
+	ArbitrumTxContract           = "contract"
+	ArbitrumTxSigned             = "signed"
+)
+
+// Phases of a transaction ArbitrumTransfer happen in
+const (
+	ArbitrumTransferBeforeEVM = "beforeEVM"
+	ArbitrumTransferDuringEVM = "duringEVM"
+	ArbitrumTransferAfterEVM  = "afterEVM"
+)
+
+// ArbitrumTransfer is a movement of funds ArbOS made, or a call carrying value.
+// From is omitted for mints and To for burns.
+type ArbitrumTransfer struct {
+	From    *common.Address `json:"from,omitempty"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value"`
+	Phase   string          `json:"phase"`
+	Purpose string          `json:"purpose"`
+}
+
+type BalanceChange struct {
+	Address common.Address `json:"address"`
+	Prev    *hexutil.Big   `json:"prev"`
+	New     *hexutil.Big   `json:"new"`
+}
+
+type StorageChange struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+	Prev    common.Hash    `json:"prev"`
+	New     common.Hash    `json:"new"`
+}
+
+// ArbitrumTxTrace is the result of arbitrumTxTracer.
+type ArbitrumTxTrace struct {
+	Kind string `json:"kind"`
+	// Whether ArbOS created the transaction, rather than it being signed or sent from the parent chain
+	Synthetic bool               `json:"synthetic"`
+	TicketId  *common.Hash       `json:"ticketId,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	Transfers []ArbitrumTransfer `json:"transfers"`
+	// Net changes, in the order the accounts and slots were first touched
+	BalanceChanges []BalanceChange `json:"balanceChanges"`
+	StorageChanges []StorageChange `json:"storageChanges"`
+}
+
+type storageSlot struct {
+	address common.Address
+	slot    common.Hash
+}
+
+// arbitrumTxTracer labels the transactions ArbOS makes, such as the start of block transaction and retryable
+// redeems, and reports every transfer and state change a transaction made, including the fee collection
+// ArbOS does outside the EVM, which call traces don't show.
+type arbitrumTxTracer struct {
+	result       ArbitrumTxTrace
+	balances     map[common.Address]*BalanceChange
+	balanceOrder []common.Address
+	storage      map[storageSlot]*StorageChange
+	storageOrder []storageSlot
+	interrupt    atomic.Bool
+	reason       error
+}
+
+func newArbitrumTxTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := &arbitrumTxTracer{
+		result:   ArbitrumTxTrace{Transfers: []ArbitrumTransfer{}},
+		balances: make(map[common.Address]*BalanceChange),
+		storage:  make(map[storageSlot]*StorageChange),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:               t.OnTxStart,
+			OnTxEnd:                 t.OnTxEnd,
+			OnEnter:                 t.OnEnter,
+			OnBalanceChange:         t.OnBalanceChange,
+			OnStorageChange:         t.OnStorageChange,
+			CaptureArbitrumTransfer: t.CaptureArbitrumTransfer,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *arbitrumTxTracer) OnTxStart(_ *tracing.VMContext, tx *types.Transaction, _ common.Address) {
+	t.result.Kind = ArbitrumTxSigned
+	switch inner := tx.GetInner().(type) {
+	case *types.ArbitrumInternalTx:
+		t.result.Synthetic = true
+		t.result.Kind = ArbitrumTxBatchPostingReport
+		if bytes.HasPrefix(inner.Data, arbos.InternalTxStartBlockMethodID[:]) {
+			t.result.Kind = ArbitrumTxStartBlock
+		}
+	case *types.ArbitrumRetryTx:
+		t.result.Synthetic = true
+		t.result.Kind = ArbitrumTxRetryRedeem
+		ticketId := inner.TicketId
+		t.result.TicketId = &ticketId
+	case *types.ArbitrumSubmitRetryableTx:
+		t.result.Kind = ArbitrumTxSubmitRetryable
+		ticketId := tx.Hash()
+		t.result.TicketId = &ticketId
+	case *types.ArbitrumDepositTx:
+		t.result.Kind = ArbitrumTxDeposit
+	case *types.ArbitrumUnsignedTx:
+		t.result.Kind = ArbitrumTxUnsigned
+	case *types.ArbitrumContractTx:
+		t.result.Kind = ArbitrumTxContract
+	}
+}
+
+func (t *arbitrumTxTracer) OnTxEnd(_ *types.Receipt, err error) {
+	if err != nil {
+		t.result.Error = err.Error()
+	}
+}
+
+func (t *arbitrumTxTracer) OnEnter(_ int, _ byte, from common.Address, to common.Address, _ []byte, _ uint64, value *big.Int) {
+	if t.interrupt.Load() || value == nil || value.Sign() == 0 {
+		return
+	}
+	// ArbOS moves funds during the EVM as mock calls, which are from the zero address for mints
+	transfer := ArbitrumTransfer{To: &to, Value: (*hexutil.Big)(new(big.Int).Set(value)), Phase: ArbitrumTransferDuringEVM, Purpose: "call"}
+	if from != (common.Address{}) {
+		transfer.From = &from
+	}
+	t.result.Transfers = append(t.result.Transfers, transfer)
+}
+
+func (t *arbitrumTxTracer) CaptureArbitrumTransfer(from, to *common.Address, value *big.Int, before bool, purpose string) {
+	if t.interrupt.Load() {
+		return
+	}
+	phase := ArbitrumTransferAfterEVM
+	if before {
+		phase = ArbitrumTransferBeforeEVM
+	}
+	transfer := ArbitrumTransfer{Value: (*hexutil.Big)(new(big.Int).Set(value)), Phase: phase, Purpose: purpose}
+	if from != nil {
+		sender := *from
+		transfer.From = &sender
+	}
+	if to != nil {
+		recipient := *to
+		transfer.To = &recipient
+	}
+	t.result.Transfers = append(t.result.Transfers, transfer)
+}
+
+func (t *arbitrumTxTracer) OnBalanceChange(address common.Address, prevBalance, newBalance *big.Int, _ tracing.BalanceChangeReason) {
+	if t.interrupt.Load() {
+		return
+	}
+	change, ok := t.balances[address]
+	if !ok {
+		change = &BalanceChange{Address: address, Prev: (*hexutil.Big)(new(big.Int).Set(prevBalance))}
+		t.balances[address] = change
+		t.balanceOrder = append(t.balanceOrder, address)
+	}
+	change.New = (*hexutil.Big)(new(big.Int).Set(newBalance))
+}
+
+func (t *arbitrumTxTracer) OnStorageChange(address common.Address, slot common.Hash, prevValue, newValue common.Hash) {
+	if t.interrupt.Load() {
+		return
+	}
+	key := storageSlot{address, slot}
+	change, ok := t.storage[key]
+	if !ok {
+		change = &StorageChange{Address: address, Slot: slot, Prev: prevValue}
+		t.storage[key] = change
+		t.storageOrder = append(t.storageOrder, key)
+	}
+	change.New = newValue
+}
+
+func (t *arbitrumTxTracer) GetResult() (json.RawMessage, error) {
+	if t.reason != nil {
+		return nil, t.reason
+	}
+	result := t.result
+	result.BalanceChanges = []BalanceChange{}
+	for _, address := range t.balanceOrder {
+		change := t.balances[address]
+		if change.Prev.ToInt().Cmp(change.New.ToInt()) != 0 {
+			result.BalanceChanges = append(result.BalanceChanges, *change)
+		}
+	}
+	result.StorageChanges = []StorageChange{}
+	for _, key := range t.storageOrder {
+		change := t.storage[key]
+		if change.Prev != change.New {
+			result.StorageChanges = append(result.StorageChanges, *change)
+		}
+	}
+	return json.Marshal(result)
+}
+
+func (t *arbitrumTxTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
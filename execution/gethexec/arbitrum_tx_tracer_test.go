@@ -0,0 +1,84 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbos"
+)
+
+func traceArbitrumTx(t *testing.T, tx *types.Transaction, run func(hooks *tracing.Hooks)) ArbitrumTxTrace {
+	t.Helper()
+	tracer, err := newArbitrumTxTracer(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.Hooks.OnTxStart(nil, tx, common.Address{})
+	run(tracer.Hooks)
+	tracer.Hooks.OnTxEnd(nil, nil)
+	data, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result ArbitrumTxTrace
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestArbitrumTxTracer(t *testing.T) {
+	arbosAddress := common.HexToAddress("0xA4B05FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+	slot := common.HexToHash("0x01")
+	startBlock := types.NewTx(&types.ArbitrumInternalTx{Data: append(arbos.InternalTxStartBlockMethodID[:], 0)})
+	result := traceArbitrumTx(t, startBlock, func(hooks *tracing.Hooks) {
+		hooks.OnEnter(1, 0xf1, types.ArbosAddress, types.ArbosAddress, nil, 0, big.NewInt(0))
+		hooks.OnStorageChange(arbosAddress, slot, common.Hash{}, common.HexToHash("0x02"))
+		hooks.OnStorageChange(arbosAddress, slot, common.HexToHash("0x02"), common.HexToHash("0x03"))
+		// Changes undone within the transaction aren't reported
+		hooks.OnStorageChange(arbosAddress, common.HexToHash("0x02"), common.Hash{}, common.HexToHash("0x04"))
+		hooks.OnStorageChange(arbosAddress, common.HexToHash("0x02"), common.HexToHash("0x04"), common.Hash{})
+	})
+	if result.Kind != ArbitrumTxStartBlock || !result.Synthetic || len(result.Transfers) != 0 {
+		t.Fatal("unexpected start block trace", result)
+	}
+	if len(result.StorageChanges) != 1 || result.StorageChanges[0].Slot != slot || result.StorageChanges[0].Prev != (common.Hash{}) || result.StorageChanges[0].New != common.HexToHash("0x03") {
+		t.Fatal("unexpected storage changes", result.StorageChanges)
+	}
+
+	ticketId := common.HexToHash("0x1234")
+	sender := common.HexToAddress("0x1111")
+	networkFeeAccount := common.HexToAddress("0x2222")
+	retry := types.NewTx(&types.ArbitrumRetryTx{TicketId: ticketId, From: sender})
+	result = traceArbitrumTx(t, retry, func(hooks *tracing.Hooks) {
+		hooks.CaptureArbitrumTransfer(nil, &sender, big.NewInt(100), true, "prepaid")
+		hooks.OnBalanceChange(sender, big.NewInt(0), big.NewInt(100), tracing.BalanceChangeTransfer)
+		hooks.OnBalanceChange(sender, big.NewInt(100), big.NewInt(40), tracing.BalanceChangeTransfer)
+		hooks.CaptureArbitrumTransfer(nil, &networkFeeAccount, big.NewInt(60), false, "feeCollection")
+		hooks.OnBalanceChange(networkFeeAccount, big.NewInt(5), big.NewInt(65), tracing.BalanceChangeTransfer)
+	})
+	if result.Kind != ArbitrumTxRetryRedeem || !result.Synthetic || result.TicketId == nil || *result.TicketId != ticketId {
+		t.Fatal("unexpected retry trace", result)
+	}
+	if len(result.Transfers) != 2 || result.Transfers[1].Phase != ArbitrumTransferAfterEVM || result.Transfers[1].Purpose != "feeCollection" || result.Transfers[1].From != nil {
+		t.Fatal("unexpected transfers", result.Transfers)
+	}
+	if len(result.BalanceChanges) != 2 || result.BalanceChanges[0].New.ToInt().Uint64() != 40 || result.BalanceChanges[1].Address != networkFeeAccount {
+		t.Fatal("unexpected balance changes", result.BalanceChanges)
+	}
+
+	result = traceArbitrumTx(t, types.NewTx(&types.DynamicFeeTx{}), func(hooks *tracing.Hooks) {
+		hooks.OnEnter(0, 0xf1, sender, networkFeeAccount, nil, 21000, big.NewInt(7))
+	})
+	if result.Kind != ArbitrumTxSigned || result.Synthetic || len(result.Transfers) != 1 || result.Transfers[0].Phase != ArbitrumTransferDuringEVM {
+		t.Fatal("unexpected signed trace", result)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/execution"
+)
+
+var (
+	builderPayloadAdoptedCounter = metrics.NewRegisteredCounter("arb/sequencer/builder/adopted", nil)
+	builderPayloadIgnoredCounter = metrics.NewRegisteredCounter("arb/sequencer/builder/ignored", nil)
+	builderPayloadInvalidCounter = metrics.NewRegisteredCounter("arb/sequencer/builder/invalid", nil)
+)
+
+const (
+	BuilderPayloadAdopted = "adopted"
+	BuilderPayloadIgnored = "ignored"
+	BuilderPayloadInvalid = "invalid"
+)
+
+type BlockBuilderConfig struct {
+	Enable        bool          `koanf:"enable"`
+	MaxPayloadAge time.Duration `koanf:"max-payload-age" reload:"hot"`
+}
+
+var DefaultBlockBuilderConfig = BlockBuilderConfig{
+	Enable:        false,
+	MaxPayloadAge: time.Second,
+}
+
+func BlockBuilderConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBlockBuilderConfig.Enable, "let external block builders submit the ordering of the next block through the builder_submitPayload RPC method")
+	f.Duration(prefix+".max-payload-age", DefaultBlockBuilderConfig.MaxPayloadAge, "a builder payload not adopted within this time is ignored")
+}
+
+func (c *BlockBuilderConfig) Validate() error {
+	if c.Enable && c.MaxPayloadAge <= 0 {
+		return errors.New("block builder max-payload-age must be positive")
+	}
+	return nil
+}
+
+type BuilderPayloadStatus struct {
+	// One of "adopted", "ignored" or "invalid"
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// For adopted payloads, why each transaction was left out of the block, or an empty string if it was included
+	TxErrors []string `json:"txErrors,omitempty"`
+}
+
+// builderPayload is a builder's ordering of the block on top of parent.
+type builderPayload struct {
+	parent   common.Hash
+	items    []txQueueItem
+	size     int
+	received time.Time
+	// Receives nil once the payload is adopted, or the reason it was ignored
+	outcome chan error
+}
+
+// blockBuilder holds the latest builder payload until the sequencer starts its next block.
+type blockBuilder struct {
+	config  func() *BlockBuilderConfig
+	mutex   sync.Mutex
+	pending *builderPayload
+	ready   chan struct{}
+}
+
+func newBlockBuilder(config func() *BlockBuilderConfig) *blockBuilder {
+	return &blockBuilder{
+		config: config,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// readyChan is signaled when a payload is submitted, and is nil if builder payloads are disabled.
+func (b *blockBuilder) readyChan() <-chan struct{} {
+	if b == nil {
+		return nil
+	}
+	return b.ready
+}
+
+// submit makes the payload the candidate for the next block, replacing any earlier one.
+func (b *blockBuilder) submit(payload *builderPayload) {
+	b.mutex.Lock()
+	if b.pending != nil {
+		b.pending.outcome <- errors.New("replaced by a newer payload")
+	}
+	b.pending = payload
+	b.mutex.Unlock()
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the pending payload if it's still fresh and builds on head, and ignores it otherwise.
+func (b *blockBuilder) take(head common.Hash) *builderPayload {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	payload := b.pending
+	b.pending = nil
+	b.mutex.Unlock()
+	if payload == nil {
+		return nil
+	}
+	if payload.parent != head {
+		payload.outcome <- fmt.Errorf("built on %v, but the head is now %v", payload.parent, head)
+		return nil
+	}
+	if age := time.Since(payload.received); age > b.config().MaxPayloadAge {
+		payload.outcome <- fmt.Errorf("not adopted within %v", age)
+		return nil
+	}
+	payload.outcome <- nil
+	return payload
+}
+
+// SubmitBuilderPayload checks a builder's ordering of the next block against the sequencer's policies,
+// and waits until the sequencer either adopts it or ignores it. An adopted payload is the next block's
+// transactions in order, though any that fail to execute are dropped from it like any other transaction.
+func (s *Sequencer) SubmitBuilderPayload(ctx context.Context, parent common.Hash, txs []hexutil.Bytes) (*BuilderPayloadStatus, error) {
+	if s.builder == nil {
+		return nil, errors.New("block builder payloads aren't enabled")
+	}
+	if s.execEngine.IsFrozen() {
+		return nil, execution.ErrSequencerFrozen
+	}
+	if pause, forwarder := s.GetPauseAndForwarder(); pause != nil || forwarder != nil {
+		return nil, errors.New("not the active sequencer")
+	}
+	invalid := func(reason string) *BuilderPayloadStatus {
+		builderPayloadInvalidCounter.Inc(1)
+		return &BuilderPayloadStatus{Status: BuilderPayloadInvalid, Reason: reason}
+	}
+	if head := s.execEngine.bc.CurrentBlock().Hash(); parent != head {
+		return invalid(fmt.Sprintf("parent %v isn't the head %v", parent, head)), nil
+	}
+	if len(txs) == 0 {
+		return invalid("no transactions"), nil
+	}
+	config := s.config()
+	payload := &builderPayload{
+		parent:   parent,
+		items:    make([]txQueueItem, 0, len(txs)),
+		received: time.Now(),
+		outcome:  make(chan error, 1),
+	}
+	results := make([]chan error, 0, len(txs))
+	for i, data := range txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return invalid(fmt.Sprintf("transaction %v: %v", i, err)), nil
+		}
+		if err := s.checkTxPolicies(ctx, tx); err != nil {
+			return invalid(fmt.Sprintf("transaction %v: %v", i, err)), nil
+		}
+		payload.size += len(data)
+		if maxTxDataSize := s.maxTxDataSize(config); payload.size > maxTxDataSize {
+			return invalid(fmt.Sprintf("transactions exceed the block size limit of %v bytes", maxTxDataSize)), nil
+		}
+		resultChan := make(chan error, 1)
+		results = append(results, resultChan)
+		payload.items = append(payload.items, txQueueItem{
+			tx:              tx,
+			txSize:          len(data),
+			resultChan:      resultChan,
+			returnedResult:  &atomic.Bool{},
+			ctx:             ctx,
+			firstAppearance: payload.received,
+			fromBuilder:     true,
+		})
+	}
+	s.builder.submit(payload)
+
+	select {
+	case err := <-payload.outcome:
+		if err != nil {
+			builderPayloadIgnoredCounter.Inc(1)
+			log.Debug("ignored builder payload", "parent", parent, "reason", err)
+			return &BuilderPayloadStatus{Status: BuilderPayloadIgnored, Reason: err.Error()}, nil
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	builderPayloadAdoptedCounter.Inc(1)
+	status := &BuilderPayloadStatus{Status: BuilderPayloadAdopted, TxErrors: make([]string, len(results))}
+	for i, resultChan := range results {
+		select {
+		case err := <-resultChan:
+			if err != nil {
+				status.TxErrors[i] = err.Error()
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return status, nil
+}
+
+// BlockBuilderAPI lets external block builders propose the ordering of the sequencer's next block.
+type BlockBuilderAPI struct {
+	sequencer *Sequencer
+}
+
+func NewBlockBuilderAPI(sequencer *Sequencer) *BlockBuilderAPI {
+	return &BlockBuilderAPI{sequencer}
+}
+
+func (a *BlockBuilderAPI) SubmitPayload(ctx context.Context, parentHash common.Hash, txs []hexutil.Bytes) (*BuilderPayloadStatus, error) {
+	return a.sequencer.SubmitBuilderPayload(ctx, parentHash, txs)
+}
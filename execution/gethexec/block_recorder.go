@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/arbitrum"
@@ -41,24 +42,32 @@ type BlockRecorder struct {
 
 	preparedQueue []*types.Header
 	preparedLock  sync.Mutex
+
+	lastPersisted atomic.Uint64
 }
 
 type BlockRecorderConfig struct {
-	TrieDirtyCache int `koanf:"trie-dirty-cache"`
-	TrieCleanCache int `koanf:"trie-clean-cache"`
-	MaxPrepared    int `koanf:"max-prepared"`
+	TrieDirtyCache    int    `koanf:"trie-dirty-cache"`
+	TrieCleanCache    int    `koanf:"trie-clean-cache"`
+	MaxPrepared       int    `koanf:"max-prepared"`
+	PersistInterval   uint64 `koanf:"persist-interval"`
+	PersistOnShutdown bool   `koanf:"persist-on-shutdown"`
 }
 
 var DefaultBlockRecorderConfig = BlockRecorderConfig{
-	TrieDirtyCache: 1024,
-	TrieCleanCache: 16,
-	MaxPrepared:    1000,
+	TrieDirtyCache:    1024,
+	TrieCleanCache:    16,
+	MaxPrepared:       1000,
+	PersistInterval:   0,
+	PersistOnShutdown: true,
 }
 
 func BlockRecorderConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".trie-dirty-cache", DefaultBlockRecorderConfig.TrieDirtyCache, "like trie-dirty-cache for the separate, recording database (used for validation)")
 	f.Int(prefix+".trie-clean-cache", DefaultBlockRecorderConfig.TrieCleanCache, "like trie-clean-cache for the separate, recording database (used for validation)")
 	f.Int(prefix+".max-prepared", DefaultBlockRecorderConfig.MaxPrepared, "max references to store in the recording database")
+	f.Uint64(prefix+".persist-interval", DefaultBlockRecorderConfig.PersistInterval, "write the recording database state of every block divisible by this to disk, so validation can restart near the head after a crash (0 = disabled)")
+	f.Bool(prefix+".persist-on-shutdown", DefaultBlockRecorderConfig.PersistOnShutdown, "on shutdown, also write the recording database state of the most recently recorded block to disk, so validation restarts near the head")
 }
 
 func NewBlockRecorder(config *BlockRecorderConfig, execEngine *ExecutionEngine, ethDb ethdb.Database) *BlockRecorder {
@@ -329,6 +338,7 @@ func (r *BlockRecorder) PrepareForRecord(ctx context.Context, start, end arbutil
 			break
 		}
 		references = append(references, header)
+		r.maybePersist(header)
 		r.updateValidCandidateHdr(header)
 		r.updateLastHdr(header)
 		hdrNum++
@@ -369,9 +379,41 @@ func (r *BlockRecorder) WriteValidStateToDb() error {
 	return err
 }
 
+// maybePersist writes the state of a referenced header to disk if it's on the persist interval.
+func (r *BlockRecorder) maybePersist(hdr *types.Header) {
+	interval := r.config.PersistInterval
+	number := hdr.Number.Uint64()
+	if interval == 0 || number%interval != 0 || number <= r.lastPersisted.Load() {
+		return
+	}
+	if err := r.recordingDatabase.WriteStateToDatabase(hdr); err != nil {
+		log.Warn("failed writing recording state to DB", "number", number, "err", err)
+		return
+	}
+	r.lastPersisted.Store(number)
+	log.Debug("wrote recording state to DB", "number", number)
+}
+
+func (r *BlockRecorder) writeLastStateToDb() error {
+	r.lastHdrLock.Lock()
+	defer r.lastHdrLock.Unlock()
+	if r.lastHdr == nil {
+		return nil
+	}
+	err := r.recordingDatabase.WriteStateToDatabase(r.lastHdr)
+	r.recordingDatabase.Dereference(r.lastHdr)
+	r.lastHdr = nil
+	return err
+}
+
 func (r *BlockRecorder) OrderlyShutdown() {
 	err := r.WriteValidStateToDb()
 	if err != nil {
 		log.Error("failed writing latest valid block state to DB", "err", err)
 	}
+	if r.config.PersistOnShutdown {
+		if err := r.writeLastStateToDb(); err != nil {
+			log.Error("failed writing latest recorded block state to DB", "err", err)
+		}
+	}
 }
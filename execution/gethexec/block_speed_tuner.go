@@ -0,0 +1,215 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	blockSpeedGauge             = metrics.NewRegisteredGauge("arb/sequencer/blockspeed/delay", nil)
+	blockSpeedValidationLag     = metrics.NewRegisteredGauge("arb/sequencer/blockspeed/validationlag", nil)
+	blockSpeedBatchBacklog      = metrics.NewRegisteredGauge("arb/sequencer/blockspeed/batchbacklog", nil)
+	blockSpeedSlowdownCounter   = metrics.NewRegisteredCounter("arb/sequencer/blockspeed/slowdown", nil)
+	blockSpeedSpeedupCounter    = metrics.NewRegisteredCounter("arb/sequencer/blockspeed/speedup", nil)
+	blockSpeedSignalErrorsGauge = metrics.NewRegisteredGauge("arb/sequencer/blockspeed/signalerrors", nil)
+)
+
+type BlockSpeedTunerConfig struct {
+	Enable           bool          `koanf:"enable"`
+	Interval         time.Duration `koanf:"interval" reload:"hot"`
+	MaxValidationLag uint64        `koanf:"max-validation-lag" reload:"hot"`
+	MaxBatchBacklog  uint64        `koanf:"max-batch-backlog" reload:"hot"`
+	HealthyFraction  float64       `koanf:"healthy-fraction" reload:"hot"`
+	SlowdownFactor   float64       `koanf:"slowdown-factor" reload:"hot"`
+	SpeedupFactor    float64       `koanf:"speedup-factor" reload:"hot"`
+	MaxDelay         time.Duration `koanf:"max-delay" reload:"hot"`
+}
+
+var DefaultBlockSpeedTunerConfig = BlockSpeedTunerConfig{
+	Enable:           false,
+	Interval:         time.Second,
+	MaxValidationLag: 20_000,
+	MaxBatchBacklog:  50_000,
+	HealthyFraction:  0.5,
+	SlowdownFactor:   1.5,
+	SpeedupFactor:    0.8,
+	MaxDelay:         2 * time.Second,
+}
+
+func BlockSpeedTunerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBlockSpeedTunerConfig.Enable, "slow down block production while validation or batch posting lags behind, within max-block-speed and max-delay")
+	f.Duration(prefix+".interval", DefaultBlockSpeedTunerConfig.Interval, "how often to check the lags and adjust the delay between blocks")
+	f.Uint64(prefix+".max-validation-lag", DefaultBlockSpeedTunerConfig.MaxValidationLag, "number of unvalidated messages above which block production slows down (0 = ignore validation)")
+	f.Uint64(prefix+".max-batch-backlog", DefaultBlockSpeedTunerConfig.MaxBatchBacklog, "number of messages not yet posted in a batch above which block production slows down (0 = ignore batch posting)")
+	f.Float64(prefix+".healthy-fraction", DefaultBlockSpeedTunerConfig.HealthyFraction, "fraction of the limits below which the lags must fall for block production to speed back up")
+	f.Float64(prefix+".slowdown-factor", DefaultBlockSpeedTunerConfig.SlowdownFactor, "factor the delay between blocks is multiplied by for each check above the limits")
+	f.Float64(prefix+".speedup-factor", DefaultBlockSpeedTunerConfig.SpeedupFactor, "factor the delay between blocks is multiplied by for each healthy check")
+	f.Duration(prefix+".max-delay", DefaultBlockSpeedTunerConfig.MaxDelay, "the longest the delay between blocks is raised to")
+}
+
+func (c *BlockSpeedTunerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return errors.New("block speed tuner interval must be positive")
+	}
+	if c.HealthyFraction <= 0 || c.HealthyFraction > 1 {
+		return errors.New("block speed tuner healthy-fraction must be in (0, 1]")
+	}
+	if c.SlowdownFactor <= 1 {
+		return errors.New("block speed tuner slowdown-factor must be greater than 1")
+	}
+	if c.SpeedupFactor <= 0 || c.SpeedupFactor >= 1 {
+		return errors.New("block speed tuner speedup-factor must be in (0, 1)")
+	}
+	if c.MaxDelay <= 0 {
+		return errors.New("block speed tuner max-delay must be positive")
+	}
+	return nil
+}
+
+type BlockSpeedTunerConfigFetcher func() *BlockSpeedTunerConfig
+
+// blockSpeedSignals reports how far the chain's safety infrastructure is behind block production.
+type blockSpeedSignals interface {
+	HeadMessageCount() (arbutil.MessageIndex, error)
+	ValidatedMessageCount() (arbutil.MessageIndex, error)
+	BatchPostedMessageCount() (arbutil.MessageIndex, error)
+}
+
+// BlockSpeedTuner raises the delay between blocks while validation or batch posting falls too far behind,
+// and lowers it back to the sequencer's max-block-speed once they've caught up. Lags which can't be read,
+// such as validation on a sequencer without a block validator, are ignored.
+type BlockSpeedTuner struct {
+	stopwaiter.StopWaiter
+	config   BlockSpeedTunerConfigFetcher
+	minDelay func() time.Duration
+	signals  blockSpeedSignals
+
+	delay atomic.Int64 // the tuned delay between blocks, never below minDelay
+}
+
+func NewBlockSpeedTuner(config BlockSpeedTunerConfigFetcher, minDelay func() time.Duration, signals blockSpeedSignals) *BlockSpeedTuner {
+	return &BlockSpeedTuner{
+		config:   config,
+		minDelay: minDelay,
+		signals:  signals,
+	}
+}
+
+func (t *BlockSpeedTuner) Start(ctx context.Context) {
+	t.StopWaiter.Start(ctx, t)
+	t.CallIteratively(func(ctx context.Context) time.Duration {
+		t.update()
+		return t.config().Interval
+	})
+}
+
+// MaxBlockSpeed returns the minimum delay between blocks.
+func (t *BlockSpeedTuner) MaxBlockSpeed() time.Duration {
+	return max(time.Duration(t.delay.Load()), t.minDelay())
+}
+
+// blockSpeedLag returns how far behind head a count is, or false if it can't be read.
+func blockSpeedLag(head arbutil.MessageIndex, read func() (arbutil.MessageIndex, error)) (uint64, bool) {
+	count, err := read()
+	if err != nil {
+		return 0, false
+	}
+	if count >= head {
+		return 0, true
+	}
+	return uint64(head - count), true
+}
+
+func (t *BlockSpeedTuner) update() {
+	config := t.config()
+	head, err := t.signals.HeadMessageCount()
+	if err != nil {
+		log.Warn("block speed tuner failed to read head message count", "err", err)
+		return
+	}
+	var overloaded bool
+	healthy := true
+	var signalErrors int64
+	check := func(limit uint64, read func() (arbutil.MessageIndex, error), gauge metrics.Gauge) {
+		if limit == 0 {
+			return
+		}
+		behind, ok := blockSpeedLag(head, read)
+		if !ok {
+			signalErrors++
+			return
+		}
+		// #nosec G115
+		gauge.Update(int64(behind))
+		if behind > limit {
+			overloaded = true
+		}
+		if float64(behind) > float64(limit)*config.HealthyFraction {
+			healthy = false
+		}
+	}
+	check(config.MaxValidationLag, t.signals.ValidatedMessageCount, blockSpeedValidationLag)
+	check(config.MaxBatchBacklog, t.signals.BatchPostedMessageCount, blockSpeedBatchBacklog)
+	blockSpeedSignalErrorsGauge.Update(signalErrors)
+
+	minDelay := t.minDelay()
+	current := t.MaxBlockSpeed()
+	next := current
+	if overloaded {
+		// Grow by at least a millisecond, in case max-block-speed is 0
+		next = max(time.Duration(float64(current)*config.SlowdownFactor), current+time.Millisecond)
+		next = min(next, max(config.MaxDelay, minDelay))
+		if next > current {
+			blockSpeedSlowdownCounter.Inc(1)
+			log.Warn("slowing down block production while the chain's infrastructure catches up", "delay", next)
+		}
+	} else if healthy && current > minDelay {
+		next = max(time.Duration(float64(current)*config.SpeedupFactor), minDelay)
+		blockSpeedSpeedupCounter.Inc(1)
+		if next == minDelay {
+			log.Info("block production back to full speed", "delay", next)
+		}
+	}
+	t.delay.Store(int64(next))
+	blockSpeedGauge.Update(next.Milliseconds())
+}
+
+// engineBlockSpeedSignals reads the lags from the consensus node the execution engine sequences for.
+type engineBlockSpeedSignals struct {
+	engine *ExecutionEngine
+}
+
+func (s engineBlockSpeedSignals) HeadMessageCount() (arbutil.MessageIndex, error) {
+	head, err := s.engine.HeadMessageNumber()
+	return head + 1, err
+}
+
+func (s engineBlockSpeedSignals) ValidatedMessageCount() (arbutil.MessageIndex, error) {
+	if s.engine.consensus == nil {
+		return 0, errors.New("consensus not set")
+	}
+	return s.engine.consensus.ValidatedMessageCount()
+}
+
+func (s engineBlockSpeedSignals) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	if s.engine.consensus == nil {
+		return 0, errors.New("consensus not set")
+	}
+	return s.engine.consensus.BatchPostedMessageCount()
+}
@@ -0,0 +1,94 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+type testBlockSpeedSignals struct {
+	head      arbutil.MessageIndex
+	validated arbutil.MessageIndex
+	posted    arbutil.MessageIndex
+	noVal     bool
+}
+
+func (s *testBlockSpeedSignals) HeadMessageCount() (arbutil.MessageIndex, error) {
+	return s.head, nil
+}
+
+func (s *testBlockSpeedSignals) ValidatedMessageCount() (arbutil.MessageIndex, error) {
+	if s.noVal {
+		return 0, errors.New("validator not set up")
+	}
+	return s.validated, nil
+}
+
+func (s *testBlockSpeedSignals) BatchPostedMessageCount() (arbutil.MessageIndex, error) {
+	return s.posted, nil
+}
+
+func TestBlockSpeedTunerSlowsDownAndRecovers(t *testing.T) {
+	config := DefaultBlockSpeedTunerConfig
+	config.Enable = true
+	config.MaxValidationLag = 100
+	config.MaxBatchBacklog = 1000
+	config.MaxDelay = time.Second
+	minDelay := 250 * time.Millisecond
+	signals := &testBlockSpeedSignals{head: 500, validated: 300, posted: 500}
+	tuner := NewBlockSpeedTuner(func() *BlockSpeedTunerConfig { return &config }, func() time.Duration { return minDelay }, signals)
+	if delay := tuner.MaxBlockSpeed(); delay != minDelay {
+		t.Fatal("expected the configured max block speed before any update but got", delay)
+	}
+
+	tuner.update()
+	if delay := tuner.MaxBlockSpeed(); delay != 375*time.Millisecond {
+		t.Fatal("expected the delay to be raised to 375ms but got", delay)
+	}
+	for i := 0; i < 10; i++ {
+		tuner.update()
+	}
+	if delay := tuner.MaxBlockSpeed(); delay != config.MaxDelay {
+		t.Fatal("expected the delay to be capped at max-delay but got", delay)
+	}
+
+	// Between the healthy fraction and the limit, the delay is held
+	signals.validated = 430
+	tuner.update()
+	if delay := tuner.MaxBlockSpeed(); delay != config.MaxDelay {
+		t.Fatal("expected the delay to be held but got", delay)
+	}
+
+	signals.validated = 490
+	for i := 0; i < 20; i++ {
+		tuner.update()
+	}
+	if delay := tuner.MaxBlockSpeed(); delay != minDelay {
+		t.Fatal("expected the delay to recover to max-block-speed but got", delay)
+	}
+}
+
+func TestBlockSpeedTunerIgnoresMissingSignals(t *testing.T) {
+	config := DefaultBlockSpeedTunerConfig
+	config.Enable = true
+	config.MaxValidationLag = 100
+	config.MaxBatchBacklog = 100
+	signals := &testBlockSpeedSignals{head: 500, posted: 450, noVal: true}
+	tuner := NewBlockSpeedTuner(func() *BlockSpeedTunerConfig { return &config }, func() time.Duration { return 0 }, signals)
+	tuner.update()
+	if delay := tuner.MaxBlockSpeed(); delay != 0 {
+		t.Fatal("expected an unreadable validation lag to be ignored but got delay", delay)
+	}
+
+	// Batch posting falling behind still slows down production, even from no delay at all
+	signals.posted = 300
+	tuner.update()
+	if delay := tuner.MaxBlockSpeed(); delay <= 0 {
+		t.Fatal("expected the delay to be raised from 0 but got", delay)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	blockWriteTimer       = metrics.NewRegisteredHistogram("arb/block/write", nil, metrics.NewBoundedHistogramSample())
+	blockWriteBarrierTime = metrics.NewRegisteredHistogram("arb/block/write/barrier", nil, metrics.NewBoundedHistogramSample())
+)
+
+// pendingBlockWrite is a sequenced block whose write to the blockchain
+// (including committing its trie) is still running in the background.
+type pendingBlockWrite struct {
+	header *types.Header
+	// a copy of the block's post state, so the next block can be built before this one is written
+	state *state.StateDB
+	done  chan struct{}
+	err   error
+}
+
+// EnableAsyncBlockWrites makes the sequencer write blocks in the background,
+// so building the next block overlaps with committing the previous block's trie.
+// At most one block write is in flight at a time.
+func (s *ExecutionEngine) EnableAsyncBlockWrites() {
+	if s.Started() {
+		panic("trying to enable async block writes after start")
+	}
+	if s.asyncBlockWrites {
+		panic("trying to enable async block writes when already set")
+	}
+	s.asyncBlockWrites = true
+}
+
+func (s *ExecutionEngine) getPendingBlockWrite() *pendingBlockWrite {
+	s.pendingBlockWriteMutex.Lock()
+	defer s.pendingBlockWriteMutex.Unlock()
+	return s.pendingBlockWrite
+}
+
+// waitForBlockWrite is the barrier that must be passed before anything depends on the
+// last sequenced block being in the database, such as publishing the next message to
+// the feed or reading results from the blockchain.
+// The error from a failed write is returned only once; after that the engine falls back
+// to the blockchain's head, and the missing block is regenerated from its message.
+func (s *ExecutionEngine) waitForBlockWrite() error {
+	pending := s.getPendingBlockWrite()
+	if pending == nil {
+		return nil
+	}
+	start := time.Now()
+	<-pending.done
+	blockWriteBarrierTime.Update(time.Since(start).Nanoseconds())
+	s.pendingBlockWriteMutex.Lock()
+	if s.pendingBlockWrite == pending {
+		s.pendingBlockWrite = nil
+	}
+	s.pendingBlockWriteMutex.Unlock()
+	return pending.err
+}
+
+// stateAtHeader returns a state to build on top of the header, using the state of the
+// pending block write if the header is for that block.
+func (s *ExecutionEngine) stateAtHeader(header *types.Header) (*state.StateDB, error) {
+	pending := s.getPendingBlockWrite()
+	if pending != nil && pending.header.Hash() == header.Hash() {
+		return pending.state.Copy(), nil
+	}
+	return s.bc.StateAt(header.Root)
+}
+
+// latestHeaderAndState returns the latest sequenced header and a state for it,
+// including a block which is still being written.
+func (s *ExecutionEngine) latestHeaderAndState() (*types.Header, *state.StateDB, error) {
+	header, err := s.getCurrentHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	statedb, err := s.stateAtHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, statedb, nil
+}
+
+// appendBlockAsync starts writing the block in the background, falling back to
+// appendBlock if async writes aren't enabled.
+// must hold createBlockMutex
+func (s *ExecutionEngine) appendBlockAsync(block *types.Block, statedb *state.StateDB, receipts types.Receipts, duration time.Duration) error {
+	if !s.asyncBlockWrites {
+		return s.appendBlock(block, statedb, receipts, duration)
+	}
+	if err := s.waitForBlockWrite(); err != nil {
+		return err
+	}
+	pending := &pendingBlockWrite{
+		header: block.Header(),
+		// copy before handing statedb to the writer, which commits it
+		state: statedb.Copy(),
+		done:  make(chan struct{}),
+	}
+	s.pendingBlockWriteMutex.Lock()
+	s.pendingBlockWrite = pending
+	s.pendingBlockWriteMutex.Unlock()
+	err := s.LaunchThreadSafe(func(context.Context) {
+		defer close(pending.done)
+		start := time.Now()
+		pending.err = s.appendBlock(block, statedb, receipts, duration)
+		blockWriteTimer.Update(time.Since(start).Nanoseconds())
+		if pending.err != nil {
+			log.Error("async block write failed", "block", block.NumberU64(), "hash", block.Hash(), "err", pending.err)
+		}
+	})
+	if err != nil {
+		s.pendingBlockWriteMutex.Lock()
+		s.pendingBlockWrite = nil
+		s.pendingBlockWriteMutex.Unlock()
+		return fmt.Errorf("failed to launch block write: %w", err)
+	}
+	return nil
+}
+
+// StopAndWait waits for any pending block write before stopping.
+func (s *ExecutionEngine) StopAndWait() {
+	if err := s.waitForBlockWrite(); err != nil {
+		log.Error("pending block write failed during shutdown", "err", err)
+	}
+	s.StopWaiter.StopAndWait()
+}
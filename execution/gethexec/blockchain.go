@@ -41,6 +41,7 @@ type CachingConfig struct {
 	DisableStylusCacheMetricsCollection bool          `koanf:"disable-stylus-cache-metrics-collection"`
 	StateScheme                         string        `koanf:"state-scheme"`
 	StateHistory                        uint64        `koanf:"state-history"`
+	StateRetention                      time.Duration `koanf:"state-retention"`
 }
 
 func CachingConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -59,11 +60,17 @@ func CachingConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".disable-stylus-cache-metrics-collection", DefaultCachingConfig.DisableStylusCacheMetricsCollection, "disable metrics collection for the stylus cache")
 	f.String(prefix+".state-scheme", DefaultCachingConfig.StateScheme, "scheme to use for state trie storage (hash, path)")
 	f.Uint64(prefix+".state-history", DefaultCachingConfig.StateHistory, "number of recent blocks to retain state history for (path state-scheme only)")
+	f.Duration(prefix+".state-retention", DefaultCachingConfig.StateRetention, "how long to retain state history for, pruning older history continuously as blocks are added; overrides state-history (path state-scheme only, 0 = use state-history)")
 }
 
 func getStateHistory(maxBlockSpeed time.Duration) uint64 {
+	return stateHistoryForRetention(24*time.Hour, maxBlockSpeed)
+}
+
+// stateHistoryForRetention is the number of blocks produced at most in the retention window
+func stateHistoryForRetention(retention time.Duration, maxBlockSpeed time.Duration) uint64 {
 	// #nosec G115
-	return uint64(24 * time.Hour / maxBlockSpeed)
+	return uint64(retention / maxBlockSpeed)
 }
 
 var DefaultCachingConfig = CachingConfig{
@@ -81,6 +88,7 @@ var DefaultCachingConfig = CachingConfig{
 	StylusLRUCacheCapacity:             256,
 	StateScheme:                        rawdb.HashScheme,
 	StateHistory:                       getStateHistory(DefaultSequencerConfig.MaxBlockSpeed),
+	StateRetention:                     0,
 }
 
 // TODO remove stack from parameters as it is no longer needed here
@@ -122,7 +130,18 @@ func (c *CachingConfig) validateStateScheme() error {
 }
 
 func (c *CachingConfig) Validate() error {
-	return c.validateStateScheme()
+	if err := c.validateStateScheme(); err != nil {
+		return err
+	}
+	if c.StateRetention < 0 {
+		return errors.New("state-retention cannot be negative")
+	}
+	// The path scheme prunes state history past its retention as blocks are added, while hash scheme state
+	// can only be pruned offline
+	if c.StateRetention > 0 && c.StateScheme != rawdb.PathScheme {
+		return errors.New("state-retention requires the path state-scheme, hash state-scheme databases can only be pruned offline with --init.prune")
+	}
+	return nil
 }
 
 func WriteOrTestGenblock(chainDb ethdb.Database, cacheConfig *core.CacheConfig, initData statetransfer.InitDataReader, chainConfig *params.ChainConfig, initMessage *arbostypes.ParsedInitMessage, accountsPerSync uint) error {
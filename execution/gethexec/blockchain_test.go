@@ -6,6 +6,8 @@ package gethexec
 import (
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
 )
 
 func TestGetStateHistory(t *testing.T) {
@@ -16,3 +18,18 @@ func TestGetStateHistory(t *testing.T) {
 		t.Errorf("Expected state history to be %d, but got %d", expectedStateHistory, actualStateHistory)
 	}
 }
+
+func TestStateRetention(t *testing.T) {
+	config := DefaultCachingConfig
+	config.StateRetention = 30 * 24 * time.Hour
+	if err := config.Validate(); err == nil {
+		t.Error("Expected state-retention to be rejected with the hash state-scheme")
+	}
+	config.StateScheme = rawdb.PathScheme
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+	if history := stateHistoryForRetention(config.StateRetention, time.Millisecond*250); history != 30*345600 {
+		t.Errorf("Expected state history to be %d, but got %d", 30*345600, history)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	callCacheInvalidationsCounter = metrics.NewRegisteredCounter("arb/rpc/callcache/invalidations", nil)
+	callCacheUncacheableCounter   = metrics.NewRegisteredCounter("arb/rpc/callcache/uncacheable", nil)
+)
+
+// Larger requests are passed through without being cached
+const maxCachedCallRequestSize = 1 << 20
+
+type CallCacheConfig struct {
+	Enable        bool `koanf:"enable"`
+	Size          int  `koanf:"size"`
+	MaxResultSize int  `koanf:"max-result-size"`
+}
+
+var DefaultCallCacheConfig = CallCacheConfig{
+	Enable:        false,
+	Size:          4096,
+	MaxResultSize: 64 * 1024,
+}
+
+func CallCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCallCacheConfig.Enable, "cache the results of identical eth_call requests made over http against the same block")
+	f.Int(prefix+".size", DefaultCallCacheConfig.Size, "maximum number of eth_call results to cache")
+	f.Int(prefix+".max-result-size", DefaultCallCacheConfig.MaxResultSize, "maximum size in bytes of an eth_call result to cache")
+}
+
+func (c *CallCacheConfig) Validate() error {
+	if c.Enable && c.Size < 1 {
+		return errors.New("call-cache size must be positive when enabled")
+	}
+	return nil
+}
+
+type callCacheChain interface {
+	CurrentBlock() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	GetHeaderByHash(hash common.Hash) *types.Header
+}
+
+// CallCache serves repeated eth_call requests from memory. Calls are keyed by the hash of the block
+// they run against, the call arguments and the state and block overrides. Calls against "latest" or
+// a block number are pinned to the block's hash before being executed, so the cached result always
+// matches its key. The cache is cleared whenever the head block changes.
+type CallCache struct {
+	chain         callCacheChain
+	maxResultSize int
+
+	mutex sync.Mutex
+	head  common.Hash
+	cache *containers.Cache[common.Hash, json.RawMessage]
+}
+
+func NewCallCache(chain callCacheChain, config *CallCacheConfig) *CallCache {
+	return &CallCache{
+		chain:         chain,
+		maxResultSize: config.MaxResultSize,
+		cache: containers.NewCache[common.Hash, json.RawMessage](containers.CacheOpts[json.RawMessage]{
+			Capacity:      config.Size,
+			MetricsPrefix: "arb/rpc/callcache",
+		}),
+	}
+}
+
+type callCacheRequest struct {
+	Version string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type callCacheResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// WrapHandler returns an http handler which answers cacheable eth_call requests from
+// the cache, and passes everything else through to next.
+func (c *CallCache) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCachedCallRequestSize+1))
+		if err != nil || len(body) > maxCachedCallRequestSize {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		var req callCacheRequest
+		if json.Unmarshal(body, &req) != nil || req.Method != "eth_call" || len(req.Params) == 0 {
+			// Batches and other methods
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, pinnedBody, ok := c.prepare(&req)
+		if !ok {
+			callCacheUncacheableCounter.Inc(1)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if result, ok := c.get(key); ok {
+			writeCallCacheResponse(w, &callCacheResponse{Version: "2.0", ID: req.ID, Result: result})
+			return
+		}
+		inner := r.Clone(r.Context())
+		inner.Body = io.NopCloser(bytes.NewReader(pinnedBody))
+		inner.ContentLength = int64(len(pinnedBody))
+		// The response is parsed below, so it mustn't be compressed
+		inner.Header.Del("Accept-Encoding")
+		recorder := newCallCacheRecorder()
+		next.ServeHTTP(recorder, inner)
+		if recorder.status == http.StatusOK {
+			var resp callCacheResponse
+			if json.Unmarshal(recorder.body.Bytes(), &resp) == nil && len(resp.Error) == 0 && len(resp.Result) > 0 && len(resp.Result) <= c.maxResultSize {
+				c.add(key, bytes.Clone(resp.Result))
+			}
+		}
+		recorder.writeTo(w)
+	})
+}
+
+// prepare computes the cache key of an eth_call, and the request pinned to the block the key refers to.
+// Calls against pending, safe, finalized or unknown blocks aren't cached.
+func (c *CallCache) prepare(req *callCacheRequest) (common.Hash, []byte, bool) {
+	blockParam := json.RawMessage(`"latest"`)
+	if len(req.Params) > 1 && string(req.Params[1]) != "null" {
+		blockParam = req.Params[1]
+	}
+	var blockNrOrHash rpc.BlockNumberOrHash
+	if err := json.Unmarshal(blockParam, &blockNrOrHash); err != nil {
+		return common.Hash{}, nil, false
+	}
+	var header *types.Header
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header = c.chain.GetHeaderByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.LatestBlockNumber {
+			header = c.chain.CurrentBlock()
+		} else if number >= 0 {
+			// #nosec G115
+			header = c.chain.GetHeaderByNumber(uint64(number))
+		}
+	}
+	if header == nil {
+		return common.Hash{}, nil, false
+	}
+	blockHash := header.Hash()
+
+	if len(req.Params) > 4 {
+		return common.Hash{}, nil, false
+	}
+	var canonical [4][]byte
+	for i := range canonical {
+		var param json.RawMessage
+		if i < len(req.Params) && i != 1 {
+			param = req.Params[i]
+		}
+		var err error
+		canonical[i], err = canonicalCallCacheJSON(param)
+		if err != nil {
+			return common.Hash{}, nil, false
+		}
+	}
+	// The call arguments, state overrides and block overrides, with the potentially large state overrides hashed
+	key := crypto.Keccak256Hash(blockHash.Bytes(), canonical[0], crypto.Keccak256(canonical[2]), canonical[3])
+
+	pinned := *req
+	pinned.Params = append([]json.RawMessage{}, req.Params...)
+	if len(pinned.Params) < 2 {
+		pinned.Params = append(pinned.Params, nil)
+	}
+	pinnedBlock, err := json.Marshal(map[string]interface{}{"blockHash": blockHash})
+	if err != nil {
+		return common.Hash{}, nil, false
+	}
+	pinned.Params[1] = pinnedBlock
+	pinnedBody, err := json.Marshal(&pinned)
+	if err != nil {
+		return common.Hash{}, nil, false
+	}
+	return key, pinnedBody, true
+}
+
+// canonicalCallCacheJSON re-encodes a parameter so equivalent requests get the same key regardless of field order and whitespace.
+func canonicalCallCacheJSON(param json.RawMessage) ([]byte, error) {
+	if len(param) == 0 {
+		return []byte("null"), nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(param))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// checkHead clears the cache if the head block changed since it was last called.
+// Must be called with the mutex held.
+func (c *CallCache) checkHead() {
+	head := c.chain.CurrentBlock()
+	if head == nil {
+		return
+	}
+	if hash := head.Hash(); hash != c.head {
+		if c.cache.Len() > 0 {
+			c.cache.Clear()
+			callCacheInvalidationsCounter.Inc(1)
+		}
+		c.head = hash
+	}
+}
+
+func (c *CallCache) get(key common.Hash) (json.RawMessage, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checkHead()
+	return c.cache.Get(key)
+}
+
+func (c *CallCache) add(key common.Hash, result json.RawMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checkHead()
+	c.cache.Add(key, result)
+}
+
+func writeCallCacheResponse(w http.ResponseWriter, resp *callCacheResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// callCacheRecorder buffers a response so its result can be cached before it's sent to the client.
+type callCacheRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newCallCacheRecorder() *callCacheRecorder {
+	return &callCacheRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *callCacheRecorder) Header() http.Header         { return r.header }
+func (r *callCacheRecorder) Write(p []byte) (int, error) { return r.body.Write(p) }
+func (r *callCacheRecorder) WriteHeader(status int)      { r.status = status }
+
+func (r *callCacheRecorder) writeTo(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -0,0 +1,118 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testCallCacheChain struct {
+	headers []*types.Header
+}
+
+func (c *testCallCacheChain) CurrentBlock() *types.Header {
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *testCallCacheChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *testCallCacheChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+func (c *testCallCacheChain) addBlock() {
+	c.headers = append(c.headers, &types.Header{Number: big.NewInt(int64(len(c.headers)))})
+}
+
+func TestCallCache(t *testing.T) {
+	chain := &testCallCacheChain{}
+	chain.addBlock()
+	chain.addBlock()
+
+	var calls int
+	var lastBlockParam string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var req callCacheRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		calls++
+		if len(req.Params) > 1 {
+			lastBlockParam = string(req.Params[1])
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"0x01"}`))
+	})
+	handler := NewCallCache(chain, &DefaultCallCacheConfig).WrapHandler(inner)
+
+	call := func(body string) string {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status %v", recorder.Code)
+		}
+		return recorder.Body.String()
+	}
+	expectCalls := func(expected int) {
+		t.Helper()
+		if calls != expected {
+			t.Fatalf("expected %v calls to reach the inner handler but got %v", expected, calls)
+		}
+	}
+
+	call(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x0000000000000000000000000000000000000001","data":"0x"},"latest"]}`)
+	expectCalls(1)
+	pinned, err := json.Marshal(map[string]interface{}{"blockHash": chain.CurrentBlock().Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastBlockParam != string(pinned) {
+		t.Fatalf("call wasn't pinned to the latest block hash: %v", lastBlockParam)
+	}
+
+	// Same call with different field order, whitespace and block tag hits the cache and keeps its id
+	resp := call(`{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"data":"0x", "to":"0x0000000000000000000000000000000000000001"},"0x1"]}`)
+	expectCalls(1)
+	if !strings.Contains(resp, `"id":2`) || !strings.Contains(resp, `"result":"0x01"`) {
+		t.Fatalf("unexpected cached response %v", resp)
+	}
+
+	// State overrides are part of the key
+	call(`{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[{"to":"0x0000000000000000000000000000000000000001","data":"0x"},"latest",{"0x0000000000000000000000000000000000000002":{"balance":"0x1"}}]}`)
+	expectCalls(2)
+
+	// Pending calls aren't cached, and other methods pass through
+	call(`{"jsonrpc":"2.0","id":4,"method":"eth_call","params":[{"to":"0x0000000000000000000000000000000000000001","data":"0x"},"pending"]}`)
+	call(`{"jsonrpc":"2.0","id":5,"method":"eth_call","params":[{"to":"0x0000000000000000000000000000000000000001","data":"0x"},"pending"]}`)
+	call(`{"jsonrpc":"2.0","id":6,"method":"eth_blockNumber","params":[]}`)
+	expectCalls(5)
+
+	// A new head clears the cache
+	chain.addBlock()
+	call(`{"jsonrpc":"2.0","id":7,"method":"eth_call","params":[{"to":"0x0000000000000000000000000000000000000001","data":"0x"},"0x1"]}`)
+	expectCalls(6)
+}
@@ -0,0 +1,158 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+const (
+	// The batch poster's default compression level
+	footprintBatchCompressionLevel = brotli.BestCompression
+	// How much of the recent chain history stands in for the rest of the batch
+	footprintContextBytes  = 32 * 1024
+	footprintContextBlocks = 64
+)
+
+type CalldataFootprint struct {
+	// The size of the signed transaction
+	Size hexutil.Uint64 `json:"size"`
+	// The transaction's size compressed on its own at ArbOS's brotli level, which is what it's charged for
+	ChargedSize   hexutil.Uint64 `json:"chargedSize"`
+	CalldataUnits hexutil.Uint64 `json:"calldataUnits"`
+	PricePerUnit  *hexutil.Big   `json:"pricePerUnit"`
+	// The L1 fee charged in wei, and the gas it's charged as at the current base fee
+	L1Fee     *hexutil.Big   `json:"l1Fee"`
+	PosterGas hexutil.Uint64 `json:"posterGas"`
+	// Estimate of how many bytes the transaction adds to the next batch, compressed together with recent
+	// transactions as the batch poster would
+	BatchSize hexutil.Uint64 `json:"batchSize"`
+}
+
+type CalldataFootprintAPI struct {
+	bc *core.BlockChain
+}
+
+func NewCalldataFootprintAPI(bc *core.BlockChain) *CalldataFootprintAPI {
+	return &CalldataFootprintAPI{bc}
+}
+
+// EstimateCalldataFootprint returns the L1 calldata footprint of a signed transaction and the L1 fee it
+// would be charged at the latest block, so senders can compare encodings against the actual compressor.
+func (api *CalldataFootprintAPI) EstimateCalldataFootprint(_ context.Context, input hexutil.Bytes) (*CalldataFootprint, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if !util.TxTypeHasPosterCosts(tx.Type()) {
+		return nil, errors.New("transaction type has no L1 calldata cost")
+	}
+	header := api.bc.CurrentBlock()
+	statedb, err := api.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	brotliLevel, err := arbState.BrotliCompressionLevel()
+	if err != nil {
+		return nil, err
+	}
+	l1Pricing := arbState.L1PricingState()
+	pricePerUnit, err := l1Pricing.PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	l1Fee, units := l1Pricing.GetPosterInfo(tx, l1pricing.BatchPosterAddress, brotliLevel)
+
+	footprint := &CalldataFootprint{
+		Size:          hexutil.Uint64(len(input)),
+		ChargedSize:   hexutil.Uint64(units / params.TxDataNonZeroGasEIP2028),
+		CalldataUnits: hexutil.Uint64(units),
+		PricePerUnit:  (*hexutil.Big)(pricePerUnit),
+		L1Fee:         (*hexutil.Big)(l1Fee),
+	}
+	if header.BaseFee != nil && header.BaseFee.Sign() > 0 {
+		footprint.PosterGas = hexutil.Uint64(arbos.GetPosterGas(arbState, header.BaseFee, core.MessageCommitMode, l1Fee))
+	}
+	batchSize, err := api.batchSize(header, input)
+	if err != nil {
+		return nil, err
+	}
+	footprint.BatchSize = hexutil.Uint64(batchSize)
+	return footprint, nil
+}
+
+// batchSize estimates the transaction's marginal size in a batch, by compressing the latest user
+// transactions with and without it appended.
+func (api *CalldataFootprintAPI) batchSize(header *types.Header, txBytes []byte) (uint64, error) {
+	var recent [][]byte
+	contextSize := 0
+	number := header.Number.Uint64()
+	for i := 0; i < footprintContextBlocks && contextSize < footprintContextBytes; i++ {
+		block := api.bc.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		txs := block.Transactions()
+		for j := len(txs) - 1; j >= 0 && contextSize < footprintContextBytes; j-- {
+			if !util.TxTypeHasPosterCosts(txs[j].Type()) {
+				continue
+			}
+			data, err := txs[j].MarshalBinary()
+			if err != nil {
+				return 0, err
+			}
+			recent = append(recent, data)
+			contextSize += len(data)
+		}
+		if number == 0 {
+			break
+		}
+		number--
+	}
+	var batch []byte
+	for i := len(recent) - 1; i >= 0; i-- {
+		batch = append(batch, recent[i]...)
+	}
+	without, err := compressedSize(batch)
+	if err != nil {
+		return 0, err
+	}
+	with, err := compressedSize(append(batch, txBytes...))
+	if err != nil {
+		return 0, err
+	}
+	return arbmath.SaturatingUSub(with, without), nil
+}
+
+func compressedSize(data []byte) (uint64, error) {
+	var buffer bytes.Buffer
+	writer := brotli.NewWriterLevel(&buffer, footprintBatchCompressionLevel)
+	if _, err := writer.Write(data); err != nil {
+		return 0, err
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	return uint64(buffer.Len()), nil
+}
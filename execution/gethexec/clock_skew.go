@@ -0,0 +1,170 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/sntp"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	ntpOffsetGauge          = metrics.NewRegisteredGauge("arb/sequencer/clockskew/ntpoffset", nil)
+	ntpRTTGauge             = metrics.NewRegisteredGauge("arb/sequencer/clockskew/ntprtt", nil)
+	ntpFailureCounter       = metrics.NewRegisteredCounter("arb/sequencer/clockskew/ntpfailure", nil)
+	parentChainDriftGauge   = metrics.NewRegisteredGauge("arb/sequencer/clockskew/parentchaindrift", nil)
+	clockSkewRefusedCounter = metrics.NewRegisteredCounter("arb/sequencer/clockskew/refused", nil)
+)
+
+var ErrClockSkew = errors.New("local clock skew too large")
+
+type ClockSkewConfig struct {
+	Enable       bool          `koanf:"enable"`
+	NTPServers   []string      `koanf:"ntp-servers"`
+	PollInterval time.Duration `koanf:"poll-interval"`
+	QueryTimeout time.Duration `koanf:"query-timeout"`
+	MaxSkew      time.Duration `koanf:"max-skew" reload:"hot"`
+	MaxNTPAge    time.Duration `koanf:"max-ntp-age" reload:"hot"`
+	RequireNTP   bool          `koanf:"require-ntp" reload:"hot"`
+}
+
+var DefaultClockSkewConfig = ClockSkewConfig{
+	Enable:       false,
+	NTPServers:   []string{"pool.ntp.org"},
+	PollInterval: time.Minute,
+	QueryTimeout: 5 * time.Second,
+	MaxSkew:      time.Second,
+	MaxNTPAge:    10 * time.Minute,
+	RequireNTP:   false,
+}
+
+func ClockSkewConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultClockSkewConfig.Enable, "measure the local clock against NTP servers and refuse to sequence blocks while it's skewed")
+	f.StringSlice(prefix+".ntp-servers", DefaultClockSkewConfig.NTPServers, "NTP servers to measure the local clock against, as host or host:port (the median offset is used)")
+	f.Duration(prefix+".poll-interval", DefaultClockSkewConfig.PollInterval, "how often to query the NTP servers")
+	f.Duration(prefix+".query-timeout", DefaultClockSkewConfig.QueryTimeout, "timeout for querying an NTP server")
+	f.Duration(prefix+".max-skew", DefaultClockSkewConfig.MaxSkew, "maximum offset between the local clock and the NTP servers to sequence blocks at")
+	f.Duration(prefix+".max-ntp-age", DefaultClockSkewConfig.MaxNTPAge, "NTP measurements older than this are ignored")
+	f.Bool(prefix+".require-ntp", DefaultClockSkewConfig.RequireNTP, "refuse to sequence blocks without a recent NTP measurement")
+}
+
+func (c *ClockSkewConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if len(c.NTPServers) == 0 {
+		return errors.New("clock skew monitoring requires at least one NTP server")
+	}
+	if c.PollInterval <= 0 || c.QueryTimeout <= 0 {
+		return errors.New("clock skew poll-interval and query-timeout must be positive")
+	}
+	if c.MaxSkew <= 0 {
+		return errors.New("clock skew max-skew must be positive")
+	}
+	if c.MaxNTPAge < c.PollInterval {
+		return errors.New("clock skew max-ntp-age can't be shorter than poll-interval")
+	}
+	return nil
+}
+
+type ClockSkewConfigFetcher func() *ClockSkewConfig
+
+// ClockSkewMonitor measures the local clock's offset against NTP servers, so the sequencer can refuse to
+// give blocks timestamps its clock can't vouch for. Timestamps are never adjusted: block timestamps must
+// stay monotonic and within the bounds the parent chain's inbox enforces, so sequencing waits for the clock
+// to be fixed instead.
+type ClockSkewMonitor struct {
+	stopwaiter.StopWaiter
+	config ClockSkewConfigFetcher
+	query  func(ctx context.Context, server string) (*sntp.Response, error)
+
+	mutex      sync.Mutex
+	offset     time.Duration
+	measuredAt time.Time
+}
+
+func NewClockSkewMonitor(config ClockSkewConfigFetcher) *ClockSkewMonitor {
+	return &ClockSkewMonitor{
+		config: config,
+		query:  sntp.Query,
+	}
+}
+
+func (m *ClockSkewMonitor) Start(ctx context.Context) {
+	m.StopWaiter.Start(ctx, m)
+	m.CallIteratively(func(ctx context.Context) time.Duration {
+		m.measure(ctx)
+		return m.config().PollInterval
+	})
+}
+
+// measure queries every NTP server and records the median offset of those that answered.
+func (m *ClockSkewMonitor) measure(ctx context.Context) {
+	config := m.config()
+	var offsets []time.Duration
+	for _, server := range config.NTPServers {
+		queryCtx, cancel := context.WithTimeout(ctx, config.QueryTimeout)
+		response, err := m.query(queryCtx, server)
+		cancel()
+		if err != nil {
+			ntpFailureCounter.Inc(1)
+			log.Debug("failed to query NTP server", "server", server, "err", err)
+			continue
+		}
+		ntpRTTGauge.Update(response.RTT.Milliseconds())
+		offsets = append(offsets, response.Offset)
+	}
+	if len(offsets) == 0 {
+		log.Warn("no NTP server answered, can't measure local clock skew", "servers", config.NTPServers)
+		return
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	offset := offsets[len(offsets)/2]
+	ntpOffsetGauge.Update(offset.Milliseconds())
+	if offset > config.MaxSkew || offset < -config.MaxSkew {
+		log.Error("local clock is skewed, sequencing is refused until it's fixed", "offset", offset, "maxSkew", config.MaxSkew)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.offset = offset
+	m.measuredAt = time.Now()
+}
+
+// Offset returns the last measured offset of the NTP servers' clocks ahead of the local one, and whether
+// the measurement is recent enough to rely on.
+func (m *ClockSkewMonitor) Offset() (time.Duration, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.measuredAt.IsZero() || time.Since(m.measuredAt) > m.config().MaxNTPAge {
+		return 0, false
+	}
+	return m.offset, true
+}
+
+// Check returns an error if the local clock is too skewed to sequence a block.
+func (m *ClockSkewMonitor) Check() error {
+	config := m.config()
+	offset, ok := m.Offset()
+	if !ok {
+		if config.RequireNTP {
+			return fmt.Errorf("%w: no recent NTP measurement", ErrClockSkew)
+		}
+		return nil
+	}
+	if offset > config.MaxSkew || offset < -config.MaxSkew {
+		return fmt.Errorf("%w: NTP clocks are %v ahead of the local clock, max skew is %v", ErrClockSkew, offset, config.MaxSkew)
+	}
+	return nil
+}
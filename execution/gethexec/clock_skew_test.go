@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/util/sntp"
+)
+
+func newTestClockSkewMonitor(config *ClockSkewConfig, offsets map[string]time.Duration) *ClockSkewMonitor {
+	monitor := NewClockSkewMonitor(func() *ClockSkewConfig { return config })
+	monitor.query = func(_ context.Context, server string) (*sntp.Response, error) {
+		offset, ok := offsets[server]
+		if !ok {
+			return nil, errors.New("no answer")
+		}
+		return &sntp.Response{Offset: offset, Stratum: 1}, nil
+	}
+	return monitor
+}
+
+func TestClockSkewMonitorUsesMedianOffset(t *testing.T) {
+	config := DefaultClockSkewConfig
+	config.Enable = true
+	config.NTPServers = []string{"a", "b", "c", "down"}
+	// One server far off doesn't refuse sequencing when the others agree
+	monitor := newTestClockSkewMonitor(&config, map[string]time.Duration{
+		"a": 10 * time.Millisecond,
+		"b": -20 * time.Millisecond,
+		"c": time.Hour,
+	})
+	if err := monitor.Check(); err != nil {
+		t.Fatal("check failed without a measurement", err)
+	}
+	monitor.measure(context.Background())
+	offset, ok := monitor.Offset()
+	if !ok || offset != 10*time.Millisecond {
+		t.Fatal("expected median offset 10ms but got", offset, ok)
+	}
+	if err := monitor.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClockSkewMonitorRefusesSkewedClock(t *testing.T) {
+	config := DefaultClockSkewConfig
+	config.Enable = true
+	config.NTPServers = []string{"a"}
+	offsets := map[string]time.Duration{"a": -2 * config.MaxSkew}
+	monitor := newTestClockSkewMonitor(&config, offsets)
+	monitor.measure(context.Background())
+	if err := monitor.Check(); !errors.Is(err, ErrClockSkew) {
+		t.Fatal("expected a clock skew error but got", err)
+	}
+
+	offsets["a"] = config.MaxSkew / 2
+	monitor.measure(context.Background())
+	if err := monitor.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stale measurements are ignored, unless NTP is required
+	monitor.measuredAt = time.Now().Add(-2 * config.MaxNTPAge)
+	if err := monitor.Check(); err != nil {
+		t.Fatal(err)
+	}
+	config.RequireNTP = true
+	if err := monitor.Check(); !errors.Is(err, ErrClockSkew) {
+		t.Fatal("expected a clock skew error without a recent measurement but got", err)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/node"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/execution/txscreener"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// Reasons a sequencer gas estimate can report for a transaction the sequencer would reject.
+const (
+	EstimateFailureExecution          = "execution-failed"
+	EstimateFailureTxTooLarge         = "tx-too-large"
+	EstimateFailureConditionalOptions = "conditional-options"
+	EstimateFailureFeeCapTooLow       = "fee-cap-too-low"
+	EstimateFailureNonceTooLow        = "nonce-too-low"
+	EstimateFailureNonceGap           = "nonce-gap"
+	EstimateFailureIntrinsicGas       = "intrinsic-gas"
+	EstimateFailureInsufficientFunds  = "insufficient-funds"
+	EstimateFailureSequencerFrozen    = "sequencer-frozen"
+	EstimateFailureScreened           = "screened"
+)
+
+// EstimateGasArgs are the eth_estimateGas arguments the sequencer's rules depend on.
+type EstimateGasArgs struct {
+	From                 *common.Address   `json:"from,omitempty"`
+	To                   *common.Address   `json:"to,omitempty"`
+	Gas                  *hexutil.Uint64   `json:"gas,omitempty"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big      `json:"value,omitempty"`
+	Nonce                *hexutil.Uint64   `json:"nonce,omitempty"`
+	Data                 *hexutil.Bytes    `json:"data,omitempty"`
+	Input                *hexutil.Bytes    `json:"input,omitempty"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+}
+
+func (args *EstimateGasArgs) data() []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+func (args *EstimateGasArgs) feeCap() *big.Int {
+	if args.MaxFeePerGas != nil {
+		return args.MaxFeePerGas.ToInt()
+	}
+	if args.GasPrice != nil {
+		return args.GasPrice.ToInt()
+	}
+	return nil
+}
+
+type EstimateFailure struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+type SequencerGasEstimate struct {
+	Gas hexutil.Uint64 `json:"gas"`
+	// Whether the sequencer would currently accept the transaction with the estimated gas
+	Accepted bool              `json:"accepted"`
+	Failures []EstimateFailure `json:"failures,omitempty"`
+}
+
+func (e *SequencerGasEstimate) fail(reason string, err error) {
+	e.Failures = append(e.Failures, EstimateFailure{Reason: reason, Message: err.Error()})
+}
+
+// GasEstimationAPI estimates gas the way eth_estimateGas does, then checks the transaction
+// against the rules the sequencer and its pre-checker apply before accepting it.
+type GasEstimationAPI struct {
+	stack         *node.Node
+	bc            *core.BlockChain
+	sequencer     *Sequencer // nil unless this node is the sequencer
+	configFetcher ConfigFetcher
+}
+
+func NewGasEstimationAPI(stack *node.Node, bc *core.BlockChain, sequencer *Sequencer, configFetcher ConfigFetcher) *GasEstimationAPI {
+	return &GasEstimationAPI{
+		stack:         stack,
+		bc:            bc,
+		sequencer:     sequencer,
+		configFetcher: configFetcher,
+	}
+}
+
+// EstimateGasForSequencer returns a gas estimate along with every reason the sequencer would reject the
+// transaction, so wallets don't submit transactions that estimate fine but are then refused.
+func (api *GasEstimationAPI) EstimateGasForSequencer(ctx context.Context, args EstimateGasArgs, options *arbitrum_types.ConditionalOptions) (*SequencerGasEstimate, error) {
+	client := api.stack.Attach()
+	defer client.Close()
+	estimate := &SequencerGasEstimate{}
+	if err := client.CallContext(ctx, &estimate.Gas, "eth_estimateGas", args, "latest"); err != nil {
+		estimate.fail(EstimateFailureExecution, err)
+		return estimate, nil
+	}
+
+	header := api.bc.CurrentBlock()
+	statedb, err := api.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var sender common.Address
+	if args.From != nil {
+		sender = *args.From
+	}
+	gas := uint64(estimate.Gas)
+	tx := api.unsignedTx(&args, gas)
+
+	config := api.configFetcher()
+	maxTxSize, maxCalldataSize, err := api.sizeLimits(arbState)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSequencerTxSize(tx, maxTxSize, maxCalldataSize); err != nil {
+		estimate.fail(EstimateFailureTxTooLarge, err)
+	}
+	if options != nil {
+		extraInfo := types.DeserializeHeaderExtraInformation(header)
+		if err := options.Check(extraInfo.L1BlockNumber, header.Time, statedb); err != nil {
+			estimate.fail(EstimateFailureConditionalOptions, err)
+		}
+	}
+	if feeCap := args.feeCap(); feeCap != nil && config.TxPreChecker.Strictness >= TxPreCheckerStrictnessLikelyCompatible {
+		if arbmath.BigLessThan(feeCap, header.BaseFee) {
+			estimate.fail(EstimateFailureFeeCapTooLow, fmt.Errorf("%w: maxFeePerGas: %s baseFee: %s", core.ErrFeeCapTooLow, feeCap, header.BaseFee))
+		}
+	}
+	stateNonce := statedb.GetNonce(sender)
+	if args.Nonce != nil && uint64(*args.Nonce) < stateNonce {
+		estimate.fail(EstimateFailureNonceTooLow, MakeNonceError(sender, uint64(*args.Nonce), stateNonce))
+	}
+	if args.From != nil {
+		feeCap := args.feeCap()
+		if feeCap == nil {
+			feeCap = header.BaseFee
+		}
+		cost := arbmath.BigMulByUint(feeCap, gas)
+		if args.Value != nil {
+			cost = arbmath.BigAdd(cost, args.Value.ToInt())
+		}
+		if balance := statedb.GetBalance(sender).ToBig(); arbmath.BigLessThan(balance, cost) {
+			estimate.fail(EstimateFailureInsufficientFunds, fmt.Errorf("%w: address %v have %v want %v", core.ErrInsufficientFunds, sender, balance, cost))
+		}
+	}
+	if err := api.checkFrozen(arbState, sender); err != nil {
+		estimate.fail(EstimateFailureSequencerFrozen, err)
+	}
+	if api.sequencer != nil && api.sequencer.screener != nil {
+		result, err := api.sequencer.screener.Screen(ctx, sender, tx)
+		if err == nil && result.Decision == txscreener.Reject {
+			err = fmt.Errorf("%w: reason %v", txscreener.ErrRejected, result.Reason)
+		}
+		if err != nil {
+			estimate.fail(EstimateFailureScreened, err)
+		}
+	}
+	estimate.Accepted = len(estimate.Failures) == 0
+	return estimate, nil
+}
+
+// unsignedTx builds the transaction with the largest possible signature, so its size is an upper bound
+// on the size the sequencer will see once it's signed.
+func (api *GasEstimationAPI) unsignedTx(args *EstimateGasArgs, gas uint64) *types.Transaction {
+	maxSignatureValue := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	inner := &types.DynamicFeeTx{
+		ChainID:   api.bc.Config().ChainID,
+		Gas:       gas,
+		GasFeeCap: new(big.Int),
+		GasTipCap: new(big.Int),
+		To:        args.To,
+		Value:     new(big.Int),
+		Data:      args.data(),
+		V:         common.Big1,
+		R:         maxSignatureValue,
+		S:         maxSignatureValue,
+	}
+	if args.Nonce != nil {
+		inner.Nonce = uint64(*args.Nonce)
+	}
+	if feeCap := args.feeCap(); feeCap != nil {
+		inner.GasFeeCap = feeCap
+	}
+	if args.MaxPriorityFeePerGas != nil {
+		inner.GasTipCap = args.MaxPriorityFeePerGas.ToInt()
+	}
+	if args.Value != nil {
+		inner.Value = args.Value.ToInt()
+	}
+	if args.AccessList != nil {
+		inner.AccessList = *args.AccessList
+	}
+	return types.NewTx(inner)
+}
+
+// sizeLimits returns the largest transaction and calldata the sequencer accepts, with 0 meaning no calldata limit.
+func (api *GasEstimationAPI) sizeLimits(arbState *arbosState.ArbosState) (uint64, uint64, error) {
+	maxTxSize, err := arbState.MaxTxSize()
+	if err != nil {
+		return 0, 0, err
+	}
+	if maxTxSize == 0 {
+		maxTxSize = uint64(api.configFetcher().Sequencer.MaxTxDataSize) // #nosec G115
+	}
+	maxCalldataSize, err := arbState.MaxCalldataSize()
+	return maxTxSize, maxCalldataSize, err
+}
+
+func checkSequencerTxSize(tx *types.Transaction, maxTxSize, maxCalldataSize uint64) error {
+	if size := tx.Size(); size > maxTxSize {
+		return fmt.Errorf("%w: size %v, max %v", txpool.ErrOversizedData, size, maxTxSize)
+	}
+	if maxCalldataSize != 0 && uint64(len(tx.Data())) > maxCalldataSize {
+		return fmt.Errorf("%w: size %v, max %v", arbosState.ErrCalldataTooLarge, len(tx.Data()), maxCalldataSize)
+	}
+	return nil
+}
+
+func (api *GasEstimationAPI) checkFrozen(arbState *arbosState.ArbosState, sender common.Address) error {
+	if api.sequencer != nil && api.sequencer.execEngine.IsFrozen() {
+		return execution.ErrSequencerFrozen
+	}
+	frozenSince, err := arbState.SequencerFrozenSince()
+	if err != nil || frozenSince == 0 {
+		return err
+	}
+	isOwner, err := arbState.ChainOwners().IsMember(sender)
+	if err != nil || isOwner {
+		return err
+	}
+	return execution.ErrSequencerFrozen
+}
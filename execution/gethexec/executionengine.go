@@ -23,9 +23,11 @@ import (
 	"runtime/pprof"
 	"runtime/trace"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -42,6 +44,9 @@ import (
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
+	"github.com/offchainlabs/nitro/util/membudget"
+	"github.com/offchainlabs/nitro/util/profiling"
 	"github.com/offchainlabs/nitro/util/sharedmetrics"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
@@ -91,7 +96,23 @@ type ExecutionEngine struct {
 
 	prefetchBlock bool
 
+	asyncBlockWrites       bool
+	pendingBlockWriteMutex sync.Mutex
+	pendingBlockWrite      *pendingBlockWrite
+
 	cachedL1PriceData *L1PriceData
+
+	// set while an operator has frozen block production, checked under the createBlocksMutex
+	freeze atomic.Pointer[sequencerFreeze]
+
+	sequencingTimestamps *SequencingTimestamps // nil unless recording is enabled
+	inclusionReceipts    *InclusionReceipts    // nil unless enabled
+	l1PricingSnapshots   *L1PricingSnapshots   // nil unless recording is enabled
+	retryableIndex       *RetryableIndex       // nil unless enabled
+	addressActivityIndex *AddressActivityIndex // nil unless enabled
+	extendedBloomIndex   *ExtendedBloomIndex   // nil unless enabled
+	outboxMerkleIndex    *OutboxMerkleIndex    // nil unless enabled
+	ownerAuditLog        *OwnerAuditLog        // nil unless enabled
 }
 
 func NewL1PriceData() *L1PriceData {
@@ -184,9 +205,28 @@ func PopulateStylusTargetCache(targetConfig *StylusTargetConfig) error {
 	return nil
 }
 
+// wasmLruCacheBudget lets the memory budget resize the stylus LRU cache
+type wasmLruCacheBudget struct{}
+
+func (wasmLruCacheBudget) MemoryUsage() uint64 {
+	return programs.GetWasmCacheMetrics().Lru.SizeBytes
+}
+
+func (wasmLruCacheBudget) SetMemoryLimit(bytes uint64) {
+	programs.SetWasmLruCacheCapacity(bytes)
+}
+
+// ClearWasmLruCache drops the compiled stylus programs cached in memory, which are recompiled or reloaded on their next call.
+func (s *ExecutionEngine) ClearWasmLruCache() {
+	programs.ClearWasmLruCache()
+	programs.UpdateWasmCacheMetrics()
+}
+
 func (s *ExecutionEngine) Initialize(rustCacheCapacityMB uint32, targetConfig *StylusTargetConfig) error {
 	if rustCacheCapacityMB != 0 {
 		programs.SetWasmLruCacheCapacity(arbmath.SaturatingUMul(uint64(rustCacheCapacityMB), 1024*1024))
+		// If a memory budget is configured it overrides the configured capacity
+		membudget.Register("wasm-cache", wasmLruCacheBudget{}, membudget.DefaultConsumerOpts)
 	}
 	if err := PopulateStylusTargetCache(targetConfig); err != nil {
 		return fmt.Errorf("error populating stylus target cache: %w", err)
@@ -234,6 +274,86 @@ func (s *ExecutionEngine) EnablePrefetchBlock() {
 	s.prefetchBlock = true
 }
 
+func (s *ExecutionEngine) EnableSequencingTimestamps(timestamps *SequencingTimestamps) {
+	if s.Started() {
+		panic("trying to enable sequencing timestamps after start")
+	}
+	if s.sequencingTimestamps != nil {
+		panic("trying to enable sequencing timestamps when already set")
+	}
+	s.sequencingTimestamps = timestamps
+}
+
+func (s *ExecutionEngine) EnableInclusionReceipts(receipts *InclusionReceipts) {
+	if s.Started() {
+		panic("trying to enable inclusion receipts after start")
+	}
+	if s.inclusionReceipts != nil {
+		panic("trying to enable inclusion receipts when already set")
+	}
+	s.inclusionReceipts = receipts
+}
+
+func (s *ExecutionEngine) EnableL1PricingSnapshots(snapshots *L1PricingSnapshots) {
+	if s.Started() {
+		panic("trying to enable l1 pricing snapshots after start")
+	}
+	if s.l1PricingSnapshots != nil {
+		panic("trying to enable l1 pricing snapshots when already set")
+	}
+	s.l1PricingSnapshots = snapshots
+}
+
+func (s *ExecutionEngine) EnableRetryableIndex(index *RetryableIndex) {
+	if s.Started() {
+		panic("trying to enable retryable index after start")
+	}
+	if s.retryableIndex != nil {
+		panic("trying to enable retryable index when already set")
+	}
+	s.retryableIndex = index
+}
+
+func (s *ExecutionEngine) EnableAddressActivityIndex(index *AddressActivityIndex) {
+	if s.Started() {
+		panic("trying to enable address activity index after start")
+	}
+	if s.addressActivityIndex != nil {
+		panic("trying to enable address activity index when already set")
+	}
+	s.addressActivityIndex = index
+}
+
+func (s *ExecutionEngine) EnableExtendedBloomIndex(index *ExtendedBloomIndex) {
+	if s.Started() {
+		panic("trying to enable extended bloom index after start")
+	}
+	if s.extendedBloomIndex != nil {
+		panic("trying to enable extended bloom index when already set")
+	}
+	s.extendedBloomIndex = index
+}
+
+func (s *ExecutionEngine) EnableOutboxMerkleIndex(index *OutboxMerkleIndex) {
+	if s.Started() {
+		panic("trying to enable outbox merkle index after start")
+	}
+	if s.outboxMerkleIndex != nil {
+		panic("trying to enable outbox merkle index when already set")
+	}
+	s.outboxMerkleIndex = index
+}
+
+func (s *ExecutionEngine) EnableOwnerAuditLog(auditLog *OwnerAuditLog) {
+	if s.Started() {
+		panic("trying to enable owner audit log after start")
+	}
+	if s.ownerAuditLog != nil {
+		panic("trying to enable owner audit log when already set")
+	}
+	s.ownerAuditLog = auditLog
+}
+
 func (s *ExecutionEngine) SetConsensus(consensus execution.FullConsensusClient) {
 	if s.Started() {
 		panic("trying to set transaction consensus after start")
@@ -253,6 +373,9 @@ func (s *ExecutionEngine) Reorg(count arbutil.MessageIndex, newMessages []arbost
 		return nil, errors.New("cannot reorg out genesis")
 	}
 	s.createBlocksMutex.Lock()
+	if err := s.waitForBlockWrite(); err != nil {
+		log.Warn("pending block write failed before reorg", "err", err)
+	}
 	resequencing := false
 	defer func() {
 		// if we are resequencing old messages - don't release the lock
@@ -301,6 +424,9 @@ func (s *ExecutionEngine) Reorg(count arbutil.MessageIndex, newMessages []arbost
 }
 
 func (s *ExecutionEngine) getCurrentHeader() (*types.Header, error) {
+	if pending := s.getPendingBlockWrite(); pending != nil {
+		return pending.header, nil
+	}
 	currentBlock := s.bc.CurrentBlock()
 	if currentBlock == nil {
 		return nil, errors.New("failed to get current block")
@@ -308,6 +434,15 @@ func (s *ExecutionEngine) getCurrentHeader() (*types.Header, error) {
 	return currentBlock, nil
 }
 
+// nextBlockNumber is only used for labelling profiles, so returns 0 on failure.
+func (s *ExecutionEngine) nextBlockNumber() uint64 {
+	header, err := s.getCurrentHeader()
+	if err != nil {
+		return 0
+	}
+	return header.Number.Uint64() + 1
+}
+
 func (s *ExecutionEngine) HeadMessageNumber() (arbutil.MessageIndex, error) {
 	currentHeader, err := s.getCurrentHeader()
 	if err != nil {
@@ -423,7 +558,15 @@ func (s *ExecutionEngine) sequencerWrapper(sequencerFunc func() (*types.Block, e
 	attempts := 0
 	for {
 		s.createBlocksMutex.Lock()
-		block, err := sequencerFunc()
+		if s.freeze.Load() != nil {
+			s.createBlocksMutex.Unlock()
+			return nil, execution.ErrSequencerFrozen
+		}
+		var block *types.Block
+		var err error
+		profiling.Do(profiling.PhaseSequencing, s.nextBlockNumber(), func() {
+			block, err = sequencerFunc()
+		})
 		s.createBlocksMutex.Unlock()
 		if !errors.Is(err, execution.ErrSequencerInsertLockTaken) {
 			return block, err
@@ -499,13 +642,18 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 		return nil, err
 	}
 
-	statedb, err := s.bc.StateAt(lastBlockHeader.Root)
+	statedb, err := s.stateAtHeader(lastBlockHeader)
 	if err != nil {
 		return nil, err
 	}
 
 	delayedMessagesRead := lastBlockHeader.Nonce.Uint64()
 
+	var sequencedAt map[common.Hash]uint64
+	if s.sequencingTimestamps != nil || s.inclusionReceipts != nil {
+		sequencedAt = recordSequencedAt(hooks)
+	}
+
 	startTime := time.Now()
 	block, receipts, err := arbos.ProduceBlockAdvanced(
 		header,
@@ -561,6 +709,12 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 		return nil, err
 	}
 
+	// The previous block must be in the database before this message is published
+	err = s.waitForBlockWrite()
+	if err != nil {
+		return nil, err
+	}
+
 	err = s.consensus.WriteMessageFromSequencer(pos, msgWithMeta, *msgResult)
 	if err != nil {
 		return nil, err
@@ -568,12 +722,31 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 
 	// Only write the block after we've written the messages, so if the node dies in the middle of this,
 	// it will naturally recover on startup by regenerating the missing block.
-	err = s.appendBlock(block, statedb, receipts, blockCalcTime)
+	err = s.appendBlockAsync(block, statedb, receipts, blockCalcTime)
 	if err != nil {
 		return nil, err
 	}
 	s.cacheL1PriceDataOfMsg(pos, receipts, block, false)
 
+	if s.sequencingTimestamps != nil {
+		// Only record the transactions that made it into the block
+		included := make(map[common.Hash]uint64, len(txes))
+		for i, tx := range txes {
+			if timestamp, ok := sequencedAt[tx.Hash()]; ok && hooks.TxErrors[i] == nil {
+				included[tx.Hash()] = timestamp
+			}
+		}
+		if err := s.sequencingTimestamps.write(included); err != nil {
+			log.Warn("failed to write sequencing timestamps", "block", block.Number(), "err", err)
+		}
+	}
+	if s.inclusionReceipts != nil {
+		if err := s.inclusionReceipts.record(pos, block, sequencedAt); err != nil {
+			log.Error("failed to record inclusion receipts", "block", block.Number(), "err", err)
+		}
+	}
+	flightrecorder.Record(flightrecorder.KindBlockSequenced, "message", pos, "block", block.NumberU64(), "hash", block.Hash(), "txs", len(block.Transactions()))
+
 	return block, nil
 }
 
@@ -585,6 +758,11 @@ func (s *ExecutionEngine) SequenceDelayedMessage(message *arbostypes.L1IncomingM
 }
 
 func (s *ExecutionEngine) sequenceDelayedMessageWithBlockMutex(message *arbostypes.L1IncomingMessage, delayedSeqNum uint64) (*types.Block, error) {
+	// Delayed messages are built on the blockchain's head, so any pending write must finish first
+	err := s.waitForBlockWrite()
+	if err != nil {
+		return nil, err
+	}
 	currentHeader, err := s.getCurrentHeader()
 	if err != nil {
 		return nil, err
@@ -623,7 +801,7 @@ func (s *ExecutionEngine) sequenceDelayedMessageWithBlockMutex(message *arbostyp
 		return nil, err
 	}
 
-	err = s.appendBlock(block, statedb, receipts, blockCalcTime)
+	err = s.appendBlockAsync(block, statedb, receipts, blockCalcTime)
 	if err != nil {
 		return nil, err
 	}
@@ -698,6 +876,12 @@ func (s *ExecutionEngine) appendBlock(block *types.Block, statedb *state.StateDB
 	for _, receipt := range receipts {
 		logs = append(logs, receipt.Logs...)
 	}
+	if s.l1PricingSnapshots != nil {
+		// Read the snapshot before writing the block commits the state
+		if err := s.l1PricingSnapshots.record(block.Hash(), statedb); err != nil {
+			log.Warn("failed to record l1 pricing snapshot", "block", block.Number(), "err", err)
+		}
+	}
 	status, err := s.bc.WriteBlockAndSetHeadWithTime(block, receipts, logs, statedb, true, duration)
 	if err != nil {
 		return err
@@ -705,6 +889,30 @@ func (s *ExecutionEngine) appendBlock(block *types.Block, statedb *state.StateDB
 	if status == core.SideStatTy {
 		return errors.New("geth rejected block as non-canonical")
 	}
+	if s.retryableIndex != nil {
+		if err := s.retryableIndex.indexBlock(block, receipts); err != nil {
+			log.Warn("failed to index retryables", "block", block.Number(), "err", err)
+		}
+	}
+	if s.outboxMerkleIndex != nil {
+		if err := s.outboxMerkleIndex.indexBlock(receipts); err != nil {
+			log.Warn("failed to index outbox merkle nodes", "block", block.Number(), "err", err)
+		}
+	}
+	if s.addressActivityIndex != nil {
+		signer := types.MakeSigner(s.bc.Config(), block.Number(), block.Time())
+		if err := s.addressActivityIndex.indexBlock(block, receipts, signer); err != nil {
+			log.Warn("failed to index address activity", "block", block.Number(), "err", err)
+		}
+	}
+	if s.extendedBloomIndex != nil {
+		if err := s.extendedBloomIndex.indexBlock(block, receipts); err != nil {
+			log.Warn("failed to index extended bloom", "block", block.Number(), "err", err)
+		}
+	}
+	if s.ownerAuditLog != nil {
+		s.ownerAuditLog.observeBlock(block, receipts)
+	}
 	baseFeeGauge.Update(block.BaseFee().Int64())
 	txCountHistogram.Update(int64(len(block.Transactions()) - 1))
 	var blockGasused uint64
@@ -731,6 +939,9 @@ func (s *ExecutionEngine) resultFromHeader(header *types.Header) (*execution.Mes
 }
 
 func (s *ExecutionEngine) ResultAtPos(pos arbutil.MessageIndex) (*execution.MessageResult, error) {
+	if pending := s.getPendingBlockWrite(); pending != nil && s.MessageIndexToBlockNumber(pos) == pending.header.Number.Uint64() {
+		return s.resultFromHeader(pending.header)
+	}
 	return s.resultFromHeader(s.bc.GetHeaderByNumber(s.MessageIndexToBlockNumber(pos)))
 }
 
@@ -844,10 +1055,18 @@ func (s *ExecutionEngine) DigestMessage(num arbutil.MessageIndex, msg *arbostype
 		return nil, errors.New("createBlock mutex held")
 	}
 	defer s.createBlocksMutex.Unlock()
-	return s.digestMessageWithBlockMutex(num, msg, msgForPrefetch)
+	var result *execution.MessageResult
+	var err error
+	profiling.Do(profiling.PhaseDigest, s.MessageIndexToBlockNumber(num), func() {
+		result, err = s.digestMessageWithBlockMutex(num, msg, msgForPrefetch)
+	})
+	return result, err
 }
 
 func (s *ExecutionEngine) digestMessageWithBlockMutex(num arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata, msgForPrefetch *arbostypes.MessageWithMetadata) (*execution.MessageResult, error) {
+	if err := s.waitForBlockWrite(); err != nil {
+		log.Warn("pending block write failed, regenerating block from message", "err", err)
+	}
 	currentHeader, err := s.getCurrentHeader()
 	if err != nil {
 		return nil, err
@@ -880,6 +1099,7 @@ func (s *ExecutionEngine) digestMessageWithBlockMutex(num arbutil.MessageIndex,
 		return nil, err
 	}
 	s.cacheL1PriceDataOfMsg(num, receipts, block, false)
+	flightrecorder.Record(flightrecorder.KindMessageDigested, "message", num, "block", block.NumberU64(), "hash", block.Hash())
 
 	if time.Now().After(s.nextScheduledVersionCheck) {
 		s.nextScheduledVersionCheck = time.Now().Add(time.Minute)
@@ -0,0 +1,301 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
+)
+
+var (
+	expressLaneAcceptedCounter = metrics.NewRegisteredCounter("arb/sequencer/expresslane/accepted", nil)
+	expressLaneRejectedCounter = metrics.NewRegisteredCounter("arb/sequencer/expresslane/rejected", nil)
+	expressLaneBufferedGauge   = metrics.NewRegisteredGauge("arb/sequencer/expresslane/buffered", nil)
+)
+
+// expressLaneSigningDomain separates express lane signatures from any other message the controller signs.
+var expressLaneSigningDomain = []byte("TIMEBOOST_EXPRESS_LANE_TRANSACTION")
+
+// expressLaneDelegationDomain separates delegations from submissions, so neither can pass for the other.
+var expressLaneDelegationDomain = []byte("TIMEBOOST_EXPRESS_LANE_DELEGATION")
+
+type ExpressLaneConfig struct {
+	Enable      bool `koanf:"enable"`
+	QueueSize   int  `koanf:"queue-size"`
+	MaxBuffered int  `koanf:"max-buffered" reload:"hot"`
+}
+
+var DefaultExpressLaneConfig = ExpressLaneConfig{
+	Enable:      false,
+	QueueSize:   1024,
+	MaxBuffered: 64,
+}
+
+func ExpressLaneConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultExpressLaneConfig.Enable, "sequence transactions submitted by the current round's express lane controller ahead of the normal queue (served by the timeboost_sendExpressLaneTransaction and timeboost_delegateExpressLane RPC methods)")
+	f.Int(prefix+".queue-size", DefaultExpressLaneConfig.QueueSize, "size of the pending express lane transaction queue")
+	f.Int(prefix+".max-buffered", DefaultExpressLaneConfig.MaxBuffered, "maximum number of express lane submissions to hold while waiting for an earlier sequence number")
+}
+
+func (c *ExpressLaneConfig) Validate() error {
+	if c.QueueSize < 1 {
+		return errors.New("express lane queue-size must be positive")
+	}
+	if c.MaxBuffered < 0 {
+		return errors.New("express lane max-buffered cannot be negative")
+	}
+	return nil
+}
+
+// ExpressLaneSubmission is a transaction the express lane controller submits in its round.
+// Each round's submissions are numbered consecutively from 0, and sequenced in that order.
+type ExpressLaneSubmission struct {
+	ChainId        *hexutil.Big   `json:"chainId"`
+	Round          hexutil.Uint64 `json:"round"`
+	SequenceNumber hexutil.Uint64 `json:"sequenceNumber"`
+	Transaction    hexutil.Bytes  `json:"transaction"`
+	// The controller's signature over the submission's signing hash
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (s *ExpressLaneSubmission) signingHash() common.Hash {
+	var chainId common.Hash
+	if s.ChainId != nil {
+		chainId = common.BigToHash(s.ChainId.ToInt())
+	}
+	return crypto.Keccak256Hash(
+		expressLaneSigningDomain,
+		chainId.Bytes(),
+		arbmath.UintToBytes(uint64(s.Round)),
+		arbmath.UintToBytes(uint64(s.SequenceNumber)),
+		s.Transaction,
+	)
+}
+
+// signer recovers the account that signed the submission.
+func (s *ExpressLaneSubmission) signer() (common.Address, error) {
+	return recoverSubmissionSigner("express lane submission", s.signingHash(), s.Signature)
+}
+
+// ExpressLaneDelegation hands the right to submit in a round's express lane from its controller to another key
+// for the rest of the round. A later delegation in the round replaces an earlier one, so delegations are
+// numbered, and delegating to the zero address gives the right back to the controller.
+type ExpressLaneDelegation struct {
+	ChainId  *hexutil.Big   `json:"chainId"`
+	Round    hexutil.Uint64 `json:"round"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	Delegate common.Address `json:"delegate"`
+	// The controller's signature over the delegation's signing hash
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (d *ExpressLaneDelegation) signingHash() common.Hash {
+	var chainId common.Hash
+	if d.ChainId != nil {
+		chainId = common.BigToHash(d.ChainId.ToInt())
+	}
+	return crypto.Keccak256Hash(
+		expressLaneDelegationDomain,
+		chainId.Bytes(),
+		arbmath.UintToBytes(uint64(d.Round)),
+		arbmath.UintToBytes(uint64(d.Nonce)),
+		d.Delegate.Bytes(),
+	)
+}
+
+// signer recovers the account that signed the delegation.
+func (d *ExpressLaneDelegation) signer() (common.Address, error) {
+	return recoverSubmissionSigner("express lane delegation", d.signingHash(), d.Signature)
+}
+
+// recoverSubmissionSigner recovers who signed a submission's hash, accepting recovery ids of 0/1 or 27/28.
+func recoverSubmissionSigner(what string, hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%v has a malformed signature", what)
+	}
+	sig := bytes.Clone(signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// expressLaneQueue puts the controller's submissions in the express lane in sequence number order,
+// holding ones that arrive early until the gap before them is filled.
+// It also tracks who the round's controller delegated submitting to, as the sequencer only knows this itself.
+type expressLaneQueue struct {
+	config    func() *ExpressLaneConfig
+	queue     chan txQueueItem
+	mutex     sync.Mutex
+	round     uint64
+	nextSeq   uint64
+	buffered  map[uint64]txQueueItem
+	delegatee common.Address // who submits for the round's controller, if it delegated
+	nextNonce uint64         // the lowest delegation nonce not yet used in the round
+}
+
+func newExpressLaneQueue(config func() *ExpressLaneConfig) *expressLaneQueue {
+	return &expressLaneQueue{
+		config:   config,
+		queue:    make(chan txQueueItem, config().QueueSize),
+		buffered: make(map[uint64]txQueueItem),
+	}
+}
+
+// items is the channel of submissions ready to sequence, which is nil (never ready) when disabled.
+func (q *expressLaneQueue) items() <-chan txQueueItem {
+	if q == nil {
+		return nil
+	}
+	return q.queue
+}
+
+// delegate records a delegation of the round's express lane, which must be numbered after the round's last.
+func (q *expressLaneQueue) delegate(round uint64, nonce uint64, delegatee common.Address) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if round < q.round {
+		return fmt.Errorf("express lane round %v is over", round)
+	}
+	if round > q.round {
+		q.startRound(round)
+	}
+	if nonce < q.nextNonce {
+		return fmt.Errorf("express lane delegation nonce %v was already used in round %v", nonce, round)
+	}
+	q.delegatee = delegatee
+	q.nextNonce = nonce + 1
+	return nil
+}
+
+// submitter returns who may submit in the round's express lane, given the round's controller.
+func (q *expressLaneQueue) submitter(round uint64, controller common.Address) common.Address {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if round != q.round || q.delegatee == (common.Address{}) {
+		return controller
+	}
+	return q.delegatee
+}
+
+func (q *expressLaneQueue) submit(round uint64, seq uint64, item txQueueItem) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if round < q.round {
+		return fmt.Errorf("express lane round %v is over", round)
+	}
+	if round > q.round {
+		q.startRound(round)
+	}
+	if seq < q.nextSeq {
+		return fmt.Errorf("express lane sequence number %v was already used in round %v", seq, round)
+	}
+	if seq > q.nextSeq {
+		if _, ok := q.buffered[seq]; ok {
+			return fmt.Errorf("express lane sequence number %v was already used in round %v", seq, round)
+		}
+		if len(q.buffered) >= q.config().MaxBuffered {
+			return fmt.Errorf("too many express lane submissions waiting for sequence number %v", q.nextSeq)
+		}
+		q.buffered[seq] = item
+		expressLaneBufferedGauge.Update(int64(len(q.buffered)))
+		return nil
+	}
+	if err := q.push(item); err != nil {
+		return err
+	}
+	q.nextSeq++
+	for {
+		next, ok := q.buffered[q.nextSeq]
+		if !ok {
+			break
+		}
+		delete(q.buffered, q.nextSeq)
+		if err := q.push(next); err != nil {
+			next.returnResult(err)
+		}
+		q.nextSeq++
+	}
+	expressLaneBufferedGauge.Update(int64(len(q.buffered)))
+	return nil
+}
+
+// startRound fails the submissions still waiting from the previous round, and forgets its delegation.
+// Must hold the mutex.
+func (q *expressLaneQueue) startRound(round uint64) {
+	for _, item := range q.buffered {
+		item.returnResult(fmt.Errorf("express lane round %v ended before the submission's turn", q.round))
+	}
+	q.buffered = make(map[uint64]txQueueItem)
+	q.round = round
+	q.nextSeq = 0
+	q.delegatee = common.Address{}
+	q.nextNonce = 0
+}
+
+func (q *expressLaneQueue) push(item txQueueItem) error {
+	select {
+	case q.queue <- item:
+		return nil
+	default:
+		return rpcerrors.New(rpcerrors.CodeSequencerQueueFull, "express lane queue is full")
+	}
+}
+
+// expressLaneFirst moves the express lane's transactions ahead of the rest, keeping the order within each.
+func expressLaneFirst(items []txQueueItem) []txQueueItem {
+	ordered := make([]txQueueItem, 0, len(items))
+	for _, item := range items {
+		if item.expressLane {
+			ordered = append(ordered, item)
+		}
+	}
+	for _, item := range items {
+		if !item.expressLane {
+			ordered = append(ordered, item)
+		}
+	}
+	return ordered
+}
+
+// ExpressLaneAPI accepts transactions from the current round's express lane controller.
+type ExpressLaneAPI struct {
+	sequencer *Sequencer
+}
+
+func NewExpressLaneAPI(sequencer *Sequencer) *ExpressLaneAPI {
+	return &ExpressLaneAPI{sequencer}
+}
+
+func (a *ExpressLaneAPI) SendExpressLaneTransaction(ctx context.Context, submission *ExpressLaneSubmission) error {
+	if submission == nil {
+		return errors.New("missing express lane submission")
+	}
+	return a.sequencer.PublishExpressLaneTransaction(ctx, submission)
+}
+
+// DelegateExpressLane lets the current round's express lane controller delegate submitting to another key.
+func (a *ExpressLaneAPI) DelegateExpressLane(ctx context.Context, delegation *ExpressLaneDelegation) error {
+	if delegation == nil {
+		return errors.New("missing express lane delegation")
+	}
+	return a.sequencer.DelegateExpressLane(delegation)
+}
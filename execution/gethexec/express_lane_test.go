@@ -0,0 +1,195 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newExpressLaneTestItem(nonce uint64, expressLane bool) (txQueueItem, chan error) {
+	resultChan := make(chan error, 1)
+	return txQueueItem{
+		tx:             types.NewTx(&types.DynamicFeeTx{Nonce: nonce}),
+		resultChan:     resultChan,
+		returnedResult: &atomic.Bool{},
+		ctx:            context.Background(),
+		expressLane:    expressLane,
+	}, resultChan
+}
+
+func TestExpressLaneQueueOrdersSubmissions(t *testing.T) {
+	config := DefaultExpressLaneConfig
+	config.MaxBuffered = 2
+	queue := newExpressLaneQueue(func() *ExpressLaneConfig { return &config })
+
+	// Submissions arriving early wait for the ones before them
+	for _, seq := range []uint64{2, 1} {
+		item, _ := newExpressLaneTestItem(seq, true)
+		if err := queue.submit(5, seq, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(queue.items()) != 0 {
+		t.Fatal("sequenced a submission before its predecessor")
+	}
+	item, _ := newExpressLaneTestItem(3, true)
+	if err := queue.submit(5, 3, item); err == nil {
+		t.Fatal("expected buffering past max-buffered to fail")
+	}
+	item, _ = newExpressLaneTestItem(0, true)
+	if err := queue.submit(5, 0, item); err != nil {
+		t.Fatal(err)
+	}
+	for seq := uint64(0); seq < 3; seq++ {
+		item := <-queue.items()
+		if item.tx.Nonce() != seq {
+			t.Fatal("sequenced submission", item.tx.Nonce(), "in position", seq)
+		}
+	}
+	if err := queue.submit(5, 1, item); err == nil {
+		t.Fatal("expected a reused sequence number to fail")
+	}
+
+	// A new round starts numbering again, and fails the last round's waiting submissions
+	waiting, result := newExpressLaneTestItem(5, true)
+	if err := queue.submit(5, 5, waiting); err != nil {
+		t.Fatal(err)
+	}
+	item, _ = newExpressLaneTestItem(0, true)
+	if err := queue.submit(6, 0, item); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err == nil {
+		t.Fatal("expected a submission left waiting at the end of its round to fail")
+	}
+	if err := queue.submit(5, 3, item); err == nil {
+		t.Fatal("expected a submission for a past round to fail")
+	}
+}
+
+func TestExpressLaneFirst(t *testing.T) {
+	var items []txQueueItem
+	for i := uint64(0); i < 6; i++ {
+		item, _ := newExpressLaneTestItem(i, i%3 == 2)
+		items = append(items, item)
+	}
+	ordered := expressLaneFirst(items)
+	expected := []uint64{2, 5, 0, 1, 3, 4}
+	for i, item := range ordered {
+		if item.tx.Nonce() != expected[i] {
+			t.Fatal("expected nonce", expected[i], "in position", i, "but got", item.tx.Nonce())
+		}
+	}
+}
+
+func TestExpressLaneSubmissionSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	submission := &ExpressLaneSubmission{
+		ChainId:        (*hexutil.Big)(big.NewInt(412346)),
+		Round:          7,
+		SequenceNumber: 3,
+		Transaction:    []byte{1, 2, 3},
+	}
+	submission.Signature, err = crypto.Sign(submission.signingHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signatures in the 27/28 recovery id convention are accepted too
+	submission.Signature[crypto.RecoveryIDOffset] += 27
+	signer, err := submission.signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("recovered the wrong signer", signer)
+	}
+
+	submission.SequenceNumber = 4
+	if signer, err := submission.signer(); err == nil && signer == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("signature still valid for a different sequence number")
+	}
+}
+
+func TestExpressLaneQueueDelegation(t *testing.T) {
+	queue := newExpressLaneQueue(func() *ExpressLaneConfig { return &DefaultExpressLaneConfig })
+	controller := common.HexToAddress("0x1")
+	delegatee := common.HexToAddress("0x2")
+
+	if queue.submitter(5, controller) != controller {
+		t.Fatal("the controller should submit until it delegates")
+	}
+	if err := queue.delegate(5, 3, delegatee); err != nil {
+		t.Fatal(err)
+	}
+	if queue.submitter(5, controller) != delegatee {
+		t.Fatal("the delegatee should submit once delegated to")
+	}
+	if err := queue.delegate(5, 3, controller); err == nil {
+		t.Fatal("expected a reused delegation nonce to fail")
+	}
+	if err := queue.delegate(5, 4, common.Address{}); err != nil {
+		t.Fatal(err)
+	}
+	if queue.submitter(5, controller) != controller {
+		t.Fatal("delegating to the zero address should give the lane back to the controller")
+	}
+
+	// Delegations only last until the end of their round
+	if err := queue.delegate(5, 5, delegatee); err != nil {
+		t.Fatal(err)
+	}
+	item, _ := newExpressLaneTestItem(0, true)
+	if err := queue.submit(6, 0, item); err != nil {
+		t.Fatal(err)
+	}
+	if queue.submitter(6, controller) != controller {
+		t.Fatal("a delegation outlived its round")
+	}
+	if err := queue.delegate(5, 6, delegatee); err == nil {
+		t.Fatal("expected a delegation for a past round to fail")
+	}
+	if err := queue.delegate(6, 0, delegatee); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpressLaneDelegationSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegation := &ExpressLaneDelegation{
+		ChainId:  (*hexutil.Big)(big.NewInt(412346)),
+		Round:    7,
+		Nonce:    1,
+		Delegate: common.HexToAddress("0x2"),
+	}
+	delegation.Signature, err = crypto.Sign(delegation.signingHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := delegation.signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("recovered the wrong signer", signer)
+	}
+
+	delegation.Delegate = common.HexToAddress("0x3")
+	if signer, err := delegation.signer(); err == nil && signer == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("signature still valid for a different delegate")
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var extendedBloomPrefix = []byte("arbitrum-extended-bloom-")
+
+func extendedBloomKey(block uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, extendedBloomPrefix...), block)
+}
+
+// The header bloom's size is fixed at 2048 bits
+const (
+	minExtendedBloomBits = 2048
+	maxExtendedBloomBits = 1 << 20
+	// Each hash function takes 4 bytes of the item's keccak hash
+	maxExtendedBloomHashes = 8
+)
+
+type ExtendedBloomConfig struct {
+	Enable bool   `koanf:"enable"`
+	Bits   uint64 `koanf:"bits"`
+	Hashes uint64 `koanf:"hashes"`
+}
+
+var DefaultExtendedBloomConfig = ExtendedBloomConfig{
+	Enable: false,
+	Bits:   1 << 16,
+	Hashes: 4,
+}
+
+func ExtendedBloomConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultExtendedBloomConfig.Enable, "keep a larger bloom filter of each block's log addresses and topics as blocks are written, which arb_getLogsPage checks before reading a block's receipts")
+	f.Uint64(prefix+".bits", DefaultExtendedBloomConfig.Bits, fmt.Sprintf("size of each block's bloom filter in bits, a power of two from %v to %v. Blocks already indexed keep the size they were indexed with", minExtendedBloomBits, maxExtendedBloomBits))
+	f.Uint64(prefix+".hashes", DefaultExtendedBloomConfig.Hashes, fmt.Sprintf("bits set in the bloom filter per address or topic, at most %v", maxExtendedBloomHashes))
+}
+
+func (c *ExtendedBloomConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Bits < minExtendedBloomBits || c.Bits > maxExtendedBloomBits || bits.OnesCount64(c.Bits) != 1 {
+		return fmt.Errorf("extended bloom bits must be a power of two from %v to %v", minExtendedBloomBits, maxExtendedBloomBits)
+	}
+	if c.Hashes < 1 || c.Hashes > maxExtendedBloomHashes {
+		return fmt.Errorf("extended bloom hashes must be from 1 to %v", maxExtendedBloomHashes)
+	}
+	return nil
+}
+
+// extendedBloom is a bloom filter of configurable size. It's empty if the block had no logs.
+type extendedBloom struct {
+	hashes uint64
+	bits   []byte
+}
+
+func newExtendedBloom(size uint64, hashes uint64) *extendedBloom {
+	return &extendedBloom{hashes: hashes, bits: make([]byte, size/8)}
+}
+
+func (b *extendedBloom) positions(item []byte) []uint64 {
+	hash := crypto.Keccak256(item)
+	size := uint64(len(b.bits)) * 8
+	positions := make([]uint64, b.hashes)
+	for i := range positions {
+		positions[i] = uint64(binary.BigEndian.Uint32(hash[4*i:])) & (size - 1)
+	}
+	return positions
+}
+
+func (b *extendedBloom) add(item []byte) {
+	for _, position := range b.positions(item) {
+		b.bits[position/8] |= 1 << (position % 8)
+	}
+}
+
+func (b *extendedBloom) test(item []byte) bool {
+	if len(b.bits) == 0 {
+		return false
+	}
+	for _, position := range b.positions(item) {
+		if b.bits[position/8]&(1<<(position%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mayMatch applies eth_getLogs' filter semantics: any of the addresses, and for each topic position any of its topics.
+func (b *extendedBloom) mayMatch(addresses []common.Address, topics [][]common.Hash) bool {
+	if len(b.bits) == 0 {
+		return false
+	}
+	if len(addresses) > 0 {
+		found := false
+		for _, address := range addresses {
+			if b.test(address.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, position := range topics {
+		if len(position) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range position {
+			if b.test(topic.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ExtendedBloomIndex stores a bloom filter of each block's log addresses and topics, larger than the header's
+// so it stays selective on chains with many logs per block. Entries are keyed by block number and record the
+// block's hash, so those of reorged blocks are ignored until overwritten.
+type ExtendedBloomIndex struct {
+	db     ethdb.KeyValueStore
+	config *ExtendedBloomConfig
+}
+
+func NewExtendedBloomIndex(db ethdb.KeyValueStore, config *ExtendedBloomConfig) *ExtendedBloomIndex {
+	return &ExtendedBloomIndex{db, config}
+}
+
+// Entries are the block hash, the number of hashes, then the bloom's bits, which are omitted if the block had no logs
+func (i *ExtendedBloomIndex) indexBlock(block *types.Block, receipts types.Receipts) error {
+	bloom := newExtendedBloom(i.config.Bits, i.config.Hashes)
+	hasLogs := false
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			hasLogs = true
+			bloom.add(log.Address.Bytes())
+			for _, topic := range log.Topics {
+				bloom.add(topic.Bytes())
+			}
+		}
+	}
+	value := append(block.Hash().Bytes(), byte(bloom.hashes))
+	if hasLogs {
+		value = append(value, bloom.bits...)
+	}
+	return i.db.Put(extendedBloomKey(block.NumberU64()), value)
+}
+
+// mayMatch returns whether a block may have logs matching the filter. Blocks that aren't indexed, or whose
+// entry is for another block with the same number, may always match.
+func (i *ExtendedBloomIndex) mayMatch(number uint64, hash common.Hash, addresses []common.Address, topics [][]common.Hash) (bool, error) {
+	key := extendedBloomKey(number)
+	has, err := i.db.Has(key)
+	if err != nil || !has {
+		return true, err
+	}
+	value, err := i.db.Get(key)
+	if err != nil {
+		return true, err
+	}
+	if len(value) < common.HashLength+1 {
+		return true, errors.New("invalid extended bloom entry")
+	}
+	if common.BytesToHash(value[:common.HashLength]) != hash {
+		return true, nil
+	}
+	bloom := &extendedBloom{hashes: uint64(value[common.HashLength]), bits: value[common.HashLength+1:]}
+	if bloom.hashes == 0 || bloom.hashes > maxExtendedBloomHashes || bits.OnesCount(uint(len(bloom.bits))) > 1 {
+		return true, errors.New("invalid extended bloom entry")
+	}
+	return bloom.mayMatch(addresses, topics), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestExtendedBloomIndex(t *testing.T) {
+	config := DefaultExtendedBloomConfig
+	config.Enable = true
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	index := NewExtendedBloomIndex(rawdb.NewMemoryDatabase(), &config)
+	token := common.HexToAddress("0x1234")
+	transfer := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// Enough logs to saturate a header bloom
+	receipt := &types.Receipt{}
+	for i := 0; i < 2000; i++ {
+		receipt.Logs = append(receipt.Logs, &types.Log{Address: token, Topics: []common.Hash{transfer, crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes())}})
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, nil, nil, nil, trie.NewStackTrie(nil))
+	if err := index.indexBlock(block, types.Receipts{receipt}); err != nil {
+		t.Fatal(err)
+	}
+	emptyBlock := types.NewBlock(&types.Header{Number: big.NewInt(2)}, nil, nil, nil, trie.NewStackTrie(nil))
+	if err := index.indexBlock(emptyBlock, types.Receipts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(number uint64, hash common.Hash, addresses []common.Address, topics [][]common.Hash, expected bool) {
+		t.Helper()
+		mayMatch, err := index.mayMatch(number, hash, addresses, topics)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mayMatch != expected {
+			t.Fatal("unexpected match", number, addresses, topics, mayMatch)
+		}
+	}
+	expect(1, block.Hash(), []common.Address{token}, [][]common.Hash{{transfer}}, true)
+	expect(1, block.Hash(), nil, [][]common.Hash{nil, {crypto.Keccak256Hash(big.NewInt(1999).Bytes())}}, true)
+	falsePositives := 0
+	for i := 0; i < 100; i++ {
+		absent := crypto.Keccak256Hash([]byte("absent"), big.NewInt(int64(i)).Bytes())
+		if mayMatch, err := index.mayMatch(1, block.Hash(), nil, [][]common.Hash{{absent}}); err != nil {
+			t.Fatal(err)
+		} else if mayMatch {
+			falsePositives++
+		}
+	}
+	if falsePositives > 5 {
+		t.Fatal("too many false positives", falsePositives)
+	}
+	expect(1, block.Hash(), []common.Address{common.HexToAddress("0x5678")}, nil, false)
+	// Blocks without logs match nothing
+	expect(2, emptyBlock.Hash(), nil, nil, false)
+	// Reorged and unindexed blocks must be read
+	expect(1, common.HexToHash("0x01"), []common.Address{common.HexToAddress("0x5678")}, nil, true)
+	expect(3, common.HexToHash("0x02"), []common.Address{common.HexToAddress("0x5678")}, nil, true)
+
+	config.Bits = 3000
+	if err := config.Validate(); err == nil {
+		t.Fatal("accepted a bloom size that isn't a power of two")
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var fairOrderingReorderedCounter = metrics.NewRegisteredCounter("arb/sequencer/fairordering/reordered", nil)
+
+type FairOrderingConfig struct {
+	Enable         bool          `koanf:"enable"`
+	BucketDuration time.Duration `koanf:"bucket-duration" reload:"hot"`
+	RevealedSeeds  int           `koanf:"revealed-seeds"`
+}
+
+var DefaultFairOrderingConfig = FairOrderingConfig{
+	Enable:         false,
+	BucketDuration: 50 * time.Millisecond,
+	RevealedSeeds:  10000,
+}
+
+func FairOrderingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultFairOrderingConfig.Enable, "order transactions that arrive within the same time bucket randomly, using a seed committed to before the bucket")
+	f.Duration(prefix+".bucket-duration", DefaultFairOrderingConfig.BucketDuration, "width of the arrival time buckets; transactions in earlier buckets are always sequenced first")
+	f.Int(prefix+".revealed-seeds", DefaultFairOrderingConfig.RevealedSeeds, "number of recent blocks to keep revealed ordering seeds for")
+}
+
+func (c *FairOrderingConfig) Validate() error {
+	if c.BucketDuration <= 0 {
+		return errors.New("fair ordering bucket-duration must be positive")
+	}
+	if c.RevealedSeeds < 1 {
+		return errors.New("fair ordering revealed-seeds must be positive")
+	}
+	return nil
+}
+
+// fairOrderingSeeds commits to the seed ordering the next block before any of its transactions arrive,
+// and reveals it once the block is sequenced, so anyone can check the block's order wasn't chosen.
+type fairOrderingSeeds struct {
+	mutex    sync.Mutex
+	next     common.Hash
+	revealed *containers.LruCache[uint64, common.Hash]
+}
+
+func newFairOrderingSeeds(revealed int) (*fairOrderingSeeds, error) {
+	s := &fairOrderingSeeds{revealed: containers.NewLruCache[uint64, common.Hash](revealed)}
+	if _, err := rand.Read(s.next[:]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// current is the committed seed for the next block.
+func (s *fairOrderingSeeds) current() common.Hash {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.next
+}
+
+// commitment is the hash of the committed seed for the next block.
+func (s *fairOrderingSeeds) commitment() common.Hash {
+	return crypto.Keccak256Hash(s.current().Bytes())
+}
+
+// reveal records the seed that ordered a block, and commits to a fresh seed for the next one.
+func (s *fairOrderingSeeds) reveal(blockNumber uint64, seed common.Hash) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if seed != s.next {
+		return errors.New("revealed a seed that wasn't committed to")
+	}
+	s.revealed.Add(blockNumber, seed)
+	_, err := rand.Read(s.next[:])
+	return err
+}
+
+func (s *fairOrderingSeeds) seed(blockNumber uint64) (common.Hash, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.revealed.Peek(blockNumber)
+}
+
+// fairOrder sorts transactions by arrival time bucket, and within a bucket by the hash of the seed and
+// their hash. Each sender's transactions keep their nonce order, in the positions its transactions sorted to.
+func fairOrder(items []txQueueItem, seed common.Hash, bucketDuration time.Duration, signer types.Signer) []txQueueItem {
+	type sortable struct {
+		item   txQueueItem
+		bucket int64
+		key    []byte
+	}
+	sorted := make([]sortable, len(items))
+	for i, item := range items {
+		sorted[i] = sortable{
+			item:   item,
+			bucket: item.firstAppearance.UnixNano() / int64(bucketDuration),
+			key:    crypto.Keccak256(seed.Bytes(), item.tx.Hash().Bytes()),
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].bucket != sorted[j].bucket {
+			return sorted[i].bucket < sorted[j].bucket
+		}
+		return bytes.Compare(sorted[i].key, sorted[j].key) < 0
+	})
+
+	ordered := make([]txQueueItem, len(sorted))
+	for i, entry := range sorted {
+		ordered[i] = entry.item
+	}
+	ordered = keepNonceOrder(ordered, signer)
+
+	reordered := 0
+	for i := range ordered {
+		if ordered[i].tx != items[i].tx {
+			reordered++
+		}
+	}
+	fairOrderingReorderedCounter.Inc(int64(reordered))
+	return ordered
+}
+
+// keepNonceOrder puts each sender's transactions back in nonce order, in the positions its transactions
+// were ordered to, so reordering never makes a sender's later nonce come first.
+func keepNonceOrder(ordered []txQueueItem, signer types.Signer) []txQueueItem {
+	positions := make(map[common.Address][]int)
+	var senders []common.Address
+	for i, item := range ordered {
+		sender, err := types.Sender(signer, item.tx)
+		if err != nil {
+			// Invalid transactions fail regardless of where they are
+			continue
+		}
+		if _, ok := positions[sender]; !ok {
+			senders = append(senders, sender)
+		}
+		positions[sender] = append(positions[sender], i)
+	}
+	for _, sender := range senders {
+		indices := positions[sender]
+		if len(indices) < 2 {
+			continue
+		}
+		senderItems := make([]txQueueItem, len(indices))
+		for i, index := range indices {
+			senderItems[i] = ordered[index]
+		}
+		sort.SliceStable(senderItems, func(i, j int) bool {
+			return senderItems[i].tx.Nonce() < senderItems[j].tx.Nonce()
+		})
+		for i, index := range indices {
+			ordered[index] = senderItems[i]
+		}
+	}
+	return ordered
+}
+
+type FairOrderingSeedCommitment struct {
+	// The hash of the seed that will order the next block
+	NextCommitment common.Hash `json:"nextCommitment"`
+}
+
+// FairOrderingAPI publishes the sequencer's commitments to its ordering seeds, and reveals them afterwards.
+type FairOrderingAPI struct {
+	seeds *fairOrderingSeeds
+}
+
+func NewFairOrderingAPI(seeds *fairOrderingSeeds) *FairOrderingAPI {
+	return &FairOrderingAPI{seeds}
+}
+
+func (a *FairOrderingAPI) FairOrderingCommitment(_ context.Context) (*FairOrderingSeedCommitment, error) {
+	return &FairOrderingSeedCommitment{NextCommitment: a.seeds.commitment()}, nil
+}
+
+// FairOrderingSeed returns the seed that ordered a block's transactions.
+func (a *FairOrderingAPI) FairOrderingSeed(_ context.Context, blockNumber hexutil.Uint64) (common.Hash, error) {
+	seed, ok := a.seeds.seed(uint64(blockNumber))
+	if !ok {
+		return common.Hash{}, fmt.Errorf("no revealed ordering seed for block %v", uint64(blockNumber))
+	}
+	return seed, nil
+}
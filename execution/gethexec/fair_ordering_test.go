@@ -0,0 +1,108 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestFairOrder(t *testing.T) {
+	signer := types.LatestSignerForChainID(big.NewInt(412346))
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	newItem := func(key *ecdsa.PrivateKey, nonce uint64, arrival time.Time) txQueueItem {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			Nonce:     nonce,
+			Gas:       21000,
+			GasFeeCap: big.NewInt(1e9),
+			To:        &common.Address{},
+		})
+		return txQueueItem{tx: tx, firstAppearance: arrival}
+	}
+
+	bucket := 50 * time.Millisecond
+	start := time.Unix(1700000000, 0)
+	repeated := newKey()
+	var items []txQueueItem
+	// The first bucket has one sender's consecutive nonces among other senders' transactions
+	for i := 0; i < 8; i++ {
+		items = append(items, newItem(newKey(), 0, start.Add(time.Duration(i)*time.Millisecond)))
+		items = append(items, newItem(repeated, uint64(i), start.Add(time.Duration(i)*time.Millisecond)))
+	}
+	late := newItem(newKey(), 0, start.Add(bucket))
+	items = append(items, late)
+
+	seed := common.HexToHash("0x1234")
+	ordered := fairOrder(items, seed, bucket, signer)
+	if len(ordered) != len(items) {
+		t.Fatal("lost transactions", len(ordered))
+	}
+	if ordered[len(ordered)-1].tx != late.tx {
+		t.Fatal("a transaction from a later bucket was sequenced early")
+	}
+	var nextNonce uint64
+	for _, item := range ordered {
+		sender, err := types.Sender(signer, item.tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sender != crypto.PubkeyToAddress(repeated.PublicKey) {
+			continue
+		}
+		if item.tx.Nonce() != nextNonce {
+			t.Fatal("sender's transactions out of nonce order", item.tx.Nonce(), nextNonce)
+		}
+		nextNonce++
+	}
+
+	// The order is determined by the seed alone
+	again := fairOrder(items, seed, bucket, signer)
+	differs := false
+	other := fairOrder(items, common.HexToHash("0x5678"), bucket, signer)
+	for i := range ordered {
+		if again[i].tx != ordered[i].tx {
+			t.Fatal("ordering isn't deterministic")
+		}
+		if other[i].tx != ordered[i].tx {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatal("different seeds produced the same order")
+	}
+}
+
+func TestFairOrderingSeeds(t *testing.T) {
+	seeds, err := newFairOrderingSeeds(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := seeds.current()
+	commitment := seeds.commitment()
+	if err := seeds.reveal(5, common.Hash{}); err == nil {
+		t.Fatal("revealed a seed that wasn't committed to")
+	}
+	if err := seeds.reveal(5, seed); err != nil {
+		t.Fatal(err)
+	}
+	revealed, ok := seeds.seed(5)
+	if !ok || revealed != seed || crypto.Keccak256Hash(revealed.Bytes()) != commitment {
+		t.Fatal("revealed seed doesn't match the commitment")
+	}
+	if seeds.current() == seed {
+		t.Fatal("seed reused after being revealed")
+	}
+}
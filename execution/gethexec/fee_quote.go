@@ -0,0 +1,354 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	usdPriceGauge          = metrics.NewRegisteredGaugeFloat64("arb/feequote/usdprice", nil)
+	usdPriceFailureCounter = metrics.NewRegisteredCounter("arb/feequote/source/failure", nil)
+)
+
+type FeeQuoteConfig struct {
+	Enable bool `koanf:"enable"`
+	// Each source is a URL returning JSON, with the path of the price in the response as its fragment
+	Sources         []string      `koanf:"sources"`
+	MinSources      int           `koanf:"min-sources" reload:"hot"`
+	RefreshInterval time.Duration `koanf:"refresh-interval" reload:"hot"`
+	MaxAge          time.Duration `koanf:"max-age" reload:"hot"`
+	Timeout         time.Duration `koanf:"timeout" reload:"hot"`
+}
+
+var DefaultFeeQuoteConfig = FeeQuoteConfig{
+	Enable:          false,
+	Sources:         []string{},
+	MinSources:      1,
+	RefreshInterval: time.Minute,
+	MaxAge:          10 * time.Minute,
+	Timeout:         10 * time.Second,
+}
+
+func FeeQuoteConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultFeeQuoteConfig.Enable, "track the USD price of the chain's native token, so the arb_quoteFeesUSD RPC method can quote transaction fees in USD")
+	f.StringSlice(prefix+".sources", DefaultFeeQuoteConfig.Sources, "price sources, each a URL returning JSON with the dot separated path of the USD price in it as the URL's fragment, e.g. https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd#ethereum.usd")
+	f.Int(prefix+".min-sources", DefaultFeeQuoteConfig.MinSources, "how many sources must return a price for it to be updated, to the median of their prices")
+	f.Duration(prefix+".refresh-interval", DefaultFeeQuoteConfig.RefreshInterval, "how often to fetch the price from the sources")
+	f.Duration(prefix+".max-age", DefaultFeeQuoteConfig.MaxAge, "oldest a price may be for fees to be quoted with it")
+	f.Duration(prefix+".timeout", DefaultFeeQuoteConfig.Timeout, "timeout for each request to a price source")
+}
+
+func (c *FeeQuoteConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if len(c.Sources) == 0 {
+		return errors.New("fee quote needs at least one price source")
+	}
+	for _, source := range c.Sources {
+		if _, _, err := parsePriceSource(source); err != nil {
+			return err
+		}
+	}
+	if c.MinSources < 1 || c.MinSources > len(c.Sources) {
+		return fmt.Errorf("fee quote min-sources must be from 1 to the number of sources, %v", len(c.Sources))
+	}
+	if c.RefreshInterval <= 0 || c.MaxAge <= 0 || c.Timeout <= 0 {
+		return errors.New("fee quote refresh-interval, max-age and timeout must be positive")
+	}
+	return nil
+}
+
+type FeeQuoteConfigFetcher func() *FeeQuoteConfig
+
+// parsePriceSource splits a source into the URL to request and the path of the price in its response.
+func parsePriceSource(source string) (string, []string, error) {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid price source %q: %w", source, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", nil, fmt.Errorf("price source %q isn't an http or https URL", source)
+	}
+	if parsed.Fragment == "" {
+		return "", nil, fmt.Errorf("price source %q has no path to the price, add it as the URL's fragment", source)
+	}
+	path := strings.Split(parsed.Fragment, ".")
+	parsed.Fragment = ""
+	return parsed.String(), path, nil
+}
+
+// priceAtPath returns the number at a path of object keys and array indexes. Prices given as strings are parsed,
+// as some sources return them that way to avoid losing precision.
+func priceAtPath(value interface{}, path []string) (float64, error) {
+	for _, key := range path {
+		switch container := value.(type) {
+		case map[string]interface{}:
+			value = container[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return 0, fmt.Errorf("no index %q in array of length %v", key, len(container))
+			}
+			value = container[index]
+		default:
+			return 0, fmt.Errorf("no key %q in %T", key, value)
+		}
+	}
+	var price float64
+	switch number := value.(type) {
+	case float64:
+		price = number
+	case string:
+		var err error
+		price, err = strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid price %q", number)
+		}
+	default:
+		return 0, fmt.Errorf("price is a %T, not a number", value)
+	}
+	if !(price > 0) || price > 1e12 {
+		return 0, fmt.Errorf("implausible price %v", price)
+	}
+	return price, nil
+}
+
+// USDPriceFeed keeps the median of the USD prices the configured sources give for the chain's native token.
+type USDPriceFeed struct {
+	stopwaiter.StopWaiter
+	config FeeQuoteConfigFetcher
+	client *http.Client
+
+	mutex   sync.RWMutex
+	price   float64
+	updated time.Time
+}
+
+func NewUSDPriceFeed(config FeeQuoteConfigFetcher) *USDPriceFeed {
+	return &USDPriceFeed{
+		config: config,
+		client: &http.Client{},
+	}
+}
+
+func (f *USDPriceFeed) Start(ctx context.Context) {
+	f.StopWaiter.Start(ctx, f)
+	f.CallIteratively(f.refresh)
+}
+
+func (f *USDPriceFeed) fetch(ctx context.Context, source string) (float64, error) {
+	target, path, err := parsePriceSource(source)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.config().Timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := f.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %v", response.Status)
+	}
+	var body interface{}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&body); err != nil {
+		return 0, err
+	}
+	return priceAtPath(body, path)
+}
+
+func (f *USDPriceFeed) refresh(ctx context.Context) time.Duration {
+	config := f.config()
+	var prices []float64
+	for _, source := range config.Sources {
+		price, err := f.fetch(ctx, source)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0
+			}
+			usdPriceFailureCounter.Inc(1)
+			log.Warn("failed to fetch USD price", "source", source, "err", err)
+			continue
+		}
+		prices = append(prices, price)
+	}
+	if len(prices) < config.MinSources {
+		log.Warn("not enough price sources responded, keeping the previous USD price", "responded", len(prices), "needed", config.MinSources)
+		return config.RefreshInterval
+	}
+	price := medianPrice(prices)
+	f.mutex.Lock()
+	f.price = price
+	f.updated = time.Now()
+	f.mutex.Unlock()
+	usdPriceGauge.Update(price)
+	return config.RefreshInterval
+}
+
+func medianPrice(prices []float64) float64 {
+	sorted := append([]float64{}, prices...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+var ErrNoUSDPrice = errors.New("no recent USD price")
+
+// Price returns the USD price of one whole native token and when it was fetched, failing if it's too old.
+func (f *USDPriceFeed) Price() (float64, time.Time, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if f.updated.IsZero() {
+		return 0, time.Time{}, ErrNoUSDPrice
+	}
+	if age := time.Since(f.updated); age > f.config().MaxAge {
+		return 0, time.Time{}, fmt.Errorf("%w: last updated %v ago", ErrNoUSDPrice, age.Truncate(time.Second))
+	}
+	return f.price, f.updated, nil
+}
+
+// weiToUSD converts an amount of wei at a price per whole token to USD.
+func weiToUSD(wei *big.Int, price float64) float64 {
+	usd, _ := new(big.Float).Quo(
+		new(big.Float).Mul(new(big.Float).SetInt(wei), big.NewFloat(price)),
+		new(big.Float).SetInt(big.NewInt(params.Ether)),
+	).Float64()
+	return usd
+}
+
+type FeeQuote struct {
+	Gas hexutil.Uint64 `json:"gas"`
+	// The part of the gas paying for the transaction's data to be posted to the parent chain
+	GasForL1          hexutil.Uint64 `json:"gasForL1"`
+	BaseFee           *hexutil.Big   `json:"baseFee"`
+	L1BaseFeeEstimate *hexutil.Big   `json:"l1BaseFeeEstimate"`
+	FeeWei            *hexutil.Big   `json:"feeWei"`
+	L1FeeWei          *hexutil.Big   `json:"l1FeeWei"`
+	L2FeeWei          *hexutil.Big   `json:"l2FeeWei"`
+	// What the transaction could cost at most, if it gave a fee cap
+	MaxFeeWei    *hexutil.Big   `json:"maxFeeWei,omitempty"`
+	USDPrice     float64        `json:"usdPrice"`
+	FeeUSD       float64        `json:"feeUsd"`
+	L1FeeUSD     float64        `json:"l1FeeUsd"`
+	L2FeeUSD     float64        `json:"l2FeeUsd"`
+	MaxFeeUSD    *float64       `json:"maxFeeUsd,omitempty"`
+	PriceUpdated hexutil.Uint64 `json:"priceUpdated"`
+}
+
+type FeeQuoteAPI struct {
+	stack *node.Node
+	feed  *USDPriceFeed
+}
+
+func NewFeeQuoteAPI(stack *node.Node, feed *USDPriceFeed) *FeeQuoteAPI {
+	return &FeeQuoteAPI{stack, feed}
+}
+
+// QuoteFeesUSD estimates a transaction's fees at the current base fee, split into its parent chain and
+// execution components, in wei and in USD.
+func (api *FeeQuoteAPI) QuoteFeesUSD(ctx context.Context, args EstimateGasArgs) (*FeeQuote, error) {
+	price, updated, err := api.feed.Price()
+	if err != nil {
+		return nil, err
+	}
+	nodeInterfaceABI, err := node_interfacegen.NodeInterfaceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	method := nodeInterfaceABI.Methods["gasEstimateComponents"]
+	var to common.Address
+	if args.To != nil {
+		to = *args.To
+	}
+	packed, err := method.Inputs.Pack(to, args.To == nil, args.data())
+	if err != nil {
+		return nil, err
+	}
+	call := args
+	call.To = &types.NodeInterfaceAddress
+	call.Data = nil
+	call.Input = (*hexutil.Bytes)(&packed)
+	client := api.stack.Attach()
+	defer client.Close()
+	var result hexutil.Bytes
+	if err := client.CallContext(ctx, &result, "eth_call", call, "latest"); err != nil {
+		return nil, err
+	}
+	outputs, err := method.Outputs.Unpack(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) != 4 {
+		return nil, fmt.Errorf("expected 4 outputs from gasEstimateComponents, got %v", len(outputs))
+	}
+	gas, _ := outputs[0].(uint64)
+	gasForL1, _ := outputs[1].(uint64)
+	baseFee, _ := outputs[2].(*big.Int)
+	l1BaseFeeEstimate, _ := outputs[3].(*big.Int)
+	if baseFee == nil || l1BaseFeeEstimate == nil || gasForL1 > gas {
+		return nil, errors.New("invalid gas estimate components")
+	}
+
+	fee := arbmath.BigMulByUint(baseFee, gas)
+	l1Fee := arbmath.BigMulByUint(baseFee, gasForL1)
+	l2Fee := arbmath.BigSub(fee, l1Fee)
+	quote := &FeeQuote{
+		Gas:               hexutil.Uint64(gas),
+		GasForL1:          hexutil.Uint64(gasForL1),
+		BaseFee:           (*hexutil.Big)(baseFee),
+		L1BaseFeeEstimate: (*hexutil.Big)(l1BaseFeeEstimate),
+		FeeWei:            (*hexutil.Big)(fee),
+		L1FeeWei:          (*hexutil.Big)(l1Fee),
+		L2FeeWei:          (*hexutil.Big)(l2Fee),
+		USDPrice:          price,
+		FeeUSD:            weiToUSD(fee, price),
+		L1FeeUSD:          weiToUSD(l1Fee, price),
+		L2FeeUSD:          weiToUSD(l2Fee, price),
+		PriceUpdated:      hexutil.Uint64(updated.Unix()), // #nosec G115
+	}
+	if feeCap := args.feeCap(); feeCap != nil {
+		maxFee := arbmath.BigMulByUint(feeCap, gas)
+		maxFeeUSD := weiToUSD(maxFee, price)
+		quote.MaxFeeWei = (*hexutil.Big)(maxFee)
+		quote.MaxFeeUSD = &maxFeeUSD
+	}
+	return quote, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUSDPriceFeed(t *testing.T) {
+	responses := map[string]string{
+		"/a": `{"ethereum":{"usd":3000}}`,
+		"/b": `{"data":{"amount":"3100.5"}}`,
+		"/c": `{"prices":[[1700000000,2900.25]]}`,
+		"/d": `{"ethereum":{"usd":-1}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	config := DefaultFeeQuoteConfig
+	config.Enable = true
+	config.Sources = []string{server.URL + "/a#ethereum.usd", server.URL + "/b#data.amount", server.URL + "/c#prices.0.1", server.URL + "/d#ethereum.usd", server.URL + "/missing#usd"}
+	config.MinSources = 3
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	feed := NewUSDPriceFeed(func() *FeeQuoteConfig { return &config })
+	if _, _, err := feed.Price(); !errors.Is(err, ErrNoUSDPrice) {
+		t.Fatal("returned a price before fetching one", err)
+	}
+	feed.refresh(context.Background())
+	price, _, err := feed.Price()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != 3000 {
+		t.Fatal("expected the median of the valid prices, got", price)
+	}
+
+	// Too few sources responding keeps the previous price until it's too old
+	config.MinSources = 4
+	responses["/a"] = `{"ethereum":{"usd":5000}}`
+	feed.refresh(context.Background())
+	if price, _, err := feed.Price(); err != nil || price != 3000 {
+		t.Fatal("price changed without enough sources", price, err)
+	}
+	config.MaxAge = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, _, err := feed.Price(); !errors.Is(err, ErrNoUSDPrice) {
+		t.Fatal("returned a stale price", err)
+	}
+
+	for _, source := range []string{"ftp://prices.example#usd", "https://prices.example/eth"} {
+		config := DefaultFeeQuoteConfig
+		config.Enable = true
+		config.Sources = []string{source}
+		if err := config.Validate(); err == nil {
+			t.Fatal("accepted invalid source", source)
+		}
+	}
+}
+
+func TestWeiToUSD(t *testing.T) {
+	// 21000 gas at 0.01 gwei is 2.1e14 wei
+	usd := weiToUSD(big.NewInt(210_000_000_000_000), 3000)
+	if math.Abs(usd-0.63) > 1e-12 {
+		t.Fatal("unexpected USD amount", usd)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/offchainlabs/nitro/util/redisutil"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 
@@ -85,6 +86,7 @@ type TxForwarder struct {
 	rpcClients            []*rpc.Client
 	ethClients            []*ethclient.Client
 	tryNewForwarderErrors *regexp.Regexp
+	regionalIngress       *RegionalIngress // nil unless enabled
 }
 
 func NewForwarder(targets []string, config *ForwarderConfig) *TxForwarder {
@@ -126,15 +128,23 @@ func (f *TxForwarder) ctxWithTimeout() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(f.ctx, f.timeout)
 }
 
+var errNoForwardingTarget = rpcerrors.New(rpcerrors.CodeSequencerUnavailable, "failed to publish transaction to any of the forwarding targets")
+
 func (f *TxForwarder) PublishTransaction(inctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
 	if !f.enabled.Load() {
 		return ErrNoSequencer
 	}
+	var submission *RegionalSubmission
+	if f.regionalIngress != nil && options == nil {
+		submission = f.regionalIngress.stamp(tx)
+	}
 	ctx, cancelFunc := f.ctxWithTimeout()
 	defer cancelFunc()
 	for pos, rpcClient := range f.rpcClients {
 		var err error
-		if options == nil {
+		if submission != nil {
+			err = rpcClient.CallContext(ctx, nil, "arb_sendRegionalTransaction", submission)
+		} else if options == nil {
 			err = f.ethClients[pos].SendTransaction(ctx, tx)
 		} else {
 			err = arbitrum.SendConditionalTransactionRPC(ctx, rpcClient, tx, options)
@@ -144,7 +154,13 @@ func (f *TxForwarder) PublishTransaction(inctx context.Context, tx *types.Transa
 		}
 		log.Warn("error forwarding transaction to a backup target", "target", f.targets[pos], "err", err)
 	}
-	return errors.New("failed to publish transaction to any of the forwarding targets")
+	return errNoForwardingTarget
+}
+
+// SetRegionalIngress stamps the transactions forwarded from now on with when they arrived, so the sequencer
+// orders them by arrival. Conditional transactions are forwarded as usual.
+func (f *TxForwarder) SetRegionalIngress(ingress *RegionalIngress) {
+	f.regionalIngress = ingress
 }
 
 const cacheUpstreamHealth = 2 * time.Second
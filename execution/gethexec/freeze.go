@@ -0,0 +1,131 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+var sequencerFrozenGauge = metrics.NewRegisteredGauge("arb/sequencer/frozen", nil)
+
+type sequencerFreeze struct {
+	reason string
+	since  time.Time
+}
+
+type SequencerFreezeStatus struct {
+	// Whether an operator has frozen block production on this node
+	Frozen   bool   `json:"frozen"`
+	Reason   string `json:"reason,omitempty"`
+	FrozenAt uint64 `json:"frozenAt,omitempty"`
+	// The number of messages in the chain; the next block produced will be for this message index
+	MessageCount uint64 `json:"messageCount"`
+	// When the chain owner froze the sequencer through ArbOwner, or 0 if it didn't
+	OwnerFrozenSince uint64 `json:"ownerFrozenSince"`
+}
+
+// Freeze stops the engine from producing blocks, from both the sequencer's queue and the delayed inbox,
+// until Resume is called. Any block being produced is finished and written first, so the chain's head
+// can't move while frozen. Freezing an already frozen engine keeps the original reason.
+func (s *ExecutionEngine) Freeze(reason string) (*SequencerFreezeStatus, error) {
+	s.createBlocksMutex.Lock()
+	defer s.createBlocksMutex.Unlock()
+	if s.freeze.Load() == nil {
+		if err := s.waitForBlockWrite(); err != nil {
+			return nil, err
+		}
+		s.freeze.Store(&sequencerFreeze{reason: reason, since: time.Now()})
+		sequencerFrozenGauge.Update(1)
+	}
+	status, err := s.freezeStatus()
+	if err != nil {
+		return nil, err
+	}
+	log.Warn("sequencer frozen", "reason", status.Reason, "messageCount", status.MessageCount)
+	return status, nil
+}
+
+// Resume lifts a freeze. Block production continues from the chain's head, so the next message
+// follows directly on the last one produced before the freeze.
+func (s *ExecutionEngine) Resume() (*SequencerFreezeStatus, error) {
+	s.createBlocksMutex.Lock()
+	defer s.createBlocksMutex.Unlock()
+	previous := s.freeze.Swap(nil)
+	sequencerFrozenGauge.Update(0)
+	status, err := s.freezeStatus()
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		log.Warn("sequencer resumed", "frozenFor", time.Since(previous.since), "messageCount", status.MessageCount)
+	}
+	return status, nil
+}
+
+func (s *ExecutionEngine) FreezeStatus() (*SequencerFreezeStatus, error) {
+	return s.freezeStatus()
+}
+
+func (s *ExecutionEngine) IsFrozen() bool {
+	return s.freeze.Load() != nil
+}
+
+func (s *ExecutionEngine) freezeStatus() (*SequencerFreezeStatus, error) {
+	header, err := s.getCurrentHeader()
+	if err != nil {
+		return nil, err
+	}
+	messageCount, err := s.BlockNumberToMessageIndex(header.Number.Uint64() + 1)
+	if err != nil {
+		return nil, err
+	}
+	status := &SequencerFreezeStatus{
+		MessageCount: uint64(messageCount),
+	}
+	if freeze := s.freeze.Load(); freeze != nil {
+		status.Frozen = true
+		status.Reason = freeze.reason
+		// #nosec G115
+		status.FrozenAt = uint64(freeze.since.Unix())
+	}
+	statedb, err := s.stateAtHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	status.OwnerFrozenSince, err = arbState.SequencerFrozenSince()
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SequencerFreezeAPI lets an operator freeze and resume block production during an incident.
+type SequencerFreezeAPI struct {
+	execEngine *ExecutionEngine
+}
+
+func (a *SequencerFreezeAPI) Freeze(reason string) (*SequencerFreezeStatus, error) {
+	if reason == "" {
+		return nil, errors.New("a reason for the freeze is required")
+	}
+	return a.execEngine.Freeze(reason)
+}
+
+func (a *SequencerFreezeAPI) Resume() (*SequencerFreezeStatus, error) {
+	return a.execEngine.Resume()
+}
+
+func (a *SequencerFreezeAPI) FreezeStatus() (*SequencerFreezeStatus, error) {
+	return a.execEngine.FreezeStatus()
+}
@@ -0,0 +1,233 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	historyBusyWorkersGauge  = metrics.NewRegisteredGauge("arb/history/workers/busy", nil)
+	historyBlocksReadCounter = metrics.NewRegisteredCounter("arb/history/blocks", nil)
+	historyRejectedCounter   = metrics.NewRegisteredCounter("arb/history/rejected", nil)
+	historyReadTimer         = metrics.NewRegisteredHistogram("arb/history/read", nil, metrics.NewBoundedHistogramSample())
+)
+
+type HistoryServerConfig struct {
+	Enable       bool          `koanf:"enable"`
+	Workers      int           `koanf:"workers"`
+	QueueTimeout time.Duration `koanf:"queue-timeout" reload:"hot"`
+	MaxBlocks    uint64        `koanf:"max-blocks" reload:"hot"`
+}
+
+var DefaultHistoryServerConfig = HistoryServerConfig{
+	Enable:       false,
+	Workers:      8,
+	QueueTimeout: 5 * time.Second,
+	MaxBlocks:    100,
+}
+
+func HistoryServerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultHistoryServerConfig.Enable, "serve frozen blocks and receipts straight from the freezer with the arb_getFrozenBlocks RPC method, using its own pool of readers so historical scans don't compete with head of chain queries")
+	f.Int(prefix+".workers", DefaultHistoryServerConfig.Workers, "how many blocks may be read from the freezer at once, across all requests")
+	f.Duration(prefix+".queue-timeout", DefaultHistoryServerConfig.QueueTimeout, "how long a block read waits for a free worker before the request is rejected")
+	f.Uint64(prefix+".max-blocks", DefaultHistoryServerConfig.MaxBlocks, "maximum blocks returned per request")
+}
+
+func (c *HistoryServerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Workers < 1 {
+		return errors.New("history server workers must be positive")
+	}
+	if c.MaxBlocks == 0 {
+		return errors.New("history server max-blocks must be positive")
+	}
+	return nil
+}
+
+var ErrHistoryServerBusy = errors.New("history server busy, try again later")
+
+// HistoryServer reads blocks that were moved to the freezer. Frozen blocks are final, so they're read
+// without the blockchain's caches or the key-value store, and reads are limited to a fixed number of
+// workers shared by all requests.
+type HistoryServer struct {
+	db          ethdb.Database
+	chainConfig *params.ChainConfig
+	config      func() *HistoryServerConfig
+	workers     chan struct{}
+}
+
+func NewHistoryServer(db ethdb.Database, chainConfig *params.ChainConfig, config func() *HistoryServerConfig) *HistoryServer {
+	return &HistoryServer{
+		db:          db,
+		chainConfig: chainConfig,
+		config:      config,
+		workers:     make(chan struct{}, config().Workers),
+	}
+}
+
+// frozenRange returns the first and one past the last block number held in the freezer.
+func (s *HistoryServer) frozenRange() (uint64, uint64, error) {
+	tail, err := s.db.Tail()
+	if err != nil {
+		return 0, 0, err
+	}
+	frozen, err := s.db.Ancients()
+	if err != nil {
+		return 0, 0, err
+	}
+	return tail, frozen, nil
+}
+
+func (s *HistoryServer) acquire(ctx context.Context) error {
+	timer := time.NewTimer(s.config().QueueTimeout)
+	defer timer.Stop()
+	select {
+	case s.workers <- struct{}{}:
+		historyBusyWorkersGauge.Update(int64(len(s.workers)))
+		return nil
+	case <-timer.C:
+		historyRejectedCounter.Inc(1)
+		return ErrHistoryServerBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *HistoryServer) release() {
+	<-s.workers
+	historyBusyWorkersGauge.Update(int64(len(s.workers)))
+}
+
+type HistoricalTransaction struct {
+	Transaction *types.Transaction `json:"transaction"`
+	From        *common.Address    `json:"from,omitempty"`
+}
+
+type HistoricalBlock struct {
+	Header       *types.Header           `json:"header"`
+	Transactions []HistoricalTransaction `json:"transactions"`
+	Receipts     types.Receipts          `json:"receipts,omitempty"`
+}
+
+func (s *HistoryServer) readBlock(number uint64, withReceipts bool) (*HistoricalBlock, error) {
+	start := time.Now()
+	defer func() { historyReadTimer.Update(time.Since(start).Microseconds()) }()
+	hashData, err := s.db.Ancient(rawdb.ChainFreezerHashTable, number)
+	if err != nil {
+		return nil, fmt.Errorf("error reading frozen block %v: %w", number, err)
+	}
+	hash := common.BytesToHash(hashData)
+	block := rawdb.ReadBlock(s.db, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("frozen block %v not found", number)
+	}
+	result := &HistoricalBlock{
+		Header:       block.Header(),
+		Transactions: make([]HistoricalTransaction, len(block.Transactions())),
+	}
+	signer := types.MakeSigner(s.chainConfig, block.Number(), block.Time())
+	for i, tx := range block.Transactions() {
+		result.Transactions[i].Transaction = tx
+		if from, err := types.Sender(signer, tx); err == nil {
+			result.Transactions[i].From = &from
+		}
+	}
+	if withReceipts {
+		result.Receipts = rawdb.ReadReceipts(s.db, hash, number, block.Time(), s.chainConfig)
+		if len(result.Receipts) != len(block.Transactions()) {
+			return nil, fmt.Errorf("frozen block %v has %v transactions but %v receipts", number, len(block.Transactions()), len(result.Receipts))
+		}
+	}
+	historyBlocksReadCounter.Inc(1)
+	return result, nil
+}
+
+// Blocks reads count consecutive frozen blocks starting at from, in parallel on the worker pool.
+func (s *HistoryServer) Blocks(ctx context.Context, from uint64, count uint64, withReceipts bool) ([]*HistoricalBlock, error) {
+	if limit := s.config().MaxBlocks; count > limit {
+		return nil, fmt.Errorf("requested %v blocks, at most %v are returned per request", count, limit)
+	}
+	tail, frozen, err := s.frozenRange()
+	if err != nil {
+		return nil, err
+	}
+	if from < tail || from+count > frozen || from+count < from {
+		return nil, fmt.Errorf("blocks %v to %v aren't all frozen, the freezer holds blocks %v to %v", from, from+count, tail, frozen)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	blocks := make([]*HistoricalBlock, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := uint64(0); i < count; i++ {
+		if err := s.acquire(ctx); err != nil {
+			errs[i] = err
+			break
+		}
+		wg.Add(1)
+		go func(i uint64) {
+			defer wg.Done()
+			defer s.release()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			blocks[i], errs[i] = s.readBlock(from+i, withReceipts)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+type FrozenBlockRange struct {
+	First hexutil.Uint64 `json:"first"`
+	// One past the last frozen block
+	End hexutil.Uint64 `json:"end"`
+}
+
+type HistoryServerAPI struct {
+	server *HistoryServer
+}
+
+func NewHistoryServerAPI(server *HistoryServer) *HistoryServerAPI {
+	return &HistoryServerAPI{server}
+}
+
+// GetFrozenBlocks returns count consecutive blocks from the freezer, with their transactions' senders and,
+// if receipts is set, their receipts.
+func (api *HistoryServerAPI) GetFrozenBlocks(ctx context.Context, from hexutil.Uint64, count hexutil.Uint64, receipts bool) ([]*HistoricalBlock, error) {
+	return api.server.Blocks(ctx, uint64(from), uint64(count), receipts)
+}
+
+// FrozenBlockRange returns the range of blocks arb_getFrozenBlocks can serve.
+func (api *HistoryServerAPI) FrozenBlockRange() (*FrozenBlockRange, error) {
+	tail, frozen, err := api.server.frozenRange()
+	if err != nil {
+		return nil, err
+	}
+	return &FrozenBlockRange{First: hexutil.Uint64(tail), End: hexutil.Uint64(frozen)}, nil
+}
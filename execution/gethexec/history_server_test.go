@@ -0,0 +1,105 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestHistoryServer(t *testing.T) {
+	dir := t.TempDir()
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Type:              rawdb.DBPebble,
+		Directory:         dir,
+		AncientsDirectory: filepath.Join(dir, "ancient"),
+		Namespace:         "history-test/",
+		Cache:             16,
+		Handles:           16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var blocks []*types.Block
+	var receipts []types.Receipts
+	parent := common.Hash{}
+	to := common.HexToAddress("0x1234")
+	for number := uint64(0); number < 10; number++ {
+		var txs types.Transactions
+		var blockReceipts types.Receipts
+		for i := uint64(0); i < number%3; i++ {
+			tx := types.NewTx(&types.LegacyTx{Nonce: number*3 + i, GasPrice: big.NewInt(1), Gas: 21000, To: &to})
+			txs = append(txs, tx)
+			blockReceipts = append(blockReceipts, &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000 * (i + 1), Logs: []*types.Log{}})
+		}
+		header := &types.Header{Number: new(big.Int).SetUint64(number), ParentHash: parent, Difficulty: common.Big1, BaseFee: big.NewInt(1)}
+		block := types.NewBlock(header, txs, nil, blockReceipts, trie.NewStackTrie(nil))
+		blocks = append(blocks, block)
+		receipts = append(receipts, blockReceipts)
+		parent = block.Hash()
+	}
+	if _, err := rawdb.WriteAncientBlocks(db, blocks, receipts, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultHistoryServerConfig
+	config.Enable = true
+	config.Workers = 3
+	config.MaxBlocks = 8
+	config.QueueTimeout = 10 * time.Millisecond
+	server := NewHistoryServer(db, params.TestChainConfig, func() *HistoryServerConfig { return &config })
+	ctx := context.Background()
+
+	read, err := server.Blocks(ctx, 2, 8, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, block := range read {
+		expected := blocks[2+i]
+		if block.Header.Hash() != expected.Hash() {
+			t.Fatal("unexpected block", 2+i)
+		}
+		if len(block.Transactions) != len(expected.Transactions()) || len(block.Receipts) != len(expected.Transactions()) {
+			t.Fatal("unexpected transactions or receipts in block", 2+i)
+		}
+		for j, receipt := range block.Receipts {
+			if receipt.BlockHash != expected.Hash() || receipt.TxHash != expected.Transactions()[j].Hash() {
+				t.Fatal("receipt fields weren't derived", 2+i, j)
+			}
+		}
+	}
+
+	if _, err := server.Blocks(ctx, 0, 9, false); err == nil {
+		t.Fatal("returned more blocks than the limit")
+	}
+	if _, err := server.Blocks(ctx, 5, 6, false); err == nil {
+		t.Fatal("returned blocks that aren't frozen")
+	}
+
+	// Reads wait for a free worker, then give up
+	for i := 0; i < config.Workers; i++ {
+		server.workers <- struct{}{}
+	}
+	if _, err := server.Blocks(ctx, 0, 1, false); !errors.Is(err, ErrHistoryServerBusy) {
+		t.Fatal("expected the busy error, got", err)
+	}
+	for i := 0; i < config.Workers; i++ {
+		server.release()
+	}
+	if _, err := server.Blocks(ctx, 0, 1, false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+type InclusionReceiptsConfig struct {
+	Enable     bool   `koanf:"enable"`
+	SigningKey string `koanf:"signing-key"`
+}
+
+var DefaultInclusionReceiptsConfig = InclusionReceiptsConfig{
+	Enable:     false,
+	SigningKey: "",
+}
+
+func InclusionReceiptsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultInclusionReceiptsConfig.Enable, "sign a receipt binding each sequenced transaction to its message index, position and sequencing time, served by the arb_inclusionReceipt and arb_sendRawTransactionWithInclusionReceipt RPC methods")
+	f.String(prefix+".signing-key", DefaultInclusionReceiptsConfig.SigningKey, "private key, or path to a file containing it, to sign inclusion receipts with")
+}
+
+func (c *InclusionReceiptsConfig) Validate() error {
+	if c.Enable && c.SigningKey == "" {
+		return errors.New("inclusion receipts enabled without a signing key")
+	}
+	return nil
+}
+
+var inclusionReceiptPrefix = []byte("arbitrum-inclusion-receipt-")
+
+func inclusionReceiptKey(txHash common.Hash) []byte {
+	return append(append([]byte{}, inclusionReceiptPrefix...), txHash.Bytes()...)
+}
+
+// Signed receipts start with this, so the signature can't be mistaken for one over other data.
+var inclusionReceiptDomain = []byte("Arbitrum inclusion receipt")
+
+// InclusionReceipt is the sequencer's signed statement that it sequenced a transaction at a position
+// and time, which the transaction's sender can use as evidence if it's later censored or reordered.
+type InclusionReceipt struct {
+	ChainId      hexutil.Uint64 `json:"chainId"`
+	TxHash       common.Hash    `json:"txHash"`
+	MessageIndex hexutil.Uint64 `json:"messageIndex"`
+	TxIndex      hexutil.Uint64 `json:"txIndex"` // the position of the transaction in its block
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Signer       common.Address `json:"signer"`
+	Signature    hexutil.Bytes  `json:"signature"`
+}
+
+// SigningHash returns the hash the sequencer signs, which covers every field but the signer and signature.
+func (r *InclusionReceipt) SigningHash() common.Hash {
+	data := append([]byte{}, inclusionReceiptDomain...)
+	data = binary.BigEndian.AppendUint64(data, uint64(r.ChainId))
+	data = append(data, r.TxHash.Bytes()...)
+	data = binary.BigEndian.AppendUint64(data, uint64(r.MessageIndex))
+	data = binary.BigEndian.AppendUint64(data, uint64(r.TxIndex))
+	data = binary.BigEndian.AppendUint64(data, uint64(r.Timestamp))
+	return crypto.Keccak256Hash(data)
+}
+
+// Verify checks the receipt is signed by its claimed signer.
+func (r *InclusionReceipt) Verify() error {
+	pubKey, err := crypto.SigToPub(r.SigningHash().Bytes(), r.Signature)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*pubKey); signer != r.Signer {
+		return fmt.Errorf("inclusion receipt is signed by %v, not the claimed %v", signer, r.Signer)
+	}
+	return nil
+}
+
+// InclusionReceipts signs and stores a receipt for each transaction the sequencer includes in a block.
+type InclusionReceipts struct {
+	db      ethdb.KeyValueStore
+	chainId uint64
+	signer  signature.DataSignerFunc
+	address common.Address
+}
+
+func NewInclusionReceipts(db ethdb.KeyValueStore, chainId uint64, config *InclusionReceiptsConfig) (*InclusionReceipts, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	keyHash, err := signature.LoadSigningKey(config.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading inclusion receipt signing key: %w", err)
+	}
+	privateKey, err := crypto.ToECDSA(keyHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid inclusion receipt signing key: %w", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	log.Info("sequencer signing inclusion receipts", "signer", address)
+	return &InclusionReceipts{
+		db:      db,
+		chainId: chainId,
+		signer:  signature.DataSignerFromPrivateKey(privateKey),
+		address: address,
+	}, nil
+}
+
+func (r *InclusionReceipts) Signer() common.Address {
+	return r.address
+}
+
+// record signs and stores receipts for a sequenced block's transactions that have a sequencing time.
+func (r *InclusionReceipts) record(pos arbutil.MessageIndex, block *types.Block, sequencedAt map[common.Hash]uint64) error {
+	batch := r.db.NewBatch()
+	for i, tx := range block.Transactions() {
+		timestamp, ok := sequencedAt[tx.Hash()]
+		if !ok {
+			continue
+		}
+		receipt := &InclusionReceipt{
+			ChainId:      hexutil.Uint64(r.chainId),
+			TxHash:       tx.Hash(),
+			MessageIndex: hexutil.Uint64(pos),
+			// #nosec G115
+			TxIndex:   hexutil.Uint64(i),
+			Timestamp: hexutil.Uint64(timestamp),
+			Signer:    r.address,
+		}
+		sig, err := r.signer(receipt.SigningHash().Bytes())
+		if err != nil {
+			return err
+		}
+		receipt.Signature = sig
+		encoded, err := json.Marshal(receipt)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(inclusionReceiptKey(tx.Hash()), encoded); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// Get returns a transaction's inclusion receipt, or nil if the sequencer didn't sign one.
+func (r *InclusionReceipts) Get(txHash common.Hash) (*InclusionReceipt, error) {
+	key := inclusionReceiptKey(txHash)
+	has, err := r.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := r.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var receipt InclusionReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, fmt.Errorf("invalid inclusion receipt for tx %v: %w", txHash, err)
+	}
+	return &receipt, nil
+}
+
+type InclusionReceiptsAPI struct {
+	txPublisher TransactionPublisher
+	receipts    *InclusionReceipts
+}
+
+func NewInclusionReceiptsAPI(txPublisher TransactionPublisher, receipts *InclusionReceipts) *InclusionReceiptsAPI {
+	return &InclusionReceiptsAPI{txPublisher, receipts}
+}
+
+// InclusionReceipt returns the sequencer's signed receipt for a transaction, or null if it didn't sign one.
+func (api *InclusionReceiptsAPI) InclusionReceipt(_ context.Context, txHash common.Hash) (*InclusionReceipt, error) {
+	return api.receipts.Get(txHash)
+}
+
+// SendRawTransactionWithInclusionReceipt submits a transaction like eth_sendRawTransaction, waits for it to
+// be sequenced, and returns its inclusion receipt.
+func (api *InclusionReceiptsAPI) SendRawTransactionWithInclusionReceipt(ctx context.Context, input hexutil.Bytes) (*InclusionReceipt, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, err
+	}
+	if err := api.txPublisher.PublishTransaction(ctx, tx, nil); err != nil {
+		return nil, err
+	}
+	receipt, err := api.receipts.Get(tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, errors.New("transaction was sequenced without an inclusion receipt, possibly by another sequencer")
+	}
+	return receipt, nil
+}
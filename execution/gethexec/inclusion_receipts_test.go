@@ -0,0 +1,73 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestInclusionReceipts(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultInclusionReceiptsConfig
+	config.Enable = true
+	config.SigningKey = hexutil.Encode(crypto.FromECDSA(key))
+	receipts, err := NewInclusionReceipts(rawdb.NewMemoryDatabase(), 412346, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipts.Signer() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("unexpected signer", receipts.Signer())
+	}
+
+	// The first transaction stands in for the internal start block transaction, which isn't sequenced
+	var txes types.Transactions
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		txes = append(txes, types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1), Gas: 21000, To: &common.Address{}}))
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(7)}, txes, nil, nil, trie.NewStackTrie(nil))
+	sequencedAt := map[common.Hash]uint64{txes[1].Hash(): 1000, txes[2].Hash(): 1001}
+	if err := receipts.record(5, block, sequencedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := receipts.Get(txes[2].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt == nil || receipt.ChainId != 412346 || receipt.MessageIndex != 5 || receipt.TxIndex != 2 || receipt.Timestamp != 1001 {
+		t.Fatal("unexpected receipt", receipt)
+	}
+	if err := receipt.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	tampered := *receipt
+	tampered.TxIndex = 1
+	if err := tampered.Verify(); err == nil {
+		t.Fatal("verified a reordered receipt")
+	}
+
+	missing, err := receipts.Get(txes[0].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing != nil {
+		t.Fatal("got a receipt for a transaction that wasn't sequenced")
+	}
+
+	config.SigningKey = ""
+	if err := config.Validate(); err == nil {
+		t.Fatal("enabled without a signing key")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+var l1PricingSnapshotPrefix = []byte("arbitrum-l1-pricing-snapshot-")
+
+func l1PricingSnapshotKey(blockHash common.Hash) []byte {
+	return append(append([]byte{}, l1PricingSnapshotPrefix...), blockHash.Bytes()...)
+}
+
+// L1PricingSnapshot is ArbOS's L1 pricing model as a block left it.
+type L1PricingSnapshot struct {
+	// The estimated L1 base fee, in wei per unit of L1 calldata
+	PricePerUnit *hexutil.Big `json:"pricePerUnit"`
+	// Funds available to pay batch posters less what they're owed, which may be negative
+	Surplus          *hexutil.Big   `json:"surplus"`
+	LastSurplus      *hexutil.Big   `json:"lastSurplus"`
+	L1FeesAvailable  *hexutil.Big   `json:"l1FeesAvailable"`
+	UnitsSinceUpdate hexutil.Uint64 `json:"unitsSinceUpdate"`
+	LastUpdateTime   hexutil.Uint64 `json:"lastUpdateTime"`
+}
+
+func readL1PricingSnapshot(statedb *state.StateDB) (*L1PricingSnapshot, error) {
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	pricing := arbState.L1PricingState()
+	pricePerUnit, err := pricing.PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	surplus, err := pricing.GetL1PricingSurplus()
+	if err != nil {
+		return nil, err
+	}
+	lastSurplus, err := pricing.LastSurplus()
+	if err != nil {
+		return nil, err
+	}
+	l1FeesAvailable, err := pricing.L1FeesAvailable()
+	if err != nil {
+		return nil, err
+	}
+	unitsSinceUpdate, err := pricing.UnitsSinceUpdate()
+	if err != nil {
+		return nil, err
+	}
+	lastUpdateTime, err := pricing.LastUpdateTime()
+	if err != nil {
+		return nil, err
+	}
+	return &L1PricingSnapshot{
+		PricePerUnit:     (*hexutil.Big)(pricePerUnit),
+		Surplus:          (*hexutil.Big)(surplus),
+		LastSurplus:      (*hexutil.Big)(lastSurplus),
+		L1FeesAvailable:  (*hexutil.Big)(l1FeesAvailable),
+		UnitsSinceUpdate: hexutil.Uint64(unitsSinceUpdate),
+		LastUpdateTime:   hexutil.Uint64(lastUpdateTime),
+	}, nil
+}
+
+// storedL1PricingSnapshot is how a snapshot is kept in the database. Unlike hexutil.Big,
+// big.Int's json encoding round trips negative values.
+type storedL1PricingSnapshot struct {
+	PricePerUnit     *big.Int
+	Surplus          *big.Int
+	LastSurplus      *big.Int
+	L1FeesAvailable  *big.Int
+	UnitsSinceUpdate uint64
+	LastUpdateTime   uint64
+}
+
+// L1PricingSnapshots stores each block's L1 pricing snapshot as it's written, so they outlive the block's state.
+// They aren't part of the block header, whose Arbitrum fields are fixed by consensus.
+type L1PricingSnapshots struct {
+	db ethdb.KeyValueStore
+}
+
+func NewL1PricingSnapshots(db ethdb.KeyValueStore) *L1PricingSnapshots {
+	return &L1PricingSnapshots{db: db}
+}
+
+func (s *L1PricingSnapshots) record(blockHash common.Hash, statedb *state.StateDB) error {
+	snapshot, err := readL1PricingSnapshot(statedb)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(storedL1PricingSnapshot{
+		PricePerUnit:     snapshot.PricePerUnit.ToInt(),
+		Surplus:          snapshot.Surplus.ToInt(),
+		LastSurplus:      snapshot.LastSurplus.ToInt(),
+		L1FeesAvailable:  snapshot.L1FeesAvailable.ToInt(),
+		UnitsSinceUpdate: uint64(snapshot.UnitsSinceUpdate),
+		LastUpdateTime:   uint64(snapshot.LastUpdateTime),
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Put(l1PricingSnapshotKey(blockHash), data)
+}
+
+// Get returns a block's recorded snapshot, or nil if it wasn't recorded.
+func (s *L1PricingSnapshots) Get(blockHash common.Hash) (*L1PricingSnapshot, error) {
+	key := l1PricingSnapshotKey(blockHash)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := s.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var stored storedL1PricingSnapshot
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &L1PricingSnapshot{
+		PricePerUnit:     (*hexutil.Big)(stored.PricePerUnit),
+		Surplus:          (*hexutil.Big)(stored.Surplus),
+		LastSurplus:      (*hexutil.Big)(stored.LastSurplus),
+		L1FeesAvailable:  (*hexutil.Big)(stored.L1FeesAvailable),
+		UnitsSinceUpdate: hexutil.Uint64(stored.UnitsSinceUpdate),
+		LastUpdateTime:   hexutil.Uint64(stored.LastUpdateTime),
+	}, nil
+}
+
+type L1PricingSnapshotAPI struct {
+	bc        *core.BlockChain
+	snapshots *L1PricingSnapshots // nil unless recording is enabled
+}
+
+func NewL1PricingSnapshotAPI(bc *core.BlockChain, snapshots *L1PricingSnapshots) *L1PricingSnapshotAPI {
+	return &L1PricingSnapshotAPI{bc, snapshots}
+}
+
+// L1PricingSnapshot returns the L1 pricing model as a block left it, from the recorded snapshots if enabled,
+// otherwise from the block's state if it's still available.
+func (api *L1PricingSnapshotAPI) L1PricingSnapshot(_ context.Context, number rpc.BlockNumber) (*L1PricingSnapshot, error) {
+	var header *types.Header
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		header = api.bc.CurrentBlock()
+	default:
+		if number < 0 {
+			return nil, errors.New("unsupported block tag")
+		}
+		// #nosec G115
+		header = api.bc.GetHeaderByNumber(uint64(number))
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	if api.snapshots != nil {
+		snapshot, err := api.snapshots.Get(header.Hash())
+		if err != nil || snapshot != nil {
+			return snapshot, err
+		}
+	}
+	statedb, err := api.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, errors.New("no snapshot recorded for block and its state isn't available")
+	}
+	return readL1PricingSnapshot(statedb)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+func TestL1PricingSnapshotsRoundTrip(t *testing.T) {
+	state, statedb := arbosState.NewArbosMemoryBackedArbOSState()
+	pricing := state.L1PricingState()
+	if err := pricing.SetPricePerUnit(big.NewInt(123456)); err != nil {
+		t.Fatal(err)
+	}
+	// A deficit makes the surplus negative
+	if err := pricing.SetFundsDueForRewards(big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pricing.SetUnitsSinceUpdate(42); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots := NewL1PricingSnapshots(rawdb.NewMemoryDatabase())
+	blockHash := common.HexToHash("0xabcd")
+	if err := snapshots.record(blockHash, statedb); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := snapshots.Get(blockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot == nil {
+		t.Fatal("snapshot wasn't recorded")
+	}
+	if snapshot.PricePerUnit.ToInt().Int64() != 123456 {
+		t.Fatal("wrong price per unit", snapshot.PricePerUnit)
+	}
+	if snapshot.Surplus.ToInt().Sign() >= 0 {
+		t.Fatal("expected a negative surplus but got", snapshot.Surplus)
+	}
+	if snapshot.UnitsSinceUpdate != 42 {
+		t.Fatal("wrong units since update", snapshot.UnitsSinceUpdate)
+	}
+
+	missing, err := snapshots.Get(common.HexToHash("0x1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing != nil {
+		t.Fatal("got a snapshot for a block that wasn't recorded")
+	}
+}
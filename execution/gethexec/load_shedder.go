@@ -0,0 +1,383 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/membudget"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	loadSheddingOverloadedGauge    = metrics.NewRegisteredGauge("arb/rpc/loadshedding/overloaded", nil)
+	loadSheddingCPUGauge           = metrics.NewRegisteredGauge("arb/rpc/loadshedding/cpu", nil) // percent of all cores
+	loadSheddingMemoryGauge        = metrics.NewRegisteredGauge("arb/rpc/loadshedding/memory", nil)
+	loadSheddingBlockCreationGauge = metrics.NewRegisteredGauge("arb/rpc/loadshedding/blockcreation", nil) // in microseconds
+	loadSheddingShedCounter        = metrics.NewRegisteredCounter("arb/rpc/loadshedding/shed", nil)
+	loadSheddingQueuedCounter      = metrics.NewRegisteredCounter("arb/rpc/loadshedding/queued", nil)
+)
+
+// Weight of each new block's creation time in the moving average compared with max-block-creation-time
+const loadSheddingBlockCreationWeight = 0.2
+
+type LoadSheddingConfig struct {
+	Enable               bool          `koanf:"enable"`
+	CheckInterval        time.Duration `koanf:"check-interval"`
+	MaxCPU               float64       `koanf:"max-cpu"`
+	MaxMemory            string        `koanf:"max-memory"`
+	MaxBlockCreationTime time.Duration `koanf:"max-block-creation-time"`
+	HealthyFraction      float64       `koanf:"healthy-fraction"`
+	ExpensiveMethods     []string      `koanf:"expensive-methods"`
+	MaxLogsBlockRange    uint64        `koanf:"max-logs-block-range"`
+	QueueTimeout         time.Duration `koanf:"queue-timeout"`
+	MaxQueued            int           `koanf:"max-queued"`
+
+	maxMemory uint64
+}
+
+var DefaultLoadSheddingConfig = LoadSheddingConfig{
+	Enable:               false,
+	CheckInterval:        time.Second,
+	MaxCPU:               0.9,
+	MaxMemory:            "",
+	MaxBlockCreationTime: 500 * time.Millisecond,
+	HealthyFraction:      0.8,
+	ExpensiveMethods:     []string{"debug_trace*", "trace_*", "arbtrace_*", "debug_getBadBlocks", "debug_storageRangeAt"},
+	MaxLogsBlockRange:    1000,
+	QueueTimeout:         0,
+	MaxQueued:            100,
+}
+
+func LoadSheddingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultLoadSheddingConfig.Enable, "reject expensive http RPC calls with 429 errors while the node is overloaded, so they can't slow down block production")
+	f.Duration(prefix+".check-interval", DefaultLoadSheddingConfig.CheckInterval, "how often to check whether the node is overloaded")
+	f.Float64(prefix+".max-cpu", DefaultLoadSheddingConfig.MaxCPU, "fraction of all CPU cores used by the node above which it's overloaded (0 = ignore CPU)")
+	f.String(prefix+".max-memory", DefaultLoadSheddingConfig.MaxMemory, "memory used by the node above which it's overloaded, expressed in bytes or multiples of bytes with suffix B, K, M, G (empty = ignore memory)")
+	f.Duration(prefix+".max-block-creation-time", DefaultLoadSheddingConfig.MaxBlockCreationTime, "average time the sequencer takes to create a block above which it's overloaded (0 = ignore block creation)")
+	f.Float64(prefix+".healthy-fraction", DefaultLoadSheddingConfig.HealthyFraction, "fraction of the limits below which everything must fall for the node to stop shedding load")
+	f.StringSlice(prefix+".expensive-methods", DefaultLoadSheddingConfig.ExpensiveMethods, "methods shed while overloaded, where a trailing * matches any suffix; every other method is always served")
+	f.Uint64(prefix+".max-logs-block-range", DefaultLoadSheddingConfig.MaxLogsBlockRange, "eth_getLogs calls over more blocks than this are shed while overloaded (0 = never shed eth_getLogs unless listed as expensive)")
+	f.Duration(prefix+".queue-timeout", DefaultLoadSheddingConfig.QueueTimeout, "how long an expensive call waits for the node to recover before it's rejected (0 = reject immediately)")
+	f.Int(prefix+".max-queued", DefaultLoadSheddingConfig.MaxQueued, "maximum number of expensive calls waiting for the node to recover, above which they're rejected immediately")
+}
+
+func (c *LoadSheddingConfig) Validate() error {
+	c.maxMemory = 0
+	if !c.Enable {
+		return nil
+	}
+	if c.CheckInterval <= 0 {
+		return errors.New("load shedding check-interval must be positive")
+	}
+	if c.MaxCPU < 0 {
+		return errors.New("load shedding max-cpu can't be negative")
+	}
+	if c.MaxMemory != "" {
+		maxMemory, err := membudget.ParseMemLimit(c.MaxMemory)
+		if err != nil {
+			return fmt.Errorf("invalid load shedding max-memory: %w", err)
+		}
+		if maxMemory <= 0 {
+			return errors.New("load shedding max-memory must be positive")
+		}
+		c.maxMemory = uint64(maxMemory)
+	}
+	if c.HealthyFraction <= 0 || c.HealthyFraction > 1 {
+		return errors.New("load shedding healthy-fraction must be in (0, 1]")
+	}
+	for _, method := range c.ExpensiveMethods {
+		if method == "" || strings.Contains(strings.TrimSuffix(method, "*"), "*") {
+			return fmt.Errorf("invalid load shedding expensive method \"%v\", expected a method name or a prefix ending in *", method)
+		}
+	}
+	if c.MaxQueued < 0 {
+		return errors.New("load shedding max-queued can't be negative")
+	}
+	return nil
+}
+
+// loadSheddingSignals reads the node's resource usage.
+type loadSheddingSignals interface {
+	CPUTime() time.Duration // used by the process since it started
+	MemoryUsage() uint64
+	HeadBlockNumber() uint64
+}
+
+// LoadShedder watches the node's CPU and memory usage and the sequencer's block creation time, and while any
+// is above its limit rejects, or queues, calls to expensive methods such as traces and wide eth_getLogs, so
+// that transaction submission and head queries keep being served without slowing down block production.
+type LoadShedder struct {
+	stopwaiter.StopWaiter
+	config  *LoadSheddingConfig
+	signals loadSheddingSignals
+
+	blockCreation atomic.Int64 // moving average in nanoseconds
+	queued        atomic.Int32
+
+	mutex       sync.Mutex
+	overloaded  bool
+	recovered   chan struct{} // closed once no longer overloaded
+	lastCPUTime time.Duration
+	lastCheck   time.Time
+}
+
+func NewLoadShedder(config *LoadSheddingConfig, signals loadSheddingSignals) *LoadShedder {
+	return &LoadShedder{
+		config:  config,
+		signals: signals,
+	}
+}
+
+func (s *LoadShedder) Start(ctx context.Context) {
+	s.StopWaiter.Start(ctx, s)
+	s.lastCPUTime = s.signals.CPUTime()
+	s.lastCheck = time.Now()
+	s.CallIteratively(func(ctx context.Context) time.Duration {
+		s.check(time.Now())
+		return s.config.CheckInterval
+	})
+}
+
+func (s *LoadShedder) observeBlockCreation(elapsed time.Duration) {
+	for {
+		old := s.blockCreation.Load()
+		next := int64(elapsed)
+		if old != 0 {
+			next = int64(float64(old)*(1-loadSheddingBlockCreationWeight) + float64(elapsed)*loadSheddingBlockCreationWeight)
+		}
+		if s.blockCreation.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// check updates whether the node is overloaded, requiring every signal below its healthy fraction to recover.
+func (s *LoadShedder) check(now time.Time) {
+	config := s.config
+	overloaded := false
+	healthy := true
+	signal := func(name string, value, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		if value > limit {
+			if !s.Overloaded() {
+				log.Warn("node overloaded, shedding expensive RPC calls", "signal", name, "value", value, "limit", limit)
+			}
+			overloaded = true
+		}
+		if value > limit*config.HealthyFraction {
+			healthy = false
+		}
+	}
+
+	cpuTime := s.signals.CPUTime()
+	if elapsed := now.Sub(s.lastCheck); elapsed > 0 {
+		cpu := float64(cpuTime-s.lastCPUTime) / float64(elapsed) / float64(runtime.NumCPU())
+		loadSheddingCPUGauge.Update(int64(cpu * 100))
+		signal("cpu", cpu, config.MaxCPU)
+	}
+	s.lastCPUTime = cpuTime
+	s.lastCheck = now
+	memory := s.signals.MemoryUsage()
+	// #nosec G115
+	loadSheddingMemoryGauge.Update(int64(memory))
+	signal("memory", float64(memory), float64(config.maxMemory))
+	blockCreation := time.Duration(s.blockCreation.Load())
+	loadSheddingBlockCreationGauge.Update(blockCreation.Microseconds())
+	signal("block-creation-time", float64(blockCreation), float64(config.MaxBlockCreationTime))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if overloaded && !s.overloaded {
+		s.overloaded = true
+		s.recovered = make(chan struct{})
+		loadSheddingOverloadedGauge.Update(1)
+	} else if healthy && s.overloaded {
+		s.overloaded = false
+		close(s.recovered)
+		loadSheddingOverloadedGauge.Update(0)
+		log.Info("node recovered, serving expensive RPC calls again")
+	}
+}
+
+func (s *LoadShedder) Overloaded() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.overloaded
+}
+
+// recoveredChan returns a channel closed once the node isn't overloaded, or nil if it isn't now.
+func (s *LoadShedder) recoveredChan() chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.overloaded {
+		return nil
+	}
+	return s.recovered
+}
+
+type loadSheddingRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type loadSheddingLogsFilter struct {
+	BlockHash *string `json:"blockHash"`
+	FromBlock *string `json:"fromBlock"`
+	ToBlock   *string `json:"toBlock"`
+}
+
+// logsBlockNumber resolves a getLogs block number or tag, where an unset block is the latest.
+func (s *LoadShedder) logsBlockNumber(block *string) uint64 {
+	if block == nil {
+		return s.signals.HeadBlockNumber()
+	}
+	switch *block {
+	case "earliest":
+		return 0
+	case "latest", "pending", "safe", "finalized":
+		return s.signals.HeadBlockNumber()
+	}
+	number, err := hexutil.DecodeUint64(*block)
+	if err != nil {
+		// Left for the RPC server to reject
+		return 0
+	}
+	return number
+}
+
+func (s *LoadShedder) expensive(call *loadSheddingRequest) bool {
+	for _, method := range s.config.ExpensiveMethods {
+		if prefix, isPrefix := strings.CutSuffix(method, "*"); isPrefix {
+			if strings.HasPrefix(call.Method, prefix) {
+				return true
+			}
+		} else if call.Method == method {
+			return true
+		}
+	}
+	if call.Method != "eth_getLogs" || s.config.MaxLogsBlockRange == 0 || len(call.Params) == 0 {
+		return false
+	}
+	var filter loadSheddingLogsFilter
+	if json.Unmarshal(call.Params[0], &filter) != nil || filter.BlockHash != nil {
+		return false
+	}
+	from := s.logsBlockNumber(filter.FromBlock)
+	to := s.logsBlockNumber(filter.ToBlock)
+	return to > from && to-from >= s.config.MaxLogsBlockRange
+}
+
+// expensiveMethod returns the first expensive method a request body calls, or "" if it calls none.
+func (s *LoadShedder) expensiveMethod(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	var calls []loadSheddingRequest
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if json.Unmarshal(trimmed, &calls) != nil {
+			return ""
+		}
+	} else {
+		var call loadSheddingRequest
+		if json.Unmarshal(trimmed, &call) != nil {
+			return ""
+		}
+		calls = append(calls, call)
+	}
+	for i := range calls {
+		if s.expensive(&calls[i]) {
+			return calls[i].Method
+		}
+	}
+	return ""
+}
+
+// wait waits for the node to recover, returning false if it didn't within the queue timeout.
+func (s *LoadShedder) wait(ctx context.Context, recovered chan struct{}) bool {
+	if s.config.QueueTimeout <= 0 {
+		return false
+	}
+	if int(s.queued.Add(1)) > s.config.MaxQueued {
+		s.queued.Add(-1)
+		return false
+	}
+	defer s.queued.Add(-1)
+	loadSheddingQueuedCounter.Inc(1)
+	timer := time.NewTimer(s.config.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case <-recovered:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// WrapHandler returns an http handler which passes requests on to next unless they call an expensive
+// method while the node is overloaded. Websocket calls aren't seen.
+func (s *LoadShedder) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recovered := s.recoveredChan()
+		if recovered == nil || r.Method != http.MethodPost || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Larger requests, like the SLO tracker's, are served without being parsed
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRPCSLORequestSize+1))
+		if err != nil || len(body) > maxRPCSLORequestSize {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		method := s.expensiveMethod(body)
+		if method == "" || s.wait(r.Context(), recovered) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		loadSheddingShedCounter.Inc(1)
+		w.Header().Set("Retry-After", fmt.Sprint(int(max(s.config.CheckInterval, time.Second).Seconds())))
+		writeRPCGatewayError(w, http.StatusTooManyRequests, -32005, fmt.Sprintf("node is overloaded, %v calls are temporarily rejected", method))
+	})
+}
+
+// processLoadSheddingSignals reads the process's resource usage and the head of the chain.
+type processLoadSheddingSignals struct {
+	headBlockNumber func() uint64
+}
+
+func (s processLoadSheddingSignals) CPUTime() time.Duration {
+	var stats metrics.CPUStats
+	metrics.ReadCPUStats(&stats)
+	return time.Duration(stats.LocalTime * float64(time.Second))
+}
+
+func (s processLoadSheddingSignals) MemoryUsage() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys - stats.HeapReleased
+}
+
+func (s processLoadSheddingSignals) HeadBlockNumber() uint64 {
+	return s.headBlockNumber()
+}
@@ -0,0 +1,122 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLoadSheddingSignals struct {
+	memory uint64
+}
+
+func (s *testLoadSheddingSignals) CPUTime() time.Duration  { return 0 }
+func (s *testLoadSheddingSignals) MemoryUsage() uint64     { return s.memory }
+func (s *testLoadSheddingSignals) HeadBlockNumber() uint64 { return 10_000 }
+
+func newTestLoadShedder(t *testing.T, queueTimeout time.Duration) (*LoadShedder, *testLoadSheddingSignals, http.Handler) {
+	config := DefaultLoadSheddingConfig
+	config.Enable = true
+	config.MaxCPU = 0
+	config.MaxMemory = "1000B"
+	config.QueueTimeout = queueTimeout
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	signals := &testLoadSheddingSignals{}
+	shedder := NewLoadShedder(&config, signals)
+	handler := shedder.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	return shedder, signals, handler
+}
+
+func loadSheddingStatus(handler http.Handler, body string) int {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return recorder.Code
+}
+
+func TestLoadShedderSheds(t *testing.T) {
+	shedder, signals, handler := newTestLoadShedder(t, 0)
+	trace := `{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":["0x01"]}`
+	if status := loadSheddingStatus(handler, trace); status != http.StatusOK {
+		t.Fatal("trace rejected while not overloaded, status", status)
+	}
+
+	signals.memory = 2000
+	shedder.check(time.Now())
+	if !shedder.Overloaded() {
+		t.Fatal("not overloaded above the memory limit")
+	}
+	for body, expected := range map[string]int{
+		trace: http.StatusTooManyRequests,
+		`[{"method":"eth_blockNumber"},{"method":"trace_block","params":["latest"]}]`:                http.StatusTooManyRequests,
+		`{"method":"eth_getLogs","params":[{"fromBlock":"0x1","toBlock":"latest"}]}`:                 http.StatusTooManyRequests,
+		`{"method":"eth_getLogs","params":[{"fromBlock":"0x2700","toBlock":"0x2710"}]}`:              http.StatusOK,
+		`{"method":"eth_getLogs","params":[{"blockHash":"0x01"}]}`:                                   http.StatusOK,
+		`{"method":"eth_sendRawTransaction","params":["0x01"]}`:                                      http.StatusOK,
+		`[{"method":"eth_blockNumber"},{"method":"eth_getBlockByNumber","params":["latest",false]}]`: http.StatusOK,
+	} {
+		if status := loadSheddingStatus(handler, body); status != expected {
+			t.Fatal("expected status", expected, "but got", status, "for", body)
+		}
+	}
+
+	// Staying overloaded until below the healthy fraction of the limit
+	signals.memory = 900
+	shedder.check(time.Now())
+	if !shedder.Overloaded() {
+		t.Fatal("recovered above the healthy fraction of the limit")
+	}
+	signals.memory = 700
+	shedder.check(time.Now())
+	if shedder.Overloaded() {
+		t.Fatal("still overloaded below the healthy fraction of the limit")
+	}
+	if status := loadSheddingStatus(handler, trace); status != http.StatusOK {
+		t.Fatal("trace rejected after recovering, status", status)
+	}
+}
+
+func TestLoadShedderBlockCreation(t *testing.T) {
+	shedder, _, _ := newTestLoadShedder(t, 0)
+	for i := 0; i < 20; i++ {
+		shedder.observeBlockCreation(time.Second)
+	}
+	shedder.check(time.Now())
+	if !shedder.Overloaded() {
+		t.Fatal("not overloaded by slow block creation")
+	}
+	for i := 0; i < 20; i++ {
+		shedder.observeBlockCreation(time.Millisecond)
+	}
+	shedder.check(time.Now())
+	if shedder.Overloaded() {
+		t.Fatal("still overloaded once blocks are created quickly")
+	}
+}
+
+func TestLoadShedderQueues(t *testing.T) {
+	shedder, signals, handler := newTestLoadShedder(t, time.Minute)
+	signals.memory = 2000
+	shedder.check(time.Now())
+
+	status := make(chan int)
+	go func() {
+		status <- loadSheddingStatus(handler, `{"method":"debug_traceBlockByNumber","params":["latest"]}`)
+	}()
+	for shedder.queued.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	signals.memory = 0
+	shedder.check(time.Now())
+	if result := <-status; result != http.StatusOK {
+		t.Fatal("queued call rejected after recovering, status", result)
+	}
+}
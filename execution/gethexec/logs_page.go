@@ -0,0 +1,261 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// logsPageChunkBlocks is how many blocks are filtered at a time, so a page that fills up early
+// doesn't scan the rest of its block budget.
+const logsPageChunkBlocks = 1000
+
+type LogsPageConfig struct {
+	MaxLogs   int    `koanf:"max-logs" reload:"hot"`
+	MaxBlocks uint64 `koanf:"max-blocks" reload:"hot"`
+	MaxBytes  int    `koanf:"max-bytes" reload:"hot"`
+}
+
+var DefaultLogsPageConfig = LogsPageConfig{
+	MaxLogs:   10000,
+	MaxBlocks: 100000,
+	MaxBytes:  32 << 20,
+}
+
+func LogsPageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".max-logs", DefaultLogsPageConfig.MaxLogs, "maximum number of logs arb_getLogsPage returns in a page")
+	f.Uint64(prefix+".max-blocks", DefaultLogsPageConfig.MaxBlocks, "maximum number of blocks arb_getLogsPage scans for a page")
+	f.Int(prefix+".max-bytes", DefaultLogsPageConfig.MaxBytes, "maximum size in bytes of the logs arb_getLogsPage returns in a page, though a page always has at least one log (0 = no limit)")
+}
+
+func (c *LogsPageConfig) Validate() error {
+	if c.MaxLogs < 1 || c.MaxBlocks < 1 {
+		return errors.New("logs-page max-logs and max-blocks must be positive")
+	}
+	if c.MaxBytes < 0 {
+		return errors.New("logs-page max-bytes cannot be negative")
+	}
+	return nil
+}
+
+type LogsPageConfigFetcher func() *LogsPageConfig
+
+type LogsPageQuery struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Addresses []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+	// Maximum number of logs to return, capped by the server's limit
+	Limit int `json:"limit"`
+	// The cursor of the previous page, which the block range is taken from
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
+// Limits that can end a page before the end of the range
+const (
+	LogsPageLimitLogs   = "logs"
+	LogsPageLimitBlocks = "blocks"
+	LogsPageLimitBytes  = "bytes"
+)
+
+type LogsPage struct {
+	Logs []*types.Log `json:"logs"`
+	// Pass it back with the same query to get the next page, null once every log in the range was returned.
+	// A page can have fewer logs than the limit and still a cursor, if the server hit another of its limits.
+	Cursor    *hexutil.Bytes `json:"cursor"`
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+	// The limit that ended the page, empty if it reached the end of the range
+	Limit string `json:"limit,omitempty"`
+}
+
+// logsCursor is the position of the next log to return, and the end of the range being paged through.
+type logsCursor struct {
+	block    uint64
+	logIndex uint64
+	toBlock  uint64
+}
+
+func (c logsCursor) encode() *hexutil.Bytes {
+	data := binary.BigEndian.AppendUint64(nil, c.block)
+	data = binary.BigEndian.AppendUint64(data, c.logIndex)
+	data = binary.BigEndian.AppendUint64(data, c.toBlock)
+	return (*hexutil.Bytes)(&data)
+}
+
+func decodeLogsCursor(data []byte) (logsCursor, error) {
+	if len(data) != 24 {
+		return logsCursor{}, errors.New("invalid cursor")
+	}
+	return logsCursor{
+		block:    binary.BigEndian.Uint64(data[:8]),
+		logIndex: binary.BigEndian.Uint64(data[8:16]),
+		toBlock:  binary.BigEndian.Uint64(data[16:]),
+	}, nil
+}
+
+type LogsPageAPI struct {
+	bc     *core.BlockChain
+	sys    *filters.FilterSystem
+	bloom  *ExtendedBloomIndex // nil unless enabled
+	config LogsPageConfigFetcher
+}
+
+func NewLogsPageAPI(bc *core.BlockChain, sys *filters.FilterSystem, bloom *ExtendedBloomIndex, config LogsPageConfigFetcher) *LogsPageAPI {
+	return &LogsPageAPI{bc, sys, bloom, config}
+}
+
+func (api *LogsPageAPI) resolveBlock(number *rpc.BlockNumber) (uint64, error) {
+	head := api.bc.CurrentBlock().Number.Uint64()
+	if number == nil {
+		return head, nil
+	}
+	switch *number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		return head, nil
+	case rpc.EarliestBlockNumber:
+		return 0, nil
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		var header *types.Header
+		if *number == rpc.SafeBlockNumber {
+			header = api.bc.CurrentSafeBlock()
+		} else {
+			header = api.bc.CurrentFinalBlock()
+		}
+		if header == nil {
+			return 0, fmt.Errorf("%v block not known", number.String())
+		}
+		return header.Number.Uint64(), nil
+	}
+	if *number < 0 {
+		return 0, errors.New("unsupported block tag")
+	}
+	// #nosec G115
+	return uint64(*number), nil
+}
+
+// GetLogsPage returns the logs matching a filter one page at a time, so indexers can walk huge block
+// ranges without splitting them to stay under eth_getLogs' response limits.
+func (api *LogsPageAPI) GetLogsPage(ctx context.Context, query LogsPageQuery) (*LogsPage, error) {
+	config := api.config()
+	limit := config.MaxLogs
+	if query.Limit > 0 && query.Limit < limit {
+		limit = query.Limit
+	}
+	var cursor logsCursor
+	if len(query.Cursor) > 0 {
+		var err error
+		cursor, err = decodeLogsCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		cursor.block, err = api.resolveBlock(query.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		cursor.toBlock, err = api.resolveBlock(query.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if head := api.bc.CurrentBlock().Number.Uint64(); cursor.toBlock > head {
+		return nil, fmt.Errorf("block %v is beyond the head %v", cursor.toBlock, head)
+	}
+
+	page := &LogsPage{Logs: []*types.Log{}, FromBlock: hexutil.Uint64(cursor.block), ToBlock: hexutil.Uint64(cursor.toBlock)}
+	var scanned uint64
+	size := 0
+	for cursor.block <= cursor.toBlock {
+		if scanned >= config.MaxBlocks {
+			page.Cursor = cursor.encode()
+			page.Limit = LogsPageLimitBlocks
+			return page, nil
+		}
+		end := cursor.block + min(logsPageChunkBlocks, config.MaxBlocks-scanned) - 1
+		if end > cursor.toBlock || end < cursor.block {
+			end = cursor.toBlock
+		}
+		logs, err := api.filterLogs(ctx, cursor.block, end, &query)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			if log.BlockNumber == cursor.block && uint64(log.Index) < cursor.logIndex {
+				// Returned by the previous page
+				continue
+			}
+			next := logsCursor{block: log.BlockNumber, logIndex: uint64(log.Index), toBlock: cursor.toBlock}
+			if len(page.Logs) >= limit {
+				page.Cursor = next.encode()
+				page.Limit = LogsPageLimitLogs
+				return page, nil
+			}
+			if config.MaxBytes > 0 {
+				encoded, err := json.Marshal(log)
+				if err != nil {
+					return nil, err
+				}
+				size += len(encoded)
+				if size > config.MaxBytes && len(page.Logs) > 0 {
+					page.Cursor = next.encode()
+					page.Limit = LogsPageLimitBytes
+					return page, nil
+				}
+			}
+			page.Logs = append(page.Logs, log)
+		}
+		scanned += end - cursor.block + 1
+		cursor.block = end + 1
+		cursor.logIndex = 0
+		if end == cursor.toBlock {
+			break
+		}
+	}
+	return page, nil
+}
+
+// filterLogs returns the logs in a range of blocks matching the query. With the extended bloom index, only the
+// blocks it can't rule out are read, rather than those the header blooms can't.
+func (api *LogsPageAPI) filterLogs(ctx context.Context, from, to uint64, query *LogsPageQuery) ([]*types.Log, error) {
+	if api.bloom == nil {
+		// #nosec G115
+		return api.sys.NewRangeFilter(int64(from), int64(to), query.Addresses, query.Topics).Logs(ctx)
+	}
+	var logs []*types.Log
+	for number := from; number <= to; number++ {
+		hash := api.bc.GetCanonicalHash(number)
+		if hash == (common.Hash{}) {
+			return nil, fmt.Errorf("block %v not found", number)
+		}
+		mayMatch, err := api.bloom.mayMatch(number, hash, query.Addresses, query.Topics)
+		if err != nil {
+			log.Warn("failed to read extended bloom", "block", number, "err", err)
+		}
+		if !mayMatch {
+			continue
+		}
+		blockLogs, err := api.sys.NewBlockFilter(hash, query.Addresses, query.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, blockLogs...)
+	}
+	return logs, nil
+}
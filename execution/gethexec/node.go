@@ -25,8 +25,10 @@ import (
 	"github.com/offchainlabs/nitro/arbos/programs"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/execution/execrpc"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/dbutil"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	flag "github.com/spf13/pflag"
 )
@@ -85,19 +87,41 @@ func StylusTargetConfigAddOptions(prefix string, f *flag.FlagSet) {
 }
 
 type Config struct {
-	ParentChainReader         headerreader.Config `koanf:"parent-chain-reader" reload:"hot"`
-	Sequencer                 SequencerConfig     `koanf:"sequencer" reload:"hot"`
-	RecordingDatabase         BlockRecorderConfig `koanf:"recording-database"`
-	TxPreChecker              TxPreCheckerConfig  `koanf:"tx-pre-checker" reload:"hot"`
-	Forwarder                 ForwarderConfig     `koanf:"forwarder"`
-	ForwardingTarget          string              `koanf:"forwarding-target"`
-	SecondaryForwardingTarget []string            `koanf:"secondary-forwarding-target"`
-	Caching                   CachingConfig       `koanf:"caching"`
-	RPC                       arbitrum.Config     `koanf:"rpc"`
-	TxLookupLimit             uint64              `koanf:"tx-lookup-limit"`
-	EnablePrefetchBlock       bool                `koanf:"enable-prefetch-block"`
-	SyncMonitor               SyncMonitorConfig   `koanf:"sync-monitor"`
-	StylusTarget              StylusTargetConfig  `koanf:"stylus-target"`
+	ParentChainReader         headerreader.Config        `koanf:"parent-chain-reader" reload:"hot"`
+	Sequencer                 SequencerConfig            `koanf:"sequencer" reload:"hot"`
+	RecordingDatabase         BlockRecorderConfig        `koanf:"recording-database"`
+	TxPreChecker              TxPreCheckerConfig         `koanf:"tx-pre-checker" reload:"hot"`
+	Forwarder                 ForwarderConfig            `koanf:"forwarder"`
+	ForwardingTarget          string                     `koanf:"forwarding-target"`
+	SecondaryForwardingTarget []string                   `koanf:"secondary-forwarding-target"`
+	Caching                   CachingConfig              `koanf:"caching"`
+	RPC                       arbitrum.Config            `koanf:"rpc"`
+	TxLookupLimit             uint64                     `koanf:"tx-lookup-limit"`
+	EnablePrefetchBlock       bool                       `koanf:"enable-prefetch-block"`
+	SyncMonitor               SyncMonitorConfig          `koanf:"sync-monitor"`
+	StylusTarget              StylusTargetConfig         `koanf:"stylus-target"`
+	StateRecreation           StateRecreatorConfig       `koanf:"state-recreation"`
+	StateConversion           StateConversionConfig      `koanf:"state-conversion" reload:"hot"`
+	CallCache                 CallCacheConfig            `koanf:"call-cache"`
+	RPCGateway                RPCGatewayConfig           `koanf:"rpc-gateway"`
+	RPCSLO                    RPCSLOConfig               `koanf:"rpc-slo"`
+	LoadShedding              LoadSheddingConfig         `koanf:"load-shedding"`
+	LogsPage                  LogsPageConfig             `koanf:"logs-page" reload:"hot"`
+	FlightRecorder            flightrecorder.Config      `koanf:"flight-recorder"`
+	IPC                       execrpc.IPCConfig          `koanf:"ipc"`
+	RecordL1PricingSnapshots  bool                       `koanf:"record-l1-pricing-snapshots"`
+	RetryableIndex            RetryableIndexConfig       `koanf:"retryable-index" reload:"hot"`
+	IndexOutboxMerkleNodes    bool                       `koanf:"index-outbox-merkle-nodes"`
+	AddressActivityIndex      AddressActivityIndexConfig `koanf:"address-activity-index" reload:"hot"`
+	OwnerAuditLog             OwnerAuditLogConfig        `koanf:"owner-audit-log"`
+	AnalyticsExport           AnalyticsExportConfig      `koanf:"analytics-export"`
+	StylusVerifier            StylusVerifierConfig       `koanf:"stylus-verifier"`
+	WasmStoreGC               WasmStoreGCConfig          `koanf:"wasm-store-gc"`
+	ExtendedBloom             ExtendedBloomConfig        `koanf:"extended-bloom"`
+	FeeQuote                  FeeQuoteConfig             `koanf:"fee-quote" reload:"hot"`
+	HistoryServer             HistoryServerConfig        `koanf:"history-server" reload:"hot"`
+	StylusTracer              StylusTracerConfig         `koanf:"stylus-tracer" reload:"hot"`
+	RegionalIngress           RegionalIngressConfig      `koanf:"regional-ingress"`
 
 	forwardingTarget string
 }
@@ -109,6 +133,12 @@ func (c *Config) Validate() error {
 	if err := c.Sequencer.Validate(); err != nil {
 		return err
 	}
+	if c.Caching.StateRetention > 0 {
+		c.Caching.StateHistory = stateHistoryForRetention(c.Caching.StateRetention, c.Sequencer.MaxBlockSpeed)
+	}
+	if err := c.TxPreChecker.Validate(); err != nil {
+		return err
+	}
 	if !c.Sequencer.Enable && c.ForwardingTarget == "" {
 		return errors.New("ForwardingTarget not set and not sequencer (can use \"null\")")
 	}
@@ -123,6 +153,66 @@ func (c *Config) Validate() error {
 	if err := c.StylusTarget.Validate(); err != nil {
 		return err
 	}
+	if err := c.StateRecreation.Validate(); err != nil {
+		return err
+	}
+	if err := c.StateConversion.Validate(); err != nil {
+		return err
+	}
+	if c.StateConversion.ArchiveFrom > 0 && !c.Caching.Archive {
+		return errors.New("state-conversion archive-from requires caching.archive")
+	}
+	if err := c.CallCache.Validate(); err != nil {
+		return err
+	}
+	if err := c.RPCGateway.Validate(); err != nil {
+		return err
+	}
+	if err := c.RPCSLO.Validate(); err != nil {
+		return err
+	}
+	if err := c.LoadShedding.Validate(); err != nil {
+		return err
+	}
+	if err := c.LogsPage.Validate(); err != nil {
+		return err
+	}
+	if err := c.FlightRecorder.Validate(); err != nil {
+		return err
+	}
+	if err := c.AddressActivityIndex.Validate(); err != nil {
+		return err
+	}
+	if err := c.RetryableIndex.Validate(); err != nil {
+		return err
+	}
+	if err := c.OwnerAuditLog.Validate(); err != nil {
+		return err
+	}
+	if err := c.AnalyticsExport.Validate(); err != nil {
+		return err
+	}
+	if err := c.StylusVerifier.Validate(); err != nil {
+		return err
+	}
+	if err := c.WasmStoreGC.Validate(); err != nil {
+		return err
+	}
+	if err := c.ExtendedBloom.Validate(); err != nil {
+		return err
+	}
+	if err := c.FeeQuote.Validate(); err != nil {
+		return err
+	}
+	if err := c.HistoryServer.Validate(); err != nil {
+		return err
+	}
+	if err := c.StylusTracer.Validate(); err != nil {
+		return err
+	}
+	if err := c.RegionalIngress.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -140,6 +230,28 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".tx-lookup-limit", ConfigDefault.TxLookupLimit, "retain the ability to lookup transactions by hash for the past N blocks (0 = all blocks)")
 	f.Bool(prefix+".enable-prefetch-block", ConfigDefault.EnablePrefetchBlock, "enable prefetching of blocks")
 	StylusTargetConfigAddOptions(prefix+".stylus-target", f)
+	StateRecreatorConfigAddOptions(prefix+".state-recreation", f)
+	StateConversionConfigAddOptions(prefix+".state-conversion", f)
+	CallCacheConfigAddOptions(prefix+".call-cache", f)
+	RPCGatewayConfigAddOptions(prefix+".rpc-gateway", f)
+	RPCSLOConfigAddOptions(prefix+".rpc-slo", f)
+	LoadSheddingConfigAddOptions(prefix+".load-shedding", f)
+	LogsPageConfigAddOptions(prefix+".logs-page", f)
+	flightrecorder.ConfigAddOptions(prefix+".flight-recorder", f)
+	execrpc.IPCConfigAddOptions(prefix+".ipc", f, "execution")
+	f.Bool(prefix+".record-l1-pricing-snapshots", ConfigDefault.RecordL1PricingSnapshots, "record the l1 pricing model as each block left it, so the arb_l1PricingSnapshot RPC method can serve blocks whose state has been pruned")
+	RetryableIndexConfigAddOptions(prefix+".retryable-index", f)
+	AddressActivityIndexConfigAddOptions(prefix+".address-activity-index", f)
+	f.Bool(prefix+".index-outbox-merkle-nodes", ConfigDefault.IndexOutboxMerkleNodes, "index the outbox merkle tree's nodes as blocks are written, so NodeInterface.constructOutboxProof reads them directly instead of searching the chain's logs")
+	OwnerAuditLogConfigAddOptions(prefix+".owner-audit-log", f)
+	AnalyticsExportConfigAddOptions(prefix+".analytics-export", f)
+	StylusVerifierConfigAddOptions(prefix+".stylus-verifier", f)
+	WasmStoreGCConfigAddOptions(prefix+".wasm-store-gc", f)
+	ExtendedBloomConfigAddOptions(prefix+".extended-bloom", f)
+	FeeQuoteConfigAddOptions(prefix+".fee-quote", f)
+	HistoryServerConfigAddOptions(prefix+".history-server", f)
+	StylusTracerConfigAddOptions(prefix+".stylus-tracer", f)
+	RegionalIngressConfigAddOptions(prefix+".regional-ingress", f)
 }
 
 var ConfigDefault = Config{
@@ -155,6 +267,28 @@ var ConfigDefault = Config{
 	Forwarder:                 DefaultNodeForwarderConfig,
 	EnablePrefetchBlock:       true,
 	StylusTarget:              DefaultStylusTargetConfig,
+	StateRecreation:           DefaultStateRecreatorConfig,
+	StateConversion:           DefaultStateConversionConfig,
+	CallCache:                 DefaultCallCacheConfig,
+	RPCGateway:                DefaultRPCGatewayConfig,
+	RPCSLO:                    DefaultRPCSLOConfig,
+	LoadShedding:              DefaultLoadSheddingConfig,
+	LogsPage:                  DefaultLogsPageConfig,
+	FlightRecorder:            flightrecorder.DefaultConfig,
+	IPC:                       execrpc.DefaultIPCConfig,
+	RecordL1PricingSnapshots:  false,
+	RetryableIndex:            DefaultRetryableIndexConfig,
+	IndexOutboxMerkleNodes:    false,
+	AddressActivityIndex:      DefaultAddressActivityIndexConfig,
+	OwnerAuditLog:             DefaultOwnerAuditLogConfig,
+	AnalyticsExport:           DefaultAnalyticsExportConfig,
+	StylusVerifier:            DefaultStylusVerifierConfig,
+	WasmStoreGC:               DefaultWasmStoreGCConfig,
+	ExtendedBloom:             DefaultExtendedBloomConfig,
+	FeeQuote:                  DefaultFeeQuoteConfig,
+	HistoryServer:             DefaultHistoryServerConfig,
+	StylusTracer:              DefaultStylusTracerConfig,
+	RegionalIngress:           DefaultRegionalIngressConfig,
 }
 
 type ConfigFetcher func() *Config
@@ -172,6 +306,21 @@ type ExecutionNode struct {
 	SyncMonitor       *SyncMonitor
 	ParentChainReader *headerreader.HeaderReader
 	ClassicOutbox     *ClassicOutboxRetriever
+	CallCache         *CallCache     // nil unless enabled
+	RPCGateway        *RPCGateway    // nil unless enabled
+	RPCSLOTracker     *RPCSLOTracker // nil unless enabled
+	LoadShedder       *LoadShedder   // nil unless enabled
+	StateConverter    *StateConverter
+	FlightRecorder    *flightrecorder.Recorder // nil unless enabled
+	IPCServer         *execrpc.IPCServer       // nil unless enabled
+	RetryableIndex    *RetryableIndex          // nil unless enabled
+	OutboxMerkleIndex *OutboxMerkleIndex       // nil unless enabled
+	OwnerAuditLog     *OwnerAuditLog           // nil unless enabled
+	AnalyticsExporter *AnalyticsExporter       // nil unless enabled
+	StylusVerifier    *StylusVerifier          // nil unless enabled
+	WasmStoreGC       *WasmStoreGC             // nil unless enabled
+	USDPriceFeed      *USDPriceFeed            // nil unless enabled
+	RegionalIngress   *RegionalIngress         // nil unless enabled
 	started           atomic.Bool
 }
 
@@ -191,6 +340,66 @@ func CreateExecutionNode(
 	if config.Caching.DisableStylusCacheMetricsCollection {
 		execEngine.DisableStylusCacheMetricsCollection()
 	}
+	if config.Sequencer.Enable && config.Sequencer.AsyncBlockWrites {
+		execEngine.EnableAsyncBlockWrites()
+	}
+	var sequencingTimestamps *SequencingTimestamps
+	if config.Sequencer.Enable && config.Sequencer.RecordSequencingTimestamps {
+		sequencingTimestamps = NewSequencingTimestamps(chainDB)
+		execEngine.EnableSequencingTimestamps(sequencingTimestamps)
+	}
+	var inclusionReceipts *InclusionReceipts
+	if config.Sequencer.Enable && config.Sequencer.InclusionReceipts.Enable {
+		var receiptsErr error
+		inclusionReceipts, receiptsErr = NewInclusionReceipts(chainDB, l2BlockChain.Config().ChainID.Uint64(), &config.Sequencer.InclusionReceipts)
+		if receiptsErr != nil {
+			return nil, receiptsErr
+		}
+		execEngine.EnableInclusionReceipts(inclusionReceipts)
+	}
+	var l1PricingSnapshots *L1PricingSnapshots
+	if config.RecordL1PricingSnapshots {
+		l1PricingSnapshots = NewL1PricingSnapshots(chainDB)
+		execEngine.EnableL1PricingSnapshots(l1PricingSnapshots)
+	}
+	var retryableIndex *RetryableIndex
+	if config.RetryableIndex.Enable {
+		retryableIndex = NewRetryableIndex(chainDB, func() *RetryableIndexConfig { return &configFetcher().RetryableIndex })
+		execEngine.EnableRetryableIndex(retryableIndex)
+	}
+	var outboxMerkleIndex *OutboxMerkleIndex
+	if config.IndexOutboxMerkleNodes {
+		outboxMerkleIndex = NewOutboxMerkleIndex(chainDB)
+		execEngine.EnableOutboxMerkleIndex(outboxMerkleIndex)
+	}
+	var addressActivityIndex *AddressActivityIndex
+	if config.AddressActivityIndex.Enable {
+		addressActivityIndex = NewAddressActivityIndex(chainDB)
+		execEngine.EnableAddressActivityIndex(addressActivityIndex)
+	}
+	var extendedBloomIndex *ExtendedBloomIndex
+	if config.ExtendedBloom.Enable {
+		extendedBloomIndex = NewExtendedBloomIndex(chainDB, &config.ExtendedBloom)
+		execEngine.EnableExtendedBloomIndex(extendedBloomIndex)
+	}
+	var ownerAuditLog *OwnerAuditLog
+	if config.OwnerAuditLog.Enable {
+		file := config.OwnerAuditLog.File
+		if file == "" {
+			file = stack.ResolvePath("owner-audit.log")
+		}
+		ownerAuditLog = NewOwnerAuditLog(&config.OwnerAuditLog, file)
+		execEngine.EnableOwnerAuditLog(ownerAuditLog)
+	}
+	var analyticsExporter *AnalyticsExporter
+	if config.AnalyticsExport.Enable {
+		sink, err := NewAnalyticsSink(&config.AnalyticsExport, stack.ResolvePath("analytics"))
+		if err != nil {
+			return nil, err
+		}
+		batches := func() BatchPostingInfo { return execEngine.consensus }
+		analyticsExporter = NewAnalyticsExporter(&config.AnalyticsExport, chainDB, l2BlockChain, batches, sink)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +436,19 @@ func CreateExecutionNode(
 		}
 	}
 
+	var regionalIngress *RegionalIngress
+	if config.RegionalIngress.Enable {
+		forwarder, ok := txPublisher.(*TxForwarder)
+		if !ok {
+			return nil, errors.New("regional ingress requires forwarding to the sequencer over http or websocket, without the sequencer enabled")
+		}
+		regionalIngress, err = NewRegionalIngress(&config.RegionalIngress, l2BlockChain.Config().ChainID)
+		if err != nil {
+			return nil, err
+		}
+		forwarder.SetRegionalIngress(regionalIngress)
+	}
+
 	txprecheckConfigFetcher := func() *TxPreCheckerConfig { return &configFetcher().TxPreChecker }
 
 	txPublisher = NewTxPreChecker(txPublisher, l2BlockChain, txprecheckConfigFetcher)
@@ -268,11 +490,91 @@ func CreateExecutionNode(
 		Service:   NewArbAPI(txPublisher),
 		Public:    false,
 	}}
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewGasEstimationAPI(stack, l2BlockChain, sequencer, configFetcher),
+		Public:    false,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewCalldataFootprintAPI(l2BlockChain),
+		Public:    false,
+	})
+	setStylusTracerConfig(func() *StylusTracerConfig { return &configFetcher().StylusTracer })
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewLogsPageAPI(l2BlockChain, filterSystem, extendedBloomIndex, func() *LogsPageConfig { return &configFetcher().LogsPage }),
+		Public:    false,
+	})
+	if sequencingTimestamps != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewSequencingTimestampsAPI(l2BlockChain, sequencingTimestamps),
+			Public:    false,
+		})
+	}
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewL1PricingSnapshotAPI(l2BlockChain, l1PricingSnapshots),
+		Public:    false,
+	})
+	if retryableIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewRetryableIndexAPI(l2BlockChain, retryableIndex),
+			Public:    false,
+		})
+	}
+	var stylusVerifier *StylusVerifier
+	if config.StylusVerifier.Enable {
+		stylusVerifier = NewStylusVerifier(&config.StylusVerifier, l2BlockChain, chainDB)
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewStylusVerifierAPI(stylusVerifier),
+			Public:    false,
+		})
+	}
+	var usdPriceFeed *USDPriceFeed
+	if config.FeeQuote.Enable {
+		usdPriceFeed = NewUSDPriceFeed(func() *FeeQuoteConfig { return &configFetcher().FeeQuote })
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewFeeQuoteAPI(stack, usdPriceFeed),
+			Public:    false,
+		})
+	}
+	if config.HistoryServer.Enable {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewHistoryServerAPI(NewHistoryServer(chainDB, l2BlockChain.Config(), func() *HistoryServerConfig { return &configFetcher().HistoryServer })),
+			Public:    false,
+		})
+	}
+	if addressActivityIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   NewAddressActivityAPI(l2BlockChain, addressActivityIndex, func() *AddressActivityIndexConfig { return &configFetcher().AddressActivityIndex }),
+			Public:    false,
+		})
+	}
+	stateConverter := NewStateConverter(l2BlockChain, chainDB, &config.Caching, func() *StateConversionConfig { return &configFetcher().StateConversion })
 	apis = append(apis, rpc.API{
 		Namespace: "arbdebug",
 		Version:   "1.0",
 		Service: NewArbDebugAPI(
 			l2BlockChain,
+			NewStateRecreator(l2BlockChain, &config.StateRecreation),
+			stateConverter,
 			config.RPC.ArbDebug.BlockRangeBound,
 			config.RPC.ArbDebug.TimeoutQueueBound,
 		),
@@ -287,15 +589,66 @@ func CreateExecutionNode(
 		),
 		Public: false,
 	})
+	if sequencer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "sequencer",
+			Version:   "1.0",
+			Service:   &SequencerFreezeAPI{execEngine: execEngine},
+			Public:    false,
+		})
+		if sequencer.fairOrdering != nil {
+			apis = append(apis, rpc.API{
+				Namespace: "arb",
+				Version:   "1.0",
+				Service:   NewFairOrderingAPI(sequencer.fairOrdering),
+				Public:    false,
+			})
+		}
+		if sequencer.expressLane != nil {
+			apis = append(apis, rpc.API{
+				Namespace: "timeboost",
+				Version:   "1.0",
+				Service:   NewExpressLaneAPI(sequencer),
+				Public:    false,
+			})
+		}
+		if sequencer.regionalArrivals != nil {
+			apis = append(apis, rpc.API{
+				Namespace: "arb",
+				Version:   "1.0",
+				Service:   NewRegionalArrivalsAPI(sequencer),
+				Public:    false,
+			})
+		}
+		if inclusionReceipts != nil {
+			apis = append(apis, rpc.API{
+				Namespace: "arb",
+				Version:   "1.0",
+				Service:   NewInclusionReceiptsAPI(txPublisher, inclusionReceipts),
+				Public:    false,
+			})
+		}
+		if config.Sequencer.BlockBuilder.Enable {
+			apis = append(apis, rpc.API{
+				Namespace: "builder",
+				Version:   "1.0",
+				Service:   NewBlockBuilderAPI(sequencer),
+				Public:    false,
+			})
+		}
+	}
 	apis = append(apis, rpc.API{
 		Namespace: "debug",
 		Service:   eth.NewDebugAPI(eth.NewArbEthereum(l2BlockChain, chainDB)),
 		Public:    false,
 	})
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Service:   NewStylusProfileAPI(stack),
+		Public:    false,
+	})
 
-	stack.RegisterAPIs(apis)
-
-	return &ExecutionNode{
+	execNode := &ExecutionNode{
 		ChainDB:           chainDB,
 		Backend:           backend,
 		FilterSystem:      filterSystem,
@@ -308,7 +661,78 @@ func CreateExecutionNode(
 		SyncMonitor:       syncMon,
 		ParentChainReader: parentChainReader,
 		ClassicOutbox:     classicOutbox,
-	}, nil
+		StateConverter:    stateConverter,
+		RetryableIndex:    retryableIndex,
+		OutboxMerkleIndex: outboxMerkleIndex,
+		OwnerAuditLog:     ownerAuditLog,
+		AnalyticsExporter: analyticsExporter,
+		StylusVerifier:    stylusVerifier,
+		USDPriceFeed:      usdPriceFeed,
+		RegionalIngress:   regionalIngress,
+	}
+	if config.WasmStoreGC.Enable {
+		execNode.WasmStoreGC = NewWasmStoreGC(&config.WasmStoreGC, l2BlockChain)
+	}
+	if config.CallCache.Enable {
+		execNode.CallCache = NewCallCache(l2BlockChain, &config.CallCache)
+	}
+	if config.RPCGateway.Enable {
+		execNode.RPCGateway, err = NewRPCGateway(&config.RPCGateway)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.RPCSLO.Enable {
+		execNode.RPCSLOTracker, err = NewRPCSLOTracker(&config.RPCSLO)
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, rpc.API{
+			Namespace: "arbdebug",
+			Version:   "1.0",
+			Service:   NewRPCSLOAPI(execNode.RPCSLOTracker),
+			Public:    false,
+		})
+	}
+	if config.LoadShedding.Enable {
+		execNode.LoadShedder = NewLoadShedder(&config.LoadShedding, processLoadSheddingSignals{
+			headBlockNumber: func() uint64 { return l2BlockChain.CurrentBlock().Number.Uint64() },
+		})
+		if sequencer != nil {
+			sequencer.loadShedder = execNode.LoadShedder
+		}
+	}
+	if config.FlightRecorder.Enable {
+		recorderConfig := config.FlightRecorder
+		if recorderConfig.Dir == "" {
+			recorderConfig.Dir = stack.ResolvePath("flight-recorder")
+		}
+		execNode.FlightRecorder = flightrecorder.NewRecorder(&recorderConfig)
+		flightrecorder.SetGlobal(execNode.FlightRecorder)
+		apis = append(apis, rpc.API{
+			Namespace: "arbdebug",
+			Version:   "1.0",
+			Service:   flightrecorder.NewAPI(execNode.FlightRecorder),
+			Public:    false,
+		})
+	}
+
+	apis = append(apis, rpc.API{
+		Namespace: execrpc.ExecutionNamespace,
+		Version:   "1.0",
+		Service:   execrpc.NewExecutionServerAPI(execNode),
+		Public:    false,
+	})
+	if config.IPC.Path != "" {
+		execNode.IPCServer, err = execrpc.NewIPCServer(config.IPC.Path, execrpc.ExecutionNamespace, execrpc.NewExecutionServerAPI(execNode))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stack.RegisterAPIs(apis)
+
+	return execNode, nil
 
 }
 
@@ -356,6 +780,40 @@ func (n *ExecutionNode) Start(ctx context.Context) error {
 	if n.ParentChainReader != nil {
 		n.ParentChainReader.Start(ctx)
 	}
+	if err := n.StateConverter.Start(ctx); err != nil {
+		return fmt.Errorf("error starting state converter: %w", err)
+	}
+	if n.IPCServer != nil {
+		if err := n.IPCServer.Start(ctx); err != nil {
+			return fmt.Errorf("error starting execution ipc server: %w", err)
+		}
+	}
+	if n.RetryableIndex != nil {
+		n.RetryableIndex.Start(ctx)
+	}
+	if n.OwnerAuditLog != nil {
+		if err := n.OwnerAuditLog.Start(ctx); err != nil {
+			return fmt.Errorf("error starting owner audit log: %w", err)
+		}
+	}
+	if n.AnalyticsExporter != nil {
+		n.AnalyticsExporter.Start(ctx)
+	}
+	if n.StylusVerifier != nil {
+		n.StylusVerifier.Start(ctx)
+	}
+	if n.WasmStoreGC != nil {
+		n.WasmStoreGC.Start(ctx)
+	}
+	if n.USDPriceFeed != nil {
+		n.USDPriceFeed.Start(ctx)
+	}
+	if n.RegionalIngress != nil {
+		n.RegionalIngress.Start(ctx)
+	}
+	if n.LoadShedder != nil {
+		n.LoadShedder.Start(ctx)
+	}
 	return nil
 }
 
@@ -365,6 +823,36 @@ func (n *ExecutionNode) StopAndWait() {
 	}
 	// TODO after separation
 	// n.Stack.StopRPC() // does nothing if not running
+	if n.IPCServer != nil {
+		n.IPCServer.StopAndWait()
+	}
+	if n.StateConverter.Started() {
+		n.StateConverter.StopAndWait()
+	}
+	if n.RetryableIndex != nil && n.RetryableIndex.Started() {
+		n.RetryableIndex.StopAndWait()
+	}
+	if n.OwnerAuditLog != nil && n.OwnerAuditLog.Started() {
+		n.OwnerAuditLog.StopAndWait()
+	}
+	if n.AnalyticsExporter != nil && n.AnalyticsExporter.Started() {
+		n.AnalyticsExporter.StopAndWait()
+	}
+	if n.StylusVerifier != nil && n.StylusVerifier.Started() {
+		n.StylusVerifier.StopAndWait()
+	}
+	if n.WasmStoreGC != nil && n.WasmStoreGC.Started() {
+		n.WasmStoreGC.StopAndWait()
+	}
+	if n.USDPriceFeed != nil && n.USDPriceFeed.Started() {
+		n.USDPriceFeed.StopAndWait()
+	}
+	if n.RegionalIngress != nil && n.RegionalIngress.Started() {
+		n.RegionalIngress.StopAndWait()
+	}
+	if n.LoadShedder != nil && n.LoadShedder.Started() {
+		n.LoadShedder.StopAndWait()
+	}
 	if n.TxPublisher.Started() {
 		n.TxPublisher.StopAndWait()
 	}
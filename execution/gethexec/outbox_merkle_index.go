@@ -0,0 +1,84 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+var outboxMerkleNodePrefix = []byte("arbitrum-outbox-merkle-node-")
+
+// The ArbSys events carrying a node of the outbox merkle tree, with its hash in the second topic and
+// its position in the third.
+var outboxMerkleNodeTopics = make(map[common.Hash]struct{})
+
+func init() {
+	arbSys, err := precompilesgen.ArbSysMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	// L2ToL1Transaction was replaced by L2ToL1Tx in ArbOS 4, but old chains still have it
+	for _, name := range []string{"L2ToL1Tx", "L2ToL1Transaction", "SendMerkleUpdate"} {
+		outboxMerkleNodeTopics[arbSys.Events[name].ID] = struct{}{}
+	}
+}
+
+func outboxMerkleNodeKey(position common.Hash) []byte {
+	return append(append([]byte{}, outboxMerkleNodePrefix...), position.Bytes()...)
+}
+
+// OutboxMerkleIndex records every node of the outbox merkle tree by its position as blocks are written,
+// so building an outbox proof takes one read per level of the tree instead of searching the chain's logs.
+// A send rewrites every node it completes, so the index follows reorgs once their blocks are written.
+type OutboxMerkleIndex struct {
+	db ethdb.KeyValueStore
+}
+
+func NewOutboxMerkleIndex(db ethdb.KeyValueStore) *OutboxMerkleIndex {
+	return &OutboxMerkleIndex{db: db}
+}
+
+// indexBlock records the merkle nodes added by a block's sends.
+func (i *OutboxMerkleIndex) indexBlock(receipts types.Receipts) error {
+	var batch ethdb.Batch
+	for _, receipt := range receipts {
+		for _, txLog := range receipt.Logs {
+			if txLog.Address != types.ArbSysAddress || len(txLog.Topics) < 4 {
+				continue
+			}
+			if _, ok := outboxMerkleNodeTopics[txLog.Topics[0]]; !ok {
+				continue
+			}
+			if batch == nil {
+				batch = i.db.NewBatch()
+			}
+			if err := batch.Put(outboxMerkleNodeKey(txLog.Topics[3]), txLog.Topics[2].Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	if batch == nil {
+		return nil
+	}
+	return batch.Write()
+}
+
+// Get returns the hash at a position of the tree as its event reported it, which for a leaf is the send hash.
+// The second result is false if the position isn't indexed.
+func (i *OutboxMerkleIndex) Get(position common.Hash) (common.Hash, bool, error) {
+	key := outboxMerkleNodeKey(position)
+	has, err := i.db.Has(key)
+	if err != nil || !has {
+		return common.Hash{}, false, err
+	}
+	data, err := i.db.Get(key)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return common.BytesToHash(data), true, nil
+}
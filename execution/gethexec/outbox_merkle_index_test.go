@@ -0,0 +1,56 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/merkletree"
+)
+
+func TestOutboxMerkleIndex(t *testing.T) {
+	arbSys, err := precompilesgen.ArbSysMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdrawTopic := arbSys.Events["L2ToL1Tx"].ID
+	merkleTopic := arbSys.Events["SendMerkleUpdate"].ID
+
+	index := NewOutboxMerkleIndex(rawdb.NewMemoryDatabase())
+	leaf := common.BigToHash(merkletree.NewLevelAndLeaf(0, 1).ToBigInt())
+	node := common.BigToHash(merkletree.NewLevelAndLeaf(1, 1).ToBigInt())
+	unrelated := common.BigToHash(merkletree.NewLevelAndLeaf(0, 2).ToBigInt())
+	receipts := types.Receipts{
+		{Logs: []*types.Log{
+			{Address: types.ArbSysAddress, Topics: []common.Hash{withdrawTopic, {}, common.HexToHash("0x01"), leaf}},
+			{Address: types.ArbSysAddress, Topics: []common.Hash{merkleTopic, {}, common.HexToHash("0x02"), node}},
+			// Not from ArbSys
+			{Address: common.HexToAddress("0x1234"), Topics: []common.Hash{merkleTopic, {}, common.HexToHash("0x03"), unrelated}},
+		}},
+	}
+	if err := index.indexBlock(receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	for position, expected := range map[common.Hash]common.Hash{leaf: common.HexToHash("0x01"), node: common.HexToHash("0x02")} {
+		hash, ok, err := index.Get(position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("position wasn't indexed", position)
+		}
+		if hash != expected {
+			t.Fatal("wrong hash", hash, "expected", expected)
+		}
+	}
+	if _, ok, err := index.Get(unrelated); err != nil || ok {
+		t.Fatal("indexed a node from another contract", err)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	ownerAuditEntriesCounter        = metrics.NewRegisteredCounter("arb/owneraudit/entries", nil)
+	ownerAuditDroppedCounter        = metrics.NewRegisteredCounter("arb/owneraudit/dropped", nil)
+	ownerAuditWebhookFailureCounter = metrics.NewRegisteredCounter("arb/owneraudit/webhook/failures", nil)
+)
+
+type OwnerAuditLogConfig struct {
+	Enable         bool          `koanf:"enable"`
+	File           string        `koanf:"file"`
+	WebhookURL     string        `koanf:"webhook-url"`
+	WebhookTimeout time.Duration `koanf:"webhook-timeout"`
+	QueueSize      int           `koanf:"queue-size"`
+}
+
+var DefaultOwnerAuditLogConfig = OwnerAuditLogConfig{
+	Enable:         false,
+	File:           "",
+	WebhookURL:     "",
+	WebhookTimeout: 5 * time.Second,
+	QueueSize:      1024,
+}
+
+func OwnerAuditLogConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultOwnerAuditLogConfig.Enable, "log every ArbOwner and ArbDebug call executed on-chain to an append-only audit log")
+	f.String(prefix+".file", DefaultOwnerAuditLogConfig.File, "file the audit log is appended to, one JSON entry per line (defaults to owner-audit.log in the node's data directory)")
+	f.String(prefix+".webhook-url", DefaultOwnerAuditLogConfig.WebhookURL, "URL each audit log entry is also POSTed to as JSON (empty = disabled)")
+	f.Duration(prefix+".webhook-timeout", DefaultOwnerAuditLogConfig.WebhookTimeout, "timeout for posting an entry to the webhook")
+	f.Int(prefix+".queue-size", DefaultOwnerAuditLogConfig.QueueSize, "how many entries can wait to be written before new ones are dropped")
+}
+
+func (c *OwnerAuditLogConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.WebhookURL != "" && c.WebhookTimeout <= 0 {
+		return errors.New("owner audit log webhook-timeout must be positive")
+	}
+	if c.QueueSize < 1 {
+		return errors.New("owner audit log queue-size must be positive")
+	}
+	return nil
+}
+
+// OwnerAuditEntry is an owner precompile call that succeeded on-chain.
+type OwnerAuditEntry struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	// Entries from blocks that were since reorged out aren't removed, so check the block hash is canonical
+	BlockHash  common.Hash            `json:"blockHash"`
+	Timestamp  hexutil.Uint64         `json:"timestamp"`
+	TxHash     common.Hash            `json:"txHash"`
+	Precompile common.Address         `json:"precompile"`
+	Caller     common.Address         `json:"caller"`
+	Method     string                 `json:"method"`
+	Selector   hexutil.Bytes          `json:"selector"`
+	Args       map[string]interface{} `json:"args,omitempty"`
+	// The raw calldata when it couldn't be decoded
+	Data hexutil.Bytes `json:"data,omitempty"`
+}
+
+var ownerActsTopic common.Hash
+var ownerActsInputs abi.Arguments
+var arbOwnerAbi, arbDebugAbi *abi.ABI
+
+func init() {
+	var err error
+	arbOwnerAbi, err = precompilesgen.ArbOwnerMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	arbDebugAbi, err = precompilesgen.ArbDebugMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	ownerActs := arbOwnerAbi.Events["OwnerActs"]
+	ownerActsTopic = ownerActs.ID
+	ownerActsInputs = ownerActs.Inputs.NonIndexed()
+}
+
+// OwnerAuditLog watches written blocks for calls to the owner precompiles, and appends them to a file and
+// optionally posts them to a webhook. ArbOwner calls are found by the OwnerActs events they emit, however
+// they were made. ArbDebug doesn't emit events, so only transactions calling it directly are found.
+type OwnerAuditLog struct {
+	stopwaiter.StopWaiter
+	config *OwnerAuditLogConfig
+	file   string
+	queue  chan *OwnerAuditEntry
+	client *http.Client
+}
+
+func NewOwnerAuditLog(config *OwnerAuditLogConfig, file string) *OwnerAuditLog {
+	return &OwnerAuditLog{
+		config: config,
+		file:   file,
+		queue:  make(chan *OwnerAuditEntry, config.QueueSize),
+		client: &http.Client{},
+	}
+}
+
+func (a *OwnerAuditLog) Start(ctx context.Context) error {
+	file, err := os.OpenFile(a.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	a.StopWaiter.Start(ctx, a)
+	a.LaunchThread(func(ctx context.Context) {
+		defer file.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-a.queue:
+				a.write(ctx, file, entry)
+			}
+		}
+	})
+	return nil
+}
+
+func (a *OwnerAuditLog) write(ctx context.Context, file *os.File, entry *OwnerAuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Error("failed to encode owner audit log entry", "tx", entry.TxHash, "err", err)
+		return
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		log.Error("failed to write owner audit log entry", "tx", entry.TxHash, "method", entry.Method, "err", err)
+	}
+	if a.config.WebhookURL != "" {
+		if err := a.post(ctx, data); err != nil {
+			ownerAuditWebhookFailureCounter.Inc(1)
+			log.Warn("failed to post owner audit log entry", "tx", entry.TxHash, "method", entry.Method, "err", err)
+		}
+	}
+}
+
+func (a *OwnerAuditLog) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, a.config.WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// observeBlock queues the owner precompile calls in a block. It doesn't wait on the log, dropping entries
+// if it falls behind rather than holding up block production.
+func (a *OwnerAuditLog) observeBlock(block *types.Block, receipts types.Receipts) {
+	for _, entry := range ownerAuditEntries(block, receipts) {
+		ownerAuditEntriesCounter.Inc(1)
+		log.Info("owner precompile called", "block", block.NumberU64(), "tx", entry.TxHash, "precompile", entry.Precompile, "caller", entry.Caller, "method", entry.Method)
+		select {
+		case a.queue <- entry:
+		default:
+			ownerAuditDroppedCounter.Inc(1)
+			log.Error("owner audit log is behind, dropped entry", "tx", entry.TxHash, "method", entry.Method)
+		}
+	}
+}
+
+func ownerAuditEntries(block *types.Block, receipts types.Receipts) []*OwnerAuditEntry {
+	var entries []*OwnerAuditEntry
+	newEntry := func(tx *types.Transaction, precompile, caller common.Address, contract *abi.ABI, calldata []byte) *OwnerAuditEntry {
+		entry := &OwnerAuditEntry{
+			BlockNumber: hexutil.Uint64(block.NumberU64()),
+			BlockHash:   block.Hash(),
+			Timestamp:   hexutil.Uint64(block.Time()),
+			TxHash:      tx.Hash(),
+			Precompile:  precompile,
+			Caller:      caller,
+		}
+		decodeOwnerCall(entry, contract, calldata)
+		return entry
+	}
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		receipt := receipts[i]
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		if to := tx.To(); to != nil && *to == types.ArbDebugAddress {
+			caller, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+			if err != nil {
+				log.Warn("failed to recover ArbDebug caller", "tx", tx.Hash(), "err", err)
+			}
+			entries = append(entries, newEntry(tx, types.ArbDebugAddress, caller, arbDebugAbi, tx.Data()))
+		}
+		for _, txLog := range receipt.Logs {
+			if txLog.Address != types.ArbOwnerAddress || len(txLog.Topics) < 3 || txLog.Topics[0] != ownerActsTopic {
+				continue
+			}
+			var calldata []byte
+			if values, err := ownerActsInputs.Unpack(txLog.Data); err == nil && len(values) == 1 {
+				calldata, _ = values[0].([]byte)
+			}
+			entry := newEntry(tx, types.ArbOwnerAddress, common.BytesToAddress(txLog.Topics[2].Bytes()), arbOwnerAbi, calldata)
+			if len(entry.Selector) == 0 {
+				entry.Selector = txLog.Topics[1].Bytes()[:4]
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func decodeOwnerCall(entry *OwnerAuditEntry, contract *abi.ABI, calldata []byte) {
+	if len(calldata) < 4 {
+		entry.Data = calldata
+		return
+	}
+	entry.Selector = calldata[:4]
+	method, err := contract.MethodById(calldata[:4])
+	if err != nil {
+		entry.Data = calldata
+		return
+	}
+	entry.Method = method.Name
+	args := make(map[string]interface{})
+	if err := method.Inputs.UnpackIntoMap(args, calldata[4:]); err != nil {
+		entry.Data = calldata
+		return
+	}
+	if len(args) > 0 {
+		entry.Args = args
+	}
+}
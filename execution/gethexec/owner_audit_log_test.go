@@ -0,0 +1,122 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestOwnerAuditEntries(t *testing.T) {
+	owner := common.HexToAddress("0xaa")
+	calldata, err := arbOwnerAbi.Pack("setL2BaseFee", big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logData, err := ownerActsInputs.Pack(calldata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var selector common.Hash
+	copy(selector[:], calldata[:4])
+	ownerTx := types.NewTx(&types.LegacyTx{To: &types.ArbOwnerAddress, Data: calldata})
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugCalldata, err := arbDebugAbi.Pack("becomeChainOwner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugTx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(1),
+		To:      &types.ArbDebugAddress,
+		Data:    debugCalldata,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failedTx := types.NewTx(&types.LegacyTx{To: &types.ArbDebugAddress, Data: debugCalldata, Nonce: 1})
+
+	header := &types.Header{Number: big.NewInt(3), Time: 100}
+	block := types.NewBlock(header, types.Transactions{ownerTx, debugTx, failedTx}, nil, nil, trie.NewStackTrie(nil))
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{
+			Address: types.ArbOwnerAddress,
+			Topics:  []common.Hash{ownerActsTopic, selector, common.BytesToHash(owner.Bytes())},
+			Data:    logData,
+		}}},
+		{Status: types.ReceiptStatusSuccessful},
+		{Status: types.ReceiptStatusFailed},
+	}
+
+	entries := ownerAuditEntries(block, receipts)
+	if len(entries) != 2 {
+		t.Fatal("expected 2 entries but got", len(entries))
+	}
+	entry := entries[0]
+	if entry.Precompile != types.ArbOwnerAddress || entry.Caller != owner || entry.Method != "setL2BaseFee" {
+		t.Fatal("wrong ArbOwner entry", entry.Precompile, entry.Caller, entry.Method)
+	}
+	if !bytes.Equal(entry.Selector, calldata[:4]) {
+		t.Fatal("wrong selector", entry.Selector)
+	}
+	if len(entry.Args) != 1 {
+		t.Fatal("expected the call's argument to be decoded but got", entry.Args)
+	}
+	for _, value := range entry.Args {
+		if value.(*big.Int).Int64() != 7 {
+			t.Fatal("wrong argument", value)
+		}
+	}
+	entry = entries[1]
+	if entry.Precompile != types.ArbDebugAddress || entry.Caller != crypto.PubkeyToAddress(key.PublicKey) || entry.Method != "becomeChainOwner" {
+		t.Fatal("wrong ArbDebug entry", entry.Precompile, entry.Caller, entry.Method)
+	}
+	if entry.BlockNumber != 3 || entry.TxHash != debugTx.Hash() {
+		t.Fatal("wrong ArbDebug entry location", entry.BlockNumber, entry.TxHash)
+	}
+}
+
+func TestOwnerAuditLogWrite(t *testing.T) {
+	config := DefaultOwnerAuditLogConfig
+	path := filepath.Join(t.TempDir(), "owner-audit.log")
+	auditLog := NewOwnerAuditLog(&config, path)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, method := range []string{"setL2BaseFee", "addChainOwner"} {
+		auditLog.write(context.Background(), file, &OwnerAuditEntry{Method: method})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatal("expected 2 lines but got", len(lines))
+	}
+	var entry OwnerAuditEntry
+	if err := json.Unmarshal(lines[1], &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Method != "addChainOwner" {
+		t.Fatal("entries weren't appended in order, got", entry.Method)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+
+	"github.com/offchainlabs/nitro/precompiles"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("precompileRefundTracer", newPrecompileRefundTracer, false)
+}
+
+// PrecompileRefund is gas an ArbOS precompile returned to its caller by reverting before burning
+// everything the call was given.
+type PrecompileRefund struct {
+	Precompile  common.Address `json:"precompile"`
+	Caller      common.Address `json:"caller"`
+	Method      hexutil.Bytes  `json:"method"`
+	Depth       int            `json:"depth"`
+	GasSupplied hexutil.Uint64 `json:"gasSupplied"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	GasRefunded hexutil.Uint64 `json:"gasRefunded"`
+}
+
+// PrecompileRefunds is the result of precompileRefundTracer.
+type PrecompileRefunds struct {
+	// The address unused gas is refunded to at the end of the transaction, which for retryable
+	// redeems is the ticket's refund address rather than the sender
+	RefundTo      common.Address     `json:"refundTo"`
+	Refunds       []PrecompileRefund `json:"refunds"`
+	TotalRefunded hexutil.Uint64     `json:"totalRefunded"`
+}
+
+type precompileRefundCall struct {
+	refund     PrecompileRefund
+	precompile bool
+}
+
+// precompileRefundTracer attributes the gas refunded by reverting precompile calls, so gas accounting
+// tools can reconcile a receipt's gas used with what each precompile burned.
+type precompileRefundTracer struct {
+	precompiles map[common.Address]bool
+	open        *containers.Stack[precompileRefundCall]
+	result      PrecompileRefunds
+	interrupt   atomic.Bool
+	reason      error
+}
+
+func newPrecompileRefundTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := &precompileRefundTracer{
+		precompiles: make(map[common.Address]bool),
+		open:        containers.NewStack[precompileRefundCall](),
+		result:      PrecompileRefunds{Refunds: []PrecompileRefund{}},
+	}
+	for addr := range precompiles.Precompiles() {
+		t.precompiles[addr] = true
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *precompileRefundTracer) OnTxStart(_ *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.result.RefundTo = from
+	if retry, ok := tx.GetInner().(*types.ArbitrumRetryTx); ok {
+		t.result.RefundTo = retry.RefundTo
+	}
+}
+
+func (t *precompileRefundTracer) OnEnter(depth int, _ byte, from common.Address, to common.Address, input []byte, gas uint64, _ *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	call := precompileRefundCall{precompile: t.precompiles[to]}
+	if call.precompile {
+		call.refund = PrecompileRefund{
+			Precompile:  to,
+			Caller:      from,
+			Depth:       depth,
+			GasSupplied: hexutil.Uint64(gas),
+		}
+		if len(input) >= 4 {
+			call.refund.Method = common.CopyBytes(input[:4])
+		}
+	}
+	t.open.Push(call)
+}
+
+func (t *precompileRefundTracer) OnExit(_ int, _ []byte, gasUsed uint64, _ error, reverted bool) {
+	if t.interrupt.Load() {
+		return
+	}
+	call, err := t.open.Pop()
+	if err != nil {
+		t.Stop(err)
+		return
+	}
+	// Geth zeroes the gas left by calls that fail other than by reverting, so only reverts refund
+	if !call.precompile || !reverted || gasUsed >= uint64(call.refund.GasSupplied) {
+		return
+	}
+	call.refund.GasUsed = hexutil.Uint64(gasUsed)
+	call.refund.GasRefunded = call.refund.GasSupplied - call.refund.GasUsed
+	t.result.Refunds = append(t.result.Refunds, call.refund)
+	t.result.TotalRefunded += call.refund.GasRefunded
+}
+
+func (t *precompileRefundTracer) GetResult() (json.RawMessage, error) {
+	if t.reason != nil {
+		return nil, t.reason
+	}
+	return json.Marshal(t.result)
+}
+
+func (t *precompileRefundTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
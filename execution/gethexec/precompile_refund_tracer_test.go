@@ -0,0 +1,61 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestPrecompileRefundTracer(t *testing.T) {
+	tracer, err := newPrecompileRefundTracer(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := common.HexToAddress("0x1111")
+	refundTo := common.HexToAddress("0x2222")
+	contract := common.HexToAddress("0x3333")
+	hooks := tracer.Hooks
+
+	tx := types.NewTx(&types.ArbitrumRetryTx{RefundTo: refundTo})
+	hooks.OnTxStart(nil, tx, sender)
+	hooks.OnEnter(0, 0xf1, sender, contract, nil, 100000, nil)
+	// A reverting precompile call returns its unused gas
+	hooks.OnEnter(1, 0xf1, contract, types.ArbSysAddress, []byte{1, 2, 3, 4, 5}, 10000, nil)
+	hooks.OnExit(1, nil, 2500, nil, true)
+	// Successful precompile calls and reverting contract calls don't refund
+	hooks.OnEnter(1, 0xf1, contract, types.ArbGasInfoAddress, []byte{1, 2, 3, 4}, 10000, nil)
+	hooks.OnExit(1, nil, 1000, nil, false)
+	hooks.OnEnter(1, 0xf1, contract, common.HexToAddress("0x4444"), nil, 10000, nil)
+	hooks.OnExit(1, nil, 1000, nil, true)
+	// Precompile calls failing other than by reverting use all their gas
+	hooks.OnEnter(1, 0xf1, contract, types.ArbRetryableTxAddress, nil, 10000, nil)
+	hooks.OnExit(1, nil, 10000, nil, true)
+	hooks.OnExit(0, nil, 50000, nil, false)
+
+	data, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result PrecompileRefunds
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.RefundTo != refundTo || result.TotalRefunded != 7500 || len(result.Refunds) != 1 {
+		t.Fatal("unexpected refunds", string(data))
+	}
+	refund := result.Refunds[0]
+	if refund.Precompile != types.ArbSysAddress || refund.Caller != contract || refund.Depth != 1 || refund.GasUsed != 2500 || len(refund.Method) != 4 {
+		t.Fatal("unexpected refund", refund)
+	}
+
+	// Unbalanced exits stop the tracer rather than misattributing gas
+	hooks.OnExit(0, nil, 0, nil, false)
+	if _, err := tracer.GetResult(); err == nil {
+		t.Fatal("expected an error after an unbalanced exit")
+	}
+}
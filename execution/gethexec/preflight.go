@@ -0,0 +1,187 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/execution/txscreener"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+const maxPreflightIntents = 1024
+
+// PreflightIntent is a transaction a sender intends to submit, as far as the sequencer's admission rules care.
+type PreflightIntent struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Value                *hexutil.Big    `json:"value,omitempty"`
+	Gas                  *hexutil.Uint64 `json:"gas,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Data                 *hexutil.Bytes  `json:"data,omitempty"`
+}
+
+type PreflightResult struct {
+	// Whether the sequencer would accept the intent, given the intents before it in the request were accepted too
+	Accepted      bool              `json:"accepted"`
+	Failures      []EstimateFailure `json:"failures,omitempty"`
+	ExpectedNonce hexutil.Uint64    `json:"expectedNonce"`
+	// The gas the intent is checked with, which defaults to its intrinsic gas plus its L1 poster gas
+	Gas     hexutil.Uint64 `json:"gas"`
+	L1Fee   *hexutil.Big   `json:"l1Fee"`
+	Cost    *hexutil.Big   `json:"cost"`
+	Balance *hexutil.Big   `json:"balance"`
+}
+
+func (r *PreflightResult) fail(reason string, err error) {
+	r.Failures = append(r.Failures, EstimateFailure{Reason: reason, Message: err.Error()})
+}
+
+// preflightAccount is a sender's nonce and balance once the intents accepted so far are applied.
+type preflightAccount struct {
+	nonce   uint64
+	balance *big.Int
+}
+
+// PreflightIntents reports, for each intent in order, whether the sequencer would accept it against the latest
+// state and every reason it wouldn't. Accepted intents advance their sender's nonce and spend its balance, so a
+// batch of consecutive nonces from one sender is checked the way the sequencer would see it.
+// Intents aren't executed, so failures that depend on execution aren't reported.
+func (api *GasEstimationAPI) PreflightIntents(ctx context.Context, intents []PreflightIntent) ([]PreflightResult, error) {
+	if len(intents) == 0 {
+		return nil, errors.New("no intents to check")
+	}
+	if len(intents) > maxPreflightIntents {
+		return nil, fmt.Errorf("can't check more than %v intents", maxPreflightIntents)
+	}
+	header := api.bc.CurrentBlock()
+	statedb, err := api.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	brotliLevel, err := arbState.BrotliCompressionLevel()
+	if err != nil {
+		return nil, err
+	}
+	maxTxSize, maxCalldataSize, err := api.sizeLimits(arbState)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig := api.bc.Config()
+	extraInfo := types.DeserializeHeaderExtraInformation(header)
+	isShanghai := chainConfig.IsShanghai(header.Number, header.Time, extraInfo.ArbOSFormatVersion)
+
+	accounts := make(map[common.Address]*preflightAccount)
+	account := func(addr common.Address) *preflightAccount {
+		if acct, ok := accounts[addr]; ok {
+			return acct
+		}
+		acct := &preflightAccount{nonce: statedb.GetNonce(addr), balance: statedb.GetBalance(addr).ToBig()}
+		accounts[addr] = acct
+		return acct
+	}
+
+	results := make([]PreflightResult, len(intents))
+	for i, intent := range intents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result := &results[i]
+		sender := account(intent.From)
+		nonce := intent.Nonce
+		args := EstimateGasArgs{
+			From:                 &intent.From,
+			To:                   intent.To,
+			Gas:                  intent.Gas,
+			MaxFeePerGas:         intent.MaxFeePerGas,
+			MaxPriorityFeePerGas: intent.MaxPriorityFeePerGas,
+			Value:                intent.Value,
+			Nonce:                &nonce,
+			Data:                 intent.Data,
+		}
+		intrinsic, err := core.IntrinsicGas(args.data(), nil, intent.To == nil, chainConfig.IsHomestead(header.Number), chainConfig.IsIstanbul(header.Number), isShanghai)
+		if err != nil {
+			return nil, err
+		}
+		// The poster gas depends on the transaction's size, which doesn't depend on its gas limit much
+		l1Fee, _ := arbState.L1PricingState().GetPosterInfo(api.unsignedTx(&args, intrinsic), l1pricing.BatchPosterAddress, brotliLevel)
+		var posterGas uint64
+		if header.BaseFee != nil && header.BaseFee.Sign() > 0 {
+			posterGas = arbos.GetPosterGas(arbState, header.BaseFee, core.MessageCommitMode, l1Fee)
+		}
+		gas := arbmath.SaturatingUAdd(intrinsic, posterGas)
+		if intent.Gas != nil {
+			if uint64(*intent.Gas) < gas {
+				result.fail(EstimateFailureIntrinsicGas, fmt.Errorf("%w: have %v, want %v including %v for L1 calldata", core.ErrIntrinsicGas, *intent.Gas, gas, posterGas))
+			}
+			gas = uint64(*intent.Gas)
+		}
+		tx := api.unsignedTx(&args, gas)
+		result.Gas = hexutil.Uint64(gas)
+		result.L1Fee = (*hexutil.Big)(l1Fee)
+		result.ExpectedNonce = hexutil.Uint64(sender.nonce)
+
+		if err := checkSequencerTxSize(tx, maxTxSize, maxCalldataSize); err != nil {
+			result.fail(EstimateFailureTxTooLarge, err)
+		}
+		feeCap := args.feeCap()
+		if feeCap == nil {
+			feeCap = header.BaseFee
+		} else if arbmath.BigLessThan(feeCap, header.BaseFee) {
+			result.fail(EstimateFailureFeeCapTooLow, fmt.Errorf("%w: maxFeePerGas: %s baseFee: %s", core.ErrFeeCapTooLow, feeCap, header.BaseFee))
+		}
+		if uint64(nonce) < sender.nonce {
+			result.fail(EstimateFailureNonceTooLow, MakeNonceError(intent.From, uint64(nonce), sender.nonce))
+		} else if uint64(nonce) > sender.nonce {
+			result.fail(EstimateFailureNonceGap, fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooHigh, intent.From, nonce, sender.nonce))
+		}
+		cost := arbmath.BigAdd(arbmath.BigMulByUint(feeCap, gas), tx.Value())
+		result.Cost = (*hexutil.Big)(cost)
+		result.Balance = (*hexutil.Big)(new(big.Int).Set(sender.balance))
+		if arbmath.BigLessThan(sender.balance, cost) {
+			result.fail(EstimateFailureInsufficientFunds, fmt.Errorf("%w: address %v have %v want %v", core.ErrInsufficientFunds, intent.From, sender.balance, cost))
+		}
+		if err := api.checkFrozen(arbState, intent.From); err != nil {
+			result.fail(EstimateFailureSequencerFrozen, err)
+		}
+		if api.sequencer != nil && api.sequencer.screener != nil {
+			screened, err := api.sequencer.screener.Screen(ctx, intent.From, tx)
+			if err == nil && screened.Decision == txscreener.Reject {
+				err = fmt.Errorf("%w: reason %v", txscreener.ErrRejected, screened.Reason)
+			}
+			if err != nil {
+				result.fail(EstimateFailureScreened, err)
+			}
+		}
+		result.Accepted = len(result.Failures) == 0
+		if !result.Accepted {
+			continue
+		}
+		// Assume all of the gas is used, at the base fee, since ArbOS doesn't pay tips
+		sender.nonce++
+		sender.balance = arbmath.BigSub(sender.balance, arbmath.BigAdd(arbmath.BigMulByUint(header.BaseFee, gas), tx.Value()))
+		if intent.To != nil {
+			recipient := account(*intent.To)
+			recipient.balance = arbmath.BigAdd(recipient.balance, tx.Value())
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,416 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+var (
+	regionalIngressStampedCounter   = metrics.NewRegisteredCounter("arb/regionalingress/stamped", nil)
+	regionalIngressUnstampedCounter = metrics.NewRegisteredCounter("arb/regionalingress/unstamped", nil)
+	regionalArrivalsRejectedCounter = metrics.NewRegisteredCounter("arb/sequencer/regionalarrivals/rejected", nil)
+)
+
+// regionalIngressSigningDomain separates arrival signatures from any other message the ingress key signs.
+var regionalIngressSigningDomain = []byte("ARBITRUM_REGIONAL_INGRESS_ARRIVAL")
+
+var regionNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// RegionalIngressConfig configures a node taking in transactions in one region for the sequencer.
+type RegionalIngressConfig struct {
+	Enable       bool          `koanf:"enable"`
+	Region       string        `koanf:"region"`
+	SigningKey   string        `koanf:"signing-key"`
+	NTPServers   []string      `koanf:"ntp-servers"`
+	MaxClockSkew time.Duration `koanf:"max-clock-skew"`
+}
+
+var DefaultRegionalIngressConfig = RegionalIngressConfig{
+	Enable:       false,
+	Region:       "",
+	SigningKey:   "",
+	NTPServers:   []string{"pool.ntp.org"},
+	MaxClockSkew: 100 * time.Millisecond,
+}
+
+func RegionalIngressConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRegionalIngressConfig.Enable, "forward transactions to the sequencer with the time they arrived at this node, signed, so they're ordered as if they'd arrived at the sequencer then")
+	f.String(prefix+".region", DefaultRegionalIngressConfig.Region, "name of the region this node takes in transactions for, as the sequencer knows it")
+	f.String(prefix+".signing-key", DefaultRegionalIngressConfig.SigningKey, "key to sign arrival times with, hex or a path to a file containing it")
+	f.StringSlice(prefix+".ntp-servers", DefaultRegionalIngressConfig.NTPServers, "NTP servers arrival times are synchronized with, as host or host:port (the median offset is used)")
+	f.Duration(prefix+".max-clock-skew", DefaultRegionalIngressConfig.MaxClockSkew, "transactions are forwarded without an arrival time while the local clock is further than this from the NTP servers")
+}
+
+func (c *RegionalIngressConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if !regionNameRegex.MatchString(c.Region) {
+		return fmt.Errorf("regional ingress region \"%v\" must only contain letters, digits, '-' and '_'", c.Region)
+	}
+	if c.SigningKey == "" {
+		return errors.New("regional ingress enabled without a signing key")
+	}
+	if len(c.NTPServers) == 0 {
+		return errors.New("regional ingress requires at least one NTP server")
+	}
+	if c.MaxClockSkew <= 0 {
+		return errors.New("regional ingress max-clock-skew must be positive")
+	}
+	return nil
+}
+
+// RegionalArrivalsConfig configures the sequencer accepting transactions from regional ingresses.
+type RegionalArrivalsConfig struct {
+	Enable          bool          `koanf:"enable"`
+	Ingresses       []string      `koanf:"ingresses"`
+	MaxForwardDelay time.Duration `koanf:"max-forward-delay" reload:"hot"`
+	MaxClockSkew    time.Duration `koanf:"max-clock-skew" reload:"hot"`
+}
+
+var DefaultRegionalArrivalsConfig = RegionalArrivalsConfig{
+	Enable:          false,
+	Ingresses:       []string{},
+	MaxForwardDelay: 500 * time.Millisecond,
+	MaxClockSkew:    100 * time.Millisecond,
+}
+
+func RegionalArrivalsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRegionalArrivalsConfig.Enable, "accept transactions forwarded by regional ingresses with their arrival times (served by the arb_sendRegionalTransaction RPC method), ordering them by arrival rather than receipt")
+	f.StringSlice(prefix+".ingresses", DefaultRegionalArrivalsConfig.Ingresses, "the regional ingresses to accept, as region:address of the key they sign with")
+	f.Duration(prefix+".max-forward-delay", DefaultRegionalArrivalsConfig.MaxForwardDelay, "arrival times further in the past than this are moved up to it, so an ingress can't place transactions arbitrarily early")
+	f.Duration(prefix+".max-clock-skew", DefaultRegionalArrivalsConfig.MaxClockSkew, "arrival times ahead of the sequencer's clock by more than this are counted as clamped in the region's accounting")
+}
+
+func (c *RegionalArrivalsConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if _, err := parseRegionalIngresses(c.Ingresses); err != nil {
+		return err
+	}
+	if c.MaxForwardDelay < 0 || c.MaxClockSkew < 0 {
+		return errors.New("regional arrivals max-forward-delay and max-clock-skew cannot be negative")
+	}
+	return nil
+}
+
+// parseRegionalIngresses maps each ingress's signing address to its region.
+func parseRegionalIngresses(ingresses []string) (map[common.Address]string, error) {
+	regions := make(map[common.Address]string)
+	for _, ingress := range ingresses {
+		region, address, ok := strings.Cut(ingress, ":")
+		if !ok || !regionNameRegex.MatchString(region) || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid regional ingress \"%v\", expected region:address", ingress)
+		}
+		regions[common.HexToAddress(address)] = region
+	}
+	if len(regions) == 0 {
+		return nil, errors.New("regional arrivals enabled without any ingresses")
+	}
+	return regions, nil
+}
+
+// RegionalSubmission is a transaction a regional ingress took in, with when it arrived there.
+type RegionalSubmission struct {
+	ChainId *hexutil.Big `json:"chainId"`
+	Region  string       `json:"region"`
+	// Unix milliseconds, on the ingress's NTP synchronized clock
+	ArrivedAt   hexutil.Uint64 `json:"arrivedAt"`
+	Transaction hexutil.Bytes  `json:"transaction"`
+	// The ingress's signature over the submission's signing hash
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (s *RegionalSubmission) signingHash() common.Hash {
+	var chainId common.Hash
+	if s.ChainId != nil {
+		chainId = common.BigToHash(s.ChainId.ToInt())
+	}
+	return crypto.Keccak256Hash(
+		regionalIngressSigningDomain,
+		chainId.Bytes(),
+		crypto.Keccak256([]byte(s.Region)),
+		arbmath.UintToBytes(uint64(s.ArrivedAt)),
+		s.Transaction,
+	)
+}
+
+// RegionalIngress stamps the transactions a node forwards with when they arrived, measured on a clock
+// synchronized with NTP, and signs them so the sequencer can order them by arrival.
+type RegionalIngress struct {
+	config  *RegionalIngressConfig
+	chainId *big.Int
+	key     *ecdsa.PrivateKey
+	clock   *ClockSkewMonitor
+}
+
+func NewRegionalIngress(config *RegionalIngressConfig, chainId *big.Int) (*RegionalIngress, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	keyHash, err := signature.LoadSigningKey(config.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading regional ingress signing key: %w", err)
+	}
+	key, err := crypto.ToECDSA(keyHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regional ingress signing key: %w", err)
+	}
+	// Arrival times are only trusted while the clock is synchronized
+	clockConfig := DefaultClockSkewConfig
+	clockConfig.Enable = true
+	clockConfig.NTPServers = config.NTPServers
+	clockConfig.MaxSkew = config.MaxClockSkew
+	clockConfig.RequireNTP = true
+	return &RegionalIngress{
+		config:  config,
+		chainId: chainId,
+		key:     key,
+		clock:   NewClockSkewMonitor(func() *ClockSkewConfig { return &clockConfig }),
+	}, nil
+}
+
+func (r *RegionalIngress) Signer() common.Address {
+	return crypto.PubkeyToAddress(r.key.PublicKey)
+}
+
+func (r *RegionalIngress) Start(ctx context.Context) {
+	r.clock.Start(ctx)
+}
+
+func (r *RegionalIngress) Started() bool {
+	return r.clock.Started()
+}
+
+func (r *RegionalIngress) StopAndWait() {
+	r.clock.StopAndWait()
+}
+
+// stamp signs a submission of the transaction arriving now, or returns nil if the clock can't vouch for
+// the time, in which case the transaction is forwarded as usual.
+func (r *RegionalIngress) stamp(tx *types.Transaction) *RegionalSubmission {
+	if err := r.clock.Check(); err != nil {
+		regionalIngressUnstampedCounter.Inc(1)
+		return nil
+	}
+	offset, _ := r.clock.Offset()
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		regionalIngressUnstampedCounter.Inc(1)
+		return nil
+	}
+	submission := &RegionalSubmission{
+		ChainId: (*hexutil.Big)(r.chainId),
+		Region:  r.config.Region,
+		// #nosec G115
+		ArrivedAt:   hexutil.Uint64(time.Now().Add(offset).UnixMilli()),
+		Transaction: txBytes,
+	}
+	sig, err := crypto.Sign(submission.signingHash().Bytes(), r.key)
+	if err != nil {
+		log.Warn("failed to sign regional submission", "err", err)
+		regionalIngressUnstampedCounter.Inc(1)
+		return nil
+	}
+	submission.Signature = sig
+	regionalIngressStampedCounter.Inc(1)
+	return submission
+}
+
+// regionalArrival is when a transaction forwarded by a regional ingress arrived there, and was received here.
+type regionalArrival struct {
+	region   string
+	arrived  time.Time
+	received time.Time
+}
+
+// RegionalArrivalStats is the fairness accounting of transactions from one region.
+type RegionalArrivalStats struct {
+	Transactions hexutil.Uint64 `json:"transactions"`
+	// Arrival times that were moved, for being further in the past than the forward delay allows or
+	// ahead of the sequencer's clock
+	Clamped hexutil.Uint64 `json:"clamped"`
+	// Sum of the time between arrival at the ingress and receipt by the sequencer, in milliseconds
+	TotalForwardDelayMs hexutil.Uint64 `json:"totalForwardDelayMs"`
+	// Sum of the positions the region's transactions moved up in their blocks by being ordered by arrival
+	PositionsGained hexutil.Uint64 `json:"positionsGained"`
+}
+
+type regionalArrivalMetrics struct {
+	received     metrics.Counter
+	clamped      metrics.Counter
+	gained       metrics.Counter
+	forwardDelay metrics.Histogram
+}
+
+// regionalArrivals admits transactions from the configured ingresses and orders each block's transactions
+// by arrival, keeping count of how each region fares.
+type regionalArrivals struct {
+	config  func() *RegionalArrivalsConfig
+	regions map[common.Address]string
+
+	mutex   sync.Mutex
+	stats   map[string]*RegionalArrivalStats
+	metrics map[string]*regionalArrivalMetrics
+}
+
+func newRegionalArrivals(config func() *RegionalArrivalsConfig) (*regionalArrivals, error) {
+	regions, err := parseRegionalIngresses(config().Ingresses)
+	if err != nil {
+		return nil, err
+	}
+	a := &regionalArrivals{
+		config:  config,
+		regions: regions,
+		stats:   make(map[string]*RegionalArrivalStats),
+		metrics: make(map[string]*regionalArrivalMetrics),
+	}
+	for _, region := range regions {
+		if _, ok := a.stats[region]; ok {
+			continue
+		}
+		prefix := "arb/sequencer/regionalarrivals/" + region
+		a.stats[region] = &RegionalArrivalStats{}
+		a.metrics[region] = &regionalArrivalMetrics{
+			received:     metrics.GetOrRegisterCounter(prefix+"/received", nil),
+			clamped:      metrics.GetOrRegisterCounter(prefix+"/clamped", nil),
+			gained:       metrics.GetOrRegisterCounter(prefix+"/gained", nil),
+			forwardDelay: metrics.GetOrRegisterHistogram(prefix+"/forwarddelay", nil, metrics.NewBoundedHistogramSample()),
+		}
+	}
+	return a, nil
+}
+
+// check verifies a submission is for this chain and signed by its region's ingress.
+func (a *regionalArrivals) check(submission *RegionalSubmission, chainId *big.Int) error {
+	if submission.ChainId == nil || submission.ChainId.ToInt().Cmp(chainId) != 0 {
+		return fmt.Errorf("regional submission is for chain %v but this is chain %v", submission.ChainId, chainId)
+	}
+	signer, err := recoverSubmissionSigner("regional submission", submission.signingHash(), submission.Signature)
+	if err != nil {
+		return err
+	}
+	region, ok := a.regions[signer]
+	if !ok {
+		return fmt.Errorf("regional submission signed by unknown ingress %v", signer)
+	}
+	if region != submission.Region {
+		return fmt.Errorf("regional submission for region %v signed by the ingress for %v", submission.Region, region)
+	}
+	return nil
+}
+
+// admit returns the time to order a transaction from the region by, given when it arrived at the ingress and
+// when the sequencer received it. It's never later than the receipt, nor earlier than the forward delay allows.
+func (a *regionalArrivals) admit(region string, arrived time.Time, received time.Time) time.Time {
+	config := a.config()
+	clamped := false
+	if arrived.After(received) {
+		clamped = arrived.Sub(received) > config.MaxClockSkew
+		arrived = received
+	}
+	if earliest := received.Add(-config.MaxForwardDelay); arrived.Before(earliest) {
+		clamped = true
+		arrived = earliest
+	}
+	delay := received.Sub(arrived)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	stats := a.stats[region]
+	stats.Transactions++
+	// #nosec G115
+	stats.TotalForwardDelayMs += hexutil.Uint64(delay.Milliseconds())
+	a.metrics[region].received.Inc(1)
+	a.metrics[region].forwardDelay.Update(delay.Milliseconds())
+	if clamped {
+		stats.Clamped++
+		a.metrics[region].clamped.Inc(1)
+	}
+	return arrived
+}
+
+// order sorts a block's transactions by arrival, keeping each sender's transactions in nonce order.
+func (a *regionalArrivals) order(items []txQueueItem, signer types.Signer) []txQueueItem {
+	ordered := make([]txQueueItem, len(items))
+	copy(ordered, items)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].firstAppearance.Before(ordered[j].firstAppearance)
+	})
+	ordered = keepNonceOrder(ordered, signer)
+
+	original := make(map[*types.Transaction]int, len(items))
+	for i, item := range items {
+		original[item.tx] = i
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for i, item := range ordered {
+		if item.region == "" || original[item.tx] <= i {
+			continue
+		}
+		gained := original[item.tx] - i
+		// #nosec G115
+		a.stats[item.region].PositionsGained += hexutil.Uint64(gained)
+		a.metrics[item.region].gained.Inc(int64(gained))
+	}
+	return ordered
+}
+
+func (a *regionalArrivals) snapshot() map[string]RegionalArrivalStats {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	snapshot := make(map[string]RegionalArrivalStats, len(a.stats))
+	for region, stats := range a.stats {
+		snapshot[region] = *stats
+	}
+	return snapshot
+}
+
+// RegionalArrivalsAPI accepts transactions from regional ingresses, and reports how each region fares.
+type RegionalArrivalsAPI struct {
+	sequencer *Sequencer
+}
+
+func NewRegionalArrivalsAPI(sequencer *Sequencer) *RegionalArrivalsAPI {
+	return &RegionalArrivalsAPI{sequencer}
+}
+
+func (a *RegionalArrivalsAPI) SendRegionalTransaction(ctx context.Context, submission *RegionalSubmission) error {
+	if submission == nil {
+		return errors.New("missing regional submission")
+	}
+	return a.sequencer.PublishRegionalTransaction(ctx, submission)
+}
+
+// RegionalArrivalStats returns the fairness accounting of each region since the sequencer started.
+func (a *RegionalArrivalsAPI) RegionalArrivalStats(_ context.Context) (map[string]RegionalArrivalStats, error) {
+	if a.sequencer.regionalArrivals == nil {
+		return nil, errors.New("regional arrivals are not enabled")
+	}
+	return a.sequencer.regionalArrivals.snapshot(), nil
+}
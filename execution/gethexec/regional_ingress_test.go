@@ -0,0 +1,231 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/sntp"
+)
+
+func newTestRegionalIngress(t *testing.T, region string, chainId *big.Int, offset *time.Duration) *RegionalIngress {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultRegionalIngressConfig
+	config.Enable = true
+	config.Region = region
+	config.SigningKey = hexutil.Encode(crypto.FromECDSA(key))
+	config.NTPServers = []string{"ntp"}
+	ingress, err := NewRegionalIngress(&config, chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingress.clock.query = func(_ context.Context, _ string) (*sntp.Response, error) {
+		if offset == nil {
+			return nil, errors.New("no answer")
+		}
+		return &sntp.Response{Offset: *offset, Stratum: 1}, nil
+	}
+	return ingress
+}
+
+func newTestRegionalArrivals(t *testing.T, config *RegionalArrivalsConfig) *regionalArrivals {
+	t.Helper()
+	config.Enable = true
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	arrivals, err := newRegionalArrivals(func() *RegionalArrivalsConfig { return config })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return arrivals
+}
+
+func newTestRegionalTx(t *testing.T, key *ecdsa.PrivateKey, signer types.Signer, nonce uint64) *types.Transaction {
+	t.Helper()
+	return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+		Nonce:     nonce,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(1e9),
+		To:        &common.Address{},
+	})
+}
+
+func TestRegionalIngressStamp(t *testing.T) {
+	chainId := big.NewInt(412346)
+	signer := types.LatestSignerForChainID(chainId)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestRegionalTx(t, key, signer, 0)
+
+	offset := 20 * time.Millisecond
+	ingress := newTestRegionalIngress(t, "tokyo", chainId, &offset)
+	if ingress.stamp(tx) != nil {
+		t.Fatal("stamped an arrival before the clock was measured")
+	}
+	ingress.clock.measure(context.Background())
+	before := time.Now().Add(offset)
+	submission := ingress.stamp(tx)
+	after := time.Now().Add(offset)
+	if submission == nil {
+		t.Fatal("synchronized ingress didn't stamp an arrival")
+	}
+	arrived := time.UnixMilli(int64(submission.ArrivedAt))
+	if arrived.Before(before.Truncate(time.Millisecond)) || arrived.After(after) {
+		t.Fatal("arrival", arrived, "not on the synchronized clock between", before, "and", after)
+	}
+
+	config := DefaultRegionalArrivalsConfig
+	config.Ingresses = []string{"tokyo:" + ingress.Signer().Hex()}
+	arrivals := newTestRegionalArrivals(t, &config)
+	if err := arrivals.check(submission, chainId); err != nil {
+		t.Fatal(err)
+	}
+
+	// A skewed clock can't vouch for arrivals
+	skewed := time.Second
+	skewedIngress := newTestRegionalIngress(t, "tokyo", chainId, &skewed)
+	skewedIngress.clock.measure(context.Background())
+	if skewedIngress.stamp(tx) != nil {
+		t.Fatal("stamped an arrival on a skewed clock")
+	}
+}
+
+func TestRegionalSubmissionCheck(t *testing.T) {
+	chainId := big.NewInt(412346)
+	signer := types.LatestSignerForChainID(chainId)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestRegionalTx(t, key, signer, 0)
+	var offset time.Duration
+	tokyo := newTestRegionalIngress(t, "tokyo", chainId, &offset)
+	tokyo.clock.measure(context.Background())
+	frankfurt := newTestRegionalIngress(t, "frankfurt", chainId, &offset)
+	frankfurt.clock.measure(context.Background())
+
+	config := DefaultRegionalArrivalsConfig
+	config.Ingresses = []string{"tokyo:" + tokyo.Signer().Hex(), "frankfurt:" + frankfurt.Signer().Hex()}
+	arrivals := newTestRegionalArrivals(t, &config)
+
+	submission := tokyo.stamp(tx)
+	if err := arrivals.check(submission, chainId); err != nil {
+		t.Fatal(err)
+	}
+	if err := arrivals.check(submission, big.NewInt(1)); err == nil {
+		t.Fatal("accepted a submission for another chain")
+	}
+	claimed := *submission
+	claimed.Region = "frankfurt"
+	if err := arrivals.check(&claimed, chainId); err == nil {
+		t.Fatal("accepted a submission whose region was changed")
+	}
+	// An ingress can only vouch for its own region
+	impersonated := *frankfurt.stamp(tx)
+	impersonated.Region = "tokyo"
+	impersonated.Signature, err = crypto.Sign(impersonated.signingHash().Bytes(), frankfurt.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := arrivals.check(&impersonated, chainId); err == nil {
+		t.Fatal("accepted a submission signed by another region's ingress")
+	}
+	earlier := *submission
+	earlier.ArrivedAt -= 1000
+	if err := arrivals.check(&earlier, chainId); err == nil {
+		t.Fatal("accepted a submission whose arrival was changed")
+	}
+
+	if _, err := parseRegionalIngresses([]string{"tokyo"}); err == nil {
+		t.Fatal("accepted an ingress without an address")
+	}
+	if _, err := parseRegionalIngresses([]string{"to kyo:" + tokyo.Signer().Hex()}); err == nil {
+		t.Fatal("accepted an invalid region name")
+	}
+}
+
+func TestRegionalArrivalsAdmit(t *testing.T) {
+	config := DefaultRegionalArrivalsConfig
+	config.Ingresses = []string{"tokyo:" + common.Address{1}.Hex()}
+	config.MaxForwardDelay = 200 * time.Millisecond
+	config.MaxClockSkew = 10 * time.Millisecond
+	arrivals := newTestRegionalArrivals(t, &config)
+
+	received := time.Unix(1700000000, 0)
+	if at := arrivals.admit("tokyo", received.Add(-120*time.Millisecond), received); !at.Equal(received.Add(-120 * time.Millisecond)) {
+		t.Fatal("arrival within the forward delay moved to", at)
+	}
+	if at := arrivals.admit("tokyo", received.Add(-time.Second), received); !at.Equal(received.Add(-200 * time.Millisecond)) {
+		t.Fatal("arrival before the forward delay not clamped to it, got", at)
+	}
+	// Arrivals slightly ahead of the sequencer's clock are within the skew allowed
+	if at := arrivals.admit("tokyo", received.Add(5*time.Millisecond), received); !at.Equal(received) {
+		t.Fatal("arrival ahead of receipt not clamped to it, got", at)
+	}
+	if at := arrivals.admit("tokyo", received.Add(time.Second), received); !at.Equal(received) {
+		t.Fatal("future arrival not clamped to receipt, got", at)
+	}
+
+	stats := arrivals.snapshot()["tokyo"]
+	if stats.Transactions != 4 || stats.Clamped != 2 || stats.TotalForwardDelayMs != 320 {
+		t.Fatal("unexpected stats", stats)
+	}
+}
+
+func TestRegionalArrivalsOrder(t *testing.T) {
+	signer := types.LatestSignerForChainID(big.NewInt(412346))
+	config := DefaultRegionalArrivalsConfig
+	config.Ingresses = []string{"tokyo:" + common.Address{1}.Hex()}
+	arrivals := newTestRegionalArrivals(t, &config)
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	start := time.Unix(1700000000, 0)
+	local := newKey()
+	remote := newKey()
+	// Local transactions were received first, but the remote ones arrived at their ingress earlier
+	items := []txQueueItem{
+		{tx: newTestRegionalTx(t, local, signer, 0), firstAppearance: start.Add(10 * time.Millisecond)},
+		{tx: newTestRegionalTx(t, local, signer, 1), firstAppearance: start.Add(20 * time.Millisecond)},
+		{tx: newTestRegionalTx(t, remote, signer, 1), firstAppearance: start, region: "tokyo"},
+		{tx: newTestRegionalTx(t, remote, signer, 0), firstAppearance: start.Add(15 * time.Millisecond), region: "tokyo"},
+	}
+	ordered := arrivals.order(items, signer)
+	if len(ordered) != len(items) {
+		t.Fatal("lost transactions", len(ordered))
+	}
+	// By arrival the remote nonce 1 would be first, but the sender's nonces keep their order
+	expected := []*types.Transaction{items[3].tx, items[0].tx, items[2].tx, items[1].tx}
+	for i, tx := range expected {
+		if ordered[i].tx != tx {
+			t.Fatal("unexpected order at position", i)
+		}
+	}
+	// Nonce 0 moved up from 3 to 0, nonce 1 stayed at 2
+	if gained := arrivals.snapshot()["tokyo"].PositionsGained; gained != 3 {
+		t.Fatal("expected 3 positions gained but got", gained)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+// sandboxChainContext resolves the headers of blocks produced in a sandbox, which aren't part of the chain.
+type sandboxChainContext struct {
+	*core.BlockChain
+	headers map[common.Hash]*types.Header
+}
+
+func (c *sandboxChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[hash]; ok {
+		return header
+	}
+	return c.BlockChain.GetHeader(hash, number)
+}
+
+type SandboxBlock struct {
+	Header       *types.Header      `json:"header"`
+	Transactions types.Transactions `json:"transactions"`
+	Receipts     types.Receipts     `json:"receipts"`
+}
+
+type SandboxReplayResult struct {
+	SnapshotNumber uint64         `json:"snapshotNumber"`
+	SnapshotHash   common.Hash    `json:"snapshotHash"`
+	Blocks         []SandboxBlock `json:"blocks"`
+}
+
+// ReplayMessages executes a sequence of messages on a copy of the state after the snapshot block, as if they
+// were the messages following it, and returns the blocks they produce. Nothing is written to the chain.
+func (api *ArbDebugAPI) ReplayMessages(ctx context.Context, snapshot rpc.BlockNumber, messages []arbostypes.MessageWithMetadata) (*SandboxReplayResult, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("no messages to replay")
+	}
+	if uint64(len(messages)) > api.blockRangeBound {
+		return nil, fmt.Errorf("can't replay more than %v messages", api.blockRangeBound)
+	}
+	snapshot, _ = api.blockchain.ClipToPostNitroGenesis(snapshot)
+	// #nosec G115
+	header := api.blockchain.GetHeaderByNumber(uint64(snapshot))
+	if header == nil {
+		return nil, fmt.Errorf("block %v not found", snapshot.Int64())
+	}
+	statedb, err := api.recreator.StateAt(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+	chainContext := &sandboxChainContext{
+		BlockChain: api.blockchain,
+		headers:    make(map[common.Hash]*types.Header),
+	}
+	result := &SandboxReplayResult{
+		SnapshotNumber: header.Number.Uint64(),
+		SnapshotHash:   header.Hash(),
+	}
+	lastHeader := header
+	for i, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if msg.Message == nil || msg.Message.Header == nil {
+			return nil, fmt.Errorf("message %v has no header", i)
+		}
+		block, receipts, err := arbos.ProduceBlock(
+			msg.Message,
+			msg.DelayedMessagesRead,
+			lastHeader,
+			statedb,
+			chainContext,
+			api.blockchain.Config(),
+			false,
+			core.MessageCommitMode,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("replaying message %v: %w", i, err)
+		}
+		var logIndex uint
+		for j, receipt := range receipts {
+			receipt.BlockHash = block.Hash()
+			receipt.BlockNumber = block.Number()
+			receipt.TransactionIndex = uint(j)
+			for _, txLog := range receipt.Logs {
+				txLog.BlockHash = block.Hash()
+				txLog.BlockNumber = block.NumberU64()
+				txLog.TxIndex = uint(j)
+				txLog.Index = logIndex
+				logIndex++
+			}
+		}
+		lastHeader = block.Header()
+		chainContext.headers[block.Hash()] = lastHeader
+		result.Blocks = append(result.Blocks, SandboxBlock{
+			Header:       lastHeader,
+			Transactions: block.Transactions(),
+			Receipts:     receipts,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	retryableIndexPrefix = []byte("arbitrum-retryable-ticket-")
+
+	retryableCanceledEventID = crypto.Keccak256Hash([]byte("Canceled(bytes32)"))
+
+	retryableIndexPrunedCounter = metrics.NewRegisteredCounter("arb/retryableindex/pruned", nil)
+)
+
+func retryableIndexKey(ticketId common.Hash) []byte {
+	return append(append([]byte{}, retryableIndexPrefix...), ticketId.Bytes()...)
+}
+
+type RetryableIndexConfig struct {
+	Enable        bool          `koanf:"enable"`
+	Retention     time.Duration `koanf:"retention" reload:"hot"`
+	PruneInterval time.Duration `koanf:"prune-interval" reload:"hot"`
+}
+
+var DefaultRetryableIndexConfig = RetryableIndexConfig{
+	Enable:        false,
+	Retention:     30 * 24 * time.Hour,
+	PruneInterval: time.Hour,
+}
+
+func RetryableIndexConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRetryableIndexConfig.Enable, "index retryable tickets' creation, redemption attempts and cancellation as blocks are written, served by the arb_retryableTicket RPC method")
+	f.Duration(prefix+".retention", DefaultRetryableIndexConfig.Retention, "how long after a ticket's last activity to keep it in the index (0 = forever)")
+	f.Duration(prefix+".prune-interval", DefaultRetryableIndexConfig.PruneInterval, "how often to prune tickets past their retention from the index")
+}
+
+func (c *RetryableIndexConfig) Validate() error {
+	if c.Retention < 0 {
+		return errors.New("retryable index retention cannot be negative")
+	}
+	if c.PruneInterval <= 0 {
+		return errors.New("retryable index prune-interval must be positive")
+	}
+	return nil
+}
+
+type RetryableAttempt struct {
+	TxHash      common.Hash    `json:"txHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Succeeded   bool           `json:"succeeded"`
+}
+
+// RetryableTicket is what the index knows about a retryable ticket. Tickets created before the index
+// was enabled only have the activity since.
+type RetryableTicket struct {
+	TicketId common.Hash `json:"ticketId"`
+	// The parent chain request that created the ticket, or the zero hash if its creation wasn't indexed
+	RequestId      common.Hash     `json:"requestId"`
+	CreatedInBlock *hexutil.Uint64 `json:"createdInBlock"`
+	From           common.Address  `json:"from"`
+	Beneficiary    common.Address  `json:"beneficiary"`
+	RetryTo        *common.Address `json:"retryTo"`
+	Callvalue      *hexutil.Big    `json:"callvalue"`
+	CalldataHash   common.Hash     `json:"calldataHash"`
+	// Redemption attempts in the order they were executed, including the automatic one
+	Attempts         []RetryableAttempt `json:"attempts"`
+	CanceledInBlock  *hexutil.Uint64    `json:"canceledInBlock"`
+	LastActivityTime hexutil.Uint64     `json:"lastActivityTime"`
+}
+
+// RetryableIndex maps retryable ticket IDs to their history, so a ticket's fate can be looked up
+// without an external indexer. It's kept up to date as blocks are written.
+type RetryableIndex struct {
+	stopwaiter.StopWaiter
+	db     ethdb.KeyValueStore
+	config func() *RetryableIndexConfig
+	mutex  sync.Mutex
+}
+
+func NewRetryableIndex(db ethdb.KeyValueStore, config func() *RetryableIndexConfig) *RetryableIndex {
+	return &RetryableIndex{
+		db:     db,
+		config: config,
+	}
+}
+
+func (r *RetryableIndex) Start(ctx context.Context) {
+	r.StopWaiter.Start(ctx, r)
+	r.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := r.prune(ctx, time.Now()); err != nil {
+			log.Warn("failed to prune retryable index", "err", err)
+		}
+		return r.config().PruneInterval
+	})
+}
+
+// Get returns what the index knows about a ticket, or nil if it isn't indexed.
+func (r *RetryableIndex) Get(ticketId common.Hash) (*RetryableTicket, error) {
+	key := retryableIndexKey(ticketId)
+	has, err := r.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := r.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var ticket RetryableTicket
+	if err := json.Unmarshal(data, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *RetryableIndex) getOrCreate(ticketId common.Hash) (*RetryableTicket, error) {
+	ticket, err := r.Get(ticketId)
+	if err != nil || ticket != nil {
+		return ticket, err
+	}
+	return &RetryableTicket{TicketId: ticketId}, nil
+}
+
+// indexBlock records the retryable activity in a block.
+func (r *RetryableIndex) indexBlock(block *types.Block, receipts types.Receipts) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	number := hexutil.Uint64(block.NumberU64())
+	updated := make(map[common.Hash]*RetryableTicket)
+	ticket := func(ticketId common.Hash) (*RetryableTicket, error) {
+		if ticket, ok := updated[ticketId]; ok {
+			return ticket, nil
+		}
+		ticket, err := r.getOrCreate(ticketId)
+		if err != nil {
+			return nil, err
+		}
+		updated[ticketId] = ticket
+		return ticket, nil
+	}
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		receipt := receipts[i]
+		switch inner := tx.GetInner().(type) {
+		case *types.ArbitrumSubmitRetryableTx:
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				continue
+			}
+			entry, err := ticket(tx.Hash())
+			if err != nil {
+				return err
+			}
+			entry.RequestId = inner.RequestId
+			entry.CreatedInBlock = &number
+			entry.From = inner.From
+			entry.Beneficiary = inner.Beneficiary
+			entry.RetryTo = inner.RetryTo
+			entry.Callvalue = (*hexutil.Big)(inner.RetryValue)
+			entry.CalldataHash = crypto.Keccak256Hash(inner.RetryData)
+		case *types.ArbitrumRetryTx:
+			entry, err := ticket(inner.TicketId)
+			if err != nil {
+				return err
+			}
+			entry.Attempts = append(entry.Attempts, RetryableAttempt{
+				TxHash:      tx.Hash(),
+				BlockNumber: number,
+				BlockHash:   block.Hash(),
+				Succeeded:   receipt.Status == types.ReceiptStatusSuccessful,
+			})
+		}
+		for _, txLog := range receipt.Logs {
+			if txLog.Address != types.ArbRetryableTxAddress || len(txLog.Topics) < 2 || txLog.Topics[0] != retryableCanceledEventID {
+				continue
+			}
+			entry, err := ticket(txLog.Topics[1])
+			if err != nil {
+				return err
+			}
+			entry.CanceledInBlock = &number
+		}
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	batch := r.db.NewBatch()
+	for ticketId, entry := range updated {
+		entry.LastActivityTime = hexutil.Uint64(block.Time())
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(retryableIndexKey(ticketId), data); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// prune removes the tickets whose last activity is older than the retention.
+func (r *RetryableIndex) prune(ctx context.Context, now time.Time) error {
+	retention := r.config().Retention
+	if retention == 0 {
+		return nil
+	}
+	cutoff := now.Add(-retention).Unix()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	it := r.db.NewIterator(retryableIndexPrefix, nil)
+	defer it.Release()
+	batch := r.db.NewBatch()
+	pruned := int64(0)
+	for it.Next() {
+		if ctx.Err() != nil {
+			break
+		}
+		var ticket RetryableTicket
+		if err := json.Unmarshal(it.Value(), &ticket); err != nil {
+			return err
+		}
+		// #nosec G115
+		if int64(ticket.LastActivityTime) >= cutoff {
+			continue
+		}
+		if err := batch.Delete(append([]byte{}, it.Key()...)); err != nil {
+			return err
+		}
+		pruned++
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	retryableIndexPrunedCounter.Inc(pruned)
+	return batch.Write()
+}
+
+type RetryableIndexAPI struct {
+	bc    *core.BlockChain
+	index *RetryableIndex
+}
+
+func NewRetryableIndexAPI(bc *core.BlockChain, index *RetryableIndex) *RetryableIndexAPI {
+	return &RetryableIndexAPI{bc, index}
+}
+
+// RetryableTicket returns the indexed history of a retryable ticket, or null if it isn't indexed.
+// Redemption attempts in blocks that were since reorged out are left out.
+func (api *RetryableIndexAPI) RetryableTicket(_ context.Context, ticketId common.Hash) (*RetryableTicket, error) {
+	ticket, err := api.index.Get(ticketId)
+	if err != nil || ticket == nil {
+		return nil, err
+	}
+	canonical := ticket.Attempts[:0]
+	for _, attempt := range ticket.Attempts {
+		if api.bc.GetCanonicalHash(uint64(attempt.BlockNumber)) == attempt.BlockHash {
+			canonical = append(canonical, attempt)
+		}
+	}
+	ticket.Attempts = canonical
+	return ticket, nil
+}
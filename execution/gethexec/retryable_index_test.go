@@ -0,0 +1,71 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestRetryableIndex(t *testing.T) {
+	config := DefaultRetryableIndexConfig
+	index := NewRetryableIndex(rawdb.NewMemoryDatabase(), func() *RetryableIndexConfig { return &config })
+
+	retryTo := common.HexToAddress("0x1234")
+	submit := types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:     big.NewInt(1),
+		RequestId:   common.HexToHash("0x01"),
+		From:        common.HexToAddress("0xaa"),
+		Beneficiary: common.HexToAddress("0xbb"),
+		RetryTo:     &retryTo,
+		RetryValue:  big.NewInt(5),
+		RetryData:   []byte{1, 2, 3},
+	})
+	ticketId := submit.Hash()
+	retry := types.NewTx(&types.ArbitrumRetryTx{ChainId: big.NewInt(1), TicketId: ticketId})
+	now := time.Now()
+	header := &types.Header{Number: big.NewInt(7), Time: uint64(now.Unix())}
+	block := types.NewBlock(header, types.Transactions{submit, retry}, nil, nil, trie.NewStackTrie(nil))
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful},
+		{Status: types.ReceiptStatusFailed},
+	}
+	if err := index.indexBlock(block, receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := index.Get(ticketId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket == nil {
+		t.Fatal("ticket wasn't indexed")
+	}
+	if ticket.RequestId != common.HexToHash("0x01") || ticket.CreatedInBlock == nil || *ticket.CreatedInBlock != 7 {
+		t.Fatal("wrong creation info", ticket.RequestId, ticket.CreatedInBlock)
+	}
+	if len(ticket.Attempts) != 1 || ticket.Attempts[0].Succeeded || ticket.Attempts[0].TxHash != retry.Hash() {
+		t.Fatal("wrong attempts", ticket.Attempts)
+	}
+
+	if err := index.prune(context.Background(), now.Add(config.Retention-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if ticket, err = index.Get(ticketId); err != nil || ticket == nil {
+		t.Fatal("ticket was pruned before its retention", err)
+	}
+	if err := index.prune(context.Background(), now.Add(config.Retention+time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if ticket, err = index.Get(ticketId); err != nil || ticket != nil {
+		t.Fatal("ticket wasn't pruned after its retention", err)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"golang.org/x/time/rate"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Requests larger than this are rejected, since their methods must be read before they're served
+const maxRPCGatewayRequestSize = 32 << 20
+
+const rpcGatewayAnonymousName = "anonymous"
+
+var rpcGatewayNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// RPCGatewayKeyConfig is the policy for requests made with one API key.
+// A zero requests-per-second or daily-quota means no limit, and an empty method list allows every method.
+// Batches with more calls than the burst are always rate limited.
+type RPCGatewayKeyConfig struct {
+	Name              string   `koanf:"name" json:"name"`
+	Key               string   `koanf:"key" json:"key"`
+	RequestsPerSecond float64  `koanf:"requests-per-second" json:"requests-per-second"`
+	Burst             int      `koanf:"burst" json:"burst"`
+	DailyQuota        uint64   `koanf:"daily-quota" json:"daily-quota"`
+	Methods           []string `koanf:"methods" json:"methods"`
+}
+
+func (c *RPCGatewayKeyConfig) Validate() error {
+	if !rpcGatewayNameRegex.MatchString(c.Name) {
+		return fmt.Errorf("rpc gateway key name \"%v\" must only contain letters, digits, '-' and '_'", c.Name)
+	}
+	if c.RequestsPerSecond < 0 {
+		return fmt.Errorf("rpc gateway key %v has negative requests-per-second", c.Name)
+	}
+	if c.RequestsPerSecond > 0 && c.Burst < 1 {
+		return fmt.Errorf("rpc gateway key %v must have a positive burst when rate limited", c.Name)
+	}
+	for _, method := range c.Methods {
+		if method == "" || strings.Contains(strings.TrimSuffix(method, "*"), "*") {
+			return fmt.Errorf("rpc gateway key %v has invalid method \"%v\", expected a method name or a prefix ending in *", c.Name, method)
+		}
+	}
+	return nil
+}
+
+type RPCGatewayConfig struct {
+	Enable         bool                `koanf:"enable"`
+	KeysFile       string              `koanf:"keys-file"`
+	KeyHeader      string              `koanf:"key-header"`
+	AllowAnonymous bool                `koanf:"allow-anonymous"`
+	Anonymous      RPCGatewayKeyConfig `koanf:"anonymous"`
+}
+
+var DefaultRPCGatewayConfig = RPCGatewayConfig{
+	Enable:         false,
+	KeysFile:       "",
+	KeyHeader:      "X-API-Key",
+	AllowAnonymous: false,
+	Anonymous: RPCGatewayKeyConfig{
+		Name:              rpcGatewayAnonymousName,
+		RequestsPerSecond: 10,
+		Burst:             20,
+		DailyQuota:        0,
+		Methods:           []string{"eth_*", "net_*", "web3_*"},
+	},
+}
+
+func RPCGatewayConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRPCGatewayConfig.Enable, "enforce per API key rate limits, quotas and method allowlists on the http RPC server")
+	f.String(prefix+".keys-file", DefaultRPCGatewayConfig.KeysFile, "JSON file with an array of API keys, each with a name, key, requests-per-second, burst, daily-quota and methods")
+	f.String(prefix+".key-header", DefaultRPCGatewayConfig.KeyHeader, "http header to read the API key from (the apikey query parameter is also accepted)")
+	f.Bool(prefix+".allow-anonymous", DefaultRPCGatewayConfig.AllowAnonymous, "serve requests without an API key using the anonymous policy")
+	f.Float64(prefix+".anonymous.requests-per-second", DefaultRPCGatewayConfig.Anonymous.RequestsPerSecond, "rate limit for all anonymous requests together (0 = unlimited)")
+	f.Int(prefix+".anonymous.burst", DefaultRPCGatewayConfig.Anonymous.Burst, "number of anonymous calls allowed in a burst above the rate limit")
+	f.Uint64(prefix+".anonymous.daily-quota", DefaultRPCGatewayConfig.Anonymous.DailyQuota, "maximum anonymous calls per UTC day (0 = unlimited)")
+	f.StringSlice(prefix+".anonymous.methods", DefaultRPCGatewayConfig.Anonymous.Methods, "methods anonymous requests may call, where a trailing * matches any suffix (empty = all)")
+}
+
+func (c *RPCGatewayConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.KeyHeader == "" {
+		return errors.New("rpc gateway key-header must be set")
+	}
+	if c.KeysFile == "" && !c.AllowAnonymous {
+		return errors.New("rpc gateway enabled without a keys-file or allow-anonymous")
+	}
+	// The anonymous policy's metrics are always reported under the same name
+	c.Anonymous.Name = rpcGatewayAnonymousName
+	return c.Anonymous.Validate()
+}
+
+type rpcGatewayTenant struct {
+	limiter    *rate.Limiter // nil if unlimited
+	dailyQuota uint64
+	methods    []string
+
+	mutex     sync.Mutex
+	day       int64
+	usedToday uint64
+
+	requestsCounter      metrics.Counter
+	callsCounter         metrics.Counter
+	rateLimitedCounter   metrics.Counter
+	quotaExceededCounter metrics.Counter
+	deniedCounter        metrics.Counter
+}
+
+func newRPCGatewayTenant(config *RPCGatewayKeyConfig) *rpcGatewayTenant {
+	prefix := "arb/rpc/gateway/" + config.Name
+	tenant := &rpcGatewayTenant{
+		dailyQuota:           config.DailyQuota,
+		methods:              config.Methods,
+		requestsCounter:      metrics.GetOrRegisterCounter(prefix+"/requests", nil),
+		callsCounter:         metrics.GetOrRegisterCounter(prefix+"/calls", nil),
+		rateLimitedCounter:   metrics.GetOrRegisterCounter(prefix+"/ratelimited", nil),
+		quotaExceededCounter: metrics.GetOrRegisterCounter(prefix+"/quotaexceeded", nil),
+		deniedCounter:        metrics.GetOrRegisterCounter(prefix+"/denied", nil),
+	}
+	if config.RequestsPerSecond > 0 {
+		tenant.limiter = rate.NewLimiter(rate.Limit(config.RequestsPerSecond), config.Burst)
+	}
+	return tenant
+}
+
+func (t *rpcGatewayTenant) methodAllowed(method string) bool {
+	if len(t.methods) == 0 {
+		return true
+	}
+	for _, allowed := range t.methods {
+		if prefix, isPrefix := strings.CutSuffix(allowed, "*"); isPrefix {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if method == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// useQuota counts calls against the daily quota, returning false without counting them if they'd exceed it.
+func (t *rpcGatewayTenant) useQuota(now time.Time, calls uint64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	day := now.Unix() / (24 * 60 * 60)
+	if day != t.day {
+		t.day = day
+		t.usedToday = 0
+	}
+	if t.dailyQuota != 0 && t.usedToday+calls > t.dailyQuota {
+		return false
+	}
+	t.usedToday += calls
+	return true
+}
+
+// RPCGateway lets a node serve public RPC directly by identifying callers by API key, and limiting
+// the rate, daily number and methods of their calls. Batches count as one call per request in them.
+type RPCGateway struct {
+	keyHeader string
+	tenants   map[string]*rpcGatewayTenant
+	anonymous *rpcGatewayTenant // nil unless anonymous requests are allowed
+}
+
+func NewRPCGateway(config *RPCGatewayConfig) (*RPCGateway, error) {
+	gateway := &RPCGateway{
+		keyHeader: config.KeyHeader,
+		tenants:   make(map[string]*rpcGatewayTenant),
+	}
+	if config.AllowAnonymous {
+		gateway.anonymous = newRPCGatewayTenant(&config.Anonymous)
+	}
+	if config.KeysFile == "" {
+		return gateway, nil
+	}
+	data, err := os.ReadFile(config.KeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rpc gateway keys file: %w", err)
+	}
+	var keys []RPCGatewayKeyConfig
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse rpc gateway keys file: %w", err)
+	}
+	names := map[string]struct{}{rpcGatewayAnonymousName: {}}
+	for i := range keys {
+		key := &keys[i]
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		if key.Key == "" {
+			return nil, fmt.Errorf("rpc gateway key %v has no key", key.Name)
+		}
+		if _, exists := names[key.Name]; exists {
+			return nil, fmt.Errorf("duplicate rpc gateway key name %v", key.Name)
+		}
+		if _, exists := gateway.tenants[key.Key]; exists {
+			return nil, fmt.Errorf("rpc gateway key %v reuses another entry's key", key.Name)
+		}
+		names[key.Name] = struct{}{}
+		gateway.tenants[key.Key] = newRPCGatewayTenant(key)
+	}
+	return gateway, nil
+}
+
+type rpcGatewayRequest struct {
+	Method string `json:"method"`
+}
+
+// requestMethods returns the methods called by a request body, or nil if it can't be parsed,
+// in which case it's left to the RPC server to reject.
+func requestMethods(body []byte) []string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []rpcGatewayRequest
+		if json.Unmarshal(trimmed, &batch) != nil {
+			return nil
+		}
+		methods := make([]string, 0, len(batch))
+		for _, req := range batch {
+			methods = append(methods, req.Method)
+		}
+		return methods
+	}
+	var req rpcGatewayRequest
+	if json.Unmarshal(trimmed, &req) != nil {
+		return nil
+	}
+	return []string{req.Method}
+}
+
+func writeRPCGatewayError(w http.ResponseWriter, status int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      nil,
+		"error":   map[string]interface{}{"code": code, "message": message},
+	})
+}
+
+func (g *RPCGateway) tenant(r *http.Request) (*rpcGatewayTenant, bool) {
+	key := r.Header.Get(g.keyHeader)
+	if key == "" {
+		key = r.URL.Query().Get("apikey")
+	}
+	if key == "" {
+		return g.anonymous, g.anonymous != nil
+	}
+	tenant, ok := g.tenants[key]
+	return tenant, ok
+}
+
+// WrapHandler returns an http handler which only passes requests on to next if their API key's policy allows them.
+func (g *RPCGateway) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := g.tenant(r)
+		if !ok {
+			writeRPCGatewayError(w, http.StatusUnauthorized, -32001, "missing or unknown API key")
+			return
+		}
+		tenant.requestsCounter.Inc(1)
+
+		calls := uint64(1)
+		if r.Method == http.MethodPost && r.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxRPCGatewayRequestSize+1))
+			if err != nil {
+				writeRPCGatewayError(w, http.StatusBadRequest, -32700, "failed to read request")
+				return
+			}
+			if len(body) > maxRPCGatewayRequestSize {
+				writeRPCGatewayError(w, http.StatusRequestEntityTooLarge, -32600, "request too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if methods := requestMethods(body); len(methods) > 0 {
+				for _, method := range methods {
+					if !tenant.methodAllowed(method) {
+						tenant.deniedCounter.Inc(1)
+						writeRPCGatewayError(w, http.StatusForbidden, -32601, fmt.Sprintf("method %v is not allowed for this API key", method))
+						return
+					}
+				}
+				calls = uint64(len(methods))
+			}
+		} else if len(tenant.methods) > 0 {
+			// The methods called over a websocket can't be checked here
+			tenant.deniedCounter.Inc(1)
+			writeRPCGatewayError(w, http.StatusForbidden, -32601, "websockets aren't allowed for API keys with a method allowlist")
+			return
+		}
+
+		now := time.Now()
+		// #nosec G115
+		if tenant.limiter != nil && !tenant.limiter.AllowN(now, int(calls)) {
+			tenant.rateLimitedCounter.Inc(1)
+			writeRPCGatewayError(w, http.StatusTooManyRequests, -32005, "rate limit exceeded")
+			return
+		}
+		if !tenant.useQuota(now, calls) {
+			tenant.quotaExceededCounter.Inc(1)
+			writeRPCGatewayError(w, http.StatusTooManyRequests, -32005, "daily quota exceeded")
+			return
+		}
+		// #nosec G115
+		tenant.callsCounter.Inc(int64(calls))
+		next.ServeHTTP(w, r)
+	})
+}
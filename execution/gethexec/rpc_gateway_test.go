@@ -0,0 +1,126 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPCGateway(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	keys := `[
+		{"name": "limited", "key": "limited-key", "requests-per-second": 0.001, "burst": 3, "methods": ["eth_*"]},
+		{"name": "quota", "key": "quota-key", "daily-quota": 2}
+	]`
+	if err := os.WriteFile(keysFile, []byte(keys), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultRPCGatewayConfig
+	config.Enable = true
+	config.KeysFile = keysFile
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	gateway, err := NewRPCGateway(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var served int
+	handler := gateway.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+	call := func(key string, body string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	single := func(method string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+	}
+
+	if code := call("", single("eth_chainId")); code != http.StatusUnauthorized {
+		t.Error("anonymous request got", code)
+	}
+	if code := call("unknown-key", single("eth_chainId")); code != http.StatusUnauthorized {
+		t.Error("unknown key got", code)
+	}
+	if code := call("limited-key", single("debug_traceTransaction")); code != http.StatusForbidden {
+		t.Error("disallowed method got", code)
+	}
+	batch := "[" + single("eth_chainId") + "," + single("arb_maintenanceStatus") + "]"
+	if code := call("limited-key", batch); code != http.StatusForbidden {
+		t.Error("batch with disallowed method got", code)
+	}
+	batch = "[" + single("eth_chainId") + "," + single("eth_blockNumber") + "]"
+	if code := call("limited-key", batch); code != http.StatusOK {
+		t.Error("allowed batch got", code)
+	}
+	if code := call("limited-key", single("eth_chainId")); code != http.StatusOK {
+		t.Error("allowed call got", code)
+	}
+	if code := call("limited-key", single("eth_chainId")); code != http.StatusTooManyRequests {
+		t.Error("call over the burst got", code)
+	}
+
+	if code := call("quota-key", single("debug_traceTransaction")); code != http.StatusOK {
+		t.Error("call within quota got", code)
+	}
+	if code := call("quota-key", "["+single("eth_chainId")+","+single("eth_chainId")+"]"); code != http.StatusTooManyRequests {
+		t.Error("batch over quota got", code)
+	}
+	if code := call("quota-key", single("eth_chainId")); code != http.StatusOK {
+		t.Error("call using the rest of the quota got", code)
+	}
+	if code := call("quota-key", single("eth_chainId")); code != http.StatusTooManyRequests {
+		t.Error("call over quota got", code)
+	}
+
+	if served != 4 {
+		t.Error("expected 4 requests to be served but got", served)
+	}
+}
+
+func TestRPCGatewayAnonymous(t *testing.T) {
+	config := DefaultRPCGatewayConfig
+	config.Enable = true
+	config.AllowAnonymous = true
+	config.Anonymous.RequestsPerSecond = 0
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	gateway, err := NewRPCGateway(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := gateway.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for method, expected := range map[string]int{"eth_call": http.StatusOK, "net_version": http.StatusOK, "admin_peers": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"`+method+`"}`))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != expected {
+			t.Error("anonymous", method, "got", recorder.Code, "expected", expected)
+		}
+	}
+	// Websockets can't be checked against the anonymous method allowlist
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusForbidden {
+		t.Error("anonymous websocket got", recorder.Code)
+	}
+}
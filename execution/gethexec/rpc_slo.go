@@ -0,0 +1,345 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Larger requests are timed without being parsed
+const maxRPCSLORequestSize = 1 << 20
+
+const (
+	rpcSLOBatchMethod    = "batch"
+	rpcSLOOtherMethod    = "other"
+	rpcSLOUnparsedMethod = "unparsed"
+)
+
+var rpcSlowQueriesCounter = metrics.NewRegisteredCounter("arb/rpc/slo/slow", nil)
+
+type RPCSLOConfig struct {
+	Enable          bool          `koanf:"enable"`
+	DefaultSLO      time.Duration `koanf:"default-slo"`
+	MethodSLOs      []string      `koanf:"method-slos"`
+	MaxMethods      int           `koanf:"max-methods"`
+	MaxParamsLength int           `koanf:"max-params-length"`
+	SlowQueries     int           `koanf:"slow-queries"`
+}
+
+var DefaultRPCSLOConfig = RPCSLOConfig{
+	Enable:          false,
+	DefaultSLO:      time.Second,
+	MethodSLOs:      []string{"eth_getLogs=5s", "debug_*=30s"},
+	MaxMethods:      256,
+	MaxParamsLength: 256,
+	SlowQueries:     100,
+}
+
+func RPCSLOConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRPCSLOConfig.Enable, "track the latency of http RPC calls by method and log calls slower than their SLO")
+	f.Duration(prefix+".default-slo", DefaultRPCSLOConfig.DefaultSLO, "latency above which a call to a method without its own SLO is logged as slow")
+	f.StringSlice(prefix+".method-slos", DefaultRPCSLOConfig.MethodSLOs, "per method SLOs as method=duration, where a trailing * in the method matches any suffix")
+	f.Int(prefix+".max-methods", DefaultRPCSLOConfig.MaxMethods, "maximum number of methods tracked separately, with further methods tracked together as \"other\"")
+	f.Int(prefix+".max-params-length", DefaultRPCSLOConfig.MaxParamsLength, "maximum length of the params logged for a slow call")
+	f.Int(prefix+".slow-queries", DefaultRPCSLOConfig.SlowQueries, "number of recent slow calls kept for the latency report")
+}
+
+func (c *RPCSLOConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.DefaultSLO <= 0 {
+		return errors.New("rpc slo default-slo must be positive")
+	}
+	if c.MaxMethods < 1 {
+		return errors.New("rpc slo max-methods must be positive")
+	}
+	_, err := parseRPCMethodSLOs(c.MethodSLOs)
+	return err
+}
+
+type rpcMethodSLO struct {
+	method string // a prefix if prefix is set
+	prefix bool
+	slo    time.Duration
+}
+
+func parseRPCMethodSLOs(entries []string) ([]rpcMethodSLO, error) {
+	var slos []rpcMethodSLO
+	for _, entry := range entries {
+		method, durationString, found := strings.Cut(entry, "=")
+		if !found || method == "" || strings.Contains(strings.TrimSuffix(method, "*"), "*") {
+			return nil, fmt.Errorf("invalid rpc method slo \"%v\", expected method=duration", entry)
+		}
+		slo, err := time.ParseDuration(durationString)
+		if err != nil || slo <= 0 {
+			return nil, fmt.Errorf("invalid duration in rpc method slo \"%v\"", entry)
+		}
+		prefix, isPrefix := strings.CutSuffix(method, "*")
+		slos = append(slos, rpcMethodSLO{method: prefix, prefix: isPrefix, slo: slo})
+	}
+	// Exact matches take precedence, then longer prefixes
+	sort.SliceStable(slos, func(i, j int) bool {
+		if slos[i].prefix != slos[j].prefix {
+			return !slos[i].prefix
+		}
+		return len(slos[i].method) > len(slos[j].method)
+	})
+	return slos, nil
+}
+
+// RPCSlowQuery is a call which took longer than its SLO.
+type RPCSlowQuery struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Calls     []string  `json:"calls,omitempty"` // the methods in a batch
+	ElapsedMs float64   `json:"elapsedMs"`
+	SLOMs     float64   `json:"sloMs"`
+	Params    string    `json:"params,omitempty"`
+	Remote    string    `json:"remote"`
+}
+
+// RPCMethodLatency summarizes the latency of the calls to a method since the node started.
+type RPCMethodLatency struct {
+	Method   string  `json:"method"`
+	Calls    int64   `json:"calls"`
+	Slow     int64   `json:"slow"`
+	SLOMs    float64 `json:"sloMs"`
+	P50Ms    float64 `json:"p50Ms"`
+	P90Ms    float64 `json:"p90Ms"`
+	P99Ms    float64 `json:"p99Ms"`
+	MaxMs    float64 `json:"maxMs"`
+	Exceeded bool    `json:"exceeded"` // whether the p99 is above the SLO
+}
+
+type RPCLatencyReport struct {
+	Methods     []RPCMethodLatency `json:"methods"`
+	SlowQueries []RPCSlowQuery     `json:"slowQueries"`
+}
+
+type rpcMethodStats struct {
+	latency     metrics.Histogram // in microseconds
+	slowCounter metrics.Counter
+}
+
+// RPCSLOTracker times http RPC calls by method, logging those slower than their method's SLO.
+type RPCSLOTracker struct {
+	config *RPCSLOConfig
+	slos   []rpcMethodSLO
+
+	mutex       sync.Mutex
+	methods     map[string]*rpcMethodStats
+	slowQueries []RPCSlowQuery // a ring of the most recent, oldest at slowNext once full
+	slowNext    int
+}
+
+func NewRPCSLOTracker(config *RPCSLOConfig) (*RPCSLOTracker, error) {
+	slos, err := parseRPCMethodSLOs(config.MethodSLOs)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCSLOTracker{
+		config:  config,
+		slos:    slos,
+		methods: make(map[string]*rpcMethodStats),
+	}, nil
+}
+
+func (t *RPCSLOTracker) methodSLO(method string) time.Duration {
+	for _, slo := range t.slos {
+		if slo.prefix && strings.HasPrefix(method, slo.method) || !slo.prefix && method == slo.method {
+			return slo.slo
+		}
+	}
+	return t.config.DefaultSLO
+}
+
+func (t *RPCSLOTracker) stats(method string) *rpcMethodStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	stats, ok := t.methods[method]
+	if ok {
+		return stats
+	}
+	// Callers choose method names, so their number is bounded
+	if len(t.methods) >= t.config.MaxMethods && method != rpcSLOOtherMethod {
+		method = rpcSLOOtherMethod
+		if stats, ok := t.methods[method]; ok {
+			return stats
+		}
+	}
+	prefix := "arb/rpc/slo/" + method
+	stats = &rpcMethodStats{
+		latency:     metrics.GetOrRegisterHistogram(prefix+"/latency", nil, metrics.NewBoundedHistogramSample()),
+		slowCounter: metrics.GetOrRegisterCounter(prefix+"/slow", nil),
+	}
+	t.methods[method] = stats
+	return stats
+}
+
+type rpcSLORequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// parseRPCSLORequest returns the calls in a request body, or nil if it can't be parsed.
+func parseRPCSLORequest(body []byte) []rpcSLORequest {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []rpcSLORequest
+		if json.Unmarshal(trimmed, &batch) != nil {
+			return nil
+		}
+		return batch
+	}
+	var req rpcSLORequest
+	if json.Unmarshal(trimmed, &req) != nil {
+		return nil
+	}
+	return []rpcSLORequest{req}
+}
+
+// WrapHandler returns an http handler which times the calls served by next. Websocket calls aren't seen.
+func (t *RPCSLOTracker) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var calls []rpcSLORequest
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRPCSLORequestSize+1))
+		if err != nil || len(body) > maxRPCSLORequestSize {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		} else {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			calls = parseRPCSLORequest(body)
+		}
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		t.record(calls, time.Since(start), r.RemoteAddr)
+	})
+}
+
+func (t *RPCSLOTracker) record(calls []rpcSLORequest, elapsed time.Duration, remote string) {
+	method := rpcSLOUnparsedMethod
+	slo := t.config.DefaultSLO
+	var batchMethods []string
+	var params json.RawMessage
+	if len(calls) == 1 {
+		method = calls[0].Method
+		slo = t.methodSLO(method)
+		params = calls[0].Params
+	} else if len(calls) > 1 {
+		// A batch is allowed the sum of its calls' SLOs
+		method = rpcSLOBatchMethod
+		slo = 0
+		for _, call := range calls {
+			batchMethods = append(batchMethods, call.Method)
+			slo += t.methodSLO(call.Method)
+		}
+	}
+	stats := t.stats(method)
+	stats.latency.Update(elapsed.Microseconds())
+	if elapsed <= slo {
+		return
+	}
+	stats.slowCounter.Inc(1)
+	rpcSlowQueriesCounter.Inc(1)
+	paramsSummary := string(params)
+	if len(paramsSummary) > t.config.MaxParamsLength {
+		paramsSummary = paramsSummary[:t.config.MaxParamsLength] + "..."
+	}
+	log.Warn("slow RPC call", "method", method, "calls", batchMethods, "elapsed", elapsed, "slo", slo, "params", paramsSummary, "remote", remote)
+	t.addSlowQuery(RPCSlowQuery{
+		Time:      time.Now(),
+		Method:    method,
+		Calls:     batchMethods,
+		ElapsedMs: durationMs(elapsed),
+		SLOMs:     durationMs(slo),
+		Params:    paramsSummary,
+		Remote:    remote,
+	})
+}
+
+func (t *RPCSLOTracker) addSlowQuery(query RPCSlowQuery) {
+	if t.config.SlowQueries <= 0 {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.slowQueries) < t.config.SlowQueries {
+		t.slowQueries = append(t.slowQueries, query)
+		return
+	}
+	t.slowQueries[t.slowNext] = query
+	t.slowNext = (t.slowNext + 1) % len(t.slowQueries)
+}
+
+func durationMs(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// Report returns the latency of each method called, slowest p99 first, and the recent slow calls, newest first.
+func (t *RPCSLOTracker) Report() *RPCLatencyReport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	report := &RPCLatencyReport{
+		Methods:     make([]RPCMethodLatency, 0, len(t.methods)),
+		SlowQueries: make([]RPCSlowQuery, 0, len(t.slowQueries)),
+	}
+	for method, stats := range t.methods {
+		snapshot := stats.latency.Snapshot()
+		percentiles := snapshot.Percentiles([]float64{0.5, 0.9, 0.99})
+		slo := t.config.DefaultSLO
+		if method != rpcSLOBatchMethod && method != rpcSLOOtherMethod && method != rpcSLOUnparsedMethod {
+			slo = t.methodSLO(method)
+		}
+		latency := RPCMethodLatency{
+			Method: method,
+			Calls:  snapshot.Count(),
+			Slow:   stats.slowCounter.Snapshot().Count(),
+			SLOMs:  durationMs(slo),
+			P50Ms:  percentiles[0] / 1000,
+			P90Ms:  percentiles[1] / 1000,
+			P99Ms:  percentiles[2] / 1000,
+			MaxMs:  float64(snapshot.Max()) / 1000,
+		}
+		// Batches don't have an SLO of their own
+		latency.Exceeded = method != rpcSLOBatchMethod && latency.P99Ms > latency.SLOMs
+		report.Methods = append(report.Methods, latency)
+	}
+	sort.Slice(report.Methods, func(i, j int) bool {
+		return report.Methods[i].P99Ms > report.Methods[j].P99Ms
+	})
+	for i := len(t.slowQueries) - 1; i >= 0; i-- {
+		report.SlowQueries = append(report.SlowQueries, t.slowQueries[(t.slowNext+i)%len(t.slowQueries)])
+	}
+	return report
+}
+
+type RPCSLOAPI struct {
+	tracker *RPCSLOTracker
+}
+
+func NewRPCSLOAPI(tracker *RPCSLOTracker) *RPCSLOAPI {
+	return &RPCSLOAPI{tracker}
+}
+
+func (a *RPCSLOAPI) RpcLatencyReport() *RPCLatencyReport {
+	return a.tracker.Report()
+}
@@ -0,0 +1,115 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRPCMethodSLOs(t *testing.T) {
+	config := DefaultRPCSLOConfig
+	config.Enable = true
+	config.DefaultSLO = time.Second
+	config.MethodSLOs = []string{"eth_*=2s", "eth_getLogs=3s", "eth_get*=4s"}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := NewRPCSLOTracker(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]time.Duration{
+		"eth_getLogs":      3 * time.Second,
+		"eth_getBalance":   4 * time.Second,
+		"eth_call":         2 * time.Second,
+		"arb_traceBlock":   time.Second,
+		"eth_getLogsExtra": 4 * time.Second,
+	}
+	for method, slo := range expected {
+		if got := tracker.methodSLO(method); got != slo {
+			t.Errorf("expected %v to have slo %v but got %v", method, slo, got)
+		}
+	}
+
+	for _, invalid := range []string{"eth_call", "eth_*call=1s", "eth_call=0s", "=1s"} {
+		config.MethodSLOs = []string{invalid}
+		if config.Validate() == nil {
+			t.Errorf("expected method slo %v to be invalid", invalid)
+		}
+	}
+}
+
+func TestRPCSLOTracker(t *testing.T) {
+	config := DefaultRPCSLOConfig
+	config.Enable = true
+	config.DefaultSLO = 5 * time.Millisecond
+	config.MethodSLOs = []string{"eth_getLogs=1h"}
+	config.MaxMethods = 3
+	config.MaxParamsLength = 8
+	config.SlowQueries = 2
+	tracker, err := NewRPCSLOTracker(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := tracker.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	call := func(body string) {
+		t.Helper()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	}
+	single := func(method string, params string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`
+	}
+
+	call(single("eth_getLogs", `[{"fromBlock":"0x0"}]`))
+	call(single("eth_call", `[{"to":"0x0000000000000000000000000000000000000000"}]`))
+	call("[" + single("eth_getLogs", "[]") + "," + single("eth_getLogs", "[]") + "]")
+	// Methods past the limit are tracked together
+	call(single("eth_unknownA", "[]"))
+	call(single("eth_unknownB", "[]"))
+
+	report := tracker.Report()
+	methods := make(map[string]RPCMethodLatency)
+	for _, method := range report.Methods {
+		methods[method.Method] = method
+	}
+	for _, method := range []string{"eth_getLogs", "eth_call", rpcSLOBatchMethod, rpcSLOOtherMethod} {
+		if _, ok := methods[method]; !ok {
+			t.Errorf("expected %v in report %v", method, report.Methods)
+		}
+	}
+	if len(methods) != 4 {
+		t.Error("expected 4 methods to be tracked but got", report.Methods)
+	}
+	if methods["eth_getLogs"].Exceeded || !methods["eth_call"].Exceeded {
+		t.Error("unexpected slo exceeded", report.Methods)
+	}
+
+	// Only the most recent slow calls are kept, newest first
+	if len(report.SlowQueries) != 2 {
+		t.Fatal("expected 2 slow queries but got", report.SlowQueries)
+	}
+	if report.SlowQueries[0].Method != rpcSLOOtherMethod || report.SlowQueries[1].Method != rpcSLOOtherMethod {
+		t.Error("unexpected slow queries", report.SlowQueries)
+	}
+
+	tracker, err = NewRPCSLOTracker(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = tracker.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	call(single("eth_call", `[{"to":"0x0000000000000000000000000000000000000000"}]`))
+	report = tracker.Report()
+	if len(report.SlowQueries) != 1 || report.SlowQueries[0].Params != `[{"to":"...` {
+		t.Error("expected slow query params to be truncated", report.SlowQueries)
+	}
+}
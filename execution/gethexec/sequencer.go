@@ -17,14 +17,18 @@ import (
 
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/execution/txscreener"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
 	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 	flag "github.com/spf13/pflag"
 
 	"github.com/ethereum/go-ethereum/arbitrum"
 	"github.com/ethereum/go-ethereum/arbitrum_types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -37,6 +41,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/expresslane"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
@@ -61,21 +66,33 @@ var (
 )
 
 type SequencerConfig struct {
-	Enable                       bool            `koanf:"enable"`
-	MaxBlockSpeed                time.Duration   `koanf:"max-block-speed" reload:"hot"`
-	MaxRevertGasReject           uint64          `koanf:"max-revert-gas-reject" reload:"hot"`
-	MaxAcceptableTimestampDelta  time.Duration   `koanf:"max-acceptable-timestamp-delta" reload:"hot"`
-	SenderWhitelist              []string        `koanf:"sender-whitelist"`
-	Forwarder                    ForwarderConfig `koanf:"forwarder"`
-	QueueSize                    int             `koanf:"queue-size"`
-	QueueTimeout                 time.Duration   `koanf:"queue-timeout" reload:"hot"`
-	NonceCacheSize               int             `koanf:"nonce-cache-size" reload:"hot"`
-	MaxTxDataSize                int             `koanf:"max-tx-data-size" reload:"hot"`
-	NonceFailureCacheSize        int             `koanf:"nonce-failure-cache-size" reload:"hot"`
-	NonceFailureCacheExpiry      time.Duration   `koanf:"nonce-failure-cache-expiry" reload:"hot"`
-	ExpectedSurplusSoftThreshold string          `koanf:"expected-surplus-soft-threshold" reload:"hot"`
-	ExpectedSurplusHardThreshold string          `koanf:"expected-surplus-hard-threshold" reload:"hot"`
-	EnableProfiling              bool            `koanf:"enable-profiling" reload:"hot"`
+	Enable                       bool                    `koanf:"enable"`
+	MaxBlockSpeed                time.Duration           `koanf:"max-block-speed" reload:"hot"`
+	MaxRevertGasReject           uint64                  `koanf:"max-revert-gas-reject" reload:"hot"`
+	MaxAcceptableTimestampDelta  time.Duration           `koanf:"max-acceptable-timestamp-delta" reload:"hot"`
+	SenderWhitelist              []string                `koanf:"sender-whitelist"`
+	Forwarder                    ForwarderConfig         `koanf:"forwarder"`
+	QueueSize                    int                     `koanf:"queue-size"`
+	QueueTimeout                 time.Duration           `koanf:"queue-timeout" reload:"hot"`
+	NonceCacheSize               int                     `koanf:"nonce-cache-size" reload:"hot"`
+	MaxTxDataSize                int                     `koanf:"max-tx-data-size" reload:"hot"`
+	NonceFailureCacheSize        int                     `koanf:"nonce-failure-cache-size" reload:"hot"`
+	NonceFailureCacheExpiry      time.Duration           `koanf:"nonce-failure-cache-expiry" reload:"hot"`
+	ExpectedSurplusSoftThreshold string                  `koanf:"expected-surplus-soft-threshold" reload:"hot"`
+	ExpectedSurplusHardThreshold string                  `koanf:"expected-surplus-hard-threshold" reload:"hot"`
+	EnableProfiling              bool                    `koanf:"enable-profiling" reload:"hot"`
+	AsyncBlockWrites             bool                    `koanf:"async-block-writes"`
+	Freeze                       bool                    `koanf:"freeze"`
+	RecordSequencingTimestamps   bool                    `koanf:"record-sequencing-timestamps"`
+	InclusionReceipts            InclusionReceiptsConfig `koanf:"inclusion-receipts"`
+	Screener                     txscreener.Config       `koanf:"screener"`
+	ClockSkew                    ClockSkewConfig         `koanf:"clock-skew"`
+	BlockBuilder                 BlockBuilderConfig      `koanf:"block-builder"`
+	FairOrdering                 FairOrderingConfig      `koanf:"fair-ordering"`
+	ExpressLane                  ExpressLaneConfig       `koanf:"express-lane"`
+	RegionalArrivals             RegionalArrivalsConfig  `koanf:"regional-arrivals"`
+	BlockSpeedTuner              BlockSpeedTunerConfig   `koanf:"block-speed-tuner"`
+	DuplicateTx                  DuplicateTxConfig       `koanf:"duplicate-tx"`
 	expectedSurplusSoftThreshold int
 	expectedSurplusHardThreshold int
 }
@@ -106,7 +123,31 @@ func (c *SequencerConfig) Validate() error {
 	if c.MaxTxDataSize > arbostypes.MaxL2MessageSize-50000 {
 		return errors.New("max-tx-data-size too large for MaxL2MessageSize")
 	}
-	return nil
+	if err := c.ClockSkew.Validate(); err != nil {
+		return err
+	}
+	if err := c.BlockBuilder.Validate(); err != nil {
+		return err
+	}
+	if err := c.FairOrdering.Validate(); err != nil {
+		return err
+	}
+	if err := c.ExpressLane.Validate(); err != nil {
+		return err
+	}
+	if err := c.RegionalArrivals.Validate(); err != nil {
+		return err
+	}
+	if err := c.BlockSpeedTuner.Validate(); err != nil {
+		return err
+	}
+	if err := c.DuplicateTx.Validate(); err != nil {
+		return err
+	}
+	if err := c.InclusionReceipts.Validate(); err != nil {
+		return err
+	}
+	return c.Screener.Validate()
 }
 
 type SequencerConfigFetcher func() *SequencerConfig
@@ -129,6 +170,18 @@ var DefaultSequencerConfig = SequencerConfig{
 	ExpectedSurplusSoftThreshold: "default",
 	ExpectedSurplusHardThreshold: "default",
 	EnableProfiling:              false,
+	AsyncBlockWrites:             false,
+	Freeze:                       false,
+	RecordSequencingTimestamps:   false,
+	InclusionReceipts:            DefaultInclusionReceiptsConfig,
+	Screener:                     txscreener.DefaultConfig,
+	ClockSkew:                    DefaultClockSkewConfig,
+	BlockBuilder:                 DefaultBlockBuilderConfig,
+	FairOrdering:                 DefaultFairOrderingConfig,
+	ExpressLane:                  DefaultExpressLaneConfig,
+	RegionalArrivals:             DefaultRegionalArrivalsConfig,
+	BlockSpeedTuner:              DefaultBlockSpeedTunerConfig,
+	DuplicateTx:                  DefaultDuplicateTxConfig,
 }
 
 func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -147,6 +200,18 @@ func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".expected-surplus-soft-threshold", DefaultSequencerConfig.ExpectedSurplusSoftThreshold, "if expected surplus is lower than this value, warnings are posted")
 	f.String(prefix+".expected-surplus-hard-threshold", DefaultSequencerConfig.ExpectedSurplusHardThreshold, "if expected surplus is lower than this value, new incoming transactions will be denied")
 	f.Bool(prefix+".enable-profiling", DefaultSequencerConfig.EnableProfiling, "enable CPU profiling and tracing")
+	f.Bool(prefix+".async-block-writes", DefaultSequencerConfig.AsyncBlockWrites, "write sequenced blocks in the background so committing the trie overlaps with producing the next block (a block is always written before the next message is published)")
+	txscreener.ConfigAddOptions(prefix+".screener", f)
+	ClockSkewConfigAddOptions(prefix+".clock-skew", f)
+	BlockBuilderConfigAddOptions(prefix+".block-builder", f)
+	FairOrderingConfigAddOptions(prefix+".fair-ordering", f)
+	ExpressLaneConfigAddOptions(prefix+".express-lane", f)
+	RegionalArrivalsConfigAddOptions(prefix+".regional-arrivals", f)
+	BlockSpeedTunerConfigAddOptions(prefix+".block-speed-tuner", f)
+	DuplicateTxConfigAddOptions(prefix+".duplicate-tx", f)
+	InclusionReceiptsConfigAddOptions(prefix+".inclusion-receipts", f)
+	f.Bool(prefix+".freeze", DefaultSequencerConfig.Freeze, "start with block production frozen, until resumed through the sequencer_resume RPC method")
+	f.Bool(prefix+".record-sequencing-timestamps", DefaultSequencerConfig.RecordSequencingTimestamps, "record when each transaction was sequenced with millisecond precision, served by the arb_sequencingTimestamp and arb_blockSequencingTimestamps RPC methods")
 }
 
 type txQueueItem struct {
@@ -157,6 +222,12 @@ type txQueueItem struct {
 	returnedResult  *atomic.Bool
 	ctx             context.Context
 	firstAppearance time.Time
+	// Part of a builder payload, which is sequenced as is rather than retried
+	fromBuilder bool
+	// Submitted by the express lane controller, and sequenced ahead of other transactions
+	expressLane bool
+	// The region of the ingress it arrived at, if it was forwarded by one
+	region string
 }
 
 func (i *txQueueItem) returnResult(err error) {
@@ -291,15 +362,26 @@ func (c nonceFailureCache) Add(err NonceError, queueItem txQueueItem) {
 type Sequencer struct {
 	stopwaiter.StopWaiter
 
-	execEngine      *ExecutionEngine
-	txQueue         chan txQueueItem
-	txRetryQueue    containers.Queue[txQueueItem]
-	l1Reader        *headerreader.HeaderReader
-	config          SequencerConfigFetcher
-	senderWhitelist map[common.Address]struct{}
-	nonceCache      *nonceCache
-	nonceFailures   *nonceFailureCache
-	onForwarderSet  chan struct{}
+	execEngine       *ExecutionEngine
+	txQueue          chan txQueueItem
+	txRetryQueue     containers.Queue[txQueueItem]
+	l1Reader         *headerreader.HeaderReader
+	config           SequencerConfigFetcher
+	senderWhitelist  map[common.Address]struct{}
+	screener         *txscreener.Screener // nil unless enabled
+	clockSkew        *ClockSkewMonitor    // nil unless enabled
+	blockSpeed       *BlockSpeedTuner     // nil unless enabled
+	builder          *blockBuilder        // nil unless enabled
+	fairOrdering     *fairOrderingSeeds   // nil unless enabled
+	expressLane      *expressLaneQueue    // nil unless enabled
+	regionalArrivals *regionalArrivals    // nil unless enabled
+	loadShedder      *LoadShedder         // nil unless enabled
+	duplicates       *duplicateTxFilter   // nil unless enabled
+	txOutcomeHook    TxOutcomeHook        // nil unless set
+	nonceCache       *nonceCache
+	nonceFailures    *nonceFailureCache
+	onForwarderSet   chan struct{}
+	arbosMaxTxSize   atomic.Uint64 // the chain owner's transaction size limit as of the latest block, or 0 if unset
 
 	L1BlockAndTimeMutex sync.Mutex
 	l1BlockNumber       atomic.Uint64
@@ -344,11 +426,69 @@ func NewSequencer(execEngine *ExecutionEngine, l1Reader *headerreader.HeaderRead
 		containers.NewLruCacheWithOnEvict(config.NonceCacheSize, s.onNonceFailureEvict),
 		func() time.Duration { return configFetcher().NonceFailureCacheExpiry },
 	}
+	if config.Screener.Enable {
+		screenerConfig := config.Screener
+		screener, err := txscreener.New(context.Background(), &screenerConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.screener = screener
+	}
+	if config.DuplicateTx.Enable {
+		s.duplicates = newDuplicateTxFilter(func() *DuplicateTxConfig { return &configFetcher().DuplicateTx })
+	}
+	if config.ClockSkew.Enable {
+		s.clockSkew = NewClockSkewMonitor(func() *ClockSkewConfig { return &configFetcher().ClockSkew })
+	}
+	if config.BlockSpeedTuner.Enable {
+		s.blockSpeed = NewBlockSpeedTuner(
+			func() *BlockSpeedTunerConfig { return &configFetcher().BlockSpeedTuner },
+			func() time.Duration { return configFetcher().MaxBlockSpeed },
+			engineBlockSpeedSignals{engine: execEngine},
+		)
+	}
+	if config.BlockBuilder.Enable {
+		s.builder = newBlockBuilder(func() *BlockBuilderConfig { return &configFetcher().BlockBuilder })
+	}
+	if config.FairOrdering.Enable {
+		seeds, err := newFairOrderingSeeds(config.FairOrdering.RevealedSeeds)
+		if err != nil {
+			return nil, err
+		}
+		s.fairOrdering = seeds
+	}
+	if config.ExpressLane.Enable {
+		s.expressLane = newExpressLaneQueue(func() *ExpressLaneConfig { return &configFetcher().ExpressLane })
+	}
+	if config.RegionalArrivals.Enable {
+		arrivals, err := newRegionalArrivals(func() *RegionalArrivalsConfig { return &configFetcher().RegionalArrivals })
+		if err != nil {
+			return nil, err
+		}
+		s.regionalArrivals = arrivals
+	}
 	s.Pause()
 	execEngine.EnableReorgSequencing()
+	if config.Freeze {
+		if _, err := execEngine.Freeze("frozen by configuration"); err != nil {
+			return nil, err
+		}
+	}
 	return s, nil
 }
 
+// TxOutcomeHook is told the outcome of each transaction submitted to this sequencer: a nil error once it's
+// included in a block, or why it was rejected or timed out.
+type TxOutcomeHook func(tx *types.Transaction, submitted time.Time, err error)
+
+// SetTxOutcomeHook must be called before the sequencer starts.
+func (s *Sequencer) SetTxOutcomeHook(hook TxOutcomeHook) {
+	if s.Started() {
+		panic("trying to set tx outcome hook after start")
+	}
+	s.txOutcomeHook = hook
+}
+
 func (s *Sequencer) onNonceFailureEvict(_ addressAndNonce, failure *nonceFailure) {
 	if failure.revived {
 		return
@@ -386,6 +526,12 @@ func ctxWithTimeout(ctx context.Context, timeout time.Duration) (context.Context
 }
 
 func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	return s.publishTransaction(parentCtx, tx, options, nil)
+}
+
+// publishTransaction forwards or sequences a transaction. A regional arrival is only kept if it's sequenced
+// here, as the forwarding target orders it by when it receives it.
+func (s *Sequencer) publishTransaction(parentCtx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, arrival *regionalArrival) error {
 	config := s.config()
 	// Only try to acquire Rlock and check for hard threshold if l1reader is not nil
 	// And hard threshold was enabled, this prevents spamming of read locks when not needed
@@ -408,23 +554,188 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 		}
 	}
 
-	if len(s.senderWhitelist) > 0 {
-		signer := types.LatestSigner(s.execEngine.bc.Config())
-		sender, err := types.Sender(signer, tx)
-		if err != nil {
+	if s.execEngine.IsFrozen() {
+		return execution.ErrSequencerFrozen
+	}
+
+	submitted := time.Now()
+	err := s.publishLocally(parentCtx, tx, options, arrival)
+	if s.txOutcomeHook != nil {
+		s.txOutcomeHook(tx, submitted, err)
+	}
+	return err
+}
+
+// publishLocally sequences a transaction this sequencer accepted rather than forwarded.
+func (s *Sequencer) publishLocally(parentCtx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, arrival *regionalArrival) error {
+	if err := s.checkTxPolicies(parentCtx, tx); err != nil {
+		return err
+	}
+	if s.duplicates != nil {
+		if err := s.duplicates.admit(tx.Hash(), time.Now()); err != nil {
+			duplicateTxRejectedCounter.Inc(1)
 			return err
 		}
-		_, authorized := s.senderWhitelist[sender]
-		if !authorized {
-			return errors.New("transaction sender is not on the whitelist")
+	}
+
+	err := s.queueTransaction(parentCtx, tx, options, false, func(queueItem txQueueItem) error {
+		if arrival != nil {
+			queueItem.firstAppearance = s.regionalArrivals.admit(arrival.region, arrival.arrived, arrival.received)
+			queueItem.region = arrival.region
+		}
+		select {
+		case s.txQueue <- queueItem:
+			return nil
+		case <-queueItem.ctx.Done():
+			return queueError(parentCtx, queueItem.ctx.Err())
 		}
+	})
+	if err != nil && s.duplicates != nil {
+		// Let the sender retry a transaction that failed, e.g. once the nonce gap before it is filled
+		s.duplicates.forget(tx.Hash())
 	}
-	if tx.Type() >= types.ArbitrumDepositTxType || tx.Type() == types.BlobTxType {
-		// Should be unreachable for Arbitrum types due to UnmarshalBinary not accepting Arbitrum internal txs
-		// and we want to disallow BlobTxType since Arbitrum doesn't support EIP-4844 txs yet.
-		return types.ErrTxTypeNotSupported
+	return err
+}
+
+// PublishRegionalTransaction sequences a transaction forwarded by a regional ingress, ordered by when it
+// arrived there rather than when it arrived here.
+func (s *Sequencer) PublishRegionalTransaction(parentCtx context.Context, submission *RegionalSubmission) error {
+	received := time.Now()
+	if s.regionalArrivals == nil {
+		return errors.New("regional arrivals are not enabled")
+	}
+	if err := s.regionalArrivals.check(submission, s.execEngine.bc.Config().ChainID); err != nil {
+		regionalArrivalsRejectedCounter.Inc(1)
+		return err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(submission.Transaction); err != nil {
+		regionalArrivalsRejectedCounter.Inc(1)
+		return err
+	}
+	arrival := &regionalArrival{
+		region: submission.Region,
+		// #nosec G115
+		arrived:  time.UnixMilli(int64(submission.ArrivedAt)),
+		received: received,
+	}
+	return s.publishTransaction(parentCtx, tx, nil, arrival)
+}
+
+// PublishExpressLaneTransaction sequences a transaction from the current round's express lane controller
+// ahead of the normal queue. Submissions arriving ahead of their sequence number wait for the ones before them.
+func (s *Sequencer) PublishExpressLaneTransaction(parentCtx context.Context, submission *ExpressLaneSubmission) error {
+	if s.expressLane == nil {
+		return errors.New("the express lane is not enabled")
+	}
+	// Forwarding would lose the transaction's priority, so only the active sequencer accepts it
+	if pause, forwarder := s.GetPauseAndForwarder(); pause != nil || forwarder != nil {
+		return errors.New("not the active sequencer")
+	}
+	if s.execEngine.IsFrozen() {
+		return execution.ErrSequencerFrozen
+	}
+	round, err := s.checkExpressLaneSubmission(submission)
+	if err != nil {
+		expressLaneRejectedCounter.Inc(1)
+		return err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(submission.Transaction); err != nil {
+		expressLaneRejectedCounter.Inc(1)
+		return err
+	}
+	if err := s.checkTxPolicies(parentCtx, tx); err != nil {
+		return err
+	}
+
+	sequencerBacklogGauge.Inc(1)
+	defer sequencerBacklogGauge.Dec(1)
+	expressLaneAcceptedCounter.Inc(1)
+	return s.queueTransaction(parentCtx, tx, nil, true, func(queueItem txQueueItem) error {
+		return s.expressLane.submit(round, uint64(submission.SequenceNumber), queueItem)
+	})
+}
+
+// DelegateExpressLane records the current round's express lane controller delegating the right to submit
+// in its lane to another key. Only the active sequencer enforces the delegation, so it isn't forwarded.
+func (s *Sequencer) DelegateExpressLane(delegation *ExpressLaneDelegation) error {
+	if s.expressLane == nil {
+		return errors.New("the express lane is not enabled")
+	}
+	if pause, forwarder := s.GetPauseAndForwarder(); pause != nil || forwarder != nil {
+		return errors.New("not the active sequencer")
+	}
+	round, controller, err := s.expressLaneController(delegation.ChainId, uint64(delegation.Round))
+	if err != nil {
+		return err
+	}
+	signer, err := delegation.signer()
+	if err != nil {
+		return err
+	}
+	if signer != controller {
+		return fmt.Errorf("express lane delegation signed by %v but round %v is controlled by %v", signer, round, controller)
+	}
+	return s.expressLane.delegate(round, uint64(delegation.Nonce), delegation.Delegate)
+}
+
+// checkExpressLaneSubmission checks a submission is for the current round and signed by its controller,
+// as recorded in ArbOS, or by whoever the controller delegated to, returning the round.
+func (s *Sequencer) checkExpressLaneSubmission(submission *ExpressLaneSubmission) (uint64, error) {
+	round, controller, err := s.expressLaneController(submission.ChainId, uint64(submission.Round))
+	if err != nil {
+		return 0, err
+	}
+	signer, err := submission.signer()
+	if err != nil {
+		return 0, err
+	}
+	if submitter := s.expressLane.submitter(round, controller); signer != submitter {
+		return 0, fmt.Errorf("express lane submission signed by %v but round %v's submitter is %v", signer, round, submitter)
+	}
+	return round, nil
+}
+
+// expressLaneController checks a message to the express lane is for this chain and the current round,
+// returning the round and its controller as recorded in ArbOS.
+func (s *Sequencer) expressLaneController(msgChainId *hexutil.Big, msgRound uint64) (uint64, common.Address, error) {
+	chainId := s.execEngine.bc.Config().ChainID
+	if msgChainId == nil || msgChainId.ToInt().Cmp(chainId) != 0 {
+		return 0, common.Address{}, fmt.Errorf("express lane message is for chain %v but this is chain %v", msgChainId, chainId)
+	}
+	_, statedb, err := s.execEngine.latestHeaderAndState()
+	if err != nil {
+		return 0, common.Address{}, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return 0, common.Address{}, err
+	}
+	if arbState.ArbOSVersion() < arbosState.ArbosVersion_40 {
+		return 0, common.Address{}, expresslane.ErrDisabled
+	}
+	// The next block is timestamped now, so the round it's in is the current one
+	round, err := arbState.ExpressLane().Round(uint64(time.Now().Unix()))
+	if err != nil {
+		return 0, common.Address{}, err
+	}
+	if msgRound != round {
+		return 0, common.Address{}, fmt.Errorf("express lane message is for round %v but the current round is %v", msgRound, round)
 	}
+	controller, err := arbState.ExpressLane().Controller(round)
+	if err != nil {
+		return 0, common.Address{}, err
+	}
+	if controller == (common.Address{}) {
+		return 0, common.Address{}, fmt.Errorf("no express lane controller for round %v", round)
+	}
+	return round, controller, nil
+}
 
+// queueTransaction hands a transaction to enqueue for block creation, and waits for its result.
+func (s *Sequencer) queueTransaction(parentCtx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, expressLane bool, enqueue func(txQueueItem) error) error {
+	config := s.config()
 	txBytes, err := tx.MarshalBinary()
 	if err != nil {
 		return err
@@ -440,23 +751,23 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 
 	resultChan := make(chan error, 1)
 	queueItem := txQueueItem{
-		tx,
-		len(txBytes),
-		options,
-		resultChan,
-		&atomic.Bool{},
-		queueCtx,
-		time.Now(),
-	}
-	select {
-	case s.txQueue <- queueItem:
-	case <-queueCtx.Done():
-		return queueCtx.Err()
+		tx:              tx,
+		txSize:          len(txBytes),
+		options:         options,
+		resultChan:      resultChan,
+		returnedResult:  &atomic.Bool{},
+		ctx:             queueCtx,
+		firstAppearance: time.Now(),
+		fromBuilder:     false,
+		expressLane:     expressLane,
+	}
+	if err := enqueue(queueItem); err != nil {
+		return err
 	}
 
 	select {
 	case res := <-resultChan:
-		return res
+		return queueError(parentCtx, res)
 	case <-abortCtx.Done():
 		// We use abortCtx here and not queueCtx, because the QueueTimeout only applies to the background queue.
 		// We want to give the background queue as much time as possible to make a response.
@@ -469,7 +780,115 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 	}
 }
 
-func (s *Sequencer) preTxFilter(_ *params.ChainConfig, header *types.Header, statedb *state.StateDB, _ *arbosState.ArbosState, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, sender common.Address, l1Info *arbos.L1Info) error {
+// queueError gives a transaction that timed out waiting in the queue the queue full error code, unless the
+// submitter gave up first.
+func queueError(parentCtx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && parentCtx.Err() == nil {
+		return rpcerrors.Errorf(rpcerrors.CodeSequencerQueueFull, "sequencer queue is full: %w", err)
+	}
+	return err
+}
+
+// checkTxPolicies checks that the sequencer accepts the transaction from its sender.
+func (s *Sequencer) checkTxPolicies(ctx context.Context, tx *types.Transaction) error {
+	if len(s.senderWhitelist) > 0 {
+		signer := types.LatestSigner(s.execEngine.bc.Config())
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		_, authorized := s.senderWhitelist[sender]
+		if !authorized {
+			return errors.New("transaction sender is not on the whitelist")
+		}
+	}
+	if tx.Type() >= types.ArbitrumDepositTxType || tx.Type() == types.BlobTxType {
+		// Should be unreachable for Arbitrum types due to UnmarshalBinary not accepting Arbitrum internal txs
+		// and we want to disallow BlobTxType since Arbitrum doesn't support EIP-4844 txs yet.
+		return types.ErrTxTypeNotSupported
+	}
+	if s.screener != nil {
+		return s.screenTransaction(ctx, tx)
+	}
+	return nil
+}
+
+// screenTransaction runs the screening policy on a transaction, waiting out any delay it asks for.
+func (s *Sequencer) screenTransaction(ctx context.Context, tx *types.Transaction) error {
+	signer := types.LatestSigner(s.execEngine.bc.Config())
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	result, err := s.screener.Screen(ctx, sender, tx)
+	if err != nil {
+		return err
+	}
+	switch result.Decision {
+	case txscreener.Reject:
+		return fmt.Errorf("%w (reason %v)", txscreener.ErrRejected, result.Reason)
+	case txscreener.Delay:
+		timer := time.NewTimer(result.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// maxTxDataSize is the largest transaction, and block, the sequencer builds: the chain owner's limit if
+// they set one, or the configured limit otherwise.
+func (s *Sequencer) maxTxDataSize(config *SequencerConfig) int {
+	if limit := s.arbosMaxTxSize.Load(); limit != 0 {
+		return int(limit) // #nosec G115 bounded by arbosState.MaxTxSizeLimit
+	}
+	return config.MaxTxDataSize
+}
+
+// updateArbosMaxTxSize reads the chain owner's transaction size limit from the latest block,
+// so a block is never built against a limit that changed since the last transaction was filtered.
+func (s *Sequencer) updateArbosMaxTxSize() {
+	_, statedb, err := s.execEngine.latestHeaderAndState()
+	if err != nil {
+		log.Warn("failed to get the latest state to read the max tx size", "err", err)
+		return
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		log.Warn("failed to open the ArbOS state to read the max tx size", "err", err)
+		return
+	}
+	maxTxSize, err := arbState.MaxTxSize()
+	if err != nil {
+		log.Warn("failed to read the max tx size", "err", err)
+		return
+	}
+	s.arbosMaxTxSize.Store(maxTxSize)
+}
+
+func (s *Sequencer) preTxFilter(_ *params.ChainConfig, header *types.Header, statedb *state.StateDB, arbState *arbosState.ArbosState, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, sender common.Address, l1Info *arbos.L1Info) error {
+	maxTxSize, err := arbState.MaxTxSize()
+	if err != nil {
+		return err
+	}
+	s.arbosMaxTxSize.Store(maxTxSize)
+	frozenSince, err := arbState.SequencerFrozenSince()
+	if err != nil {
+		return err
+	}
+	if frozenSince != 0 {
+		// Chain owners may still transact, so they can lift the freeze
+		isOwner, err := arbState.ChainOwners().IsMember(sender)
+		if err != nil {
+			return err
+		}
+		if !isOwner {
+			return execution.ErrSequencerFrozen
+		}
+	}
 	if s.nonceCache.Caching() {
 		stateNonce := s.nonceCache.Get(header, statedb, sender)
 		err := MakeNonceError(sender, tx.Nonce(), stateNonce)
@@ -482,7 +901,7 @@ func (s *Sequencer) preTxFilter(_ *params.ChainConfig, header *types.Header, sta
 		err := options.Check(l1Info.L1BlockNumber(), header.Time, statedb)
 		if err != nil {
 			conditionalTxRejectedBySequencerCounter.Inc(1)
-			return err
+			return rpcerrors.WithDefault(rpcerrors.CodeConditionalRejected, err)
 		}
 		conditionalTxAcceptedBySequencerCounter.Inc(1)
 	}
@@ -587,7 +1006,7 @@ func (s *Sequencer) Pause() {
 	}
 }
 
-var ErrNoSequencer = errors.New("sequencer temporarily not available")
+var ErrNoSequencer = rpcerrors.New(rpcerrors.CodeSequencerUnavailable, "sequencer temporarily not available")
 
 func (s *Sequencer) GetPauseAndForwarder() (chan struct{}, *TxForwarder) {
 	s.activeMutex.Lock()
@@ -668,10 +1087,9 @@ func (s *Sequencer) expireNonceFailures() *time.Timer {
 
 // There's no guarantee that returned tx nonces will be correct
 func (s *Sequencer) precheckNonces(queueItems []txQueueItem, totalBlockSize int) []txQueueItem {
-	config := s.config()
+	maxTxDataSize := s.maxTxDataSize(s.config())
 	bc := s.execEngine.bc
-	latestHeader := bc.CurrentBlock()
-	latestState, err := bc.StateAt(latestHeader.Root)
+	latestHeader, latestState, err := s.execEngine.latestHeaderAndState()
 	if err != nil {
 		log.Error("failed to get current state to pre-check nonces", "err", err)
 		return queueItems
@@ -718,7 +1136,7 @@ func (s *Sequencer) precheckNonces(queueItems []txQueueItem, totalBlockSize int)
 				if err != nil {
 					revivingFailure.queueItem.returnResult(err)
 				} else {
-					if arbmath.SaturatingAdd(totalBlockSize, revivingFailure.queueItem.txSize) > config.MaxTxDataSize {
+					if arbmath.SaturatingAdd(totalBlockSize, revivingFailure.queueItem.txSize) > maxTxDataSize {
 						// This tx would be too large to add to this block
 						s.txRetryQueue.Push(revivingFailure.queueItem)
 					} else {
@@ -766,6 +1184,7 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		panicErr := recover()
 		if panicErr != nil {
 			log.Error("sequencer block creation panicked", "panic", panicErr, "backtrace", string(debug.Stack()))
+			flightrecorder.DumpPanic(panicErr)
 			// Return an internal error to any queue items we were trying to process
 			for _, item := range queueItems {
 				// This can race, but that's alright, worst case is a log line in returnResult
@@ -780,6 +1199,8 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	defer nonceFailureCacheSizeGauge.Update(int64(s.nonceFailures.Len()))
 
 	config := s.config()
+	s.updateArbosMaxTxSize()
+	maxTxDataSize := s.maxTxDataSize(config)
 
 	// Clear out old nonceFailures
 	s.nonceFailures.Resize(config.NonceFailureCacheSize)
@@ -791,8 +1212,18 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		}
 	}()
 
+	builderBlock := false
 	for {
 		var queueItem txQueueItem
+		if len(queueItems) == 0 {
+			// A builder payload replaces the queue for this block
+			if payload := s.builder.take(s.execEngine.bc.CurrentBlock().Hash()); payload != nil {
+				queueItems = payload.items
+				totalBlockSize = payload.size
+				builderBlock = true
+				break
+			}
+		}
 		if s.txRetryQueue.Len() > 0 {
 			queueItem = s.txRetryQueue.Pop()
 		} else if len(queueItems) == 0 {
@@ -801,11 +1232,14 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 				nextNonceExpiryChan = nextNonceExpiryTimer.C
 			}
 			select {
+			case queueItem = <-s.expressLane.items():
 			case queueItem = <-s.txQueue:
 			case <-nextNonceExpiryChan:
 				// No need to stop the previous timer since it already elapsed
 				nextNonceExpiryTimer = s.expireNonceFailures()
 				continue
+			case <-s.builder.readyChan():
+				continue
 			case <-s.onForwarderSet:
 				// Make sure this notification isn't outdated
 				_, forwarder := s.GetPauseAndForwarder()
@@ -819,9 +1253,13 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		} else {
 			done := false
 			select {
-			case queueItem = <-s.txQueue:
+			case queueItem = <-s.expressLane.items():
 			default:
-				done = true
+				select {
+				case queueItem = <-s.txQueue:
+				default:
+					done = true
+				}
 			}
 			if done {
 				break
@@ -832,12 +1270,12 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			queueItem.returnResult(err)
 			continue
 		}
-		if queueItem.txSize > config.MaxTxDataSize {
+		if queueItem.txSize > maxTxDataSize {
 			// This tx is too large
 			queueItem.returnResult(txpool.ErrOversizedData)
 			continue
 		}
-		if totalBlockSize+queueItem.txSize > config.MaxTxDataSize {
+		if totalBlockSize+queueItem.txSize > maxTxDataSize {
 			// This tx would be too large to add to this batch
 			s.txRetryQueue.Push(queueItem)
 			// End the batch here to put this tx in the next one
@@ -849,7 +1287,22 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 
 	s.nonceCache.Resize(config.NonceCacheSize) // Would probably be better in a config hook but this is basically free
 	s.nonceCache.BeginNewBlock()
-	queueItems = s.precheckNonces(queueItems, totalBlockSize)
+	var orderingSeed *common.Hash
+	if !builderBlock {
+		if s.fairOrdering != nil {
+			seed := s.fairOrdering.current()
+			orderingSeed = &seed
+			queueItems = fairOrder(queueItems, seed, config.FairOrdering.BucketDuration, types.LatestSigner(s.execEngine.bc.Config()))
+		} else if s.regionalArrivals != nil {
+			// Fair ordering already buckets by arrival, which regional transactions are stamped with
+			queueItems = s.regionalArrivals.order(queueItems, types.LatestSigner(s.execEngine.bc.Config()))
+		}
+		if s.expressLane != nil {
+			queueItems = expressLaneFirst(queueItems)
+		}
+		// Prechecking could pull revived transactions into the builder's ordering
+		queueItems = s.precheckNonces(queueItems, totalBlockSize)
+	}
 	txes := make([]*types.Transaction, len(queueItems))
 	hooks := s.makeSequencingHooks()
 	hooks.ConditionalOptionsForTx = make([]*arbitrum_types.ConditionalOptions, len(queueItems))
@@ -860,7 +1313,7 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		hooks.ConditionalOptionsForTx[i] = queueItem.options
 	}
 
-	if totalBlockSize > config.MaxTxDataSize {
+	if totalBlockSize > maxTxDataSize {
 		for _, queueItem := range queueItems {
 			s.txRetryQueue.Push(queueItem)
 		}
@@ -868,7 +1321,7 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			"put too many transactions in a block",
 			"numTxes", len(queueItems),
 			"totalBlockSize", totalBlockSize,
-			"maxTxDataSize", config.MaxTxDataSize,
+			"maxTxDataSize", maxTxDataSize,
 		)
 		return false
 	}
@@ -896,6 +1349,19 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		)
 		return true
 	}
+	if s.l1Reader != nil {
+		parentChainDriftGauge.Update(timestamp - int64(l1Timestamp)) // #nosec G115
+	}
+	if s.clockSkew != nil {
+		if err := s.clockSkew.Check(); err != nil {
+			for _, queueItem := range queueItems {
+				s.txRetryQueue.Push(queueItem)
+			}
+			clockSkewRefusedCounter.Inc(1)
+			log.Error("cannot sequence: local clock can't be trusted", "err", err, "localTimestamp", time.Unix(timestamp, 0))
+			return true
+		}
+	}
 
 	header := &arbostypes.L1IncomingMessageHeader{
 		Kind:        arbostypes.L1MessageType_L2Message,
@@ -918,6 +1384,9 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	}
 	elapsed := time.Since(start)
 	blockCreationTimer.Update(elapsed)
+	if s.loadShedder != nil {
+		s.loadShedder.observeBlockCreation(elapsed)
+	}
 	if elapsed >= time.Second*5 {
 		var blockNum *big.Int
 		if block != nil {
@@ -959,6 +1428,11 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	if block != nil {
 		successfulBlocksCounter.Inc(1)
 		s.nonceCache.Finalize(block)
+		if orderingSeed != nil {
+			if err := s.fairOrdering.reveal(block.NumberU64(), *orderingSeed); err != nil {
+				log.Error("failed to reveal fair ordering seed", "block", block.NumberU64(), "err", err)
+			}
+		}
 	}
 
 	madeBlock := false
@@ -967,6 +1441,10 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			madeBlock = true
 		}
 		queueItem := queueItems[i]
+		if queueItem.fromBuilder {
+			queueItem.returnResult(err)
+			continue
+		}
 		if errors.Is(err, core.ErrGasLimitReached) {
 			// There's not enough gas left in the block for this tx.
 			if madeBlock {
@@ -1058,8 +1536,22 @@ func (s *Sequencer) updateExpectedSurplus(ctx context.Context) (int64, error) {
 	return expectedSurplus, nil
 }
 
+// maxBlockSpeed returns the minimum delay between blocks, raised by the block speed tuner if enabled.
+func (s *Sequencer) maxBlockSpeed() time.Duration {
+	if s.blockSpeed != nil {
+		return s.blockSpeed.MaxBlockSpeed()
+	}
+	return s.config().MaxBlockSpeed
+}
+
 func (s *Sequencer) Start(ctxIn context.Context) error {
 	s.StopWaiter.Start(ctxIn, s)
+	if s.clockSkew != nil {
+		s.clockSkew.Start(ctxIn)
+	}
+	if s.blockSpeed != nil {
+		s.blockSpeed.Start(ctxIn)
+	}
 	config := s.config()
 	if (config.ExpectedSurplusHardThreshold != "default" || config.ExpectedSurplusSoftThreshold != "default") && s.l1Reader == nil {
 		return errors.New("expected surplus soft/hard thresholds are enabled but l1Reader is nil")
@@ -1115,7 +1607,7 @@ func (s *Sequencer) Start(ctxIn context.Context) error {
 	}
 
 	s.CallIteratively(func(ctx context.Context) time.Duration {
-		nextBlock := time.Now().Add(s.config().MaxBlockSpeed)
+		nextBlock := time.Now().Add(s.maxBlockSpeed())
 		if s.createBlock(ctx) {
 			// Note: this may return a negative duration, but timers are fine with that (they treat negative durations as 0).
 			return time.Until(nextBlock)
@@ -1129,7 +1621,18 @@ func (s *Sequencer) Start(ctxIn context.Context) error {
 
 func (s *Sequencer) StopAndWait() {
 	s.StopWaiter.StopAndWait()
-	if s.txRetryQueue.Len() == 0 && len(s.txQueue) == 0 && s.nonceFailures.Len() == 0 {
+	if s.clockSkew != nil {
+		s.clockSkew.StopAndWait()
+	}
+	if s.blockSpeed != nil {
+		s.blockSpeed.StopAndWait()
+	}
+	if s.screener != nil {
+		if err := s.screener.Close(context.Background()); err != nil {
+			log.Warn("failed to close transaction screener", "err", err)
+		}
+	}
+	if s.txRetryQueue.Len() == 0 && len(s.txQueue) == 0 && len(s.expressLane.items()) == 0 && s.nonceFailures.Len() == 0 {
 		return
 	}
 	// this usually means that coordinator's safe-shutdown-delay is too low
@@ -1152,6 +1655,8 @@ func (s *Sequencer) StopAndWait() {
 				s.nonceFailures.RemoveOldest()
 			} else {
 				select {
+				case item = <-s.expressLane.items():
+					source = "expressLane"
 				case item = <-s.txQueue:
 					source = "txQueue"
 				default:
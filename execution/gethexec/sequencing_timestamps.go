@@ -0,0 +1,133 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+var sequencingTimestampPrefix = []byte("arbitrum-sequencing-time-")
+
+func sequencingTimestampKey(txHash common.Hash) []byte {
+	return append(append([]byte{}, sequencingTimestampPrefix...), txHash.Bytes()...)
+}
+
+// SequencingTimestamps stores when the sequencer executed each transaction, in milliseconds since
+// the unix epoch. Blocks only have second granularity timestamps, which isn't enough to order
+// transactions sequenced within the same second.
+type SequencingTimestamps struct {
+	db ethdb.KeyValueStore
+}
+
+func NewSequencingTimestamps(db ethdb.KeyValueStore) *SequencingTimestamps {
+	return &SequencingTimestamps{db: db}
+}
+
+func (t *SequencingTimestamps) write(timestamps map[common.Hash]uint64) error {
+	batch := t.db.NewBatch()
+	for txHash, timestamp := range timestamps {
+		if err := batch.Put(sequencingTimestampKey(txHash), binary.BigEndian.AppendUint64(nil, timestamp)); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// Get returns the millisecond timestamp a transaction was sequenced at, or nil if it wasn't recorded.
+func (t *SequencingTimestamps) Get(txHash common.Hash) (*uint64, error) {
+	key := sequencingTimestampKey(txHash)
+	has, err := t.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := t.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 8 {
+		return nil, fmt.Errorf("invalid sequencing timestamp length %v for tx %v", len(data), txHash)
+	}
+	timestamp := binary.BigEndian.Uint64(data)
+	return &timestamp, nil
+}
+
+// recordSequencedAt wraps the sequencing hooks to note when each transaction finished executing.
+func recordSequencedAt(hooks *arbos.SequencingHooks) map[common.Hash]uint64 {
+	timestamps := make(map[common.Hash]uint64)
+	postTxFilter := hooks.PostTxFilter
+	hooks.PostTxFilter = func(header *types.Header, arbState *arbosState.ArbosState, tx *types.Transaction, sender common.Address, dataGas uint64, result *core.ExecutionResult) error {
+		if err := postTxFilter(header, arbState, tx, sender, dataGas, result); err != nil {
+			return err
+		}
+		// #nosec G115
+		timestamps[tx.Hash()] = uint64(time.Now().UnixMilli())
+		return nil
+	}
+	return timestamps
+}
+
+type TxSequencingTimestamp struct {
+	TxHash common.Hash `json:"txHash"`
+	// Milliseconds since the unix epoch, or null if the sequencer didn't record it
+	SequencedAt *hexutil.Uint64 `json:"sequencedAt"`
+}
+
+type SequencingTimestampsAPI struct {
+	bc         *core.BlockChain
+	timestamps *SequencingTimestamps
+}
+
+func NewSequencingTimestampsAPI(bc *core.BlockChain, timestamps *SequencingTimestamps) *SequencingTimestampsAPI {
+	return &SequencingTimestampsAPI{bc, timestamps}
+}
+
+// SequencingTimestamp returns when the sequencer executed a transaction, in milliseconds since the unix epoch.
+func (api *SequencingTimestampsAPI) SequencingTimestamp(_ context.Context, txHash common.Hash) (*hexutil.Uint64, error) {
+	timestamp, err := api.timestamps.Get(txHash)
+	if err != nil || timestamp == nil {
+		return nil, err
+	}
+	return (*hexutil.Uint64)(timestamp), nil
+}
+
+// BlockSequencingTimestamps returns the sequencing timestamp of every transaction in a block, in order.
+func (api *SequencingTimestampsAPI) BlockSequencingTimestamps(_ context.Context, number rpc.BlockNumber) ([]TxSequencingTimestamp, error) {
+	var block *types.Block
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		block = api.bc.GetBlockByHash(api.bc.CurrentBlock().Hash())
+	default:
+		if number < 0 {
+			return nil, errors.New("unsupported block tag")
+		}
+		// #nosec G115
+		block = api.bc.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	result := make([]TxSequencingTimestamp, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		timestamp, err := api.timestamps.Get(tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, TxSequencingTimestamp{TxHash: tx.Hash(), SequencedAt: (*hexutil.Uint64)(timestamp)})
+	}
+	return result, nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+
+	"github.com/offchainlabs/nitro/util/dbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	stateConversionNextGauge        = metrics.NewRegisteredGauge("arb/execution/stateconversion/next", nil)
+	stateConversionTargetGauge      = metrics.NewRegisteredGauge("arb/execution/stateconversion/target", nil)
+	stateConversionRecreatedCounter = metrics.NewRegisteredCounter("arb/execution/stateconversion/recreated", nil)
+)
+
+var (
+	StateModeKey               []byte = []byte("_stateMode")               // the state mode, archive or pruned, the node last ran in
+	StateConversionPositionKey []byte = []byte("_stateConversionPosition") // progress of converting a pruned database to an archive
+)
+
+const (
+	StateModeArchive = "archive"
+	StateModePruned  = "pruned"
+)
+
+type StateConversionConfig struct {
+	ArchiveFrom   uint64        `koanf:"archive-from"`
+	BlocksPerStep uint64        `koanf:"blocks-per-step" reload:"hot"`
+	StepDelay     time.Duration `koanf:"step-delay" reload:"hot"`
+}
+
+var DefaultStateConversionConfig = StateConversionConfig{
+	ArchiveFrom:   0,
+	BlocksPerStep: 100,
+	StepDelay:     100 * time.Millisecond,
+}
+
+func StateConversionConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".archive-from", DefaultStateConversionConfig.ArchiveFrom, "when running as an archive, regenerate in the background the states missing from this block onward, converting a pruned database into an archive (0 = disabled)")
+	f.Uint64(prefix+".blocks-per-step", DefaultStateConversionConfig.BlocksPerStep, "number of blocks to re-execute before pausing")
+	f.Duration(prefix+".step-delay", DefaultStateConversionConfig.StepDelay, "pause between steps, throttling the conversion")
+}
+
+func (c *StateConversionConfig) Validate() error {
+	if c.BlocksPerStep == 0 {
+		return errors.New("state-conversion blocks-per-step must be positive")
+	}
+	return nil
+}
+
+type StateConversionConfigFetcher func() *StateConversionConfig
+
+// stateConversionPosition is the persisted progress of a conversion, so a restart resumes it.
+type stateConversionPosition struct {
+	From   uint64
+	Next   uint64
+	Target uint64
+}
+
+type StateConversionProgress struct {
+	Archive   bool   `json:"archive"`
+	From      uint64 `json:"from"`
+	Next      uint64 `json:"next"`
+	Target    uint64 `json:"target"`
+	Recreated uint64 `json:"recreated"`
+	Done      bool   `json:"done"`
+}
+
+// ReadStateMode returns the state mode the node last ran in, or "" if it's unknown.
+func ReadStateMode(db ethdb.KeyValueReader) (string, error) {
+	mode, err := db.Get(StateModeKey)
+	if dbutil.IsErrNotFound(err) {
+		return "", nil
+	}
+	return string(mode), err
+}
+
+// StateConverter converts a pruned database into an archive without a resync, by re-executing
+// the blocks from a chosen height whose states weren't kept and writing their states to disk.
+// Blocks after the node restarted as an archive keep their states anyway, so the conversion ends
+// at the head the node started with.
+type StateConverter struct {
+	stopwaiter.StopWaiter
+	bc      *core.BlockChain
+	chainDb ethdb.Database
+	caching *CachingConfig
+	config  StateConversionConfigFetcher
+
+	mutex    sync.Mutex
+	progress StateConversionProgress
+}
+
+func NewStateConverter(bc *core.BlockChain, chainDb ethdb.Database, caching *CachingConfig, config StateConversionConfigFetcher) *StateConverter {
+	return &StateConverter{
+		bc:       bc,
+		chainDb:  chainDb,
+		caching:  caching,
+		config:   config,
+		progress: StateConversionProgress{Archive: caching.Archive, Done: true},
+	}
+}
+
+func (c *StateConverter) Progress() StateConversionProgress {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.progress
+}
+
+func (c *StateConverter) setProgress(position stateConversionPosition, recreated uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.progress.From = position.From
+	c.progress.Next = position.Next
+	c.progress.Target = position.Target
+	c.progress.Recreated += recreated
+	c.progress.Done = position.Next > position.Target
+	// #nosec G115
+	stateConversionNextGauge.Update(int64(position.Next))
+	// #nosec G115
+	stateConversionTargetGauge.Update(int64(position.Target))
+	// #nosec G115
+	stateConversionRecreatedCounter.Inc(int64(recreated))
+}
+
+func (c *StateConverter) Start(ctx context.Context) error {
+	c.StopWaiter.Start(ctx, c)
+	mode := StateModePruned
+	if c.caching.Archive {
+		mode = StateModeArchive
+	}
+	if err := c.chainDb.Put(StateModeKey, []byte(mode)); err != nil {
+		return err
+	}
+	from := c.config().ArchiveFrom
+	if !c.caching.Archive || from == 0 {
+		return nil
+	}
+	if c.caching.StateScheme == rawdb.PathScheme {
+		return errors.New("converting to an archive isn't supported with the path state-scheme")
+	}
+	if genesis := c.bc.Config().ArbitrumChainParams.GenesisBlockNum; from <= genesis {
+		from = genesis + 1
+	}
+
+	position, err := ReadFromKeyValueStore[stateConversionPosition](c.chainDb, StateConversionPositionKey)
+	if err != nil && !dbutil.IsErrNotFound(err) {
+		return err
+	}
+	if err != nil || position.From != from {
+		position = stateConversionPosition{
+			From:   from,
+			Next:   from,
+			Target: c.bc.CurrentBlock().Number.Uint64(),
+		}
+	}
+	c.setProgress(position, 0)
+	if position.Next > position.Target {
+		return nil
+	}
+	log.Info("Converting database to an archive", "from", position.From, "next", position.Next, "target", position.Target)
+	c.LaunchThread(func(ctx context.Context) {
+		if err := c.convert(ctx, position); err != nil && ctx.Err() == nil {
+			log.Error("Converting database to an archive failed", "err", err)
+		}
+	})
+	return nil
+}
+
+// statesDatabase returns a state database that writes recreated states straight to disk,
+// independently of the blockchain's in memory trie database.
+func (c *StateConverter) statesDatabase() state.Database {
+	hashConfig := *hashdb.Defaults
+	hashConfig.CleanCacheSize = c.caching.TrieCleanCache * 1024 * 1024
+	return state.NewDatabaseWithConfig(c.chainDb, &triedb.Config{HashDB: &hashConfig})
+}
+
+// startingState returns the nearest stored state at or before block, and the block it belongs to.
+func (c *StateConverter) startingState(database state.Database, number uint64) (*state.StateDB, *types.Header, error) {
+	header := c.bc.GetHeaderByNumber(number)
+	for header != nil {
+		if statedb, err := state.New(header.Root, database, nil); err == nil {
+			return statedb, header, nil
+		}
+		if header.Number.Uint64() == 0 {
+			break
+		}
+		header = c.bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return nil, nil, fmt.Errorf("no stored state at or before block %v", number)
+}
+
+func (c *StateConverter) convert(ctx context.Context, position stateConversionPosition) error {
+	database := c.statesDatabase()
+	defer database.TrieDB().Close()
+	previousState, header, err := c.startingState(database, position.Next-1)
+	if err != nil {
+		return err
+	}
+	if start := header.Number.Uint64() + 1; start < position.Next {
+		log.Info("Re-executing from the nearest stored state", "block", header.Number, "blocks", position.Next-start)
+		position.Next = start
+	}
+
+	logged := time.Now()
+	for position.Next <= position.Target {
+		config := c.config()
+		var recreated uint64
+		for i := uint64(0); i < config.BlocksPerStep && position.Next <= position.Target; i++ {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			block := c.bc.GetBlockByNumber(position.Next)
+			if block == nil {
+				return fmt.Errorf("block %v not found", position.Next)
+			}
+			statedb, err := state.New(block.Root(), database, nil)
+			if err != nil {
+				if _, _, _, err := c.bc.Processor().Process(block, previousState, vm.Config{}); err != nil {
+					return fmt.Errorf("processing block %v failed: %w", position.Next, err)
+				}
+				root, err := previousState.Commit(position.Next, c.bc.Config().IsEIP158(block.Number()))
+				if err != nil {
+					return fmt.Errorf("committing state of block %v failed: %w", position.Next, err)
+				}
+				if root != block.Root() {
+					return fmt.Errorf("re-executing block %v reached root %v, expected %v", position.Next, root, block.Root())
+				}
+				if err := database.TrieDB().Commit(root, false); err != nil {
+					return fmt.Errorf("writing state of block %v failed: %w", position.Next, err)
+				}
+				statedb, err = state.New(root, database, nil)
+				if err != nil {
+					return fmt.Errorf("reopening state of block %v failed: %w", position.Next, err)
+				}
+				recreated++
+			}
+			previousState = statedb
+			position.Next++
+		}
+		if err := WriteToKeyValueStore(c.chainDb, StateConversionPositionKey, position); err != nil {
+			return err
+		}
+		c.setProgress(position, recreated)
+		if time.Since(logged) > time.Minute {
+			log.Info("Converting database to an archive", "next", position.Next, "target", position.Target, "remaining", position.Target+1-position.Next)
+			logged = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.StepDelay):
+		}
+	}
+	log.Info("Finished converting database to an archive", "from", position.From, "target", position.Target, "recreated", c.Progress().Recreated)
+	return nil
+}
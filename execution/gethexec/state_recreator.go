@@ -0,0 +1,146 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	recreatedBlocksCounter = metrics.NewRegisteredCounter("arb/execution/staterecreator/blocks", nil)
+
+	ErrTooManyBlocksToRecreate = errors.New("too many blocks to recreate state")
+)
+
+type StateRecreatorConfig struct {
+	MaxBlocks uint64 `koanf:"max-blocks"`
+	CacheSize int    `koanf:"cache-size"`
+}
+
+var DefaultStateRecreatorConfig = StateRecreatorConfig{
+	MaxBlocks: 1024,
+	CacheSize: 16,
+}
+
+func StateRecreatorConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".max-blocks", DefaultStateRecreatorConfig.MaxBlocks, "maximum number of blocks to re-execute to recreate a historical state that isn't stored (0 = disabled)")
+	f.Int(prefix+".cache-size", DefaultStateRecreatorConfig.CacheSize, "number of recreated historical states to keep in memory")
+}
+
+func (c *StateRecreatorConfig) Validate() error {
+	if c.MaxBlocks > 0 && c.CacheSize < 1 {
+		return errors.New("state-recreation cache-size must be positive when max-blocks is set")
+	}
+	return nil
+}
+
+// StateRecreator returns historical states, re-executing blocks from the nearest stored
+// or cached ancestor state when the requested state isn't available on disk. Recreated
+// states only live in memory and are never written to the database.
+type StateRecreator struct {
+	bc     *core.BlockChain
+	config *StateRecreatorConfig
+	cache  *containers.Cache[common.Hash, *state.StateDB]
+	mutex  sync.Mutex // serializes recreation so concurrent requests for nearby blocks share work
+}
+
+func NewStateRecreator(bc *core.BlockChain, config *StateRecreatorConfig) *StateRecreator {
+	capacity := config.CacheSize
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &StateRecreator{
+		bc:     bc,
+		config: config,
+		cache: containers.NewCache[common.Hash, *state.StateDB](containers.CacheOpts[*state.StateDB]{
+			Capacity:      capacity,
+			MetricsPrefix: "arb/execution/staterecreator/cache",
+		}),
+	}
+}
+
+// cachedState returns a copy of a stored or cached state, or nil if neither exists.
+func (r *StateRecreator) cachedState(header *types.Header) *state.StateDB {
+	if statedb, err := r.bc.StateAt(header.Root); err == nil {
+		return statedb
+	}
+	if statedb, ok := r.cache.Get(header.Hash()); ok {
+		return statedb.Copy()
+	}
+	return nil
+}
+
+// StateAt returns the state after the given block. The returned state is owned by the caller.
+func (r *StateRecreator) StateAt(ctx context.Context, header *types.Header) (*state.StateDB, error) {
+	if statedb := r.cachedState(header); statedb != nil {
+		return statedb, nil
+	}
+	if r.config.MaxBlocks == 0 {
+		return nil, fmt.Errorf("state for block %v not available and state recreation is disabled", header.Number)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// Another request may have recreated it while we waited for the lock
+	if statedb := r.cachedState(header); statedb != nil {
+		return statedb, nil
+	}
+
+	var blocks []*types.Block
+	var statedb *state.StateDB
+	current := header
+	for statedb == nil {
+		if uint64(len(blocks)) >= r.config.MaxBlocks {
+			return nil, fmt.Errorf("%w: no state found within %v blocks of block %v", ErrTooManyBlocksToRecreate, r.config.MaxBlocks, header.Number)
+		}
+		number := current.Number.Uint64()
+		block := r.bc.GetBlock(current.Hash(), number)
+		if block == nil {
+			return nil, fmt.Errorf("block %v not found while recreating state", number)
+		}
+		blocks = append(blocks, block)
+		if number == 0 {
+			return nil, errors.New("reached genesis without finding a stored state")
+		}
+		parent := r.bc.GetHeader(current.ParentHash, number-1)
+		if parent == nil {
+			return nil, fmt.Errorf("parent of block %v not found while recreating state", number)
+		}
+		statedb = r.cachedState(parent)
+		current = parent
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := blocks[i]
+		_, _, _, err := r.bc.Processor().Process(block, statedb, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("processing block %v failed while recreating state: %w", block.Number(), err)
+		}
+		root := statedb.IntermediateRoot(r.bc.Config().IsEIP158(block.Number()))
+		if root != block.Root() {
+			return nil, fmt.Errorf("recreated state of block %v has root %v, expected %v", block.Number(), root, block.Root())
+		}
+		r.cache.Add(block.Hash(), statedb.Copy())
+		recreatedBlocksCounter.Inc(1)
+	}
+	log.Debug("Recreated historical state", "block", header.Number, "reexecuted", len(blocks))
+	return statedb, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const stylusProfilerName = "stylusProfiler"
+
+func init() {
+	tracers.DefaultDirectory.Register(stylusProfilerName, newStylusProfiler, false)
+}
+
+// StylusHostioProfile aggregates the calls to one HostIO.
+type StylusHostioProfile struct {
+	Count uint64 `json:"count"`
+	// Ink charged by the calls, including the cost of any nested call or create
+	Ink uint64 `json:"ink"`
+}
+
+// StylusMemoryGrowth is a memory.grow performed by a program.
+type StylusMemoryGrowth struct {
+	Pages uint16 `json:"pages"`
+	// Ink left before paying for the new pages, to place the growth in the execution
+	InkLeft uint64 `json:"inkLeft"`
+	Ink     uint64 `json:"ink"`
+}
+
+// StylusProfile is the profile of a call frame. Frames that didn't run a Stylus program
+// only carry their address and nested calls.
+type StylusProfile struct {
+	Address common.Address `json:"address"`
+	Stylus  bool           `json:"stylus"`
+	// Ink spent from the program's entrypoint until it returned
+	InkUsed uint64 `json:"inkUsed"`
+	// Ink spent executing the program's own WASM, outside of HostIOs
+	WasmInk      uint64                          `json:"wasmInk"`
+	Hostios      map[string]*StylusHostioProfile `json:"hostios,omitempty"`
+	MemoryGrowth []StylusMemoryGrowth            `json:"memoryGrowth,omitempty"`
+	Calls        []*StylusProfile                `json:"calls,omitempty"`
+
+	initialInk uint64
+	lastInk    uint64
+}
+
+// stylusProfiler aggregates Stylus HostIOs per call frame instead of recording each one like stylusTracer.
+type stylusProfiler struct {
+	root      *StylusProfile
+	frames    []*StylusProfile
+	interrupt atomic.Bool
+	reason    error
+}
+
+func newStylusProfiler(_ *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	p := &stylusProfiler{}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnEnter:             p.OnEnter,
+			OnExit:              p.OnExit,
+			CaptureStylusHostio: p.CaptureStylusHostio,
+		},
+		GetResult: p.GetResult,
+		Stop:      p.Stop,
+	}, nil
+}
+
+func (p *stylusProfiler) OnEnter(depth int, _ byte, _ common.Address, to common.Address, _ []byte, _ uint64, _ *big.Int) {
+	if p.interrupt.Load() {
+		return
+	}
+	frame := &StylusProfile{Address: to}
+	if depth == 0 {
+		p.root = frame
+	} else {
+		if len(p.frames) == 0 {
+			p.Stop(errors.New("stylusProfiler: nested call without a parent frame"))
+			return
+		}
+		parent := p.frames[len(p.frames)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	p.frames = append(p.frames, frame)
+}
+
+func (p *stylusProfiler) OnExit(_ int, _ []byte, _ uint64, _ error, _ bool) {
+	if p.interrupt.Load() {
+		return
+	}
+	if len(p.frames) == 0 {
+		p.Stop(errors.New("stylusProfiler: exit without a frame"))
+		return
+	}
+	p.frames = p.frames[:len(p.frames)-1]
+}
+
+func (p *stylusProfiler) CaptureStylusHostio(name string, args, _ []byte, startInk, endInk uint64) {
+	if p.interrupt.Load() {
+		return
+	}
+	if len(p.frames) == 0 {
+		p.Stop(errors.New("stylusProfiler: hostio outside of a frame"))
+		return
+	}
+	frame := p.frames[len(p.frames)-1]
+	if !frame.Stylus {
+		frame.Stylus = true
+		frame.initialInk = startInk
+		frame.lastInk = startInk
+		frame.Hostios = make(map[string]*StylusHostioProfile)
+	}
+	if frame.lastInk > startInk {
+		frame.WasmInk += frame.lastInk - startInk
+	}
+	ink := uint64(0)
+	if startInk > endInk {
+		ink = startInk - endInk
+	}
+	switch name {
+	case "user_entrypoint", "user_returned":
+		// Mark the program's boundaries rather than calls it made
+	default:
+		hostio, ok := frame.Hostios[name]
+		if !ok {
+			hostio = &StylusHostioProfile{}
+			frame.Hostios[name] = hostio
+		}
+		hostio.Count++
+		hostio.Ink += ink
+	}
+	if name == "pay_for_memory_grow" && len(args) >= 2 {
+		frame.MemoryGrowth = append(frame.MemoryGrowth, StylusMemoryGrowth{
+			Pages:   binary.BigEndian.Uint16(args),
+			InkLeft: startInk,
+			Ink:     ink,
+		})
+	}
+	frame.lastInk = endInk
+	if frame.initialInk > endInk {
+		frame.InkUsed = frame.initialInk - endInk
+	}
+}
+
+func (p *stylusProfiler) GetResult() (json.RawMessage, error) {
+	if p.reason != nil {
+		return nil, p.reason
+	}
+	if p.root == nil {
+		return nil, errors.New("no call was profiled")
+	}
+	return json.Marshal(p.root)
+}
+
+func (p *stylusProfiler) Stop(err error) {
+	p.reason = err
+	p.interrupt.Store(true)
+}
+
+type StylusProfileAPI struct {
+	stack *node.Node
+}
+
+func NewStylusProfileAPI(stack *node.Node) *StylusProfileAPI {
+	return &StylusProfileAPI{stack}
+}
+
+// TraceStylusCall executes a call like debug_traceCall and returns a StylusProfile of it: the HostIO counts
+// and ink, the ink spent in WASM and the memory growth of every Stylus program it ran.
+func (api *StylusProfileAPI) TraceStylusCall(ctx context.Context, args json.RawMessage, blockNrOrHash rpc.BlockNumberOrHash, config map[string]interface{}) (json.RawMessage, error) {
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	config["tracer"] = stylusProfilerName
+	delete(config, "tracerConfig")
+	client := api.stack.Attach()
+	defer client.Close()
+	var profile json.RawMessage
+	err := client.CallContext(ctx, &profile, "debug_traceCall", args, blockNrOrHash, config)
+	return profile, err
+}
@@ -0,0 +1,65 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestStylusProfiler(t *testing.T) {
+	tracer, err := newStylusProfiler(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := tracer.Hooks
+	program := common.HexToAddress("0x1234")
+	callee := common.HexToAddress("0x5678")
+
+	hooks.OnEnter(0, byte(vm.CALL), common.Address{}, program, nil, 0, nil)
+	hooks.CaptureStylusHostio("user_entrypoint", []byte{0, 0, 0, 4}, nil, 10000, 10000)
+	hooks.CaptureStylusHostio("pay_for_memory_grow", []byte{0x00, 0x02}, nil, 9900, 9000)
+	hooks.CaptureStylusHostio("read_args", nil, nil, 8900, 8800)
+	hooks.OnEnter(1, byte(vm.CALL), program, callee, nil, 0, nil)
+	hooks.OnExit(1, nil, 0, nil, false)
+	hooks.CaptureStylusHostio("call_contract", nil, nil, 8700, 5000)
+	hooks.CaptureStylusHostio("read_args", nil, nil, 5000, 4900)
+	hooks.CaptureStylusHostio("user_returned", nil, []byte{0, 0, 0, 0}, 4800, 4800)
+	hooks.OnExit(0, nil, 0, nil, false)
+
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profile StylusProfile
+	if err := json.Unmarshal(result, &profile); err != nil {
+		t.Fatal(err)
+	}
+	if !profile.Stylus || profile.Address != program {
+		t.Fatal("wrong root frame", profile.Address, profile.Stylus)
+	}
+	if profile.InkUsed != 5200 {
+		t.Fatal("wrong ink used", profile.InkUsed)
+	}
+	// 100 before growing memory, the first read_args, the call and returning
+	if profile.WasmInk != 400 {
+		t.Fatal("wrong wasm ink", profile.WasmInk)
+	}
+	readArgs := profile.Hostios["read_args"]
+	if readArgs == nil || readArgs.Count != 2 || readArgs.Ink != 200 {
+		t.Fatal("wrong read_args profile", readArgs)
+	}
+	if _, ok := profile.Hostios["user_entrypoint"]; ok {
+		t.Fatal("the entrypoint was profiled as a hostio")
+	}
+	if len(profile.MemoryGrowth) != 1 || profile.MemoryGrowth[0].Pages != 2 || profile.MemoryGrowth[0].Ink != 900 {
+		t.Fatal("wrong memory growth", profile.MemoryGrowth)
+	}
+	if len(profile.Calls) != 1 || profile.Calls[0].Address != callee || profile.Calls[0].Stylus {
+		t.Fatal("wrong nested calls", profile.Calls)
+	}
+}
@@ -4,13 +4,17 @@
 package gethexec
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
 	"sync/atomic"
 
+	flag "github.com/spf13/pflag"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/tracing"
@@ -24,6 +28,42 @@ func init() {
 	tracers.DefaultDirectory.Register("stylusTracer", newStylusTracer, false)
 }
 
+type StylusTracerConfig struct {
+	MaxDepth int `koanf:"max-depth" reload:"hot"`
+}
+
+var DefaultStylusTracerConfig = StylusTracerConfig{
+	MaxDepth: 0,
+}
+
+func StylusTracerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".max-depth", DefaultStylusTracerConfig.MaxDepth, "maximum levels of nested calls stylusTracer returns, with deeper calls replaced by a cursor to trace them separately (0 = no limit)")
+}
+
+func (c *StylusTracerConfig) Validate() error {
+	if c.MaxDepth < 0 {
+		return errors.New("stylus-tracer max-depth cannot be negative")
+	}
+	return nil
+}
+
+type StylusTracerConfigFetcher func() *StylusTracerConfig
+
+// Tracers are created by geth's tracer directory, so the node's limits are shared with them here
+var stylusTracerConfig atomic.Pointer[StylusTracerConfigFetcher]
+
+func setStylusTracerConfig(fetcher StylusTracerConfigFetcher) {
+	stylusTracerConfig.Store(&fetcher)
+}
+
+// stylusTracerOptions are the options a client can pass as the tracer config.
+type stylusTracerOptions struct {
+	// Levels of nested calls to return, capped by the node's limit
+	MaxDepth int `json:"maxDepth"`
+	// The cursor of a call whose steps were left out of a previous trace, to trace that call instead
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
 // stylusTracer captures Stylus HostIOs and returns them in a structured format to be used in Cargo
 // Stylus Replay.
 type stylusTracer struct {
@@ -31,6 +71,8 @@ type stylusTracer struct {
 	stack     *containers.Stack[*containers.Stack[HostioTraceInfo]]
 	interrupt atomic.Bool
 	reason    error
+	maxDepth  int
+	cursor    []uint32
 }
 
 // HostioTraceInfo contains the captured HostIO log returned by stylusTracer.
@@ -59,6 +101,10 @@ type HostioTraceInfo struct {
 
 	// For *call HostIOs, the steps performed by the called contract.
 	Steps *containers.Stack[HostioTraceInfo] `json:"steps,omitempty"`
+
+	// For *call HostIOs nested deeper than the depth limit, replaces the steps.
+	// Pass it back in the tracer config to trace the call's steps.
+	Cursor *hexutil.Bytes `json:"cursor,omitempty"`
 }
 
 // nestsHostios contains the hostios with nested calls.
@@ -68,10 +114,28 @@ var nestsHostios = map[string]bool{
 	"static_call_contract":   true,
 }
 
-func newStylusTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+func newStylusTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	var options stylusTracerOptions
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &options); err != nil {
+			return nil, err
+		}
+	}
+	cursor, err := decodeStylusTraceCursor(options.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	maxDepth := options.MaxDepth
+	if fetcher := stylusTracerConfig.Load(); fetcher != nil {
+		if limit := (*fetcher)().MaxDepth; limit > 0 && (maxDepth <= 0 || maxDepth > limit) {
+			maxDepth = limit
+		}
+	}
 	t := &stylusTracer{
-		open:  containers.NewStack[HostioTraceInfo](),
-		stack: containers.NewStack[*containers.Stack[HostioTraceInfo]](),
+		open:     containers.NewStack[HostioTraceInfo](),
+		stack:    containers.NewStack[*containers.Stack[HostioTraceInfo]](),
+		maxDepth: maxDepth,
+		cursor:   cursor,
 	}
 
 	return &tracers.Tracer{
@@ -186,13 +250,70 @@ func (t *stylusTracer) GetResult() (json.RawMessage, error) {
 		return nil, fmt.Errorf("internal error: %w", internalErr)
 	}
 
-	msg, err := json.Marshal(t.open)
+	steps, err := stylusTraceAt(t.open, t.cursor)
+	if err != nil {
+		return nil, err
+	}
+	if t.maxDepth > 0 {
+		steps = truncateStylusTrace(steps, t.cursor, t.maxDepth)
+	}
+	msg, err := json.Marshal(steps)
 	if err != nil {
 		return nil, err
 	}
 	return msg, nil
 }
 
+// stylusTraceAt returns the steps of the call a cursor points to.
+func stylusTraceAt(steps *containers.Stack[HostioTraceInfo], path []uint32) (*containers.Stack[HostioTraceInfo], error) {
+	for _, index := range path {
+		if int(index) >= steps.Len() || (*steps)[index].Steps == nil {
+			return nil, errors.New("cursor doesn't point to a call in this trace")
+		}
+		steps = (*steps)[index].Steps
+	}
+	return steps, nil
+}
+
+// truncateStylusTrace copies the steps down to depth levels of calls, giving the calls any deeper a cursor
+// in place of their steps. The path is where the steps are in the full trace.
+func truncateStylusTrace(steps *containers.Stack[HostioTraceInfo], path []uint32, depth int) *containers.Stack[HostioTraceInfo] {
+	truncated := make(containers.Stack[HostioTraceInfo], 0, steps.Len())
+	for i, step := range *steps {
+		if !step.Steps.Empty() {
+			// #nosec G115
+			stepPath := append(slices.Clone(path), uint32(i))
+			if depth <= 1 {
+				step.Steps = nil
+				step.Cursor = encodeStylusTraceCursor(stepPath)
+			} else {
+				step.Steps = truncateStylusTrace(step.Steps, stepPath, depth-1)
+			}
+		}
+		truncated = append(truncated, step)
+	}
+	return &truncated
+}
+
+func encodeStylusTraceCursor(path []uint32) *hexutil.Bytes {
+	data := make([]byte, 0, 4*len(path))
+	for _, index := range path {
+		data = binary.BigEndian.AppendUint32(data, index)
+	}
+	return (*hexutil.Bytes)(&data)
+}
+
+func decodeStylusTraceCursor(data []byte) ([]uint32, error) {
+	if len(data)%4 != 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	path := make([]uint32, 0, len(data)/4)
+	for i := 0; i < len(data); i += 4 {
+		path = append(path, binary.BigEndian.Uint32(data[i:]))
+	}
+	return path, nil
+}
+
 func (t *stylusTracer) Stop(err error) {
 	t.reason = err
 	t.interrupt.Store(true)
@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+func stylusTraceCall(name string, steps ...HostioTraceInfo) HostioTraceInfo {
+	stack := containers.Stack[HostioTraceInfo](steps)
+	return HostioTraceInfo{Name: name, Steps: &stack}
+}
+
+func TestStylusTraceDepthLimit(t *testing.T) {
+	// read_args, then a call that makes another call
+	innermost := stylusTraceCall("call_contract", HostioTraceInfo{Name: "storage_load_bytes32"})
+	inner := stylusTraceCall("call_contract", HostioTraceInfo{Name: "read_args"}, innermost)
+	full := containers.Stack[HostioTraceInfo]{{Name: "read_args"}, inner, {Name: "write_result"}}
+
+	truncated := truncateStylusTrace(&full, nil, 1)
+	if truncated.Len() != 3 || (*truncated)[1].Steps != nil || (*truncated)[1].Cursor == nil {
+		t.Fatal("call not truncated at depth 1", truncated)
+	}
+	if full[1].Steps == nil || full[1].Cursor != nil {
+		t.Fatal("truncating modified the full trace")
+	}
+
+	// The cursor traces the call, whose own call gets a cursor relative to the full trace
+	cursor, err := decodeStylusTraceCursor(*(*truncated)[1].Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := stylusTraceAt(&full, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := truncateStylusTrace(steps, cursor, 1)
+	if page.Len() != 2 || (*page)[1].Cursor == nil {
+		t.Fatal("unexpected page of the call", page)
+	}
+	cursor, err = decodeStylusTraceCursor(*(*page)[1].Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err = stylusTraceAt(&full, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps.Len() != 1 || (*steps)[0].Name != "storage_load_bytes32" {
+		t.Fatal("cursor points to the wrong call", steps)
+	}
+
+	// Deep enough limits return the trace unchanged
+	want, err := json.Marshal(&full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(truncateStylusTrace(&full, nil, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatal("trace within the limit changed", string(got))
+	}
+
+	if _, err := stylusTraceAt(&full, []uint32{0}); err == nil {
+		t.Fatal("cursor to a hostio that isn't a call accepted")
+	}
+	if _, err := decodeStylusTraceCursor([]byte{1, 2}); err == nil {
+		t.Fatal("invalid cursor accepted")
+	}
+}
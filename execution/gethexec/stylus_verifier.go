@@ -0,0 +1,425 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbos/programs"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	stylusVerificationPrefix = []byte("arbitrum-stylus-verification-")
+
+	stylusVerifiedCounter = metrics.NewRegisteredCounter("arb/stylusverifier/verified", nil)
+	stylusMismatchCounter = metrics.NewRegisteredCounter("arb/stylusverifier/mismatch", nil)
+	stylusFailedCounter   = metrics.NewRegisteredCounter("arb/stylusverifier/failed", nil)
+)
+
+func stylusVerificationKey(program common.Address) []byte {
+	return append(append([]byte{}, stylusVerificationPrefix...), program.Bytes()...)
+}
+
+type StylusVerifierConfig struct {
+	Enable bool `koanf:"enable"`
+	// Split on whitespace and run without a shell, after substituting the placeholders in each argument
+	BuildCommand    string        `koanf:"build-command"`
+	OutputPath      string        `koanf:"output-path"`
+	WorkDir         string        `koanf:"work-dir"`
+	BuildTimeout    time.Duration `koanf:"build-timeout"`
+	MaxSourceSize   int           `koanf:"max-source-size"`
+	MaxUnpackedSize int64         `koanf:"max-unpacked-size"`
+	QueueSize       int           `koanf:"queue-size"`
+}
+
+var DefaultStylusVerifierConfig = StylusVerifierConfig{
+	Enable:          false,
+	BuildCommand:    "docker run --rm --network none --cpus 2 --memory 4g -v {source}:/source -w /source offchainlabs/cargo-stylus-base:{cargo-stylus-version} cargo +{toolchain} build --offline --locked --release --target wasm32-unknown-unknown",
+	OutputPath:      "target/wasm32-unknown-unknown/release/{package}.wasm",
+	WorkDir:         "",
+	BuildTimeout:    15 * time.Minute,
+	MaxSourceSize:   16 * 1024 * 1024,
+	MaxUnpackedSize: 1024 * 1024 * 1024,
+	QueueSize:       16,
+}
+
+func StylusVerifierConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultStylusVerifierConfig.Enable, "verify Stylus programs against their submitted source by rebuilding it, served by the arb_submitStylusSource and arb_stylusVerification RPC methods")
+	f.String(prefix+".build-command", DefaultStylusVerifierConfig.BuildCommand, "command building the unpacked source, which should be sandboxed without network access so dependencies must be vendored. {source}, {toolchain}, {cargo-stylus-version} and {package} are replaced by the source directory and the submission's build metadata")
+	f.String(prefix+".output-path", DefaultStylusVerifierConfig.OutputPath, "path of the built wasm relative to the source directory, with the same placeholders as build-command")
+	f.String(prefix+".work-dir", DefaultStylusVerifierConfig.WorkDir, "directory sources are unpacked and built in (defaults to the system's temporary directory)")
+	f.Duration(prefix+".build-timeout", DefaultStylusVerifierConfig.BuildTimeout, "how long a build may run before it's killed")
+	f.Int(prefix+".max-source-size", DefaultStylusVerifierConfig.MaxSourceSize, "largest compressed source archive accepted, in bytes")
+	f.Int64(prefix+".max-unpacked-size", DefaultStylusVerifierConfig.MaxUnpackedSize, "largest a source archive may be once unpacked, in bytes")
+	f.Int(prefix+".queue-size", DefaultStylusVerifierConfig.QueueSize, "how many submissions can wait to be built before new ones are rejected")
+}
+
+func (c *StylusVerifierConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if len(strings.Fields(c.BuildCommand)) == 0 {
+		return errors.New("stylus verifier build-command cannot be empty")
+	}
+	if c.OutputPath == "" {
+		return errors.New("stylus verifier output-path cannot be empty")
+	}
+	if c.BuildTimeout <= 0 {
+		return errors.New("stylus verifier build-timeout must be positive")
+	}
+	if c.MaxSourceSize <= 0 || c.MaxUnpackedSize <= 0 {
+		return errors.New("stylus verifier source size limits must be positive")
+	}
+	if c.QueueSize < 1 {
+		return errors.New("stylus verifier queue-size must be positive")
+	}
+	return nil
+}
+
+// StylusBuildMetadata pins the build a Stylus program's source is reproduced with.
+type StylusBuildMetadata struct {
+	Toolchain          string `json:"toolchain"`
+	CargoStylusVersion string `json:"cargoStylusVersion"`
+	// The crate whose wasm is deployed
+	Package string `json:"package"`
+}
+
+// Metadata is substituted into the build command, so it's restricted to characters safe in image tags and paths
+var stylusMetadataPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+func (m *StylusBuildMetadata) Validate() error {
+	for name, value := range map[string]string{"toolchain": m.Toolchain, "cargoStylusVersion": m.CargoStylusVersion, "package": m.Package} {
+		if !stylusMetadataPattern.MatchString(value) {
+			return fmt.Errorf("invalid %v %q", name, value)
+		}
+	}
+	return nil
+}
+
+// StylusSourceSubmission is a program's source, as a gzipped tarball of its crate with vendored dependencies.
+type StylusSourceSubmission struct {
+	Program  common.Address      `json:"program"`
+	Source   hexutil.Bytes       `json:"source"`
+	Metadata StylusBuildMetadata `json:"metadata"`
+}
+
+const (
+	StylusVerificationPending  = "pending"
+	StylusVerificationVerified = "verified"
+	// The source built, but not to the deployed wasm
+	StylusVerificationMismatch = "mismatch"
+	StylusVerificationFailed   = "failed"
+)
+
+// StylusVerification is the outcome of the latest submission of a program's source.
+type StylusVerification struct {
+	Program    common.Address      `json:"program"`
+	Status     string              `json:"status"`
+	SourceHash common.Hash         `json:"sourceHash"`
+	Metadata   StylusBuildMetadata `json:"metadata"`
+	// The program's code hash when it was checked, as the program may since have been redeployed
+	CodeHash         common.Hash    `json:"codeHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	DeployedWasmHash *common.Hash   `json:"deployedWasmHash,omitempty"`
+	BuiltWasmHash    *common.Hash   `json:"builtWasmHash,omitempty"`
+	Error            string         `json:"error,omitempty"`
+	Timestamp        hexutil.Uint64 `json:"timestamp"`
+}
+
+type stylusVerificationJob struct {
+	submission *StylusSourceSubmission
+	result     *StylusVerification
+	// The deployed wasm, decompressed when the source was submitted
+	deployedWasm []byte
+}
+
+// StylusVerifier rebuilds the sources submitted for Stylus programs one at a time, and records whether they
+// reproduce the deployed wasm.
+type StylusVerifier struct {
+	stopwaiter.StopWaiter
+	config *StylusVerifierConfig
+	bc     *core.BlockChain
+	db     ethdb.KeyValueStore
+	queue  chan *stylusVerificationJob
+
+	mutex   sync.Mutex
+	pending map[common.Address]bool
+}
+
+func NewStylusVerifier(config *StylusVerifierConfig, bc *core.BlockChain, db ethdb.KeyValueStore) *StylusVerifier {
+	return &StylusVerifier{
+		config:  config,
+		bc:      bc,
+		db:      db,
+		queue:   make(chan *stylusVerificationJob, config.QueueSize),
+		pending: make(map[common.Address]bool),
+	}
+}
+
+func (v *StylusVerifier) Start(ctx context.Context) {
+	v.StopWaiter.Start(ctx, v)
+	v.LaunchThread(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-v.queue:
+				v.verify(ctx, job)
+			}
+		}
+	})
+}
+
+// Verification returns the outcome of the latest submission for a program, or nil if there wasn't one.
+func (v *StylusVerifier) Verification(program common.Address) (*StylusVerification, error) {
+	key := stylusVerificationKey(program)
+	has, err := v.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := v.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var result StylusVerification
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (v *StylusVerifier) write(result *StylusVerification) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return v.db.Put(stylusVerificationKey(result.Program), data)
+}
+
+// Submit checks the program is deployed and queues its source to be built.
+func (v *StylusVerifier) Submit(submission *StylusSourceSubmission) (*StylusVerification, error) {
+	if len(submission.Source) > v.config.MaxSourceSize {
+		return nil, fmt.Errorf("source archive is %v bytes, over the limit of %v", len(submission.Source), v.config.MaxSourceSize)
+	}
+	if err := submission.Metadata.Validate(); err != nil {
+		return nil, err
+	}
+	header := v.bc.CurrentBlock()
+	statedb, err := v.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	code := statedb.GetCode(submission.Program)
+	if !state.IsStylusProgram(code) {
+		return nil, fmt.Errorf("%v isn't a Stylus program", submission.Program)
+	}
+	deployedWasm, err := programs.GetWasmFromContractCode(code)
+	if err != nil {
+		return nil, err
+	}
+	codeHash := crypto.Keccak256Hash(code)
+	previous, err := v.Verification(submission.Program)
+	if err != nil {
+		return nil, err
+	}
+	// Don't let anyone replace a verification that still holds
+	if previous != nil && previous.Status == StylusVerificationVerified && previous.CodeHash == codeHash {
+		return nil, fmt.Errorf("%v is already verified", submission.Program)
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.pending[submission.Program] {
+		return nil, fmt.Errorf("a submission for %v is already pending", submission.Program)
+	}
+	result := &StylusVerification{
+		Program:     submission.Program,
+		Status:      StylusVerificationPending,
+		SourceHash:  crypto.Keccak256Hash(submission.Source),
+		Metadata:    submission.Metadata,
+		CodeHash:    codeHash,
+		BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		Timestamp:   hexutil.Uint64(time.Now().Unix()),
+	}
+	select {
+	case v.queue <- &stylusVerificationJob{submission, result, deployedWasm}:
+	default:
+		return nil, errors.New("too many pending submissions, try again later")
+	}
+	v.pending[submission.Program] = true
+	return result, nil
+}
+
+func (v *StylusVerifier) verify(ctx context.Context, job *stylusVerificationJob) {
+	result := job.result
+	builtWasm, err := v.build(ctx, job.submission)
+	if ctx.Err() != nil {
+		return
+	}
+	deployedHash := crypto.Keccak256Hash(job.deployedWasm)
+	result.DeployedWasmHash = &deployedHash
+	if err != nil {
+		result.Status = StylusVerificationFailed
+		result.Error = err.Error()
+		stylusFailedCounter.Inc(1)
+	} else {
+		builtHash := crypto.Keccak256Hash(builtWasm)
+		result.BuiltWasmHash = &builtHash
+		result.Status = StylusVerificationMismatch
+		if bytes.Equal(builtWasm, job.deployedWasm) {
+			result.Status = StylusVerificationVerified
+			stylusVerifiedCounter.Inc(1)
+		} else {
+			stylusMismatchCounter.Inc(1)
+		}
+	}
+	result.Timestamp = hexutil.Uint64(time.Now().Unix())
+	log.Info("verified Stylus program source", "program", result.Program, "status", result.Status, "err", result.Error)
+	if err := v.write(result); err != nil {
+		log.Error("failed to record Stylus verification", "program", result.Program, "err", err)
+	}
+	v.mutex.Lock()
+	delete(v.pending, result.Program)
+	v.mutex.Unlock()
+}
+
+// build unpacks the source into a fresh directory, runs the build command there and returns the wasm it built.
+func (v *StylusVerifier) build(ctx context.Context, submission *StylusSourceSubmission) ([]byte, error) {
+	dir, err := os.MkdirTemp(v.config.WorkDir, "stylus-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := unpackSource(submission.Source, dir, v.config.MaxUnpackedSize); err != nil {
+		return nil, fmt.Errorf("failed to unpack source: %w", err)
+	}
+	expand := strings.NewReplacer(
+		"{source}", dir,
+		"{toolchain}", submission.Metadata.Toolchain,
+		"{cargo-stylus-version}", submission.Metadata.CargoStylusVersion,
+		"{package}", strings.ReplaceAll(submission.Metadata.Package, "-", "_"),
+	).Replace
+	var args []string
+	for _, arg := range strings.Fields(v.config.BuildCommand) {
+		args = append(args, expand(arg))
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.config.BuildTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("build failed: %w: %v", err, lastLines(output.String(), 20))
+	}
+	return os.ReadFile(filepath.Join(dir, filepath.FromSlash(expand(v.config.OutputPath))))
+}
+
+func lastLines(text string, count int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unpackSource extracts a gzipped tarball's regular files and directories into dir, rejecting paths
+// escaping it and archives unpacking to more than maxSize bytes.
+func unpackSource(archive []byte, dir string, maxSize int64) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	remaining := maxSize
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("path %q escapes the source directory", header.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if header.Size > remaining {
+				return fmt.Errorf("source unpacks to more than %v bytes", maxSize)
+			}
+			remaining -= header.Size
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, io.LimitReader(reader, header.Size))
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %q in source archive", header.Name)
+		}
+	}
+}
+
+type StylusVerifierAPI struct {
+	verifier *StylusVerifier
+}
+
+func NewStylusVerifierAPI(verifier *StylusVerifier) *StylusVerifierAPI {
+	return &StylusVerifierAPI{verifier}
+}
+
+// SubmitStylusSource queues a Stylus program's source to be rebuilt and compared with the deployed wasm,
+// returning the pending verification.
+func (api *StylusVerifierAPI) SubmitStylusSource(_ context.Context, submission StylusSourceSubmission) (*StylusVerification, error) {
+	return api.verifier.Submit(&submission)
+}
+
+// StylusVerification returns the outcome of the latest source submitted for a Stylus program, or null if
+// none was. Check its code hash against the program's current one, as the program may have been redeployed.
+func (api *StylusVerifierAPI) StylusVerification(_ context.Context, program common.Address) (*StylusVerification, error) {
+	return api.verifier.Verification(program)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func packSource(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gz)
+	for name, contents := range files {
+		if err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestStylusVerifier(t *testing.T) {
+	config := DefaultStylusVerifierConfig
+	config.Enable = true
+	// The source ships the "built" wasm, so the test needs no toolchain
+	config.BuildCommand = "true"
+	config.OutputPath = "out/{package}.wasm"
+	config.WorkDir = t.TempDir()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewStylusVerifier(&config, nil, rawdb.NewMemoryDatabase())
+	deployedWasm := []byte("\x00asm\x01\x00\x00\x00deployed")
+	metadata := StylusBuildMetadata{Toolchain: "1.81.0", CargoStylusVersion: "0.5.3", Package: "my-program"}
+
+	run := func(program common.Address, builtWasm []byte) *StylusVerification {
+		t.Helper()
+		job := &stylusVerificationJob{
+			submission: &StylusSourceSubmission{
+				Program:  program,
+				Source:   packSource(t, map[string][]byte{"Cargo.toml": []byte("[package]"), "out/my_program.wasm": builtWasm}),
+				Metadata: metadata,
+			},
+			result:       &StylusVerification{Program: program, Status: StylusVerificationPending},
+			deployedWasm: deployedWasm,
+		}
+		verifier.verify(context.Background(), job)
+		result, err := verifier.Verification(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result == nil {
+			t.Fatal("verification wasn't recorded")
+		}
+		return result
+	}
+
+	verified := common.HexToAddress("0x1111")
+	if result := run(verified, deployedWasm); result.Status != StylusVerificationVerified || *result.BuiltWasmHash != *result.DeployedWasmHash {
+		t.Fatal("matching build wasn't verified", result)
+	}
+	if result := run(common.HexToAddress("0x2222"), []byte("\x00asm\x01\x00\x00\x00other")); result.Status != StylusVerificationMismatch {
+		t.Fatal("differing build wasn't a mismatch", result)
+	}
+	// The build's output is missing
+	verifier.config.OutputPath = "missing.wasm"
+	if result := run(common.HexToAddress("0x3333"), deployedWasm); result.Status != StylusVerificationFailed || result.Error == "" {
+		t.Fatal("failed build wasn't recorded", result)
+	}
+	if result, err := verifier.Verification(common.HexToAddress("0x4444")); err != nil || result != nil {
+		t.Fatal("unexpected verification of an unsubmitted program", result, err)
+	}
+
+	metadata.Toolchain = "1.81.0 --privileged"
+	if err := metadata.Validate(); err == nil {
+		t.Fatal("accepted metadata that would inject arguments")
+	}
+}
+
+func TestUnpackSourceRejectsEscapes(t *testing.T) {
+	for _, name := range []string{"../escape", "/etc/passwd", "src/../../escape"} {
+		archive := packSource(t, map[string][]byte{name: []byte("x")})
+		if err := unpackSource(archive, t.TempDir(), 1024); err == nil {
+			t.Fatal("unpacked an entry escaping the source directory", name)
+		}
+	}
+	archive := packSource(t, map[string][]byte{"big": make([]byte, 2048)})
+	if err := unpackSource(archive, t.TempDir(), 1024); err == nil {
+		t.Fatal("unpacked a source over the size limit")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
+)
+
+var duplicateTxRejectedCounter = metrics.NewRegisteredCounter("arb/sequencer/duplicatetx/rejected", nil)
+
+// DuplicateTxErrorCode is the JSON-RPC error code of transactions rejected as duplicates
+const DuplicateTxErrorCode = rpcerrors.CodeDuplicateTx
+
+var ErrDuplicateTx = errors.New("duplicate transaction")
+
+// DuplicateTxError rejects a transaction byte-identical to one submitted within the duplicate window.
+type DuplicateTxError struct {
+	Hash common.Hash
+	Age  time.Duration
+}
+
+func (e DuplicateTxError) Error() string {
+	return fmt.Sprintf("%v: %v was already submitted %v ago", ErrDuplicateTx, e.Hash, e.Age.Round(time.Millisecond))
+}
+
+func (e DuplicateTxError) ErrorCode() int {
+	return DuplicateTxErrorCode
+}
+
+func (e DuplicateTxError) Unwrap() error {
+	return ErrDuplicateTx
+}
+
+type DuplicateTxConfig struct {
+	Enable    bool          `koanf:"enable"`
+	Window    time.Duration `koanf:"window" reload:"hot"`
+	CacheSize int           `koanf:"cache-size"`
+}
+
+var DefaultDuplicateTxConfig = DuplicateTxConfig{
+	Enable:    false,
+	Window:    10 * time.Second,
+	CacheSize: 16384,
+}
+
+func DuplicateTxConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultDuplicateTxConfig.Enable, "reject transactions byte-identical to one submitted within the window, with a distinct error code")
+	f.Duration(prefix+".window", DefaultDuplicateTxConfig.Window, "how long after a transaction is submitted identical copies of it are rejected")
+	f.Int(prefix+".cache-size", DefaultDuplicateTxConfig.CacheSize, "number of recently submitted transactions to remember")
+}
+
+func (c *DuplicateTxConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Window <= 0 {
+		return errors.New("duplicate transaction window must be positive")
+	}
+	if c.CacheSize <= 0 {
+		return errors.New("duplicate transaction cache-size must be positive")
+	}
+	return nil
+}
+
+// duplicateTxFilter remembers when recently submitted transactions were first seen, by hash, which
+// identifies their raw bytes.
+type duplicateTxFilter struct {
+	mutex  sync.Mutex
+	seen   *containers.LruCache[common.Hash, time.Time]
+	config func() *DuplicateTxConfig
+}
+
+func newDuplicateTxFilter(config func() *DuplicateTxConfig) *duplicateTxFilter {
+	return &duplicateTxFilter{
+		seen:   containers.NewLruCache[common.Hash, time.Time](config().CacheSize),
+		config: config,
+	}
+}
+
+// admit records a transaction as seen, or returns a DuplicateTxError if it was already seen within the window.
+func (f *duplicateTxFilter) admit(hash common.Hash, now time.Time) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if firstSeen, ok := f.seen.Peek(hash); ok {
+		if age := now.Sub(firstSeen); age < f.config().Window {
+			return DuplicateTxError{Hash: hash, Age: age}
+		}
+	}
+	f.seen.Add(hash, now)
+	return nil
+}
+
+// forget stops treating a transaction as seen, so it can be resubmitted once it's failed.
+func (f *duplicateTxFilter) forget(hash common.Hash) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.seen.Remove(hash)
+}
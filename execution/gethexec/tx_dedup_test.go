@@ -0,0 +1,56 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDuplicateTxFilter(t *testing.T) {
+	config := DefaultDuplicateTxConfig
+	config.Enable = true
+	config.CacheSize = 2
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	filter := newDuplicateTxFilter(func() *DuplicateTxConfig { return &config })
+	start := time.Now()
+	first := common.HexToHash("0x01")
+
+	if err := filter.admit(first, start); err != nil {
+		t.Fatal(err)
+	}
+	err := filter.admit(first, start.Add(time.Second))
+	var duplicate DuplicateTxError
+	if !errors.As(err, &duplicate) || !errors.Is(err, ErrDuplicateTx) || duplicate.ErrorCode() != DuplicateTxErrorCode {
+		t.Fatal("duplicate within the window wasn't rejected", err)
+	}
+	if duplicate.Age != time.Second {
+		t.Fatal("unexpected age", duplicate.Age)
+	}
+	// Rejected copies don't extend the window
+	if err := filter.admit(first, start.Add(config.Window)); err != nil {
+		t.Fatal("duplicate after the window was rejected", err)
+	}
+
+	filter.forget(first)
+	if err := filter.admit(first, start.Add(config.Window+time.Second)); err != nil {
+		t.Fatal("forgotten transaction was rejected", err)
+	}
+
+	// The oldest transactions are forgotten once the cache is full
+	if err := filter.admit(common.HexToHash("0x02"), start); err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.admit(common.HexToHash("0x03"), start); err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.admit(first, start.Add(config.Window+2*time.Second)); err != nil {
+		t.Fatal("evicted transaction was rejected", err)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 	flag "github.com/spf13/pflag"
 )
 
@@ -35,9 +36,10 @@ const TxPreCheckerStrictnessLikelyCompatible uint = 20
 const TxPreCheckerStrictnessFullValidation uint = 30
 
 type TxPreCheckerConfig struct {
-	Strictness             uint  `koanf:"strictness" reload:"hot"`
-	RequiredStateAge       int64 `koanf:"required-state-age" reload:"hot"`
-	RequiredStateMaxBlocks uint  `koanf:"required-state-max-blocks" reload:"hot"`
+	Strictness             uint                    `koanf:"strictness" reload:"hot"`
+	RequiredStateAge       int64                   `koanf:"required-state-age" reload:"hot"`
+	RequiredStateMaxBlocks uint                    `koanf:"required-state-max-blocks" reload:"hot"`
+	Rules                  TxPreCheckerRulesConfig `koanf:"rules" reload:"hot"`
 }
 
 type TxPreCheckerConfigFetcher func() *TxPreCheckerConfig
@@ -46,6 +48,7 @@ var DefaultTxPreCheckerConfig = TxPreCheckerConfig{
 	Strictness:             TxPreCheckerStrictnessLikelyCompatible,
 	RequiredStateAge:       2,
 	RequiredStateMaxBlocks: 4,
+	Rules:                  DefaultTxPreCheckerRulesConfig,
 }
 
 func TxPreCheckerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -54,6 +57,11 @@ func TxPreCheckerConfigAddOptions(prefix string, f *flag.FlagSet) {
 		"30 = full validation which may reject txs that would succeed")
 	f.Int64(prefix+".required-state-age", DefaultTxPreCheckerConfig.RequiredStateAge, "how long ago should the storage conditions from eth_SendRawTransactionConditional be true, 0 = don't check old state")
 	f.Uint(prefix+".required-state-max-blocks", DefaultTxPreCheckerConfig.RequiredStateMaxBlocks, "maximum number of blocks to look back while looking for the <required-state-age> seconds old state, 0 = don't limit the search")
+	TxPreCheckerRulesConfigAddOptions(prefix+".rules", f)
+}
+
+func (c *TxPreCheckerConfig) Validate() error {
+	return c.Rules.Validate()
 }
 
 type TxPreChecker struct {
@@ -110,6 +118,9 @@ func MakeNonceError(sender common.Address, txNonce uint64, stateNonce uint64) er
 }
 
 func PreCheckTx(bc *core.BlockChain, chainConfig *params.ChainConfig, header *types.Header, statedb *state.StateDB, arbos *arbosState.ArbosState, tx *types.Transaction, options *arbitrum_types.ConditionalOptions, config *TxPreCheckerConfig) error {
+	if err := config.Rules.check(statedb, tx); err != nil {
+		return err
+	}
 	if config.Strictness < TxPreCheckerStrictnessAlwaysCompatible {
 		return nil
 	}
@@ -153,7 +164,7 @@ func PreCheckTx(bc *core.BlockChain, chainConfig *params.ChainConfig, header *ty
 	if options != nil {
 		if err := options.Check(extraInfo.L1BlockNumber, header.Time, statedb); err != nil {
 			conditionalTxRejectedByTxPreCheckerCurrentStateCounter.Inc(1)
-			return err
+			return rpcerrors.WithDefault(rpcerrors.CodeConditionalRejected, err)
 		}
 		conditionalTxAcceptedByTxPreCheckerCurrentStateCounter.Inc(1)
 		if config.RequiredStateAge > 0 {
@@ -180,7 +191,7 @@ func PreCheckTx(bc *core.BlockChain, chainConfig *params.ChainConfig, header *ty
 				oldExtraInfo := types.DeserializeHeaderExtraInformation(oldHeader)
 				if err := options.Check(oldExtraInfo.L1BlockNumber, oldHeader.Time, secondOldStatedb); err != nil {
 					conditionalTxRejectedByTxPreCheckerOldStateCounter.Inc(1)
-					return arbitrum_types.WrapOptionsCheckError(err, "conditions check failed for old state")
+					return rpcerrors.WithDefault(rpcerrors.CodeConditionalRejected, arbitrum_types.WrapOptionsCheckError(err, "conditions check failed for old state"))
 				}
 			}
 			conditionalTxAcceptedByTxPreCheckerOldStateCounter.Inc(1)
@@ -0,0 +1,80 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	eoaCalldataRuleRejectedCounter    = metrics.NewRegisteredCounter("arb/txprechecker/rules/eoacalldata/rejected", nil)
+	bannedInitCodeRuleRejectedCounter = metrics.NewRegisteredCounter("arb/txprechecker/rules/bannedinitcode/rejected", nil)
+)
+
+var ErrTxRejectedByRule = errors.New("transaction rejected by node policy")
+
+// TxPreCheckerRulesConfig holds cheap policy rules checked before a transaction is forwarded or sequenced,
+// which apply whatever the pre-checker's strictness.
+type TxPreCheckerRulesConfig struct {
+	MaxEOACalldata int      `koanf:"max-eoa-calldata"`
+	BannedInitCode []string `koanf:"banned-init-code"`
+	bannedInitCode [][]byte
+}
+
+var DefaultTxPreCheckerRulesConfig = TxPreCheckerRulesConfig{
+	MaxEOACalldata: 0,
+	BannedInitCode: []string{},
+}
+
+func TxPreCheckerRulesConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".max-eoa-calldata", DefaultTxPreCheckerRulesConfig.MaxEOACalldata, "reject transactions to accounts without code (other than precompiles) carrying more than this many bytes of calldata (0 = no limit)")
+	f.StringSlice(prefix+".banned-init-code", DefaultTxPreCheckerRulesConfig.BannedInitCode, "comma separated hex byte patterns; reject contract creations whose init code contains any of them")
+}
+
+func (c *TxPreCheckerRulesConfig) Validate() error {
+	if c.MaxEOACalldata < 0 {
+		return errors.New("tx pre-checker rules max-eoa-calldata cannot be negative")
+	}
+	c.bannedInitCode = make([][]byte, 0, len(c.BannedInitCode))
+	for _, pattern := range c.BannedInitCode {
+		decoded, err := hexutil.Decode(pattern)
+		if err != nil {
+			return fmt.Errorf("tx pre-checker banned-init-code pattern \"%v\" is not valid hex: %w", pattern, err)
+		}
+		if len(decoded) == 0 {
+			return errors.New("tx pre-checker banned-init-code patterns cannot be empty")
+		}
+		c.bannedInitCode = append(c.bannedInitCode, decoded)
+	}
+	return nil
+}
+
+func (c *TxPreCheckerRulesConfig) check(statedb *state.StateDB, tx *types.Transaction) error {
+	to := tx.To()
+	if to == nil {
+		for _, pattern := range c.bannedInitCode {
+			if bytes.Contains(tx.Data(), pattern) {
+				bannedInitCodeRuleRejectedCounter.Inc(1)
+				return fmt.Errorf("%w: init code contains banned pattern %v", ErrTxRejectedByRule, hexutil.Encode(pattern))
+			}
+		}
+		return nil
+	}
+	if c.MaxEOACalldata > 0 && len(tx.Data()) > c.MaxEOACalldata && statedb.GetCodeSize(*to) == 0 && !slices.Contains(vm.PrecompiledAddressesArbOS30, *to) {
+		eoaCalldataRuleRejectedCounter.Inc(1)
+		return fmt.Errorf("%w: %v bytes of calldata to account without code %v exceeds limit of %v", ErrTxRejectedByRule, len(tx.Data()), *to, c.MaxEOACalldata)
+	}
+	return nil
+}
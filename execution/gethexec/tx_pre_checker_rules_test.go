@@ -0,0 +1,59 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxPreCheckerRules(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eoa := common.HexToAddress("0x1234")
+	contract := common.HexToAddress("0x5678")
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	arbSys := common.HexToAddress("0x64")
+
+	rules := DefaultTxPreCheckerRulesConfig
+	rules.MaxEOACalldata = 4
+	rules.BannedInitCode = []string{"0xdeadbeef"}
+	if err := rules.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	newTx := func(to *common.Address, data []byte) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{To: to, Data: data})
+	}
+	calldata := []byte{1, 2, 3, 4, 5}
+	if err := rules.check(statedb, newTx(&eoa, calldata)); !errors.Is(err, ErrTxRejectedByRule) {
+		t.Fatal("expected large calldata to an account without code to be rejected but got", err)
+	}
+	if err := rules.check(statedb, newTx(&eoa, calldata[:4])); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.check(statedb, newTx(&contract, calldata)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.check(statedb, newTx(&arbSys, calldata)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.check(statedb, newTx(nil, []byte{0x60, 0xde, 0xad, 0xbe, 0xef, 0x00})); !errors.Is(err, ErrTxRejectedByRule) {
+		t.Fatal("expected init code with a banned pattern to be rejected but got", err)
+	}
+	if err := rules.check(statedb, newTx(nil, []byte{0x60, 0xde, 0xad, 0x00})); err != nil {
+		t.Fatal(err)
+	}
+
+	rules.BannedInitCode = []string{"not hex"}
+	if err := rules.Validate(); err == nil {
+		t.Fatal("expected an invalid banned-init-code pattern to fail validation")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gethexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	wasmStoreGCDeletedCounter = metrics.NewRegisteredCounter("arb/wasmstore/gc/deleted", nil)
+	wasmStoreGCFreedCounter   = metrics.NewRegisteredCounter("arb/wasmstore/gc/freed", nil)
+	wasmStoreGCModulesGauge   = metrics.NewRegisteredGauge("arb/wasmstore/gc/modules", nil)
+	wasmStoreGCFailureCounter = metrics.NewRegisteredCounter("arb/wasmstore/gc/failures", nil)
+)
+
+// The prefix rawdb stores activated asm under, followed by the target and module hash
+var activatedAsmPrefix = []byte{0x00, 'w'}
+
+type WasmStoreGCConfig struct {
+	Enable    bool          `koanf:"enable"`
+	Interval  time.Duration `koanf:"interval"`
+	Retention time.Duration `koanf:"retention"`
+}
+
+var DefaultWasmStoreGCConfig = WasmStoreGCConfig{
+	Enable:    false,
+	Interval:  24 * time.Hour,
+	Retention: 7 * 24 * time.Hour,
+}
+
+func WasmStoreGCConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultWasmStoreGCConfig.Enable, "periodically delete compiled Stylus programs from the wasm store once they're no longer activated")
+	f.Duration(prefix+".interval", DefaultWasmStoreGCConfig.Interval, "how often to garbage collect the wasm store")
+	f.Duration(prefix+".retention", DefaultWasmStoreGCConfig.Retention, "how long to keep the compiled programs of expired or outdated activations, in case they're reactivated")
+}
+
+func (c *WasmStoreGCConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return errors.New("wasm store gc interval must be positive")
+	}
+	if c.Retention < 0 {
+		return errors.New("wasm store gc retention cannot be negative")
+	}
+	return nil
+}
+
+// WasmStoreGC deletes the compiled programs in the wasm store whose module isn't used by any program
+// activated in the latest state, or only by activations that expired more than the retention ago.
+// A program that turns out to need a deleted module is recompiled on use, like after a wasm store rebuild.
+type WasmStoreGC struct {
+	stopwaiter.StopWaiter
+	config    *WasmStoreGCConfig
+	bc        *core.BlockChain
+	wasmStore ethdb.KeyValueStore
+}
+
+func NewWasmStoreGC(config *WasmStoreGCConfig, bc *core.BlockChain) *WasmStoreGC {
+	return &WasmStoreGC{
+		config:    config,
+		bc:        bc,
+		wasmStore: bc.StateCache().WasmStore(),
+	}
+}
+
+func (g *WasmStoreGC) Start(ctx context.Context) {
+	g.StopWaiter.Start(ctx, g)
+	g.LaunchThread(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(g.config.Interval):
+			}
+			deleted, freed, err := g.Collect(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					wasmStoreGCFailureCounter.Inc(1)
+					log.Warn("failed to garbage collect the wasm store", "err", err)
+				}
+				continue
+			}
+			log.Info("garbage collected the wasm store", "deletedEntries", deleted, "freedBytes", freed)
+		}
+	})
+}
+
+// Collect deletes unused entries, returning how many it deleted and the bytes they held.
+func (g *WasmStoreGC) Collect(ctx context.Context) (int, int, error) {
+	// List the modules before reading the state, so modules activated in the meantime aren't seen as unused
+	entries, err := g.activatedEntries(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	live, err := g.liveModules(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	wasmStoreGCModulesGauge.Update(int64(len(live)))
+
+	deleted, freed := 0, 0
+	batch := g.wasmStore.NewBatch()
+	for moduleHash, keys := range entries {
+		if live[moduleHash] {
+			continue
+		}
+		for _, entry := range keys {
+			if err := batch.Delete(entry.key); err != nil {
+				return deleted, freed, err
+			}
+			deleted++
+			freed += entry.size
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return deleted, freed, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return deleted, freed, err
+	}
+	wasmStoreGCDeletedCounter.Inc(int64(deleted))
+	wasmStoreGCFreedCounter.Inc(int64(freed))
+	return deleted, freed, nil
+}
+
+type wasmStoreEntry struct {
+	key  []byte
+	size int
+}
+
+// activatedEntries lists the wasm store's compiled programs for every target by module hash.
+func (g *WasmStoreGC) activatedEntries(ctx context.Context) (map[common.Hash][]wasmStoreEntry, error) {
+	entries := make(map[common.Hash][]wasmStoreEntry)
+	iter := g.wasmStore.NewIterator(activatedAsmPrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		key := iter.Key()
+		if len(key) != rawdb.WasmKeyLen {
+			continue
+		}
+		moduleHash := common.BytesToHash(key[rawdb.WasmPrefixLen:])
+		entry := wasmStoreEntry{key: bytes.Clone(key), size: len(key) + len(iter.Value())}
+		entries[moduleHash] = append(entries[moduleHash], entry)
+	}
+	return entries, iter.Error()
+}
+
+// liveModules finds the modules of the programs activated in the latest state, or that expired within the retention.
+func (g *WasmStoreGC) liveModules(ctx context.Context) (map[common.Hash]bool, error) {
+	header := g.bc.CurrentBlock()
+	statedb, err := g.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	programs := arbState.Programs()
+	params, err := programs.Params()
+	if err != nil {
+		return nil, err
+	}
+	retention := uint64(g.config.Retention.Seconds())
+
+	live := make(map[common.Hash]bool)
+	iter := statedb.Database().DiskDB().NewIterator(rawdb.CodePrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		key := iter.Key()
+		if len(key) != len(rawdb.CodePrefix)+common.HashLength || !state.IsStylusProgram(iter.Value()) {
+			continue
+		}
+		codeHash := common.BytesToHash(key[len(rawdb.CodePrefix):])
+		moduleHash, inactiveFor, ok, err := programs.ActivatedModule(codeHash, header.Time, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read activation of %v: %w", codeHash, err)
+		}
+		if ok && inactiveFor <= retention {
+			live[moduleHash] = true
+		}
+	}
+	return live, iter.Error()
+}
@@ -25,6 +25,7 @@ type RecordResult struct {
 
 var ErrRetrySequencer = errors.New("please retry transaction")
 var ErrSequencerInsertLockTaken = errors.New("insert lock taken")
+var ErrSequencerFrozen = errors.New("sequencer is frozen")
 
 // always needed
 type ExecutionClient interface {
@@ -77,6 +78,18 @@ type FullExecutionClient interface {
 type BatchFetcher interface {
 	FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error)
 	GetBatchParentChainBlock(seqNum uint64) (uint64, error)
+	GetBatchDataProof(ctx context.Context, seqNum uint64) (*BatchDataProof, error)
+}
+
+// BatchDataProof ties the hash of a posted batch's data to its accumulator in the SequencerInbox,
+// where keccak256(BeforeAcc, DataHash, DelayedAcc) is inboxAccs(SequenceNumber).
+type BatchDataProof struct {
+	SequenceNumber   uint64      `json:"sequenceNumber"`
+	DataHash         common.Hash `json:"dataHash"`
+	BeforeAcc        common.Hash `json:"beforeAcc"`
+	DelayedAcc       common.Hash `json:"delayedAcc"`
+	AfterAcc         common.Hash `json:"afterAcc"`
+	ParentChainBlock uint64      `json:"parentChainBlock"`
 }
 
 type ConsensusInfo interface {
@@ -88,6 +101,7 @@ type ConsensusInfo interface {
 	GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error)
 	GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error)
 	ValidatedMessageCount() (arbutil.MessageIndex, error)
+	BatchPostedMessageCount() (arbutil.MessageIndex, error)
 }
 
 type ConsensusSequencer interface {
@@ -20,9 +20,11 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/arbutil"
@@ -177,6 +179,30 @@ func (n NodeInterface) GetL1Confirmations(c ctx, evm mech, blockHash bytes32) (u
 	return (latestBlockNum - parentChainBlockNum), nil
 }
 
+// ConstructBatchDataProof proves a batch posted the data with the given hash. A contract on the parent chain
+// checks it by comparing keccak256(beforeAcc, dataHash, delayedAcc) with the SequencerInbox's inboxAccs(batchNum).
+func (n NodeInterface) ConstructBatchDataProof(c ctx, evm mech, batchNum uint64, dataHash bytes32) (bytes32, bytes32, bytes32, uint64, error) {
+	hash0 := bytes32{}
+	node, err := gethExecFromNodeInterfaceBackend(n.backend)
+	if err != nil {
+		return hash0, hash0, hash0, 0, err
+	}
+	fetcher := node.ExecEngine.GetBatchFetcher()
+	if fetcher == nil {
+		return hash0, hash0, hash0, 0, errors.New("batch fetcher not set")
+	}
+	proof, err := fetcher.GetBatchDataProof(n.context, batchNum)
+	if err != nil {
+		// Hide the parent chain RPC error from the client in case it contains sensitive information.
+		log.Warn("Failed to construct batch data proof", "batchNum", batchNum, "err", err)
+		return hash0, hash0, hash0, 0, fmt.Errorf("failed to construct proof for batch %v", batchNum)
+	}
+	if proof.DataHash != dataHash {
+		return hash0, hash0, hash0, 0, fmt.Errorf("batch %v didn't post data with hash %v", batchNum, common.Hash(dataHash))
+	}
+	return proof.BeforeAcc, proof.DelayedAcc, proof.AfterAcc, proof.ParentChainBlock, nil
+}
+
 func (n NodeInterface) EstimateRetryableTicket(
 	c ctx,
 	evm mech,
@@ -224,6 +250,205 @@ func (n NodeInterface) EstimateRetryableTicket(
 	return nil
 }
 
+// Padding applied to the fees RecommendRetryableTicketParams recommends, so the retryable can still be
+// created and auto-redeemed if the parent chain's or this chain's base fee rises before it lands.
+const (
+	RetryableL1BaseFeePadding arbmath.Bips = 40000 // quadruple the parent chain's base fee
+	RetryableBaseFeePadding   arbmath.Bips = 20000 // double the L2 base fee
+)
+
+// parentChainBaseFee is the base fee the inbox charges submission fees at: the latest parent chain header's,
+// falling back to ArbOS's estimate of the L1 price.
+func (n NodeInterface) parentChainBaseFee(c ctx) (huge, error) {
+	l1BaseFee, err := c.State.L1PricingState().PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	if node, err := gethExecFromNodeInterfaceBackend(n.backend); err == nil && node.ParentChainReader != nil {
+		header, err := node.ParentChainReader.LastHeaderWithError()
+		if err == nil && header != nil && header.BaseFee != nil {
+			l1BaseFee = header.BaseFee
+		}
+	}
+	return l1BaseFee, nil
+}
+
+// estimateRetryableGas estimates the gas limit a retryable needs to be created and auto-redeemed at
+// maxFeePerGas, by simulating estimateRetryableTicket with a deposit covering any gas limit it might try.
+func (n NodeInterface) estimateRetryableGas(
+	evm mech,
+	backend *arbitrum.APIBackend,
+	sender addr,
+	to addr,
+	l2CallValue huge,
+	maxSubmissionCost huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	maxFeePerGas huge,
+	data []byte,
+) (uint64, error) {
+	gasCap := backend.RPCGasCap()
+	deposit := arbmath.BigAdd(l2CallValue, maxSubmissionCost)
+	deposit = arbmath.BigAdd(deposit, arbmath.BigMulByUint(maxFeePerGas, gasCap))
+
+	nodeInterfaceAbi, err := node_interfacegen.NodeInterfaceMetaData.GetAbi()
+	if err != nil {
+		return 0, err
+	}
+	calldata, err := nodeInterfaceAbi.Pack(
+		"estimateRetryableTicket", sender, deposit, to, l2CallValue, excessFeeRefundAddress, callValueRefundAddress, data,
+	)
+	if err != nil {
+		return 0, err
+	}
+	nodeInterfaceAddress := types.NodeInterfaceAddress
+	args := arbitrum.TransactionArgs{
+		ChainID:      (*hexutil.Big)(evm.ChainConfig().ChainID),
+		From:         &sender,
+		To:           &nodeInterfaceAddress,
+		MaxFeePerGas: (*hexutil.Big)(maxFeePerGas),
+		Data:         (*hexutil.Bytes)(&calldata),
+	}
+	block := rpc.BlockNumberOrHashWithHash(n.header.Hash(), false)
+	gasLimit, err := arbitrum.EstimateGas(n.context, backend, args, block, nil, gasCap)
+	return uint64(gasLimit), err
+}
+
+// RecommendRetryableTicketParams recommends the maxSubmissionCost, gasLimit and maxFeePerGas for a retryable
+// from sender calling to with l2CallValue and data. The gas limit comes from simulating the ticket's
+// auto-redeem like EstimateRetryableTicket does, at the recommended maxFeePerGas.
+func (n NodeInterface) RecommendRetryableTicketParams(
+	c ctx,
+	evm mech,
+	sender addr,
+	to addr,
+	l2CallValue huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	data []byte,
+) (huge, uint64, huge, error) {
+	backend, ok := n.backend.(*arbitrum.APIBackend)
+	if !ok {
+		return nil, 0, nil, errors.New("failed getting API backend")
+	}
+
+	l1BaseFee, err := n.parentChainBaseFee(c)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	maxSubmissionCost := retryables.RetryableSubmissionFee(len(data), arbmath.BigMulByBips(l1BaseFee, RetryableL1BaseFeePadding))
+
+	baseFee, err := c.State.L2PricingState().BaseFeeWei()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	maxFeePerGas := arbmath.BigMulByBips(baseFee, RetryableBaseFeePadding)
+
+	gasLimit, err := n.estimateRetryableGas(
+		evm, backend, sender, to, l2CallValue, maxSubmissionCost, excessFeeRefundAddress, callValueRefundAddress, maxFeePerGas, data,
+	)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return maxSubmissionCost, gasLimit, maxFeePerGas, nil
+}
+
+// EstimateRetryableAutoRedeem checks whether a retryable created with these parameters would be auto-redeemed
+// successfully at the current L2 base fee. It returns the gas limit the auto-redeem needs (0 if it reverts
+// at any gas limit), the current base fee, the lowest base fee at which the auto-redeem would no longer be
+// attempted (0 if it already wouldn't be, whatever the base fee), and how much more gas backlog the chain
+// can build up before the pricing model raises the base fee that far.
+func (n NodeInterface) EstimateRetryableAutoRedeem(
+	c ctx,
+	evm mech,
+	sender addr,
+	deposit huge,
+	to addr,
+	l2CallValue huge,
+	maxSubmissionCost huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	gasLimit uint64,
+	maxFeePerGas huge,
+	data []byte,
+) (bool, uint64, huge, huge, uint64, error) {
+	backend, ok := n.backend.(*arbitrum.APIBackend)
+	if !ok {
+		return false, 0, nil, nil, 0, errors.New("failed getting API backend")
+	}
+	l2Pricing := c.State.L2PricingState()
+	baseFee, err := l2Pricing.BaseFeeWei()
+	if err != nil {
+		return false, 0, nil, nil, 0, err
+	}
+
+	// Mirror the checks ArbOS makes before scheduling the auto-redeem
+	l1BaseFee, err := n.parentChainBaseFee(c)
+	if err != nil {
+		return false, 0, nil, nil, 0, err
+	}
+	funded := !arbmath.BigLessThan(maxSubmissionCost, retryables.RetryableSubmissionFee(len(data), l1BaseFee))
+	balance := evm.StateDB.GetBalance(util.RemapL1Address(sender)).ToBig()
+	balance = arbmath.BigSub(arbmath.BigAdd(balance, deposit), arbmath.BigAdd(maxSubmissionCost, l2CallValue))
+	if arbmath.BigLessThan(balance, arbmath.BigMulByUint(maxFeePerGas, gasLimit)) || gasLimit < params.TxGas {
+		funded = false
+	}
+
+	// A revert at every gas limit means the auto-redeem can't succeed, which isn't an error for the caller
+	estimateFeePerGas := arbmath.BigMax(maxFeePerGas, baseFee)
+	gasNeeded, err := n.estimateRetryableGas(
+		evm, backend, sender, to, l2CallValue, maxSubmissionCost, excessFeeRefundAddress, callValueRefundAddress, estimateFeePerGas, data,
+	)
+	if err != nil {
+		log.Debug("retryable auto-redeem estimate failed", "err", err)
+		gasNeeded = 0
+	}
+
+	if !funded || gasNeeded == 0 || gasLimit < gasNeeded {
+		return false, gasNeeded, baseFee, common.Big0, 0, nil
+	}
+	failingBaseFee := arbmath.BigAddByUint(maxFeePerGas, 1)
+	headroom, err := backlogHeadroom(l2Pricing, maxFeePerGas)
+	if err != nil {
+		return false, 0, nil, nil, 0, err
+	}
+	return !arbmath.BigLessThan(maxFeePerGas, baseFee), gasNeeded, baseFee, failingBaseFee, headroom, nil
+}
+
+// backlogHeadroom approximates how much the gas backlog can grow before the pricing model's base fee
+// exceeds maxBaseFee, by inverting the model's exponential.
+func backlogHeadroom(l2Pricing *l2pricing.L2PricingState, maxBaseFee huge) (uint64, error) {
+	speedLimit, err := l2Pricing.SpeedLimitPerSecond()
+	if err != nil {
+		return 0, err
+	}
+	inertia, err := l2Pricing.PricingInertia()
+	if err != nil {
+		return 0, err
+	}
+	tolerance, err := l2Pricing.BacklogTolerance()
+	if err != nil {
+		return 0, err
+	}
+	backlog, err := l2Pricing.GasBacklog()
+	if err != nil {
+		return 0, err
+	}
+	minBaseFee, err := l2Pricing.MinBaseFeeWei()
+	if err != nil {
+		return 0, err
+	}
+	if arbmath.BigLessThan(maxBaseFee, minBaseFee) || minBaseFee.Sign() <= 0 {
+		return 0, nil
+	}
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(maxBaseFee), new(big.Float).SetInt(minBaseFee)).Float64()
+	limit := float64(arbmath.SaturatingUMul(tolerance, speedLimit)) + float64(arbmath.SaturatingUMul(inertia, speedLimit))*math.Log(ratio)
+	if limit >= math.MaxUint64 {
+		return math.MaxUint64, nil
+	}
+	return arbmath.SaturatingUSub(uint64(limit), backlog), nil
+}
+
 func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64) (bytes32, bytes32, []bytes32, error) {
 
 	hash0 := bytes32{}
@@ -288,9 +513,33 @@ func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64)
 		return query[i].Leaf < query[j].Leaf
 	})
 
+	// the hashes of the nodes we queried, as their events reported them
+	type foundNode struct {
+		position hash
+		hash     hash
+	}
+	var found []foundNode
+
+	// read what we can from the merkle index, if the node keeps one
+	if node, err := gethExecFromNodeInterfaceBackend(n.backend); err == nil && node.OutboxMerkleIndex != nil && size <= currentBlockInfo.SendCount {
+		missing := []merkletree.LevelAndLeaf{}
+		for _, item := range query {
+			position := common.BigToHash(item.ToBigInt())
+			hash, ok, err := node.OutboxMerkleIndex.Get(position)
+			if err != nil {
+				return hash0, hash0, nil, err
+			}
+			if ok {
+				found = append(found, foundNode{position, hash})
+			} else {
+				missing = append(missing, item)
+			}
+		}
+		query = missing
+	}
+
 	// collect the logs
 	var search func(lo, hi uint64, find []merkletree.LevelAndLeaf)
-	var searchLogs []*types.Log
 	var searchErr error
 	var searchPositions = make(map[hash]struct{})
 	for _, item := range query {
@@ -332,7 +581,7 @@ func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64)
 					position := log.Topics[3]
 					if _, ok := searchPositions[position]; ok {
 						// ensure log is one we're looking for
-						searchLogs = append(searchLogs, log)
+						found = append(found, foundNode{position, log.Topics[2]})
 					}
 				}
 			}
@@ -360,7 +609,9 @@ func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64)
 		}
 	}
 
-	search(0, currentBlock.Number.Uint64(), query)
+	if len(query) > 0 {
+		search(0, currentBlock.Number.Uint64(), query)
+	}
 
 	if searchErr != nil {
 		return hash0, hash0, nil, searchErr
@@ -371,10 +622,10 @@ func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64)
 	var minPartialPlace *merkletree.LevelAndLeaf    // the lowest-level partial
 	var send hash
 
-	for _, log := range searchLogs {
+	for _, node := range found {
 
-		hash := log.Topics[2]
-		position := log.Topics[3]
+		hash := node.hash
+		position := node.position
 
 		level := new(big.Int).SetBytes(position[:8]).Uint64()
 		leafAdded := new(big.Int).SetBytes(position[8:]).Uint64()
@@ -546,7 +797,10 @@ func (n NodeInterface) GasEstimateL1Component(
 	}
 	feeForL1, _ := pricing.PosterDataCost(msg, l1pricing.BatchPosterAddress, brotliCompressionLevel)
 	feeForL1 = arbmath.BigMulByBips(feeForL1, arbos.GasEstimationL1PricePadding)
-	gasForL1 := arbmath.BigDiv(feeForL1, baseFee).Uint64()
+	gasForL1 := uint64(0)
+	if baseFee.Sign() > 0 {
+		gasForL1 = arbmath.BigDiv(feeForL1, baseFee).Uint64()
+	}
 	return gasForL1, baseFee, l1BaseFeeEstimate, nil
 }
 
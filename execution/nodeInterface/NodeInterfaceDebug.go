@@ -5,12 +5,12 @@ package nodeInterface
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 )
 
 type NodeInterfaceDebug struct {
@@ -34,7 +34,7 @@ func (n NodeInterfaceDebug) GetRetryable(c ctx, evm mech, ticket bytes32) (Retry
 		return RetryableInfo{}, err
 	}
 	if retryable == nil {
-		return RetryableInfo{}, fmt.Errorf("no retryable with id %v exists", ticket)
+		return RetryableInfo{}, rpcerrors.Errorf(rpcerrors.CodeRetryableExpired, "no retryable with id %v exists", ticket)
 	}
 
 	timeout, _ := retryable.CalculateTimeout()
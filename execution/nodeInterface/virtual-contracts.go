@@ -20,6 +20,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/execution/gethexec"
 	"github.com/offchainlabs/nitro/gethhook"
+	"github.com/offchainlabs/nitro/gethhook/hooks"
 	"github.com/offchainlabs/nitro/precompiles"
 	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
@@ -46,7 +47,7 @@ func init() {
 	nodeInterfaceDebugMeta := node_interfacegen.NodeInterfaceDebugMetaData
 	_, nodeInterfaceDebug := precompiles.MakePrecompile(nodeInterfaceDebugMeta, nodeInterfaceDebugImpl)
 
-	core.InterceptRPCMessage = func(
+	interceptMessage := func(
 		msg *core.Message,
 		ctx context.Context,
 		statedb *state.StateDB,
@@ -92,7 +93,7 @@ func init() {
 				<-ctx.Done()
 				evm.Cancel()
 			}()
-			core.ReadyEVMForL2(evm, msg)
+			hooks.ReadyEVM(evm, msg)
 
 			output, gasLeft, err := precompile.Call(
 				msg.Data, address, address, msg.From, msg.Value, false, msg.GasLimit, evm,
@@ -114,7 +115,7 @@ func init() {
 		return msg, nil, nil
 	}
 
-	core.RPCPostingGasHook = func(msg *core.Message, header *types.Header, statedb *state.StateDB) (uint64, error) {
+	postingGas := func(msg *core.Message, header *types.Header, statedb *state.StateDB) (uint64, error) {
 		arbosVersion := arbosState.ArbOSVersion(statedb)
 		if arbosVersion == 0 {
 			// ArbOS hasn't been installed, so use the vanilla gas cap
@@ -138,7 +139,7 @@ func init() {
 		return arbos.GetPosterGas(state, header.BaseFee, core.MessageGasEstimationMode, posterCost), nil
 	}
 
-	core.GetArbOSSpeedLimitPerSecond = func(statedb *state.StateDB) (uint64, error) {
+	speedLimitPerSecond := func(statedb *state.StateDB) (uint64, error) {
 		arbosVersion := arbosState.ArbOSVersion(statedb)
 		if arbosVersion == 0 {
 			return 0.0, errors.New("ArbOS not installed")
@@ -157,6 +158,12 @@ func init() {
 		return speedLimit, nil
 	}
 
+	hooks.SetRPCHooks(hooks.RPCHooks{
+		InterceptMessage:    interceptMessage,
+		PostingGas:          postingGas,
+		SpeedLimitPerSecond: speedLimitPerSecond,
+	})
+
 	arbSys, err := precompilesgen.ArbSysMetaData.GetAbi()
 	if err != nil {
 		panic(err)
@@ -0,0 +1,313 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package txscreener runs an operator supplied WebAssembly policy module against transactions
+// before the sequencer accepts them.
+//
+// The module must not import anything, and must export its memory along with two functions:
+//
+//	alloc(size i32) -> i32         returns a pointer to size bytes the screener may write the transaction to
+//	screen(ptr i32, len i32) -> i64 returns the decision for the transaction written at ptr
+//
+// The transaction is encoded as the sender's address (20 bytes), the recipient's address
+// (20 bytes, all zero for contract creations), the value (32 bytes, big endian), then the calldata.
+//
+// The low byte of the decision is 0 to accept the transaction, 1 to reject it, or 2 to delay it.
+// The high 32 bits are a reason code for rejections, and the delay in milliseconds for delays.
+package txscreener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	acceptedCounter  = metrics.NewRegisteredCounter("arb/sequencer/screener/accepted", nil)
+	rejectedCounter  = metrics.NewRegisteredCounter("arb/sequencer/screener/rejected", nil)
+	delayedCounter   = metrics.NewRegisteredCounter("arb/sequencer/screener/delayed", nil)
+	failedCounter    = metrics.NewRegisteredCounter("arb/sequencer/screener/failed", nil)
+	screenTimeTimer  = metrics.NewRegisteredHistogram("arb/sequencer/screener/time", nil, metrics.NewBoundedHistogramSample())
+	instanceRestarts = metrics.NewRegisteredCounter("arb/sequencer/screener/restarts", nil)
+)
+
+// The length of an encoded transaction before its calldata
+const encodedHeaderLength = 72
+
+var ErrRejected = errors.New("transaction rejected by the sequencer's screening policy")
+
+type Decision uint8
+
+const (
+	Accept Decision = 0
+	Reject Decision = 1
+	Delay  Decision = 2
+)
+
+type Result struct {
+	Decision Decision
+	// The policy's reason code for a rejection
+	Reason uint32
+	// How long to hold a delayed transaction before sequencing it
+	Delay time.Duration
+}
+
+type Config struct {
+	Enable         bool          `koanf:"enable"`
+	Module         string        `koanf:"module"`
+	Timeout        time.Duration `koanf:"timeout"`
+	MaxMemoryPages uint32        `koanf:"max-memory-pages"`
+	MaxDelay       time.Duration `koanf:"max-delay"`
+	Instances      int           `koanf:"instances"`
+	FailOpen       bool          `koanf:"fail-open"`
+}
+
+var DefaultConfig = Config{
+	Enable:         false,
+	Module:         "",
+	Timeout:        5 * time.Millisecond,
+	MaxMemoryPages: 256,
+	MaxDelay:       10 * time.Second,
+	Instances:      4,
+	FailOpen:       false,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "screen incoming transactions with a WebAssembly policy module")
+	f.String(prefix+".module", DefaultConfig.Module, "path to the WebAssembly policy module")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "maximum time the policy may take to screen a transaction")
+	f.Uint32(prefix+".max-memory-pages", DefaultConfig.MaxMemoryPages, "maximum number of 64KiB memory pages the policy may use")
+	f.Duration(prefix+".max-delay", DefaultConfig.MaxDelay, "maximum time the policy may delay a transaction by")
+	f.Int(prefix+".instances", DefaultConfig.Instances, "number of policy instances, which is the number of transactions that can be screened at once")
+	f.Bool(prefix+".fail-open", DefaultConfig.FailOpen, "accept transactions the policy fails to screen, instead of rejecting them")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Module == "" {
+		return errors.New("transaction screener enabled without a module")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("transaction screener timeout must be positive")
+	}
+	if c.MaxMemoryPages == 0 || c.MaxMemoryPages > 65536 {
+		return errors.New("transaction screener max-memory-pages must be between 1 and 65536")
+	}
+	if c.Instances < 1 {
+		return errors.New("transaction screener needs at least one instance")
+	}
+	return nil
+}
+
+// Screener runs transactions through a pool of sandboxed policy module instances.
+type Screener struct {
+	config    *Config
+	runtime   wazero.Runtime
+	compiled  wazero.CompiledModule
+	instances chan api.Module // nil entries are instances that need to be recreated
+}
+
+func New(ctx context.Context, config *Config) (*Screener, error) {
+	binary, err := os.ReadFile(config.Module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction screener module: %w", err)
+	}
+	return NewFromBinary(ctx, config, binary)
+}
+
+func NewFromBinary(ctx context.Context, config *Config, binary []byte) (*Screener, error) {
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(config.MaxMemoryPages)
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	compiled, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile transaction screener module: %w", err)
+	}
+	if err := checkModule(compiled); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	s := &Screener{
+		config:    config,
+		runtime:   runtime,
+		compiled:  compiled,
+		instances: make(chan api.Module, config.Instances),
+	}
+	for i := 0; i < config.Instances; i++ {
+		instance, err := s.instantiate(ctx)
+		if err != nil {
+			_ = runtime.Close(ctx)
+			return nil, err
+		}
+		s.instances <- instance
+	}
+	return s, nil
+}
+
+// checkModule makes sure the module is sandboxed and has the expected exports.
+func checkModule(compiled wazero.CompiledModule) error {
+	if len(compiled.ImportedFunctions()) > 0 || len(compiled.ImportedMemories()) > 0 {
+		return errors.New("transaction screener module must not have imports")
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		return errors.New("transaction screener module must export its memory")
+	}
+	exports := compiled.ExportedFunctions()
+	expected := map[string]struct {
+		params  []api.ValueType
+		results []api.ValueType
+	}{
+		"alloc":  {[]api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}},
+		"screen": {[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}},
+	}
+	for name, signature := range expected {
+		function, ok := exports[name]
+		if !ok {
+			return fmt.Errorf("transaction screener module doesn't export %v", name)
+		}
+		if !slices.Equal(function.ParamTypes(), signature.params) || !slices.Equal(function.ResultTypes(), signature.results) {
+			return fmt.Errorf("transaction screener module's %v function has the wrong signature", name)
+		}
+	}
+	return nil
+}
+
+func (s *Screener) instantiate(ctx context.Context) (api.Module, error) {
+	// Instances are anonymous so the same module can be instantiated many times
+	instance, err := s.runtime.InstantiateModule(ctx, s.compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate transaction screener module: %w", err)
+	}
+	return instance, nil
+}
+
+// Encode returns the bytes a transaction is passed to the policy as.
+func Encode(sender common.Address, tx *types.Transaction) []byte {
+	data := tx.Data()
+	encoded := make([]byte, encodedHeaderLength, encodedHeaderLength+len(data))
+	copy(encoded[0:20], sender.Bytes())
+	if to := tx.To(); to != nil {
+		copy(encoded[20:40], to.Bytes())
+	}
+	tx.Value().FillBytes(encoded[40:encodedHeaderLength])
+	return append(encoded, data...)
+}
+
+// Screen runs the policy on a transaction. If the policy fails or takes too long, the transaction
+// is rejected, or accepted if the screener is configured to fail open.
+func (s *Screener) Screen(ctx context.Context, sender common.Address, tx *types.Transaction) (Result, error) {
+	start := time.Now()
+	result, err := s.screen(ctx, Encode(sender, tx))
+	screenTimeTimer.Update(time.Since(start).Nanoseconds())
+	if err != nil {
+		failedCounter.Inc(1)
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		log.Warn("transaction screener failed", "tx", tx.Hash(), "err", err)
+		if s.config.FailOpen {
+			return Result{Decision: Accept}, nil
+		}
+		return Result{}, fmt.Errorf("%w: screening failed", ErrRejected)
+	}
+	switch result.Decision {
+	case Accept:
+		acceptedCounter.Inc(1)
+	case Reject:
+		rejectedCounter.Inc(1)
+	case Delay:
+		delayedCounter.Inc(1)
+		if result.Delay > s.config.MaxDelay {
+			result.Delay = s.config.MaxDelay
+		}
+	}
+	return result, nil
+}
+
+func (s *Screener) screen(ctx context.Context, input []byte) (Result, error) {
+	var instance api.Module
+	select {
+	case instance = <-s.instances:
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+	var err error
+	if instance == nil {
+		instanceRestarts.Inc(1)
+		instance, err = s.instantiate(context.Background())
+		if err != nil {
+			s.instances <- nil
+			return Result{}, err
+		}
+	}
+	callCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	result, err := call(callCtx, instance, input)
+	if err != nil {
+		// The instance may have been closed by the timeout or left in a bad state, so replace it
+		_ = instance.Close(context.Background())
+		s.instances <- nil
+		return Result{}, err
+	}
+	s.instances <- instance
+	return result, nil
+}
+
+func call(ctx context.Context, instance api.Module, input []byte) (Result, error) {
+	// #nosec G115
+	size := uint64(uint32(len(input)))
+	if size != uint64(len(input)) {
+		return Result{}, errors.New("transaction too large to screen")
+	}
+	ptrs, err := instance.ExportedFunction("alloc").Call(ctx, size)
+	if err != nil {
+		return Result{}, err
+	}
+	// #nosec G115
+	ptr := uint32(ptrs[0])
+	if !instance.Memory().Write(ptr, input) {
+		return Result{}, errors.New("transaction screener module's allocation is out of bounds")
+	}
+	results, err := instance.ExportedFunction("screen").Call(ctx, uint64(ptr), size)
+	if err != nil {
+		return Result{}, err
+	}
+	return decode(results[0])
+}
+
+func decode(value uint64) (Result, error) {
+	// #nosec G115
+	decision := Decision(value & 0xff)
+	// #nosec G115
+	arg := uint32(value >> 32)
+	switch decision {
+	case Accept:
+		return Result{Decision: Accept}, nil
+	case Reject:
+		return Result{Decision: Reject, Reason: arg}, nil
+	case Delay:
+		return Result{Decision: Delay, Delay: time.Duration(arg) * time.Millisecond}, nil
+	default:
+		return Result{}, fmt.Errorf("transaction screener module returned unknown decision %v", decision)
+	}
+}
+
+func (s *Screener) Close(ctx context.Context) error {
+	return s.runtime.Close(ctx)
+}
@@ -0,0 +1,135 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package txscreener
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// (module
+//
+//	(memory (export "memory") 1)
+//	(func (export "alloc") (param i32) (result i32) (i32.const 1024))
+//	(func (export "screen") (param $ptr i32) (param $len i32) (result i64)
+//	  ;; accept transactions without calldata, otherwise use the first byte of calldata as the decision
+//	  (if (result i64) (i32.le_u (local.get $len) (i32.const 72))
+//	    (then (i64.const 0))
+//	    (else (i64.or
+//	      (i64.extend_i32_u (i32.load8_u offset=72 (local.get $ptr)))
+//	      (i64.const 0x700000000))))))
+var testPolicyModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f,
+	0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, 0x03, 0x03, 0x02, 0x00, 0x01, 0x05, 0x03, 0x01, 0x00, 0x01,
+	0x07, 0x1b, 0x03, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00, 0x05, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x00, 0x00, 0x06, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x00, 0x01, 0x0a, 0x24, 0x02,
+	0x05, 0x00, 0x41, 0x80, 0x08, 0x0b, 0x1c, 0x00, 0x20, 0x01, 0x41, 0xc8, 0x00, 0x4d, 0x04, 0x7e,
+	0x42, 0x00, 0x05, 0x20, 0x00, 0x2d, 0x00, 0x48, 0xad, 0x42, 0x80, 0x80, 0x80, 0x80, 0xf0, 0x00,
+	0x84, 0x0b, 0x0b,
+}
+
+// The same module, except screen never returns:
+//
+//	(func (export "screen") (param i32 i32) (result i64) (loop (br 0)) (i64.const 0))
+var testLoopingModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f,
+	0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, 0x03, 0x03, 0x02, 0x00, 0x01, 0x05, 0x03, 0x01, 0x00, 0x01,
+	0x07, 0x1b, 0x03, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00, 0x05, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x00, 0x00, 0x06, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x00, 0x01, 0x0a, 0x11, 0x02,
+	0x05, 0x00, 0x41, 0x80, 0x08, 0x0b, 0x09, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b,
+}
+
+func testConfig() *Config {
+	config := DefaultConfig
+	config.Enable = true
+	config.Module = "test"
+	config.Instances = 2
+	config.Timeout = 100 * time.Millisecond
+	return &config
+}
+
+func testTx(data []byte) *types.Transaction {
+	to := common.HexToAddress("0x1234")
+	return types.NewTx(&types.LegacyTx{To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1), Data: data})
+}
+
+func TestScreener(t *testing.T) {
+	ctx := context.Background()
+	config := testConfig()
+	config.MaxDelay = 5 * time.Millisecond
+	screener, err := NewFromBinary(ctx, config, testPolicyModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer screener.Close(ctx)
+	sender := common.HexToAddress("0xabcd")
+
+	result, err := screener.Screen(ctx, sender, testTx(nil))
+	if err != nil || result.Decision != Accept {
+		t.Fatal("transaction without calldata wasn't accepted", result, err)
+	}
+	result, err = screener.Screen(ctx, sender, testTx([]byte{1}))
+	if err != nil || result.Decision != Reject || result.Reason != 7 {
+		t.Fatal("transaction wasn't rejected with the policy's reason", result, err)
+	}
+	result, err = screener.Screen(ctx, sender, testTx([]byte{2}))
+	if err != nil || result.Decision != Delay || result.Delay != config.MaxDelay {
+		t.Fatal("transaction delay wasn't capped", result, err)
+	}
+	_, err = screener.Screen(ctx, sender, testTx([]byte{3}))
+	if !errors.Is(err, ErrRejected) {
+		t.Fatal("unknown decision didn't reject the transaction", err)
+	}
+	// Failed instances are replaced
+	for i := 0; i < 2*config.Instances; i++ {
+		result, err = screener.Screen(ctx, sender, testTx(nil))
+		if err != nil || result.Decision != Accept {
+			t.Fatal("screener didn't recover from a failure", result, err)
+		}
+	}
+
+	config.FailOpen = true
+	result, err = screener.Screen(ctx, sender, testTx([]byte{3}))
+	if err != nil || result.Decision != Accept {
+		t.Fatal("screener configured to fail open didn't accept the transaction", result, err)
+	}
+}
+
+func TestScreenerTimeout(t *testing.T) {
+	ctx := context.Background()
+	config := testConfig()
+	screener, err := NewFromBinary(ctx, config, testLoopingModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer screener.Close(ctx)
+
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		_, err = screener.Screen(ctx, common.Address{}, testTx(nil))
+		if !errors.Is(err, ErrRejected) {
+			t.Fatal("looping policy didn't reject the transaction", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*config.Timeout {
+			t.Fatal("screening took", elapsed, "with a timeout of", config.Timeout)
+		}
+	}
+}
+
+func TestScreenerRejectsImports(t *testing.T) {
+	// (module (import "env" "f" (func)))
+	module := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x60, 0x00, 0x00, 0x02, 0x09,
+		0x01, 0x03, 0x65, 0x6e, 0x76, 0x01, 0x66, 0x00, 0x00,
+	}
+	if _, err := NewFromBinary(context.Background(), testConfig(), module); err == nil {
+		t.Fatal("module with imports was accepted")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/gethhook/hooks"
 	"github.com/offchainlabs/nitro/precompiles"
 )
 
@@ -45,20 +46,16 @@ func (p ArbosPrecompileWrapper) RunAdvanced(
 }
 
 func init() {
-	core.ReadyEVMForL2 = func(evm *vm.EVM, msg *core.Message) {
-		if evm.ChainConfig().IsArbitrum() {
-			evm.ProcessingHook = arbos.NewTxProcessor(evm, msg)
-		}
-	}
+	hooks.SetTxProcessor(func(evm *vm.EVM, msg *core.Message) vm.TxProcessingHook {
+		return arbos.NewTxProcessor(evm, msg)
+	})
 
-	for k, v := range vm.PrecompiledContractsBerlin {
-		vm.PrecompiledAddressesArbitrum = append(vm.PrecompiledAddressesArbitrum, k)
-		vm.PrecompiledContractsArbitrum[k] = v
+	for k, v := range hooks.BerlinPrecompiles() {
+		hooks.AddPrecompile(hooks.PrecompilesArbitrum, k, v)
 	}
 
-	for k, v := range vm.PrecompiledContractsCancun {
-		vm.PrecompiledAddressesArbOS30 = append(vm.PrecompiledAddressesArbOS30, k)
-		vm.PrecompiledContractsArbOS30[k] = v
+	for k, v := range hooks.CancunPrecompiles() {
+		hooks.AddPrecompile(hooks.PrecompilesArbOS30, k, v)
 	}
 
 	precompileErrors := make(map[[4]byte]abi.Error)
@@ -67,41 +64,39 @@ func init() {
 			precompileErrors[[4]byte(errABI.ID.Bytes())] = errABI
 		}
 		var wrapped vm.AdvancedPrecompile = ArbosPrecompileWrapper{precompile}
-		vm.PrecompiledContractsArbOS30[addr] = wrapped
-		vm.PrecompiledAddressesArbOS30 = append(vm.PrecompiledAddressesArbOS30, addr)
+		hooks.AddPrecompile(hooks.PrecompilesArbOS30, addr, wrapped)
 
 		if precompile.Precompile().ArbosVersion() < params.ArbosVersion_Stylus {
-			vm.PrecompiledContractsArbitrum[addr] = wrapped
-			vm.PrecompiledAddressesArbitrum = append(vm.PrecompiledAddressesArbitrum, addr)
+			hooks.AddPrecompile(hooks.PrecompilesArbitrum, addr, wrapped)
 		}
 	}
 
-	for addr, precompile := range vm.PrecompiledContractsArbitrum {
-		vm.PrecompiledContractsArbOS30[addr] = precompile
-		vm.PrecompiledAddressesArbOS30 = append(vm.PrecompiledAddressesArbOS30, addr)
+	for addr, precompile := range hooks.Precompiles(hooks.PrecompilesArbitrum) {
+		hooks.AddPrecompile(hooks.PrecompilesArbOS30, addr, precompile)
 	}
-	for addr, precompile := range vm.PrecompiledContractsP256Verify {
-		vm.PrecompiledContractsArbOS30[addr] = precompile
-		vm.PrecompiledAddressesArbOS30 = append(vm.PrecompiledAddressesArbOS30, addr)
+	for addr, precompile := range hooks.P256VerifyPrecompiles() {
+		hooks.AddPrecompile(hooks.PrecompilesArbOS30, addr, precompile)
 	}
 
-	core.RenderRPCError = func(data []byte) error {
-		if len(data) < 4 {
-			return nil
-		}
-		var id [4]byte
-		copy(id[:], data[:4])
-		errABI, found := precompileErrors[id]
-		if !found {
-			return nil
-		}
-		rendered, err := precompiles.RenderSolError(errABI, data)
-		if err != nil {
-			log.Warn("failed to render rpc error", "err", err)
-			return nil
-		}
-		return errors.New(rendered)
-	}
+	hooks.SetRPCHooks(hooks.RPCHooks{
+		RenderError: func(data []byte) error {
+			if len(data) < 4 {
+				return nil
+			}
+			var id [4]byte
+			copy(id[:], data[:4])
+			errABI, found := precompileErrors[id]
+			if !found {
+				return nil
+			}
+			rendered, err := precompiles.RenderSolError(errABI, data)
+			if err != nil {
+				log.Warn("failed to render rpc error", "err", err)
+				return nil
+			}
+			return errors.New(rendered)
+		},
+	})
 }
 
 // RequireHookedGeth does nothing, but forces an import to let the init function run
@@ -0,0 +1,191 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package hooks is the only place nitro installs its hooks into go-ethereum. Everything else registers
+// through the narrower interface here, so a geth upgrade that moves or reshapes a hook point only needs
+// this package updated. It imports nothing from nitro so that any package can register hooks.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Version is the version of the hook interface. Bump it whenever a geth upgrade changes the signature or
+// semantics of a hook below, so that registrants written against the old interface fail loudly.
+const Version = 1
+
+// TxProcessorFactory makes the ArbOS hook that processes a message in an Arbitrum chain's EVM.
+type TxProcessorFactory func(evm *vm.EVM, msg *core.Message) vm.TxProcessingHook
+
+// RPCHooks customize how geth's RPC server simulates messages.
+type RPCHooks struct {
+	// InterceptMessage may answer or replace a message before geth executes it, as NodeInterface does.
+	InterceptMessage func(
+		msg *core.Message,
+		ctx context.Context,
+		statedb *state.StateDB,
+		header *types.Header,
+		backend core.NodeInterfaceBackendAPI,
+		blockCtx *vm.BlockContext,
+	) (*core.Message, *core.ExecutionResult, error)
+	// PostingGas is the gas a message needs on top of execution to pay for its parent chain data.
+	PostingGas func(msg *core.Message, header *types.Header, statedb *state.StateDB) (uint64, error)
+	// SpeedLimitPerSecond is ArbOS's gas speed limit, which bounds gas estimation.
+	SpeedLimitPerSecond func(statedb *state.StateDB) (uint64, error)
+	// RenderError turns revert data into a readable error, or returns nil if it doesn't recognize it.
+	RenderError func(data []byte) error
+}
+
+// WasmCacheHooks manage the native cache of compiled stylus programs.
+type WasmCacheHooks struct {
+	Cache func(asm []byte, moduleHash common.Hash, version uint16, tag uint32, debug bool)
+	Evict func(moduleHash common.Hash, version uint16, tag uint32, debug bool)
+}
+
+// PrecompileSet identifies one of the precompile sets geth picks from by ArbOS version.
+type PrecompileSet int
+
+const (
+	PrecompilesArbitrum PrecompileSet = iota // ArbOS versions before stylus
+	PrecompilesArbOS30                       // stylus and later
+)
+
+var (
+	mutex     sync.Mutex
+	installed = map[string]bool{}
+)
+
+func markInstalled(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	installed[name] = true
+}
+
+// SetTxProcessor installs the factory geth uses to process messages in Arbitrum chains.
+func SetTxProcessor(factory TxProcessorFactory) {
+	core.ReadyEVMForL2 = func(evm *vm.EVM, msg *core.Message) {
+		if evm.ChainConfig().IsArbitrum() {
+			evm.ProcessingHook = factory(evm, msg)
+		}
+	}
+	markInstalled("tx-processor")
+}
+
+// ReadyEVM installs the ArbOS tx processor in an EVM geth didn't make ready itself.
+func ReadyEVM(evm *vm.EVM, msg *core.Message) {
+	core.ReadyEVMForL2(evm, msg)
+}
+
+// SetRPCHooks installs the hooks set in rpcHooks, leaving the others as they were.
+func SetRPCHooks(rpcHooks RPCHooks) {
+	if rpcHooks.InterceptMessage != nil {
+		core.InterceptRPCMessage = rpcHooks.InterceptMessage
+		markInstalled("rpc-intercept-message")
+	}
+	if rpcHooks.PostingGas != nil {
+		core.RPCPostingGasHook = rpcHooks.PostingGas
+		markInstalled("rpc-posting-gas")
+	}
+	if rpcHooks.SpeedLimitPerSecond != nil {
+		core.GetArbOSSpeedLimitPerSecond = rpcHooks.SpeedLimitPerSecond
+		markInstalled("rpc-speed-limit")
+	}
+	if rpcHooks.RenderError != nil {
+		core.RenderRPCError = rpcHooks.RenderError
+		markInstalled("rpc-render-error")
+	}
+}
+
+// SetWasmCacheHooks installs the native stylus cache.
+func SetWasmCacheHooks(cacheHooks WasmCacheHooks) {
+	state.CacheWasmRust = cacheHooks.Cache
+	state.EvictWasmRust = cacheHooks.Evict
+	markInstalled("wasm-cache")
+}
+
+// CacheWasm and EvictWasm call the installed stylus cache hooks.
+func CacheWasm(asm []byte, moduleHash common.Hash, version uint16, tag uint32, debug bool) {
+	state.CacheWasmRust(asm, moduleHash, version, tag, debug)
+}
+
+func EvictWasm(moduleHash common.Hash, version uint16, tag uint32, debug bool) {
+	state.EvictWasmRust(moduleHash, version, tag, debug)
+}
+
+// AddPrecompile makes contract callable at addr in the given set.
+func AddPrecompile(set PrecompileSet, addr common.Address, contract vm.PrecompiledContract) {
+	switch set {
+	case PrecompilesArbitrum:
+		vm.PrecompiledContractsArbitrum[addr] = contract
+		vm.PrecompiledAddressesArbitrum = append(vm.PrecompiledAddressesArbitrum, addr)
+	case PrecompilesArbOS30:
+		vm.PrecompiledContractsArbOS30[addr] = contract
+		vm.PrecompiledAddressesArbOS30 = append(vm.PrecompiledAddressesArbOS30, addr)
+	default:
+		panic(fmt.Sprintf("unknown precompile set %v", set))
+	}
+	markInstalled("precompiles")
+}
+
+// Precompiles returns a copy of the contracts in the given set.
+func Precompiles(set PrecompileSet) map[common.Address]vm.PrecompiledContract {
+	var contracts map[common.Address]vm.PrecompiledContract
+	switch set {
+	case PrecompilesArbitrum:
+		contracts = vm.PrecompiledContractsArbitrum
+	case PrecompilesArbOS30:
+		contracts = vm.PrecompiledContractsArbOS30
+	default:
+		panic(fmt.Sprintf("unknown precompile set %v", set))
+	}
+	copied := make(map[common.Address]vm.PrecompiledContract, len(contracts))
+	for addr, contract := range contracts {
+		copied[addr] = contract
+	}
+	return copied
+}
+
+// Ethereum's precompiles that Arbitrum chains inherit, by the upstream fork that introduced them.
+func BerlinPrecompiles() map[common.Address]vm.PrecompiledContract {
+	return vm.PrecompiledContractsBerlin
+}
+
+func CancunPrecompiles() map[common.Address]vm.PrecompiledContract {
+	return vm.PrecompiledContractsCancun
+}
+
+func P256VerifyPrecompiles() map[common.Address]vm.PrecompiledContract {
+	return vm.PrecompiledContractsP256Verify
+}
+
+// Required lists the hooks a node can't run correctly without.
+var Required = []string{
+	"tx-processor",
+	"precompiles",
+	"rpc-intercept-message",
+	"rpc-posting-gas",
+	"rpc-speed-limit",
+	"rpc-render-error",
+}
+
+// CheckInstalled returns an error naming any required hook that nothing has installed.
+func CheckInstalled() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	var missing []error
+	for _, name := range Required {
+		if !installed[name] {
+			missing = append(missing, fmt.Errorf("geth hook %v (interface version %v) isn't installed", name, Version))
+		}
+	}
+	return errors.Join(missing...)
+}
@@ -0,0 +1,49 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package hooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestCheckInstalled(t *testing.T) {
+	err := CheckInstalled()
+	if err == nil || !strings.Contains(err.Error(), "tx-processor") || !strings.Contains(err.Error(), "rpc-posting-gas") {
+		t.Fatal("expected every hook to be missing, got", err)
+	}
+
+	SetTxProcessor(func(evm *vm.EVM, msg *core.Message) vm.TxProcessingHook { return nil })
+	addr := common.HexToAddress("0x0100")
+	AddPrecompile(PrecompilesArbOS30, addr, nil)
+	if _, ok := Precompiles(PrecompilesArbOS30)[addr]; !ok {
+		t.Fatal("added precompile missing")
+	}
+	if _, ok := Precompiles(PrecompilesArbitrum)[addr]; ok {
+		t.Fatal("precompile added to the wrong set")
+	}
+	SetRPCHooks(RPCHooks{RenderError: func([]byte) error { return nil }})
+	err = CheckInstalled()
+	if err == nil || strings.Contains(err.Error(), "tx-processor") || !strings.Contains(err.Error(), "rpc-intercept-message") {
+		t.Fatal("unexpected missing hooks", err)
+	}
+
+	SetRPCHooks(RPCHooks{
+		InterceptMessage: func(*core.Message, context.Context, *state.StateDB, *types.Header, core.NodeInterfaceBackendAPI, *vm.BlockContext) (*core.Message, *core.ExecutionResult, error) {
+			return nil, nil, nil
+		},
+		PostingGas:          func(*core.Message, *types.Header, *state.StateDB) (uint64, error) { return 0, nil },
+		SpeedLimitPerSecond: func(*state.StateDB) (uint64, error) { return 0, nil },
+	})
+	if err := CheckInstalled(); err != nil {
+		t.Fatal(err)
+	}
+}
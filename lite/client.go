@@ -0,0 +1,213 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package lite verifies an Arbitrum chain's progress without running a node. It checks sequencer feed
+// signatures, the batches delivered to the sequencer inbox on the parent chain, and the assertions the
+// rollup has confirmed, and reports the chain's head at each of these levels of trust.
+//
+// It only depends on the parent chain's RPC, and optionally on an untrusted RPC of the chain itself to
+// look up block numbers by their verified hashes, so it can be embedded in other Go services.
+package lite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/util/contracts"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+// Trust is how a head was verified, from least to most trusted.
+type Trust uint8
+
+const (
+	// Signed by the sequencer or a configured feed signer, which the sequencer could still equivocate on
+	TrustSequencerSigned Trust = iota
+	// Delivered to the sequencer inbox as of the parent chain's latest block
+	TrustPosted
+	// Delivered to the sequencer inbox as of the parent chain's finalized block
+	TrustParentFinalized
+	// Executed by an assertion the rollup has confirmed
+	TrustConfirmed
+)
+
+func (t Trust) String() string {
+	switch t {
+	case TrustSequencerSigned:
+		return "sequencer-signed"
+	case TrustPosted:
+		return "posted"
+	case TrustParentFinalized:
+		return "parent-finalized"
+	case TrustConfirmed:
+		return "confirmed"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+func (t Trust) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Head is the furthest the chain has been verified at one level of trust.
+type Head struct {
+	Trust Trust `json:"trust"`
+	// The head block, when it's known at this level. Sequencer signed heads don't have a block hash,
+	// since feed signatures don't cover it.
+	BlockNumber *uint64      `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+	// The number of batches the head covers, unset for sequencer signed heads
+	BatchCount *uint64 `json:"batchCount,omitempty"`
+	// The parent chain block the head was read at, unset for sequencer signed heads
+	ParentChainBlock *uint64   `json:"parentChainBlock,omitempty"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type Config struct {
+	PollInterval time.Duration `koanf:"poll-interval"`
+	// Accepted feed signers besides the sequencer and batch posters the sequencer inbox lists
+	FeedSigners []string `koanf:"feed-signers"`
+}
+
+var DefaultConfig = Config{
+	PollInterval: 15 * time.Second,
+	FeedSigners:  []string{},
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".poll-interval", DefaultConfig.PollInterval, "how often to read the sequencer inbox and rollup on the parent chain")
+	f.StringSlice(prefix+".feed-signers", DefaultConfig.FeedSigners, "addresses accepted as feed signers besides the sequencer and batch posters")
+}
+
+func (c *Config) Validate() error {
+	if c.PollInterval <= 0 {
+		return errors.New("lite client poll-interval must be positive")
+	}
+	for _, signer := range c.FeedSigners {
+		if !common.IsHexAddress(signer) {
+			return fmt.Errorf("invalid feed signer address %v", signer)
+		}
+	}
+	return nil
+}
+
+// ParentChainClient is the parent chain RPC the client verifies everything against.
+type ParentChainClient interface {
+	bind.ContractBackend
+	ethereum.TransactionReader
+}
+
+// Client follows a chain's heads. Sequencer signed messages are passed to it with AddBroadcastMessages,
+// which lets it take a broadcastclient's place of a transaction streamer; the parent chain is polled.
+type Client struct {
+	stopwaiter.StopWaiter
+	config          *Config
+	chainId         uint64
+	genesisBlockNum uint64
+	addresses       chaininfo.RollupAddresses
+	parentChain     ParentChainClient
+	chain           ethereum.ChainReader // nil if the client doesn't look up block numbers
+	seqInbox        *bridgegen.SequencerInbox
+	rollup          *rollupgen.RollupUserLogic
+	feed            *FeedVerifier
+
+	headsMutex sync.Mutex
+	heads      map[Trust]Head
+	confirmed  *ConfirmedAssertion
+}
+
+// NewClient creates a client for the chain. chain may be nil; it's only used to look up the numbers of
+// the blocks confirmed assertions end at, which are verified against their hashes.
+func NewClient(config *Config, info *chaininfo.ChainInfo, parentChain ParentChainClient, chain ethereum.ChainReader) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if info.ChainConfig == nil || info.RollupAddresses == nil {
+		return nil, errors.New("chain info is missing the chain config or rollup addresses")
+	}
+	seqInbox, err := bridgegen.NewSequencerInbox(info.RollupAddresses.SequencerInbox, parentChain)
+	if err != nil {
+		return nil, err
+	}
+	rollup, err := rollupgen.NewRollupUserLogic(info.RollupAddresses.Rollup, parentChain)
+	if err != nil {
+		return nil, err
+	}
+	chainId := info.ChainConfig.ChainID.Uint64()
+	feed, err := NewFeedVerifier(chainId, config.FeedSigners, contracts.NewAddressVerifier(&seqInbox.SequencerInboxCaller))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		config:          config,
+		chainId:         chainId,
+		genesisBlockNum: info.ChainConfig.ArbitrumChainParams.GenesisBlockNum,
+		addresses:       *info.RollupAddresses,
+		parentChain:     parentChain,
+		chain:           chain,
+		seqInbox:        seqInbox,
+		rollup:          rollup,
+		feed:            feed,
+		heads:           make(map[Trust]Head),
+	}, nil
+}
+
+func (c *Client) Start(ctxIn context.Context) {
+	c.StopWaiter.Start(ctxIn, c)
+	c.CallIteratively(c.poll)
+}
+
+func (c *Client) poll(ctx context.Context) time.Duration {
+	if err := c.UpdateParentChainHeads(ctx); err != nil {
+		log.Warn("lite client failed to read the parent chain", "err", err)
+	}
+	return c.config.PollInterval
+}
+
+// Heads returns the known heads, from least to most trusted.
+func (c *Client) Heads() []Head {
+	c.headsMutex.Lock()
+	defer c.headsMutex.Unlock()
+	heads := make([]Head, 0, len(c.heads))
+	for trust := TrustSequencerSigned; trust <= TrustConfirmed; trust++ {
+		if head, ok := c.heads[trust]; ok {
+			heads = append(heads, head)
+		}
+	}
+	return heads
+}
+
+// Head returns the head at a level of trust, if it's known.
+func (c *Client) Head(trust Trust) (Head, bool) {
+	c.headsMutex.Lock()
+	defer c.headsMutex.Unlock()
+	head, ok := c.heads[trust]
+	return head, ok
+}
+
+// LatestConfirmed returns the latest confirmed assertion, if it's been read.
+func (c *Client) LatestConfirmed() *ConfirmedAssertion {
+	c.headsMutex.Lock()
+	defer c.headsMutex.Unlock()
+	return c.confirmed
+}
+
+func (c *Client) setHead(head Head) {
+	c.headsMutex.Lock()
+	defer c.headsMutex.Unlock()
+	c.heads[head.Trust] = head
+}
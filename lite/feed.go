@@ -0,0 +1,83 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package lite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/contracts"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+// FeedVerifier checks sequencer feed messages are signed by the sequencer, a batch poster, or an
+// allowed signer. Unlike a node's feed verification, it never accepts unsigned messages, and doesn't
+// accept the signers the chain owner publishes since that would mean trusting the chain's state.
+type FeedVerifier struct {
+	chainId  uint64
+	verifier *signature.Verifier
+}
+
+func NewFeedVerifier(chainId uint64, allowedSigners []string, addrVerifier contracts.AddressVerifierInterface) (*FeedVerifier, error) {
+	verifier, err := signature.NewVerifier(&signature.VerifierConfig{
+		AllowedAddresses: allowedSigners,
+		AcceptSequencer:  addrVerifier != nil,
+	}, addrVerifier, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &FeedVerifier{chainId: chainId, verifier: verifier}, nil
+}
+
+func (v *FeedVerifier) Verify(ctx context.Context, message *m.BroadcastFeedMessage) error {
+	hash, err := message.Hash(v.chainId)
+	if err != nil {
+		return fmt.Errorf("error getting message hash for sequence number %v: %w", message.SequenceNumber, err)
+	}
+	if err := v.verifier.VerifyHash(ctx, message.Signature, hash); err != nil {
+		return fmt.Errorf("message %v: %w", message.SequenceNumber, err)
+	}
+	return nil
+}
+
+// AddBroadcastMessages verifies feed messages and advances the sequencer signed head past them.
+// Messages after the first one that fails verification are ignored.
+func (c *Client) AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error {
+	ctx, err := c.GetContextSafe()
+	if err != nil {
+		return err
+	}
+	var count arbutil.MessageIndex
+	for _, message := range feedMessages {
+		if err := c.feed.Verify(ctx, message); err != nil {
+			c.advanceSequencerSigned(count)
+			return err
+		}
+		count = message.SequenceNumber + 1
+	}
+	c.advanceSequencerSigned(count)
+	return nil
+}
+
+func (c *Client) advanceSequencerSigned(count arbutil.MessageIndex) {
+	if count == 0 {
+		return
+	}
+	c.headsMutex.Lock()
+	defer c.headsMutex.Unlock()
+	head, ok := c.heads[TrustSequencerSigned]
+	// #nosec G115
+	blockNumber := uint64(arbutil.MessageCountToBlockNumber(count, c.genesisBlockNum))
+	if ok && head.BlockNumber != nil && *head.BlockNumber >= blockNumber {
+		return
+	}
+	c.heads[TrustSequencerSigned] = Head{
+		Trust:       TrustSequencerSigned,
+		BlockNumber: &blockNumber,
+		UpdatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package lite
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/contracts"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+const testChainId = 412346
+
+func signedMessages(t *testing.T, seqNums []arbutil.MessageIndex, sign func(message *m.BroadcastFeedMessage) []byte) []*m.BroadcastFeedMessage {
+	t.Helper()
+	messages := m.CreateDummyBroadcastMessages(seqNums)
+	for _, message := range messages {
+		message.Signature = sign(message)
+	}
+	return messages
+}
+
+func TestFeedVerification(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sequencerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signWith := func(key *ecdsa.PrivateKey) func(*m.BroadcastFeedMessage) []byte {
+		return func(message *m.BroadcastFeedMessage) []byte {
+			hash, err := message.Hash(testChainId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := crypto.Sign(hash.Bytes(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sig
+		}
+	}
+	signer := crypto.PubkeyToAddress(signerKey.PublicKey)
+	verifier, err := NewFeedVerifier(testChainId, []string{signer.Hex()}, contracts.NewMockAddressVerifier(crypto.PubkeyToAddress(sequencerKey.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bySequencer := signedMessages(t, []arbutil.MessageIndex{0}, signWith(sequencerKey))[0]
+	if err := verifier.Verify(ctx, bySequencer); err != nil {
+		t.Fatal("message signed by the sequencer wasn't verified", err)
+	}
+	bySigner := signedMessages(t, []arbutil.MessageIndex{1}, signWith(signerKey))[0]
+	if err := verifier.Verify(ctx, bySigner); err != nil {
+		t.Fatal("message signed by an allowed signer wasn't verified", err)
+	}
+	byOther := signedMessages(t, []arbutil.MessageIndex{2}, signWith(otherKey))[0]
+	if err := verifier.Verify(ctx, byOther); !errors.Is(err, signature.ErrSignerNotApproved) {
+		t.Fatal("message signed by an unknown key was accepted", err)
+	}
+	unsigned := m.CreateDummyBroadcastMessages([]arbutil.MessageIndex{3})[0]
+	if err := verifier.Verify(ctx, unsigned); !errors.Is(err, signature.ErrMissingSignature) {
+		t.Fatal("unsigned message was accepted", err)
+	}
+	// The signature covers the sequence number
+	bySequencer.SequenceNumber = 4
+	if err := verifier.Verify(ctx, bySequencer); err == nil {
+		t.Fatal("message moved to another sequence number was accepted")
+	}
+
+	client := &Client{
+		feed:            verifier,
+		genesisBlockNum: 10,
+		heads:           make(map[Trust]Head),
+	}
+	client.StopWaiter.Start(ctx, client)
+	defer client.StopAndWait()
+
+	good := signedMessages(t, []arbutil.MessageIndex{0, 1, 2}, signWith(sequencerKey))
+	if err := client.AddBroadcastMessages(good); err != nil {
+		t.Fatal(err)
+	}
+	mixed := signedMessages(t, []arbutil.MessageIndex{3, 4}, signWith(sequencerKey))
+	mixed = append(mixed, signedMessages(t, []arbutil.MessageIndex{5}, signWith(otherKey))...)
+	if err := client.AddBroadcastMessages(mixed); err == nil {
+		t.Fatal("messages with a bad signature were accepted")
+	}
+	head, ok := client.Head(TrustSequencerSigned)
+	if !ok || head.BlockNumber == nil || *head.BlockNumber != 14 || head.BlockHash != nil {
+		t.Fatal("sequencer signed head should end at the last verified message", head)
+	}
+	// Messages resent by the feed don't move the head back
+	if err := client.AddBroadcastMessages(good[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if heads := client.Heads(); len(heads) != 1 || *heads[0].BlockNumber != 14 {
+		t.Fatal("unexpected heads", heads)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package lite
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var batchDeliveredID common.Hash
+var nodeCreatedID common.Hash
+
+func init() {
+	parsedSeqInbox, err := bridgegen.SequencerInboxMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	batchDeliveredID = parsedSeqInbox.Events["SequencerBatchDelivered"].ID
+	parsedRollup, err := rollupgen.RollupUserLogicMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	nodeCreatedID = parsedRollup.Events["NodeCreated"].ID
+}
+
+var ErrBatchNotDelivered = errors.New("batch not delivered to the sequencer inbox")
+
+// ConfirmedAssertion is the state the rollup's latest confirmed assertion ended at, checked against the
+// confirm data the rollup stores for it.
+type ConfirmedAssertion struct {
+	NodeNum     uint64                  `json:"nodeNum"`
+	NodeHash    common.Hash             `json:"nodeHash"`
+	GlobalState validator.GoGlobalState `json:"globalState"`
+	// Set if the client has a chain RPC to look the block up with
+	BlockNumber *uint64 `json:"blockNumber,omitempty"`
+	// The parent chain block the assertion was read at
+	ParentChainBlock uint64 `json:"parentChainBlock"`
+}
+
+// UpdateParentChainHeads reads the sequencer inbox's batch counts and the rollup's latest confirmed assertion.
+func (c *Client) UpdateParentChainHeads(ctx context.Context) error {
+	latest, err := c.parentChain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if err := c.updateBatchHead(ctx, TrustPosted, latest); err != nil {
+		errs = append(errs, err)
+	}
+	finalized, err := c.parentChain.HeaderByNumber(ctx, big.NewInt(rpc.FinalizedBlockNumber.Int64()))
+	if err == nil {
+		err = c.updateBatchHead(ctx, TrustParentFinalized, finalized)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading finalized batches: %w", err))
+	}
+	confirmed, err := c.readLatestConfirmed(ctx, latest.Number)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading latest confirmed assertion: %w", err))
+	} else {
+		batchCount := confirmed.GlobalState.Batch
+		parentChainBlock := confirmed.ParentChainBlock
+		c.headsMutex.Lock()
+		c.confirmed = confirmed
+		c.heads[TrustConfirmed] = Head{
+			Trust:            TrustConfirmed,
+			BlockNumber:      confirmed.BlockNumber,
+			BlockHash:        &confirmed.GlobalState.BlockHash,
+			BatchCount:       &batchCount,
+			ParentChainBlock: &parentChainBlock,
+			UpdatedAt:        time.Now(),
+		}
+		c.headsMutex.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) updateBatchHead(ctx context.Context, trust Trust, header *types.Header) error {
+	count, err := c.seqInbox.BatchCount(&bind.CallOpts{Context: ctx, BlockNumber: header.Number})
+	if err != nil {
+		return err
+	}
+	if !count.IsUint64() {
+		return errors.New("sequencer inbox returned non-uint64 batch count")
+	}
+	batchCount := count.Uint64()
+	parentChainBlock := header.Number.Uint64()
+	c.setHead(Head{
+		Trust:            trust,
+		BatchCount:       &batchCount,
+		ParentChainBlock: &parentChainBlock,
+		UpdatedAt:        time.Now(),
+	})
+	return nil
+}
+
+func (c *Client) readLatestConfirmed(ctx context.Context, blockNumber *big.Int) (*ConfirmedAssertion, error) {
+	callOpts := &bind.CallOpts{Context: ctx, BlockNumber: blockNumber}
+	nodeNum, err := c.rollup.LatestConfirmed(callOpts)
+	if err != nil {
+		return nil, err
+	}
+	node, err := c.rollup.GetNode(callOpts, nodeNum)
+	if err != nil {
+		return nil, err
+	}
+	createdAtBlock, err := c.rollup.GetNodeCreationBlockForLogLookup(callOpts, nodeNum)
+	if err != nil {
+		// Older rollups don't have the lookup, and their nodes record the parent chain's block number
+		createdAtBlock = new(big.Int).SetUint64(node.CreatedAtBlock)
+	}
+	var numberAsHash common.Hash
+	binary.BigEndian.PutUint64(numberAsHash[(32-8):], nodeNum)
+	logs, err := c.parentChain.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: createdAtBlock,
+		ToBlock:   createdAtBlock,
+		Addresses: []common.Address{c.addresses.Rollup},
+		Topics:    [][]common.Hash{{nodeCreatedID}, {numberAsHash}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) != 1 {
+		return nil, fmt.Errorf("found %v creations of node %v", len(logs), nodeNum)
+	}
+	created, err := c.rollup.ParseNodeCreated(logs[0])
+	if err != nil {
+		return nil, err
+	}
+	if created.NodeHash != node.NodeHash {
+		return nil, fmt.Errorf("node %v was created with hash %v but the rollup has %v", nodeNum, common.Hash(created.NodeHash), common.Hash(node.NodeHash))
+	}
+	globalState := validator.NewExecutionStateFromSolidity(created.Assertion.AfterState).GlobalState
+	if confirmData := crypto.Keccak256Hash(globalState.BlockHash[:], globalState.SendRoot[:]); confirmData != node.ConfirmData {
+		return nil, fmt.Errorf("node %v ended at block %v and send root %v, which don't match its confirm data", nodeNum, globalState.BlockHash, globalState.SendRoot)
+	}
+	confirmed := &ConfirmedAssertion{
+		NodeNum:          nodeNum,
+		NodeHash:         node.NodeHash,
+		GlobalState:      globalState,
+		ParentChainBlock: blockNumber.Uint64(),
+	}
+	if c.chain != nil {
+		blockNumber, err := c.blockNumberByHash(ctx, globalState.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		confirmed.BlockNumber = &blockNumber
+	}
+	return confirmed, nil
+}
+
+// blockNumberByHash looks a block up with the untrusted chain RPC, trusting its number only if the
+// header it returns hashes to the requested hash.
+func (c *Client) blockNumberByHash(ctx context.Context, hash common.Hash) (uint64, error) {
+	header, err := c.chain.HeaderByHash(ctx, hash)
+	if err != nil {
+		return 0, err
+	}
+	if header.Hash() != hash {
+		return 0, fmt.Errorf("chain RPC returned a header hashing to %v for block %v", header.Hash(), hash)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// VerifyBatchTransaction checks a parent chain transaction delivered a batch to the sequencer inbox, and
+// that the batch is still in the inbox, returning how far it's been verified. A node reports the parent
+// chain transaction of the batch it posted a transaction in with arb_transactionBatchInfo.
+func (c *Client) VerifyBatchTransaction(ctx context.Context, batch uint64, parentChainTx common.Hash) (Trust, error) {
+	receipt, err := c.parentChain.TransactionReceipt(ctx, parentChainTx)
+	if err != nil {
+		return 0, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return 0, fmt.Errorf("%w: parent chain transaction %v failed", ErrBatchNotDelivered, parentChainTx)
+	}
+	var delivered *bridgegen.SequencerInboxSequencerBatchDelivered
+	for _, log := range receipt.Logs {
+		if log.Address != c.addresses.SequencerInbox || len(log.Topics) == 0 || log.Topics[0] != batchDeliveredID {
+			continue
+		}
+		parsed, err := c.seqInbox.ParseSequencerBatchDelivered(*log)
+		if err != nil {
+			return 0, err
+		}
+		if parsed.BatchSequenceNumber.IsUint64() && parsed.BatchSequenceNumber.Uint64() == batch {
+			delivered = parsed
+			break
+		}
+	}
+	if delivered == nil {
+		return 0, fmt.Errorf("%w: parent chain transaction %v didn't deliver batch %v", ErrBatchNotDelivered, parentChainTx, batch)
+	}
+
+	// The transaction's block may have been reorged out, so check the inbox still has the batch
+	acc, err := c.seqInbox.InboxAccs(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(batch))
+	if err != nil {
+		return 0, err
+	}
+	if acc != delivered.AfterAcc {
+		return 0, fmt.Errorf("%w: batch %v in the sequencer inbox isn't the one transaction %v delivered", ErrBatchNotDelivered, batch, parentChainTx)
+	}
+	if confirmed := c.LatestConfirmed(); confirmed != nil && batch < confirmed.GlobalState.Batch {
+		return TrustConfirmed, nil
+	}
+	if head, ok := c.Head(TrustParentFinalized); ok && batch < *head.BatchCount {
+		return TrustParentFinalized, nil
+	}
+	return TrustPosted, nil
+}
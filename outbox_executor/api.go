@@ -0,0 +1,42 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package outboxexecutor
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type API struct {
+	executor *OutboxExecutor
+}
+
+func NewAPI(executor *OutboxExecutor) *API {
+	return &API{executor: executor}
+}
+
+// OutboxExecutions returns the confirmed L2 to L1 messages matching the executor's filters
+// which haven't been executed yet, along with their outbox proofs.
+func (a *API) OutboxExecutions() []*Execution {
+	return a.executor.Executions()
+}
+
+type TwoHopAPI struct {
+	relay *TwoHopRelay
+}
+
+func NewTwoHopAPI(relay *TwoHopRelay) *TwoHopAPI {
+	return &TwoHopAPI{relay: relay}
+}
+
+// TrackTwoHopWithdrawal starts tracking the withdrawals an L3 transaction sent through the L2 to the L1.
+func (a *TwoHopAPI) TrackTwoHopWithdrawal(ctx context.Context, l3Tx common.Hash) ([]*TwoHopWithdrawal, error) {
+	return a.relay.Track(ctx, l3Tx)
+}
+
+// TwoHopWithdrawals returns the tracked withdrawals, with the proofs of the legs that are ready to execute.
+func (a *TwoHopAPI) TwoHopWithdrawals() []*TwoHopWithdrawal {
+	return a.relay.Withdrawals()
+}
@@ -0,0 +1,470 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package outboxexecutor watches confirmed assertions for L2 to L1 messages matching configured
+// filters, builds their outbox proofs, and optionally executes them on the parent chain.
+package outboxexecutor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/staker"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	pendingGauge      = metrics.NewRegisteredGauge("arb/outboxexecutor/pending", nil)
+	executedCounter   = metrics.NewRegisteredCounter("arb/outboxexecutor/executed", nil)
+	executeErrCounter = metrics.NewRegisteredCounter("arb/outboxexecutor/execute/errors", nil)
+)
+
+type Config struct {
+	Enable            bool                     `koanf:"enable"`
+	Execute           bool                     `koanf:"execute"`
+	Destinations      []string                 `koanf:"destinations"`
+	Callers           []string                 `koanf:"callers"`
+	FromBlock         uint64                   `koanf:"from-block"`
+	PollInterval      time.Duration            `koanf:"poll-interval"`
+	LogQueryRange     uint64                   `koanf:"log-query-range"`
+	ParentChainWallet genericconf.WalletConfig `koanf:"parent-chain-wallet"`
+}
+
+var DefaultParentChainWalletConfig = genericconf.WalletConfig{
+	Pathname:      "outbox-executor-wallet",
+	Password:      genericconf.WalletConfigDefault.Password,
+	PrivateKey:    genericconf.WalletConfigDefault.PrivateKey,
+	Account:       genericconf.WalletConfigDefault.Account,
+	OnlyCreateKey: genericconf.WalletConfigDefault.OnlyCreateKey,
+}
+
+var DefaultConfig = Config{
+	Enable:            false,
+	Execute:           false,
+	PollInterval:      time.Minute,
+	LogQueryRange:     10000,
+	ParentChainWallet: DefaultParentChainWalletConfig,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "enable watching confirmed assertions for L2 to L1 messages to execute, exposed over the arb_outboxExecutions RPC")
+	f.Bool(prefix+".execute", DefaultConfig.Execute, "automatically execute matching messages on the parent chain once they're confirmed")
+	f.StringSlice(prefix+".destinations", DefaultConfig.Destinations, "only handle messages sent to these parent chain addresses (defaults to any destination)")
+	f.StringSlice(prefix+".callers", DefaultConfig.Callers, "only handle messages sent by these L2 addresses (defaults to any caller)")
+	f.Uint64(prefix+".from-block", DefaultConfig.FromBlock, "first L2 block to look for messages in")
+	f.Duration(prefix+".poll-interval", DefaultConfig.PollInterval, "how often to check for newly confirmed assertions")
+	f.Uint64(prefix+".log-query-range", DefaultConfig.LogQueryRange, "maximum number of L2 blocks to look for messages in per query")
+	genericconf.WalletConfigAddOptions(prefix+".parent-chain-wallet", f, DefaultConfig.ParentChainWallet.Pathname)
+}
+
+func parseAddresses(name string, addresses []string) (map[common.Address]struct{}, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[common.Address]struct{}, len(addresses))
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid outbox-executor %v address %v", name, address)
+		}
+		parsed[common.HexToAddress(address)] = struct{}{}
+	}
+	return parsed, nil
+}
+
+func (c *Config) Validate() error {
+	if _, err := parseAddresses("destination", c.Destinations); err != nil {
+		return err
+	}
+	if _, err := parseAddresses("caller", c.Callers); err != nil {
+		return err
+	}
+	if !c.Enable {
+		return nil
+	}
+	if c.Execute && len(c.Destinations) == 0 && len(c.Callers) == 0 {
+		return errors.New("outbox-executor must filter on destinations or callers to execute messages automatically")
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("outbox-executor poll-interval must be positive")
+	}
+	if c.LogQueryRange == 0 {
+		return errors.New("outbox-executor log-query-range must be positive")
+	}
+	return nil
+}
+
+func (e *OutboxExecutor) matches(caller, destination common.Address) bool {
+	if e.callers != nil {
+		if _, ok := e.callers[caller]; !ok {
+			return false
+		}
+	}
+	if e.destinations != nil {
+		if _, ok := e.destinations[destination]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Execution is an L2 to L1 message along with what's needed to execute it in the outbox.
+type Execution struct {
+	Leaf        uint64         `json:"leaf"`
+	Caller      common.Address `json:"caller"`
+	Destination common.Address `json:"destination"`
+	L2Block     *hexutil.Big   `json:"l2Block"`
+	L1Block     *hexutil.Big   `json:"l1Block"`
+	Timestamp   *hexutil.Big   `json:"timestamp"`
+	Value       *hexutil.Big   `json:"value"`
+	Data        hexutil.Bytes  `json:"data"`
+	// The proof is against the send root of the latest confirmed assertion
+	Proof     []common.Hash `json:"proof"`
+	ProofSize uint64        `json:"proofSize"`
+	// Set once the executor has submitted the execution
+	ExecutionTx *common.Hash `json:"executionTx,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+type OutboxExecutor struct {
+	stopwaiter.StopWaiter
+	config        *Config
+	l1Client      *ethclient.Client
+	l2Client      *ethclient.Client
+	rollup        *staker.RollupWatcher
+	outbox        *bridgegen.Outbox
+	arbSys        *precompilesgen.ArbSysFilterer
+	nodeInterface *node_interfacegen.NodeInterface
+	transactOpts  *bind.TransactOpts
+	l2ToL1TxID    common.Hash
+	destinations  map[common.Address]struct{}
+	callers       map[common.Address]struct{}
+
+	mutex          sync.Mutex
+	confirmedNode  uint64
+	confirmedBlock uint64
+	sendCount      uint64
+	nextBlock      uint64
+	pending        map[uint64]*Execution
+}
+
+// New creates an outbox executor. transactOpts may be nil, in which case executions are only exposed over RPC.
+func New(config *Config, l1Client, l2Client *ethclient.Client, rollupAddress common.Address, transactOpts *bind.TransactOpts) (*OutboxExecutor, error) {
+	if config.Execute && transactOpts == nil {
+		return nil, errors.New("outbox executor can't execute messages without a parent chain wallet")
+	}
+	destinations, err := parseAddresses("destination", config.Destinations)
+	if err != nil {
+		return nil, err
+	}
+	callers, err := parseAddresses("caller", config.Callers)
+	if err != nil {
+		return nil, err
+	}
+	rollup, err := staker.NewRollupWatcher(rollupAddress, l1Client, bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	arbSys, err := precompilesgen.NewArbSysFilterer(types.ArbSysAddress, l2Client)
+	if err != nil {
+		return nil, err
+	}
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, l2Client)
+	if err != nil {
+		return nil, err
+	}
+	arbSysAbi, err := precompilesgen.ArbSysMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxExecutor{
+		config:        config,
+		l1Client:      l1Client,
+		l2Client:      l2Client,
+		rollup:        rollup,
+		arbSys:        arbSys,
+		nodeInterface: nodeInterface,
+		transactOpts:  transactOpts,
+		l2ToL1TxID:    arbSysAbi.Events["L2ToL1Tx"].ID,
+		destinations:  destinations,
+		callers:       callers,
+		nextBlock:     config.FromBlock,
+		pending:       make(map[uint64]*Execution),
+	}, nil
+}
+
+func (e *OutboxExecutor) Start(ctxIn context.Context) error {
+	outboxAddress, err := e.rollup.Outbox(&bind.CallOpts{Context: ctxIn})
+	if err != nil {
+		return fmt.Errorf("failed to get outbox address from rollup: %w", err)
+	}
+	e.outbox, err = bridgegen.NewOutbox(outboxAddress, e.l1Client)
+	if err != nil {
+		return err
+	}
+	e.StopWaiter.Start(ctxIn, e)
+	e.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := e.update(ctx); err != nil {
+			log.Warn("outbox executor failed to update", "err", err)
+		}
+		return e.config.PollInterval
+	})
+	return nil
+}
+
+func (e *OutboxExecutor) update(ctx context.Context) error {
+	callOpts := &bind.CallOpts{Context: ctx}
+	latestConfirmed, err := e.rollup.LatestConfirmed(callOpts)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	upToDate := e.confirmedBlock != 0 && e.confirmedNode == latestConfirmed
+	e.mutex.Unlock()
+	if !upToDate {
+		if err := e.updateConfirmed(ctx, latestConfirmed); err != nil {
+			return err
+		}
+	}
+	if err := e.scanMessages(ctx); err != nil {
+		return err
+	}
+	return e.processPending(ctx)
+}
+
+// confirmedSends returns the block and send count of a rollup's assertion, read from the rollup's chain.
+func confirmedSends(ctx context.Context, rollup *staker.RollupWatcher, client *ethclient.Client, nodeNum uint64) (uint64, uint64, error) {
+	node, err := rollup.LookupNode(ctx, nodeNum)
+	if err != nil {
+		return 0, 0, err
+	}
+	globalState := node.AfterState().GlobalState
+	header, err := client.HeaderByHash(ctx, globalState.BlockHash)
+	if err != nil {
+		return 0, 0, fmt.Errorf("confirmed block %v not available yet: %w", globalState.BlockHash, err)
+	}
+	info := types.DeserializeHeaderExtraInformation(header)
+	if info.SendRoot != globalState.SendRoot {
+		return 0, 0, fmt.Errorf("confirmed block %v has send root %v but the assertion has %v", globalState.BlockHash, info.SendRoot, globalState.SendRoot)
+	}
+	return header.Number.Uint64(), info.SendCount, nil
+}
+
+// constructProof builds the outbox proof of a leaf against the send root after sendCount messages.
+func constructProof(ctx context.Context, nodeInterface *node_interfacegen.NodeInterface, sendCount, leaf uint64) ([]common.Hash, error) {
+	proof, err := nodeInterface.ConstructOutboxProof(&bind.CallOpts{Context: ctx}, sendCount, leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct outbox proof for leaf %v: %w", leaf, err)
+	}
+	hashes := make([]common.Hash, 0, len(proof.Proof))
+	for _, hash := range proof.Proof {
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// executionFromEvent returns the message an L2ToL1Tx event sent, without its proof.
+func executionFromEvent(event *precompilesgen.ArbSysL2ToL1Tx) *Execution {
+	return &Execution{
+		Leaf:        event.Position.Uint64(),
+		Caller:      event.Caller,
+		Destination: event.Destination,
+		L2Block:     (*hexutil.Big)(event.ArbBlockNum),
+		L1Block:     (*hexutil.Big)(event.EthBlockNum),
+		Timestamp:   (*hexutil.Big)(event.Timestamp),
+		Value:       (*hexutil.Big)(event.Callvalue),
+		Data:        event.Data,
+	}
+}
+
+// updateConfirmed records the L2 block and send count of the latest confirmed assertion.
+func (e *OutboxExecutor) updateConfirmed(ctx context.Context, nodeNum uint64) error {
+	block, sendCount, err := confirmedSends(ctx, e.rollup, e.l2Client, nodeNum)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.confirmedNode = nodeNum
+	e.confirmedBlock = block
+	e.sendCount = sendCount
+	// Proofs are against the confirmed send root, so they need rebuilding
+	for _, execution := range e.pending {
+		execution.Proof = nil
+	}
+	log.Info("outbox executor saw newly confirmed assertion", "node", nodeNum, "block", e.confirmedBlock, "sendCount", e.sendCount)
+	return nil
+}
+
+// scanMessages looks for matching messages in the L2 blocks up to the confirmed one.
+func (e *OutboxExecutor) scanMessages(ctx context.Context) error {
+	e.mutex.Lock()
+	from, confirmedBlock := e.nextBlock, e.confirmedBlock
+	e.mutex.Unlock()
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{types.ArbSysAddress},
+		Topics:    [][]common.Hash{{e.l2ToL1TxID}},
+	}
+	if e.destinations != nil {
+		// The destination is the first indexed argument
+		var destinations []common.Hash
+		for destination := range e.destinations {
+			destinations = append(destinations, common.BytesToHash(destination.Bytes()))
+		}
+		query.Topics = append(query.Topics, destinations)
+	}
+	for from <= confirmedBlock {
+		to := from + e.config.LogQueryRange - 1
+		if to > confirmedBlock {
+			to = confirmedBlock
+		}
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(to)
+		logs, err := e.l2Client.FilterLogs(ctx, query)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		for _, l := range logs {
+			event, err := e.arbSys.ParseL2ToL1Tx(l)
+			if err != nil {
+				e.mutex.Unlock()
+				return err
+			}
+			if !e.matches(event.Caller, event.Destination) || !event.Position.IsUint64() {
+				continue
+			}
+			e.pending[event.Position.Uint64()] = executionFromEvent(event)
+		}
+		e.nextBlock = to + 1
+		pendingGauge.Update(int64(len(e.pending)))
+		e.mutex.Unlock()
+		from = to + 1
+	}
+	return nil
+}
+
+func (e *OutboxExecutor) pendingLeaves() []uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	leaves := make([]uint64, 0, len(e.pending))
+	for leaf := range e.pending {
+		leaves = append(leaves, leaf)
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i] < leaves[j] })
+	return leaves
+}
+
+// processPending drops executed messages, builds proofs for the rest, and executes them if configured to.
+func (e *OutboxExecutor) processPending(ctx context.Context) error {
+	callOpts := &bind.CallOpts{Context: ctx}
+	for _, leaf := range e.pendingLeaves() {
+		spent, err := e.outbox.IsSpent(callOpts, new(big.Int).SetUint64(leaf))
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		execution := e.pending[leaf]
+		sendCount := e.sendCount
+		if spent {
+			delete(e.pending, leaf)
+			pendingGauge.Update(int64(len(e.pending)))
+		}
+		e.mutex.Unlock()
+		if spent || execution == nil || execution.Proof != nil {
+			continue
+		}
+		hashes, err := constructProof(ctx, e.nodeInterface, sendCount, leaf)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		execution.Proof = hashes
+		execution.ProofSize = sendCount
+		e.mutex.Unlock()
+	}
+	if !e.config.Execute {
+		return nil
+	}
+	for _, leaf := range e.pendingLeaves() {
+		e.mutex.Lock()
+		execution := e.pending[leaf]
+		ready := execution != nil && execution.Proof != nil && execution.ExecutionTx == nil && execution.Error == ""
+		e.mutex.Unlock()
+		if ready {
+			e.execute(ctx, execution)
+		}
+	}
+	return nil
+}
+
+func (e *OutboxExecutor) execute(ctx context.Context, execution *Execution) {
+	opts := *e.transactOpts
+	opts.Context = ctx
+	proof := make([][32]byte, 0, len(execution.Proof))
+	for _, hash := range execution.Proof {
+		proof = append(proof, hash)
+	}
+	tx, err := e.outbox.ExecuteTransaction(
+		&opts,
+		proof,
+		new(big.Int).SetUint64(execution.Leaf),
+		execution.Caller,
+		execution.Destination,
+		execution.L2Block.ToInt(),
+		execution.L1Block.ToInt(),
+		execution.Timestamp.ToInt(),
+		execution.Value.ToInt(),
+		execution.Data,
+	)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if err != nil {
+		// Not retried, as it would most likely fail again
+		executeErrCounter.Inc(1)
+		execution.Error = err.Error()
+		log.Error("failed to execute outbox message", "leaf", execution.Leaf, "destination", execution.Destination, "err", err)
+		return
+	}
+	executedCounter.Inc(1)
+	hash := tx.Hash()
+	execution.ExecutionTx = &hash
+	log.Info("executed outbox message", "leaf", execution.Leaf, "destination", execution.Destination, "tx", hash)
+}
+
+// Executions returns the matching messages which haven't been executed yet, ordered by leaf.
+// Messages whose proofs haven't been built yet are omitted.
+func (e *OutboxExecutor) Executions() []*Execution {
+	leaves := e.pendingLeaves()
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	executions := make([]*Execution, 0, len(leaves))
+	for _, leaf := range leaves {
+		execution, ok := e.pending[leaf]
+		if !ok || execution.Proof == nil {
+			continue
+		}
+		copied := *execution
+		executions = append(executions, &copied)
+	}
+	return executions
+}
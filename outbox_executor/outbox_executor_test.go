@@ -0,0 +1,92 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package outboxexecutor
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestConfigValidate(t *testing.T) {
+	config := DefaultConfig
+	config.Enable = true
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	config.Execute = true
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected executing without filters to be rejected")
+	}
+	config.Destinations = []string{"0x0000000000000000000000000000000000000001"}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	config.Callers = []string{"not an address"}
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected invalid caller to be rejected")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	bridge := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	gateway := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	other := common.HexToAddress("0x0000000000000000000000000000000000000003")
+
+	executor := &OutboxExecutor{}
+	if !executor.matches(other, other) {
+		t.Fatal("executor without filters should match every message")
+	}
+
+	var err error
+	executor.destinations, err = parseAddresses("destination", []string{bridge.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !executor.matches(other, bridge) || executor.matches(other, other) {
+		t.Fatal("destination filter not applied")
+	}
+
+	executor.callers, err = parseAddresses("caller", []string{gateway.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !executor.matches(gateway, bridge) || executor.matches(other, bridge) || executor.matches(gateway, other) {
+		t.Fatal("caller and destination filters not both applied")
+	}
+}
+
+func TestTwoHopConfigValidate(t *testing.T) {
+	config := DefaultTwoHopConfig
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	config.Enable = true
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected missing grandparent chain url to be rejected")
+	}
+	config.GrandparentChainURL = "ws://localhost:8546"
+	config.ParentChainRollup = "not an address"
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected invalid parent chain rollup to be rejected")
+	}
+	config.ParentChainRollup = "0x0000000000000000000000000000000000000001"
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTwoHopWithdrawalCopy(t *testing.T) {
+	withdrawal := &TwoHopWithdrawal{
+		Status: TwoHopL2LegReady,
+		L3Leg:  &Execution{Leaf: 1},
+		L2Legs: []*Execution{{Leaf: 2}},
+	}
+	copied := withdrawal.copy()
+	copied.L3Leg.Leaf = 3
+	copied.L2Legs[0].Leaf = 4
+	if withdrawal.L3Leg.Leaf != 1 || withdrawal.L2Legs[0].Leaf != 2 {
+		t.Fatal("copy shares executions with the tracked withdrawal")
+	}
+}
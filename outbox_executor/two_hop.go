@@ -0,0 +1,447 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package outboxexecutor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/staker"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var twoHopPendingGauge = metrics.NewRegisteredGauge("arb/outboxexecutor/twohop/pending", nil)
+
+// TwoHopConfig configures tracking withdrawals from an L3 through its parent chain to the L1.
+type TwoHopConfig struct {
+	Enable              bool          `koanf:"enable"`
+	GrandparentChainURL string        `koanf:"grandparent-chain-url"`
+	ParentChainRollup   string        `koanf:"parent-chain-rollup"`
+	PollInterval        time.Duration `koanf:"poll-interval"`
+	LogQueryRange       uint64        `koanf:"log-query-range"`
+}
+
+var DefaultTwoHopConfig = TwoHopConfig{
+	Enable:        false,
+	PollInterval:  time.Minute,
+	LogQueryRange: 10000,
+}
+
+func TwoHopConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultTwoHopConfig.Enable, "enable tracking withdrawals from this chain through its parent chain to the grandparent chain, exposed over the arb_trackTwoHopWithdrawal and arb_twoHopWithdrawals RPCs")
+	f.String(prefix+".grandparent-chain-url", DefaultTwoHopConfig.GrandparentChainURL, "URL of the parent chain's own parent chain")
+	f.String(prefix+".parent-chain-rollup", DefaultTwoHopConfig.ParentChainRollup, "address of the parent chain's rollup contract on the grandparent chain")
+	f.Duration(prefix+".poll-interval", DefaultTwoHopConfig.PollInterval, "how often to check on tracked withdrawals")
+	f.Uint64(prefix+".log-query-range", DefaultTwoHopConfig.LogQueryRange, "maximum number of parent chain blocks to look for a withdrawal's execution in per query")
+}
+
+func (c *TwoHopConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.GrandparentChainURL == "" {
+		return errors.New("two-hop-withdrawals requires grandparent-chain-url")
+	}
+	if !common.IsHexAddress(c.ParentChainRollup) {
+		return fmt.Errorf("invalid two-hop-withdrawals parent-chain-rollup address %v", c.ParentChainRollup)
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("two-hop-withdrawals poll-interval must be positive")
+	}
+	if c.LogQueryRange == 0 {
+		return errors.New("two-hop-withdrawals log-query-range must be positive")
+	}
+	return nil
+}
+
+type TwoHopStatus string
+
+const (
+	TwoHopAwaitingL3Confirmation TwoHopStatus = "awaiting-l3-confirmation"
+	TwoHopL3LegReady             TwoHopStatus = "l3-leg-ready"
+	TwoHopAwaitingL2Confirmation TwoHopStatus = "awaiting-l2-confirmation"
+	TwoHopL2LegReady             TwoHopStatus = "l2-leg-ready"
+	TwoHopComplete               TwoHopStatus = "complete"
+	// Executing the L3 leg didn't send anything on to the L1
+	TwoHopNoL2Leg TwoHopStatus = "no-l2-leg"
+)
+
+// TwoHopWithdrawal is an L3 to L2 message whose execution on the L2 sends L2 to L1 messages,
+// along with what's needed to execute each leg once its assertion is confirmed.
+type TwoHopWithdrawal struct {
+	L3Tx   common.Hash  `json:"l3Tx"`
+	Status TwoHopStatus `json:"status"`
+	// Executed in the L3's outbox on the L2, with a proof once the L3 assertion including it is confirmed
+	L3Leg *Execution `json:"l3Leg"`
+	// The L2 transaction that executed the L3 leg
+	L3LegExecutionTx *common.Hash `json:"l3LegExecutionTx,omitempty"`
+	// What executing the L3 leg sent on, executed in the L2's outbox on the L1, with proofs once confirmed
+	L2Legs []*Execution `json:"l2Legs,omitempty"`
+	// Why the withdrawal couldn't be moved on when last checked, if it couldn't
+	Error string `json:"error,omitempty"`
+
+	l2ScanFrom uint64 // the L3 leg can't have been executed in an L2 block before this
+}
+
+func (w *TwoHopWithdrawal) copy() *TwoHopWithdrawal {
+	copied := *w
+	l3Leg := *w.L3Leg
+	copied.L3Leg = &l3Leg
+	copied.L2Legs = make([]*Execution, 0, len(w.L2Legs))
+	for _, leg := range w.L2Legs {
+		l2Leg := *leg
+		copied.L2Legs = append(copied.L2Legs, &l2Leg)
+	}
+	return &copied
+}
+
+// TwoHopRelay tracks withdrawals from an L3 until both their legs have been executed.
+// It doesn't execute them itself, as the L3 leg's destination usually needs calling in a particular way.
+type TwoHopRelay struct {
+	stopwaiter.StopWaiter
+	config          *TwoHopConfig
+	l3Client        *ethclient.Client
+	l2Client        *ethclient.Client
+	l1Client        *ethclient.Client
+	l3Rollup        *staker.RollupWatcher // on the L2
+	l2Rollup        *staker.RollupWatcher // on the L1
+	l3Outbox        *bridgegen.Outbox
+	l2Outbox        *bridgegen.Outbox
+	l3NodeInterface *node_interfacegen.NodeInterface
+	l2NodeInterface *node_interfacegen.NodeInterface
+	arbSys          *precompilesgen.ArbSysFilterer
+	l2ToL1TxID      common.Hash
+
+	mutex       sync.Mutex
+	withdrawals map[uint64]*TwoHopWithdrawal // by L3 leaf
+}
+
+// NewTwoHopRelay creates a relay for the L3 at l3Client, whose rollup is on the L2 at l2Client.
+func NewTwoHopRelay(config *TwoHopConfig, l3Client, l2Client, l1Client *ethclient.Client, l3RollupAddress common.Address) (*TwoHopRelay, error) {
+	l3Rollup, err := staker.NewRollupWatcher(l3RollupAddress, l2Client, bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	l2Rollup, err := staker.NewRollupWatcher(common.HexToAddress(config.ParentChainRollup), l1Client, bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	l3NodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, l3Client)
+	if err != nil {
+		return nil, err
+	}
+	l2NodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, l2Client)
+	if err != nil {
+		return nil, err
+	}
+	arbSys, err := precompilesgen.NewArbSysFilterer(types.ArbSysAddress, l3Client)
+	if err != nil {
+		return nil, err
+	}
+	arbSysAbi, err := precompilesgen.ArbSysMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &TwoHopRelay{
+		config:          config,
+		l3Client:        l3Client,
+		l2Client:        l2Client,
+		l1Client:        l1Client,
+		l3Rollup:        l3Rollup,
+		l2Rollup:        l2Rollup,
+		l3NodeInterface: l3NodeInterface,
+		l2NodeInterface: l2NodeInterface,
+		arbSys:          arbSys,
+		l2ToL1TxID:      arbSysAbi.Events["L2ToL1Tx"].ID,
+		withdrawals:     make(map[uint64]*TwoHopWithdrawal),
+	}, nil
+}
+
+func (r *TwoHopRelay) Start(ctxIn context.Context) error {
+	callOpts := &bind.CallOpts{Context: ctxIn}
+	l3OutboxAddress, err := r.l3Rollup.Outbox(callOpts)
+	if err != nil {
+		return fmt.Errorf("failed to get outbox address from the L3's rollup: %w", err)
+	}
+	r.l3Outbox, err = bridgegen.NewOutbox(l3OutboxAddress, r.l2Client)
+	if err != nil {
+		return err
+	}
+	l2OutboxAddress, err := r.l2Rollup.Outbox(callOpts)
+	if err != nil {
+		return fmt.Errorf("failed to get outbox address from the L2's rollup: %w", err)
+	}
+	r.l2Outbox, err = bridgegen.NewOutbox(l2OutboxAddress, r.l1Client)
+	if err != nil {
+		return err
+	}
+	r.StopWaiter.Start(ctxIn, r)
+	r.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := r.update(ctx); err != nil {
+			log.Warn("two-hop withdrawal relay failed to update", "err", err)
+		}
+		return r.config.PollInterval
+	})
+	return nil
+}
+
+// messagesSent returns the L2 to L1 messages sent in a receipt's logs.
+func (r *TwoHopRelay) messagesSent(receipt *types.Receipt) ([]*Execution, error) {
+	var messages []*Execution
+	for _, l := range receipt.Logs {
+		if l.Address != types.ArbSysAddress || len(l.Topics) == 0 || l.Topics[0] != r.l2ToL1TxID {
+			continue
+		}
+		event, err := r.arbSys.ParseL2ToL1Tx(*l)
+		if err != nil {
+			return nil, err
+		}
+		if !event.Position.IsUint64() {
+			return nil, fmt.Errorf("message position %v out of range", event.Position)
+		}
+		messages = append(messages, executionFromEvent(event))
+	}
+	return messages, nil
+}
+
+// Track starts tracking the withdrawals an L3 transaction sent, returning them.
+func (r *TwoHopRelay) Track(ctx context.Context, l3Tx common.Hash) ([]*TwoHopWithdrawal, error) {
+	receipt, err := r.l3Client.TransactionReceipt(ctx, l3Tx)
+	if err != nil {
+		return nil, err
+	}
+	legs, err := r.messagesSent(receipt)
+	if err != nil {
+		return nil, err
+	}
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("transaction %v sent no messages to the parent chain", l3Tx)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	tracked := make([]*TwoHopWithdrawal, 0, len(legs))
+	for _, leg := range legs {
+		withdrawal, ok := r.withdrawals[leg.Leaf]
+		if !ok {
+			withdrawal = &TwoHopWithdrawal{
+				L3Tx:       l3Tx,
+				Status:     TwoHopAwaitingL3Confirmation,
+				L3Leg:      leg,
+				l2ScanFrom: leg.L1Block.ToInt().Uint64(),
+			}
+			r.withdrawals[leg.Leaf] = withdrawal
+			log.Info("tracking two-hop withdrawal", "l3Tx", l3Tx, "leaf", leg.Leaf, "destination", leg.Destination)
+		}
+		tracked = append(tracked, withdrawal.copy())
+	}
+	twoHopPendingGauge.Update(int64(r.pendingCount()))
+	return tracked, nil
+}
+
+// Withdrawals returns every tracked withdrawal, ordered by L3 leaf.
+func (r *TwoHopRelay) Withdrawals() []*TwoHopWithdrawal {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	withdrawals := make([]*TwoHopWithdrawal, 0, len(r.withdrawals))
+	for _, withdrawal := range r.withdrawals {
+		withdrawals = append(withdrawals, withdrawal.copy())
+	}
+	sort.Slice(withdrawals, func(i, j int) bool { return withdrawals[i].L3Leg.Leaf < withdrawals[j].L3Leg.Leaf })
+	return withdrawals
+}
+
+// Only call this with the mutex held.
+func (r *TwoHopRelay) pendingCount() int {
+	pending := 0
+	for _, withdrawal := range r.withdrawals {
+		if withdrawal.Status != TwoHopComplete && withdrawal.Status != TwoHopNoL2Leg {
+			pending++
+		}
+	}
+	return pending
+}
+
+// latestConfirmedSendCount returns how many messages the rollup's latest confirmed assertion includes.
+func latestConfirmedSendCount(ctx context.Context, rollup *staker.RollupWatcher, client *ethclient.Client) (uint64, error) {
+	nodeNum, err := rollup.LatestConfirmed(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, err
+	}
+	_, sendCount, err := confirmedSends(ctx, rollup, client, nodeNum)
+	return sendCount, err
+}
+
+func (r *TwoHopRelay) update(ctx context.Context) error {
+	r.mutex.Lock()
+	withdrawals := make([]*TwoHopWithdrawal, 0, len(r.withdrawals))
+	for _, withdrawal := range r.withdrawals {
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	r.mutex.Unlock()
+	if len(withdrawals) == 0 {
+		return nil
+	}
+	l3SendCount, err := latestConfirmedSendCount(ctx, r.l3Rollup, r.l3Client)
+	if err != nil {
+		return fmt.Errorf("error reading the L3's confirmed sends: %w", err)
+	}
+	l2SendCount, err := latestConfirmedSendCount(ctx, r.l2Rollup, r.l2Client)
+	if err != nil {
+		return fmt.Errorf("error reading the L2's confirmed sends: %w", err)
+	}
+	for _, withdrawal := range withdrawals {
+		err := r.advance(ctx, withdrawal, l3SendCount, l2SendCount)
+		r.mutex.Lock()
+		withdrawal.Error = ""
+		if err != nil {
+			withdrawal.Error = err.Error()
+		}
+		r.mutex.Unlock()
+		if err != nil {
+			log.Warn("error advancing two-hop withdrawal", "leaf", withdrawal.L3Leg.Leaf, "err", err)
+		}
+	}
+	r.mutex.Lock()
+	twoHopPendingGauge.Update(int64(r.pendingCount()))
+	r.mutex.Unlock()
+	return nil
+}
+
+// advance moves a withdrawal on as far as it's gotten. Only the update thread changes withdrawals,
+// so they're only locked while being written to.
+func (r *TwoHopRelay) advance(ctx context.Context, withdrawal *TwoHopWithdrawal, l3SendCount, l2SendCount uint64) error {
+	callOpts := &bind.CallOpts{Context: ctx}
+	if withdrawal.Status == TwoHopAwaitingL3Confirmation {
+		leaf := withdrawal.L3Leg.Leaf
+		if leaf >= l3SendCount {
+			return nil
+		}
+		proof, err := constructProof(ctx, r.l3NodeInterface, l3SendCount, leaf)
+		if err != nil {
+			return err
+		}
+		r.mutex.Lock()
+		withdrawal.L3Leg.Proof = proof
+		withdrawal.L3Leg.ProofSize = l3SendCount
+		withdrawal.Status = TwoHopL3LegReady
+		r.mutex.Unlock()
+	}
+	if withdrawal.Status == TwoHopL3LegReady {
+		header, err := r.l2Client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return err
+		}
+		spent, err := r.l3Outbox.IsSpent(&bind.CallOpts{Context: ctx, BlockNumber: header.Number}, new(big.Int).SetUint64(withdrawal.L3Leg.Leaf))
+		if err != nil {
+			return err
+		}
+		if !spent {
+			r.mutex.Lock()
+			withdrawal.l2ScanFrom = header.Number.Uint64() + 1
+			r.mutex.Unlock()
+			return nil
+		}
+		executionTx, err := r.findL3LegExecution(ctx, withdrawal, header.Number.Uint64())
+		if err != nil {
+			return err
+		}
+		receipt, err := r.l2Client.TransactionReceipt(ctx, executionTx)
+		if err != nil {
+			return err
+		}
+		l2Legs, err := r.messagesSent(receipt)
+		if err != nil {
+			return err
+		}
+		r.mutex.Lock()
+		withdrawal.L3LegExecutionTx = &executionTx
+		withdrawal.L2Legs = l2Legs
+		withdrawal.Status = TwoHopAwaitingL2Confirmation
+		if len(l2Legs) == 0 {
+			withdrawal.Status = TwoHopNoL2Leg
+		}
+		r.mutex.Unlock()
+		log.Info("two-hop withdrawal's L3 leg executed", "leaf", withdrawal.L3Leg.Leaf, "tx", executionTx, "l2Legs", len(l2Legs))
+	}
+	if withdrawal.Status == TwoHopAwaitingL2Confirmation {
+		proofs := make([][]common.Hash, 0, len(withdrawal.L2Legs))
+		for _, leg := range withdrawal.L2Legs {
+			if leg.Leaf >= l2SendCount {
+				return nil
+			}
+			proof, err := constructProof(ctx, r.l2NodeInterface, l2SendCount, leg.Leaf)
+			if err != nil {
+				return err
+			}
+			proofs = append(proofs, proof)
+		}
+		r.mutex.Lock()
+		for i, leg := range withdrawal.L2Legs {
+			leg.Proof = proofs[i]
+			leg.ProofSize = l2SendCount
+		}
+		withdrawal.Status = TwoHopL2LegReady
+		r.mutex.Unlock()
+	}
+	if withdrawal.Status == TwoHopL2LegReady {
+		for _, leg := range withdrawal.L2Legs {
+			spent, err := r.l2Outbox.IsSpent(callOpts, new(big.Int).SetUint64(leg.Leaf))
+			if err != nil || !spent {
+				return err
+			}
+		}
+		r.mutex.Lock()
+		withdrawal.Status = TwoHopComplete
+		r.mutex.Unlock()
+		log.Info("two-hop withdrawal complete", "leaf", withdrawal.L3Leg.Leaf)
+	}
+	return nil
+}
+
+// findL3LegExecution finds the L2 transaction that executed a withdrawal's L3 leg, at or before block to.
+func (r *TwoHopRelay) findL3LegExecution(ctx context.Context, withdrawal *TwoHopWithdrawal, to uint64) (common.Hash, error) {
+	leg := withdrawal.L3Leg
+	for from := withdrawal.l2ScanFrom; from <= to; from += r.config.LogQueryRange {
+		end := from + r.config.LogQueryRange - 1
+		if end > to {
+			end = to
+		}
+		iter, err := r.l3Outbox.FilterOutBoxTransactionExecuted(
+			&bind.FilterOpts{Start: from, End: &end, Context: ctx}, []common.Address{leg.Destination}, []common.Address{leg.Caller}, nil,
+		)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		for iter.Next() {
+			if iter.Event.TransactionIndex.IsUint64() && iter.Event.TransactionIndex.Uint64() == leg.Leaf {
+				txHash := iter.Event.Raw.TxHash
+				return txHash, iter.Close()
+			}
+		}
+		if err := iter.Error(); err != nil {
+			return common.Hash{}, err
+		}
+		if err := iter.Close(); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return common.Hash{}, fmt.Errorf("L3 leg %v is spent but its execution wasn't found in L2 blocks %v to %v", leg.Leaf, withdrawal.l2ScanFrom, to)
+}
@@ -89,6 +89,31 @@ func (con ArbAggregator) SetFeeCollector(c ctx, evm mech, batchPoster addr, newF
 	return posterInfo.SetPayTo(newFeeCollector)
 }
 
+// GetParentTokenExchangeRateUpdater gets the account allowed to push the parent token exchange rate
+func (con ArbAggregator) GetParentTokenExchangeRateUpdater(c ctx, evm mech) (addr, error) {
+	return c.State.L1PricingState().ParentTokenRateUpdater()
+}
+
+// SetParentTokenExchangeRate sets how many wei of the fee token a wei of the parent chain's gas token is worth,
+// scaled by 1e18, which L1 pricing uses to convert batch posting costs (caller must be the updater or an owner)
+func (con ArbAggregator) SetParentTokenExchangeRate(c ctx, evm mech, rate huge) error {
+	l1p := c.State.L1PricingState()
+	updater, err := l1p.ParentTokenRateUpdater()
+	if err != nil {
+		return err
+	}
+	if c.caller != updater || updater == (addr{}) {
+		isOwner, err := c.State.ChainOwners().IsMember(c.caller)
+		if err != nil {
+			return err
+		}
+		if !isOwner {
+			return errors.New("only the parent token exchange rate updater (or a chain owner) may set the exchange rate")
+		}
+	}
+	return l1p.SetParentTokenExchangeRate(rate)
+}
+
 // GetTxBaseFee gets an aggregator's current fixed fee to submit a tx
 func (con ArbAggregator) GetTxBaseFee(c ctx, evm mech, aggregator addr) (huge, error) {
 	// This is deprecated and now always returns zero.
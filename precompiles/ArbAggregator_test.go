@@ -9,7 +9,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
 func TestFeeCollector(t *testing.T) {
@@ -81,3 +83,36 @@ func TestTxBaseFee(t *testing.T) {
 		Fail(t, fee)
 	}
 }
+
+func TestParentTokenExchangeRate(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	owner := testhelpers.RandomAddress()
+	ownerCtx := testContext(owner, evm)
+	Require(t, ownerCtx.State.ChainOwners().Add(owner))
+	updater := testhelpers.RandomAddress()
+	updaterCtx := testContext(updater, evm)
+	agg := ArbAggregator{}
+	gasInfo := ArbGasInfo{}
+
+	rate, err := gasInfo.GetParentTokenExchangeRate(ownerCtx, evm)
+	Require(t, err)
+	if rate.Cmp(l1pricing.ParentTokenExchangeRateOne) != 0 {
+		Fail(t, "exchange rate didn't start at 1:1", rate)
+	}
+
+	if err := agg.SetParentTokenExchangeRate(updaterCtx, evm, big.NewInt(2e18)); err == nil {
+		Fail(t, "exchange rate set before the updater was authorized")
+	}
+	Require(t, ArbOwner{}.SetParentTokenExchangeRateUpdater(ownerCtx, evm, updater))
+	Require(t, agg.SetParentTokenExchangeRate(updaterCtx, evm, big.NewInt(2e18)))
+	if err := agg.SetParentTokenExchangeRate(updaterCtx, evm, big.NewInt(0)); err == nil {
+		Fail(t, "exchange rate set to zero")
+	}
+	rate, err = gasInfo.GetParentTokenExchangeRate(ownerCtx, evm)
+	Require(t, err)
+	if rate.Cmp(big.NewInt(2e18)) != 0 {
+		Fail(t, "unexpected exchange rate", rate)
+	}
+	Require(t, agg.SetParentTokenExchangeRate(ownerCtx, evm, big.NewInt(5e17)))
+}
@@ -64,3 +64,57 @@ func (con ArbDebug) Panic(c ctx, evm mech) error {
 func (con ArbDebug) LegacyError(c ctx) error {
 	return errors.New("example legacy error")
 }
+
+// DumpArbosState gets the ArbOS version, the L2 and L1 pricing models' internals,
+// the number of retryables in the timeout queue, and the address table's size in one call
+func (con ArbDebug) DumpArbosState(c ctx, evm mech) (
+	uint64, huge, huge, uint64, uint64, uint64, huge, uint64, huge, huge, uint64, uint64, error,
+) {
+	var errs []error
+	read := func(value uint64, err error) uint64 {
+		errs = append(errs, err)
+		return value
+	}
+	readBig := func(value huge, err error) huge {
+		errs = append(errs, err)
+		return value
+	}
+	l2pricing := c.State.L2PricingState()
+	l1pricing := c.State.L1PricingState()
+	l2BaseFee := readBig(l2pricing.BaseFeeWei())
+	l2MinBaseFee := readBig(l2pricing.MinBaseFeeWei())
+	l2GasBacklog := read(l2pricing.GasBacklog())
+	l2SpeedLimit := read(l2pricing.SpeedLimitPerSecond())
+	l2PerBlockGasLimit := read(l2pricing.PerBlockGasLimit())
+	l1PricePerUnit := readBig(l1pricing.PricePerUnit())
+	l1UnitsSinceUpdate := read(l1pricing.UnitsSinceUpdate())
+	l1LastSurplus := readBig(l1pricing.LastSurplus())
+	l1FundsDueForRewards := readBig(l1pricing.FundsDueForRewards())
+	retryableQueueSize := read(c.State.RetryableState().TimeoutQueue.Size())
+	addressTableSize := read(c.State.AddressTable().Size())
+	return c.State.ArbOSVersion(),
+		l2BaseFee, l2MinBaseFee, l2GasBacklog, l2SpeedLimit, l2PerBlockGasLimit,
+		l1PricePerUnit, l1UnitsSinceUpdate, l1LastSurplus, l1FundsDueForRewards,
+		retryableQueueSize, addressTableSize,
+		errors.Join(errs...)
+}
+
+// WarpTime moves the timestamps of blocks after this one forward by the given number of seconds
+func (con ArbDebug) WarpTime(c ctx, evm mech, seconds uint64) error {
+	return c.State.WarpTime(seconds)
+}
+
+// WarpL1BlockNumber moves the L1 block number recorded for blocks after this one forward by the given number of blocks
+func (con ArbDebug) WarpL1BlockNumber(c ctx, evm mech, blocks uint64) error {
+	return c.State.WarpL1BlockNumber(blocks)
+}
+
+// GetWarps gets how far time and the recorded L1 block number have been moved forward
+func (con ArbDebug) GetWarps(c ctx, evm mech) (uint64, uint64, error) {
+	timeWarp, err := c.State.TimeWarp()
+	if err != nil {
+		return 0, 0, err
+	}
+	l1BlockNumberWarp, err := c.State.L1BlockNumberWarp()
+	return timeWarp, l1BlockNumberWarp, err
+}
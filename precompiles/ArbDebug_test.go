@@ -0,0 +1,42 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestArbDebugDumpArbosState(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	debug := ArbDebug{}
+
+	Require(t, context.State.L2PricingState().SetGasBacklog(1234))
+	_, err := context.State.AddressTable().Register(common.HexToAddress("0x1234"))
+	Require(t, err)
+
+	version, l2BaseFee, l2MinBaseFee, gasBacklog, speedLimit, perBlockGasLimit, l1PricePerUnit, _, _, _, queueSize, addressTableSize, err := debug.DumpArbosState(context, evm)
+	Require(t, err)
+	if version != context.State.ArbOSVersion() {
+		Fail(t, "wrong ArbOS version", version)
+	}
+	minBaseFee, err := context.State.L2PricingState().MinBaseFeeWei()
+	Require(t, err)
+	if l2MinBaseFee.Cmp(minBaseFee) != 0 || l2BaseFee.Cmp(minBaseFee) < 0 {
+		Fail(t, "unexpected L2 base fees", l2BaseFee, l2MinBaseFee)
+	}
+	if gasBacklog != 1234 || speedLimit == 0 || perBlockGasLimit == 0 {
+		Fail(t, "unexpected L2 pricing", gasBacklog, speedLimit, perBlockGasLimit)
+	}
+	pricePerUnit, err := context.State.L1PricingState().PricePerUnit()
+	Require(t, err)
+	if l1PricePerUnit.Cmp(pricePerUnit) != 0 {
+		Fail(t, "unexpected L1 price per unit", l1PricePerUnit)
+	}
+	if queueSize != 0 || addressTableSize != 1 {
+		Fail(t, "unexpected retryable queue or address table size", queueSize, addressTableSize)
+	}
+}
@@ -172,6 +172,18 @@ func (con ArbGasInfo) GetMinimumGasPrice(c ctx, evm mech) (huge, error) {
 	return c.State.L2PricingState().MinBaseFeeWei()
 }
 
+// GetPricingFloorAt gets the minimum gas price and the speed limit that will be in effect at a future timestamp,
+// given the scheduled minimum gas price changes. ArbOS upgrades don't change either on a running chain.
+func (con ArbGasInfo) GetPricingFloorAt(c ctx, evm mech, timestamp uint64) (huge, uint64, error) {
+	l2pricing := c.State.L2PricingState()
+	minBaseFee, err := l2pricing.MinBaseFeeWeiAt(timestamp)
+	if err != nil {
+		return nil, 0, err
+	}
+	speedLimit, err := l2pricing.SpeedLimitPerSecond()
+	return minBaseFee, speedLimit, err
+}
+
 // GetL1BaseFeeEstimate gets the current estimate of the L1 basefee
 func (con ArbGasInfo) GetL1BaseFeeEstimate(c ctx, evm mech) (huge, error) {
 	return c.State.L1PricingState().PricePerUnit()
@@ -217,6 +229,12 @@ func (con ArbGasInfo) GetGasBacklogTolerance(c ctx, evm mech) (uint64, error) {
 	return c.State.L2PricingState().BacklogTolerance()
 }
 
+// GetGasTargetBips gets the rate the L2 gas backlog drains at, in basis points of the speed limit (0 if it's the speed limit)
+func (con ArbGasInfo) GetGasTargetBips(c ctx, evm mech) (uint64, error) {
+	bips, err := c.State.L2PricingState().GasTargetBips()
+	return uint64(bips), err
+}
+
 // GetL1PricingSurplus gets the surplus of funds for L1 batch posting payments (may be negative)
 func (con ArbGasInfo) GetL1PricingSurplus(c ctx, evm mech) (*big.Int, error) {
 	if c.State.ArbOSVersion() < 10 {
@@ -280,3 +298,53 @@ func (con ArbGasInfo) GetL1PricingUnitsSinceUpdate(c ctx, evm mech) (uint64, err
 func (con ArbGasInfo) GetLastL1PricingSurplus(c ctx, evm mech) (*big.Int, error) {
 	return c.State.L1PricingState().LastSurplus()
 }
+
+// GetParentTokenExchangeRate gets how many wei of the fee token a wei of the parent chain's gas token is worth, scaled by 1e18
+func (con ArbGasInfo) GetParentTokenExchangeRate(c ctx, evm mech) (huge, error) {
+	return c.State.L1PricingState().ParentTokenExchangeRate()
+}
+
+// GetFeeDiscount gets the discount on L2 gas fees, in basis points, for transactions sent by or to an account
+func (con ArbGasInfo) GetFeeDiscount(c ctx, evm mech, account addr) (uint64, error) {
+	discount, err := c.State.L2PricingState().FeeDiscount(account)
+	return uint64(discount), err
+}
+
+// GetBatchPosterStats gets what a batch poster has posted and been paid for since its stats were last reset:
+// its batches, the L1 data units allocated to them, the cost attributed to it, what its fee collector has been
+// reimbursed, and what it's still owed
+func (con ArbGasInfo) GetBatchPosterStats(c ctx, evm mech, batchPoster addr) (uint64, uint64, huge, huge, huge, error) {
+	poster, err := c.State.L1PricingState().BatchPosterTable().OpenPoster(batchPoster, false)
+	if err != nil {
+		return 0, 0, nil, nil, nil, err
+	}
+	stats, err := poster.Stats()
+	if err != nil {
+		return 0, 0, nil, nil, nil, err
+	}
+	fundsDue, err := poster.FundsDue()
+	if err != nil {
+		return 0, 0, nil, nil, nil, err
+	}
+	return stats.BatchesPosted, stats.UnitsPosted, stats.CostAttributed, stats.FeesReimbursed, fundsDue, nil
+}
+
+// GetMaxTxSize gets the largest encoded transaction the chain accepts, or 0 if it's left to the sequencer
+func (con ArbGasInfo) GetMaxTxSize(c ctx, evm mech) (uint64, error) {
+	return c.State.MaxTxSize()
+}
+
+// GetMaxCalldataSize gets the largest transaction calldata the chain accepts, or 0 if it's unlimited
+func (con ArbGasInfo) GetMaxCalldataSize(c ctx, evm mech) (uint64, error) {
+	return c.State.MaxCalldataSize()
+}
+
+// GetMaxLogsPerTx gets the most logs a signed transaction may emit, or 0 if it's unlimited
+func (con ArbGasInfo) GetMaxLogsPerTx(c ctx, evm mech) (uint64, error) {
+	return c.State.MaxLogsPerTx()
+}
+
+// GetMaxReturnDataSize gets the largest data a signed transaction may return, or 0 if it's unlimited
+func (con ArbGasInfo) GetMaxReturnDataSize(c ctx, evm mech) (uint64, error) {
+	return c.State.MaxReturnDataSize()
+}
@@ -54,6 +54,30 @@ func TestGetGasBacklog(t *testing.T) {
 	}
 }
 
+func TestGetPricingFloorAt(t *testing.T) {
+	t.Parallel()
+
+	evm, state, callCtx, arbGasInfo := setupArbGasInfo(t)
+
+	l2pricing := state.L2PricingState()
+	currentMin, err := l2pricing.MinBaseFeeWei()
+	Require(t, err)
+	speedLimit, err := l2pricing.SpeedLimitPerSecond()
+	Require(t, err)
+	Require(t, l2pricing.ScheduleMinBaseFee(1000, big.NewInt(123)))
+
+	minBaseFee, retrievedSpeedLimit, err := arbGasInfo.GetPricingFloorAt(callCtx, evm, 999)
+	Require(t, err)
+	if minBaseFee.Cmp(currentMin) != 0 || retrievedSpeedLimit != speedLimit {
+		t.Fatal("expected the current floor before the scheduled change but got", minBaseFee, retrievedSpeedLimit)
+	}
+	minBaseFee, _, err = arbGasInfo.GetPricingFloorAt(callCtx, evm, 1000)
+	Require(t, err)
+	if minBaseFee.Cmp(big.NewInt(123)) != 0 {
+		t.Fatal("expected the scheduled minimum gas price but got", minBaseFee)
+	}
+}
+
 func TestGetL1PricingUpdateTime(t *testing.T) {
 	t.Parallel()
 
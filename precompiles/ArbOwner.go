@@ -8,8 +8,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/chainmetadata"
+	"github.com/offchainlabs/nitro/arbos/disabledmethods"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/programs"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -69,12 +74,250 @@ func (con ArbOwner) SetL2BaseFee(c ctx, evm mech, priceInWei huge) error {
 
 // SetMinimumL2BaseFee sets the minimum base fee needed for a transaction to succeed
 func (con ArbOwner) SetMinimumL2BaseFee(c ctx, evm mech, priceInWei huge) error {
-	if c.txProcessor.MsgIsNonMutating() && priceInWei.Sign() == 0 {
+	if priceInWei.Sign() == 0 {
 		return errors.New("minimum base fee must be nonzero")
 	}
 	return c.State.L2PricingState().SetMinBaseFeeWei(priceInWei)
 }
 
+// StartFeeHoliday drops the L2 base fee, and with it L1 data charges, to zero for duration seconds, after which
+// ArbOS restores the usual pricing. The duration is capped, and a holiday in progress can't be extended.
+func (con ArbOwner) StartFeeHoliday(c ctx, evm mech, duration uint64) error {
+	end, err := c.State.StartFeeHoliday(evm.Context.Time, duration)
+	if err != nil {
+		return err
+	}
+	return arbos.EmitFeeHolidayStartedEvent(evm, evm.Context.Time, end)
+}
+
+// EndFeeHoliday ends a fee holiday early, restoring the usual pricing
+func (con ArbOwner) EndFeeHoliday(c ctx, evm mech) error {
+	found, err := c.State.EndFeeHoliday()
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("no fee holiday in progress")
+	}
+	return arbos.EmitFeeHolidayEndedEvent(evm, evm.Context.Time)
+}
+
+// SetFeeDiscount sets the discount on L2 gas fees, in basis points, for transactions sent by or to an account.
+// Fees for posting the transactions to the parent chain aren't discounted.
+func (con ArbOwner) SetFeeDiscount(c ctx, evm mech, account addr, basisPoints uint64) error {
+	return c.State.L2PricingState().SetFeeDiscount(account, arbmath.UBips(basisPoints))
+}
+
+// ScheduleMinimumL2BaseFee schedules the minimum base fee to change at a future timestamp,
+// replacing any change already scheduled for then
+func (con ArbOwner) ScheduleMinimumL2BaseFee(c ctx, evm mech, timestamp uint64, priceInWei huge) error {
+	if timestamp <= evm.Context.Time {
+		return errors.New("minimum base fee change must be scheduled in the future")
+	}
+	if priceInWei.Sign() == 0 {
+		return errors.New("minimum base fee must be nonzero")
+	}
+	return c.State.L2PricingState().ScheduleMinBaseFee(timestamp, priceInWei)
+}
+
+// CancelScheduledMinimumL2BaseFee cancels the minimum base fee change scheduled at timestamp
+func (con ArbOwner) CancelScheduledMinimumL2BaseFee(c ctx, evm mech, timestamp uint64) error {
+	found, err := c.State.L2PricingState().CancelScheduledMinBaseFee(timestamp)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("no minimum base fee change scheduled at that time")
+	}
+	return nil
+}
+
+// SetParameterWithSunset sets a parameter until a future timestamp, when ArbOS reverts it to its previous value,
+// so risky changes can be tried out without relying on a later transaction to undo them
+func (con ArbOwner) SetParameterWithSunset(c ctx, evm mech, parameter uint64, value uint64, revertAt uint64) error {
+	if revertAt <= evm.Context.Time {
+		return errors.New("parameter sunset must be in the future")
+	}
+	return c.State.SetParameterWithSunset(arbosState.Parameter(parameter), value, revertAt)
+}
+
+// CancelParameterSunset keeps a parameter at its current value instead of reverting it
+func (con ArbOwner) CancelParameterSunset(c ctx, evm mech, parameter uint64) error {
+	found, err := c.State.CancelParameterSunset(arbosState.Parameter(parameter))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("no sunset pending for that parameter")
+	}
+	return nil
+}
+
+// FreezeSequencer asks sequencers to stop including transactions from anyone but the chain owners,
+// while they keep sequencing delayed messages so the freeze can be lifted from the parent chain
+func (con ArbOwner) FreezeSequencer(c ctx, evm mech) error {
+	frozenSince, err := c.State.SequencerFrozenSince()
+	if err != nil {
+		return err
+	}
+	if frozenSince != 0 {
+		return errors.New("sequencer is already frozen")
+	}
+	return c.State.SetSequencerFrozenSince(evm.Context.Time)
+}
+
+// UnfreezeSequencer lifts a freeze put in place by FreezeSequencer
+func (con ArbOwner) UnfreezeSequencer(c ctx, evm mech) error {
+	frozenSince, err := c.State.SequencerFrozenSince()
+	if err != nil {
+		return err
+	}
+	if frozenSince == 0 {
+		return errors.New("sequencer isn't frozen")
+	}
+	return c.State.SetSequencerFrozenSince(0)
+}
+
+// SetMaxTxSize sets the largest encoded transaction the chain accepts, or 0 to leave it to each sequencer's
+// configuration. Batch posters must be able to post blocks of this size.
+func (con ArbOwner) SetMaxTxSize(c ctx, evm mech, limit uint64) error {
+	return c.State.SetMaxTxSize(limit)
+}
+
+// SetMaxCalldataSize sets the largest transaction calldata the chain accepts, or 0 for no limit
+func (con ArbOwner) SetMaxCalldataSize(c ctx, evm mech, limit uint64) error {
+	return c.State.SetMaxCalldataSize(limit)
+}
+
+// SetMaxLogsPerTx sets the most logs a signed transaction may emit, or 0 for no limit.
+// Transactions going over it are included as failed, still paying for their gas.
+func (con ArbOwner) SetMaxLogsPerTx(c ctx, evm mech, limit uint64) error {
+	return c.State.SetMaxLogsPerTx(limit)
+}
+
+// SetMaxReturnDataSize sets the largest data a signed transaction may return, or 0 for no limit.
+// Transactions going over it are included as failed, still paying for their gas.
+func (con ArbOwner) SetMaxReturnDataSize(c ctx, evm mech, limit uint64) error {
+	return c.State.SetMaxReturnDataSize(limit)
+}
+
+// SetFeeReportInterval makes ArbOS emit a FeesCollected event every so many blocks, breaking down the fees
+// paid to the fee accounts over them, or stops the events if the interval is 0
+func (con ArbOwner) SetFeeReportInterval(c ctx, evm mech, blocks uint64) error {
+	return c.State.FeeReports().SetInterval(blocks, evm.Context.BlockNumber.Uint64())
+}
+
+// SetExpressLaneRoundTiming sets the length of the sequencer's express lane rounds in seconds, and the
+// timestamp round 0 starts at, or disables the express lane if the duration is 0
+func (con ArbOwner) SetExpressLaneRoundTiming(c ctx, evm mech, roundDuration uint64, roundOffset uint64) error {
+	return c.State.ExpressLane().SetRoundTiming(roundDuration, roundOffset)
+}
+
+// SetExpressLaneAuctioneer sets the account allowed to record each express lane round's controller
+func (con ArbOwner) SetExpressLaneAuctioneer(c ctx, evm mech, auctioneer addr) error {
+	return c.State.ExpressLane().SetAuctioneer(auctioneer)
+}
+
+// SetParentTokenExchangeRateUpdater sets the account allowed to push the exchange rate between the fee token
+// and the parent chain's gas token through ArbAggregator
+func (con ArbOwner) SetParentTokenExchangeRateUpdater(c ctx, evm mech, updater addr) error {
+	return c.State.L1PricingState().SetParentTokenRateUpdater(updater)
+}
+
+// ResetBatchPosterStats zeroes a batch poster's stats, such as after reconciling what it's owed
+func (con ArbOwner) ResetBatchPosterStats(c ctx, evm mech, batchPoster addr) error {
+	poster, err := c.State.L1PricingState().BatchPosterTable().OpenPoster(batchPoster, false)
+	if err != nil {
+		return err
+	}
+	return poster.ResetStats()
+}
+
+// ResetAllBatchPosterStats zeroes the stats of every batch poster
+func (con ArbOwner) ResetAllBatchPosterStats(c ctx, evm mech) error {
+	table := c.State.L1PricingState().BatchPosterTable()
+	posters, err := table.AllPosters(math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	for _, batchPoster := range posters {
+		poster, err := table.OpenPoster(batchPoster, false)
+		if err != nil {
+			return err
+		}
+		if err := poster.ResetStats(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFeedSigner authorizes a key to sign the sequencer feed
+func (con ArbOwner) AddFeedSigner(c ctx, evm mech, signer addr) error {
+	return c.State.FeedSigners().Add(signer)
+}
+
+// RemoveFeedSigner revokes a key's authorization to sign the sequencer feed
+func (con ArbOwner) RemoveFeedSigner(c ctx, evm mech, signer addr) error {
+	member, err := c.State.FeedSigners().IsMember(signer)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return errors.New("tried to remove non-signer")
+	}
+	return c.State.FeedSigners().Remove(signer, c.State.ArbOSVersion())
+}
+
+// SetPaymaster registers a paymaster contract to pay for the gas of transactions to a target,
+// or removes the target's paymaster if it's the zero address
+func (con ArbOwner) SetPaymaster(c ctx, evm mech, target addr, paymaster addr) error {
+	return c.State.Paymasters().Set(target, paymaster)
+}
+
+// DisablePrecompileMethod makes calls to a precompile method revert, so it can be sunset ahead of
+// the ArbOS version removing it. ArbOwner's own methods can't be disabled.
+func (con ArbOwner) DisablePrecompileMethod(c ctx, evm mech, precompile addr, method bytes4) error {
+	if precompile == con.Address {
+		return errors.New("ArbOwner methods can't be disabled")
+	}
+	if _, ok := arbosState.PrecompileMinArbOSVersions[precompile]; !ok {
+		return errors.New("not a precompile")
+	}
+	return c.State.DisabledMethods().Disable(disabledmethods.Method{Precompile: precompile, Selector: method})
+}
+
+// EnablePrecompileMethod lets a disabled precompile method be called again
+func (con ArbOwner) EnablePrecompileMethod(c ctx, evm mech, precompile addr, method bytes4) error {
+	if _, ok := arbosState.PrecompileMinArbOSVersions[precompile]; !ok {
+		return errors.New("not a precompile")
+	}
+	toEnable := disabledmethods.Method{Precompile: precompile, Selector: method}
+	disabled, err := c.State.DisabledMethods().IsDisabled(toEnable)
+	if err != nil {
+		return err
+	}
+	if !disabled {
+		return errors.New("method isn't disabled")
+	}
+	return c.State.DisabledMethods().Enable(toEnable)
+}
+
+// SetChainName sets the chain's human readable name
+func (con ArbOwner) SetChainName(c ctx, evm mech, name string) error {
+	return c.State.ChainMetadata().SetName(name)
+}
+
+// SetChainLogoURI sets where wallets and explorers can fetch the chain's logo
+func (con ArbOwner) SetChainLogoURI(c ctx, evm mech, uri string) error {
+	return c.State.ChainMetadata().SetLogoURI(uri)
+}
+
+// SetNativeTokenInfo describes the token the chain's gas is paid in
+func (con ArbOwner) SetNativeTokenInfo(c ctx, evm mech, name string, symbol string, decimals uint8) error {
+	return c.State.ChainMetadata().SetNativeToken(chainmetadata.NativeToken{Name: name, Symbol: symbol, Decimals: decimals})
+}
+
 // SetSpeedLimit sets the computational speed limit for the chain
 func (con ArbOwner) SetSpeedLimit(c ctx, evm mech, limit uint64) error {
 	return c.State.L2PricingState().SetSpeedLimitPerSecond(limit)
@@ -95,6 +338,12 @@ func (con ArbOwner) SetL2GasBacklogTolerance(c ctx, evm mech, sec uint64) error
 	return c.State.L2PricingState().SetBacklogTolerance(sec)
 }
 
+// SetL2GasTargetBips sets the rate the L2 gas backlog drains at, in basis points of the speed limit,
+// or 0 to drain it at the speed limit
+func (con ArbOwner) SetL2GasTargetBips(c ctx, evm mech, basisPoints uint64) error {
+	return c.State.L2PricingState().SetGasTargetBips(arbmath.UBips(basisPoints))
+}
+
 // GetNetworkFeeAccount gets the network fee collector
 func (con ArbOwner) GetNetworkFeeAccount(c ctx, evm mech) (addr, error) {
 	return c.State.NetworkFeeAccount()
@@ -226,6 +475,19 @@ func (con ArbOwner) SetWasmPageLimit(c ctx, evm mech, limit uint16) error {
 	return params.Save()
 }
 
+// Sets the ramp that drives exponential memory costs, scaling them relative to the initial ramp
+func (con ArbOwner) SetWasmPageRamp(c ctx, _ mech, ramp uint64) error {
+	if ramp == 0 || ramp > math.MaxUint32 {
+		return errors.New("page ramp must be a positive uint32")
+	}
+	params, err := c.State.Programs().Params()
+	if err != nil {
+		return err
+	}
+	params.PageRamp = ramp
+	return params.Save()
+}
+
 // Sets the minimum costs to invoke a program
 func (con ArbOwner) SetWasmMinInitGas(c ctx, _ mech, gas, cached uint64) error {
 	params, err := c.State.Programs().Params()
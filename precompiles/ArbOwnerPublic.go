@@ -4,16 +4,28 @@
 package precompiles
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/disabledmethods"
 )
 
 // ArbOwnerPublic precompile provides non-owners with info about the current chain owners.
 // The calls to this precompile do not require the sender be a chain owner.
 // For those that are, see ArbOwner
 type ArbOwnerPublic struct {
-	Address                    addr // 0x6b
-	ChainOwnerRectified        func(ctx, mech, addr) error
-	ChainOwnerRectifiedGasCost func(addr) (uint64, error)
+	Address                         addr // 0x6b
+	ChainOwnerRectified             func(ctx, mech, addr) error
+	ChainOwnerRectifiedGasCost      func(addr) (uint64, error)
+	FeesCollected                   func(ctx, mech, uint64, uint64, addr, huge, addr, huge, huge, huge) error
+	FeesCollectedGasCost            func(uint64, uint64, addr, huge, addr, huge, huge, huge) (uint64, error)
+	ExpressLaneControllerSet        func(ctx, mech, uint64, addr) error
+	ExpressLaneControllerSetGasCost func(uint64, addr) (uint64, error)
+	FeeHolidayStarted               func(ctx, mech, uint64, uint64) error
+	FeeHolidayStartedGasCost        func(uint64, uint64) (uint64, error)
+	FeeHolidayEnded                 func(ctx, mech, uint64) error
+	FeeHolidayEndedGasCost          func(uint64) (uint64, error)
 }
 
 // GetAllChainOwners retrieves the list of chain owners
@@ -65,3 +77,154 @@ func (con ArbOwnerPublic) GetScheduledUpgrade(c ctx, evm mech) (uint64, uint64,
 	}
 	return version, timestamp, nil
 }
+
+// GetScheduledMinimumL2BaseFees gets the pending minimum base fee changes and the timestamps they take effect at, earliest first
+func (con ArbOwnerPublic) GetScheduledMinimumL2BaseFees(c ctx, evm mech) ([]uint64, []huge, error) {
+	schedule, err := c.State.L2PricingState().MinBaseFeeSchedule()
+	if err != nil {
+		return nil, nil, err
+	}
+	timestamps := make([]uint64, 0, len(schedule))
+	prices := make([]huge, 0, len(schedule))
+	for _, entry := range schedule {
+		timestamps = append(timestamps, entry.Timestamp)
+		prices = append(prices, entry.MinBaseFeeWei)
+	}
+	return timestamps, prices, nil
+}
+
+// GetFeeHolidayEnd gets when the chain owner's fee holiday ends, or 0 if there isn't one
+func (con ArbOwnerPublic) GetFeeHolidayEnd(c ctx, evm mech) (uint64, error) {
+	return c.State.FeeHolidayEnd()
+}
+
+// GetParameterSunsets gets the parameters the chain owner changed with a sunset, when they revert, and the values they revert to
+func (con ArbOwnerPublic) GetParameterSunsets(c ctx, evm mech) ([]uint64, []uint64, []uint64, error) {
+	sunsets, err := c.State.ParameterSunsets()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	parameters := make([]uint64, 0, len(sunsets))
+	revertTimes := make([]uint64, 0, len(sunsets))
+	previousValues := make([]uint64, 0, len(sunsets))
+	for _, sunset := range sunsets {
+		parameters = append(parameters, uint64(sunset.Parameter))
+		revertTimes = append(revertTimes, sunset.RevertAt)
+		previousValues = append(previousValues, sunset.PreviousValue)
+	}
+	return parameters, revertTimes, previousValues, nil
+}
+
+// GetSequencerFrozenSince gets when the chain owner froze the sequencer, or 0 if it isn't frozen
+func (con ArbOwnerPublic) GetSequencerFrozenSince(c ctx, evm mech) (uint64, error) {
+	return c.State.SequencerFrozenSince()
+}
+
+// GetAllFeedSigners retrieves the keys the chain owner authorizes to sign the sequencer feed
+func (con ArbOwnerPublic) GetAllFeedSigners(c ctx, evm mech) ([]common.Address, error) {
+	return c.State.FeedSigners().AllMembers(65536)
+}
+
+// IsFeedSigner checks if a key is authorized to sign the sequencer feed
+func (con ArbOwnerPublic) IsFeedSigner(c ctx, evm mech, signer addr) (bool, error) {
+	return c.State.FeedSigners().IsMember(signer)
+}
+
+// IsPrecompileMethodDisabled checks if the chain owner disabled a precompile method
+func (con ArbOwnerPublic) IsPrecompileMethodDisabled(c ctx, evm mech, precompile addr, method bytes4) (bool, error) {
+	return c.State.DisabledMethods().IsDisabled(disabledmethods.Method{Precompile: precompile, Selector: method})
+}
+
+// GetAllDisabledPrecompileMethods retrieves the precompile methods the chain owner disabled,
+// as lists of precompile addresses and their corresponding method selectors
+func (con ArbOwnerPublic) GetAllDisabledPrecompileMethods(c ctx, evm mech) ([]addr, []bytes4, error) {
+	methods, err := c.State.DisabledMethods().All(65536)
+	if err != nil {
+		return nil, nil, err
+	}
+	precompiles := make([]addr, len(methods))
+	selectors := make([]bytes4, len(methods))
+	for i, method := range methods {
+		precompiles[i] = method.Precompile
+		selectors[i] = method.Selector
+	}
+	return precompiles, selectors, nil
+}
+
+// GetFeeReportInterval gets the number of blocks each FeesCollected event covers, or 0 if they're disabled
+func (con ArbOwnerPublic) GetFeeReportInterval(c ctx, evm mech) (uint64, error) {
+	return c.State.FeeReports().Interval()
+}
+
+// GetPaymaster gets the paymaster registered to pay for transactions to a target, or the zero address if there isn't one
+func (con ArbOwnerPublic) GetPaymaster(c ctx, evm mech, target addr) (addr, error) {
+	return c.State.Paymasters().Get(target)
+}
+
+// GetChainMetadata gets the chain owner's description of the chain and its native token
+func (con ArbOwnerPublic) GetChainMetadata(c ctx, evm mech) (string, string, string, string, uint8, error) {
+	metadata := c.State.ChainMetadata()
+	name, err := metadata.Name()
+	if err != nil {
+		return "", "", "", "", 0, err
+	}
+	logoURI, err := metadata.LogoURI()
+	if err != nil {
+		return "", "", "", "", 0, err
+	}
+	token, err := metadata.NativeToken()
+	if err != nil {
+		return "", "", "", "", 0, err
+	}
+	return name, logoURI, token.Name, token.Symbol, token.Decimals, nil
+}
+
+// GetEnabledFeatures gets a bitmap of the protocol features enabled on the chain:
+// stylus (1), timeboost (2), blob posting (4) and a custom fee token (8)
+func (con ArbOwnerPublic) GetEnabledFeatures(c ctx, evm mech) (uint64, error) {
+	return c.State.EnabledFeatures()
+}
+
+// GetExpressLaneRoundTiming gets the length of the sequencer's express lane rounds in seconds, and the timestamp
+// round 0 starts at. A duration of 0 means the express lane is disabled.
+func (con ArbOwnerPublic) GetExpressLaneRoundTiming(c ctx, evm mech) (uint64, uint64, error) {
+	return c.State.ExpressLane().RoundTiming()
+}
+
+// GetExpressLaneRound gets the current express lane round
+func (con ArbOwnerPublic) GetExpressLaneRound(c ctx, evm mech) (uint64, error) {
+	return c.State.ExpressLane().Round(evm.Context.Time)
+}
+
+// GetExpressLaneAuctioneer gets the account allowed to record each express lane round's controller
+func (con ArbOwnerPublic) GetExpressLaneAuctioneer(c ctx, evm mech) (addr, error) {
+	return c.State.ExpressLane().Auctioneer()
+}
+
+// GetExpressLaneController gets the controller of the current or next express lane round, or the zero address if none
+func (con ArbOwnerPublic) GetExpressLaneController(c ctx, evm mech, round uint64) (addr, error) {
+	return c.State.ExpressLane().Controller(round)
+}
+
+// SetExpressLaneController records the controller of the current or next express lane round
+// (caller must be the auctioneer or an owner)
+func (con ArbOwnerPublic) SetExpressLaneController(c ctx, evm mech, round uint64, controller addr) error {
+	expressLane := c.State.ExpressLane()
+	auctioneer, err := expressLane.Auctioneer()
+	if err != nil {
+		return err
+	}
+	if c.caller != auctioneer || auctioneer == (addr{}) {
+		isOwner, err := c.State.ChainOwners().IsMember(c.caller)
+		if err != nil {
+			return err
+		}
+		if !isOwner {
+			return errors.New("only the express lane auctioneer (or a chain owner) may set the controller")
+		}
+	}
+	if err := expressLane.SetController(round, controller, evm.Context.Time); err != nil {
+		return err
+	}
+	return con.ExpressLaneControllerSet(c, evm, round, controller)
+}
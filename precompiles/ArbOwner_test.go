@@ -6,8 +6,11 @@ package precompiles
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"math"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -19,7 +22,9 @@ import (
 
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/chainmetadata"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/programs"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 )
@@ -246,3 +251,404 @@ func TestArbInfraFeeAccount(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestArbOwnerMinimumL2BaseFeeSchedule(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	evm.Context.Time = 1000
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+
+	if err := prec.ScheduleMinimumL2BaseFee(callCtx, evm, 1000, big.NewInt(1)); err == nil {
+		Fail(t, "scheduled a minimum base fee change in the past")
+	}
+	if err := prec.ScheduleMinimumL2BaseFee(callCtx, evm, 2000, big.NewInt(0)); err == nil {
+		Fail(t, "scheduled a zero minimum base fee")
+	}
+	Require(t, prec.ScheduleMinimumL2BaseFee(callCtx, evm, 3000, big.NewInt(3)))
+	Require(t, prec.ScheduleMinimumL2BaseFee(callCtx, evm, 2000, big.NewInt(2)))
+	Require(t, prec.CancelScheduledMinimumL2BaseFee(callCtx, evm, 3000))
+	if err := prec.CancelScheduledMinimumL2BaseFee(callCtx, evm, 3000); err == nil {
+		Fail(t, "cancelled a minimum base fee change that wasn't scheduled")
+	}
+
+	timestamps, prices, err := precPublic.GetScheduledMinimumL2BaseFees(callCtx, evm)
+	Require(t, err)
+	if len(timestamps) != 1 || timestamps[0] != 2000 || len(prices) != 1 || prices[0].Cmp(big.NewInt(2)) != 0 {
+		Fail(t, "unexpected schedule", timestamps, prices)
+	}
+}
+
+func TestArbOwnerParameterSunset(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	evm.Context.Time = 1000
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+	parameter := uint64(arbosState.ParameterMaxTxSize)
+
+	if err := prec.SetParameterWithSunset(callCtx, evm, parameter, 200000, 1000); err == nil {
+		Fail(t, "set a parameter with a sunset in the past")
+	}
+	Require(t, prec.SetParameterWithSunset(callCtx, evm, parameter, 200000, 2000))
+	maxTxSize, err := callCtx.State.MaxTxSize()
+	Require(t, err)
+	if maxTxSize != 200000 {
+		Fail(t, "parameter wasn't set", maxTxSize)
+	}
+	parameters, revertTimes, previousValues, err := precPublic.GetParameterSunsets(callCtx, evm)
+	Require(t, err)
+	if len(parameters) != 1 || parameters[0] != parameter || revertTimes[0] != 2000 || previousValues[0] != 0 {
+		Fail(t, "unexpected sunsets", parameters, revertTimes, previousValues)
+	}
+
+	Require(t, prec.CancelParameterSunset(callCtx, evm, parameter))
+	if err := prec.CancelParameterSunset(callCtx, evm, parameter); err == nil {
+		Fail(t, "cancelled a sunset that wasn't pending")
+	}
+}
+
+func TestArbOwnerFreezeSequencer(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	evm.Context.Time = 1000
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+
+	if err := prec.UnfreezeSequencer(callCtx, evm); err == nil {
+		Fail(t, "unfroze a sequencer that wasn't frozen")
+	}
+	Require(t, prec.FreezeSequencer(callCtx, evm))
+	if err := prec.FreezeSequencer(callCtx, evm); err == nil {
+		Fail(t, "froze an already frozen sequencer")
+	}
+	frozenSince, err := precPublic.GetSequencerFrozenSince(callCtx, evm)
+	Require(t, err)
+	if frozenSince != 1000 {
+		Fail(t, "unexpected freeze time", frozenSince)
+	}
+	Require(t, prec.UnfreezeSequencer(callCtx, evm))
+	frozenSince, err = precPublic.GetSequencerFrozenSince(callCtx, evm)
+	Require(t, err)
+	if frozenSince != 0 {
+		Fail(t, "sequencer still frozen", frozenSince)
+	}
+}
+
+func TestArbOwnerFeedSigners(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+
+	signer := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+	if err := prec.RemoveFeedSigner(callCtx, evm, signer); err == nil {
+		Fail(t, "removed a feed signer that wasn't added")
+	}
+	Require(t, prec.AddFeedSigner(callCtx, evm, signer))
+	isSigner, err := precPublic.IsFeedSigner(callCtx, evm, signer)
+	Require(t, err)
+	if !isSigner {
+		Fail(t, "added feed signer not found")
+	}
+	signers, err := precPublic.GetAllFeedSigners(callCtx, evm)
+	Require(t, err)
+	if len(signers) != 1 || signers[0] != signer {
+		Fail(t, "unexpected feed signers", signers)
+	}
+	Require(t, prec.RemoveFeedSigner(callCtx, evm, signer))
+	signers, err = precPublic.GetAllFeedSigners(callCtx, evm)
+	Require(t, err)
+	if len(signers) != 0 {
+		Fail(t, "feed signers left after removal", signers)
+	}
+}
+
+func TestArbOwnerChainMetadata(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+
+	name, logoURI, tokenName, tokenSymbol, decimals, err := precPublic.GetChainMetadata(callCtx, evm)
+	Require(t, err)
+	if name != "" || logoURI != "" || tokenName != "" || tokenSymbol != "" || decimals != 0 {
+		Fail(t, "chain metadata should start out empty")
+	}
+
+	Require(t, prec.SetChainName(callCtx, evm, "Test Chain"))
+	Require(t, prec.SetChainLogoURI(callCtx, evm, "ipfs://logo"))
+	Require(t, prec.SetNativeTokenInfo(callCtx, evm, "Test Token", "TST", 6))
+	if err := prec.SetChainName(callCtx, evm, strings.Repeat("a", chainmetadata.MaxFieldLength+1)); err == nil {
+		Fail(t, "set a chain name over the length limit")
+	}
+
+	name, logoURI, tokenName, tokenSymbol, decimals, err = precPublic.GetChainMetadata(callCtx, evm)
+	Require(t, err)
+	if name != "Test Chain" || logoURI != "ipfs://logo" || tokenName != "Test Token" || tokenSymbol != "TST" || decimals != 6 {
+		Fail(t, "unexpected chain metadata", name, logoURI, tokenName, tokenSymbol, decimals)
+	}
+}
+
+func TestArbOwnerPaymasters(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+	target := testhelpers.RandomAddress()
+	paymaster := testhelpers.RandomAddress()
+
+	Require(t, prec.SetPaymaster(callCtx, evm, target, paymaster))
+	got, err := precPublic.GetPaymaster(callCtx, evm, target)
+	Require(t, err)
+	if got != paymaster {
+		Fail(t, "unexpected paymaster", got)
+	}
+	Require(t, prec.SetPaymaster(callCtx, evm, target, common.Address{}))
+	got, err = precPublic.GetPaymaster(callCtx, evm, target)
+	Require(t, err)
+	if got != (common.Address{}) {
+		Fail(t, "paymaster not removed", got)
+	}
+}
+
+func TestArbOwnerTxSizeLimits(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	gasInfo := &ArbGasInfo{}
+
+	size, err := gasInfo.GetMaxTxSize(callCtx, evm)
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "tx size limit set by default", size)
+	}
+	if err := prec.SetMaxTxSize(callCtx, evm, arbosState.MinTxSizeLimit-1); err == nil {
+		Fail(t, "set a tx size limit below the minimum")
+	}
+	if err := prec.SetMaxTxSize(callCtx, evm, arbosState.MaxTxSizeLimit+1); err == nil {
+		Fail(t, "set a tx size limit above the maximum")
+	}
+	Require(t, prec.SetMaxTxSize(callCtx, evm, 80000))
+	size, err = gasInfo.GetMaxTxSize(callCtx, evm)
+	Require(t, err)
+	if size != 80000 {
+		Fail(t, "unexpected tx size limit", size)
+	}
+
+	if err := prec.SetMaxCalldataSize(callCtx, evm, arbosState.MinCalldataSizeLimit-1); err == nil {
+		Fail(t, "set a calldata size limit below the minimum")
+	}
+	if err := prec.SetMaxCalldataSize(callCtx, evm, arbosState.MaxTxSizeLimit+1); err == nil {
+		Fail(t, "set a calldata size limit above the maximum")
+	}
+	Require(t, prec.SetMaxCalldataSize(callCtx, evm, 64000))
+	size, err = gasInfo.GetMaxCalldataSize(callCtx, evm)
+	Require(t, err)
+	if size != 64000 {
+		Fail(t, "unexpected calldata size limit", size)
+	}
+	Require(t, prec.SetMaxCalldataSize(callCtx, evm, 0))
+	size, err = gasInfo.GetMaxCalldataSize(callCtx, evm)
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "calldata size limit not unset", size)
+	}
+}
+
+func TestArbOwnerTxResultLimits(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	gasInfo := &ArbGasInfo{}
+
+	if err := prec.SetMaxLogsPerTx(callCtx, evm, arbosState.MinLogsPerTxLimit-1); err == nil {
+		Fail(t, "set a logs per tx limit below the minimum")
+	}
+	if err := prec.SetMaxReturnDataSize(callCtx, evm, arbosState.MinReturnDataSizeLimit-1); err == nil {
+		Fail(t, "set a return data size limit below the minimum")
+	}
+	Require(t, callCtx.State.CheckTxResult(100000, 1<<20))
+
+	Require(t, prec.SetMaxLogsPerTx(callCtx, evm, 1000))
+	Require(t, prec.SetMaxReturnDataSize(callCtx, evm, 10000))
+	logs, err := gasInfo.GetMaxLogsPerTx(callCtx, evm)
+	Require(t, err)
+	size, err := gasInfo.GetMaxReturnDataSize(callCtx, evm)
+	Require(t, err)
+	if logs != 1000 || size != 10000 {
+		Fail(t, "unexpected tx result limits", logs, size)
+	}
+	Require(t, callCtx.State.CheckTxResult(1000, 10000))
+	if err := callCtx.State.CheckTxResult(1001, 0); !errors.Is(err, arbosState.ErrTooManyLogs) {
+		Fail(t, "too many logs weren't rejected", err)
+	}
+	if err := callCtx.State.CheckTxResult(0, 10001); !errors.Is(err, arbosState.ErrReturnDataTooLarge) {
+		Fail(t, "too much return data wasn't rejected", err)
+	}
+
+	Require(t, prec.SetMaxLogsPerTx(callCtx, evm, 0))
+	Require(t, prec.SetMaxReturnDataSize(callCtx, evm, 0))
+	Require(t, callCtx.State.CheckTxResult(100000, 1<<20))
+}
+
+func TestArbOwnerDisablePrecompileMethod(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{Address: types.ArbOwnerAddress}
+	pub := &ArbOwnerPublic{}
+
+	contracts := Precompiles()
+	gasInfo := contracts[types.ArbGasInfoAddress].Precompile()
+	selector := gasInfo.GetMethodID("GetMinimumGasPrice")
+	gasSupplied := uint64(1000000)
+	var gasLeft uint64
+	call := func() ([]byte, error) {
+		output, left, err := contracts[types.ArbGasInfoAddress].Call(
+			selector[:], types.ArbGasInfoAddress, types.ArbGasInfoAddress, caller, big.NewInt(0), true, gasSupplied, evm,
+		)
+		gasLeft = left
+		return output, err
+	}
+	if output, err := call(); bytes.Equal(output, methodDisabledRevert) {
+		Fail(t, "method disabled by default", err)
+	}
+	emptyGasLeft := gasLeft
+
+	ownerSelector := contracts[types.ArbOwnerAddress].Precompile().GetMethodID("EnablePrecompileMethod")
+	if err := prec.DisablePrecompileMethod(callCtx, evm, types.ArbOwnerAddress, ownerSelector); err == nil {
+		Fail(t, "disabled an ArbOwner method")
+	}
+	if err := prec.DisablePrecompileMethod(callCtx, evm, testhelpers.RandomAddress(), selector); err == nil {
+		Fail(t, "disabled a method of an address which isn't a precompile")
+	}
+
+	Require(t, prec.DisablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, selector))
+	output, err := call()
+	if err == nil || !bytes.Equal(output, methodDisabledRevert) {
+		Fail(t, "disabled method didn't revert with the expected error", output, err)
+	}
+	if gasLeft == 0 || gasLeft >= gasSupplied {
+		Fail(t, "disabled method should charge a fixed cost and return the rest", gasLeft)
+	}
+	disabled, err := pub.IsPrecompileMethodDisabled(callCtx, evm, types.ArbGasInfoAddress, selector)
+	Require(t, err)
+	if !disabled {
+		Fail(t, "method not reported as disabled")
+	}
+	precompiles, selectors, err := pub.GetAllDisabledPrecompileMethods(callCtx, evm)
+	Require(t, err)
+	if len(precompiles) != 1 || precompiles[0] != types.ArbGasInfoAddress || selectors[0] != selector {
+		Fail(t, "unexpected disabled methods", precompiles, selectors)
+	}
+
+	if err := prec.EnablePrecompileMethod(callCtx, evm, testhelpers.RandomAddress(), selector); err == nil {
+		Fail(t, "enabled a method of an address which isn't a precompile")
+	}
+	otherSelector := gasInfo.GetMethodID("GetL1BaseFeeEstimate")
+	if err := prec.EnablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, otherSelector); err == nil {
+		Fail(t, "enabled a method which wasn't disabled")
+	}
+	Require(t, prec.EnablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, selector))
+	if output, err := call(); bytes.Equal(output, methodDisabledRevert) {
+		Fail(t, "method still disabled after being enabled", err)
+	}
+	if err := prec.EnablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, selector); err == nil {
+		Fail(t, "enabled a method twice")
+	}
+
+	// Methods are only looked up while some are disabled
+	Require(t, prec.DisablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, otherSelector))
+	_, err = call()
+	Require(t, err)
+	if gasLeft >= emptyGasLeft {
+		Fail(t, "looking up whether the method is disabled wasn't charged", gasLeft, emptyGasLeft)
+	}
+	Require(t, prec.EnablePrecompileMethod(callCtx, evm, types.ArbGasInfoAddress, otherSelector))
+	_, err = call()
+	Require(t, err)
+	if gasLeft != emptyGasLeft {
+		Fail(t, "checking for disabled methods was charged with none disabled", gasLeft, emptyGasLeft)
+	}
+	precompiles, _, err = pub.GetAllDisabledPrecompileMethods(callCtx, evm)
+	Require(t, err)
+	if len(precompiles) != 0 {
+		Fail(t, "methods still disabled", precompiles)
+	}
+}
+
+func TestArbOwnerWasmPageRamp(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	arbWasm := &ArbWasm{}
+
+	ramp, err := arbWasm.PageRamp(callCtx, evm)
+	Require(t, err)
+	if ramp != programs.InitialPageRamp {
+		Fail(t, "unexpected initial page ramp", ramp)
+	}
+
+	if err := prec.SetWasmPageRamp(callCtx, evm, 0); err == nil {
+		Fail(t, "set a zero page ramp")
+	}
+	if err := prec.SetWasmPageRamp(callCtx, evm, math.MaxUint32+1); err == nil {
+		Fail(t, "set a page ramp that doesn't fit in a uint32")
+	}
+	Require(t, prec.SetWasmPageRamp(callCtx, evm, 2*programs.InitialPageRamp))
+	ramp, err = arbWasm.PageRamp(callCtx, evm)
+	Require(t, err)
+	if ramp != 2*programs.InitialPageRamp {
+		Fail(t, "unexpected page ramp", ramp)
+	}
+	pageLimit, err := arbWasm.PageLimit(callCtx, evm)
+	Require(t, err)
+	blockCacheSize, err := arbWasm.BlockCacheSize(callCtx, evm)
+	Require(t, err)
+	if pageLimit != 128 || blockCacheSize != 32 {
+		Fail(t, "setting the page ramp changed other params", pageLimit, blockCacheSize)
+	}
+}
+
+func TestArbOwnerPublicEnabledFeatures(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	precPublic := &ArbOwnerPublic{}
+
+	features, err := precPublic.GetEnabledFeatures(callCtx, evm)
+	Require(t, err)
+	if features != arbosState.FeatureStylus|arbosState.FeatureBlobPosting {
+		Fail(t, "unexpected features", features)
+	}
+	Require(t, prec.SetExpressLaneRoundTiming(callCtx, evm, 60, 0))
+	Require(t, callCtx.State.L1PricingState().SetParentTokenExchangeRate(big.NewInt(2e18)))
+	features, err = precPublic.GetEnabledFeatures(callCtx, evm)
+	Require(t, err)
+	all := arbosState.FeatureStylus | arbosState.FeatureTimeboost | arbosState.FeatureBlobPosting | arbosState.FeatureCustomFeeToken
+	if features != all {
+		Fail(t, "unexpected features", features)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
@@ -47,87 +48,122 @@ func (con ArbRetryableTx) oldNotFoundError(c ctx) error {
 
 // Redeem schedules an attempt to redeem the retryable, donating all of the call's gas to the redeem attempt
 func (con ArbRetryableTx) Redeem(c ctx, evm mech, ticketId bytes32) (bytes32, error) {
-	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
-		return bytes32{}, ErrSelfModifyingRetryable
-	}
-	retryableState := c.State.RetryableState()
-	byteCount, err := retryableState.RetryableSizeBytes(ticketId, evm.Context.Time)
+	// Result is 32 bytes long which is 1 word
+	retryTxHashes, err := con.redeemTickets(c, evm, []bytes32{ticketId}, 1)
 	if err != nil {
 		return hash{}, err
 	}
-	writeBytes := arbmath.WordsForBytes(byteCount)
-	if err := c.Burn(params.SloadGas * writeBytes); err != nil {
-		return hash{}, err
-	}
+	return retryTxHashes[0], nil
+}
 
-	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
-	if err != nil {
-		return hash{}, err
-	}
-	if retryable == nil {
-		return hash{}, con.oldNotFoundError(c)
+// RedeemMultiple schedules an attempt to redeem each of the retryables, splitting the call's gas evenly between them
+func (con ArbRetryableTx) RedeemMultiple(c ctx, evm mech, ticketIds []bytes32) ([]bytes32, error) {
+	if len(ticketIds) == 0 {
+		return nil, errors.New("no retryables to redeem")
 	}
-	nextNonce, err := retryable.IncrementNumTries()
-	if err != nil {
-		return hash{}, err
+	seen := make(map[bytes32]struct{}, len(ticketIds))
+	for _, ticketId := range ticketIds {
+		if _, ok := seen[ticketId]; ok {
+			return nil, errors.New("retryable listed more than once")
+		}
+		seen[ticketId] = struct{}{}
 	}
-	nonce := nextNonce - 1
+	// Result is an offset, a length, and a word per hash
+	return con.redeemTickets(c, evm, ticketIds, 2+uint64(len(ticketIds)))
+}
 
+func (con ArbRetryableTx) redeemTickets(c ctx, evm mech, ticketIds []bytes32, resultWords uint64) ([]bytes32, error) {
+	retryableState := c.State.RetryableState()
+	retryTxInners := make([]*types.ArbitrumRetryTx, len(ticketIds))
+	nonces := make([]uint64, len(ticketIds))
 	maxRefund := new(big.Int).Exp(common.Big2, common.Big256, nil)
 	maxRefund.Sub(maxRefund, common.Big1)
-	retryTxInner, err := retryable.MakeTx(
-		evm.ChainConfig().ChainID,
-		nonce,
-		evm.Context.BaseFee,
-		0, // will fill this in below
-		ticketId,
-		c.caller,
-		maxRefund,
-		common.Big0,
-	)
-	if err != nil {
-		return hash{}, err
+	for i, ticketId := range ticketIds {
+		if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
+			return nil, ErrSelfModifyingRetryable
+		}
+		byteCount, err := retryableState.RetryableSizeBytes(ticketId, evm.Context.Time)
+		if err != nil {
+			return nil, err
+		}
+		writeBytes := arbmath.WordsForBytes(byteCount)
+		if err := c.Burn(params.SloadGas * writeBytes); err != nil {
+			return nil, err
+		}
+
+		retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+		if err != nil {
+			return nil, err
+		}
+		if retryable == nil {
+			return nil, con.oldNotFoundError(c)
+		}
+		nextNonce, err := retryable.IncrementNumTries()
+		if err != nil {
+			return nil, err
+		}
+		nonces[i] = nextNonce - 1
+
+		retryTxInners[i], err = retryable.MakeTx(
+			evm.ChainConfig().ChainID,
+			nonces[i],
+			evm.Context.BaseFee,
+			0, // will fill this in below
+			ticketId,
+			c.caller,
+			maxRefund,
+			common.Big0,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// figure out how much gas the event issuance will cost, and reduce the donated gas amount in the event
 	//     by that much, so that we'll donate the correct amount of gas
 	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
 	if err != nil {
-		return hash{}, err
+		return nil, err
 	}
-	// Result is 32 bytes long which is 1 word
-	gasCostToReturnResult := params.CopyGas
+	gasCostToReturnResult := params.CopyGas * resultWords
 	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
-	futureGasCosts := eventCost + gasCostToReturnResult + gasPoolUpdateCost
+	count := uint64(len(ticketIds))
+	futureGasCosts := count*(eventCost+gasPoolUpdateCost) + gasCostToReturnResult
 	if c.gasLeft < futureGasCosts {
-		return hash{}, c.Burn(futureGasCosts) // this will error
+		return nil, c.Burn(futureGasCosts) // this will error
 	}
-	gasToDonate := c.gasLeft - futureGasCosts
+	gasToDonate := (c.gasLeft - futureGasCosts) / count
 	if gasToDonate < params.TxGas {
-		return hash{}, errors.New("not enough gas to run redeem attempt")
+		return nil, errors.New("not enough gas to run redeem attempt")
 	}
 
-	// fix up the gas in the retry
-	retryTxInner.Gas = gasToDonate
+	retryTxHashes := make([]bytes32, len(ticketIds))
+	for i, ticketId := range ticketIds {
+		// fix up the gas in the retry
+		retryTxInners[i].Gas = gasToDonate
 
-	retryTx := types.NewTx(retryTxInner)
-	retryTxHash := retryTx.Hash()
+		retryTx := types.NewTx(retryTxInners[i])
+		retryTxHashes[i] = retryTx.Hash()
 
-	err = con.RedeemScheduled(c, evm, ticketId, retryTxHash, nonce, gasToDonate, c.caller, maxRefund, common.Big0)
-	if err != nil {
-		return hash{}, err
-	}
+		err = con.RedeemScheduled(c, evm, ticketId, retryTxHashes[i], nonces[i], gasToDonate, c.caller, maxRefund, common.Big0)
+		if err != nil {
+			return nil, err
+		}
 
-	// To prepare for the enqueued retry event, we burn gas here, adding it back to the pool right before retrying.
-	// The gas payer for this tx will get a credit for the wei they paid for this gas when retrying.
-	// We burn as much gas as we can, leaving only enough to pay for copying out the return data.
-	if err := c.Burn(gasToDonate); err != nil {
-		return hash{}, err
-	}
+		// To prepare for the enqueued retry event, we burn gas here, adding it back to the pool right before retrying.
+		// The gas payer for this tx will get a credit for the wei they paid for this gas when retrying.
+		// We burn as much gas as we can, leaving only enough to pay for the remaining events and copying out the return data.
+		if err := c.Burn(gasToDonate); err != nil {
+			return nil, err
+		}
 
-	// Add the gasToDonate back to the gas pool: the retryable attempt will then consume it.
-	// This ensures that the gas pool has enough gas to run the retryable attempt.
-	return retryTxHash, c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast[int64](gasToDonate))
+		// Add the gasToDonate back to the gas pool: the retryable attempt will then consume it.
+		// This ensures that the gas pool has enough gas to run the retryable attempt.
+		if err := c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast[int64](gasToDonate)); err != nil {
+			return nil, err
+		}
+	}
+	return retryTxHashes, nil
 }
 
 // GetLifetime gets the default lifetime period a retryable has at creation
@@ -215,13 +251,35 @@ func (con ArbRetryableTx) Cancel(c ctx, evm mech, ticketId bytes32) error {
 	}
 
 	// no refunds are given for deleting retryables because they use rented space
-	_, err = retryableState.DeleteRetryable(ticketId, evm, util.TracingDuringEVM)
+	_, err = retryableState.DeleteRetryable(ticketId, evm, util.TracingDuringEVM, c.State.ArbOSVersion() >= arbosState.ArbosVersion_40)
 	if err != nil {
 		return err
 	}
 	return con.Canceled(c, evm, ticketId)
 }
 
+// GetRetryablesByBeneficiary lists a page of the live tickets of a beneficiary created since ArbOS 40, with their timeouts
+// and callvalues. The page covers up to limit entries from offset, out of the returned total, and skips expired tickets.
+func (con ArbRetryableTx) GetRetryablesByBeneficiary(c ctx, evm mech, beneficiary addr, offset uint64, limit uint64) ([]bytes32, []uint64, []huge, uint64, error) {
+	retryables, total, err := c.State.RetryableState().TicketsByBeneficiary(beneficiary, evm.Context.Time, offset, limit)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	ticketIds := make([]bytes32, len(retryables))
+	timeouts := make([]uint64, len(retryables))
+	callvalues := make([]huge, len(retryables))
+	for i, retryable := range retryables {
+		ticketIds[i] = retryable.Id()
+		if timeouts[i], err = retryable.CalculateTimeout(); err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if callvalues[i], err = retryable.Callvalue(); err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
+	return ticketIds, timeouts, callvalues, total, nil
+}
+
 func (con ArbRetryableTx) GetCurrentRedeemer(c ctx, evm mech) (common.Address, error) {
 	if c.txProcessor.CurrentRefundTo != nil {
 		return *c.txProcessor.CurrentRefundTo, nil
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/storage"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -60,6 +61,7 @@ func TestRetryableRedeem(t *testing.T) {
 		callvalue,
 		beneficiary,
 		calldata,
+		precompileCtx.State.ArbOSVersion() >= arbosState.ArbosVersion_40,
 	)
 	Require(t, err)
 
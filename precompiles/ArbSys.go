@@ -23,6 +23,9 @@ type ArbSys struct {
 	SendMerkleUpdateGasCost func(huge, bytes32, huge) (uint64, error)
 	InvalidBlockNumberError func(huge, huge) error
 
+	L2ToL1TxCancelled        func(ctx, mech, addr, bytes32) error
+	L2ToL1TxCancelledGasCost func(addr, bytes32) (uint64, error)
+
 	// deprecated event
 	L2ToL1Transaction        func(ctx, mech, addr, addr, huge, huge, huge, huge, huge, huge, huge, []byte) error
 	L2ToL1TransactionGasCost func(addr, addr, huge, huge, huge, huge, huge, huge, huge, []byte) (uint64, error)
@@ -116,15 +119,7 @@ func (con *ArbSys) SendTxToL1(c ctx, evm mech, value huge, destination addr, cal
 	arbosState := c.State
 	var t big.Int
 	t.SetUint64(evm.Context.Time)
-	sendHash, err := arbosState.KeccakHash(
-		c.caller.Bytes(),
-		destination.Bytes(),
-		arbmath.U256Bytes(evm.Context.BlockNumber),
-		arbmath.U256Bytes(bigL1BlockNum),
-		arbmath.U256Bytes(&t),
-		common.BigToHash(value).Bytes(),
-		calldataForL1,
-	)
+	sendHash, err := con.l2ToL1TxHash(c, c.caller, destination, evm.Context.BlockNumber, bigL1BlockNum, &t, value, calldataForL1)
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +180,50 @@ func (con *ArbSys) SendTxToL1(c ctx, evm mech, value huge, destination addr, cal
 	return sendHash.Big(), err
 }
 
+// CancelL2ToL1Tx marks an outbox message the caller sent as cancelled, so executors know not to execute it.
+// A message's hash commits to its sender, so only the sender can cancel it. The outbox merkle tree can't
+// change, since its root may already be asserted to the parent chain, nor is the burnt callvalue refunded.
+func (con *ArbSys) CancelL2ToL1Tx(
+	c ctx, evm mech, destination addr, arbBlockNum huge, ethBlockNum huge, timestamp huge, value huge, data []byte,
+) (bytes32, error) {
+	if arbBlockNum.Cmp(evm.Context.BlockNumber) > 0 {
+		return bytes32{}, errors.New("message is from a future block")
+	}
+	sendHash, err := con.l2ToL1TxHash(c, c.caller, destination, arbBlockNum, ethBlockNum, timestamp, value, data)
+	if err != nil {
+		return bytes32{}, err
+	}
+	cancelled := c.State.CancelledSends()
+	alreadyCancelled, err := cancelled.GetUint64(sendHash)
+	if err != nil || alreadyCancelled != 0 {
+		return sendHash, err
+	}
+	if err := cancelled.SetUint64(sendHash, 1); err != nil {
+		return bytes32{}, err
+	}
+	return sendHash, con.L2ToL1TxCancelled(c, evm, c.caller, sendHash)
+}
+
+// IsL2ToL1TxCancelled checks if the outbox message with the given hash was cancelled by its sender
+func (con *ArbSys) IsL2ToL1TxCancelled(c ctx, evm mech, hash bytes32) (bool, error) {
+	cancelled, err := c.State.CancelledSends().GetUint64(hash)
+	return cancelled != 0, err
+}
+
+func (con *ArbSys) l2ToL1TxHash(
+	c ctx, sender addr, destination addr, arbBlockNum huge, ethBlockNum huge, timestamp huge, value huge, data []byte,
+) (bytes32, error) {
+	return c.State.KeccakHash(
+		sender.Bytes(),
+		destination.Bytes(),
+		arbmath.U256Bytes(arbBlockNum),
+		arbmath.U256Bytes(ethBlockNum),
+		arbmath.U256Bytes(timestamp),
+		common.BigToHash(value).Bytes(),
+		data,
+	)
+}
+
 // SendMerkleTreeState gets the root, size, and partials of the outbox Merkle tree state (caller must be the 0 address)
 func (con ArbSys) SendMerkleTreeState(c ctx, evm mech) (huge, bytes32, []bytes32, error) {
 	if c.caller != (addr{}) {
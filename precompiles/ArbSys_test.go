@@ -0,0 +1,67 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestCancelL2ToL1Tx(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	cancelledEvents := 0
+	arbSys := ArbSys{
+		L2ToL1TxCancelled: func(ctx, mech, addr, bytes32) error {
+			cancelledEvents++
+			return nil
+		},
+	}
+	sender := testhelpers.RandomAddress()
+	other := testhelpers.RandomAddress()
+	destination := testhelpers.RandomAddress()
+	senderCtx := testContext(sender, evm)
+	otherCtx := testContext(other, evm)
+	data := []byte{1, 2, 3}
+	zero := big.NewInt(0)
+	value := big.NewInt(1e18)
+
+	// Another caller can't cancel the sender's message, since the hash commits to the caller
+	otherHash, err := arbSys.CancelL2ToL1Tx(otherCtx, evm, destination, zero, zero, zero, value, data)
+	Require(t, err)
+	hash, err := arbSys.l2ToL1TxHash(senderCtx, sender, destination, zero, zero, zero, value, data)
+	Require(t, err)
+	if otherHash == hash {
+		Fail(t, "different senders produced the same hash")
+	}
+	cancelled, err := arbSys.IsL2ToL1TxCancelled(senderCtx, evm, hash)
+	Require(t, err)
+	if cancelled {
+		Fail(t, "message cancelled by someone other than its sender")
+	}
+
+	cancelledHash, err := arbSys.CancelL2ToL1Tx(senderCtx, evm, destination, zero, zero, zero, value, data)
+	Require(t, err)
+	if cancelledHash != hash {
+		Fail(t, "unexpected hash", cancelledHash, hash)
+	}
+	cancelled, err = arbSys.IsL2ToL1TxCancelled(senderCtx, evm, hash)
+	Require(t, err)
+	if !cancelled {
+		Fail(t, "message not cancelled")
+	}
+	// Cancelling again is a no-op
+	_, err = arbSys.CancelL2ToL1Tx(senderCtx, evm, destination, zero, zero, zero, value, data)
+	Require(t, err)
+	if cancelledEvents != 2 {
+		Fail(t, "unexpected number of cancellation events", cancelledEvents)
+	}
+
+	if _, err := arbSys.CancelL2ToL1Tx(senderCtx, evm, destination, big.NewInt(1), zero, zero, value, data); err == nil {
+		Fail(t, "cancelled a message from a future block")
+	}
+}
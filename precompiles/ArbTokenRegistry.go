@@ -0,0 +1,62 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import "github.com/ethereum/go-ethereum/common"
+
+var ArbTokenRegistryAddress = common.HexToAddress("0x73")
+
+// ArbTokenRegistry provides the chain owner's canonical mappings between parent chain tokens and
+// their child chain counterparts, so bridges and wallets can look them up on-chain.
+type ArbTokenRegistry struct {
+	Address addr // 0x73
+
+	TokenRegistered          func(ctx, mech, addr, addr) error
+	TokenRegisteredGasCost   func(addr, addr) (uint64, error)
+	TokenUnregistered        func(ctx, mech, addr, addr) error
+	TokenUnregisteredGasCost func(addr, addr) (uint64, error)
+}
+
+// Maps a parent chain token to a child chain token. Caller must be a chain owner.
+func (con ArbTokenRegistry) RegisterToken(c ctx, evm mech, parentToken addr, childToken addr) error {
+	if !con.isOwner(c) {
+		return c.BurnOut()
+	}
+	if err := c.State.TokenRegistry().Register(parentToken, childToken); err != nil {
+		return err
+	}
+	return con.TokenRegistered(c, evm, parentToken, childToken)
+}
+
+// Removes a parent chain token's mapping. Caller must be a chain owner.
+func (con ArbTokenRegistry) UnregisterToken(c ctx, evm mech, parentToken addr) error {
+	if !con.isOwner(c) {
+		return c.BurnOut()
+	}
+	childToken, err := c.State.TokenRegistry().Unregister(parentToken, c.State.ArbOSVersion())
+	if err != nil {
+		return err
+	}
+	return con.TokenUnregistered(c, evm, parentToken, childToken)
+}
+
+// Gets the child chain token for a parent chain token, or the zero address if it isn't registered.
+func (con ArbTokenRegistry) GetChildChainToken(c ctx, _ mech, parentToken addr) (addr, error) {
+	return c.State.TokenRegistry().ChildToken(parentToken)
+}
+
+// Gets the parent chain token for a child chain token, or the zero address if it isn't registered.
+func (con ArbTokenRegistry) GetParentChainToken(c ctx, _ mech, childToken addr) (addr, error) {
+	return c.State.TokenRegistry().ParentToken(childToken)
+}
+
+// Retrieves every registered parent chain token.
+func (con ArbTokenRegistry) GetAllParentChainTokens(c ctx, _ mech) ([]addr, error) {
+	return c.State.TokenRegistry().AllParentTokens(65536)
+}
+
+func (con ArbTokenRegistry) isOwner(c ctx) bool {
+	owner, err := c.State.ChainOwners().IsMember(c.caller)
+	return owner && err == nil
+}
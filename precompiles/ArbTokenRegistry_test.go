@@ -0,0 +1,66 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestArbTokenRegistry(t *testing.T) {
+	version := arbosState.ArbosVersion_40
+	evm := newMockEVMForTestingWithVersion(&version)
+	owner := testhelpers.RandomAddress()
+	ownerCtx := testContext(owner, evm)
+	Require(t, ownerCtx.State.ChainOwners().Add(owner))
+	userCtx := testContext(testhelpers.RandomAddress(), evm)
+
+	var emitted []string
+	prec := &ArbTokenRegistry{
+		TokenRegistered: func(ctx, mech, addr, addr) error {
+			emitted = append(emitted, "registered")
+			return nil
+		},
+		TokenUnregistered: func(ctx, mech, addr, addr) error {
+			emitted = append(emitted, "unregistered")
+			return nil
+		},
+	}
+	parentToken := testhelpers.RandomAddress()
+	childToken := testhelpers.RandomAddress()
+
+	if err := prec.RegisterToken(userCtx, evm, parentToken, childToken); err == nil {
+		Fail(t, "non-owner registered a token")
+	}
+	Require(t, prec.RegisterToken(ownerCtx, evm, parentToken, childToken))
+	child, err := prec.GetChildChainToken(userCtx, evm, parentToken)
+	Require(t, err)
+	parent, err := prec.GetParentChainToken(userCtx, evm, childToken)
+	Require(t, err)
+	if child != childToken || parent != parentToken {
+		Fail(t, "wrong mappings", child, parent)
+	}
+	all, err := prec.GetAllParentChainTokens(userCtx, evm)
+	Require(t, err)
+	if len(all) != 1 || all[0] != parentToken {
+		Fail(t, "wrong registered tokens", all)
+	}
+
+	if err := prec.UnregisterToken(userCtx, evm, parentToken); err == nil {
+		Fail(t, "non-owner unregistered a token")
+	}
+	Require(t, prec.UnregisterToken(ownerCtx, evm, parentToken))
+	child, err = prec.GetChildChainToken(userCtx, evm, parentToken)
+	Require(t, err)
+	if child != (common.Address{}) {
+		Fail(t, "token still registered", child)
+	}
+	if len(emitted) != 2 {
+		Fail(t, "expected 2 events but got", emitted)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/util"
 	pgen "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/rpcerrors"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -539,7 +540,18 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbGasInfo.methodsByName["GetL1PricingFundsDueForRewards"].arbosVersion = 20
 	ArbGasInfo.methodsByName["GetL1PricingUnitsSinceUpdate"].arbosVersion = 20
 	ArbGasInfo.methodsByName["GetLastL1PricingSurplus"].arbosVersion = 20
-	insert(MakePrecompile(pgen.ArbAggregatorMetaData, &ArbAggregator{Address: types.ArbAggregatorAddress}))
+	ArbGasInfo.methodsByName["GetParentTokenExchangeRate"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetMaxTxSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetMaxCalldataSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetMaxLogsPerTx"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetMaxReturnDataSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetFeeDiscount"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetGasTargetBips"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetBatchPosterStats"].arbosVersion = arbosState.ArbosVersion_40
+	ArbGasInfo.methodsByName["GetPricingFloorAt"].arbosVersion = arbosState.ArbosVersion_40
+	ArbAggregator := insert(MakePrecompile(pgen.ArbAggregatorMetaData, &ArbAggregator{Address: types.ArbAggregatorAddress}))
+	ArbAggregator.methodsByName["GetParentTokenExchangeRateUpdater"].arbosVersion = arbosState.ArbosVersion_40
+	ArbAggregator.methodsByName["SetParentTokenExchangeRate"].arbosVersion = arbosState.ArbosVersion_40
 	insert(MakePrecompile(pgen.ArbStatisticsMetaData, &ArbStatistics{Address: types.ArbStatisticsAddress}))
 
 	eventCtx := func(gasLimit uint64, err error) *Context {
@@ -558,6 +570,41 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbOwnerPublic.methodsByName["RectifyChainOwner"].arbosVersion = 11
 	ArbOwnerPublic.methodsByName["GetBrotliCompressionLevel"].arbosVersion = 20
 	ArbOwnerPublic.methodsByName["GetScheduledUpgrade"].arbosVersion = 20
+	ArbOwnerPublic.methodsByName["GetScheduledMinimumL2BaseFees"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetSequencerFrozenSince"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetAllFeedSigners"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["IsFeedSigner"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetChainMetadata"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetPaymaster"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["IsPrecompileMethodDisabled"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetAllDisabledPrecompileMethods"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetFeeReportInterval"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetExpressLaneRoundTiming"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetExpressLaneRound"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetExpressLaneAuctioneer"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetExpressLaneController"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["SetExpressLaneController"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetEnabledFeatures"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetParameterSunsets"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwnerPublic.methodsByName["GetFeeHolidayEnd"].arbosVersion = arbosState.ArbosVersion_40
+	arbos.EmitFeesCollectedEvent = func(
+		evm mech, fromBlock, toBlock uint64, networkFeeAccount addr, networkFees huge,
+		infraFeeAccount addr, infraFees, posterFees, totalFees huge,
+	) error {
+		zero := common.Big0
+		context := eventCtx(ArbOwnerPublicImpl.FeesCollectedGasCost(0, 0, addr{}, zero, addr{}, zero, zero, zero))
+		return ArbOwnerPublicImpl.FeesCollected(
+			context, evm, fromBlock, toBlock, networkFeeAccount, networkFees, infraFeeAccount, infraFees, posterFees, totalFees,
+		)
+	}
+	arbos.EmitFeeHolidayStartedEvent = func(evm mech, startTime, endTime uint64) error {
+		context := eventCtx(ArbOwnerPublicImpl.FeeHolidayStartedGasCost(startTime, endTime))
+		return ArbOwnerPublicImpl.FeeHolidayStarted(context, evm, startTime, endTime)
+	}
+	arbos.EmitFeeHolidayEndedEvent = func(evm mech, endTime uint64) error {
+		context := eventCtx(ArbOwnerPublicImpl.FeeHolidayEndedGasCost(endTime))
+		return ArbOwnerPublicImpl.FeeHolidayEnded(context, evm, endTime)
+	}
 
 	ArbWasmImpl := &ArbWasm{Address: types.ArbWasmAddress}
 	ArbWasm := insert(MakePrecompile(pgen.ArbWasmMetaData, ArbWasmImpl))
@@ -581,9 +628,17 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbWasmCache.methodsByName["CacheCodehash"].maxArbosVersion = params.ArbosVersion_Stylus
 	ArbWasmCache.methodsByName["CacheProgram"].arbosVersion = params.ArbosVersion_StylusFixes
 
+	ArbTokenRegistry := insert(MakePrecompile(pgen.ArbTokenRegistryMetaData, &ArbTokenRegistry{Address: ArbTokenRegistryAddress}))
+	ArbTokenRegistry.arbosVersion = arbosState.ArbosVersion_40
+	for _, method := range ArbTokenRegistry.methods {
+		method.arbosVersion = ArbTokenRegistry.arbosVersion
+	}
+
 	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
 	ArbRetryable := insert(MakePrecompile(pgen.ArbRetryableTxMetaData, ArbRetryableImpl))
 	arbos.ArbRetryableTxAddress = ArbRetryable.address
+	ArbRetryable.methodsByName["RedeemMultiple"].arbosVersion = arbosState.ArbosVersion_40
+	ArbRetryable.methodsByName["GetRetryablesByBeneficiary"].arbosVersion = arbosState.ArbosVersion_40
 	arbos.RedeemScheduledEventID = ArbRetryable.events["RedeemScheduled"].template.ID
 	arbos.EmitReedeemScheduledEvent = func(
 		evm mech, gas, nonce uint64, ticketId, retryTxHash bytes32,
@@ -604,6 +659,8 @@ func Precompiles() map[addr]ArbosPrecompile {
 	arbos.ArbSysAddress = ArbSys.address
 	arbos.L2ToL1TransactionEventID = ArbSys.events["L2ToL1Transaction"].template.ID
 	arbos.L2ToL1TxEventID = ArbSys.events["L2ToL1Tx"].template.ID
+	ArbSys.methodsByName["CancelL2ToL1Tx"].arbosVersion = arbosState.ArbosVersion_40
+	ArbSys.methodsByName["IsL2ToL1TxCancelled"].arbosVersion = arbosState.ArbosVersion_40
 
 	ArbOwnerImpl := &ArbOwner{Address: types.ArbOwnerAddress}
 	emitOwnerActs := func(evm mech, method bytes4, owner addr, data []byte) error {
@@ -616,6 +673,35 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbOwner.methodsByName["ReleaseL1PricerSurplusFunds"].arbosVersion = 10
 	ArbOwner.methodsByName["SetChainConfig"].arbosVersion = 11
 	ArbOwner.methodsByName["SetBrotliCompressionLevel"].arbosVersion = 20
+	ArbOwner.methodsByName["ScheduleMinimumL2BaseFee"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["CancelScheduledMinimumL2BaseFee"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["FreezeSequencer"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["UnfreezeSequencer"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["AddFeedSigner"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["RemoveFeedSigner"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetChainName"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetChainLogoURI"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetNativeTokenInfo"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetParentTokenExchangeRateUpdater"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetPaymaster"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetMaxTxSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetMaxCalldataSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetMaxLogsPerTx"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetMaxReturnDataSize"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["DisablePrecompileMethod"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["EnablePrecompileMethod"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetFeeReportInterval"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetFeeDiscount"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetL2GasTargetBips"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["ResetBatchPosterStats"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["ResetAllBatchPosterStats"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetExpressLaneRoundTiming"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetExpressLaneAuctioneer"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetWasmPageRamp"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["SetParameterWithSunset"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["CancelParameterSunset"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["StartFeeHoliday"].arbosVersion = arbosState.ArbosVersion_40
+	ArbOwner.methodsByName["EndFeeHoliday"].arbosVersion = arbosState.ArbosVersion_40
 	stylusMethods := []string{
 		"SetInkPrice", "SetWasmMaxStackDepth", "SetWasmFreePages", "SetWasmPageGas",
 		"SetWasmPageLimit", "SetWasmMinInitGas", "SetWasmInitCostScalar",
@@ -629,6 +715,10 @@ func Precompiles() map[addr]ArbosPrecompile {
 	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs))
 	_, arbDebug := MakePrecompile(pgen.ArbDebugMetaData, &ArbDebug{Address: types.ArbDebugAddress})
 	arbDebug.methodsByName["Panic"].arbosVersion = params.ArbosVersion_Stylus
+	arbDebug.methodsByName["DumpArbosState"].arbosVersion = arbosState.ArbosVersion_40
+	arbDebug.methodsByName["WarpTime"].arbosVersion = arbosState.ArbosVersion_40
+	arbDebug.methodsByName["WarpL1BlockNumber"].arbosVersion = arbosState.ArbosVersion_40
+	arbDebug.methodsByName["GetWarps"].arbosVersion = arbosState.ArbosVersion_40
 	insert(debugOnly(arbDebug.address, arbDebug))
 
 	ArbosActs := insert(MakePrecompile(pgen.ArbosActsMetaData, &ArbosActs{Address: types.ArbosAddress}))
@@ -661,6 +751,16 @@ func (p *Precompile) ArbosVersion() uint64 {
 	return p.arbosVersion
 }
 
+// methodDisabledRevert is what disabled methods revert with, an Error(string) like Solidity's require
+var methodDisabledRevert = func() []byte {
+	stringType, _ := abi.NewType("string", "", nil)
+	message, err := abi.Arguments{{Type: stringType}}.Pack("precompile method disabled by the chain owner")
+	if err != nil {
+		panic(err)
+	}
+	return append(crypto.Keccak256([]byte("Error(string)"))[:4], message...)
+}()
+
 // Call a precompile in typed form, deserializing its inputs and serializing its outputs
 func (p *Precompile) Call(
 	input []byte,
@@ -721,6 +821,22 @@ func (p *Precompile) Call(
 		return nil, 0, vm.ErrExecutionReverted
 	}
 
+	if arbosVersion >= arbosState.ArbosVersion_40 {
+		disabled, err := arbosState.IsPrecompileMethodDisabled(evm.StateDB, callerCtx, precompileAddress, id)
+		if err != nil {
+			// user cannot afford checking whether the method is disabled
+			return nil, 0, vm.ErrExecutionReverted
+		}
+		if disabled {
+			// the chain owner disabled the method, so revert like a failed require, keeping the gas not spent so far
+			resultCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(methodDisabledRevert)))
+			if err := callerCtx.Burn(resultCost); err != nil {
+				return nil, 0, vm.ErrExecutionReverted
+			}
+			return methodDisabledRevert, callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+	}
+
 	if method.purity != pure {
 		// impure methods may need the ArbOS state, so open & update the call context now
 		state, err := arbosState.OpenArbosState(evm.StateDB, callerCtx)
@@ -791,6 +907,11 @@ func (p *Precompile) Call(
 		if errors.Is(errRet, programs.ErrProgramActivation) {
 			return nil, 0, errRet
 		}
+		nodeInterface := precompileAddress == types.NodeInterfaceAddress || precompileAddress == types.NodeInterfaceDebugAddress
+		if _, coded := rpcerrors.Code(errRet); coded && nodeInterface {
+			// The node interface is only called over RPC, where the error's code tells the caller what went wrong
+			return nil, 0, errRet
+		}
 		if !errors.Is(errRet, vm.ErrOutOfGas) {
 			log.Debug(
 				"precompile reverted with non-solidity error",
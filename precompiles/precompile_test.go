@@ -197,6 +197,7 @@ func TestPrecompilesPerArbosVersion(t *testing.T) {
 		20: 8,
 		30: 38,
 		31: 1,
+		40: 70,
 	}
 
 	precompiles := Precompiles()
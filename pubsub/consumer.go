@@ -90,6 +90,11 @@ func (c *Consumer[Request, Response]) StreamName() string {
 	return c.redisStream
 }
 
+// Ready returns whether the redis stream has been created by the producer.
+func (c *Consumer[Request, Response]) Ready(ctx context.Context) bool {
+	return StreamExists(ctx, c.redisStream, c.client)
+}
+
 func decrementMsgIdByOne(msgId string) string {
 	id, err := getUintParts(msgId)
 	if err != nil {
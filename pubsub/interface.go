@@ -0,0 +1,36 @@
+package pubsub
+
+import (
+	"context"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// RequestProducer publishes requests to a work queue and returns a promise
+// which resolves once some consumer has set the result for that request.
+// Implementations must be started before producing.
+type RequestProducer[Request any, Response any] interface {
+	Start(ctx context.Context)
+	StopAndWait()
+	Produce(ctx context.Context, value Request) (*containers.Promise[Response], error)
+}
+
+// RequestConsumer takes requests off a work queue shared with other consumers
+// and publishes their results back to the producer that sent them.
+type RequestConsumer[Request any, Response any] interface {
+	Start(ctx context.Context)
+	StopAndWait()
+	Id() string
+	// Ready returns whether the underlying queue exists and can be consumed from.
+	Ready(ctx context.Context) bool
+	// Consume returns the next request, or nil if there's none available right now.
+	Consume(ctx context.Context) (*Message[Request], error)
+	SetResult(ctx context.Context, messageID string, result Response) error
+}
+
+var (
+	_ RequestProducer[any, any] = (*Producer[any, any])(nil)
+	_ RequestConsumer[any, any] = (*Consumer[any, any])(nil)
+	_ RequestProducer[any, any] = (*LocalProducer[any, any])(nil)
+	_ RequestConsumer[any, any] = (*LocalConsumer[any, any])(nil)
+)
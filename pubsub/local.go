@@ -0,0 +1,166 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type localRequest[Request any, Response any] struct {
+	id       string
+	value    Request
+	promise  *containers.Promise[Response]
+	produced time.Time
+}
+
+// LocalQueue is an in-process work queue, for deployments that run producers
+// and consumers in the same process and don't want to depend on Redis.
+// Requests aren't persisted, so they're lost if the process restarts.
+type LocalQueue[Request any, Response any] struct {
+	mutex   sync.Mutex
+	queue   containers.Queue[*localRequest[Request, Response]]
+	pending map[string]*localRequest[Request, Response]
+	nextId  uint64
+}
+
+func NewLocalQueue[Request any, Response any]() *LocalQueue[Request, Response] {
+	return &LocalQueue[Request, Response]{
+		pending: make(map[string]*localRequest[Request, Response]),
+	}
+}
+
+func (q *LocalQueue[Request, Response]) push(value Request) *containers.Promise[Response] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	promise := containers.NewPromise[Response](nil)
+	req := &localRequest[Request, Response]{
+		id:       strconv.FormatUint(q.nextId, 10),
+		value:    value,
+		promise:  &promise,
+		produced: time.Now(),
+	}
+	q.nextId++
+	q.pending[req.id] = req
+	q.queue.Push(req)
+	return &promise
+}
+
+func (q *LocalQueue[Request, Response]) pop() *localRequest[Request, Response] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for q.queue.Len() > 0 {
+		req := q.queue.Pop()
+		// Skip requests which already timed out
+		if _, ok := q.pending[req.id]; ok {
+			return req
+		}
+	}
+	return nil
+}
+
+func (q *LocalQueue[Request, Response]) complete(id string) (*localRequest[Request, Response], bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	req, ok := q.pending[id]
+	if ok {
+		delete(q.pending, id)
+	}
+	return req, ok
+}
+
+func (q *LocalQueue[Request, Response]) expire(timeout time.Duration) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	expired := 0
+	for id, req := range q.pending {
+		if time.Since(req.produced) > timeout {
+			req.promise.ProduceError(errors.New("error getting response, request has been waiting for too long"))
+			delete(q.pending, id)
+			expired++
+		}
+	}
+	return expired
+}
+
+// LocalProducer implements RequestProducer on top of a LocalQueue.
+type LocalProducer[Request any, Response any] struct {
+	stopwaiter.StopWaiter
+	queue *LocalQueue[Request, Response]
+	cfg   *ProducerConfig
+}
+
+func NewLocalProducer[Request any, Response any](queue *LocalQueue[Request, Response], cfg *ProducerConfig) *LocalProducer[Request, Response] {
+	return &LocalProducer[Request, Response]{
+		queue: queue,
+		cfg:   cfg,
+	}
+}
+
+func (p *LocalProducer[Request, Response]) Start(ctx context.Context) {
+	p.StopWaiter.Start(ctx, p)
+	p.StopWaiter.CallIteratively(func(ctx context.Context) time.Duration {
+		if expired := p.queue.expire(p.cfg.RequestTimeout); expired > 0 {
+			log.Error("local producer: requests waited past their timeout", "count", expired)
+		}
+		return p.cfg.CheckResultInterval
+	})
+}
+
+func (p *LocalProducer[Request, Response]) Produce(_ context.Context, value Request) (*containers.Promise[Response], error) {
+	return p.queue.push(value), nil
+}
+
+// LocalConsumer implements RequestConsumer on top of a LocalQueue.
+type LocalConsumer[Request any, Response any] struct {
+	stopwaiter.StopWaiter
+	id    string
+	queue *LocalQueue[Request, Response]
+}
+
+func NewLocalConsumer[Request any, Response any](queue *LocalQueue[Request, Response]) *LocalConsumer[Request, Response] {
+	return &LocalConsumer[Request, Response]{
+		id:    uuid.NewString(),
+		queue: queue,
+	}
+}
+
+func (c *LocalConsumer[Request, Response]) Start(ctx context.Context) {
+	c.StopWaiter.Start(ctx, c)
+}
+
+func (c *LocalConsumer[Request, Response]) Id() string {
+	return c.id
+}
+
+func (c *LocalConsumer[Request, Response]) Ready(_ context.Context) bool {
+	return true
+}
+
+func (c *LocalConsumer[Request, Response]) Consume(_ context.Context) (*Message[Request], error) {
+	req := c.queue.pop()
+	if req == nil {
+		return nil, nil
+	}
+	return &Message[Request]{
+		ID:    req.id,
+		Value: req.value,
+		Ack:   func() {},
+	}, nil
+}
+
+func (c *LocalConsumer[Request, Response]) SetResult(_ context.Context, messageID string, result Response) error {
+	req, ok := c.queue.complete(messageID)
+	if !ok {
+		return fmt.Errorf("setting result for unknown or expired message-id: %v", messageID)
+	}
+	req.promise.Produce(result)
+	return nil
+}
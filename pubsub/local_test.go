@@ -0,0 +1,65 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := NewLocalQueue[string, string]()
+	producer := NewLocalProducer(queue, &TestProducerConfig)
+	producer.Start(ctx)
+	defer producer.StopAndWait()
+	consumer := NewLocalConsumer(queue)
+	consumer.Start(ctx)
+	defer consumer.StopAndWait()
+
+	if msg, err := consumer.Consume(ctx); err != nil || msg != nil {
+		t.Fatalf("expected no message from empty queue, got: %v, err: %v", msg, err)
+	}
+	promise, err := producer.Produce(ctx, "request")
+	if err != nil {
+		t.Fatalf("Produce() unexpected error: %v", err)
+	}
+	msg, err := consumer.Consume(ctx)
+	if err != nil || msg == nil || msg.Value != "request" {
+		t.Fatalf("expected to consume request, got: %v, err: %v", msg, err)
+	}
+	if err := consumer.SetResult(ctx, msg.ID, "response"); err != nil {
+		t.Fatalf("SetResult() unexpected error: %v", err)
+	}
+	res, err := promise.Await(ctx)
+	if err != nil || res != "response" {
+		t.Fatalf("expected response, got: %v, err: %v", res, err)
+	}
+	if err := consumer.SetResult(ctx, msg.ID, "response"); err == nil {
+		t.Error("expected error setting result twice")
+	}
+}
+
+func TestLocalQueueTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := NewLocalQueue[string, string]()
+	cfg := TestProducerConfig
+	cfg.RequestTimeout = 10 * time.Millisecond
+	cfg.CheckResultInterval = 5 * time.Millisecond
+	producer := NewLocalProducer(queue, &cfg)
+	producer.Start(ctx)
+	defer producer.StopAndWait()
+
+	promise, err := producer.Produce(ctx, "request")
+	if err != nil {
+		t.Fatalf("Produce() unexpected error: %v", err)
+	}
+	if _, err := promise.Await(ctx); err == nil {
+		t.Fatal("expected timed out request to error")
+	}
+	consumer := NewLocalConsumer(queue)
+	if msg, err := consumer.Consume(ctx); err != nil || msg != nil {
+		t.Fatalf("expected timed out request to be dropped, got: %v, err: %v", msg, err)
+	}
+}
@@ -56,6 +56,7 @@ func NewRelay(config *Config, feedErrChan chan error) (*Relay, error) {
 		confirmedSequenceNumberListener,
 		feedErrChan,
 		nil,
+		nil,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,217 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package rpcdiff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The position of the block parameter of methods that read state at a block. It's optional, and
+// defaults to latest, if it's the last parameter.
+var blockParamIndex = map[string]int{
+	"debug_traceBlockByNumber":                0,
+	"debug_traceCall":                         1,
+	"eth_call":                                1,
+	"eth_estimateGas":                         1,
+	"eth_feeHistory":                          1,
+	"eth_getBalance":                          1,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getCode":                             1,
+	"eth_getProof":                            2,
+	"eth_getStorageAt":                        2,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getTransactionCount":                 1,
+}
+
+// Block tags whose block moves, so the local node and the reference may read different blocks for them
+var movingTags = map[string]bool{
+	"latest":    true,
+	"pending":   true,
+	"safe":      true,
+	"finalized": true,
+}
+
+// pinBlockTags replaces moving block tags in the params with the number of the local node's block for
+// the tag, so both endpoints are asked about the same block. Returns whether anything was replaced.
+func (p *Proxy) pinBlockTags(ctx context.Context, method string, params []json.RawMessage) ([]json.RawMessage, bool, error) {
+	resolved := make(map[string]json.RawMessage)
+	resolve := func(tag string) (json.RawMessage, error) {
+		if number, ok := resolved[tag]; ok {
+			return number, nil
+		}
+		result, err := call(ctx, p.local, "eth_getBlockByNumber", []json.RawMessage{json.RawMessage(strconv.Quote(tag)), json.RawMessage("false")})
+		if err != nil {
+			return nil, err
+		}
+		var header struct {
+			Number *hexutil.Big `json:"number"`
+		}
+		if err := json.Unmarshal(result, &header); err != nil {
+			return nil, err
+		}
+		if header.Number == nil {
+			return nil, fmt.Errorf("no %v block", tag)
+		}
+		number, err := json.Marshal(header.Number)
+		if err != nil {
+			return nil, err
+		}
+		resolved[tag] = number
+		return number, nil
+	}
+	// pin returns the param with a moving tag replaced, or nil if it isn't a moving tag
+	pin := func(param json.RawMessage) (json.RawMessage, error) {
+		var tag string
+		if param == nil || isNull(param) {
+			tag = "latest"
+		} else if json.Unmarshal(param, &tag) != nil || !movingTags[tag] {
+			return nil, nil
+		}
+		return resolve(tag)
+	}
+
+	if method == "eth_getLogs" {
+		if len(params) != 1 {
+			return params, false, nil
+		}
+		var filter map[string]json.RawMessage
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return nil, false, err
+		}
+		if _, ok := filter["blockHash"]; ok {
+			return params, false, nil
+		}
+		changed := false
+		for _, key := range []string{"fromBlock", "toBlock"} {
+			number, err := pin(filter[key])
+			if err != nil {
+				return nil, false, err
+			}
+			if number != nil {
+				filter[key] = number
+				changed = true
+			}
+		}
+		if !changed {
+			return params, false, nil
+		}
+		encoded, err := json.Marshal(filter)
+		if err != nil {
+			return nil, false, err
+		}
+		return []json.RawMessage{encoded}, true, nil
+	}
+
+	index, ok := blockParamIndex[method]
+	if !ok || len(params) < index {
+		return params, false, nil
+	}
+	var param json.RawMessage
+	if len(params) > index {
+		param = params[index]
+	}
+	number, err := pin(param)
+	if err != nil || number == nil {
+		return params, false, err
+	}
+	pinned := make([]json.RawMessage, max(len(params), index+1))
+	copy(pinned, params)
+	pinned[index] = number
+	return pinned, true, nil
+}
+
+// diff compares two results, ignoring the configured fields, and returns the path of the first difference.
+// Errors only have to match by code, since their messages may be reworded between versions.
+func (p *Proxy) diff(localResult json.RawMessage, localErr error, referenceResult json.RawMessage, referenceErr error) (string, bool) {
+	if localErr != nil || referenceErr != nil {
+		if localErr == nil || referenceErr == nil {
+			return "error", true
+		}
+		if toJSONError(localErr).Code != toJSONError(referenceErr).Code {
+			return "error.code", true
+		}
+		return "", false
+	}
+	local, err := decode(localResult)
+	if err != nil {
+		return "result", !bytes.Equal(localResult, referenceResult)
+	}
+	reference, err := decode(referenceResult)
+	if err != nil {
+		return "result", true
+	}
+	return p.diffValues("result", local, reference)
+}
+
+func decode(data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *Proxy) diffValues(path string, local, reference interface{}) (string, bool) {
+	switch local := local.(type) {
+	case map[string]interface{}:
+		reference, ok := reference.(map[string]interface{})
+		if !ok {
+			return path, true
+		}
+		keys := make([]string, 0, len(local)+len(reference))
+		for key := range local {
+			keys = append(keys, key)
+		}
+		for key := range reference {
+			if _, ok := local[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if p.ignore[key] {
+				continue
+			}
+			localValue, localOk := local[key]
+			referenceValue, referenceOk := reference[key]
+			if localOk != referenceOk {
+				return path + "." + key, true
+			}
+			if diffPath, differ := p.diffValues(path+"."+key, localValue, referenceValue); differ {
+				return diffPath, true
+			}
+		}
+		return "", false
+	case []interface{}:
+		reference, ok := reference.([]interface{})
+		if !ok || len(local) != len(reference) {
+			return path, true
+		}
+		for i := range local {
+			if diffPath, differ := p.diffValues(fmt.Sprintf("%v[%v]", path, i), local[i], reference[i]); differ {
+				return diffPath, true
+			}
+		}
+		return "", false
+	default:
+		if local != reference {
+			return path, true
+		}
+		return "", false
+	}
+}
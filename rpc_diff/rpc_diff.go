@@ -0,0 +1,396 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package rpcdiff serves JSON-RPC from the local node while comparing read calls against a reference
+// endpoint, so a new node version can be canaried against production traffic.
+package rpcdiff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	comparedCounter        = metrics.NewRegisteredCounter("arb/rpcdiff/compared", nil)
+	mismatchCounter        = metrics.NewRegisteredCounter("arb/rpcdiff/mismatch", nil)
+	laggingCounter         = metrics.NewRegisteredCounter("arb/rpcdiff/lagging", nil)
+	droppedCounter         = metrics.NewRegisteredCounter("arb/rpcdiff/dropped", nil)
+	referenceErrorsCounter = metrics.NewRegisteredCounter("arb/rpcdiff/reference/errors", nil)
+)
+
+const maxRequestContentLength = 1024 * 1024 * 5
+
+var errUnsupportedParams = errors.New("non-array params aren't supported")
+
+type Config struct {
+	Enable       bool          `koanf:"enable"`
+	Addr         string        `koanf:"addr"`
+	Port         int           `koanf:"port"`
+	ReferenceURL string        `koanf:"reference-url"`
+	Methods      []string      `koanf:"methods"`
+	IgnoreFields []string      `koanf:"ignore-fields"`
+	SampleRate   float64       `koanf:"sample-rate"`
+	Timeout      time.Duration `koanf:"timeout"`
+	QueueSize    int           `koanf:"queue-size"`
+	Workers      int           `koanf:"workers"`
+}
+
+var DefaultConfig = Config{
+	Enable:       false,
+	Addr:         "localhost",
+	Port:         8549,
+	ReferenceURL: "",
+	Methods: []string{
+		"eth_call",
+		"eth_chainId",
+		"eth_estimateGas",
+		"eth_getBalance",
+		"eth_getBlockByHash",
+		"eth_getBlockByNumber",
+		"eth_getBlockReceipts",
+		"eth_getCode",
+		"eth_getLogs",
+		"eth_getProof",
+		"eth_getStorageAt",
+		"eth_getTransactionByHash",
+		"eth_getTransactionCount",
+		"eth_getTransactionReceipt",
+	},
+	// Dropped from blocks by newer geth versions
+	IgnoreFields: []string{"totalDifficulty"},
+	SampleRate:   1,
+	Timeout:      10 * time.Second,
+	QueueSize:    1024,
+	Workers:      4,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "serve JSON-RPC on a separate port, comparing the results of read calls against a reference endpoint")
+	f.String(prefix+".addr", DefaultConfig.Addr, "differential RPC listening interface")
+	f.Int(prefix+".port", DefaultConfig.Port, "differential RPC listening port")
+	f.String(prefix+".reference-url", DefaultConfig.ReferenceURL, "RPC endpoint of the reference node to compare results against")
+	f.StringSlice(prefix+".methods", DefaultConfig.Methods, "read methods whose results are compared; other methods are only served locally")
+	f.StringSlice(prefix+".ignore-fields", DefaultConfig.IgnoreFields, "JSON object fields ignored wherever they appear in results, for differences known to be benign")
+	f.Float64(prefix+".sample-rate", DefaultConfig.SampleRate, "fraction of calls to compared methods that are compared")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout of each comparison's calls")
+	f.Int(prefix+".queue-size", DefaultConfig.QueueSize, "number of comparisons waiting for a worker before more are dropped")
+	f.Int(prefix+".workers", DefaultConfig.Workers, "number of comparisons run at a time")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.ReferenceURL == "" {
+		return errors.New("rpc-diff requires a reference-url")
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return errors.New("rpc-diff sample-rate must be in (0, 1]")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("rpc-diff timeout must be positive")
+	}
+	if c.QueueSize <= 0 || c.Workers <= 0 {
+		return errors.New("rpc-diff queue-size and workers must be positive")
+	}
+	return nil
+}
+
+// Proxy serves every call from the local node, and compares a sample of the calls to read methods
+// against the reference in the background, so the reference never affects responses.
+type Proxy struct {
+	stopwaiter.StopWaiter
+	config    *Config
+	local     *rpc.Client
+	reference *rpc.Client
+	methods   map[string]bool
+	ignore    map[string]bool
+	requests  atomic.Uint64
+	pending   chan *comparison
+}
+
+type comparison struct {
+	method string
+	params []json.RawMessage
+	result json.RawMessage
+	err    error
+}
+
+func New(ctx context.Context, config *Config, local *rpc.Client) (*Proxy, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	reference, err := rpc.DialContext(ctx, config.ReferenceURL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the reference endpoint: %w", err)
+	}
+	return newProxy(config, local, reference), nil
+}
+
+func newProxy(config *Config, local *rpc.Client, reference *rpc.Client) *Proxy {
+	methods := make(map[string]bool, len(config.Methods))
+	for _, method := range config.Methods {
+		methods[method] = true
+	}
+	ignore := make(map[string]bool, len(config.IgnoreFields))
+	for _, field := range config.IgnoreFields {
+		ignore[field] = true
+	}
+	return &Proxy{
+		config:    config,
+		local:     local,
+		reference: reference,
+		methods:   methods,
+		ignore:    ignore,
+		pending:   make(chan *comparison, config.QueueSize),
+	}
+}
+
+func (p *Proxy) Start(ctxIn context.Context) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(p.config.Addr, strconv.Itoa(p.config.Port)))
+	if err != nil {
+		return err
+	}
+	p.StopWaiter.Start(ctxIn, p)
+	for i := 0; i < p.config.Workers; i++ {
+		p.LaunchThread(p.compareLoop)
+	}
+	p.LaunchThread(func(ctx context.Context) {
+		p.serve(ctx, listener)
+	})
+	log.Info("Differential RPC proxy started", "addr", listener.Addr(), "reference", p.config.ReferenceURL)
+	return nil
+}
+
+func (p *Proxy) StopAndWait() {
+	p.StopWaiter.StopAndWait()
+	p.reference.Close()
+}
+
+func (p *Proxy) serve(ctx context.Context, listener net.Listener) {
+	server := &http.Server{
+		Handler:           p,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn("error shutting down differential RPC proxy", "err", err)
+		}
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("differential RPC proxy failed", "err", err)
+	}
+}
+
+type jsonError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonError      `json:"error,omitempty"`
+}
+
+func toJSONError(err error) *jsonError {
+	jsonErr := &jsonError{Code: -32000, Message: err.Error()}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		jsonErr.Code = rpcErr.ErrorCode()
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		jsonErr.Data = dataErr.ErrorData()
+	}
+	return jsonErr
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestContentLength+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestContentLength {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body = bytes.TrimSpace(body)
+	var response interface{}
+	if len(body) > 0 && body[0] == '[' {
+		var requests []*jsonrpcMessage
+		if err := json.Unmarshal(body, &requests); err != nil {
+			response = &jsonrpcMessage{Version: "2.0", Error: &jsonError{Code: -32700, Message: err.Error()}}
+		} else {
+			responses := make([]*jsonrpcMessage, 0, len(requests))
+			for _, request := range requests {
+				responses = append(responses, p.handle(r.Context(), request))
+			}
+			response = responses
+		}
+	} else {
+		var request jsonrpcMessage
+		if err := json.Unmarshal(body, &request); err != nil {
+			response = &jsonrpcMessage{Version: "2.0", Error: &jsonError{Code: -32700, Message: err.Error()}}
+		} else {
+			response = p.handle(r.Context(), &request)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Debug("failed to write differential RPC proxy response", "err", err)
+	}
+}
+
+func (p *Proxy) handle(ctx context.Context, request *jsonrpcMessage) *jsonrpcMessage {
+	response := &jsonrpcMessage{Version: "2.0", ID: request.ID}
+	if request.Method == "" {
+		response.Error = &jsonError{Code: -32600, Message: "invalid request"}
+		return response
+	}
+	var params []json.RawMessage
+	if len(request.Params) > 0 && !bytes.Equal(request.Params, []byte("null")) {
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			response.Error = &jsonError{Code: -32602, Message: errUnsupportedParams.Error()}
+			return response
+		}
+	}
+	result, err := call(ctx, p.local, request.Method, params)
+	if err != nil {
+		response.Error = toJSONError(err)
+	} else {
+		response.Result = result
+	}
+	if p.methods[request.Method] && p.sampled() {
+		select {
+		case p.pending <- &comparison{method: request.Method, params: params, result: result, err: err}:
+		default:
+			droppedCounter.Inc(1)
+		}
+	}
+	return response
+}
+
+// sampled picks an evenly spread sample-rate fraction of requests.
+func (p *Proxy) sampled() bool {
+	n := p.requests.Add(1)
+	rate := p.config.SampleRate
+	return uint64(float64(n)*rate) > uint64(float64(n-1)*rate)
+}
+
+func call(ctx context.Context, client *rpc.Client, method string, params []json.RawMessage) (json.RawMessage, error) {
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+	var result json.RawMessage
+	err := client.CallContext(ctx, &result, method, args...)
+	return result, err
+}
+
+func (p *Proxy) compareLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-p.pending:
+			compareCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+			p.compare(compareCtx, c)
+			cancel()
+		}
+	}
+}
+
+func (p *Proxy) compare(ctx context.Context, c *comparison) {
+	params, pinned, err := p.pinBlockTags(ctx, c.method, c.params)
+	if err != nil {
+		log.Debug("couldn't pin the block of a call to compare", "method", c.method, "err", err)
+		return
+	}
+	localResult, localErr := c.result, c.err
+	if pinned {
+		// The local response was for the head at the time, so call again at the block the reference is asked about
+		localResult, localErr = call(ctx, p.local, c.method, params)
+	}
+	referenceResult, referenceErr := call(ctx, p.reference, c.method, params)
+	var rpcErr rpc.Error
+	if referenceErr != nil && !errors.As(referenceErr, &rpcErr) {
+		referenceErrorsCounter.Inc(1)
+		log.Debug("reference endpoint call failed", "method", c.method, "err", referenceErr)
+		return
+	}
+	if localErr != nil && !errors.As(localErr, &rpcErr) {
+		return
+	}
+	comparedCounter.Inc(1)
+	path, differ := p.diff(localResult, localErr, referenceResult, referenceErr)
+	if !differ {
+		return
+	}
+	if localErr == nil && referenceErr == nil && isNull(referenceResult) {
+		// The reference hasn't seen the block or transaction yet
+		laggingCounter.Inc(1)
+		log.Debug("reference endpoint is behind", "method", c.method, "params", joinParams(params))
+		return
+	}
+	mismatchCounter.Inc(1)
+	metrics.GetOrRegisterCounter("arb/rpcdiff/mismatch/"+c.method, nil).Inc(1)
+	log.Warn(
+		"RPC result differs from the reference",
+		"method", c.method,
+		"params", joinParams(params),
+		"requestParams", joinParams(c.params),
+		"path", path,
+		"local", describe(localResult, localErr),
+		"reference", describe(referenceResult, referenceErr),
+	)
+}
+
+func isNull(result json.RawMessage) bool {
+	return len(result) == 0 || bytes.Equal(bytes.TrimSpace(result), []byte("null"))
+}
+
+func joinParams(params []json.RawMessage) string {
+	joined, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprint(params)
+	}
+	return string(joined)
+}
+
+func describe(result json.RawMessage, err error) string {
+	if err != nil {
+		jsonErr := toJSONError(err)
+		return fmt.Sprintf("error %v: %v", jsonErr.Code, jsonErr.Message)
+	}
+	return string(result)
+}
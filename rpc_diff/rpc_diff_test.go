@@ -0,0 +1,212 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package rpcdiff
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testError struct {
+	code    int
+	message string
+}
+
+func (e *testError) Error() string {
+	return e.message
+}
+
+func (e *testError) ErrorCode() int {
+	return e.code
+}
+
+// testChain serves the eth methods the tests call, with a balance per block and a moving head.
+type testChain struct {
+	head     uint64
+	balances map[uint64]uint64
+	block    map[string]interface{}
+}
+
+func (c *testChain) GetBalance(_ common.Address, number rpc.BlockNumber) (*hexutil.Big, error) {
+	block := c.head
+	if number >= 0 {
+		block = uint64(number)
+	}
+	balance, ok := c.balances[block]
+	if !ok {
+		return nil, &testError{code: -32000, message: "header not found"}
+	}
+	return (*hexutil.Big)(new(big.Int).SetUint64(balance)), nil
+}
+
+func (c *testChain) GetBlockByNumber(number rpc.BlockNumber, _ bool) (map[string]interface{}, error) {
+	block := c.head
+	if number >= 0 {
+		block = uint64(number)
+	}
+	result := map[string]interface{}{"number": hexutil.Uint64(block)}
+	for key, value := range c.block {
+		result[key] = value
+	}
+	return result, nil
+}
+
+func newTestClient(t *testing.T, chain *testChain) *rpc.Client {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chain); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return rpc.DialInProc(server)
+}
+
+func TestDiff(t *testing.T) {
+	config := DefaultConfig
+	proxy := newProxy(&config, nil, nil)
+	for _, tc := range []struct {
+		local, reference string
+		path             string
+	}{
+		{local: `{"a":"0x1","b":["0x2"]}`, reference: `{"b":["0x2"],"a":"0x1"}`},
+		{local: `{"a":"0x1","totalDifficulty":"0x5"}`, reference: `{"a":"0x1"}`},
+		{local: `{"a":"0x1","b":["0x2"]}`, reference: `{"a":"0x1","b":["0x3"]}`, path: "result.b[0]"},
+		{local: `{"a":"0x1"}`, reference: `{"a":"0x1","c":true}`, path: "result.c"},
+		{local: `[1,2]`, reference: `[1]`, path: "result"},
+		{local: `null`, reference: `{}`, path: "result"},
+	} {
+		path, differ := proxy.diff(json.RawMessage(tc.local), nil, json.RawMessage(tc.reference), nil)
+		if differ != (tc.path != "") || path != tc.path {
+			t.Errorf("diff(%v, %v) = %q, %v want %q", tc.local, tc.reference, path, differ, tc.path)
+		}
+	}
+
+	reverted := &testError{code: 3, message: "execution reverted"}
+	if _, differ := proxy.diff(nil, reverted, nil, &testError{code: 3, message: "execution reverted: reworded"}); differ {
+		t.Error("errors with the same code should match")
+	}
+	if path, differ := proxy.diff(nil, reverted, nil, &testError{code: -32000, message: "execution reverted"}); !differ || path != "error.code" {
+		t.Error("errors with different codes should differ", path)
+	}
+	if path, differ := proxy.diff(json.RawMessage(`"0x1"`), nil, nil, reverted); !differ || path != "error" {
+		t.Error("an error should differ from a result", path)
+	}
+}
+
+func TestPinBlockTags(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig
+	proxy := newProxy(&config, newTestClient(t, &testChain{head: 7}), nil)
+
+	for _, tc := range []struct {
+		method string
+		params string
+		want   string
+	}{
+		{method: "eth_getBalance", params: `["0x0000000000000000000000000000000000000001"]`, want: `["0x0000000000000000000000000000000000000001","0x7"]`},
+		{method: "eth_getBalance", params: `["0x0000000000000000000000000000000000000001","safe"]`, want: `["0x0000000000000000000000000000000000000001","0x7"]`},
+		{method: "eth_getBalance", params: `["0x0000000000000000000000000000000000000001","0x3"]`},
+		{method: "eth_getBalance", params: `["0x0000000000000000000000000000000000000001","earliest"]`},
+		{method: "eth_getLogs", params: `[{"fromBlock":"0x1"}]`, want: `[{"fromBlock":"0x1","toBlock":"0x7"}]`},
+		{method: "eth_getLogs", params: `[{"blockHash":"0x01"}]`},
+		{method: "eth_getTransactionReceipt", params: `["0x01"]`},
+	} {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(tc.params), &params); err != nil {
+			t.Fatal(err)
+		}
+		pinned, changed, err := proxy.pinBlockTags(ctx, tc.method, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != (tc.want != "") {
+			t.Errorf("%v %v: changed = %v", tc.method, tc.params, changed)
+			continue
+		}
+		if changed && joinParams(pinned) != tc.want {
+			t.Errorf("%v %v pinned to %v want %v", tc.method, tc.params, joinParams(pinned), tc.want)
+		}
+		if !changed && joinParams(pinned) != joinParams(params) {
+			t.Errorf("%v %v changed to %v", tc.method, tc.params, joinParams(pinned))
+		}
+	}
+}
+
+func TestProxyComparesAgainstReference(t *testing.T) {
+	ctx := context.Background()
+	local := &testChain{head: 6, balances: map[uint64]uint64{5: 100, 6: 200}, block: map[string]interface{}{"totalDifficulty": "0x1"}}
+	// The reference is a block ahead, and disagrees about block 5
+	reference := &testChain{head: 7, balances: map[uint64]uint64{5: 101, 6: 200, 7: 300}}
+	config := DefaultConfig
+	config.Enable = true
+	config.ReferenceURL = "test"
+	proxy := newProxy(&config, newTestClient(t, local), newTestClient(t, reference))
+
+	request := func(body string) string {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		proxy.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			t.Fatal("unexpected status", recorder.Code, recorder.Body.String())
+		}
+		return strings.TrimSpace(recorder.Body.String())
+	}
+	compareNext := func() {
+		t.Helper()
+		select {
+		case c := <-proxy.pending:
+			proxy.compare(ctx, c)
+		default:
+			t.Fatal("call wasn't queued for comparison")
+		}
+	}
+
+	// Latest is pinned to the local head, where both agree
+	before := mismatchCounter.Snapshot().Count()
+	response := request(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000001","latest"]}`)
+	if response != `{"jsonrpc":"2.0","id":1,"result":"0xc8"}` {
+		t.Fatal("unexpected response", response)
+	}
+	compareNext()
+	if mismatchCounter.Snapshot().Count() != before {
+		t.Fatal("calls at the latest block shouldn't differ")
+	}
+
+	// Batches are served from the local node, and block 5 differs
+	response = request(`[{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000001","0x5"]},{"jsonrpc":"2.0","id":3,"method":"eth_getBlockByNumber","params":["0x6",false]}]`)
+	if !strings.HasPrefix(response, `[{"jsonrpc":"2.0","id":2,"result":"0x64"}`) {
+		t.Fatal("unexpected response", response)
+	}
+	compareNext()
+	if mismatchCounter.Snapshot().Count() != before+1 {
+		t.Fatal("differing balances weren't reported")
+	}
+	// totalDifficulty is ignored by default
+	compareNext()
+	if mismatchCounter.Snapshot().Count() != before+1 {
+		t.Fatal("ignored field was compared")
+	}
+
+	// Methods that aren't compared are only served locally
+	response = request(`{"jsonrpc":"2.0","id":4,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000001","0x9"]}`)
+	if !strings.Contains(response, `"error":{"code":-32000,"message":"header not found"}`) {
+		t.Fatal("local error wasn't returned", response)
+	}
+	compareNext()
+	config.Methods = []string{"eth_getBalance"}
+	proxy = newProxy(&config, newTestClient(t, local), newTestClient(t, reference))
+	request(`{"jsonrpc":"2.0","id":5,"method":"eth_getBlockByNumber","params":["0x6",false]}`)
+	if len(proxy.pending) != 0 {
+		t.Fatal("call to a method that isn't compared was queued")
+	}
+}
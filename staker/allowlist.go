@@ -0,0 +1,117 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+var (
+	stakerAllowlistDisabledGauge = metrics.NewRegisteredGauge("arb/staker/allowlist/disabled", nil)
+	stakerAllowlistedGauge       = metrics.NewRegisteredGauge("arb/staker/allowlist/allowed", nil)
+	stakerReadyGauge             = metrics.NewRegisteredGauge("arb/staker/ready", nil)
+)
+
+// AllowlistStatus is whether the rollup's validator allowlist lets the staker's address act.
+// The rollup checks the allowlist against the address calling it, which is the validator wallet
+// contract if one is used, rather than the transaction sender.
+type AllowlistStatus struct {
+	Address *common.Address `json:"address"`
+	// The rollup is permissionless, so anyone can stake
+	Disabled bool `json:"disabled"`
+	Allowed  bool `json:"allowed"`
+}
+
+// ValidatorReadiness is whether the staker is able to post assertions, and what stops it if it isn't.
+type ValidatorReadiness struct {
+	Allowlist     AllowlistStatus `json:"allowlist"`
+	Strategy      string          `json:"strategy"`
+	TxSender      *common.Address `json:"txSender"`
+	Staked        bool            `json:"staked"`
+	RequiredStake *hexutil.Big    `json:"requiredStake"`
+	Balance       *hexutil.Big    `json:"balance"`
+	Ready         bool            `json:"ready"`
+	Problems      []string        `json:"problems"`
+}
+
+func (s *Staker) AllowlistStatus(ctx context.Context) (AllowlistStatus, error) {
+	callOpts := s.getCallOpts(ctx)
+	status := AllowlistStatus{Address: s.wallet.Address()}
+	var err error
+	status.Disabled, err = s.rollup.ValidatorWhitelistDisabled(callOpts)
+	if err != nil {
+		return AllowlistStatus{}, fmt.Errorf("error checking if the validator allowlist is disabled: %w", err)
+	}
+	if status.Disabled {
+		status.Allowed = true
+	} else if status.Address != nil {
+		status.Allowed, err = s.rollup.IsValidator(callOpts, *status.Address)
+		if err != nil {
+			return AllowlistStatus{}, fmt.Errorf("error checking if %v is on the validator allowlist: %w", *status.Address, err)
+		}
+	}
+	stakerAllowlistDisabledGauge.Update(int64(arbmath.BoolToUint8(status.Disabled)))
+	stakerAllowlistedGauge.Update(int64(arbmath.BoolToUint8(status.Allowed)))
+	return status, nil
+}
+
+// Readiness checks everything the rollup requires of a validator before it accepts a stake from it,
+// so operators of permissionless or newly allowlisted validators can tell whether it's able to post.
+func (s *Staker) Readiness(ctx context.Context) (*ValidatorReadiness, error) {
+	allowlist, err := s.AllowlistStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.config()
+	readiness := &ValidatorReadiness{
+		Allowlist: allowlist,
+		Strategy:  cfg.Strategy,
+		TxSender:  s.wallet.TxSenderAddress(),
+		Problems:  []string{},
+	}
+	if cfg.strategy == WatchtowerStrategy {
+		readiness.Problems = append(readiness.Problems, "the staker is a watchtower, which never posts")
+	}
+	if !allowlist.Allowed {
+		if allowlist.Address == nil {
+			readiness.Problems = append(readiness.Problems, "the rollup has a validator allowlist, and the validator wallet contract that'd need to be on it doesn't exist yet")
+		} else {
+			readiness.Problems = append(readiness.Problems, fmt.Sprintf("%v isn't on the rollup's validator allowlist", *allowlist.Address))
+		}
+	}
+	if allowlist.Address != nil {
+		info, err := s.rollup.StakerInfo(ctx, *allowlist.Address)
+		if err != nil {
+			return nil, fmt.Errorf("error getting staker %v info: %w", *allowlist.Address, err)
+		}
+		readiness.Staked = info != nil
+	}
+	requiredStake, err := s.rollup.CurrentRequiredStake(s.getCallOpts(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting the current required stake: %w", err)
+	}
+	readiness.RequiredStake = (*hexutil.Big)(requiredStake)
+	if readiness.TxSender == nil {
+		readiness.Problems = append(readiness.Problems, "the staker has no wallet to send transactions with")
+	} else {
+		balance, err := s.client.BalanceAt(ctx, *readiness.TxSender, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting the balance of %v: %w", *readiness.TxSender, err)
+		}
+		readiness.Balance = (*hexutil.Big)(balance)
+		if !readiness.Staked && balance.Cmp(requiredStake) < 0 {
+			readiness.Problems = append(readiness.Problems, fmt.Sprintf("%v has %v wei, less than the required stake of %v wei", *readiness.TxSender, balance, requiredStake))
+		}
+	}
+	readiness.Ready = len(readiness.Problems) == 0
+	stakerReadyGauge.Update(int64(arbmath.BoolToUint8(readiness.Ready)))
+	return readiness, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+var (
+	stakerAutoRequiredStakeGauge    = metrics.NewRegisteredGaugeFloat64("arb/staker/auto/required_stake", nil)
+	stakerAutoStakeGauge            = metrics.NewRegisteredGaugeFloat64("arb/staker/auto/stake", nil)
+	stakerAutoAssertionPeriodGauge  = metrics.NewRegisteredGauge("arb/staker/auto/assertion_period", nil)
+	stakerAutoBalanceShortfallGauge = metrics.NewRegisteredGaugeFloat64("arb/staker/auto/balance_shortfall", nil)
+)
+
+var ErrStakeAboveMax = errors.New("required stake exceeds the configured maximum")
+
+// AutoParamsConfig lets the staker derive its stake and assertion pace from the rollup contract and
+// parent chain instead of hand-maintained values.
+type AutoParamsConfig struct {
+	Enable                    bool          `koanf:"enable"`
+	AssertionPeriodMarginBips arbmath.UBips `koanf:"assertion-period-margin-bips" reload:"hot"`
+	StakeMarginBips           arbmath.UBips `koanf:"stake-margin-bips" reload:"hot"`
+	MaxStake                  string        `koanf:"max-stake" reload:"hot"`
+	AssertionGas              uint64        `koanf:"assertion-gas" reload:"hot"`
+	ReserveAssertions         uint64        `koanf:"reserve-assertions" reload:"hot"`
+
+	maxStake *big.Int
+}
+
+var DefaultAutoParamsConfig = AutoParamsConfig{
+	Enable:                    false,
+	AssertionPeriodMarginBips: 1000,
+	StakeMarginBips:           0,
+	MaxStake:                  "",
+	AssertionGas:              600_000,
+	ReserveAssertions:         20,
+}
+
+func AutoParamsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAutoParamsConfig.Enable, "pace assertions by the rollup's minimum assertion period instead of make-assertion-interval, and size stakes by the rollup's current required stake")
+	f.Uint64(prefix+".assertion-period-margin-bips", uint64(DefaultAutoParamsConfig.AssertionPeriodMarginBips), "how far past the rollup's minimum assertion period to make assertions, in basis points of the period")
+	f.Uint64(prefix+".stake-margin-bips", uint64(DefaultAutoParamsConfig.StakeMarginBips), "how much more than the current required stake to stake, in basis points, in case the requirement rises before the stake lands")
+	f.String(prefix+".max-stake", DefaultAutoParamsConfig.MaxStake, "maximum stake in wei to place automatically (empty = unlimited)")
+	f.Uint64(prefix+".assertion-gas", DefaultAutoParamsConfig.AssertionGas, "parent chain gas an assertion is expected to use, for sizing the balance alert")
+	f.Uint64(prefix+".reserve-assertions", DefaultAutoParamsConfig.ReserveAssertions, "alert when the wallet can't pay for this many assertions at the current parent chain gas price, plus the stake if not yet staked")
+}
+
+func (c *AutoParamsConfig) Validate() error {
+	c.maxStake = nil
+	if c.MaxStake != "" {
+		maxStake, ok := new(big.Int).SetString(c.MaxStake, 10)
+		if !ok || maxStake.Sign() <= 0 {
+			return fmt.Errorf("invalid auto-params max-stake \"%v\"", c.MaxStake)
+		}
+		c.maxStake = maxStake
+	}
+	return nil
+}
+
+// paddedAssertionPeriod returns the number of parent chain blocks to wait between assertions.
+func paddedAssertionPeriod(minPeriod *big.Int, marginBips arbmath.UBips) *big.Int {
+	return arbmath.BigMulByUBips(minPeriod, arbmath.OneInUBips+marginBips)
+}
+
+// sizeStake returns the stake to place given the rollup's current required stake.
+func sizeStake(required, maxStake *big.Int, marginBips arbmath.UBips) (*big.Int, error) {
+	stake := arbmath.BigMulByUBips(required, arbmath.OneInUBips+marginBips)
+	if maxStake != nil && stake.Cmp(maxStake) > 0 {
+		if required.Cmp(maxStake) > 0 {
+			return nil, fmt.Errorf("%w: required %v, max %v", ErrStakeAboveMax, required, maxStake)
+		}
+		stake = new(big.Int).Set(maxStake)
+	}
+	return stake, nil
+}
+
+// stakeAmount returns how much to stake when placing a new stake.
+func (s *Staker) stakeAmount(ctx context.Context) (*big.Int, error) {
+	required, err := s.rollup.CurrentRequiredStake(s.getCallOpts(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting current required stake: %w", err)
+	}
+	cfg := &s.config().AutoParams
+	if !cfg.Enable {
+		return required, nil
+	}
+	stakerAutoRequiredStakeGauge.Update(arbmath.BalancePerEther(required))
+	stake, err := sizeStake(required, cfg.maxStake, cfg.StakeMarginBips)
+	if err != nil {
+		log.Error("not staking automatically", "err", err)
+		return nil, err
+	}
+	stakerAutoStakeGauge.Update(arbmath.BalancePerEther(stake))
+	return stake, nil
+}
+
+// checkAutoParams reports the assertion pace, and alerts if the wallet can't afford the stake and the
+// reserve of assertions at the current parent chain gas price.
+func (s *Staker) checkAutoParams(ctx context.Context) {
+	cfg := &s.config().AutoParams
+	callOpts := s.getCallOpts(ctx)
+	minPeriod, err := s.rollup.MinimumAssertionPeriod(callOpts)
+	if err != nil {
+		log.Warn("error getting rollup minimum assertion period", "err", err)
+		return
+	}
+	stakerAutoAssertionPeriodGauge.Update(arbmath.BigToIntSaturating(paddedAssertionPeriod(minPeriod, cfg.AssertionPeriodMarginBips)))
+
+	sender := s.wallet.TxSenderAddress()
+	if sender == nil {
+		return
+	}
+	gasPrice, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		log.Warn("error getting parent chain gas price", "err", err)
+		return
+	}
+	needed := arbmath.BigMulByUint(gasPrice, arbmath.SaturatingUMul(cfg.AssertionGas, cfg.ReserveAssertions))
+	staker := s.wallet.AddressOrZero()
+	stakeFrom := *sender
+	if staker != (common.Address{}) {
+		stakeFrom = staker
+		staked, err := s.rollup.AmountStaked(callOpts, staker)
+		if err != nil {
+			log.Warn("error getting amount staked", "err", err)
+			return
+		}
+		if staked.Sign() == 0 {
+			stake, err := s.stakeAmount(ctx)
+			if err != nil {
+				return
+			}
+			if stakeFrom == *sender {
+				needed = arbmath.BigAdd(needed, stake)
+			} else if shortfall := s.balanceShortfall(ctx, stakeFrom, stake); shortfall != nil && shortfall.Sign() > 0 {
+				stakerAutoBalanceShortfallGauge.Update(arbmath.BalancePerEther(shortfall))
+				log.Error("staker wallet can't afford the stake", "wallet", stakeFrom, "stake", stake, "shortfall", shortfall)
+				return
+			}
+		}
+	}
+	shortfall := s.balanceShortfall(ctx, *sender, needed)
+	if shortfall == nil {
+		return
+	}
+	stakerAutoBalanceShortfallGauge.Update(arbmath.BalancePerEther(shortfall))
+	if shortfall.Sign() > 0 {
+		log.Error(
+			"staker balance is running low",
+			"sender", *sender,
+			"needed", needed,
+			"shortfall", shortfall,
+			"gasPrice", gasPrice,
+			"reserveAssertions", cfg.ReserveAssertions,
+		)
+	}
+}
+
+// balanceShortfall returns how much more than its balance an address needs, which is negative if it has
+// enough, or nil if the balance couldn't be read.
+func (s *Staker) balanceShortfall(ctx context.Context, address common.Address, needed *big.Int) *big.Int {
+	balance, err := s.client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		log.Warn("error getting balance", "address", address, "err", err)
+		return nil
+	}
+	return arbmath.BigSub(needed, balance)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSizeStake(t *testing.T) {
+	required := big.NewInt(1e18)
+	stake, err := sizeStake(required, nil, 0)
+	Require(t, err)
+	if stake.Cmp(required) != 0 {
+		Fail(t, "stake without a margin was", stake)
+	}
+	stake, err = sizeStake(required, nil, 500)
+	Require(t, err)
+	if stake.Cmp(big.NewInt(105e16)) != 0 {
+		Fail(t, "stake with a 5% margin was", stake)
+	}
+	// The margin is dropped rather than exceeding the maximum
+	stake, err = sizeStake(required, big.NewInt(102e16), 500)
+	Require(t, err)
+	if stake.Cmp(big.NewInt(102e16)) != 0 {
+		Fail(t, "stake wasn't capped at the maximum", stake)
+	}
+	if _, err := sizeStake(required, big.NewInt(1e17), 0); !errors.Is(err, ErrStakeAboveMax) {
+		Fail(t, "expected a required stake above the maximum to fail, got", err)
+	}
+}
+
+func TestPaddedAssertionPeriod(t *testing.T) {
+	if period := paddedAssertionPeriod(big.NewInt(75), 0); period.Uint64() != 75 {
+		Fail(t, "period without a margin was", period)
+	}
+	if period := paddedAssertionPeriod(big.NewInt(75), 2000); period.Uint64() != 90 {
+		Fail(t, "period with a 20% margin was", period)
+	}
+}
+
+func TestAutoParamsConfigValidate(t *testing.T) {
+	config := DefaultAutoParamsConfig
+	Require(t, config.Validate())
+	if config.maxStake != nil {
+		Fail(t, "max stake set by default")
+	}
+	config.MaxStake = "2000000000000000000"
+	Require(t, config.Validate())
+	if config.maxStake.Cmp(big.NewInt(2e18)) != 0 {
+		Fail(t, "max stake parsed as", config.maxStake)
+	}
+	config.MaxStake = "1 ether"
+	if config.Validate() == nil {
+		Fail(t, "invalid max stake accepted")
+	}
+}
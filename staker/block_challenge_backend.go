@@ -71,6 +71,11 @@ func NewBlockChallengeBackend(
 	}, nil
 }
 
+// MessageRange returns the first message the challenge is over, and the message count it ends at.
+func (b *BlockChallengeBackend) MessageRange() (arbutil.MessageIndex, arbutil.MessageIndex) {
+	return b.startMsgCount, b.startMsgCount + arbutil.MessageIndex(b.tooFarStartsAtPosition) - 1
+}
+
 func (b *BlockChallengeBackend) findBatchAfterMessageCount(msgCount arbutil.MessageIndex) (uint64, error) {
 	if msgCount == 0 {
 		return 0, nil
@@ -35,18 +35,19 @@ import (
 )
 
 var (
-	validatorPendingValidationsGauge  = metrics.NewRegisteredGauge("arb/validator/validations/pending", nil)
-	validatorValidValidationsCounter  = metrics.NewRegisteredCounter("arb/validator/validations/valid", nil)
-	validatorFailedValidationsCounter = metrics.NewRegisteredCounter("arb/validator/validations/failed", nil)
-	validatorProfileWaitToRecordHist  = metrics.NewRegisteredHistogram("arb/validator/profile/wait_to_record", nil, metrics.NewBoundedHistogramSample())
-	validatorProfileRecordingHist     = metrics.NewRegisteredHistogram("arb/validator/profile/recording", nil, metrics.NewBoundedHistogramSample())
-	validatorProfileWaitToLaunchHist  = metrics.NewRegisteredHistogram("arb/validator/profile/wait_to_launch", nil, metrics.NewBoundedHistogramSample())
-	validatorProfileLaunchingHist     = metrics.NewRegisteredHistogram("arb/validator/profile/launching", nil, metrics.NewBoundedHistogramSample())
-	validatorProfileRunningHist       = metrics.NewRegisteredHistogram("arb/validator/profile/running", nil, metrics.NewBoundedHistogramSample())
-	validatorMsgCountCurrentBatch     = metrics.NewRegisteredGauge("arb/validator/msg_count_current_batch", nil)
-	validatorMsgCountCreatedGauge     = metrics.NewRegisteredGauge("arb/validator/msg_count_created", nil)
-	validatorMsgCountRecordSentGauge  = metrics.NewRegisteredGauge("arb/validator/msg_count_record_sent", nil)
-	validatorMsgCountValidatedGauge   = metrics.NewRegisteredGauge("arb/validator/msg_count_validated", nil)
+	validatorPendingValidationsGauge    = metrics.NewRegisteredGauge("arb/validator/validations/pending", nil)
+	validatorValidValidationsCounter    = metrics.NewRegisteredCounter("arb/validator/validations/valid", nil)
+	validatorFailedValidationsCounter   = metrics.NewRegisteredCounter("arb/validator/validations/failed", nil)
+	validatorDisputedValidationsCounter = metrics.NewRegisteredCounter("arb/validator/validations/disputed", nil)
+	validatorProfileWaitToRecordHist    = metrics.NewRegisteredHistogram("arb/validator/profile/wait_to_record", nil, metrics.NewBoundedHistogramSample())
+	validatorProfileRecordingHist       = metrics.NewRegisteredHistogram("arb/validator/profile/recording", nil, metrics.NewBoundedHistogramSample())
+	validatorProfileWaitToLaunchHist    = metrics.NewRegisteredHistogram("arb/validator/profile/wait_to_launch", nil, metrics.NewBoundedHistogramSample())
+	validatorProfileLaunchingHist       = metrics.NewRegisteredHistogram("arb/validator/profile/launching", nil, metrics.NewBoundedHistogramSample())
+	validatorProfileRunningHist         = metrics.NewRegisteredHistogram("arb/validator/profile/running", nil, metrics.NewBoundedHistogramSample())
+	validatorMsgCountCurrentBatch       = metrics.NewRegisteredGauge("arb/validator/msg_count_current_batch", nil)
+	validatorMsgCountCreatedGauge       = metrics.NewRegisteredGauge("arb/validator/msg_count_created", nil)
+	validatorMsgCountRecordSentGauge    = metrics.NewRegisteredGauge("arb/validator/msg_count_record_sent", nil)
+	validatorMsgCountValidatedGauge     = metrics.NewRegisteredGauge("arb/validator/msg_count_validated", nil)
 )
 
 type BlockValidator struct {
@@ -88,6 +89,11 @@ type BlockValidator struct {
 
 	chosenValidator map[common.Hash]validator.ValidationSpawner
 
+	// messages in [disputedStart, disputedEnd) are validated with high priority while we're in a challenge
+	disputedMutex sync.RWMutex
+	disputedStart arbutil.MessageIndex
+	disputedEnd   arbutil.MessageIndex
+
 	// wasmModuleRoot
 	moduleMutex           sync.Mutex
 	currentWasmModuleRoot common.Hash
@@ -101,7 +107,11 @@ type BlockValidator struct {
 
 	fatalErr chan<- error
 
+	statusFeed *ValidationStatusFeed
+
 	MemoryFreeLimitChecker resourcemanager.LimitChecker
+
+	paused func() bool // may be nil
 }
 
 type BlockValidatorConfig struct {
@@ -287,6 +297,7 @@ func NewBlockValidator(
 		config:                  config,
 		fatalErr:                fatalErr,
 		prevBatchCache:          make(map[uint64][]byte),
+		statusFeed:              NewValidationStatusFeed(),
 	}
 	valInputsWriter, err := inputs.NewWriter(
 		inputs.WithBaseDir(ret.stack.InstanceDir()),
@@ -389,6 +400,28 @@ func nonBlockingTrigger(channel chan struct{}) {
 	}
 }
 
+// SetDisputedRange makes validations of messages from start up to end high priority, so the validation
+// servers run them ahead of routine validations. An empty range clears it.
+func (v *BlockValidator) SetDisputedRange(start, end arbutil.MessageIndex) {
+	v.disputedMutex.Lock()
+	defer v.disputedMutex.Unlock()
+	if start != v.disputedStart || end != v.disputedEnd {
+		log.Info("validating disputed messages with high priority", "start", start, "end", end)
+	}
+	v.disputedStart = start
+	v.disputedEnd = end
+}
+
+func (v *BlockValidator) ClearDisputedRange() {
+	v.SetDisputedRange(0, 0)
+}
+
+func (v *BlockValidator) isDisputed(pos arbutil.MessageIndex) bool {
+	v.disputedMutex.RLock()
+	defer v.disputedMutex.RUnlock()
+	return pos >= v.disputedStart && pos < v.disputedEnd
+}
+
 func (v *BlockValidator) GetModuleRootsToValidate() []common.Hash {
 	v.moduleMutex.Lock()
 	defer v.moduleMutex.Unlock()
@@ -539,28 +572,36 @@ func (v *BlockValidator) writeToFile(validationEntry *validationEntry) error {
 }
 
 func (v *BlockValidator) SetCurrentWasmModuleRoot(hash common.Hash) error {
+	changed, err := v.setCurrentWasmModuleRoot(hash)
+	if changed {
+		v.statusFeed.send(v.newStatus(ValidationStatusModuleRoot))
+	}
+	return err
+}
+
+func (v *BlockValidator) setCurrentWasmModuleRoot(hash common.Hash) (bool, error) {
 	v.moduleMutex.Lock()
 	defer v.moduleMutex.Unlock()
 
 	if (hash == common.Hash{}) {
-		return errors.New("trying to set zero as wasmModuleRoot")
+		return false, errors.New("trying to set zero as wasmModuleRoot")
 	}
 	if hash == v.currentWasmModuleRoot {
-		return nil
+		return false, nil
 	}
 	if (v.currentWasmModuleRoot == common.Hash{}) {
 		v.currentWasmModuleRoot = hash
-		return nil
+		return true, nil
 	}
 	if v.pendingWasmModuleRoot == hash {
 		log.Info("Block validator: detected progressing to pending machine", "hash", hash)
 		v.currentWasmModuleRoot = hash
-		return nil
+		return true, nil
 	}
 	if v.config().CurrentModuleRoot != "current" {
-		return nil
+		return false, nil
 	}
-	return fmt.Errorf(
+	return false, fmt.Errorf(
 		"unexpected wasmModuleRoot! cannot validate! found %v , current %v, pending %v",
 		hash, v.currentWasmModuleRoot, v.pendingWasmModuleRoot,
 	)
@@ -751,7 +792,23 @@ func (v *BlockValidator) sendNextRecordRequests(ctx context.Context) (bool, erro
 	return true, nil
 }
 
+// SetPausedCheck makes the validator stop recording and validating new blocks while paused returns true.
+// Validations already running finish, and are recorded once it resumes.
+func (v *BlockValidator) SetPausedCheck(paused func() bool) {
+	if v.Started() {
+		panic("trying to set paused check after start")
+	}
+	v.paused = paused
+}
+
+func (v *BlockValidator) isPaused() bool {
+	return v.paused != nil && v.paused()
+}
+
 func (v *BlockValidator) iterativeValidationEntryRecorder(ctx context.Context, ignored struct{}) time.Duration {
+	if v.isPaused() {
+		return v.config().ValidationPoll
+	}
 	moreWork, err := v.sendNextRecordRequests(ctx)
 	if err != nil {
 		log.Error("error trying to record for validation node", "err", err)
@@ -846,6 +903,11 @@ validationsLoop:
 				}
 				if err != nil {
 					validatorFailedValidationsCounter.Inc(1)
+					status := v.newStatus(ValidationStatusFailed)
+					failed := pos
+					status.FailedMessage = &failed
+					status.Error = err.Error()
+					v.statusFeed.send(status)
 					v.possiblyFatal(err)
 					return &pos, nil // if not fatal - retry
 				}
@@ -858,6 +920,10 @@ validationsLoop:
 			go v.recorder.MarkValid(pos, v.lastValidGS.BlockHash)
 			atomicStorePos(&v.validatedA, pos+1, validatorMsgCountValidatedGauge)
 			v.validations.Delete(pos)
+			status := v.newStatus(ValidationStatusValidated)
+			validatedGS := v.lastValidGS
+			status.GlobalState = &validatedGS
+			v.statusFeed.send(status)
 			nonBlockingTrigger(v.createNodesChan)
 			nonBlockingTrigger(v.sendRecordChan)
 			if v.testingProgressMadeChan != nil {
@@ -890,6 +956,10 @@ validationsLoop:
 			validatorProfileWaitToLaunchHist.Update(validationStatus.profileStep())
 			validatorPendingValidationsGauge.Inc(1)
 			var runs []validator.ValidationRun
+			disputed := v.isDisputed(validationStatus.Entry.Pos)
+			if disputed {
+				validatorDisputedValidationsCounter.Inc(1)
+			}
 			for _, moduleRoot := range wasmRoots {
 				spawner := v.chosenValidator[moduleRoot]
 				input, err := validationStatus.Entry.ToInput(spawner.StylusArchs())
@@ -900,6 +970,7 @@ validationsLoop:
 				if ctx.Err() != nil {
 					return nil, ctx.Err()
 				}
+				input.HighPriority = disputed
 				run := spawner.Launch(input, moduleRoot)
 				log.Trace("advanceValidations: launched", "pos", validationStatus.Entry.Pos, "moduleRoot", moduleRoot)
 				runs = append(runs, run)
@@ -933,6 +1004,9 @@ validationsLoop:
 }
 
 func (v *BlockValidator) iterativeValidationProgress(ctx context.Context, ignored struct{}) time.Duration {
+	if v.isPaused() {
+		return v.config().ValidationPoll
+	}
 	reorg, err := v.advanceValidations(ctx)
 	if err != nil {
 		log.Error("error trying to record for validation node", "err", err)
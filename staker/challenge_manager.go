@@ -225,6 +225,11 @@ func (m *ChallengeManager) ChallengeIndex() uint64 {
 	return m.challengeIndex
 }
 
+// DisputedMessageRange returns the first message the challenge is over, and the message count it ends at.
+func (m *ChallengeManager) DisputedMessageRange() (arbutil.MessageIndex, arbutil.MessageIndex) {
+	return m.blockChallengeBackend.MessageRange()
+}
+
 func uint64ToIndex(val uint64) common.Hash {
 	var challengeIndex common.Hash
 	binary.BigEndian.PutUint64(challengeIndex[(32-8):], val)
@@ -0,0 +1,267 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+// ExecutionWitness is what's needed to execute a block without a database. Headers, codes and state
+// (trie nodes) follow geth's execution witness format, and the accounts and storage slots the block
+// accessed are decoded from the trie nodes, keyed by their hashes as the state tries are.
+type ExecutionWitness struct {
+	BlockNumber     uint64           `json:"blockNumber"`
+	BlockHash       common.Hash      `json:"blockHash"`
+	ParentHash      common.Hash      `json:"parentHash"`
+	ParentStateRoot common.Hash      `json:"parentStateRoot"`
+	Headers         []hexutil.Bytes  `json:"headers"`
+	Codes           []hexutil.Bytes  `json:"codes"`
+	State           []hexutil.Bytes  `json:"state"`
+	Accounts        []WitnessAccount `json:"accounts"`
+	// Recorded preimages that aren't headers, codes or reachable trie nodes
+	Preimages []hexutil.Bytes `json:"preimages,omitempty"`
+}
+
+type WitnessAccount struct {
+	AddressHash common.Hash    `json:"addressHash"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Balance     *hexutil.Big   `json:"balance"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	Storage     []WitnessSlot  `json:"storage,omitempty"`
+}
+
+type WitnessSlot struct {
+	KeyHash common.Hash `json:"keyHash"`
+	Value   common.Hash `json:"value"`
+}
+
+// ExecutionWitnessAt records the execution of the block produced by the message at pos, and returns its witness.
+func (v *StatelessBlockValidator) ExecutionWitnessAt(ctx context.Context, pos arbutil.MessageIndex) (*ExecutionWitness, error) {
+	if pos == 0 {
+		return nil, errors.New("the genesis block has no witness")
+	}
+	msg, err := v.streamer.GetMessage(pos)
+	if err != nil {
+		return nil, err
+	}
+	prevResult, err := v.streamer.ResultAtCount(pos)
+	if err != nil {
+		return nil, err
+	}
+	recording, err := v.recorder.RecordBlockCreation(ctx, pos, msg)
+	if err != nil {
+		return nil, err
+	}
+	return BuildExecutionWitness(prevResult.BlockHash, recording.BlockHash, recording.Preimages)
+}
+
+// BuildExecutionWitness sorts the preimages recorded while executing a block into a witness.
+// The parent block's header must be among them, as it is for the replay binary.
+func BuildExecutionWitness(parentHash common.Hash, blockHash common.Hash, preimages map[common.Hash][]byte) (*ExecutionWitness, error) {
+	parentEnc, ok := preimages[parentHash]
+	if !ok {
+		return nil, fmt.Errorf("parent header %v wasn't recorded", parentHash)
+	}
+	var parent types.Header
+	if err := rlp.DecodeBytes(parentEnc, &parent); err != nil {
+		return nil, fmt.Errorf("decoding parent header: %w", err)
+	}
+	witness := &ExecutionWitness{
+		BlockNumber:     parent.Number.Uint64() + 1,
+		BlockHash:       blockHash,
+		ParentHash:      parentHash,
+		ParentStateRoot: parent.Root,
+	}
+	walker := &trieWalker{nodes: preimages, reached: make(map[common.Hash]bool)}
+	codes := make(map[common.Hash]bool)
+	err := walker.walk(parent.Root, func(key, value []byte) error {
+		var account struct {
+			Nonce    uint64
+			Balance  *big.Int
+			Root     common.Hash
+			CodeHash []byte
+		}
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("decoding account %x: %w", key, err)
+		}
+		entry := WitnessAccount{
+			AddressHash: common.BytesToHash(key),
+			Nonce:       hexutil.Uint64(account.Nonce),
+			Balance:     (*hexutil.Big)(account.Balance),
+			StorageRoot: account.Root,
+			CodeHash:    common.BytesToHash(account.CodeHash),
+		}
+		err := walker.walk(account.Root, func(key, value []byte) error {
+			_, content, _, err := rlp.Split(value)
+			if err != nil {
+				return fmt.Errorf("decoding storage slot %x: %w", key, err)
+			}
+			entry.Storage = append(entry.Storage, WitnessSlot{KeyHash: common.BytesToHash(key), Value: common.BytesToHash(content)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if _, ok := preimages[entry.CodeHash]; ok && entry.CodeHash != types.EmptyCodeHash {
+			codes[entry.CodeHash] = true
+		}
+		witness.Accounts = append(witness.Accounts, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]common.Hash, 0, len(preimages))
+	for hash := range preimages {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	var headers []*types.Header
+	for _, hash := range hashes {
+		preimage := preimages[hash]
+		switch {
+		case walker.reached[hash]:
+			witness.State = append(witness.State, preimage)
+		case codes[hash]:
+			witness.Codes = append(witness.Codes, preimage)
+		default:
+			var header types.Header
+			if rlp.DecodeBytes(preimage, &header) == nil {
+				headers = append(headers, &header)
+			} else {
+				witness.Preimages = append(witness.Preimages, preimage)
+			}
+		}
+	}
+	// Latest first, as in geth's witnesses
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Number.Cmp(headers[j].Number) > 0 })
+	for _, header := range headers {
+		enc, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, err
+		}
+		witness.Headers = append(witness.Headers, enc)
+	}
+	return witness, nil
+}
+
+// trieWalker visits the parts of a trie whose nodes are in a set, which are the parts a block accessed.
+type trieWalker struct {
+	nodes   map[common.Hash][]byte
+	reached map[common.Hash]bool
+}
+
+// walk calls leaf with the key and value of every leaf under root that can be reached through known nodes.
+func (w *trieWalker) walk(root common.Hash, leaf func(key, value []byte) error) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	return w.walkHash(root, nil, leaf)
+}
+
+func (w *trieWalker) walkHash(hash common.Hash, path []byte, leaf func(key, value []byte) error) error {
+	enc, ok := w.nodes[hash]
+	if !ok {
+		return nil
+	}
+	w.reached[hash] = true
+	return w.walkNode(enc, path, leaf)
+}
+
+func (w *trieWalker) walkNode(enc []byte, path []byte, leaf func(key, value []byte) error) error {
+	elems, _, err := rlp.SplitList(enc)
+	if err != nil {
+		return fmt.Errorf("decoding trie node: %w", err)
+	}
+	count, err := rlp.CountValues(elems)
+	if err != nil {
+		return fmt.Errorf("decoding trie node: %w", err)
+	}
+	switch count {
+	case 2:
+		compact, rest, err := rlp.SplitString(elems)
+		if err != nil {
+			return fmt.Errorf("decoding short node key: %w", err)
+		}
+		nibbles, isLeaf := compactToNibbles(compact)
+		childPath := append(append([]byte{}, path...), nibbles...)
+		if isLeaf {
+			value, _, err := rlp.SplitString(rest)
+			if err != nil {
+				return fmt.Errorf("decoding leaf value: %w", err)
+			}
+			return leaf(nibblesToBytes(childPath), value)
+		}
+		return w.walkChild(rest, childPath, leaf)
+	case 17:
+		for i := byte(0); i < 16; i++ {
+			_, _, rest, err := rlp.Split(elems)
+			if err != nil {
+				return fmt.Errorf("decoding branch node: %w", err)
+			}
+			child := elems[:len(elems)-len(rest)]
+			if err := w.walkChild(child, append(append([]byte{}, path...), i), leaf); err != nil {
+				return err
+			}
+			elems = rest
+		}
+		return nil
+	default:
+		return fmt.Errorf("trie node has %v elements", count)
+	}
+}
+
+// walkChild follows a reference to a child node, which is either its hash or the node itself if it's small.
+func (w *trieWalker) walkChild(ref []byte, path []byte, leaf func(key, value []byte) error) error {
+	kind, content, _, err := rlp.Split(ref)
+	if err != nil {
+		return fmt.Errorf("decoding child reference: %w", err)
+	}
+	switch {
+	case kind == rlp.List:
+		return w.walkNode(ref, path, leaf)
+	case len(content) == common.HashLength:
+		return w.walkHash(common.BytesToHash(content), path, leaf)
+	default:
+		return nil
+	}
+}
+
+// compactToNibbles decodes a hex-prefix encoded key, returning its nibbles and whether it ends at a leaf.
+func compactToNibbles(compact []byte) ([]byte, bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	flag := compact[0] >> 4
+	var nibbles []byte
+	if flag&1 == 1 {
+		nibbles = append(nibbles, compact[0]&0x0f)
+	}
+	for _, b := range compact[1:] {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles, flag&2 != 0
+}
+
+func nibblesToBytes(nibbles []byte) []byte {
+	key := make([]byte, len(nibbles)/2)
+	for i := range key {
+		key[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+	return key
+}
@@ -0,0 +1,109 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// buildTestTrie builds a secure trie out of the entries, adding its nodes to the preimages.
+func buildTestTrie(t *testing.T, preimages map[common.Hash][]byte, entries map[common.Hash][]byte) common.Hash {
+	t.Helper()
+	stack := trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		preimages[hash] = common.CopyBytes(blob)
+	})
+	keys := make([]common.Hash, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+	for _, key := range keys {
+		Require(t, stack.Update(key[:], entries[key]))
+	}
+	return stack.Hash()
+}
+
+func TestBuildExecutionWitness(t *testing.T) {
+	preimages := make(map[common.Hash][]byte)
+	slot := crypto.Keccak256Hash(common.Hash{}.Bytes())
+	slotValue, err := rlp.EncodeToBytes(common.TrimLeftZeroes(common.HexToHash("0x2a").Bytes()))
+	Require(t, err)
+	storageRoot := buildTestTrie(t, preimages, map[common.Hash][]byte{slot: slotValue})
+	code := []byte{0x60, 0x2a, 0x60, 0x00, 0x55}
+	codeHash := crypto.Keccak256Hash(code)
+	preimages[codeHash] = code
+
+	encodeAccount := func(nonce uint64, balance int64, root common.Hash, codeHash common.Hash) []byte {
+		enc, err := rlp.EncodeToBytes([]interface{}{nonce, big.NewInt(balance), root, codeHash.Bytes()})
+		Require(t, err)
+		return enc
+	}
+	contract := crypto.Keccak256Hash(common.HexToAddress("0x01").Bytes())
+	eoa := crypto.Keccak256Hash(common.HexToAddress("0x02").Bytes())
+	accounts := map[common.Hash][]byte{
+		contract: encodeAccount(1, 0, storageRoot, codeHash),
+		eoa:      encodeAccount(5, 1e18, types.EmptyRootHash, types.EmptyCodeHash),
+	}
+	for i := byte(3); i < 40; i++ {
+		accounts[crypto.Keccak256Hash(common.BytesToAddress([]byte{i}).Bytes())] = encodeAccount(0, int64(i), types.EmptyRootHash, types.EmptyCodeHash)
+	}
+	stateRoot := buildTestTrie(t, preimages, accounts)
+
+	parent := &types.Header{Number: big.NewInt(9), Root: stateRoot, Difficulty: common.Big1}
+	parentEnc, err := rlp.EncodeToBytes(parent)
+	Require(t, err)
+	preimages[parent.Hash()] = parentEnc
+	other := []byte("not a header")
+	preimages[crypto.Keccak256Hash(other)] = other
+
+	witness, err := BuildExecutionWitness(parent.Hash(), common.HexToHash("0xbeef"), preimages)
+	Require(t, err)
+	if witness.BlockNumber != 10 || witness.ParentStateRoot != stateRoot {
+		Fail(t, "unexpected block", witness.BlockNumber, witness.ParentStateRoot)
+	}
+	if len(witness.Accounts) != len(accounts) {
+		Fail(t, "expected", len(accounts), "accounts but got", len(witness.Accounts))
+	}
+	for _, account := range witness.Accounts {
+		switch account.AddressHash {
+		case contract:
+			if len(account.Storage) != 1 || account.Storage[0].KeyHash != slot || account.Storage[0].Value != common.HexToHash("0x2a") {
+				Fail(t, "unexpected contract storage", account.Storage)
+			}
+		case eoa:
+			if account.Nonce != 5 || account.Balance.ToInt().Cmp(big.NewInt(1e18)) != 0 {
+				Fail(t, "unexpected account", account)
+			}
+		}
+	}
+	if len(witness.Codes) != 1 || !bytes.Equal(witness.Codes[0], code) {
+		Fail(t, "unexpected codes", witness.Codes)
+	}
+	if len(witness.Headers) != 1 || !bytes.Equal(witness.Headers[0], parentEnc) {
+		Fail(t, "unexpected headers", witness.Headers)
+	}
+	if len(witness.Preimages) != 1 || !bytes.Equal(witness.Preimages[0], other) {
+		Fail(t, "unexpected preimages", witness.Preimages)
+	}
+	if len(witness.State)+len(witness.Codes)+len(witness.Headers)+len(witness.Preimages) != len(preimages) {
+		Fail(t, "not every preimage is in the witness")
+	}
+
+	// Without the nodes of part of the trie, only the accounts the block reached are reported
+	delete(preimages, stateRoot)
+	witness, err = BuildExecutionWitness(parent.Hash(), common.Hash{}, preimages)
+	Require(t, err)
+	if len(witness.Accounts) != 0 || len(witness.State) != 0 {
+		Fail(t, "accounts reported without the state root", len(witness.Accounts))
+	}
+}
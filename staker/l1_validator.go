@@ -454,8 +454,12 @@ func (v *L1Validator) generateNodeAction(
 		return correctNode, wrongNodesExist, nil
 	}
 
-	makeAssertionInterval := stakerConfig.MakeAssertionInterval
-	if wrongNodesExist || (strategy >= MakeNodesStrategy && time.Since(startStateProposedTime) >= makeAssertionInterval) {
+	makeAssertion := time.Since(startStateProposedTime) >= stakerConfig.MakeAssertionInterval
+	if stakerConfig.AutoParams.Enable {
+		// Assert as soon as the rollup allows, plus a margin so the assertion doesn't land too early
+		makeAssertion = timeSinceProposed.Cmp(paddedAssertionPeriod(minAssertionPeriod, stakerConfig.AutoParams.AssertionPeriodMarginBips)) >= 0
+	}
+	if wrongNodesExist || (strategy >= MakeNodesStrategy && makeAssertion) {
 		// There's no correct node; create one.
 		var lastNodeHashIfExists *common.Hash
 		if len(successorNodes) > 0 {
@@ -18,7 +18,6 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/google/btree"
 	flag "github.com/spf13/pflag"
 
@@ -95,6 +94,7 @@ type L1ValidatorConfig struct {
 	ParentChainWallet         genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
 	LogQueryBatchSize         uint64                      `koanf:"log-query-batch-size" reload:"hot"`
 	EnableFastConfirmation    bool                        `koanf:"enable-fast-confirmation"`
+	AutoParams                AutoParamsConfig            `koanf:"auto-params"`
 
 	strategy    StakerStrategy
 	gasRefunder common.Address
@@ -140,7 +140,7 @@ func (c *L1ValidatorConfig) Validate() error {
 		return errors.New("invalid validator gas refunder address")
 	}
 	c.gasRefunder = common.HexToAddress(c.GasRefunderAddress)
-	return nil
+	return c.AutoParams.Validate()
 }
 
 type L1ValidatorConfigFetcher func() *L1ValidatorConfig
@@ -165,6 +165,7 @@ var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	ParentChainWallet:         DefaultValidatorL1WalletConfig,
 	LogQueryBatchSize:         0,
 	EnableFastConfirmation:    false,
+	AutoParams:                DefaultAutoParamsConfig,
 }
 
 var TestL1ValidatorConfig = L1ValidatorConfig{
@@ -187,6 +188,7 @@ var TestL1ValidatorConfig = L1ValidatorConfig{
 	ParentChainWallet:         DefaultValidatorL1WalletConfig,
 	LogQueryBatchSize:         0,
 	EnableFastConfirmation:    false,
+	AutoParams:                DefaultAutoParamsConfig,
 }
 
 var DefaultValidatorL1WalletConfig = genericconf.WalletConfig{
@@ -217,6 +219,7 @@ func L1ValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	genericconf.WalletConfigAddOptions(prefix+".parent-chain-wallet", f, DefaultL1ValidatorConfig.ParentChainWallet.Pathname)
 	f.Bool(prefix+".enable-fast-confirmation", DefaultL1ValidatorConfig.EnableFastConfirmation, "enable fast confirmation")
+	AutoParamsConfigAddOptions(prefix+".auto-params", f)
 }
 
 type DangerousConfig struct {
@@ -270,6 +273,7 @@ type Staker struct {
 	statelessBlockValidator *StatelessBlockValidator
 	fatalErr                chan<- error
 	fastConfirmSafe         *FastConfirmSafe
+	paused                  func() bool // may be nil
 }
 
 type ValidatorWalletInterface interface {
@@ -455,7 +459,11 @@ func (s *Staker) tryFastConfirmation(ctx context.Context, blockHash common.Hash,
 func (s *Staker) getLatestStakedState(ctx context.Context, staker common.Address) (uint64, arbutil.MessageIndex, *validator.GoGlobalState, error) {
 	callOpts := s.getCallOpts(ctx)
 	if s.l1Reader.UseFinalityData() {
-		callOpts.BlockNumber = big.NewInt(int64(rpc.FinalizedBlockNumber))
+		finalized, err := s.l1Reader.FinalizedBlockTag(ctx)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		callOpts.BlockNumber = finalized
 	}
 	latestStaked, _, err := s.validatorUtils.LatestStaked(s.getCallOpts(ctx), s.rollupAddress, staker)
 	if err != nil {
@@ -505,6 +513,15 @@ func (s *Staker) StopAndWait() {
 	}
 }
 
+// SetPausedCheck makes the staker skip acting on the rollup while paused returns true. It keeps tracking
+// the latest staked and confirmed assertions.
+func (s *Staker) SetPausedCheck(paused func() bool) {
+	if s.Started() {
+		panic("trying to set paused check after start")
+	}
+	s.paused = paused
+}
+
 func (s *Staker) Start(ctxIn context.Context) {
 	if s.Strategy() != WatchtowerStrategy {
 		s.wallet.Start(ctxIn)
@@ -525,6 +542,10 @@ func (s *Staker) Start(ctxIn context.Context) {
 		}()
 		var err error
 		cfg := s.config()
+		if s.paused != nil && s.paused() {
+			log.Debug("staker is paused, not acting")
+			return cfg.StakerInterval
+		}
 		if common.HexToAddress(cfg.GasRefunderAddress) != (common.Address{}) {
 			gasRefunderBalance, err := s.client.BalanceAt(ctx, common.HexToAddress(cfg.GasRefunderAddress), nil)
 			if err != nil {
@@ -537,6 +558,9 @@ func (s *Staker) Start(ctxIn context.Context) {
 		if err != nil {
 			log.Warn("error updating latest wasm module root", "err", err)
 		}
+		if cfg.AutoParams.Enable && s.Strategy() != WatchtowerStrategy {
+			s.checkAutoParams(ctx)
+		}
 		arbTx, err := s.Act(ctx)
 		if err == nil && arbTx != nil {
 			_, err = s.l1Reader.WaitForTxApproval(ctx, arbTx)
@@ -607,19 +631,11 @@ func (s *Staker) Start(ctxIn context.Context) {
 }
 
 func (s *Staker) IsWhitelisted(ctx context.Context) (bool, error) {
-	callOpts := s.getCallOpts(ctx)
-	whitelistDisabled, err := s.rollup.ValidatorWhitelistDisabled(callOpts)
+	status, err := s.AllowlistStatus(ctx)
 	if err != nil {
 		return false, err
 	}
-	if whitelistDisabled {
-		return true, nil
-	}
-	addr := s.wallet.Address()
-	if addr != nil {
-		return s.rollup.IsValidator(callOpts, *addr)
-	}
-	return false, nil
+	return status.Allowed, nil
 }
 
 func (s *Staker) shouldAct(ctx context.Context) bool {
@@ -695,17 +711,19 @@ func (s *Staker) confirmDataPosterIsReady(ctx context.Context) error {
 
 func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	cfg := s.config()
+	allowed := true
 	if cfg.strategy != WatchtowerStrategy {
 		err := s.confirmDataPosterIsReady(ctx)
 		if err != nil {
 			return nil, err
 		}
-		whitelisted, err := s.IsWhitelisted(ctx)
+		allowlist, err := s.AllowlistStatus(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("error checking if whitelisted: %w", err)
+			return nil, err
 		}
-		if !whitelisted {
-			log.Warn("validator address isn't whitelisted", "address", s.wallet.Address(), "txSender", s.wallet.TxSenderAddress())
+		allowed = allowlist.Allowed
+		if !allowed {
+			log.Error("validator address isn't on the rollup's validator allowlist, only watching until it's added", "address", allowlist.Address, "txSender", s.wallet.TxSenderAddress())
 		}
 	}
 	if !s.shouldAct(ctx) {
@@ -773,6 +791,10 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		}
 		s.inactiveLastCheckedNode = nil
 	}
+	if !allowed {
+		// The rollup would revert anything but challenge moves and withdrawals from this address
+		effectiveStrategy = WatchtowerStrategy
+	}
 	if effectiveStrategy <= DefensiveStrategy && s.inactiveLastCheckedNode != nil {
 		info.LatestStakedNode = s.inactiveLastCheckedNode.id
 		info.LatestStakedNodeHash = s.inactiveLastCheckedNode.hash
@@ -881,7 +903,7 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	}
 
 	// If we have an old stake, remove it
-	if rawInfo != nil && rawInfo.LatestStakedNode <= latestConfirmedNode && canActFurther() {
+	if rawInfo != nil && rawInfo.LatestStakedNode <= latestConfirmedNode && allowed && canActFurther() {
 		stakeIsTooOutdated := rawInfo.LatestStakedNode < latestConfirmedNode
 		// We're not trying to stake anyways
 		stakeIsUnwanted := effectiveStrategy < StakeLatestStrategy
@@ -945,7 +967,7 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		}
 	}
 
-	if rawInfo != nil && s.builder.BuildingTransactionCount() == 0 && canActFurther() {
+	if rawInfo != nil && s.builder.BuildingTransactionCount() == 0 && allowed && canActFurther() {
 		if err := s.createConflict(ctx, rawInfo); err != nil {
 			return nil, fmt.Errorf("error creating conflict: %w", err)
 		}
@@ -963,6 +985,10 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 
 func (s *Staker) handleConflict(ctx context.Context, info *StakerInfo) error {
 	if info.CurrentChallenge == nil {
+		if s.activeChallenge != nil && s.blockValidator != nil {
+			s.blockValidator.ClearDisputedRange()
+			s.blockValidator.PublishChallengeEnded(s.activeChallenge.ChallengeIndex())
+		}
 		s.activeChallenge = nil
 		return nil
 	}
@@ -991,6 +1017,11 @@ func (s *Staker) handleConflict(ctx context.Context, info *StakerInfo) error {
 		}
 
 		s.activeChallenge = newChallengeManager
+		if s.blockValidator != nil {
+			start, end := newChallengeManager.DisputedMessageRange()
+			s.blockValidator.SetDisputedRange(start, end)
+			s.blockValidator.PublishChallengeStarted(newChallengeManager.ChallengeIndex(), start, end)
+		}
 	}
 
 	_, err := s.activeChallenge.Act(ctx)
@@ -1048,9 +1079,9 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		}
 
 		// If we have no stake yet, we'll put one down
-		stakeAmount, err := s.rollup.CurrentRequiredStake(s.getCallOpts(ctx))
+		stakeAmount, err := s.stakeAmount(ctx)
 		if err != nil {
-			return fmt.Errorf("error getting current required stake: %w", err)
+			return err
 		}
 		auth, err := s.builder.AuthWithAmount(ctx, stakeAmount)
 		if err != nil {
@@ -1102,9 +1133,9 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		}
 
 		// If we have no stake yet, we'll put one down
-		stakeAmount, err := s.rollup.CurrentRequiredStake(s.getCallOpts(ctx))
+		stakeAmount, err := s.stakeAmount(ctx)
 		if err != nil {
-			return fmt.Errorf("error getting current required stake: %w", err)
+			return err
 		}
 		auth, err := s.builder.AuthWithAmount(ctx, stakeAmount)
 		if err != nil {
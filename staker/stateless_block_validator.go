@@ -542,6 +542,32 @@ func (v *StatelessBlockValidator) GetLatestWasmModuleRoot(ctx context.Context) (
 	return common.Hash{}, fmt.Errorf("couldn't detect latest WasmModuleRoot: %w", lastErr)
 }
 
+// WasmModuleRoots returns the module roots any of the validation servers can validate with.
+func (v *StatelessBlockValidator) WasmModuleRoots() ([]common.Hash, error) {
+	var spawners []validator.ValidationSpawner
+	if v.redisValidator != nil {
+		spawners = append(spawners, v.redisValidator)
+	}
+	for _, spawner := range v.execSpawners {
+		spawners = append(spawners, spawner)
+	}
+	seen := make(map[common.Hash]bool)
+	var roots []common.Hash
+	for _, spawner := range spawners {
+		supported, err := spawner.WasmModuleRoots()
+		if err != nil {
+			return nil, fmt.Errorf("getting module roots of %v: %w", spawner.Name(), err)
+		}
+		for _, root := range supported {
+			if !seen[root] {
+				seen[root] = true
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots, nil
+}
+
 func (v *StatelessBlockValidator) Start(ctx_in context.Context) error {
 	if v.redisValidator != nil {
 		if err := v.redisValidator.Start(ctx_in); err != nil {
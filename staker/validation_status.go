@@ -0,0 +1,131 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var validationStatusDroppedCounter = metrics.NewRegisteredCounter("arb/validator/status/dropped", nil)
+
+type ValidationStatusKind string
+
+const (
+	// The state when subscribing, before any change
+	ValidationStatusSnapshot         ValidationStatusKind = "snapshot"
+	ValidationStatusValidated        ValidationStatusKind = "validated"
+	ValidationStatusFailed           ValidationStatusKind = "failed"
+	ValidationStatusModuleRoot       ValidationStatusKind = "moduleRootChanged"
+	ValidationStatusChallengeStarted ValidationStatusKind = "challengeStarted"
+	ValidationStatusChallengeEnded   ValidationStatusKind = "challengeEnded"
+)
+
+// ValidationStatus is a change in the block validator's progress, along with where it stands.
+type ValidationStatus struct {
+	Kind      ValidationStatusKind `json:"kind"`
+	Timestamp int64                `json:"timestamp"` // unix milliseconds
+	// How many messages have been validated, and the module roots they're validated against
+	ValidatedMessageCount arbutil.MessageIndex     `json:"validatedMessageCount"`
+	WasmModuleRoots       []common.Hash            `json:"wasmModuleRoots"`
+	GlobalState           *validator.GoGlobalState `json:"globalState,omitempty"`
+	// For failed validations
+	FailedMessage *arbutil.MessageIndex `json:"failedMessage,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	// For challenge transitions
+	ChallengeIndex *uint64               `json:"challengeIndex,omitempty"`
+	DisputedStart  *arbutil.MessageIndex `json:"disputedStart,omitempty"`
+	DisputedEnd    *arbutil.MessageIndex `json:"disputedEnd,omitempty"`
+}
+
+// ValidationStatusFeed fans validation status changes out to subscribers. A subscriber that falls behind
+// misses changes rather than stalling validation.
+type ValidationStatusFeed struct {
+	mutex       sync.Mutex
+	subscribers map[chan ValidationStatus]struct{}
+}
+
+func NewValidationStatusFeed() *ValidationStatusFeed {
+	return &ValidationStatusFeed{subscribers: make(map[chan ValidationStatus]struct{})}
+}
+
+// Subscribe returns a channel of status changes buffering up to buffer of them, and a function to unsubscribe.
+func (f *ValidationStatusFeed) Subscribe(buffer int) (<-chan ValidationStatus, func()) {
+	ch := make(chan ValidationStatus, buffer)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.subscribers[ch] = struct{}{}
+	return ch, func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(f.subscribers, ch)
+	}
+}
+
+func (f *ValidationStatusFeed) send(status ValidationStatus) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for ch := range f.subscribers {
+		select {
+		case ch <- status:
+		default:
+			validationStatusDroppedCounter.Inc(1)
+		}
+	}
+}
+
+func (v *BlockValidator) StatusFeed() *ValidationStatusFeed {
+	return v.statusFeed
+}
+
+func (v *BlockValidator) newStatus(kind ValidationStatusKind) ValidationStatus {
+	return ValidationStatus{
+		Kind:                  kind,
+		Timestamp:             time.Now().UnixMilli(),
+		ValidatedMessageCount: v.validated(),
+		WasmModuleRoots:       v.GetModuleRootsToValidate(),
+	}
+}
+
+// Status returns where validation stands, as sent when subscribing.
+func (v *BlockValidator) Status() (ValidationStatus, error) {
+	status := v.newStatus(ValidationStatusSnapshot)
+	info, err := v.ReadLastValidatedInfo()
+	if err != nil {
+		return status, err
+	}
+	if info != nil {
+		status.GlobalState = &info.GlobalState
+	}
+	v.disputedMutex.RLock()
+	defer v.disputedMutex.RUnlock()
+	if v.disputedEnd > v.disputedStart {
+		start, end := v.disputedStart, v.disputedEnd
+		status.DisputedStart = &start
+		status.DisputedEnd = &end
+	}
+	return status, nil
+}
+
+// PublishChallengeStarted reports that the staker entered a challenge over the given range of messages.
+func (v *BlockValidator) PublishChallengeStarted(challengeIndex uint64, start, end arbutil.MessageIndex) {
+	status := v.newStatus(ValidationStatusChallengeStarted)
+	status.ChallengeIndex = &challengeIndex
+	status.DisputedStart = &start
+	status.DisputedEnd = &end
+	v.statusFeed.send(status)
+}
+
+// PublishChallengeEnded reports that the staker is no longer in a challenge.
+func (v *BlockValidator) PublishChallengeEnded(challengeIndex uint64) {
+	status := v.newStatus(ValidationStatusChallengeEnded)
+	status.ChallengeIndex = &challengeIndex
+	v.statusFeed.send(status)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"testing"
+)
+
+func TestValidationStatusFeed(t *testing.T) {
+	feed := NewValidationStatusFeed()
+	fast, unsubscribeFast := feed.Subscribe(4)
+	defer unsubscribeFast()
+	slow, unsubscribeSlow := feed.Subscribe(1)
+
+	feed.send(ValidationStatus{Kind: ValidationStatusValidated, ValidatedMessageCount: 1})
+	feed.send(ValidationStatus{Kind: ValidationStatusValidated, ValidatedMessageCount: 2})
+
+	for want := 1; want <= 2; want++ {
+		status := <-fast
+		if int(status.ValidatedMessageCount) != want {
+			Fail(t, "expected validated message count", want, "got", status.ValidatedMessageCount)
+		}
+	}
+	// The slow subscriber misses what doesn't fit in its buffer instead of blocking the feed
+	if status := <-slow; status.ValidatedMessageCount != 1 {
+		Fail(t, "expected the first status but got", status.ValidatedMessageCount)
+	}
+	select {
+	case status := <-slow:
+		Fail(t, "expected the second status to be dropped but got", status.ValidatedMessageCount)
+	default:
+	}
+
+	unsubscribeSlow()
+	feed.send(ValidationStatus{Kind: ValidationStatusModuleRoot})
+	if status := <-fast; status.Kind != ValidationStatusModuleRoot {
+		Fail(t, "expected a module root change but got", status.Kind)
+	}
+	select {
+	case status := <-slow:
+		Fail(t, "got a status after unsubscribing", status.Kind)
+	default:
+	}
+}
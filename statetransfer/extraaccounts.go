@@ -0,0 +1,74 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package statetransfer
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type extraAccountsInitDataReader struct {
+	InitDataReader
+	accounts []AccountInitializationInfo
+}
+
+// WithExtraAccounts returns an InitDataReader with accounts following those of reader.
+// Reading an account from reader with the address of one of the extra accounts is an error.
+func WithExtraAccounts(reader InitDataReader, accounts []AccountInitializationInfo) InitDataReader {
+	return &extraAccountsInitDataReader{
+		InitDataReader: reader,
+		accounts:       accounts,
+	}
+}
+
+func (r *extraAccountsInitDataReader) GetAccountDataReader() (AccountDataReader, error) {
+	inner, err := r.InitDataReader.GetAccountDataReader()
+	if err != nil {
+		return nil, err
+	}
+	extra := make(map[common.Address]struct{}, len(r.accounts))
+	for _, account := range r.accounts {
+		extra[account.Addr] = struct{}{}
+	}
+	return &extraAccountDataReader{
+		inner:    inner,
+		accounts: r.accounts,
+		extra:    extra,
+	}, nil
+}
+
+type extraAccountDataReader struct {
+	inner    AccountDataReader
+	accounts []AccountInitializationInfo
+	extra    map[common.Address]struct{}
+	count    int
+}
+
+func (r *extraAccountDataReader) More() bool {
+	return r.inner.More() || r.count < len(r.accounts)
+}
+
+func (r *extraAccountDataReader) GetNext() (*AccountInitializationInfo, error) {
+	if r.inner.More() {
+		account, err := r.inner.GetNext()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := r.extra[account.Addr]; exists {
+			return nil, fmt.Errorf("account %v is both imported and predeployed", account.Addr)
+		}
+		return account, nil
+	}
+	if r.count >= len(r.accounts) {
+		return nil, errNoMore
+	}
+	r.count++
+	return &r.accounts[r.count-1], nil
+}
+
+func (r *extraAccountDataReader) Close() error {
+	r.count = len(r.accounts)
+	return r.inner.Close()
+}
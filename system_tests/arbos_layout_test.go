@@ -0,0 +1,47 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+func TestArbosStorageLayout(t *testing.T) {
+	t.Parallel()
+	for _, arbosVersion := range []uint64{params.ArbosVersion_StylusChargingFixes, arbosState.ArbosVersion_40} {
+		testArbosStorageLayout(t, arbosVersion)
+	}
+}
+
+func testArbosStorageLayout(t *testing.T, arbosVersion uint64) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false).WithArbOSVersion(arbosVersion)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	// Write to a few subsystems before checking the layout
+	auth := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	tx, err := arbOwner.SetInfraFeeAccount(&auth, builder.L2Info.GetAddress("Owner"))
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	builder.L2Info.GenerateAccount("User2")
+	builder.L2.TransferBalance(t, "Owner", "User2", big.NewInt(1e12), builder.L2Info)
+
+	statedb, err := builder.L2.ExecNode.Backend.ArbInterface().BlockChain().State()
+	Require(t, err)
+	Require(t, arbosState.CheckStorageLayout(statedb))
+}
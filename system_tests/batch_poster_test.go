@@ -24,6 +24,7 @@ import (
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/upgrade_executorgen"
 	"github.com/offchainlabs/nitro/util/redisutil"
+	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
 func TestBatchPosterParallel(t *testing.T) {
@@ -363,3 +364,37 @@ func TestAllowPostingFirstBatchWhenSequencerMessageCountMismatchEnabled(t *testi
 func TestAllowPostingFirstBatchWhenSequencerMessageCountMismatchDisabled(t *testing.T) {
 	testAllowPostingFirstBatchWhenSequencerMessageCountMismatch(t, false)
 }
+
+func TestBatchPosterDryRun(t *testing.T) {
+	logHandler := testhelpers.InitTestLog(t, log.LvlInfo)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.nodeConfig.BatchPoster.DryRun = true
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	batchCount, err := builder.L2.ConsensusNode.InboxTracker.GetBatchCount()
+	Require(t, err)
+
+	builder.L2Info.GenerateAccount("User2")
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	for i := 0; !logHandler.WasLogged("BatchPoster: dry run batch"); i++ {
+		if i > 100 {
+			Fatal(t, "dry run batch wasn't built")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// Give a real batch time to land, which it mustn't
+	time.Sleep(time.Second)
+	newBatchCount, err := builder.L2.ConsensusNode.InboxTracker.GetBatchCount()
+	Require(t, err)
+	if newBatchCount != batchCount {
+		Fatal(t, "dry run posted batches", batchCount, "to", newBatchCount)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestBlockBuilderPayload(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.execConfig.Sequencer.BlockBuilder.Enable = true
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	builder.L2Info.GenerateAccount("User3")
+	fund := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e18), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, fund))
+	_, err := builder.L2.EnsureTxSucceeded(fund)
+	Require(t, err)
+
+	first := builder.L2Info.PrepareTx("User2", "User3", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	second := builder.L2Info.PrepareTx("Owner", "User3", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	encode := func(txs ...*types.Transaction) []hexutil.Bytes {
+		var encoded []hexutil.Bytes
+		for _, tx := range txs {
+			data, err := tx.MarshalBinary()
+			Require(t, err)
+			encoded = append(encoded, data)
+		}
+		return encoded
+	}
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	var status gethexec.BuilderPayloadStatus
+	Require(t, rpcClient.CallContext(ctx, &status, "builder_submitPayload", common.Hash{1}, encode(first, second)))
+	if status.Status != gethexec.BuilderPayloadInvalid {
+		Fatal(t, "accepted a payload not built on the head", status)
+	}
+
+	head, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+	Require(t, rpcClient.CallContext(ctx, &status, "builder_submitPayload", head.Hash(), encode(first, second)))
+	if status.Status != gethexec.BuilderPayloadAdopted || len(status.TxErrors) != 2 || status.TxErrors[0] != "" || status.TxErrors[1] != "" {
+		Fatal(t, "payload not adopted", status)
+	}
+	firstReceipt, err := builder.L2.EnsureTxSucceeded(first)
+	Require(t, err)
+	secondReceipt, err := builder.L2.EnsureTxSucceeded(second)
+	Require(t, err)
+	// The block is the builder's ordering, after the start block transaction
+	if firstReceipt.BlockHash != secondReceipt.BlockHash || firstReceipt.BlockNumber.Uint64() != head.Number.Uint64()+1 {
+		Fatal(t, "payload not sequenced as the next block", firstReceipt.BlockNumber, secondReceipt.BlockNumber)
+	}
+	if firstReceipt.TransactionIndex != 1 || secondReceipt.TransactionIndex != 2 {
+		Fatal(t, "payload reordered", firstReceipt.TransactionIndex, secondReceipt.TransactionIndex)
+	}
+
+	// The head moved on, so a payload built on the old one is invalid
+	stale := builder.L2Info.PrepareTx("Owner", "User3", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	Require(t, rpcClient.CallContext(ctx, &status, "builder_submitPayload", head.Hash(), encode(stale)))
+	if status.Status != gethexec.BuilderPayloadInvalid {
+		Fatal(t, "accepted a stale payload", status)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestEstimateCalldataFootprint(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+
+	estimateAndSend := func(data []byte) *gethexec.CalldataFootprint {
+		t.Helper()
+		tx := builder.L2Info.PrepareTx("Owner", "User2", 1_000_000, nil, data)
+		input, err := tx.MarshalBinary()
+		Require(t, err)
+		var footprint gethexec.CalldataFootprint
+		Require(t, rpcClient.CallContext(ctx, &footprint, "arb_estimateCalldataFootprint", hexutil.Bytes(input)))
+		if uint64(footprint.Size) != uint64(len(input)) {
+			Fatal(t, "footprint size", footprint.Size, "doesn't match transaction size", len(input))
+		}
+		Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+		receipt, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		// Without a parent chain the L1 price doesn't change, so the estimate is exact
+		if receipt.GasUsedForL1 != uint64(footprint.PosterGas) {
+			Fatal(t, "estimated poster gas", footprint.PosterGas, "but was charged", receipt.GasUsedForL1)
+		}
+		return &footprint
+	}
+
+	random := estimateAndSend(testhelpers.RandomizeSlice(make([]byte, 1000)))
+	zeros := estimateAndSend(make([]byte, 1000))
+	if zeros.ChargedSize >= random.ChargedSize || zeros.BatchSize >= random.BatchSize {
+		Fatal(t, "compressible calldata didn't have a smaller footprint", zeros, random)
+	}
+	if random.BatchSize == 0 || random.L1Fee.ToInt().Sign() <= 0 {
+		Fatal(t, "random calldata had no footprint", random)
+	}
+
+	// Transactions without calldata costs are rejected
+	internal := types.NewTx(&types.ArbitrumInternalTx{ChainId: builder.L2Info.Signer.ChainID()})
+	input, err := internal.MarshalBinary()
+	Require(t, err)
+	var footprint gethexec.CalldataFootprint
+	if err := rpcClient.CallContext(ctx, &footprint, "arb_estimateCalldataFootprint", hexutil.Bytes(input)); err == nil {
+		Fatal(t, "footprint of an internal transaction didn't fail")
+	}
+}
@@ -103,6 +103,8 @@ type TestClient struct {
 	Stack         *node.Node
 	ConsensusNode *arbnode.Node
 	ExecNode      *gethexec.ExecutionNode
+	// Serves the blobs posted to a parent chain built with WithL1Blobs
+	Beacon *simulatedBeaconServer
 
 	// having cleanup() field makes cleanup customizable from default cleanup methods after calling build
 	cleanup func()
@@ -252,11 +254,16 @@ type NodeBuilder struct {
 	l3InitMessage               *arbostypes.ParsedInitMessage
 	withProdConfirmPeriodBlocks bool
 	wasmCacheTag                uint32
+	redundantSequencers         int
+	withL1Blobs                 bool
+	faults                      *faultInjector // nil unless WithFaults was called
 
 	// Created nodes
 	L1 *TestClient
 	L2 *TestClient
 	L3 *TestClient
+	// With redundant sequencers, every sequencer-capable node in priority order, starting with L2
+	Sequencers []*TestClient
 }
 
 type NitroConfig struct {
@@ -364,8 +371,27 @@ func (b *NodeBuilder) WithStylusLongTermCache(enabled bool) *NodeBuilder {
 	return b
 }
 
+// WithRedundantSequencers makes Build stand up n sequencer-capable L2 nodes sharing a redis sequencer
+// coordinator, in priority order starting with L2. Only L2 posts batches. Requires an L1.
+func (b *NodeBuilder) WithRedundantSequencers(n int) *NodeBuilder {
+	b.redundantSequencers = n
+	return b
+}
+
+// WithL1Blobs makes the L1 serve the blobs posted to it over a simulated beacon API, has the batch poster post
+// blobs and has the L2 nodes read them back through it.
+func (b *NodeBuilder) WithL1Blobs() *NodeBuilder {
+	b.withL1Blobs = true
+	b.nodeConfig.BatchPoster.Post4844Blobs = true
+	b.nodeConfig.BatchPoster.IgnoreBlobPrice = true
+	return b
+}
+
 func (b *NodeBuilder) Build(t *testing.T) func() {
 	b.CheckConfig(t)
+	if b.redundantSequencers > 0 {
+		return b.buildRedundantSequencers(t)
+	}
 	if b.withL1 {
 		b.BuildL1(t)
 		return b.BuildL2OnL1(t)
@@ -400,7 +426,7 @@ func (b *NodeBuilder) CheckConfig(t *testing.T) {
 
 func (b *NodeBuilder) BuildL1(t *testing.T) {
 	b.L1 = NewTestClient(b.ctx)
-	b.L1Info, b.L1.Client, b.L1.L1Backend, b.L1.Stack = createTestL1BlockChain(t, b.L1Info)
+	b.L1Info, b.L1.Client, b.L1.L1Backend, b.L1.Stack, b.L1.Beacon = createTestL1BlockChain(t, b.L1Info, b.withL1Blobs)
 	locator, err := server_common.NewMachineLocator(b.valnodeConfig.Wasm.RootPath)
 	Require(t, err)
 	b.addresses, b.initMessage = deployOnParentChain(
@@ -479,10 +505,15 @@ func buildOnParentChain(
 	execNode, err := gethexec.CreateExecutionNode(ctx, chainTestClient.Stack, chainDb, blockchain, parentChainTestClient.Client, execConfigFetcher)
 	Require(t, err)
 
+	var blobReader daprovider.BlobReader
+	if parentChainTestClient.Beacon != nil {
+		blobReader = parentChainTestClient.Beacon.BlobReader(t, parentChainTestClient.Client)
+	}
+
 	fatalErrChan := make(chan error, 10)
 	chainTestClient.ConsensusNode, err = arbnode.CreateNode(
 		ctx, chainTestClient.Stack, execNode, arbDb, NewFetcherFromConfig(nodeConfig), blockchain.Config(), parentChainTestClient.Client,
-		addresses, validatorTxOptsPtr, sequencerTxOptsPtr, dataSigner, fatalErrChan, parentChainId, nil)
+		addresses, validatorTxOptsPtr, sequencerTxOptsPtr, dataSigner, fatalErrChan, parentChainId, blobReader)
 	Require(t, err)
 
 	err = chainTestClient.ConsensusNode.Start(ctx)
@@ -548,6 +579,12 @@ func (b *NodeBuilder) BuildL3OnL2(t *testing.T) func() {
 }
 
 func (b *NodeBuilder) BuildL2OnL1(t *testing.T) func() {
+	parentChain := b.L1
+	if b.faults != nil {
+		b.faults.init(t)
+		parentChain = b.faults.wrapParentChain(t, b.ctx, b.L1)
+		b.faults.relayFeed(t, b.ctx, b)
+	}
 	b.L2 = buildOnParentChain(
 		t,
 		b.ctx,
@@ -555,7 +592,7 @@ func (b *NodeBuilder) BuildL2OnL1(t *testing.T) func() {
 		b.dataDir,
 
 		b.L1Info,
-		b.L1,
+		parentChain,
 		big.NewInt(1337),
 
 		b.chainConfig,
@@ -571,6 +608,14 @@ func (b *NodeBuilder) BuildL2OnL1(t *testing.T) func() {
 
 		b.wasmCacheTag,
 	)
+	if b.faults != nil {
+		b.faults.start(t, b.ctx, b, big.NewInt(1337))
+		nodeCleanup := b.L2.cleanup
+		b.L2.cleanup = func() {
+			b.faults.stopCrashes()
+			nodeCleanup()
+		}
+	}
 
 	return func() {
 		b.L2.cleanup()
@@ -580,10 +625,113 @@ func (b *NodeBuilder) BuildL2OnL1(t *testing.T) func() {
 	}
 }
 
+func (b *NodeBuilder) buildRedundantSequencers(t *testing.T) func() {
+	if !b.withL1 {
+		t.Fatal("redundant sequencers require an L1")
+	}
+	coordinator := &b.nodeConfig.SeqCoordinator
+	coordinator.Enable = true
+	if coordinator.RedisUrl == "" {
+		coordinator.RedisUrl = redisutil.CreateTestRedis(b.ctx, t)
+	}
+	// stdio protocol makes sure forwarder initialization doesn't fail
+	nodeNames := make([]string, b.redundantSequencers)
+	for i := range nodeNames {
+		nodeNames[i] = "stdio://sequencer" + strconv.Itoa(i)
+	}
+	initRedisForTest(t, b.ctx, coordinator.RedisUrl, nodeNames)
+	coordinator.MyUrl = nodeNames[0]
+
+	b.BuildL1(t)
+	cleanups := []func(){b.BuildL2OnL1(t)}
+	b.Sequencers = []*TestClient{b.L2}
+	for _, name := range nodeNames[1:] {
+		nodeConfig := *b.nodeConfig
+		nodeConfig.SeqCoordinator.MyUrl = name
+		nodeConfig.BatchPoster.Enable = false
+		nodeConfig.Feed.Output = *newBroadcasterConfigTest()
+		sequencer, cleanup := b.Build2ndNode(t, &SecondNodeParams{nodeConfig: &nodeConfig})
+		b.Sequencers = append(b.Sequencers, sequencer)
+		cleanups = append(cleanups, cleanup)
+	}
+	return func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+}
+
+// ChosenSequencer returns the index in Sequencers of the node holding the coordinator's lockout, or -1 if none does.
+func (b *NodeBuilder) ChosenSequencer(t *testing.T) int {
+	chosen := -1
+	for i, sequencer := range b.Sequencers {
+		if sequencer.ConsensusNode == nil || !sequencer.ConsensusNode.SeqCoordinator.CurrentlyChosen() {
+			continue
+		}
+		if chosen >= 0 {
+			Fatal(t, "sequencers", chosen, "and", i, "both hold the lockout")
+		}
+		chosen = i
+	}
+	return chosen
+}
+
+// WaitForChosenSequencer waits for a node to hold the coordinator's lockout and returns its index in Sequencers.
+func (b *NodeBuilder) WaitForChosenSequencer(t *testing.T) int {
+	config := &b.nodeConfig.SeqCoordinator
+	deadline := time.Now().Add(3 * config.LockoutDuration)
+	for {
+		if chosen := b.ChosenSequencer(t); chosen >= 0 {
+			return chosen
+		}
+		if time.Now().After(deadline) {
+			Fatal(t, "timed out waiting for a sequencer to hold the lockout")
+		}
+		time.Sleep(config.UpdateInterval / 3)
+	}
+}
+
+// RequireChosenSequencer waits for the node at the given index in Sequencers to hold the coordinator's lockout.
+func (b *NodeBuilder) RequireChosenSequencer(t *testing.T, index int) {
+	config := &b.nodeConfig.SeqCoordinator
+	deadline := time.Now().Add(3 * config.LockoutDuration)
+	for b.ChosenSequencer(t) != index {
+		if time.Now().After(deadline) {
+			Fatal(t, "timed out waiting for sequencer", index, "to hold the lockout, chosen:", b.ChosenSequencer(t))
+		}
+		time.Sleep(config.UpdateInterval / 3)
+	}
+}
+
+// ForceSequencerFailover makes the chosen sequencer hand off the lockout, and returns the index in Sequencers
+// of the node taking it over. The old one avoids the lockout until its SeqCoordinator's SeekLockout is called.
+func (b *NodeBuilder) ForceSequencerFailover(t *testing.T) int {
+	from := b.WaitForChosenSequencer(t)
+	coordinator := b.Sequencers[from].ConsensusNode.SeqCoordinator
+	if !coordinator.AvoidLockout(b.ctx) {
+		Fatal(t, "sequencer", from, "failed to release wanting the lockout")
+	}
+	if !coordinator.TryToHandoffChosenOne(b.ctx) {
+		Fatal(t, "sequencer", from, "failed to hand off the lockout", coordinator.DebugPrint())
+	}
+	to := b.WaitForChosenSequencer(t)
+	if to == from {
+		Fatal(t, "sequencer", from, "still holds the lockout after handing it off")
+	}
+	return to
+}
+
 // L2 -Only. Enough for tests that needs no interface to L1
 // Requires precompiles.AllowDebugPrecompiles = true
 func (b *NodeBuilder) BuildL2(t *testing.T) func() {
 	b.L2 = NewTestClient(b.ctx)
+	if b.faults != nil {
+		if b.faults.needsL1() {
+			t.Fatal("L1 faults and crashes require an L1")
+		}
+		b.faults.init(t)
+		b.faults.relayFeed(t, b.ctx, b)
+	}
 
 	AddValNodeIfNeeded(t, b.ctx, b.nodeConfig, true, "", b.valnodeConfig.Wasm.RootPath)
 
@@ -719,7 +867,7 @@ func build2ndNode(
 
 	testClient := NewTestClient(ctx)
 	testClient.Client, testClient.ConsensusNode =
-		Create2ndNodeWithConfig(t, ctx, firstNodeTestClient.ConsensusNode, parentChainTestClient.Stack, parentChainInfo, params.initData, params.nodeConfig, params.execConfig, params.stackConfig, valnodeConfig, params.addresses, initMessage, params.wasmCacheTag)
+		Create2ndNodeWithConfig(t, ctx, firstNodeTestClient.ConsensusNode, parentChainTestClient.Stack, parentChainTestClient.Beacon, parentChainInfo, params.initData, params.nodeConfig, params.execConfig, params.stackConfig, valnodeConfig, params.addresses, initMessage, params.wasmCacheTag)
 	testClient.ExecNode = getExecNode(t, testClient.ConsensusNode)
 	testClient.cleanup = func() { testClient.ConsensusNode.StopAndWait() }
 	return testClient, func() { testClient.cleanup() }
@@ -1172,7 +1320,7 @@ func AddValNode(t *testing.T, ctx context.Context, nodeConfig *arbnode.Config, u
 	configByValidationNode(nodeConfig, valStack)
 }
 
-func createTestL1BlockChain(t *testing.T, l1info info) (info, *ethclient.Client, *eth.Ethereum, *node.Node) {
+func createTestL1BlockChain(t *testing.T, l1info info, withBeacon bool) (info, *ethclient.Client, *eth.Ethereum, *node.Node, *simulatedBeaconServer) {
 	if l1info == nil {
 		l1info = NewL1TestInfo(t)
 	}
@@ -1223,13 +1371,18 @@ func createTestL1BlockChain(t *testing.T, l1info info) (info, *ethclient.Client,
 	}})
 	stack.RegisterAPIs(tracers.APIs(l1backend.APIBackend))
 
+	var beacon *simulatedBeaconServer
+	if withBeacon {
+		beacon = newSimulatedBeaconServer(stack, l1backend)
+	}
+
 	Require(t, stack.Start())
 
 	rpcClient := stack.Attach()
 
 	l1Client := ethclient.NewClient(rpcClient)
 
-	return l1info, l1Client, l1backend, stack
+	return l1info, l1Client, l1backend, stack, beacon
 }
 
 func getInitMessage(ctx context.Context, t *testing.T, parentChainClient *ethclient.Client, addresses *chaininfo.RollupAddresses) *arbostypes.ParsedInitMessage {
@@ -1391,6 +1544,7 @@ func Create2ndNodeWithConfig(
 	ctx context.Context,
 	first *arbnode.Node,
 	parentChainStack *node.Node,
+	parentChainBeacon *simulatedBeaconServer,
 	parentChainInfo *BlockchainTestInfo,
 	chainInitData *statetransfer.ArbosInitializationInfo,
 	nodeConfig *arbnode.Config,
@@ -1446,7 +1600,12 @@ func Create2ndNodeWithConfig(
 	currentExec, err := gethexec.CreateExecutionNode(ctx, chainStack, chainDb, blockchain, parentChainClient, configFetcher)
 	Require(t, err)
 
-	currentNode, err := arbnode.CreateNode(ctx, chainStack, currentExec, arbDb, NewFetcherFromConfig(nodeConfig), blockchain.Config(), parentChainClient, addresses, &validatorTxOpts, &sequencerTxOpts, dataSigner, feedErrChan, big.NewInt(1337), nil)
+	var blobReader daprovider.BlobReader
+	if parentChainBeacon != nil {
+		blobReader = parentChainBeacon.BlobReader(t, parentChainClient)
+	}
+
+	currentNode, err := arbnode.CreateNode(ctx, chainStack, currentExec, arbDb, NewFetcherFromConfig(nodeConfig), blockchain.Config(), parentChainClient, addresses, &validatorTxOpts, &sequencerTxOpts, dataSigner, feedErrChan, big.NewInt(1337), blobReader)
 	Require(t, err)
 
 	err = currentNode.Start(ctx)
@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+)
+
+func TestDepositStatus(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	delayedTx := builder.L2Info.PrepareTx("Owner", "User2", 50001, big.NewInt(1e6), nil)
+	txbytes, err := delayedTx.MarshalBinary()
+	Require(t, err)
+	inbox, err := bridgegen.NewInbox(builder.L1Info.GetAddress("Inbox"), builder.L1.Client)
+	Require(t, err)
+	opts := builder.L1Info.GetDefaultTransactOpts("User", ctx)
+	l1Tx, err := inbox.SendL2Message(&opts, append([]byte{arbos.L2MessageKind_SignedTx}, txbytes...))
+	Require(t, err)
+	_, err = builder.L1.EnsureTxSucceeded(l1Tx)
+	Require(t, err)
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	getStatus := func() *arbnode.DepositInfo {
+		t.Helper()
+		var status arbnode.DepositStatus
+		Require(t, rpcClient.CallContext(ctx, &status, "arb_depositStatus", l1Tx.Hash()))
+		if len(status.Deposits) != 1 {
+			Fatal(t, "expected one deposit but got", len(status.Deposits))
+		}
+		deposit := status.Deposits[0]
+		if len(deposit.L2TxHashes) != 1 || deposit.L2TxHashes[0] != delayedTx.Hash() {
+			Fatal(t, "unexpected L2 transaction hashes", deposit.L2TxHashes, "expected", delayedTx.Hash())
+		}
+		return deposit
+	}
+	deposit := getStatus()
+	if deposit.State == arbnode.DepositStateSequenced || deposit.State == arbnode.DepositStatePosted {
+		Fatal(t, "deposit sequenced before its parent chain block could be final")
+	}
+	if deposit.EstimatedSequencingTime == nil || deposit.ForceInclusionTime == nil {
+		Fatal(t, "missing sequencing estimates for pending deposit")
+	}
+
+	for i := 0; i < 30; i++ {
+		builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+			builder.L1Info.PrepareTx("Faucet", "Faucet", 30000, big.NewInt(1e12), nil),
+		})
+	}
+	receipt, err := builder.L2.EnsureTxSucceeded(delayedTx)
+	Require(t, err)
+
+	deposit = getStatus()
+	if deposit.State != arbnode.DepositStateSequenced && deposit.State != arbnode.DepositStatePosted {
+		Fatal(t, "unexpected deposit state", deposit.State)
+	}
+	if deposit.L2Block == nil || *deposit.L2Block != receipt.BlockNumber.Uint64() {
+		Fatal(t, "deposit reported in the wrong block", deposit.L2Block, "expected", receipt.BlockNumber)
+	}
+}
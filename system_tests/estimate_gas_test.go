@@ -0,0 +1,72 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestEstimateGasForSequencer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.execConfig.Sequencer.MaxTxDataSize = 10000
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	owner := builder.L2Info.GetAddress("Owner")
+	user2 := builder.L2Info.GetAddress("User2")
+	estimate := func(args gethexec.EstimateGasArgs, options *arbitrum_types.ConditionalOptions) *gethexec.SequencerGasEstimate {
+		t.Helper()
+		var result gethexec.SequencerGasEstimate
+		Require(t, rpcClient.CallContext(ctx, &result, "arb_estimateGasForSequencer", args, options))
+		return &result
+	}
+	hasFailure := func(result *gethexec.SequencerGasEstimate, reason string) bool {
+		for _, failure := range result.Failures {
+			if failure.Reason == reason {
+				return true
+			}
+		}
+		return false
+	}
+
+	value := (*hexutil.Big)(big.NewInt(1e12))
+	result := estimate(gethexec.EstimateGasArgs{From: &owner, To: &user2, Value: value}, nil)
+	if !result.Accepted || result.Gas == 0 {
+		Fatal(t, "transfer wasn't accepted", result)
+	}
+
+	data := hexutil.Bytes(make([]byte, 20000))
+	result = estimate(gethexec.EstimateGasArgs{From: &owner, To: &user2, Data: &data}, nil)
+	if result.Accepted || !hasFailure(result, gethexec.EstimateFailureTxTooLarge) {
+		Fatal(t, "oversized transaction wasn't rejected", result)
+	}
+
+	header, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+	past := math.HexOrDecimal64(header.Time - 1)
+	result = estimate(gethexec.EstimateGasArgs{From: &owner, To: &user2, Value: value}, &arbitrum_types.ConditionalOptions{TimestampMax: &past})
+	if result.Accepted || !hasFailure(result, gethexec.EstimateFailureConditionalOptions) {
+		Fatal(t, "expired conditional options weren't rejected", result)
+	}
+
+	// User2 has no funds to pay for gas
+	result = estimate(gethexec.EstimateGasArgs{From: &user2, To: &owner}, nil)
+	if result.Accepted || !hasFailure(result, gethexec.EstimateFailureExecution) && !hasFailure(result, gethexec.EstimateFailureInsufficientFunds) {
+		Fatal(t, "unfunded sender wasn't rejected", result)
+	}
+}
@@ -0,0 +1,478 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// Faults are failures injected into the L2 node the NodeBuilder builds. Every random choice is drawn from
+// Seed, so a failing run can be reproduced by running it again with the seed it logged.
+type Faults struct {
+	Seed int64 // picked and logged if zero
+
+	// Fraction of the node's L1 RPC calls to fail, and the most to delay the rest by. Only calls to
+	// L1Methods are affected, or all of them if it's empty. Calls are only faulted once the node has started.
+	L1DropRate float64
+	L1MaxDelay time.Duration
+	L1Methods  []string
+
+	// Message counts at which to crash and restart the batch poster and the inbox reader. Restarted batch
+	// posters post to the L1 directly, so crashing one isn't supported with a data availability provider.
+	CrashBatchPosterAt []arbutil.MessageIndex
+	CrashInboxReaderAt []arbutil.MessageIndex
+
+	// Sequence numbers of the feed messages to corrupt before they reach the node, which must read a feed.
+	CorruptFeedMessages []arbutil.MessageIndex
+}
+
+// WithFaults has the L2 node built with the given faults injected. L1 faults and crashes require an L1.
+func (b *NodeBuilder) WithFaults(faults Faults) *NodeBuilder {
+	b.faults = &faultInjector{Faults: faults}
+	return b
+}
+
+type faultInjector struct {
+	Faults
+
+	l1Active     atomic.Bool
+	l1RandsMutex sync.Mutex
+	l1Rands      map[string]*rand.Rand // one per method, so concurrent calls to others don't change its draws
+
+	feedRand *rand.Rand // only used by the feed relay's single thread
+
+	stopCrashes func()
+
+	// What was injected, for tests to check
+	droppedL1Calls        atomic.Uint64
+	batchPosterCrashes    atomic.Uint64
+	inboxReaderCrashes    atomic.Uint64
+	corruptedFeedMessages atomic.Uint64
+}
+
+func (f *faultInjector) init(t *testing.T) {
+	if f.Seed == 0 {
+		f.Seed = time.Now().UnixNano()
+	}
+	t.Log("injecting faults with seed", f.Seed)
+	f.l1Rands = make(map[string]*rand.Rand)
+	f.feedRand = rand.New(rand.NewSource(f.Seed))
+}
+
+func (f *faultInjector) needsL1() bool {
+	return f.L1DropRate > 0 || f.L1MaxDelay > 0 || len(f.CrashBatchPosterAt) > 0 || len(f.CrashInboxReaderAt) > 0
+}
+
+func (f *faultInjector) l1Rand(method string) *rand.Rand {
+	if r, ok := f.l1Rands[method]; ok {
+		return r
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(method))
+	// #nosec G115
+	r := rand.New(rand.NewSource(f.Seed ^ int64(hash.Sum64())))
+	f.l1Rands[method] = r
+	return r
+}
+
+// l1Fault decides whether to drop the method's next call and how long to delay it otherwise.
+func (f *faultInjector) l1Fault(method string) (bool, time.Duration) {
+	if !f.l1Active.Load() {
+		return false, 0
+	}
+	if len(f.L1Methods) > 0 {
+		affected := false
+		for _, faulted := range f.L1Methods {
+			affected = affected || faulted == method
+		}
+		if !affected {
+			return false, 0
+		}
+	}
+	f.l1RandsMutex.Lock()
+	defer f.l1RandsMutex.Unlock()
+	r := f.l1Rand(method)
+	drop := r.Float64() < f.L1DropRate
+	var delay time.Duration
+	if f.L1MaxDelay > 0 {
+		delay = time.Duration(r.Int63n(int64(f.L1MaxDelay)))
+	}
+	return drop, delay
+}
+
+type faultyRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type faultyRPCMessage struct {
+	Version string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *faultyRPCError   `json:"error,omitempty"`
+}
+
+func (f *faultInjector) forwardL1Call(ctx context.Context, upstream *rpc.Client, request *faultyRPCMessage) *faultyRPCMessage {
+	response := &faultyRPCMessage{Version: "2.0", ID: request.ID}
+	drop, delay := f.l1Fault(request.Method)
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+	if drop {
+		f.droppedL1Calls.Add(1)
+		response.Error = &faultyRPCError{Code: -32000, Message: "injected fault: dropped " + request.Method}
+		return response
+	}
+	params := make([]interface{}, len(request.Params))
+	for i, param := range request.Params {
+		params[i] = param
+	}
+	var result json.RawMessage
+	err := upstream.CallContext(ctx, &result, request.Method, params...)
+	if err != nil {
+		response.Error = &faultyRPCError{Code: -32000, Message: err.Error()}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			response.Error.Code = rpcErr.ErrorCode()
+		}
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			response.Error.Data = dataErr.ErrorData()
+		}
+		return response
+	}
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	response.Result = result
+	return response
+}
+
+// wrapParentChain returns a copy of the parent chain's test client whose Client goes through the L1 faults.
+// Without subscriptions over HTTP, the node polls for parent chain headers.
+func (f *faultInjector) wrapParentChain(t *testing.T, ctx context.Context, parentChain *TestClient) *TestClient {
+	upstream := parentChain.Stack.Attach()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var response interface{}
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			var requests []*faultyRPCMessage
+			if err := json.Unmarshal(body, &requests); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			responses := make([]*faultyRPCMessage, len(requests))
+			for i, request := range requests {
+				responses[i] = f.forwardL1Call(r.Context(), upstream, request)
+			}
+			response = responses
+		} else {
+			var request faultyRPCMessage
+			if err := json.Unmarshal(body, &request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			response = f.forwardL1Call(r.Context(), upstream, &request)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Warn("error writing faulty L1 response", "err", err)
+		}
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		upstream.Close()
+	})
+	rpcClient, err := rpc.DialContext(ctx, server.URL)
+	Require(t, err)
+	faulty := *parentChain
+	faulty.Client = ethclient.NewClient(rpcClient)
+	return &faulty
+}
+
+// feedRelay passes on a feed, corrupting the messages it's told to.
+type feedRelay struct {
+	faults      *faultInjector
+	broadcaster *broadcaster.Broadcaster
+}
+
+func (r *feedRelay) AddBroadcastMessages(feedMessages []*m.BroadcastFeedMessage) error {
+	for _, message := range feedMessages {
+		for _, corrupt := range r.faults.CorruptFeedMessages {
+			if message.SequenceNumber != corrupt || message.Message.Message == nil {
+				continue
+			}
+			l2msg := message.Message.Message.L2msg
+			if len(l2msg) == 0 {
+				l2msg = []byte{0}
+			} else {
+				l2msg = append([]byte{}, l2msg...)
+			}
+			i := r.faults.feedRand.Intn(len(l2msg))
+			l2msg[i] ^= byte(1 << r.faults.feedRand.Intn(8))
+			message.Message.Message.L2msg = l2msg
+			r.faults.corruptedFeedMessages.Add(1)
+			log.Info("injected fault: corrupted feed message", "sequenceNumber", message.SequenceNumber, "byte", i)
+		}
+	}
+	r.broadcaster.BroadcastFeedMessages(feedMessages)
+	return nil
+}
+
+// relayFeed points the node's feed input at a relay of its feed that corrupts the configured messages.
+func (f *faultInjector) relayFeed(t *testing.T, ctx context.Context, b *NodeBuilder) {
+	if len(f.CorruptFeedMessages) == 0 {
+		return
+	}
+	input := &b.nodeConfig.Feed.Input
+	if len(input.URL) == 0 || input.URL[0] == "" {
+		t.Fatal("corrupting feed messages requires the node to read a feed")
+	}
+	chainId := b.chainConfig.ChainID.Uint64()
+	relay := &feedRelay{
+		faults:      f,
+		broadcaster: broadcaster.NewBroadcaster(newBroadcasterConfigTest, chainId, nil, nil),
+	}
+	Require(t, relay.broadcaster.Initialize())
+	Require(t, relay.broadcaster.Start(ctx))
+	clientConfig := *input
+	fatalErrChan := make(chan error, 10)
+	client, err := broadcastclient.NewBroadcastClient(
+		func() *broadcastclient.Config { return &clientConfig }, input.URL[0], chainId, 0, relay, nil, fatalErrChan, nil, nil, func(int32) {},
+	)
+	Require(t, err)
+	client.Start(ctx)
+	StartWatchChanErr(t, ctx, fatalErrChan, nil)
+	t.Cleanup(func() {
+		client.StopAndWait()
+		relay.broadcaster.StopAndWait()
+	})
+	port := relay.broadcaster.ListenerAddr().(*net.TCPAddr).Port
+	input.URL = []string{fmt.Sprintf("ws://localhost:%d/feed", port)}
+}
+
+// start begins faulting the L1 calls of the built node and crashing its components at the configured
+// message counts, until stopCrashes is called.
+func (f *faultInjector) start(t *testing.T, ctx context.Context, b *NodeBuilder, parentChainID *big.Int) {
+	f.l1Active.Store(true)
+	if len(f.CrashBatchPosterAt) == 0 && len(f.CrashInboxReaderAt) == 0 {
+		f.stopCrashes = func() {}
+		return
+	}
+	if len(f.CrashBatchPosterAt) > 0 && (b.L2.ConsensusNode.BatchPoster == nil || b.nodeConfig.DataAvailability.Enable) {
+		t.Fatal("crashing the batch poster requires one posting to the L1 directly")
+	}
+	crashCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	f.stopCrashes = func() {
+		cancel()
+		<-done
+	}
+	go func() {
+		defer close(done)
+		batchPosterCrashes := append([]arbutil.MessageIndex{}, f.CrashBatchPosterAt...)
+		inboxReaderCrashes := append([]arbutil.MessageIndex{}, f.CrashInboxReaderAt...)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for len(batchPosterCrashes) > 0 || len(inboxReaderCrashes) > 0 {
+			select {
+			case <-crashCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			count, err := b.L2.ConsensusNode.TxStreamer.GetMessageCount()
+			if err != nil {
+				t.Error("error getting message count to inject faults at:", err)
+				return
+			}
+			for len(batchPosterCrashes) > 0 && batchPosterCrashes[0] <= count {
+				batchPosterCrashes = batchPosterCrashes[1:]
+				if err := f.crashBatchPoster(ctx, b, parentChainID); err != nil {
+					t.Error("error restarting the batch poster:", err)
+					return
+				}
+				log.Info("injected fault: crashed and restarted the batch poster", "messageCount", count)
+			}
+			for len(inboxReaderCrashes) > 0 && inboxReaderCrashes[0] <= count {
+				inboxReaderCrashes = inboxReaderCrashes[1:]
+				b.L2.ConsensusNode.InboxReader.Restart()
+				f.inboxReaderCrashes.Add(1)
+				log.Info("injected fault: crashed and restarted the inbox reader", "messageCount", count)
+			}
+		}
+	}()
+}
+
+// crashBatchPoster replaces the node's batch poster with a new one, which only has what was stored to go on.
+func (f *faultInjector) crashBatchPoster(ctx context.Context, b *NodeBuilder, parentChainID *big.Int) error {
+	node := b.L2.ConsensusNode
+	node.BatchPoster.StopAndWait()
+	f.batchPosterCrashes.Add(1)
+	txOpts := b.L1Info.GetDefaultTransactOpts("Sequencer", ctx)
+	batchPoster, err := arbnode.NewBatchPoster(ctx, &arbnode.BatchPosterOpts{
+		DataPosterDB:  rawdb.NewTable(node.ArbDB, storage.BatchPosterPrefix),
+		L1Reader:      node.L1Reader,
+		Inbox:         node.InboxTracker,
+		Streamer:      node.TxStreamer,
+		VersionGetter: b.L2.ExecNode,
+		SyncMonitor:   node.SyncMonitor,
+		Config:        func() *arbnode.BatchPosterConfig { return &b.nodeConfig.BatchPoster },
+		DeployInfo:    node.DeployInfo,
+		TransactOpts:  &txOpts,
+		ParentChainID: parentChainID,
+	})
+	if err != nil {
+		return err
+	}
+	batchPoster.Start(ctx)
+	node.BatchPoster = batchPoster
+	return nil
+}
+
+func TestFaultsL1AndCrashes(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true).WithFaults(Faults{
+		Seed:               1,
+		L1DropRate:         0.1,
+		L1MaxDelay:         20 * time.Millisecond,
+		CrashBatchPosterAt: []arbutil.MessageIndex{4},
+		CrashInboxReaderAt: []arbutil.MessageIndex{3, 6},
+	})
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	var lastTx *types.Transaction
+	for i := 0; i < 8; i++ {
+		lastTx = builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+		Require(t, builder.L2.Client.SendTransaction(ctx, lastTx))
+		_, err := builder.L2.EnsureTxSucceeded(lastTx)
+		Require(t, err)
+	}
+
+	// A node reading the L1 without faults sees everything the faulty node posted
+	testClientB, cleanupB := builder.Build2ndNode(t, &SecondNodeParams{})
+	defer cleanupB()
+	for i := 90; i >= 0; i-- {
+		builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+			builder.L1Info.PrepareTx("Faucet", "User", 30000, big.NewInt(1e12), nil),
+		})
+		time.Sleep(200 * time.Millisecond)
+		_, err := testClientB.Client.TransactionReceipt(ctx, lastTx.Hash())
+		if err == nil {
+			break
+		}
+		if i == 0 {
+			Require(t, err)
+		}
+	}
+
+	faults := builder.faults
+	if faults.droppedL1Calls.Load() == 0 {
+		Fatal(t, "no L1 calls dropped")
+	}
+	if crashes := faults.batchPosterCrashes.Load(); crashes != 1 {
+		Fatal(t, "expected the batch poster to crash once but it crashed", crashes, "times")
+	}
+	if crashes := faults.inboxReaderCrashes.Load(); crashes != 2 {
+		Fatal(t, "expected the inbox reader to crash twice but it crashed", crashes, "times")
+	}
+}
+
+func TestFaultsCorruptFeed(t *testing.T) {
+	logHandler := testhelpers.InitTestLog(t, log.LvlTrace)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builderSeq := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builderSeq.nodeConfig.Feed.Output = *newBroadcasterConfigTest()
+	cleanupSeq := builderSeq.Build(t)
+	defer cleanupSeq()
+
+	port := builderSeq.L2.ConsensusNode.BroadcastServer.ListenerAddr().(*net.TCPAddr).Port
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.nodeConfig.Feed.Input = *newBroadcastClientConfigTest(port)
+	builder.takeOwnership = false
+	builder.WithFaults(Faults{Seed: 1, CorruptFeedMessages: []arbutil.MessageIndex{2}})
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builderSeq.L2Info.GenerateAccount("User2")
+	for i := 0; i < 3; i++ {
+		tx := builderSeq.L2Info.PrepareTx("Owner", "User2", builderSeq.L2Info.TransferGas, big.NewInt(1e12), nil)
+		Require(t, builderSeq.L2.Client.SendTransaction(ctx, tx))
+		_, err := builderSeq.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+	// Transactions after the corrupted one have nonces the node doesn't expect, so wait for its messages instead
+	expectedCount, err := builderSeq.L2.ConsensusNode.TxStreamer.GetMessageCount()
+	Require(t, err)
+	for i := 50; ; i-- {
+		count, err := builder.L2.ConsensusNode.TxStreamer.GetMessageCount()
+		Require(t, err)
+		if count >= expectedCount {
+			break
+		}
+		if i == 0 {
+			Fatal(t, "node read", count, "feed messages but the sequencer has", expectedCount)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if corrupted := builder.faults.corruptedFeedMessages.Load(); corrupted != 1 {
+		Fatal(t, "expected one corrupted feed message but got", corrupted)
+	}
+	expected, err := builderSeq.L2.Client.HeaderByNumber(ctx, big.NewInt(2))
+	Require(t, err)
+	actual, err := builder.L2.Client.HeaderByNumber(ctx, big.NewInt(2))
+	Require(t, err)
+	if actual.Hash() == expected.Hash() {
+		Fatal(t, "block from the corrupted feed message matches the sequencer's")
+	}
+	if !logHandler.WasLogged(arbnode.BlockHashMismatchLogMsg) {
+		Fatal(t, "corrupted feed message not detected by its block hash")
+	}
+}
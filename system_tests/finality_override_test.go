@@ -0,0 +1,55 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+func TestFinalityOverride(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	// The simulated parent chain has no finality data, so this node only syncs because of the override
+	nodeConfig := arbnode.ConfigDefaultL1NonSequencerTest()
+	nodeConfig.InboxReader.ReadMode = "finalized"
+	nodeConfig.ParentChainReader.FinalityOverride.Enable = true
+	testClientB, cleanupB := builder.Build2ndNode(t, &SecondNodeParams{nodeConfig: nodeConfig})
+	defer cleanupB()
+
+	finalized, err := testClientB.ConsensusNode.L1Reader.LatestFinalizedBlockNr(ctx)
+	Require(t, err)
+	head, err := builder.L1.Client.BlockNumber(ctx)
+	Require(t, err)
+	if finalized == 0 || finalized > head {
+		Fatal(t, "unexpected finalized block", finalized, "with head", head)
+	}
+
+	builder.L2Info.GenerateAccount("User2")
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	// Advance the parent chain so the batch gets posted and read
+	for i := 0; i < 30; i++ {
+		builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+			builder.L1Info.PrepareTx("Faucet", "User", 30000, big.NewInt(1e12), nil),
+		})
+	}
+	_, err = WaitForTx(ctx, testClientB.Client, tx.Hash(), time.Second*30)
+	Require(t, err)
+}
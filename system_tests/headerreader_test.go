@@ -0,0 +1,48 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestHeaderReaderSubscription(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	// Headers can only arrive through the subscription
+	builder.nodeConfig.ParentChainReader.SubscribedPollInterval = time.Hour
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	reader := builder.L2.ConsensusNode.L1Reader
+	for !reader.Subscribed() {
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			Fatal(t, "parent chain reader never subscribed to new headers")
+		}
+	}
+
+	headers, unsubscribe := reader.Subscribe(false)
+	defer unsubscribe()
+	builder.L1Info.GenerateAccount("User")
+	_, receipt := builder.L1.TransferBalance(t, "Faucet", "User", big.NewInt(1), builder.L1Info)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case header := <-headers:
+			if header.Number.Cmp(receipt.BlockNumber) >= 0 {
+				return
+			}
+		case <-timeout:
+			Fatal(t, "didn't receive the new parent chain header")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+func TestL1FeeReport(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User")
+	for i := 0; i < 3; i++ {
+		tx := builder.L2Info.PrepareTx("Owner", "User", builder.L2Info.TransferGas, common.Big1, nil)
+		Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+		_, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	reporter := arbnode.NewL1FeeReporter(builder.L2.ExecNode.ArbInterface.BlockChain(), builder.L2.ConsensusNode.InboxTracker)
+	now := time.Now()
+	report, err := reporter.Generate(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+	Require(t, err)
+	if report.TotalFeesCollectedWei.Sign() <= 0 {
+		Fatal(t, "expected transactions to have paid L1 fees")
+	}
+	var transactions uint64
+	for _, day := range report.Days {
+		transactions += day.Transactions
+	}
+	if transactions < 3 {
+		Fatal(t, "expected at least 3 transactions charged L1 fees but got", transactions)
+	}
+
+	var csv bytes.Buffer
+	Require(t, report.WriteDaysCSV(&csv))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	// Header, one row per day, and the total
+	if len(lines) != len(report.Days)+2 || !strings.HasPrefix(lines[len(lines)-1], "total,") {
+		Fatal(t, "unexpected csv report", csv.String())
+	}
+
+	report, err = reporter.Generate(ctx, now.Add(time.Hour), now.Add(2*time.Hour))
+	Require(t, err)
+	if len(report.Days) != 0 || report.TotalFeesCollectedWei.Sign() != 0 {
+		Fatal(t, "expected an empty report for a range without blocks")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
+)
+
+func TestGetLogsPage(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.execConfig.LogsPage.MaxLogs = 4
+	builder.execConfig.LogsPage.MaxBlocks = 5
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	simpleAddr, simple := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	startBlock, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+	const events = 10
+	for i := 0; i < events; i++ {
+		tx, err := simple.IncrementEmit(&ownerTxOpts)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	from := rpc.BlockNumber(startBlock)
+	query := gethexec.LogsPageQuery{
+		FromBlock: &from,
+		Addresses: []common.Address{simpleAddr},
+		Topics:    [][]common.Hash{{simpleABI.Events["CounterEvent"].ID}},
+		Limit:     100,
+	}
+	var counts []uint64
+	pages := 0
+	for {
+		var page gethexec.LogsPage
+		Require(t, rpcClient.CallContext(ctx, &page, "arb_getLogsPage", query))
+		pages++
+		if len(page.Logs) > builder.execConfig.LogsPage.MaxLogs {
+			Fatal(t, "page exceeds the server's log limit", len(page.Logs))
+		}
+		for _, log := range page.Logs {
+			parsed, err := simple.ParseCounterEvent(*log)
+			Require(t, err)
+			counts = append(counts, parsed.Count)
+		}
+		if page.Cursor == nil {
+			break
+		}
+		query.Cursor = *page.Cursor
+		if pages > events*2 {
+			Fatal(t, "paging doesn't terminate")
+		}
+	}
+	// Every log is returned exactly once, in order
+	if len(counts) != events || pages < 3 {
+		Fatal(t, "unexpected pages", pages, "counts", counts)
+	}
+	for i := 1; i < len(counts); i++ {
+		if counts[i] != counts[i-1]+1 {
+			Fatal(t, "logs out of order or repeated", counts)
+		}
+	}
+
+	query.Cursor = []byte{1, 2, 3}
+	var page gethexec.LogsPage
+	if err := rpcClient.CallContext(ctx, &page, "arb_getLogsPage", query); err == nil {
+		Fatal(t, "accepted an invalid cursor")
+	}
+}
+
+func TestGetLogsPageMaxBytes(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	// Smaller than any log, so every page has exactly one
+	builder.execConfig.LogsPage.MaxBytes = 1
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	simpleAddr, simple := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	startBlock, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+	const events = 3
+	for i := 0; i < events; i++ {
+		tx, err := simple.IncrementEmit(&ownerTxOpts)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	from := rpc.BlockNumber(startBlock)
+	query := gethexec.LogsPageQuery{
+		FromBlock: &from,
+		Addresses: []common.Address{simpleAddr},
+		Topics:    [][]common.Hash{{simpleABI.Events["CounterEvent"].ID}},
+	}
+	for i := 0; i < events; i++ {
+		var page gethexec.LogsPage
+		Require(t, rpcClient.CallContext(ctx, &page, "arb_getLogsPage", query))
+		if len(page.Logs) != 1 {
+			Fatal(t, "page", i, "has", len(page.Logs), "logs")
+		}
+		if i < events-1 {
+			if page.Cursor == nil || page.Limit != gethexec.LogsPageLimitBytes {
+				Fatal(t, "page", i, "not ended by the byte limit, limit", page.Limit)
+			}
+			query.Cursor = *page.Cursor
+		} else if page.Cursor != nil || page.Limit != "" {
+			Fatal(t, "last page has a cursor, limit", page.Limit)
+		}
+	}
+}
@@ -11,11 +11,14 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
 )
 
@@ -181,3 +184,54 @@ func TestGetL1Confirmations(t *testing.T) {
 		t.Fatalf("L1Confirmations for latest block %v is only %v (did not hit expected %v)", genesisBlock.Number(), l1Confs, numTransactions)
 	}
 }
+
+func TestConstructBatchDataProof(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2.TransferBalance(t, "Owner", "Owner", common.Big1, builder.L2Info)
+	var batchCount uint64
+	for i := 0; ; i++ {
+		var err error
+		batchCount, err = builder.L2.ConsensusNode.InboxTracker.GetBatchCount()
+		Require(t, err)
+		if batchCount > 1 {
+			break
+		}
+		if i == 100 {
+			Fatal(t, "batch wasn't posted in time")
+		}
+		builder.L1.TransferBalance(t, "Faucet", "Faucet", common.Big1, builder.L1Info)
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	seqInbox, err := bridgegen.NewSequencerInbox(builder.L1Info.GetAddress("SequencerInbox"), builder.L1.Client)
+	Require(t, err)
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	callOpts := &bind.CallOpts{Context: ctx}
+	for batchNum := uint64(0); batchNum < batchCount; batchNum++ {
+		proof, err := builder.L2.ConsensusNode.GetBatchDataProof(ctx, batchNum)
+		Require(t, err)
+		result, err := nodeInterface.ConstructBatchDataProof(callOpts, batchNum, proof.DataHash)
+		Require(t, err)
+		if result.ParentChainBlock != proof.ParentChainBlock {
+			Fatal(t, "batch", batchNum, "posted in block", proof.ParentChainBlock, "but proof has block", result.ParentChainBlock)
+		}
+		onChainAcc, err := seqInbox.InboxAccs(callOpts, new(big.Int).SetUint64(batchNum))
+		Require(t, err)
+		provenAcc := crypto.Keccak256Hash(result.BeforeAcc[:], proof.DataHash[:], result.DelayedAcc[:])
+		if provenAcc != onChainAcc || provenAcc != result.AfterAcc {
+			Fatal(t, "proof for batch", batchNum, "gives accumulator", provenAcc, "but the sequencer inbox has", onChainAcc)
+		}
+		_, err = nodeInterface.ConstructBatchDataProof(callOpts, batchNum, common.Hash{1})
+		if err == nil {
+			Fatal(t, "proved batch", batchNum, "posted data it didn't")
+		}
+	}
+}
@@ -52,6 +52,15 @@ func TestP256VerifyEnabled(t *testing.T) {
 
 func TestOutboxProofs(t *testing.T) {
 	t.Parallel()
+	testOutboxProofs(t, false)
+}
+
+func TestOutboxProofsWithMerkleIndex(t *testing.T) {
+	t.Parallel()
+	testOutboxProofs(t, true)
+}
+
+func testOutboxProofs(t *testing.T, indexMerkleNodes bool) {
 	gethhook.RequireHookedGeth()
 	rand.Seed(time.Now().UTC().UnixNano())
 	ctx, cancel := context.WithCancel(context.Background())
@@ -63,6 +72,7 @@ func TestOutboxProofs(t *testing.T) {
 	merkleTopic := arbSysAbi.Events["SendMerkleUpdate"].ID
 
 	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.execConfig.IndexOutboxMerkleNodes = indexMerkleNodes
 	cleanup := builder.Build(t)
 	defer cleanup()
 
@@ -16,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
@@ -94,6 +95,56 @@ func TestArbDebugPanic(t *testing.T) {
 	}
 }
 
+func TestArbDebugWarp(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false).WithArbOSVersion(arbosState.ArbosVersion_40)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	auth := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	callOpts := &bind.CallOpts{Context: ctx}
+
+	arbDebug, err := precompilesgen.NewArbDebug(types.ArbDebugAddress, builder.L2.Client)
+	Require(t, err)
+
+	before, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+
+	const day = 24 * 60 * 60
+	tx, err := arbDebug.WarpTime(&auth, day)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	tx, err = arbDebug.WarpL1BlockNumber(&auth, 1000)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	timeWarp, l1BlockNumberWarp, err := arbDebug.GetWarps(callOpts)
+	Require(t, err)
+	if timeWarp != day || l1BlockNumberWarp != 1000 {
+		Fatal(t, "unexpected warps", timeWarp, l1BlockNumberWarp)
+	}
+
+	builder.L2Info.GenerateAccount("User2")
+	_, receipt := builder.L2.TransferBalance(t, "Owner", "User2", big.NewInt(1e12), builder.L2Info)
+	after, err := builder.L2.Client.HeaderByNumber(ctx, receipt.BlockNumber)
+	Require(t, err)
+
+	if after.Time < before.Time+day {
+		Fatal(t, "time wasn't warped", before.Time, after.Time)
+	}
+	l1Before := types.DeserializeHeaderExtraInformation(before).L1BlockNumber
+	l1After := types.DeserializeHeaderExtraInformation(after).L1BlockNumber
+	if l1After < l1Before+1000 {
+		Fatal(t, "L1 block number wasn't warped", l1Before, l1After)
+	}
+}
+
 func TestArbDebugLegacyError(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,76 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestPreflightIntents(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	builder.L2Info.GenerateAccount("User3")
+	builder.L2.TransferBalance(t, "Owner", "User2", big.NewInt(1e16), builder.L2Info)
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	owner := builder.L2Info.GetAddress("Owner")
+	user2 := builder.L2Info.GetAddress("User2")
+	user3 := builder.L2Info.GetAddress("User3")
+	nonce, err := builder.L2.Client.NonceAt(ctx, user2, nil)
+	Require(t, err)
+
+	value := (*hexutil.Big)(big.NewInt(1e9))
+	intents := []gethexec.PreflightIntent{
+		{From: user2, To: &owner, Nonce: hexutil.Uint64(nonce), Value: value},
+		{From: user2, To: &owner, Nonce: hexutil.Uint64(nonce + 1), Value: value},
+		{From: user2, To: &owner, Nonce: hexutil.Uint64(nonce + 3), Value: value},
+		{From: user2, To: &owner, Nonce: hexutil.Uint64(nonce), Value: value},
+		{From: user2, To: &owner, Nonce: hexutil.Uint64(nonce + 2), Value: (*hexutil.Big)(big.NewInt(1e16))},
+		{From: user3, To: &owner, Nonce: 0},
+	}
+	var results []gethexec.PreflightResult
+	Require(t, rpcClient.CallContext(ctx, &results, "arb_preflightIntents", intents))
+	if len(results) != len(intents) {
+		Fatal(t, "got", len(results), "results for", len(intents), "intents")
+	}
+	onlyFailure := func(i int, reason string) {
+		t.Helper()
+		result := results[i]
+		if result.Accepted || len(result.Failures) != 1 || result.Failures[0].Reason != reason {
+			Fatal(t, "intent", i, "should only fail with", reason, "but got", result)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if !results[i].Accepted || uint64(results[i].ExpectedNonce) != nonce+uint64(i) || results[i].Gas <= 21000 {
+			Fatal(t, "intent", i, "wasn't accepted", results[i])
+		}
+	}
+	onlyFailure(2, gethexec.EstimateFailureNonceGap)
+	onlyFailure(3, gethexec.EstimateFailureNonceTooLow)
+	// The balance left after the first two intents doesn't cover the value
+	onlyFailure(4, gethexec.EstimateFailureInsufficientFunds)
+	if results[4].Balance.ToInt().Cmp(big.NewInt(1e16)) >= 0 {
+		Fatal(t, "earlier intents didn't spend the sender's balance", results[4].Balance)
+	}
+	onlyFailure(5, gethexec.EstimateFailureInsufficientFunds)
+
+	// Nothing was executed
+	after, err := builder.L2.Client.NonceAt(ctx, user2, nil)
+	Require(t, err)
+	if after != nonce {
+		Fatal(t, "preflight changed the sender's nonce")
+	}
+}
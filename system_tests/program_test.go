@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbcompress"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/programs"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/arbutil"
@@ -790,6 +791,51 @@ func testReturnData(t *testing.T, jit bool) {
 	validateBlocks(t, 11, jit, builder)
 }
 
+func TestProgramCallContracts(t *testing.T) {
+	t.Parallel()
+	testCallContracts(t, true)
+}
+
+func testCallContracts(t *testing.T, jit bool) {
+	builder, auth, cleanup := setupProgramTest(t, jit, func(b *NodeBuilder) { b.WithArbOSVersion(arbosState.ArbosVersion_40) })
+	ctx := builder.ctx
+	l2info := builder.L2Info
+	l2client := builder.L2.Client
+	defer cleanup()
+
+	storeAddr := deployWasm(t, ctx, auth, l2client, rustFile("storage"))
+	returnSizeAddr := deployWasm(t, ctx, auth, l2client, watFile("return-size"))
+	batchAddr := deployWasm(t, ctx, auth, l2client, watFile("call-contracts"))
+
+	// write a value, then read it back, in one batch
+	key := testhelpers.RandomHash()
+	value := testhelpers.RandomHash()
+	batch := callContractsAppend(nil, storeAddr, argsForStorageWrite(key, value))
+	batch = callContractsAppend(batch, storeAddr, argsForStorageRead(key))
+	tx := l2info.PrepareTxTo("Owner", &batchAddr, 1e9, nil, batch)
+	Require(t, l2client.SendTransaction(ctx, tx))
+	_, err := EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+	assertStorageAt(t, ctx, l2client, storeAddr, key, value)
+
+	results := sendContractCall(t, ctx, batchAddr, l2client, callContractsAppend(nil, storeAddr, argsForStorageRead(key)))
+	if len(results) != 13+32 || results[0] != 0 || binary.BigEndian.Uint32(results[9:13]) != 32 {
+		Fatal(t, "unexpected call results", results)
+	}
+	if !bytes.Equal(results[13:], value[:]) {
+		Fatal(t, "unexpected return data", results[13:], "expected", value)
+	}
+
+	// return data past the results' capacity isn't copied, but its length is still reported
+	returnSize := uint32(0x10000)
+	results = sendContractCall(t, ctx, batchAddr, l2client, callContractsAppend(nil, returnSizeAddr, binary.BigEndian.AppendUint32(nil, returnSize)))
+	if len(results) != 0x8000 || results[0] != 0 || binary.BigEndian.Uint32(results[9:13]) != returnSize {
+		Fatal(t, "unexpected truncated call results", len(results), results[:13])
+	}
+
+	validateBlocks(t, 1, jit, builder)
+}
+
 func TestProgramLogs(t *testing.T) {
 	t.Parallel()
 	testLogs(t, true, false)
@@ -1053,7 +1099,7 @@ func testMemory(t *testing.T, jit bool) {
 		EnsureTxFailed(t, ctx, l2client, tx)
 	}
 
-	model := programs.NewMemoryModel(programs.InitialFreePages, programs.InitialPageGas)
+	model := programs.NewMemoryModel(programs.InitialFreePages, programs.InitialPageGas, programs.InitialPageRamp)
 
 	// expand to 128 pages, retract, then expand again to 128.
 	//   - multicall takes 1 page to init, and then 1 more at runtime.
@@ -1377,7 +1423,7 @@ func TestStylusPrecompileMethodsSimple(t *testing.T) {
 		t.Errorf("PageLimit from arbWasm precompile didnt match the value set by arbowner. have: %d, want: %d", pl, 8)
 	}
 
-	// pageramp currently is initialPageRamp = 620674314 value in programs package
+	// pageramp currently is InitialPageRamp = 620674314 value in programs package
 	_, err = arbWasm.PageRamp(nil)
 	Require(t, err)
 
@@ -1805,6 +1851,16 @@ func multicallAppend(calls []byte, opcode vm.OpCode, address common.Address, inn
 	return calls
 }
 
+// callContractsAppend adds a normal call with no value, which must succeed, to a call_contracts batch
+func callContractsAppend(batch []byte, address common.Address, calldata []byte) []byte {
+	batch = append(batch, 0, 0)
+	batch = append(batch, address[:]...)
+	batch = append(batch, common.Hash{}.Bytes()...)
+	batch = binary.BigEndian.AppendUint64(batch, math.MaxUint64)
+	batch = binary.BigEndian.AppendUint32(batch, uint32(len(calldata))) // #nosec G115
+	return append(batch, calldata...)
+}
+
 func multicallEmptyArgs() []byte {
 	return []byte{0} // number of actions
 }
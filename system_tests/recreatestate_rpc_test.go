@@ -698,3 +698,98 @@ func TestStateAndHeaderForRecentBlock(t *testing.T) {
 		}
 	}
 }
+
+func TestStateRecreatorMaxBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	execConfig := ExecConfigDefaultTest(t)
+	execConfig.Sequencer.MaxBlockSpeed = 0
+	execConfig.Sequencer.MaxTxDataSize = 150 // 1 test tx ~= 110
+	execConfig.Caching.Archive = true
+	execConfig.Caching.StateScheme = rawdb.HashScheme
+	execConfig.Caching.SnapshotCache = 0
+	execConfig.Caching.TrieCleanCache = 0
+	execConfig.Caching.MaxNumberOfBlocksToSkipStateSaving = 0
+	execConfig.Caching.MaxAmountOfGasToSkipStateSaving = 0
+	builder, cancelNode := prepareNodeWithHistory(t, ctx, execConfig, 32)
+	defer cancelNode()
+	execNode, l2client := builder.L2.ExecNode, builder.L2.Client
+	bc := execNode.Backend.ArbInterface().BlockChain()
+	db := execNode.Backend.ChainDb()
+
+	lastBlock, err := l2client.BlockNumber(ctx)
+	Require(t, err)
+	middleBlock := lastBlock / 2
+	lastHeader := bc.GetHeaderByNumber(lastBlock)
+	expectedState, err := bc.StateAt(lastHeader.Root)
+	Require(t, err)
+	user2 := GetTestAddressForAccountName(t, "User2")
+	expectedBalance := expectedState.GetBalance(user2)
+
+	removeStatesFromDb(t, bc, db, middleBlock, lastBlock)
+
+	recreator := gethexec.NewStateRecreator(bc, &gethexec.StateRecreatorConfig{MaxBlocks: lastBlock - middleBlock, CacheSize: 4})
+	_, err = recreator.StateAt(ctx, lastHeader)
+	if !errors.Is(err, gethexec.ErrTooManyBlocksToRecreate) {
+		Fatal(t, "expected ErrTooManyBlocksToRecreate, got:", err)
+	}
+
+	recreator = gethexec.NewStateRecreator(bc, &gethexec.StateRecreatorConfig{MaxBlocks: lastBlock - middleBlock + 1, CacheSize: 4})
+	statedb, err := recreator.StateAt(ctx, lastHeader)
+	Require(t, err)
+	if statedb.GetBalance(user2).Cmp(expectedBalance) != 0 {
+		Fatal(t, "unexpected balance in recreated state, want:", expectedBalance, "have:", statedb.GetBalance(user2))
+	}
+	// The states of the last few blocks are cached, so the previous block needs no re-execution
+	_, err = recreator.StateAt(ctx, bc.GetHeaderByNumber(lastBlock-1))
+	Require(t, err)
+}
+
+func TestStateConversionToArchive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	execConfig := ExecConfigDefaultTest(t)
+	execConfig.Sequencer.MaxBlockSpeed = 0
+	execConfig.Sequencer.MaxTxDataSize = 150 // 1 test tx ~= 110
+	execConfig.Caching.Archive = true
+	execConfig.Caching.StateScheme = rawdb.HashScheme
+	execConfig.Caching.SnapshotCache = 0
+	execConfig.Caching.TrieCleanCache = 0
+	execConfig.Caching.MaxNumberOfBlocksToSkipStateSaving = 0
+	execConfig.Caching.MaxAmountOfGasToSkipStateSaving = 0
+	builder, cancelNode := prepareNodeWithHistory(t, ctx, execConfig, 32)
+	defer cancelNode()
+	execNode, l2client := builder.L2.ExecNode, builder.L2.Client
+	bc := execNode.Backend.ArbInterface().BlockChain()
+	db := execNode.Backend.ChainDb()
+
+	lastBlock, err := l2client.BlockNumber(ctx)
+	Require(t, err)
+	middleBlock := lastBlock / 2
+	// States before the conversion's start are regenerated from the nearest stored one
+	removeStatesFromDb(t, bc, db, middleBlock-2, lastBlock)
+
+	conversionConfig := gethexec.StateConversionConfig{ArchiveFrom: middleBlock, BlocksPerStep: 4}
+	converter := gethexec.NewStateConverter(bc, db, &execConfig.Caching, func() *gethexec.StateConversionConfig { return &conversionConfig })
+	Require(t, converter.Start(ctx))
+	defer converter.StopAndWait()
+	for i := 0; !converter.Progress().Done; i++ {
+		if i > 1000 {
+			Fatal(t, "conversion didn't finish", converter.Progress())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	progress := converter.Progress()
+	if progress.Target != lastBlock || progress.Recreated != lastBlock-middleBlock+3 {
+		Fatal(t, "unexpected progress", progress)
+	}
+	for i := middleBlock - 2; i <= lastBlock; i++ {
+		_, err := bc.StateAt(bc.GetHeaderByNumber(i).Root)
+		Require(t, err, "state of block", i, "wasn't regenerated")
+	}
+	mode, err := gethexec.ReadStateMode(db)
+	Require(t, err)
+	if mode != gethexec.StateModeArchive {
+		Fatal(t, "unexpected state mode", mode)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestReplayMessagesInSandbox(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	builder.L2.TransferBalance(t, "Owner", "User2", big.NewInt(1e12), builder.L2Info)
+	head, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+
+	messageOf := func(txs ...*types.Transaction) arbostypes.MessageWithMetadata {
+		t.Helper()
+		msg, err := gethexec.MessageFromTxes(&arbostypes.L1IncomingMessageHeader{
+			Kind:      arbostypes.L1MessageType_L2Message,
+			Poster:    l1pricing.BatchPosterAddress,
+			Timestamp: head.Time + 1,
+		}, txs, make([]error, len(txs)))
+		Require(t, err)
+		return arbostypes.MessageWithMetadata{Message: msg, DelayedMessagesRead: head.Nonce.Uint64()}
+	}
+	transfer := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e9), nil)
+	// The second message depends on the state the first one left behind
+	next := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e9), nil)
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	var result gethexec.SandboxReplayResult
+	snapshot := rpc.BlockNumber(head.Number.Int64())
+	Require(t, rpcClient.CallContext(ctx, &result, "arbdebug_replayMessages", snapshot, []arbostypes.MessageWithMetadata{messageOf(transfer), messageOf(next)}))
+	if result.SnapshotHash != head.Hash() || len(result.Blocks) != 2 {
+		Fatal(t, "unexpected replay of", len(result.Blocks), "blocks on", result.SnapshotHash)
+	}
+	for i, block := range result.Blocks {
+		if block.Header.Number.Uint64() != head.Number.Uint64()+uint64(i)+1 {
+			Fatal(t, "block", i, "has number", block.Header.Number)
+		}
+		// The start block internal transaction comes first
+		if len(block.Receipts) != 2 || block.Receipts[1].Status != types.ReceiptStatusSuccessful {
+			Fatal(t, "transfer in block", i, "didn't succeed", block.Receipts)
+		}
+	}
+	if result.Blocks[1].Header.ParentHash != result.Blocks[0].Header.Hash() {
+		Fatal(t, "sandbox blocks aren't chained")
+	}
+
+	// The live chain is untouched
+	current, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+	if current.Hash() != head.Hash() {
+		Fatal(t, "replaying messages changed the chain head")
+	}
+	if _, err := builder.L2.Client.TransactionReceipt(ctx, transfer.Hash()); err == nil {
+		Fatal(t, "replayed transaction is on the live chain")
+	}
+
+	// A transaction replayed against the snapshot again fails on its nonce
+	Require(t, rpcClient.CallContext(ctx, &result, "arbdebug_replayMessages", snapshot, []arbostypes.MessageWithMetadata{messageOf(next)}))
+	if len(result.Blocks[0].Receipts) != 1 {
+		Fatal(t, "transaction with a future nonce was included in the sandbox")
+	}
+}
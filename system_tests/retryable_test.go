@@ -239,6 +239,111 @@ func TestSubmitRetryableImmediateSuccess(t *testing.T) {
 	}
 }
 
+func TestRecommendRetryableTicketParams(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t)
+	defer teardown()
+
+	user2Address := builder.L2Info.GetAddress("User2")
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	callValue := big.NewInt(1e6)
+	data := []byte{0x32, 0x42, 0x32, 0x88}
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	sender := builder.L1Info.GetAddress("Faucet")
+	recommended, err := nodeInterface.RecommendRetryableTicketParams(
+		&bind.CallOpts{Context: ctx}, sender, user2Address, callValue, beneficiaryAddress, beneficiaryAddress, data,
+	)
+	Require(t, err)
+	if recommended.MaxFeePerGas.Cmp(big.NewInt(l2pricing.InitialBaseFeeWei)) <= 0 {
+		Fatal(t, "recommended max fee per gas isn't padded", recommended.MaxFeePerGas)
+	}
+	if recommended.GasLimit < params.TxGas {
+		Fatal(t, "recommended gas limit is too low", recommended.GasLimit)
+	}
+
+	// The recommended parameters must be enough to create and auto-redeem the retryable
+	deposit := arbmath.BigAdd(callValue, recommended.MaxSubmissionCost)
+	deposit = arbmath.BigAdd(deposit, arbmath.BigMulByUint(recommended.MaxFeePerGas, recommended.GasLimit))
+	usertxoptsL1 := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxoptsL1.Value = deposit
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxoptsL1,
+		user2Address,
+		callValue,
+		recommended.MaxSubmissionCost,
+		beneficiaryAddress,
+		beneficiaryAddress,
+		arbmath.UintToBig(recommended.GasLimit),
+		recommended.MaxFeePerGas,
+		data,
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+
+	waitForL1DelayBlocks(t, builder)
+
+	receipt, err := builder.L2.EnsureTxSucceeded(lookupL2Tx(l1Receipt))
+	Require(t, err)
+	if len(receipt.Logs) != 2 {
+		Fatal(t, "expected the retryable to be scheduled for auto-redeem, got", len(receipt.Logs), "logs")
+	}
+	l2balance, err := builder.L2.Client.BalanceAt(ctx, user2Address, nil)
+	Require(t, err)
+	if !arbmath.BigEquals(l2balance, callValue) {
+		Fatal(t, "auto-redeem didn't deliver the call value, balance", l2balance)
+	}
+}
+
+func TestEstimateRetryableAutoRedeem(t *testing.T) {
+	t.Parallel()
+	builder, _, _, ctx, teardown := retryableSetup(t)
+	defer teardown()
+
+	user2Address := builder.L2Info.GetAddress("User2")
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	callValue := big.NewInt(1e6)
+	data := []byte{0x32, 0x42, 0x32, 0x88}
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	sender := builder.L1Info.GetAddress("Faucet")
+	callOpts := &bind.CallOpts{Context: ctx}
+	recommended, err := nodeInterface.RecommendRetryableTicketParams(callOpts, sender, user2Address, callValue, beneficiaryAddress, beneficiaryAddress, data)
+	Require(t, err)
+
+	estimate := func(gasLimit uint64, maxFeePerGas *big.Int) node_interfacegen.EstimateRetryableAutoRedeemOutput {
+		t.Helper()
+		deposit := arbmath.BigAdd(callValue, recommended.MaxSubmissionCost)
+		deposit = arbmath.BigAdd(deposit, arbmath.BigMulByUint(maxFeePerGas, gasLimit))
+		result, err := nodeInterface.EstimateRetryableAutoRedeem(
+			callOpts, sender, deposit, user2Address, callValue, recommended.MaxSubmissionCost,
+			beneficiaryAddress, beneficiaryAddress, gasLimit, maxFeePerGas, data,
+		)
+		Require(t, err)
+		return result
+	}
+
+	result := estimate(recommended.GasLimit, recommended.MaxFeePerGas)
+	if !result.WouldSucceed || result.GasNeeded == 0 || result.GasNeeded > recommended.GasLimit {
+		Fatal(t, "recommended parameters wouldn't auto-redeem", result)
+	}
+	if !arbmath.BigEquals(result.FailingBaseFee, arbmath.BigAddByUint(recommended.MaxFeePerGas, 1)) || result.BacklogHeadroom == 0 {
+		Fatal(t, "unexpected failure point", result.FailingBaseFee, result.BacklogHeadroom)
+	}
+
+	result = estimate(result.GasNeeded-1, recommended.MaxFeePerGas)
+	if result.WouldSucceed || result.FailingBaseFee.Sign() != 0 {
+		Fatal(t, "auto-redeem with too little gas would succeed", result)
+	}
+	result = estimate(recommended.GasLimit, arbmath.BigSubByUint(result.BaseFee, 1))
+	if result.WouldSucceed {
+		Fatal(t, "auto-redeem below the base fee would succeed", result)
+	}
+}
+
 func testSubmitRetryableEmptyEscrow(t *testing.T, arbosVersion uint64) {
 	t.Parallel()
 	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
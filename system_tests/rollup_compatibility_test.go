@@ -0,0 +1,56 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+func TestRollupCompatibility(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	var result arbnode.RollupCompatibility
+	Require(t, rpcClient.CallContext(ctx, &result, "arb_rollupCompatibility"))
+	if !result.Compatible {
+		Fatal(t, "node incompatible with the rollup it was deployed with", result.Problems)
+	}
+	if result.WasmModuleRoot == (common.Hash{}) {
+		Fatal(t, "no wasm module root read from the rollup")
+	}
+	if result.ArbOSVersion == 0 || result.ArbOSVersion > result.MaxArbOSVersionSupported {
+		Fatal(t, "unexpected ArbOS versions", result.ArbOSVersion, result.MaxArbOSVersionSupported)
+	}
+
+	// Chain info with its bridge and sequencer inbox swapped
+	deployInfo := *builder.L2.ConsensusNode.DeployInfo
+	deployInfo.Bridge, deployInfo.SequencerInbox = deployInfo.SequencerInbox, deployInfo.Bridge
+	checker := arbnode.NewRollupCompatibilityChecker(builder.L1.Client, &deployInfo, nil, nil)
+	swapped, err := checker.Check(ctx)
+	Require(t, err)
+	if swapped.Compatible {
+		Fatal(t, "node compatible with swapped rollup addresses")
+	}
+	found := false
+	for _, problem := range swapped.Problems {
+		if strings.Contains(problem, "rollup's bridge() is") {
+			found = true
+		}
+	}
+	if !found {
+		Fatal(t, "no problem reported for the rollup's bridge", swapped.Problems)
+	}
+}
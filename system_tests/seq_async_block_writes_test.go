@@ -0,0 +1,67 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSequencerAsyncBlockWrites(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.execConfig.Sequencer.AsyncBlockWrites = true
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	testClientB, cleanupB := builder.Build2ndNode(t, &SecondNodeParams{})
+	defer cleanupB()
+
+	const numUsers = 20
+	for i := 0; i < numUsers; i++ {
+		user := fmt.Sprintf("User%d", i)
+		builder.L2Info.GenerateAccount(user)
+		tx := builder.L2Info.PrepareTx("Owner", user, builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+		Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+		_, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	// Send transactions back to back so blocks are produced while the previous one is still being written
+	var txs types.Transactions
+	for i := 0; i < numUsers; i++ {
+		tx := builder.L2Info.PrepareTx(fmt.Sprintf("User%d", i), "Owner", builder.L2Info.TransferGas, big.NewInt(1), nil)
+		Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+		txs = append(txs, tx)
+	}
+	for _, tx := range txs {
+		_, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		_, err = WaitForTx(ctx, testClientB.Client, tx.Hash(), time.Second*30)
+		Require(t, err)
+	}
+
+	lastTx := builder.L2Info.PrepareTx("Owner", "User0", builder.L2Info.TransferGas, big.NewInt(1), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, lastTx))
+	_, err := builder.L2.EnsureTxSucceeded(lastTx)
+	Require(t, err)
+	_, err = WaitForTx(ctx, testClientB.Client, lastTx.Hash(), time.Second*30)
+	Require(t, err)
+
+	headA, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+	headB, err := testClientB.Client.HeaderByNumber(ctx, headA.Number)
+	Require(t, err)
+	if headA.Hash() != headB.Hash() {
+		Fatal(t, "block mismatch between sequencer and follower", headA.Number, headA.Hash(), headB.Hash())
+	}
+}
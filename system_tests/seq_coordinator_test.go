@@ -447,3 +447,31 @@ func verifyTxIsProcessed(t *testing.T, ctx context.Context, builder *NodeBuilder
 		t.Fatal("Unexpected balance:", l2balance)
 	}
 }
+
+func TestRedundantSequencersFailover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true).WithRedundantSequencers(3)
+	builder.nodeConfig.BatchPoster.Enable = false
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.RequireChosenSequencer(t, 0)
+	builder.L2Info.GenerateAccount("User2")
+	verifyTxIsProcessed(t, ctx, builder, builder.Sequencers[1], 1e12)
+
+	// Sequencer 1 has the next highest priority
+	chosen := builder.ForceSequencerFailover(t)
+	if chosen != 1 {
+		Fatal(t, "expected sequencer 1 to take over the lockout but got", chosen)
+	}
+	verifyTxIsProcessed(t, ctx, builder, builder.Sequencers[2], 1e12*2)
+
+	builder.Sequencers[0].ConsensusNode.SeqCoordinator.SeekLockout(ctx)
+	chosen = builder.ForceSequencerFailover(t)
+	if chosen != 0 {
+		Fatal(t, "expected sequencer 0 to take back the lockout but got", chosen)
+	}
+	verifyTxIsProcessed(t, ctx, builder, builder.Sequencers[2], 1e12*3)
+}
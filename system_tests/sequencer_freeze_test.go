@@ -0,0 +1,66 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestSequencerFreeze(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+	_, err := builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	var frozen gethexec.SequencerFreezeStatus
+	Require(t, rpcClient.CallContext(ctx, &frozen, "sequencer_freeze", "incident test"))
+	if !frozen.Frozen || frozen.Reason != "incident test" {
+		Fatal(t, "unexpected freeze status", frozen)
+	}
+	headBefore, err := builder.L2.Client.BlockNumber(ctx)
+	Require(t, err)
+
+	tx = builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	err = builder.L2.Client.SendTransaction(ctx, tx)
+	if err == nil || !strings.Contains(err.Error(), execution.ErrSequencerFrozen.Error()) {
+		Fatal(t, "expected frozen sequencer to reject transaction, got", err)
+	}
+	// Reads keep working while frozen
+	_, err = builder.L2.Client.BalanceAt(ctx, builder.L2Info.GetAddress("User2"), nil)
+	Require(t, err)
+
+	var status gethexec.SequencerFreezeStatus
+	Require(t, rpcClient.CallContext(ctx, &status, "sequencer_freezeStatus"))
+	if !status.Frozen || status.MessageCount != frozen.MessageCount {
+		Fatal(t, "chain moved while frozen", status, "frozen at", frozen)
+	}
+
+	var resumed gethexec.SequencerFreezeStatus
+	Require(t, rpcClient.CallContext(ctx, &resumed, "sequencer_resume"))
+	if resumed.Frozen || resumed.MessageCount != frozen.MessageCount {
+		Fatal(t, "unexpected status after resuming", resumed)
+	}
+	Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+	receipt, err := builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	if receipt.BlockNumber.Uint64() != headBefore+1 {
+		Fatal(t, "first block after resuming is", receipt.BlockNumber, "expected", headBefore+1)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestSequencingTimestamps(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.execConfig.Sequencer.RecordSequencingTimestamps = true
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	builder.L2Info.GenerateAccount("User2")
+	before := uint64(time.Now().UnixMilli())
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	Require(t, builder.L2.Client.SendTransaction(ctx, tx))
+	receipt, err := builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	after := uint64(time.Now().UnixMilli())
+
+	rpcClient := builder.L2.ConsensusNode.Stack.Attach()
+	var sequencedAt *hexutil.Uint64
+	Require(t, rpcClient.CallContext(ctx, &sequencedAt, "arb_sequencingTimestamp", tx.Hash()))
+	if sequencedAt == nil || uint64(*sequencedAt) < before || uint64(*sequencedAt) > after {
+		Fatal(t, "sequencing timestamp", sequencedAt, "not between", before, "and", after)
+	}
+
+	var blockTimestamps []gethexec.TxSequencingTimestamp
+	Require(t, rpcClient.CallContext(ctx, &blockTimestamps, "arb_blockSequencingTimestamps", rpc.BlockNumber(receipt.BlockNumber.Int64())))
+	// The first transaction in every block is the internal start block transaction, which isn't sequenced
+	if len(blockTimestamps) != 2 || blockTimestamps[0].SequencedAt != nil {
+		Fatal(t, "unexpected block sequencing timestamps", blockTimestamps)
+	}
+	if blockTimestamps[1].TxHash != tx.Hash() || blockTimestamps[1].SequencedAt == nil || *blockTimestamps[1].SequencedAt != *sequencedAt {
+		Fatal(t, "unexpected sequencing timestamp for the transfer", blockTimestamps[1])
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbstate/daprovider"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+// simulatedBeaconServer serves the part of the beacon API that the BlobClient uses, for the test parent chain.
+// Every parent chain block is its own slot, one second apart from the genesis block.
+// The execution client drops blob sidecars once their transactions are included, so they're kept as
+// blob transactions are submitted through eth_sendRawTransaction.
+type simulatedBeaconServer struct {
+	l1backend *eth.Ethereum
+	server    *httptest.Server
+
+	mutex    sync.Mutex
+	sidecars map[common.Hash]*types.BlobTxSidecar
+	served   int
+}
+
+func newSimulatedBeaconServer(stack *node.Node, l1backend *eth.Ethereum) *simulatedBeaconServer {
+	beacon := &simulatedBeaconServer{
+		l1backend: l1backend,
+		sidecars:  make(map[common.Hash]*types.BlobTxSidecar),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", beacon.serveGenesis)
+	mux.HandleFunc("/eth/v1/config/spec", beacon.serveSpec)
+	mux.HandleFunc("/eth/v1/beacon/blob_sidecars/", beacon.serveBlobSidecars)
+	beacon.server = httptest.NewServer(mux)
+
+	// Registered after the eth service's APIs, so this takes over eth_sendRawTransaction
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   &blobCapturingTxAPI{beacon},
+	}})
+	stack.RegisterLifecycle(&lifecycle{stop: func() error {
+		beacon.server.Close()
+		return nil
+	}})
+	return beacon
+}
+
+func (b *simulatedBeaconServer) URL() string {
+	return b.server.URL
+}
+
+// BlobReader returns a BlobClient reading from this server.
+func (b *simulatedBeaconServer) BlobReader(t *testing.T, l1client *ethclient.Client) daprovider.BlobReader {
+	t.Helper()
+	blobClient, err := headerreader.NewBlobClient(headerreader.BlobClientConfig{BeaconUrl: b.URL()}, l1client)
+	Require(t, err)
+	return blobClient
+}
+
+// SidecarsServed returns how many blob sidecars have been served.
+func (b *simulatedBeaconServer) SidecarsServed() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.served
+}
+
+type blobCapturingTxAPI struct {
+	beacon *simulatedBeaconServer
+}
+
+func (api *blobCapturingTxAPI) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if sidecar := tx.BlobTxSidecar(); sidecar != nil {
+		api.beacon.mutex.Lock()
+		api.beacon.sidecars[tx.Hash()] = sidecar
+		api.beacon.mutex.Unlock()
+	}
+	return tx.Hash(), api.beacon.l1backend.APIBackend.SendTx(ctx, tx)
+}
+
+func writeBeaconResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": data}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (b *simulatedBeaconServer) genesisTime() uint64 {
+	return b.l1backend.BlockChain().Genesis().Time()
+}
+
+func (b *simulatedBeaconServer) serveGenesis(w http.ResponseWriter, _ *http.Request) {
+	writeBeaconResponse(w, map[string]string{"genesis_time": strconv.FormatUint(b.genesisTime(), 10)})
+}
+
+func (b *simulatedBeaconServer) serveSpec(w http.ResponseWriter, _ *http.Request) {
+	writeBeaconResponse(w, map[string]string{"SECONDS_PER_SLOT": "1"})
+}
+
+type simulatedBlobSidecar struct {
+	BlockRoot       common.Hash   `json:"block_root"`
+	Index           string        `json:"index"`
+	Slot            string        `json:"slot"`
+	BlockParentRoot common.Hash   `json:"block_parent_root"`
+	ProposerIndex   string        `json:"proposer_index"`
+	Blob            hexutil.Bytes `json:"blob"`
+	KzgCommitment   hexutil.Bytes `json:"kzg_commitment"`
+	KzgProof        hexutil.Bytes `json:"kzg_proof"`
+}
+
+func (b *simulatedBeaconServer) serveBlobSidecars(w http.ResponseWriter, r *http.Request) {
+	slot, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/blob_sidecars/"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid slot: %v", err), http.StatusBadRequest)
+		return
+	}
+	// The simulated beacon gives every block a later timestamp than its parent
+	bc := b.l1backend.BlockChain()
+	slotTime := b.genesisTime() + slot
+	header := bc.CurrentBlock()
+	for header != nil && header.Time > slotTime && header.Number.Uint64() > 0 {
+		header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	if header == nil || header.Time != slotTime {
+		http.Error(w, fmt.Sprintf("no block in slot %d", slot), http.StatusNotFound)
+		return
+	}
+	block := bc.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		http.Error(w, fmt.Sprintf("missing block for slot %d", slot), http.StatusNotFound)
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	items := []simulatedBlobSidecar{}
+	for _, tx := range block.Transactions() {
+		if len(tx.BlobHashes()) == 0 {
+			continue
+		}
+		sidecar, ok := b.sidecars[tx.Hash()]
+		if !ok {
+			http.Error(w, fmt.Sprintf("no sidecar for blob transaction %v", tx.Hash()), http.StatusInternalServerError)
+			return
+		}
+		for i := range sidecar.Blobs {
+			items = append(items, simulatedBlobSidecar{
+				BlockRoot:       block.Hash(),
+				Index:           strconv.Itoa(len(items)),
+				Slot:            strconv.FormatUint(slot, 10),
+				BlockParentRoot: block.ParentHash(),
+				ProposerIndex:   "0",
+				Blob:            sidecar.Blobs[i][:],
+				KzgCommitment:   sidecar.Commitments[i][:],
+				KzgProof:        sidecar.Proofs[i][:],
+			})
+		}
+	}
+	b.served += len(items)
+	writeBeaconResponse(w, items)
+}
+
+func TestBatchPostingWithBlobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true).WithL1Blobs()
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	// Syncs from the parent chain only, reading the batches from the blobs
+	testClientB, cleanupB := builder.Build2ndNode(t, &SecondNodeParams{})
+	defer cleanupB()
+
+	builder.L2Info.GenerateAccount("User2")
+	tx, receiptA := builder.L2.TransferBalance(t, "Owner", "User2", common.Big1, builder.L2Info)
+	receiptB, err := testClientB.EnsureTxSucceededWithTimeout(tx, time.Second*30)
+	Require(t, err)
+	if receiptA.BlockHash != receiptB.BlockHash {
+		Fatal(t, "receipt A block hash", receiptA.BlockHash, "does not equal receipt B block hash", receiptB.BlockHash)
+	}
+	if builder.L1.Beacon.SidecarsServed() == 0 {
+		Fatal(t, "the batch was read without fetching any blob")
+	}
+}
@@ -516,3 +516,93 @@ func TestGetValidatorWalletContractWithDataposterOnlyUsedToCreateValidatorWallet
 		Require(t, err, "didn't cache validator wallet address", valWalletAddrA.String(), "vs", valWalletAddrCheck.String())
 	}
 }
+
+func TestStakerAllowlist(t *testing.T) {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+	l2node := builder.L2.ConsensusNode
+
+	balance := big.NewInt(params.Ether)
+	balance.Mul(balance, big.NewInt(100))
+	builder.L1.TransferBalance(t, "Faucet", "Validator", balance, builder.L1Info)
+	l1auth := builder.L1Info.GetDefaultTransactOpts("Validator", ctx)
+	parentChainID, err := builder.L1.Client.ChainID(ctx)
+	Require(t, err)
+	dp, err := arbnode.StakerDataposter(
+		ctx,
+		rawdb.NewTable(l2node.ArbDB, storage.StakerPrefix),
+		l2node.L1Reader,
+		&l1auth, NewFetcherFromConfig(arbnode.ConfigDefaultL1NonSequencerTest()),
+		nil,
+		parentChainID,
+	)
+	Require(t, err)
+	valWallet, err := validatorwallet.NewEOA(dp, l2node.DeployInfo.Rollup, l2node.L1Reader.Client(), func() uint64 { return 0 })
+	Require(t, err)
+	Require(t, valWallet.Initialize(ctx))
+	valConfig := staker.TestL1ValidatorConfig
+	valConfig.Strategy = "MakeNodes"
+
+	_, valStack := createTestValidationNode(t, ctx, &valnode.TestValidationConfig)
+	blockValidatorConfig := staker.TestBlockValidatorConfig
+	stateless, err := staker.NewStatelessBlockValidator(
+		l2node.InboxReader,
+		l2node.InboxTracker,
+		l2node.TxStreamer,
+		builder.L2.ExecNode,
+		l2node.ArbDB,
+		nil,
+		StaticFetcherFrom(t, &blockValidatorConfig),
+		valStack,
+	)
+	Require(t, err)
+	Require(t, stateless.Start(ctx))
+	stakerObj, err := staker.NewStaker(
+		l2node.L1Reader,
+		valWallet,
+		bind.CallOpts{},
+		func() *staker.L1ValidatorConfig { return &valConfig },
+		nil,
+		stateless,
+		nil,
+		nil,
+		l2node.DeployInfo.ValidatorUtils,
+		nil,
+	)
+	Require(t, err)
+	Require(t, stakerObj.Initialize(ctx))
+
+	readiness, err := stakerObj.Readiness(ctx)
+	Require(t, err)
+	if readiness.Allowlist.Disabled || readiness.Allowlist.Allowed || readiness.Ready || len(readiness.Problems) != 1 {
+		Fatal(t, "validator off the allowlist reported as ready", readiness)
+	}
+	// Rather than sending transactions the rollup reverts, the staker only watches
+	tx, err := stakerObj.Act(ctx)
+	Require(t, err)
+	if tx != nil {
+		Fatal(t, "validator off the allowlist sent a transaction", tx.Hash())
+	}
+
+	deployAuth := builder.L1Info.GetDefaultTransactOpts("RollupOwner", ctx)
+	upgradeExecutor, err := upgrade_executorgen.NewUpgradeExecutor(l2node.DeployInfo.UpgradeExecutor, builder.L1.Client)
+	Require(t, err)
+	rollupABI, err := abi.JSON(strings.NewReader(rollupgen.RollupAdminLogicABI))
+	Require(t, err)
+	setValidatorCalldata, err := rollupABI.Pack("setValidator", []common.Address{l1auth.From}, []bool{true})
+	Require(t, err)
+	tx, err = upgradeExecutor.ExecuteCall(&deployAuth, l2node.DeployInfo.Rollup, setValidatorCalldata)
+	Require(t, err)
+	_, err = builder.L1.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	readiness, err = stakerObj.Readiness(ctx)
+	Require(t, err)
+	if !readiness.Allowlist.Allowed || !readiness.Ready {
+		Fatal(t, "allowlisted validator with enough balance isn't ready", readiness)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
+	"github.com/offchainlabs/nitro/util/colors"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+const stylusFixtureDir = "../arbitrator/stylus/tests"
+
+var stylusFixtureMutex sync.Mutex
+
+// stylusFixture returns the wasm of a Rust fixture program, compiling it as the Makefile would if the
+// prebuilt wasm is missing or older than its sources.
+func stylusFixture(t *testing.T, name string) string {
+	t.Helper()
+	stylusFixtureMutex.Lock()
+	defer stylusFixtureMutex.Unlock()
+
+	wasm := rustFile(name)
+	if !stylusFixtureStale(t, name, wasm) {
+		return wasm
+	}
+	nightly := os.Getenv("STYLUS_NIGHTLY_VER")
+	if nightly == "" {
+		nightly = "+nightly"
+	}
+	args := []string{
+		nightly, "build", "-Z", "build-std=std,panic_abort", "-Z", "build-std-features=panic_immediate_abort",
+		"--manifest-path", filepath.Join(stylusFixtureDir, name, "Cargo.toml"),
+		"--release", "--config", filepath.Join(stylusFixtureDir, ".cargo", "config.toml"),
+	}
+	colors.PrintGrey("compiling stylus fixture ", name)
+	output, err := exec.Command("cargo", args...).CombinedOutput()
+	if err != nil {
+		Fatal(t, "failed to compile stylus fixture", name, "(make test-go-deps builds every fixture):", err, string(output))
+	}
+	// cargo might decide not to rebuild the binary
+	now := time.Now()
+	Require(t, os.Chtimes(wasm, now, now))
+	return wasm
+}
+
+func stylusFixtureStale(t *testing.T, name, wasm string) bool {
+	t.Helper()
+	info, err := os.Stat(wasm)
+	if os.IsNotExist(err) {
+		return true
+	}
+	Require(t, err)
+	sources, err := filepath.Glob(filepath.Join(stylusFixtureDir, name, "src", "*.rs"))
+	Require(t, err)
+	sources = append(sources, filepath.Join(stylusFixtureDir, name, "Cargo.toml"))
+	for _, source := range sources {
+		sourceInfo, err := os.Stat(source)
+		Require(t, err)
+		if sourceInfo.ModTime().After(info.ModTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStylusSdk(t *testing.T) {
+	testStylusSdk(t, true)
+}
+
+// testStylusSdk exercises the Rust SDK's fixture programs against the Go side of the Stylus host: calls
+// between the EVM and Stylus, storage seen by both, and the SDK's reentrancy protection.
+func testStylusSdk(t *testing.T, jit bool) {
+	builder, auth, cleanup := setupProgramTest(t, jit)
+	ctx := builder.ctx
+	l2info := builder.L2Info
+	l2client := builder.L2.Client
+	defer cleanup()
+
+	multicall := deployWasm(t, ctx, auth, l2client, stylusFixture(t, "multicall"))
+	storage := deployWasm(t, ctx, auth, l2client, stylusFixture(t, "storage"))
+	keccak := deployWasm(t, ctx, auth, l2client, stylusFixture(t, "keccak"))
+	forwarder := deployWasm(t, ctx, auth, l2client, stylusFixture(t, "sdk-reentrancy"))
+	mock, tx, _, err := mocksgen.DeployProgramTest(&auth, l2client)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+
+	send := func(to common.Address, data []byte) *types.Receipt {
+		t.Helper()
+		tx := l2info.PrepareTxTo("Owner", &to, 1e9, nil, data)
+		Require(t, l2client.SendTransaction(ctx, tx))
+		receipt, err := EnsureTxSucceeded(ctx, l2client, tx)
+		Require(t, err)
+		return receipt
+	}
+	expectRevert := func(to common.Address, data []byte) {
+		t.Helper()
+		_, err := l2client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+		if err == nil {
+			Fatal(t, "call should have reverted")
+		}
+		// execute onchain for proving's sake
+		tx := l2info.PrepareTxTo("Owner", &to, 1e9, nil, data)
+		Require(t, l2client.SendTransaction(ctx, tx))
+		EnsureTxFailed(t, ctx, l2client, tx)
+	}
+	forward := func(target common.Address, data []byte) []byte {
+		return append(common.CopyBytes(target[:]), data...)
+	}
+
+	colors.PrintBlue("Checking storage written by Stylus is seen by the EVM and Stylus")
+	key, value := testhelpers.RandomHash(), testhelpers.RandomHash()
+	send(multicall, argsForMulticall(vm.CALL, storage, nil, argsForStorageWrite(key, value)))
+	assertStorageAt(t, ctx, l2client, storage, key, value)
+	read := sendContractCall(t, ctx, multicall, l2client, argsForMulticall(vm.STATICCALL, storage, nil, argsForStorageRead(key)))
+	if !bytes.Equal(read, value[:]) {
+		Fatal(t, "Stylus read", common.Bytes2Hex(read), "but stored", value)
+	}
+
+	colors.PrintBlue("Checking calls between the EVM and Stylus (Rust => Solidity => Rust)")
+	callKeccak, err := util.NewCallParser(mocksgen.ProgramTestABI, "callKeccak")
+	Require(t, err)
+	keccakArgs := append([]byte{0x01}, []byte("stylus sdk fixtures")...)
+	mockArgs, err := callKeccak(keccak, keccakArgs)
+	Require(t, err)
+	send(multicall, argsForMulticall(vm.CALL, mock, nil, mockArgs))
+	send(forwarder, forward(mock, mockArgs))
+
+	colors.PrintBlue("Checking programs built with the reentrant feature can be reentered")
+	key, value = testhelpers.RandomHash(), testhelpers.RandomHash()
+	inner := argsForMulticall(vm.CALL, storage, nil, argsForStorageWrite(key, value))
+	send(multicall, argsForMulticall(vm.CALL, multicall, nil, inner))
+	assertStorageAt(t, ctx, l2client, storage, key, value)
+
+	colors.PrintBlue("Checking programs built without the reentrant feature revert when reentered")
+	key, value = testhelpers.RandomHash(), testhelpers.RandomHash()
+	write := forward(storage, argsForStorageWrite(key, value))
+	send(forwarder, write)
+	send(multicall, argsForMulticall(vm.CALL, forwarder, nil, write))
+	assertStorageAt(t, ctx, l2client, storage, key, value)
+	reenter := forward(multicall, argsForMulticall(vm.CALL, forwarder, nil, forward(storage, argsForStorageRead(key))))
+	expectRevert(forwarder, reenter)
+	expectRevert(multicall, argsForMulticall(vm.CALL, forwarder, nil, reenter))
+
+	blocks := []uint64{10}
+	validateBlockRange(t, blocks, jit, builder)
+}
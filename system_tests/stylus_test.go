@@ -36,6 +36,10 @@ func TestProgramArbitratorCalls(t *testing.T) {
 	testCalls(t, false)
 }
 
+func TestProgramArbitratorStylusSdk(t *testing.T) {
+	testStylusSdk(t, false)
+}
+
 func TestProgramArbitratorReturnData(t *testing.T) {
 	testReturnData(t, false)
 }
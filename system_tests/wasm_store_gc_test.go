@@ -0,0 +1,50 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/execution/gethexec"
+)
+
+func TestWasmStoreGC(t *testing.T) {
+	builder, auth, cleanup := setupProgramTest(t, true)
+	ctx := builder.ctx
+	defer cleanup()
+
+	deployWasm(t, ctx, auth, builder.L2.Client, rustFile("storage"))
+
+	bc := builder.L2.ExecNode.Backend.ArbInterface().BlockChain()
+	wasmDb := bc.StateCache().WasmStore()
+	checkWasmStoreContent(t, wasmDb, builder.execConfig.StylusTarget.ExtraArchs, 1)
+
+	// A module no activated program uses, as left behind by an expired program
+	orphan := common.HexToHash("0x0123456789abcdef")
+	batch := wasmDb.NewBatch()
+	rawdb.WriteActivation(batch, orphan, map[ethdb.WasmTarget][]byte{rawdb.LocalTarget(): {1, 2, 3}})
+	Require(t, batch.Write())
+	checkWasmStoreContent(t, wasmDb, []string{string(rawdb.LocalTarget())}, 2)
+
+	config := gethexec.DefaultWasmStoreGCConfig
+	config.Enable = true
+	Require(t, config.Validate())
+	deleted, freed, err := gethexec.NewWasmStoreGC(&config, bc).Collect(ctx)
+	Require(t, err)
+	if deleted != 1 || freed < 3 {
+		Fatal(t, "unexpected garbage collection", deleted, freed)
+	}
+	checkWasmStoreContent(t, wasmDb, builder.execConfig.StylusTarget.ExtraArchs, 1)
+
+	// The live program's module is kept
+	deleted, _, err = gethexec.NewWasmStoreGC(&config, bc).Collect(ctx)
+	Require(t, err)
+	if deleted != 0 {
+		Fatal(t, "deleted a live module")
+	}
+}
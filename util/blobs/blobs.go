@@ -1,6 +1,13 @@
 // Copyright 2023-2024, Offchain Labs, Inc.
 // For license information, see https://github.com/nitro/blob/master/LICENSE
 
+// Package blobs implements the encoding Nitro uses to pack batch data into
+// EIP-4844 blobs, along with helpers for computing and verifying the KZG
+// commitments, proofs, and versioned hashes that accompany them.
+//
+// EncodeBlobs and DecodeBlobs operate on whole payloads held in memory, while
+// EncodeBlobsFromReader and BlobDecoder produce identical results incrementally
+// for payloads too large to buffer.
 package blobs
 
 import (
@@ -76,27 +83,37 @@ func EncodeBlobs(data []byte) ([]kzg4844.Blob, error) {
 	return blobs, nil
 }
 
+// appendBlobData appends the raw (still RLP encoded) bytes stored in a blob to rlpData.
+func appendBlobData(rlpData []byte, blob *kzg4844.Blob) ([]byte, error) {
+	for fieldIndex := 0; fieldIndex < params.BlobTxFieldElementsPerBlob; fieldIndex++ {
+		rlpData = append(rlpData, blob[fieldIndex*32+1:(fieldIndex+1)*32]...)
+	}
+	var acc uint16
+	accBits := 0
+	for fieldIndex := 0; fieldIndex < params.BlobTxFieldElementsPerBlob; fieldIndex++ {
+		acc |= uint16(blob[fieldIndex*32]) << accBits
+		accBits += spareBlobBits
+		if accBits >= 8 {
+			// #nosec G115
+			rlpData = append(rlpData, uint8(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	if accBits != 0 {
+		return nil, fmt.Errorf("somehow ended up with %v spare accBits", accBits)
+	}
+	return rlpData, nil
+}
+
 // DecodeBlobs decodes blobs into the batch data encoded in them.
 func DecodeBlobs(blobs []kzg4844.Blob) ([]byte, error) {
-	var rlpData []byte
-	for _, blob := range blobs {
-		for fieldIndex := 0; fieldIndex < params.BlobTxFieldElementsPerBlob; fieldIndex++ {
-			rlpData = append(rlpData, blob[fieldIndex*32+1:(fieldIndex+1)*32]...)
-		}
-		var acc uint16
-		accBits := 0
-		for fieldIndex := 0; fieldIndex < params.BlobTxFieldElementsPerBlob; fieldIndex++ {
-			acc |= uint16(blob[fieldIndex*32]) << accBits
-			accBits += spareBlobBits
-			if accBits >= 8 {
-				// #nosec G115
-				rlpData = append(rlpData, uint8(acc))
-				acc >>= 8
-				accBits -= 8
-			}
-		}
-		if accBits != 0 {
-			return nil, fmt.Errorf("somehow ended up with %v spare accBits", accBits)
+	rlpData := make([]byte, 0, len(blobs)*BlobEncodableData)
+	for i := range blobs {
+		var err error
+		rlpData, err = appendBlobData(rlpData, &blobs[i])
+		if err != nil {
+			return nil, err
 		}
 	}
 	var outputData []byte
@@ -104,6 +121,7 @@ func DecodeBlobs(blobs []kzg4844.Blob) ([]byte, error) {
 	return outputData, err
 }
 
+// CommitmentToVersionedHash returns the EIP-4844 versioned hash of a KZG commitment.
 func CommitmentToVersionedHash(commitment kzg4844.Commitment) common.Hash {
 	// As per the EIP-4844 spec, the versioned hash is the SHA-256 hash of the commitment with the first byte set to 1.
 	hash := sha256.Sum256(commitment[:])
@@ -128,6 +146,7 @@ func ComputeCommitmentsAndHashes(blobs []kzg4844.Blob) ([]kzg4844.Commitment, []
 	return commitments, versionedHashes, nil
 }
 
+// ComputeBlobProofs returns the KZG proof for each blob against its commitment.
 func ComputeBlobProofs(blobs []kzg4844.Blob, commitments []kzg4844.Commitment) ([]kzg4844.Proof, error) {
 	if len(blobs) != len(commitments) {
 		return nil, fmt.Errorf("ComputeBlobProofs got %v blobs but %v commitments", len(blobs), len(commitments))
@@ -143,3 +162,30 @@ func ComputeBlobProofs(blobs []kzg4844.Blob, commitments []kzg4844.Commitment) (
 
 	return proofs, nil
 }
+
+// VerifyBlobProofs checks that each blob matches its commitment and proof.
+// The index of the first blob failing verification is included in the error.
+func VerifyBlobProofs(blobs []kzg4844.Blob, commitments []kzg4844.Commitment, proofs []kzg4844.Proof) error {
+	if len(blobs) != len(commitments) || len(blobs) != len(proofs) {
+		return fmt.Errorf("VerifyBlobProofs got %v blobs, %v commitments, and %v proofs", len(blobs), len(commitments), len(proofs))
+	}
+	for i := range blobs {
+		if err := kzg4844.VerifyBlobProof(&blobs[i], commitments[i], proofs[i]); err != nil {
+			return fmt.Errorf("failed to verify proof for blob %v: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// VerifyVersionedHashes checks that the commitments hash to the expected versioned hashes, in order.
+func VerifyVersionedHashes(commitments []kzg4844.Commitment, versionedHashes []common.Hash) error {
+	if len(commitments) != len(versionedHashes) {
+		return fmt.Errorf("VerifyVersionedHashes got %v commitments but %v versioned hashes", len(commitments), len(versionedHashes))
+	}
+	for i, commitment := range commitments {
+		if have := CommitmentToVersionedHash(commitment); have != versionedHashes[i] {
+			return fmt.Errorf("commitment %v has versioned hash %v but expected %v", i, have, versionedHashes[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package blobs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// rlpStringHeader returns the RLP header for a byte string of the given size.
+// Strings of a single byte below 0x80 are encoded without a header, so callers must handle that case separately.
+func rlpStringHeader(size uint64) []byte {
+	if size <= 55 {
+		// #nosec G115
+		return []byte{0x80 + uint8(size)}
+	}
+	var sizeBytes []byte
+	for s := size; s > 0; s >>= 8 {
+		// #nosec G115
+		sizeBytes = append([]byte{uint8(s)}, sizeBytes...)
+	}
+	// #nosec G115
+	return append([]byte{0xb7 + uint8(len(sizeBytes))}, sizeBytes...)
+}
+
+// EncodeBlobsFromReader reads exactly size bytes from r and passes each resulting blob to emit, in order.
+// The blobs are identical to those EncodeBlobs would produce for the same data, but at most one blob's
+// worth of the payload is held in memory at a time. The blob passed to emit is only valid for the
+// duration of the call.
+func EncodeBlobsFromReader(r io.Reader, size uint64, emit func(*kzg4844.Blob) error) error {
+	buf := make([]byte, 0, BlobEncodableData)
+	if size == 1 {
+		var single [1]byte
+		if _, err := io.ReadFull(r, single[:]); err != nil {
+			return fmt.Errorf("error reading blob payload: %w", err)
+		}
+		if single[0] >= 0x80 {
+			buf = append(buf, rlpStringHeader(1)...)
+		}
+		buf = append(buf, single[0])
+		size = 0
+	} else {
+		buf = append(buf, rlpStringHeader(size)...)
+	}
+	for {
+		want := uint64(BlobEncodableData - len(buf))
+		if want > size {
+			want = size
+		}
+		start := len(buf)
+		buf = buf[:start+int(want)]
+		if _, err := io.ReadFull(r, buf[start:]); err != nil {
+			return fmt.Errorf("error reading blob payload: %w", err)
+		}
+		size -= want
+		var blob kzg4844.Blob
+		rest := fillBlobBytes(blob[:], buf)
+		rest, err := fillBlobBits(blob[:], rest)
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("somehow ended up with %v bytes that didn't fit in a blob", len(rest))
+		}
+		if err := emit(&blob); err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		buf = buf[:0]
+	}
+}
+
+// BlobDecoder incrementally decodes blobs produced by EncodeBlobs or EncodeBlobsFromReader,
+// writing the decoded payload to an underlying writer as each blob arrives.
+// Not thread safe!
+type BlobDecoder struct {
+	w         io.Writer
+	pending   []byte
+	parsed    bool
+	remaining uint64
+	written   uint64
+}
+
+func NewBlobDecoder(w io.Writer) *BlobDecoder {
+	return &BlobDecoder{w: w}
+}
+
+var ErrTruncatedBlobPayload = errors.New("blob payload is truncated")
+
+// parseHeader attempts to parse the RLP string header at the start of d.pending.
+// It returns false without error if more data is needed.
+func (d *BlobDecoder) parseHeader() (bool, error) {
+	if len(d.pending) == 0 {
+		return false, nil
+	}
+	first := d.pending[0]
+	switch {
+	case first < 0x80:
+		d.remaining = 1
+		return true, nil
+	case first <= 0xb7:
+		d.remaining = uint64(first - 0x80)
+		if d.remaining == 1 && len(d.pending) > 1 && d.pending[1] < 0x80 {
+			return false, errors.New("non-canonical rlp encoding of single byte blob payload")
+		}
+		d.pending = d.pending[1:]
+		return true, nil
+	case first <= 0xbf:
+		sizeLen := int(first - 0xb7)
+		if len(d.pending) < 1+sizeLen {
+			return false, nil
+		}
+		if d.pending[1] == 0 {
+			return false, errors.New("non-canonical rlp size with leading zero in blob payload")
+		}
+		var size uint64
+		for _, b := range d.pending[1 : 1+sizeLen] {
+			size = size<<8 | uint64(b)
+		}
+		if size <= 55 {
+			return false, errors.New("non-canonical rlp size in blob payload")
+		}
+		d.remaining = size
+		d.pending = d.pending[1+sizeLen:]
+		return true, nil
+	default:
+		return false, fmt.Errorf("blob payload doesn't start with an rlp byte string (got prefix %#x)", first)
+	}
+}
+
+// Write decodes the next blob in the sequence and writes any newly decoded payload bytes.
+func (d *BlobDecoder) Write(blob *kzg4844.Blob) error {
+	var err error
+	d.pending, err = appendBlobData(d.pending, blob)
+	if err != nil {
+		return err
+	}
+	if !d.parsed {
+		d.parsed, err = d.parseHeader()
+		if err != nil || !d.parsed {
+			return err
+		}
+	}
+	data := d.pending
+	if uint64(len(data)) > d.remaining {
+		// Anything past the payload is padding
+		data = data[:d.remaining]
+	}
+	if len(data) > 0 {
+		if _, err := d.w.Write(data); err != nil {
+			return err
+		}
+	}
+	d.remaining -= uint64(len(data))
+	d.written += uint64(len(data))
+	d.pending = d.pending[:0]
+	return nil
+}
+
+// Close returns an error if the blobs written so far don't contain the full payload.
+func (d *BlobDecoder) Close() error {
+	if !d.parsed || d.remaining > 0 {
+		return ErrTruncatedBlobPayload
+	}
+	return nil
+}
+
+// Written returns the number of payload bytes decoded so far.
+func (d *BlobDecoder) Written() uint64 {
+	return d.written
+}
@@ -0,0 +1,82 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package blobs
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+func TestStreamingBlobEncoding(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	lengths := []int{0, 1, 2, 55, 56, BlobEncodableData - 3, BlobEncodableData, BlobEncodableData + 1}
+	for i := 0; i < 10; i++ {
+		lengths = append(lengths, r.Int()%(BlobEncodableData*3))
+	}
+	for _, length := range lengths {
+		data := make([]byte, length)
+		_, err := r.Read(data)
+		if err != nil {
+			t.Fatalf("failed to generate random bytes: %v", err)
+		}
+		if length == 1 {
+			// exercise the rlp single byte special case
+			data[0] = 0x7f
+		}
+		expected, err := EncodeBlobs(data)
+		if err != nil {
+			t.Fatalf("failed to encode blobs for length %v: %v", length, err)
+		}
+		var streamed []kzg4844.Blob
+		err = EncodeBlobsFromReader(bytes.NewReader(data), uint64(length), func(b *kzg4844.Blob) error {
+			streamed = append(streamed, *b)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to stream encode blobs for length %v: %v", length, err)
+		}
+		if len(streamed) != len(expected) {
+			t.Fatalf("for length %v streamed %v blobs but expected %v", length, len(streamed), len(expected))
+		}
+		for j := range expected {
+			if streamed[j] != expected[j] {
+				t.Fatalf("for length %v streamed blob %v differs from EncodeBlobs", length, j)
+			}
+		}
+
+		var out bytes.Buffer
+		decoder := NewBlobDecoder(&out)
+		for j := range streamed {
+			if err := decoder.Write(&streamed[j]); err != nil {
+				t.Fatalf("failed to stream decode blob %v for length %v: %v", j, length, err)
+			}
+		}
+		if err := decoder.Close(); err != nil {
+			t.Fatalf("failed to close decoder for length %v: %v", length, err)
+		}
+		if !bytes.Equal(out.Bytes(), data) {
+			t.Fatalf("got different streamed decoding for length %v", length)
+		}
+	}
+}
+
+func TestStreamingBlobDecodingTruncated(t *testing.T) {
+	data := make([]byte, BlobEncodableData*2)
+	enc, err := EncodeBlobs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	decoder := NewBlobDecoder(&out)
+	if err := decoder.Write(&enc[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoder.Close(); !errors.Is(err, ErrTruncatedBlobPayload) {
+		t.Fatalf("expected truncated payload error but got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package containers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+type CacheOpts[V any] struct {
+	// Maximum number of entries, must be positive.
+	Capacity int
+	// Maximum total size of entries as reported by SizeOf, 0 means unlimited.
+	MaxBytes uint64
+	// Entries older than this are treated as missing, 0 means entries never expire.
+	TTL time.Duration
+	// Returns the size of a value, only required if MaxBytes is set.
+	SizeOf func(V) uint64
+	// If set, hit/miss/eviction counters and entry/byte gauges are registered under this prefix.
+	MetricsPrefix string
+}
+
+type cacheEntry[V any] struct {
+	value    V
+	size     uint64
+	inserted time.Time
+}
+
+type cacheMetrics struct {
+	hits        metrics.Counter
+	misses      metrics.Counter
+	evictions   metrics.Counter
+	expirations metrics.Counter
+	entries     metrics.Gauge
+	bytes       metrics.Gauge
+}
+
+func newCacheMetrics(prefix string) *cacheMetrics {
+	if prefix == "" {
+		return nil
+	}
+	return &cacheMetrics{
+		hits:        metrics.GetOrRegisterCounter(prefix+"/hits", nil),
+		misses:      metrics.GetOrRegisterCounter(prefix+"/misses", nil),
+		evictions:   metrics.GetOrRegisterCounter(prefix+"/evictions", nil),
+		expirations: metrics.GetOrRegisterCounter(prefix+"/expirations", nil),
+		entries:     metrics.GetOrRegisterGauge(prefix+"/entries", nil),
+		bytes:       metrics.GetOrRegisterGauge(prefix+"/bytes", nil),
+	}
+}
+
+// Cache is a thread safe LRU cache which can additionally bound the total size of its
+// entries and expire entries after a fixed time to live.
+// The Stylus wasm cache isn't one: it holds compiled native modules in the Rust runtime, which reports its
+// own metrics through programs.UpdateWasmCacheMetrics. Nor is the header cache, which is internal to geth's
+// HeaderChain.
+type Cache[K comparable, V any] struct {
+	mutex    sync.Mutex
+	inner    *LruCache[K, cacheEntry[V]]
+	opts     CacheOpts[V]
+	bytes    uint64
+	expiring bool // set while removing an expired entry so onEvict doesn't count it as an eviction
+	metrics  *cacheMetrics
+	now      func() time.Time
+}
+
+func NewCache[K comparable, V any](opts CacheOpts[V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		opts:    opts,
+		metrics: newCacheMetrics(opts.MetricsPrefix),
+		now:     time.Now,
+	}
+	c.inner = NewLruCacheWithOnEvict(opts.Capacity, c.onEvict)
+	return c
+}
+
+// Only called with the mutex held.
+func (c *Cache[K, V]) onEvict(_ K, entry cacheEntry[V]) {
+	c.bytes -= entry.size
+	if c.metrics == nil {
+		return
+	}
+	if c.expiring {
+		c.metrics.expirations.Inc(1)
+	} else {
+		c.metrics.evictions.Inc(1)
+	}
+}
+
+// Only called with the mutex held.
+func (c *Cache[K, V]) updateGauges() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.entries.Update(int64(c.inner.Len()))
+	// #nosec G115
+	c.metrics.bytes.Update(int64(c.bytes))
+}
+
+// Only called with the mutex held.
+func (c *Cache[K, V]) expired(entry cacheEntry[V]) bool {
+	return c.opts.TTL > 0 && c.now().Sub(entry.inserted) >= c.opts.TTL
+}
+
+// Only called with the mutex held.
+func (c *Cache[K, V]) removeExpired(key K) {
+	c.expiring = true
+	c.inner.Remove(key)
+	c.expiring = false
+}
+
+// Add inserts or replaces a value, evicting the least recently used entries as needed.
+// Returns true if any other entry was evicted.
+func (c *Cache[K, V]) Add(key K, value V) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var size uint64
+	if c.opts.SizeOf != nil {
+		size = c.opts.SizeOf(value)
+	}
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		// Would evict everything and still not fit
+		c.inner.Remove(key)
+		c.updateGauges()
+		return false
+	}
+	if old, ok := c.inner.Peek(key); ok {
+		// Replacing an entry updates it in place without calling onEvict
+		c.bytes -= old.size
+	}
+	evicted := c.inner.Add(key, cacheEntry[V]{value: value, size: size, inserted: c.now()})
+	c.bytes += size
+	for c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes && c.inner.Len() > 1 {
+		c.inner.RemoveOldest()
+		evicted = true
+	}
+	c.updateGauges()
+	return evicted
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var empty V
+	entry, ok := c.inner.Get(key)
+	if ok && c.expired(entry) {
+		c.removeExpired(key)
+		c.updateGauges()
+		ok = false
+	}
+	if c.metrics != nil {
+		if ok {
+			c.metrics.hits.Inc(1)
+		} else {
+			c.metrics.misses.Inc(1)
+		}
+	}
+	if !ok {
+		return empty, false
+	}
+	return entry.value, true
+}
+
+// Contains doesn't update the recency of the key or the hit/miss metrics.
+func (c *Cache[K, V]) Contains(key K) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.inner.Peek(key)
+	return ok && !c.expired(entry)
+}
+
+func (c *Cache[K, V]) Remove(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.inner.Remove(key)
+	c.updateGauges()
+}
+
+// RemoveExpired drops all expired entries, returning how many were removed.
+func (c *Cache[K, V]) RemoveExpired() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.opts.TTL == 0 {
+		return 0
+	}
+	removed := 0
+	for _, key := range c.inner.Keys() {
+		entry, ok := c.inner.Peek(key)
+		if ok && c.expired(entry) {
+			c.removeExpired(key)
+			removed++
+		}
+	}
+	c.updateGauges()
+	return removed
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.inner.Len()
+}
+
+// Bytes returns the total size of the entries as reported by SizeOf.
+func (c *Cache[K, V]) Bytes() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.bytes
+}
+
+func (c *Cache[K, V]) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.inner.Clear()
+	c.bytes = 0
+	c.updateGauges()
+}
+
+func (c *Cache[K, V]) Resize(capacity int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.inner.Resize(capacity)
+	c.opts.Capacity = capacity
+	c.updateGauges()
+}
+
+// SetMaxBytes changes the size bound, evicting the least recently used entries if over it.
+func (c *Cache[K, V]) SetMaxBytes(maxBytes uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.opts.MaxBytes = maxBytes
+	for maxBytes > 0 && c.bytes > maxBytes && c.inner.Len() > 0 {
+		c.inner.RemoveOldest()
+	}
+	c.updateGauges()
+}
@@ -0,0 +1,69 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package containers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheSizeEviction(t *testing.T) {
+	c := NewCache[int, []byte](CacheOpts[[]byte]{
+		Capacity: 10,
+		MaxBytes: 100,
+		SizeOf:   func(v []byte) uint64 { return uint64(len(v)) },
+	})
+	for i := 0; i < 4; i++ {
+		c.Add(i, make([]byte, 30))
+	}
+	if c.Len() != 3 || c.Bytes() != 90 {
+		t.Fatalf("expected 3 entries totalling 90 bytes but got %v entries totalling %v bytes", c.Len(), c.Bytes())
+	}
+	if c.Contains(0) {
+		t.Fatal("expected oldest entry to be evicted")
+	}
+	// Replacing an entry shouldn't double count its size
+	c.Add(3, make([]byte, 10))
+	if c.Bytes() != 70 {
+		t.Fatalf("expected 70 bytes after replacing an entry but got %v", c.Bytes())
+	}
+	// An entry bigger than the whole cache is never stored
+	c.Add(4, make([]byte, 101))
+	if c.Contains(4) || c.Len() != 3 {
+		t.Fatal("expected oversized entry to be rejected")
+	}
+	c.Clear()
+	if c.Len() != 0 || c.Bytes() != 0 {
+		t.Fatalf("expected empty cache after clear but got %v entries totalling %v bytes", c.Len(), c.Bytes())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := NewCache[string, int](CacheOpts[int]{
+		Capacity: 10,
+		TTL:      time.Minute,
+	})
+	now := time.Unix(1_000_000, 0)
+	c.now = func() time.Time { return now }
+	c.Add("a", 1)
+	now = now.Add(30 * time.Second)
+	c.Add("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1 before expiry but got %v, %v", v, ok)
+	}
+	now = now.Add(30 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a to have expired")
+	}
+	if !c.Contains("b") {
+		t.Fatal("expected b to not have expired yet")
+	}
+	now = now.Add(30 * time.Second)
+	if removed := c.RemoveExpired(); removed != 1 {
+		t.Fatalf("expected to remove 1 expired entry but removed %v", removed)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected empty cache but got %v entries", c.Len())
+	}
+}
@@ -48,6 +48,15 @@ func (c *LruCache[K, V]) Get(key K) (V, bool) {
 	return c.inner.Get(key)
 }
 
+// Peek returns the value for a key without updating its recency.
+func (c *LruCache[K, V]) Peek(key K) (V, bool) {
+	var empty V
+	if c.inner == nil {
+		return empty, false
+	}
+	return c.inner.Peek(key)
+}
+
 func (c *LruCache[K, V]) Contains(key K) bool {
 	if c.inner == nil {
 		return false
@@ -78,6 +87,14 @@ func (c *LruCache[K, V]) RemoveOldest() {
 	c.inner.RemoveOldest()
 }
 
+// Keys returns the keys from oldest to newest.
+func (c *LruCache[K, V]) Keys() []K {
+	if c.inner == nil {
+		return nil
+	}
+	return c.inner.Keys()
+}
+
 func (c *LruCache[K, V]) Len() int {
 	if c.inner == nil {
 		return 0
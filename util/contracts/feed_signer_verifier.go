@@ -0,0 +1,80 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package contracts
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+// FeedSignerVerifier checks feed signers against the set the chain owner publishes through ArbOwnerPublic.
+// The whole set is cached, so a removed signer is rejected once the cache expires, while a signer missing
+// from the cache triggers a refetch so newly added signers are accepted promptly.
+type FeedSignerVerifier struct {
+	arbOwnerPublic *precompilesgen.ArbOwnerPublicCaller
+	signers        map[common.Address]struct{}
+	fetchedAt      time.Time
+	mutex          sync.Mutex
+}
+
+var feedSignerVerifierLifetime = time.Minute
+var feedSignerVerifierMinRefetch = 5 * time.Second
+
+func NewFeedSignerVerifier(arbOwnerPublic *precompilesgen.ArbOwnerPublicCaller) *FeedSignerVerifier {
+	return &FeedSignerVerifier{
+		arbOwnerPublic: arbOwnerPublic,
+		signers:        make(map[common.Address]struct{}),
+	}
+}
+
+func (fv *FeedSignerVerifier) IsFeedSigner(ctx context.Context, addr common.Address) (bool, error) {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	age := time.Since(fv.fetchedAt)
+	_, isSigner := fv.signers[addr]
+	if age < feedSignerVerifierLifetime && (isSigner || age < feedSignerVerifierMinRefetch) {
+		return isSigner, nil
+	}
+	// Even a failed fetch waits out the refetch interval, so a chain without feed signers isn't hammered
+	fv.fetchedAt = time.Now()
+	signers, err := fv.arbOwnerPublic.GetAllFeedSigners(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return isSigner, err
+	}
+	fv.signers = make(map[common.Address]struct{}, len(signers))
+	for _, signer := range signers {
+		fv.signers[signer] = struct{}{}
+	}
+	_, isSigner = fv.signers[addr]
+	return isSigner, nil
+}
+
+func NewMockFeedSignerVerifier(signers ...common.Address) *MockFeedSignerVerifier {
+	return &MockFeedSignerVerifier{
+		signers: signers,
+	}
+}
+
+type MockFeedSignerVerifier struct {
+	signers []common.Address
+}
+
+func (fv *MockFeedSignerVerifier) IsFeedSigner(_ context.Context, addr common.Address) (bool, error) {
+	for _, signer := range fv.signers {
+		if signer == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type FeedSignerVerifierInterface interface {
+	IsFeedSigner(ctx context.Context, addr common.Address) (bool, error)
+}
@@ -0,0 +1,182 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package deterministic implements a minimal canonical binary encoding.
+// Every value has exactly one valid encoding, and the Reader rejects anything
+// else, so encoded messages can be compared and hashed byte for byte and
+// decoders in other implementations can be checked against this one.
+//
+// Integers are fixed width big endian, booleans are a single 0 or 1 byte,
+// variable length byte strings are prefixed with their uint32 length, and
+// big integers are non-negative byte strings without leading zeros.
+package deterministic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+var (
+	ErrTruncated     = errors.New("deterministic encoding truncated")
+	ErrNonCanonical  = errors.New("non-canonical deterministic encoding")
+	ErrTrailingBytes = errors.New("trailing bytes after deterministic encoding")
+)
+
+type Writer struct {
+	buf []byte
+}
+
+func NewWriter(capacity int) *Writer {
+	return &Writer{buf: make([]byte, 0, capacity)}
+}
+
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+func (w *Writer) Uint8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *Writer) Uint64(v uint64) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *Writer) Bool(v bool) {
+	if v {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+// Fixed writes data without a length prefix; the reader must know its size.
+func (w *Writer) Fixed(data []byte) {
+	w.buf = append(w.buf, data...)
+}
+
+func (w *Writer) VarBytes(data []byte) error {
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("byte string of length %v too long to encode", len(data))
+	}
+	// #nosec G115
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(len(data)))
+	w.buf = append(w.buf, data...)
+	return nil
+}
+
+func (w *Writer) BigInt(v *big.Int) error {
+	if v.Sign() < 0 {
+		return fmt.Errorf("cannot encode negative big int %v", v)
+	}
+	return w.VarBytes(v.Bytes())
+}
+
+// Reader decodes values written by a Writer. The first error is sticky, so
+// callers can read a whole structure and check Finish once at the end.
+type Reader struct {
+	data []byte
+	err  error
+}
+
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+func (r *Reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Reader) take(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if uint64(len(r.data)) < n {
+		r.fail(ErrTruncated)
+		return nil
+	}
+	out := r.data[:n]
+	r.data = r.data[n:]
+	return out
+}
+
+func (r *Reader) Uint8() uint8 {
+	b := r.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *Reader) Uint64() uint64 {
+	b := r.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (r *Reader) Bool() bool {
+	switch r.Uint8() {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.fail(fmt.Errorf("%w: invalid bool", ErrNonCanonical))
+		return false
+	}
+}
+
+// Fixed reads exactly len(out) bytes into out.
+func (r *Reader) Fixed(out []byte) {
+	b := r.take(uint64(len(out)))
+	if b != nil {
+		copy(out, b)
+	}
+}
+
+// VarBytes returns a copy of a length prefixed byte string.
+func (r *Reader) VarBytes() []byte {
+	lenBytes := r.take(4)
+	if lenBytes == nil {
+		return nil
+	}
+	b := r.take(uint64(binary.BigEndian.Uint32(lenBytes)))
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (r *Reader) BigInt() *big.Int {
+	b := r.VarBytes()
+	if r.err != nil {
+		return nil
+	}
+	if len(b) > 0 && b[0] == 0 {
+		r.fail(fmt.Errorf("%w: big int with leading zero", ErrNonCanonical))
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Finish returns the first decoding error, or ErrTrailingBytes if any input is left unread.
+func (r *Reader) Finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: %v bytes", ErrTrailingBytes, len(r.data))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package deterministic
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	w := NewWriter(0)
+	w.Uint8(7)
+	w.Uint64(1 << 40)
+	w.Bool(true)
+	w.Fixed([]byte{1, 2, 3})
+	if err := w.VarBytes([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.BigInt(big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.BigInt(new(big.Int)); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(w.Bytes())
+	if v := r.Uint8(); v != 7 {
+		t.Errorf("got uint8 %v", v)
+	}
+	if v := r.Uint64(); v != 1<<40 {
+		t.Errorf("got uint64 %v", v)
+	}
+	if !r.Bool() {
+		t.Error("got false bool")
+	}
+	fixed := make([]byte, 3)
+	r.Fixed(fixed)
+	if !bytes.Equal(fixed, []byte{1, 2, 3}) {
+		t.Errorf("got fixed %v", fixed)
+	}
+	if v := r.VarBytes(); string(v) != "hello" {
+		t.Errorf("got bytes %q", v)
+	}
+	if v := r.BigInt(); v.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("got big int %v", v)
+	}
+	if v := r.BigInt(); v.Sign() != 0 {
+		t.Errorf("got big int %v", v)
+	}
+	if err := r.Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRejectsNonCanonical(t *testing.T) {
+	if err := NewWriter(0).BigInt(big.NewInt(-1)); err == nil {
+		t.Error("expected error encoding negative big int")
+	}
+	cases := []struct {
+		name string
+		data []byte
+		read func(r *Reader)
+		want error
+	}{
+		{"bool", []byte{2}, func(r *Reader) { r.Bool() }, ErrNonCanonical},
+		{"big int leading zero", []byte{0, 0, 0, 2, 0, 1}, func(r *Reader) { r.BigInt() }, ErrNonCanonical},
+		{"truncated uint64", []byte{1, 2, 3}, func(r *Reader) { r.Uint64() }, ErrTruncated},
+		{"truncated bytes", []byte{0, 0, 0, 5, 1}, func(r *Reader) { r.VarBytes() }, ErrTruncated},
+		{"trailing bytes", []byte{1, 0}, func(r *Reader) { r.Uint8() }, ErrTrailingBytes},
+	}
+	for _, c := range cases {
+		r := NewReader(c.data)
+		c.read(r)
+		if err := r.Finish(); !errors.Is(err, c.want) {
+			t.Errorf("%v: got error %v, expected %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package flightrecorder keeps the last few seconds of key node events in memory, so
+// they can be written to disk when the node panics or an operator asks for them.
+package flightrecorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	KindBlockSequenced  = "block-sequenced"
+	KindMessageDigested = "message-digested"
+	KindFeedBroadcast   = "feed-broadcast"
+	KindRPCError        = "rpc-error"
+	KindPanic           = "panic"
+)
+
+var (
+	eventsRecordedCounter = metrics.NewRegisteredCounter("arb/flightrecorder/recorded", nil)
+	dumpsCounter          = metrics.NewRegisteredCounter("arb/flightrecorder/dumps", nil)
+	dumpsFailedCounter    = metrics.NewRegisteredCounter("arb/flightrecorder/dumps/failed", nil)
+)
+
+type Config struct {
+	Enable      bool          `koanf:"enable"`
+	Window      time.Duration `koanf:"window"`
+	MaxEvents   int           `koanf:"max-events"`
+	Dir         string        `koanf:"dir"`
+	DumpOnPanic bool          `koanf:"dump-on-panic"`
+}
+
+var DefaultConfig = Config{
+	Enable:      true,
+	Window:      30 * time.Second,
+	MaxEvents:   100_000,
+	Dir:         "",
+	DumpOnPanic: true,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "keep recent sequencing, digest, feed and rpc error events in memory so they can be dumped to disk")
+	f.Duration(prefix+".window", DefaultConfig.Window, "how far back events are kept")
+	f.Int(prefix+".max-events", DefaultConfig.MaxEvents, "maximum number of events kept, regardless of the window")
+	f.String(prefix+".dir", DefaultConfig.Dir, "directory dumps are written to (defaults to flight-recorder in the node's data directory)")
+	f.Bool(prefix+".dump-on-panic", DefaultConfig.DumpOnPanic, "dump the recorded events when the node panics")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Window <= 0 {
+		return errors.New("flight recorder window must be positive")
+	}
+	if c.MaxEvents < 1 {
+		return errors.New("flight recorder max-events must be positive")
+	}
+	return nil
+}
+
+type Event struct {
+	Time   time.Time
+	Kind   string
+	Fields []interface{}
+}
+
+// MarshalJSON writes the fields as an object, the same way they'd appear in a log line.
+func (e Event) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(e.Fields)/2)
+	for i := 0; i+1 < len(e.Fields); i += 2 {
+		fields[fmt.Sprint(e.Fields[i])] = e.Fields[i+1]
+	}
+	return json.Marshal(struct {
+		Time   time.Time              `json:"time"`
+		Kind   string                 `json:"kind"`
+		Fields map[string]interface{} `json:"fields,omitempty"`
+	}{e.Time, e.Kind, fields})
+}
+
+// Recorder is a fixed size ring buffer of events.
+type Recorder struct {
+	config *Config
+
+	mutex  sync.Mutex
+	events []Event
+	next   int
+	full   bool
+
+	dumpMutex sync.Mutex
+	panicked  atomic.Bool
+}
+
+func NewRecorder(config *Config) *Recorder {
+	return &Recorder{
+		config: config,
+		events: make([]Event, config.MaxEvents),
+	}
+}
+
+// Record adds an event, overwriting the oldest one if the buffer is full.
+// Fields are key value pairs, like the context of a log line.
+func (r *Recorder) Record(kind string, fields ...interface{}) {
+	event := Event{Time: time.Now(), Kind: kind, Fields: fields}
+	r.mutex.Lock()
+	r.events[r.next] = event
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+	r.mutex.Unlock()
+	eventsRecordedCounter.Inc(1)
+}
+
+// Events returns the events within the window, oldest first.
+func (r *Recorder) Events() []Event {
+	cutoff := time.Now().Add(-r.config.Window)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var ordered []Event
+	if r.full {
+		ordered = append(ordered, r.events[r.next:]...)
+	}
+	ordered = append(ordered, r.events[:r.next]...)
+	for i, event := range ordered {
+		if !event.Time.Before(cutoff) {
+			return ordered[i:]
+		}
+	}
+	return nil
+}
+
+// Dump writes the events within the window to a new file in the configured directory, one JSON object per line,
+// and returns the file's path.
+func (r *Recorder) Dump(reason string) (string, error) {
+	r.dumpMutex.Lock()
+	defer r.dumpMutex.Unlock()
+	path, err := r.dump(reason)
+	if err != nil {
+		dumpsFailedCounter.Inc(1)
+		return "", err
+	}
+	dumpsCounter.Inc(1)
+	return path, nil
+}
+
+func (r *Recorder) dump(reason string) (string, error) {
+	if err := os.MkdirAll(r.config.Dir, 0755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	path := filepath.Join(r.config.Dir, fmt.Sprintf("flight-recorder-%d-%s.jsonl", now.UnixMilli(), reason))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, event := range r.Events() {
+		if err := encoder.Encode(event); err != nil {
+			// Fields that can't be encoded, like channels, are written as strings
+			if err := encoder.Encode(Event{Time: event.Time, Kind: event.Kind, Fields: stringFields(event.Fields)}); err != nil {
+				file.Close()
+				return "", err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+func stringFields(fields []interface{}) []interface{} {
+	strings := make([]interface{}, len(fields))
+	for i, field := range fields {
+		strings[i] = fmt.Sprint(field)
+	}
+	return strings
+}
+
+// dumpPanic dumps the events at most once, as a panic in one thread is often followed by others.
+func (r *Recorder) dumpPanic(panicErr interface{}) {
+	if !r.config.DumpOnPanic || r.panicked.Swap(true) {
+		return
+	}
+	r.Record(KindPanic, "panic", fmt.Sprint(panicErr))
+	path, err := r.Dump("panic")
+	if err != nil {
+		log.Error("failed to dump flight recorder on panic", "err", err)
+		return
+	}
+	log.Error("dumped flight recorder on panic", "path", path)
+}
+
+var global atomic.Pointer[Recorder]
+
+// SetGlobal sets the recorder used by Record and DumpOnPanic. A nil recorder disables them.
+func SetGlobal(r *Recorder) {
+	global.Store(r)
+}
+
+// Record adds an event to the global recorder, if there is one.
+func Record(kind string, fields ...interface{}) {
+	if r := global.Load(); r != nil {
+		r.Record(kind, fields...)
+	}
+}
+
+// DumpOnPanic must be deferred directly. It dumps the global recorder if the thread
+// is panicking, then continues the panic.
+func DumpOnPanic() {
+	if panicErr := recover(); panicErr != nil {
+		DumpPanic(panicErr)
+		panic(panicErr)
+	}
+}
+
+// DumpPanic dumps the global recorder for a panic which was recovered from.
+func DumpPanic(panicErr interface{}) {
+	if r := global.Load(); r != nil {
+		r.dumpPanic(panicErr)
+	}
+}
+
+type API struct {
+	recorder *Recorder
+}
+
+func NewAPI(recorder *Recorder) *API {
+	return &API{recorder}
+}
+
+// DumpFlightRecorder writes the recorded events to disk and returns the path of the dump.
+func (a *API) DumpFlightRecorder() (string, error) {
+	return a.recorder.Dump("admin")
+}
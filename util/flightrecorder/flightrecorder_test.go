@@ -0,0 +1,84 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package flightrecorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderRing(t *testing.T) {
+	config := DefaultConfig
+	config.MaxEvents = 3
+	config.Dir = t.TempDir()
+	recorder := NewRecorder(&config)
+	for i := 0; i < 5; i++ {
+		recorder.Record(KindMessageDigested, "message", i)
+	}
+	events := recorder.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events but got %v", len(events))
+	}
+	for i, event := range events {
+		if event.Fields[1] != i+2 {
+			t.Errorf("expected event %v to be message %v but got %v", i, i+2, event.Fields[1])
+		}
+	}
+
+	config.Window = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	recorder.Record(KindBlockSequenced, "block", 1)
+	if events := recorder.Events(); len(events) != 1 || events[0].Kind != KindBlockSequenced {
+		t.Fatalf("expected only the event within the window but got %v", events)
+	}
+
+	path, err := recorder.Dump("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 1 || lines[0]["kind"] != KindBlockSequenced || lines[0]["fields"].(map[string]interface{})["block"] != float64(1) {
+		t.Fatalf("unexpected dump %v", lines)
+	}
+}
+
+func TestRecordRPCErrors(t *testing.T) {
+	config := DefaultConfig
+	recorder := NewRecorder(&config)
+	handler := recorder.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"execution reverted"}}]`))
+	}))
+	request := `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[]}]`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request)))
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected one rpc error but got %v", events)
+	}
+	want := []interface{}{"method", "eth_call", "id", "2", "code", -32000, "message", "execution reverted"}
+	for i := range want {
+		if events[0].Fields[i] != want[i] {
+			t.Fatalf("expected fields %v but got %v", want, events[0].Fields)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package flightrecorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Larger requests and responses aren't inspected for errors
+const maxInspectedSize = 1 << 20
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+type rpcResponse struct {
+	ID    json.RawMessage `json:"id"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// responseCapture passes a response through while keeping a copy of its start.
+type responseCapture struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (c *responseCapture) Write(data []byte) (int, error) {
+	if !c.truncated {
+		if c.body.Len()+len(data) > maxInspectedSize {
+			c.truncated = true
+		} else {
+			c.body.Write(data)
+		}
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// WrapHandler returns an http handler which records the errors in JSON-RPC responses
+// served over http by next. Websocket calls aren't seen.
+func (r *Recorder) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Body == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxInspectedSize+1))
+		if err != nil || len(body) > maxInspectedSize {
+			req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+			next.ServeHTTP(w, req)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		capture := &responseCapture{ResponseWriter: w}
+		next.ServeHTTP(capture, req)
+		// Compressed responses aren't inspected
+		if capture.truncated || w.Header().Get("Content-Encoding") != "" {
+			return
+		}
+		r.recordRPCErrors(body, capture.body.Bytes())
+	})
+}
+
+func (r *Recorder) recordRPCErrors(request []byte, response []byte) {
+	var responses []rpcResponse
+	trimmed := bytes.TrimSpace(response)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if json.Unmarshal(trimmed, &responses) != nil {
+			return
+		}
+	} else {
+		var single rpcResponse
+		if json.Unmarshal(trimmed, &single) != nil {
+			return
+		}
+		responses = []rpcResponse{single}
+	}
+	var methods map[string]string
+	for _, resp := range responses {
+		if resp.Error == nil {
+			continue
+		}
+		if methods == nil {
+			methods = requestMethods(request)
+		}
+		r.Record(KindRPCError, "method", methods[string(resp.ID)], "id", string(resp.ID), "code", resp.Error.Code, "message", resp.Error.Message)
+	}
+}
+
+// requestMethods maps the ids of the calls in a request to their methods.
+func requestMethods(body []byte) map[string]string {
+	var requests []rpcRequest
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if json.Unmarshal(trimmed, &requests) != nil {
+			return map[string]string{}
+		}
+	} else {
+		var single rpcRequest
+		if json.Unmarshal(trimmed, &single) != nil {
+			return map[string]string{}
+		}
+		requests = []rpcRequest{single}
+	}
+	methods := make(map[string]string, len(requests))
+	for _, req := range requests {
+		methods[string(req.ID)] = req.Method
+	}
+	return methods
+}
@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -18,12 +19,19 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 )
 
+var (
+	subscribedGauge         = metrics.NewRegisteredGauge("arb/headerreader/subscribed", nil)
+	subscribedHeaderCounter = metrics.NewRegisteredCounter("arb/headerreader/headers/subscribed", nil)
+	polledHeaderCounter     = metrics.NewRegisteredCounter("arb/headerreader/headers/polled", nil)
+)
+
 // A regexp matching "execution reverted" errors returned from the parent chain RPC.
 var ExecutionRevertedRegexp = regexp.MustCompile(`(?i)execution reverted|VM execution error\.?`)
 
@@ -37,6 +45,7 @@ type HeaderReader struct {
 	client                *ethclient.Client
 	isParentChainArbitrum bool
 	arbSys                ArbSysInterface
+	subscribed            atomic.Bool
 
 	chanMutex sync.RWMutex
 	// All fields below require the chanMutex
@@ -61,15 +70,34 @@ type cachedHeader struct {
 }
 
 type Config struct {
-	Enable               bool            `koanf:"enable"`
-	PollOnly             bool            `koanf:"poll-only" reload:"hot"`
-	PollInterval         time.Duration   `koanf:"poll-interval" reload:"hot"`
-	PollTimeout          time.Duration   `koanf:"poll-timeout" reload:"hot"`
-	SubscribeErrInterval time.Duration   `koanf:"subscribe-err-interval" reload:"hot"`
-	TxTimeout            time.Duration   `koanf:"tx-timeout" reload:"hot"`
-	OldHeaderTimeout     time.Duration   `koanf:"old-header-timeout" reload:"hot"`
-	UseFinalityData      bool            `koanf:"use-finality-data" reload:"hot"`
-	Dangerous            DangerousConfig `koanf:"dangerous"`
+	Enable                 bool                   `koanf:"enable"`
+	PollOnly               bool                   `koanf:"poll-only" reload:"hot"`
+	PollInterval           time.Duration          `koanf:"poll-interval" reload:"hot"`
+	SubscribedPollInterval time.Duration          `koanf:"subscribed-poll-interval" reload:"hot"`
+	PollTimeout            time.Duration          `koanf:"poll-timeout" reload:"hot"`
+	SubscribeErrInterval   time.Duration          `koanf:"subscribe-err-interval" reload:"hot"`
+	TxTimeout              time.Duration          `koanf:"tx-timeout" reload:"hot"`
+	OldHeaderTimeout       time.Duration          `koanf:"old-header-timeout" reload:"hot"`
+	UseFinalityData        bool                   `koanf:"use-finality-data" reload:"hot"`
+	FinalityOverride       FinalityOverrideConfig `koanf:"finality-override" reload:"hot"`
+	Dangerous              DangerousConfig        `koanf:"dangerous"`
+}
+
+// FinalityOverrideConfig makes the reader judge parent chain blocks safe and finalized by their age,
+// so devnets and local chains don't have to wait for (or support) real finality.
+type FinalityOverrideConfig struct {
+	Enable bool          `koanf:"enable"`
+	Delay  time.Duration `koanf:"delay"`
+}
+
+var DefaultFinalityOverrideConfig = FinalityOverrideConfig{
+	Enable: false,
+	Delay:  0,
+}
+
+func FinalityOverrideConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultFinalityOverrideConfig.Enable, "for devnets and test chains only: ignore the parent chain's finality data and treat its blocks as safe and finalized once they're older than the delay")
+	f.Duration(prefix+".delay", DefaultFinalityOverrideConfig.Delay, "how old a parent chain block must be to be treated as safe and finalized (0 = as soon as it's seen)")
 }
 
 type DangerousConfig struct {
@@ -79,14 +107,16 @@ type DangerousConfig struct {
 type ConfigFetcher func() *Config
 
 var DefaultConfig = Config{
-	Enable:               true,
-	PollOnly:             false,
-	PollInterval:         15 * time.Second,
-	PollTimeout:          5 * time.Second,
-	SubscribeErrInterval: 5 * time.Minute,
-	TxTimeout:            5 * time.Minute,
-	OldHeaderTimeout:     5 * time.Minute,
-	UseFinalityData:      true,
+	Enable:                 true,
+	PollOnly:               false,
+	PollInterval:           15 * time.Second,
+	SubscribedPollInterval: time.Minute,
+	PollTimeout:            5 * time.Second,
+	SubscribeErrInterval:   5 * time.Minute,
+	TxTimeout:              5 * time.Minute,
+	OldHeaderTimeout:       5 * time.Minute,
+	UseFinalityData:        true,
+	FinalityOverride:       DefaultFinalityOverrideConfig,
 	Dangerous: DangerousConfig{
 		WaitForTxApprovalSafePoll: 0,
 	},
@@ -97,10 +127,12 @@ func AddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".poll-only", DefaultConfig.PollOnly, "do not attempt to subscribe to header events")
 	f.Bool(prefix+".use-finality-data", DefaultConfig.UseFinalityData, "use l1 data about finalized/safe blocks")
 	f.Duration(prefix+".poll-interval", DefaultConfig.PollInterval, "interval when polling endpoint")
+	f.Duration(prefix+".subscribed-poll-interval", DefaultConfig.SubscribedPollInterval, "interval when polling endpoint while subscribed to header events, which only guards against a stalled subscription (0 = use poll-interval)")
 	f.Duration(prefix+".poll-timeout", DefaultConfig.PollTimeout, "timeout when polling endpoint")
 	f.Duration(prefix+".subscribe-err-interval", DefaultConfig.SubscribeErrInterval, "interval for subscribe error")
 	f.Duration(prefix+".tx-timeout", DefaultConfig.TxTimeout, "timeout when waiting for a transaction")
 	f.Duration(prefix+".old-header-timeout", DefaultConfig.OldHeaderTimeout, "warns if the latest l1 block is at least this old")
+	FinalityOverrideConfigAddOptions(prefix+".finality-override", f)
 	AddDangerousOptions(prefix+".dangerous", f)
 }
 
@@ -109,13 +141,15 @@ func AddDangerousOptions(prefix string, f *flag.FlagSet) {
 }
 
 var TestConfig = Config{
-	Enable:           true,
-	PollOnly:         false,
-	PollInterval:     time.Millisecond * 10,
-	PollTimeout:      time.Second * 5,
-	TxTimeout:        time.Second * 5,
-	OldHeaderTimeout: 5 * time.Minute,
-	UseFinalityData:  false,
+	Enable:                 true,
+	PollOnly:               false,
+	PollInterval:           time.Millisecond * 10,
+	SubscribedPollInterval: time.Millisecond * 10,
+	PollTimeout:            time.Second * 5,
+	TxTimeout:              time.Second * 5,
+	OldHeaderTimeout:       5 * time.Minute,
+	UseFinalityData:        false,
+	FinalityOverride:       DefaultFinalityOverrideConfig,
 	Dangerous: DangerousConfig{
 		WaitForTxApprovalSafePoll: time.Millisecond * 100,
 	},
@@ -148,6 +182,10 @@ func New(ctx context.Context, client *ethclient.Client, config ConfigFetcher, ar
 
 func (s *HeaderReader) Config() *Config { return s.config() }
 
+// Subscribed returns whether headers are currently received through an eth_subscribe newHeads
+// subscription, which needs a WebSocket connection to the parent chain, rather than by polling.
+func (s *HeaderReader) Subscribed() bool { return s.subscribed.Load() }
+
 // Subscribe to block header updates.
 // Subscribers are notified when there is a change.
 // Channel could be missing headers and have duplicates.
@@ -264,12 +302,22 @@ func (s *HeaderReader) setError(err error) {
 	s.lastBroadcastErr = err
 }
 
+func (s *HeaderReader) setSubscribed(subscribed bool) {
+	s.subscribed.Store(subscribed)
+	if subscribed {
+		subscribedGauge.Update(1)
+	} else {
+		subscribedGauge.Update(0)
+	}
+}
+
 func (s *HeaderReader) broadcastLoop(ctx context.Context) {
 	var clientSubscription ethereum.Subscription = nil
 	defer func() {
 		if clientSubscription != nil {
 			clientSubscription.Unsubscribe()
 		}
+		s.setSubscribed(false)
 	}()
 	inputChannel := make(chan *types.Header)
 	if err := ctx.Err(); err != nil {
@@ -279,16 +327,43 @@ func (s *HeaderReader) broadcastLoop(ctx context.Context) {
 	nextSubscribeErr := time.Now().Add(-time.Second)
 	var errChannel <-chan error
 	pollOnlyOverride := false
+	subscribe := func() {
+		if s.config().PollOnly || pollOnlyOverride || clientSubscription != nil {
+			return
+		}
+		var err error
+		clientSubscription, err = s.client.SubscribeNewHead(ctx, inputChannel)
+		if err != nil {
+			clientSubscription = nil
+			if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+				// Plain HTTP connections can't subscribe, so keep polling
+				pollOnlyOverride = true
+			} else if time.Now().After(nextSubscribeErr) {
+				s.setError(fmt.Errorf("failed subscribing to header: %w", err))
+				log.Warn("failed subscribing to header", "err", err)
+				nextSubscribeErr = time.Now().Add(s.config().SubscribeErrInterval)
+			}
+		}
+		s.setSubscribed(clientSubscription != nil)
+	}
+	// Subscribe right away rather than waiting for the first poll
+	subscribe()
 	for {
+		pollInterval := s.config().PollInterval
 		if clientSubscription != nil {
 			errChannel = clientSubscription.Err()
+			// While subscribed, polling only guards against a subscription that stopped delivering headers
+			if s.config().SubscribedPollInterval > pollInterval {
+				pollInterval = s.config().SubscribedPollInterval
+			}
 		} else {
 			errChannel = nil
 		}
-		timer := time.NewTimer(s.config().PollInterval)
+		timer := time.NewTimer(pollInterval)
 		select {
 		case h := <-inputChannel:
 			log.Trace("got new header from L1", "number", h.Number, "hash", h.Hash(), "header", h)
+			subscribedHeaderCounter.Inc(1)
 			s.possiblyBroadcast(h)
 			timer.Stop()
 		case <-timer.C:
@@ -301,27 +376,17 @@ func (s *HeaderReader) broadcastLoop(ctx context.Context) {
 					log.Warn("failed reading header", "err", err)
 				}
 			} else {
+				polledHeaderCounter.Inc(1)
 				s.possiblyBroadcast(h)
 			}
-			if !(s.config().PollOnly || pollOnlyOverride) && clientSubscription == nil {
-				clientSubscription, err = s.client.SubscribeNewHead(ctx, inputChannel)
-				if err != nil {
-					clientSubscription = nil
-					if errors.Is(err, rpc.ErrNotificationsUnsupported) {
-						pollOnlyOverride = true
-					} else if time.Now().After(nextSubscribeErr) {
-						s.setError(fmt.Errorf("failed subscribing to header: %w", err))
-						log.Warn("failed subscribing to header", "err", err)
-						nextSubscribeErr = time.Now().Add(s.config().SubscribeErrInterval)
-					}
-				}
-			}
+			subscribe()
 		case err := <-errChannel:
 			if ctx.Err() != nil {
 				s.setError(fmt.Errorf("exiting broadcastLoop: %w", ctx.Err()))
 				return
 			}
 			clientSubscription = nil
+			s.setSubscribed(false)
 			s.setError(fmt.Errorf("error in subscription to headers: %w", err))
 			log.Warn("error in subscription to headers", "err", err)
 			timer.Stop()
@@ -471,13 +536,20 @@ func (s *HeaderReader) getCached(ctx context.Context, c *cachedHeader) (*types.H
 	if err != nil {
 		return nil, err
 	}
-	if HeadersEqual(currentHead, c.headWhenCached) {
+	override := s.config().FinalityOverride
+	// With the override, a head that isn't final yet becomes final with time, so keep checking it
+	if HeadersEqual(currentHead, c.headWhenCached) && (!override.Enable || HeadersEqual(c.header, currentHead)) {
 		return c.header, nil
 	}
-	if !s.config().UseFinalityData || !HeaderIndicatesFinalitySupport(currentHead) {
-		return nil, ErrBlockNumberNotSupported
+	var header *types.Header
+	if override.Enable {
+		header, err = s.headerOlderThan(ctx, currentHead, c.header, override.Delay)
+	} else {
+		if !s.config().UseFinalityData || !HeaderIndicatesFinalitySupport(currentHead) {
+			return nil, ErrBlockNumberNotSupported
+		}
+		header, err = s.client.HeaderByNumber(ctx, c.rpcBlockNum)
 	}
-	header, err := s.client.HeaderByNumber(ctx, c.rpcBlockNum)
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			log.Warn("Failed to get latest confirmed block", "blockTag", c.blockTag, "err", err)
@@ -491,6 +563,40 @@ func (s *HeaderReader) getCached(ctx context.Context, c *cachedHeader) (*types.H
 	return c.header, nil
 }
 
+// headerOlderThan returns the latest header up to the head that's at least delay old, or the genesis
+// header if none is. The search starts from a previous result, if it's still below the head.
+func (s *HeaderReader) headerOlderThan(ctx context.Context, head *types.Header, previous *types.Header, delay time.Duration) (*types.Header, error) {
+	// #nosec G115
+	cutoff := uint64(time.Now().Add(-delay).Unix())
+	if head.Time <= cutoff {
+		return head, nil
+	}
+	// The header at high is too new, and the one at low is old enough or the genesis
+	var low uint64
+	var lowHeader *types.Header
+	high := head.Number.Uint64()
+	if previous != nil && previous.Number.Uint64() < high && previous.Time <= cutoff {
+		low = previous.Number.Uint64()
+	}
+	for low+1 < high {
+		mid := low + (high-low)/2
+		header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, err
+		}
+		if header.Time <= cutoff {
+			low, lowHeader = mid, header
+		} else {
+			high = mid
+		}
+	}
+	if lowHeader != nil {
+		return lowHeader, nil
+	}
+	// Refetch the starting point in case the previous header was reorged out
+	return s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(low))
+}
+
 func (s *HeaderReader) LatestSafeBlockHeader(ctx context.Context) (*types.Header, error) {
 	header, err := s.getCached(ctx, &s.safe)
 	if errors.Is(err, ErrBlockNumberNotSupported) {
@@ -523,12 +629,25 @@ func (s *HeaderReader) LatestFinalizedBlockNr(ctx context.Context) (uint64, erro
 	return header.Number.Uint64(), nil
 }
 
+// FinalizedBlockTag returns the block number to query the parent chain's finalized state at: the
+// finalized tag, or the number of the block the finality override treats as finalized.
+func (s *HeaderReader) FinalizedBlockTag(ctx context.Context) (*big.Int, error) {
+	if !s.config().FinalityOverride.Enable {
+		return big.NewInt(rpc.FinalizedBlockNumber.Int64()), nil
+	}
+	header, err := s.LatestFinalizedBlockHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return header.Number, nil
+}
+
 func (s *HeaderReader) Client() *ethclient.Client {
 	return s.client
 }
 
 func (s *HeaderReader) UseFinalityData() bool {
-	return s.config().UseFinalityData
+	return s.config().UseFinalityData || s.config().FinalityOverride.Enable
 }
 
 func (s *HeaderReader) IsParentChainArbitrum() bool {
@@ -0,0 +1,317 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package membudget divides a node-wide memory limit between the caches which register with it.
+package membudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	memBudgetLimitGauge     = metrics.NewRegisteredGauge("arb/memory/budget/limit", nil)
+	memBudgetAllocatedGauge = metrics.NewRegisteredGauge("arb/memory/budget/allocated", nil)
+	memBudgetUsageGauge     = metrics.NewRegisteredGauge("arb/memory/budget/usage", nil)
+)
+
+// Config configures a node-wide memory limit shared between the caches
+// which register themselves with Register.
+type Config struct {
+	Limit          string        `koanf:"limit"`
+	UpdateInterval time.Duration `koanf:"update-interval"`
+	Headroom       float64       `koanf:"headroom"`
+
+	limit uint64
+}
+
+var DefaultConfig = Config{
+	Limit:          "",
+	UpdateInterval: 10 * time.Second,
+	Headroom:       0.2,
+}
+
+func ConfigAddOptions(prefix string, f *pflag.FlagSet) {
+	f.String(prefix+".limit", DefaultConfig.Limit, "total memory to divide between the trie, wasm, feed backlog and DAS caches, expressed in bytes or multiples of bytes with suffix B, K, M, G (disabled if empty)")
+	f.Duration(prefix+".update-interval", DefaultConfig.UpdateInterval, "how often cache limits are rebalanced according to their usage")
+	f.Float64(prefix+".headroom", DefaultConfig.Headroom, "fraction above its current usage a cache asks for when rebalancing")
+}
+
+func (c *Config) Validate() error {
+	c.limit = 0
+	if c.Limit == "" {
+		return nil
+	}
+	limit, err := ParseMemLimit(c.Limit)
+	if err != nil {
+		return fmt.Errorf("invalid mem-budget limit: %w", err)
+	}
+	if limit <= 0 {
+		return errors.New("mem-budget limit must be positive")
+	}
+	if c.UpdateInterval <= 0 {
+		return errors.New("mem-budget update-interval must be positive")
+	}
+	if c.Headroom < 0 {
+		return errors.New("mem-budget headroom can't be negative")
+	}
+	c.limit = uint64(limit)
+	return nil
+}
+
+func (c *Config) Enabled() bool {
+	return c.limit > 0
+}
+
+// ParseMemLimit parses a memory limit in bytes, or in multiples of bytes with suffix K, M, G or T.
+func ParseMemLimit(limitStr string) (int, error) {
+	var (
+		limit int = 1
+		s     string
+	)
+	if _, err := fmt.Sscanf(limitStr, "%d%s", &limit, &s); err != nil {
+		return 0, err
+	}
+
+	switch strings.ToUpper(s) {
+	case "K", "KB":
+		limit <<= 10
+	case "M", "MB":
+		limit <<= 20
+	case "G", "GB":
+		limit <<= 30
+	case "T", "TB":
+		limit <<= 40
+	case "B":
+	default:
+		return 0, fmt.Errorf("unsupported memory limit suffix string %s", s)
+	}
+
+	return limit, nil
+}
+
+// Consumer is a cache whose size limit can be changed at runtime.
+type Consumer interface {
+	// MemoryUsage returns the bytes currently used.
+	MemoryUsage() uint64
+	// SetMemoryLimit sets the maximum bytes to use, evicting if over it.
+	SetMemoryLimit(bytes uint64)
+}
+
+type ConsumerOpts struct {
+	// Relative share of memory left over once each consumer's demand is met.
+	Weight uint64
+	// Never allocate less than this.
+	MinBytes uint64
+	// Fixed consumers can't be resized, their usage is just taken out of the budget.
+	Fixed bool
+}
+
+var DefaultConsumerOpts = ConsumerOpts{
+	Weight: 1,
+}
+
+// FlooredConsumer is a Consumer holding data it can't evict yet, which is never allocated less than that.
+type FlooredConsumer interface {
+	Consumer
+	// MemoryFloor returns the bytes currently used by data that mustn't be evicted.
+	MemoryFloor() uint64
+}
+
+// FixedConsumer reserves a constant amount of memory, for caches which are sized at startup.
+type FixedConsumer uint64
+
+func (c FixedConsumer) MemoryUsage() uint64   { return uint64(c) }
+func (c FixedConsumer) SetMemoryLimit(uint64) {}
+
+type registeredConsumer struct {
+	consumer Consumer
+	opts     ConsumerOpts
+	limit    metrics.Gauge
+	usage    metrics.Gauge
+}
+
+var (
+	memoryConsumersMutex sync.Mutex
+	memoryConsumers      = make(map[string]*registeredConsumer)
+)
+
+// Register adds a cache to the memory budget, replacing any consumer
+// registered with the same name. The returned function unregisters it.
+// Consumers are only resized if a Budget has been started.
+func Register(name string, consumer Consumer, opts ConsumerOpts) func() {
+	reg := &registeredConsumer{
+		consumer: consumer,
+		opts:     opts,
+		limit:    metrics.GetOrRegisterGauge("arb/memory/budget/consumer/"+name+"/limit", nil),
+		usage:    metrics.GetOrRegisterGauge("arb/memory/budget/consumer/"+name+"/usage", nil),
+	}
+	memoryConsumersMutex.Lock()
+	defer memoryConsumersMutex.Unlock()
+	memoryConsumers[name] = reg
+	return func() {
+		memoryConsumersMutex.Lock()
+		defer memoryConsumersMutex.Unlock()
+		if memoryConsumers[name] == reg {
+			delete(memoryConsumers, name)
+		}
+	}
+}
+
+// Budget periodically divides the configured limit between the registered consumers.
+type Budget struct {
+	stopwaiter.StopWaiter
+	config func() *Config
+}
+
+func New(config func() *Config) *Budget {
+	return &Budget{config: config}
+}
+
+func (b *Budget) Start(ctx context.Context) {
+	b.StopWaiter.Start(ctx, b)
+	b.CallIteratively(func(ctx context.Context) time.Duration {
+		config := b.config()
+		if config.Enabled() {
+			b.Rebalance(config)
+		}
+		return config.UpdateInterval
+	})
+}
+
+type budgetEntry struct {
+	name  string
+	usage uint64
+	opts  ConsumerOpts
+}
+
+// Rebalance sets the limit of each registered consumer according to its current usage.
+func (b *Budget) Rebalance(config *Config) {
+	memoryConsumersMutex.Lock()
+	consumers := make(map[string]*registeredConsumer, len(memoryConsumers))
+	for name, reg := range memoryConsumers {
+		consumers[name] = reg
+	}
+	memoryConsumersMutex.Unlock()
+
+	entries := make([]budgetEntry, 0, len(consumers))
+	var totalUsage uint64
+	for name, reg := range consumers {
+		usage := reg.consumer.MemoryUsage()
+		totalUsage += usage
+		// #nosec G115
+		reg.usage.Update(int64(usage))
+		opts := reg.opts
+		if floored, ok := reg.consumer.(FlooredConsumer); ok {
+			opts.MinBytes = arbmath.SaturatingUAdd(opts.MinBytes, floored.MemoryFloor())
+		}
+		entries = append(entries, budgetEntry{name: name, usage: usage, opts: opts})
+	}
+	allocations := allocateMemoryBudget(config.limit, config.Headroom, entries)
+	var totalAllocated uint64
+	for name, bytes := range allocations {
+		reg := consumers[name]
+		if reg.opts.Fixed {
+			continue
+		}
+		reg.consumer.SetMemoryLimit(bytes)
+		// #nosec G115
+		reg.limit.Update(int64(bytes))
+		totalAllocated += bytes
+	}
+	// #nosec G115
+	memBudgetLimitGauge.Update(int64(config.limit))
+	// #nosec G115
+	memBudgetAllocatedGauge.Update(int64(totalAllocated))
+	// #nosec G115
+	memBudgetUsageGauge.Update(int64(totalUsage))
+	if totalUsage > config.limit {
+		log.Warn("caches are using more memory than the memory budget", "usage", totalUsage, "limit", config.limit)
+	}
+}
+
+// allocateMemoryBudget divides limit between the flexible consumers after taking out the fixed ones.
+// Each consumer asks for its usage plus headroom (but at least its minimum). If everyone fits, the
+// remainder is shared by weight. Otherwise each consumer gets its minimum, and what's left is shared
+// in proportion to how much more than its minimum it asked for, scaled by weight.
+func allocateMemoryBudget(limit uint64, headroom float64, entries []budgetEntry) map[string]uint64 {
+	// Sort so the result doesn't depend on map iteration order when rounding
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	allocations := make(map[string]uint64, len(entries))
+	remaining := limit
+	var flexible []budgetEntry
+	for _, e := range entries {
+		if e.opts.Fixed {
+			allocations[e.name] = e.usage
+			remaining = arbmath.SaturatingUSub(remaining, e.usage)
+		} else {
+			flexible = append(flexible, e)
+		}
+	}
+	if len(flexible) == 0 {
+		return allocations
+	}
+
+	demands := make([]uint64, len(flexible))
+	var totalDemand, totalMin, totalWeight uint64
+	for i, e := range flexible {
+		demand := uint64(float64(e.usage) * (1 + headroom))
+		if demand < e.opts.MinBytes {
+			demand = e.opts.MinBytes
+		}
+		demands[i] = demand
+		totalDemand += demand
+		totalMin += e.opts.MinBytes
+		totalWeight += e.opts.Weight
+	}
+
+	if totalDemand <= remaining {
+		spare := remaining - totalDemand
+		for i, e := range flexible {
+			share := uint64(0)
+			if totalWeight > 0 {
+				share = uint64(float64(spare) * float64(e.opts.Weight) / float64(totalWeight))
+			}
+			allocations[e.name] = demands[i] + share
+		}
+		return allocations
+	}
+
+	if totalMin >= remaining {
+		// Not even the minimums fit, scale them down
+		for _, e := range flexible {
+			share := uint64(0)
+			if totalMin > 0 {
+				share = uint64(float64(remaining) * float64(e.opts.MinBytes) / float64(totalMin))
+			}
+			allocations[e.name] = share
+		}
+		return allocations
+	}
+
+	spare := remaining - totalMin
+	var totalPressure float64
+	for i, e := range flexible {
+		totalPressure += float64(demands[i]-e.opts.MinBytes) * float64(e.opts.Weight)
+	}
+	for i, e := range flexible {
+		share := uint64(0)
+		if totalPressure > 0 {
+			share = uint64(float64(spare) * float64(demands[i]-e.opts.MinBytes) * float64(e.opts.Weight) / totalPressure)
+		}
+		allocations[e.name] = e.opts.MinBytes + share
+	}
+	return allocations
+}
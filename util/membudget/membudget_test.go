@@ -0,0 +1,93 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package membudget
+
+import (
+	"testing"
+)
+
+func TestAllocateMemoryBudgetFits(t *testing.T) {
+	entries := []budgetEntry{
+		{name: "trie", usage: 400, opts: ConsumerOpts{Fixed: true}},
+		{name: "a", usage: 100, opts: ConsumerOpts{Weight: 1}},
+		{name: "b", usage: 200, opts: ConsumerOpts{Weight: 3}},
+	}
+	// a wants 150 and b wants 300, leaving 150 of the 600 non-trie bytes to share 1:3
+	alloc := allocateMemoryBudget(1000, 0.5, entries)
+	if alloc["trie"] != 400 || alloc["a"] != 150+37 || alloc["b"] != 300+112 {
+		t.Fatalf("unexpected allocation %v", alloc)
+	}
+}
+
+func TestAllocateMemoryBudgetUnderPressure(t *testing.T) {
+	entries := []budgetEntry{
+		{name: "a", usage: 1000, opts: ConsumerOpts{Weight: 1, MinBytes: 100}},
+		{name: "b", usage: 100, opts: ConsumerOpts{Weight: 1, MinBytes: 100}},
+	}
+	// b asks for no more than its minimum, so a gets everything else
+	alloc := allocateMemoryBudget(500, 0, entries)
+	if alloc["a"] != 400 || alloc["b"] != 100 {
+		t.Fatalf("unexpected allocation %v", alloc)
+	}
+	// Minimums are scaled down if even they don't fit
+	alloc = allocateMemoryBudget(100, 0, entries)
+	if alloc["a"] != 50 || alloc["b"] != 50 {
+		t.Fatalf("unexpected allocation %v", alloc)
+	}
+}
+
+type testConsumer struct {
+	usage uint64
+	limit uint64
+}
+
+func (c *testConsumer) MemoryUsage() uint64         { return c.usage }
+func (c *testConsumer) SetMemoryLimit(bytes uint64) { c.limit = bytes }
+
+func TestMemoryBudgetRebalance(t *testing.T) {
+	config := DefaultConfig
+	config.Limit = "1K"
+	config.Headroom = 0
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	consumer := &testConsumer{usage: 100}
+	unregister := Register("test-consumer", consumer, DefaultConsumerOpts)
+	budget := New(func() *Config { return &config })
+	budget.Rebalance(&config)
+	if consumer.limit != 1024 {
+		t.Fatalf("expected the only consumer to get the whole budget but got %v", consumer.limit)
+	}
+	unregister()
+	consumer.limit = 0
+	budget.Rebalance(&config)
+	if consumer.limit != 0 {
+		t.Fatal("unregistered consumer was resized")
+	}
+}
+
+type flooredTestConsumer struct {
+	testConsumer
+	floor uint64
+}
+
+func (c *flooredTestConsumer) MemoryFloor() uint64 { return c.floor }
+
+func TestMemoryBudgetFloor(t *testing.T) {
+	config := DefaultConfig
+	config.Limit = "1K"
+	config.Headroom = 0
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	floored := &flooredTestConsumer{testConsumer: testConsumer{usage: 800}, floor: 800}
+	other := &testConsumer{usage: 800}
+	defer Register("test-floored", floored, ConsumerOpts{Weight: 1, MinBytes: 100})()
+	defer Register("test-other", other, DefaultConsumerOpts)()
+	New(func() *Config { return &config }).Rebalance(&config)
+	// The floored consumer keeps its floor plus its minimum, and the other gets what's left
+	if floored.limit != 900 || other.limit != 124 {
+		t.Fatalf("unexpected limits %v and %v", floored.limit, other.limit)
+	}
+}
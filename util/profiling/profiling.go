@@ -0,0 +1,239 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package profiling continuously collects CPU and heap profiles while the node runs.
+// CPU samples are labelled with the block processing phase and block number they
+// were taken in, so regressions can be tied to the workload that caused them.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+const (
+	PhaseSequencing = "sequencing"
+	PhaseDigest     = "digest"
+)
+
+var (
+	profilesCollectedCounter = metrics.NewRegisteredCounter("arb/profiling/collected", nil)
+	profilesFailedCounter    = metrics.NewRegisteredCounter("arb/profiling/failed", nil)
+)
+
+var (
+	labelsEnabled atomic.Bool
+	blockHeight   atomic.Uint64
+)
+
+// Do runs fn with the phase and block number attached as pprof labels, and records the
+// block as the current height. Labels are only attached while a profiler is running.
+func Do(phase string, block uint64, fn func()) {
+	blockHeight.Store(block)
+	if !labelsEnabled.Load() {
+		fn()
+		return
+	}
+	labels := pprof.Labels("phase", phase, "block", strconv.FormatUint(block, 10))
+	pprof.Do(context.Background(), labels, func(context.Context) { fn() })
+}
+
+// BlockHeight returns the block most recently passed to Do.
+func BlockHeight() uint64 {
+	return blockHeight.Load()
+}
+
+type Config struct {
+	Enable    bool          `koanf:"enable"`
+	Interval  time.Duration `koanf:"interval"`
+	CPU       bool          `koanf:"cpu"`
+	Heap      bool          `koanf:"heap"`
+	AppName   string        `koanf:"app-name"`
+	PushURL   string        `koanf:"push-url"`
+	OutputDir string        `koanf:"output-dir"`
+	Timeout   time.Duration `koanf:"timeout"`
+}
+
+var DefaultConfig = Config{
+	Enable:    false,
+	Interval:  time.Minute,
+	CPU:       true,
+	Heap:      true,
+	AppName:   "nitro",
+	PushURL:   "",
+	OutputDir: "",
+	Timeout:   10 * time.Second,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "enable continuous profiling")
+	f.Duration(prefix+".interval", DefaultConfig.Interval, "duration of each profile")
+	f.Bool(prefix+".cpu", DefaultConfig.CPU, "collect CPU profiles (samples are labelled with the block processing phase and block number)")
+	f.Bool(prefix+".heap", DefaultConfig.Heap, "collect heap profiles")
+	f.String(prefix+".app-name", DefaultConfig.AppName, "application name profiles are pushed under")
+	f.String(prefix+".push-url", DefaultConfig.PushURL, "base URL of a server implementing the pyroscope compatible /ingest API to push pprof profiles to")
+	f.String(prefix+".output-dir", DefaultConfig.OutputDir, "directory to write pprof profiles to")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout for pushing a profile")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return errors.New("continuous profiling interval must be positive")
+	}
+	if !c.CPU && !c.Heap {
+		return errors.New("continuous profiling enabled but neither cpu nor heap profiles are collected")
+	}
+	if c.PushURL == "" && c.OutputDir == "" {
+		return errors.New("continuous profiling requires push-url or output-dir")
+	}
+	if c.PushURL != "" {
+		if _, err := url.Parse(c.PushURL); err != nil {
+			return fmt.Errorf("invalid continuous profiling push-url: %w", err)
+		}
+	}
+	return nil
+}
+
+type profile struct {
+	kind       string
+	from       time.Time
+	until      time.Time
+	fromHeight uint64
+	toHeight   uint64
+	data       []byte
+}
+
+type ContinuousProfiler struct {
+	stopwaiter.StopWaiter
+	config *Config
+	client *http.Client
+}
+
+func NewContinuousProfiler(config *Config) *ContinuousProfiler {
+	return &ContinuousProfiler{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (p *ContinuousProfiler) Start(ctx context.Context) {
+	p.StopWaiter.Start(ctx, p)
+	labelsEnabled.Store(p.config.CPU)
+	p.LaunchThread(func(ctx context.Context) {
+		defer labelsEnabled.Store(false)
+		for ctx.Err() == nil {
+			p.collect(ctx)
+		}
+	})
+}
+
+// collect gathers one interval's worth of profiles and publishes them.
+func (p *ContinuousProfiler) collect(ctx context.Context) {
+	from := time.Now()
+	fromHeight := BlockHeight()
+	var cpu bytes.Buffer
+	cpuStarted := false
+	if p.config.CPU {
+		// Fails if something else, like the sequencer's profiling, is already running a CPU profile
+		if err := pprof.StartCPUProfile(&cpu); err != nil {
+			log.Warn("skipping continuous cpu profile", "err", err)
+		} else {
+			cpuStarted = true
+		}
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(p.config.Interval):
+	}
+	if cpuStarted {
+		pprof.StopCPUProfile()
+	}
+	until := time.Now()
+	toHeight := BlockHeight()
+	if cpuStarted {
+		p.publish(&profile{kind: "cpu", from: from, until: until, fromHeight: fromHeight, toHeight: toHeight, data: cpu.Bytes()})
+	}
+	if p.config.Heap {
+		var heap bytes.Buffer
+		if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+			log.Warn("failed to collect heap profile", "err", err)
+			profilesFailedCounter.Inc(1)
+		} else {
+			p.publish(&profile{kind: "heap", from: from, until: until, fromHeight: fromHeight, toHeight: toHeight, data: heap.Bytes()})
+		}
+	}
+}
+
+// publish doesn't take a context so the last profile is still pushed while shutting down.
+func (p *ContinuousProfiler) publish(prof *profile) {
+	if p.config.OutputDir != "" {
+		if err := p.writeFile(prof); err != nil {
+			log.Warn("failed to write profile", "kind", prof.kind, "err", err)
+			profilesFailedCounter.Inc(1)
+		} else {
+			profilesCollectedCounter.Inc(1)
+		}
+	}
+	if p.config.PushURL != "" {
+		if err := p.push(prof); err != nil {
+			log.Warn("failed to push profile", "kind", prof.kind, "err", err)
+			profilesFailedCounter.Inc(1)
+		} else {
+			profilesCollectedCounter.Inc(1)
+		}
+	}
+}
+
+func (p *ContinuousProfiler) writeFile(prof *profile) error {
+	if err := os.MkdirAll(p.config.OutputDir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%d-blocks-%d-%d.pb.gz", p.config.AppName, prof.kind, prof.from.UnixMilli(), prof.fromHeight, prof.toHeight)
+	return os.WriteFile(filepath.Join(p.config.OutputDir, name), prof.data, 0600)
+}
+
+func (p *ContinuousProfiler) push(prof *profile) error {
+	pushURL, err := url.JoinPath(p.config.PushURL, "ingest")
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	// Tags go in braces after the name, which pyroscope turns into labels on the whole profile
+	query.Set("name", fmt.Sprintf("%s.%s{block_from=%d,block_to=%d}", p.config.AppName, prof.kind, prof.fromHeight, prof.toHeight))
+	query.Set("from", strconv.FormatInt(prof.from.Unix(), 10))
+	query.Set("until", strconv.FormatInt(prof.until.Unix(), 10))
+	query.Set("format", "pprof")
+	req, err := http.NewRequest(http.MethodPost, pushURL+"?"+query.Encode(), bytes.NewReader(prof.data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("profile push returned status %v", resp.Status)
+	}
+	return nil
+}
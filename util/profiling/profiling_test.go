@@ -0,0 +1,88 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package profiling
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContinuousProfiler(t *testing.T) {
+	var mutex sync.Mutex
+	var pushed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || len(body) == 0 || r.URL.Path != "/ingest" || r.URL.Query().Get("format") != "pprof" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mutex.Lock()
+		pushed = append(pushed, r.URL.Query().Get("name"))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	config := DefaultConfig
+	config.Enable = true
+	config.Interval = 50 * time.Millisecond
+	config.PushURL = server.URL
+	config.OutputDir = t.TempDir()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profiler := NewContinuousProfiler(&config)
+	profiler.Start(context.Background())
+	Do(PhaseDigest, 7, func() {
+		time.Sleep(200 * time.Millisecond)
+	})
+	profiler.StopAndWait()
+
+	if BlockHeight() != 7 {
+		t.Errorf("expected block height 7 but got %v", BlockHeight())
+	}
+	files, err := os.ReadDir(config.OutputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cpu, heap int
+	for _, f := range files {
+		if strings.Contains(f.Name(), "-cpu-") {
+			cpu++
+		} else if strings.Contains(f.Name(), "-heap-") {
+			heap++
+		}
+	}
+	if cpu == 0 || heap == 0 {
+		t.Fatalf("expected cpu and heap profiles to be written but got %v cpu and %v heap", cpu, heap)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(pushed) != cpu+heap {
+		t.Fatalf("expected %v profiles to be pushed but got %v", cpu+heap, len(pushed))
+	}
+	if !strings.Contains(pushed[len(pushed)-1], "block_to=7") {
+		t.Errorf("expected last profile to be tagged with the block height but got %v", pushed[len(pushed)-1])
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := DefaultConfig
+	config.Enable = true
+	if err := config.Validate(); err == nil {
+		t.Error("expected error without push-url or output-dir")
+	}
+	config.OutputDir = t.TempDir()
+	config.CPU = false
+	config.Heap = false
+	if err := config.Validate(); err == nil {
+		t.Error("expected error without any profile kinds")
+	}
+}
@@ -20,6 +20,7 @@ const PRIORITIES_KEY string = "coordinator.priorities"                 // Read o
 const WANTS_LOCKOUT_KEY_PREFIX string = "coordinator.liveliness."      // Per server. Only written by self
 const MESSAGE_KEY_PREFIX string = "coordinator.msg."                   // Per Message. Only written by sequencer holding CHOSEN
 const SIGNATURE_KEY_PREFIX string = "coordinator.msg.sig."             // Per Message. Only written by sequencer holding CHOSEN
+const LOCKOUT_FENCE_KEY string = "coordinator.lockoutFence"            // Fencing token of the latest external lockout holder to write. Only grows
 const WANTS_LOCKOUT_VAL string = "OK"
 const SWITCHED_REDIS string = "SWITCHED_REDIS"
 const INVALID_VAL string = "INVALID"
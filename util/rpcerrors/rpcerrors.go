@@ -0,0 +1,117 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package rpcerrors is the registry of Nitro-specific JSON-RPC error codes.
+// Codes are never reused or renumbered, so clients can branch on them rather than on error messages.
+package rpcerrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// A transaction's conditional options weren't met. Matches go-ethereum's arbitrum_types.
+	CodeConditionalRejected = -32003
+	// A transaction's conditional options check too much storage. Matches go-ethereum's arbitrum_types.
+	CodeConditionalLimitExceeded = -32005
+	CodeDuplicateTx              = -32010
+	CodeSequencerQueueFull       = -32011
+	CodeSequencerUnavailable     = -32012
+	CodeRetryableExpired         = -32013
+	CodeDAUnavailable            = -32014
+)
+
+// Definition documents an error code.
+type Definition struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Retryable   bool   `json:"retryable"` // whether the same request may succeed later
+}
+
+var definitions = []Definition{
+	{CodeConditionalRejected, "conditional-rejected", "the transaction's conditional options weren't met", false},
+	{CodeConditionalLimitExceeded, "conditional-limit-exceeded", "the transaction's conditional options check too many storage slots", false},
+	{CodeDuplicateTx, "duplicate-tx", "an identical transaction was submitted moments ago", false},
+	{CodeSequencerQueueFull, "sequencer-queue-full", "the sequencer's queue didn't have room for the transaction in time", true},
+	{CodeSequencerUnavailable, "sequencer-unavailable", "no sequencer is currently accepting transactions", true},
+	{CodeRetryableExpired, "retryable-expired", "the retryable doesn't exist, or expired and was deleted", false},
+	{CodeDAUnavailable, "da-unavailable", "the data availability service couldn't store or serve the data", true},
+}
+
+// Definitions returns every registered error code.
+func Definitions() []Definition {
+	return append([]Definition{}, definitions...)
+}
+
+// Lookup returns the definition of a registered error code.
+func Lookup(code int) (Definition, bool) {
+	for _, definition := range definitions {
+		if definition.Code == code {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Error gives an error a JSON-RPC error code. The RPC server only sees the code of the error a method
+// returns, not of errors it wraps, so coded errors must be returned as they are.
+type Error struct {
+	code int
+	err  error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) ErrorCode() int {
+	return e.code
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// New makes an error with a code, which can also be used as a sentinel with errors.Is.
+func New(code int, message string) error {
+	return &Error{code: code, err: errors.New(message)}
+}
+
+// Errorf makes an error with a code, formatted like fmt.Errorf.
+func Errorf(code int, format string, args ...interface{}) error {
+	return &Error{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// Wrap gives an error a code, or returns nil if it's nil.
+func Wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{code: code, err: err}
+}
+
+// WithDefault gives an error a code unless it already has one.
+func WithDefault(code int, err error) error {
+	// nolint:errorlint
+	if _, ok := err.(interface{ ErrorCode() int }); ok || err == nil {
+		return err
+	}
+	return Wrap(code, err)
+}
+
+// Code returns the code of an error or of any error it wraps, including errors returned by an RPC client.
+func Code(err error) (int, bool) {
+	var coded interface{ ErrorCode() int }
+	if !errors.As(err, &coded) {
+		return 0, false
+	}
+	return coded.ErrorCode(), true
+}
+
+// Is reports whether an error, or any error it wraps, has a code.
+func Is(err error, code int) bool {
+	found, ok := Code(err)
+	return ok && found == code
+}
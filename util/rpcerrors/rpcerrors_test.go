@@ -0,0 +1,85 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package rpcerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestDefinitions(t *testing.T) {
+	codes := make(map[int]bool)
+	names := make(map[string]bool)
+	for _, definition := range Definitions() {
+		if codes[definition.Code] || names[definition.Name] {
+			t.Fatal("error code registered twice", definition)
+		}
+		codes[definition.Code] = true
+		names[definition.Name] = true
+		if found, ok := Lookup(definition.Code); !ok || found != definition {
+			t.Fatal("failed to look up", definition)
+		}
+	}
+	if _, ok := Lookup(-32000); ok {
+		t.Fatal("looked up an unregistered code")
+	}
+}
+
+type testService struct{}
+
+var errTestUnavailable = New(CodeDAUnavailable, "test data unavailable")
+
+func (testService) Fail(code int) error {
+	if code == 0 {
+		return errors.New("uncoded")
+	}
+	return Errorf(code, "failed with %v", code)
+}
+
+func (testService) Unavailable() error {
+	return errTestUnavailable
+}
+
+func TestCodesOverRPC(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("test", testService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+	ctx := context.Background()
+
+	err := client.CallContext(ctx, nil, "test_fail", CodeSequencerQueueFull)
+	if !Is(err, CodeSequencerQueueFull) {
+		t.Fatal("expected the queue full code, got", err)
+	}
+	err = client.CallContext(ctx, nil, "test_unavailable")
+	if code, ok := Code(err); !ok || code != CodeDAUnavailable {
+		t.Fatal("expected the DA unavailable code, got", err)
+	}
+	err = client.CallContext(ctx, nil, "test_fail", 0)
+	if Is(err, CodeSequencerQueueFull) || Is(err, CodeDAUnavailable) {
+		t.Fatal("uncoded error has a registered code", err)
+	}
+
+	// Codes are found through wrapping, and sentinels still match
+	wrapped := fmt.Errorf("storing batch: %w", errTestUnavailable)
+	if !Is(wrapped, CodeDAUnavailable) || !errors.Is(wrapped, errTestUnavailable) {
+		t.Fatal("code lost by wrapping")
+	}
+	if err := WithDefault(CodeConditionalRejected, errTestUnavailable); !Is(err, CodeDAUnavailable) {
+		t.Fatal("default code replaced an existing one", err)
+	}
+	if err := WithDefault(CodeConditionalRejected, context.DeadlineExceeded); !Is(err, CodeConditionalRejected) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("default code not applied", err)
+	}
+	if WithDefault(CodeConditionalRejected, nil) != nil || Wrap(CodeDAUnavailable, nil) != nil {
+		t.Fatal("nil error given a code")
+	}
+}
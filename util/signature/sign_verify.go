@@ -54,7 +54,7 @@ func NewSignVerify(config *SignVerifyConfig, signerFunc DataSignerFunc, bpValida
 			return nil, err
 		}
 	}
-	verifier, err := NewVerifier(&config.ECDSA, bpValidator)
+	verifier, err := NewVerifier(&config.ECDSA, bpValidator, nil)
 	if err != nil {
 		return nil, err
 	}
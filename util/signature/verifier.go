@@ -12,6 +12,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/offchainlabs/nitro/util/contracts"
 )
@@ -20,12 +21,14 @@ type Verifier struct {
 	config        *VerifierConfig
 	authorizedMap map[common.Address]struct{}
 	addrVerifier  contracts.AddressVerifierInterface
+	feedSigners   contracts.FeedSignerVerifierInterface
 }
 
 type VerifierConfig struct {
-	AllowedAddresses []string                `koanf:"allowed-addresses"`
-	AcceptSequencer  bool                    `koanf:"accept-sequencer"`
-	Dangerous        DangerousVerifierConfig `koanf:"dangerous"`
+	AllowedAddresses        []string                `koanf:"allowed-addresses"`
+	AcceptSequencer         bool                    `koanf:"accept-sequencer"`
+	AcceptChainOwnerSigners bool                    `koanf:"accept-chain-owner-signers"`
+	Dangerous               DangerousVerifierConfig `koanf:"dangerous"`
 }
 
 type DangerousVerifierConfig struct {
@@ -39,6 +42,7 @@ var ErrSignerNotApproved = fmt.Errorf("%w: signer not approved", ErrSignatureNot
 func FeedVerifierConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.StringSlice(prefix+".allowed-addresses", DefultFeedVerifierConfig.AllowedAddresses, "a list of allowed addresses")
 	f.Bool(prefix+".accept-sequencer", DefultFeedVerifierConfig.AcceptSequencer, "accept verified message from sequencer")
+	f.Bool(prefix+".accept-chain-owner-signers", DefultFeedVerifierConfig.AcceptChainOwnerSigners, "accept verified message from the feed signers published by the chain owner through ArbOwnerPublic")
 	DangerousFeedVerifierConfigAddOptions(prefix+".dangerous", f)
 }
 
@@ -47,22 +51,25 @@ func DangerousFeedVerifierConfigAddOptions(prefix string, f *flag.FlagSet) {
 }
 
 var DefultFeedVerifierConfig = VerifierConfig{
-	AllowedAddresses: []string{},
-	AcceptSequencer:  true,
+	AllowedAddresses:        []string{},
+	AcceptSequencer:         true,
+	AcceptChainOwnerSigners: true,
 	Dangerous: DangerousVerifierConfig{
 		AcceptMissing: true,
 	},
 }
 
 var TestingFeedVerifierConfig = VerifierConfig{
-	AllowedAddresses: []string{},
-	AcceptSequencer:  false,
+	AllowedAddresses:        []string{},
+	AcceptSequencer:         false,
+	AcceptChainOwnerSigners: false,
 	Dangerous: DangerousVerifierConfig{
 		AcceptMissing: false,
 	},
 }
 
-func NewVerifier(config *VerifierConfig, addrVerifier contracts.AddressVerifierInterface) (*Verifier, error) {
+// NewVerifier creates a Verifier; feedSigners may be nil if the chain's state isn't available, as for a relay.
+func NewVerifier(config *VerifierConfig, addrVerifier contracts.AddressVerifierInterface, feedSigners contracts.FeedSignerVerifierInterface) (*Verifier, error) {
 	authorizedMap := make(map[common.Address]struct{}, len(config.AllowedAddresses))
 	for _, addrString := range config.AllowedAddresses {
 		addr := common.HexToAddress(addrString)
@@ -75,6 +82,7 @@ func NewVerifier(config *VerifierConfig, addrVerifier contracts.AddressVerifierI
 		config:        config,
 		authorizedMap: authorizedMap,
 		addrVerifier:  addrVerifier,
+		feedSigners:   feedSigners,
 	}, nil
 }
 
@@ -111,6 +119,15 @@ func (v *Verifier) verifyClosure(ctx context.Context, sig []byte, hash common.Ha
 		return nil
 	}
 
+	if v.config.AcceptChainOwnerSigners && v.feedSigners != nil {
+		isSigner, err := v.feedSigners.IsFeedSigner(ctx, addr)
+		if err != nil {
+			log.Warn("error reading feed signers published by the chain owner", "err", err)
+		} else if isSigner {
+			return nil
+		}
+	}
+
 	if !v.config.AcceptSequencer || v.addrVerifier == nil {
 		return ErrSignerNotApproved
 	}
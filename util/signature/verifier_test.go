@@ -25,7 +25,7 @@ func TestVerifier(t *testing.T) {
 
 	config := TestingFeedVerifierConfig
 	config.AllowedAddresses = []string{signingAddr.Hex()}
-	verifier, err := NewVerifier(&config, nil)
+	verifier, err := NewVerifier(&config, nil, nil)
 	Require(t, err)
 
 	data := []byte{0, 1, 2, 3, 4, 5, 6, 7}
@@ -53,7 +53,7 @@ func TestMissingRequiredSignature(t *testing.T) {
 
 	config := TestingFeedVerifierConfig
 	config.Dangerous.AcceptMissing = false
-	verifier, err := NewVerifier(&config, nil)
+	verifier, err := NewVerifier(&config, nil, nil)
 	Require(t, err)
 	err = verifier.VerifyData(ctx, nil, nil)
 	if !errors.Is(err, ErrMissingSignature) {
@@ -67,7 +67,7 @@ func TestMissingSignatureAllowed(t *testing.T) {
 
 	config := TestingFeedVerifierConfig
 	config.Dangerous.AcceptMissing = true
-	verifier, err := NewVerifier(&config, nil)
+	verifier, err := NewVerifier(&config, nil, nil)
 	Require(t, err)
 	err = verifier.VerifyData(ctx, nil, nil)
 	Require(t, err, "error verifying data")
@@ -85,7 +85,7 @@ func TestVerifierBatchPoster(t *testing.T) {
 	bpVerifier := contracts.NewMockAddressVerifier(signingAddr)
 	config := TestingFeedVerifierConfig
 	config.AcceptSequencer = true
-	verifier, err := NewVerifier(&config, bpVerifier)
+	verifier, err := NewVerifier(&config, bpVerifier, nil)
 	Require(t, err)
 
 	data := []byte{0, 1, 2, 3, 4, 5, 6, 7}
@@ -112,6 +112,44 @@ func TestVerifierBatchPoster(t *testing.T) {
 	}
 }
 
+func TestVerifierChainOwnerSigners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	signingAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := DataSignerFromPrivateKey(privateKey)
+
+	feedSigners := contracts.NewMockFeedSignerVerifier(signingAddr)
+	config := TestingFeedVerifierConfig
+	config.AcceptChainOwnerSigners = true
+	verifier, err := NewVerifier(&config, nil, feedSigners)
+	Require(t, err)
+
+	data := []byte{0, 1, 2, 3, 4, 5, 6, 7}
+	signature, err := dataSigner(crypto.Keccak256(data))
+	Require(t, err, "error signing data")
+	err = verifier.VerifyData(ctx, signature, data)
+	Require(t, err, "error verifying data")
+
+	badKey, err := crypto.GenerateKey()
+	Require(t, err)
+	badSignature, err := DataSignerFromPrivateKey(badKey)(crypto.Keccak256(data))
+	Require(t, err, "error signing data")
+	err = verifier.VerifyData(ctx, badSignature, data)
+	if !errors.Is(err, ErrSignerNotApproved) {
+		t.Error("unexpected error", err)
+	}
+
+	// The published signers are only trusted if configured to be
+	config.AcceptChainOwnerSigners = false
+	err = verifier.VerifyData(ctx, signature, data)
+	if !errors.Is(err, ErrSignerNotApproved) {
+		t.Error("accepted a chain owner signer when not configured to", err)
+	}
+}
+
 func Require(t *testing.T, err error, printables ...interface{}) {
 	t.Helper()
 	testhelpers.RequireImpl(t, err, printables...)
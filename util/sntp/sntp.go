@@ -0,0 +1,118 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package sntp implements a minimal SNTP (RFC 4330) client for measuring the local clock's offset.
+package sntp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	packetSize = 48
+	// Seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+
+	modeClient = 3
+	modeServer = 4
+	version    = 4
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid SNTP response")
+	ErrKissOfDeath     = errors.New("SNTP server sent a kiss-o'-death")
+)
+
+// Response is the result of querying an SNTP server.
+type Response struct {
+	// Offset is how far the server's clock is ahead of the local clock
+	Offset time.Duration
+	// RTT is the round trip time excluding the server's processing time
+	RTT     time.Duration
+	Stratum uint8
+}
+
+// Query measures the local clock's offset against the server, given as host or host:port.
+func Query(ctx context.Context, server string) (*Response, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	request := make([]byte, packetSize)
+	request[0] = version<<3 | modeClient
+	sent := time.Now()
+	// The server echoes the transmit timestamp back as the origin timestamp, tying its response to this request
+	binary.BigEndian.PutUint64(request[40:], toNTPTime(sent))
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	response := make([]byte, packetSize)
+	for {
+		n, err := conn.Read(response)
+		if err != nil {
+			return nil, err
+		}
+		received := time.Now()
+		if n < packetSize || binary.BigEndian.Uint64(response[24:]) != binary.BigEndian.Uint64(request[40:]) {
+			// Not a response to this request, keep waiting until the deadline
+			continue
+		}
+		return parseResponse(response, sent, received)
+	}
+}
+
+func parseResponse(response []byte, sent, received time.Time) (*Response, error) {
+	if mode := response[0] & 0x7; mode != modeServer {
+		return nil, fmt.Errorf("%w: mode %v", ErrInvalidResponse, mode)
+	}
+	if leap := response[0] >> 6; leap == 3 {
+		return nil, fmt.Errorf("%w: server clock is unsynchronized", ErrInvalidResponse)
+	}
+	stratum := response[1]
+	if stratum == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrKissOfDeath, response[12:16])
+	}
+	serverReceived := binary.BigEndian.Uint64(response[32:])
+	serverSent := binary.BigEndian.Uint64(response[40:])
+	if serverSent == 0 {
+		return nil, fmt.Errorf("%w: no transmit timestamp", ErrInvalidResponse)
+	}
+	t2 := fromNTPTime(serverReceived)
+	t3 := fromNTPTime(serverSent)
+	return &Response{
+		Offset:  (t2.Sub(sent) + t3.Sub(received)) / 2,
+		RTT:     max(received.Sub(sent)-t3.Sub(t2), 0),
+		Stratum: stratum,
+	}, nil
+}
+
+// toNTPTime encodes a time as 32 bits of seconds since 1900 and 32 bits of fractional seconds.
+func toNTPTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second) // #nosec G115
+	seconds := nanos / uint64(time.Second)
+	fraction := ((nanos % uint64(time.Second)) << 32) / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+func fromNTPTime(ntp uint64) time.Time {
+	seconds := ntp >> 32
+	nanos := ((ntp & 0xffffffff) * uint64(time.Second)) >> 32
+	// #nosec G115
+	return time.Unix(int64(seconds)-ntpEpochOffset, int64(nanos))
+}
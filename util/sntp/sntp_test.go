@@ -0,0 +1,77 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package sntp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer answers SNTP requests with a clock running offset ahead of the local one.
+func fakeServer(t *testing.T, offset time.Duration, stratum uint8) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		request := make([]byte, packetSize)
+		for {
+			n, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+			if n < packetSize {
+				continue
+			}
+			now := toNTPTime(time.Now().Add(offset))
+			response := make([]byte, packetSize)
+			response[0] = version<<3 | modeServer
+			response[1] = stratum
+			copy(response[24:32], request[40:48])
+			binary.BigEndian.PutUint64(response[32:], now)
+			binary.BigEndian.PutUint64(response[40:], now)
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQueryMeasuresOffset(t *testing.T) {
+	for _, offset := range []time.Duration{0, 5 * time.Second, -3 * time.Second} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		response, err := Query(ctx, fakeServer(t, offset, 2))
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := response.Offset - offset; diff > 100*time.Millisecond || diff < -100*time.Millisecond {
+			t.Errorf("expected offset %v but measured %v", offset, response.Offset)
+		}
+		if response.Stratum != 2 {
+			t.Errorf("expected stratum 2 but got %v", response.Stratum)
+		}
+	}
+}
+
+func TestQueryRejectsKissOfDeath(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Query(ctx, fakeServer(t, 0, 0))
+	if !errors.Is(err, ErrKissOfDeath) {
+		t.Fatalf("expected a kiss-o'-death error but got %v", err)
+	}
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	now := time.Now()
+	if diff := fromNTPTime(toNTPTime(now)).Sub(now); diff > time.Microsecond || diff < -time.Microsecond {
+		t.Fatalf("NTP time round trip was off by %v", diff)
+	}
+}
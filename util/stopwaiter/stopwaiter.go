@@ -14,6 +14,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/flightrecorder"
 )
 
 const stopDelayWarningTimeout = 30 * time.Second
@@ -177,6 +178,7 @@ func (s *StopWaiterSafe) LaunchThreadSafe(foo func(context.Context)) error {
 	}
 	s.wg.Add(1)
 	go func() {
+		defer flightrecorder.DumpOnPanic()
 		foo(ctx)
 		s.wg.Done()
 	}()
@@ -0,0 +1,177 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package telemetry reports what a node runs, never who runs it, to an endpoint chosen by the operator.
+// Reports only carry a random node id, the version, the chain id, whether the node is synced, and which
+// features are enabled. Nothing is sent unless telemetry is enabled.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	reportsSentCounter   = metrics.NewRegisteredCounter("arb/telemetry/sent", nil)
+	reportsFailedCounter = metrics.NewRegisteredCounter("arb/telemetry/failed", nil)
+)
+
+type Config struct {
+	Enable   bool          `koanf:"enable"`
+	Endpoint string        `koanf:"endpoint"`
+	Interval time.Duration `koanf:"interval"`
+	Timeout  time.Duration `koanf:"timeout"`
+	Preview  bool          `koanf:"preview"`
+}
+
+var DefaultConfig = Config{
+	Enable:   false,
+	Endpoint: "",
+	Interval: 6 * time.Hour,
+	Timeout:  10 * time.Second,
+	Preview:  false,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConfig.Enable, "opt in to periodically reporting the node's version, chain id, sync status and enabled features, with a random node id and nothing identifying the operator")
+	f.String(prefix+".endpoint", DefaultConfig.Endpoint, "URL reports are POSTed to as JSON")
+	f.Duration(prefix+".interval", DefaultConfig.Interval, "how often to send a report")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout for sending a report")
+	f.Bool(prefix+".preview", DefaultConfig.Preview, "print the report the node would send, built from its configuration without starting it, then exit without sending anything")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if !strings.HasPrefix(c.Endpoint, "http://") && !strings.HasPrefix(c.Endpoint, "https://") {
+		return errors.New("telemetry enabled without an http or https endpoint")
+	}
+	if c.Interval <= 0 || c.Timeout <= 0 {
+		return errors.New("telemetry interval and timeout must be positive")
+	}
+	return nil
+}
+
+// Report is everything sent about a node.
+type Report struct {
+	NodeId   string    `json:"nodeId"`
+	Version  string    `json:"version"`
+	ChainId  uint64    `json:"chainId"`
+	Synced   bool      `json:"synced"`
+	Features []string  `json:"features"`
+	Time     time.Time `json:"time"`
+}
+
+// Features lists the names of the enabled features, sorted so reports are stable.
+func Features(enabled map[string]bool) []string {
+	features := []string{}
+	for name, on := range enabled {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+const nodeIdFile = "telemetry-node-id"
+
+// LoadNodeId returns the random id the node reports under, creating it in the directory on first use.
+// It isn't derived from anything about the node, so deleting the file gives the node a new identity.
+func LoadNodeId(dir string) (string, error) {
+	path := filepath.Join(dir, nodeIdFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		id := strings.TrimSpace(string(data))
+		if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+			return "", fmt.Errorf("invalid telemetry node id in %v", path)
+		}
+		return id, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(raw[:])
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Reporter periodically sends the node's report, starting as soon as it starts.
+type Reporter struct {
+	stopwaiter.StopWaiter
+	config func() *Config
+	report func() *Report
+	client *http.Client
+}
+
+func NewReporter(config func() *Config, report func() *Report) *Reporter {
+	return &Reporter{
+		config: config,
+		report: report,
+		client: &http.Client{},
+	}
+}
+
+func (r *Reporter) Start(ctx context.Context) {
+	r.StopWaiter.Start(ctx, r)
+	r.CallIteratively(func(ctx context.Context) time.Duration {
+		config := r.config()
+		if err := r.send(ctx, config, r.report()); err != nil {
+			reportsFailedCounter.Inc(1)
+			log.Warn("failed to send telemetry report", "err", err)
+		} else {
+			reportsSentCounter.Inc(1)
+		}
+		return config.Interval
+	})
+}
+
+func (r *Reporter) send(ctx context.Context, config *Config, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+	return nil
+}
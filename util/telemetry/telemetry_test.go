@@ -0,0 +1,87 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadNodeId(t *testing.T) {
+	dir := t.TempDir()
+	id, err := LoadNodeId(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 32 {
+		t.Fatal("unexpected node id", id)
+	}
+	again, err := LoadNodeId(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != id {
+		t.Fatal("node id changed from", id, "to", again)
+	}
+	other, err := LoadNodeId(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == id {
+		t.Fatal("two nodes got the same id")
+	}
+}
+
+func TestReporter(t *testing.T) {
+	received := make(chan *Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case received <- &report:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig
+	config.Enable = true
+	config.Endpoint = server.URL
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	features := Features(map[string]bool{"staker": true, "archive": true, "sequencer": false})
+	reporter := NewReporter(func() *Config { return &config }, func() *Report {
+		return &Report{NodeId: "node", Version: "v1", ChainId: 42161, Synced: true, Features: features}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reporter.Start(ctx)
+	defer reporter.StopAndWait()
+
+	select {
+	case report := <-received:
+		if report.NodeId != "node" || report.ChainId != 42161 || !report.Synced {
+			t.Fatal("unexpected report", report)
+		}
+		if !reflect.DeepEqual(report.Features, []string{"archive", "staker"}) {
+			t.Fatal("unexpected features", report.Features)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no report received")
+	}
+
+	config.Endpoint = "localhost:1234"
+	if err := config.Validate(); err == nil {
+		t.Fatal("accepted an endpoint without a scheme")
+	}
+}
@@ -78,7 +78,7 @@ type ValidationClient struct {
 	config *ValidationClientConfig
 	room   atomic.Int32
 	// producers stores moduleRoot to producer mapping.
-	producers   map[common.Hash]*pubsub.Producer[*validator.ValidationInput, validator.GoGlobalState]
+	producers   map[common.Hash]pubsub.RequestProducer[*validator.ValidationInput, validator.GoGlobalState]
 	redisClient redis.UniversalClient
 	moduleRoots []common.Hash
 }
@@ -93,7 +93,7 @@ func NewValidationClient(cfg *ValidationClientConfig) (*ValidationClient, error)
 	}
 	validationClient := &ValidationClient{
 		config:      cfg,
-		producers:   make(map[common.Hash]*pubsub.Producer[*validator.ValidationInput, validator.GoGlobalState]),
+		producers:   make(map[common.Hash]pubsub.RequestProducer[*validator.ValidationInput, validator.GoGlobalState]),
 		redisClient: redisClient,
 	}
 	validationClient.room.Store(cfg.Room)
@@ -65,6 +65,7 @@ type InputJSON struct {
 	StartState    validator.GoGlobalState
 	UserWasms     map[ethdb.WasmTarget]map[common.Hash]string
 	DebugChain    bool
+	HighPriority  bool
 }
 
 // Marshal returns the JSON encoding of the InputJSON.
@@ -91,6 +92,7 @@ func ValidationInputToJson(entry *validator.ValidationInput) *InputJSON {
 		PreimagesB64:  jsonPreimagesMap,
 		UserWasms:     make(map[ethdb.WasmTarget]map[common.Hash]string),
 		DebugChain:    entry.DebugChain,
+		HighPriority:  entry.HighPriority,
 	}
 	for _, binfo := range entry.BatchInfo {
 		encData := base64.StdEncoding.EncodeToString(binfo.Data)
@@ -123,6 +125,7 @@ func ValidationInputFromJson(entry *InputJSON) (*validator.ValidationInput, erro
 		Preimages:     preimages,
 		UserWasms:     make(map[ethdb.WasmTarget]map[common.Hash][]byte),
 		DebugChain:    entry.DebugChain,
+		HighPriority:  entry.HighPriority,
 	}
 	delayed, err := base64.StdEncoding.DecodeString(entry.DelayedMsgB64)
 	if err != nil {
@@ -27,11 +27,16 @@ import (
 var arbitratorValidationSteps = metrics.NewRegisteredHistogram("arbitrator/validation/steps", nil, metrics.NewBoundedHistogramSample())
 
 type ArbitratorSpawnerConfig struct {
-	Workers                     int                          `koanf:"workers" reload:"hot"`
-	OutputPath                  string                       `koanf:"output-path" reload:"hot"`
-	Execution                   MachineCacheConfig           `koanf:"execution" reload:"hot"` // hot reloading for new executions only
-	ExecutionRunTimeout         time.Duration                `koanf:"execution-run-timeout" reload:"hot"`
-	RedisValidationServerConfig redis.ValidationServerConfig `koanf:"redis-validation-server-config"`
+	Workers                     int                                 `koanf:"workers" reload:"hot"`
+	OutputPath                  string                              `koanf:"output-path" reload:"hot"`
+	Execution                   MachineCacheConfig                  `koanf:"execution" reload:"hot"` // hot reloading for new executions only
+	ExecutionRunTimeout         time.Duration                       `koanf:"execution-run-timeout" reload:"hot"`
+	RedisValidationServerConfig redis.ValidationServerConfig        `koanf:"redis-validation-server-config"`
+	RemotePreimages             server_common.RemotePreimagesConfig `koanf:"remote-preimages"`
+}
+
+func (c *ArbitratorSpawnerConfig) Validate() error {
+	return c.RemotePreimages.Validate()
 }
 
 type ArbitratorSpawnerConfigFecher func() *ArbitratorSpawnerConfig
@@ -42,6 +47,7 @@ var DefaultArbitratorSpawnerConfig = ArbitratorSpawnerConfig{
 	Execution:                   DefaultMachineCacheConfig,
 	ExecutionRunTimeout:         time.Minute * 15,
 	RedisValidationServerConfig: redis.DefaultValidationServerConfig,
+	RemotePreimages:             server_common.DefaultRemotePreimagesConfig,
 }
 
 func ArbitratorSpawnerConfigAddOptions(prefix string, f *pflag.FlagSet) {
@@ -50,6 +56,7 @@ func ArbitratorSpawnerConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.String(prefix+".output-path", DefaultArbitratorSpawnerConfig.OutputPath, "path to write machines to")
 	MachineCacheConfigConfigAddOptions(prefix+".execution", f)
 	redis.ValidationServerConfigAddOptions(prefix+".redis-validation-server-config", f)
+	server_common.RemotePreimagesConfigAddOptions(prefix+".remote-preimages", f)
 }
 
 func DefaultArbitratorSpawnerConfigFetcher() *ArbitratorSpawnerConfig {
@@ -62,6 +69,9 @@ type ArbitratorSpawner struct {
 	locator       *server_common.MachineLocator
 	machineLoader *ArbMachineLoader
 	config        ArbitratorSpawnerConfigFecher
+	scheduler     *server_common.Scheduler
+	// remotePreimages, if set, resolves preimages missing from validation inputs
+	remotePreimages *server_common.RemotePreimageStore
 }
 
 func NewArbitratorSpawner(locator *server_common.MachineLocator, config ArbitratorSpawnerConfigFecher) (*ArbitratorSpawner, error) {
@@ -71,11 +81,20 @@ func NewArbitratorSpawner(locator *server_common.MachineLocator, config Arbitrat
 		machineLoader: NewArbMachineLoader(&DefaultArbitratorMachineConfig, locator),
 		config:        config,
 	}
+	spawner.scheduler = server_common.NewScheduler(spawner.Room)
+	if remoteConfig := &config().RemotePreimages; remoteConfig.Enabled() {
+		store, err := server_common.NewRemotePreimageStore(remoteConfig)
+		if err != nil {
+			return nil, err
+		}
+		spawner.remotePreimages = store
+	}
 	return spawner, nil
 }
 
 func (s *ArbitratorSpawner) Start(ctx_in context.Context) error {
 	s.StopWaiter.Start(ctx_in, s)
+	s.scheduler.Start(ctx_in)
 	return nil
 }
 
@@ -101,6 +120,9 @@ func (v *ArbitratorSpawner) loadEntryToMachine(_ context.Context, entry *validat
 		if preimage, ok := entry.Preimages[ty][hash]; ok {
 			return preimage, nil
 		}
+		if v.remotePreimages != nil {
+			return v.remotePreimages.GetPreimage(v.GetContext(), ty, hash)
+		}
 		return nil, errors.New("preimage not found")
 	}
 	if err := mach.SetPreimageResolver(resolver); err != nil {
@@ -156,7 +178,7 @@ func (v *ArbitratorSpawner) execute(
 ) (validator.GoGlobalState, error) {
 	basemachine, err := v.machineLoader.GetHostIoMachine(ctx, moduleRoot)
 	if err != nil {
-		return validator.GoGlobalState{}, fmt.Errorf("unabled to get WASM machine: %w", err)
+		return validator.GoGlobalState{}, fmt.Errorf("%w: %w", server_common.ErrMachineLoad, err)
 	}
 
 	mach := basemachine.Clone()
@@ -183,15 +205,15 @@ func (v *ArbitratorSpawner) execute(
 
 	if mach.IsErrored() {
 		log.Error("machine entered errored state during attempted validation", "block", entry.Id)
-		return validator.GoGlobalState{}, errors.New("machine entered errored state during attempted validation")
+		return validator.GoGlobalState{}, fmt.Errorf("%w: machine entered errored state during attempted validation", server_common.ErrMachineTrap)
 	}
 	return mach.GetGlobalState(), nil
 }
 
 func (v *ArbitratorSpawner) Launch(entry *validator.ValidationInput, moduleRoot common.Hash) validator.ValidationRun {
 	println("LAUCHING ARBITRATOR VALIDATION")
-	v.count.Add(1)
-	promise := stopwaiter.LaunchPromiseThread[validator.GoGlobalState](v, func(ctx context.Context) (validator.GoGlobalState, error) {
+	promise := v.scheduler.Launch(entry.HighPriority, func(ctx context.Context) (validator.GoGlobalState, error) {
+		v.count.Add(1)
 		defer v.count.Add(-1)
 		return v.execute(ctx, entry, moduleRoot)
 	})
@@ -228,4 +250,5 @@ func (v *ArbitratorSpawner) CreateExecutionRun(wasmModuleRoot common.Hash, input
 
 func (v *ArbitratorSpawner) Stop() {
 	v.StopOnly()
+	v.scheduler.StopOnly()
 }
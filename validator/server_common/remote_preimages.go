@@ -0,0 +1,259 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package server_common
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/blobs"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	remotePreimagesFetchedCounter = metrics.NewRegisteredCounter("arb/validator/remotepreimages/fetched", nil)
+	remotePreimagesBytesCounter   = metrics.NewRegisteredCounter("arb/validator/remotepreimages/bytes", nil)
+	remotePreimagesFailedCounter  = metrics.NewRegisteredCounter("arb/validator/remotepreimages/failed", nil)
+	remotePreimagesFetchTimer     = metrics.NewRegisteredHistogram("arb/validator/remotepreimages/fetch_time", nil, metrics.NewBoundedHistogramSample())
+)
+
+// A remote preimage store holds preimages under "<type>/<hash>", where type is the decimal arbutil.PreimageType
+// and hash the 0x prefixed hex hash, at an HTTP base URL or under an S3 object prefix.
+const maxRemotePreimageSize = 16 * 1024 * 1024
+
+var ErrRemotePreimageNotFound = errors.New("preimage not found in remote store")
+
+type RemotePreimagesS3Config struct {
+	Bucket       string `koanf:"bucket"`
+	ObjectPrefix string `koanf:"object-prefix"`
+	Region       string `koanf:"region"`
+	AccessKey    string `koanf:"access-key"`
+	SecretKey    string `koanf:"secret-key"`
+}
+
+type RemotePreimagesConfig struct {
+	URL       string                  `koanf:"url"`
+	S3        RemotePreimagesS3Config `koanf:"s3"`
+	Timeout   time.Duration           `koanf:"timeout"`
+	CacheSize int                     `koanf:"cache-size"`
+}
+
+var DefaultRemotePreimagesConfig = RemotePreimagesConfig{
+	Timeout:   10 * time.Second,
+	CacheSize: 10000,
+}
+
+func RemotePreimagesConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultRemotePreimagesConfig.URL, "HTTP or HTTPS base URL of a store to fetch preimages missing from validation inputs from (the JIT validator needs every preimage in its inputs)")
+	f.String(prefix+".s3.bucket", DefaultRemotePreimagesConfig.S3.Bucket, "S3 bucket of a store to fetch preimages missing from validation inputs from")
+	f.String(prefix+".s3.object-prefix", DefaultRemotePreimagesConfig.S3.ObjectPrefix, "prefix of the preimage objects in the S3 bucket")
+	f.String(prefix+".s3.region", DefaultRemotePreimagesConfig.S3.Region, "S3 region")
+	f.String(prefix+".s3.access-key", DefaultRemotePreimagesConfig.S3.AccessKey, "S3 access key")
+	f.String(prefix+".s3.secret-key", DefaultRemotePreimagesConfig.S3.SecretKey, "S3 secret key")
+	f.Duration(prefix+".timeout", DefaultRemotePreimagesConfig.Timeout, "timeout for fetching a single preimage")
+	f.Int(prefix+".cache-size", DefaultRemotePreimagesConfig.CacheSize, "number of fetched preimages to cache")
+}
+
+func (c *RemotePreimagesConfig) Enabled() bool {
+	return c.URL != "" || c.S3.Bucket != ""
+}
+
+func (c *RemotePreimagesConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.URL != "" && c.S3.Bucket != "" {
+		return errors.New("only one of the remote preimage store's url and s3 bucket can be set")
+	}
+	if c.URL != "" {
+		parsed, err := url.Parse(c.URL)
+		if err != nil {
+			return fmt.Errorf("invalid remote preimage store url: %w", err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("remote preimage store url must be http or https, not %v", parsed.Scheme)
+		}
+	}
+	if c.Timeout <= 0 {
+		return errors.New("remote preimage store timeout must be positive")
+	}
+	return nil
+}
+
+// VerifyPreimage checks that preimage hashes to hash under the preimage type's hash function.
+func VerifyPreimage(ty arbutil.PreimageType, hash common.Hash, preimage []byte) error {
+	var actual common.Hash
+	switch ty {
+	case arbutil.Keccak256PreimageType:
+		actual = crypto.Keccak256Hash(preimage)
+	case arbutil.Sha2_256PreimageType:
+		actual = sha256.Sum256(preimage)
+	case arbutil.EthVersionedHashPreimageType:
+		var blob kzg4844.Blob
+		if len(preimage) != len(blob) {
+			return fmt.Errorf("blob preimage is %v bytes instead of %v", len(preimage), len(blob))
+		}
+		copy(blob[:], preimage)
+		commitment, err := kzg4844.BlobToCommitment(&blob)
+		if err != nil {
+			return err
+		}
+		actual = blobs.CommitmentToVersionedHash(commitment)
+	default:
+		return fmt.Errorf("unknown preimage type %v", ty)
+	}
+	if actual != hash {
+		return fmt.Errorf("preimage of type %v hashes to %v instead of %v", ty, actual, hash)
+	}
+	return nil
+}
+
+type remotePreimageKey struct {
+	ty   arbutil.PreimageType
+	hash common.Hash
+}
+
+// RemotePreimageStore fetches preimages on demand, so a validator doesn't need every preimage to be recorded
+// in its inputs. Preimages are verified against their hash before being used.
+type RemotePreimageStore struct {
+	fetch   func(ctx context.Context, key string) ([]byte, error)
+	timeout time.Duration
+
+	cacheMutex sync.Mutex
+	cache      *containers.LruCache[remotePreimageKey, []byte]
+}
+
+func NewRemotePreimageStore(config *RemotePreimagesConfig) (*RemotePreimageStore, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	store := &RemotePreimageStore{
+		timeout: config.Timeout,
+		cache:   containers.NewLruCache[remotePreimageKey, []byte](config.CacheSize),
+	}
+	if config.URL != "" {
+		store.fetch = httpPreimageFetcher(strings.TrimSuffix(config.URL, "/"))
+	} else {
+		fetch, err := s3PreimageFetcher(&config.S3)
+		if err != nil {
+			return nil, err
+		}
+		store.fetch = fetch
+	}
+	return store, nil
+}
+
+func httpPreimageFetcher(baseURL string) func(ctx context.Context, key string) ([]byte, error) {
+	client := &http.Client{}
+	return func(ctx context.Context, key string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/"+key, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrRemotePreimageNotFound
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("remote preimage store returned status %v", resp.Status)
+		}
+		return readRemotePreimage(resp.Body)
+	}
+}
+
+func s3PreimageFetcher(config *RemotePreimagesS3Config) (func(ctx context.Context, key string) ([]byte, error), error) {
+	cfg, err := awsConfig.LoadDefaultConfig(context.Background(), awsConfig.WithRegion(config.Region), func(options *awsConfig.LoadOptions) error {
+		if config.AccessKey != "" && config.SecretKey != "" {
+			options.Credentials = credentials.NewStaticCredentialsProvider(config.AccessKey, config.SecretKey, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewFromConfig(cfg)
+	bucket, objectPrefix := config.Bucket, config.ObjectPrefix
+	return func(ctx context.Context, key string) ([]byte, error) {
+		output, err := client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(objectPrefix + key),
+		})
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrRemotePreimageNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer output.Body.Close()
+		return readRemotePreimage(output.Body)
+	}, nil
+}
+
+func readRemotePreimage(r io.Reader) ([]byte, error) {
+	preimage, err := io.ReadAll(io.LimitReader(r, maxRemotePreimageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(preimage) > maxRemotePreimageSize {
+		return nil, fmt.Errorf("remote preimage is larger than %v bytes", maxRemotePreimageSize)
+	}
+	return preimage, nil
+}
+
+// GetPreimage returns the preimage of hash, fetching and verifying it if it isn't cached.
+func (s *RemotePreimageStore) GetPreimage(ctx context.Context, ty arbutil.PreimageType, hash common.Hash) ([]byte, error) {
+	key := remotePreimageKey{ty, hash}
+	s.cacheMutex.Lock()
+	preimage, ok := s.cache.Get(key)
+	s.cacheMutex.Unlock()
+	if ok {
+		return preimage, nil
+	}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	preimage, err := s.fetch(ctx, fmt.Sprintf("%d/%v", ty, hash))
+	if err == nil {
+		err = VerifyPreimage(ty, hash, preimage)
+	}
+	if err != nil {
+		remotePreimagesFailedCounter.Inc(1)
+		log.Warn("failed to fetch remote preimage", "type", ty, "hash", hash, "err", err)
+		return nil, fmt.Errorf("fetching preimage %v of type %v: %w", hash, ty, err)
+	}
+	remotePreimagesFetchedCounter.Inc(1)
+	remotePreimagesBytesCounter.Inc(int64(len(preimage)))
+	remotePreimagesFetchTimer.Update(time.Since(start).Microseconds())
+	s.cacheMutex.Lock()
+	s.cache.Add(key, preimage)
+	s.cacheMutex.Unlock()
+	return preimage, nil
+}
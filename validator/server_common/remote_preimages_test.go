@@ -0,0 +1,88 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package server_common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func TestVerifyPreimage(t *testing.T) {
+	preimage := []byte("preimage")
+	if err := VerifyPreimage(arbutil.Keccak256PreimageType, crypto.Keccak256Hash(preimage), preimage); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPreimage(arbutil.Sha2_256PreimageType, sha256.Sum256(preimage), preimage); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPreimage(arbutil.Sha2_256PreimageType, crypto.Keccak256Hash(preimage), preimage); err == nil {
+		t.Fatal("verified a preimage against the wrong hash function")
+	}
+	if err := VerifyPreimage(arbutil.EthVersionedHashPreimageType, common.Hash{}, preimage); err == nil {
+		t.Fatal("verified a blob of the wrong size")
+	}
+}
+
+func TestRemotePreimageStore(t *testing.T) {
+	good := []byte("good preimage")
+	goodHash := crypto.Keccak256Hash(good)
+	forgedHash := crypto.Keccak256Hash([]byte("forged"))
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch strings.TrimPrefix(r.URL.Path, "/preimages/") {
+		case "0/" + goodHash.Hex():
+			_, _ = w.Write(good)
+		case "0/" + forgedHash.Hex():
+			_, _ = w.Write(good)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultRemotePreimagesConfig
+	config.URL = server.URL + "/preimages/"
+	store, err := NewRemotePreimageStore(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		preimage, err := store.GetPreimage(ctx, arbutil.Keccak256PreimageType, goodHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(preimage, good) {
+			t.Fatal("fetched the wrong preimage", preimage)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Fatal("cached preimage was fetched again, requests:", requests.Load())
+	}
+	if _, err := store.GetPreimage(ctx, arbutil.Keccak256PreimageType, forgedHash); err == nil {
+		t.Fatal("accepted a preimage that doesn't match its hash")
+	}
+	_, err = store.GetPreimage(ctx, arbutil.Sha2_256PreimageType, goodHash)
+	if !errors.Is(err, ErrRemotePreimageNotFound) {
+		t.Fatal("expected a missing preimage, got", err)
+	}
+
+	config.URL = "ftp://example.com"
+	if _, err := NewRemotePreimageStore(&config); err == nil {
+		t.Fatal("accepted a non-HTTP store url")
+	}
+}
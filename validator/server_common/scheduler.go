@@ -0,0 +1,195 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package server_common
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+var (
+	schedulerHighPriorityQueued = metrics.NewRegisteredGauge("arb/validator/scheduler/queued/high", nil)
+	schedulerLowPriorityQueued  = metrics.NewRegisteredGauge("arb/validator/scheduler/queued/low", nil)
+	schedulerRunningGauge       = metrics.NewRegisteredGauge("arb/validator/scheduler/running", nil)
+	schedulerPreemptedCounter   = metrics.NewRegisteredCounter("arb/validator/scheduler/preempted", nil)
+)
+
+type scheduledValidation struct {
+	run          func(context.Context) (validator.GoGlobalState, error)
+	highPriority bool
+	promise      *containers.Promise[validator.GoGlobalState]
+
+	// guarded by the scheduler's mutex
+	cancelAttempt func() // cancels the current attempt, nil unless running
+	preempted     bool   // the current attempt was cancelled to make room, and will be requeued
+	cancelled     bool   // the caller no longer wants the result
+}
+
+// Scheduler runs validations on a limited number of workers, starting high priority ones first.
+// When high priority validations are waiting and every worker is busy, the most recently started
+// routine validations are preempted and requeued ahead of the other routine ones.
+type Scheduler struct {
+	stopwaiter.StopWaiter
+	room func() int
+
+	mutex   sync.Mutex
+	high    []*scheduledValidation
+	low     []*scheduledValidation
+	running []*scheduledValidation // in the order they were started
+}
+
+func NewScheduler(room func() int) *Scheduler {
+	return &Scheduler{room: room}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	s.StopWaiter.Start(ctx, s)
+}
+
+// Launch queues a validation, which is run with a context cancelled if it's preempted or the result is no longer wanted.
+// A preempted validation is run again from scratch, so run must not have side effects.
+func (s *Scheduler) Launch(highPriority bool, run func(context.Context) (validator.GoGlobalState, error)) containers.PromiseInterface[validator.GoGlobalState] {
+	job := &scheduledValidation{
+		run:          run,
+		highPriority: highPriority,
+	}
+	promise := containers.NewPromise[validator.GoGlobalState](func() { s.cancel(job) })
+	job.promise = &promise
+	if _, err := s.GetContextSafe(); err != nil {
+		promise.ProduceError(err)
+		return &promise
+	}
+	if s.Stopped() {
+		promise.ProduceError(errors.New("stopped"))
+		return &promise
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if highPriority {
+		s.high = append(s.high, job)
+	} else {
+		s.low = append(s.low, job)
+	}
+	s.dispatch()
+	return &promise
+}
+
+// Running returns the number of validations currently running.
+func (s *Scheduler) Running() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.running)
+}
+
+// must be called with the mutex held
+func (s *Scheduler) dispatch() {
+	defer s.updateMetrics()
+	for len(s.running) < s.room() {
+		var job *scheduledValidation
+		if len(s.high) > 0 {
+			job, s.high = s.high[0], s.high[1:]
+		} else if len(s.low) > 0 {
+			job, s.low = s.low[0], s.low[1:]
+		} else {
+			return
+		}
+		s.start(job)
+	}
+	waiting := len(s.high)
+	for i := len(s.running) - 1; i >= 0 && waiting > 0; i-- {
+		job := s.running[i]
+		if job.highPriority {
+			continue
+		}
+		if !job.preempted {
+			job.preempted = true
+			job.cancelAttempt()
+			schedulerPreemptedCounter.Inc(1)
+		}
+		waiting--
+	}
+}
+
+// must be called with the mutex held
+func (s *Scheduler) start(job *scheduledValidation) {
+	ctx, err := s.GetContextSafe()
+	if err != nil {
+		job.promise.ProduceError(err)
+		return
+	}
+	attemptCtx, cancel := context.WithCancel(ctx)
+	job.cancelAttempt = cancel
+	s.running = append(s.running, job)
+	err = s.LaunchThreadSafe(func(context.Context) {
+		val, err := job.run(attemptCtx)
+		cancel()
+		s.finish(job, val, err)
+	})
+	if err != nil {
+		cancel()
+		s.running = s.running[:len(s.running)-1]
+		job.cancelAttempt = nil
+		job.promise.ProduceError(err)
+	}
+}
+
+func (s *Scheduler) finish(job *scheduledValidation, val validator.GoGlobalState, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running = removeScheduled(s.running, job)
+	job.cancelAttempt = nil
+	if job.preempted && !job.cancelled {
+		job.preempted = false
+		s.low = append([]*scheduledValidation{job}, s.low...)
+	} else if err != nil {
+		job.promise.ProduceError(err)
+	} else {
+		job.promise.Produce(val)
+	}
+	s.dispatch()
+}
+
+func (s *Scheduler) cancel(job *scheduledValidation) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if job.cancelled {
+		return
+	}
+	job.cancelled = true
+	if job.cancelAttempt != nil {
+		// finish produces the result once the attempt returns
+		job.cancelAttempt()
+		return
+	}
+	queued := len(s.high) + len(s.low)
+	s.high = removeScheduled(s.high, job)
+	s.low = removeScheduled(s.low, job)
+	if len(s.high)+len(s.low) < queued {
+		job.promise.ProduceError(context.Canceled)
+		s.updateMetrics()
+	}
+}
+
+// must be called with the mutex held
+func (s *Scheduler) updateMetrics() {
+	schedulerHighPriorityQueued.Update(int64(len(s.high)))
+	schedulerLowPriorityQueued.Update(int64(len(s.low)))
+	schedulerRunningGauge.Update(int64(len(s.running)))
+}
+
+func removeScheduled(jobs []*scheduledValidation, job *scheduledValidation) []*scheduledValidation {
+	for i, j := range jobs {
+		if j == job {
+			return append(jobs[:i], jobs[i+1:]...)
+		}
+	}
+	return jobs
+}
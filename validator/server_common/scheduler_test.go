@@ -0,0 +1,100 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package server_common
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestSchedulerPreemptsRoutineValidations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := NewScheduler(func() int { return 1 })
+	scheduler.Start(ctx)
+	defer scheduler.StopAndWait()
+
+	var lowAttempts atomic.Int32
+	lowStarted := make(chan struct{}, 2)
+	releaseLow := make(chan struct{})
+	low := scheduler.Launch(false, func(ctx context.Context) (validator.GoGlobalState, error) {
+		lowAttempts.Add(1)
+		lowStarted <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return validator.GoGlobalState{}, ctx.Err()
+		case <-releaseLow:
+			return validator.GoGlobalState{Batch: 1}, nil
+		}
+	})
+	<-lowStarted
+
+	// A second routine validation waits behind the first
+	queued := scheduler.Launch(false, func(ctx context.Context) (validator.GoGlobalState, error) {
+		return validator.GoGlobalState{Batch: 3}, nil
+	})
+	high := scheduler.Launch(true, func(ctx context.Context) (validator.GoGlobalState, error) {
+		if lowAttempts.Load() != 1 {
+			t.Error("high priority validation didn't preempt the running one")
+		}
+		return validator.GoGlobalState{Batch: 2}, nil
+	})
+	awaitCtx, awaitCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer awaitCancel()
+	res, err := high.Await(awaitCtx)
+	if err != nil || res.Batch != 2 {
+		t.Fatal("unexpected high priority result", res, err)
+	}
+
+	// The preempted validation is requeued ahead of the other routine one
+	<-lowStarted
+	if queued.Ready() {
+		t.Fatal("queued validation ran before the preempted one")
+	}
+	close(releaseLow)
+	res, err = low.Await(awaitCtx)
+	if err != nil || res.Batch != 1 {
+		t.Fatal("unexpected preempted validation result", res, err)
+	}
+	if attempts := lowAttempts.Load(); attempts != 2 {
+		t.Fatal("expected the preempted validation to run twice but it ran", attempts)
+	}
+	res, err = queued.Await(awaitCtx)
+	if err != nil || res.Batch != 3 {
+		t.Fatal("unexpected queued validation result", res, err)
+	}
+}
+
+func TestSchedulerCancelQueued(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := NewScheduler(func() int { return 1 })
+	scheduler.Start(ctx)
+	defer scheduler.StopAndWait()
+
+	release := make(chan struct{})
+	running := scheduler.Launch(true, func(ctx context.Context) (validator.GoGlobalState, error) {
+		<-release
+		return validator.GoGlobalState{}, nil
+	})
+	queued := scheduler.Launch(false, func(ctx context.Context) (validator.GoGlobalState, error) {
+		t.Error("cancelled validation was run")
+		return validator.GoGlobalState{}, nil
+	})
+	queued.Cancel()
+	if _, err := queued.Current(); err == nil {
+		t.Fatal("expected a cancelled validation to fail")
+	}
+	close(release)
+	if _, err := running.Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if running := scheduler.Running(); running != 0 {
+		t.Fatal("expected no running validations but got", running)
+	}
+}
@@ -1,11 +1,20 @@
 package server_common
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/validator"
 )
 
+var (
+	// ErrMachineLoad is wrapped by validation errors from failing to load or compile the replay binary
+	ErrMachineLoad = errors.New("unable to get WASM machine")
+	// ErrMachineTrap is wrapped by validation errors from the replay binary trapping
+	ErrMachineTrap = errors.New("validation machine trapped")
+)
+
 type ValRun struct {
 	containers.PromiseInterface[validator.GoGlobalState]
 	root common.Hash
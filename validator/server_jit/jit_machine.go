@@ -21,6 +21,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/server_common"
 )
 
 var jitWasmMemoryUsage = metrics.NewRegisteredHistogram("jit/wasm/memoryusage", nil, metrics.NewBoundedHistogramSample())
@@ -290,7 +291,7 @@ func (machine *JitMachine) prove(
 				return state, err
 			}
 			log.Error("Jit Machine Failure", "message", string(message))
-			return state, errors.New(string(message))
+			return state, fmt.Errorf("%w: %s", server_common.ErrMachineTrap, message)
 		case successByte:
 			if state.Batch, err = readUint64(); err != nil {
 				return state, err
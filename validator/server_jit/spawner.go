@@ -48,6 +48,7 @@ type JitSpawner struct {
 	locator       *server_common.MachineLocator
 	machineLoader *JitMachineLoader
 	config        JitSpawnerConfigFecher
+	scheduler     *server_common.Scheduler
 }
 
 func NewJitSpawner(locator *server_common.MachineLocator, config JitSpawnerConfigFecher, fatalErrChan chan error) (*JitSpawner, error) {
@@ -65,11 +66,13 @@ func NewJitSpawner(locator *server_common.MachineLocator, config JitSpawnerConfi
 		machineLoader: loader,
 		config:        config,
 	}
+	spawner.scheduler = server_common.NewScheduler(spawner.Room)
 	return spawner, nil
 }
 
 func (v *JitSpawner) Start(ctx_in context.Context) error {
 	v.StopWaiter.Start(ctx_in, v)
+	v.scheduler.Start(ctx_in)
 	return nil
 }
 
@@ -86,7 +89,7 @@ func (v *JitSpawner) execute(
 ) (validator.GoGlobalState, error) {
 	machine, err := v.machineLoader.GetMachine(ctx, moduleRoot)
 	if err != nil {
-		return validator.GoGlobalState{}, fmt.Errorf("unabled to get WASM machine: %w", err)
+		return validator.GoGlobalState{}, fmt.Errorf("%w: %w", server_common.ErrMachineLoad, err)
 	}
 
 	state, err := machine.prove(ctx, entry)
@@ -101,8 +104,8 @@ func (s *JitSpawner) Name() string {
 }
 
 func (v *JitSpawner) Launch(entry *validator.ValidationInput, moduleRoot common.Hash) validator.ValidationRun {
-	v.count.Add(1)
-	promise := stopwaiter.LaunchPromiseThread[validator.GoGlobalState](v, func(ctx context.Context) (validator.GoGlobalState, error) {
+	promise := v.scheduler.Launch(entry.HighPriority, func(ctx context.Context) (validator.GoGlobalState, error) {
+		v.count.Add(1)
 		defer v.count.Add(-1)
 		return v.execute(ctx, entry, moduleRoot)
 	})
@@ -119,5 +122,6 @@ func (v *JitSpawner) Room() int {
 
 func (v *JitSpawner) Stop() {
 	v.StopOnly()
+	v.scheduler.StopOnly()
 	v.machineLoader.Stop()
 }
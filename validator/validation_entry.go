@@ -21,4 +21,5 @@ type ValidationInput struct {
 	DelayedMsg    []byte
 	StartState    GoGlobalState
 	DebugChain    bool
+	HighPriority  bool // in a disputed range, so scheduled ahead of routine validations
 }
@@ -0,0 +1,307 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package valnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/server_common"
+)
+
+var (
+	divergenceStateCounter          = metrics.NewRegisteredCounter("arb/validation/divergence/state", nil)
+	divergenceTrapCounter           = metrics.NewRegisteredCounter("arb/validation/divergence/traps", nil)
+	divergenceCompileFailureCounter = metrics.NewRegisteredCounter("arb/validation/divergence/compile_failures", nil)
+	divergenceCrossChecksCounter    = metrics.NewRegisteredCounter("arb/validation/divergence/cross_checks", nil)
+	divergenceDroppedCounter        = metrics.NewRegisteredCounter("arb/validation/divergence/dropped", nil)
+	divergenceWebhookFailureCounter = metrics.NewRegisteredCounter("arb/validation/divergence/webhook/failures", nil)
+)
+
+type DivergenceMonitorConfig struct {
+	Enable         bool          `koanf:"enable"`
+	CrossCheckRate float64       `koanf:"cross-check-rate" reload:"hot"`
+	WebhookURL     string        `koanf:"webhook-url"`
+	WebhookTimeout time.Duration `koanf:"webhook-timeout"`
+	QueueSize      int           `koanf:"queue-size"`
+}
+
+var DefaultDivergenceMonitorConfig = DivergenceMonitorConfig{
+	Enable:         false,
+	CrossCheckRate: 0,
+	WebhookURL:     "",
+	WebhookTimeout: 5 * time.Second,
+	QueueSize:      256,
+}
+
+func DivergenceMonitorConfigAddOptions(prefix string, f *pflag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultDivergenceMonitorConfig.Enable, "report validation traps, replay binary compilation failures, and disagreements between jit and arbitrator validation")
+	f.Float64(prefix+".cross-check-rate", DefaultDivergenceMonitorConfig.CrossCheckRate, "fraction of jit validations also run on the arbitrator to compare their results (0 = none, 1 = all)")
+	f.String(prefix+".webhook-url", DefaultDivergenceMonitorConfig.WebhookURL, "URL each divergence event is also POSTed to as JSON (empty = disabled)")
+	f.Duration(prefix+".webhook-timeout", DefaultDivergenceMonitorConfig.WebhookTimeout, "timeout for posting an event to the webhook")
+	f.Int(prefix+".queue-size", DefaultDivergenceMonitorConfig.QueueSize, "how many events can wait to be posted before new ones are dropped")
+}
+
+func (c *DivergenceMonitorConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.CrossCheckRate < 0 || c.CrossCheckRate > 1 {
+		return errors.New("divergence monitor cross-check-rate must be between 0 and 1")
+	}
+	if c.WebhookURL != "" && c.WebhookTimeout <= 0 {
+		return errors.New("divergence monitor webhook-timeout must be positive")
+	}
+	if c.QueueSize < 1 {
+		return errors.New("divergence monitor queue-size must be positive")
+	}
+	return nil
+}
+
+type DivergenceKind string
+
+const (
+	// The jit and the arbitrator validated the same input to different end states
+	DivergenceState DivergenceKind = "state"
+	// The replay binary trapped during validation
+	DivergenceTrap DivergenceKind = "trap"
+	// The replay binary couldn't be loaded or compiled
+	DivergenceCompileFailure DivergenceKind = "compile-failure"
+)
+
+// DivergenceEvent is a validation result that puts the node's ability to prove its blocks in doubt.
+type DivergenceEvent struct {
+	Kind       DivergenceKind          `json:"kind"`
+	Time       time.Time               `json:"time"`
+	ModuleRoot common.Hash             `json:"moduleRoot"`
+	InputId    uint64                  `json:"inputId"`
+	Start      validator.GoGlobalState `json:"start"`
+	// The end state each spawner reached, for state divergences
+	Results map[string]validator.GoGlobalState `json:"results,omitempty"`
+	// The spawner that failed and its error, for traps and compile failures
+	Spawner string `json:"spawner,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DivergenceMonitor wraps the spawner serving validations, reporting traps and compilation failures, and
+// running a fraction of validations on a reference spawner to check the two agree. Events are logged,
+// counted, and optionally posted to a webhook. The results returned to clients are the primary spawner's.
+type DivergenceMonitor struct {
+	stopwaiter.StopWaiter
+	validator.ValidationSpawner
+	reference validator.ValidationSpawner // nil if there's nothing to cross-check against
+	config    func() *DivergenceMonitorConfig
+	queue     chan *DivergenceEvent
+	client    *http.Client
+}
+
+func NewDivergenceMonitor(primary, reference validator.ValidationSpawner, config func() *DivergenceMonitorConfig) *DivergenceMonitor {
+	return &DivergenceMonitor{
+		ValidationSpawner: primary,
+		reference:         reference,
+		config:            config,
+		queue:             make(chan *DivergenceEvent, config().QueueSize),
+		client:            &http.Client{},
+	}
+}
+
+// Start only starts the monitor; the spawners are started by the validation node.
+func (m *DivergenceMonitor) Start(ctx context.Context) error {
+	m.StopWaiter.Start(ctx, m)
+	m.LaunchThread(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-m.queue:
+				m.post(ctx, event)
+			}
+		}
+	})
+	return nil
+}
+
+func (m *DivergenceMonitor) Stop() {
+	m.StopAndWait()
+}
+
+// StylusArchs includes the reference spawner's targets, so inputs can be validated by both.
+func (m *DivergenceMonitor) StylusArchs() []ethdb.WasmTarget {
+	archs := m.ValidationSpawner.StylusArchs()
+	if m.reference == nil || m.config().CrossCheckRate == 0 {
+		return archs
+	}
+	for _, arch := range m.reference.StylusArchs() {
+		found := false
+		for _, have := range archs {
+			found = found || have == arch
+		}
+		if !found {
+			archs = append(archs, arch)
+		}
+	}
+	return archs
+}
+
+func (m *DivergenceMonitor) Launch(entry *validator.ValidationInput, moduleRoot common.Hash) validator.ValidationRun {
+	run := m.ValidationSpawner.Launch(entry, moduleRoot)
+	var reference validator.ValidationRun
+	if m.reference != nil && m.canCrossCheck(entry) && rand.Float64() < m.config().CrossCheckRate {
+		divergenceCrossChecksCounter.Inc(1)
+		reference = m.reference.Launch(entry, moduleRoot)
+	}
+	m.LaunchUntrackedThread(func() {
+		ctx := m.GetContext()
+		// Wait on the ready channels rather than Await, which would cancel the runs when the monitor stops
+		for _, r := range []validator.ValidationRun{run, reference} {
+			if r == nil {
+				continue
+			}
+			select {
+			case <-r.ReadyChan():
+			case <-ctx.Done():
+				if reference != nil {
+					reference.Cancel()
+				}
+				return
+			}
+		}
+		m.check(entry, moduleRoot, m.ValidationSpawner.Name(), run, m.referenceName(), reference)
+	})
+	return run
+}
+
+func (m *DivergenceMonitor) referenceName() string {
+	if m.reference == nil {
+		return ""
+	}
+	return m.reference.Name()
+}
+
+// canCrossCheck reports whether an input has the stylus programs the reference spawner needs.
+func (m *DivergenceMonitor) canCrossCheck(entry *validator.ValidationInput) bool {
+	if len(entry.UserWasms) == 0 {
+		return true
+	}
+	for _, arch := range m.reference.StylusArchs() {
+		if _, ok := entry.UserWasms[arch]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// check reports on finished runs. The reference run is nil if the input wasn't cross-checked.
+func (m *DivergenceMonitor) check(
+	entry *validator.ValidationInput, moduleRoot common.Hash,
+	name string, run validator.ValidationRun, referenceName string, reference validator.ValidationRun,
+) {
+	newEvent := func(kind DivergenceKind) *DivergenceEvent {
+		return &DivergenceEvent{
+			Kind:       kind,
+			Time:       time.Now(),
+			ModuleRoot: moduleRoot,
+			InputId:    entry.Id,
+			Start:      entry.StartState,
+		}
+	}
+	failed := false
+	for _, result := range []struct {
+		name string
+		run  validator.ValidationRun
+	}{{name, run}, {referenceName, reference}} {
+		if result.run == nil {
+			continue
+		}
+		_, err := result.run.Current()
+		var event *DivergenceEvent
+		switch {
+		case errors.Is(err, server_common.ErrMachineTrap):
+			divergenceTrapCounter.Inc(1)
+			event = newEvent(DivergenceTrap)
+		case errors.Is(err, server_common.ErrMachineLoad):
+			divergenceCompileFailureCounter.Inc(1)
+			event = newEvent(DivergenceCompileFailure)
+		case err != nil:
+			failed = true
+			continue
+		default:
+			continue
+		}
+		failed = true
+		event.Spawner = result.name
+		event.Error = err.Error()
+		m.report(event)
+	}
+	if reference == nil || failed {
+		return
+	}
+	end, _ := run.Current()
+	referenceEnd, _ := reference.Current()
+	if end != referenceEnd {
+		divergenceStateCounter.Inc(1)
+		event := newEvent(DivergenceState)
+		event.Results = map[string]validator.GoGlobalState{name: end, referenceName: referenceEnd}
+		m.report(event)
+	}
+}
+
+// report logs an event and queues it for the webhook, dropping it if the webhook is behind.
+func (m *DivergenceMonitor) report(event *DivergenceEvent) {
+	log.Error("validation divergence", "kind", event.Kind, "moduleRoot", event.ModuleRoot, "inputId", event.InputId, "spawner", event.Spawner, "results", event.Results, "err", event.Error)
+	if m.config().WebhookURL == "" {
+		return
+	}
+	select {
+	case m.queue <- event:
+	default:
+		divergenceDroppedCounter.Inc(1)
+	}
+}
+
+func (m *DivergenceMonitor) post(ctx context.Context, event *DivergenceEvent) {
+	config := m.config()
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error("failed to encode divergence event", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.WebhookTimeout)
+	defer cancel()
+	err = func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+		}
+		return nil
+	}()
+	if err != nil {
+		divergenceWebhookFailureCounter.Inc(1)
+		log.Warn("failed to post divergence event", "kind", event.Kind, "inputId", event.InputId, "err", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package valnode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/server_common"
+)
+
+type mockDivergenceSpawner struct {
+	name   string
+	arch   ethdb.WasmTarget
+	result func(*validator.ValidationInput) (validator.GoGlobalState, error)
+}
+
+func (s *mockDivergenceSpawner) Launch(entry *validator.ValidationInput, moduleRoot common.Hash) validator.ValidationRun {
+	return server_common.NewValRun(containers.NewReadyPromise(s.result(entry)), moduleRoot)
+}
+
+func (s *mockDivergenceSpawner) WasmModuleRoots() ([]common.Hash, error) { return nil, nil }
+func (s *mockDivergenceSpawner) Start(context.Context) error             { return nil }
+func (s *mockDivergenceSpawner) Stop()                                   {}
+func (s *mockDivergenceSpawner) Name() string                            { return s.name }
+func (s *mockDivergenceSpawner) StylusArchs() []ethdb.WasmTarget         { return []ethdb.WasmTarget{s.arch} }
+func (s *mockDivergenceSpawner) Room() int                               { return 1 }
+
+func TestDivergenceMonitor(t *testing.T) {
+	events := make(chan *DivergenceEvent, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event DivergenceEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		events <- &event
+	}))
+	defer server.Close()
+
+	// The jit disagrees on input 1, traps on input 2, and can't load its machine for input 3
+	jit := &mockDivergenceSpawner{name: "jit", arch: "local", result: func(entry *validator.ValidationInput) (validator.GoGlobalState, error) {
+		switch entry.Id {
+		case 2:
+			return validator.GoGlobalState{}, fmt.Errorf("%w: unreachable", server_common.ErrMachineTrap)
+		case 3:
+			return validator.GoGlobalState{}, fmt.Errorf("%w: bad binary", server_common.ErrMachineLoad)
+		}
+		return validator.GoGlobalState{Batch: entry.Id}, nil
+	}}
+	arbitrator := &mockDivergenceSpawner{name: "arbitrator", arch: "wavm", result: func(entry *validator.ValidationInput) (validator.GoGlobalState, error) {
+		if entry.Id == 1 {
+			return validator.GoGlobalState{Batch: 100}, nil
+		}
+		return validator.GoGlobalState{Batch: entry.Id}, nil
+	}}
+
+	config := DefaultDivergenceMonitorConfig
+	config.Enable = true
+	config.CrossCheckRate = 1
+	config.WebhookURL = server.URL
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	monitor := NewDivergenceMonitor(jit, arbitrator, func() *DivergenceMonitorConfig { return &config })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := monitor.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer monitor.Stop()
+
+	if archs := monitor.StylusArchs(); len(archs) != 2 {
+		t.Fatal("inputs wouldn't include the arbitrator's programs", archs)
+	}
+	for id := uint64(0); id <= 3; id++ {
+		end, err := monitor.Launch(&validator.ValidationInput{Id: id}, common.Hash{}).Await(ctx)
+		if id < 2 && (err != nil || end.Batch != id) {
+			t.Fatal("monitor changed the jit's result", id, end, err)
+		}
+	}
+
+	expected := map[uint64]DivergenceKind{1: DivergenceState, 2: DivergenceTrap, 3: DivergenceCompileFailure}
+	for len(expected) > 0 {
+		select {
+		case event := <-events:
+			if expected[event.InputId] != event.Kind {
+				t.Fatal("unexpected event", event)
+			}
+			if event.Kind == DivergenceState && (event.Results["jit"].Batch != 1 || event.Results["arbitrator"].Batch != 100) {
+				t.Fatal("unexpected results", event.Results)
+			}
+			if event.Kind != DivergenceState && event.Spawner != "jit" {
+				t.Fatal("unexpected spawner", event.Spawner)
+			}
+			delete(expected, event.InputId)
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing events", expected)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatal("unexpected event", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
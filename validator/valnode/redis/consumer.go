@@ -23,7 +23,7 @@ type ValidationServer struct {
 	spawner validator.ValidationSpawner
 
 	// consumers stores moduleRoot to consumer mapping.
-	consumers map[common.Hash]*pubsub.Consumer[*validator.ValidationInput, validator.GoGlobalState]
+	consumers map[common.Hash]pubsub.RequestConsumer[*validator.ValidationInput, validator.GoGlobalState]
 
 	config *ValidationServerConfig
 }
@@ -36,7 +36,7 @@ func NewValidationServer(cfg *ValidationServerConfig, spawner validator.Validati
 	if err != nil {
 		return nil, err
 	}
-	consumers := make(map[common.Hash]*pubsub.Consumer[*validator.ValidationInput, validator.GoGlobalState])
+	consumers := make(map[common.Hash]pubsub.RequestConsumer[*validator.ValidationInput, validator.GoGlobalState])
 	for _, hash := range cfg.ModuleRoots {
 		mr := common.HexToHash(hash)
 		c, err := pubsub.NewConsumer[*validator.ValidationInput, validator.GoGlobalState](redisClient, server_api.RedisStreamForRoot(cfg.StreamPrefix, mr), &cfg.ConsumerConfig)
@@ -82,7 +82,7 @@ func (s *ValidationServer) Start(ctx_in context.Context) {
 		ready := make(chan struct{}, 1)
 		s.StopWaiter.LaunchThread(func(ctx context.Context) {
 			for {
-				if pubsub.StreamExists(ctx, c.StreamName(), c.RedisClient()) {
+				if c.Ready(ctx) {
 					ready <- struct{}{}
 					readyStreams <- struct{}{}
 					return
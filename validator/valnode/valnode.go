@@ -41,6 +41,7 @@ type Config struct {
 	Arbitrator server_arb.ArbitratorSpawnerConfig `koanf:"arbitrator" reload:"hot"`
 	Jit        server_jit.JitSpawnerConfig        `koanf:"jit" reload:"hot"`
 	Wasm       WasmConfig                         `koanf:"wasm"`
+	Divergence DivergenceMonitorConfig            `koanf:"divergence-monitor" reload:"hot"`
 }
 
 type ValidationConfigFetcher func() *Config
@@ -52,6 +53,7 @@ var DefaultValidationConfig = Config{
 	ApiPublic:  false,
 	Arbitrator: server_arb.DefaultArbitratorSpawnerConfig,
 	Wasm:       DefaultWasmConfig,
+	Divergence: DefaultDivergenceMonitorConfig,
 }
 
 var TestValidationConfig = Config{
@@ -61,6 +63,7 @@ var TestValidationConfig = Config{
 	ApiPublic:  true,
 	Arbitrator: server_arb.DefaultArbitratorSpawnerConfig,
 	Wasm:       DefaultWasmConfig,
+	Divergence: DefaultDivergenceMonitorConfig,
 }
 
 func ValidationConfigAddOptions(prefix string, f *pflag.FlagSet) {
@@ -70,12 +73,14 @@ func ValidationConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	server_arb.ArbitratorSpawnerConfigAddOptions(prefix+".arbitrator", f)
 	server_jit.JitSpawnerConfigAddOptions(prefix+".jit", f)
 	WasmConfigAddOptions(prefix+".wasm", f)
+	DivergenceMonitorConfigAddOptions(prefix+".divergence-monitor", f)
 }
 
 type ValidationNode struct {
 	config     ValidationConfigFetcher
 	arbSpawner *server_arb.ArbitratorSpawner
 	jitSpawner *server_jit.JitSpawner
+	divergence *DivergenceMonitor
 
 	redisConsumer *redis.ValidationServer
 }
@@ -106,7 +111,7 @@ func CreateValidationNode(configFetcher ValidationConfigFetcher, stack *node.Nod
 	if err != nil {
 		return nil, err
 	}
-	var serverAPI *ExecServerAPI
+	var valSpawner validator.ValidationSpawner = arbSpawner
 	var jitSpawner *server_jit.JitSpawner
 	if config.UseJit {
 		jitConfigFetcher := func() *server_jit.JitSpawnerConfig { return &configFetcher().Jit }
@@ -115,10 +120,19 @@ func CreateValidationNode(configFetcher ValidationConfigFetcher, stack *node.Nod
 		if err != nil {
 			return nil, err
 		}
-		serverAPI = NewExecutionServerAPI(jitSpawner, arbSpawner, arbConfigFetcher)
-	} else {
-		serverAPI = NewExecutionServerAPI(arbSpawner, arbSpawner, arbConfigFetcher)
+		valSpawner = jitSpawner
 	}
+	var divergence *DivergenceMonitor
+	if config.Divergence.Enable {
+		divergenceConfigFetcher := func() *DivergenceMonitorConfig { return &configFetcher().Divergence }
+		var reference validator.ValidationSpawner
+		if jitSpawner != nil {
+			reference = arbSpawner
+		}
+		divergence = NewDivergenceMonitor(valSpawner, reference, divergenceConfigFetcher)
+		valSpawner = divergence
+	}
+	serverAPI := NewExecutionServerAPI(valSpawner, arbSpawner, arbConfigFetcher)
 	var redisConsumer *redis.ValidationServer
 	redisValidationConfig := arbConfigFetcher().RedisValidationServerConfig
 	if redisValidationConfig.Enabled() {
@@ -136,7 +150,7 @@ func CreateValidationNode(configFetcher ValidationConfigFetcher, stack *node.Nod
 	}}
 	stack.RegisterAPIs(valAPIs)
 
-	return &ValidationNode{configFetcher, arbSpawner, jitSpawner, redisConsumer}, nil
+	return &ValidationNode{configFetcher, arbSpawner, jitSpawner, divergence, redisConsumer}, nil
 }
 
 func (v *ValidationNode) Start(ctx context.Context) error {
@@ -148,6 +162,11 @@ func (v *ValidationNode) Start(ctx context.Context) error {
 			return err
 		}
 	}
+	if v.divergence != nil {
+		if err := v.divergence.Start(ctx); err != nil {
+			return err
+		}
+	}
 	if v.redisConsumer != nil {
 		v.redisConsumer.Start(ctx)
 	}
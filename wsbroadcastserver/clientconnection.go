@@ -27,8 +27,10 @@ import (
 var errContextDone = errors.New("context done")
 
 type message struct {
-	data           []byte
-	sequenceNumber *arbutil.MessageIndex
+	data *frame
+	// The first and last feed messages in the frame, if it has any
+	sequenceNumber     *arbutil.MessageIndex
+	lastSequenceNumber *arbutil.MessageIndex
 }
 
 type ClientConnectionAction struct {
@@ -60,6 +62,9 @@ type ClientConnection struct {
 	compression bool
 	flateReader *wsflate.Reader
 
+	protocolVersion       int
+	batchCompressionLevel uint64
+
 	delay time.Duration
 }
 
@@ -70,25 +75,29 @@ func NewClientConnection(
 	requestedSeqNum arbutil.MessageIndex,
 	connectingIP net.IP,
 	compression bool,
+	protocolVersion int,
+	batchCompressionLevel uint64,
 	maxSendQueue int,
 	delay time.Duration,
 	bklg backlog.Backlog,
 ) *ClientConnection {
 	clientConnection := &ClientConnection{
-		conn:            conn,
-		clientIp:        connectingIP,
-		desc:            desc,
-		creation:        time.Now(),
-		Name:            fmt.Sprintf("%s@%s-%d", connectingIP, conn.RemoteAddr(), rand.Intn(10)),
-		clientAction:    clientAction,
-		requestedSeqNum: requestedSeqNum,
-		out:             make(chan message, maxSendQueue),
-		compression:     compression,
-		flateReader:     NewFlateReader(),
-		delay:           delay,
-		backlog:         bklg,
-		registered:      make(chan bool, 1),
-		backlogSent:     false,
+		conn:                  conn,
+		clientIp:              connectingIP,
+		desc:                  desc,
+		creation:              time.Now(),
+		Name:                  fmt.Sprintf("%s@%s-%d", connectingIP, conn.RemoteAddr(), rand.Intn(10)),
+		clientAction:          clientAction,
+		requestedSeqNum:       requestedSeqNum,
+		out:                   make(chan message, maxSendQueue),
+		compression:           compression,
+		flateReader:           NewFlateReader(),
+		protocolVersion:       protocolVersion,
+		batchCompressionLevel: batchCompressionLevel,
+		delay:                 delay,
+		backlog:               bklg,
+		registered:            make(chan bool, 1),
+		backlogSent:           false,
 	}
 	clientConnection.lastHeardUnix.Store(time.Now().Unix())
 	return clientConnection
@@ -102,6 +111,11 @@ func (cc *ClientConnection) Compression() bool {
 	return cc.compression
 }
 
+// ProtocolVersion returns the feed protocol version negotiated with the client.
+func (cc *ClientConnection) ProtocolVersion() int {
+	return cc.protocolVersion
+}
+
 // Register sends the ClientConnection to be registered with the ClientManager.
 func (cc *ClientConnection) Register() {
 	cc.clientAction <- ClientConnectionAction{
@@ -166,22 +180,25 @@ func (cc *ClientConnection) writeBacklog(ctx context.Context, segment backlog.Ba
 }
 
 func (cc *ClientConnection) writeBroadcastMessage(bm *m.BroadcastMessage) error {
+	if cc.protocolVersion >= FeedProtocolV2 {
+		data, err := serializeBatch(bm, cc.batchCompressionLevel)
+		if err != nil {
+			return err
+		}
+		defer data.release()
+		return cc.writeRaw(data.Bytes())
+	}
 	notCompressed, compressed, err := serializeMessage(bm, !cc.compression, cc.compression)
 	if err != nil {
 		return err
 	}
 
-	var data []byte
+	data := notCompressed
 	if cc.compression {
-		data = compressed.Bytes()
-	} else {
-		data = notCompressed.Bytes()
-	}
-	err = cc.writeRaw(data)
-	if err != nil {
-		return err
+		data = compressed
 	}
-	return nil
+	defer data.release()
+	return cc.writeRaw(data.Bytes())
 }
 
 func (cc *ClientConnection) Start(parentCtx context.Context) {
@@ -239,8 +256,9 @@ func (cc *ClientConnection) Start(parentCtx context.Context) {
 			case <-ctx.Done():
 				return
 			case msg := <-cc.out:
-				if msg.sequenceNumber != nil && uint64(*msg.sequenceNumber) <= cc.LastSentSeqNum.Load() {
-					log.Debug("client has already sent message with this sequence number, skipping the message", "client", cc.Name, "sequence number", *msg.sequenceNumber)
+				if msg.lastSequenceNumber != nil && uint64(*msg.lastSequenceNumber) <= cc.LastSentSeqNum.Load() {
+					log.Debug("client has already sent message with this sequence number, skipping the message", "client", cc.Name, "sequence number", *msg.lastSequenceNumber)
+					msg.data.release()
 					continue
 				}
 
@@ -262,7 +280,8 @@ func (cc *ClientConnection) Start(parentCtx context.Context) {
 				}
 				cc.backlogSent = true
 
-				err := cc.writeRaw(msg.data)
+				err := cc.writeRaw(msg.data.Bytes())
+				msg.data.release()
 				if err != nil {
 					logWarn(err, "error writing data to client")
 					cc.Remove()
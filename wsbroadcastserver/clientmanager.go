@@ -4,20 +4,13 @@
 package wsbroadcastserver
 
 import (
-	"bytes"
-	"compress/flate"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"strings"
 	"sync/atomic"
 	"time"
 
-	"github.com/gobwas/ws"
 	"github.com/gobwas/ws-examples/src/gopool"
-	"github.com/gobwas/ws/wsflate"
-	"github.com/gobwas/ws/wsutil"
 	"github.com/mailru/easygo/netpoll"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -37,6 +30,9 @@ var (
 	clientsTotalFailedUpgradeCounter = metrics.NewRegisteredCounter("arb/feed/clients/failed/upgrade", nil)
 	clientsTotalFailedWorkerCounter  = metrics.NewRegisteredCounter("arb/feed/clients/failed/worker", nil)
 	clientsDurationHistogram         = metrics.NewRegisteredHistogram("arb/feed/clients/duration", nil, metrics.NewBoundedHistogramSample())
+	clientsProtocolV2Gauge           = metrics.NewRegisteredGauge("arb/feed/clients/protocolv2", nil)
+	batchesSentCounter               = metrics.NewRegisteredCounter("arb/feed/batches/sent", nil)
+	batchSizeHistogram               = metrics.NewRegisteredHistogram("arb/feed/batches/size", nil, metrics.NewBoundedHistogramSample())
 )
 
 // ClientManager manages client connections
@@ -52,6 +48,10 @@ type ClientManager struct {
 	config        BroadcasterConfigFetcher
 	backlog       backlog.Backlog
 
+	// Only accessed by the main ClientManager thread
+	protocolV2Clients int
+	pendingBatch      *m.BroadcastMessage
+
 	connectionLimiter *ConnectionLimiter
 }
 
@@ -88,6 +88,10 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 	cm.clientCount.Add(1)
 	cm.clientPtrMap[clientConnection] = true
 	clientsTotalSuccessCounter.Inc(1)
+	if clientConnection.ProtocolVersion() >= FeedProtocolV2 {
+		cm.protocolV2Clients++
+		clientsProtocolV2Gauge.Inc(1)
+	}
 
 	return nil
 }
@@ -129,6 +133,10 @@ func (cm *ClientManager) removeClient(clientConnection *ClientConnection) {
 	}
 
 	cm.removeClientImpl(clientConnection)
+	if clientConnection.ProtocolVersion() >= FeedProtocolV2 {
+		cm.protocolV2Clients--
+		clientsProtocolV2Gauge.Dec(1)
+	}
 	if cm.config().ConnectionLimits.Enable {
 		cm.connectionLimiter.Release(clientConnection.clientIp)
 	}
@@ -156,22 +164,36 @@ func (cm *ClientManager) doBroadcast(bm *m.BroadcastMessage) ([]*ClientConnectio
 		return nil, err
 	}
 	config := cm.config()
-	//                                        /-> wsutil.Writer -> not compressed msg buffer
-	// bm -> json.Encoder -> io.MultiWriter -|
-	//                                        \-> flateWriter -> wsutil.Writer -> compressed msg buffer
 
+	var seqNum *arbutil.MessageIndex
+	n := len(bm.Messages)
+	if n == 0 {
+		seqNum = nil
+	} else if n == 1 {
+		seqNum = &bm.Messages[0].SequenceNumber
+	} else {
+		return nil, fmt.Errorf("doBroadcast was sent %d BroadcastFeedMessages, it can only parse 1 BroadcastFeedMessage at a time", n)
+	}
+
+	// The message is encoded once, and the same frames are queued for every client
 	notCompressed, compressed, err := serializeMessage(bm, !config.RequireCompression, config.EnableCompression)
 	if err != nil {
 		return nil, err
 	}
+	defer notCompressed.release()
+	defer compressed.release()
 
 	sendQueueTooLargeCount := 0
 	clientDeleteList := make([]*ClientConnection, 0, len(cm.clientPtrMap))
 	for client := range cm.clientPtrMap {
-		var data []byte
+		if client.ProtocolVersion() >= FeedProtocolV2 {
+			// Sent in batches instead
+			continue
+		}
+		var data *frame
 		if client.Compression() {
 			if config.EnableCompression {
-				data = compressed.Bytes()
+				data = compressed
 			} else {
 				log.Warn("disconnecting because client has enabled compression, but compression support is disabled", "client", client.Name)
 				clientDeleteList = append(clientDeleteList, client)
@@ -179,7 +201,7 @@ func (cm *ClientManager) doBroadcast(bm *m.BroadcastMessage) ([]*ClientConnectio
 			}
 		} else {
 			if !config.RequireCompression {
-				data = notCompressed.Bytes()
+				data = notCompressed
 			} else {
 				log.Warn("disconnecting because client has disabled compression, but compression support is required", "client", client.Name)
 				clientDeleteList = append(clientDeleteList, client)
@@ -187,24 +209,17 @@ func (cm *ClientManager) doBroadcast(bm *m.BroadcastMessage) ([]*ClientConnectio
 			}
 		}
 
-		var seqNum *arbutil.MessageIndex
-		n := len(bm.Messages)
-		if n == 0 {
-			seqNum = nil
-		} else if n == 1 {
-			seqNum = &bm.Messages[0].SequenceNumber
-		} else {
-			return nil, fmt.Errorf("doBroadcast was sent %d BroadcastFeedMessages, it can only parse 1 BroadcastFeedMessage at a time", n)
-		}
-
+		data.retain()
 		m := message{
-			sequenceNumber: seqNum,
-			data:           data,
+			sequenceNumber:     seqNum,
+			lastSequenceNumber: seqNum,
+			data:               data,
 		}
 		select {
 		case client.out <- m:
 		default:
 			// Queue for client too backed up, disconnect instead of blocking on channel send
+			data.release()
 			sendQueueTooLargeCount++
 			clientDeleteList = append(clientDeleteList, client)
 		}
@@ -221,49 +236,62 @@ func (cm *ClientManager) doBroadcast(bm *m.BroadcastMessage) ([]*ClientConnectio
 	return clientDeleteList, nil
 }
 
-func serializeMessage(bm *m.BroadcastMessage, enableNonCompressedOutput, enableCompressedOutput bool) (bytes.Buffer, bytes.Buffer, error) {
-	flateWriter, err := flate.NewWriterDict(nil, DeflateCompressionLevel, GetStaticCompressorDictionary())
-	if err != nil {
-		return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to create flate writer: %w", err)
+// addToBatch queues the contents of bm to be sent to protocol version 2 clients.
+func (cm *ClientManager) addToBatch(bm *m.BroadcastMessage) {
+	if cm.protocolV2Clients == 0 {
+		return
 	}
-
-	var notCompressed bytes.Buffer
-	var compressed bytes.Buffer
-	writers := []io.Writer{}
-	var notCompressedWriter *wsutil.Writer
-	var compressedWriter *wsutil.Writer
-	if enableNonCompressedOutput {
-		notCompressedWriter = wsutil.NewWriter(&notCompressed, ws.StateServerSide, ws.OpText)
-		writers = append(writers, notCompressedWriter)
+	if cm.pendingBatch == nil {
+		cm.pendingBatch = &m.BroadcastMessage{Version: bm.Version}
+	}
+	cm.pendingBatch.Messages = append(cm.pendingBatch.Messages, bm.Messages...)
+	if bm.ConfirmedSequenceNumberMessage != nil {
+		cm.pendingBatch.ConfirmedSequenceNumberMessage = bm.ConfirmedSequenceNumberMessage
 	}
-	if enableCompressedOutput {
-		compressedWriter = wsutil.NewWriter(&compressed, ws.StateServerSide|ws.StateExtended, ws.OpText)
-		var msg wsflate.MessageState
-		msg.SetCompressed(true)
-		compressedWriter.SetExtensions(&msg)
-		flateWriter.Reset(compressedWriter)
-		writers = append(writers, flateWriter)
+	if bm.SequencerAuditMessage != nil {
+		cm.pendingBatch.SequencerAuditMessage = bm.SequencerAuditMessage
 	}
+}
 
-	multiWriter := io.MultiWriter(writers...)
-	encoder := json.NewEncoder(multiWriter)
-	if err := encoder.Encode(bm); err != nil {
-		return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to encode message: %w", err)
+// flushBatch sends the queued batch to protocol version 2 clients, returning the clients to disconnect.
+func (cm *ClientManager) flushBatch() []*ClientConnection {
+	bm := cm.pendingBatch
+	cm.pendingBatch = nil
+	if bm == nil || cm.protocolV2Clients == 0 {
+		return nil
 	}
-	if notCompressedWriter != nil {
-		if err := notCompressedWriter.Flush(); err != nil {
-			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to flush message: %w", err)
-		}
+	data, err := serializeBatch(bm, cm.config().ProtocolV2.CompressionLevel)
+	if err != nil {
+		logError(err, "failed to serialize feed batch")
+		return nil
+	}
+	defer data.release()
+	batchesSentCounter.Inc(1)
+	batchSizeHistogram.Update(int64(len(bm.Messages)))
+
+	var first, last *arbutil.MessageIndex
+	if n := len(bm.Messages); n > 0 {
+		first = &bm.Messages[0].SequenceNumber
+		last = &bm.Messages[n-1].SequenceNumber
 	}
-	if compressedWriter != nil {
-		if err := flateWriter.Close(); err != nil {
-			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to close flate writer: %w", err)
+	var clientDeleteList []*ClientConnection
+	for client := range cm.clientPtrMap {
+		if client.ProtocolVersion() < FeedProtocolV2 {
+			continue
 		}
-		if err := compressedWriter.Flush(); err != nil {
-			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to flush message: %w", err)
+		data.retain()
+		select {
+		case client.out <- message{data: data, sequenceNumber: first, lastSequenceNumber: last}:
+		default:
+			// Queue for client too backed up, disconnect instead of blocking on channel send
+			data.release()
+			clientDeleteList = append(clientDeleteList, client)
 		}
 	}
-	return notCompressed, compressed, nil
+	if len(clientDeleteList) > 0 {
+		log.Warn("disconnecting protocol version 2 clients because send queue too large", "count", len(clientDeleteList))
+	}
+	return clientDeleteList
 }
 
 // verifyClients should be called every cm.config.ClientPingInterval
@@ -302,6 +330,8 @@ func (cm *ClientManager) Start(parentCtx context.Context) {
 		pingTimer := time.NewTimer(cm.config().Ping)
 		var clientDeleteList []*ClientConnection
 		defer pingTimer.Stop()
+		// Fires once the pending batch has waited long enough, or nil if nothing is pending
+		var batchDeadline <-chan time.Time
 		for {
 			select {
 			case <-ctx.Done():
@@ -332,12 +362,23 @@ func (cm *ClientManager) Start(parentCtx context.Context) {
 					logError(err, "failed to do broadcast")
 				}
 
-				// A message with ConfirmedSequenceNumberMessage could be sent without any messages
-				// this section ensures that message is still sent.
+				// A message with ConfirmedSequenceNumberMessage or SequencerAuditMessage could be sent
+				// without any messages, this section ensures that message is still sent.
 				if len(bm.Messages) == 0 {
 					clientDeleteList, err = cm.doBroadcast(bm)
 					logError(err, "failed to do broadcast")
 				}
+
+				cm.addToBatch(bm)
+				if cm.pendingBatch != nil && len(cm.pendingBatch.Messages) >= cm.config().ProtocolV2.MaxBatchSize {
+					clientDeleteList = append(clientDeleteList, cm.flushBatch()...)
+					batchDeadline = nil
+				} else if cm.pendingBatch != nil && batchDeadline == nil {
+					batchDeadline = time.After(cm.config().ProtocolV2.FlushInterval)
+				}
+			case <-batchDeadline:
+				batchDeadline = nil
+				clientDeleteList = cm.flushBatch()
 			case <-pingTimer.C:
 				clientDeleteList = cm.verifyClients()
 				pingTimer.Reset(cm.config().Ping)
@@ -0,0 +1,166 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsflate"
+	"github.com/gobwas/ws/wsutil"
+
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+)
+
+// Buffers which grew larger than this, e.g. for a big backlog segment, aren't pooled
+const maxPooledFrameSize = 1 << 20
+
+var (
+	frameBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	serializerPool  = sync.Pool{New: func() any { return newMessageSerializer() }}
+)
+
+// frame is a serialized websocket message, shared by every client it's sent to.
+// Its buffer goes back to the pool once the last reference is released.
+type frame struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+func newFrame() *frame {
+	buf, _ := frameBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	f := &frame{buf: buf}
+	f.refs.Store(1)
+	return f
+}
+
+func (f *frame) Bytes() []byte {
+	return f.buf.Bytes()
+}
+
+// retain adds a reference, which must be released once the frame has been written.
+func (f *frame) retain() {
+	f.refs.Add(1)
+}
+
+func (f *frame) release() {
+	if f == nil {
+		return
+	}
+	refs := f.refs.Add(-1)
+	if refs == 0 {
+		if f.buf.Cap() <= maxPooledFrameSize {
+			frameBufferPool.Put(f.buf)
+		}
+		f.buf = nil
+	} else if refs < 0 {
+		panic("websocket frame released too many times")
+	}
+}
+
+// messageSerializer holds everything needed to serialize a message, so it can be reused between messages.
+type messageSerializer struct {
+	json        bytes.Buffer
+	encoder     *json.Encoder
+	wsWriter    *wsutil.Writer
+	flateWriter *flate.Writer
+	extensions  []wsutil.SendExtension
+}
+
+func newMessageSerializer() *messageSerializer {
+	s := &messageSerializer{}
+	s.encoder = json.NewEncoder(&s.json)
+	s.wsWriter = wsutil.NewWriter(nil, ws.StateServerSide, ws.OpText)
+	var msg wsflate.MessageState
+	msg.SetCompressed(true)
+	s.extensions = []wsutil.SendExtension{&msg}
+	return s
+}
+
+// serializeMessage encodes bm once and frames it without compression, with compression, or both.
+// The frames that aren't nil must be released by the caller.
+func serializeMessage(bm *m.BroadcastMessage, enableNonCompressedOutput, enableCompressedOutput bool) (*frame, *frame, error) {
+	s, _ := serializerPool.Get().(*messageSerializer)
+	defer serializerPool.Put(s)
+	return s.serialize(bm, enableNonCompressedOutput, enableCompressedOutput)
+}
+
+func (s *messageSerializer) serialize(bm *m.BroadcastMessage, enableNonCompressedOutput, enableCompressedOutput bool) (*frame, *frame, error) {
+	s.json.Reset()
+	if err := s.encoder.Encode(bm); err != nil {
+		return nil, nil, fmt.Errorf("unable to encode message: %w", err)
+	}
+	var notCompressed, compressed *frame
+	if enableNonCompressedOutput {
+		notCompressed = newFrame()
+		s.wsWriter.Reset(notCompressed.buf, ws.StateServerSide, ws.OpText)
+		if _, err := s.wsWriter.Write(s.json.Bytes()); err != nil {
+			notCompressed.release()
+			return nil, nil, fmt.Errorf("unable to write message: %w", err)
+		}
+		if err := s.wsWriter.Flush(); err != nil {
+			notCompressed.release()
+			return nil, nil, fmt.Errorf("unable to flush message: %w", err)
+		}
+	}
+	if enableCompressedOutput {
+		compressed = newFrame()
+		if err := s.writeCompressed(compressed); err != nil {
+			notCompressed.release()
+			compressed.release()
+			return nil, nil, err
+		}
+	}
+	return notCompressed, compressed, nil
+}
+
+// serializeBatch encodes bm as a protocol version 2 binary frame, which must be released by the caller.
+func serializeBatch(bm *m.BroadcastMessage, compressionLevel uint64) (*frame, error) {
+	data, err := m.EncodeBatch(bm, compressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode batch: %w", err)
+	}
+	f := newFrame()
+	header := ws.Header{
+		Fin:    true,
+		OpCode: ws.OpBinary,
+		Length: int64(len(data)),
+	}
+	if err := ws.WriteHeader(f.buf, header); err != nil {
+		f.release()
+		return nil, fmt.Errorf("unable to write batch header: %w", err)
+	}
+	f.buf.Write(data)
+	return f, nil
+}
+
+func (s *messageSerializer) writeCompressed(f *frame) error {
+	s.wsWriter.Reset(f.buf, ws.StateServerSide|ws.StateExtended, ws.OpText)
+	s.wsWriter.SetExtensions(s.extensions...)
+	if s.flateWriter == nil {
+		flateWriter, err := flate.NewWriterDict(s.wsWriter, DeflateCompressionLevel, GetStaticCompressorDictionary())
+		if err != nil {
+			return fmt.Errorf("unable to create flate writer: %w", err)
+		}
+		s.flateWriter = flateWriter
+	} else {
+		s.flateWriter.Reset(s.wsWriter)
+	}
+	if _, err := s.flateWriter.Write(s.json.Bytes()); err != nil {
+		return fmt.Errorf("unable to compress message: %w", err)
+	}
+	if err := s.flateWriter.Close(); err != nil {
+		return fmt.Errorf("unable to close flate writer: %w", err)
+	}
+	if err := s.wsWriter.Flush(); err != nil {
+		return fmt.Errorf("unable to flush message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/gobwas/ws"
+
+	m "github.com/offchainlabs/nitro/broadcaster/message"
+)
+
+func readFramePayload(t *testing.T, data []byte) (ws.Header, []byte) {
+	t.Helper()
+	r := bytes.NewReader(data)
+	header, err := ws.ReadHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(payload)) != header.Length {
+		t.Fatalf("frame payload is %d bytes but header says %d", len(payload), header.Length)
+	}
+	return header, payload
+}
+
+func TestSerializeMessage(t *testing.T) {
+	bm := &m.BroadcastMessage{Version: m.V1}
+	expected, err := json.Marshal(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, '\n')
+
+	// Serialize several times so pooled buffers and writers are reused
+	var previous []byte
+	for i := 0; i < 3; i++ {
+		notCompressed, compressed, err := serializeMessage(bm, true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header, payload := readFramePayload(t, notCompressed.Bytes())
+		if header.Rsv1() || !bytes.Equal(payload, expected) {
+			t.Fatalf("unexpected uncompressed frame %q", payload)
+		}
+
+		header, payload = readFramePayload(t, compressed.Bytes())
+		if !header.Rsv1() {
+			t.Fatal("compressed frame doesn't have the compression bit set")
+		}
+		decompressed, err := io.ReadAll(flate.NewReaderDict(bytes.NewReader(payload), GetStaticCompressorDictionary()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decompressed, expected) {
+			t.Fatalf("unexpected decompressed frame %q", decompressed)
+		}
+		if previous != nil && !bytes.Equal(previous, compressed.Bytes()) {
+			t.Fatal("reused serializer produced a different compressed frame")
+		}
+		previous = bytes.Clone(compressed.Bytes())
+
+		compressed.retain()
+		compressed.release()
+		notCompressed.release()
+		compressed.release()
+	}
+}
+
+func BenchmarkSerializeMessage(b *testing.B) {
+	bm := &m.BroadcastMessage{Version: m.V1}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		notCompressed, compressed, err := serializeMessage(bm, true, true)
+		if err != nil {
+			b.Fatal(err)
+		}
+		notCompressed.release()
+		compressed.release()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/textproto"
@@ -24,9 +25,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/offchainlabs/nitro/arbcompress"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcaster/backlog"
 	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
 )
 
 var (
@@ -35,6 +38,8 @@ var (
 	HTTPHeaderFeedClientVersion       = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Client-Version")
 	HTTPHeaderRequestedSequenceNumber = textproto.CanonicalMIMEHeaderKey("Arbitrum-Requested-Sequence-Number")
 	HTTPHeaderChainId                 = textproto.CanonicalMIMEHeaderKey("Arbitrum-Chain-Id")
+	HTTPHeaderFeedMaxProtocolVersion  = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Max-Protocol-Version")
+	HTTPHeaderFeedProtocolVersion     = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Protocol-Version")
 	upgradeToWSTimer                  = metrics.NewRegisteredTimer("arb/feed/clients/upgrade/duration", nil)
 	startWithHeaderTimer              = metrics.NewRegisteredTimer("arb/feed/clients/start/duration", nil)
 )
@@ -45,6 +50,48 @@ const (
 	LivenessProbeURI  = "livenessprobe"
 )
 
+// Feed protocol versions. Version 1 sends each message as its own JSON text frame, while version 2
+// sends batches of messages as brotli compressed binary frames. Clients ask for version 2 with the
+// Arbitrum-Feed-Max-Protocol-Version header, and servers that accept say so in Arbitrum-Feed-Protocol-Version.
+const (
+	FeedProtocolV1 = 1
+	FeedProtocolV2 = 2
+)
+
+type ProtocolV2Config struct {
+	Enable           bool          `koanf:"enable" reload:"hot"` // reloading will affect only new connections
+	MaxBatchSize     int           `koanf:"max-batch-size" reload:"hot"`
+	FlushInterval    time.Duration `koanf:"flush-interval" reload:"hot"`
+	CompressionLevel uint64        `koanf:"compression-level" reload:"hot"`
+}
+
+func ProtocolV2ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultProtocolV2Config.Enable, "send batched, brotli compressed frames to clients supporting feed protocol version 2")
+	f.Int(prefix+".max-batch-size", DefaultProtocolV2Config.MaxBatchSize, "the maximum number of messages in each protocol version 2 frame")
+	f.Duration(prefix+".flush-interval", DefaultProtocolV2Config.FlushInterval, "the longest a message waits for its protocol version 2 frame to fill up before it's sent")
+	f.Uint64(prefix+".compression-level", DefaultProtocolV2Config.CompressionLevel, "brotli compression level for protocol version 2 frames")
+}
+
+func (c *ProtocolV2Config) Validate() error {
+	if c.MaxBatchSize <= 0 {
+		return errors.New("protocol-v2 max-batch-size must be positive")
+	}
+	if c.FlushInterval <= 0 {
+		return errors.New("protocol-v2 flush-interval must be positive")
+	}
+	if c.CompressionLevel > arbcompress.LEVEL_WELL {
+		return fmt.Errorf("protocol-v2 compression-level %v above the maximum of %v", c.CompressionLevel, arbcompress.LEVEL_WELL)
+	}
+	return nil
+}
+
+var DefaultProtocolV2Config = ProtocolV2Config{
+	Enable:           false,
+	MaxBatchSize:     64,
+	FlushInterval:    20 * time.Millisecond,
+	CompressionLevel: 5,
+}
+
 type BroadcasterConfig struct {
 	Enable             bool                    `koanf:"enable"`
 	Signed             bool                    `koanf:"signed"`
@@ -69,13 +116,14 @@ type BroadcasterConfig struct {
 	ConnectionLimits   ConnectionLimiterConfig `koanf:"connection-limits" reload:"hot"`
 	ClientDelay        time.Duration           `koanf:"client-delay" reload:"hot"`
 	Backlog            backlog.Config          `koanf:"backlog" reload:"hot"`
+	ProtocolV2         ProtocolV2Config        `koanf:"protocol-v2" reload:"hot"`
 }
 
 func (bc *BroadcasterConfig) Validate() error {
 	if !bc.EnableCompression && bc.RequireCompression {
 		return errors.New("require-compression cannot be true while enable-compression is false")
 	}
-	return nil
+	return bc.ProtocolV2.Validate()
 }
 
 type BroadcasterConfigFetcher func() *BroadcasterConfig
@@ -104,6 +152,7 @@ func BroadcasterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	ConnectionLimiterConfigAddOptions(prefix+".connection-limits", f)
 	f.Duration(prefix+".client-delay", DefaultBroadcasterConfig.ClientDelay, "delay the first messages sent to each client by this amount")
 	backlog.AddOptions(prefix+".backlog", f)
+	ProtocolV2ConfigAddOptions(prefix+".protocol-v2", f)
 }
 
 var DefaultBroadcasterConfig = BroadcasterConfig{
@@ -130,6 +179,7 @@ var DefaultBroadcasterConfig = BroadcasterConfig{
 	ConnectionLimits:   DefaultConnectionLimiterConfig,
 	ClientDelay:        0,
 	Backlog:            backlog.DefaultConfig,
+	ProtocolV2:         DefaultProtocolV2Config,
 }
 
 var DefaultTestBroadcasterConfig = BroadcasterConfig{
@@ -156,6 +206,7 @@ var DefaultTestBroadcasterConfig = BroadcasterConfig{
 	ConnectionLimits:   DefaultConnectionLimiterConfig,
 	ClientDelay:        0,
 	Backlog:            backlog.DefaultTestConfig,
+	ProtocolV2:         DefaultProtocolV2Config,
 }
 
 type WSBroadcastServer struct {
@@ -172,6 +223,7 @@ type WSBroadcastServer struct {
 	backlog       backlog.Backlog
 	chainId       uint64
 	fatalErrChan  chan error
+	auth          *genericconf.HTTPServerAuth
 }
 
 func NewWSBroadcastServer(config BroadcasterConfigFetcher, bklg backlog.Backlog, chainId uint64, fatalErrChan chan error) *WSBroadcastServer {
@@ -184,6 +236,11 @@ func NewWSBroadcastServer(config BroadcasterConfigFetcher, bklg backlog.Backlog,
 	}
 }
 
+// SetAuth requires clients to present the auth token when connecting. It must be called before Start.
+func (s *WSBroadcastServer) SetAuth(auth *genericconf.HTTPServerAuth) {
+	s.auth = auth
+}
+
 func (s *WSBroadcastServer) Initialize() error {
 	if s.poller != nil {
 		return errors.New("broadcast server already initialized")
@@ -256,8 +313,10 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 			negotiate = compress.Negotiate
 		}
 		var feedClientVersionSeen bool
+		protocolVersion := FeedProtocolV1
 		var connectingIP net.IP
 		var requestedSeqNum arbutil.MessageIndex
+		var requestPath, authorization string
 		upgrader := ws.Upgrader{
 			OnRequest: func(uri []byte) error {
 				if strings.Contains(string(uri), LivenessProbeURI) {
@@ -265,11 +324,14 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 						ws.RejectionStatus(http.StatusOK),
 					)
 				}
+				requestPath, _, _ = strings.Cut(string(uri), "?")
 				return nil
 			},
 			OnHeader: func(key []byte, value []byte) error {
 				headerName := textproto.CanonicalMIMEHeaderKey(string(key))
-				if headerName == HTTPHeaderFeedClientVersion {
+				if headerName == "Authorization" {
+					authorization = string(value)
+				} else if headerName == HTTPHeaderFeedClientVersion {
 					feedClientVersion, err := strconv.ParseUint(string(value), 0, 64)
 					if err != nil {
 						return ws.RejectConnectionError(
@@ -293,6 +355,17 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 						)
 					}
 					requestedSeqNum = arbutil.MessageIndex(num)
+				} else if headerName == HTTPHeaderFeedMaxProtocolVersion {
+					maxProtocolVersion, err := strconv.ParseUint(string(value), 0, 64)
+					if err != nil {
+						return ws.RejectConnectionError(
+							ws.RejectionStatus(http.StatusBadRequest),
+							ws.RejectionReason(fmt.Sprintf("Malformed HTTP header %s", HTTPHeaderFeedMaxProtocolVersion)),
+						)
+					}
+					if maxProtocolVersion >= FeedProtocolV2 && config.ProtocolV2.Enable {
+						protocolVersion = FeedProtocolV2
+					}
 				} else if headerName == HTTPHeaderCloudflareConnectingIP {
 					connectingIP = net.ParseIP(string(value))
 					log.Trace("Client IP parsed from header", "ip", connectingIP, "header", headerName, "value", string(value))
@@ -301,6 +374,12 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 				return nil
 			},
 			OnBeforeUpgrade: func() (ws.HandshakeHeader, error) {
+				if !s.auth.Authorized(requestPath, authorization) {
+					return nil, ws.RejectConnectionError(
+						ws.RejectionStatus(http.StatusUnauthorized),
+						ws.RejectionHeader(ws.HandshakeHeaderHTTP(http.Header{"WWW-Authenticate": []string{"Bearer"}})),
+					)
+				}
 				if config.RequireVersion && !feedClientVersionSeen {
 					return nil, ws.RejectConnectionError(
 						ws.RejectionStatus(http.StatusBadRequest),
@@ -323,6 +402,11 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 					)
 				}
 
+				if protocolVersion != FeedProtocolV1 {
+					return handshakeHeaders{header, ws.HandshakeHeaderHTTP(http.Header{
+						HTTPHeaderFeedProtocolVersion: []string{strconv.Itoa(protocolVersion)},
+					})}, nil
+				}
 				return header, nil
 			},
 			Negotiate: negotiate,
@@ -348,7 +432,8 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		if compress != nil {
 			_, compressionAccepted = compress.Accepted()
 		}
-		if config.RequireCompression && !compressionAccepted {
+		// Protocol version 2 frames are compressed regardless of the websocket extension
+		if config.RequireCompression && !compressionAccepted && protocolVersion == FeedProtocolV1 {
 			log.Warn("client did not accept required compression, disconnecting", "connectingIP", connectingIP)
 			_ = conn.Close()
 			return
@@ -378,7 +463,7 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		// Register incoming client in clientManager.
 		safeConn := writeDeadliner{conn, config.WriteTimeout}
 
-		client := NewClientConnection(safeConn, desc, s.clientManager.clientAction, requestedSeqNum, connectingIP, compressionAccepted, s.config().MaxSendQueue, s.config().ClientDelay, s.backlog)
+		client := NewClientConnection(safeConn, desc, s.clientManager.clientAction, requestedSeqNum, connectingIP, compressionAccepted, protocolVersion, config.ProtocolV2.CompressionLevel, s.config().MaxSendQueue, s.config().ClientDelay, s.backlog)
 		client.Start(ctx)
 
 		// Subscribe to events about conn.
@@ -543,6 +628,21 @@ func (s *WSBroadcastServer) ClientCount() int32 {
 	return s.clientManager.ClientCount()
 }
 
+// handshakeHeaders writes several handshake headers in turn.
+type handshakeHeaders []ws.HandshakeHeader
+
+func (h handshakeHeaders) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, header := range h {
+		n, err := header.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
 // writeDeadliner is a wrapper around net.Conn that sets write deadlines before
 // every Write() call.
 type writeDeadliner struct {